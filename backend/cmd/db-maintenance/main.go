@@ -0,0 +1,132 @@
+// Command db-maintenance runs VACUUM, ANALYZE and REINDEX over the per-board
+// partitions and preview views, once or on a schedule.
+//
+//	go run ./backend/cmd/db-maintenance -config_folder config -ops vacuum,analyze
+//	go run ./backend/cmd/db-maintenance -boards b,tech -ops reindex -interval 24h
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/itchan-dev/itchan/backend/internal/storage/pg"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+func main() {
+	var (
+		configFolder string
+		boardsFlag   string
+		opsFlag      string
+		interval     time.Duration
+		lockTimeout  time.Duration
+	)
+	flag.StringVar(&configFolder, "config_folder", "config", "path to folder with configs")
+	flag.StringVar(&boardsFlag, "boards", "", "comma-separated board short names (default: all boards)")
+	flag.StringVar(&opsFlag, "ops", "vacuum,analyze", "comma-separated operations: vacuum, analyze, reindex")
+	flag.DurationVar(&interval, "interval", 0, "repeat the run at this interval (0 = run once)")
+	flag.DurationVar(&lockTimeout, "lock_timeout", 5*time.Second, "skip a relation when its lock is not granted within this time")
+	flag.Parse()
+
+	cfg := config.MustLoad(configFolder)
+	logger.Initialize(cfg.Public.LogLevel, cfg.Public.LogFormat == "json", logger.Sampling{})
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := run(ctx, cfg, splitList(boardsFlag), splitList(opsFlag), interval, lockTimeout); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, boardNames, opNames []string, interval, lockTimeout time.Duration) error {
+	ops := make([]pg.MaintenanceOp, 0, len(opNames))
+	for _, name := range opNames {
+		ops = append(ops, pg.MaintenanceOp(name))
+	}
+
+	if cfg.Private.Storage != config.StoragePostgres {
+		return fmt.Errorf("this command requires the postgres storage, configured storage is %q", cfg.Private.Storage)
+	}
+	storage, err := pg.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Cleanup()
+
+	for {
+		if err := maintain(ctx, storage, boardNames, ops, lockTimeout); err != nil {
+			if interval == 0 || ctx.Err() != nil {
+				return err
+			}
+			// A failed scheduled run is retried at the next tick.
+			logger.Log.Error("maintenance run failed", "error", err)
+		}
+		if interval == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// maintain runs one maintenance pass. Boards are looked up on every pass, so
+// boards created since the previous scheduled run are included.
+func maintain(ctx context.Context, storage *pg.Storage, boardNames []string, ops []pg.MaintenanceOp, lockTimeout time.Duration) error {
+	var boards []domain.BoardShortName
+	if len(boardNames) == 0 {
+		all, err := storage.GetBoards(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list boards: %w", err)
+		}
+		for _, b := range all {
+			boards = append(boards, b.ShortName)
+		}
+	} else {
+		for _, name := range boardNames {
+			boards = append(boards, domain.BoardShortName(name))
+		}
+	}
+
+	start := time.Now()
+	results, err := storage.MaintainBoards(ctx, boards, ops, lockTimeout)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("%-8s %-40s FAILED: %v\n", r.Op, r.Relation, r.Err)
+			continue
+		}
+		fmt.Printf("%-8s %-40s %s\n", r.Op, r.Relation, r.Duration.Round(time.Millisecond))
+	}
+	logger.Log.Info("maintenance run finished", "boards", len(boards), "operations", len(results),
+		"failed", failed, "duration", time.Since(start))
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
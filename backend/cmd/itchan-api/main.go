@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/itchan-dev/itchan/backend/internal/setup"
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/tracing"
 )
 
 func main() {
@@ -26,7 +28,16 @@ func main() {
 
 	// Initialize logger with config settings
 	useJSON := cfg.Public.LogFormat == "json"
-	logger.Initialize(cfg.Public.LogLevel, useJSON)
+	logger.Initialize(cfg.Public.LogLevel, useJSON, logger.Sampling{
+		Initial:    cfg.Public.LogSampleInitial,
+		Thereafter: cfg.Public.LogSampleThereafter,
+	})
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Private.Tracing, "itchan-api")
+	if err != nil {
+		logger.Log.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
 
 	// Check ffmpeg availability for video sanitization
 	if err := utils.CheckFFmpegAvailable(); err != nil {
@@ -37,6 +48,13 @@ func main() {
 	}
 	logger.Log.Info("ffmpeg available for video processing")
 
+	// Ghostscript is optional: without it PDF uploads are rejected
+	if slices.Contains(cfg.Public.AllowedDocumentMimeTypes, "application/pdf") {
+		if err := utils.CheckGhostscriptAvailable(); err != nil {
+			logger.Log.Warn("ghostscript not available, PDF attachments will be rejected", "error", err)
+		}
+	}
+
 	deps, err := setup.SetupDependencies(cfg)
 	if err != nil {
 		logger.Log.Error("failed to initialize dependencies", "error", err)
@@ -86,4 +104,9 @@ func main() {
 	} else {
 		logger.Log.Info("http server gracefully stopped")
 	}
+
+	// Send the spans of the last requests before exiting
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Log.Error("tracing shutdown error", "error", err)
+	}
 }
@@ -0,0 +1,129 @@
+// Command rotate-encryption-key re-encrypts the stored emails with
+// encryption_key, decrypting them with the keys of previous_encryption_keys.
+// To rotate, put the old key in previous_encryption_keys and the new one in
+// encryption_key, restart the API (it reads emails under either key) and run
+// this command. Progress is saved to -state after every batch, so an
+// interrupted run continues where it stopped; emails already under the new
+// key are skipped either way. Once it reports no failures, the old key can be
+// dropped from previous_encryption_keys.
+//
+//	go run ./backend/cmd/rotate-encryption-key -config_folder config
+//	go run ./backend/cmd/rotate-encryption-key -batch_size 200 -state /var/tmp/rotation.state
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/backend/internal/storage"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/crypto"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+func main() {
+	var (
+		configFolder string
+		batchSize    int
+		stateFile    string
+	)
+	flag.StringVar(&configFolder, "config_folder", "config", "path to folder with configs")
+	flag.IntVar(&batchSize, "batch_size", 500, "users re-encrypted per batch")
+	flag.StringVar(&stateFile, "state", "rotate-encryption-key.state", "file keeping the last finished user id, to resume from")
+	flag.Parse()
+
+	cfg := config.MustLoad(configFolder)
+	logger.Initialize(cfg.Public.LogLevel, cfg.Public.LogFormat == "json", logger.Sampling{})
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := run(ctx, cfg, batchSize, stateFile); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, batchSize int, stateFile string) error {
+	if len(cfg.Private.PreviousEncryptionKeys) == 0 {
+		return fmt.Errorf("previous_encryption_keys is empty, there is no key to rotate from")
+	}
+	if batchSize < 1 {
+		return fmt.Errorf("-batch_size must be positive")
+	}
+	emailCrypto, err := crypto.NewEmailCrypto(cfg.Private.EncryptionKey, cfg.Private.EmailIndexKey, cfg.Private.PreviousEncryptionKeys...)
+	if err != nil {
+		return fmt.Errorf("failed to initialize email crypto: %w", err)
+	}
+
+	afterId, err := readState(stateFile)
+	if err != nil {
+		return err
+	}
+	if afterId > 0 {
+		fmt.Printf("resuming after user %d\n", afterId)
+	}
+
+	store, err := storage.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Cleanup()
+
+	rotation := service.NewEmailKeyRotation(store, emailCrypto)
+	report, err := rotation.Run(ctx, afterId, batchSize, func(lastId domain.UserId) error {
+		return writeState(stateFile, lastId)
+	})
+	fmt.Printf("rotated %d, already current %d, unreadable %d, failed %d (last user %d)\n",
+		report.Rotated, report.Current, len(report.Unreadable), len(report.Failed), report.LastId)
+	if err != nil {
+		return err
+	}
+
+	if len(report.Unreadable) > 0 {
+		fmt.Printf("no key decrypts the emails of users %v\n", report.Unreadable)
+	}
+	// A finished run starts over next time; the users before the state were
+	// rotated already, but failures are worth another look
+	if err := os.Remove(stateFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("emails of users %v were not rotated, run again before dropping the previous keys", report.Failed)
+	}
+	return nil
+}
+
+func readState(path string) (domain.UserId, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read state: %w", err)
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid state in %s: %w", path, err)
+	}
+	return domain.UserId(id), nil
+}
+
+// writeState replaces the state file atomically, so an interrupted write
+// leaves the previous checkpoint
+func writeState(path string, lastId domain.UserId) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(int64(lastId), 10)+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,117 @@
+// Command seed fills a database with a generated board for local development
+// and benchmarking of board pages, thread pages and retention jobs.
+//
+//	go run ./backend/cmd/seed -config_folder config -board bench -threads 2000 -messages 200
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/itchan-dev/itchan/backend/internal/storage/pg"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+func main() {
+	var (
+		configFolder string
+		board        string
+		threads      int
+		messages     int
+		authors      int
+		span         time.Duration
+		seed         int64
+	)
+	flag.StringVar(&configFolder, "config_folder", "config", "path to folder with configs")
+	flag.StringVar(&board, "board", "bench", "short name of the board to create")
+	flag.IntVar(&threads, "threads", 1000, "number of threads")
+	flag.IntVar(&messages, "messages", 100, "messages per thread, including the OP")
+	flag.IntVar(&authors, "authors", 50, "number of seed users posting the messages")
+	flag.DurationVar(&span, "span", 30*24*time.Hour, "period the messages are spread over, ending now")
+	flag.Int64Var(&seed, "seed", 1, "seed for the generated texts")
+	flag.Parse()
+
+	cfg := config.MustLoad(configFolder)
+	logger.Initialize(cfg.Public.LogLevel, cfg.Public.LogFormat == "json", logger.Sampling{})
+
+	if err := run(cfg, board, threads, messages, authors, span, seed); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg *config.Config, board string, threads, messages, authors int, span time.Duration, seed int64) error {
+	// Interrupting the command cancels the load, which is rolled back as a whole.
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if cfg.Private.Storage != config.StoragePostgres {
+		return fmt.Errorf("this command requires the postgres storage, configured storage is %q", cfg.Private.Storage)
+	}
+	storage, err := pg.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Cleanup()
+
+	shortName := domain.BoardShortName(board)
+	err = storage.CreateBoard(ctx, domain.BoardCreationData{
+		Name:        domain.BoardName("Seeded " + board),
+		ShortName:   shortName,
+		Description: "Generated by the seed command",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create board: %w", err)
+	}
+
+	authorIds, err := createSeedUsers(ctx, storage, board, authors)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := storage.BulkLoad(ctx, pg.BulkLoadSpec{
+		Board:             shortName,
+		Threads:           threads,
+		MessagesPerThread: messages,
+		Authors:           authorIds,
+		Span:              span,
+		Seed:              seed,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Seeded board /%s/ with %d threads and %d messages\n", board, threads, inserted)
+	return nil
+}
+
+// createSeedUsers creates accounts that only exist to author seeded messages.
+// Their email hashes are random, so they cannot log in.
+func createSeedUsers(ctx context.Context, storage *pg.Storage, board string, n int) ([]domain.UserId, error) {
+	ids := make([]domain.UserId, 0, n)
+	for i := 0; i < n; i++ {
+		hash := make([]byte, 32)
+		if _, err := rand.Read(hash); err != nil {
+			return nil, fmt.Errorf("failed to generate email hash: %w", err)
+		}
+		id, err := storage.SaveUser(ctx, domain.User{
+			EmailEncrypted: []byte(fmt.Sprintf("seed-%s-%d@seed.invalid", board, i)),
+			EmailDomain:    "seed.invalid",
+			EmailHash:      hash,
+			PassHash:       "!",
+			ReferralSource: "seed",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create seed user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,101 @@
+// Command verify-archive checks a thread export against its content hashes:
+// every message is hashed again from its exported fields and the Merkle root
+// is rebuilt from the hashes. With -root, the root also has to match one
+// published earlier, which proves no message of that export was changed,
+// added or removed since.
+//
+//	curl -s https://example.org/api/v1/b/123/export | go run ./backend/cmd/verify-archive -file -
+//	go run ./backend/cmd/verify-archive -file b-123.json -root 9f86d08...
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/itchan-dev/itchan/shared/crypto"
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+func main() {
+	var file, root string
+	flag.StringVar(&file, "file", "", "thread export to check (- reads stdin)")
+	flag.StringVar(&root, "root", "", "Merkle root the export has to match, in hex")
+	flag.Parse()
+
+	if err := run(file, root); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(1)
+	}
+}
+
+func run(file, wantRoot string) error {
+	var in io.Reader
+	switch file {
+	case "":
+		return fmt.Errorf("-file is required")
+	case "-":
+		in = os.Stdin
+	default:
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var archive domain.ThreadArchive
+	if err := json.NewDecoder(in).Decode(&archive); err != nil {
+		return fmt.Errorf("failed to read export: %w", err)
+	}
+	if archive.HashVersion != crypto.ContentHashVersion {
+		return fmt.Errorf("unsupported hash version %d", archive.HashVersion)
+	}
+
+	var leaves [][]byte
+	var unhashed, mismatched int
+	for _, m := range archive.Messages {
+		if m.ContentHash == "" {
+			unhashed++
+			continue
+		}
+		commitment, err := hex.DecodeString(m.AuthorCommitment)
+		if err != nil {
+			return fmt.Errorf("message %d: invalid author commitment: %w", m.Id, err)
+		}
+		stored, err := hex.DecodeString(m.ContentHash)
+		if err != nil {
+			return fmt.Errorf("message %d: invalid content hash: %w", m.Id, err)
+		}
+		hash := crypto.MessageContentHash(crypto.MessageContent{
+			ThreadId:         int64(archive.ThreadId),
+			MessageId:        int64(m.Id),
+			CreatedAt:        m.CreatedAt,
+			Text:             m.Text,
+			AttachmentHashes: m.AttachmentHashes,
+			AuthorCommitment: commitment,
+		})
+		if hex.EncodeToString(hash) != m.ContentHash {
+			fmt.Printf("message %d: content does not match its hash\n", m.Id)
+			mismatched++
+		}
+		leaves = append(leaves, stored)
+	}
+
+	root := hex.EncodeToString(crypto.MerkleRoot(leaves))
+	fmt.Printf("/%s/%d: %d messages, %d without a hash, root %s\n",
+		archive.Board, archive.ThreadId, len(archive.Messages), unhashed, root)
+	switch {
+	case mismatched > 0:
+		return fmt.Errorf("%d messages do not match their hash", mismatched)
+	case root != archive.MerkleRoot:
+		return fmt.Errorf("root does not match the exported root %s", archive.MerkleRoot)
+	case wantRoot != "" && root != wantRoot:
+		return fmt.Errorf("root does not match %s", wantRoot)
+	}
+	fmt.Println("OK")
+	return nil
+}
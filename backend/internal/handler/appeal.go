@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// SubmitAppeal handles POST /v1/auth/appeal
+func (h *Handler) SubmitAppeal(w http.ResponseWriter, r *http.Request) {
+	var req api.SubmitAppealRequest
+	if err := utils.DecodeValidate(r.Body, &req); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	id, err := h.appeal.Submit(r.Context(), domain.Credentials{Email: req.Email, Password: req.Password}, req.Text)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, api.SubmitAppealResponse{ID: id})
+}
+
+// GetAppeals handles GET /v1/admin/appeals?status=&page=
+func (h *Handler) GetAppeals(w http.ResponseWriter, r *http.Request) {
+	page := utils.GetPage(r)
+
+	appeals, err := h.appeal.List(r.Context(), r.URL.Query().Get("status"), page)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	// If no appeals, return empty array instead of null
+	if appeals == nil {
+		appeals = []domain.Appeal{}
+	}
+
+	writeJSON(w, api.AppealsResponse{Appeals: appeals, Page: page})
+}
+
+// AcceptAppeal handles POST /v1/admin/appeals/:appealId/accept
+func (h *Handler) AcceptAppeal(w http.ResponseWriter, r *http.Request) {
+	h.decideAppeal(w, r, h.appeal.Accept)
+}
+
+// DenyAppeal handles POST /v1/admin/appeals/:appealId/deny
+func (h *Handler) DenyAppeal(w http.ResponseWriter, r *http.Request) {
+	h.decideAppeal(w, r, h.appeal.Deny)
+}
+
+func (h *Handler) decideAppeal(w http.ResponseWriter, r *http.Request, decide func(context.Context, domain.AppealId, domain.UserId, string) error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "appealId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid appeal ID", http.StatusBadRequest)
+		return
+	}
+
+	var req api.DecideAppealRequest
+	if err := decodeOptionalBody(r, &req); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	var moderatorId domain.UserId
+	if admin := mw.GetUserFromContext(r); admin != nil {
+		moderatorId = admin.Id
+	}
+
+	if err := decide(r.Context(), id, moderatorId, req.Response); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
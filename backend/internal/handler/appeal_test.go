@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAppealService struct {
+	MockSubmit func(creds domain.Credentials, text string) (domain.AppealId, error)
+	MockList   func(status domain.AppealStatus, page int) ([]domain.Appeal, error)
+	MockAccept func(id domain.AppealId, moderator domain.UserId, response string) error
+	MockDeny   func(id domain.AppealId, moderator domain.UserId, response string) error
+}
+
+func (m *MockAppealService) Submit(ctx context.Context, creds domain.Credentials, text string) (domain.AppealId, error) {
+	if m.MockSubmit != nil {
+		return m.MockSubmit(creds, text)
+	}
+	return 1, nil
+}
+
+func (m *MockAppealService) List(ctx context.Context, status domain.AppealStatus, page int) ([]domain.Appeal, error) {
+	if m.MockList != nil {
+		return m.MockList(status, page)
+	}
+	return nil, nil
+}
+
+func (m *MockAppealService) Accept(ctx context.Context, id domain.AppealId, moderator domain.UserId, response string) error {
+	if m.MockAccept != nil {
+		return m.MockAccept(id, moderator, response)
+	}
+	return nil
+}
+
+func (m *MockAppealService) Deny(ctx context.Context, id domain.AppealId, moderator domain.UserId, response string) error {
+	if m.MockDeny != nil {
+		return m.MockDeny(id, moderator, response)
+	}
+	return nil
+}
+
+func setupAppealTestHandler(appealService service.AppealService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		appeal: appealService,
+	}
+	router := chi.NewRouter()
+	router.Post("/v1/auth/appeal", h.SubmitAppeal)
+	router.Get("/v1/admin/appeals", h.GetAppeals)
+	router.Post("/v1/admin/appeals/{appealId}/accept", h.AcceptAppeal)
+	router.Post("/v1/admin/appeals/{appealId}/deny", h.DenyAppeal)
+
+	return h, router
+}
+
+func TestSubmitAppealHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockService := &MockAppealService{
+			MockSubmit: func(creds domain.Credentials, text string) (domain.AppealId, error) {
+				assert.Equal(t, "user@example.com", creds.Email)
+				assert.Equal(t, "secret", creds.Password)
+				assert.Equal(t, "please", text)
+				return 3, nil
+			},
+		}
+		_, router := setupAppealTestHandler(mockService)
+
+		body := []byte(`{"email": "user@example.com", "password": "secret", "text": "please"}`)
+		req := createRequest(t, http.MethodPost, "/v1/auth/appeal", body)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var resp api.SubmitAppealResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, int64(3), resp.ID)
+	})
+
+	t.Run("missing text", func(t *testing.T) {
+		_, router := setupAppealTestHandler(&MockAppealService{})
+
+		body := []byte(`{"email": "user@example.com", "password": "secret"}`)
+		req := createRequest(t, http.MethodPost, "/v1/auth/appeal", body)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &MockAppealService{
+			MockSubmit: func(domain.Credentials, string) (domain.AppealId, error) {
+				return 0, &internal_errors.ErrorWithStatusCode{Message: "already submitted", StatusCode: http.StatusConflict}
+			},
+		}
+		_, router := setupAppealTestHandler(mockService)
+
+		body := []byte(`{"email": "user@example.com", "password": "secret", "text": "please"}`)
+		req := createRequest(t, http.MethodPost, "/v1/auth/appeal", body)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "already submitted")
+	})
+}
+
+func TestGetAppealsHandler(t *testing.T) {
+	t.Run("passes status and page", func(t *testing.T) {
+		mockService := &MockAppealService{
+			MockList: func(status domain.AppealStatus, page int) ([]domain.Appeal, error) {
+				assert.Equal(t, domain.AppealDenied, status)
+				assert.Equal(t, 2, page)
+				return []domain.Appeal{{Id: 5}}, nil
+			},
+		}
+		_, router := setupAppealTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/v1/admin/appeals?status=denied&page=2", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.AppealsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Appeals, 1)
+		assert.Equal(t, domain.AppealId(5), resp.Appeals[0].Id)
+		assert.Equal(t, 2, resp.Page)
+	})
+
+	t.Run("empty list is an array", func(t *testing.T) {
+		_, router := setupAppealTestHandler(&MockAppealService{})
+
+		req := createRequest(t, http.MethodGet, "/v1/admin/appeals", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"appeals":[]`)
+	})
+}
+
+func TestDecideAppealHandler(t *testing.T) {
+	admin := &domain.User{Id: 1}
+
+	t.Run("accept with response", func(t *testing.T) {
+		called := false
+		mockService := &MockAppealService{
+			MockAccept: func(id domain.AppealId, moderator domain.UserId, response string) error {
+				called = true
+				assert.Equal(t, domain.AppealId(7), id)
+				assert.Equal(t, admin.Id, moderator)
+				assert.Equal(t, "ok", response)
+				return nil
+			},
+		}
+		_, router := setupAppealTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/appeals/7/accept", []byte(`{"response": "ok"}`))
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("deny without body", func(t *testing.T) {
+		called := false
+		mockService := &MockAppealService{
+			MockDeny: func(id domain.AppealId, moderator domain.UserId, response string) error {
+				called = true
+				assert.Empty(t, response)
+				return nil
+			},
+		}
+		_, router := setupAppealTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/appeals/7/deny", nil)
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("invalid appeal ID", func(t *testing.T) {
+		_, router := setupAppealTestHandler(&MockAppealService{})
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/appeals/abc/accept", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
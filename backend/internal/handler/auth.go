@@ -5,6 +5,7 @@ import (
 
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
 	"github.com/itchan-dev/itchan/shared/utils"
 )
 
@@ -15,7 +16,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.auth.Register(domain.Credentials{Email: req.Email, Password: req.Password}); err != nil {
+	if err := h.auth.Register(r.Context(), domain.Credentials{Email: req.Email, Password: req.Password}); err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
@@ -30,7 +31,7 @@ func (h *Handler) CheckConfirmationCode(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.auth.CheckConfirmationCode(req.Email, req.ConfirmationCode, req.RefSource); err != nil {
+	if err := h.auth.CheckConfirmationCode(r.Context(), req.Email, req.ConfirmationCode, req.RefSource); err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
@@ -45,14 +46,91 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, err := h.auth.Login(domain.Credentials{Email: req.Email, Password: req.Password})
+	// The IP is the frontend's X-Real-IP, which carries the browser's
+	ip, _ := mw.GetIP(r)
+	creds := domain.Credentials{
+		Email:    req.Email,
+		Password: req.Password,
+		Device:   &domain.Device{UserAgent: r.UserAgent(), IP: ip},
+	}
+	var accessToken, refreshToken string
+	var err error
+	if req.RememberMe {
+		accessToken, refreshToken, err = h.auth.LoginRemembered(r.Context(), creds)
+	} else {
+		accessToken, err = h.auth.Login(r.Context(), creds)
+	}
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, api.LoginResponse{
+		Message:      "You logged in",
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+// Refresh handles POST /v1/auth/refresh, trading a refresh token for a new
+// access token and refresh token.
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req api.RefreshRequest
+	if err := utils.DecodeValidate(r.Body, &req); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.auth.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, api.LoginResponse{
+		Message:      "Session refreshed",
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+// RevokeSessions handles POST /v1/auth/revoke_sessions, the "this wasn't me"
+// link of new device emails. The token authorizes it, no login is needed.
+func (h *Handler) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	var req api.RevokeSessionsRequest
+	if err := utils.DecodeValidate(r.Body, &req); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.auth.RevokeSessions(r.Context(), req.Token); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Renew handles POST /v1/auth/renew, reissuing the caller's access token.
+func (h *Handler) Renew(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUserFromContext(r)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if user.ImpersonatedBy != nil {
+		http.Error(w, "View-as sessions can't be renewed", http.StatusForbidden)
+		return
+	}
+
+	accessToken, err := h.auth.Renew(r.Context(), user.Id)
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
 
 	writeJSON(w, api.LoginResponse{
-		Message:     "You logged in",
+		Message:     "Session renewed",
 		AccessToken: accessToken,
 	})
 }
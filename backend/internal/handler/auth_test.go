@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -11,80 +12,138 @@ import (
 	"github.com/itchan-dev/itchan/backend/internal/service"
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type MockAuthService struct {
 	MockRegister                       func(creds domain.Credentials) error
 	MockCheckConfirmationCode          func(email domain.Email, confirmationCode string, refSource string) error
 	MockLogin                          func(creds domain.Credentials) (string, error)
+	MockLoginRemembered                func(creds domain.Credentials) (string, string, error)
+	MockRefresh                        func(refreshToken string) (string, string, error)
+	MockRenew                          func(userId domain.UserId) (string, error)
+	MockRevokeSessions                 func(token string) error
+	MockAuthenticate                   func(creds domain.Credentials) (domain.User, error)
 	MockBlacklistUser                  func(userId domain.UserId, reason string, blacklistedBy domain.UserId) error
+	MockBlacklistUserUntil             func(userId domain.UserId, reason string, expiresAt time.Time) error
 	MockUnblacklistUser                func(userId domain.UserId) error
 	MockGetBlacklistedUsersWithDetails func(page int) ([]domain.BlacklistEntry, error)
 	MockRefreshBlacklistCache          func() error
+	MockFindUserByEmail                func(email domain.Email) (domain.User, error)
 	MockRegisterWithInvite             func(inviteCode string, password domain.Password, refSource string) (string, error)
 	MockGenerateInvite                 func(user domain.User) (*domain.InviteCodeWithPlaintext, error)
 	MockGetUserInvites                 func(userId domain.UserId, page int) ([]domain.InviteCode, error)
 	MockRevokeInvite                   func(userId domain.UserId, codeHash string) error
 }
 
-func (m *MockAuthService) Register(creds domain.Credentials) error {
+func (m *MockAuthService) Register(ctx context.Context, creds domain.Credentials) error {
 	if m.MockRegister != nil {
 		return m.MockRegister(creds)
 	}
 	return nil
 }
 
-func (m *MockAuthService) CheckConfirmationCode(email domain.Email, confirmationCode string, refSource string) error {
+func (m *MockAuthService) CheckConfirmationCode(ctx context.Context, email domain.Email, confirmationCode string, refSource string) error {
 	if m.MockCheckConfirmationCode != nil {
 		return m.MockCheckConfirmationCode(email, confirmationCode, refSource)
 	}
 	return nil
 }
 
-func (m *MockAuthService) Login(creds domain.Credentials) (string, error) {
+func (m *MockAuthService) Login(ctx context.Context, creds domain.Credentials) (string, error) {
 	if m.MockLogin != nil {
 		return m.MockLogin(creds)
 	}
 	return "", nil
 }
 
-func (m *MockAuthService) BlacklistUser(userId domain.UserId, reason string, blacklistedBy domain.UserId) error {
+func (m *MockAuthService) LoginRemembered(ctx context.Context, creds domain.Credentials) (string, string, error) {
+	if m.MockLoginRemembered != nil {
+		return m.MockLoginRemembered(creds)
+	}
+	return "", "", nil
+}
+
+func (m *MockAuthService) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	if m.MockRefresh != nil {
+		return m.MockRefresh(refreshToken)
+	}
+	return "", "", nil
+}
+
+func (m *MockAuthService) Renew(ctx context.Context, userId domain.UserId) (string, error) {
+	if m.MockRenew != nil {
+		return m.MockRenew(userId)
+	}
+	return "", nil
+}
+
+func (m *MockAuthService) RevokeSessions(ctx context.Context, token string) error {
+	if m.MockRevokeSessions != nil {
+		return m.MockRevokeSessions(token)
+	}
+	return nil
+}
+
+func (m *MockAuthService) Authenticate(ctx context.Context, creds domain.Credentials) (domain.User, error) {
+	if m.MockAuthenticate != nil {
+		return m.MockAuthenticate(creds)
+	}
+	return domain.User{}, nil
+}
+
+func (m *MockAuthService) BlacklistUser(ctx context.Context, userId domain.UserId, reason string, blacklistedBy domain.UserId) error {
 	if m.MockBlacklistUser != nil {
 		return m.MockBlacklistUser(userId, reason, blacklistedBy)
 	}
 	return nil
 }
 
-func (m *MockAuthService) UnblacklistUser(userId domain.UserId) error {
+func (m *MockAuthService) BlacklistUserUntil(ctx context.Context, userId domain.UserId, reason string, expiresAt time.Time) error {
+	if m.MockBlacklistUserUntil != nil {
+		return m.MockBlacklistUserUntil(userId, reason, expiresAt)
+	}
+	return nil
+}
+
+func (m *MockAuthService) UnblacklistUser(ctx context.Context, userId domain.UserId) error {
 	if m.MockUnblacklistUser != nil {
 		return m.MockUnblacklistUser(userId)
 	}
 	return nil
 }
 
-func (m *MockAuthService) GetBlacklistedUsersWithDetails(page int) ([]domain.BlacklistEntry, error) {
+func (m *MockAuthService) GetBlacklistedUsersWithDetails(ctx context.Context, page int) ([]domain.BlacklistEntry, error) {
 	if m.MockGetBlacklistedUsersWithDetails != nil {
 		return m.MockGetBlacklistedUsersWithDetails(page)
 	}
 	return nil, nil
 }
 
-func (m *MockAuthService) RefreshBlacklistCache() error {
+func (m *MockAuthService) RefreshBlacklistCache(ctx context.Context) error {
 	if m.MockRefreshBlacklistCache != nil {
 		return m.MockRefreshBlacklistCache()
 	}
 	return nil
 }
 
-func (m *MockAuthService) RegisterWithInvite(inviteCode string, password domain.Password, refSource string) (string, error) {
+func (m *MockAuthService) FindUserByEmail(ctx context.Context, email domain.Email) (domain.User, error) {
+	if m.MockFindUserByEmail != nil {
+		return m.MockFindUserByEmail(email)
+	}
+	return domain.User{}, nil
+}
+
+func (m *MockAuthService) RegisterWithInvite(ctx context.Context, inviteCode string, password domain.Password, refSource string) (string, error) {
 	if m.MockRegisterWithInvite != nil {
 		return m.MockRegisterWithInvite(inviteCode, password, refSource)
 	}
 	return "generated@itchan.ru", nil
 }
 
-func (m *MockAuthService) GenerateInvite(user domain.User) (*domain.InviteCodeWithPlaintext, error) {
+func (m *MockAuthService) GenerateInvite(ctx context.Context, user domain.User) (*domain.InviteCodeWithPlaintext, error) {
 	if m.MockGenerateInvite != nil {
 		return m.MockGenerateInvite(user)
 	}
@@ -99,14 +158,14 @@ func (m *MockAuthService) GenerateInvite(user domain.User) (*domain.InviteCodeWi
 	}, nil
 }
 
-func (m *MockAuthService) GetUserInvites(userId domain.UserId, page int) ([]domain.InviteCode, error) {
+func (m *MockAuthService) GetUserInvites(ctx context.Context, userId domain.UserId, page int) ([]domain.InviteCode, error) {
 	if m.MockGetUserInvites != nil {
 		return m.MockGetUserInvites(userId, page)
 	}
 	return []domain.InviteCode{}, nil
 }
 
-func (m *MockAuthService) RevokeInvite(userId domain.UserId, codeHash string) error {
+func (m *MockAuthService) RevokeInvite(ctx context.Context, userId domain.UserId, codeHash string) error {
 	if m.MockRevokeInvite != nil {
 		return m.MockRevokeInvite(userId, codeHash)
 	}
@@ -125,6 +184,9 @@ func setupAuthTestHandler(authService service.AuthService, cfg *config.Config) (
 	router.Post("/v1/auth/register", h.Register)
 	router.Post("/v1/auth/check-confirmation-code", h.CheckConfirmationCode)
 	router.Post("/v1/auth/login", h.Login)
+	router.Post("/v1/auth/refresh", h.Refresh)
+	router.Post("/v1/auth/renew", h.Renew)
+	router.Post("/v1/auth/revoke_sessions", h.RevokeSessions)
 
 	return h, router
 }
@@ -243,12 +305,16 @@ func TestAuthLoginHandler(t *testing.T) {
 			MockLogin: func(creds domain.Credentials) (string, error) {
 				assert.Equal(t, expectedEmail, creds.Email)
 				assert.Equal(t, expectedPassword, creds.Password)
+				require.NotNil(t, creds.Device)
+				assert.Equal(t, domain.Device{UserAgent: "TestBrowser/1.0", IP: "203.0.113.7"}, *creds.Device)
 				return expectedToken, nil
 			},
 		}
 		_, router := setupAuthTestHandler(mockService, cfg)
 
 		req := createRequest(t, http.MethodPost, route, requestBody)
+		req.Header.Set("User-Agent", "TestBrowser/1.0")
+		req.Header.Set("X-Real-IP", "203.0.113.7")
 		rr := httptest.NewRecorder()
 		router.ServeHTTP(rr, req)
 
@@ -257,6 +323,27 @@ func TestAuthLoginHandler(t *testing.T) {
 		assert.Empty(t, rr.Result().Cookies())
 	})
 
+	t.Run("remember me", func(t *testing.T) {
+		mockService := &MockAuthService{
+			MockLogin: func(creds domain.Credentials) (string, error) {
+				t.Fatal("Login must not be called with remember_me")
+				return "", nil
+			},
+			MockLoginRemembered: func(creds domain.Credentials) (string, string, error) {
+				assert.Equal(t, expectedEmail, creds.Email)
+				return expectedToken, "test_refresh_token", nil
+			},
+		}
+		_, router := setupAuthTestHandler(mockService, cfg)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{"email": "test@example.com", "password": "password", "remember_me": true}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"message":"You logged in","access_token":"test_access_token","refresh_token":"test_refresh_token"}`, rr.Body.String())
+	})
+
 	t.Run("validation error", func(t *testing.T) {
 		_, router := setupAuthTestHandler(&MockAuthService{}, cfg)
 		req := createRequest(t, http.MethodPost, route, []byte(`{"password": "password"}`))
@@ -283,3 +370,132 @@ func TestAuthLoginHandler(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	})
 }
+
+func TestRefreshHandler(t *testing.T) {
+	route := "/v1/auth/refresh"
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &MockAuthService{
+			MockRefresh: func(refreshToken string) (string, string, error) {
+				assert.Equal(t, "old_refresh", refreshToken)
+				return "new_access", "new_refresh", nil
+			},
+		}
+		_, router := setupAuthTestHandler(mockService, nil)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{"refresh_token": "old_refresh"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"message":"Session refreshed","access_token":"new_access","refresh_token":"new_refresh"}`, rr.Body.String())
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		_, router := setupAuthTestHandler(&MockAuthService{}, nil)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("rejected token", func(t *testing.T) {
+		mockService := &MockAuthService{
+			MockRefresh: func(refreshToken string) (string, string, error) {
+				return "", "", &internal_errors.ErrorWithStatusCode{Message: "Invalid refresh token", StatusCode: http.StatusUnauthorized}
+			},
+		}
+		_, router := setupAuthTestHandler(mockService, nil)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{"refresh_token": "expired"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestRenewHandler(t *testing.T) {
+	route := "/v1/auth/renew"
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &MockAuthService{
+			MockRenew: func(userId domain.UserId) (string, error) {
+				assert.Equal(t, domain.UserId(5), userId)
+				return "renewed", nil
+			},
+		}
+		h, _ := setupAuthTestHandler(mockService, nil)
+
+		req := addUserToContext(createRequest(t, http.MethodPost, route, nil), &domain.User{Id: 5})
+		rr := httptest.NewRecorder()
+		h.Renew(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"message":"Session renewed","access_token":"renewed"}`, rr.Body.String())
+	})
+
+	t.Run("view-as session", func(t *testing.T) {
+		mockService := &MockAuthService{
+			MockRenew: func(userId domain.UserId) (string, error) {
+				t.Fatal("view-as sessions must not be renewed")
+				return "", nil
+			},
+		}
+		h, _ := setupAuthTestHandler(mockService, nil)
+
+		admin := domain.UserId(1)
+		req := addUserToContext(createRequest(t, http.MethodPost, route, nil), &domain.User{Id: 5, ImpersonatedBy: &admin})
+		rr := httptest.NewRecorder()
+		h.Renew(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
+
+func TestRevokeSessionsHandler(t *testing.T) {
+	route := "/v1/auth/revoke_sessions"
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &MockAuthService{
+			MockRevokeSessions: func(token string) error {
+				assert.Equal(t, "abc123", token)
+				return nil
+			},
+		}
+		_, router := setupAuthTestHandler(mockService, nil)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{"token": "abc123"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		_, router := setupAuthTestHandler(&MockAuthService{}, nil)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("used link", func(t *testing.T) {
+		mockService := &MockAuthService{
+			MockRevokeSessions: func(token string) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "Link is invalid or was already used", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupAuthTestHandler(mockService, nil)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{"token": "used"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
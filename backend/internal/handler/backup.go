@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// PauseWritesDuringBackup refuses writes with 503 while a backup has paused
+// them, and counts the others so the backup can wait for them to finish.
+// Reads and the paths in maintenanceExemptPaths go through.
+func (h *Handler) PauseWritesDuringBackup(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.backup == nil || isReadMethod(r.Method) || maintenanceExemptPaths[strings.TrimSuffix(r.URL.Path, "/")] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		done, ok := h.backup.BeginWrite()
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(h.cfg.Private.Backup.PauseWrites.Seconds())+1))
+			http.Error(w, "A backup is in progress, please try again in a few seconds", http.StatusServiceUnavailable)
+			return
+		}
+		defer done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetBackups handles GET /v1/admin/backups
+func (h *Handler) GetBackups(w http.ResponseWriter, r *http.Request) {
+	state, err := h.backup.Status(r.Context())
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	runs, err := h.backup.Runs(r.Context())
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	writeJSON(w, api.BackupsResponse{State: state, Runs: runs})
+}
+
+// TriggerBackup handles POST /v1/admin/backups: starts a backup in the
+// background, 409 while one is running.
+func (h *Handler) TriggerBackup(w http.ResponseWriter, r *http.Request) {
+	if err := h.backup.Trigger(r.Context()); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockBackupService struct {
+	MockTrigger    func() error
+	MockStatus     func() (domain.BackupState, error)
+	MockRuns       func() ([]domain.BackupRun, error)
+	MockBeginWrite func() (func(), bool)
+}
+
+func (m *MockBackupService) Trigger(ctx context.Context) error {
+	if m.MockTrigger != nil {
+		return m.MockTrigger()
+	}
+	return nil
+}
+
+func (m *MockBackupService) Status(ctx context.Context) (domain.BackupState, error) {
+	if m.MockStatus != nil {
+		return m.MockStatus()
+	}
+	return domain.BackupState{}, nil
+}
+
+func (m *MockBackupService) Runs(ctx context.Context) ([]domain.BackupRun, error) {
+	if m.MockRuns != nil {
+		return m.MockRuns()
+	}
+	return []domain.BackupRun{}, nil
+}
+
+func (m *MockBackupService) BeginWrite() (func(), bool) {
+	if m.MockBeginWrite != nil {
+		return m.MockBeginWrite()
+	}
+	return func() {}, true
+}
+
+func setupBackupTestHandler(backup *MockBackupService) *chi.Mux {
+	h := &Handler{backup: backup, cfg: &config.Config{}}
+	router := chi.NewRouter()
+	router.Use(h.PauseWritesDuringBackup)
+	router.Get("/v1/admin/backups", h.GetBackups)
+	router.Post("/v1/admin/backups", h.TriggerBackup)
+	router.Post("/v1/auth/login", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/v1/b", func(w http.ResponseWriter, r *http.Request) {})
+	router.Post("/v1/b", func(w http.ResponseWriter, r *http.Request) {})
+	return router
+}
+
+func TestPauseWritesDuringBackup(t *testing.T) {
+	t.Run("writes are refused while paused", func(t *testing.T) {
+		router := setupBackupTestHandler(&MockBackupService{
+			MockBeginWrite: func() (func(), bool) { return nil, false },
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/b", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/v1/b", nil),
+			httptest.NewRequest(http.MethodPost, "/v1/auth/login", nil),
+		} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code, req.URL.Path)
+		}
+	})
+
+	t.Run("finished writes are reported", func(t *testing.T) {
+		done := 0
+		router := setupBackupTestHandler(&MockBackupService{
+			MockBeginWrite: func() (func(), bool) { return func() { done++ }, true },
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/b", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, 1, done)
+	})
+}
+
+func TestBackupHandlers(t *testing.T) {
+	t.Run("lists runs", func(t *testing.T) {
+		age := int64(3600)
+		router := setupBackupTestHandler(&MockBackupService{
+			MockStatus: func() (domain.BackupState, error) {
+				return domain.BackupState{Enabled: true, LastSuccessAgeSeconds: &age}, nil
+			},
+			MockRuns: func() ([]domain.BackupRun, error) {
+				return []domain.BackupRun{{Id: 2, Status: domain.BackupFailed}, {Id: 1, Status: domain.BackupSucceeded}}, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/admin/backups", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.BackupsResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.True(t, resp.State.Enabled)
+		require.NotNil(t, resp.State.LastSuccessAgeSeconds)
+		assert.Equal(t, age, *resp.State.LastSuccessAgeSeconds)
+		assert.Len(t, resp.Runs, 2)
+	})
+
+	t.Run("trigger", func(t *testing.T) {
+		triggered := false
+		router := setupBackupTestHandler(&MockBackupService{
+			MockTrigger: func() error { triggered = true; return nil },
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/admin/backups", nil))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.True(t, triggered)
+	})
+
+	t.Run("trigger while running", func(t *testing.T) {
+		router := setupBackupTestHandler(&MockBackupService{
+			MockTrigger: func() error {
+				return &internal_errors.ErrorWithStatusCode{Message: "A backup is already running", StatusCode: http.StatusConflict}
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/admin/backups", nil))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+}
+
+func TestReadyReportsBackupAge(t *testing.T) {
+	t.Run("age of the last backup", func(t *testing.T) {
+		age := int64(7200)
+		h := &Handler{health: &MockHealthChecker{}, backup: &MockBackupService{
+			MockStatus: func() (domain.BackupState, error) {
+				return domain.BackupState{Enabled: true, LastSuccessAgeSeconds: &age}, nil
+			},
+		}}
+
+		rr := httptest.NewRecorder()
+		h.Ready(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "7200", rr.Header().Get("X-Backup-Age-Seconds"))
+		assert.Equal(t, "ok\nlast successful backup: 2h0m0s ago", rr.Body.String())
+	})
+
+	t.Run("no backup yet does not fail the probe", func(t *testing.T) {
+		h := &Handler{health: &MockHealthChecker{}, backup: &MockBackupService{
+			MockStatus: func() (domain.BackupState, error) { return domain.BackupState{Enabled: true}, nil },
+		}}
+
+		rr := httptest.NewRecorder()
+		h.Ready(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "ok\nlast successful backup: never", rr.Body.String())
+	})
+
+	t.Run("backups not configured", func(t *testing.T) {
+		h := &Handler{health: &MockHealthChecker{}, backup: &MockBackupService{}}
+
+		rr := httptest.NewRecorder()
+		h.Ready(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		assert.Equal(t, "ok", rr.Body.String())
+	})
+}
@@ -32,7 +32,7 @@ func (h *Handler) BlacklistUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Blacklist the user (service handles cache update)
-	if err := h.auth.BlacklistUser(userId, req.Reason, admin.Id); err != nil {
+	if err := h.auth.BlacklistUser(r.Context(), userId, req.Reason, admin.Id); err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
@@ -51,7 +51,7 @@ func (h *Handler) UnblacklistUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Unblacklist the user (service handles cache update)
-	if err := h.auth.UnblacklistUser(userId); err != nil {
+	if err := h.auth.UnblacklistUser(r.Context(), userId); err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
@@ -61,7 +61,7 @@ func (h *Handler) UnblacklistUser(w http.ResponseWriter, r *http.Request) {
 
 // RefreshBlacklistCache handles POST /v1/admin/blacklist/refresh
 func (h *Handler) RefreshBlacklistCache(w http.ResponseWriter, r *http.Request) {
-	if err := h.auth.RefreshBlacklistCache(); err != nil {
+	if err := h.auth.RefreshBlacklistCache(r.Context()); err != nil {
 		http.Error(w, "Failed to refresh blacklist cache", http.StatusInternalServerError)
 		return
 	}
@@ -73,7 +73,7 @@ func (h *Handler) RefreshBlacklistCache(w http.ResponseWriter, r *http.Request)
 func (h *Handler) GetBlacklistedUsers(w http.ResponseWriter, r *http.Request) {
 	page := utils.GetPage(r)
 
-	entries, err := h.auth.GetBlacklistedUsersWithDetails(page)
+	entries, err := h.auth.GetBlacklistedUsersWithDetails(r.Context(), page)
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -86,3 +86,25 @@ func (h *Handler) GetBlacklistedUsers(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, api.BlacklistResponse{Users: entries, Page: page})
 }
+
+// LookupUser handles POST /v1/admin/users/lookup: finds the account of an exact email
+func (h *Handler) LookupUser(w http.ResponseWriter, r *http.Request) {
+	var req api.UserLookupRequest
+	if err := utils.DecodeValidate(r.Body, &req); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	user, err := h.auth.FindUserByEmail(r.Context(), req.Email)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, api.UserLookupResponse{
+		UserId:      user.Id,
+		EmailDomain: user.EmailDomain,
+		Admin:       user.Admin,
+		CreatedAt:   user.CreatedAt,
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/itchan-dev/itchan/backend/internal/service"
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -270,3 +271,62 @@ func TestGetBlacklistedUsers(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	})
 }
+
+func TestLookupUser(t *testing.T) {
+	setup := func(authService service.AuthService) *chi.Mux {
+		h := &Handler{auth: authService}
+		router := chi.NewRouter()
+		router.Post("/v1/admin/users/lookup", h.LookupUser)
+		return router
+	}
+
+	t.Run("found", func(t *testing.T) {
+		createdAt := time.Now().UTC().Truncate(time.Second)
+		router := setup(&MockAuthService{
+			MockFindUserByEmail: func(email domain.Email) (domain.User, error) {
+				assert.Equal(t, "user@example.com", email)
+				return domain.User{Id: 42, EmailDomain: "example.com", CreatedAt: createdAt}, nil
+			},
+		})
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/users/lookup", []byte(`{"email": "user@example.com"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response api.UserLookupResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, domain.UserId(42), response.UserId)
+		assert.Equal(t, "example.com", response.EmailDomain)
+		assert.True(t, createdAt.Equal(response.CreatedAt))
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		router := setup(&MockAuthService{
+			MockFindUserByEmail: func(email domain.Email) (domain.User, error) {
+				t.Fatal("service should not be called")
+				return domain.User{}, nil
+			},
+		})
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/users/lookup", []byte(`{"email": "not-an-email"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		router := setup(&MockAuthService{
+			MockFindUserByEmail: func(email domain.Email) (domain.User, error) {
+				return domain.User{}, &internal_errors.ErrorWithStatusCode{Message: "User not found", StatusCode: http.StatusNotFound}
+			},
+		})
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/users/lookup", []byte(`{"email": "user@example.com"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
 	"github.com/itchan-dev/itchan/shared/utils"
 )
 
@@ -16,7 +19,12 @@ func (h *Handler) CreateBoard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.board.Create(domain.BoardCreationData{Name: domain.BoardName(body.Name), ShortName: domain.BoardShortName(body.ShortName), AllowedEmails: body.AllowedEmails})
+	err := h.board.Create(r.Context(), domain.BoardCreationData{
+		Name:          domain.BoardName(body.Name),
+		ShortName:     domain.BoardShortName(body.ShortName),
+		AllowedEmails: body.AllowedEmails,
+		Description:   strings.TrimSpace(body.Description),
+	})
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -29,11 +37,14 @@ func (h *Handler) GetBoard(w http.ResponseWriter, r *http.Request) {
 	shortName := chi.URLParam(r, "board")
 	page := utils.GetPage(r)
 
-	board, err := h.board.Get(shortName, page)
+	board, err := h.board.Get(r.Context(), shortName, page, mw.GetUserFromContext(r))
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
+	for _, thread := range board.Threads {
+		liteAttachments(r, thread.Messages)
+	}
 
 	writeJSON(w, board)
 }
@@ -41,7 +52,7 @@ func (h *Handler) GetBoard(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetBoardLastModified(w http.ResponseWriter, r *http.Request) {
 	shortName := chi.URLParam(r, "board")
 
-	lastModified, err := h.board.GetLastModified(domain.BoardShortName(shortName))
+	lastModified, err := h.board.GetLastModified(r.Context(), domain.BoardShortName(shortName))
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -50,24 +61,227 @@ func (h *Handler) GetBoardLastModified(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, api.LastModifiedResponse{LastModifiedAt: lastModified})
 }
 
+// DeleteBoard handles DELETE /v1/admin/:board. The board is only hidden until
+// the deletion grace period ends; ?dry_run=true reports what would be removed.
 func (h *Handler) DeleteBoard(w http.ResponseWriter, r *http.Request) {
 	shortName := chi.URLParam(r, "board")
 
-	err := h.board.Delete(shortName)
+	if r.URL.Query().Get("dry_run") == "true" {
+		report, err := h.board.DeletionReport(r.Context(), shortName)
+		if err != nil {
+			utils.WriteErrorAndStatusCode(w, err)
+			return
+		}
+		writeJSON(w, report)
+		return
+	}
+
+	deleteAfter, err := h.board.ScheduleDeletion(r.Context(), shortName)
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
 
+	var resp api.DeleteBoardResponse
+	if !deleteAfter.IsZero() {
+		resp.DeleteAfter = &deleteAfter
+	}
+	writeJSON(w, resp)
+}
+
+// RestoreBoard handles POST /v1/admin/:board/restore, cancelling a scheduled deletion.
+func (h *Handler) RestoreBoard(w http.ResponseWriter, r *http.Request) {
+	if err := h.board.CancelDeletion(r.Context(), chi.URLParam(r, "board")); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
+// RenameBoard handles POST /v1/admin/:board/rename
+func (h *Handler) RenameBoard(w http.ResponseWriter, r *http.Request) {
+	var body api.RenameBoardRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.board.Rename(r.Context(), chi.URLParam(r, "board"), strings.TrimSpace(body.ShortName)); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetPendingBoardDeletions handles GET /v1/admin/boards/pending_deletion
+func (h *Handler) GetPendingBoardDeletions(w http.ResponseWriter, r *http.Request) {
+	boards, err := h.board.PendingDeletions(r.Context())
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if boards == nil {
+		boards = []domain.PendingBoardDeletion{}
+	}
+	writeJSON(w, api.PendingBoardDeletionsResponse{Boards: boards})
+}
+
+// GetBoards handles GET /v1/boards?sort=&page=&include_stats=
+// include_stats is a domain.BoardStatsMode; "true" and "false" are kept for
+// the counts and no stats.
 func (h *Handler) GetBoards(w http.ResponseWriter, r *http.Request) {
-	boards, err := h.board.GetAllBoards()
+	page := utils.GetPage(r)
+	query := r.URL.Query()
+
+	stats := query.Get("include_stats")
+	switch stats {
+	case "true":
+		stats = domain.BoardStatsCounts
+	case "false":
+		stats = domain.BoardStatsNone
+	}
+
+	boards, total, err := h.board.List(r.Context(), query.Get("sort"), stats, page, mw.GetUserFromContext(r))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	// If no boards, return empty array instead of null
+	if boards == nil {
+		boards = []domain.BoardMetadata{}
+	}
+
+	writeJSON(w, api.BoardsResponse{Boards: boards, Page: page, Total: total})
+}
+
+// GetGroupedBoards handles GET /v1/boards/grouped
+func (h *Handler) GetGroupedBoards(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.board.GetGroupedBoards(r.Context(), mw.GetUserFromContext(r))
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
 
-	writeJSON(w, boards)
+	writeJSON(w, categories)
+}
+
+// CreateBoardCategory handles POST /v1/admin/categories
+func (h *Handler) CreateBoardCategory(w http.ResponseWriter, r *http.Request) {
+	var body api.BoardCategoryRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	id, err := h.board.CreateCategory(r.Context(), domain.BoardCategoryCreationData{Name: body.Name, Position: body.Position})
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, api.CreateBoardCategoryResponse{ID: id})
+}
+
+// UpdateBoardCategory handles PUT /v1/admin/categories/:categoryId
+func (h *Handler) UpdateBoardCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "categoryId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	var body api.BoardCategoryRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.board.UpdateCategory(r.Context(), id, domain.BoardCategoryCreationData{Name: body.Name, Position: body.Position}); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteBoardCategory handles DELETE /v1/admin/categories/:categoryId
+func (h *Handler) DeleteBoardCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "categoryId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.board.DeleteCategory(r.Context(), id); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SetBoardCategory handles PUT /v1/admin/:board/category
+func (h *Handler) SetBoardCategory(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+
+	var body api.SetBoardCategoryRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.board.SetCategory(r.Context(), shortName, body.CategoryId, body.Position); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) UpdateBoardSettings(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+
+	var body api.UpdateBoardSettingsRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	settings := domain.BoardSettings{
+		Description:             strings.TrimSpace(body.Description),
+		ShowDeletionStubs:       body.ShowDeletionStubs,
+		Noindex:                 body.Noindex,
+		PublicModLog:            body.PublicModLog,
+		SelfDeleteReplied:       body.SelfDeleteReplied,
+		BumpLimitNotice:         body.BumpLimitNotice,
+		PreModeration:           body.PreModeration,
+		MinOpTextLength:         body.MinOpTextLength,
+		RequireOpAttachment:     body.RequireOpAttachment,
+		MaxThreadsPerUserPerDay: body.MaxThreadsPerUserPerDay,
+		VideoProfile:            body.VideoProfile,
+		TextFilter:              body.TextFilter,
+		Language:                body.Language,
+
+		PostingEmailDomains:      body.PostingEmailDomains,
+		PostingMinAccountAgeDays: body.PostingMinAccountAgeDays,
+
+		PremodTrustApprovals:      body.PremodTrustApprovals,
+		PremodTrustAccountAgeDays: body.PremodTrustAccountAgeDays,
+		UploadRules: domain.UploadRules{
+			AllowDocuments:   body.AllowDocuments,
+			MaxAttachments:   body.MaxAttachments,
+			AllowedMimeTypes: body.AllowedMimeTypes,
+		},
+	}
+	if err := h.board.UpdateSettings(r.Context(), shortName, settings); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
 }
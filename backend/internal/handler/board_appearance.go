@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/utils"
+	"github.com/itchan-dev/itchan/shared/validation"
+)
+
+// GetBoardAppearance handles GET /v1/:board/appearance
+func (h *Handler) GetBoardAppearance(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+
+	appearance, err := h.boardAppearance.Get(r.Context(), board)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, appearance)
+}
+
+// SetBoardCustomCSS handles PUT /v1/admin/:board/custom_css
+func (h *Handler) SetBoardCustomCSS(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+
+	var body api.SetBoardCustomCSSRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.boardAppearance.SetCustomCSS(r.Context(), board, body.CSS); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// UploadBoardBanner handles POST /v1/admin/:board/banners, a multipart form
+// with the image in the "banner" field
+func (h *Handler) UploadBoardBanner(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+
+	maxRequestSize := validation.CalculateMaxRequestSize(h.cfg.Public.MaxBannerSizeBytes, 1<<20)
+	if err := validation.ValidateAndParseMultipart(r, w, maxRequestSize); err != nil {
+		http.Error(w, "Banner is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	headers := r.MultipartForm.File["banner"]
+	if len(headers) != 1 {
+		http.Error(w, "Expected exactly one file in the banner field", http.StatusBadRequest)
+		return
+	}
+	files, err := validation.ValidateAttachments(headers, h.cfg.Public.AllowedImageMimeTypes)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, validation.ErrInvalidMimeType) {
+			statusCode = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), statusCode)
+		return
+	}
+	if closer, ok := files[0].Data.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if err := validation.CheckFileContents(files, h.denylist()); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, validation.ErrBlockedFile) || errors.Is(err, validation.ErrContentMismatch) {
+			statusCode = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), statusCode)
+		return
+	}
+
+	id, err := h.boardAppearance.AddBanner(r.Context(), board, files[0])
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, api.CreateBoardBannerResponse{ID: id})
+}
+
+// DeleteBoardBanner handles DELETE /v1/admin/:board/banners/:bannerId
+func (h *Handler) DeleteBoardBanner(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	id, err := strconv.ParseInt(chi.URLParam(r, "bannerId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid banner ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.boardAppearance.DeleteBanner(r.Context(), board, id); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
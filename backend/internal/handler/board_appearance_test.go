@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockBoardAppearanceService struct {
+	MockGet          func(board domain.BoardShortName) (domain.BoardAppearance, error)
+	MockSetCustomCSS func(board domain.BoardShortName, css string) error
+	MockAddBanner    func(board domain.BoardShortName, file *domain.PendingFile) (domain.BoardBannerId, error)
+	MockDeleteBanner func(board domain.BoardShortName, id domain.BoardBannerId) error
+}
+
+func (m *MockBoardAppearanceService) Get(ctx context.Context, board domain.BoardShortName) (domain.BoardAppearance, error) {
+	if m.MockGet != nil {
+		return m.MockGet(board)
+	}
+	return domain.BoardAppearance{}, nil
+}
+
+func (m *MockBoardAppearanceService) SetCustomCSS(ctx context.Context, board domain.BoardShortName, css string) error {
+	if m.MockSetCustomCSS != nil {
+		return m.MockSetCustomCSS(board, css)
+	}
+	return nil
+}
+
+func (m *MockBoardAppearanceService) AddBanner(ctx context.Context, board domain.BoardShortName, file *domain.PendingFile) (domain.BoardBannerId, error) {
+	if m.MockAddBanner != nil {
+		return m.MockAddBanner(board, file)
+	}
+	return 1, nil
+}
+
+func (m *MockBoardAppearanceService) DeleteBanner(ctx context.Context, board domain.BoardShortName, id domain.BoardBannerId) error {
+	if m.MockDeleteBanner != nil {
+		return m.MockDeleteBanner(board, id)
+	}
+	return nil
+}
+
+func setupBoardAppearanceTestHandler(appearance *MockBoardAppearanceService) *chi.Mux {
+	h := &Handler{
+		boardAppearance: appearance,
+		cfg: &config.Config{Public: config.Public{
+			MaxBannerSizeBytes:    1 << 20,
+			AllowedImageMimeTypes: []string{"image/jpeg", "image/png"},
+		}},
+	}
+	router := chi.NewRouter()
+	router.Get("/v1/{board}/appearance", h.GetBoardAppearance)
+	router.Put("/v1/admin/{board}/custom_css", h.SetBoardCustomCSS)
+	router.Post("/v1/admin/{board}/banners", h.UploadBoardBanner)
+	router.Delete("/v1/admin/{board}/banners/{bannerId}", h.DeleteBoardBanner)
+	return router
+}
+
+// bannerRequest builds an upload with the file in the given form field.
+func bannerRequest(t *testing.T, field, contentType string, content []byte) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="`+field+`"; filename="banner"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/b/banners", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestGetBoardAppearanceHandler(t *testing.T) {
+	t.Run("returns css and banners", func(t *testing.T) {
+		router := setupBoardAppearanceTestHandler(&MockBoardAppearanceService{
+			MockGet: func(board domain.BoardShortName) (domain.BoardAppearance, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				return domain.BoardAppearance{
+					CustomCSS: "body{color:red}",
+					Banners:   []domain.BoardBanner{{Id: 1, Board: "b", FilePath: "b/banners/1.png"}},
+				}, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, createRequest(t, http.MethodGet, "/v1/b/appearance", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp domain.BoardAppearance
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "body{color:red}", resp.CustomCSS)
+		require.Len(t, resp.Banners, 1)
+		assert.Equal(t, "b/banners/1.png", resp.Banners[0].FilePath)
+	})
+
+	t.Run("unknown board", func(t *testing.T) {
+		router := setupBoardAppearanceTestHandler(&MockBoardAppearanceService{
+			MockGet: func(board domain.BoardShortName) (domain.BoardAppearance, error) {
+				return domain.BoardAppearance{}, &internal_errors.ErrorWithStatusCode{Message: "Board not found", StatusCode: http.StatusNotFound}
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, createRequest(t, http.MethodGet, "/v1/b/appearance", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestSetBoardCustomCSSHandler(t *testing.T) {
+	var stored string
+	router := setupBoardAppearanceTestHandler(&MockBoardAppearanceService{
+		MockSetCustomCSS: func(board domain.BoardShortName, css string) error {
+			stored = css
+			return nil
+		},
+	})
+
+	body, _ := json.Marshal(api.SetBoardCustomCSSRequest{CSS: ".post{border:0}"})
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, createRequest(t, http.MethodPut, "/v1/admin/b/custom_css", body))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, ".post{border:0}", stored)
+}
+
+func TestUploadBoardBannerHandler(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nfake png")
+
+	t.Run("passes the image to the service", func(t *testing.T) {
+		router := setupBoardAppearanceTestHandler(&MockBoardAppearanceService{
+			MockAddBanner: func(board domain.BoardShortName, file *domain.PendingFile) (domain.BoardBannerId, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, "image/png", file.MimeType)
+				data, err := io.ReadAll(file.Data)
+				require.NoError(t, err)
+				assert.Equal(t, png, data)
+				return 5, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, bannerRequest(t, "banner", "image/png", png))
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var resp api.CreateBoardBannerResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, int64(5), resp.ID)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		setupBoardAppearanceTestHandler(&MockBoardAppearanceService{}).ServeHTTP(rr, bannerRequest(t, "other", "image/png", png))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not an allowed image type", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		setupBoardAppearanceTestHandler(&MockBoardAppearanceService{}).ServeHTTP(rr, bannerRequest(t, "banner", "text/html", []byte("<script></script>")))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("content that doesn't match its type", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		setupBoardAppearanceTestHandler(&MockBoardAppearanceService{}).ServeHTTP(rr, bannerRequest(t, "banner", "image/png", []byte("<html><script></script>")))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestDeleteBoardBannerHandler(t *testing.T) {
+	t.Run("deletes by id", func(t *testing.T) {
+		router := setupBoardAppearanceTestHandler(&MockBoardAppearanceService{
+			MockDeleteBanner: func(board domain.BoardShortName, id domain.BoardBannerId) error {
+				assert.Equal(t, domain.BoardBannerId(3), id)
+				return nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, createRequest(t, http.MethodDelete, "/v1/admin/b/banners/3", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		setupBoardAppearanceTestHandler(&MockBoardAppearanceService{}).ServeHTTP(rr, createRequest(t, http.MethodDelete, "/v1/admin/b/banners/x", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
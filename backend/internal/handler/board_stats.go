@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetPostsPerHour handles GET /v1/admin/stats/posts_per_hour?hours=N&board=b
+func (h *Handler) GetPostsPerHour(w http.ResponseWriter, r *http.Request) {
+	hours := 0 // the service default
+	if v := r.URL.Query().Get("hours"); v != "" {
+		parsed, err := parseIntParam(v, "hours")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hours = parsed
+	}
+
+	stats, err := h.boardStats.PostsPerHour(r.Context(), r.URL.Query().Get("board"), hours)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, stats)
+}
+
+// GetTopPosters handles GET /v1/admin/{board}/posters?window=1h
+func (h *Handler) GetTopPosters(w http.ResponseWriter, r *http.Request) {
+	var window time.Duration // the service default
+	if v := r.URL.Query().Get("window"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid window %q, expected a duration such as 30m or 6h", v), http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	posters, err := h.boardStats.TopPosters(r.Context(), chi.URLParam(r, "board"), window)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, posters)
+}
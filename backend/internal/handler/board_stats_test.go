@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockBoardStatsService struct {
+	MockPostsPerHour func(board domain.BoardShortName, hours int) (domain.PostsPerHour, error)
+	MockTopPosters   func(board domain.BoardShortName, window time.Duration) (domain.BoardPosters, error)
+}
+
+func (m *MockBoardStatsService) PostsPerHour(ctx context.Context, board domain.BoardShortName, hours int) (domain.PostsPerHour, error) {
+	if m.MockPostsPerHour != nil {
+		return m.MockPostsPerHour(board, hours)
+	}
+	return domain.PostsPerHour{}, nil
+}
+
+func (m *MockBoardStatsService) TopPosters(ctx context.Context, board domain.BoardShortName, window time.Duration) (domain.BoardPosters, error) {
+	if m.MockTopPosters != nil {
+		return m.MockTopPosters(board, window)
+	}
+	return domain.BoardPosters{}, nil
+}
+
+func TestGetPostsPerHourHandler(t *testing.T) {
+	setup := func(stats *MockBoardStatsService) *chi.Mux {
+		h := &Handler{boardStats: stats}
+		router := chi.NewRouter()
+		router.Get("/admin/stats/posts_per_hour", h.GetPostsPerHour)
+		return router
+	}
+
+	t.Run("passes board and hours", func(t *testing.T) {
+		from := time.Date(2026, 3, 4, 13, 0, 0, 0, time.UTC)
+		expected := domain.PostsPerHour{From: from, Hours: 3, Boards: []domain.BoardPostSeries{{Board: "b", Posts: []int{1, 0, 2}}}}
+		router := setup(&MockBoardStatsService{
+			MockPostsPerHour: func(board domain.BoardShortName, hours int) (domain.PostsPerHour, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, 3, hours)
+				return expected, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/stats/posts_per_hour?board=b&hours=3", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got domain.PostsPerHour
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("hours left to the service default", func(t *testing.T) {
+		router := setup(&MockBoardStatsService{
+			MockPostsPerHour: func(board domain.BoardShortName, hours int) (domain.PostsPerHour, error) {
+				assert.Equal(t, domain.BoardShortName(""), board)
+				assert.Equal(t, 0, hours)
+				return domain.PostsPerHour{}, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/stats/posts_per_hour", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("invalid hours", func(t *testing.T) {
+		router := setup(&MockBoardStatsService{})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/stats/posts_per_hour?hours=week", nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		router := setup(&MockBoardStatsService{
+			MockPostsPerHour: func(board domain.BoardShortName, hours int) (domain.PostsPerHour, error) {
+				return domain.PostsPerHour{}, &internal_errors.ErrorWithStatusCode{Message: "hours must be between 1 and 720", StatusCode: http.StatusBadRequest}
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/stats/posts_per_hour?hours=1000", nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetTopPostersHandler(t *testing.T) {
+	setup := func(stats *MockBoardStatsService) *chi.Mux {
+		h := &Handler{boardStats: stats}
+		router := chi.NewRouter()
+		router.Get("/admin/{board}/posters", h.GetTopPosters)
+		return router
+	}
+
+	t.Run("passes board and window", func(t *testing.T) {
+		since := time.Date(2026, 3, 4, 9, 30, 0, 0, time.UTC)
+		expected := domain.BoardPosters{
+			Board:   "b",
+			Since:   since,
+			Posters: []domain.PosterActivity{{UserId: 7, Posts: 40, Threads: 2, Burst: 15, LastPostAt: since.Add(time.Hour)}},
+		}
+		router := setup(&MockBoardStatsService{
+			MockTopPosters: func(board domain.BoardShortName, window time.Duration) (domain.BoardPosters, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, 6*time.Hour, window)
+				return expected, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/b/posters?window=6h", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got domain.BoardPosters
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("window left to the service default", func(t *testing.T) {
+		router := setup(&MockBoardStatsService{
+			MockTopPosters: func(board domain.BoardShortName, window time.Duration) (domain.BoardPosters, error) {
+				assert.Zero(t, window)
+				return domain.BoardPosters{}, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/b/posters", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("invalid window", func(t *testing.T) {
+		router := setup(&MockBoardStatsService{})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/b/posters?window=day", nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		router := setup(&MockBoardStatsService{
+			MockTopPosters: func(board domain.BoardShortName, window time.Duration) (domain.BoardPosters, error) {
+				return domain.BoardPosters{}, &internal_errors.ErrorWithStatusCode{Message: "window must be between 1m and 168h", StatusCode: http.StatusBadRequest}
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/b/posters?window=720h", nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
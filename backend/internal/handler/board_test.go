@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -10,48 +11,132 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 type MockBoardService struct {
-	MockCreate       func(creationData domain.BoardCreationData) error
-	MockGet          func(shortName domain.BoardShortName, page int) (domain.Board, error)
-	MockDelete       func(shortName domain.BoardShortName) error
-	MockGetAllBoards func() ([]domain.BoardMetadata, error)
+	MockCreate         func(creationData domain.BoardCreationData) error
+	MockGet            func(shortName domain.BoardShortName, page int) (domain.Board, error)
+	MockDelete         func(shortName domain.BoardShortName) error
+	MockDeletionReport func(shortName domain.BoardShortName) (domain.DeletionReport, error)
+	MockList           func(sort domain.BoardSort, stats domain.BoardStatsMode, page int) ([]domain.BoardMetadata, int, error)
+	MockGetGrouped     func(viewer *domain.User) ([]domain.BoardCategory, error)
+	MockSetCategory    func(shortName domain.BoardShortName, categoryId *domain.BoardCategoryId, position int) error
+	MockUpdateSettings func(shortName domain.BoardShortName, settings domain.BoardSettings) error
+
+	MockScheduleDeletion func(shortName domain.BoardShortName) (time.Time, error)
+	MockCancelDeletion   func(shortName domain.BoardShortName) error
+	MockPendingDeletions func() ([]domain.PendingBoardDeletion, error)
+	MockRename           func(oldName, newName domain.BoardShortName) error
 }
 
-func (m *MockBoardService) Create(creationData domain.BoardCreationData) error {
+func (m *MockBoardService) Create(ctx context.Context, creationData domain.BoardCreationData) error {
 	if m.MockCreate != nil {
 		return m.MockCreate(creationData)
 	}
 	return nil
 }
 
-func (m *MockBoardService) Get(shortName domain.BoardShortName, page int) (domain.Board, error) {
+func (m *MockBoardService) Get(ctx context.Context, shortName domain.BoardShortName, page int, viewer *domain.User) (domain.Board, error) {
 	if m.MockGet != nil {
 		return m.MockGet(shortName, page)
 	}
 	return domain.Board{}, nil
 }
 
-func (m *MockBoardService) Delete(shortName domain.BoardShortName) error {
+func (m *MockBoardService) Delete(ctx context.Context, shortName domain.BoardShortName) error {
 	if m.MockDelete != nil {
 		return m.MockDelete(shortName)
 	}
 	return nil
 }
 
-func (m *MockBoardService) GetLastModified(shortName domain.BoardShortName) (time.Time, error) {
+func (m *MockBoardService) DeletionReport(ctx context.Context, shortName domain.BoardShortName) (domain.DeletionReport, error) {
+	if m.MockDeletionReport != nil {
+		return m.MockDeletionReport(shortName)
+	}
+	return domain.DeletionReport{}, nil
+}
+
+// ScheduleDeletion falls back to MockDelete, as the service does without a
+// grace period.
+func (m *MockBoardService) ScheduleDeletion(ctx context.Context, shortName domain.BoardShortName) (time.Time, error) {
+	if m.MockScheduleDeletion != nil {
+		return m.MockScheduleDeletion(shortName)
+	}
+	return time.Time{}, m.Delete(ctx, shortName)
+}
+
+func (m *MockBoardService) CancelDeletion(ctx context.Context, shortName domain.BoardShortName) error {
+	if m.MockCancelDeletion != nil {
+		return m.MockCancelDeletion(shortName)
+	}
+	return nil
+}
+
+func (m *MockBoardService) Rename(ctx context.Context, oldName, newName domain.BoardShortName) error {
+	if m.MockRename != nil {
+		return m.MockRename(oldName, newName)
+	}
+	return nil
+}
+
+func (m *MockBoardService) PendingDeletions(ctx context.Context) ([]domain.PendingBoardDeletion, error) {
+	if m.MockPendingDeletions != nil {
+		return m.MockPendingDeletions()
+	}
+	return nil, nil
+}
+
+func (m *MockBoardService) PurgeDeleted(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *MockBoardService) GetLastModified(ctx context.Context, shortName domain.BoardShortName) (time.Time, error) {
 	return time.Now().UTC(), nil
 }
 
-func (m *MockBoardService) GetAllBoards() ([]domain.BoardMetadata, error) {
-	if m.MockGetAllBoards != nil {
-		return m.MockGetAllBoards()
+func (m *MockBoardService) List(ctx context.Context, sort domain.BoardSort, stats domain.BoardStatsMode, page int, viewer *domain.User) ([]domain.BoardMetadata, int, error) {
+	if m.MockList != nil {
+		return m.MockList(sort, stats, page)
+	}
+	return nil, 0, nil
+}
+
+func (m *MockBoardService) GetGroupedBoards(ctx context.Context, viewer *domain.User) ([]domain.BoardCategory, error) {
+	if m.MockGetGrouped != nil {
+		return m.MockGetGrouped(viewer)
+	}
+	return []domain.BoardCategory{}, nil
+}
+
+func (m *MockBoardService) CreateCategory(ctx context.Context, data domain.BoardCategoryCreationData) (domain.BoardCategoryId, error) {
+	return 1, nil
+}
+
+func (m *MockBoardService) UpdateCategory(ctx context.Context, id domain.BoardCategoryId, data domain.BoardCategoryCreationData) error {
+	return nil
+}
+
+func (m *MockBoardService) DeleteCategory(ctx context.Context, id domain.BoardCategoryId) error {
+	return nil
+}
+
+func (m *MockBoardService) SetCategory(ctx context.Context, shortName domain.BoardShortName, categoryId *domain.BoardCategoryId, position int) error {
+	if m.MockSetCategory != nil {
+		return m.MockSetCategory(shortName, categoryId, position)
 	}
-	return []domain.BoardMetadata{}, nil
+	return nil
+}
+
+func (m *MockBoardService) UpdateSettings(ctx context.Context, shortName domain.BoardShortName, settings domain.BoardSettings) error {
+	if m.MockUpdateSettings != nil {
+		return m.MockUpdateSettings(shortName, settings)
+	}
+	return nil
 }
 
 func setupBoardTestHandler(boardService service.BoardService) (*Handler, *chi.Mux) {
@@ -63,6 +148,12 @@ func setupBoardTestHandler(boardService service.BoardService) (*Handler, *chi.Mu
 	router.Get("/v1/boards", h.GetBoards)
 	router.Get("/v1/{board}", h.GetBoard)
 	router.Delete("/v1/{board}", h.DeleteBoard)
+	router.Post("/v1/admin/{board}/restore", h.RestoreBoard)
+	router.Post("/v1/admin/{board}/rename", h.RenameBoard)
+	router.Get("/v1/admin/boards/pending_deletion", h.GetPendingBoardDeletions)
+	router.Get("/v1/boards/grouped", h.GetGroupedBoards)
+	router.Put("/v1/admin/{board}/category", h.SetBoardCategory)
+	router.Put("/v1/admin/{board}/settings", h.UpdateBoardSettings)
 
 	return h, router
 }
@@ -91,6 +182,22 @@ func TestCreateBoardHandler(t *testing.T) {
 		assert.Empty(t, rr.Body.String())
 	})
 
+	t.Run("successful creation with description", func(t *testing.T) {
+		mockService := &MockBoardService{
+			MockCreate: func(data domain.BoardCreationData) error {
+				assert.Equal(t, "Talk about anything", data.Description, "description is trimmed")
+				return nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{"name": "Test Board", "short_name": "tb", "description": "  Talk about anything "}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
 	t.Run("successful creation with allowed emails", func(t *testing.T) {
 		expectedEmails := &domain.Emails{"test@example.com", "another@domain.org"}
 		mockService := &MockBoardService{
@@ -234,7 +341,28 @@ func TestDeleteBoardHandler(t *testing.T) {
 		router.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusOK, rr.Code)
-		assert.Empty(t, rr.Body.String())
+		assert.JSONEq(t, `{}`, rr.Body.String(), "no delete_after when deleted at once")
+	})
+
+	t.Run("scheduled deletion", func(t *testing.T) {
+		deleteAfter := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+		mockService := &MockBoardService{
+			MockScheduleDeletion: func(shortName domain.BoardShortName) (time.Time, error) {
+				assert.Equal(t, boardShortName, shortName)
+				return deleteAfter, nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodDelete, route, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got api.DeleteBoardResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		require.NotNil(t, got.DeleteAfter)
+		assert.True(t, deleteAfter.Equal(*got.DeleteAfter))
 	})
 
 	t.Run("service error", func(t *testing.T) {
@@ -252,6 +380,118 @@ func TestDeleteBoardHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	})
+
+	t.Run("dry run reports without deleting", func(t *testing.T) {
+		want := domain.DeletionReport{Threads: 3, Messages: 10, Attachments: 2, AttachmentBytes: 2048}
+		mockService := &MockBoardService{
+			MockDelete: func(shortName domain.BoardShortName) error {
+				t.Fatal("a dry run must not delete the board")
+				return nil
+			},
+			MockDeletionReport: func(shortName domain.BoardShortName) (domain.DeletionReport, error) {
+				assert.Equal(t, boardShortName, shortName)
+				return want, nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodDelete, route+"?dry_run=true", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got domain.DeletionReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, want, got)
+	})
+}
+
+func TestRestoreBoardHandler(t *testing.T) {
+	t.Run("cancels the deletion", func(t *testing.T) {
+		called := false
+		mockService := &MockBoardService{
+			MockCancelDeletion: func(shortName domain.BoardShortName) error {
+				called = true
+				assert.Equal(t, "old", shortName)
+				return nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/old/restore", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("lists pending boards", func(t *testing.T) {
+		mockService := &MockBoardService{}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/v1/admin/boards/pending_deletion", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"boards":[]}`, rr.Body.String())
+	})
+}
+
+func TestRenameBoardHandler(t *testing.T) {
+	route := "/v1/admin/old/rename"
+
+	t.Run("renames the board", func(t *testing.T) {
+		called := false
+		mockService := &MockBoardService{
+			MockRename: func(oldName, newName domain.BoardShortName) error {
+				called = true
+				assert.Equal(t, "old", oldName)
+				assert.Equal(t, "new", newName)
+				return nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{"short_name": " new "}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("missing short name", func(t *testing.T) {
+		mockService := &MockBoardService{
+			MockRename: func(oldName, newName domain.BoardShortName) error {
+				t.Fatal("Rename should not be called")
+				return nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &MockBoardService{
+			MockRename: func(oldName, newName domain.BoardShortName) error {
+				return errors.New("rename failed")
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, route, []byte(`{"short_name": "new"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
 }
 
 func TestGetBoardsHandler(t *testing.T) {
@@ -263,8 +503,11 @@ func TestGetBoardsHandler(t *testing.T) {
 
 	t.Run("successful retrieval", func(t *testing.T) {
 		mockService := &MockBoardService{
-			MockGetAllBoards: func() ([]domain.BoardMetadata, error) {
-				return expectedBoards, nil
+			MockList: func(sort domain.BoardSort, stats domain.BoardStatsMode, page int) ([]domain.BoardMetadata, int, error) {
+				assert.Empty(t, sort)
+				assert.Equal(t, domain.BoardStatsNone, stats)
+				assert.Equal(t, 1, page)
+				return expectedBoards, 2, nil
 			},
 		}
 		_, router := setupBoardTestHandler(mockService)
@@ -275,10 +518,44 @@ func TestGetBoardsHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 
-		var response []domain.BoardMetadata
+		var response api.BoardsResponse
 		err := json.Unmarshal(rr.Body.Bytes(), &response)
 		require.NoError(t, err)
-		assert.Equal(t, expectedBoards, response)
+		assert.Equal(t, api.BoardsResponse{Boards: expectedBoards, Page: 1, Total: 2}, response)
+	})
+
+	t.Run("sort, page and stats are passed through", func(t *testing.T) {
+		mockService := &MockBoardService{
+			MockList: func(sort domain.BoardSort, stats domain.BoardStatsMode, page int) ([]domain.BoardMetadata, int, error) {
+				assert.Equal(t, domain.BoardSortActivity, sort)
+				assert.Equal(t, domain.BoardStatsCounts, stats)
+				assert.Equal(t, 3, page)
+				return nil, 0, nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, route+"?sort=activity&include_stats=true&page=3", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("latest stats mode", func(t *testing.T) {
+		mockService := &MockBoardService{
+			MockList: func(sort domain.BoardSort, stats domain.BoardStatsMode, page int) ([]domain.BoardMetadata, int, error) {
+				assert.Equal(t, domain.BoardStatsLatest, stats)
+				return nil, 0, nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, route+"?include_stats=latest", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
 	})
 
 	t.Run("unauthenticated access returns all boards", func(t *testing.T) {
@@ -289,17 +566,74 @@ func TestGetBoardsHandler(t *testing.T) {
 		router.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusOK, rr.Code)
-		var response []domain.BoardMetadata
-		err := json.Unmarshal(rr.Body.Bytes(), &response)
-		require.NoError(t, err)
-		assert.Empty(t, response)
+		assert.JSONEq(t, `{"boards": [], "page": 1, "total": 0}`, rr.Body.String())
 	})
 
 	t.Run("service error", func(t *testing.T) {
 		mockErr := errors.New("failed to query boards")
 		mockService := &MockBoardService{
-			MockGetAllBoards: func() ([]domain.BoardMetadata, error) {
-				return nil, mockErr
+			MockList: func(sort domain.BoardSort, stats domain.BoardStatsMode, page int) ([]domain.BoardMetadata, int, error) {
+				return nil, 0, mockErr
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, route, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestGetGroupedBoardsHandler(t *testing.T) {
+	route := "/v1/boards/grouped"
+
+	t.Run("successful get", func(t *testing.T) {
+		expected := []domain.BoardCategory{
+			{Id: 1, Name: "Tech", Boards: []domain.BoardMetadata{{ShortName: "g"}}},
+			{Boards: []domain.BoardMetadata{{ShortName: "b"}}},
+		}
+		mockService := &MockBoardService{
+			MockGetGrouped: func(viewer *domain.User) ([]domain.BoardCategory, error) {
+				return expected, nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, route, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []domain.BoardCategory
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("passes the viewer", func(t *testing.T) {
+		user := &domain.User{Id: 7, EmailDomain: "corp.com"}
+		var got *domain.User
+		mockService := &MockBoardService{
+			MockGetGrouped: func(viewer *domain.User) ([]domain.BoardCategory, error) {
+				got = viewer
+				return nil, nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := addUserToContext(createRequest(t, http.MethodGet, route, nil), user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, user, got)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &MockBoardService{
+			MockGetGrouped: func(viewer *domain.User) ([]domain.BoardCategory, error) {
+				return nil, errors.New("db down")
 			},
 		}
 		_, router := setupBoardTestHandler(mockService)
@@ -311,3 +645,122 @@ func TestGetBoardsHandler(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	})
 }
+
+func TestSetBoardCategoryHandler(t *testing.T) {
+	route := "/v1/admin/tb/category"
+
+	t.Run("assign category", func(t *testing.T) {
+		called := false
+		mockService := &MockBoardService{
+			MockSetCategory: func(shortName domain.BoardShortName, categoryId *domain.BoardCategoryId, position int) error {
+				called = true
+				assert.Equal(t, "tb", shortName)
+				require.NotNil(t, categoryId)
+				assert.Equal(t, domain.BoardCategoryId(3), *categoryId)
+				assert.Equal(t, 2, position)
+				return nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, route, []byte(`{"category_id": 3, "position": 2}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("null category unassigns", func(t *testing.T) {
+		mockService := &MockBoardService{
+			MockSetCategory: func(shortName domain.BoardShortName, categoryId *domain.BoardCategoryId, position int) error {
+				assert.Nil(t, categoryId)
+				return nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, route, []byte(`{"category_id": null}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
+func TestUpdateBoardSettingsHandler(t *testing.T) {
+	route := "/v1/admin/tb/settings"
+
+	t.Run("enable deletion stubs", func(t *testing.T) {
+		called := false
+		mockService := &MockBoardService{
+			MockUpdateSettings: func(shortName domain.BoardShortName, settings domain.BoardSettings) error {
+				called = true
+				assert.Equal(t, "tb", shortName)
+				assert.True(t, settings.ShowDeletionStubs)
+				return nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, route, []byte(`{"show_deletion_stubs": true}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("thread creation requirements", func(t *testing.T) {
+		mockService := &MockBoardService{
+			MockUpdateSettings: func(shortName domain.BoardShortName, settings domain.BoardSettings) error {
+				assert.Equal(t, domain.BoardSettings{MinOpTextLength: 30, RequireOpAttachment: true, MaxThreadsPerUserPerDay: 2}, settings)
+				return nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		body := `{"min_op_text_length": 30, "require_op_attachment": true, "max_threads_per_user_per_day": 2}`
+		req := createRequest(t, http.MethodPut, route, []byte(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("noindex", func(t *testing.T) {
+		mockService := &MockBoardService{
+			MockUpdateSettings: func(shortName domain.BoardShortName, settings domain.BoardSettings) error {
+				assert.True(t, settings.Noindex)
+				return nil
+			},
+		}
+		_, router := setupBoardTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, route, []byte(`{"noindex": true}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("negative limit", func(t *testing.T) {
+		_, router := setupBoardTestHandler(&MockBoardService{})
+
+		req := createRequest(t, http.MethodPut, route, []byte(`{"min_op_text_length": -1}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, router := setupBoardTestHandler(&MockBoardService{})
+
+		req := createRequest(t, http.MethodPut, route, []byte(`{`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// AddBookmark handles POST /v1/me/bookmarks
+func (h *Handler) AddBookmark(w http.ResponseWriter, r *http.Request) {
+	var body api.AddBookmarkRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.bookmark.Add(r.Context(), mw.GetUserFromContext(r), body.Board, body.ThreadId, body.MessageId); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// GetBookmarks handles GET /v1/me/bookmarks: one page of the user's bookmarks
+func (h *Handler) GetBookmarks(w http.ResponseWriter, r *http.Request) {
+	page := utils.GetPage(r)
+
+	bookmarks, total, err := h.bookmark.List(r.Context(), mw.GetUserFromContext(r), page)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	if bookmarks == nil {
+		bookmarks = []domain.Bookmark{}
+	}
+
+	writeJSON(w, api.BookmarksResponse{Bookmarks: bookmarks, Page: page, Total: total})
+}
+
+// DeleteBookmark handles DELETE /v1/me/bookmarks/:board/:thread/:message
+func (h *Handler) DeleteBookmark(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	msgId, err := parseIntParam(chi.URLParam(r, "message"), "message ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.bookmark.Remove(r.Context(), mw.GetUserFromContext(r).Id, board, domain.ThreadId(threadId), domain.MsgId(msgId)); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockBookmarkService struct {
+	MockAdd    func(user *domain.User, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	MockRemove func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	MockList   func(user *domain.User, page int) ([]domain.Bookmark, int, error)
+}
+
+func (m *MockBookmarkService) Add(ctx context.Context, user *domain.User, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	if m.MockAdd != nil {
+		return m.MockAdd(user, board, threadId, msgId)
+	}
+	return nil
+}
+
+func (m *MockBookmarkService) Remove(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	if m.MockRemove != nil {
+		return m.MockRemove(userId, board, threadId, msgId)
+	}
+	return nil
+}
+
+func (m *MockBookmarkService) List(ctx context.Context, user *domain.User, page int) ([]domain.Bookmark, int, error) {
+	if m.MockList != nil {
+		return m.MockList(user, page)
+	}
+	return nil, 0, nil
+}
+
+func setupBookmarkTestHandler(bookmarkService service.BookmarkService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		bookmark: bookmarkService,
+	}
+	router := chi.NewRouter()
+	router.Post("/v1/me/bookmarks", h.AddBookmark)
+	router.Get("/v1/me/bookmarks", h.GetBookmarks)
+	router.Delete("/v1/me/bookmarks/{board}/{thread}/{message}", h.DeleteBookmark)
+
+	return h, router
+}
+
+func TestAddBookmarkHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	t.Run("success", func(t *testing.T) {
+		called := false
+		mockService := &MockBookmarkService{
+			MockAdd: func(u *domain.User, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+				called = true
+				assert.Equal(t, user.Id, u.Id)
+				assert.Equal(t, "b", board)
+				assert.Equal(t, domain.ThreadId(3), threadId)
+				assert.Equal(t, domain.MsgId(5), msgId)
+				return nil
+			},
+		}
+		_, router := setupBookmarkTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/me/bookmarks", []byte(`{"board": "b", "thread_id": 3, "message_id": 5}`))
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("message is required", func(t *testing.T) {
+		_, router := setupBookmarkTestHandler(&MockBookmarkService{})
+
+		req := createRequest(t, http.MethodPost, "/v1/me/bookmarks", []byte(`{"board": "b", "thread_id": 3}`))
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := &MockBookmarkService{
+			MockAdd: func(*domain.User, domain.BoardShortName, domain.ThreadId, domain.MsgId) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupBookmarkTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/me/bookmarks", []byte(`{"board": "b", "thread_id": 3, "message_id": 99}`))
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestGetBookmarksHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &MockBookmarkService{
+			MockList: func(u *domain.User, page int) ([]domain.Bookmark, int, error) {
+				assert.Equal(t, user.Id, u.Id)
+				assert.Equal(t, 2, page)
+				return []domain.Bookmark{{Message: domain.LatestPost{Board: "b", ThreadId: 3, Id: 5}}}, 11, nil
+			},
+		}
+		_, router := setupBookmarkTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/v1/me/bookmarks?page=2", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.BookmarksResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Bookmarks, 1)
+		assert.Equal(t, domain.MsgId(5), resp.Bookmarks[0].Message.Id)
+		assert.Equal(t, 2, resp.Page)
+		assert.Equal(t, 11, resp.Total)
+	})
+
+	t.Run("empty list is not null", func(t *testing.T) {
+		_, router := setupBookmarkTestHandler(&MockBookmarkService{})
+
+		req := createRequest(t, http.MethodGet, "/v1/me/bookmarks", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"bookmarks":[]`)
+	})
+}
+
+func TestDeleteBookmarkHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	t.Run("success", func(t *testing.T) {
+		called := false
+		mockService := &MockBookmarkService{
+			MockRemove: func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+				called = true
+				assert.Equal(t, user.Id, userId)
+				assert.Equal(t, "b", board)
+				assert.Equal(t, domain.ThreadId(3), threadId)
+				assert.Equal(t, domain.MsgId(5), msgId)
+				return nil
+			},
+		}
+		_, router := setupBookmarkTestHandler(mockService)
+
+		req := createRequest(t, http.MethodDelete, "/v1/me/bookmarks/b/3/5", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("invalid message ID", func(t *testing.T) {
+		_, router := setupBookmarkTestHandler(&MockBookmarkService{})
+
+		req := createRequest(t, http.MethodDelete, "/v1/me/bookmarks/b/3/abc", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
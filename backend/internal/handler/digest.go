@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// WatchThread handles PUT /v1/:board/:thread/watch
+func (h *Handler) WatchThread(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.digest.Watch(r.Context(), mw.GetUserFromContext(r).Id, board, domain.ThreadId(threadId)); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// UnwatchThread handles DELETE /v1/:board/:thread/watch
+func (h *Handler) UnwatchThread(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.digest.Unwatch(r.Context(), mw.GetUserFromContext(r).Id, board, domain.ThreadId(threadId)); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// MarkThreadRead handles PUT /v1/:board/:thread/read
+func (h *Handler) MarkThreadRead(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var body api.MarkThreadReadRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.digest.MarkRead(r.Context(), mw.GetUserFromContext(r).Id, board, domain.ThreadId(threadId), body.LastReadId); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetWatchedThreads handles GET /v1/me/watched
+func (h *Handler) GetWatchedThreads(w http.ResponseWriter, r *http.Request) {
+	threads, err := h.digest.Watched(r.Context(), mw.GetUserFromContext(r).Id)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	if threads == nil {
+		threads = []domain.WatchedThread{}
+	}
+
+	writeJSON(w, api.WatchedThreadsResponse{Threads: threads})
+}
+
+// GetDigest handles GET /v1/me/digest
+func (h *Handler) GetDigest(w http.ResponseWriter, r *http.Request) {
+	frequency, err := h.digest.Frequency(r.Context(), mw.GetUserFromContext(r).Id)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, api.DigestResponse{Frequency: frequency})
+}
+
+// SetDigest handles PUT /v1/me/digest
+func (h *Handler) SetDigest(w http.ResponseWriter, r *http.Request) {
+	var body api.SetDigestRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.digest.SetFrequency(r.Context(), mw.GetUserFromContext(r).Id, body.Frequency); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// UnsubscribeDigest handles POST /v1/digest/unsubscribe, the target of the
+// unsubscribe link in digest emails. The token identifies the subscription.
+func (h *Handler) UnsubscribeDigest(w http.ResponseWriter, r *http.Request) {
+	var body api.UnsubscribeDigestRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.digest.Unsubscribe(r.Context(), body.Token); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockDigestService struct {
+	MockWatch        func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error
+	MockUnwatch      func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error
+	MockFrequency    func(userId domain.UserId) (domain.DigestFrequency, error)
+	MockSetFrequency func(userId domain.UserId, frequency domain.DigestFrequency) error
+	MockUnsubscribe  func(token string) error
+	MockWatched      func(userId domain.UserId) ([]domain.WatchedThread, error)
+	MockMarkRead     func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, lastReadId domain.MsgId) error
+}
+
+func (m *MockDigestService) Watch(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error {
+	if m.MockWatch != nil {
+		return m.MockWatch(userId, board, threadId)
+	}
+	return nil
+}
+
+func (m *MockDigestService) Unwatch(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error {
+	if m.MockUnwatch != nil {
+		return m.MockUnwatch(userId, board, threadId)
+	}
+	return nil
+}
+
+func (m *MockDigestService) Frequency(ctx context.Context, userId domain.UserId) (domain.DigestFrequency, error) {
+	if m.MockFrequency != nil {
+		return m.MockFrequency(userId)
+	}
+	return domain.DigestOff, nil
+}
+
+func (m *MockDigestService) SetFrequency(ctx context.Context, userId domain.UserId, frequency domain.DigestFrequency) error {
+	if m.MockSetFrequency != nil {
+		return m.MockSetFrequency(userId, frequency)
+	}
+	return nil
+}
+
+func (m *MockDigestService) Unsubscribe(ctx context.Context, token string) error {
+	if m.MockUnsubscribe != nil {
+		return m.MockUnsubscribe(token)
+	}
+	return nil
+}
+
+func (m *MockDigestService) Watched(ctx context.Context, userId domain.UserId) ([]domain.WatchedThread, error) {
+	if m.MockWatched != nil {
+		return m.MockWatched(userId)
+	}
+	return nil, nil
+}
+
+func (m *MockDigestService) MarkRead(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, lastReadId domain.MsgId) error {
+	if m.MockMarkRead != nil {
+		return m.MockMarkRead(userId, board, threadId, lastReadId)
+	}
+	return nil
+}
+
+func setupDigestTestHandler(digestService service.DigestService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		digest: digestService,
+	}
+	router := chi.NewRouter()
+	router.Put("/v1/{board}/{thread}/watch", h.WatchThread)
+	router.Delete("/v1/{board}/{thread}/watch", h.UnwatchThread)
+	router.Get("/v1/me/digest", h.GetDigest)
+	router.Put("/v1/me/digest", h.SetDigest)
+	router.Post("/v1/digest/unsubscribe", h.UnsubscribeDigest)
+	router.Put("/v1/{board}/{thread}/read", h.MarkThreadRead)
+	router.Get("/v1/me/watched", h.GetWatchedThreads)
+
+	return h, router
+}
+
+func TestWatchThreadHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	t.Run("success", func(t *testing.T) {
+		called := false
+		mockService := &MockDigestService{
+			MockWatch: func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error {
+				called = true
+				assert.Equal(t, user.Id, userId)
+				assert.Equal(t, "b", board)
+				assert.Equal(t, domain.ThreadId(3), threadId)
+				return nil
+			},
+		}
+		_, router := setupDigestTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, "/v1/b/3/watch", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("invalid thread ID", func(t *testing.T) {
+		_, router := setupDigestTestHandler(&MockDigestService{})
+
+		req := createRequest(t, http.MethodDelete, "/v1/b/abc/watch", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestDigestSettingsHandlers(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	t.Run("get", func(t *testing.T) {
+		mockService := &MockDigestService{
+			MockFrequency: func(domain.UserId) (domain.DigestFrequency, error) { return domain.DigestDaily, nil },
+		}
+		_, router := setupDigestTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/v1/me/digest", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.DigestResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Equal(t, domain.DigestDaily, resp.Frequency)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		var got domain.DigestFrequency
+		mockService := &MockDigestService{
+			MockSetFrequency: func(userId domain.UserId, frequency domain.DigestFrequency) error {
+				got = frequency
+				return nil
+			},
+		}
+		_, router := setupDigestTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, "/v1/me/digest", []byte(`{"frequency":"weekly"}`))
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, domain.DigestWeekly, got)
+	})
+
+	t.Run("set unknown frequency", func(t *testing.T) {
+		_, router := setupDigestTestHandler(&MockDigestService{})
+
+		req := createRequest(t, http.MethodPut, "/v1/me/digest", []byte(`{"frequency":"hourly"}`))
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestUnsubscribeDigestHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockService := &MockDigestService{
+			MockUnsubscribe: func(token string) error {
+				assert.Equal(t, "tok", token)
+				return nil
+			},
+		}
+		_, router := setupDigestTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/digest/unsubscribe", []byte(`{"token":"tok"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		mockService := &MockDigestService{
+			MockUnsubscribe: func(string) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "Subscription not found", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupDigestTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/digest/unsubscribe", []byte(`{"token":"tok"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestMarkThreadReadHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	t.Run("success", func(t *testing.T) {
+		called := false
+		mockService := &MockDigestService{
+			MockMarkRead: func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, lastReadId domain.MsgId) error {
+				called = true
+				assert.Equal(t, user.Id, userId)
+				assert.Equal(t, domain.ThreadId(3), threadId)
+				assert.Equal(t, domain.MsgId(12), lastReadId)
+				return nil
+			},
+		}
+		_, router := setupDigestTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, "/v1/b/3/read", []byte(`{"last_read_id": 12}`))
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("missing message ID", func(t *testing.T) {
+		_, router := setupDigestTestHandler(&MockDigestService{})
+
+		req := createRequest(t, http.MethodPut, "/v1/b/3/read", []byte(`{}`))
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetWatchedThreadsHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &MockDigestService{
+			MockWatched: func(userId domain.UserId) ([]domain.WatchedThread, error) {
+				assert.Equal(t, user.Id, userId)
+				return []domain.WatchedThread{{Board: "b", ThreadId: 3, LastReadId: 4, Unread: 2}}, nil
+			},
+		}
+		_, router := setupDigestTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/v1/me/watched", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.WatchedThreadsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Threads, 1)
+		assert.Equal(t, 2, resp.Threads[0].Unread)
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		_, router := setupDigestTestHandler(&MockDigestService{})
+
+		req := createRequest(t, http.MethodGet, "/v1/me/watched", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"threads": []}`, rr.Body.String())
+	})
+}
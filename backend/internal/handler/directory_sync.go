@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// SyncDirectory handles POST /v1/admin/directory/sync?dry_run=true&force=true.
+// It answers what the sync did, or with dry_run what it would do.
+func (h *Handler) SyncDirectory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	report, err := h.directorySync.Sync(r.Context(), query.Get("dry_run") == "true", query.Get("force") == "true")
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	writeJSON(w, report)
+}
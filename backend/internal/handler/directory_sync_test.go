@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockDirectorySyncService struct {
+	MockSync func(dryRun, force bool) (domain.DirectorySyncReport, error)
+}
+
+func (m *MockDirectorySyncService) Sync(ctx context.Context, dryRun, force bool) (domain.DirectorySyncReport, error) {
+	if m.MockSync != nil {
+		return m.MockSync(dryRun, force)
+	}
+	return domain.DirectorySyncReport{}, nil
+}
+
+func setupDirectorySyncTestHandler(directorySyncService service.DirectorySyncService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		directorySync: directorySyncService,
+	}
+	router := chi.NewRouter()
+	router.Post("/v1/admin/directory/sync", h.SyncDirectory)
+
+	return h, router
+}
+
+func TestSyncDirectoryHandler(t *testing.T) {
+	t.Run("dry run", func(t *testing.T) {
+		mockService := &MockDirectorySyncService{
+			MockSync: func(dryRun, force bool) (domain.DirectorySyncReport, error) {
+				assert.True(t, dryRun)
+				assert.False(t, force)
+				return domain.DirectorySyncReport{DryRun: true, Listed: 10, Managed: 12, Deactivated: []domain.UserId{3, 4}}, nil
+			},
+		}
+		_, router := setupDirectorySyncTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/directory/sync?dry_run=true", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report domain.DirectorySyncReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.True(t, report.DryRun)
+		assert.Equal(t, []domain.UserId{3, 4}, report.Deactivated)
+	})
+
+	t.Run("guard tripped", func(t *testing.T) {
+		mockService := &MockDirectorySyncService{
+			MockSync: func(dryRun, force bool) (domain.DirectorySyncReport, error) {
+				assert.False(t, dryRun)
+				return domain.DirectorySyncReport{}, &internal_errors.ErrorWithStatusCode{Message: "Directory sync would deactivate 80 accounts", StatusCode: http.StatusConflict}
+			},
+		}
+		_, router := setupDirectorySyncTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/directory/sync", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "80 accounts")
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		mockService := &MockDirectorySyncService{
+			MockSync: func(dryRun, force bool) (domain.DirectorySyncReport, error) {
+				assert.True(t, force)
+				return domain.DirectorySyncReport{}, nil
+			},
+		}
+		_, router := setupDirectorySyncTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/directory/sync?force=true", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
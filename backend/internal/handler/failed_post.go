@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// Payloads past this size are kept as a truncated string, enough to see the
+// start of the text that was refused.
+const maxFailedPostPayload = 64 << 10
+
+// CaptureFailedPost saves a snapshot of a thread or message creation request
+// that was refused as invalid (400, 413, 422) or failed on the server (5xx),
+// when failed_post_capture is enabled. The snapshot is read from the
+// multipart form the handler parsed: the JSON payload without upload tokens
+// and the names, types and sizes of the files, never their contents.
+func (h *Handler) CaptureFailedPost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := mw.GetUserFromContext(r)
+		if h.failedPosts == nil || !h.failedPosts.Enabled() || user == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if !isCapturedFailure(rec.statusCode) {
+			return
+		}
+
+		post := domain.FailedPost{
+			UserId:     user.Id,
+			Board:      chi.URLParam(r, "board"),
+			StatusCode: rec.statusCode,
+			Error:      strings.TrimSpace(rec.body.String()),
+			Request:    failedPostRequest(r),
+		}
+		if threadId, err := strconv.ParseInt(chi.URLParam(r, "thread"), 10, 64); err == nil {
+			post.ThreadId = &threadId
+		}
+
+		// Captured after the response is written, the client may be gone
+		if err := h.failedPosts.Capture(context.WithoutCancel(r.Context()), post); err != nil {
+			logger.Log.Error("failed to capture failed post", "user_id", user.Id, "board", post.Board, "error", err)
+		}
+	})
+}
+
+// GetFailedPosts handles GET /v1/admin/failed_posts
+func (h *Handler) GetFailedPosts(w http.ResponseWriter, r *http.Request) {
+	page := utils.GetPage(r)
+
+	posts, err := h.failedPosts.List(r.Context(), page)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if posts == nil {
+		posts = []domain.FailedPost{}
+	}
+
+	writeJSON(w, api.FailedPostsResponse{Posts: posts, Page: page, Enabled: h.failedPosts.Enabled()})
+}
+
+func isCapturedFailure(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return true
+	}
+	return statusCode >= 500
+}
+
+func failedPostRequest(r *http.Request) domain.FailedPostRequest {
+	req := domain.FailedPostRequest{
+		ContentLength: r.ContentLength,
+		UserAgent:     r.UserAgent(),
+	}
+	// Nil when the body was too large or not a multipart form
+	if r.MultipartForm == nil {
+		return req
+	}
+	if values := r.MultipartForm.Value["json"]; len(values) > 0 {
+		req.Payload, req.UploadTokens = sanitizePostPayload(values[0])
+	}
+	for _, fh := range r.MultipartForm.File["attachments"] {
+		req.Attachments = append(req.Attachments, domain.FailedPostAttachment{
+			Filename:  fh.Filename,
+			MimeType:  fh.Header.Get("Content-Type"),
+			SizeBytes: fh.Size,
+		})
+	}
+	return req
+}
+
+// sanitizePostPayload drops the upload tokens of a message or thread payload,
+// which would let anyone reading the capture claim the uploads, and returns
+// how many there were. Only the sanitized payload is ever kept: over
+// maxFailedPostPayload it is kept as truncated text, and a payload that is
+// not a JSON object, whose tokens can't be found, is replaced by its size.
+func sanitizePostPayload(payload string) (json.RawMessage, int) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal([]byte(payload), &fields) != nil || fields == nil {
+		raw, _ := json.Marshal(fmt.Sprintf("[not a JSON object, %d bytes]", len(payload)))
+		return raw, 0
+	}
+
+	tokens := dropUploadTokens(fields)
+	// A thread's tokens are in its OP message
+	if op, ok := fields["op_message"]; ok {
+		var opFields map[string]json.RawMessage
+		if json.Unmarshal(op, &opFields) == nil {
+			tokens += dropUploadTokens(opFields)
+			fields["op_message"], _ = json.Marshal(opFields)
+		}
+	}
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil, tokens
+	}
+	if len(raw) > maxFailedPostPayload {
+		raw, _ = json.Marshal(truncateUTF8(string(raw), maxFailedPostPayload))
+	}
+	return raw, tokens
+}
+
+func dropUploadTokens(fields map[string]json.RawMessage) int {
+	raw, ok := fields["upload_tokens"]
+	if !ok {
+		return 0
+	}
+	delete(fields, "upload_tokens")
+	var tokens []string
+	if json.Unmarshal(raw, &tokens) != nil {
+		return 0
+	}
+	return len(tokens)
+}
+
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
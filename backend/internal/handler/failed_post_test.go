@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockFailedPostService struct {
+	MockEnabled func() bool
+	MockCapture func(post domain.FailedPost) error
+	MockList    func(page int) ([]domain.FailedPost, error)
+}
+
+func (m *MockFailedPostService) Enabled() bool {
+	if m.MockEnabled != nil {
+		return m.MockEnabled()
+	}
+	return true
+}
+
+func (m *MockFailedPostService) Capture(ctx context.Context, post domain.FailedPost) error {
+	if m.MockCapture != nil {
+		return m.MockCapture(post)
+	}
+	return nil
+}
+
+func (m *MockFailedPostService) List(ctx context.Context, page int) ([]domain.FailedPost, error) {
+	if m.MockList != nil {
+		return m.MockList(page)
+	}
+	return nil, nil
+}
+
+func TestCaptureFailedPost(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	setup := func(failedPosts *MockFailedPostService, status int) *chi.Mux {
+		h := &Handler{failedPosts: failedPosts}
+		router := chi.NewRouter()
+		handle := func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			if status >= 400 {
+				http.Error(w, "Message text is too long", status)
+				return
+			}
+			w.WriteHeader(status)
+		}
+		router.With(h.CaptureFailedPost).Post("/v1/{board}", handle)
+		router.With(h.CaptureFailedPost).Post("/v1/{board}/{thread}", handle)
+		return router
+	}
+	post := func(router *chi.Mux, path, payload string) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		require.NoError(t, form.WriteField("json", payload))
+		file, err := form.CreateFormFile("attachments", "cat.png")
+		require.NoError(t, err)
+		file.Write([]byte("not really a png"))
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, path, &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("User-Agent", "test-agent")
+		req = addUserToContext(req, user)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	t.Run("captures a refused message without its upload tokens", func(t *testing.T) {
+		var captured *domain.FailedPost
+		failedPosts := &MockFailedPostService{
+			MockCapture: func(post domain.FailedPost) error {
+				captured = &post
+				return nil
+			},
+		}
+
+		post(setup(failedPosts, http.StatusBadRequest), "/v1/b/12", `{"text":"hello","upload_tokens":["secret-1","secret-2"]}`)
+
+		require.NotNil(t, captured)
+		assert.Equal(t, user.Id, captured.UserId)
+		assert.Equal(t, "b", captured.Board)
+		require.NotNil(t, captured.ThreadId)
+		assert.Equal(t, domain.ThreadId(12), *captured.ThreadId)
+		assert.Equal(t, http.StatusBadRequest, captured.StatusCode)
+		assert.Equal(t, "Message text is too long", captured.Error)
+		assert.JSONEq(t, `{"text":"hello"}`, string(captured.Request.Payload))
+		assert.Equal(t, 2, captured.Request.UploadTokens)
+		assert.Equal(t, "test-agent", captured.Request.UserAgent)
+		assert.Equal(t, []domain.FailedPostAttachment{{Filename: "cat.png", MimeType: "application/octet-stream", SizeBytes: 16}}, captured.Request.Attachments)
+	})
+
+	t.Run("drops the tokens of a thread's OP message", func(t *testing.T) {
+		var captured *domain.FailedPost
+		failedPosts := &MockFailedPostService{
+			MockCapture: func(post domain.FailedPost) error {
+				captured = &post
+				return nil
+			},
+		}
+
+		post(setup(failedPosts, http.StatusInternalServerError), "/v1/b", `{"title":"t","op_message":{"text":"op","upload_tokens":["secret"]}}`)
+
+		require.NotNil(t, captured)
+		assert.Nil(t, captured.ThreadId)
+		assert.JSONEq(t, `{"title":"t","op_message":{"text":"op"}}`, string(captured.Request.Payload))
+		assert.Equal(t, 1, captured.Request.UploadTokens)
+	})
+
+	t.Run("oversized payload is truncated after its tokens are dropped", func(t *testing.T) {
+		var captured *domain.FailedPost
+		failedPosts := &MockFailedPostService{
+			MockCapture: func(post domain.FailedPost) error {
+				captured = &post
+				return nil
+			},
+		}
+
+		text := strings.Repeat("a", maxFailedPostPayload)
+		post(setup(failedPosts, http.StatusRequestEntityTooLarge), "/v1/b/12", `{"upload_tokens":["secret-1"],"text":"`+text+`"}`)
+
+		require.NotNil(t, captured)
+		var stored string
+		require.NoError(t, json.Unmarshal(captured.Request.Payload, &stored))
+		assert.Len(t, stored, maxFailedPostPayload)
+		assert.NotContains(t, stored, "secret-1")
+		assert.NotContains(t, stored, "upload_tokens")
+		assert.Equal(t, 1, captured.Request.UploadTokens)
+	})
+
+	t.Run("payload that is not a JSON object is not kept", func(t *testing.T) {
+		var captured *domain.FailedPost
+		failedPosts := &MockFailedPostService{
+			MockCapture: func(post domain.FailedPost) error {
+				captured = &post
+				return nil
+			},
+		}
+
+		payload := `{"text":"hello","upload_tokens":["secret-1"]`
+		post(setup(failedPosts, http.StatusBadRequest), "/v1/b/12", payload)
+
+		require.NotNil(t, captured)
+		assert.NotContains(t, string(captured.Request.Payload), "secret-1")
+		assert.JSONEq(t, `"[not a JSON object, 44 bytes]"`, string(captured.Request.Payload))
+	})
+
+	t.Run("does not capture posts that went through or were refused for other reasons", func(t *testing.T) {
+		failedPosts := &MockFailedPostService{
+			MockCapture: func(post domain.FailedPost) error {
+				t.Fatalf("captured a %d response", post.StatusCode)
+				return nil
+			},
+		}
+
+		for _, status := range []int{http.StatusCreated, http.StatusForbidden, http.StatusTooManyRequests} {
+			post(setup(failedPosts, status), "/v1/b/12", `{"text":"hello"}`)
+		}
+	})
+
+	t.Run("does nothing when capture is disabled", func(t *testing.T) {
+		failedPosts := &MockFailedPostService{
+			MockEnabled: func() bool { return false },
+			MockCapture: func(post domain.FailedPost) error {
+				t.Fatal("captured while disabled")
+				return nil
+			},
+		}
+
+		post(setup(failedPosts, http.StatusBadRequest), "/v1/b/12", `{"text":"hello"}`)
+	})
+}
+
+func TestGetFailedPosts(t *testing.T) {
+	t.Run("lists a page of captures", func(t *testing.T) {
+		failedPosts := &MockFailedPostService{
+			MockList: func(page int) ([]domain.FailedPost, error) {
+				assert.Equal(t, 2, page)
+				return []domain.FailedPost{{Id: 3, Board: "b", StatusCode: http.StatusBadRequest}}, nil
+			},
+		}
+		h := &Handler{failedPosts: failedPosts}
+		router := chi.NewRouter()
+		router.Get("/v1/admin/failed_posts", h.GetFailedPosts)
+
+		req := createRequest(t, http.MethodGet, "/v1/admin/failed_posts?page=2", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.FailedPostsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.True(t, resp.Enabled)
+		assert.Equal(t, 2, resp.Page)
+		require.Len(t, resp.Posts, 1)
+		assert.Equal(t, domain.FailedPostId(3), resp.Posts[0].Id)
+	})
+}
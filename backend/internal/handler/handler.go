@@ -7,6 +7,7 @@ import (
 
 	"github.com/itchan-dev/itchan/backend/internal/service"
 	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/itchan-dev/itchan/shared/logger"
 )
 
@@ -15,29 +16,78 @@ type HealthChecker interface {
 	Ping(ctx context.Context) error
 }
 
+// DiskUsageReporter provides the latest disk usage for the admin stats.
+type DiskUsageReporter interface {
+	Usage() domain.DiskUsage
+}
+
 type Handler struct {
-	auth         service.AuthService
-	board        service.BoardService
-	thread       service.ThreadService
-	message      service.MessageService
-	userActivity service.UserActivityService
-	referral     service.ReferralService
-	mediaStorage service.MediaStorage
-	cfg          *config.Config
-	health       HealthChecker
+	auth            service.AuthService
+	board           service.BoardService
+	boardAppearance service.BoardAppearanceService
+	thread          service.ThreadService
+	message         service.MessageService
+	userActivity    service.UserActivityService
+	referral        service.ReferralService
+	siteActivity    service.SiteActivityService
+	appeal          service.AppealService
+	shadowban       service.ShadowbanService
+	viewAs          service.ViewAsService
+	modLog          service.ModLogService
+	notification    service.NotificationService
+	digest          service.DigestService
+	push            service.PushService
+	mediaLookup     service.MediaLookupService
+	boardStats      service.BoardStatsService
+	premod          service.PremodService
+	bookmark        service.BookmarkService
+	mirror          service.MirrorService
+	userImport      service.UserImportService
+	directorySync   service.DirectorySyncService
+	idempotency     service.IdempotencyService
+	maintenance     service.MaintenanceService
+	failedPosts     service.FailedPostService
+	threadSnapshot  service.ThreadSnapshotService
+	backup          service.BackupService
+	mediaStorage    service.MediaStorage
+	cfg             *config.Config
+	health          HealthChecker
+	diskUsage       DiskUsageReporter
 }
 
-func New(auth service.AuthService, board service.BoardService, thread service.ThreadService, message service.MessageService, userActivity service.UserActivityService, referral service.ReferralService, mediaStorage service.MediaStorage, cfg *config.Config, health HealthChecker) *Handler {
+func New(auth service.AuthService, board service.BoardService, boardAppearance service.BoardAppearanceService, thread service.ThreadService, message service.MessageService, userActivity service.UserActivityService, referral service.ReferralService, siteActivity service.SiteActivityService, appeal service.AppealService, shadowban service.ShadowbanService, viewAs service.ViewAsService, modLog service.ModLogService, notification service.NotificationService, digest service.DigestService, push service.PushService, mediaLookup service.MediaLookupService, boardStats service.BoardStatsService, premod service.PremodService, bookmark service.BookmarkService, mirror service.MirrorService, userImport service.UserImportService, directorySync service.DirectorySyncService, idempotency service.IdempotencyService, maintenance service.MaintenanceService, failedPosts service.FailedPostService, threadSnapshot service.ThreadSnapshotService, backup service.BackupService, mediaStorage service.MediaStorage, cfg *config.Config, health HealthChecker, diskUsage DiskUsageReporter) *Handler {
 	return &Handler{
-		auth:         auth,
-		board:        board,
-		thread:       thread,
-		message:      message,
-		userActivity: userActivity,
-		referral:     referral,
-		mediaStorage: mediaStorage,
-		cfg:          cfg,
-		health:       health,
+		auth:            auth,
+		board:           board,
+		boardAppearance: boardAppearance,
+		thread:          thread,
+		message:         message,
+		userActivity:    userActivity,
+		referral:        referral,
+		siteActivity:    siteActivity,
+		appeal:          appeal,
+		shadowban:       shadowban,
+		viewAs:          viewAs,
+		modLog:          modLog,
+		notification:    notification,
+		digest:          digest,
+		push:            push,
+		mediaLookup:     mediaLookup,
+		boardStats:      boardStats,
+		premod:          premod,
+		bookmark:        bookmark,
+		mirror:          mirror,
+		userImport:      userImport,
+		directorySync:   directorySync,
+		idempotency:     idempotency,
+		maintenance:     maintenance,
+		failedPosts:     failedPosts,
+		threadSnapshot:  threadSnapshot,
+		backup:          backup,
+		mediaStorage:    mediaStorage,
+		cfg:             cfg,
+		health:          health,
+		diskUsage:       diskUsage,
 	}
 }
 
@@ -108,6 +108,10 @@ func (m *MockMediaStorage) DeleteBoard(boardID string) error {
 	return nil
 }
 
+func (m *MockMediaStorage) RenameBoard(oldBoardID, newBoardID string) error {
+	return nil
+}
+
 var _ service.MediaStorage = (*MockMediaStorage)(nil)
 
 func TestWriteJSON(t *testing.T) {
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -16,6 +18,8 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 // Ready is a readiness probe endpoint.
 // Returns 200 OK if the server can handle requests (DB is connected).
 // Returns 503 Service Unavailable if dependencies are not ready.
+// With backups configured it also reports the age of the latest successful
+// one, which never fails the probe.
 func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -29,6 +33,18 @@ func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	body := "ok"
+	if h.backup != nil {
+		if state, err := h.backup.Status(ctx); err == nil && state.Enabled {
+			if age := state.LastSuccessAgeSeconds; age != nil {
+				w.Header().Set("X-Backup-Age-Seconds", strconv.FormatInt(*age, 10))
+				body += fmt.Sprintf("\nlast successful backup: %s ago", time.Duration(*age)*time.Second)
+			} else {
+				body += "\nlast successful backup: never"
+			}
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("ok"))
+	w.Write([]byte(body))
 }
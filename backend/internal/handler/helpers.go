@@ -39,6 +39,7 @@ func parseMultipartRequest[T any](w http.ResponseWriter, r *http.Request, h *Han
 			files,
 			h.cfg.Public.AllowedImageMimeTypes,
 			h.cfg.Public.AllowedVideoMimeTypes,
+			h.cfg.Public.AllowedDocumentMimeTypes,
 		)
 		if err != nil {
 			return
@@ -52,6 +53,10 @@ func parseMultipartRequest[T any](w http.ResponseWriter, r *http.Request, h *Han
 				}
 			}
 		}
+		if err = validation.CheckFileContents(pendingFiles, h.denylist()); err != nil {
+			cleanup()
+			return
+		}
 	} else {
 		cleanup = func() {} // No-op if no files
 	}
@@ -59,6 +64,23 @@ func parseMultipartRequest[T any](w http.ResponseWriter, r *http.Request, h *Han
 	return
 }
 
+// denylist returns the file extensions and MIME types refused for any upload.
+func (h *Handler) denylist() validation.Denylist {
+	return validation.Denylist{
+		Extensions: h.cfg.Public.BlockedFileExtensions,
+		MimeTypes:  h.cfg.Public.BlockedMimeTypes,
+	}
+}
+
+// decodeOptionalBody decodes a JSON body that clients may omit entirely, as with
+// DELETE requests. An empty body leaves body untouched.
+func decodeOptionalBody(r *http.Request, body any) error {
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil
+	}
+	return utils.DecodeValidate(r.Body, body)
+}
+
 func parseIntParam(param string, paramName string) (int, error) {
 	val, err := strconv.Atoi(param)
 	if err != nil {
@@ -66,3 +88,18 @@ func parseIntParam(param string, paramName string) (int, error) {
 	}
 	return val, nil
 }
+
+// liteAttachments trims the attachment files of messages to what is needed to
+// show thumbnails when the client asked for a low-bandwidth response (?lite=true).
+func liteAttachments(r *http.Request, messages []*domain.Message) {
+	if r.URL.Query().Get("lite") != "true" {
+		return
+	}
+	for _, msg := range messages {
+		for _, a := range msg.Attachments {
+			if a.File != nil {
+				a.File = a.File.Lite()
+			}
+		}
+	}
+}
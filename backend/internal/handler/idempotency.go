@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/logger"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// responseRecorder passes the response through while keeping a copy, for
+// replays to retries and captures of failed posts.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	rec.statusCode = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// Idempotent makes post creation safe to retry. A request with an
+// Idempotency-Key header reserves the key; once it succeeds, a retry with the
+// same key gets the stored response (marked with Idempotent-Replayed) instead
+// of posting again. A failed request frees the key. It runs before the rate
+// limits, so replays do not count against them.
+func (h *Handler) Idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		user := mw.GetUserFromContext(r)
+		if key == "" || user == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		stored, err := h.idempotency.Begin(r.Context(), user.Id, key, r.URL.Path)
+		if err != nil {
+			utils.WriteErrorAndStatusCode(w, err)
+			return
+		}
+		if stored != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Response)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// The client may be gone already, which is when the stored response matters
+		ctx := context.WithoutCancel(r.Context())
+		if rec.statusCode >= 200 && rec.statusCode < 300 {
+			err = h.idempotency.Finish(ctx, user.Id, key, rec.statusCode, rec.body.Bytes())
+		} else {
+			err = h.idempotency.Abort(ctx, user.Id, key)
+		}
+		if err != nil {
+			logger.Log.Error("failed to settle idempotency key", "user_id", user.Id, "error", err)
+		}
+	})
+}
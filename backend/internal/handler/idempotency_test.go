@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockIdempotencyService struct {
+	MockBegin  func(userId domain.UserId, key, path string) (*domain.IdempotencyKey, error)
+	MockFinish func(userId domain.UserId, key string, statusCode int, response []byte) error
+	MockAbort  func(userId domain.UserId, key string) error
+}
+
+func (m *MockIdempotencyService) Begin(ctx context.Context, userId domain.UserId, key, path string) (*domain.IdempotencyKey, error) {
+	if m.MockBegin != nil {
+		return m.MockBegin(userId, key, path)
+	}
+	return nil, nil
+}
+
+func (m *MockIdempotencyService) Finish(ctx context.Context, userId domain.UserId, key string, statusCode int, response []byte) error {
+	if m.MockFinish != nil {
+		return m.MockFinish(userId, key, statusCode, response)
+	}
+	return nil
+}
+
+func (m *MockIdempotencyService) Abort(ctx context.Context, userId domain.UserId, key string) error {
+	if m.MockAbort != nil {
+		return m.MockAbort(userId, key)
+	}
+	return nil
+}
+
+func TestIdempotent(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	setup := func(idempotency *MockIdempotencyService, status int, calls *int) *chi.Mux {
+		h := &Handler{idempotency: idempotency}
+		router := chi.NewRouter()
+		router.With(h.Idempotent).Post("/v1/{board}/{thread}", func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			w.WriteHeader(status)
+			writeJSON(w, map[string]int{"id": 5})
+		})
+		return router
+	}
+	post := func(router *chi.Mux, key string) *httptest.ResponseRecorder {
+		req := createRequest(t, http.MethodPost, "/v1/b/1", nil)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("stores the response of a successful request", func(t *testing.T) {
+		var finished []byte
+		idempotency := &MockIdempotencyService{
+			MockBegin: func(userId domain.UserId, key, path string) (*domain.IdempotencyKey, error) {
+				assert.Equal(t, user.Id, userId)
+				assert.Equal(t, "retry-1", key)
+				assert.Equal(t, "/v1/b/1", path)
+				return nil, nil
+			},
+			MockFinish: func(userId domain.UserId, key string, statusCode int, response []byte) error {
+				assert.Equal(t, http.StatusCreated, statusCode)
+				finished = response
+				return nil
+			},
+			MockAbort: func(userId domain.UserId, key string) error {
+				t.Fatal("a successful request must not free its key")
+				return nil
+			},
+		}
+		calls := 0
+
+		rr := post(setup(idempotency, http.StatusCreated, &calls), "retry-1")
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, 1, calls)
+		assert.JSONEq(t, `{"id": 5}`, string(finished))
+	})
+
+	t.Run("replays a stored response", func(t *testing.T) {
+		idempotency := &MockIdempotencyService{
+			MockBegin: func(userId domain.UserId, key, path string) (*domain.IdempotencyKey, error) {
+				return &domain.IdempotencyKey{StatusCode: http.StatusCreated, Response: []byte(`{"id": 4}`)}, nil
+			},
+		}
+		calls := 0
+
+		rr := post(setup(idempotency, http.StatusCreated, &calls), "retry-1")
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, 0, calls, "the post is not created again")
+		assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+		assert.JSONEq(t, `{"id": 4}`, rr.Body.String())
+	})
+
+	t.Run("a failed request frees the key", func(t *testing.T) {
+		aborted := false
+		idempotency := &MockIdempotencyService{
+			MockFinish: func(userId domain.UserId, key string, statusCode int, response []byte) error {
+				t.Fatal("failures must not be stored")
+				return nil
+			},
+			MockAbort: func(userId domain.UserId, key string) error {
+				aborted = true
+				return nil
+			},
+		}
+		calls := 0
+
+		rr := post(setup(idempotency, http.StatusBadRequest, &calls), "retry-1")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.True(t, aborted)
+	})
+
+	t.Run("key in use", func(t *testing.T) {
+		idempotency := &MockIdempotencyService{
+			MockBegin: func(userId domain.UserId, key, path string) (*domain.IdempotencyKey, error) {
+				return nil, &internal_errors.ErrorWithStatusCode{Message: "in progress", StatusCode: http.StatusConflict}
+			},
+		}
+		calls := 0
+
+		rr := post(setup(idempotency, http.StatusCreated, &calls), "retry-1")
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("requests without a key are passed through", func(t *testing.T) {
+		idempotency := &MockIdempotencyService{
+			MockBegin: func(userId domain.UserId, key, path string) (*domain.IdempotencyKey, error) {
+				t.Fatal("no key to reserve")
+				return nil, nil
+			},
+		}
+		calls := 0
+
+		rr := post(setup(idempotency, http.StatusCreated, &calls), "")
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, 1, calls)
+	})
+}
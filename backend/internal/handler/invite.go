@@ -21,7 +21,7 @@ func (h *Handler) RegisterWithInvite(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Register and get generated email
-	email, err := h.auth.RegisterWithInvite(req.InviteCode, domain.Password(req.Password), req.RefSource)
+	email, err := h.auth.RegisterWithInvite(r.Context(), req.InviteCode, domain.Password(req.Password), req.RefSource)
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -39,7 +39,7 @@ func (h *Handler) RegisterWithInvite(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GenerateInvite(w http.ResponseWriter, r *http.Request) {
 	user := mw.GetUserFromContext(r)
 
-	invite, err := h.auth.GenerateInvite(*user)
+	invite, err := h.auth.GenerateInvite(r.Context(), *user)
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -58,7 +58,7 @@ func (h *Handler) GetMyInvites(w http.ResponseWriter, r *http.Request) {
 
 	page := utils.GetPage(r)
 
-	invites, err := h.auth.GetUserInvites(user.Id, page)
+	invites, err := h.auth.GetUserInvites(r.Context(), user.Id, page)
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -78,7 +78,7 @@ func (h *Handler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
 	user := mw.GetUserFromContext(r)
 	codeHash := chi.URLParam(r, "codeHash")
 
-	if err := h.auth.RevokeInvite(user.Id, codeHash); err != nil {
+	if err := h.auth.RevokeInvite(r.Context(), user.Id, codeHash); err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
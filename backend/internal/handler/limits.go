@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/middleware/ratelimiter"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetPostLimits handles GET /v1/me/limits. It reports the state of the rate
+// limiters guarding thread and message creation, so clients can count down
+// instead of running into 429s. Admins are never limited.
+func (h *Handler) GetPostLimits(thread, message *ratelimiter.UserRateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var resp api.PostLimitsResponse
+		if user := mw.GetUserFromContext(r); user == nil || !user.Admin {
+			identity, err := mw.GetUserIDFromContext(r)
+			if err != nil {
+				utils.WriteErrorAndStatusCode(w, err)
+				return
+			}
+			resp.ThreadCooldownSeconds = cooldownSeconds(thread.Wait(identity))
+			resp.MessageCooldownSeconds = cooldownSeconds(message.Wait(identity))
+		}
+		writeJSON(w, resp)
+	}
+}
+
+// cooldownSeconds rounds up, so a client waiting that long is always allowed
+func cooldownSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
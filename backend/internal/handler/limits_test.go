@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/middleware/ratelimiter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPostLimits(t *testing.T) {
+	thread := ratelimiter.OncePerMinute()
+	message := ratelimiter.OncePerSecond()
+	defer thread.Stop()
+	defer message.Stop()
+	h := &Handler{}
+
+	get := func(user *domain.User) api.PostLimitsResponse {
+		req := addUserToContext(createRequest(t, http.MethodGet, "/v1/me/limits", nil), user)
+		rr := httptest.NewRecorder()
+		h.GetPostLimits(thread, message)(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.PostLimitsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp
+	}
+
+	user := &domain.User{Id: 5}
+	assert.Equal(t, api.PostLimitsResponse{}, get(user))
+
+	require.True(t, thread.Allow("user_5"))
+	require.True(t, message.Allow("user_5"))
+	resp := get(user)
+	assert.Equal(t, 60, resp.ThreadCooldownSeconds)
+	assert.Equal(t, 1, resp.MessageCooldownSeconds)
+
+	assert.Equal(t, api.PostLimitsResponse{}, get(&domain.User{Id: 6}), "limits are per user")
+	require.True(t, thread.Allow("user_7"))
+	assert.Equal(t, api.PostLimitsResponse{}, get(&domain.User{Id: 7, Admin: true}), "admins are not limited")
+}
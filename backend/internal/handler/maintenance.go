@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// maintenanceExemptPaths stay writable during maintenance: the endpoints that
+// end it and the sign-in endpoints admins need to reach them.
+var maintenanceExemptPaths = map[string]bool{
+	"/v1/admin/maintenance": true,
+	"/v1/auth/login":        true,
+	"/v1/auth/refresh":      true,
+	"/v1/auth/renew":        true,
+}
+
+// ReadOnlyDuringMaintenance refuses writes with 503 while a maintenance window
+// is active. Reads go through, as do the paths in maintenanceExemptPaths.
+func (h *Handler) ReadOnlyDuringMaintenance(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.maintenance == nil || isReadMethod(r.Method) || maintenanceExemptPaths[strings.TrimSuffix(r.URL.Path, "/")] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		window := h.maintenance.Current()
+		now := time.Now().UTC()
+		if window == nil || !window.ActiveAt(now) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if window.EndsAt != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(window.EndsAt.Sub(now).Seconds())+1))
+		}
+		http.Error(w, window.Message, http.StatusServiceUnavailable)
+	})
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// GetMaintenance handles GET /v1/maintenance: the active or upcoming
+// maintenance window, 404 if none is scheduled.
+func (h *Handler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	window := h.maintenance.Current()
+	if window == nil {
+		http.Error(w, "No maintenance is scheduled", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, maintenanceResponse(*window))
+}
+
+// ScheduleMaintenance handles PUT /v1/admin/maintenance, replacing any
+// scheduled window.
+func (h *Handler) ScheduleMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req api.ScheduleMaintenanceRequest
+	if err := utils.DecodeValidate(r.Body, &req); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	admin := mw.GetUserFromContext(r)
+	window := domain.MaintenanceWindow{
+		Message:   strings.TrimSpace(req.Message),
+		EndsAt:    req.EndsAt,
+		CreatedBy: &admin.Id,
+	}
+	if req.StartsAt != nil {
+		window.StartsAt = req.StartsAt.UTC()
+	}
+	if window.EndsAt != nil {
+		endsAt := window.EndsAt.UTC()
+		window.EndsAt = &endsAt
+	}
+
+	window, err := h.maintenance.Schedule(r.Context(), window)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	writeJSON(w, maintenanceResponse(window))
+}
+
+// CancelMaintenance handles DELETE /v1/admin/maintenance, ending an active
+// window early or dropping a scheduled one.
+func (h *Handler) CancelMaintenance(w http.ResponseWriter, r *http.Request) {
+	if err := h.maintenance.Cancel(r.Context()); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func maintenanceResponse(window domain.MaintenanceWindow) api.MaintenanceResponse {
+	return api.MaintenanceResponse{
+		Message:  window.Message,
+		StartsAt: window.StartsAt,
+		EndsAt:   window.EndsAt,
+		Active:   window.ActiveAt(time.Now().UTC()),
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockMaintenanceService struct {
+	MockCurrent  func() *domain.MaintenanceWindow
+	MockSchedule func(window domain.MaintenanceWindow) (domain.MaintenanceWindow, error)
+	MockCancel   func() error
+}
+
+func (m *MockMaintenanceService) Current() *domain.MaintenanceWindow {
+	if m.MockCurrent != nil {
+		return m.MockCurrent()
+	}
+	return nil
+}
+
+func (m *MockMaintenanceService) Schedule(ctx context.Context, window domain.MaintenanceWindow) (domain.MaintenanceWindow, error) {
+	if m.MockSchedule != nil {
+		return m.MockSchedule(window)
+	}
+	return window, nil
+}
+
+func (m *MockMaintenanceService) Cancel(ctx context.Context) error {
+	if m.MockCancel != nil {
+		return m.MockCancel()
+	}
+	return nil
+}
+
+func setupMaintenanceTestHandler(maintenance *MockMaintenanceService) *chi.Mux {
+	h := &Handler{maintenance: maintenance}
+	router := chi.NewRouter()
+	router.Use(h.ReadOnlyDuringMaintenance)
+	router.Get("/v1/maintenance", h.GetMaintenance)
+	router.Put("/v1/admin/maintenance", h.ScheduleMaintenance)
+	router.Delete("/v1/admin/maintenance", h.CancelMaintenance)
+	router.Post("/v1/auth/login", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/v1/b", func(w http.ResponseWriter, r *http.Request) {})
+	router.Post("/v1/b", func(w http.ResponseWriter, r *http.Request) {})
+	return router
+}
+
+func TestReadOnlyDuringMaintenance(t *testing.T) {
+	endsAt := time.Now().Add(time.Hour).UTC()
+	active := &domain.MaintenanceWindow{Message: "Back soon", StartsAt: time.Now().Add(-time.Minute), EndsAt: &endsAt}
+	upcoming := &domain.MaintenanceWindow{Message: "Later", StartsAt: time.Now().Add(time.Hour)}
+
+	serve := func(window *domain.MaintenanceWindow, method, path string) *httptest.ResponseRecorder {
+		router := setupMaintenanceTestHandler(&MockMaintenanceService{
+			MockCurrent: func() *domain.MaintenanceWindow { return window },
+		})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, createRequest(t, method, path, nil))
+		return rr
+	}
+
+	t.Run("writes are refused", func(t *testing.T) {
+		rr := serve(active, http.MethodPost, "/v1/b")
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Back soon")
+		assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("reads continue", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(active, http.MethodGet, "/v1/b").Code)
+	})
+
+	t.Run("sign-in and the maintenance endpoints stay open", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(active, http.MethodPost, "/v1/auth/login").Code)
+		assert.Equal(t, http.StatusOK, serve(active, http.MethodDelete, "/v1/admin/maintenance").Code)
+	})
+
+	t.Run("upcoming window does not block", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(upcoming, http.MethodPost, "/v1/b").Code)
+	})
+
+	t.Run("no window", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(nil, http.MethodPost, "/v1/b").Code)
+	})
+}
+
+func TestGetMaintenanceHandler(t *testing.T) {
+	t.Run("none scheduled", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		setupMaintenanceTestHandler(&MockMaintenanceService{}).ServeHTTP(rr, createRequest(t, http.MethodGet, "/v1/maintenance", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("active window without the admin", func(t *testing.T) {
+		adminId := domain.UserId(1)
+		window := &domain.MaintenanceWindow{Message: "Back soon", StartsAt: time.Now().Add(-time.Minute).UTC(), CreatedBy: &adminId}
+		router := setupMaintenanceTestHandler(&MockMaintenanceService{
+			MockCurrent: func() *domain.MaintenanceWindow { return window },
+		})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, createRequest(t, http.MethodGet, "/v1/maintenance", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "created_by")
+		var resp api.MaintenanceResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "Back soon", resp.Message)
+		assert.True(t, resp.Active)
+		assert.Nil(t, resp.EndsAt)
+	})
+}
+
+func TestScheduleMaintenanceHandler(t *testing.T) {
+	admin := &domain.User{Id: 1, Admin: true}
+	startsAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	endsAt := startsAt.Add(time.Hour)
+
+	router := setupMaintenanceTestHandler(&MockMaintenanceService{
+		MockSchedule: func(window domain.MaintenanceWindow) (domain.MaintenanceWindow, error) {
+			assert.Equal(t, "db upgrade", window.Message)
+			assert.True(t, startsAt.Equal(window.StartsAt))
+			require.NotNil(t, window.EndsAt)
+			assert.True(t, endsAt.Equal(*window.EndsAt))
+			require.NotNil(t, window.CreatedBy)
+			assert.Equal(t, admin.Id, *window.CreatedBy)
+			return window, nil
+		},
+	})
+
+	body, _ := json.Marshal(api.ScheduleMaintenanceRequest{Message: " db upgrade ", StartsAt: &startsAt, EndsAt: &endsAt})
+	req := addUserToContext(createRequest(t, http.MethodPut, "/v1/admin/maintenance", body), admin)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp api.MaintenanceResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.False(t, resp.Active)
+	assert.True(t, startsAt.Equal(resp.StartsAt))
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetMediaLocations handles GET /v1/media/sha256/{hash}, telling the frontend
+// which stored files serve a content-addressed media URL.
+func (h *Handler) GetMediaLocations(w http.ResponseWriter, r *http.Request) {
+	locations, err := h.mediaLookup.Locate(r.Context(), chi.URLParam(r, "hash"), mw.GetUserFromContext(r))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, locations)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockMediaLookupService struct {
+	MockLocate func(sha256 string, viewer *domain.User) ([]domain.MediaLocation, error)
+}
+
+func (m *MockMediaLookupService) Locate(ctx context.Context, sha256 string, viewer *domain.User) ([]domain.MediaLocation, error) {
+	if m.MockLocate != nil {
+		return m.MockLocate(sha256, viewer)
+	}
+	return nil, nil
+}
+
+func setupMediaTestHandler(mediaLookup service.MediaLookupService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		mediaLookup: mediaLookup,
+	}
+	router := chi.NewRouter()
+	router.Get("/v1/media/sha256/{hash}", h.GetMediaLocations)
+
+	return h, router
+}
+
+func TestGetMediaLocationsHandler(t *testing.T) {
+	hash := strings.Repeat("0f", 32)
+
+	t.Run("success", func(t *testing.T) {
+		user := &domain.User{Id: 3}
+		expected := []domain.MediaLocation{{Board: "b", FilePath: "b/1/file.png"}}
+		mockService := &MockMediaLookupService{
+			MockLocate: func(sha256 string, viewer *domain.User) ([]domain.MediaLocation, error) {
+				assert.Equal(t, hash, sha256)
+				assert.Equal(t, user, viewer)
+				return expected, nil
+			},
+		}
+		_, router := setupMediaTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/v1/media/sha256/"+hash, nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []domain.MediaLocation
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := &MockMediaLookupService{
+			MockLocate: func(string, *domain.User) ([]domain.MediaLocation, error) {
+				return nil, &internal_errors.ErrorWithStatusCode{Message: "Media not found", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupMediaTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/v1/media/sha256/"+hash, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -5,9 +5,11 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
 	mw "github.com/itchan-dev/itchan/shared/middleware"
@@ -49,10 +51,15 @@ func (h *Handler) CreateMessage(w http.ResponseWriter, r *http.Request) {
 		Text:            domain.MsgText(body.Text),
 		ShowEmailDomain: body.ShowEmailDomain,
 		PendingFiles:    pendingFiles,
+		UploadTokens:    body.UploadTokens,
 		ReplyTo:         body.ReplyTo,
 	}
 
-	msgId, err := h.message.Create(creation)
+	msgId, err := h.message.Create(r.Context(), creation)
+	if errors.Is(err, service.ErrPostQueued) {
+		writePostQueued(w)
+		return
+	}
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -69,6 +76,57 @@ func (h *Handler) CreateMessage(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, response)
 }
 
+// UploadFile handles POST /v1/:board/uploads, a multipart form with one file in
+// the "file" field. The file is stored before the post is written and the post
+// attaches it by sending the returned token in upload_tokens.
+func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+
+	user := mw.GetUserFromContext(r)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	maxRequestSize := validation.CalculateMaxRequestSize(h.cfg.Public.MaxTotalAttachmentSize, 1<<20)
+	if err := validation.ValidateAndParseMultipart(r, w, maxRequestSize); err != nil {
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) != 1 {
+		http.Error(w, "Expected exactly one file in the file field", http.StatusBadRequest)
+		return
+	}
+	files, err := validation.ValidateAttachments(
+		headers,
+		h.cfg.Public.AllowedImageMimeTypes,
+		h.cfg.Public.AllowedVideoMimeTypes,
+		h.cfg.Public.AllowedDocumentMimeTypes,
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if closer, ok := files[0].Data.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if err := validation.CheckFileContents(files, h.denylist()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	upload, err := h.message.Upload(r.Context(), board, *user, files[0])
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, api.UploadFileResponse{Token: upload.Token, File: upload.File})
+}
+
 func (h *Handler) GetMessage(w http.ResponseWriter, r *http.Request) {
 	board := chi.URLParam(r, "board")
 	threadIdStr := chi.URLParam(r, "thread")
@@ -84,15 +142,61 @@ func (h *Handler) GetMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	msg, err := h.message.Get(board, domain.ThreadId(threadId), domain.MsgId(msgId))
+	msg, err := h.message.Get(r.Context(), board, domain.ThreadId(threadId), domain.MsgId(msgId))
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
 
+	viewer := mw.GetUserFromContext(r)
+	visible, err := h.shadowban.FilterMessage(r.Context(), board, &msg, viewer)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	if !visible {
+		http.Error(w, "Message not found", http.StatusNotFound)
+		return
+	}
+	msg.IsOwn = viewer != nil && msg.Author.Id == viewer.Id
+
 	writeJSON(w, msg)
 }
 
+// ResolvePostNumber maps a board-local post number to the thread and message
+// it belongs to, so clients can follow quote links written as >>N.
+func (h *Handler) ResolvePostNumber(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	postNumber, err := parseIntParam(chi.URLParam(r, "postNumber"), "post number")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	msg, err := h.message.Resolve(r.Context(), board, domain.PostNumber(postNumber))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	hidden, err := h.shadowban.Hides(r.Context(), board, msg.Author.Id, mw.GetUserFromContext(r))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	if hidden {
+		http.Error(w, "Message not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, api.ResolvePostResponse{
+		Board:     msg.Board,
+		ThreadId:  msg.ThreadId,
+		MessageId: msg.Id,
+		Page:      msg.Page,
+	})
+}
+
 func (h *Handler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
 	board := chi.URLParam(r, "board")
 	threadIdStr := chi.URLParam(r, "thread")
@@ -108,10 +212,69 @@ func (h *Handler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.message.Delete(board, domain.ThreadId(threadId), domain.MsgId(msgId)); err != nil {
+	var req api.DeleteContentRequest
+	if err := decodeOptionalBody(r, &req); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	deletion := domain.MessageDeletionData{Reason: req.Reason}
+	if admin := mw.GetUserFromContext(r); admin != nil {
+		deletion.DeletedBy = &admin.Id
+	}
+
+	if err := h.message.Delete(r.Context(), board, domain.ThreadId(threadId), domain.MsgId(msgId), deletion); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteOwnMessage handles DELETE /v1/:board/:thread/:message, an author
+// deleting their own reply within the self-delete window.
+func (h *Handler) DeleteOwnMessage(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	msgId, err := parseIntParam(chi.URLParam(r, "message"), "message ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := mw.GetUserFromContext(r)
+	if err := h.message.DeleteOwn(r.Context(), board, domain.ThreadId(threadId), domain.MsgId(msgId), user.Id); err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// GetMessageFileMetadata handles GET /v1/admin/{board}/{thread}/{message}/metadata,
+// showing moderators the camera details and GPS flag stripped from the attachments.
+func (h *Handler) GetMessageFileMetadata(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	msgId, err := parseIntParam(chi.URLParam(r, "message"), "message ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reports, err := h.message.GetFileMetadata(r.Context(), board, domain.ThreadId(threadId), domain.MsgId(msgId))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, reports)
+}
@@ -54,6 +54,20 @@ func TestProcessAttachments(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid MIME type")
 	})
 
+	t.Run("accepts documents when their types are allowed", func(t *testing.T) {
+		files := createMultipartFiles(t, []fileData{
+			{name: "paper.pdf", content: []byte("fake pdf"), contentType: "application/pdf"},
+			{name: "notes.txt", content: []byte("plain text"), contentType: "text/plain; charset=utf-8"},
+		})
+
+		pendingFiles, err := validation.ValidateAttachments(files, cfg.Public.AllowedImageMimeTypes, []string{"application/pdf", "text/plain"})
+
+		require.NoError(t, err)
+		require.Len(t, pendingFiles, 2)
+		assert.Equal(t, "application/pdf", pendingFiles[0].MimeType)
+		assert.Equal(t, "text/plain", pendingFiles[1].MimeType, "parameters are stripped")
+	})
+
 	t.Run("handles missing content type", func(t *testing.T) {
 		files := createMultipartFiles(t, []fileData{
 			{name: "image.jpg", content: []byte("fake jpeg"), contentType: ""},
@@ -227,3 +241,50 @@ func TestCalculateMaxRequestSize(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckFileContents(t *testing.T) {
+	allowed := []string{"image/jpeg", "image/png", "video/mp4", "text/plain", "image/svg+xml"}
+	denylist := validation.Denylist{Extensions: []string{".svg"}, MimeTypes: []string{"text/html"}}
+	png := []byte("\x89PNG\r\n\x1a\nfake png")
+	check := func(t *testing.T, f fileData) error {
+		t.Helper()
+		pendingFiles, err := validation.ValidateAttachments(createMultipartFiles(t, []fileData{f}), allowed)
+		require.NoError(t, err)
+		return validation.CheckFileContents(pendingFiles, denylist)
+	}
+
+	t.Run("matching content is rewound for the caller", func(t *testing.T) {
+		pendingFiles, err := validation.ValidateAttachments(createMultipartFiles(t, []fileData{
+			{name: "pic.png", content: png, contentType: "image/png"},
+		}), allowed)
+		require.NoError(t, err)
+
+		require.NoError(t, validation.CheckFileContents(pendingFiles, denylist))
+		data, err := io.ReadAll(pendingFiles[0].Data)
+		require.NoError(t, err)
+		assert.Equal(t, png, data)
+	})
+
+	t.Run("content of another type", func(t *testing.T) {
+		err := check(t, fileData{name: "pic.jpg", content: png, contentType: "image/jpeg"})
+		assert.ErrorIs(t, err, validation.ErrContentMismatch)
+	})
+
+	t.Run("html uploaded as text", func(t *testing.T) {
+		err := check(t, fileData{name: "notes.txt", content: []byte("<html><script>alert(1)</script>"), contentType: "text/plain"})
+		assert.ErrorIs(t, err, validation.ErrBlockedFile)
+	})
+
+	t.Run("blocked extension", func(t *testing.T) {
+		err := check(t, fileData{name: "logo.SVG", content: []byte("<svg></svg>"), contentType: "image/svg+xml"})
+		assert.ErrorIs(t, err, validation.ErrBlockedFile)
+	})
+
+	t.Run("formats the sniffer doesn't know are left to the decoders", func(t *testing.T) {
+		mp4 := []byte("\x00\x00\x00\x10ftypisom\x00\x00\x02\x00")
+		assert.NoError(t, check(t, fileData{name: "clip.mp4", content: mp4, contentType: "video/mp4"}))
+
+		err := check(t, fileData{name: "clip.mp4", content: []byte("just text"), contentType: "video/mp4"})
+		assert.ErrorIs(t, err, validation.ErrContentMismatch)
+	})
+}
@@ -2,19 +2,23 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -22,30 +26,67 @@ import (
 type MockMessageService struct {
 	MockCreate func(creationData domain.MessageCreationData) (domain.MsgId, error)
 	MockGet    func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error)
-	MockDelete func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error
+	MockDelete func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error
+
+	MockDeleteOwn       func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, author domain.UserId) error
+	MockGetFileMetadata func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error)
+	MockUpload          func(board domain.BoardShortName, author domain.User, file *domain.PendingFile) (domain.PendingUpload, error)
+	MockResolve         func(board domain.BoardShortName, postNumber domain.PostNumber) (domain.Message, error)
 }
 
-func (m *MockMessageService) Create(creationData domain.MessageCreationData) (domain.MsgId, error) {
+func (m *MockMessageService) Create(ctx context.Context, creationData domain.MessageCreationData) (domain.MsgId, error) {
 	if m.MockCreate != nil {
 		return m.MockCreate(creationData)
 	}
 	return 0, nil
 }
 
-func (m *MockMessageService) Get(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+func (m *MockMessageService) QueueThread(ctx context.Context, creationData domain.MessageCreationData, thread domain.QueuedThread) error {
+	return nil
+}
+
+func (m *MockMessageService) Get(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
 	if m.MockGet != nil {
 		return m.MockGet(board, threadId, id)
 	}
 	return domain.Message{}, nil
 }
 
-func (m *MockMessageService) Delete(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error {
+func (m *MockMessageService) Resolve(ctx context.Context, board domain.BoardShortName, postNumber domain.PostNumber) (domain.Message, error) {
+	if m.MockResolve != nil {
+		return m.MockResolve(board, postNumber)
+	}
+	return domain.Message{}, nil
+}
+
+func (m *MockMessageService) Delete(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
 	if m.MockDelete != nil {
-		return m.MockDelete(board, threadId, id)
+		return m.MockDelete(board, threadId, id, deletion)
 	}
 	return nil
 }
 
+func (m *MockMessageService) DeleteOwn(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, author domain.UserId) error {
+	if m.MockDeleteOwn != nil {
+		return m.MockDeleteOwn(board, threadId, id, author)
+	}
+	return nil
+}
+
+func (m *MockMessageService) GetFileMetadata(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error) {
+	if m.MockGetFileMetadata != nil {
+		return m.MockGetFileMetadata(board, threadId, id)
+	}
+	return []domain.FileMetadataReport{}, nil
+}
+
+func (m *MockMessageService) Upload(ctx context.Context, board domain.BoardShortName, author domain.User, file *domain.PendingFile) (domain.PendingUpload, error) {
+	if m.MockUpload != nil {
+		return m.MockUpload(board, author, file)
+	}
+	return domain.PendingUpload{}, nil
+}
+
 func setupMessageTestHandler(messageService service.MessageService) (*Handler, *chi.Mux) {
 	cfg := &config.Config{
 		Public: config.Public{
@@ -57,13 +98,18 @@ func setupMessageTestHandler(messageService service.MessageService) (*Handler, *
 		},
 	}
 	h := &Handler{
-		message: messageService,
-		cfg:     cfg,
+		message:   messageService,
+		shadowban: &MockShadowbanService{},
+		cfg:       cfg,
 	}
 	router := chi.NewRouter()
+	router.Post("/{board}/uploads", h.UploadFile)
 	router.Post("/{board}/{thread}", h.CreateMessage)
+	router.Get("/{board}/resolve/{postNumber}", h.ResolvePostNumber)
 	router.Get("/{board}/{thread}/{message}", h.GetMessage)
 	router.Delete("/{board}/{thread}/{message}", h.DeleteMessage)
+	router.Delete("/own/{board}/{thread}/{message}", h.DeleteOwnMessage)
+	router.Get("/{board}/{thread}/{message}/metadata", h.GetMessageFileMetadata)
 
 	return h, router
 }
@@ -137,6 +183,31 @@ func TestCreateMessageHandler(t *testing.T) {
 		assert.Equal(t, http.StatusCreated, rr.Code)
 	})
 
+	t.Run("successful request with upload tokens", func(t *testing.T) {
+		mockService := &MockMessageService{
+			MockCreate: func(data domain.MessageCreationData) (domain.MsgId, error) {
+				assert.Equal(t, []domain.UploadToken{"abc", "def"}, data.UploadTokens)
+				assert.Empty(t, data.PendingFiles)
+				return 1, nil
+			},
+		}
+		_, router := setupMessageTestHandler(mockService)
+
+		body := bytes.NewBuffer(nil)
+		writer := multipart.NewWriter(body)
+		writer.WriteField("json", `{"upload_tokens": ["abc", "def"]}`)
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, route, body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req = addUserToContext(req, &user)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
 	t.Run("successful request with show_email_domain", func(t *testing.T) {
 		expectedMsgId := domain.MsgId(123)
 		mockService := &MockMessageService{
@@ -267,6 +338,109 @@ func TestCreateMessageHandler(t *testing.T) {
 	})
 }
 
+func TestUploadFileHandler(t *testing.T) {
+	user := domain.User{Id: 1}
+	uploadRequest := func(t *testing.T, field, contentType string) *http.Request {
+		t.Helper()
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="`+field+`"; filename="pic.png"`)
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		require.NoError(t, err)
+		_, err = part.Write([]byte("\x89PNG\r\n\x1a\nfake png"))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/b/uploads", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return addUserToContext(req, &user)
+	}
+
+	t.Run("returns the token and the stored file", func(t *testing.T) {
+		_, router := setupMessageTestHandler(&MockMessageService{
+			MockUpload: func(board domain.BoardShortName, author domain.User, file *domain.PendingFile) (domain.PendingUpload, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, user, author)
+				assert.Equal(t, "pic.png", file.Filename)
+				assert.Equal(t, "image/png", file.MimeType)
+				return domain.PendingUpload{
+					Token: "tok",
+					File:  &domain.File{FilePath: "b/uploads/pic.png", OriginalFilename: "pic.png"},
+				}, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, uploadRequest(t, "file", "image/png"))
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var resp api.UploadFileResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "tok", resp.Token)
+		require.NotNil(t, resp.File)
+		assert.Equal(t, "b/uploads/pic.png", resp.File.FilePath)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, router := setupMessageTestHandler(&MockMessageService{})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, uploadRequest(t, "attachments", "image/png"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		_, router := setupMessageTestHandler(&MockMessageService{})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, uploadRequest(t, "file", "text/html"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("content that doesn't match its type", func(t *testing.T) {
+		_, router := setupMessageTestHandler(&MockMessageService{
+			MockUpload: func(board domain.BoardShortName, author domain.User, file *domain.PendingFile) (domain.PendingUpload, error) {
+				t.Fatal("Upload should not be called")
+				return domain.PendingUpload{}, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, uploadRequest(t, "file", "image/gif"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "does not match")
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		_, router := setupMessageTestHandler(&MockMessageService{
+			MockUpload: func(board domain.BoardShortName, author domain.User, file *domain.PendingFile) (domain.PendingUpload, error) {
+				return domain.PendingUpload{}, &internal_errors.ErrorWithStatusCode{Message: "uploads are paused", StatusCode: http.StatusInsufficientStorage}
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, uploadRequest(t, "file", "image/png"))
+
+		assert.Equal(t, http.StatusInsufficientStorage, rr.Code)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		_, router := setupMessageTestHandler(&MockMessageService{})
+		req := uploadRequest(t, "file", "image/png")
+		req = req.WithContext(context.Background())
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
 func TestGetMessageHandler(t *testing.T) {
 	board := "b"
 	threadId := domain.ThreadId(123)
@@ -370,6 +544,120 @@ func TestGetMessageHandler(t *testing.T) {
 		assert.Equal(t, "company.com", actualMsg.Author.EmailDomain)
 	})
 
+	t.Run("attachments carry display fields", func(t *testing.T) {
+		width, height := 640, 480
+		thumb := "b/123/thumb_a.jpg"
+		msgWithFile := expectedMessage
+		msgWithFile.Attachments = domain.Attachments{{
+			Id: 1, FileId: 2,
+			File: &domain.File{
+				FileCommonMetadata: domain.FileCommonMetadata{
+					Filename: "a.jpg", SizeBytes: 1024, MimeType: "image/jpeg", ImageWidth: &width, ImageHeight: &height,
+				},
+				FilePath:         "b/123/a.jpg",
+				OriginalFilename: "holiday.jpg",
+				ThumbnailPath:    &thumb,
+				Sha256:           "abc",
+			},
+		}}
+		mockService := &MockMessageService{
+			MockGet: func(board domain.BoardShortName, tid domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+				return msgWithFile, nil
+			},
+		}
+		_, router := setupMessageTestHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, route, nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var raw struct {
+			Attachments []map[string]any
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+		require.Len(t, raw.Attachments, 1)
+		got := raw.Attachments[0]
+		assert.Equal(t, "/media/sha256/abc.jpg", got["url"])
+		assert.Equal(t, "/media/b/123/thumb_a.jpg", got["thumb_url"])
+		assert.Equal(t, "image/jpeg", got["mime"])
+		assert.EqualValues(t, 640, got["width"])
+		assert.EqualValues(t, 480, got["height"])
+		assert.EqualValues(t, 1024, got["size"])
+		assert.Equal(t, "holiday.jpg", got["original_filename"])
+		assert.Contains(t, got, "file", "nested file is kept")
+
+		var decoded domain.Message
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &decoded))
+		assert.Equal(t, msgWithFile, decoded, "the domain type still round-trips")
+	})
+
+	t.Run("hidden by shadowban", func(t *testing.T) {
+		mockService := &MockMessageService{
+			MockGet: func(board domain.BoardShortName, tid domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+				return domain.Message{MessageMetadata: domain.MessageMetadata{Id: msgId, Author: domain.User{Id: 7}}}, nil
+			},
+		}
+		h, router := setupMessageTestHandler(mockService)
+		h.shadowban = &MockShadowbanService{
+			MockFilterMessage: func(b domain.BoardShortName, msg *domain.Message, viewer *domain.User) (bool, error) {
+				assert.Equal(t, board, b)
+				assert.Equal(t, domain.UserId(7), msg.Author.Id)
+				assert.Nil(t, viewer)
+				return false, nil
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, route, nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("replies filtered by shadowban", func(t *testing.T) {
+		mockService := &MockMessageService{
+			MockGet: func(board domain.BoardShortName, tid domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+				return domain.Message{MessageMetadata: domain.MessageMetadata{Id: msgId, Author: domain.User{Id: 7}, Replies: domain.Replies{{From: 2, FromAuthor: 8}, {From: 3, FromAuthor: 9}}}}, nil
+			},
+		}
+		h, router := setupMessageTestHandler(mockService)
+		h.shadowban = &MockShadowbanService{
+			MockFilterMessage: func(b domain.BoardShortName, msg *domain.Message, viewer *domain.User) (bool, error) {
+				msg.Replies = msg.Replies[:1]
+				return true, nil
+			},
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, route, nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var decoded domain.Message
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &decoded))
+		require.Len(t, decoded.Replies, 1)
+		assert.Equal(t, domain.MsgId(2), decoded.Replies[0].From)
+	})
+
+	t.Run("reason from body", func(t *testing.T) {
+		mockService := &MockMessageService{
+			MockDelete: func(b domain.BoardShortName, tid domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
+				assert.Equal(t, "rule 3", deletion.Reason)
+				return nil
+			},
+		}
+		_, router := setupMessageTestHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodDelete, route, bytes.NewBufferString(`{"reason": "rule 3"}`))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
 	t.Run("invalid message id", func(t *testing.T) {
 		_, router := setupMessageTestHandler(&MockMessageService{})
 		badRoute := "/" + board + "/" + threadIdStr + "/abc"
@@ -400,6 +688,53 @@ func TestGetMessageHandler(t *testing.T) {
 	})
 }
 
+func TestResolvePostNumberHandler(t *testing.T) {
+	t.Run("returns the location", func(t *testing.T) {
+		mockService := &MockMessageService{
+			MockResolve: func(board domain.BoardShortName, postNumber domain.PostNumber) (domain.Message, error) {
+				assert.Equal(t, "b", board)
+				assert.Equal(t, domain.PostNumber(42), postNumber)
+				return domain.Message{MessageMetadata: domain.MessageMetadata{Board: board, ThreadId: 7, Id: 3, Page: 1}}, nil
+			},
+		}
+		_, router := setupMessageTestHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/b/resolve/42", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.ResolvePostResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, api.ResolvePostResponse{Board: "b", ThreadId: 7, MessageId: 3, Page: 1}, resp)
+	})
+
+	t.Run("invalid post number", func(t *testing.T) {
+		_, router := setupMessageTestHandler(&MockMessageService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/b/resolve/abc", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := &MockMessageService{
+			MockResolve: func(board domain.BoardShortName, postNumber domain.PostNumber) (domain.Message, error) {
+				return domain.Message{}, &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupMessageTestHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/b/resolve/9", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
 func TestDeleteMessageHandler(t *testing.T) {
 	board := "b"
 	threadId := int64(123)
@@ -410,7 +745,7 @@ func TestDeleteMessageHandler(t *testing.T) {
 
 	t.Run("successful delete", func(t *testing.T) {
 		mockService := &MockMessageService{
-			MockDelete: func(b domain.BoardShortName, tid domain.ThreadId, id domain.MsgId) error {
+			MockDelete: func(b domain.BoardShortName, tid domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
 				assert.Equal(t, domain.BoardShortName(board), b)
 				assert.Equal(t, domain.ThreadId(threadId), tid)
 				assert.Equal(t, domain.MsgId(msgId), id)
@@ -443,7 +778,7 @@ func TestDeleteMessageHandler(t *testing.T) {
 	t.Run("service error", func(t *testing.T) {
 		mockErr := errors.New("permission denied to delete")
 		mockService := &MockMessageService{
-			MockDelete: func(b domain.BoardShortName, tid domain.ThreadId, id domain.MsgId) error {
+			MockDelete: func(b domain.BoardShortName, tid domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
 				return mockErr
 			},
 		}
@@ -457,3 +792,77 @@ func TestDeleteMessageHandler(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	})
 }
+
+func TestDeleteOwnMessageHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	t.Run("passes the author", func(t *testing.T) {
+		mockService := &MockMessageService{
+			MockDeleteOwn: func(b domain.BoardShortName, tid domain.ThreadId, id domain.MsgId, author domain.UserId) error {
+				assert.Equal(t, domain.BoardShortName("b"), b)
+				assert.Equal(t, domain.ThreadId(12), tid)
+				assert.Equal(t, domain.MsgId(3), id)
+				assert.Equal(t, user.Id, author)
+				return nil
+			},
+		}
+		_, router := setupMessageTestHandler(mockService)
+
+		req := addUserToContext(httptest.NewRequest(http.MethodDelete, "/own/b/12/3", nil), user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("service refuses", func(t *testing.T) {
+		mockService := &MockMessageService{
+			MockDeleteOwn: func(b domain.BoardShortName, tid domain.ThreadId, id domain.MsgId, author domain.UserId) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "too late", StatusCode: http.StatusForbidden}
+			},
+		}
+		_, router := setupMessageTestHandler(mockService)
+
+		req := addUserToContext(httptest.NewRequest(http.MethodDelete, "/own/b/12/3", nil), user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), "too late")
+	})
+}
+
+func TestGetMessageFileMetadataHandler(t *testing.T) {
+	t.Run("returns reports as JSON", func(t *testing.T) {
+		mockService := &MockMessageService{
+			MockGetFileMetadata: func(b domain.BoardShortName, tid domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error) {
+				assert.Equal(t, domain.BoardShortName("b"), b)
+				assert.Equal(t, domain.ThreadId(12), tid)
+				assert.Equal(t, domain.MsgId(3), id)
+				return []domain.FileMetadataReport{{FileId: 5, OriginalFilename: "photo.jpg", StrippedMetadata: domain.StrippedMetadata{CameraMake: "Canon", HasGPS: true}}}, nil
+			},
+		}
+		_, router := setupMessageTestHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/b/12/3/metadata", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []domain.FileMetadataReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		require.Len(t, got, 1)
+		assert.Equal(t, "Canon", got[0].CameraMake)
+		assert.True(t, got[0].HasGPS)
+	})
+
+	t.Run("invalid thread id", func(t *testing.T) {
+		_, router := setupMessageTestHandler(&MockMessageService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/b/abc/3/metadata", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
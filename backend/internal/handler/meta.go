@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+)
+
+// GetMeta handles GET /v1/meta: what the instance is and who runs it. Unlike
+// GetPublicConfig the response is a stable format meant for other sites.
+func (h *Handler) GetMeta(w http.ResponseWriter, r *http.Request) {
+	instance := h.cfg.Public.Instance
+	writeJSON(w, api.MetaResponse{
+		Name:         instance.Name,
+		Description:  instance.Description,
+		RulesURL:     instance.RulesURL,
+		AdminContact: instance.AdminContact,
+		Footer:       instance.Footer,
+	})
+}
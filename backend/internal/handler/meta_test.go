@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMeta(t *testing.T) {
+	t.Run("describes the instance", func(t *testing.T) {
+		cfg := &config.Config{Public: config.Public{Instance: config.InstanceConfig{
+			Name:         "Itchan",
+			Description:  "A board",
+			RulesURL:     "https://example.com/rules",
+			AdminContact: "admin@example.com",
+			Footer:       "Posts are [CC BY-SA](https://creativecommons.org/licenses/by-sa/4.0/)",
+		}}}
+		h := &Handler{cfg: cfg}
+
+		rr := httptest.NewRecorder()
+		h.GetMeta(rr, httptest.NewRequest(http.MethodGet, "/v1/meta", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.MetaResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, api.MetaResponse{
+			Name:         "Itchan",
+			Description:  "A board",
+			RulesURL:     "https://example.com/rules",
+			AdminContact: "admin@example.com",
+			Footer:       "Posts are [CC BY-SA](https://creativecommons.org/licenses/by-sa/4.0/)",
+		}, resp)
+	})
+
+	t.Run("unset fields are left out", func(t *testing.T) {
+		h := &Handler{cfg: &config.Config{Public: config.Public{Instance: config.InstanceConfig{Name: "Itchan"}}}}
+
+		rr := httptest.NewRecorder()
+		h.GetMeta(rr, httptest.NewRequest(http.MethodGet, "/v1/meta", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"name": "Itchan"}`, rr.Body.String())
+	})
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetBoardMirrors handles GET /v1/admin/mirrors
+func (h *Handler) GetBoardMirrors(w http.ResponseWriter, r *http.Request) {
+	mirrors, err := h.mirror.List(r.Context())
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	writeJSON(w, api.BoardMirrorsResponse{Mirrors: mirrors})
+}
+
+// CreateBoardMirror handles PUT /v1/admin/:board/mirror. The board must not
+// have threads yet.
+func (h *Handler) CreateBoardMirror(w http.ResponseWriter, r *http.Request) {
+	var body api.CreateBoardMirrorRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	admin := mw.GetUserFromContext(r)
+	err := h.mirror.Create(r.Context(), domain.BoardMirror{
+		Board:       chi.URLParam(r, "board"),
+		SourceURL:   body.SourceURL,
+		SourceBoard: body.SourceBoard,
+		CreatedBy:   admin.Id,
+	})
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// DeleteBoardMirror handles DELETE /v1/admin/:board/mirror
+func (h *Handler) DeleteBoardMirror(w http.ResponseWriter, r *http.Request) {
+	if err := h.mirror.Delete(r.Context(), chi.URLParam(r, "board")); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// SyncBoardMirror handles POST /v1/admin/:board/mirror/sync
+func (h *Handler) SyncBoardMirror(w http.ResponseWriter, r *http.Request) {
+	if err := h.mirror.Sync(r.Context(), chi.URLParam(r, "board")); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockMirrorService struct {
+	MockCreate func(mirror domain.BoardMirror) error
+	MockDelete func(board domain.BoardShortName) error
+	MockList   func() ([]domain.BoardMirror, error)
+	MockSync   func(board domain.BoardShortName) error
+}
+
+func (m *MockMirrorService) Create(ctx context.Context, mirror domain.BoardMirror) error {
+	if m.MockCreate != nil {
+		return m.MockCreate(mirror)
+	}
+	return nil
+}
+
+func (m *MockMirrorService) Delete(ctx context.Context, board domain.BoardShortName) error {
+	if m.MockDelete != nil {
+		return m.MockDelete(board)
+	}
+	return nil
+}
+
+func (m *MockMirrorService) List(ctx context.Context) ([]domain.BoardMirror, error) {
+	if m.MockList != nil {
+		return m.MockList()
+	}
+	return nil, nil
+}
+
+func (m *MockMirrorService) Sync(ctx context.Context, board domain.BoardShortName) error {
+	if m.MockSync != nil {
+		return m.MockSync(board)
+	}
+	return nil
+}
+
+func setupMirrorTestHandler(mirrorService service.MirrorService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		mirror: mirrorService,
+	}
+	router := chi.NewRouter()
+	router.Get("/v1/admin/mirrors", h.GetBoardMirrors)
+	router.Put("/v1/admin/{board}/mirror", h.CreateBoardMirror)
+	router.Delete("/v1/admin/{board}/mirror", h.DeleteBoardMirror)
+	router.Post("/v1/admin/{board}/mirror/sync", h.SyncBoardMirror)
+
+	return h, router
+}
+
+func TestGetBoardMirrorsHandler(t *testing.T) {
+	mockService := &MockMirrorService{
+		MockList: func() ([]domain.BoardMirror, error) {
+			return []domain.BoardMirror{{Board: "b", SourceURL: "https://source.example", SourceBoard: "src", LastError: "timeout"}}, nil
+		},
+	}
+	_, router := setupMirrorTestHandler(mockService)
+
+	req := createRequest(t, http.MethodGet, "/v1/admin/mirrors", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp api.BoardMirrorsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp.Mirrors, 1)
+	assert.Equal(t, domain.BoardShortName("src"), resp.Mirrors[0].SourceBoard)
+	assert.Equal(t, "timeout", resp.Mirrors[0].LastError)
+}
+
+func TestCreateBoardMirrorHandler(t *testing.T) {
+	admin := &domain.User{Id: 1, Admin: true}
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &MockMirrorService{
+			MockCreate: func(mirror domain.BoardMirror) error {
+				assert.Equal(t, "b", mirror.Board)
+				assert.Equal(t, "https://source.example", mirror.SourceURL)
+				assert.Equal(t, "src", mirror.SourceBoard)
+				assert.Equal(t, admin.Id, mirror.CreatedBy)
+				return nil
+			},
+		}
+		_, router := setupMirrorTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, "/v1/admin/b/mirror", []byte(`{"source_url": "https://source.example", "source_board": "src"}`))
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	t.Run("missing source board", func(t *testing.T) {
+		mockService := &MockMirrorService{
+			MockCreate: func(mirror domain.BoardMirror) error {
+				t.Fatal("service must not be called")
+				return nil
+			},
+		}
+		_, router := setupMirrorTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, "/v1/admin/b/mirror", []byte(`{"source_url": "https://source.example"}`))
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("board has threads", func(t *testing.T) {
+		mockService := &MockMirrorService{
+			MockCreate: func(mirror domain.BoardMirror) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "Only a board without threads can become a mirror", StatusCode: http.StatusConflict}
+			},
+		}
+		_, router := setupMirrorTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, "/v1/admin/b/mirror", []byte(`{"source_url": "https://source.example", "source_board": "src"}`))
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+}
+
+func TestDeleteBoardMirrorHandler(t *testing.T) {
+	mockService := &MockMirrorService{
+		MockDelete: func(board domain.BoardShortName) error {
+			return &internal_errors.ErrorWithStatusCode{Message: "Board is not a mirror", StatusCode: http.StatusNotFound}
+		},
+	}
+	_, router := setupMirrorTestHandler(mockService)
+
+	req := createRequest(t, http.MethodDelete, "/v1/admin/b/mirror", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestSyncBoardMirrorHandler(t *testing.T) {
+	var synced domain.BoardShortName
+	mockService := &MockMirrorService{
+		MockSync: func(board domain.BoardShortName) error {
+			synced = board
+			return nil
+		},
+	}
+	_, router := setupMirrorTestHandler(mockService)
+
+	req := createRequest(t, http.MethodPost, "/v1/admin/b/mirror/sync", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "b", synced)
+}
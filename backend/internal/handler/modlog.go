@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetModLog handles GET /v1/:board/modlog
+func (h *Handler) GetModLog(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	page := utils.GetPage(r)
+
+	entries, total, err := h.modLog.List(r.Context(), board, page)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	// If no entries, return empty array instead of null
+	if entries == nil {
+		entries = []domain.ModLogEntry{}
+	}
+
+	writeJSON(w, api.ModLogResponse{Entries: entries, Page: page, Total: total})
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockModLogService struct {
+	MockList func(board domain.BoardShortName, page int) ([]domain.ModLogEntry, int, error)
+}
+
+func (m *MockModLogService) List(ctx context.Context, board domain.BoardShortName, page int) ([]domain.ModLogEntry, int, error) {
+	if m.MockList != nil {
+		return m.MockList(board, page)
+	}
+	return nil, 0, nil
+}
+
+func TestGetModLogHandler(t *testing.T) {
+	setup := func(modLog *MockModLogService) *chi.Mux {
+		h := &Handler{modLog: modLog}
+		router := chi.NewRouter()
+		router.Get("/v1/{board}/modlog", h.GetModLog)
+		return router
+	}
+
+	t.Run("passes board and page", func(t *testing.T) {
+		router := setup(&MockModLogService{
+			MockList: func(board domain.BoardShortName, page int) ([]domain.ModLogEntry, int, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, 2, page)
+				return []domain.ModLogEntry{{Board: "b", Action: domain.ModLogDeleteThread, Reason: "spam"}}, 51, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, createRequest(t, http.MethodGet, "/v1/b/modlog?page=2", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.ModLogResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Entries, 1)
+		assert.Equal(t, "spam", resp.Entries[0].Reason)
+		assert.Equal(t, 2, resp.Page)
+		assert.Equal(t, 51, resp.Total)
+	})
+
+	t.Run("empty log is an array", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		setup(&MockModLogService{}).ServeHTTP(rr, createRequest(t, http.MethodGet, "/v1/b/modlog", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"entries":[]`)
+	})
+
+	t.Run("private log", func(t *testing.T) {
+		router := setup(&MockModLogService{
+			MockList: func(board domain.BoardShortName, page int) ([]domain.ModLogEntry, int, error) {
+				return nil, 0, &internal_errors.ErrorWithStatusCode{Message: "not published", StatusCode: http.StatusNotFound}
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, createRequest(t, http.MethodGet, "/v1/b/modlog", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetNotifications handles GET /v1/me/notifications
+func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUserFromContext(r)
+	page := utils.GetPage(r)
+
+	notifications, total, err := h.notification.List(r.Context(), user.Id, page)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	unread, err := h.notification.UnreadCount(r.Context(), user.Id)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, api.NotificationsResponse{Notifications: notifications, Page: page, Total: total, Unread: unread})
+}
+
+// GetUnreadNotifications handles GET /v1/me/notifications/unread, the count shown in the site header
+func (h *Handler) GetUnreadNotifications(w http.ResponseWriter, r *http.Request) {
+	unread, err := h.notification.UnreadCount(r.Context(), mw.GetUserFromContext(r).Id)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, api.UnreadNotificationsResponse{Unread: unread})
+}
+
+// MarkNotificationRead handles POST /v1/me/notifications/:id/read
+func (h *Handler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.notification.MarkRead(r.Context(), mw.GetUserFromContext(r).Id, id); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// MarkAllNotificationsRead handles POST /v1/me/notifications/read
+func (h *Handler) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	if err := h.notification.MarkAllRead(r.Context(), mw.GetUserFromContext(r).Id); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
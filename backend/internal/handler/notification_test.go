@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockNotificationService struct {
+	MockList        func(userId domain.UserId, page int) ([]domain.Notification, int, error)
+	MockUnreadCount func(userId domain.UserId) (int, error)
+	MockMarkRead    func(userId domain.UserId, id domain.NotificationId) error
+	MockMarkAllRead func(userId domain.UserId) error
+}
+
+func (m *MockNotificationService) List(ctx context.Context, userId domain.UserId, page int) ([]domain.Notification, int, error) {
+	if m.MockList != nil {
+		return m.MockList(userId, page)
+	}
+	return nil, 0, nil
+}
+
+func (m *MockNotificationService) UnreadCount(ctx context.Context, userId domain.UserId) (int, error) {
+	if m.MockUnreadCount != nil {
+		return m.MockUnreadCount(userId)
+	}
+	return 0, nil
+}
+
+func (m *MockNotificationService) MarkRead(ctx context.Context, userId domain.UserId, id domain.NotificationId) error {
+	if m.MockMarkRead != nil {
+		return m.MockMarkRead(userId, id)
+	}
+	return nil
+}
+
+func (m *MockNotificationService) MarkAllRead(ctx context.Context, userId domain.UserId) error {
+	if m.MockMarkAllRead != nil {
+		return m.MockMarkAllRead(userId)
+	}
+	return nil
+}
+
+func setupNotificationTestHandler(notificationService service.NotificationService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		notification: notificationService,
+	}
+	router := chi.NewRouter()
+	router.Get("/v1/me/notifications", h.GetNotifications)
+	router.Get("/v1/me/notifications/unread", h.GetUnreadNotifications)
+	router.Post("/v1/me/notifications/read", h.MarkAllNotificationsRead)
+	router.Post("/v1/me/notifications/{id}/read", h.MarkNotificationRead)
+
+	return h, router
+}
+
+func TestGetNotificationsHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+	mockService := &MockNotificationService{
+		MockList: func(userId domain.UserId, page int) ([]domain.Notification, int, error) {
+			assert.Equal(t, user.Id, userId)
+			assert.Equal(t, 2, page)
+			return []domain.Notification{{Id: 1, Message: domain.LatestPost{Board: "b", ThreadId: 1, Id: 2}}}, 21, nil
+		},
+		MockUnreadCount: func(domain.UserId) (int, error) { return 3, nil },
+	}
+	_, router := setupNotificationTestHandler(mockService)
+
+	req := createRequest(t, http.MethodGet, "/v1/me/notifications?page=2", nil)
+	req = addUserToContext(req, user)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp api.NotificationsResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Len(t, resp.Notifications, 1)
+	assert.Equal(t, 2, resp.Page)
+	assert.Equal(t, 21, resp.Total)
+	assert.Equal(t, 3, resp.Unread)
+}
+
+func TestMarkNotificationReadHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	t.Run("success", func(t *testing.T) {
+		called := false
+		mockService := &MockNotificationService{
+			MockMarkRead: func(userId domain.UserId, id domain.NotificationId) error {
+				called = true
+				assert.Equal(t, user.Id, userId)
+				assert.Equal(t, domain.NotificationId(5), id)
+				return nil
+			},
+		}
+		_, router := setupNotificationTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/me/notifications/5/read", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		_, router := setupNotificationTestHandler(&MockNotificationService{})
+
+		req := createRequest(t, http.MethodPost, "/v1/me/notifications/abc/read", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := &MockNotificationService{
+			MockMarkRead: func(domain.UserId, domain.NotificationId) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "Notification not found", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupNotificationTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/me/notifications/5/read", nil)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestMarkAllNotificationsReadHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+	called := false
+	mockService := &MockNotificationService{
+		MockMarkAllRead: func(userId domain.UserId) error {
+			called = true
+			assert.Equal(t, user.Id, userId)
+			return nil
+		},
+	}
+	_, router := setupNotificationTestHandler(mockService)
+
+	req := createRequest(t, http.MethodPost, "/v1/me/notifications/read", nil)
+	req = addUserToContext(req, user)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, called)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// oEmbedCacheAge is how long (in seconds) consumers may cache a thread preview.
+const oEmbedCacheAge = 3600
+
+// GetThreadOEmbed describes a thread for link unfurling. It is read as an
+// anonymous viewer, so shadowbanned threads are not found.
+func (h *Handler) GetThreadOEmbed(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	thread, err := h.thread.Get(r.Context(), board, domain.ThreadId(threadId), 1, nil)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, h.threadOEmbed(thread))
+}
+
+func (h *Handler) threadOEmbed(thread domain.Thread) api.OEmbedResponse {
+	resp := api.OEmbedResponse{
+		Version:      "1.0",
+		Type:         "link",
+		Title:        fmt.Sprintf("/%s/ - %s", thread.Board, thread.Title),
+		ProviderName: h.cfg.Public.Instance.Name,
+		CacheAge:     oEmbedCacheAge,
+	}
+	if thread.Title == "" {
+		resp.Title = fmt.Sprintf("/%s/ - Thread No.%d", thread.Board, thread.Id)
+	}
+
+	if len(thread.Messages) == 0 {
+		return resp
+	}
+	for _, a := range thread.Messages[0].Attachments {
+		if a.File == nil || a.File.ThumbnailURL() == "" {
+			continue
+		}
+		resp.ThumbnailURL = a.File.ThumbnailURL()
+		resp.ThumbnailWidth, resp.ThumbnailHeight = thumbnailSize(a.File, h.cfg.Public.Media.ThumbnailMaxSize)
+		break
+	}
+	return resp
+}
+
+// thumbnailSize returns the dimensions of a generated thumbnail, which keeps
+// the aspect ratio and fits into maxSize×maxSize.
+func thumbnailSize(f *domain.File, maxSize int) (int, int) {
+	if f.ImageWidth == nil || f.ImageHeight == nil || *f.ImageWidth == 0 || *f.ImageHeight == 0 {
+		return maxSize, maxSize
+	}
+	w, h := *f.ImageWidth, *f.ImageHeight
+	if w <= maxSize && h <= maxSize {
+		return w, h
+	}
+	if w > h {
+		return maxSize, h * maxSize / w
+	}
+	return w * maxSize / h, maxSize
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupOEmbedTestHandler(threadService *MockThreadService) *chi.Mux {
+	cfg := &config.Config{Public: config.Public{Media: config.MediaConfig{ThumbnailMaxSize: 200}}}
+	h := &Handler{thread: threadService, cfg: cfg}
+	router := chi.NewRouter()
+	router.Get("/{board}/{thread}/oembed", h.GetThreadOEmbed)
+	return router
+}
+
+func TestGetThreadOEmbedHandler(t *testing.T) {
+	t.Run("thread with image", func(t *testing.T) {
+		thumb := "b/1/thumb.jpg"
+		width, height := 800, 400
+		service := &MockThreadService{
+			MockGet: func(board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error) {
+				assert.Equal(t, "b", board)
+				assert.Equal(t, domain.ThreadId(1), id)
+				assert.Equal(t, 1, page)
+				assert.Nil(t, viewer, "previews are built for anonymous viewers")
+				return domain.Thread{
+					ThreadMetadata: domain.ThreadMetadata{Id: 1, Board: "b", Title: "Hello"},
+					Messages: []*domain.Message{{
+						Attachments: domain.Attachments{{File: &domain.File{
+							FileCommonMetadata: domain.FileCommonMetadata{ImageWidth: &width, ImageHeight: &height},
+							ThumbnailPath:      &thumb,
+						}}},
+					}},
+				}, nil
+			},
+		}
+
+		rr := httptest.NewRecorder()
+		setupOEmbedTestHandler(service).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/b/1/oembed", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.OEmbedResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Equal(t, "1.0", resp.Version)
+		assert.Equal(t, "link", resp.Type)
+		assert.Equal(t, "/b/ - Hello", resp.Title)
+		assert.Equal(t, "/media/b/1/thumb.jpg", resp.ThumbnailURL)
+		assert.Equal(t, 200, resp.ThumbnailWidth)
+		assert.Equal(t, 100, resp.ThumbnailHeight)
+	})
+
+	t.Run("untitled thread without attachments", func(t *testing.T) {
+		service := &MockThreadService{
+			MockGet: func(board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error) {
+				return domain.Thread{
+					ThreadMetadata: domain.ThreadMetadata{Id: 7, Board: "b"},
+					Messages:       []*domain.Message{{}},
+				}, nil
+			},
+		}
+
+		rr := httptest.NewRecorder()
+		setupOEmbedTestHandler(service).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/b/7/oembed", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.OEmbedResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Equal(t, "/b/ - Thread No.7", resp.Title)
+		assert.Empty(t, resp.ThumbnailURL)
+	})
+
+	t.Run("thread not found", func(t *testing.T) {
+		service := &MockThreadService{
+			MockGet: func(board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error) {
+				return domain.Thread{}, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+			},
+		}
+
+		rr := httptest.NewRecorder()
+		setupOEmbedTestHandler(service).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/b/1/oembed", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("invalid thread id", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		setupOEmbedTestHandler(&MockThreadService{}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/b/abc/oembed", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// postQueuedMessage tells the author where their post went on a board with pre-moderation
+const postQueuedMessage = "Your post is awaiting moderator approval"
+
+// writePostQueued answers a create request whose post was held for pre-moderation.
+func writePostQueued(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusAccepted)
+	writeJSON(w, api.PostQueuedResponse{Pending: true, Message: postQueuedMessage})
+}
+
+// GetPremodQueue handles GET /v1/admin/:board/premod
+func (h *Handler) GetPremodQueue(w http.ResponseWriter, r *http.Request) {
+	queue, err := h.premod.Queue(r.Context(), chi.URLParam(r, "board"))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	writeJSON(w, api.PremodQueueResponse{Posts: queue.Posts, Posters: queue.Posters})
+}
+
+// ApproveQueuedPost handles POST /v1/admin/:board/premod/:postId/approve
+func (h *Handler) ApproveQueuedPost(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	postId, err := strconv.ParseInt(chi.URLParam(r, "postId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	var body api.ApproveQueuedPostRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	admin := mw.GetUserFromContext(r)
+	approved, err := h.premod.Approve(r.Context(), board, postId, admin.Id, body.Trust)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, api.ApproveQueuedPostResponse{ThreadId: approved.ThreadId, Id: approved.Id})
+}
+
+// RejectQueuedPost handles POST /v1/admin/:board/premod/:postId/reject
+func (h *Handler) RejectQueuedPost(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	postId, err := strconv.ParseInt(chi.URLParam(r, "postId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	admin := mw.GetUserFromContext(r)
+	if err := h.premod.Reject(r.Context(), board, postId, admin.Id); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SetPosterTrust handles PUT /v1/admin/:board/premod/posters/:userId/trust
+func (h *Handler) SetPosterTrust(w http.ResponseWriter, r *http.Request) {
+	var body api.SetPosterTrustRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	h.setPosterTrust(w, r, body.Trusted)
+}
+
+// ClearPosterTrust handles DELETE /v1/admin/:board/premod/posters/:userId/trust
+func (h *Handler) ClearPosterTrust(w http.ResponseWriter, r *http.Request) {
+	h.setPosterTrust(w, r, nil)
+}
+
+func (h *Handler) setPosterTrust(w http.ResponseWriter, r *http.Request, trusted *bool) {
+	board := chi.URLParam(r, "board")
+	userId, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	admin := mw.GetUserFromContext(r)
+	if err := h.premod.SetTrust(r.Context(), board, userId, trusted, admin.Id); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
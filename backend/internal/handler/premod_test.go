@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPremodService struct {
+	MockQueue    func(board domain.BoardShortName) (domain.PremodQueue, error)
+	MockApprove  func(board domain.BoardShortName, id domain.QueuedPostId, moderator domain.UserId, trust bool) (domain.ApprovedPost, error)
+	MockReject   func(board domain.BoardShortName, id domain.QueuedPostId, moderator domain.UserId) error
+	MockSetTrust func(board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error
+}
+
+func (m *MockPremodService) Queue(ctx context.Context, board domain.BoardShortName) (domain.PremodQueue, error) {
+	if m.MockQueue != nil {
+		return m.MockQueue(board)
+	}
+	return domain.PremodQueue{Board: board}, nil
+}
+
+func (m *MockPremodService) Approve(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId, moderator domain.UserId, trust bool) (domain.ApprovedPost, error) {
+	if m.MockApprove != nil {
+		return m.MockApprove(board, id, moderator, trust)
+	}
+	return domain.ApprovedPost{}, nil
+}
+
+func (m *MockPremodService) Reject(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId, moderator domain.UserId) error {
+	if m.MockReject != nil {
+		return m.MockReject(board, id, moderator)
+	}
+	return nil
+}
+
+func (m *MockPremodService) SetTrust(ctx context.Context, board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error {
+	if m.MockSetTrust != nil {
+		return m.MockSetTrust(board, userId, trusted, moderator)
+	}
+	return nil
+}
+
+func setupPremodTestHandler(premodService service.PremodService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		premod: premodService,
+	}
+	router := chi.NewRouter()
+	router.Get("/v1/admin/{board}/premod", h.GetPremodQueue)
+	router.Post("/v1/admin/{board}/premod/{postId}/approve", h.ApproveQueuedPost)
+	router.Post("/v1/admin/{board}/premod/{postId}/reject", h.RejectQueuedPost)
+	router.Put("/v1/admin/{board}/premod/posters/{userId}/trust", h.SetPosterTrust)
+	router.Delete("/v1/admin/{board}/premod/posters/{userId}/trust", h.ClearPosterTrust)
+
+	return h, router
+}
+
+func TestCreateMessageHandler_Queued(t *testing.T) {
+	mockService := &MockMessageService{
+		MockCreate: func(data domain.MessageCreationData) (domain.MsgId, error) {
+			return 0, service.ErrPostQueued
+		},
+	}
+	_, router := setupMessageTestHandler(mockService)
+
+	body := bytes.NewBuffer(nil)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("json", `{"text": "test text"}`)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/b/1", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req = addUserToContext(req, &domain.User{Id: 1})
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var resp api.PostQueuedResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.True(t, resp.Pending)
+	assert.Equal(t, postQueuedMessage, resp.Message)
+}
+
+func TestGetPremodQueueHandler(t *testing.T) {
+	mockService := &MockPremodService{
+		MockQueue: func(board domain.BoardShortName) (domain.PremodQueue, error) {
+			assert.Equal(t, "b", board)
+			return domain.PremodQueue{
+				Board:   board,
+				Posts:   []domain.QueuedPost{{Id: 3, Text: "held"}},
+				Posters: []domain.PremodPoster{{UserId: 5, Approvals: 2}},
+			}, nil
+		},
+	}
+	_, router := setupPremodTestHandler(mockService)
+
+	req := createRequest(t, http.MethodGet, "/v1/admin/b/premod", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp api.PremodQueueResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp.Posts, 1)
+	assert.Equal(t, domain.QueuedPostId(3), resp.Posts[0].Id)
+	require.Len(t, resp.Posters, 1)
+	assert.Equal(t, 2, resp.Posters[0].Approvals)
+}
+
+func TestApproveQueuedPostHandler(t *testing.T) {
+	admin := &domain.User{Id: 1, Admin: true}
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &MockPremodService{
+			MockApprove: func(board domain.BoardShortName, id domain.QueuedPostId, moderator domain.UserId, trust bool) (domain.ApprovedPost, error) {
+				assert.Equal(t, "b", board)
+				assert.Equal(t, domain.QueuedPostId(3), id)
+				assert.Equal(t, admin.Id, moderator)
+				assert.True(t, trust)
+				return domain.ApprovedPost{ThreadId: 10, Id: 11, AuthorId: 5}, nil
+			},
+		}
+		_, router := setupPremodTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/b/premod/3/approve", []byte(`{"trust": true}`))
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.ApproveQueuedPostResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, domain.ThreadId(10), resp.ThreadId)
+		assert.Equal(t, domain.MsgId(11), resp.Id)
+	})
+
+	t.Run("invalid post ID", func(t *testing.T) {
+		_, router := setupPremodTestHandler(&MockPremodService{})
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/b/premod/abc/approve", []byte(`{}`))
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := &MockPremodService{
+			MockApprove: func(domain.BoardShortName, domain.QueuedPostId, domain.UserId, bool) (domain.ApprovedPost, error) {
+				return domain.ApprovedPost{}, &internal_errors.ErrorWithStatusCode{Message: "Queued post not found", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupPremodTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/b/premod/3/approve", []byte(`{}`))
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestRejectQueuedPostHandler(t *testing.T) {
+	admin := &domain.User{Id: 1, Admin: true}
+	called := false
+	mockService := &MockPremodService{
+		MockReject: func(board domain.BoardShortName, id domain.QueuedPostId, moderator domain.UserId) error {
+			called = true
+			assert.Equal(t, domain.QueuedPostId(3), id)
+			assert.Equal(t, admin.Id, moderator)
+			return nil
+		},
+	}
+	_, router := setupPremodTestHandler(mockService)
+
+	req := createRequest(t, http.MethodPost, "/v1/admin/b/premod/3/reject", nil)
+	req = addUserToContext(req, admin)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, called)
+}
+
+func TestSetPosterTrustHandler(t *testing.T) {
+	admin := &domain.User{Id: 1, Admin: true}
+
+	t.Run("hold", func(t *testing.T) {
+		called := false
+		mockService := &MockPremodService{
+			MockSetTrust: func(board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error {
+				called = true
+				assert.Equal(t, "b", board)
+				assert.Equal(t, domain.UserId(42), userId)
+				require.NotNil(t, trusted)
+				assert.False(t, *trusted)
+				assert.Equal(t, admin.Id, moderator)
+				return nil
+			},
+		}
+		_, router := setupPremodTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPut, "/v1/admin/b/premod/posters/42/trust", []byte(`{"trusted": false}`))
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("trusted is required", func(t *testing.T) {
+		_, router := setupPremodTestHandler(&MockPremodService{})
+
+		req := createRequest(t, http.MethodPut, "/v1/admin/b/premod/posters/42/trust", []byte(`{}`))
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid user ID", func(t *testing.T) {
+		_, router := setupPremodTestHandler(&MockPremodService{})
+
+		req := createRequest(t, http.MethodPut, "/v1/admin/b/premod/posters/abc/trust", []byte(`{"trusted": true}`))
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestClearPosterTrustHandler(t *testing.T) {
+	admin := &domain.User{Id: 1, Admin: true}
+
+	t.Run("success", func(t *testing.T) {
+		called := false
+		mockService := &MockPremodService{
+			MockSetTrust: func(board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error {
+				called = true
+				assert.Equal(t, domain.UserId(42), userId)
+				assert.Nil(t, trusted)
+				return nil
+			},
+		}
+		_, router := setupPremodTestHandler(mockService)
+
+		req := createRequest(t, http.MethodDelete, "/v1/admin/b/premod/posters/42/trust", nil)
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("no override", func(t *testing.T) {
+		mockService := &MockPremodService{
+			MockSetTrust: func(domain.BoardShortName, domain.UserId, *bool, domain.UserId) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "User has no trust override on this board", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupPremodTestHandler(mockService)
+
+		req := createRequest(t, http.MethodDelete, "/v1/admin/b/premod/posters/42/trust", nil)
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// SubscribePush handles POST /v1/me/push_subscriptions
+func (h *Handler) SubscribePush(w http.ResponseWriter, r *http.Request) {
+	var body api.PushSubscriptionRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	sub := domain.PushSubscription{
+		UserId:   mw.GetUserFromContext(r).Id,
+		Endpoint: body.Endpoint,
+		P256dh:   body.Keys.P256dh,
+		Auth:     body.Keys.Auth,
+	}
+	if err := h.push.Subscribe(r.Context(), sub); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// UnsubscribePush handles DELETE /v1/me/push_subscriptions
+func (h *Handler) UnsubscribePush(w http.ResponseWriter, r *http.Request) {
+	var body api.DeletePushSubscriptionRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.push.Unsubscribe(r.Context(), mw.GetUserFromContext(r).Id, body.Endpoint); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
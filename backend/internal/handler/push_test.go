@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockPushService struct {
+	MockSubscribe   func(sub domain.PushSubscription) error
+	MockUnsubscribe func(userId domain.UserId, endpoint string) error
+}
+
+func (m *MockPushService) Subscribe(ctx context.Context, sub domain.PushSubscription) error {
+	if m.MockSubscribe != nil {
+		return m.MockSubscribe(sub)
+	}
+	return nil
+}
+
+func (m *MockPushService) Unsubscribe(ctx context.Context, userId domain.UserId, endpoint string) error {
+	if m.MockUnsubscribe != nil {
+		return m.MockUnsubscribe(userId, endpoint)
+	}
+	return nil
+}
+
+func setupPushTestHandler(pushService service.PushService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		push: pushService,
+	}
+	router := chi.NewRouter()
+	router.Post("/v1/me/push_subscriptions", h.SubscribePush)
+	router.Delete("/v1/me/push_subscriptions", h.UnsubscribePush)
+
+	return h, router
+}
+
+func TestSubscribePushHandler(t *testing.T) {
+	user := &domain.User{Id: 7}
+
+	t.Run("success", func(t *testing.T) {
+		var got domain.PushSubscription
+		mockService := &MockPushService{
+			MockSubscribe: func(sub domain.PushSubscription) error {
+				got = sub
+				return nil
+			},
+		}
+		_, router := setupPushTestHandler(mockService)
+
+		body := []byte(`{"endpoint":"https://push.example.com/abc","expirationTime":null,"keys":{"p256dh":"key","auth":"secret"}}`)
+		req := createRequest(t, http.MethodPost, "/v1/me/push_subscriptions", body)
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, domain.PushSubscription{UserId: 7, Endpoint: "https://push.example.com/abc", P256dh: "key", Auth: "secret"}, got)
+	})
+
+	t.Run("missing keys", func(t *testing.T) {
+		_, router := setupPushTestHandler(&MockPushService{})
+
+		req := createRequest(t, http.MethodPost, "/v1/me/push_subscriptions", []byte(`{"endpoint":"https://push.example.com/abc"}`))
+		req = addUserToContext(req, user)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestUnsubscribePushHandler(t *testing.T) {
+	called := false
+	mockService := &MockPushService{
+		MockUnsubscribe: func(userId domain.UserId, endpoint string) error {
+			called = true
+			assert.Equal(t, domain.UserId(7), userId)
+			assert.Equal(t, "https://push.example.com/abc", endpoint)
+			return nil
+		},
+	}
+	_, router := setupPushTestHandler(mockService)
+
+	req := createRequest(t, http.MethodDelete, "/v1/me/push_subscriptions", []byte(`{"endpoint":"https://push.example.com/abc"}`))
+	req = addUserToContext(req, &domain.User{Id: 7})
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, called)
+}
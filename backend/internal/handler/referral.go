@@ -17,7 +17,7 @@ func (h *Handler) RecordReferralAction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ip, _ := utils.GetIP(r)
-	if err := h.referral.RecordAction(req.Source, req.Action, ip); err != nil {
+	if err := h.referral.RecordAction(r.Context(), req.Source, req.Action, ip); err != nil {
 		http.Error(w, "Failed to record action", http.StatusInternalServerError)
 		return
 	}
@@ -27,7 +27,7 @@ func (h *Handler) RecordReferralAction(w http.ResponseWriter, r *http.Request) {
 
 // GetReferralStats handles GET /v1/admin/referral/stats
 func (h *Handler) GetReferralStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.referral.GetStats()
+	stats, err := h.referral.GetStats(r.Context())
 	if err != nil {
 		http.Error(w, "Failed to get referral stats", http.StatusInternalServerError)
 		return
@@ -39,3 +39,17 @@ func (h *Handler) GetReferralStats(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, stats)
 }
+
+// GetAdminStats handles GET /v1/admin/stats
+func (h *Handler) GetAdminStats(w http.ResponseWriter, r *http.Request) {
+	stats := domain.AdminStats{Disk: h.diskUsage.Usage()}
+	if h.backup != nil {
+		backup, err := h.backup.Status(r.Context())
+		if err != nil {
+			utils.WriteErrorAndStatusCode(w, err)
+			return
+		}
+		stats.Backup = backup
+	}
+	writeJSON(w, stats)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// ShadowbanUser handles POST /v1/admin/:board/users/:userId/shadowban
+func (h *Handler) ShadowbanUser(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	userId, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	admin := mw.GetUserFromContext(r)
+	if err := h.shadowban.Shadowban(r.Context(), board, userId, admin.Id); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// UnshadowbanUser handles DELETE /v1/admin/:board/users/:userId/shadowban
+func (h *Handler) UnshadowbanUser(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	userId, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.shadowban.Unshadowban(r.Context(), board, userId); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetShadowbans handles GET /v1/admin/shadowbans
+func (h *Handler) GetShadowbans(w http.ResponseWriter, r *http.Request) {
+	page := utils.GetPage(r)
+
+	shadowbans, err := h.shadowban.List(r.Context(), page)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	// If no shadowbans, return empty array instead of null
+	if shadowbans == nil {
+		shadowbans = []domain.Shadowban{}
+	}
+
+	writeJSON(w, api.ShadowbansResponse{Shadowbans: shadowbans, Page: page})
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockShadowbanService struct {
+	MockShadowban     func(board domain.BoardShortName, userId, moderator domain.UserId) error
+	MockUnshadowban   func(board domain.BoardShortName, userId domain.UserId) error
+	MockList          func(page int) ([]domain.Shadowban, error)
+	MockHides         func(board domain.BoardShortName, author domain.UserId, viewer *domain.User) (bool, error)
+	MockFilterMessage func(board domain.BoardShortName, msg *domain.Message, viewer *domain.User) (bool, error)
+}
+
+func (m *MockShadowbanService) Shadowban(ctx context.Context, board domain.BoardShortName, userId, moderator domain.UserId) error {
+	if m.MockShadowban != nil {
+		return m.MockShadowban(board, userId, moderator)
+	}
+	return nil
+}
+
+func (m *MockShadowbanService) Unshadowban(ctx context.Context, board domain.BoardShortName, userId domain.UserId) error {
+	if m.MockUnshadowban != nil {
+		return m.MockUnshadowban(board, userId)
+	}
+	return nil
+}
+
+func (m *MockShadowbanService) List(ctx context.Context, page int) ([]domain.Shadowban, error) {
+	if m.MockList != nil {
+		return m.MockList(page)
+	}
+	return nil, nil
+}
+
+func (m *MockShadowbanService) Hides(ctx context.Context, board domain.BoardShortName, author domain.UserId, viewer *domain.User) (bool, error) {
+	if m.MockHides != nil {
+		return m.MockHides(board, author, viewer)
+	}
+	return false, nil
+}
+
+func (m *MockShadowbanService) FilterMessage(ctx context.Context, board domain.BoardShortName, msg *domain.Message, viewer *domain.User) (bool, error) {
+	if m.MockFilterMessage != nil {
+		return m.MockFilterMessage(board, msg, viewer)
+	}
+	return true, nil
+}
+
+func setupShadowbanTestHandler(shadowbanService service.ShadowbanService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		shadowban: shadowbanService,
+	}
+	router := chi.NewRouter()
+	router.Post("/v1/admin/{board}/users/{userId}/shadowban", h.ShadowbanUser)
+	router.Delete("/v1/admin/{board}/users/{userId}/shadowban", h.UnshadowbanUser)
+	router.Get("/v1/admin/shadowbans", h.GetShadowbans)
+
+	return h, router
+}
+
+func TestShadowbanUserHandler(t *testing.T) {
+	admin := &domain.User{Id: 1, Admin: true}
+
+	t.Run("success", func(t *testing.T) {
+		called := false
+		mockService := &MockShadowbanService{
+			MockShadowban: func(board domain.BoardShortName, userId, moderator domain.UserId) error {
+				called = true
+				assert.Equal(t, "b", board)
+				assert.Equal(t, domain.UserId(42), userId)
+				assert.Equal(t, admin.Id, moderator)
+				return nil
+			},
+		}
+		_, router := setupShadowbanTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/b/users/42/shadowban", nil)
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("invalid user ID", func(t *testing.T) {
+		_, router := setupShadowbanTestHandler(&MockShadowbanService{})
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/b/users/abc/shadowban", nil)
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &MockShadowbanService{
+			MockShadowban: func(domain.BoardShortName, domain.UserId, domain.UserId) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "Board or user not found", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupShadowbanTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/b/users/42/shadowban", nil)
+		req = addUserToContext(req, admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestUnshadowbanUserHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		called := false
+		mockService := &MockShadowbanService{
+			MockUnshadowban: func(board domain.BoardShortName, userId domain.UserId) error {
+				called = true
+				assert.Equal(t, "b", board)
+				assert.Equal(t, domain.UserId(42), userId)
+				return nil
+			},
+		}
+		_, router := setupShadowbanTestHandler(mockService)
+
+		req := createRequest(t, http.MethodDelete, "/v1/admin/b/users/42/shadowban", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("not shadowbanned", func(t *testing.T) {
+		mockService := &MockShadowbanService{
+			MockUnshadowban: func(domain.BoardShortName, domain.UserId) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "User is not shadowbanned on this board", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupShadowbanTestHandler(mockService)
+
+		req := createRequest(t, http.MethodDelete, "/v1/admin/b/users/42/shadowban", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestGetShadowbansHandler(t *testing.T) {
+	t.Run("passes page", func(t *testing.T) {
+		mockService := &MockShadowbanService{
+			MockList: func(page int) ([]domain.Shadowban, error) {
+				assert.Equal(t, 2, page)
+				return []domain.Shadowban{{Board: "b", UserId: 42}}, nil
+			},
+		}
+		_, router := setupShadowbanTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/v1/admin/shadowbans?page=2", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.ShadowbansResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Shadowbans, 1)
+		assert.Equal(t, domain.UserId(42), resp.Shadowbans[0].UserId)
+		assert.Equal(t, 2, resp.Page)
+	})
+
+	t.Run("empty list is an array", func(t *testing.T) {
+		_, router := setupShadowbanTestHandler(&MockShadowbanService{})
+
+		req := createRequest(t, http.MethodGet, "/v1/admin/shadowbans", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"shadowbans":[]`)
+	})
+}
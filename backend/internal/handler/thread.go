@@ -2,11 +2,14 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
 	mw "github.com/itchan-dev/itchan/shared/middleware"
 	"github.com/itchan-dev/itchan/shared/utils"
 	"github.com/itchan-dev/itchan/shared/validation"
@@ -35,16 +38,22 @@ func (h *Handler) CreateThread(w http.ResponseWriter, r *http.Request) {
 		Title:    domain.ThreadTitle(body.Title),
 		Board:    board,
 		IsPinned: body.IsPinned,
+		OpOnly:   body.OpOnly,
 		OpMessage: domain.MessageCreationData{
 			Author:          *user,
 			Text:            domain.MsgText(body.OpMessage.Text),
 			ShowEmailDomain: body.OpMessage.ShowEmailDomain,
 			PendingFiles:    pendingFiles,
+			UploadTokens:    body.OpMessage.UploadTokens,
 			ReplyTo:         body.OpMessage.ReplyTo,
 		},
 	}
 
-	threadId, err := h.thread.Create(creation)
+	threadId, err := h.thread.Create(r.Context(), creation)
+	if errors.Is(err, service.ErrPostQueued) {
+		writePostQueued(w)
+		return
+	}
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -63,17 +72,128 @@ func (h *Handler) GetThread(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// ?from=N[&to=M] fetches a message range instead of a page
+	query := r.URL.Query()
+	if query.Has("from") || query.Has("to") {
+		from, err := parseIntParam(query.Get("from"), "from")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to := 0
+		if query.Get("to") != "" {
+			if to, err = parseIntParam(query.Get("to"), "to"); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		thread, err := h.thread.GetRange(r.Context(), board, domain.ThreadId(threadId), domain.MsgId(from), domain.MsgId(to), mw.GetUserFromContext(r))
+		if err != nil {
+			utils.WriteErrorAndStatusCode(w, err)
+			return
+		}
+		liteAttachments(r, thread.Messages)
+		writeJSON(w, thread)
+		return
+	}
+
 	page := utils.GetPage(r)
 
-	thread, err := h.thread.Get(board, domain.ThreadId(threadId), page)
+	thread, err := h.thread.Get(r.Context(), board, domain.ThreadId(threadId), page, mw.GetUserFromContext(r))
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
+	liteAttachments(r, thread.Messages)
 
 	writeJSON(w, thread)
 }
 
+// GetThreadUpdates returns the messages posted after the "since" message id,
+// so open thread pages can poll for new posts.
+func (h *Handler) GetThreadUpdates(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadIdStr := chi.URLParam(r, "thread")
+	threadId, err := parseIntParam(threadIdStr, "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	since, err := parseIntParam(r.URL.Query().Get("since"), "since")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updates, err := h.thread.GetUpdates(r.Context(), board, domain.ThreadId(threadId), domain.MsgId(since), mw.GetUserFromContext(r))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, updates)
+}
+
+// GetThreadGraph returns the reply graph of a thread for the thread map.
+func (h *Handler) GetThreadGraph(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadIdStr := chi.URLParam(r, "thread")
+	threadId, err := parseIntParam(threadIdStr, "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	graph, err := h.thread.GetGraph(r.Context(), board, domain.ThreadId(threadId), mw.GetUserFromContext(r))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, graph)
+}
+
+// GetThreadAttachments returns every attachment of a thread for the gallery view.
+func (h *Handler) GetThreadAttachments(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadIdStr := chi.URLParam(r, "thread")
+	threadId, err := parseIntParam(threadIdStr, "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	attachments, err := h.thread.GetAttachments(r.Context(), board, domain.ThreadId(threadId), mw.GetUserFromContext(r))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, attachments)
+}
+
+// ExportThread returns the whole thread as a downloadable archive with the
+// content hash of every message and their Merkle root.
+func (h *Handler) ExportThread(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadIdStr := chi.URLParam(r, "thread")
+	threadId, err := parseIntParam(threadIdStr, "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	archive, err := h.thread.Export(r.Context(), board, domain.ThreadId(threadId), mw.GetUserFromContext(r))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%d.json"`, board, threadId))
+	writeJSON(w, archive)
+}
+
 func (h *Handler) GetThreadLastModified(w http.ResponseWriter, r *http.Request) {
 	board := chi.URLParam(r, "board")
 	threadIdStr := chi.URLParam(r, "thread")
@@ -83,7 +203,7 @@ func (h *Handler) GetThreadLastModified(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	lastModified, err := h.thread.GetLastModified(board, domain.ThreadId(threadId))
+	lastModified, err := h.thread.GetLastModified(r.Context(), board, domain.ThreadId(threadId))
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -101,11 +221,35 @@ func (h *Handler) DeleteThread(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.thread.Delete(board, domain.ThreadId(threadId)); err != nil {
+	if r.URL.Query().Get("dry_run") == "true" {
+		report, err := h.thread.DeletionReport(r.Context(), board, domain.ThreadId(threadId))
+		if err != nil {
+			utils.WriteErrorAndStatusCode(w, err)
+			return
+		}
+		writeJSON(w, report)
+		return
+	}
+
+	var req api.DeleteContentRequest
+	if err := decodeOptionalBody(r, &req); err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
 
+	if err := h.thread.Delete(r.Context(), board, domain.ThreadId(threadId), req.Reason); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	// The thread and everything in it is gone, so there is no page to show a
+	// stub on; the reason is kept in the moderation log and the logs.
+	logArgs := []any{"board", board, "thread_id", threadId, "reason", req.Reason}
+	if admin := mw.GetUserFromContext(r); admin != nil {
+		logArgs = append(logArgs, "admin_id", admin.Id)
+	}
+	logger.Log.Info("thread deleted by moderator", logArgs...)
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -118,7 +262,7 @@ func (h *Handler) TogglePinnedThread(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newStatus, err := h.thread.TogglePinned(board, domain.ThreadId(threadId))
+	newStatus, err := h.thread.TogglePinned(r.Context(), board, domain.ThreadId(threadId))
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -126,3 +270,96 @@ func (h *Handler) TogglePinnedThread(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, api.TogglePinnedThreadResponse{IsPinned: newStatus})
 }
+
+func (h *Handler) ToggleOpOnlyThread(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	newStatus, err := h.thread.ToggleOpOnly(r.Context(), board, domain.ThreadId(threadId))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, api.ToggleOpOnlyThreadResponse{OpOnly: newStatus})
+}
+
+func (h *Handler) EditThreadTitle(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user := mw.GetUserFromContext(r)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body api.EditThreadTitleRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.thread.EditTitle(r.Context(), board, domain.ThreadId(threadId), domain.ThreadTitle(body.Title), *user); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	if user.Admin {
+		logger.Log.Info("thread title changed by moderator", "board", board, "thread_id", threadId, "admin_id", user.Id)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SetThreadSuccessor links the thread to the newer thread it is continued in.
+func (h *Handler) SetThreadSuccessor(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user := mw.GetUserFromContext(r)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body api.SetThreadSuccessorRequest
+	if err := utils.DecodeValidate(r.Body, &body); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if err := h.thread.SetSuccessor(r.Context(), board, domain.ThreadId(threadId), domain.ThreadId(body.SuccessorId), *user); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetThreadTitleHistory lists the title changes of a thread for moderators.
+func (h *Handler) GetThreadTitleHistory(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	edits, err := h.thread.GetTitleHistory(r.Context(), board, domain.ThreadId(threadId))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, api.ThreadTitleHistoryResponse{Edits: edits})
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// CreateThreadSnapshot handles POST /v1/:board/:thread/snapshots. It returns
+// the latest snapshot instead of a new one when the thread has not changed.
+func (h *Handler) CreateThreadSnapshot(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId, err := parseIntParam(chi.URLParam(r, "thread"), "thread ID")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user := mw.GetUserFromContext(r)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	snapshot, err := h.threadSnapshot.Create(r.Context(), board, domain.ThreadId(threadId), user.Id)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, snapshot)
+}
+
+// GetThreadSnapshots handles GET /v1/:board/snapshots?thread=&page=
+func (h *Handler) GetThreadSnapshots(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	page := utils.GetPage(r)
+
+	var threadId *domain.ThreadId
+	if s := r.URL.Query().Get("thread"); s != "" {
+		id, err := parseIntParam(s, "thread ID")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tid := domain.ThreadId(id)
+		threadId = &tid
+	}
+
+	snapshots, err := h.threadSnapshot.List(r.Context(), board, threadId, page)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, api.ThreadSnapshotsResponse{Snapshots: snapshots, Page: page})
+}
+
+// GetThreadSnapshot handles GET /v1/:board/snapshots/:snapshot. The page
+// itself is served by the frontend from the media storage.
+func (h *Handler) GetThreadSnapshot(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	id, err := strconv.ParseInt(chi.URLParam(r, "snapshot"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid snapshot ID", http.StatusBadRequest)
+		return
+	}
+
+	snapshot, err := h.threadSnapshot.Get(r.Context(), board, id)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, snapshot)
+}
+
+// DeleteThreadSnapshot handles DELETE /v1/admin/:board/snapshots/:snapshot
+func (h *Handler) DeleteThreadSnapshot(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	id, err := strconv.ParseInt(chi.URLParam(r, "snapshot"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid snapshot ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.threadSnapshot.Delete(r.Context(), board, id); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockThreadSnapshotService struct {
+	MockCreate func(board domain.BoardShortName, threadId domain.ThreadId, author domain.UserId) (domain.ThreadSnapshot, error)
+	MockList   func(board domain.BoardShortName, threadId *domain.ThreadId, page int) ([]domain.ThreadSnapshot, error)
+	MockGet    func(board domain.BoardShortName, id domain.ThreadSnapshotId) (domain.ThreadSnapshot, error)
+	MockDelete func(board domain.BoardShortName, id domain.ThreadSnapshotId) error
+}
+
+func (m *MockThreadSnapshotService) Create(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, author domain.UserId) (domain.ThreadSnapshot, error) {
+	if m.MockCreate != nil {
+		return m.MockCreate(board, threadId, author)
+	}
+	return domain.ThreadSnapshot{}, nil
+}
+
+func (m *MockThreadSnapshotService) List(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId, page int) ([]domain.ThreadSnapshot, error) {
+	if m.MockList != nil {
+		return m.MockList(board, threadId, page)
+	}
+	return []domain.ThreadSnapshot{}, nil
+}
+
+func (m *MockThreadSnapshotService) Get(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) (domain.ThreadSnapshot, error) {
+	if m.MockGet != nil {
+		return m.MockGet(board, id)
+	}
+	return domain.ThreadSnapshot{}, nil
+}
+
+func (m *MockThreadSnapshotService) Delete(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) error {
+	if m.MockDelete != nil {
+		return m.MockDelete(board, id)
+	}
+	return nil
+}
+
+func setupThreadSnapshotTestHandler(snapshots *MockThreadSnapshotService) *chi.Mux {
+	h := &Handler{threadSnapshot: snapshots}
+	router := chi.NewRouter()
+	router.Post("/v1/{board}/{thread}/snapshots", h.CreateThreadSnapshot)
+	router.Get("/v1/{board}/snapshots", h.GetThreadSnapshots)
+	router.Get("/v1/{board}/snapshots/{snapshot}", h.GetThreadSnapshot)
+	router.Delete("/v1/admin/{board}/snapshots/{snapshot}", h.DeleteThreadSnapshot)
+	return router
+}
+
+func TestCreateThreadSnapshot(t *testing.T) {
+	t.Run("creates a snapshot for the user", func(t *testing.T) {
+		var gotThread domain.ThreadId
+		var gotAuthor domain.UserId
+		router := setupThreadSnapshotTestHandler(&MockThreadSnapshotService{
+			MockCreate: func(board domain.BoardShortName, threadId domain.ThreadId, author domain.UserId) (domain.ThreadSnapshot, error) {
+				gotThread, gotAuthor = threadId, author
+				return domain.ThreadSnapshot{Id: 3, Board: board, ThreadId: threadId}, nil
+			},
+		})
+
+		req := addUserToContext(httptest.NewRequest(http.MethodPost, "/v1/b/7/snapshots", nil), &domain.User{Id: 42})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, domain.ThreadId(7), gotThread)
+		assert.Equal(t, domain.UserId(42), gotAuthor)
+		var snapshot domain.ThreadSnapshot
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&snapshot))
+		assert.Equal(t, domain.ThreadSnapshotId(3), snapshot.Id)
+	})
+
+	t.Run("invalid thread ID", func(t *testing.T) {
+		router := setupThreadSnapshotTestHandler(&MockThreadSnapshotService{})
+
+		req := addUserToContext(httptest.NewRequest(http.MethodPost, "/v1/b/abc/snapshots", nil), &domain.User{Id: 42})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("thread not found", func(t *testing.T) {
+		router := setupThreadSnapshotTestHandler(&MockThreadSnapshotService{
+			MockCreate: func(board domain.BoardShortName, threadId domain.ThreadId, author domain.UserId) (domain.ThreadSnapshot, error) {
+				return domain.ThreadSnapshot{}, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+			},
+		})
+
+		req := addUserToContext(httptest.NewRequest(http.MethodPost, "/v1/b/7/snapshots", nil), &domain.User{Id: 42})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestGetThreadSnapshots(t *testing.T) {
+	t.Run("filters by thread", func(t *testing.T) {
+		var gotThread *domain.ThreadId
+		var gotPage int
+		router := setupThreadSnapshotTestHandler(&MockThreadSnapshotService{
+			MockList: func(board domain.BoardShortName, threadId *domain.ThreadId, page int) ([]domain.ThreadSnapshot, error) {
+				gotThread, gotPage = threadId, page
+				return []domain.ThreadSnapshot{{Id: 1, Board: board, ThreadId: 7}}, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/b/snapshots?thread=7&page=2", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NotNil(t, gotThread)
+		assert.Equal(t, domain.ThreadId(7), *gotThread)
+		assert.Equal(t, 2, gotPage)
+		var resp api.ThreadSnapshotsResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Len(t, resp.Snapshots, 1)
+		assert.Equal(t, 2, resp.Page)
+	})
+
+	t.Run("whole board", func(t *testing.T) {
+		var gotThread *domain.ThreadId
+		router := setupThreadSnapshotTestHandler(&MockThreadSnapshotService{
+			MockList: func(board domain.BoardShortName, threadId *domain.ThreadId, page int) ([]domain.ThreadSnapshot, error) {
+				gotThread = threadId
+				return []domain.ThreadSnapshot{}, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/b/snapshots", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Nil(t, gotThread)
+	})
+
+	t.Run("invalid thread ID", func(t *testing.T) {
+		router := setupThreadSnapshotTestHandler(&MockThreadSnapshotService{})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/b/snapshots?thread=x", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetAndDeleteThreadSnapshot(t *testing.T) {
+	t.Run("get", func(t *testing.T) {
+		router := setupThreadSnapshotTestHandler(&MockThreadSnapshotService{
+			MockGet: func(board domain.BoardShortName, id domain.ThreadSnapshotId) (domain.ThreadSnapshot, error) {
+				return domain.ThreadSnapshot{Id: id, Board: board, FilePath: "b/snapshots/x.html"}, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/b/snapshots/4", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var snapshot domain.ThreadSnapshot
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&snapshot))
+		assert.Equal(t, domain.ThreadSnapshotId(4), snapshot.Id)
+		assert.Equal(t, "b/snapshots/x.html", snapshot.FilePath)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		var deleted domain.ThreadSnapshotId
+		router := setupThreadSnapshotTestHandler(&MockThreadSnapshotService{
+			MockDelete: func(board domain.BoardShortName, id domain.ThreadSnapshotId) error {
+				deleted = id
+				return nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/v1/admin/b/snapshots/4", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, domain.ThreadSnapshotId(4), deleted)
+	})
+
+	t.Run("invalid snapshot ID", func(t *testing.T) {
+		router := setupThreadSnapshotTestHandler(&MockThreadSnapshotService{})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/v1/admin/b/snapshots/x", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
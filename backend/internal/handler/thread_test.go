@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,49 +17,118 @@ import (
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 type MockThreadService struct {
-	MockCreate       func(creationData domain.ThreadCreationData) (domain.ThreadId, error)
-	MockGet          func(board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error)
-	MockDelete       func(board domain.BoardShortName, id domain.ThreadId) error
-	MockTogglePinned func(board domain.BoardShortName, id domain.ThreadId) (bool, error)
+	MockCreate         func(creationData domain.ThreadCreationData) (domain.ThreadId, error)
+	MockGet            func(board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error)
+	MockDelete         func(board domain.BoardShortName, id domain.ThreadId, reason string) error
+	MockTogglePinned   func(board domain.BoardShortName, id domain.ThreadId) (bool, error)
+	MockGetUpdates     func(board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, viewer *domain.User) (domain.ThreadUpdates, error)
+	MockEditTitle      func(board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editor domain.User) error
+	MockGetRange       func(board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error)
+	MockGetGraph       func(board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadGraph, error)
+	MockGetAttachments func(board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadAttachments, error)
+	MockExport         func(board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadArchive, error)
+	MockSetSuccessor   func(board domain.BoardShortName, id, successor domain.ThreadId, editor domain.User) error
 }
 
-func (m *MockThreadService) Create(creationData domain.ThreadCreationData) (domain.ThreadId, error) {
+func (m *MockThreadService) Create(ctx context.Context, creationData domain.ThreadCreationData) (domain.ThreadId, error) {
 	if m.MockCreate != nil {
 		return m.MockCreate(creationData)
 	}
 	return 1, nil
 }
 
-func (m *MockThreadService) Get(board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error) {
+func (m *MockThreadService) Get(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error) {
 	if m.MockGet != nil {
-		return m.MockGet(board, id, page)
+		return m.MockGet(board, id, page, viewer)
 	}
 	return domain.Thread{Messages: []*domain.Message{{MessageMetadata: domain.MessageMetadata{Id: domain.MsgId(id)}}}}, nil
 }
 
-func (m *MockThreadService) Delete(board domain.BoardShortName, id domain.ThreadId) error {
+func (m *MockThreadService) GetRange(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId, viewer *domain.User) (domain.Thread, error) {
+	if m.MockGetRange != nil {
+		return m.MockGetRange(board, id, from, to)
+	}
+	return domain.Thread{}, nil
+}
+
+func (m *MockThreadService) GetGraph(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadGraph, error) {
+	if m.MockGetGraph != nil {
+		return m.MockGetGraph(board, id, viewer)
+	}
+	return domain.ThreadGraph{}, nil
+}
+
+func (m *MockThreadService) GetAttachments(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadAttachments, error) {
+	if m.MockGetAttachments != nil {
+		return m.MockGetAttachments(board, id, viewer)
+	}
+	return domain.ThreadAttachments{}, nil
+}
+
+func (m *MockThreadService) Export(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadArchive, error) {
+	if m.MockExport != nil {
+		return m.MockExport(board, id, viewer)
+	}
+	return domain.ThreadArchive{}, nil
+}
+
+func (m *MockThreadService) GetUpdates(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, viewer *domain.User) (domain.ThreadUpdates, error) {
+	if m.MockGetUpdates != nil {
+		return m.MockGetUpdates(board, id, since, viewer)
+	}
+	return domain.ThreadUpdates{}, nil
+}
+
+func (m *MockThreadService) Delete(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, reason string) error {
 	if m.MockDelete != nil {
-		return m.MockDelete(board, id)
+		return m.MockDelete(board, id, reason)
 	}
 	return nil
 }
 
-func (m *MockThreadService) GetLastModified(board domain.BoardShortName, id domain.ThreadId) (time.Time, error) {
+func (m *MockThreadService) DeletionReport(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.DeletionReport, error) {
+	return domain.DeletionReport{Threads: 1}, nil
+}
+
+func (m *MockThreadService) GetLastModified(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (time.Time, error) {
 	return time.Now().UTC(), nil
 }
 
-func (m *MockThreadService) TogglePinned(board domain.BoardShortName, id domain.ThreadId) (bool, error) {
+func (m *MockThreadService) TogglePinned(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (bool, error) {
 	if m.MockTogglePinned != nil {
 		return m.MockTogglePinned(board, id)
 	}
 	return true, nil
 }
 
+func (m *MockThreadService) ToggleOpOnly(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (bool, error) {
+	return true, nil
+}
+
+func (m *MockThreadService) EditTitle(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editor domain.User) error {
+	if m.MockEditTitle != nil {
+		return m.MockEditTitle(board, id, title, editor)
+	}
+	return nil
+}
+
+func (m *MockThreadService) GetTitleHistory(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) ([]domain.ThreadTitleEdit, error) {
+	return nil, nil
+}
+
+func (m *MockThreadService) SetSuccessor(ctx context.Context, board domain.BoardShortName, id, successor domain.ThreadId, editor domain.User) error {
+	if m.MockSetSuccessor != nil {
+		return m.MockSetSuccessor(board, id, successor, editor)
+	}
+	return nil
+}
+
 func setupThreadTestHandler(threadService service.ThreadService) (*Handler, *chi.Mux) {
 	cfg := &config.Config{
 		Public: config.Public{
@@ -76,7 +146,13 @@ func setupThreadTestHandler(threadService service.ThreadService) (*Handler, *chi
 	router := chi.NewRouter()
 	router.Post("/{board}", h.CreateThread)
 	router.Get("/{board}/{thread}", h.GetThread)
+	router.Get("/{board}/{thread}/messages", h.GetThreadUpdates)
+	router.Get("/{board}/{thread}/graph", h.GetThreadGraph)
+	router.Get("/{board}/{thread}/attachments", h.GetThreadAttachments)
+	router.Get("/{board}/{thread}/export", h.ExportThread)
 	router.Delete("/{board}/{thread}", h.DeleteThread)
+	router.Patch("/{board}/{thread}", h.EditThreadTitle)
+	router.Put("/{board}/{thread}/successor", h.SetThreadSuccessor)
 
 	return h, router
 }
@@ -220,7 +296,7 @@ func TestGetThreadHandler(t *testing.T) {
 
 	t.Run("successful get", func(t *testing.T) {
 		mockService := &MockThreadService{
-			MockGet: func(board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error) {
+			MockGet: func(board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error) {
 				assert.Equal(t, domain.ThreadId(threadID), id)
 				assert.Equal(t, domain.BoardShortName(boardName), board)
 				assert.Equal(t, 1, page)
@@ -243,6 +319,44 @@ func TestGetThreadHandler(t *testing.T) {
 		assert.Equal(t, expectedThread, actualThread)
 	})
 
+	t.Run("lite trims attachment files", func(t *testing.T) {
+		thumb := "b/thumb/t.jpg"
+		mockService := &MockThreadService{
+			MockGet: func(board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error) {
+				return domain.Thread{Messages: []*domain.Message{{
+					MessageMetadata: domain.MessageMetadata{Id: threadID},
+					Attachments: domain.Attachments{{Id: 1, File: &domain.File{
+						FileCommonMetadata: domain.FileCommonMetadata{Filename: "f.txt", MimeType: "text/plain", SizeBytes: 10},
+						FilePath:           "b/f.txt",
+						OriginalFilename:   "notes.txt",
+						OriginalMimeType:   "text/plain",
+						ThumbnailPath:      &thumb,
+						TextPreview:        "long preview",
+					}}},
+				}}}, nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, route+"?lite=true", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var actual domain.Thread
+		require.NoError(t, json.Unmarshal(bytes.TrimSpace(rr.Body.Bytes()), &actual))
+		file := actual.Messages[0].Attachments[0].File
+		require.NotNil(t, file)
+		assert.Equal(t, "b/f.txt", file.FilePath)
+		assert.Equal(t, "notes.txt", file.OriginalFilename)
+		assert.Equal(t, &thumb, file.ThumbnailPath)
+		assert.Equal(t, int64(10), file.SizeBytes)
+		assert.Empty(t, file.TextPreview)
+		assert.Empty(t, file.OriginalMimeType)
+		assert.Empty(t, file.Filename)
+	})
+
 	t.Run("invalid thread id", func(t *testing.T) {
 		_, router := setupThreadTestHandler(&MockThreadService{})
 		badRoute := "/" + boardName + "/abc"
@@ -257,7 +371,7 @@ func TestGetThreadHandler(t *testing.T) {
 	t.Run("service error", func(t *testing.T) {
 		mockErr := errors.New("thread not found")
 		mockService := &MockThreadService{
-			MockGet: func(board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error) {
+			MockGet: func(board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error) {
 				return domain.Thread{}, mockErr
 			},
 		}
@@ -269,6 +383,236 @@ func TestGetThreadHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	})
+
+	t.Run("message range", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockGet: func(board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error) {
+				t.Fatal("page fetched for a range request")
+				return domain.Thread{}, nil
+			},
+			MockGetRange: func(board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error) {
+				assert.Equal(t, domain.BoardShortName(boardName), board)
+				assert.Equal(t, domain.MsgId(100), from)
+				assert.Equal(t, domain.MsgId(200), to)
+				return domain.Thread{}, nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+		req := createRequest(t, http.MethodGet, route+"?from=100&to=200", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("range without an upper bound", func(t *testing.T) {
+		var gotTo domain.MsgId = -1
+		mockService := &MockThreadService{
+			MockGetRange: func(board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error) {
+				gotTo = to
+				return domain.Thread{}, nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+		req := createRequest(t, http.MethodGet, route+"?from=100", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, domain.MsgId(0), gotTo)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, router := setupThreadTestHandler(&MockThreadService{})
+		for _, query := range []string{"?from=abc", "?to=200", "?from=1&to=x"} {
+			req := createRequest(t, http.MethodGet, route+query, nil)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+}
+
+func TestGetThreadUpdatesHandler(t *testing.T) {
+	route := "/b/123/messages"
+	expected := domain.ThreadUpdates{
+		Messages:  []*domain.Message{{MessageMetadata: domain.MessageMetadata{Id: 8, Author: domain.User{Id: 2}}, Text: "new"}},
+		Backlinks: domain.Replies{{From: 8, To: 3}},
+	}
+
+	t.Run("successful get", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockGetUpdates: func(board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, viewer *domain.User) (domain.ThreadUpdates, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, domain.ThreadId(123), id)
+				assert.Equal(t, domain.MsgId(7), since)
+				return expected, nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, route+"?since=7", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var actual domain.ThreadUpdates
+		require.NoError(t, json.Unmarshal(bytes.TrimSpace(rr.Body.Bytes()), &actual))
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("missing since", func(t *testing.T) {
+		_, router := setupThreadTestHandler(&MockThreadService{})
+		req := createRequest(t, http.MethodGet, route, nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetThreadGraphHandler(t *testing.T) {
+	t.Run("successful get", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockGetGraph: func(board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadGraph, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, domain.ThreadId(123), id)
+				return domain.ThreadGraph{
+					Nodes: []domain.GraphNode{{Id: 1, Page: 1, Author: 5}, {Id: 2, Page: 1, Author: 6}},
+					Edges: []domain.GraphEdge{{From: 2, To: 1}},
+				}, nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/b/123/graph", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var body map[string][]map[string]any
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		require.Len(t, body["nodes"], 2)
+		assert.NotContains(t, body["nodes"][0], "author", "posters stay anonymous")
+		assert.Equal(t, []map[string]any{{"from": float64(2), "to": float64(1)}}, body["edges"])
+	})
+
+	t.Run("invalid thread id", func(t *testing.T) {
+		_, router := setupThreadTestHandler(&MockThreadService{})
+		req := createRequest(t, http.MethodGet, "/b/abc/graph", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestExportThreadHandler(t *testing.T) {
+	t.Run("successful export", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockExport: func(board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadArchive, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, domain.ThreadId(123), id)
+				return domain.ThreadArchive{
+					Board: board, ThreadId: id, HashVersion: 1, MerkleRoot: "ab",
+					Messages: []domain.ArchivedMessage{{Id: 1, Text: "hi", ContentHash: "cd", AuthorCommitment: "ef", Author: 5}},
+				}, nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/b/123/export", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `attachment; filename="b-123.json"`, rr.Header().Get("Content-Disposition"))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, "ab", body["merkle_root"])
+		messages := body["messages"].([]any)
+		require.Len(t, messages, 1)
+		message := messages[0].(map[string]any)
+		assert.NotContains(t, message, "author", "posters stay anonymous")
+		assert.Equal(t, "cd", message["content_hash"])
+		assert.Equal(t, "ef", message["author_commitment"])
+	})
+
+	t.Run("thread not found", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockExport: func(board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadArchive, error) {
+				return domain.ThreadArchive{}, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/b/123/export", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestGetThreadAttachmentsHandler(t *testing.T) {
+	t.Run("successful get", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockGetAttachments: func(board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadAttachments, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, domain.ThreadId(123), id)
+				return domain.ThreadAttachments{
+					Attachments: []domain.ThreadAttachment{{
+						Attachment: &domain.Attachment{Id: 7, MessageId: 2, File: &domain.File{
+							FileCommonMetadata: domain.FileCommonMetadata{MimeType: "image/png"},
+							FilePath:           "b/123/pic.png",
+						}},
+						Page:   1,
+						Author: 5,
+					}},
+					OpAuthor: 5,
+				}, nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := createRequest(t, http.MethodGet, "/b/123/attachments", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.NotContains(t, body, "op_author")
+		items := body["attachments"].([]any)
+		require.Len(t, items, 1)
+		item := items[0].(map[string]any)
+		assert.NotContains(t, item, "author", "posters stay anonymous")
+		assert.Equal(t, float64(1), item["page"])
+		attachment := item["attachment"].(map[string]any)
+		assert.Equal(t, "/media/b/123/pic.png", attachment["url"])
+		assert.Equal(t, float64(2), attachment["message_id"])
+	})
+
+	t.Run("invalid thread id", func(t *testing.T) {
+		_, router := setupThreadTestHandler(&MockThreadService{})
+		req := createRequest(t, http.MethodGet, "/b/abc/attachments", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
 }
 
 func TestDeleteThreadHandler(t *testing.T) {
@@ -278,15 +622,16 @@ func TestDeleteThreadHandler(t *testing.T) {
 
 	t.Run("successful deletion", func(t *testing.T) {
 		mockService := &MockThreadService{
-			MockDelete: func(board domain.BoardShortName, id domain.ThreadId) error {
+			MockDelete: func(board domain.BoardShortName, id domain.ThreadId, reason string) error {
 				assert.Equal(t, domain.BoardShortName(boardName), board)
 				assert.Equal(t, domain.ThreadId(threadID), id)
+				assert.Equal(t, "off-topic", reason)
 				return nil
 			},
 		}
 		_, router := setupThreadTestHandler(mockService)
 
-		req := createRequest(t, http.MethodDelete, route, nil)
+		req := createRequest(t, http.MethodDelete, route, []byte(`{"reason":"off-topic"}`))
 		rr := httptest.NewRecorder()
 
 		router.ServeHTTP(rr, req)
@@ -295,6 +640,24 @@ func TestDeleteThreadHandler(t *testing.T) {
 		assert.Empty(t, rr.Body.String())
 	})
 
+	t.Run("dry run reports without deleting", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockDelete: func(board domain.BoardShortName, id domain.ThreadId, reason string) error {
+				t.Fatal("a dry run must not delete the thread")
+				return nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := createRequest(t, http.MethodDelete, route+"?dry_run=true", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"threads":1,"messages":0,"attachments":0,"attachment_bytes":0}`, rr.Body.String())
+	})
+
 	t.Run("invalid thread id", func(t *testing.T) {
 		_, router := setupThreadTestHandler(&MockThreadService{})
 		badRoute := "/" + boardName + "/abc"
@@ -309,7 +672,7 @@ func TestDeleteThreadHandler(t *testing.T) {
 	t.Run("service error", func(t *testing.T) {
 		mockErr := errors.New("permission denied to delete")
 		mockService := &MockThreadService{
-			MockDelete: func(board domain.BoardShortName, id domain.ThreadId) error {
+			MockDelete: func(board domain.BoardShortName, id domain.ThreadId, reason string) error {
 				return mockErr
 			},
 		}
@@ -322,3 +685,114 @@ func TestDeleteThreadHandler(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	})
 }
+
+func TestEditThreadTitleHandler(t *testing.T) {
+	route := "/b/123"
+	testUser := domain.User{Id: 7}
+
+	t.Run("successful edit", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockEditTitle: func(board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editor domain.User) error {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, domain.ThreadId(123), id)
+				assert.Equal(t, domain.ThreadTitle("new title"), title)
+				assert.Equal(t, testUser, editor)
+				return nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := addUserToContext(createRequest(t, http.MethodPatch, route, []byte(`{"title":"new title"}`)), &testUser)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("missing title", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockEditTitle: func(domain.BoardShortName, domain.ThreadId, domain.ThreadTitle, domain.User) error {
+				t.Fatal("service called without a title")
+				return nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := addUserToContext(createRequest(t, http.MethodPatch, route, []byte(`{}`)), &testUser)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockEditTitle: func(domain.BoardShortName, domain.ThreadId, domain.ThreadTitle, domain.User) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "Only the thread's author can change its title", StatusCode: http.StatusForbidden}
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := addUserToContext(createRequest(t, http.MethodPatch, route, []byte(`{"title":"new title"}`)), &testUser)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), "thread's author")
+	})
+}
+
+func TestSetThreadSuccessorHandler(t *testing.T) {
+	route := "/b/123/successor"
+	testUser := domain.User{Id: 7}
+
+	t.Run("links the threads", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockSetSuccessor: func(board domain.BoardShortName, id, successor domain.ThreadId, editor domain.User) error {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, domain.ThreadId(123), id)
+				assert.Equal(t, domain.ThreadId(456), successor)
+				assert.Equal(t, testUser, editor)
+				return nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := addUserToContext(createRequest(t, http.MethodPut, route, []byte(`{"successor_id":456}`)), &testUser)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("missing successor", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockSetSuccessor: func(domain.BoardShortName, domain.ThreadId, domain.ThreadId, domain.User) error {
+				t.Fatal("service called without a successor")
+				return nil
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := addUserToContext(createRequest(t, http.MethodPut, route, []byte(`{}`)), &testUser)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("already continues another thread", func(t *testing.T) {
+		mockService := &MockThreadService{
+			MockSetSuccessor: func(domain.BoardShortName, domain.ThreadId, domain.ThreadId, domain.User) error {
+				return &internal_errors.ErrorWithStatusCode{Message: "Thread 456 already continues thread 100", StatusCode: http.StatusConflict}
+			},
+		}
+		_, router := setupThreadTestHandler(mockService)
+
+		req := addUserToContext(createRequest(t, http.MethodPut, route, []byte(`{"successor_id":456}`)), &testUser)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+}
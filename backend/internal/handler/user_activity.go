@@ -2,7 +2,11 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
 	mw "github.com/itchan-dev/itchan/shared/middleware"
 	"github.com/itchan-dev/itchan/shared/utils"
 )
@@ -17,7 +21,7 @@ func (h *Handler) GetUserActivity(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch user activity from service
-	activity, err := h.userActivity.GetUserActivity(user.Id)
+	activity, err := h.userActivity.GetUserActivity(r.Context(), user.Id)
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
@@ -26,3 +30,71 @@ func (h *Handler) GetUserActivity(w http.ResponseWriter, r *http.Request) {
 	// Return JSON response
 	writeJSON(w, activity)
 }
+
+// GetUserPosts handles GET /v1/me/posts: one page of the user's post history
+func (h *Handler) GetUserPosts(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUserFromContext(r)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page := utils.GetPage(r)
+
+	posts, total, err := h.userActivity.GetUserPosts(r.Context(), user, page)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	// If no posts, return empty array instead of null
+	if posts == nil {
+		posts = []domain.LatestPost{}
+	}
+
+	writeJSON(w, api.UserPostsResponse{Posts: posts, Page: page, Total: total})
+}
+
+// GetUserStats handles GET /v1/me/stats: the authenticated user's posting statistics
+func (h *Handler) GetUserStats(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUserFromContext(r)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	stats, err := h.userActivity.GetUserStats(r.Context(), user.Id)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, stats)
+}
+
+// GetUserStatsById handles GET /v1/admin/users/:userId/stats
+func (h *Handler) GetUserStatsById(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.userActivity.GetUserStats(r.Context(), domain.UserId(userId))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, stats)
+}
+
+// GetSiteActivity returns the index page widgets data for the boards the caller can read
+func (h *Handler) GetSiteActivity(w http.ResponseWriter, r *http.Request) {
+	activity, err := h.siteActivity.Get(r.Context(), mw.GetUserFromContext(r))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	writeJSON(w, activity)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// maxUserImportBytes bounds the CSV body of an import.
+const maxUserImportBytes = 2 << 20
+
+// ImportUsers handles POST /v1/admin/users/import?invite=true. The body is a
+// CSV of emails; accounts are created right away and invitations go out in
+// the background.
+func (h *Handler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUserImportBytes))
+	if err != nil {
+		http.Error(w, "CSV file is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	rows, err := service.ParseUserImportCSV(bytes.NewReader(body))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	report, err := h.userImport.Import(r.Context(), rows, r.URL.Query().Get("invite") == "true")
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	writeJSON(w, report)
+}
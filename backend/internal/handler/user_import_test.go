@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockUserImportService struct {
+	MockImport func(rows []domain.UserImportRow, invite bool) (domain.UserImportReport, error)
+}
+
+func (m *MockUserImportService) Import(ctx context.Context, rows []domain.UserImportRow, invite bool) (domain.UserImportReport, error) {
+	if m.MockImport != nil {
+		return m.MockImport(rows, invite)
+	}
+	return domain.UserImportReport{}, nil
+}
+
+func setupUserImportTestHandler(userImportService service.UserImportService) (*Handler, *chi.Mux) {
+	h := &Handler{
+		userImport: userImportService,
+	}
+	router := chi.NewRouter()
+	router.Post("/v1/admin/users/import", h.ImportUsers)
+
+	return h, router
+}
+
+func TestImportUsersHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockService := &MockUserImportService{
+			MockImport: func(rows []domain.UserImportRow, invite bool) (domain.UserImportReport, error) {
+				assert.True(t, invite)
+				assert.Equal(t, []domain.UserImportRow{{Line: 2, Email: "a@example.com"}, {Line: 3, Email: "b@example.com"}}, rows)
+				return domain.UserImportReport{
+					Created:  1,
+					Existing: 1,
+					Invited:  1,
+					Rejected: []domain.UserImportProblem{},
+				}, nil
+			},
+		}
+		_, router := setupUserImportTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/users/import?invite=true", []byte("email\na@example.com\nb@example.com\n"))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report domain.UserImportReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Invited)
+	})
+
+	t.Run("malformed csv", func(t *testing.T) {
+		mockService := &MockUserImportService{
+			MockImport: func(rows []domain.UserImportRow, invite bool) (domain.UserImportReport, error) {
+				t.Fatal("service must not be called")
+				return domain.UserImportReport{}, nil
+			},
+		}
+		_, router := setupUserImportTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/users/import", []byte("\"a@example.com\n"))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		_, router := setupUserImportTestHandler(&MockUserImportService{})
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/users/import", []byte(strings.Repeat("a@example.com\n", maxUserImportBytes/10)))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &MockUserImportService{
+			MockImport: func(rows []domain.UserImportRow, invite bool) (domain.UserImportReport, error) {
+				assert.False(t, invite)
+				return domain.UserImportReport{}, &internal_errors.ErrorWithStatusCode{Message: "Too many emails", StatusCode: http.StatusBadRequest}
+			},
+		}
+		_, router := setupUserImportTestHandler(mockService)
+
+		req := createRequest(t, http.MethodPost, "/v1/admin/users/import", []byte("a@example.com\n"))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// StartViewAs handles POST /v1/admin/users/:userId/view_as
+func (h *Handler) StartViewAs(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req api.ViewAsRequest
+	if err := utils.DecodeValidate(r.Body, &req); err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	admin := mw.GetUserFromContext(r)
+	token, expiresAt, err := h.viewAs.Start(r.Context(), admin.Id, userId, req.Reason)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, api.ViewAsResponse{Token: token, ExpiresAt: expiresAt})
+}
+
+// GetViewAsSessions handles GET /v1/admin/view_as
+func (h *Handler) GetViewAsSessions(w http.ResponseWriter, r *http.Request) {
+	page := utils.GetPage(r)
+
+	sessions, err := h.viewAs.List(r.Context(), page)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	if sessions == nil {
+		sessions = []domain.ViewAsSession{}
+	}
+
+	writeJSON(w, api.ViewAsSessionsResponse{Sessions: sessions, Page: page})
+}
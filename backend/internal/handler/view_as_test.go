@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockViewAsService struct {
+	MockStart func(admin, userId domain.UserId, reason string) (string, time.Time, error)
+	MockList  func(page int) ([]domain.ViewAsSession, error)
+}
+
+func (m *MockViewAsService) Start(ctx context.Context, admin, userId domain.UserId, reason string) (string, time.Time, error) {
+	if m.MockStart != nil {
+		return m.MockStart(admin, userId, reason)
+	}
+	return "", time.Time{}, nil
+}
+
+func (m *MockViewAsService) List(ctx context.Context, page int) ([]domain.ViewAsSession, error) {
+	if m.MockList != nil {
+		return m.MockList(page)
+	}
+	return nil, nil
+}
+
+func setupViewAsTestHandler(viewAs *MockViewAsService) *chi.Mux {
+	h := &Handler{viewAs: viewAs}
+	router := chi.NewRouter()
+	router.Post("/v1/admin/users/{userId}/view_as", h.StartViewAs)
+	router.Get("/v1/admin/view_as", h.GetViewAsSessions)
+	return router
+}
+
+func TestStartViewAsHandler(t *testing.T) {
+	admin := &domain.User{Id: 1, Admin: true}
+
+	t.Run("returns the token", func(t *testing.T) {
+		expiresAt := time.Now().Add(15 * time.Minute).UTC().Truncate(time.Second)
+		router := setupViewAsTestHandler(&MockViewAsService{
+			MockStart: func(adminId, userId domain.UserId, reason string) (string, time.Time, error) {
+				assert.Equal(t, admin.Id, adminId)
+				assert.Equal(t, domain.UserId(42), userId)
+				assert.Equal(t, "permission report", reason)
+				return "token", expiresAt, nil
+			},
+		})
+
+		body, _ := json.Marshal(api.ViewAsRequest{Reason: "permission report"})
+		req := addUserToContext(createRequest(t, http.MethodPost, "/v1/admin/users/42/view_as", body), admin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+		var resp api.ViewAsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "token", resp.Token)
+		assert.True(t, expiresAt.Equal(resp.ExpiresAt))
+	})
+
+	t.Run("reason is required", func(t *testing.T) {
+		body, _ := json.Marshal(api.ViewAsRequest{})
+		req := addUserToContext(createRequest(t, http.MethodPost, "/v1/admin/users/42/view_as", body), admin)
+		rr := httptest.NewRecorder()
+		setupViewAsTestHandler(&MockViewAsService{}).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid user ID", func(t *testing.T) {
+		body, _ := json.Marshal(api.ViewAsRequest{Reason: "x"})
+		req := addUserToContext(createRequest(t, http.MethodPost, "/v1/admin/users/abc/view_as", body), admin)
+		rr := httptest.NewRecorder()
+		setupViewAsTestHandler(&MockViewAsService{}).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetViewAsSessionsHandler(t *testing.T) {
+	t.Run("empty list is an array", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		setupViewAsTestHandler(&MockViewAsService{}).ServeHTTP(rr, createRequest(t, http.MethodGet, "/v1/admin/view_as?page=2", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"sessions": [], "page": 2}`, rr.Body.String())
+	})
+
+	t.Run("lists sessions", func(t *testing.T) {
+		router := setupViewAsTestHandler(&MockViewAsService{
+			MockList: func(page int) ([]domain.ViewAsSession, error) {
+				assert.Equal(t, 1, page)
+				return []domain.ViewAsSession{{Id: 3, AdminId: 1, UserId: 42, Reason: "r"}}, nil
+			},
+		})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, createRequest(t, http.MethodGet, "/v1/admin/view_as", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp api.ViewAsSessionsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Sessions, 1)
+		assert.Equal(t, domain.UserId(42), resp.Sessions[0].UserId)
+	})
+}
@@ -13,6 +13,7 @@ import (
 	mw "github.com/itchan-dev/itchan/shared/middleware"
 	"github.com/itchan-dev/itchan/shared/middleware/metrics"
 	rl "github.com/itchan-dev/itchan/shared/middleware/ratelimiter"
+	"github.com/itchan-dev/itchan/shared/tracing"
 )
 
 // New creates and configures a new chi router with all the routes.
@@ -20,12 +21,21 @@ import (
 func New(deps *setup.Dependencies) *chi.Mux {
 	r := chi.NewRouter()
 
+	// Tag every request with an ID for the logs (honours an incoming X-Request-Id)
+	r.Use(middleware.RequestID)
+
 	// Strip trailing slashes (replaces mux.StrictSlash)
 	r.Use(middleware.StripSlashes)
 
+	// Request spans, continuing the frontend's trace (no-op unless tracing is configured)
+	r.Use(tracing.Middleware)
+
 	// Prometheus metrics middleware (must be early to capture all requests)
 	r.Use(metrics.Middleware)
 
+	// Answer panics with a 500 JSON error; inside metrics so they are counted
+	r.Use(mw.Recover)
+
 	// Enable gzip compression for all responses
 	r.Use(middleware.Compress(5))
 
@@ -40,8 +50,11 @@ func New(deps *setup.Dependencies) *chi.Mux {
 
 	// Add security headers
 	// Backend CSP: strict policy (JSON API only, no scripts/styles needed)
-	backendCSP := "default-src 'none'; frame-ancestors 'none'"
-	r.Use(mw.SecurityHeadersWithCSP(deps.Config.Public.SecureCookies, backendCSP))
+	r.Use(mw.SecurityHeaders(mw.SecurityHeadersConfig{
+		HTTPS:          deps.Config.Public.SecureCookies,
+		CSP:            "default-src 'none'",
+		ReferrerPolicy: deps.Config.Public.ReferrerPolicy,
+	}))
 
 	// Add a wildcard OPTIONS handler to avoid 404s for preflight requests
 	r.Options("/*", func(w http.ResponseWriter, r *http.Request) {
@@ -51,6 +64,23 @@ func New(deps *setup.Dependencies) *chi.Mux {
 	h := deps.Handler
 	authMw := deps.AuthMiddleware
 
+	// Request timeouts; a deadline can't be extended by a nested group, so
+	// every route gets exactly one of these
+	readTimeout := middleware.Timeout(deps.Config.Public.ReadRequestTimeout)
+	writeTimeout := middleware.Timeout(deps.Config.Public.WriteRequestTimeout)
+	uploadTimeout := middleware.Timeout(deps.Config.Public.UploadRequestTimeout)
+
+	// Board access check (allowed email domains, boards pending deletion). It
+	// needs the user, so each group with {board} routes uses it after its auth
+	// middleware; router_test.go checks that no such route is left out.
+	boardAccess := mw.RestrictBoardAccess(deps.AccessData, deps.Config.Public.HideRestrictedBoards)
+
+	// Posting limiters, also reported to users by GET /v1/me/limits
+	createThreadLimiter := rl.OncePerMinute()
+	createMessageLimiter := rl.OncePerSecond()
+	// Uploads ahead of a post come in bursts of up to one post's attachments
+	uploadLimiter := rl.New(1, float64(deps.Config.Public.MaxAttachmentsPerMessage), time.Hour)
+
 	// Health check and metrics endpoints (no auth required)
 	// Support both GET and HEAD for health checks (wget --spider uses HEAD)
 	r.Get("/health", h.Health)
@@ -60,31 +90,112 @@ func New(deps *setup.Dependencies) *chi.Mux {
 	r.Handle("/metrics", promhttp.Handler())
 
 	r.Route("/v1", func(v1 chi.Router) {
+		// Writes get 503 during a maintenance window, reads continue
+		v1.Use(h.ReadOnlyDuringMaintenance)
+		// and while a backup has paused them
+		v1.Use(h.PauseWritesDuringBackup)
+
 		// Public config endpoint
-		v1.Get("/public_config", h.GetPublicConfig)
+		v1.With(readTimeout).Get("/public_config", h.GetPublicConfig)
+		v1.With(readTimeout).Get("/meta", h.GetMeta)
+		v1.With(readTimeout).Get("/maintenance", h.GetMaintenance)
 
 		// Admin routes
 		v1.Route("/admin", func(admin chi.Router) {
 			admin.Use(authMw.AdminOnly())
 
-			admin.Post("/boards", h.CreateBoard)
-			admin.Delete("/{board}", h.DeleteBoard)
-			admin.Delete("/{board}/{thread}", h.DeleteThread)
-			admin.Post("/{board}/{thread}/pin", h.TogglePinnedThread)
-			admin.Delete("/{board}/{thread}/{message}", h.DeleteMessage)
+			admin.With(uploadTimeout).Post("/{board}/banners", h.UploadBoardBanner)
+			// Copies attachments from the source instance, so it gets the upload deadline
+			admin.With(uploadTimeout).Post("/{board}/mirror/sync", h.SyncBoardMirror)
+			// Hashes and encrypts every email of the file, which takes a while for large ones
+			admin.With(uploadTimeout).Post("/users/import", h.ImportUsers)
+			// Waits for the external directory, which may be slow to list a large organization
+			admin.With(uploadTimeout).Post("/directory/sync", h.SyncDirectory)
+
+			admin.Group(func(admin chi.Router) {
+				admin.Use(writeTimeout)
+
+				admin.Post("/boards", h.CreateBoard)
+				admin.Delete("/{board}", h.DeleteBoard)
+				admin.Post("/{board}/restore", h.RestoreBoard)
+				admin.Post("/{board}/rename", h.RenameBoard)
+				admin.Get("/boards/pending_deletion", h.GetPendingBoardDeletions)
+				admin.Put("/{board}/category", h.SetBoardCategory)
+				admin.Put("/{board}/settings", h.UpdateBoardSettings)
+				admin.Put("/{board}/custom_css", h.SetBoardCustomCSS)
+				admin.Delete("/{board}/banners/{bannerId}", h.DeleteBoardBanner)
+				admin.Delete("/{board}/snapshots/{snapshot}", h.DeleteThreadSnapshot)
+				admin.Delete("/{board}/{thread}", h.DeleteThread)
+				admin.Post("/{board}/{thread}/pin", h.TogglePinnedThread)
+				admin.Post("/{board}/{thread}/op_only", h.ToggleOpOnlyThread)
+				admin.Get("/{board}/{thread}/title_history", h.GetThreadTitleHistory)
+				admin.Delete("/{board}/{thread}/{message}", h.DeleteMessage)
+				admin.Get("/{board}/{thread}/{message}/metadata", h.GetMessageFileMetadata)
+
+				// Admin blacklist routes
+				admin.Post("/users/{userId}/blacklist", h.BlacklistUser)
+				admin.Delete("/users/{userId}/blacklist", h.UnblacklistUser)
+				admin.Post("/blacklist/refresh", h.RefreshBlacklistCache)
+				admin.Get("/blacklist", h.GetBlacklistedUsers)
+				admin.Get("/users/{userId}/stats", h.GetUserStatsById)
+				admin.Post("/users/lookup", h.LookupUser)
+
+				// Admin ban appeal queue
+				admin.Get("/appeals", h.GetAppeals)
+				admin.Post("/appeals/{appealId}/accept", h.AcceptAppeal)
+				admin.Post("/appeals/{appealId}/deny", h.DenyAppeal)
+
+				// Admin shadowban routes
+				admin.Post("/{board}/users/{userId}/shadowban", h.ShadowbanUser)
+				admin.Delete("/{board}/users/{userId}/shadowban", h.UnshadowbanUser)
+				admin.Get("/shadowbans", h.GetShadowbans)
+
+				// Admin view-as (read-only impersonation) routes
+				admin.Post("/users/{userId}/view_as", h.StartViewAs)
+				admin.Get("/view_as", h.GetViewAsSessions)
+
+				// Admin board category routes
+				admin.Post("/categories", h.CreateBoardCategory)
+				admin.Put("/categories/{categoryId}", h.UpdateBoardCategory)
+				admin.Delete("/categories/{categoryId}", h.DeleteBoardCategory)
+
+				// Admin referral stats
+				admin.Get("/referral/stats", h.GetReferralStats)
+
+				// Admin operational stats (disk usage, posting activity)
+				admin.Get("/stats", h.GetAdminStats)
+				admin.Get("/stats/posts_per_hour", h.GetPostsPerHour)
+				admin.Get("/{board}/posters", h.GetTopPosters)
 
-			// Admin blacklist routes
-			admin.Post("/users/{userId}/blacklist", h.BlacklistUser)
-			admin.Delete("/users/{userId}/blacklist", h.UnblacklistUser)
-			admin.Post("/blacklist/refresh", h.RefreshBlacklistCache)
-			admin.Get("/blacklist", h.GetBlacklistedUsers)
+				// Admin pre-moderation queue
+				admin.Get("/{board}/premod", h.GetPremodQueue)
+				admin.Post("/{board}/premod/{postId}/approve", h.ApproveQueuedPost)
+				admin.Post("/{board}/premod/{postId}/reject", h.RejectQueuedPost)
+				admin.Put("/{board}/premod/posters/{userId}/trust", h.SetPosterTrust)
+				admin.Delete("/{board}/premod/posters/{userId}/trust", h.ClearPosterTrust)
 
-			// Admin referral stats
-			admin.Get("/referral/stats", h.GetReferralStats)
+				// Admin read-only maintenance windows
+				admin.Put("/maintenance", h.ScheduleMaintenance)
+				admin.Delete("/maintenance", h.CancelMaintenance)
+
+				// Admin backup runs; a triggered backup runs in the background
+				admin.Get("/backups", h.GetBackups)
+				admin.Post("/backups", h.TriggerBackup)
+
+				// Captured failed posts (failed_post_capture)
+				admin.Get("/failed_posts", h.GetFailedPosts)
+
+				// Admin board mirrors of other instances
+				admin.Get("/mirrors", h.GetBoardMirrors)
+				admin.Put("/{board}/mirror", h.CreateBoardMirror)
+				admin.Delete("/{board}/mirror", h.DeleteBoardMirror)
+			})
 		})
 
 		// Auth routes
 		v1.Route("/auth", func(auth chi.Router) {
+			auth.Use(writeTimeout)
+
 			// Rate-limited email sending endpoints
 			auth.Group(func(authSendingEmail chi.Router) {
 				authSendingEmail.Use(mw.RateLimit(rl.OncePerSecond(), mw.GetEmailFromBody))
@@ -106,6 +217,16 @@ func New(deps *setup.Dependencies) *chi.Mux {
 				authLogin.Use(mw.RateLimit(rl.OncePerSecond(), mw.GetIP))
 				authLogin.Use(mw.GlobalRateLimit(rl.Rps1000()))
 				authLogin.Post("/login", h.Login)
+				authLogin.Post("/appeal", h.SubmitAppeal) // banned users authenticate with credentials here
+			})
+
+			// Session refresh ("remember me") and renewal of active sessions
+			auth.Group(func(authSession chi.Router) {
+				authSession.Use(mw.RateLimit(rl.Rps10(), mw.GetIP))
+				authSession.Use(mw.GlobalRateLimit(rl.Rps1000()))
+				authSession.Post("/refresh", h.Refresh)
+				authSession.With(authMw.NeedAuth()).Post("/renew", h.Renew)
+				authSession.Post("/revoke_sessions", h.RevokeSessions) // Link in new device emails
 			})
 
 			// Invite-based registration (public, rate limited)
@@ -124,17 +245,39 @@ func New(deps *setup.Dependencies) *chi.Mux {
 
 			})
 
+		// Unsubscribe link of digest emails (public, the token authorizes it)
+		v1.Group(func(digestUnsubscribe chi.Router) {
+			digestUnsubscribe.Use(writeTimeout)
+			digestUnsubscribe.Use(mw.RateLimit(rl.OncePerSecond(), mw.GetIP))
+			digestUnsubscribe.Post("/digest/unsubscribe", h.UnsubscribeDigest)
+		})
+
 		// Public board reading routes (no auth required, optional auth for richer experience)
 		v1.Group(func(publicRead chi.Router) {
+			publicRead.Use(readTimeout)
 			publicRead.Use(authMw.OptionalAuth())
-			publicRead.Use(mw.RestrictBoardAccess(deps.AccessData))
+			publicRead.Use(mw.RedirectRenamedBoards(deps.AccessData))
+			publicRead.Use(boardAccess)
 			publicRead.Use(mw.RateLimit(rl.Rps10(), mw.GetIP))
 
 			publicRead.Get("/boards", h.GetBoards)
+			publicRead.Get("/boards/grouped", h.GetGroupedBoards)
+			publicRead.Get("/activity", h.GetSiteActivity)
+			publicRead.Get("/media/sha256/{hash}", h.GetMediaLocations)
 			publicRead.Get("/{board}", h.GetBoard)
 			publicRead.Get("/{board}/last_modified", h.GetBoardLastModified)
+			publicRead.Get("/{board}/modlog", h.GetModLog)
+			publicRead.Get("/{board}/appearance", h.GetBoardAppearance)
+			publicRead.Get("/{board}/snapshots", h.GetThreadSnapshots)
+			publicRead.Get("/{board}/snapshots/{snapshot}", h.GetThreadSnapshot)
+			publicRead.Get("/{board}/resolve/{postNumber}", h.ResolvePostNumber)
 			publicRead.Get("/{board}/{thread}", h.GetThread)
 			publicRead.Get("/{board}/{thread}/last_modified", h.GetThreadLastModified)
+			publicRead.Get("/{board}/{thread}/messages", h.GetThreadUpdates)
+			publicRead.Get("/{board}/{thread}/graph", h.GetThreadGraph)
+			publicRead.Get("/{board}/{thread}/attachments", h.GetThreadAttachments)
+			publicRead.Get("/{board}/{thread}/export", h.ExportThread)
+			publicRead.Get("/{board}/{thread}/oembed", h.GetThreadOEmbed)
 			publicRead.Get("/{board}/{thread}/{message}", h.GetMessage)
 		})
 
@@ -143,23 +286,73 @@ func New(deps *setup.Dependencies) *chi.Mux {
 			loggedIn.Use(authMw.NeedAuth()) // Enforce JWT authentication with blacklist check
 			loggedIn.Use(mw.RateLimit(rl.Rps100(), mw.GetUserIDFromContext))
 
-			// User activity endpoint
-			loggedIn.Get("/users/me/activity", h.GetUserActivity)
+			// Posting, which may carry attachments
+			loggedIn.Group(func(posting chi.Router) {
+				posting.Use(uploadTimeout)
+				posting.Use(boardAccess)
 
-			// Invite management routes (authenticated users only)
-			loggedIn.Route("/invites", func(invites chi.Router) {
-				invites.Get("/", h.GetMyInvites)
-				// Generate invite: 1 per minute per user to prevent spam
-				invites.With(mw.RateLimit(rl.OncePerMinute(), mw.GetUserIDFromContext)).Post("/", h.GenerateInvite)
-				invites.Delete("/{codeHash}", h.RevokeInvite)
+				// CreateThread: 1 per minute per user; retries with an Idempotency-Key are replayed before the limit
+				posting.With(h.CaptureFailedPost, h.Idempotent, mw.RateLimit(createThreadLimiter, mw.GetUserIDFromContext)).Post("/{board}", h.CreateThread)
+				posting.With(mw.RateLimit(uploadLimiter, mw.GetUserIDFromContext)).Post("/{board}/uploads", h.UploadFile)
+				posting.With(h.CaptureFailedPost, h.Idempotent, mw.RateLimit(createMessageLimiter, mw.GetUserIDFromContext)).Post("/{board}/{thread}", h.CreateMessage)
 			})
 
-			loggedIn.Group(func(boards chi.Router) {
-				boards.Use(mw.RestrictBoardAccess(deps.AccessData)) // Restrict access based on board and email domain
+			loggedIn.Group(func(user chi.Router) {
+				user.Use(writeTimeout)
+
+				// User activity endpoint
+				user.Get("/users/me/activity", h.GetUserActivity)
+				user.Get("/me/posts", h.GetUserPosts)
+				user.Get("/me/stats", h.GetUserStats)
+				user.Get("/me/limits", h.GetPostLimits(createThreadLimiter, createMessageLimiter))
+
+				// Notification center
+				user.Route("/me/notifications", func(notifications chi.Router) {
+					notifications.Get("/", h.GetNotifications)
+					notifications.Get("/unread", h.GetUnreadNotifications)
+					notifications.Post("/read", h.MarkAllNotificationsRead)
+					notifications.Post("/{id}/read", h.MarkNotificationRead)
+				})
+
+				// Email digest preferences
+				user.Get("/me/digest", h.GetDigest)
+				user.Put("/me/digest", h.SetDigest)
+
+				// Watched threads with unread counts
+				user.Get("/me/watched", h.GetWatchedThreads)
+
+				// Bookmarked messages
+				user.Route("/me/bookmarks", func(bookmarks chi.Router) {
+					bookmarks.Get("/", h.GetBookmarks)
+					bookmarks.Post("/", h.AddBookmark)
+					bookmarks.Delete("/{board}/{thread}/{message}", h.DeleteBookmark)
+				})
+
+				// Browser push notifications
+				user.Post("/me/push_subscriptions", h.SubscribePush)
+				user.Delete("/me/push_subscriptions", h.UnsubscribePush)
+
+				// Invite management routes (authenticated users only)
+				user.Route("/invites", func(invites chi.Router) {
+					invites.Get("/", h.GetMyInvites)
+					// Generate invite: 1 per minute per user to prevent spam
+					invites.With(mw.RateLimit(rl.OncePerMinute(), mw.GetUserIDFromContext)).Post("/", h.GenerateInvite)
+					invites.Delete("/{codeHash}", h.RevokeInvite)
+				})
+
+				user.Group(func(boards chi.Router) {
+					boards.Use(mw.RedirectRenamedBoards(deps.AccessData))
+					boards.Use(boardAccess) // Restrict access based on board and email domain
 
-				// CreateThread: 1 per minute per user
-				boards.With(mw.RateLimit(rl.OncePerMinute(), mw.GetUserIDFromContext)).Post("/{board}", h.CreateThread)
-				boards.With(mw.RateLimit(rl.OncePerSecond(), mw.GetUserIDFromContext)).Post("/{board}/{thread}", h.CreateMessage)
+					boards.Patch("/{board}/{thread}", h.EditThreadTitle)
+					boards.Put("/{board}/{thread}/successor", h.SetThreadSuccessor)
+					boards.Delete("/{board}/{thread}/{message}", h.DeleteOwnMessage)
+					boards.Put("/{board}/{thread}/watch", h.WatchThread)
+					boards.Delete("/{board}/{thread}/watch", h.UnwatchThread)
+					boards.Put("/{board}/{thread}/read", h.MarkThreadRead)
+					// Rendering embeds every thumbnail, so snapshots are limited to 1 per minute per user
+					boards.With(mw.RateLimit(rl.OncePerMinute(), mw.GetUserIDFromContext)).Post("/{board}/{thread}/snapshots", h.CreateThreadSnapshot)
+				})
 			})
 		})
 	})
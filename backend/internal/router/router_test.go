@@ -0,0 +1,152 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/backend/internal/handler"
+	"github.com/itchan-dev/itchan/backend/internal/setup"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/jwt"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/middleware/board_access"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// boardAccessExempt lists the {board} routes that are deliberately not behind
+// the board access check.
+var boardAccessExempt = map[string]string{
+	"DELETE /v1/me/bookmarks/{board}/{thread}/{message}": "removes the user's own bookmark, which stays possible after losing access",
+}
+
+type accessRules struct{}
+
+func (accessRules) GetBoardsWithPermissions() (map[string][]string, error) {
+	return map[string][]string{"private": {"example.com"}}, nil
+}
+
+func (accessRules) GetBoardsPendingDeletion() ([]string, error) {
+	return []string{"old"}, nil
+}
+
+func (accessRules) GetBoardRedirects() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+// boardRoutes returns the method and pattern of every route with a {board}
+// parameter.
+func boardRoutes(t *testing.T, r *chi.Mux) [][2]string {
+	t.Helper()
+	var routes [][2]string
+	err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(route, "/")
+		if strings.Contains(route, "{board}") {
+			if _, ok := boardAccessExempt[method+" "+route]; !ok {
+				routes = append(routes, [2]string{method, route})
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return routes
+}
+
+// boardPath fills the parameters of a route pattern, with board as {board}.
+func boardPath(route, board string) string {
+	parts := strings.Split(route, "/")
+	for i, part := range parts {
+		if part == "{board}" {
+			parts[i] = board
+		} else if strings.HasPrefix(part, "{") {
+			parts[i] = "1"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// newTestRouter returns the router with a board "private" restricted to
+// example.com and a board "old" pending deletion, and a token of a user of
+// other.com.
+func newTestRouter(t *testing.T, hideRestricted bool) (*chi.Mux, string) {
+	t.Helper()
+	cfg := &config.Config{Public: config.Public{
+		ReadRequestTimeout:       time.Minute,
+		WriteRequestTimeout:      time.Minute,
+		UploadRequestTimeout:     time.Minute,
+		MaxAttachmentsPerMessage: 4,
+		HideRestrictedBoards:     hideRestricted,
+	}}
+	jwtService := jwt.New("test_secret", time.Hour)
+	access := board_access.New()
+	require.NoError(t, access.Update(accessRules{}))
+	r := New(&setup.Dependencies{
+		Handler:        &handler.Handler{},
+		AccessData:     access,
+		AuthMiddleware: mw.NewAuth(jwtService, nil, false),
+		Config:         cfg,
+	})
+
+	outsider, err := jwtService.NewToken(domain.User{Id: 7, EmailDomain: "other.com", CreatedAt: time.Now()})
+	require.NoError(t, err)
+	return r, outsider
+}
+
+func send(r *chi.Mux, method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestBoardRoutesRestrictAccess(t *testing.T) {
+	r, outsider := newTestRouter(t, false)
+	routes := boardRoutes(t, r)
+	require.NotEmpty(t, routes)
+
+	for _, route := range routes {
+		method, pattern := route[0], route[1]
+		t.Run(method+" "+pattern, func(t *testing.T) {
+			assert.Equal(t, http.StatusUnauthorized, send(r, method, boardPath(pattern, "private"), ""),
+				"anonymous users must sign in for a restricted board")
+			assert.Equal(t, http.StatusForbidden, send(r, method, boardPath(pattern, "private"), outsider),
+				"users of other email domains are refused")
+
+			want := http.StatusNotFound
+			if strings.HasPrefix(pattern, "/v1/admin/") {
+				want = http.StatusForbidden // refused as a non-admin before the board is looked at
+			}
+			assert.Equal(t, want, send(r, method, boardPath(pattern, "old"), outsider),
+				"boards pending deletion are hidden")
+		})
+	}
+}
+
+func TestBoardRoutesHideRestrictedBoards(t *testing.T) {
+	r, outsider := newTestRouter(t, true)
+
+	for _, route := range boardRoutes(t, r) {
+		method, pattern := route[0], route[1]
+		if strings.HasPrefix(pattern, "/v1/admin/") {
+			continue // non-admins are refused before the board is looked at
+		}
+		t.Run(method+" "+pattern, func(t *testing.T) {
+			path := boardPath(pattern, "private")
+			if strings.HasPrefix(pattern, "/v1/{board}") && method == http.MethodGet {
+				assert.Equal(t, http.StatusNotFound, send(r, method, path, ""))
+			} else {
+				// Logged-in routes ask anonymous users to sign in, restricted board or not
+				assert.Equal(t, http.StatusUnauthorized, send(r, method, path, ""))
+			}
+			assert.Equal(t, http.StatusNotFound, send(r, method, path, outsider))
+		})
+	}
+}
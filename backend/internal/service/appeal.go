@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// AppealService lets banned users contest their suspension and moderators
+// work through the resulting queue.
+type AppealService interface {
+	Submit(ctx context.Context, creds domain.Credentials, text string) (domain.AppealId, error)
+	List(ctx context.Context, status domain.AppealStatus, page int) ([]domain.Appeal, error)
+	Accept(ctx context.Context, id domain.AppealId, moderator domain.UserId, response string) error
+	Deny(ctx context.Context, id domain.AppealId, moderator domain.UserId, response string) error
+}
+
+// AppealStorage defines storage interface for ban appeals
+type AppealStorage interface {
+	CreateAppeal(ctx context.Context, userId domain.UserId, text string) (domain.AppealId, error)
+	GetAppeal(ctx context.Context, id domain.AppealId) (domain.Appeal, error)
+	GetLatestAppeal(ctx context.Context, userId domain.UserId) (domain.Appeal, error)
+	GetAppeals(ctx context.Context, status domain.AppealStatus, limit, offset int) ([]domain.Appeal, error)
+	DecideAppeal(ctx context.Context, id domain.AppealId, status domain.AppealStatus, response string, decidedBy domain.UserId) error
+}
+
+type Appeal struct {
+	storage AppealStorage
+	auth    AuthService
+	cfg     *config.Public
+}
+
+// NewAppeal creates a new Appeal service. Accepting an appeal lifts the ban
+// through auth so the blacklist cache is refreshed as well.
+func NewAppeal(storage AppealStorage, auth AuthService, cfg *config.Public) AppealService {
+	return &Appeal{
+		storage: storage,
+		auth:    auth,
+		cfg:     cfg,
+	}
+}
+
+// Submit files an appeal for the user identified by creds. Banned users
+// cannot obtain a token, so the appeal is authenticated with credentials.
+// Only one appeal is allowed per suspension.
+func (a *Appeal) Submit(ctx context.Context, creds domain.Credentials, text string) (domain.AppealId, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, &errors.ErrorWithStatusCode{Message: "Appeal text is required", StatusCode: http.StatusBadRequest}
+	}
+	if utf8.RuneCountInString(text) > a.cfg.AppealTextMaxLen {
+		return 0, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Appeal text must be at most %d characters", a.cfg.AppealTextMaxLen),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	user, err := a.auth.Authenticate(ctx, creds)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := a.storage.CreateAppeal(ctx, user.Id, text)
+	if err != nil {
+		if e, ok := err.(*errors.ErrorWithStatusCode); ok && e.StatusCode == http.StatusConflict {
+			return 0, a.describePreviousAppeal(ctx, user.Id, e)
+		}
+		return 0, err
+	}
+
+	logger.Log.Info("ban appeal submitted", "appeal_id", id, "user_id", user.Id)
+	return id, nil
+}
+
+// describePreviousAppeal extends a duplicate-appeal error with the outcome of
+// the earlier appeal, which is the only way a banned user can learn it.
+func (a *Appeal) describePreviousAppeal(ctx context.Context, userId domain.UserId, conflict *errors.ErrorWithStatusCode) error {
+	prev, err := a.storage.GetLatestAppeal(ctx, userId)
+	if err != nil {
+		return conflict
+	}
+	msg := fmt.Sprintf("%s (status: %s)", conflict.Message, prev.Status)
+	if prev.Response != "" {
+		msg += ": " + prev.Response
+	}
+	return &errors.ErrorWithStatusCode{Message: msg, StatusCode: http.StatusConflict}
+}
+
+func (a *Appeal) List(ctx context.Context, status domain.AppealStatus, page int) ([]domain.Appeal, error) {
+	switch status {
+	case "":
+		status = domain.AppealPending
+	case domain.AppealPending, domain.AppealAccepted, domain.AppealDenied:
+	default:
+		return nil, &errors.ErrorWithStatusCode{Message: fmt.Sprintf("Unknown appeal status '%s'", status), StatusCode: http.StatusBadRequest}
+	}
+	page = max(1, page)
+	limit := a.cfg.AppealsPageLimit
+	offset := (page - 1) * limit
+	return a.storage.GetAppeals(ctx, status, limit, offset)
+}
+
+// Accept lifts the user's ban and then marks the appeal accepted, so an
+// unban that fails leaves the appeal pending and the accept can be retried.
+func (a *Appeal) Accept(ctx context.Context, id domain.AppealId, moderator domain.UserId, response string) error {
+	appeal, err := a.storage.GetAppeal(ctx, id)
+	if err != nil {
+		return err
+	}
+	if appeal.Status != domain.AppealPending {
+		return &errors.ErrorWithStatusCode{Message: fmt.Sprintf("Appeal %d was already decided", id), StatusCode: http.StatusConflict}
+	}
+	if err := a.validateResponse(response); err != nil {
+		return err
+	}
+	if err := a.auth.UnblacklistUser(ctx, appeal.UserId); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return a.decide(ctx, id, domain.AppealAccepted, moderator, response)
+}
+
+func (a *Appeal) Deny(ctx context.Context, id domain.AppealId, moderator domain.UserId, response string) error {
+	return a.decide(ctx, id, domain.AppealDenied, moderator, response)
+}
+
+func (a *Appeal) decide(ctx context.Context, id domain.AppealId, status domain.AppealStatus, moderator domain.UserId, response string) error {
+	if err := a.validateResponse(response); err != nil {
+		return err
+	}
+	if err := a.storage.DecideAppeal(ctx, id, status, strings.TrimSpace(response), moderator); err != nil {
+		return err
+	}
+	logger.Log.Info("ban appeal decided", "appeal_id", id, "status", status, "moderator_id", moderator)
+	return nil
+}
+
+func (a *Appeal) validateResponse(response string) error {
+	if utf8.RuneCountInString(strings.TrimSpace(response)) > a.cfg.AppealTextMaxLen {
+		return &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Response must be at most %d characters", a.cfg.AppealTextMaxLen),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockAppealStorage struct {
+	CreateAppealFunc    func(userId domain.UserId, text string) (domain.AppealId, error)
+	GetAppealFunc       func(id domain.AppealId) (domain.Appeal, error)
+	GetLatestAppealFunc func(userId domain.UserId) (domain.Appeal, error)
+	GetAppealsFunc      func(status domain.AppealStatus, limit, offset int) ([]domain.Appeal, error)
+	DecideAppealFunc    func(id domain.AppealId, status domain.AppealStatus, response string, decidedBy domain.UserId) error
+}
+
+func (m *MockAppealStorage) CreateAppeal(ctx context.Context, userId domain.UserId, text string) (domain.AppealId, error) {
+	if m.CreateAppealFunc != nil {
+		return m.CreateAppealFunc(userId, text)
+	}
+	return 1, nil
+}
+
+func (m *MockAppealStorage) GetAppeal(ctx context.Context, id domain.AppealId) (domain.Appeal, error) {
+	if m.GetAppealFunc != nil {
+		return m.GetAppealFunc(id)
+	}
+	return domain.Appeal{Id: id, Status: domain.AppealPending}, nil
+}
+
+func (m *MockAppealStorage) GetLatestAppeal(ctx context.Context, userId domain.UserId) (domain.Appeal, error) {
+	if m.GetLatestAppealFunc != nil {
+		return m.GetLatestAppealFunc(userId)
+	}
+	return domain.Appeal{UserId: userId}, nil
+}
+
+func (m *MockAppealStorage) GetAppeals(ctx context.Context, status domain.AppealStatus, limit, offset int) ([]domain.Appeal, error) {
+	if m.GetAppealsFunc != nil {
+		return m.GetAppealsFunc(status, limit, offset)
+	}
+	return nil, nil
+}
+
+func (m *MockAppealStorage) DecideAppeal(ctx context.Context, id domain.AppealId, status domain.AppealStatus, response string, decidedBy domain.UserId) error {
+	if m.DecideAppealFunc != nil {
+		return m.DecideAppealFunc(id, status, response, decidedBy)
+	}
+	return nil
+}
+
+// mockAppealAuth implements the parts of AuthService the appeal service uses.
+type mockAppealAuth struct {
+	AuthService
+	authenticateFunc    func(creds domain.Credentials) (domain.User, error)
+	unblacklistUserFunc func(userId domain.UserId) error
+}
+
+func (m *mockAppealAuth) Authenticate(ctx context.Context, creds domain.Credentials) (domain.User, error) {
+	if m.authenticateFunc != nil {
+		return m.authenticateFunc(creds)
+	}
+	return domain.User{Id: 7}, nil
+}
+
+func (m *mockAppealAuth) UnblacklistUser(ctx context.Context, userId domain.UserId) error {
+	if m.unblacklistUserFunc != nil {
+		return m.unblacklistUserFunc(userId)
+	}
+	return nil
+}
+
+func requireStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	require.Error(t, err)
+	var errWithStatus *internal_errors.ErrorWithStatusCode
+	require.True(t, errors.As(err, &errWithStatus))
+	assert.Equal(t, status, errWithStatus.StatusCode)
+}
+
+// --- Tests ---
+
+func TestAppealSubmit(t *testing.T) {
+	cfg := &config.Public{AppealTextMaxLen: 10}
+	creds := domain.Credentials{Email: "user@example.com", Password: "password"}
+
+	t.Run("success", func(t *testing.T) {
+		var gotUser domain.UserId
+		var gotText string
+		storage := &MockAppealStorage{
+			CreateAppealFunc: func(userId domain.UserId, text string) (domain.AppealId, error) {
+				gotUser, gotText = userId, text
+				return 42, nil
+			},
+		}
+		id, err := NewAppeal(storage, &mockAppealAuth{}, cfg).Submit(context.Background(), creds, "  sorry  ")
+		require.NoError(t, err)
+		assert.Equal(t, domain.AppealId(42), id)
+		assert.Equal(t, domain.UserId(7), gotUser)
+		assert.Equal(t, "sorry", gotText)
+	})
+
+	t.Run("text validation", func(t *testing.T) {
+		s := NewAppeal(&MockAppealStorage{}, &mockAppealAuth{}, cfg)
+		_, err := s.Submit(context.Background(), creds, "   ")
+		requireStatus(t, err, 400)
+		_, err = s.Submit(context.Background(), creds, "this is far too long")
+		requireStatus(t, err, 400)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		auth := &mockAppealAuth{authenticateFunc: func(domain.Credentials) (domain.User, error) {
+			return domain.User{}, &internal_errors.ErrorWithStatusCode{Message: "Invalid credentials", StatusCode: 401}
+		}}
+		storage := &MockAppealStorage{
+			CreateAppealFunc: func(domain.UserId, string) (domain.AppealId, error) {
+				t.Fatal("appeal must not be stored")
+				return 0, nil
+			},
+		}
+		_, err := NewAppeal(storage, auth, cfg).Submit(context.Background(), creds, "sorry")
+		requireStatus(t, err, 401)
+	})
+
+	t.Run("duplicate reports previous outcome", func(t *testing.T) {
+		storage := &MockAppealStorage{
+			CreateAppealFunc: func(domain.UserId, string) (domain.AppealId, error) {
+				return 0, &internal_errors.ErrorWithStatusCode{Message: "already submitted", StatusCode: 409}
+			},
+			GetLatestAppealFunc: func(domain.UserId) (domain.Appeal, error) {
+				return domain.Appeal{Status: domain.AppealDenied, Response: "no"}, nil
+			},
+		}
+		_, err := NewAppeal(storage, &mockAppealAuth{}, cfg).Submit(context.Background(), creds, "sorry")
+		requireStatus(t, err, 409)
+		assert.Equal(t, "already submitted (status: denied): no", err.Error())
+	})
+}
+
+func TestAppealList(t *testing.T) {
+	cfg := &config.Public{AppealsPageLimit: 20}
+
+	t.Run("defaults to pending and paginates", func(t *testing.T) {
+		var gotStatus domain.AppealStatus
+		var gotLimit, gotOffset int
+		storage := &MockAppealStorage{
+			GetAppealsFunc: func(status domain.AppealStatus, limit, offset int) ([]domain.Appeal, error) {
+				gotStatus, gotLimit, gotOffset = status, limit, offset
+				return nil, nil
+			},
+		}
+		_, err := NewAppeal(storage, &mockAppealAuth{}, cfg).List(context.Background(), "", 3)
+		require.NoError(t, err)
+		assert.Equal(t, domain.AppealPending, gotStatus)
+		assert.Equal(t, 20, gotLimit)
+		assert.Equal(t, 40, gotOffset)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		_, err := NewAppeal(&MockAppealStorage{}, &mockAppealAuth{}, cfg).List(context.Background(), "bogus", 1)
+		requireStatus(t, err, 400)
+	})
+}
+
+func TestAppealDecide(t *testing.T) {
+	cfg := &config.Public{AppealTextMaxLen: 100}
+
+	t.Run("accept lifts ban", func(t *testing.T) {
+		var decided domain.AppealStatus
+		var unbanned domain.UserId
+		storage := &MockAppealStorage{
+			GetAppealFunc: func(id domain.AppealId) (domain.Appeal, error) {
+				return domain.Appeal{Id: id, UserId: 9, Status: domain.AppealPending}, nil
+			},
+			DecideAppealFunc: func(id domain.AppealId, status domain.AppealStatus, response string, decidedBy domain.UserId) error {
+				decided = status
+				assert.Equal(t, "welcome back", response)
+				assert.Equal(t, domain.UserId(1), decidedBy)
+				return nil
+			},
+		}
+		auth := &mockAppealAuth{unblacklistUserFunc: func(userId domain.UserId) error {
+			unbanned = userId
+			return nil
+		}}
+		require.NoError(t, NewAppeal(storage, auth, cfg).Accept(context.Background(), 5, 1, " welcome back "))
+		assert.Equal(t, domain.AppealAccepted, decided)
+		assert.Equal(t, domain.UserId(9), unbanned)
+	})
+
+	t.Run("accept tolerates ban already lifted", func(t *testing.T) {
+		auth := &mockAppealAuth{unblacklistUserFunc: func(domain.UserId) error {
+			return &internal_errors.ErrorWithStatusCode{Message: "not found", StatusCode: 404}
+		}}
+		require.NoError(t, NewAppeal(&MockAppealStorage{}, auth, cfg).Accept(context.Background(), 5, 1, ""))
+	})
+
+	t.Run("deny keeps ban", func(t *testing.T) {
+		var decided domain.AppealStatus
+		storage := &MockAppealStorage{
+			DecideAppealFunc: func(id domain.AppealId, status domain.AppealStatus, response string, decidedBy domain.UserId) error {
+				decided = status
+				return nil
+			},
+		}
+		auth := &mockAppealAuth{unblacklistUserFunc: func(domain.UserId) error {
+			t.Fatal("deny must not lift the ban")
+			return nil
+		}}
+		require.NoError(t, NewAppeal(storage, auth, cfg).Deny(context.Background(), 5, 1, "no"))
+		assert.Equal(t, domain.AppealDenied, decided)
+	})
+
+	t.Run("already decided", func(t *testing.T) {
+		storage := &MockAppealStorage{
+			GetAppealFunc: func(id domain.AppealId) (domain.Appeal, error) {
+				return domain.Appeal{Id: id, UserId: 9, Status: domain.AppealDenied}, nil
+			},
+			DecideAppealFunc: func(domain.AppealId, domain.AppealStatus, string, domain.UserId) error {
+				t.Fatal("decided appeal must not be decided again")
+				return nil
+			},
+		}
+		auth := &mockAppealAuth{unblacklistUserFunc: func(domain.UserId) error {
+			t.Fatal("ban must not be lifted for a decided appeal")
+			return nil
+		}}
+		requireStatus(t, NewAppeal(storage, auth, cfg).Accept(context.Background(), 5, 1, ""), 409)
+	})
+
+	t.Run("failed unban leaves the appeal pending", func(t *testing.T) {
+		storage := &MockAppealStorage{
+			DecideAppealFunc: func(domain.AppealId, domain.AppealStatus, string, domain.UserId) error {
+				t.Fatal("appeal must stay pending when the ban could not be lifted")
+				return nil
+			},
+		}
+		auth := &mockAppealAuth{unblacklistUserFunc: func(domain.UserId) error {
+			return errors.New("database unavailable")
+		}}
+		require.Error(t, NewAppeal(storage, auth, cfg).Accept(context.Background(), 5, 1, ""))
+	})
+}
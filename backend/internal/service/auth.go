@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -16,21 +17,32 @@ import (
 )
 
 type AuthService interface {
-	Register(creds domain.Credentials) error
-	CheckConfirmationCode(email domain.Email, confirmationCode string, refSource string) error
-	Login(creds domain.Credentials) (string, error)
+	Register(ctx context.Context, creds domain.Credentials) error
+	CheckConfirmationCode(ctx context.Context, email domain.Email, confirmationCode string, refSource string) error
+	Login(ctx context.Context, creds domain.Credentials) (string, error)
+	LoginRemembered(ctx context.Context, creds domain.Credentials) (accessToken, refreshToken string, err error)
+	Refresh(ctx context.Context, refreshToken string) (accessToken, newRefreshToken string, err error)
+	Renew(ctx context.Context, userId domain.UserId) (string, error)
+	// RevokeSessions signs out everywhere the user a new device email with
+	// the token was sent to
+	RevokeSessions(ctx context.Context, token string) error
+	Authenticate(ctx context.Context, creds domain.Credentials) (domain.User, error)
 
 	// Invite system methods
-	RegisterWithInvite(inviteCode string, password domain.Password, refSource string) (string, error)
-	GenerateInvite(user domain.User) (*domain.InviteCodeWithPlaintext, error)
-	GetUserInvites(userId domain.UserId, page int) ([]domain.InviteCode, error)
-	RevokeInvite(userId domain.UserId, codeHash string) error
+	RegisterWithInvite(ctx context.Context, inviteCode string, password domain.Password, refSource string) (string, error)
+	GenerateInvite(ctx context.Context, user domain.User) (*domain.InviteCodeWithPlaintext, error)
+	GetUserInvites(ctx context.Context, userId domain.UserId, page int) ([]domain.InviteCode, error)
+	RevokeInvite(ctx context.Context, userId domain.UserId, codeHash string) error
 
 	// Admin blacklist operations
-	BlacklistUser(userId domain.UserId, reason string, blacklistedBy domain.UserId) error
-	UnblacklistUser(userId domain.UserId) error
-	GetBlacklistedUsersWithDetails(page int) ([]domain.BlacklistEntry, error)
-	RefreshBlacklistCache() error
+	BlacklistUser(ctx context.Context, userId domain.UserId, reason string, blacklistedBy domain.UserId) error
+	BlacklistUserUntil(ctx context.Context, userId domain.UserId, reason string, expiresAt time.Time) error
+	UnblacklistUser(ctx context.Context, userId domain.UserId) error
+	GetBlacklistedUsersWithDetails(ctx context.Context, page int) ([]domain.BlacklistEntry, error)
+	RefreshBlacklistCache(ctx context.Context) error
+
+	// FindUserByEmail looks a user up by exact email for the admin panel
+	FindUserByEmail(ctx context.Context, email domain.Email) (domain.User, error)
 }
 
 type CredentialsValidator interface {
@@ -51,32 +63,43 @@ type Auth struct {
 type EmailCrypto interface {
 	Encrypt(email string) ([]byte, error)
 	Hash(email string) []byte
+	BlindIndex(email string) []byte
 	ExtractDomain(email string) (string, error)
 }
 
 type AuthStorage interface {
-	SaveUser(user domain.User) (domain.UserId, error)
-	User(emailHash []byte) (domain.User, error)
-	UpdatePassword(emailHash []byte, newPasswordHash domain.Password) error
-	DeleteUser(emailHash []byte) error
-	SaveConfirmationData(data domain.ConfirmationData) error
-	ConfirmationData(emailHash []byte) (domain.ConfirmationData, error)
-	DeleteConfirmationData(emailHash []byte) error
+	SaveUser(ctx context.Context, user domain.User) (domain.UserId, error)
+	User(ctx context.Context, emailHash []byte) (domain.User, error)
+	GetUserById(ctx context.Context, id domain.UserId) (domain.User, error)
+	UserByEmailIndex(ctx context.Context, emailIndex []byte) (domain.User, error)
+	SetEmailIndex(ctx context.Context, userId domain.UserId, emailIndex []byte) error
+	UpdatePassword(ctx context.Context, emailHash []byte, newPasswordHash domain.Password) error
+	DeleteUser(ctx context.Context, emailHash []byte) error
+	SaveConfirmationData(ctx context.Context, data domain.ConfirmationData) error
+	ConfirmationData(ctx context.Context, emailHash []byte) (domain.ConfirmationData, error)
+	DeleteConfirmationData(ctx context.Context, emailHash []byte) error
 
 	// Invite code operations
-	SaveInviteCode(invite domain.InviteCode) error
-	InviteCodeByHash(codeHash string) (domain.InviteCode, error)
-	GetInvitesByUser(userId domain.UserId, limit, offset int) ([]domain.InviteCode, error)
-	CountActiveInvites(userId domain.UserId) (int, error)
-	MarkInviteUsed(codeHash string, usedBy domain.UserId) error
-	DeleteInviteCode(codeHash string) error
-	DeleteInvitesByUser(userId domain.UserId) error
+	SaveInviteCode(ctx context.Context, invite domain.InviteCode) error
+	InviteCodeByHash(ctx context.Context, codeHash string) (domain.InviteCode, error)
+	GetInvitesByUser(ctx context.Context, userId domain.UserId, limit, offset int) ([]domain.InviteCode, error)
+	CountActiveInvites(ctx context.Context, userId domain.UserId) (int, error)
+	MarkInviteUsed(ctx context.Context, codeHash string, usedBy domain.UserId) error
+	DeleteInviteCode(ctx context.Context, codeHash string) error
+	DeleteInvitesByUser(ctx context.Context, userId domain.UserId) error
 
 	// Admin blacklist operations
-	IsUserBlacklisted(userId domain.UserId) (bool, error)
-	BlacklistUser(userId domain.UserId, reason string, blacklistedBy domain.UserId) error
-	UnblacklistUser(userId domain.UserId) error
-	GetBlacklistedUsersWithDetails(limit, offset int) ([]domain.BlacklistEntry, error)
+	IsUserBlacklisted(ctx context.Context, userId domain.UserId) (bool, error)
+	BlacklistUser(ctx context.Context, userId domain.UserId, reason string, blacklistedBy domain.UserId) error
+	BlacklistUserUntil(ctx context.Context, userId domain.UserId, reason string, expiresAt time.Time) error
+	UnblacklistUser(ctx context.Context, userId domain.UserId) error
+	GetBlacklistedUsersWithDetails(ctx context.Context, limit, offset int) ([]domain.BlacklistEntry, error)
+
+	// Login devices and session revocation
+	SaveLoginDevice(ctx context.Context, device domain.LoginDevice) (bool, error)
+	CountLoginDevices(ctx context.Context, userId domain.UserId) (int, error)
+	RevokeSessionsByDeviceToken(ctx context.Context, tokenHash string) (domain.UserId, error)
+	SessionsRevokedAt(ctx context.Context, userId domain.UserId) (time.Time, error)
 }
 
 type Email interface {
@@ -86,6 +109,8 @@ type Email interface {
 
 type Jwt interface {
 	NewToken(user domain.User) (string, error)
+	NewRefreshToken(userId domain.UserId, ttl time.Duration) (string, error)
+	DecodeRefreshToken(jwtStr string) (domain.UserId, time.Time, error)
 }
 
 func NewAuth(storage AuthStorage, email Email, jwt Jwt, cfg *config.Public, blacklistCache *blacklist.Cache, emailCrypto EmailCrypto, credentialsValidator CredentialsValidator, allowedRefs sharedutils.AllowedSources) *Auth {
@@ -101,7 +126,7 @@ func NewAuth(storage AuthStorage, email Email, jwt Jwt, cfg *config.Public, blac
 	}
 }
 
-func (a *Auth) Register(creds domain.Credentials) error {
+func (a *Auth) Register(ctx context.Context, creds domain.Credentials) error {
 	email := strings.ToLower(creds.Email)
 
 	var err error
@@ -115,7 +140,10 @@ func (a *Auth) Register(creds domain.Credentials) error {
 		return err
 	}
 
-	// Check domain restrictions
+	emailHash := a.emailCrypto.Hash(email)
+
+	// Check domain restrictions; accounts imported by an admin are exempt so
+	// their owners can set the first password whatever their domain
 	if len(a.cfg.AllowedRegistrationDomains) > 0 {
 		emailDomain, err := a.emailCrypto.ExtractDomain(email)
 		if err != nil {
@@ -137,24 +165,28 @@ func (a *Auth) Register(creds domain.Credentials) error {
 		}
 
 		if !allowed {
-			logger.Log.Info("registration blocked - domain not allowed",
-				"domain", emailDomain)
-			return &errors.ErrorWithStatusCode{
-				Message:    "Registration is restricted to specific email domains. Try to use /invites.txt",
-				StatusCode: http.StatusForbidden,
+			user, err := a.storage.User(ctx, emailHash)
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			if err != nil || user.PassHash != provisionedPassHash {
+				logger.Log.Info("registration blocked - domain not allowed",
+					"domain", emailDomain)
+				return &errors.ErrorWithStatusCode{
+					Message:    "Registration is restricted to specific email domains. Try to use /invites.txt",
+					StatusCode: http.StatusForbidden,
+				}
 			}
 		}
 	}
 
-	emailHash := a.emailCrypto.Hash(email)
-
-	cData, err := a.storage.ConfirmationData(emailHash)
+	cData, err := a.storage.ConfirmationData(ctx, emailHash)
 	if err != nil && !errors.IsNotFound(err) {
 		return err
 	}
 	if err == nil {
 		if cData.Expires.Before(time.Now()) {
-			if err := a.storage.DeleteConfirmationData(emailHash); err != nil {
+			if err := a.storage.DeleteConfirmationData(ctx, emailHash); err != nil {
 				return err
 			}
 		} else {
@@ -192,7 +224,7 @@ func (a *Auth) Register(creds domain.Credentials) error {
 		return err
 	}
 
-	err = a.storage.SaveConfirmationData(domain.ConfirmationData{
+	err = a.storage.SaveConfirmationData(ctx, domain.ConfirmationData{
 		EmailHash:            emailHash,
 		PasswordHash:         domain.Password(passHash),
 		ConfirmationCodeHash: string(confirmationCodeHash),
@@ -206,7 +238,7 @@ func (a *Auth) Register(creds domain.Credentials) error {
 	return nil
 }
 
-func (a *Auth) CheckConfirmationCode(email domain.Email, confirmationCode string, refSource string) error {
+func (a *Auth) CheckConfirmationCode(ctx context.Context, email domain.Email, confirmationCode string, refSource string) error {
 	email = strings.ToLower(email)
 
 	if err := a.email.IsCorrect(email); err != nil {
@@ -215,7 +247,7 @@ func (a *Auth) CheckConfirmationCode(email domain.Email, confirmationCode string
 
 	emailHash := a.emailCrypto.Hash(email)
 
-	data, err := a.storage.ConfirmationData(emailHash)
+	data, err := a.storage.ConfirmationData(ctx, emailHash)
 	if err != nil {
 		return err
 	}
@@ -226,7 +258,7 @@ func (a *Auth) CheckConfirmationCode(email domain.Email, confirmationCode string
 		logger.Log.Warn("failed confirmation code attempt", "email_hash_prefix", fmt.Sprintf("%x", emailHash[:8]), "error", err)
 		return &errors.ErrorWithStatusCode{Message: "Wrong confirmation code", StatusCode: http.StatusBadRequest}
 	}
-	_, err = a.storage.User(emailHash)
+	_, err = a.storage.User(ctx, emailHash)
 	if err != nil {
 		e, ok := err.(*errors.ErrorWithStatusCode)
 		if ok && e.StatusCode == http.StatusNotFound {
@@ -243,10 +275,11 @@ func (a *Auth) CheckConfirmationCode(email domain.Email, confirmationCode string
 			if refSource != "" && a.allowedRefs.IsAllowed(refSource) {
 				referralSource = refSource
 			}
-			userId, err := a.storage.SaveUser(domain.User{
+			userId, err := a.storage.SaveUser(ctx, domain.User{
 				EmailEncrypted: emailEncrypted,
 				EmailDomain:    emailDomain,
 				EmailHash:      emailHash,
+				EmailIndex:     a.emailCrypto.BlindIndex(email),
 				PassHash:       data.PasswordHash,
 				Admin:          false,
 				ReferralSource: referralSource,
@@ -259,78 +292,213 @@ func (a *Auth) CheckConfirmationCode(email domain.Email, confirmationCode string
 			return err
 		}
 	} else {
-		if err := a.storage.UpdatePassword(emailHash, data.PasswordHash); err != nil {
+		if err := a.storage.UpdatePassword(ctx, emailHash, data.PasswordHash); err != nil {
 			return err
 		}
 		logger.Log.Info("password updated", "email_hash_prefix", fmt.Sprintf("%x", emailHash[:8]))
 	}
-	if err := a.storage.DeleteConfirmationData(emailHash); err != nil {
+	if err := a.storage.DeleteConfirmationData(ctx, emailHash); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (a *Auth) Login(creds domain.Credentials) (string, error) {
+// Authenticate verifies credentials and returns the matching user.
+// Unlike Login it does not reject suspended accounts, so it can back flows
+// that banned users still need (e.g. submitting an appeal).
+func (a *Auth) Authenticate(ctx context.Context, creds domain.Credentials) (domain.User, error) {
 	email := strings.ToLower(creds.Email)
 	password := creds.Password
 
 	err := a.email.IsCorrect(email)
 	if err != nil {
-		return "", err
+		return domain.User{}, err
 	}
 
 	emailHash := a.emailCrypto.Hash(email)
 
 	dummyHash := []byte("$2a$10$xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx") // prevent timing attack
-	user, err := a.storage.User(emailHash)
+	user, err := a.storage.User(ctx, emailHash)
 	if err != nil {
 		bcrypt.CompareHashAndPassword(dummyHash, []byte(password)) // constant time
 		e, ok := err.(*errors.ErrorWithStatusCode)
 		if ok && e.StatusCode == http.StatusNotFound {
-			return "", &errors.ErrorWithStatusCode{
+			return domain.User{}, &errors.ErrorWithStatusCode{
 				Message:    "Invalid credentials",
 				StatusCode: http.StatusUnauthorized,
 			}
 		}
-		return "", err
+		return domain.User{}, err
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.PassHash), []byte(password))
 	if err != nil {
 		logger.Log.Warn("failed login attempt - invalid password", "user_id", user.Id)
-		return "", &errors.ErrorWithStatusCode{Message: "Invalid credentials", StatusCode: http.StatusUnauthorized}
+		return domain.User{}, &errors.ErrorWithStatusCode{Message: "Invalid credentials", StatusCode: http.StatusUnauthorized}
+	}
+
+	// Users registered before the index key was set get indexed on login
+	if user.EmailIndex == nil {
+		if emailIndex := a.emailCrypto.BlindIndex(email); emailIndex != nil {
+			if err := a.storage.SetEmailIndex(ctx, user.Id, emailIndex); err != nil {
+				logger.Log.Error("failed to backfill email index", "user_id", user.Id, "error", err)
+			} else {
+				user.EmailIndex = emailIndex
+			}
+		}
 	}
 
-	isBlacklisted, err := a.storage.IsUserBlacklisted(user.Id)
+	return user, nil
+}
+
+// FindUserByEmail resolves an email to its user through the blind index, so
+// admins can find an account without emails ever being decrypted.
+func (a *Auth) FindUserByEmail(ctx context.Context, email domain.Email) (domain.User, error) {
+	email = strings.ToLower(email)
+	if err := a.email.IsCorrect(email); err != nil {
+		return domain.User{}, err
+	}
+
+	emailIndex := a.emailCrypto.BlindIndex(email)
+	if emailIndex == nil {
+		return domain.User{}, &errors.ErrorWithStatusCode{Message: "Email lookup is not configured", StatusCode: http.StatusNotFound}
+	}
+	return a.storage.UserByEmailIndex(ctx, emailIndex)
+}
+
+func (a *Auth) Login(ctx context.Context, creds domain.Credentials) (string, error) {
+	_, token, err := a.login(ctx, creds)
+	return token, err
+}
+
+// LoginRemembered logs in like Login and also issues a refresh token, which
+// keeps getting the user new access tokens for RememberMeTTL.
+func (a *Auth) LoginRemembered(ctx context.Context, creds domain.Credentials) (string, string, error) {
+	user, token, err := a.login(ctx, creds)
 	if err != nil {
-		logger.Log.Error("failed to check blacklist status", "user_id", user.Id, "error", err)
-		return "", err
+		return "", "", err
 	}
-	if isBlacklisted {
-		logger.Log.Warn("login attempt by blacklisted user", "user_id", user.Id)
-		return "", &errors.ErrorWithStatusCode{
-			Message:    "Account suspended",
-			StatusCode: http.StatusForbidden,
-		}
+
+	refreshToken, err := a.jwt.NewRefreshToken(user.Id, a.cfg.RememberMeTTL)
+	if err != nil {
+		logger.Log.Error("failed to create refresh token", "user_id", user.Id, "error", err)
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
+
+func (a *Auth) login(ctx context.Context, creds domain.Credentials) (domain.User, string, error) {
+	user, err := a.Authenticate(ctx, creds)
+	if err != nil {
+		return domain.User{}, "", err
+	}
+
+	if err := a.checkNotBlacklisted(ctx, user.Id); err != nil {
+		return domain.User{}, "", err
 	}
 
 	token, err := a.jwt.NewToken(user)
 	if err != nil {
 		logger.Log.Error("failed to create jwt token", "user_id", user.Id, "error", err)
-		return "", err
+		return domain.User{}, "", err
 	}
 
 	logger.Log.Info("successful login", "user_id", user.Id, "is_admin", user.Admin)
+	if creds.Device != nil {
+		a.checkLoginDevice(ctx, user, creds.Email, *creds.Device)
+	}
+	return user, token, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token, so a remembered session lasts as long as it keeps being used. The
+// user is reloaded, so role changes and suspensions take effect.
+func (a *Auth) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	userId, issuedAt, err := a.jwt.DecodeRefreshToken(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	if err := a.checkSessionNotRevoked(ctx, userId, issuedAt); err != nil {
+		return "", "", err
+	}
+
+	user, err := a.sessionUser(ctx, userId)
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := a.jwt.NewToken(user)
+	if err != nil {
+		logger.Log.Error("failed to create jwt token", "user_id", user.Id, "error", err)
+		return "", "", err
+	}
+	newRefreshToken, err := a.jwt.NewRefreshToken(user.Id, a.cfg.RememberMeTTL)
+	if err != nil {
+		logger.Log.Error("failed to create refresh token", "user_id", user.Id, "error", err)
+		return "", "", err
+	}
+	return token, newRefreshToken, nil
+}
+
+// Renew reissues the access token of a signed-in user before it expires.
+func (a *Auth) Renew(ctx context.Context, userId domain.UserId) (string, error) {
+	user, err := a.sessionUser(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := a.jwt.NewToken(user)
+	if err != nil {
+		logger.Log.Error("failed to create jwt token", "user_id", user.Id, "error", err)
+		return "", err
+	}
 	return token, nil
 }
 
-func (a *Auth) BlacklistUser(userId domain.UserId, reason string, blacklistedBy domain.UserId) error {
-	if err := a.storage.BlacklistUser(userId, reason, blacklistedBy); err != nil {
+// sessionUser loads the user a session is renewed for. Deleted users have to
+// log in again and suspended ones are turned away, as they are by Login.
+func (a *Auth) sessionUser(ctx context.Context, userId domain.UserId) (domain.User, error) {
+	user, err := a.storage.GetUserById(ctx, userId)
+	if err != nil {
+		e, ok := err.(*errors.ErrorWithStatusCode)
+		if ok && e.StatusCode == http.StatusNotFound {
+			return domain.User{}, &errors.ErrorWithStatusCode{
+				Message:    "Session expired",
+				StatusCode: http.StatusUnauthorized,
+			}
+		}
+		return domain.User{}, err
+	}
+
+	if err := a.checkNotBlacklisted(ctx, user.Id); err != nil {
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+func (a *Auth) checkNotBlacklisted(ctx context.Context, userId domain.UserId) error {
+	isBlacklisted, err := a.storage.IsUserBlacklisted(ctx, userId)
+	if err != nil {
+		logger.Log.Error("failed to check blacklist status", "user_id", userId, "error", err)
+		return err
+	}
+	if isBlacklisted {
+		logger.Log.Warn("session for blacklisted user refused", "user_id", userId)
+		return &errors.ErrorWithStatusCode{
+			Message:    "Account suspended",
+			StatusCode: http.StatusForbidden,
+		}
+	}
+	return nil
+}
+
+func (a *Auth) BlacklistUser(ctx context.Context, userId domain.UserId, reason string, blacklistedBy domain.UserId) error {
+	if err := a.storage.BlacklistUser(ctx, userId, reason, blacklistedBy); err != nil {
 		return err
 	}
 
 	// Delete all unused invites created by this user
-	if err := a.storage.DeleteInvitesByUser(userId); err != nil {
+	if err := a.storage.DeleteInvitesByUser(ctx, userId); err != nil {
 		logger.Log.Warn("failed to delete user's invites",
 			"user_id", userId,
 			"error", err)
@@ -345,8 +513,24 @@ func (a *Auth) BlacklistUser(userId domain.UserId, reason string, blacklistedBy
 	return nil
 }
 
-func (a *Auth) UnblacklistUser(userId domain.UserId) error {
-	if err := a.storage.UnblacklistUser(userId); err != nil {
+// BlacklistUserUntil applies an automatic temporary ban. Unlike BlacklistUser it
+// keeps the user's invites, since the ban lifts on its own.
+func (a *Auth) BlacklistUserUntil(ctx context.Context, userId domain.UserId, reason string, expiresAt time.Time) error {
+	if err := a.storage.BlacklistUserUntil(ctx, userId, reason, expiresAt); err != nil {
+		return err
+	}
+
+	if err := a.blacklistCache.Update(); err != nil {
+		logger.Log.Warn("user temporarily banned but cache update failed",
+			"user_id", userId,
+			"error", err)
+	}
+
+	return nil
+}
+
+func (a *Auth) UnblacklistUser(ctx context.Context, userId domain.UserId) error {
+	if err := a.storage.UnblacklistUser(ctx, userId); err != nil {
 		return err
 	}
 
@@ -360,14 +544,14 @@ func (a *Auth) UnblacklistUser(userId domain.UserId) error {
 	return nil
 }
 
-func (a *Auth) GetBlacklistedUsersWithDetails(page int) ([]domain.BlacklistEntry, error) {
+func (a *Auth) GetBlacklistedUsersWithDetails(ctx context.Context, page int) ([]domain.BlacklistEntry, error) {
 	page = max(1, page)
 	limit := a.cfg.BlacklistPageLimit
 	offset := (page - 1) * limit
-	return a.storage.GetBlacklistedUsersWithDetails(limit, offset)
+	return a.storage.GetBlacklistedUsersWithDetails(ctx, limit, offset)
 }
 
-func (a *Auth) RefreshBlacklistCache() error {
+func (a *Auth) RefreshBlacklistCache(ctx context.Context) error {
 	return a.blacklistCache.Update()
 }
 
@@ -377,12 +561,12 @@ func (a *Auth) RefreshBlacklistCache() error {
 
 // RegisterWithInvite creates a user account using an invite code
 // Returns the generated @itchan.ru email address
-func (a *Auth) RegisterWithInvite(inviteCode string, password domain.Password, refSource string) (string, error) {
+func (a *Auth) RegisterWithInvite(ctx context.Context, inviteCode string, password domain.Password, refSource string) (string, error) {
 	// 1. Hash invite code for storage lookup
 	inviteCodeHash := sharedutils.HashSHA256(inviteCode)
 
 	// 2. Validate invite code exists and is valid
-	invite, err := a.storage.InviteCodeByHash(inviteCodeHash)
+	invite, err := a.storage.InviteCodeByHash(ctx, inviteCodeHash)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return "", &errors.ErrorWithStatusCode{
@@ -419,7 +603,7 @@ func (a *Auth) RegisterWithInvite(inviteCode string, password domain.Password, r
 	var emailHash []byte
 	for range 10 {
 		emailHash = a.emailCrypto.Hash(email)
-		_, err := a.storage.User(emailHash)
+		_, err := a.storage.User(ctx, emailHash)
 		if errors.IsNotFound(err) {
 			break // Email is available
 		}
@@ -453,10 +637,11 @@ func (a *Auth) RegisterWithInvite(inviteCode string, password domain.Password, r
 	if refSource != "" && a.allowedRefs.IsAllowed(refSource) {
 		referralSource = refSource
 	}
-	userId, err := a.storage.SaveUser(domain.User{
+	userId, err := a.storage.SaveUser(ctx, domain.User{
 		EmailEncrypted: emailEncrypted,
 		EmailDomain:    emailDomain,
 		EmailHash:      emailHash,
+		EmailIndex:     a.emailCrypto.BlindIndex(email),
 		PassHash:       domain.Password(passHash),
 		Admin:          false,
 		ReferralSource: referralSource,
@@ -466,7 +651,7 @@ func (a *Auth) RegisterWithInvite(inviteCode string, password domain.Password, r
 	}
 
 	// 8. Mark invite as used
-	if err := a.storage.MarkInviteUsed(inviteCodeHash, userId); err != nil {
+	if err := a.storage.MarkInviteUsed(ctx, inviteCodeHash, userId); err != nil {
 		logger.Log.Error("failed to mark invite used", "user_id", userId, "error", err)
 		// Don't fail - user is already created
 	}
@@ -480,7 +665,7 @@ func (a *Auth) RegisterWithInvite(inviteCode string, password domain.Password, r
 }
 
 // GenerateInvite creates a new invite code for a user
-func (a *Auth) GenerateInvite(user domain.User) (*domain.InviteCodeWithPlaintext, error) {
+func (a *Auth) GenerateInvite(ctx context.Context, user domain.User) (*domain.InviteCodeWithPlaintext, error) {
 	// 1. Check if invites enabled
 	if !a.cfg.InviteEnabled {
 		return nil, &errors.ErrorWithStatusCode{
@@ -510,7 +695,7 @@ func (a *Auth) GenerateInvite(user domain.User) (*domain.InviteCodeWithPlaintext
 
 	// 3. Check invite limit (skip for admins)
 	if !user.Admin && a.cfg.MaxInvitesPerUser > 0 {
-		activeCount, err := a.storage.CountActiveInvites(user.Id)
+		activeCount, err := a.storage.CountActiveInvites(ctx, user.Id)
 		if err != nil {
 			return nil, err
 		}
@@ -539,7 +724,7 @@ func (a *Auth) GenerateInvite(user domain.User) (*domain.InviteCodeWithPlaintext
 	}
 
 	// 6. Save to database
-	if err := a.storage.SaveInviteCode(invite); err != nil {
+	if err := a.storage.SaveInviteCode(ctx, invite); err != nil {
 		return nil, err
 	}
 
@@ -554,17 +739,17 @@ func (a *Auth) GenerateInvite(user domain.User) (*domain.InviteCodeWithPlaintext
 }
 
 // GetUserInvites returns invite codes created by a user, with pagination.
-func (a *Auth) GetUserInvites(userId domain.UserId, page int) ([]domain.InviteCode, error) {
+func (a *Auth) GetUserInvites(ctx context.Context, userId domain.UserId, page int) ([]domain.InviteCode, error) {
 	page = max(1, page)
 	limit := a.cfg.InvitesPageLimit
 	offset := (page - 1) * limit
-	return a.storage.GetInvitesByUser(userId, limit, offset)
+	return a.storage.GetInvitesByUser(ctx, userId, limit, offset)
 }
 
 // RevokeInvite deletes an unused invite code
-func (a *Auth) RevokeInvite(userId domain.UserId, codeHash string) error {
+func (a *Auth) RevokeInvite(ctx context.Context, userId domain.UserId, codeHash string) error {
 	// Verify the invite belongs to the user and is unused
-	invite, err := a.storage.InviteCodeByHash(codeHash)
+	invite, err := a.storage.InviteCodeByHash(ctx, codeHash)
 	if err != nil {
 		return err
 	}
@@ -583,5 +768,5 @@ func (a *Auth) RevokeInvite(userId domain.UserId, codeHash string) error {
 		}
 	}
 
-	return a.storage.DeleteInviteCode(codeHash)
+	return a.storage.DeleteInviteCode(ctx, codeHash)
 }
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -22,6 +23,9 @@ import (
 type MockAuthStorage struct {
 	SaveUserFunc                       func(user domain.User) (domain.UserId, error)
 	UserFunc                           func(emailHash []byte) (domain.User, error)
+	GetUserByIdFunc                    func(id domain.UserId) (domain.User, error)
+	UserByEmailIndexFunc               func(emailIndex []byte) (domain.User, error)
+	SetEmailIndexFunc                  func(userId domain.UserId, emailIndex []byte) error
 	DeleteUserFunc                     func(emailHash []byte) error
 	UpdatePasswordFunc                 func(emailHash []byte, newPasswordHash domain.Password) error
 	SaveConfirmationDataFunc           func(data domain.ConfirmationData) error
@@ -29,6 +33,7 @@ type MockAuthStorage struct {
 	DeleteConfirmationDataFunc         func(emailHash []byte) error
 	IsUserBlacklistedFunc              func(userId domain.UserId) (bool, error)
 	BlacklistUserFunc                  func(userId domain.UserId, reason string, blacklistedBy domain.UserId) error
+	BlacklistUserUntilFunc             func(userId domain.UserId, reason string, expiresAt time.Time) error
 	UnblacklistUserFunc                func(userId domain.UserId) error
 	GetBlacklistedUsersWithDetailsFunc func(limit, offset int) ([]domain.BlacklistEntry, error)
 
@@ -40,16 +45,43 @@ type MockAuthStorage struct {
 	MarkInviteUsedFunc      func(codeHash string, usedBy domain.UserId) error
 	DeleteInviteCodeFunc    func(codeHash string) error
 	DeleteInvitesByUserFunc func(userId domain.UserId) error
+
+	// Login device function fields
+	SaveLoginDeviceFunc             func(device domain.LoginDevice) (bool, error)
+	CountLoginDevicesFunc           func(userId domain.UserId) (int, error)
+	RevokeSessionsByDeviceTokenFunc func(tokenHash string) (domain.UserId, error)
+	SessionsRevokedAtFunc           func(userId domain.UserId) (time.Time, error)
 }
 
-func (m *MockAuthStorage) SaveUser(user domain.User) (domain.UserId, error) {
+func (m *MockAuthStorage) SaveUser(ctx context.Context, user domain.User) (domain.UserId, error) {
 	if m.SaveUserFunc != nil {
 		return m.SaveUserFunc(user)
 	}
 	return 1, nil
 }
 
-func (m *MockAuthStorage) User(emailHash []byte) (domain.User, error) {
+func (m *MockAuthStorage) GetUserById(ctx context.Context, id domain.UserId) (domain.User, error) {
+	if m.GetUserByIdFunc != nil {
+		return m.GetUserByIdFunc(id)
+	}
+	return domain.User{Id: id}, nil
+}
+
+func (m *MockAuthStorage) UserByEmailIndex(ctx context.Context, emailIndex []byte) (domain.User, error) {
+	if m.UserByEmailIndexFunc != nil {
+		return m.UserByEmailIndexFunc(emailIndex)
+	}
+	return domain.User{}, &internal_errors.ErrorWithStatusCode{Message: "User not found", StatusCode: http.StatusNotFound}
+}
+
+func (m *MockAuthStorage) SetEmailIndex(ctx context.Context, userId domain.UserId, emailIndex []byte) error {
+	if m.SetEmailIndexFunc != nil {
+		return m.SetEmailIndexFunc(userId, emailIndex)
+	}
+	return nil
+}
+
+func (m *MockAuthStorage) User(ctx context.Context, emailHash []byte) (domain.User, error) {
 	if m.UserFunc != nil {
 		return m.UserFunc(emailHash)
 	}
@@ -58,28 +90,28 @@ func (m *MockAuthStorage) User(emailHash []byte) (domain.User, error) {
 	return domain.User{Id: 1, PassHash: string(passHash)}, nil
 }
 
-func (m *MockAuthStorage) DeleteUser(emailHash []byte) error {
+func (m *MockAuthStorage) DeleteUser(ctx context.Context, emailHash []byte) error {
 	if m.DeleteUserFunc != nil {
 		return m.DeleteUserFunc(emailHash)
 	}
 	return nil
 }
 
-func (m *MockAuthStorage) UpdatePassword(emailHash []byte, newPasswordHash domain.Password) error {
+func (m *MockAuthStorage) UpdatePassword(ctx context.Context, emailHash []byte, newPasswordHash domain.Password) error {
 	if m.UpdatePasswordFunc != nil {
 		return m.UpdatePasswordFunc(emailHash, newPasswordHash)
 	}
 	return nil
 }
 
-func (m *MockAuthStorage) SaveConfirmationData(data domain.ConfirmationData) error {
+func (m *MockAuthStorage) SaveConfirmationData(ctx context.Context, data domain.ConfirmationData) error {
 	if m.SaveConfirmationDataFunc != nil {
 		return m.SaveConfirmationDataFunc(data)
 	}
 	return nil
 }
 
-func (m *MockAuthStorage) ConfirmationData(emailHash []byte) (domain.ConfirmationData, error) {
+func (m *MockAuthStorage) ConfirmationData(ctx context.Context, emailHash []byte) (domain.ConfirmationData, error) {
 	if m.ConfirmationDataFunc != nil {
 		return m.ConfirmationDataFunc(emailHash)
 	}
@@ -90,14 +122,14 @@ func (m *MockAuthStorage) ConfirmationData(emailHash []byte) (domain.Confirmatio
 	}
 }
 
-func (m *MockAuthStorage) DeleteConfirmationData(emailHash []byte) error {
+func (m *MockAuthStorage) DeleteConfirmationData(ctx context.Context, emailHash []byte) error {
 	if m.DeleteConfirmationDataFunc != nil {
 		return m.DeleteConfirmationDataFunc(emailHash)
 	}
 	return nil
 }
 
-func (m *MockAuthStorage) IsUserBlacklisted(userId domain.UserId) (bool, error) {
+func (m *MockAuthStorage) IsUserBlacklisted(ctx context.Context, userId domain.UserId) (bool, error) {
 	if m.IsUserBlacklistedFunc != nil {
 		return m.IsUserBlacklistedFunc(userId)
 	}
@@ -105,71 +137,107 @@ func (m *MockAuthStorage) IsUserBlacklisted(userId domain.UserId) (bool, error)
 	return false, nil
 }
 
-func (m *MockAuthStorage) BlacklistUser(userId domain.UserId, reason string, blacklistedBy domain.UserId) error {
+func (m *MockAuthStorage) BlacklistUser(ctx context.Context, userId domain.UserId, reason string, blacklistedBy domain.UserId) error {
 	if m.BlacklistUserFunc != nil {
 		return m.BlacklistUserFunc(userId, reason, blacklistedBy)
 	}
 	return nil
 }
 
-func (m *MockAuthStorage) UnblacklistUser(userId domain.UserId) error {
+func (m *MockAuthStorage) BlacklistUserUntil(ctx context.Context, userId domain.UserId, reason string, expiresAt time.Time) error {
+	if m.BlacklistUserUntilFunc != nil {
+		return m.BlacklistUserUntilFunc(userId, reason, expiresAt)
+	}
+	return nil
+}
+
+func (m *MockAuthStorage) UnblacklistUser(ctx context.Context, userId domain.UserId) error {
 	if m.UnblacklistUserFunc != nil {
 		return m.UnblacklistUserFunc(userId)
 	}
 	return nil
 }
 
-func (m *MockAuthStorage) GetBlacklistedUsersWithDetails(limit, offset int) ([]domain.BlacklistEntry, error) {
+func (m *MockAuthStorage) GetBlacklistedUsersWithDetails(ctx context.Context, limit, offset int) ([]domain.BlacklistEntry, error) {
 	if m.GetBlacklistedUsersWithDetailsFunc != nil {
 		return m.GetBlacklistedUsersWithDetailsFunc(limit, offset)
 	}
 	return nil, nil
 }
 
+// Login device methods
+func (m *MockAuthStorage) SaveLoginDevice(ctx context.Context, device domain.LoginDevice) (bool, error) {
+	if m.SaveLoginDeviceFunc != nil {
+		return m.SaveLoginDeviceFunc(device)
+	}
+	return false, nil
+}
+
+func (m *MockAuthStorage) CountLoginDevices(ctx context.Context, userId domain.UserId) (int, error) {
+	if m.CountLoginDevicesFunc != nil {
+		return m.CountLoginDevicesFunc(userId)
+	}
+	return 1, nil
+}
+
+func (m *MockAuthStorage) RevokeSessionsByDeviceToken(ctx context.Context, tokenHash string) (domain.UserId, error) {
+	if m.RevokeSessionsByDeviceTokenFunc != nil {
+		return m.RevokeSessionsByDeviceTokenFunc(tokenHash)
+	}
+	return 1, nil
+}
+
+func (m *MockAuthStorage) SessionsRevokedAt(ctx context.Context, userId domain.UserId) (time.Time, error) {
+	if m.SessionsRevokedAtFunc != nil {
+		return m.SessionsRevokedAtFunc(userId)
+	}
+	return time.Time{}, nil
+}
+
 // Invite code methods
-func (m *MockAuthStorage) SaveInviteCode(invite domain.InviteCode) error {
+func (m *MockAuthStorage) SaveInviteCode(ctx context.Context, invite domain.InviteCode) error {
 	if m.SaveInviteCodeFunc != nil {
 		return m.SaveInviteCodeFunc(invite)
 	}
 	return nil
 }
 
-func (m *MockAuthStorage) InviteCodeByHash(codeHash string) (domain.InviteCode, error) {
+func (m *MockAuthStorage) InviteCodeByHash(ctx context.Context, codeHash string) (domain.InviteCode, error) {
 	if m.InviteCodeByHashFunc != nil {
 		return m.InviteCodeByHashFunc(codeHash)
 	}
 	return domain.InviteCode{}, &internal_errors.ErrorWithStatusCode{Message: "Invite code not found", StatusCode: http.StatusNotFound}
 }
 
-func (m *MockAuthStorage) GetInvitesByUser(userId domain.UserId, limit, offset int) ([]domain.InviteCode, error) {
+func (m *MockAuthStorage) GetInvitesByUser(ctx context.Context, userId domain.UserId, limit, offset int) ([]domain.InviteCode, error) {
 	if m.GetInvitesByUserFunc != nil {
 		return m.GetInvitesByUserFunc(userId, limit, offset)
 	}
 	return nil, nil
 }
 
-func (m *MockAuthStorage) CountActiveInvites(userId domain.UserId) (int, error) {
+func (m *MockAuthStorage) CountActiveInvites(ctx context.Context, userId domain.UserId) (int, error) {
 	if m.CountActiveInvitesFunc != nil {
 		return m.CountActiveInvitesFunc(userId)
 	}
 	return 0, nil
 }
 
-func (m *MockAuthStorage) MarkInviteUsed(codeHash string, usedBy domain.UserId) error {
+func (m *MockAuthStorage) MarkInviteUsed(ctx context.Context, codeHash string, usedBy domain.UserId) error {
 	if m.MarkInviteUsedFunc != nil {
 		return m.MarkInviteUsedFunc(codeHash, usedBy)
 	}
 	return nil
 }
 
-func (m *MockAuthStorage) DeleteInviteCode(codeHash string) error {
+func (m *MockAuthStorage) DeleteInviteCode(ctx context.Context, codeHash string) error {
 	if m.DeleteInviteCodeFunc != nil {
 		return m.DeleteInviteCodeFunc(codeHash)
 	}
 	return nil
 }
 
-func (m *MockAuthStorage) DeleteInvitesByUser(userId domain.UserId) error {
+func (m *MockAuthStorage) DeleteInvitesByUser(ctx context.Context, userId domain.UserId) error {
 	if m.DeleteInvitesByUserFunc != nil {
 		return m.DeleteInvitesByUserFunc(userId)
 	}
@@ -200,7 +268,9 @@ func (m *MockEmail) IsCorrect(email domain.Email) error {
 }
 
 type MockJwt struct {
-	NewTokenFunc func(user domain.User) (string, error)
+	NewTokenFunc           func(user domain.User) (string, error)
+	NewRefreshTokenFunc    func(userId domain.UserId, ttl time.Duration) (string, error)
+	DecodeRefreshTokenFunc func(jwtStr string) (domain.UserId, time.Time, error)
 }
 
 func (m *MockJwt) NewToken(user domain.User) (string, error) {
@@ -210,9 +280,24 @@ func (m *MockJwt) NewToken(user domain.User) (string, error) {
 	return "test_token", nil
 }
 
+func (m *MockJwt) NewRefreshToken(userId domain.UserId, ttl time.Duration) (string, error) {
+	if m.NewRefreshTokenFunc != nil {
+		return m.NewRefreshTokenFunc(userId, ttl)
+	}
+	return "test_refresh_token", nil
+}
+
+func (m *MockJwt) DecodeRefreshToken(jwtStr string) (domain.UserId, time.Time, error) {
+	if m.DecodeRefreshTokenFunc != nil {
+		return m.DecodeRefreshTokenFunc(jwtStr)
+	}
+	return 1, time.Now(), nil
+}
+
 type MockEmailCrypto struct {
 	EncryptFunc       func(email string) ([]byte, error)
 	HashFunc          func(email string) []byte
+	BlindIndexFunc    func(email string) []byte
 	ExtractDomainFunc func(email string) (string, error)
 }
 
@@ -232,6 +317,14 @@ func (m *MockEmailCrypto) Hash(email string) []byte {
 	return []byte("hash_" + email)
 }
 
+func (m *MockEmailCrypto) BlindIndex(email string) []byte {
+	if m.BlindIndexFunc != nil {
+		return m.BlindIndexFunc(email)
+	}
+	// Default: no index key configured
+	return nil
+}
+
 func (m *MockEmailCrypto) ExtractDomain(email string) (string, error) {
 	if m.ExtractDomainFunc != nil {
 		return m.ExtractDomainFunc(email)
@@ -301,7 +394,7 @@ func TestRegister(t *testing.T) {
 		}
 
 		// Act
-		err := service.Register(creds)
+		err := service.Register(context.Background(), creds)
 
 		// Assert
 		require.NoError(t, err)
@@ -316,7 +409,7 @@ func TestRegister(t *testing.T) {
 		defer func() { email.IsCorrectFunc = nil }() // Restore default mock behavior
 
 		// Act
-		err := service.Register(domain.Credentials{Email: "invalid-email", Password: "password"})
+		err := service.Register(context.Background(), domain.Credentials{Email: "invalid-email", Password: "password"})
 
 		// Assert
 		require.Error(t, err)
@@ -332,7 +425,7 @@ func TestRegister(t *testing.T) {
 		defer func() { storage.ConfirmationDataFunc = nil }() // Restore default
 
 		// Act
-		err := service.Register(creds)
+		err := service.Register(context.Background(), creds)
 
 		// Assert
 		require.Error(t, err)
@@ -350,7 +443,7 @@ func TestRegister(t *testing.T) {
 		defer func() { storage.ConfirmationDataFunc = nil }() // Restore default
 
 		// Act
-		err := service.Register(creds)
+		err := service.Register(context.Background(), creds)
 
 		// Assert
 		require.Error(t, err)
@@ -398,7 +491,7 @@ func TestRegister(t *testing.T) {
 		}()
 
 		// Act
-		err := service.Register(creds)
+		err := service.Register(context.Background(), creds)
 
 		// Assert
 		require.NoError(t, err)
@@ -423,7 +516,7 @@ func TestRegister(t *testing.T) {
 		}()
 
 		// Act
-		err := service.Register(creds)
+		err := service.Register(context.Background(), creds)
 
 		// Assert
 		require.Error(t, err)
@@ -456,7 +549,7 @@ func TestRegister(t *testing.T) {
 		}()
 
 		// Act
-		err := service.Register(creds)
+		err := service.Register(context.Background(), creds)
 
 		// Assert
 		require.Error(t, err)
@@ -482,7 +575,7 @@ func TestRegister(t *testing.T) {
 		}()
 
 		// Act
-		err := service.Register(creds)
+		err := service.Register(context.Background(), creds)
 
 		// Assert
 		require.Error(t, err)
@@ -516,7 +609,7 @@ func TestRegisterWithDomainRestrictions(t *testing.T) {
 		}
 
 		// Act
-		err := service.Register(domain.Credentials{Email: "user@anydomain.com", Password: "password"})
+		err := service.Register(context.Background(), domain.Credentials{Email: "user@anydomain.com", Password: "password"})
 
 		// Assert
 		require.NoError(t, err)
@@ -548,7 +641,7 @@ func TestRegisterWithDomainRestrictions(t *testing.T) {
 		}
 
 		// Act
-		err := service.Register(domain.Credentials{Email: "user@gmail.com", Password: "password"})
+		err := service.Register(context.Background(), domain.Credentials{Email: "user@gmail.com", Password: "password"})
 
 		// Assert
 		require.NoError(t, err)
@@ -580,7 +673,7 @@ func TestRegisterWithDomainRestrictions(t *testing.T) {
 		}
 
 		// Act
-		err := service.Register(domain.Credentials{Email: "user@yahoo.com", Password: "password"})
+		err := service.Register(context.Background(), domain.Credentials{Email: "user@yahoo.com", Password: "password"})
 
 		// Assert
 		require.Error(t, err)
@@ -592,6 +685,35 @@ func TestRegisterWithDomainRestrictions(t *testing.T) {
 		assert.False(t, sendCalled, "Send should NOT be called for blocked domains")
 	})
 
+	t.Run("Blocked domain - imported account can set its password", func(t *testing.T) {
+		// Arrange
+		storage := &MockAuthStorage{}
+		email := &MockEmail{}
+		jwt := &MockJwt{}
+		emailCrypto := &MockEmailCrypto{}
+		service := NewAuth(storage, email, jwt, &config.Public{
+			ConfirmationCodeLen:        8,
+			ConfirmationCodeTTL:        10 * time.Minute,
+			AllowedRegistrationDomains: []string{"gmail.com"},
+		}, nil, emailCrypto, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
+
+		storage.UserFunc = func(emailHash []byte) (domain.User, error) {
+			return domain.User{Id: 1, PassHash: provisionedPassHash}, nil
+		}
+		sendCalled := false
+		email.SendFunc = func(recipientEmail, subject, body string) error {
+			sendCalled = true
+			return nil
+		}
+
+		// Act
+		err := service.Register(context.Background(), domain.Credentials{Email: "user@yahoo.com", Password: "password"})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, sendCalled)
+	})
+
 	t.Run("Multiple allowed domains - both work", func(t *testing.T) {
 		// Arrange
 		storage := &MockAuthStorage{}
@@ -612,15 +734,15 @@ func TestRegisterWithDomainRestrictions(t *testing.T) {
 		}
 
 		// Act & Assert - Test first allowed domain
-		err1 := service.Register(domain.Credentials{Email: "user@gmail.com", Password: "password"})
+		err1 := service.Register(context.Background(), domain.Credentials{Email: "user@gmail.com", Password: "password"})
 		require.NoError(t, err1)
 
 		// Act & Assert - Test second allowed domain
-		err2 := service.Register(domain.Credentials{Email: "admin@company.com", Password: "password"})
+		err2 := service.Register(context.Background(), domain.Credentials{Email: "admin@company.com", Password: "password"})
 		require.NoError(t, err2)
 
 		// Act & Assert - Test blocked domain
-		err3 := service.Register(domain.Credentials{Email: "hacker@evil.com", Password: "password"})
+		err3 := service.Register(context.Background(), domain.Credentials{Email: "hacker@evil.com", Password: "password"})
 		require.Error(t, err3)
 		var errWithStatus *internal_errors.ErrorWithStatusCode
 		require.True(t, errors.As(err3, &errWithStatus))
@@ -651,7 +773,7 @@ func TestRegisterWithDomainRestrictions(t *testing.T) {
 		}
 
 		// Act - User enters uppercase domain
-		err := service.Register(domain.Credentials{Email: "user@Gmail.COM", Password: "password"})
+		err := service.Register(context.Background(), domain.Credentials{Email: "user@Gmail.COM", Password: "password"})
 
 		// Assert - Should succeed (case insensitive)
 		require.NoError(t, err)
@@ -676,7 +798,7 @@ func TestRegisterWithDomainRestrictions(t *testing.T) {
 		}, nil, emailCrypto, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
 
 		// Act - Use valid email format so IsCorrect passes
-		err := service.Register(domain.Credentials{Email: "user@example.com", Password: "password"})
+		err := service.Register(context.Background(), domain.Credentials{Email: "user@example.com", Password: "password"})
 
 		// Assert
 		require.Error(t, err)
@@ -738,7 +860,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		}()
 
 		// Act
-		err := service.CheckConfirmationCode(testEmail, confirmationCode, "")
+		err := service.CheckConfirmationCode(context.Background(), testEmail, confirmationCode, "")
 
 		// Assert
 		require.NoError(t, err)
@@ -781,7 +903,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		}()
 
 		// Act
-		err := service.CheckConfirmationCode(testEmail, confirmationCode, "")
+		err := service.CheckConfirmationCode(context.Background(), testEmail, confirmationCode, "")
 
 		// Assert
 		require.NoError(t, err)
@@ -796,7 +918,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		defer func() { emailMock.IsCorrectFunc = nil }() // Restore default
 
 		// Act
-		err := service.CheckConfirmationCode("invalid-email", confirmationCode, "")
+		err := service.CheckConfirmationCode(context.Background(), "invalid-email", confirmationCode, "")
 
 		// Assert
 		require.Error(t, err)
@@ -811,7 +933,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		defer func() { storage.ConfirmationDataFunc = nil }() // Restore default
 
 		// Act
-		err := service.CheckConfirmationCode(testEmail, confirmationCode, "")
+		err := service.CheckConfirmationCode(context.Background(), testEmail, confirmationCode, "")
 
 		// Assert
 		require.Error(t, err)
@@ -830,7 +952,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		defer func() { storage.ConfirmationDataFunc = nil }() // Restore default
 
 		// Act
-		err := service.CheckConfirmationCode(testEmail, confirmationCode, "")
+		err := service.CheckConfirmationCode(context.Background(), testEmail, confirmationCode, "")
 
 		// Assert
 		require.Error(t, err)
@@ -847,7 +969,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		defer func() { storage.ConfirmationDataFunc = nil }() // Restore default
 
 		// Act
-		err := service.CheckConfirmationCode(testEmail, confirmationCode, "")
+		err := service.CheckConfirmationCode(context.Background(), testEmail, confirmationCode, "")
 
 		// Assert
 		require.Error(t, err)
@@ -865,7 +987,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		defer func() { storage.ConfirmationDataFunc = nil }() // Restore default
 
 		// Act
-		err := service.CheckConfirmationCode(testEmail, "wrong_code_654321", "") // Provide the WRONG code
+		err := service.CheckConfirmationCode(context.Background(), testEmail, "wrong_code_654321", "") // Provide the WRONG code
 
 		// Assert
 		require.Error(t, err)
@@ -890,7 +1012,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		}()
 
 		// Act
-		err := service.CheckConfirmationCode(testEmail, confirmationCode, "")
+		err := service.CheckConfirmationCode(context.Background(), testEmail, confirmationCode, "")
 
 		// Assert
 		require.Error(t, err)
@@ -916,7 +1038,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		}()
 
 		// Act
-		err := service.CheckConfirmationCode(testEmail, confirmationCode, "")
+		err := service.CheckConfirmationCode(context.Background(), testEmail, confirmationCode, "")
 
 		// Assert
 		require.Error(t, err)
@@ -942,7 +1064,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		}()
 
 		// Act
-		err := service.CheckConfirmationCode(testEmail, confirmationCode, "")
+		err := service.CheckConfirmationCode(context.Background(), testEmail, confirmationCode, "")
 
 		// Assert
 		require.Error(t, err)
@@ -972,7 +1094,7 @@ func TestCheckConfirmationCode(t *testing.T) {
 		}()
 
 		// Act
-		err := service.CheckConfirmationCode(testEmail, confirmationCode, "")
+		err := service.CheckConfirmationCode(context.Background(), testEmail, confirmationCode, "")
 
 		// Assert
 		// The primary operation (update/create user) succeeded, but cleanup failed.
@@ -1014,7 +1136,7 @@ func TestLogin(t *testing.T) {
 		}()
 
 		// Act
-		token, err := service.Login(creds)
+		token, err := service.Login(context.Background(), creds)
 
 		// Assert
 		require.NoError(t, err)
@@ -1028,7 +1150,7 @@ func TestLogin(t *testing.T) {
 		defer func() { emailMock.IsCorrectFunc = nil }() // Restore default
 
 		// Act
-		token, err := service.Login(domain.Credentials{Email: "invalid-email", Password: "password"})
+		token, err := service.Login(context.Background(), domain.Credentials{Email: "invalid-email", Password: "password"})
 
 		// Assert
 		require.Error(t, err)
@@ -1047,7 +1169,7 @@ func TestLogin(t *testing.T) {
 		defer func() { storage.UserFunc = nil }() // Restore default
 
 		// Act
-		token, err := service.Login(creds)
+		token, err := service.Login(context.Background(), creds)
 
 		// Assert
 		require.Error(t, err)
@@ -1067,7 +1189,7 @@ func TestLogin(t *testing.T) {
 		defer func() { storage.UserFunc = nil }() // Restore default
 
 		// Act
-		token, err := service.Login(creds)
+		token, err := service.Login(context.Background(), creds)
 
 		// Assert
 		require.Error(t, err)
@@ -1086,7 +1208,7 @@ func TestLogin(t *testing.T) {
 
 		// Act
 		// Use the WRONG password in credentials
-		token, err := service.Login(domain.Credentials{Email: creds.Email, Password: "wrong_password"})
+		token, err := service.Login(context.Background(), domain.Credentials{Email: creds.Email, Password: "wrong_password"})
 
 		// Assert
 		require.Error(t, err)
@@ -1112,7 +1234,7 @@ func TestLogin(t *testing.T) {
 		}()
 
 		// Act
-		token, err := service.Login(creds)
+		token, err := service.Login(context.Background(), creds)
 
 		// Assert
 		require.Error(t, err)
@@ -1135,7 +1257,7 @@ func TestLogin(t *testing.T) {
 		}()
 
 		// Act
-		token, err := service.Login(creds)
+		token, err := service.Login(context.Background(), creds)
 
 		// Assert
 		require.Error(t, err)
@@ -1161,13 +1283,273 @@ func TestLogin(t *testing.T) {
 		}()
 
 		// Act
-		token, err := service.Login(creds)
+		token, err := service.Login(context.Background(), creds)
 
 		// Assert
 		require.Error(t, err)
 		assert.True(t, errors.Is(err, mockError))
 		assert.Empty(t, token)
 	})
+
+	t.Run("Authenticate ignores blacklist", func(t *testing.T) {
+		// Arrange
+		storage.UserFunc = func(emailHash []byte) (domain.User, error) {
+			return correctUser, nil
+		}
+		storage.IsUserBlacklistedFunc = func(userId domain.UserId) (bool, error) {
+			t.Fatal("Authenticate must not check the blacklist")
+			return true, nil
+		}
+		defer func() {
+			storage.UserFunc = nil
+			storage.IsUserBlacklistedFunc = nil
+		}()
+
+		// Act
+		user, err := service.Authenticate(context.Background(), creds)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, correctUser.Id, user.Id)
+	})
+}
+
+func TestFindUserByEmail(t *testing.T) {
+	indexCrypto := &MockEmailCrypto{
+		BlindIndexFunc: func(email string) []byte { return []byte("index_" + email) },
+	}
+
+	t.Run("looks up the normalized email's index", func(t *testing.T) {
+		storage := &MockAuthStorage{
+			UserByEmailIndexFunc: func(emailIndex []byte) (domain.User, error) {
+				assert.Equal(t, []byte("index_user@example.com"), emailIndex)
+				return domain.User{Id: 7}, nil
+			},
+		}
+		service := NewAuth(storage, &MockEmail{}, &MockJwt{}, &config.Public{}, nil, indexCrypto, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
+
+		user, err := service.FindUserByEmail(context.Background(), "User@Example.com")
+		require.NoError(t, err)
+		assert.Equal(t, domain.UserId(7), user.Id)
+	})
+
+	t.Run("no index key", func(t *testing.T) {
+		storage := &MockAuthStorage{
+			UserByEmailIndexFunc: func(emailIndex []byte) (domain.User, error) {
+				t.Fatal("storage should not be queried")
+				return domain.User{}, nil
+			},
+		}
+		service := NewAuth(storage, &MockEmail{}, &MockJwt{}, &config.Public{}, nil, &MockEmailCrypto{}, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
+
+		_, err := service.FindUserByEmail(context.Background(), "user@example.com")
+		var errWithStatus *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &errWithStatus))
+		assert.Equal(t, http.StatusNotFound, errWithStatus.StatusCode)
+	})
+
+	t.Run("login backfills a missing index", func(t *testing.T) {
+		passHash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+		var backfilled []byte
+		storage := &MockAuthStorage{
+			UserFunc: func(emailHash []byte) (domain.User, error) {
+				return domain.User{Id: 3, PassHash: string(passHash)}, nil
+			},
+			SetEmailIndexFunc: func(userId domain.UserId, emailIndex []byte) error {
+				assert.Equal(t, domain.UserId(3), userId)
+				backfilled = emailIndex
+				return nil
+			},
+		}
+		service := NewAuth(storage, &MockEmail{}, &MockJwt{}, &config.Public{}, nil, indexCrypto, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
+
+		user, err := service.Authenticate(context.Background(), domain.Credentials{Email: "user@example.com", Password: "password"})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("index_user@example.com"), backfilled)
+		assert.Equal(t, backfilled, user.EmailIndex)
+	})
+
+	t.Run("login keeps an existing index", func(t *testing.T) {
+		passHash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+		storage := &MockAuthStorage{
+			UserFunc: func(emailHash []byte) (domain.User, error) {
+				return domain.User{Id: 3, PassHash: string(passHash), EmailIndex: []byte("index_user@example.com")}, nil
+			},
+			SetEmailIndexFunc: func(userId domain.UserId, emailIndex []byte) error {
+				t.Fatal("index should not be rewritten")
+				return nil
+			},
+		}
+		service := NewAuth(storage, &MockEmail{}, &MockJwt{}, &config.Public{}, nil, indexCrypto, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
+
+		_, err := service.Authenticate(context.Background(), domain.Credentials{Email: "user@example.com", Password: "password"})
+		require.NoError(t, err)
+	})
+}
+
+func TestLoginRemembered(t *testing.T) {
+	storage := &MockAuthStorage{}
+	jwt := &MockJwt{}
+	service := NewAuth(storage, &MockEmail{}, jwt, &config.Public{RememberMeTTL: 48 * time.Hour}, nil, &MockEmailCrypto{}, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
+	creds := domain.Credentials{Email: "test@example.com", Password: "password"}
+
+	t.Run("Issues both tokens", func(t *testing.T) {
+		jwt.NewRefreshTokenFunc = func(userId domain.UserId, ttl time.Duration) (string, error) {
+			assert.Equal(t, domain.UserId(1), userId)
+			assert.Equal(t, 48*time.Hour, ttl)
+			return "refresh", nil
+		}
+		defer func() { jwt.NewRefreshTokenFunc = nil }()
+
+		token, refreshToken, err := service.LoginRemembered(context.Background(), creds)
+
+		require.NoError(t, err)
+		assert.Equal(t, "test_token", token)
+		assert.Equal(t, "refresh", refreshToken)
+	})
+
+	t.Run("Blacklisted user gets no refresh token", func(t *testing.T) {
+		storage.IsUserBlacklistedFunc = func(userId domain.UserId) (bool, error) { return true, nil }
+		jwt.NewRefreshTokenFunc = func(userId domain.UserId, ttl time.Duration) (string, error) {
+			t.Fatal("refresh token must not be issued")
+			return "", nil
+		}
+		defer func() {
+			storage.IsUserBlacklistedFunc = nil
+			jwt.NewRefreshTokenFunc = nil
+		}()
+
+		token, refreshToken, err := service.LoginRemembered(context.Background(), creds)
+
+		var errWithStatus *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &errWithStatus))
+		assert.Equal(t, http.StatusForbidden, errWithStatus.StatusCode)
+		assert.Empty(t, token)
+		assert.Empty(t, refreshToken)
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	storage := &MockAuthStorage{}
+	jwt := &MockJwt{}
+	service := NewAuth(storage, &MockEmail{}, jwt, &config.Public{RememberMeTTL: 48 * time.Hour}, nil, &MockEmailCrypto{}, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
+
+	t.Run("Rotates the refresh token", func(t *testing.T) {
+		jwt.DecodeRefreshTokenFunc = func(jwtStr string) (domain.UserId, time.Time, error) {
+			assert.Equal(t, "old_refresh", jwtStr)
+			return 7, time.Now(), nil
+		}
+		storage.GetUserByIdFunc = func(id domain.UserId) (domain.User, error) {
+			return domain.User{Id: id, Admin: true}, nil
+		}
+		jwt.NewTokenFunc = func(user domain.User) (string, error) {
+			assert.Equal(t, domain.UserId(7), user.Id)
+			assert.True(t, user.Admin, "token should carry the reloaded user")
+			return "new_access", nil
+		}
+		jwt.NewRefreshTokenFunc = func(userId domain.UserId, ttl time.Duration) (string, error) {
+			return "new_refresh", nil
+		}
+		defer func() {
+			jwt.DecodeRefreshTokenFunc = nil
+			storage.GetUserByIdFunc = nil
+			jwt.NewTokenFunc = nil
+			jwt.NewRefreshTokenFunc = nil
+		}()
+
+		token, refreshToken, err := service.Refresh(context.Background(), "old_refresh")
+
+		require.NoError(t, err)
+		assert.Equal(t, "new_access", token)
+		assert.Equal(t, "new_refresh", refreshToken)
+	})
+
+	t.Run("Revoked session", func(t *testing.T) {
+		jwt.DecodeRefreshTokenFunc = func(jwtStr string) (domain.UserId, time.Time, error) {
+			return 7, time.Now().Add(-time.Hour), nil
+		}
+		storage.SessionsRevokedAtFunc = func(userId domain.UserId) (time.Time, error) {
+			assert.Equal(t, domain.UserId(7), userId)
+			return time.Now().Add(-time.Minute), nil
+		}
+		defer func() {
+			jwt.DecodeRefreshTokenFunc = nil
+			storage.SessionsRevokedAtFunc = nil
+		}()
+
+		_, _, err := service.Refresh(context.Background(), "stolen_refresh")
+
+		var errWithStatus *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &errWithStatus))
+		assert.Equal(t, http.StatusUnauthorized, errWithStatus.StatusCode)
+	})
+
+	t.Run("Invalid refresh token", func(t *testing.T) {
+		mockError := &internal_errors.ErrorWithStatusCode{Message: "Invalid refresh token", StatusCode: http.StatusUnauthorized}
+		jwt.DecodeRefreshTokenFunc = func(jwtStr string) (domain.UserId, time.Time, error) { return 0, time.Time{}, mockError }
+		defer func() { jwt.DecodeRefreshTokenFunc = nil }()
+
+		_, _, err := service.Refresh(context.Background(), "garbage")
+
+		assert.Equal(t, mockError, err)
+	})
+
+	t.Run("Deleted user", func(t *testing.T) {
+		storage.GetUserByIdFunc = func(id domain.UserId) (domain.User, error) {
+			return domain.User{}, &internal_errors.ErrorWithStatusCode{Message: "User not found", StatusCode: http.StatusNotFound}
+		}
+		defer func() { storage.GetUserByIdFunc = nil }()
+
+		_, _, err := service.Refresh(context.Background(), "refresh")
+
+		var errWithStatus *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &errWithStatus))
+		assert.Equal(t, http.StatusUnauthorized, errWithStatus.StatusCode)
+	})
+
+	t.Run("Blacklisted user", func(t *testing.T) {
+		storage.IsUserBlacklistedFunc = func(userId domain.UserId) (bool, error) { return true, nil }
+		defer func() { storage.IsUserBlacklistedFunc = nil }()
+
+		token, refreshToken, err := service.Refresh(context.Background(), "refresh")
+
+		var errWithStatus *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &errWithStatus))
+		assert.Equal(t, http.StatusForbidden, errWithStatus.StatusCode)
+		assert.Empty(t, token)
+		assert.Empty(t, refreshToken)
+	})
+}
+
+func TestRenew(t *testing.T) {
+	storage := &MockAuthStorage{}
+	jwt := &MockJwt{}
+	service := NewAuth(storage, &MockEmail{}, jwt, &config.Public{}, nil, &MockEmailCrypto{}, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
+
+	t.Run("Success", func(t *testing.T) {
+		jwt.NewTokenFunc = func(user domain.User) (string, error) {
+			assert.Equal(t, domain.UserId(3), user.Id)
+			return "renewed", nil
+		}
+		defer func() { jwt.NewTokenFunc = nil }()
+
+		token, err := service.Renew(context.Background(), 3)
+
+		require.NoError(t, err)
+		assert.Equal(t, "renewed", token)
+	})
+
+	t.Run("Blacklisted user", func(t *testing.T) {
+		storage.IsUserBlacklistedFunc = func(userId domain.UserId) (bool, error) { return true, nil }
+		defer func() { storage.IsUserBlacklistedFunc = nil }()
+
+		token, err := service.Renew(context.Background(), 3)
+
+		var errWithStatus *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &errWithStatus))
+		assert.Equal(t, http.StatusForbidden, errWithStatus.StatusCode)
+		assert.Empty(t, token)
+	})
 }
 
 func TestRegisterWithInvite(t *testing.T) {
@@ -1233,7 +1615,7 @@ func TestRegisterWithInvite(t *testing.T) {
 		}()
 
 		// Act
-		email, err := service.RegisterWithInvite(testInviteCode, testPassword, "")
+		email, err := service.RegisterWithInvite(context.Background(), testInviteCode, testPassword, "")
 
 		// Assert
 		require.NoError(t, err)
@@ -1254,7 +1636,7 @@ func TestRegisterWithInvite(t *testing.T) {
 		defer func() { storage.InviteCodeByHashFunc = nil }()
 
 		// Act
-		email, err := service.RegisterWithInvite("INVALIDCODE", testPassword, "")
+		email, err := service.RegisterWithInvite(context.Background(), "INVALIDCODE", testPassword, "")
 
 		// Assert
 		require.Error(t, err)
@@ -1278,7 +1660,7 @@ func TestRegisterWithInvite(t *testing.T) {
 		defer func() { storage.InviteCodeByHashFunc = nil }()
 
 		// Act
-		email, err := service.RegisterWithInvite(testInviteCode, testPassword, "")
+		email, err := service.RegisterWithInvite(context.Background(), testInviteCode, testPassword, "")
 
 		// Assert
 		require.Error(t, err)
@@ -1300,7 +1682,7 @@ func TestRegisterWithInvite(t *testing.T) {
 		defer func() { storage.InviteCodeByHashFunc = nil }()
 
 		// Act
-		email, err := service.RegisterWithInvite(testInviteCode, testPassword, "")
+		email, err := service.RegisterWithInvite(context.Background(), testInviteCode, testPassword, "")
 
 		// Assert
 		require.Error(t, err)
@@ -1340,7 +1722,7 @@ func TestRegisterWithInvite(t *testing.T) {
 		}()
 
 		// Act
-		email, err := service.RegisterWithInvite(testInviteCode, testPassword, "")
+		email, err := service.RegisterWithInvite(context.Background(), testInviteCode, testPassword, "")
 
 		// Assert
 		require.NoError(t, err)
@@ -1367,7 +1749,7 @@ func TestRegisterWithInvite(t *testing.T) {
 		}()
 
 		// Act
-		email, err := service.RegisterWithInvite(testInviteCode, testPassword, "")
+		email, err := service.RegisterWithInvite(context.Background(), testInviteCode, testPassword, "")
 
 		// Assert
 		require.Error(t, err)
@@ -1416,7 +1798,7 @@ func TestGenerateInvite(t *testing.T) {
 		}()
 
 		// Act
-		invite, err := service.GenerateInvite(testUser)
+		invite, err := service.GenerateInvite(context.Background(), testUser)
 
 		// Assert
 		require.NoError(t, err)
@@ -1453,7 +1835,7 @@ func TestGenerateInvite(t *testing.T) {
 		}()
 
 		// Act
-		invite, err := service.GenerateInvite(adminUser)
+		invite, err := service.GenerateInvite(context.Background(), adminUser)
 
 		// Assert
 		require.NoError(t, err)
@@ -1477,7 +1859,7 @@ func TestGenerateInvite(t *testing.T) {
 		defer func() { storage.CountActiveInvitesFunc = nil }()
 
 		// Act
-		invite, err := service.GenerateInvite(testUser)
+		invite, err := service.GenerateInvite(context.Background(), testUser)
 
 		// Assert
 		require.Error(t, err)
@@ -1513,7 +1895,7 @@ func TestGenerateInvite(t *testing.T) {
 		}()
 
 		// Act
-		invite, err := service.GenerateInvite(testUser)
+		invite, err := service.GenerateInvite(context.Background(), testUser)
 
 		// Assert
 		require.NoError(t, err)
@@ -1529,7 +1911,7 @@ func TestGenerateInvite(t *testing.T) {
 		}, nil, emailCrypto, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
 
 		// Act
-		invite, err := service.GenerateInvite(testUser)
+		invite, err := service.GenerateInvite(context.Background(), testUser)
 
 		// Assert
 		require.Error(t, err)
@@ -1568,8 +1950,8 @@ func TestGenerateInvite(t *testing.T) {
 		defer func() { storage.SaveInviteCodeFunc = nil }()
 
 		// Act - Generate two invites
-		invite1, err1 := service.GenerateInvite(testUser)
-		invite2, err2 := service.GenerateInvite(testUser)
+		invite1, err1 := service.GenerateInvite(context.Background(), testUser)
+		invite2, err2 := service.GenerateInvite(context.Background(), testUser)
 
 		// Assert
 		require.NoError(t, err1)
@@ -1595,7 +1977,7 @@ func TestGenerateInvite(t *testing.T) {
 		defer func() { storage.CountActiveInvitesFunc = nil }()
 
 		// Act
-		invite, err := service.GenerateInvite(testUser)
+		invite, err := service.GenerateInvite(context.Background(), testUser)
 
 		// Assert
 		require.Error(t, err)
@@ -1626,7 +2008,7 @@ func TestGenerateInvite(t *testing.T) {
 		}()
 
 		// Act
-		invite, err := service.GenerateInvite(testUser)
+		invite, err := service.GenerateInvite(context.Background(), testUser)
 
 		// Assert
 		require.Error(t, err)
@@ -1657,7 +2039,7 @@ func TestGetUserInvites(t *testing.T) {
 		defer func() { storage.GetInvitesByUserFunc = nil }()
 
 		// Act
-		invites, err := service.GetUserInvites(userId, 1)
+		invites, err := service.GetUserInvites(context.Background(), userId, 1)
 
 		// Assert
 		require.NoError(t, err)
@@ -1673,7 +2055,7 @@ func TestGetUserInvites(t *testing.T) {
 		defer func() { storage.GetInvitesByUserFunc = nil }()
 
 		// Act
-		invites, err := service.GetUserInvites(userId, 1)
+		invites, err := service.GetUserInvites(context.Background(), userId, 1)
 
 		// Assert
 		require.Error(t, err)
@@ -1712,7 +2094,7 @@ func TestRevokeInvite(t *testing.T) {
 		}()
 
 		// Act
-		err := service.RevokeInvite(userId, codeHash)
+		err := service.RevokeInvite(context.Background(), userId, codeHash)
 
 		// Assert
 		require.NoError(t, err)
@@ -1737,7 +2119,7 @@ func TestRevokeInvite(t *testing.T) {
 		}()
 
 		// Act
-		err := service.RevokeInvite(userId, codeHash)
+		err := service.RevokeInvite(context.Background(), userId, codeHash)
 
 		// Assert
 		require.Error(t, err)
@@ -1751,6 +2133,7 @@ func TestRevokeInvite(t *testing.T) {
 
 type MockBlacklistCacheStorage struct {
 	GetRecentlyBlacklistedUsersFunc func(since time.Time) ([]domain.UserId, error)
+	GetRecentSessionRevocationsFunc func(since time.Time) (map[domain.UserId]time.Time, error)
 }
 
 func (m *MockBlacklistCacheStorage) GetRecentlyBlacklistedUsers(since time.Time) ([]domain.UserId, error) {
@@ -1760,6 +2143,13 @@ func (m *MockBlacklistCacheStorage) GetRecentlyBlacklistedUsers(since time.Time)
 	return []domain.UserId{}, nil
 }
 
+func (m *MockBlacklistCacheStorage) GetRecentSessionRevocations(since time.Time) (map[domain.UserId]time.Time, error) {
+	if m.GetRecentSessionRevocationsFunc != nil {
+		return m.GetRecentSessionRevocationsFunc(since)
+	}
+	return map[domain.UserId]time.Time{}, nil
+}
+
 func TestBlacklistUser(t *testing.T) {
 	targetUserId := domain.UserId(10)
 	adminUserId := domain.UserId(1)
@@ -1797,7 +2187,7 @@ func TestBlacklistUser(t *testing.T) {
 		}
 
 		// Act
-		err := service.BlacklistUser(targetUserId, reason, adminUserId)
+		err := service.BlacklistUser(context.Background(), targetUserId, reason, adminUserId)
 
 		// Assert
 		require.NoError(t, err)
@@ -1822,7 +2212,7 @@ func TestBlacklistUser(t *testing.T) {
 		}
 
 		// Act
-		err := service.BlacklistUser(targetUserId, reason, adminUserId)
+		err := service.BlacklistUser(context.Background(), targetUserId, reason, adminUserId)
 
 		// Assert
 		require.Error(t, err)
@@ -1850,7 +2240,7 @@ func TestBlacklistUser(t *testing.T) {
 		}
 
 		// Act
-		err := service.BlacklistUser(targetUserId, reason, adminUserId)
+		err := service.BlacklistUser(context.Background(), targetUserId, reason, adminUserId)
 
 		// Assert - Should succeed despite delete invites error
 		require.NoError(t, err)
@@ -1877,7 +2267,7 @@ func TestBlacklistUser(t *testing.T) {
 		}
 
 		// Act
-		err := service.BlacklistUser(targetUserId, reason, adminUserId)
+		err := service.BlacklistUser(context.Background(), targetUserId, reason, adminUserId)
 
 		// Assert - Should succeed despite cache update error
 		require.NoError(t, err)
@@ -1911,7 +2301,7 @@ func TestUnblacklistUser(t *testing.T) {
 		}
 
 		// Act
-		err := service.UnblacklistUser(userId)
+		err := service.UnblacklistUser(context.Background(), userId)
 
 		// Assert
 		require.NoError(t, err)
@@ -1935,7 +2325,7 @@ func TestUnblacklistUser(t *testing.T) {
 		}
 
 		// Act
-		err := service.UnblacklistUser(userId)
+		err := service.UnblacklistUser(context.Background(), userId)
 
 		// Assert
 		require.Error(t, err)
@@ -1960,7 +2350,7 @@ func TestUnblacklistUser(t *testing.T) {
 		}
 
 		// Act
-		err := service.UnblacklistUser(userId)
+		err := service.UnblacklistUser(context.Background(), userId)
 
 		// Assert - Should succeed despite cache update error
 		require.NoError(t, err)
@@ -1986,7 +2376,7 @@ func TestGetBlacklistedUsersWithDetails(t *testing.T) {
 		defer func() { storage.GetBlacklistedUsersWithDetailsFunc = nil }()
 
 		// Act
-		entries, err := service.GetBlacklistedUsersWithDetails(1)
+		entries, err := service.GetBlacklistedUsersWithDetails(context.Background(), 1)
 
 		// Assert
 		require.NoError(t, err)
@@ -2002,7 +2392,7 @@ func TestGetBlacklistedUsersWithDetails(t *testing.T) {
 		defer func() { storage.GetBlacklistedUsersWithDetailsFunc = nil }()
 
 		// Act
-		entries, err := service.GetBlacklistedUsersWithDetails(1)
+		entries, err := service.GetBlacklistedUsersWithDetails(context.Background(), 1)
 
 		// Assert
 		require.Error(t, err)
@@ -2029,7 +2419,7 @@ func TestRefreshBlacklistCache(t *testing.T) {
 		}
 
 		// Act
-		err := service.RefreshBlacklistCache()
+		err := service.RefreshBlacklistCache(context.Background())
 
 		// Assert
 		require.NoError(t, err)
@@ -2052,7 +2442,7 @@ func TestRefreshBlacklistCache(t *testing.T) {
 		}
 
 		// Act
-		err := service.RefreshBlacklistCache()
+		err := service.RefreshBlacklistCache(context.Background())
 
 		// Assert
 		require.Error(t, err)
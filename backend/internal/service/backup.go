@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// backupRunsLimit is the number of runs listed to admins.
+	backupRunsLimit = 20
+	// backupErrorMaxLen bounds the error stored with a failed run, which
+	// ends with the output of the failing command.
+	backupErrorMaxLen = 2000
+	// backupTimeLayout formats the start time substituted for {time}.
+	backupTimeLayout = "20060102T150405Z"
+)
+
+// backupLastSuccess lets monitoring alert on stale backups without polling
+// the admin API.
+var backupLastSuccess = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "backup_last_success_timestamp_seconds",
+		Help: "Unix time of the start of the latest successful backup",
+	},
+)
+
+// BackupService runs the configured backup commands and reports on them.
+type BackupService interface {
+	// Trigger starts a backup in the background.
+	Trigger(ctx context.Context) error
+	Status(ctx context.Context) (domain.BackupState, error)
+	Runs(ctx context.Context) ([]domain.BackupRun, error)
+	// BeginWrite registers a write request. It returns false while writes
+	// are paused for a backup; otherwise done must be called once the
+	// request is finished.
+	BeginWrite() (done func(), ok bool)
+}
+
+// BackupStorage defines storage interface for backup runs
+type BackupStorage interface {
+	AddBackupRun(ctx context.Context, startedAt time.Time) (domain.BackupRunId, error)
+	FinishBackupRun(ctx context.Context, run domain.BackupRun) error
+	GetBackupRuns(ctx context.Context, limit int) ([]domain.BackupRun, error)
+	GetLastSuccessfulBackup(ctx context.Context) (domain.BackupRun, error)
+}
+
+// CommandRunner runs an external command to completion.
+type CommandRunner interface {
+	Run(ctx context.Context, argv []string) error
+}
+
+// Backup runs the database command and then the media command, one run at a
+// time. While the database command starts, writes can be paused so the dump
+// does not begin in the middle of a post.
+type Backup struct {
+	storage BackupStorage
+	runner  CommandRunner
+	alerts  AlertSender // nil when no webhook is configured, alerts are only logged
+	cfg     *config.Backup
+	now     func() time.Time
+
+	running  atomic.Bool
+	paused   atomic.Bool
+	inflight atomic.Int64
+}
+
+func NewBackup(storage BackupStorage, alerts AlertSender, cfg *config.Backup) *Backup {
+	return &Backup{
+		storage: storage,
+		runner:  execRunner{},
+		alerts:  alerts,
+		cfg:     cfg,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Enabled reports whether any backup command is configured.
+func (b *Backup) Enabled() bool {
+	return len(b.cfg.DatabaseCommand) > 0 || len(b.cfg.MediaCommand) > 0
+}
+
+// StartBackgroundBackups runs a backup one interval after the latest
+// successful one (right away if that is overdue) and then every interval
+// until ctx is done. It does nothing when no command is configured.
+func (b *Backup) StartBackgroundBackups(ctx context.Context) {
+	if !b.Enabled() {
+		return
+	}
+	logger.Log.Info("started scheduled backups",
+		"component", "backup",
+		"interval", b.cfg.Interval,
+		"pause_writes", b.cfg.PauseWrites)
+
+	go func() {
+		var delay time.Duration
+		last, err := b.storage.GetLastSuccessfulBackup(ctx)
+		switch {
+		case err == nil:
+			backupLastSuccess.Set(float64(last.StartedAt.Unix()))
+			delay = last.StartedAt.Add(b.cfg.Interval).Sub(b.now())
+		case !errors.IsNotFound(err):
+			logger.Log.Error("failed to get last successful backup", "component", "backup", "error", err)
+		}
+
+		timer := time.NewTimer(max(delay, 0))
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				logger.Log.Info("stopping scheduled backups", "component", "backup")
+				return
+			}
+			if _, err := b.Run(ctx); err != nil {
+				logger.Log.Error("scheduled backup failed", "component", "backup", "error", err)
+			}
+			timer.Reset(b.cfg.Interval)
+		}
+	}()
+}
+
+// Trigger starts a backup in the background, unless one is already running.
+// The backup outlives the request that triggered it.
+func (b *Backup) Trigger(ctx context.Context) error {
+	if err := b.acquire(); err != nil {
+		return err
+	}
+	go func() {
+		defer b.running.Store(false)
+		if _, err := b.run(context.WithoutCancel(ctx)); err != nil {
+			logger.Log.Error("triggered backup failed", "component", "backup", "error", err)
+		}
+	}()
+	return nil
+}
+
+// Run performs a backup and records it. The run is returned with its outcome
+// even when a command failed.
+func (b *Backup) Run(ctx context.Context) (domain.BackupRun, error) {
+	if err := b.acquire(); err != nil {
+		return domain.BackupRun{}, err
+	}
+	defer b.running.Store(false)
+	return b.run(ctx)
+}
+
+func (b *Backup) acquire() error {
+	if !b.Enabled() {
+		return &errors.ErrorWithStatusCode{Message: "Backups are not configured", StatusCode: http.StatusNotFound}
+	}
+	if !b.running.CompareAndSwap(false, true) {
+		return &errors.ErrorWithStatusCode{Message: "A backup is already running", StatusCode: http.StatusConflict}
+	}
+	return nil
+}
+
+func (b *Backup) run(ctx context.Context) (domain.BackupRun, error) {
+	run := domain.BackupRun{Status: domain.BackupRunning, StartedAt: b.now()}
+	id, err := b.storage.AddBackupRun(ctx, run.StartedAt)
+	if err != nil {
+		return run, err
+	}
+	run.Id = id
+	logger.Log.Info("backup started", "component", "backup", "run_id", id)
+
+	backupErr := b.backup(ctx, &run)
+	finishedAt := b.now()
+	run.FinishedAt = &finishedAt
+	run.Status = domain.BackupSucceeded
+	if backupErr != nil {
+		run.Status = domain.BackupFailed
+		run.Error = backupErr.Error()
+		if len(run.Error) > backupErrorMaxLen {
+			run.Error = run.Error[:backupErrorMaxLen]
+		}
+	}
+
+	// Recorded even when ctx was cancelled mid-run by a shutdown
+	if err := b.storage.FinishBackupRun(context.WithoutCancel(ctx), run); err != nil {
+		logger.Log.Error("failed to record backup run", "component", "backup", "run_id", id, "error", err)
+	}
+	if backupErr != nil {
+		b.alert(ctx, fmt.Sprintf("Backup %d failed: %s", id, run.Error))
+		return run, backupErr
+	}
+	backupLastSuccess.Set(float64(run.StartedAt.Unix()))
+	logger.Log.Info("backup finished",
+		"component", "backup",
+		"run_id", id,
+		"duration", finishedAt.Sub(run.StartedAt),
+		"paused_ms", run.PausedMs)
+	return run, nil
+}
+
+// backup runs the commands. The media command is skipped when the database
+// command fails, the run having failed already.
+func (b *Backup) backup(ctx context.Context, run *domain.BackupRun) error {
+	if len(b.cfg.DatabaseCommand) > 0 {
+		resume := b.pauseWrites(ctx)
+		err := b.command(ctx, b.cfg.DatabaseCommand, run.StartedAt)
+		run.PausedMs = resume().Milliseconds()
+		if err != nil {
+			return fmt.Errorf("database backup: %w", err)
+		}
+	}
+	if len(b.cfg.MediaCommand) > 0 {
+		if err := b.command(ctx, b.cfg.MediaCommand, run.StartedAt); err != nil {
+			return fmt.Errorf("media backup: %w", err)
+		}
+	}
+	return nil
+}
+
+func (b *Backup) command(ctx context.Context, argv []string, startedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, b.cfg.Timeout)
+	defer cancel()
+	stamp := startedAt.UTC().Format(backupTimeLayout)
+	args := make([]string, len(argv))
+	for i, arg := range argv {
+		args[i] = strings.ReplaceAll(arg, "{time}", stamp)
+	}
+	return b.runner.Run(ctx, args)
+}
+
+// pauseWrites refuses new writes and waits up to DrainTimeout for those in
+// progress. Writes resume when the returned function is called or after
+// PauseWrites, whichever comes first; the function reports how long they
+// were refused.
+func (b *Backup) pauseWrites(ctx context.Context) (resume func() time.Duration) {
+	if b.cfg.PauseWrites <= 0 {
+		return func() time.Duration { return 0 }
+	}
+	start := time.Now()
+	b.paused.Store(true)
+	var once sync.Once
+	var paused time.Duration
+	end := func() {
+		once.Do(func() {
+			b.paused.Store(false)
+			paused = time.Since(start)
+		})
+	}
+	timer := time.AfterFunc(b.cfg.PauseWrites, end)
+	resume = func() time.Duration {
+		timer.Stop()
+		end()
+		return paused
+	}
+
+	drainTimer := time.NewTimer(b.cfg.DrainTimeout)
+	defer drainTimer.Stop()
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for b.paused.Load() && b.inflight.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-drainTimer.C:
+			logger.Log.Warn("writes still in progress when the backup started",
+				"component", "backup",
+				"inflight", b.inflight.Load())
+			return resume
+		case <-ctx.Done():
+			return resume
+		}
+	}
+	return resume
+}
+
+// BeginWrite counts the request before checking the pause, so pauseWrites
+// either sees it in flight or the request sees the pause.
+func (b *Backup) BeginWrite() (done func(), ok bool) {
+	b.inflight.Add(1)
+	if b.paused.Load() {
+		b.inflight.Add(-1)
+		return nil, false
+	}
+	return func() { b.inflight.Add(-1) }, true
+}
+
+// Status reports the age of the latest successful backup.
+func (b *Backup) Status(ctx context.Context) (domain.BackupState, error) {
+	state := domain.BackupState{Enabled: b.Enabled(), Running: b.running.Load()}
+	last, err := b.storage.GetLastSuccessfulBackup(ctx)
+	if errors.IsNotFound(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	age := int64(b.now().Sub(last.StartedAt).Seconds())
+	state.LastSuccessAt = &last.StartedAt
+	state.LastSuccessAgeSeconds = &age
+	return state, nil
+}
+
+func (b *Backup) Runs(ctx context.Context) ([]domain.BackupRun, error) {
+	return b.storage.GetBackupRuns(ctx, backupRunsLimit)
+}
+
+func (b *Backup) alert(ctx context.Context, text string) {
+	logger.Log.Warn(text, "component", "backup")
+	if b.alerts == nil {
+		return
+	}
+	if err := b.alerts.SendAlert(context.WithoutCancel(ctx), text); err != nil {
+		logger.Log.Error("failed to send backup alert", "component", "backup", "error", err)
+	}
+}
+
+// execRunner runs commands directly, without a shell. A failure carries the
+// end of the command's output, where tools print their errors.
+type execRunner struct{}
+
+func (execRunner) Run(ctx context.Context, argv []string) error {
+	output, err := exec.CommandContext(ctx, argv[0], argv[1:]...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	tail := strings.TrimSpace(string(output))
+	if len(tail) > 500 {
+		tail = "..." + tail[len(tail)-500:]
+	}
+	if tail == "" {
+		return fmt.Errorf("%s: %w", argv[0], err)
+	}
+	return fmt.Errorf("%s: %w: %s", argv[0], err, tail)
+}
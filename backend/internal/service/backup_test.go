@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockBackupStorage struct {
+	started     []time.Time
+	finished    []domain.BackupRun
+	lastSuccess *domain.BackupRun
+}
+
+func (m *MockBackupStorage) AddBackupRun(ctx context.Context, startedAt time.Time) (domain.BackupRunId, error) {
+	m.started = append(m.started, startedAt)
+	return domain.BackupRunId(len(m.started)), nil
+}
+
+func (m *MockBackupStorage) FinishBackupRun(ctx context.Context, run domain.BackupRun) error {
+	m.finished = append(m.finished, run)
+	if run.Status == domain.BackupSucceeded {
+		m.lastSuccess = &run
+	}
+	return nil
+}
+
+func (m *MockBackupStorage) GetBackupRuns(ctx context.Context, limit int) ([]domain.BackupRun, error) {
+	return m.finished, nil
+}
+
+func (m *MockBackupStorage) GetLastSuccessfulBackup(ctx context.Context) (domain.BackupRun, error) {
+	if m.lastSuccess == nil {
+		return domain.BackupRun{}, &internal_errors.ErrorWithStatusCode{Message: "No backup has succeeded yet", StatusCode: http.StatusNotFound}
+	}
+	return *m.lastSuccess, nil
+}
+
+type MockCommandRunner struct {
+	calls   [][]string
+	runFunc func(argv []string) error
+}
+
+func (m *MockCommandRunner) Run(ctx context.Context, argv []string) error {
+	m.calls = append(m.calls, argv)
+	if m.runFunc != nil {
+		return m.runFunc(argv)
+	}
+	return nil
+}
+
+func setupBackupService(cfg config.Backup) (*Backup, *MockBackupStorage, *MockCommandRunner, *MockAlertSender) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Minute
+	}
+	if cfg.DrainTimeout == 0 {
+		cfg.DrainTimeout = time.Second
+	}
+	storage := &MockBackupStorage{}
+	runner := &MockCommandRunner{}
+	alerts := &MockAlertSender{}
+	b := NewBackup(storage, alerts, &cfg)
+	b.runner = runner
+	b.now = func() time.Time { return time.Date(2024, 1, 31, 2, 0, 0, 0, time.UTC) }
+	return b, storage, runner, alerts
+}
+
+// --- Tests ---
+
+func TestBackupRun(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Backup{
+		DatabaseCommand: []string{"pg_dump", "-f", "/backups/db-{time}.dump"},
+		MediaCommand:    []string{"rsync", "-a", "media/", "/backups/media/"},
+	}
+
+	t.Run("runs the database and then the media command", func(t *testing.T) {
+		b, storage, runner, alerts := setupBackupService(cfg)
+
+		run, err := b.Run(ctx)
+		require.NoError(t, err)
+
+		require.Len(t, runner.calls, 2)
+		assert.Equal(t, []string{"pg_dump", "-f", "/backups/db-20240131T020000Z.dump"}, runner.calls[0])
+		assert.Equal(t, "rsync", runner.calls[1][0])
+		assert.Equal(t, domain.BackupSucceeded, run.Status)
+		require.Len(t, storage.finished, 1)
+		assert.Equal(t, domain.BackupSucceeded, storage.finished[0].Status)
+		assert.NotNil(t, storage.finished[0].FinishedAt)
+		assert.Empty(t, alerts.alerts)
+	})
+
+	t.Run("failed dump skips media and alerts", func(t *testing.T) {
+		b, storage, runner, alerts := setupBackupService(cfg)
+		runner.runFunc = func(argv []string) error { return errors.New("pg_dump: connection refused") }
+
+		run, err := b.Run(ctx)
+		require.Error(t, err)
+
+		assert.Len(t, runner.calls, 1)
+		assert.Equal(t, domain.BackupFailed, run.Status)
+		require.Len(t, storage.finished, 1)
+		assert.Contains(t, storage.finished[0].Error, "connection refused")
+		require.Len(t, alerts.alerts, 1)
+		assert.Contains(t, alerts.alerts[0], "connection refused")
+		assert.False(t, b.running.Load())
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		b, _, _, _ := setupBackupService(config.Backup{})
+
+		_, err := b.Run(ctx)
+		requireStatus(t, err, http.StatusNotFound)
+		requireStatus(t, b.Trigger(ctx), http.StatusNotFound)
+	})
+
+	t.Run("one run at a time", func(t *testing.T) {
+		b, _, _, _ := setupBackupService(cfg)
+		b.running.Store(true)
+
+		_, err := b.Run(ctx)
+		requireStatus(t, err, http.StatusConflict)
+		requireStatus(t, b.Trigger(ctx), http.StatusConflict)
+	})
+}
+
+func TestBackupPauseWrites(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("writes are refused while the dump runs", func(t *testing.T) {
+		b, storage, runner, _ := setupBackupService(config.Backup{
+			DatabaseCommand: []string{"pg_dump"},
+			PauseWrites:     time.Minute,
+		})
+		var allowedDuringDump bool
+		runner.runFunc = func(argv []string) error {
+			_, allowedDuringDump = b.BeginWrite()
+			return nil
+		}
+
+		_, err := b.Run(ctx)
+		require.NoError(t, err)
+
+		assert.False(t, allowedDuringDump)
+		done, ok := b.BeginWrite()
+		require.True(t, ok, "writes resume after the dump")
+		done()
+		assert.Equal(t, int64(0), b.inflight.Load())
+		assert.Less(t, storage.finished[0].PausedMs, int64(time.Minute/time.Millisecond))
+	})
+
+	t.Run("pause ends after pause_writes", func(t *testing.T) {
+		b, _, runner, _ := setupBackupService(config.Backup{
+			DatabaseCommand: []string{"pg_dump"},
+			PauseWrites:     20 * time.Millisecond,
+		})
+		var allowedLater bool
+		runner.runFunc = func(argv []string) error {
+			time.Sleep(100 * time.Millisecond)
+			_, allowedLater = b.BeginWrite()
+			return nil
+		}
+
+		_, err := b.Run(ctx)
+		require.NoError(t, err)
+		assert.True(t, allowedLater)
+	})
+
+	t.Run("waits for writes in progress", func(t *testing.T) {
+		b, _, runner, _ := setupBackupService(config.Backup{
+			DatabaseCommand: []string{"pg_dump"},
+			PauseWrites:     time.Minute,
+		})
+		done, ok := b.BeginWrite()
+		require.True(t, ok)
+		finished := make(chan struct{})
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			close(finished)
+			done()
+		}()
+		var writeFinished bool
+		runner.runFunc = func(argv []string) error {
+			select {
+			case <-finished:
+				writeFinished = true
+			default:
+			}
+			return nil
+		}
+
+		_, err := b.Run(ctx)
+		require.NoError(t, err)
+		assert.True(t, writeFinished)
+	})
+
+	t.Run("no pause by default", func(t *testing.T) {
+		b, _, runner, _ := setupBackupService(config.Backup{DatabaseCommand: []string{"pg_dump"}})
+		var allowed bool
+		runner.runFunc = func(argv []string) error {
+			var done func()
+			done, allowed = b.BeginWrite()
+			done()
+			return nil
+		}
+
+		_, err := b.Run(ctx)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+}
+
+func TestBackupStatus(t *testing.T) {
+	ctx := context.Background()
+	b, storage, _, _ := setupBackupService(config.Backup{MediaCommand: []string{"rsync"}})
+
+	state, err := b.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, state.Enabled)
+	assert.Nil(t, state.LastSuccessAt)
+	assert.Nil(t, state.LastSuccessAgeSeconds)
+
+	storage.lastSuccess = &domain.BackupRun{Id: 1, Status: domain.BackupSucceeded, StartedAt: b.now().Add(-90 * time.Minute)}
+	state, err = b.Status(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, state.LastSuccessAgeSeconds)
+	assert.Equal(t, int64(90*60), *state.LastSuccessAgeSeconds)
+}
@@ -1,95 +1,327 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/validation"
 )
 
 type BoardService interface {
-	Create(creationData domain.BoardCreationData) error
-	Get(shortName domain.BoardShortName, page int) (domain.Board, error)
-	GetLastModified(shortName domain.BoardShortName) (time.Time, error)
-	Delete(shortName domain.BoardShortName) error
-	GetAllBoards() ([]domain.BoardMetadata, error)
+	Create(ctx context.Context, creationData domain.BoardCreationData) error
+	Get(ctx context.Context, shortName domain.BoardShortName, page int, viewer *domain.User) (domain.Board, error)
+	GetLastModified(ctx context.Context, shortName domain.BoardShortName) (time.Time, error)
+	Delete(ctx context.Context, shortName domain.BoardShortName) error
+	// DeletionReport counts what Delete would remove, without changing anything
+	DeletionReport(ctx context.Context, shortName domain.BoardShortName) (domain.DeletionReport, error)
+	// ScheduleDeletion hides the board until the grace period ends and returns
+	// when it will be dropped. Without a grace period it deletes at once and
+	// returns the zero time.
+	ScheduleDeletion(ctx context.Context, shortName domain.BoardShortName) (time.Time, error)
+	CancelDeletion(ctx context.Context, shortName domain.BoardShortName) error
+	PendingDeletions(ctx context.Context) ([]domain.PendingBoardDeletion, error)
+	// PurgeDeleted drops the boards whose grace period has ended and returns how many
+	PurgeDeleted(ctx context.Context) (int, error)
+	// Rename moves the board to a new short name, keeping its threads and media
+	Rename(ctx context.Context, oldName, newName domain.BoardShortName) error
+	List(ctx context.Context, sort domain.BoardSort, stats domain.BoardStatsMode, page int, viewer *domain.User) ([]domain.BoardMetadata, int, error)
+	GetGroupedBoards(ctx context.Context, viewer *domain.User) ([]domain.BoardCategory, error)
+	CreateCategory(ctx context.Context, data domain.BoardCategoryCreationData) (domain.BoardCategoryId, error)
+	UpdateCategory(ctx context.Context, id domain.BoardCategoryId, data domain.BoardCategoryCreationData) error
+	DeleteCategory(ctx context.Context, id domain.BoardCategoryId) error
+	SetCategory(ctx context.Context, shortName domain.BoardShortName, categoryId *domain.BoardCategoryId, position int) error
+	UpdateSettings(ctx context.Context, shortName domain.BoardShortName, settings domain.BoardSettings) error
 }
 
 type Board struct {
 	storage       BoardStorage
 	nameValidator BoardValidator
 	mediaStorage  MediaStorage
+	permissions   PermissionChecker
+	cfg           *config.Public
 }
 
 type BoardStorage interface {
-	CreateBoard(creationData domain.BoardCreationData) error
-	GetBoard(shortName domain.BoardShortName, page int) (domain.Board, error)
-	GetBoardLastModified(shortName domain.BoardShortName) (time.Time, error)
-	DeleteBoard(shortName domain.BoardShortName) error
-	GetBoards() ([]domain.BoardMetadata, error)
+	CreateBoard(ctx context.Context, creationData domain.BoardCreationData) error
+	GetBoard(ctx context.Context, shortName domain.BoardShortName, page int) (domain.Board, error)
+	GetBoardLastModified(ctx context.Context, shortName domain.BoardShortName) (time.Time, error)
+	DeleteBoard(ctx context.Context, shortName domain.BoardShortName) error
+	GetDeletionReport(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId) (domain.DeletionReport, error)
+	ScheduleBoardDeletion(ctx context.Context, shortName domain.BoardShortName, deleteAfter time.Time) error
+	CancelBoardDeletion(ctx context.Context, shortName domain.BoardShortName) error
+	GetPendingBoardDeletions(ctx context.Context) ([]domain.PendingBoardDeletion, error)
+	RenameBoard(ctx context.Context, oldName, newName domain.BoardShortName, redirectUntil *time.Time) error
+	GetBoards(ctx context.Context) ([]domain.BoardMetadata, error)
+	ListBoards(ctx context.Context, sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error)
+	CreateBoardCategory(ctx context.Context, data domain.BoardCategoryCreationData) (domain.BoardCategoryId, error)
+	UpdateBoardCategory(ctx context.Context, id domain.BoardCategoryId, data domain.BoardCategoryCreationData) error
+	DeleteBoardCategory(ctx context.Context, id domain.BoardCategoryId) error
+	GetBoardCategories(ctx context.Context) ([]domain.BoardCategory, error)
+	SetBoardCategory(ctx context.Context, shortName domain.BoardShortName, categoryId *domain.BoardCategoryId, position int) error
+	UpdateBoardSettings(ctx context.Context, shortName domain.BoardShortName, settings domain.BoardSettings) error
 }
 
 type BoardValidator interface {
 	Name(name domain.BoardName) error
 	ShortName(name domain.BoardShortName) error
+	CategoryName(name domain.BoardCategoryName) error
+	Description(description string) error
 }
 
-func NewBoard(storage BoardStorage, validator BoardValidator, mediaStorage MediaStorage) BoardService {
+// PermissionChecker is the cache of board access rules (allowed email domains,
+// pending deletions and renames) the access middleware reads. The board
+// service invalidates it after every change to them.
+type PermissionChecker interface {
+	AllowedDomains(board string) []string
+	PendingDeletion(board string) bool
+	RenamedTo(board string) (string, bool)
+	Invalidate()
+}
+
+func NewBoard(storage BoardStorage, validator BoardValidator, mediaStorage MediaStorage, permissions PermissionChecker, cfg *config.Public) BoardService {
 	return &Board{
 		storage:       storage,
 		nameValidator: validator,
 		mediaStorage:  mediaStorage,
+		permissions:   permissions,
+		cfg:           cfg,
 	}
 }
 
-func (b *Board) Create(creationData domain.BoardCreationData) error {
+func (b *Board) Create(ctx context.Context, creationData domain.BoardCreationData) error {
 	if err := b.nameValidator.Name(creationData.Name); err != nil {
 		return err
 	}
 	if err := b.nameValidator.ShortName(creationData.ShortName); err != nil {
 		return err
 	}
-	if err := b.storage.CreateBoard(creationData); err != nil {
+	if err := b.nameValidator.Description(creationData.Description); err != nil {
+		return err
+	}
+	if err := b.storage.CreateBoard(ctx, creationData); err != nil {
 		return err
 	}
+	b.permissions.Invalidate()
 
 	return nil
 }
 
-func (b *Board) Get(shortName domain.BoardShortName, page int) (domain.Board, error) {
+// Get returns a page of the board as seen by viewer (nil for anonymous readers).
+func (b *Board) Get(ctx context.Context, shortName domain.BoardShortName, page int, viewer *domain.User) (domain.Board, error) {
 	page = max(1, page)
 
 	if err := b.nameValidator.ShortName(shortName); err != nil {
 		return domain.Board{}, err
 	}
 
-	board, err := b.storage.GetBoard(shortName, page)
+	board, err := b.storage.GetBoard(ctx, shortName, page)
 	if err != nil {
 		return domain.Board{}, err
 	}
 	board.Page = page
+	var messages []*domain.Message
+	for _, thread := range board.Threads {
+		messages = append(messages, thread.Messages...)
+	}
+	markOwn(messages, viewer)
 	return board, nil
 }
 
-func (b *Board) GetLastModified(shortName domain.BoardShortName) (time.Time, error) {
+func (b *Board) GetLastModified(ctx context.Context, shortName domain.BoardShortName) (time.Time, error) {
 	if err := b.nameValidator.ShortName(shortName); err != nil {
 		return time.Time{}, err
 	}
-	return b.storage.GetBoardLastModified(shortName)
+	return b.storage.GetBoardLastModified(ctx, shortName)
 }
 
-func (b *Board) GetAllBoards() ([]domain.BoardMetadata, error) {
-	return b.storage.GetBoards()
+// List returns one page of boards in the given order (position when empty)
+// and the total number of boards. Stats are only computed when asked for; the
+// latest thread of a board is left out unless viewer may read the board. With
+// HideRestrictedBoards the boards viewer may not read are left out entirely.
+func (b *Board) List(ctx context.Context, sort domain.BoardSort, stats domain.BoardStatsMode, page int, viewer *domain.User) ([]domain.BoardMetadata, int, error) {
+	switch sort {
+	case "":
+		sort = domain.BoardSortPosition
+	case domain.BoardSortPosition, domain.BoardSortActivity, domain.BoardSortCreated, domain.BoardSortName:
+	default:
+		return nil, 0, &errors.ErrorWithStatusCode{Message: fmt.Sprintf("Unknown board sort '%s'", sort), StatusCode: http.StatusBadRequest}
+	}
+	switch stats {
+	case domain.BoardStatsNone, domain.BoardStatsCounts, domain.BoardStatsLatest:
+	default:
+		return nil, 0, &errors.ErrorWithStatusCode{Message: fmt.Sprintf("Unknown board stats mode '%s'", stats), StatusCode: http.StatusBadRequest}
+	}
+	page = max(1, page)
+	limit := b.cfg.BoardsPageLimit
+	offset := (page - 1) * limit
+	var boards []domain.BoardMetadata
+	var total int
+	var err error
+	if b.cfg.HideRestrictedBoards {
+		boards, total, err = b.listVisible(ctx, sort, stats, limit, offset, viewer)
+	} else {
+		boards, total, err = b.storage.ListBoards(ctx, sort, stats, limit, offset)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range boards {
+		if boards[i].Stats != nil && !canView(viewer, boards[i]) {
+			boards[i].Stats.LatestThread = nil
+		}
+	}
+	return boards, total, nil
+}
+
+// listVisible pages through all boards and returns the page of those viewer
+// may read, with their count as the total, so hidden boards leave no gaps.
+func (b *Board) listVisible(ctx context.Context, sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int, viewer *domain.User) ([]domain.BoardMetadata, int, error) {
+	var visible []domain.BoardMetadata
+	for from := 0; ; from += limit {
+		boards, total, err := b.storage.ListBoards(ctx, sort, stats, limit, from)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, board := range boards {
+			if canView(viewer, board) {
+				visible = append(visible, board)
+			}
+		}
+		if len(boards) == 0 || from+limit >= total {
+			break
+		}
+	}
+	start := min(offset, len(visible))
+	return visible[start:min(offset+limit, len(visible))], len(visible), nil
+}
+
+// GetGroupedBoards returns all categories in display order, each with its boards.
+// Boards without a category are collected into a trailing group with zero Id and
+// empty Name; the group is omitted when every board is categorized. With
+// HideRestrictedBoards the boards viewer may not read are left out, and so are
+// categories that had only such boards.
+func (b *Board) GetGroupedBoards(ctx context.Context, viewer *domain.User) ([]domain.BoardCategory, error) {
+	categories, err := b.storage.GetBoardCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	boards, err := b.storage.GetBoards(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[domain.BoardCategoryId]int, len(categories))
+	for i, c := range categories {
+		index[c.Id] = i
+	}
+
+	var uncategorized []domain.BoardMetadata
+	hidden := make(map[domain.BoardCategoryId]bool)
+	for _, board := range boards { // already sorted by position
+		if b.cfg.HideRestrictedBoards && !canView(viewer, board) {
+			if board.CategoryId != nil {
+				hidden[*board.CategoryId] = true
+			}
+			continue
+		}
+		if board.CategoryId != nil {
+			if i, ok := index[*board.CategoryId]; ok {
+				categories[i].Boards = append(categories[i].Boards, board)
+				continue
+			}
+		}
+		uncategorized = append(uncategorized, board)
+	}
+
+	if len(hidden) > 0 {
+		categories = slices.DeleteFunc(categories, func(c domain.BoardCategory) bool { return hidden[c.Id] && len(c.Boards) == 0 })
+	}
+	if len(uncategorized) > 0 {
+		categories = append(categories, domain.BoardCategory{Boards: uncategorized})
+	}
+	return categories, nil
+}
+
+func (b *Board) CreateCategory(ctx context.Context, data domain.BoardCategoryCreationData) (domain.BoardCategoryId, error) {
+	if err := b.nameValidator.CategoryName(data.Name); err != nil {
+		return 0, err
+	}
+	return b.storage.CreateBoardCategory(ctx, data)
+}
+
+func (b *Board) UpdateCategory(ctx context.Context, id domain.BoardCategoryId, data domain.BoardCategoryCreationData) error {
+	if err := b.nameValidator.CategoryName(data.Name); err != nil {
+		return err
+	}
+	return b.storage.UpdateBoardCategory(ctx, id, data)
+}
+
+func (b *Board) DeleteCategory(ctx context.Context, id domain.BoardCategoryId) error {
+	return b.storage.DeleteBoardCategory(ctx, id)
+}
+
+func (b *Board) SetCategory(ctx context.Context, shortName domain.BoardShortName, categoryId *domain.BoardCategoryId, position int) error {
+	if err := b.nameValidator.ShortName(shortName); err != nil {
+		return err
+	}
+	return b.storage.SetBoardCategory(ctx, shortName, categoryId, position)
+}
+
+func (b *Board) UpdateSettings(ctx context.Context, shortName domain.BoardShortName, settings domain.BoardSettings) error {
+	if err := b.nameValidator.ShortName(shortName); err != nil {
+		return err
+	}
+	if err := b.nameValidator.Description(settings.Description); err != nil {
+		return err
+	}
+	if _, ok := b.cfg.Media.VideoProfiles[settings.VideoProfile]; settings.VideoProfile != "" && !ok {
+		return &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Unknown video profile '%s'", settings.VideoProfile),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	// A board can only narrow the globally accepted image and video types
+	configured := validation.BuildAllowedMimeMap(b.cfg.AllowedImageMimeTypes, b.cfg.AllowedVideoMimeTypes)
+	for _, mimeType := range settings.AllowedMimeTypes {
+		if !configured[mimeType] {
+			return &errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("'%s' is not an image or video type enabled on this site", mimeType),
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+	}
+	// Domains are matched against account email domains as they are stored
+	for i, emailDomain := range settings.PostingEmailDomains {
+		emailDomain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(emailDomain), "@"))
+		if emailDomain == "" || strings.ContainsAny(emailDomain, ", @") {
+			return &errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("'%s' is not an email domain", settings.PostingEmailDomains[i]),
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+		settings.PostingEmailDomains[i] = emailDomain
+	}
+	return b.storage.UpdateBoardSettings(ctx, shortName, settings)
 }
 
-func (b *Board) Delete(shortName domain.BoardShortName) error {
+func (b *Board) Delete(ctx context.Context, shortName domain.BoardShortName) error {
 	if err := b.nameValidator.ShortName(shortName); err != nil {
 		return err
 	}
 
-	err := b.storage.DeleteBoard(shortName)
+	err := b.storage.DeleteBoard(ctx, shortName)
 	if err != nil {
 		return err
 	}
+	b.permissions.Invalidate()
 
 	// Best effort: log errors but don't fail the operation
 	if err := b.mediaStorage.DeleteBoard(string(shortName)); err != nil {
@@ -97,3 +329,127 @@ func (b *Board) Delete(shortName domain.BoardShortName) error {
 
 	return nil
 }
+
+func (b *Board) DeletionReport(ctx context.Context, shortName domain.BoardShortName) (domain.DeletionReport, error) {
+	if err := b.nameValidator.ShortName(shortName); err != nil {
+		return domain.DeletionReport{}, err
+	}
+	return b.storage.GetDeletionReport(ctx, shortName, nil)
+}
+
+func (b *Board) ScheduleDeletion(ctx context.Context, shortName domain.BoardShortName) (time.Time, error) {
+	if err := b.nameValidator.ShortName(shortName); err != nil {
+		return time.Time{}, err
+	}
+	if b.cfg.BoardDeletionGracePeriod <= 0 {
+		return time.Time{}, b.Delete(ctx, shortName)
+	}
+
+	deleteAfter := time.Now().UTC().Add(b.cfg.BoardDeletionGracePeriod).Round(time.Microsecond)
+	if err := b.storage.ScheduleBoardDeletion(ctx, shortName, deleteAfter); err != nil {
+		return time.Time{}, err
+	}
+	b.permissions.Invalidate()
+	return deleteAfter, nil
+}
+
+func (b *Board) CancelDeletion(ctx context.Context, shortName domain.BoardShortName) error {
+	if err := b.nameValidator.ShortName(shortName); err != nil {
+		return err
+	}
+	if err := b.storage.CancelBoardDeletion(ctx, shortName); err != nil {
+		return err
+	}
+	b.permissions.Invalidate()
+	return nil
+}
+
+func (b *Board) PendingDeletions(ctx context.Context) ([]domain.PendingBoardDeletion, error) {
+	return b.storage.GetPendingBoardDeletions(ctx)
+}
+
+// Rename moves the media directory before the database, as the stored paths
+// point into it once the rename commits. If the database rename fails, the
+// directory is moved back.
+func (b *Board) Rename(ctx context.Context, oldName, newName domain.BoardShortName) error {
+	if err := b.nameValidator.ShortName(oldName); err != nil {
+		return err
+	}
+	if err := b.nameValidator.ShortName(newName); err != nil {
+		return err
+	}
+	if oldName == newName {
+		return &errors.ErrorWithStatusCode{Message: "New short name must differ from the current one", StatusCode: http.StatusBadRequest}
+	}
+
+	var redirectUntil *time.Time
+	if b.cfg.BoardRenameRedirectPeriod > 0 {
+		until := time.Now().UTC().Add(b.cfg.BoardRenameRedirectPeriod).Round(time.Microsecond)
+		redirectUntil = &until
+	}
+
+	if err := b.mediaStorage.RenameBoard(string(oldName), string(newName)); err != nil {
+		return fmt.Errorf("failed to move media of board '%s': %w", oldName, err)
+	}
+	if err := b.storage.RenameBoard(ctx, oldName, newName, redirectUntil); err != nil {
+		if mediaErr := b.mediaStorage.RenameBoard(string(newName), string(oldName)); mediaErr != nil {
+			logger.Log.Error("failed to move media back after board rename failed",
+				"board", oldName, "new_short_name", newName, "error", mediaErr)
+		}
+		return err
+	}
+	b.permissions.Invalidate()
+	return nil
+}
+
+// PurgeDeleted keeps going past boards that fail to drop, so one broken board
+// does not hold back the others; the first error is returned.
+func (b *Board) PurgeDeleted(ctx context.Context) (int, error) {
+	pending, err := b.storage.GetPendingBoardDeletions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	purged := 0
+	var firstErr error
+	for _, p := range pending {
+		if p.DeleteAfter.After(now) {
+			break // ordered by delete_after
+		}
+		if err := b.Delete(ctx, p.Board); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to purge board '%s': %w", p.Board, err)
+			}
+			continue
+		}
+		purged++
+	}
+	return purged, firstErr
+}
+
+// StartBoardPurge drops boards whose deletion grace period has ended, checking
+// every interval until ctx is cancelled.
+func StartBoardPurge(ctx context.Context, board BoardService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	logger.Log.Info("started board purge", "component", "board_purge", "interval", interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				purged, err := board.PurgeDeleted(ctx)
+				if err != nil {
+					logger.Log.Error("board purge failed", "component", "board_purge", "error", err)
+				}
+				if purged > 0 {
+					logger.Log.Info("boards purged", "component", "board_purge", "purged", purged)
+				}
+			case <-ctx.Done():
+				logger.Log.Info("board purge shutting down gracefully", "component", "board_purge")
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"net/http"
+	"unicode/utf8"
+
+	svcutils "github.com/itchan-dev/itchan/backend/internal/service/utils"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// bannersDir is the directory under the board's media folder that holds its banners.
+const bannersDir = "banners"
+
+// BoardAppearanceService manages board banners and custom CSS.
+type BoardAppearanceService interface {
+	Get(ctx context.Context, board domain.BoardShortName) (domain.BoardAppearance, error)
+	SetCustomCSS(ctx context.Context, board domain.BoardShortName, css string) error
+	AddBanner(ctx context.Context, board domain.BoardShortName, file *domain.PendingFile) (domain.BoardBannerId, error)
+	DeleteBanner(ctx context.Context, board domain.BoardShortName, id domain.BoardBannerId) error
+}
+
+// BoardAppearanceStorage defines storage interface for board banners and custom CSS
+type BoardAppearanceStorage interface {
+	GetBoardAppearance(ctx context.Context, board domain.BoardShortName) (domain.BoardAppearance, error)
+	SetBoardCustomCSS(ctx context.Context, board domain.BoardShortName, css string) error
+	AddBoardBanner(ctx context.Context, board domain.BoardShortName, filePath string) (domain.BoardBannerId, error)
+	DeleteBoardBanner(ctx context.Context, board domain.BoardShortName, id domain.BoardBannerId) (string, error)
+}
+
+type BoardAppearance struct {
+	storage      BoardAppearanceStorage
+	mediaStorage MediaStorage
+	cfg          *config.Public
+}
+
+func NewBoardAppearance(storage BoardAppearanceStorage, mediaStorage MediaStorage, cfg *config.Public) BoardAppearanceService {
+	return &BoardAppearance{
+		storage:      storage,
+		mediaStorage: mediaStorage,
+		cfg:          cfg,
+	}
+}
+
+func (a *BoardAppearance) Get(ctx context.Context, board domain.BoardShortName) (domain.BoardAppearance, error) {
+	return a.storage.GetBoardAppearance(ctx, board)
+}
+
+// SetCustomCSS stores the board's stylesheet as entered. Only its length is
+// checked here; the frontend sanitizes it before serving.
+func (a *BoardAppearance) SetCustomCSS(ctx context.Context, board domain.BoardShortName, css string) error {
+	if utf8.RuneCountInString(css) > a.cfg.BoardCustomCSSMaxLen {
+		return &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Custom CSS is too long (max %d characters)", a.cfg.BoardCustomCSSMaxLen),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return a.storage.SetBoardCustomCSS(ctx, board, css)
+}
+
+// AddBanner re-encodes an uploaded image the same way image attachments are,
+// which strips its metadata and gives the file an extension matching its
+// content, and adds it to the board's banners.
+func (a *BoardAppearance) AddBanner(ctx context.Context, board domain.BoardShortName, file *domain.PendingFile) (domain.BoardBannerId, error) {
+	if file.SizeBytes > a.cfg.MaxBannerSizeBytes {
+		return 0, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Banner is too large (max %d bytes)", a.cfg.MaxBannerSizeBytes),
+			StatusCode: http.StatusRequestEntityTooLarge,
+		}
+	}
+	appearance, err := a.storage.GetBoardAppearance(ctx, board)
+	if err != nil {
+		return 0, err
+	}
+	if len(appearance.Banners) >= a.cfg.MaxBannersPerBoard {
+		return 0, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Board already has %d banners, delete one first", len(appearance.Banners)),
+			StatusCode: http.StatusConflict,
+		}
+	}
+
+	sanitized, err := svcutils.SanitizeImage(file, a.cfg.MaxDecodedImageSize)
+	if err != nil {
+		return 0, &errors.ErrorWithStatusCode{Message: "Banner must be a valid image", StatusCode: http.StatusBadRequest}
+	}
+	filePath, _, err := a.mediaStorage.SaveImage(sanitized.Image.(image.Image), sanitized.Format, string(board), bannersDir, sanitized.Filename)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save banner: %w", err)
+	}
+
+	id, err := a.storage.AddBoardBanner(ctx, board, filePath)
+	if err != nil {
+		if delErr := a.mediaStorage.DeleteFile(filePath); delErr != nil {
+			logger.Log.Error("failed to delete banner file", "path", filePath, "error", delErr)
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+func (a *BoardAppearance) DeleteBanner(ctx context.Context, board domain.BoardShortName, id domain.BoardBannerId) error {
+	filePath, err := a.storage.DeleteBoardBanner(ctx, board, id)
+	if err != nil {
+		return err
+	}
+	// Best effort: the media garbage collector removes the file otherwise
+	if err := a.mediaStorage.DeleteFile(filePath); err != nil {
+		logger.Log.Error("failed to delete banner file", "path", filePath, "error", err)
+	}
+	return nil
+}
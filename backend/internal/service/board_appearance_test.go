@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockBoardAppearanceStorage struct {
+	getBoardAppearanceFunc func(board domain.BoardShortName) (domain.BoardAppearance, error)
+	setBoardCustomCSSFunc  func(board domain.BoardShortName, css string) error
+	addBoardBannerFunc     func(board domain.BoardShortName, filePath string) (domain.BoardBannerId, error)
+	deleteBoardBannerFunc  func(board domain.BoardShortName, id domain.BoardBannerId) (string, error)
+}
+
+func (m *MockBoardAppearanceStorage) GetBoardAppearance(ctx context.Context, board domain.BoardShortName) (domain.BoardAppearance, error) {
+	if m.getBoardAppearanceFunc != nil {
+		return m.getBoardAppearanceFunc(board)
+	}
+	return domain.BoardAppearance{}, nil
+}
+
+func (m *MockBoardAppearanceStorage) SetBoardCustomCSS(ctx context.Context, board domain.BoardShortName, css string) error {
+	if m.setBoardCustomCSSFunc != nil {
+		return m.setBoardCustomCSSFunc(board, css)
+	}
+	return nil
+}
+
+func (m *MockBoardAppearanceStorage) AddBoardBanner(ctx context.Context, board domain.BoardShortName, filePath string) (domain.BoardBannerId, error) {
+	if m.addBoardBannerFunc != nil {
+		return m.addBoardBannerFunc(board, filePath)
+	}
+	return 1, nil
+}
+
+func (m *MockBoardAppearanceStorage) DeleteBoardBanner(ctx context.Context, board domain.BoardShortName, id domain.BoardBannerId) (string, error) {
+	if m.deleteBoardBannerFunc != nil {
+		return m.deleteBoardBannerFunc(board, id)
+	}
+	return "", nil
+}
+
+func TestBoardAppearanceSetCustomCSS(t *testing.T) {
+	cfg := &config.Public{BoardCustomCSSMaxLen: 20}
+
+	t.Run("stores the stylesheet", func(t *testing.T) {
+		var stored string
+		appearance := NewBoardAppearance(&MockBoardAppearanceStorage{
+			setBoardCustomCSSFunc: func(board domain.BoardShortName, css string) error {
+				stored = css
+				return nil
+			},
+		}, &SharedMockMediaStorage{}, cfg)
+
+		require.NoError(t, appearance.SetCustomCSS(context.Background(), "b", "body{color:red}"))
+		assert.Equal(t, "body{color:red}", stored)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		appearance := NewBoardAppearance(&MockBoardAppearanceStorage{}, &SharedMockMediaStorage{}, cfg)
+
+		err := appearance.SetCustomCSS(context.Background(), "b", strings.Repeat("a", 21))
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+}
+
+func TestBoardAppearanceAddBanner(t *testing.T) {
+	cfg := &config.Public{MaxBannerSizeBytes: 1 << 20, MaxBannersPerBoard: 2, MaxDecodedImageSize: 100 << 20}
+	banner := func(t *testing.T) *domain.PendingFile {
+		data := loadTestImage(t)
+		return &domain.PendingFile{
+			FileCommonMetadata: domain.FileCommonMetadata{Filename: "banner.jpg", SizeBytes: int64(len(data)), MimeType: "image/jpeg"},
+			Data:               bytes.NewReader(data),
+		}
+	}
+
+	t.Run("saves the re-encoded image under the board", func(t *testing.T) {
+		media := &SharedMockMediaStorage{}
+		var recorded string
+		appearance := NewBoardAppearance(&MockBoardAppearanceStorage{
+			addBoardBannerFunc: func(board domain.BoardShortName, filePath string) (domain.BoardBannerId, error) {
+				recorded = filePath
+				return 7, nil
+			},
+		}, media, cfg)
+
+		id, err := appearance.AddBanner(context.Background(), "b", banner(t))
+		require.NoError(t, err)
+		assert.Equal(t, domain.BoardBannerId(7), id)
+		require.Len(t, media.saveImageCalls, 1)
+		assert.Equal(t, "b", media.saveImageCalls[0].BoardID)
+		assert.Equal(t, bannersDir, media.saveImageCalls[0].ThreadID)
+		assert.Equal(t, "b/banners/banner.jpg", recorded)
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		media := &SharedMockMediaStorage{}
+		appearance := NewBoardAppearance(&MockBoardAppearanceStorage{}, media, cfg)
+
+		_, err := appearance.AddBanner(context.Background(), "b", &domain.PendingFile{
+			FileCommonMetadata: domain.FileCommonMetadata{Filename: "banner.html", SizeBytes: 20},
+			Data:               strings.NewReader("<script>x</script>"),
+		})
+		requireStatus(t, err, http.StatusBadRequest)
+		assert.Empty(t, media.saveImageCalls)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		file := banner(t)
+		file.SizeBytes = cfg.MaxBannerSizeBytes + 1
+		appearance := NewBoardAppearance(&MockBoardAppearanceStorage{}, &SharedMockMediaStorage{}, cfg)
+
+		_, err := appearance.AddBanner(context.Background(), "b", file)
+		requireStatus(t, err, http.StatusRequestEntityTooLarge)
+	})
+
+	t.Run("banner limit", func(t *testing.T) {
+		appearance := NewBoardAppearance(&MockBoardAppearanceStorage{
+			getBoardAppearanceFunc: func(board domain.BoardShortName) (domain.BoardAppearance, error) {
+				return domain.BoardAppearance{Banners: make([]domain.BoardBanner, 2)}, nil
+			},
+		}, &SharedMockMediaStorage{}, cfg)
+
+		_, err := appearance.AddBanner(context.Background(), "b", banner(t))
+		requireStatus(t, err, http.StatusConflict)
+	})
+
+	t.Run("file is removed when the record fails", func(t *testing.T) {
+		media := &SharedMockMediaStorage{}
+		appearance := NewBoardAppearance(&MockBoardAppearanceStorage{
+			addBoardBannerFunc: func(board domain.BoardShortName, filePath string) (domain.BoardBannerId, error) {
+				return 0, errors.New("db down")
+			},
+		}, media, cfg)
+
+		_, err := appearance.AddBanner(context.Background(), "b", banner(t))
+		require.Error(t, err)
+		assert.Equal(t, []string{"b/banners/banner.jpg"}, media.deleteFileCalls)
+	})
+}
+
+func TestBoardAppearanceDeleteBanner(t *testing.T) {
+	media := &SharedMockMediaStorage{}
+	appearance := NewBoardAppearance(&MockBoardAppearanceStorage{
+		deleteBoardBannerFunc: func(board domain.BoardShortName, id domain.BoardBannerId) (string, error) {
+			assert.Equal(t, domain.BoardBannerId(3), id)
+			return "b/banners/x.png", nil
+		},
+	}, media, &config.Public{})
+
+	require.NoError(t, appearance.DeleteBanner(context.Background(), "b", 3))
+	assert.Equal(t, []string{"b/banners/x.png"}, media.deleteFileCalls)
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// defaultPostsPerHourWindow is the number of hours returned when the caller
+// does not ask for a specific window (capped by the retention period).
+const defaultPostsPerHourWindow = 7 * 24
+
+// Top posters are counted over the last hour by default and over at most a
+// week, which keeps the scan within the newest message partitions.
+const (
+	defaultPostersWindow = time.Hour
+	maxPostersWindow     = 7 * 24 * time.Hour
+	topPostersLimit      = 50
+)
+
+// BoardStatsService serves the hourly post counts behind the admin activity
+// charts and the per-board poster activity moderators check for floods.
+type BoardStatsService interface {
+	// PostsPerHour returns the last hours of post counts of one board, or of
+	// every board with posts in that time when board is empty. Zero hours
+	// selects the default window.
+	PostsPerHour(ctx context.Context, board domain.BoardShortName, hours int) (domain.PostsPerHour, error)
+	// TopPosters returns the users who posted most on board within the last
+	// window. A zero window selects the default one.
+	TopPosters(ctx context.Context, board domain.BoardShortName, window time.Duration) (domain.BoardPosters, error)
+}
+
+// BoardStatsStorage defines the storage operations of the post count rollup.
+type BoardStatsStorage interface {
+	RollupPostCounts(ctx context.Context, from, until time.Time) error
+	GetLatestPostCountHour(ctx context.Context) (*time.Time, error)
+	DeletePostCountsBefore(ctx context.Context, before time.Time) (int64, error)
+	GetPostCounts(ctx context.Context, board domain.BoardShortName, since time.Time) ([]domain.HourlyPostCount, error)
+	GetTopPosters(ctx context.Context, board domain.BoardShortName, since time.Time, limit int) ([]domain.PosterActivity, error)
+}
+
+// BoardStats rolls up messages into hourly post counts per board, so the
+// charts never have to count the message partitions themselves.
+type BoardStats struct {
+	storage BoardStatsStorage
+	cfg     *config.Public
+	now     func() time.Time
+}
+
+// NewBoardStats creates a new BoardStats service.
+func NewBoardStats(storage BoardStatsStorage, cfg *config.Public) *BoardStats {
+	return &BoardStats{
+		storage: storage,
+		cfg:     cfg,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// StartBackgroundRollup rolls up post counts right away and then every
+// interval until ctx is cancelled.
+func (s *BoardStats) StartBackgroundRollup(ctx context.Context, interval time.Duration) {
+	logger.Log.Info("started board stats rollup",
+		"component", "board_stats",
+		"interval", interval,
+		"retention", s.cfg.BoardStatsRetention)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Rollup(ctx); err != nil {
+				logger.Log.Error("board stats rollup failed", "component", "board_stats", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				logger.Log.Info("board stats rollup shutting down gracefully", "component", "board_stats")
+				return
+			}
+		}
+	}()
+}
+
+// Rollup recounts the hours from the one before the latest stored hour up to
+// the current one, which is still filling up. The hour before is recounted as
+// well so posts committed just after a run are not missed. The first run
+// covers the whole retention period. Counts older than the retention period
+// are dropped.
+func (s *BoardStats) Rollup(ctx context.Context) error {
+	current := s.now().Truncate(time.Hour)
+	oldest := current.Add(-s.cfg.BoardStatsRetention)
+
+	from := oldest
+	latest, err := s.storage.GetLatestPostCountHour(ctx)
+	if err != nil {
+		return err
+	}
+	if latest != nil && latest.Add(-time.Hour).After(from) {
+		from = latest.Add(-time.Hour)
+	}
+
+	if err := s.storage.RollupPostCounts(ctx, from, current.Add(time.Hour)); err != nil {
+		return err
+	}
+	if _, err := s.storage.DeletePostCountsBefore(ctx, oldest); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PostsPerHour builds zero-filled series ending with the current hour.
+func (s *BoardStats) PostsPerHour(ctx context.Context, board domain.BoardShortName, hours int) (domain.PostsPerHour, error) {
+	maxHours := int(s.cfg.BoardStatsRetention / time.Hour)
+	if hours == 0 {
+		hours = min(defaultPostsPerHourWindow, maxHours)
+	}
+	if hours < 1 || hours > maxHours {
+		return domain.PostsPerHour{}, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("hours must be between 1 and %d", maxHours),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	from := s.now().Truncate(time.Hour).Add(-time.Duration(hours-1) * time.Hour)
+	counts, err := s.storage.GetPostCounts(ctx, board, from)
+	if err != nil {
+		return domain.PostsPerHour{}, err
+	}
+
+	result := domain.PostsPerHour{From: from, Hours: hours, Boards: []domain.BoardPostSeries{}}
+	if board != "" {
+		result.Boards = append(result.Boards, domain.BoardPostSeries{Board: board, Posts: make([]int, hours)})
+	}
+	// Counts come ordered by board, so each board's series is the last one
+	for _, c := range counts {
+		if n := len(result.Boards); n == 0 || result.Boards[n-1].Board != c.Board {
+			result.Boards = append(result.Boards, domain.BoardPostSeries{Board: c.Board, Posts: make([]int, hours)})
+		}
+		i := int(c.Hour.UTC().Sub(from) / time.Hour)
+		if i >= 0 && i < hours {
+			result.Boards[len(result.Boards)-1].Posts[i] = c.Posts
+		}
+	}
+	return result, nil
+}
+
+// TopPosters counts the messages themselves rather than the hourly rollup,
+// since the rollup does not keep authors.
+func (s *BoardStats) TopPosters(ctx context.Context, board domain.BoardShortName, window time.Duration) (domain.BoardPosters, error) {
+	if window == 0 {
+		window = defaultPostersWindow
+	}
+	if window < time.Minute || window > maxPostersWindow {
+		return domain.BoardPosters{}, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("window must be between 1m and %dh", int(maxPostersWindow/time.Hour)),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	since := s.now().Add(-window)
+	posters, err := s.storage.GetTopPosters(ctx, board, since, topPostersLimit)
+	if err != nil {
+		return domain.BoardPosters{}, err
+	}
+	if posters == nil {
+		posters = []domain.PosterActivity{}
+	}
+	return domain.BoardPosters{Board: board, Since: since, Posters: posters}, nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mock for BoardStatsStorage ---
+
+type MockBoardStatsStorage struct {
+	RollupPostCountsFunc       func(from, until time.Time) error
+	GetLatestPostCountHourFunc func() (*time.Time, error)
+	DeletePostCountsBeforeFunc func(before time.Time) (int64, error)
+	GetPostCountsFunc          func(board domain.BoardShortName, since time.Time) ([]domain.HourlyPostCount, error)
+	GetTopPostersFunc          func(board domain.BoardShortName, since time.Time, limit int) ([]domain.PosterActivity, error)
+}
+
+func (m *MockBoardStatsStorage) RollupPostCounts(ctx context.Context, from, until time.Time) error {
+	if m.RollupPostCountsFunc != nil {
+		return m.RollupPostCountsFunc(from, until)
+	}
+	return nil
+}
+
+func (m *MockBoardStatsStorage) GetLatestPostCountHour(ctx context.Context) (*time.Time, error) {
+	if m.GetLatestPostCountHourFunc != nil {
+		return m.GetLatestPostCountHourFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockBoardStatsStorage) DeletePostCountsBefore(ctx context.Context, before time.Time) (int64, error) {
+	if m.DeletePostCountsBeforeFunc != nil {
+		return m.DeletePostCountsBeforeFunc(before)
+	}
+	return 0, nil
+}
+
+func (m *MockBoardStatsStorage) GetPostCounts(ctx context.Context, board domain.BoardShortName, since time.Time) ([]domain.HourlyPostCount, error) {
+	if m.GetPostCountsFunc != nil {
+		return m.GetPostCountsFunc(board, since)
+	}
+	return nil, nil
+}
+
+// --- Tests ---
+
+func (m *MockBoardStatsStorage) GetTopPosters(ctx context.Context, board domain.BoardShortName, since time.Time, limit int) ([]domain.PosterActivity, error) {
+	if m.GetTopPostersFunc != nil {
+		return m.GetTopPostersFunc(board, since, limit)
+	}
+	return nil, nil
+}
+
+func TestBoardStatsRollup(t *testing.T) {
+	cfg := &config.Public{BoardStatsRetention: 48 * time.Hour}
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	hour := time.Date(2026, 3, 4, 15, 0, 0, 0, time.UTC)
+
+	newService := func(storage BoardStatsStorage) *BoardStats {
+		s := NewBoardStats(storage, cfg)
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("first run backfills the retention period", func(t *testing.T) {
+		var from, until, before time.Time
+		storage := &MockBoardStatsStorage{
+			RollupPostCountsFunc: func(f, u time.Time) error { from, until = f, u; return nil },
+			DeletePostCountsBeforeFunc: func(b time.Time) (int64, error) {
+				before = b
+				return 0, nil
+			},
+		}
+
+		require.NoError(t, newService(storage).Rollup(context.Background()))
+		assert.Equal(t, hour.Add(-48*time.Hour), from)
+		assert.Equal(t, hour.Add(time.Hour), until, "the current hour is included")
+		assert.Equal(t, hour.Add(-48*time.Hour), before)
+	})
+
+	t.Run("later runs recount from the hour before the latest", func(t *testing.T) {
+		latest := hour.Add(-time.Hour)
+		var from time.Time
+		storage := &MockBoardStatsStorage{
+			GetLatestPostCountHourFunc: func() (*time.Time, error) { return &latest, nil },
+			RollupPostCountsFunc:       func(f, u time.Time) error { from = f; return nil },
+		}
+
+		require.NoError(t, newService(storage).Rollup(context.Background()))
+		assert.Equal(t, hour.Add(-2*time.Hour), from)
+	})
+
+	t.Run("latest hour older than the retention period", func(t *testing.T) {
+		latest := hour.Add(-100 * time.Hour)
+		var from time.Time
+		storage := &MockBoardStatsStorage{
+			GetLatestPostCountHourFunc: func() (*time.Time, error) { return &latest, nil },
+			RollupPostCountsFunc:       func(f, u time.Time) error { from = f; return nil },
+		}
+
+		require.NoError(t, newService(storage).Rollup(context.Background()))
+		assert.Equal(t, hour.Add(-48*time.Hour), from)
+	})
+}
+
+func TestBoardStatsPostsPerHour(t *testing.T) {
+	cfg := &config.Public{BoardStatsRetention: 48 * time.Hour}
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	hour := time.Date(2026, 3, 4, 15, 0, 0, 0, time.UTC)
+
+	newService := func(storage BoardStatsStorage) *BoardStats {
+		s := NewBoardStats(storage, cfg)
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("builds zero-filled series per board", func(t *testing.T) {
+		storage := &MockBoardStatsStorage{
+			GetPostCountsFunc: func(board domain.BoardShortName, since time.Time) ([]domain.HourlyPostCount, error) {
+				assert.Equal(t, domain.BoardShortName(""), board)
+				assert.Equal(t, hour.Add(-2*time.Hour), since)
+				return []domain.HourlyPostCount{
+					{Board: "a", Hour: hour.Add(-2 * time.Hour), Posts: 4},
+					{Board: "a", Hour: hour, Posts: 1},
+					{Board: "b", Hour: hour.Add(-time.Hour), Posts: 7},
+				}, nil
+			},
+		}
+
+		stats, err := newService(storage).PostsPerHour(context.Background(), "", 3)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PostsPerHour{
+			From:  hour.Add(-2 * time.Hour),
+			Hours: 3,
+			Boards: []domain.BoardPostSeries{
+				{Board: "a", Posts: []int{4, 0, 1}},
+				{Board: "b", Posts: []int{0, 7, 0}},
+			},
+		}, stats)
+	})
+
+	t.Run("requested board without posts", func(t *testing.T) {
+		stats, err := newService(&MockBoardStatsStorage{}).PostsPerHour(context.Background(), "quiet", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.BoardPostSeries{{Board: "quiet", Posts: []int{0, 0}}}, stats.Boards)
+	})
+
+	t.Run("default window is capped by the retention period", func(t *testing.T) {
+		stats, err := newService(&MockBoardStatsStorage{}).PostsPerHour(context.Background(), "", 0)
+		require.NoError(t, err)
+		assert.Equal(t, 48, stats.Hours)
+		assert.Empty(t, stats.Boards)
+	})
+
+	t.Run("window beyond the retention period", func(t *testing.T) {
+		_, err := newService(&MockBoardStatsStorage{}).PostsPerHour(context.Background(), "", 49)
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+}
+
+func TestBoardStatsTopPosters(t *testing.T) {
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	newService := func(storage BoardStatsStorage) *BoardStats {
+		s := NewBoardStats(storage, &config.Public{BoardStatsRetention: 48 * time.Hour})
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("counts the requested window", func(t *testing.T) {
+		storage := &MockBoardStatsStorage{
+			GetTopPostersFunc: func(board domain.BoardShortName, since time.Time, limit int) ([]domain.PosterActivity, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, now.Add(-6*time.Hour), since)
+				assert.Equal(t, topPostersLimit, limit)
+				return []domain.PosterActivity{{UserId: 7, Posts: 12, Burst: 9}}, nil
+			},
+		}
+
+		posters, err := newService(storage).TopPosters(context.Background(), "b", 6*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, domain.BoardPosters{
+			Board:   "b",
+			Since:   now.Add(-6 * time.Hour),
+			Posters: []domain.PosterActivity{{UserId: 7, Posts: 12, Burst: 9}},
+		}, posters)
+	})
+
+	t.Run("default window without posters", func(t *testing.T) {
+		posters, err := newService(&MockBoardStatsStorage{}).TopPosters(context.Background(), "b", 0)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(-defaultPostersWindow), posters.Since)
+		assert.NotNil(t, posters.Posters)
+		assert.Empty(t, posters.Posters)
+	})
+
+	t.Run("window out of range", func(t *testing.T) {
+		for _, window := range []time.Duration{time.Second, -time.Hour, maxPostersWindow + time.Hour} {
+			_, err := newService(&MockBoardStatsStorage{}).TopPosters(context.Background(), "b", window)
+			requireStatus(t, err, http.StatusBadRequest)
+		}
+	})
+}
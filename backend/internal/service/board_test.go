@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,16 +21,26 @@ type MockBoardStorage struct {
 	getBoardFunc    func(shortName domain.BoardShortName, page int) (domain.Board, error)
 	deleteBoardFunc func(shortName domain.BoardShortName) error
 	getBoardsFunc   func() ([]domain.BoardMetadata, error)
+	listBoardsFunc  func(sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error)
+	getCategories   func() ([]domain.BoardCategory, error)
+
+	updateBoardSettingsFunc func(shortName domain.BoardShortName, settings domain.BoardSettings) error
+	getDeletionReportFunc   func(board domain.BoardShortName, threadId *domain.ThreadId) (domain.DeletionReport, error)
+
+	scheduleBoardDeletionFunc    func(shortName domain.BoardShortName, deleteAfter time.Time) error
+	cancelBoardDeletionFunc      func(shortName domain.BoardShortName) error
+	getPendingBoardDeletionsFunc func() ([]domain.PendingBoardDeletion, error)
+	renameBoardFunc              func(oldName, newName domain.BoardShortName, redirectUntil *time.Time) error
 }
 
-func (m *MockBoardStorage) CreateBoard(creationData domain.BoardCreationData) error {
+func (m *MockBoardStorage) CreateBoard(ctx context.Context, creationData domain.BoardCreationData) error {
 	if m.createBoardFunc != nil {
 		return m.createBoardFunc(creationData)
 	}
 	return nil // Default success
 }
 
-func (m *MockBoardStorage) GetBoard(shortName domain.BoardShortName, page int) (domain.Board, error) {
+func (m *MockBoardStorage) GetBoard(ctx context.Context, shortName domain.BoardShortName, page int) (domain.Board, error) {
 	if m.getBoardFunc != nil {
 		return m.getBoardFunc(shortName, page)
 	}
@@ -35,28 +48,102 @@ func (m *MockBoardStorage) GetBoard(shortName domain.BoardShortName, page int) (
 	return domain.Board{BoardMetadata: domain.BoardMetadata{ShortName: shortName}}, nil
 }
 
-func (m *MockBoardStorage) DeleteBoard(shortName domain.BoardShortName) error {
+func (m *MockBoardStorage) DeleteBoard(ctx context.Context, shortName domain.BoardShortName) error {
 	if m.deleteBoardFunc != nil {
 		return m.deleteBoardFunc(shortName)
 	}
 	return nil // Default success
 }
 
-func (m *MockBoardStorage) GetBoardLastModified(shortName domain.BoardShortName) (time.Time, error) {
+func (m *MockBoardStorage) GetDeletionReport(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId) (domain.DeletionReport, error) {
+	if m.getDeletionReportFunc != nil {
+		return m.getDeletionReportFunc(board, threadId)
+	}
+	return domain.DeletionReport{}, nil
+}
+
+func (m *MockBoardStorage) ScheduleBoardDeletion(ctx context.Context, shortName domain.BoardShortName, deleteAfter time.Time) error {
+	if m.scheduleBoardDeletionFunc != nil {
+		return m.scheduleBoardDeletionFunc(shortName, deleteAfter)
+	}
+	return nil
+}
+
+func (m *MockBoardStorage) CancelBoardDeletion(ctx context.Context, shortName domain.BoardShortName) error {
+	if m.cancelBoardDeletionFunc != nil {
+		return m.cancelBoardDeletionFunc(shortName)
+	}
+	return nil
+}
+
+func (m *MockBoardStorage) GetPendingBoardDeletions(ctx context.Context) ([]domain.PendingBoardDeletion, error) {
+	if m.getPendingBoardDeletionsFunc != nil {
+		return m.getPendingBoardDeletionsFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockBoardStorage) RenameBoard(ctx context.Context, oldName, newName domain.BoardShortName, redirectUntil *time.Time) error {
+	if m.renameBoardFunc != nil {
+		return m.renameBoardFunc(oldName, newName, redirectUntil)
+	}
+	return nil
+}
+
+func (m *MockBoardStorage) GetBoardLastModified(ctx context.Context, shortName domain.BoardShortName) (time.Time, error) {
 	return time.Now().UTC(), nil
 }
 
-func (m *MockBoardStorage) GetBoards() ([]domain.BoardMetadata, error) {
+func (m *MockBoardStorage) GetBoards(ctx context.Context) ([]domain.BoardMetadata, error) {
 	if m.getBoardsFunc != nil {
 		return m.getBoardsFunc()
 	}
 	return []domain.BoardMetadata{}, nil
 }
 
+func (m *MockBoardStorage) ListBoards(ctx context.Context, sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error) {
+	if m.listBoardsFunc != nil {
+		return m.listBoardsFunc(sort, stats, limit, offset)
+	}
+	return nil, 0, nil
+}
+
+func (m *MockBoardStorage) CreateBoardCategory(ctx context.Context, data domain.BoardCategoryCreationData) (domain.BoardCategoryId, error) {
+	return 1, nil
+}
+
+func (m *MockBoardStorage) UpdateBoardCategory(ctx context.Context, id domain.BoardCategoryId, data domain.BoardCategoryCreationData) error {
+	return nil
+}
+
+func (m *MockBoardStorage) DeleteBoardCategory(ctx context.Context, id domain.BoardCategoryId) error {
+	return nil
+}
+
+func (m *MockBoardStorage) GetBoardCategories(ctx context.Context) ([]domain.BoardCategory, error) {
+	if m.getCategories != nil {
+		return m.getCategories()
+	}
+	return []domain.BoardCategory{}, nil
+}
+
+func (m *MockBoardStorage) SetBoardCategory(ctx context.Context, shortName domain.BoardShortName, categoryId *domain.BoardCategoryId, position int) error {
+	return nil
+}
+
+func (m *MockBoardStorage) UpdateBoardSettings(ctx context.Context, shortName domain.BoardShortName, settings domain.BoardSettings) error {
+	if m.updateBoardSettingsFunc != nil {
+		return m.updateBoardSettingsFunc(shortName, settings)
+	}
+	return nil
+}
+
 // MockBoardValidator mocks the BoardValidator interface.
 type MockBoardValidator struct {
-	nameFunc      func(name domain.BoardName) error
-	shortNameFunc func(shortName domain.BoardShortName) error
+	nameFunc        func(name domain.BoardName) error
+	shortNameFunc   func(shortName domain.BoardShortName) error
+	categoryFunc    func(name domain.BoardCategoryName) error
+	descriptionFunc func(description string) error
 }
 
 func (m *MockBoardValidator) Name(name domain.BoardName) error {
@@ -73,6 +160,30 @@ func (m *MockBoardValidator) ShortName(shortName domain.BoardShortName) error {
 	return nil // Default valid
 }
 
+func (m *MockBoardValidator) CategoryName(name domain.BoardCategoryName) error {
+	if m.categoryFunc != nil {
+		return m.categoryFunc(name)
+	}
+	return nil // Default valid
+}
+
+func (m *MockBoardValidator) Description(description string) error {
+	if m.descriptionFunc != nil {
+		return m.descriptionFunc(description)
+	}
+	return nil // Default valid
+}
+
+// MockPermissionChecker mocks the PermissionChecker interface and counts invalidations.
+type MockPermissionChecker struct {
+	invalidations int
+}
+
+func (m *MockPermissionChecker) AllowedDomains(board string) []string  { return nil }
+func (m *MockPermissionChecker) PendingDeletion(board string) bool     { return false }
+func (m *MockPermissionChecker) RenamedTo(board string) (string, bool) { return "", false }
+func (m *MockPermissionChecker) Invalidate()                           { m.invalidations++ }
+
 // --- Tests ---
 
 func TestBoardCreate(t *testing.T) {
@@ -102,14 +213,16 @@ func TestBoardCreate(t *testing.T) {
 			return nil
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		permissions := &MockPermissionChecker{}
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, permissions, &config.Public{})
 
 		// Act
-		err := service.Create(validCreationData)
+		err := service.Create(context.Background(), validCreationData)
 
 		// Assert
 		require.NoError(t, err)
 		assert.True(t, storageCalled, "Storage CreateBoard should be called")
+		assert.Equal(t, 1, permissions.invalidations, "the new board's permissions apply at once")
 	})
 
 	t.Run("Invalid Name", func(t *testing.T) {
@@ -133,10 +246,10 @@ func TestBoardCreate(t *testing.T) {
 			return nil
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, &MockPermissionChecker{}, &config.Public{})
 
 		// Act
-		err := service.Create(invalidData)
+		err := service.Create(context.Background(), invalidData)
 
 		// Assert
 		require.Error(t, err)
@@ -163,10 +276,36 @@ func TestBoardCreate(t *testing.T) {
 			return nil
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, &MockPermissionChecker{}, &config.Public{})
 
 		// Act
-		err := service.Create(invalidData)
+		err := service.Create(context.Background(), invalidData)
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, validationError))
+	})
+
+	t.Run("Invalid Description", func(t *testing.T) {
+		// Arrange
+		mockStorage := &MockBoardStorage{}
+		mockValidator := &MockBoardValidator{}
+		validationError := errors.New("description too long")
+		invalidData := domain.BoardCreationData{Name: validName, ShortName: validShortName, Description: "too long"}
+
+		mockValidator.descriptionFunc = func(description string) error {
+			assert.Equal(t, "too long", description)
+			return validationError
+		}
+		mockStorage.createBoardFunc = func(creationData domain.BoardCreationData) error {
+			t.Fatal("Storage CreateBoard should not be called when validation fails")
+			return nil
+		}
+
+		service := NewBoard(mockStorage, mockValidator, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{})
+
+		// Act
+		err := service.Create(context.Background(), invalidData)
 
 		// Assert
 		require.Error(t, err)
@@ -191,10 +330,10 @@ func TestBoardCreate(t *testing.T) {
 			return storageError
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, &MockPermissionChecker{}, &config.Public{})
 
 		// Act
-		err := service.Create(validCreationData)
+		err := service.Create(context.Background(), validCreationData)
 
 		// Assert
 		require.Error(t, err)
@@ -226,10 +365,10 @@ func TestBoardGet(t *testing.T) {
 			return expectedBoard, nil
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, &MockPermissionChecker{}, &config.Public{})
 
 		// Act
-		board, err := service.Get(validShortName, requestedPage)
+		board, err := service.Get(context.Background(), validShortName, requestedPage, nil)
 
 		// Assert
 		require.NoError(t, err)
@@ -254,10 +393,10 @@ func TestBoardGet(t *testing.T) {
 			return domain.Board{}, nil
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, &MockPermissionChecker{}, &config.Public{})
 
 		// Act
-		_, err := service.Get(invalidShortName, 1)
+		_, err := service.Get(context.Background(), invalidShortName, 1, nil)
 
 		// Assert
 		require.Error(t, err)
@@ -284,10 +423,10 @@ func TestBoardGet(t *testing.T) {
 			return domain.Board{}, storageError
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, &MockPermissionChecker{}, &config.Public{})
 
 		// Act
-		_, err := service.Get(validShortName, requestedPage)
+		_, err := service.Get(context.Background(), validShortName, requestedPage, nil)
 
 		// Assert
 		require.Error(t, err)
@@ -315,10 +454,10 @@ func TestBoardGet(t *testing.T) {
 			return expectedBoard, nil
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, &MockPermissionChecker{}, &config.Public{})
 
 		// Act
-		board, err := service.Get(validShortName, requestedPage)
+		board, err := service.Get(context.Background(), validShortName, requestedPage, nil)
 
 		// Assert
 		require.NoError(t, err)
@@ -326,6 +465,34 @@ func TestBoardGet(t *testing.T) {
 		assert.Equal(t, 1, board.Page, "Page should be corrected to 1")
 		assert.True(t, storageCalled, "Storage GetBoard should be called")
 	})
+
+	t.Run("Marks viewer's messages across threads", func(t *testing.T) {
+		// Arrange
+		mockStorage := &MockBoardStorage{}
+		mockValidator := &MockBoardValidator{}
+		viewer := &domain.User{Id: 7}
+		own := &domain.Message{MessageMetadata: domain.MessageMetadata{
+			ThreadId: 1, Id: 1, Author: domain.User{Id: 7},
+			Replies: domain.Replies{{FromThreadId: 2, From: 3, FromAuthor: 8}},
+		}}
+		other := &domain.Message{MessageMetadata: domain.MessageMetadata{ThreadId: 2, Id: 3, Author: domain.User{Id: 8}}}
+		mockStorage.getBoardFunc = func(domain.BoardShortName, int) (domain.Board, error) {
+			return domain.Board{Threads: []*domain.Thread{
+				{Messages: []*domain.Message{own}},
+				{Messages: []*domain.Message{other}},
+			}}, nil
+		}
+		service := NewBoard(mockStorage, mockValidator, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{})
+
+		// Act
+		_, err := service.Get(context.Background(), validShortName, 1, viewer)
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, own.IsOwn)
+		assert.False(t, other.IsOwn)
+		assert.True(t, other.RepliesToOwn, "Reply from another thread on the page should be flagged")
+	})
 }
 
 func TestBoardDelete(t *testing.T) {
@@ -348,10 +515,10 @@ func TestBoardDelete(t *testing.T) {
 			return nil
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, &MockPermissionChecker{}, &config.Public{})
 
 		// Act
-		err := service.Delete(validShortName)
+		err := service.Delete(context.Background(), validShortName)
 
 		// Assert
 		require.NoError(t, err)
@@ -374,10 +541,10 @@ func TestBoardDelete(t *testing.T) {
 			return nil
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, &MockPermissionChecker{}, &config.Public{})
 
 		// Act
-		err := service.Delete(invalidShortName)
+		err := service.Delete(context.Background(), invalidShortName)
 
 		// Assert
 		require.Error(t, err)
@@ -403,10 +570,10 @@ func TestBoardDelete(t *testing.T) {
 			return storageError
 		}
 
-		service := NewBoard(mockStorage, mockValidator, mockMediaStorage)
+		service := NewBoard(mockStorage, mockValidator, mockMediaStorage, &MockPermissionChecker{}, &config.Public{})
 
 		// Act
-		err := service.Delete(nonExistentShortName)
+		err := service.Delete(context.Background(), nonExistentShortName)
 
 		// Assert
 		require.Error(t, err)
@@ -414,3 +581,498 @@ func TestBoardDelete(t *testing.T) {
 		assert.True(t, storageCalled, "Storage DeleteBoard should be called")
 	})
 }
+
+func TestBoardGetGroupedBoards(t *testing.T) {
+	tech := domain.BoardCategoryId(1)
+	art := domain.BoardCategoryId(2)
+	missing := domain.BoardCategoryId(99)
+
+	t.Run("Groups boards by category and keeps order", func(t *testing.T) {
+		// Arrange
+		mockStorage := &MockBoardStorage{
+			getCategories: func() ([]domain.BoardCategory, error) {
+				return []domain.BoardCategory{{Id: tech, Name: "Tech"}, {Id: art, Name: "Art"}}, nil
+			},
+			getBoardsFunc: func() ([]domain.BoardMetadata, error) {
+				return []domain.BoardMetadata{
+					{ShortName: "g", CategoryId: &tech},
+					{ShortName: "b"},
+					{ShortName: "pr", CategoryId: &tech, Position: 1},
+					{ShortName: "x", CategoryId: &missing},
+				}, nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{})
+
+		// Act
+		groups, err := service.GetGroupedBoards(context.Background(), nil)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, groups, 3)
+		assert.Equal(t, "Tech", groups[0].Name)
+		require.Len(t, groups[0].Boards, 2)
+		assert.Equal(t, "g", groups[0].Boards[0].ShortName)
+		assert.Equal(t, "pr", groups[0].Boards[1].ShortName)
+		assert.Equal(t, "Art", groups[1].Name)
+		assert.Empty(t, groups[1].Boards)
+		assert.Zero(t, groups[2].Id, "uncategorized group should have zero id")
+		require.Len(t, groups[2].Boards, 2)
+		assert.Equal(t, "b", groups[2].Boards[0].ShortName)
+		assert.Equal(t, "x", groups[2].Boards[1].ShortName)
+	})
+
+	t.Run("No uncategorized group when every board has a category", func(t *testing.T) {
+		// Arrange
+		mockStorage := &MockBoardStorage{
+			getCategories: func() ([]domain.BoardCategory, error) {
+				return []domain.BoardCategory{{Id: tech, Name: "Tech"}}, nil
+			},
+			getBoardsFunc: func() ([]domain.BoardMetadata, error) {
+				return []domain.BoardMetadata{{ShortName: "g", CategoryId: &tech}}, nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{})
+
+		// Act
+		groups, err := service.GetGroupedBoards(context.Background(), nil)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+	})
+
+	t.Run("Hides restricted boards and their categories", func(t *testing.T) {
+		// Arrange
+		mockStorage := &MockBoardStorage{
+			getCategories: func() ([]domain.BoardCategory, error) {
+				return []domain.BoardCategory{{Id: tech, Name: "Tech"}, {Id: art, Name: "Art"}, {Id: 3, Name: "Empty"}}, nil
+			},
+			getBoardsFunc: func() ([]domain.BoardMetadata, error) {
+				return []domain.BoardMetadata{
+					{ShortName: "g", CategoryId: &tech},
+					{ShortName: "corp", CategoryId: &tech, AllowedEmailDomains: []string{"corp.com"}},
+					{ShortName: "secret", CategoryId: &art, AllowedEmailDomains: []string{"corp.com"}},
+					{ShortName: "hr", AllowedEmailDomains: []string{"corp.com"}},
+				}, nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{HideRestrictedBoards: true})
+
+		// Act
+		groups, err := service.GetGroupedBoards(context.Background(), &domain.User{EmailDomain: "other.com"})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, groups, 2, "Art only had a hidden board, no uncategorized boards are left")
+		assert.Equal(t, "Tech", groups[0].Name)
+		require.Len(t, groups[0].Boards, 1)
+		assert.Equal(t, "g", groups[0].Boards[0].ShortName)
+		assert.Equal(t, "Empty", groups[1].Name)
+
+		groups, err = service.GetGroupedBoards(context.Background(), &domain.User{EmailDomain: "corp.com"})
+		require.NoError(t, err)
+		require.Len(t, groups, 4)
+		assert.Len(t, groups[0].Boards, 2)
+	})
+
+	t.Run("Restricted boards are listed without the flag", func(t *testing.T) {
+		// Arrange
+		mockStorage := &MockBoardStorage{
+			getCategories: func() ([]domain.BoardCategory, error) { return nil, nil },
+			getBoardsFunc: func() ([]domain.BoardMetadata, error) {
+				return []domain.BoardMetadata{{ShortName: "hr", AllowedEmailDomains: []string{"corp.com"}}}, nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{})
+
+		// Act
+		groups, err := service.GetGroupedBoards(context.Background(), nil)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		assert.Len(t, groups[0].Boards, 1)
+	})
+
+	t.Run("Storage Error", func(t *testing.T) {
+		// Arrange
+		storageError := errors.New("db down")
+		mockStorage := &MockBoardStorage{
+			getCategories: func() ([]domain.BoardCategory, error) {
+				return nil, storageError
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{})
+
+		// Act
+		_, err := service.GetGroupedBoards(context.Background(), nil)
+
+		// Assert
+		assert.ErrorIs(t, err, storageError)
+	})
+}
+
+func TestBoardList(t *testing.T) {
+	t.Run("defaults to position order", func(t *testing.T) {
+		mockStorage := &MockBoardStorage{
+			listBoardsFunc: func(sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error) {
+				assert.Equal(t, domain.BoardSortPosition, sort)
+				assert.Equal(t, domain.BoardStatsCounts, stats)
+				assert.Equal(t, 10, limit)
+				assert.Equal(t, 20, offset)
+				return []domain.BoardMetadata{{ShortName: "b"}}, 21, nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{BoardsPageLimit: 10})
+
+		boards, total, err := service.List(context.Background(), "", domain.BoardStatsCounts, 3, nil)
+		require.NoError(t, err)
+		assert.Len(t, boards, 1)
+		assert.Equal(t, 21, total)
+	})
+
+	t.Run("page below one is the first page", func(t *testing.T) {
+		mockStorage := &MockBoardStorage{
+			listBoardsFunc: func(sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error) {
+				assert.Equal(t, domain.BoardSortName, sort)
+				assert.Equal(t, 0, offset)
+				return nil, 0, nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{BoardsPageLimit: 10})
+
+		_, _, err := service.List(context.Background(), domain.BoardSortName, domain.BoardStatsNone, 0, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown sort", func(t *testing.T) {
+		service := NewBoard(&MockBoardStorage{}, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{BoardsPageLimit: 10})
+
+		_, _, err := service.List(context.Background(), "popularity", domain.BoardStatsNone, 1, nil)
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("unknown stats mode", func(t *testing.T) {
+		service := NewBoard(&MockBoardStorage{}, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{BoardsPageLimit: 10})
+
+		_, _, err := service.List(context.Background(), "", "everything", 1, nil)
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("latest thread only for readable boards", func(t *testing.T) {
+		latest := func() *domain.BoardStats {
+			return &domain.BoardStats{ThreadCount: 1, LatestThread: &domain.BoardLatestThread{Id: 1, Title: "Hello"}}
+		}
+		mockStorage := &MockBoardStorage{
+			listBoardsFunc: func(sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error) {
+				assert.Equal(t, domain.BoardStatsLatest, stats)
+				return []domain.BoardMetadata{
+					{ShortName: "b", Stats: latest()},
+					{ShortName: "corp", AllowedEmailDomains: []string{"corp.com"}, Stats: latest()},
+				}, 2, nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{BoardsPageLimit: 10})
+
+		boards, _, err := service.List(context.Background(), "", domain.BoardStatsLatest, 1, &domain.User{EmailDomain: "other.com"})
+		require.NoError(t, err)
+		assert.NotNil(t, boards[0].Stats.LatestThread)
+		assert.Nil(t, boards[1].Stats.LatestThread, "corporate board hidden from outsiders")
+		assert.Equal(t, 1, boards[1].Stats.ThreadCount, "counts stay, as with the counts mode")
+
+		boards, _, err = service.List(context.Background(), "", domain.BoardStatsLatest, 1, &domain.User{EmailDomain: "corp.com"})
+		require.NoError(t, err)
+		assert.NotNil(t, boards[1].Stats.LatestThread)
+	})
+}
+
+func TestBoardListHidesRestrictedBoards(t *testing.T) {
+	// Five boards in storage, two of them restricted to corp.com
+	all := []domain.BoardMetadata{
+		{ShortName: "a"},
+		{ShortName: "corp", AllowedEmailDomains: []string{"corp.com"}},
+		{ShortName: "b"},
+		{ShortName: "hr", AllowedEmailDomains: []string{"corp.com"}},
+		{ShortName: "c"},
+	}
+	mockStorage := &MockBoardStorage{
+		listBoardsFunc: func(sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error) {
+			end := min(offset+limit, len(all))
+			return all[min(offset, end):end], len(all), nil
+		},
+	}
+	service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{BoardsPageLimit: 2, HideRestrictedBoards: true})
+	names := func(boards []domain.BoardMetadata) []domain.BoardShortName {
+		var result []domain.BoardShortName
+		for _, b := range boards {
+			result = append(result, b.ShortName)
+		}
+		return result
+	}
+
+	t.Run("outsiders page through the boards they may see", func(t *testing.T) {
+		boards, total, err := service.List(context.Background(), "", domain.BoardStatsNone, 1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.BoardShortName{"a", "b"}, names(boards))
+		assert.Equal(t, 3, total)
+
+		boards, _, err = service.List(context.Background(), "", domain.BoardStatsNone, 2, &domain.User{EmailDomain: "other.com"})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.BoardShortName{"c"}, names(boards))
+
+		boards, _, err = service.List(context.Background(), "", domain.BoardStatsNone, 3, nil)
+		require.NoError(t, err)
+		assert.Empty(t, boards)
+	})
+
+	t.Run("members and admins see every board", func(t *testing.T) {
+		for _, viewer := range []*domain.User{{EmailDomain: "corp.com"}, {Admin: true}} {
+			boards, total, err := service.List(context.Background(), "", domain.BoardStatsNone, 2, viewer)
+			require.NoError(t, err)
+			assert.Equal(t, []domain.BoardShortName{"b", "hr"}, names(boards))
+			assert.Equal(t, 5, total)
+		}
+	})
+}
+
+func TestBoardDeletionReport(t *testing.T) {
+	want := domain.DeletionReport{Threads: 2, Messages: 5, Attachments: 1, AttachmentBytes: 1024}
+	mockStorage := &MockBoardStorage{
+		getDeletionReportFunc: func(board domain.BoardShortName, threadId *domain.ThreadId) (domain.DeletionReport, error) {
+			assert.Equal(t, domain.BoardShortName("b"), board)
+			assert.Nil(t, threadId, "the whole board is counted")
+			return want, nil
+		},
+		deleteBoardFunc: func(shortName domain.BoardShortName) error {
+			t.Fatal("a dry run must not delete the board")
+			return nil
+		},
+	}
+	mediaStorage := &SharedMockMediaStorage{
+		deleteBoardFunc: func(boardID string) error {
+			t.Fatal("a dry run must not delete media")
+			return nil
+		},
+	}
+	service := NewBoard(mockStorage, &MockBoardValidator{}, mediaStorage, &MockPermissionChecker{}, &config.Public{})
+
+	report, err := service.DeletionReport(context.Background(), "b")
+	require.NoError(t, err)
+	assert.Equal(t, want, report)
+}
+
+func TestBoardScheduleDeletion(t *testing.T) {
+	t.Run("schedules after the grace period", func(t *testing.T) {
+		var scheduled time.Time
+		mockStorage := &MockBoardStorage{
+			scheduleBoardDeletionFunc: func(shortName domain.BoardShortName, deleteAfter time.Time) error {
+				assert.Equal(t, domain.BoardShortName("b"), shortName)
+				scheduled = deleteAfter
+				return nil
+			},
+			deleteBoardFunc: func(shortName domain.BoardShortName) error {
+				t.Fatal("the board must not be dropped during the grace period")
+				return nil
+			},
+		}
+		permissions := &MockPermissionChecker{}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, permissions, &config.Public{BoardDeletionGracePeriod: time.Hour})
+
+		deleteAfter, err := service.ScheduleDeletion(context.Background(), "b")
+		require.NoError(t, err)
+		assert.Equal(t, scheduled, deleteAfter)
+		assert.Equal(t, 1, permissions.invalidations, "the board is hidden at once")
+		assert.WithinDuration(t, time.Now().Add(time.Hour), deleteAfter, time.Minute)
+	})
+
+	t.Run("deletes at once without a grace period", func(t *testing.T) {
+		deleted := false
+		mockStorage := &MockBoardStorage{
+			scheduleBoardDeletionFunc: func(shortName domain.BoardShortName, deleteAfter time.Time) error {
+				t.Fatal("nothing should be scheduled")
+				return nil
+			},
+			deleteBoardFunc: func(shortName domain.BoardShortName) error {
+				deleted = true
+				return nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{})
+
+		deleteAfter, err := service.ScheduleDeletion(context.Background(), "b")
+		require.NoError(t, err)
+		assert.True(t, deleteAfter.IsZero())
+		assert.True(t, deleted)
+	})
+}
+
+func TestBoardRename(t *testing.T) {
+	t.Run("moves media and sets a redirect", func(t *testing.T) {
+		var moved []string
+		var redirect *time.Time
+		mediaStorage := &SharedMockMediaStorage{
+			renameBoardFunc: func(oldBoardID, newBoardID string) error {
+				moved = append(moved, oldBoardID+">"+newBoardID)
+				return nil
+			},
+		}
+		mockStorage := &MockBoardStorage{
+			renameBoardFunc: func(oldName, newName domain.BoardShortName, redirectUntil *time.Time) error {
+				redirect = redirectUntil
+				return nil
+			},
+		}
+		permissions := &MockPermissionChecker{}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, mediaStorage, permissions, &config.Public{BoardRenameRedirectPeriod: time.Hour})
+
+		err := service.Rename(context.Background(), "old", "new")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"old>new"}, moved)
+		assert.Equal(t, 1, permissions.invalidations)
+		require.NotNil(t, redirect)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *redirect, time.Minute)
+	})
+
+	t.Run("no redirect without a redirect period", func(t *testing.T) {
+		mockStorage := &MockBoardStorage{
+			renameBoardFunc: func(oldName, newName domain.BoardShortName, redirectUntil *time.Time) error {
+				assert.Nil(t, redirectUntil)
+				return nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{})
+
+		require.NoError(t, service.Rename(context.Background(), "old", "new"))
+	})
+
+	t.Run("rejects the same name", func(t *testing.T) {
+		service := NewBoard(&MockBoardStorage{}, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{})
+
+		err := service.Rename(context.Background(), "b", "b")
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("moves media back when the database rename fails", func(t *testing.T) {
+		var moved []string
+		mediaStorage := &SharedMockMediaStorage{
+			renameBoardFunc: func(oldBoardID, newBoardID string) error {
+				moved = append(moved, oldBoardID+">"+newBoardID)
+				return nil
+			},
+		}
+		storageErr := errors.New("board already exists")
+		mockStorage := &MockBoardStorage{
+			renameBoardFunc: func(oldName, newName domain.BoardShortName, redirectUntil *time.Time) error {
+				return storageErr
+			},
+		}
+		permissions := &MockPermissionChecker{}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, mediaStorage, permissions, &config.Public{})
+
+		err := service.Rename(context.Background(), "old", "new")
+		assert.ErrorIs(t, err, storageErr)
+		assert.Equal(t, []string{"old>new", "new>old"}, moved)
+		assert.Zero(t, permissions.invalidations)
+	})
+}
+
+func TestBoardPurgeDeleted(t *testing.T) {
+	now := time.Now().UTC()
+	var deleted []domain.BoardShortName
+	mockStorage := &MockBoardStorage{
+		getPendingBoardDeletionsFunc: func() ([]domain.PendingBoardDeletion, error) {
+			return []domain.PendingBoardDeletion{
+				{Board: "old", DeleteAfter: now.Add(-2 * time.Hour)},
+				{Board: "due", DeleteAfter: now.Add(-time.Minute)},
+				{Board: "new", DeleteAfter: now.Add(time.Hour)},
+			}, nil
+		},
+		deleteBoardFunc: func(shortName domain.BoardShortName) error {
+			deleted = append(deleted, shortName)
+			if shortName == "old" {
+				return errors.New("drop failed")
+			}
+			return nil
+		},
+	}
+	service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, &config.Public{BoardDeletionGracePeriod: time.Hour})
+
+	purged, err := service.PurgeDeleted(context.Background())
+	require.Error(t, err, "the failed drop is reported")
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, []domain.BoardShortName{"old", "due"}, deleted, "boards still in their grace period are kept")
+}
+
+func TestBoardUpdateSettings(t *testing.T) {
+	cfg := &config.Public{
+		AllowedImageMimeTypes: []string{"image/jpeg", "image/png"},
+		Media: config.MediaConfig{
+			VideoProfiles: map[string]config.VideoProfile{"original": {}, "720p": {Codec: config.VideoCodecH264, MaxHeight: 720}},
+		},
+	}
+
+	t.Run("known video profile", func(t *testing.T) {
+		var saved domain.BoardSettings
+		mockStorage := &MockBoardStorage{
+			updateBoardSettingsFunc: func(shortName domain.BoardShortName, settings domain.BoardSettings) error {
+				saved = settings
+				return nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, cfg)
+
+		require.NoError(t, service.UpdateSettings(context.Background(), "b", domain.BoardSettings{VideoProfile: "720p"}))
+		assert.Equal(t, "720p", saved.VideoProfile)
+	})
+
+	t.Run("default video profile", func(t *testing.T) {
+		service := NewBoard(&MockBoardStorage{}, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, cfg)
+
+		require.NoError(t, service.UpdateSettings(context.Background(), "b", domain.BoardSettings{}))
+	})
+
+	t.Run("unknown video profile", func(t *testing.T) {
+		mockStorage := &MockBoardStorage{
+			updateBoardSettingsFunc: func(domain.BoardShortName, domain.BoardSettings) error {
+				t.Fatal("settings with an unknown profile must not be saved")
+				return nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, cfg)
+
+		err := service.UpdateSettings(context.Background(), "b", domain.BoardSettings{VideoProfile: "4k"})
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("narrowed mime types", func(t *testing.T) {
+		service := NewBoard(&MockBoardStorage{}, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, cfg)
+
+		err := service.UpdateSettings(context.Background(), "b", domain.BoardSettings{UploadRules: domain.UploadRules{AllowedMimeTypes: []string{"image/png"}}})
+		require.NoError(t, err)
+	})
+
+	t.Run("mime type not enabled on the site", func(t *testing.T) {
+		service := NewBoard(&MockBoardStorage{}, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, cfg)
+
+		err := service.UpdateSettings(context.Background(), "b", domain.BoardSettings{UploadRules: domain.UploadRules{AllowedMimeTypes: []string{"image/png", "video/mp4"}}})
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("posting email domains", func(t *testing.T) {
+		var saved domain.BoardSettings
+		mockStorage := &MockBoardStorage{
+			updateBoardSettingsFunc: func(shortName domain.BoardShortName, settings domain.BoardSettings) error {
+				saved = settings
+				return nil
+			},
+		}
+		service := NewBoard(mockStorage, &MockBoardValidator{}, &SharedMockMediaStorage{}, &MockPermissionChecker{}, cfg)
+
+		require.NoError(t, service.UpdateSettings(context.Background(), "b", domain.BoardSettings{PostingEmailDomains: []string{" Corp.com", "@corp.org"}}))
+		assert.Equal(t, []string{"corp.com", "corp.org"}, saved.PostingEmailDomains)
+
+		err := service.UpdateSettings(context.Background(), "b", domain.BoardSettings{PostingEmailDomains: []string{"user@corp.com"}})
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+)
+
+// BookmarkService manages the messages a user saved for later. Unlike thread
+// watches, bookmarks point at single posts and send no notifications.
+type BookmarkService interface {
+	Add(ctx context.Context, user *domain.User, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	Remove(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	List(ctx context.Context, user *domain.User, page int) ([]domain.Bookmark, int, error)
+}
+
+// BookmarkStorage defines storage interface for message bookmarks
+type BookmarkStorage interface {
+	GetBoards(ctx context.Context) ([]domain.BoardMetadata, error)
+	AddBookmark(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	DeleteBookmark(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	GetBookmarks(ctx context.Context, userId domain.UserId, boards []domain.BoardShortName, limit, offset int) ([]domain.Bookmark, int, error)
+}
+
+type Bookmark struct {
+	storage BookmarkStorage
+	cfg     *config.Public
+}
+
+func NewBookmark(storage BookmarkStorage, cfg *config.Public) BookmarkService {
+	return &Bookmark{
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+// Add bookmarks a message. The board comes from the request body rather than
+// the URL, so the board access middleware does not run and a board the user
+// cannot read is reported as missing here.
+func (b *Bookmark) Add(ctx context.Context, user *domain.User, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	visible, err := b.visibleBoards(ctx, user)
+	if err != nil {
+		return err
+	}
+	for _, name := range visible {
+		if name == board {
+			return b.storage.AddBookmark(ctx, user.Id, board, threadId, msgId)
+		}
+	}
+	return &errors.ErrorWithStatusCode{Message: "Board not found", StatusCode: http.StatusNotFound}
+}
+
+func (b *Bookmark) Remove(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	return b.storage.DeleteBookmark(ctx, userId, board, threadId, msgId)
+}
+
+// List returns one page of the user's bookmarks, newest first, and their total
+// number. Bookmarks on boards the user can no longer read are left out.
+func (b *Bookmark) List(ctx context.Context, user *domain.User, page int) ([]domain.Bookmark, int, error) {
+	visible, err := b.visibleBoards(ctx, user)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page = max(1, page)
+	limit := b.cfg.UserPostsPageLimit
+	offset := (page - 1) * limit
+	return b.storage.GetBookmarks(ctx, user.Id, visible, limit, offset)
+}
+
+func (b *Bookmark) visibleBoards(ctx context.Context, user *domain.User) ([]domain.BoardShortName, error) {
+	boards, err := b.storage.GetBoards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var visible []domain.BoardShortName
+	for _, board := range boards {
+		if canView(user, board) {
+			visible = append(visible, board.ShortName)
+		}
+	}
+	return visible, nil
+}
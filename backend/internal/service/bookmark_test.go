@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockBookmarkStorage struct {
+	getBoardsFunc      func() ([]domain.BoardMetadata, error)
+	addBookmarkFunc    func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	deleteBookmarkFunc func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	getBookmarksFunc   func(userId domain.UserId, boards []domain.BoardShortName, limit, offset int) ([]domain.Bookmark, int, error)
+}
+
+func (m *MockBookmarkStorage) GetBoards(ctx context.Context) ([]domain.BoardMetadata, error) {
+	if m.getBoardsFunc != nil {
+		return m.getBoardsFunc()
+	}
+	return []domain.BoardMetadata{}, nil
+}
+
+func (m *MockBookmarkStorage) AddBookmark(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	if m.addBookmarkFunc != nil {
+		return m.addBookmarkFunc(userId, board, threadId, msgId)
+	}
+	return nil
+}
+
+func (m *MockBookmarkStorage) DeleteBookmark(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	if m.deleteBookmarkFunc != nil {
+		return m.deleteBookmarkFunc(userId, board, threadId, msgId)
+	}
+	return nil
+}
+
+func (m *MockBookmarkStorage) GetBookmarks(ctx context.Context, userId domain.UserId, boards []domain.BoardShortName, limit, offset int) ([]domain.Bookmark, int, error) {
+	if m.getBookmarksFunc != nil {
+		return m.getBookmarksFunc(userId, boards, limit, offset)
+	}
+	return []domain.Bookmark{}, 0, nil
+}
+
+// --- Tests ---
+
+var bookmarkTestBoards = []domain.BoardMetadata{
+	{ShortName: "b"},
+	{ShortName: "corp", AllowedEmailDomains: []string{"corp.com"}},
+	{ShortName: "other", AllowedEmailDomains: []string{"other.com"}},
+}
+
+func TestBookmarkAdd(t *testing.T) {
+	user := &domain.User{Id: 7, EmailDomain: "corp.com"}
+
+	t.Run("visible board", func(t *testing.T) {
+		called := false
+		storage := &MockBookmarkStorage{
+			getBoardsFunc: func() ([]domain.BoardMetadata, error) { return bookmarkTestBoards, nil },
+			addBookmarkFunc: func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+				called = true
+				assert.Equal(t, user.Id, userId)
+				assert.Equal(t, domain.BoardShortName("corp"), board)
+				assert.Equal(t, domain.ThreadId(3), threadId)
+				assert.Equal(t, domain.MsgId(5), msgId)
+				return nil
+			},
+		}
+		svc := NewBookmark(storage, &config.Public{})
+
+		require.NoError(t, svc.Add(context.Background(), user, "corp", 3, 5))
+		assert.True(t, called)
+	})
+
+	t.Run("hidden or unknown board", func(t *testing.T) {
+		storage := &MockBookmarkStorage{
+			getBoardsFunc: func() ([]domain.BoardMetadata, error) { return bookmarkTestBoards, nil },
+			addBookmarkFunc: func(domain.UserId, domain.BoardShortName, domain.ThreadId, domain.MsgId) error {
+				t.Fatal("AddBookmark must not be called")
+				return nil
+			},
+		}
+		svc := NewBookmark(storage, &config.Public{})
+
+		for _, board := range []domain.BoardShortName{"other", "missing"} {
+			err := svc.Add(context.Background(), user, board, 3, 5)
+			var e *errors.ErrorWithStatusCode
+			require.ErrorAs(t, err, &e)
+			assert.Equal(t, http.StatusNotFound, e.StatusCode)
+		}
+	})
+}
+
+func TestBookmarkList(t *testing.T) {
+	user := &domain.User{Id: 7, EmailDomain: "corp.com"}
+	storage := &MockBookmarkStorage{
+		getBoardsFunc: func() ([]domain.BoardMetadata, error) { return bookmarkTestBoards, nil },
+		getBookmarksFunc: func(userId domain.UserId, boards []domain.BoardShortName, limit, offset int) ([]domain.Bookmark, int, error) {
+			assert.Equal(t, user.Id, userId)
+			assert.Equal(t, []domain.BoardShortName{"b", "corp"}, boards)
+			assert.Equal(t, 10, limit)
+			assert.Equal(t, 10, offset)
+			return []domain.Bookmark{{Message: domain.LatestPost{Board: "b", Id: 1}}}, 11, nil
+		},
+	}
+	svc := NewBookmark(storage, &config.Public{UserPostsPageLimit: 10})
+
+	bookmarks, total, err := svc.List(context.Background(), user, 2)
+	require.NoError(t, err)
+	assert.Len(t, bookmarks, 1)
+	assert.Equal(t, 11, total)
+
+	t.Run("page below one is the first page", func(t *testing.T) {
+		storage.getBookmarksFunc = func(_ domain.UserId, _ []domain.BoardShortName, _, offset int) ([]domain.Bookmark, int, error) {
+			assert.Equal(t, 0, offset)
+			return nil, 0, nil
+		}
+		_, _, err := svc.List(context.Background(), user, 0)
+		require.NoError(t, err)
+	})
+}
+
+func TestBookmarkRemove(t *testing.T) {
+	called := false
+	storage := &MockBookmarkStorage{
+		deleteBookmarkFunc: func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+			called = true
+			assert.Equal(t, domain.UserId(7), userId)
+			assert.Equal(t, domain.MsgId(5), msgId)
+			return nil
+		},
+	}
+	svc := NewBookmark(storage, &config.Public{})
+
+	require.NoError(t, svc.Remove(context.Background(), 7, "b", 3, 5))
+	assert.True(t, called)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// cleanupRowsDeleted counts the rows removed by the background cleanups, by
+// table. The media garbage collector reports its expired uploads here too.
+var cleanupRowsDeleted = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cleanup_rows_deleted_total",
+		Help: "Total number of expired rows removed by background cleanups",
+	},
+	[]string{"table"},
+)
+
+// CleanupStorage defines the storage operations of the expired rows cleanup.
+type CleanupStorage interface {
+	DeleteExpiredConfirmationData(ctx context.Context, before time.Time) (int64, error)
+	DeleteExpiredBans(ctx context.Context, before time.Time) (int64, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+	DeleteExpiredFailedPosts(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Cleanup removes rows that no longer have any effect: confirmation codes
+// past their expiry, temporary bans that ended, idempotency keys older than
+// idempotency_key_ttl and failed post captures older than their retention.
+// Nothing else deletes them.
+type Cleanup struct {
+	storage CleanupStorage
+	cfg     *config.Public
+	now     func() time.Time
+}
+
+// NewCleanup creates a new Cleanup job.
+func NewCleanup(storage CleanupStorage, cfg *config.Public) *Cleanup {
+	return &Cleanup{
+		storage: storage,
+		cfg:     cfg,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// StartBackgroundCleanup cleans up right away and then every interval until
+// ctx is cancelled.
+func (c *Cleanup) StartBackgroundCleanup(ctx context.Context, interval time.Duration) {
+	logger.Log.Info("started expired rows cleanup",
+		"component", "cleanup",
+		"interval", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := c.Run(ctx); err != nil {
+				logger.Log.Error("expired rows cleanup failed", "component", "cleanup", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				logger.Log.Info("expired rows cleanup shutting down gracefully", "component", "cleanup")
+				return
+			}
+		}
+	}()
+}
+
+// Run removes the expired rows once and returns how many were removed per
+// table. A failing table does not stop the others; the first error is
+// returned after all were tried.
+func (c *Cleanup) Run(ctx context.Context) (map[string]int64, error) {
+	now := c.now()
+	steps := []struct {
+		table  string
+		before time.Time
+		delete func(context.Context, time.Time) (int64, error)
+	}{
+		{"confirmation_data", now, c.storage.DeleteExpiredConfirmationData},
+		{"user_blacklist", now, c.storage.DeleteExpiredBans},
+		{"idempotency_keys", now.Add(-c.cfg.IdempotencyKeyTTL), c.storage.DeleteExpiredIdempotencyKeys},
+		{"failed_posts", now.Add(-c.cfg.FailedPostCapture.Retention), c.storage.DeleteExpiredFailedPosts},
+	}
+
+	deleted := make(map[string]int64, len(steps))
+	var firstErr error
+	for _, step := range steps {
+		count, err := step.delete(ctx, step.before)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("cleanup of %s: %w", step.table, err)
+			}
+			continue
+		}
+		deleted[step.table] = count
+		cleanupRowsDeleted.WithLabelValues(step.table).Add(float64(count))
+	}
+
+	logger.Log.Info("expired rows cleanup completed",
+		"component", "cleanup",
+		"confirmation_data", deleted["confirmation_data"],
+		"bans", deleted["user_blacklist"],
+		"idempotency_keys", deleted["idempotency_keys"])
+	return deleted, firstErr
+}
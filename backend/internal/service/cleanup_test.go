@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mock for CleanupStorage ---
+
+type MockCleanupStorage struct {
+	DeleteExpiredConfirmationDataFunc func(before time.Time) (int64, error)
+	DeleteExpiredBansFunc             func(before time.Time) (int64, error)
+	DeleteExpiredIdempotencyKeysFunc  func(before time.Time) (int64, error)
+	DeleteExpiredFailedPostsFunc      func(before time.Time) (int64, error)
+}
+
+func (m *MockCleanupStorage) DeleteExpiredConfirmationData(ctx context.Context, before time.Time) (int64, error) {
+	if m.DeleteExpiredConfirmationDataFunc != nil {
+		return m.DeleteExpiredConfirmationDataFunc(before)
+	}
+	return 0, nil
+}
+
+func (m *MockCleanupStorage) DeleteExpiredBans(ctx context.Context, before time.Time) (int64, error) {
+	if m.DeleteExpiredBansFunc != nil {
+		return m.DeleteExpiredBansFunc(before)
+	}
+	return 0, nil
+}
+
+func (m *MockCleanupStorage) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	if m.DeleteExpiredIdempotencyKeysFunc != nil {
+		return m.DeleteExpiredIdempotencyKeysFunc(before)
+	}
+	return 0, nil
+}
+
+func (m *MockCleanupStorage) DeleteExpiredFailedPosts(ctx context.Context, before time.Time) (int64, error) {
+	if m.DeleteExpiredFailedPostsFunc != nil {
+		return m.DeleteExpiredFailedPostsFunc(before)
+	}
+	return 0, nil
+}
+
+// --- Tests ---
+
+func TestCleanupRun(t *testing.T) {
+	now := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	cfg := &config.Public{IdempotencyKeyTTL: 24 * time.Hour, FailedPostCapture: config.FailedPostCaptureConfig{Retention: 7 * 24 * time.Hour}}
+
+	t.Run("removes expired rows and counts them", func(t *testing.T) {
+		storage := &MockCleanupStorage{
+			DeleteExpiredConfirmationDataFunc: func(before time.Time) (int64, error) {
+				assert.Equal(t, now, before)
+				return 3, nil
+			},
+			DeleteExpiredBansFunc: func(before time.Time) (int64, error) {
+				assert.Equal(t, now, before)
+				return 2, nil
+			},
+			DeleteExpiredIdempotencyKeysFunc: func(before time.Time) (int64, error) {
+				assert.Equal(t, now.Add(-24*time.Hour), before, "keys are kept for their TTL")
+				return 4, nil
+			},
+			DeleteExpiredFailedPostsFunc: func(before time.Time) (int64, error) {
+				assert.Equal(t, now.Add(-7*24*time.Hour), before, "captures are kept for their retention")
+				return 5, nil
+			},
+		}
+		c := NewCleanup(storage, cfg)
+		c.now = func() time.Time { return now }
+		bansBefore := testutil.ToFloat64(cleanupRowsDeleted.WithLabelValues("user_blacklist"))
+
+		deleted, err := c.Run(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"confirmation_data": 3, "user_blacklist": 2, "idempotency_keys": 4, "failed_posts": 5}, deleted)
+		assert.Equal(t, bansBefore+2, testutil.ToFloat64(cleanupRowsDeleted.WithLabelValues("user_blacklist")))
+	})
+
+	t.Run("a failing table does not stop the others", func(t *testing.T) {
+		storage := &MockCleanupStorage{
+			DeleteExpiredConfirmationDataFunc: func(before time.Time) (int64, error) {
+				return 0, errors.New("db down")
+			},
+			DeleteExpiredBansFunc: func(before time.Time) (int64, error) {
+				return 1, nil
+			},
+		}
+		c := NewCleanup(storage, cfg)
+
+		deleted, err := c.Run(context.Background())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "confirmation_data")
+		assert.Equal(t, map[string]int64{"user_blacklist": 1, "idempotency_keys": 0, "failed_posts": 0}, deleted)
+	})
+}
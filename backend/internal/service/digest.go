@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+	sharedutils "github.com/itchan-dev/itchan/shared/utils"
+)
+
+// digestRepliesLimit caps the replies listed in one digest; the rest are
+// behind the link to the notification center.
+const digestRepliesLimit = 20
+
+const unsubscribeTokenLength = 32
+
+// DigestService manages thread watches, read positions and email digest
+// preferences. The digests themselves are sent by Digest.StartBackgroundSending.
+type DigestService interface {
+	Watch(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error
+	Unwatch(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error
+	Watched(ctx context.Context, userId domain.UserId) ([]domain.WatchedThread, error)
+	MarkRead(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, lastReadId domain.MsgId) error
+	Frequency(ctx context.Context, userId domain.UserId) (domain.DigestFrequency, error)
+	SetFrequency(ctx context.Context, userId domain.UserId, frequency domain.DigestFrequency) error
+	Unsubscribe(ctx context.Context, token string) error
+}
+
+// DigestStorage defines storage interface for thread watches and email digests
+type DigestStorage interface {
+	WatchThread(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error
+	UnwatchThread(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error
+	GetWatchedThreads(ctx context.Context, userId domain.UserId) ([]domain.WatchedThread, error)
+	SetReadPosition(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	GetDigestFrequency(ctx context.Context, userId domain.UserId) (domain.DigestFrequency, error)
+	SetDigestFrequency(ctx context.Context, userId domain.UserId, frequency domain.DigestFrequency, unsubscribeToken string, since time.Time) error
+	DeleteDigestSubscription(ctx context.Context, userId domain.UserId) error
+	DeleteDigestSubscriptionByToken(ctx context.Context, token string) error
+	GetDueDigestSubscriptions(ctx context.Context, dailyBefore, weeklyBefore time.Time) ([]domain.DigestSubscription, error)
+	GetDigest(ctx context.Context, userId domain.UserId, since time.Time, repliesLimit int) (domain.Digest, error)
+	MarkDigestSent(ctx context.Context, userId domain.UserId, sentAt time.Time) error
+}
+
+// EmailDecrypter recovers the address of a digest recipient.
+type EmailDecrypter interface {
+	Decrypt(ciphertext []byte) (string, error)
+}
+
+type Digest struct {
+	storage     DigestStorage
+	email       Email
+	emailCrypto EmailDecrypter
+	cfg         *config.Public
+}
+
+func NewDigest(storage DigestStorage, email Email, emailCrypto EmailDecrypter, cfg *config.Public) *Digest {
+	return &Digest{
+		storage:     storage,
+		email:       email,
+		emailCrypto: emailCrypto,
+		cfg:         cfg,
+	}
+}
+
+func (d *Digest) Watch(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error {
+	return d.storage.WatchThread(ctx, userId, board, threadId)
+}
+
+func (d *Digest) Unwatch(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error {
+	return d.storage.UnwatchThread(ctx, userId, board, threadId)
+}
+
+// Watched lists the user's watched threads with their unread message counts.
+func (d *Digest) Watched(ctx context.Context, userId domain.UserId) ([]domain.WatchedThread, error) {
+	return d.storage.GetWatchedThreads(ctx, userId)
+}
+
+// MarkRead moves the user's read position in the thread forward to
+// lastReadId. Thread pages do this on their own; this is for posts loaded
+// without a page view, such as those the thread auto-refresh appends.
+func (d *Digest) MarkRead(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, lastReadId domain.MsgId) error {
+	if lastReadId < 1 {
+		return &errors.ErrorWithStatusCode{Message: "last_read_id must be a positive message id", StatusCode: http.StatusBadRequest}
+	}
+	return d.storage.SetReadPosition(ctx, userId, board, threadId, lastReadId)
+}
+
+func (d *Digest) Frequency(ctx context.Context, userId domain.UserId) (domain.DigestFrequency, error) {
+	return d.storage.GetDigestFrequency(ctx, userId)
+}
+
+// SetFrequency subscribes the user to digests, changes the frequency of an
+// existing subscription, or unsubscribes them with DigestOff. The first digest
+// covers the activity since the user subscribed.
+func (d *Digest) SetFrequency(ctx context.Context, userId domain.UserId, frequency domain.DigestFrequency) error {
+	if frequency == domain.DigestOff {
+		return d.storage.DeleteDigestSubscription(ctx, userId)
+	}
+	if frequency.Period() == 0 {
+		return &errors.ErrorWithStatusCode{Message: fmt.Sprintf("Unknown digest frequency %q", frequency), StatusCode: http.StatusBadRequest}
+	}
+	token := sharedutils.GenerateRandomString(unsubscribeTokenLength, "abcdefghijklmnopqrstuvwxyz0123456789")
+	return d.storage.SetDigestFrequency(ctx, userId, frequency, token, time.Now())
+}
+
+// Unsubscribe cancels the subscription the token was issued for. It is the
+// target of the link in every digest, so it needs no login.
+func (d *Digest) Unsubscribe(ctx context.Context, token string) error {
+	if token == "" {
+		return &errors.ErrorWithStatusCode{Message: "Unsubscribe token is required", StatusCode: http.StatusBadRequest}
+	}
+	return d.storage.DeleteDigestSubscriptionByToken(ctx, token)
+}
+
+// StartBackgroundSending starts a background goroutine that sends the due
+// digests every interval. It follows the same pattern as
+// MediaGarbageCollector.StartBackgroundCleanup.
+func (d *Digest) StartBackgroundSending(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	logger.Log.Info("started email digest sender", "component", "digest", "interval", interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sent, err := d.SendDue(ctx)
+				if err != nil {
+					logger.Log.Error("email digest run failed", "component", "digest", "error", err)
+				} else if sent > 0 {
+					logger.Log.Info("email digests sent", "component", "digest", "sent", sent)
+				}
+			case <-ctx.Done():
+				logger.Log.Info("email digest sender shutting down gracefully", "component", "digest")
+				return
+			}
+		}
+	}()
+}
+
+// SendDue sends a digest to every subscriber whose period has passed since
+// the last one and returns the number of emails sent. Subscribers without new
+// activity get no email, but their period starts over all the same. A failed
+// delivery is retried on the next run.
+func (d *Digest) SendDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	subs, err := d.storage.GetDueDigestSubscriptions(ctx, now.Add(-domain.DigestDaily.Period()), now.Add(-domain.DigestWeekly.Period()))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, sub := range subs {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		ok, err := d.send(ctx, sub, now)
+		if err != nil {
+			logger.Log.Error("failed to send email digest", "component", "digest", "user_id", sub.UserId, "error", err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+		if err := d.storage.MarkDigestSent(ctx, sub.UserId, now); err != nil {
+			logger.Log.Error("failed to record email digest", "component", "digest", "user_id", sub.UserId, "error", err)
+		}
+	}
+	return sent, nil
+}
+
+// send emails the activity since the subscriber's last digest, if there is any.
+func (d *Digest) send(ctx context.Context, sub domain.DigestSubscription, now time.Time) (bool, error) {
+	digest, err := d.storage.GetDigest(ctx, sub.UserId, sub.LastSentAt, digestRepliesLimit)
+	if err != nil {
+		return false, err
+	}
+	if digest.Empty() {
+		return false, nil
+	}
+
+	address, err := d.emailCrypto.Decrypt(sub.EmailEncrypted)
+	if err != nil {
+		return false, fmt.Errorf("failed to decrypt email: %w", err)
+	}
+	subject := "Новое в отслеживаемых тредах (Itchan)"
+	if err := d.email.Send(address, subject, d.formatDigest(sub, digest)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// formatDigest renders the plain-text body of a digest email.
+func (d *Digest) formatDigest(sub domain.DigestSubscription, digest domain.Digest) string {
+	site := strings.TrimRight(d.cfg.SiteURL, "/")
+	var b strings.Builder
+	b.WriteString("Здравствуйте.\n")
+
+	if len(digest.Threads) > 0 {
+		b.WriteString("\nНовые ответы в отслеживаемых тредах:\n\n")
+		for _, t := range digest.Threads {
+			fmt.Fprintf(&b, "/%s/ %s: %d\n%s/%s/%d\n\n", t.Board, t.Title, t.NewReplies, site, t.Board, t.ThreadId)
+		}
+	}
+
+	if len(digest.Replies) > 0 {
+		b.WriteString("\nОтветы на ваши сообщения:\n\n")
+		for _, p := range digest.Replies {
+			link := fmt.Sprintf("%s/%s/%d", site, p.Board, p.ThreadId)
+			if p.Page > 1 {
+				link += fmt.Sprintf("?page=%d", p.Page)
+			}
+			fmt.Fprintf(&b, "/%s/ %s\n%s#p%d\n\n", p.Board, p.ThreadTitle, link, p.Id)
+		}
+		fmt.Fprintf(&b, "Все уведомления: %s/notifications\n", site)
+	}
+
+	fmt.Fprintf(&b, `
+---
+Вы получаете это письмо, потому что подписались на дайджест Itchan.
+Отписаться: %s/digest/unsubscribe?token=%s`, site, sub.UnsubscribeToken)
+	return b.String()
+}
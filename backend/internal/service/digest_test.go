@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockDigestStorage struct {
+	setDigestFrequencyFunc              func(userId domain.UserId, frequency domain.DigestFrequency, token string, since time.Time) error
+	deleteDigestSubscriptionFunc        func(userId domain.UserId) error
+	deleteDigestSubscriptionByTokenFunc func(token string) error
+	getDueDigestSubscriptionsFunc       func(dailyBefore, weeklyBefore time.Time) ([]domain.DigestSubscription, error)
+	getDigestFunc                       func(userId domain.UserId, since time.Time, repliesLimit int) (domain.Digest, error)
+	markDigestSentFunc                  func(userId domain.UserId, sentAt time.Time) error
+	setReadPositionFunc                 func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+}
+
+func (m *MockDigestStorage) WatchThread(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error {
+	return nil
+}
+
+func (m *MockDigestStorage) UnwatchThread(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error {
+	return nil
+}
+
+func (m *MockDigestStorage) GetWatchedThreads(ctx context.Context, userId domain.UserId) ([]domain.WatchedThread, error) {
+	return nil, nil
+}
+
+func (m *MockDigestStorage) SetReadPosition(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	if m.setReadPositionFunc != nil {
+		return m.setReadPositionFunc(userId, board, threadId, msgId)
+	}
+	return nil
+}
+
+func (m *MockDigestStorage) GetDigestFrequency(ctx context.Context, userId domain.UserId) (domain.DigestFrequency, error) {
+	return domain.DigestOff, nil
+}
+
+func (m *MockDigestStorage) SetDigestFrequency(ctx context.Context, userId domain.UserId, frequency domain.DigestFrequency, token string, since time.Time) error {
+	if m.setDigestFrequencyFunc != nil {
+		return m.setDigestFrequencyFunc(userId, frequency, token, since)
+	}
+	return nil
+}
+
+func (m *MockDigestStorage) DeleteDigestSubscription(ctx context.Context, userId domain.UserId) error {
+	if m.deleteDigestSubscriptionFunc != nil {
+		return m.deleteDigestSubscriptionFunc(userId)
+	}
+	return nil
+}
+
+func (m *MockDigestStorage) DeleteDigestSubscriptionByToken(ctx context.Context, token string) error {
+	if m.deleteDigestSubscriptionByTokenFunc != nil {
+		return m.deleteDigestSubscriptionByTokenFunc(token)
+	}
+	return nil
+}
+
+func (m *MockDigestStorage) GetDueDigestSubscriptions(ctx context.Context, dailyBefore, weeklyBefore time.Time) ([]domain.DigestSubscription, error) {
+	if m.getDueDigestSubscriptionsFunc != nil {
+		return m.getDueDigestSubscriptionsFunc(dailyBefore, weeklyBefore)
+	}
+	return nil, nil
+}
+
+func (m *MockDigestStorage) GetDigest(ctx context.Context, userId domain.UserId, since time.Time, repliesLimit int) (domain.Digest, error) {
+	if m.getDigestFunc != nil {
+		return m.getDigestFunc(userId, since, repliesLimit)
+	}
+	return domain.Digest{}, nil
+}
+
+func (m *MockDigestStorage) MarkDigestSent(ctx context.Context, userId domain.UserId, sentAt time.Time) error {
+	if m.markDigestSentFunc != nil {
+		return m.markDigestSentFunc(userId, sentAt)
+	}
+	return nil
+}
+
+type MockEmailDecrypter struct{}
+
+func (m *MockEmailDecrypter) Decrypt(ciphertext []byte) (string, error) {
+	return string(ciphertext), nil
+}
+
+// --- Tests ---
+
+func TestDigestSetFrequency(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("subscribe with a fresh token", func(t *testing.T) {
+		var token string
+		storage := &MockDigestStorage{
+			setDigestFrequencyFunc: func(userId domain.UserId, frequency domain.DigestFrequency, tok string, since time.Time) error {
+				assert.Equal(t, domain.UserId(7), userId)
+				assert.Equal(t, domain.DigestWeekly, frequency)
+				assert.WithinDuration(t, time.Now(), since, time.Minute)
+				token = tok
+				return nil
+			},
+		}
+		d := NewDigest(storage, &MockEmail{}, &MockEmailDecrypter{}, &config.Public{})
+
+		require.NoError(t, d.SetFrequency(ctx, 7, domain.DigestWeekly))
+		assert.Len(t, token, unsubscribeTokenLength)
+	})
+
+	t.Run("off unsubscribes", func(t *testing.T) {
+		deleted := false
+		storage := &MockDigestStorage{
+			deleteDigestSubscriptionFunc: func(userId domain.UserId) error {
+				deleted = true
+				return nil
+			},
+		}
+		d := NewDigest(storage, &MockEmail{}, &MockEmailDecrypter{}, &config.Public{})
+
+		require.NoError(t, d.SetFrequency(ctx, 7, domain.DigestOff))
+		assert.True(t, deleted)
+	})
+
+	t.Run("unknown frequency", func(t *testing.T) {
+		d := NewDigest(&MockDigestStorage{}, &MockEmail{}, &MockEmailDecrypter{}, &config.Public{})
+		requireStatus(t, d.SetFrequency(ctx, 7, "hourly"), http.StatusBadRequest)
+	})
+}
+
+func TestDigestSendDue(t *testing.T) {
+	ctx := context.Background()
+	lastSent := time.Now().Add(-25 * time.Hour)
+	subs := []domain.DigestSubscription{
+		{UserId: 1, EmailEncrypted: []byte("one@example.com"), Frequency: domain.DigestDaily, UnsubscribeToken: "tok1", LastSentAt: lastSent},
+		{UserId: 2, EmailEncrypted: []byte("two@example.com"), Frequency: domain.DigestDaily, UnsubscribeToken: "tok2", LastSentAt: lastSent},
+		{UserId: 3, EmailEncrypted: []byte("three@example.com"), Frequency: domain.DigestWeekly, UnsubscribeToken: "tok3", LastSentAt: lastSent},
+	}
+	activity := map[domain.UserId]domain.Digest{
+		1: {
+			Threads: []domain.WatchedThreadActivity{{Board: "b", ThreadId: 4, Title: "Watched", NewReplies: 3}},
+			Replies: []domain.LatestPost{{Board: "b", ThreadId: 5, ThreadTitle: "Mine", Id: 6, Page: 2}},
+		},
+		3: {Replies: []domain.LatestPost{{Board: "b", ThreadId: 5, ThreadTitle: "Mine", Id: 7, Page: 1}}},
+	}
+
+	marked := map[domain.UserId]bool{}
+	storage := &MockDigestStorage{
+		getDueDigestSubscriptionsFunc: func(dailyBefore, weeklyBefore time.Time) ([]domain.DigestSubscription, error) {
+			assert.WithinDuration(t, time.Now().Add(-24*time.Hour), dailyBefore, time.Minute)
+			assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), weeklyBefore, time.Minute)
+			return subs, nil
+		},
+		getDigestFunc: func(userId domain.UserId, since time.Time, repliesLimit int) (domain.Digest, error) {
+			assert.Equal(t, lastSent, since)
+			assert.Equal(t, digestRepliesLimit, repliesLimit)
+			return activity[userId], nil
+		},
+		markDigestSentFunc: func(userId domain.UserId, sentAt time.Time) error {
+			marked[userId] = true
+			return nil
+		},
+	}
+	bodies := map[string]string{}
+	email := &MockEmail{
+		SendFunc: func(recipient, subject, body string) error {
+			if recipient == "three@example.com" {
+				return errors.New("smtp down")
+			}
+			bodies[recipient] = body
+			return nil
+		},
+	}
+	d := NewDigest(storage, email, &MockEmailDecrypter{}, &config.Public{SiteURL: "https://itchan.example/"})
+
+	sent, err := d.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	require.Contains(t, bodies, "one@example.com")
+	body := bodies["one@example.com"]
+	assert.Contains(t, body, "/b/ Watched: 3\nhttps://itchan.example/b/4\n")
+	assert.Contains(t, body, "https://itchan.example/b/5?page=2#p6")
+	assert.Contains(t, body, "https://itchan.example/digest/unsubscribe?token=tok1")
+	assert.NotContains(t, bodies, "two@example.com", "nothing new, no email")
+
+	assert.True(t, marked[1])
+	assert.True(t, marked[2], "the period starts over even without an email")
+	assert.False(t, marked[3], "failed deliveries are retried on the next run")
+}
+
+func TestDigestUnsubscribe(t *testing.T) {
+	called := false
+	storage := &MockDigestStorage{
+		deleteDigestSubscriptionByTokenFunc: func(token string) error {
+			called = true
+			assert.Equal(t, "tok", token)
+			return nil
+		},
+	}
+	d := NewDigest(storage, &MockEmail{}, &MockEmailDecrypter{}, &config.Public{})
+
+	require.NoError(t, d.Unsubscribe(context.Background(), "tok"))
+	assert.True(t, called)
+	requireStatus(t, d.Unsubscribe(context.Background(), ""), http.StatusBadRequest)
+}
+
+func TestDigestMarkRead(t *testing.T) {
+	var marked domain.MsgId
+	storage := &MockDigestStorage{
+		setReadPositionFunc: func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+			assert.Equal(t, domain.UserId(5), userId)
+			assert.Equal(t, domain.ThreadId(7), threadId)
+			marked = msgId
+			return nil
+		},
+	}
+	d := NewDigest(storage, &MockEmail{}, &MockEmailDecrypter{}, &config.Public{})
+
+	require.NoError(t, d.MarkRead(context.Background(), 5, "b", 7, 12))
+	assert.Equal(t, domain.MsgId(12), marked)
+	requireStatus(t, d.MarkRead(context.Background(), 5, "b", 7, 0), http.StatusBadRequest)
+}
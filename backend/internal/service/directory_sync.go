@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/blacklist"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// DirectorySyncService keeps the accounts of a domain-restricted instance in
+// line with the organization's directory, so people who leave lose access.
+type DirectorySyncService interface {
+	// Sync compares the managed accounts with the directory. A dry run only
+	// reports; force lifts the max_deactivations guard.
+	Sync(ctx context.Context, dryRun, force bool) (domain.DirectorySyncReport, error)
+}
+
+// DirectorySyncStorage defines storage interface for directory sync
+type DirectorySyncStorage interface {
+	GetDirectoryAccounts(ctx context.Context, domains []string) ([]domain.DirectoryAccount, error)
+	DeactivateUsers(ctx context.Context, userIds []domain.UserId) error
+	ReactivateUsers(ctx context.Context, userIds []domain.UserId) error
+	DeleteInvitesByUser(ctx context.Context, userId domain.UserId) error
+}
+
+// DirectorySource lists the emails of the directory's active members.
+type DirectorySource interface {
+	Emails(ctx context.Context) ([]string, error)
+}
+
+type DirectorySync struct {
+	storage        DirectorySyncStorage
+	source         DirectorySource // nil when directory sync is not configured
+	emailCrypto    EmailCrypto
+	blacklistCache *blacklist.Cache
+	cfg            *config.DirectorySync
+	domains        []string
+	mu             sync.Mutex
+}
+
+// NewDirectorySync manages the accounts of cfg.Private.DirectorySync.Domains,
+// or of the allowed registration domains when none are set.
+func NewDirectorySync(storage DirectorySyncStorage, source DirectorySource, emailCrypto EmailCrypto, blacklistCache *blacklist.Cache, cfg *config.Config) *DirectorySync {
+	domains := cfg.Private.DirectorySync.Domains
+	if len(domains) == 0 {
+		domains = cfg.Public.AllowedRegistrationDomains
+	}
+	managed := make([]string, 0, len(domains))
+	for _, d := range domains {
+		managed = append(managed, strings.ToLower(strings.TrimSpace(d)))
+	}
+	return &DirectorySync{
+		storage:        storage,
+		source:         source,
+		emailCrypto:    emailCrypto,
+		blacklistCache: blacklistCache,
+		cfg:            &cfg.Private.DirectorySync,
+		domains:        managed,
+	}
+}
+
+// StartBackgroundSync syncs every interval until ctx is done. It does nothing
+// when directory sync is not configured.
+func (s *DirectorySync) StartBackgroundSync(ctx context.Context, interval time.Duration) {
+	if s.source == nil {
+		return
+	}
+	logger.Log.Info("started directory sync",
+		"component", "directory_sync",
+		"interval", interval,
+		"domains", s.domains)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.Sync(ctx, false, false); err != nil {
+				logger.Log.Error("directory sync failed", "component", "directory_sync", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				logger.Log.Info("directory sync shutting down gracefully", "component", "directory_sync")
+				return
+			}
+		}
+	}()
+}
+
+// Sync deactivates the managed accounts missing from the directory and lifts
+// the deactivation of those listed again. Admins are never deactivated, so a
+// directory mistake cannot lock everyone out; they are reported instead. An
+// empty directory is treated as an error for the same reason.
+func (s *DirectorySync) Sync(ctx context.Context, dryRun, force bool) (domain.DirectorySyncReport, error) {
+	report := domain.DirectorySyncReport{
+		DryRun:        dryRun,
+		Deactivated:   []domain.UserId{},
+		Reactivated:   []domain.UserId{},
+		SkippedAdmins: []domain.UserId{},
+	}
+	if s.source == nil {
+		return report, &errors.ErrorWithStatusCode{Message: "Directory sync is not configured", StatusCode: http.StatusNotFound}
+	}
+	if len(s.domains) == 0 {
+		return report, &errors.ErrorWithStatusCode{
+			Message:    "Directory sync has no domains to manage: set directory_sync.domains or allowed_registration_domains",
+			StatusCode: http.StatusConflict,
+		}
+	}
+	if !s.mu.TryLock() {
+		return report, &errors.ErrorWithStatusCode{Message: "A directory sync is already running", StatusCode: http.StatusConflict}
+	}
+	defer s.mu.Unlock()
+
+	emails, err := s.source.Emails(ctx)
+	if err != nil {
+		return report, &errors.ErrorWithStatusCode{Message: fmt.Sprintf("Directory is unavailable: %v", err), StatusCode: http.StatusBadGateway}
+	}
+	listed := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		listed[string(s.emailCrypto.Hash(email))] = true
+	}
+	report.Listed = len(listed)
+	if report.Listed == 0 {
+		return report, &errors.ErrorWithStatusCode{Message: "Directory lists no members, nothing was deactivated", StatusCode: http.StatusBadGateway}
+	}
+
+	accounts, err := s.storage.GetDirectoryAccounts(ctx, s.domains)
+	if err != nil {
+		return report, err
+	}
+	report.Managed = len(accounts)
+	for _, account := range accounts {
+		inDirectory := listed[string(account.EmailHash)]
+		switch {
+		case inDirectory && account.Deactivated:
+			report.Reactivated = append(report.Reactivated, account.Id)
+		case !inDirectory && !account.Deactivated && account.Admin:
+			report.SkippedAdmins = append(report.SkippedAdmins, account.Id)
+		case !inDirectory && !account.Deactivated:
+			report.Deactivated = append(report.Deactivated, account.Id)
+		}
+	}
+
+	if len(report.Deactivated) > s.cfg.MaxDeactivations && !force {
+		return report, &errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Directory sync would deactivate %d accounts, more than max_deactivations (%d); check the directory or force the sync",
+				len(report.Deactivated), s.cfg.MaxDeactivations),
+			StatusCode: http.StatusConflict,
+		}
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	if len(report.Deactivated) > 0 {
+		if err := s.storage.DeactivateUsers(ctx, report.Deactivated); err != nil {
+			return report, err
+		}
+		for _, userId := range report.Deactivated {
+			if err := s.storage.DeleteInvitesByUser(ctx, userId); err != nil {
+				logger.Log.Warn("failed to delete deactivated user's invites", "user_id", userId, "error", err)
+			}
+		}
+	}
+	if len(report.Reactivated) > 0 {
+		if err := s.storage.ReactivateUsers(ctx, report.Reactivated); err != nil {
+			return report, err
+		}
+	}
+	if len(report.Deactivated)+len(report.Reactivated) > 0 {
+		if err := s.blacklistCache.Update(); err != nil {
+			logger.Log.Warn("directory sync applied but cache update failed", "error", err)
+		}
+	}
+
+	logger.Log.Info("directory sync finished",
+		"component", "directory_sync",
+		"listed", report.Listed,
+		"managed", report.Managed,
+		"deactivated", len(report.Deactivated),
+		"reactivated", len(report.Reactivated),
+		"skipped_admins", len(report.SkippedAdmins))
+	return report, nil
+}
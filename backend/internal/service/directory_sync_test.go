@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/blacklist"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockDirectorySyncStorage struct {
+	accounts       []domain.DirectoryAccount
+	gotDomains     []string
+	deactivated    []domain.UserId
+	reactivated    []domain.UserId
+	invitesDeleted []domain.UserId
+}
+
+func (m *MockDirectorySyncStorage) GetDirectoryAccounts(ctx context.Context, domains []string) ([]domain.DirectoryAccount, error) {
+	m.gotDomains = domains
+	return m.accounts, nil
+}
+
+func (m *MockDirectorySyncStorage) DeactivateUsers(ctx context.Context, userIds []domain.UserId) error {
+	m.deactivated = append(m.deactivated, userIds...)
+	return nil
+}
+
+func (m *MockDirectorySyncStorage) ReactivateUsers(ctx context.Context, userIds []domain.UserId) error {
+	m.reactivated = append(m.reactivated, userIds...)
+	return nil
+}
+
+func (m *MockDirectorySyncStorage) DeleteInvitesByUser(ctx context.Context, userId domain.UserId) error {
+	m.invitesDeleted = append(m.invitesDeleted, userId)
+	return nil
+}
+
+type MockDirectorySource struct {
+	emails []string
+	err    error
+}
+
+func (m *MockDirectorySource) Emails(ctx context.Context) ([]string, error) {
+	return m.emails, m.err
+}
+
+func setupDirectorySyncService(storage *MockDirectorySyncStorage, source DirectorySource, maxDeactivations int) (*DirectorySync, *int) {
+	cacheUpdates := 0
+	cache := blacklist.NewCache(&MockBlacklistCacheStorage{
+		GetRecentlyBlacklistedUsersFunc: func(since time.Time) ([]domain.UserId, error) {
+			cacheUpdates++
+			return nil, nil
+		},
+	}, time.Hour)
+	cfg := &config.Config{
+		Public: config.Public{AllowedRegistrationDomains: []string{"Corp.com"}},
+		Private: config.Private{DirectorySync: config.DirectorySync{
+			MaxDeactivations: maxDeactivations,
+		}},
+	}
+	return NewDirectorySync(storage, source, &MockEmailCrypto{}, cache, cfg), &cacheUpdates
+}
+
+func directoryAccount(id domain.UserId, email string, admin, deactivated bool) domain.DirectoryAccount {
+	return domain.DirectoryAccount{Id: id, EmailHash: []byte("hash_" + email), Admin: admin, Deactivated: deactivated}
+}
+
+// --- Tests ---
+
+func TestDirectorySync(t *testing.T) {
+	accounts := []domain.DirectoryAccount{
+		directoryAccount(1, "admin@corp.com", true, false),
+		directoryAccount(2, "stays@corp.com", false, false),
+		directoryAccount(3, "left@corp.com", false, false),
+		directoryAccount(4, "back@corp.com", false, true),
+		directoryAccount(5, "gone@corp.com", false, true),
+	}
+	source := &MockDirectorySource{emails: []string{"stays@corp.com", "back@corp.com", "new@corp.com"}}
+
+	t.Run("deactivates missing accounts and reactivates returning ones", func(t *testing.T) {
+		storage := &MockDirectorySyncStorage{accounts: accounts}
+		s, cacheUpdates := setupDirectorySyncService(storage, source, 10)
+
+		report, err := s.Sync(context.Background(), false, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"corp.com"}, storage.gotDomains, "falls back to the allowed registration domains")
+		assert.Equal(t, 3, report.Listed)
+		assert.Equal(t, 5, report.Managed)
+		assert.Equal(t, []domain.UserId{3}, report.Deactivated)
+		assert.Equal(t, []domain.UserId{4}, report.Reactivated)
+		assert.Equal(t, []domain.UserId{1}, report.SkippedAdmins)
+		assert.Equal(t, []domain.UserId{3}, storage.deactivated)
+		assert.Equal(t, []domain.UserId{3}, storage.invitesDeleted)
+		assert.Equal(t, []domain.UserId{4}, storage.reactivated)
+		assert.Equal(t, 1, *cacheUpdates)
+	})
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		storage := &MockDirectorySyncStorage{accounts: accounts}
+		s, cacheUpdates := setupDirectorySyncService(storage, source, 10)
+
+		report, err := s.Sync(context.Background(), true, false)
+
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, []domain.UserId{3}, report.Deactivated)
+		assert.Empty(t, storage.deactivated)
+		assert.Empty(t, storage.reactivated)
+		assert.Zero(t, *cacheUpdates)
+	})
+
+	t.Run("too many deactivations need force", func(t *testing.T) {
+		storage := &MockDirectorySyncStorage{accounts: accounts}
+		s, _ := setupDirectorySyncService(storage, &MockDirectorySource{emails: []string{"someone@corp.com"}}, 1)
+
+		_, err := s.Sync(context.Background(), false, false)
+		var e *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, http.StatusConflict, e.StatusCode)
+		assert.Empty(t, storage.deactivated)
+
+		report, err := s.Sync(context.Background(), false, true)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.UserId{2, 3}, report.Deactivated)
+		assert.Equal(t, []domain.UserId{2, 3}, storage.deactivated)
+	})
+
+	t.Run("empty or failing directory deactivates nobody", func(t *testing.T) {
+		for _, source := range []*MockDirectorySource{{}, {err: errors.New("connection refused")}} {
+			storage := &MockDirectorySyncStorage{accounts: accounts}
+			s, _ := setupDirectorySyncService(storage, source, 10)
+
+			_, err := s.Sync(context.Background(), false, true)
+			var e *internal_errors.ErrorWithStatusCode
+			require.True(t, errors.As(err, &e))
+			assert.Equal(t, http.StatusBadGateway, e.StatusCode)
+			assert.Empty(t, storage.deactivated)
+		}
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		s, _ := setupDirectorySyncService(&MockDirectorySyncStorage{}, nil, 10)
+
+		_, err := s.Sync(context.Background(), false, false)
+		var e *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, http.StatusNotFound, e.StatusCode)
+	})
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// DiskMonitorStorage defines the database query of the disk usage monitor.
+type DiskMonitorStorage interface {
+	GetDatabaseSize(ctx context.Context) (int64, error)
+}
+
+// DiskUsageReader measures the filesystem holding the media files.
+type DiskUsageReader interface {
+	DiskUsage() (used, total uint64, err error)
+}
+
+// AlertSender delivers an operational alert to the admins.
+type AlertSender interface {
+	SendAlert(ctx context.Context, text string) error
+}
+
+// DiskMonitor periodically measures media and database usage. Crossing the
+// alert threshold sends one alert until usage drops below it again; past the
+// freeze threshold new uploads are refused.
+type DiskMonitor struct {
+	storage DiskMonitorStorage
+	media   DiskUsageReader
+	alerts  AlertSender // nil when no webhook is configured, alerts are only logged
+	cfg     *config.DiskMonitorConfig
+	now     func() time.Time
+
+	mu      sync.RWMutex
+	usage   domain.DiskUsage
+	alerted bool
+}
+
+func NewDiskMonitor(storage DiskMonitorStorage, media DiskUsageReader, alerts AlertSender, cfg *config.DiskMonitorConfig) *DiskMonitor {
+	return &DiskMonitor{
+		storage: storage,
+		media:   media,
+		alerts:  alerts,
+		cfg:     cfg,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// StartBackgroundCheck measures usage right away and then every configured
+// interval until ctx is cancelled.
+func (m *DiskMonitor) StartBackgroundCheck(ctx context.Context) {
+	logger.Log.Info("started disk usage monitor",
+		"component", "disk_monitor",
+		"interval", m.cfg.Interval,
+		"alert_percent", m.cfg.AlertPercent,
+		"freeze_percent", m.cfg.FreezePercent)
+
+	go func() {
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			if err := m.Check(ctx); err != nil {
+				logger.Log.Error("disk usage check failed", "component", "disk_monitor", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				logger.Log.Info("stopping disk usage monitor", "component", "disk_monitor")
+				return
+			}
+		}
+	}()
+}
+
+// Check measures usage once, updates the upload freeze and sends alerts.
+func (m *DiskMonitor) Check(ctx context.Context) error {
+	used, total, err := m.media.DiskUsage()
+	if err != nil {
+		return err
+	}
+	dbSize, err := m.storage.GetDatabaseSize(ctx)
+	if err != nil {
+		return err
+	}
+
+	usage := domain.DiskUsage{
+		CheckedAt:       m.now(),
+		MediaUsedBytes:  used,
+		MediaTotalBytes: total,
+		MediaPercent:    percentOf(used, total),
+		DatabaseBytes:   dbSize,
+	}
+	if m.cfg.MaxDatabaseBytes > 0 {
+		usage.DatabasePercent = percentOf(uint64(dbSize), uint64(m.cfg.MaxDatabaseBytes))
+	}
+	usage.UploadsFrozen = usage.Percent() >= m.cfg.FreezePercent
+	overAlert := usage.Percent() >= m.cfg.AlertPercent
+
+	m.mu.Lock()
+	wasFrozen := m.usage.UploadsFrozen
+	sendAlert := overAlert && !m.alerted
+	m.alerted = overAlert
+	m.usage = usage
+	m.mu.Unlock()
+
+	switch {
+	case usage.UploadsFrozen && !wasFrozen:
+		m.alert(ctx, fmt.Sprintf("Uploads are frozen: disk usage is %.1f%% (media %.1f%%, database %.1f%%), the limit is %.0f%%",
+			usage.Percent(), usage.MediaPercent, usage.DatabasePercent, m.cfg.FreezePercent))
+	case sendAlert:
+		m.alert(ctx, fmt.Sprintf("Disk usage is %.1f%% (media %.1f%%, database %.1f%%), uploads freeze at %.0f%%",
+			usage.Percent(), usage.MediaPercent, usage.DatabasePercent, m.cfg.FreezePercent))
+	case wasFrozen && !usage.UploadsFrozen:
+		logger.Log.Info("uploads resumed", "component", "disk_monitor", "percent", usage.Percent())
+	}
+	return nil
+}
+
+// Usage returns the latest measurement.
+func (m *DiskMonitor) Usage() domain.DiskUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.usage
+}
+
+// UploadsFrozen reports whether new attachments are refused.
+func (m *DiskMonitor) UploadsFrozen() bool {
+	return m.Usage().UploadsFrozen
+}
+
+// checkUploads returns the error shown to users posting files during a freeze.
+func (m *DiskMonitor) checkUploads(files []*domain.PendingFile) error {
+	if len(files) == 0 || !m.UploadsFrozen() {
+		return nil
+	}
+	return &errors.ErrorWithStatusCode{
+		Message:    "File uploads are paused because the server is running out of disk space. You can still post without attachments.",
+		StatusCode: http.StatusInsufficientStorage,
+	}
+}
+
+func (m *DiskMonitor) alert(ctx context.Context, text string) {
+	logger.Log.Warn(text, "component", "disk_monitor")
+	if m.alerts == nil {
+		return
+	}
+	if err := m.alerts.SendAlert(ctx, text); err != nil {
+		logger.Log.Error("failed to send disk usage alert", "component", "disk_monitor", "error", err)
+	}
+}
+
+func percentOf(part, whole uint64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole) * 100
+}
+
+// UploadFreeze wraps MessageService to refuse attachments while the disk
+// monitor has frozen uploads. Text-only messages still go through.
+type UploadFreeze struct {
+	MessageService
+	monitor *DiskMonitor
+}
+
+func NewUploadFreeze(message MessageService, monitor *DiskMonitor) *UploadFreeze {
+	return &UploadFreeze{
+		MessageService: message,
+		monitor:        monitor,
+	}
+}
+
+func (u *UploadFreeze) Create(ctx context.Context, creationData domain.MessageCreationData) (domain.MsgId, error) {
+	if err := u.monitor.checkUploads(creationData.PendingFiles); err != nil {
+		return 0, err
+	}
+	return u.MessageService.Create(ctx, creationData)
+}
+
+// Upload refuses files uploaded ahead of a post the same way. Posts claiming
+// earlier uploads still go through, their files are already on disk.
+func (u *UploadFreeze) Upload(ctx context.Context, board domain.BoardShortName, author domain.User, file *domain.PendingFile) (domain.PendingUpload, error) {
+	if err := u.monitor.checkUploads([]*domain.PendingFile{file}); err != nil {
+		return domain.PendingUpload{}, err
+	}
+	return u.MessageService.Upload(ctx, board, author, file)
+}
+
+// ThreadUploadFreeze does the same for new threads, before the thread is
+// created for an OP message that would be refused.
+type ThreadUploadFreeze struct {
+	ThreadService
+	monitor *DiskMonitor
+}
+
+func NewThreadUploadFreeze(thread ThreadService, monitor *DiskMonitor) *ThreadUploadFreeze {
+	return &ThreadUploadFreeze{
+		ThreadService: thread,
+		monitor:       monitor,
+	}
+}
+
+func (t *ThreadUploadFreeze) Create(ctx context.Context, creationData domain.ThreadCreationData) (domain.ThreadId, error) {
+	if err := t.monitor.checkUploads(creationData.OpMessage.PendingFiles); err != nil {
+		return -1, err
+	}
+	return t.ThreadService.Create(ctx, creationData)
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockDiskMonitorStorage struct {
+	getDatabaseSizeFunc func() (int64, error)
+}
+
+func (m *MockDiskMonitorStorage) GetDatabaseSize(ctx context.Context) (int64, error) {
+	if m.getDatabaseSizeFunc != nil {
+		return m.getDatabaseSizeFunc()
+	}
+	return 0, nil
+}
+
+type MockDiskUsageReader struct {
+	used, total uint64
+	err         error
+}
+
+func (m *MockDiskUsageReader) DiskUsage() (uint64, uint64, error) {
+	return m.used, m.total, m.err
+}
+
+type MockAlertSender struct {
+	alerts []string
+}
+
+func (m *MockAlertSender) SendAlert(ctx context.Context, text string) error {
+	m.alerts = append(m.alerts, text)
+	return nil
+}
+
+func TestDiskMonitorCheck(t *testing.T) {
+	cfg := &config.DiskMonitorConfig{AlertPercent: 80, FreezePercent: 90}
+	ctx := context.Background()
+
+	t.Run("measures media and database usage", func(t *testing.T) {
+		storage := &MockDiskMonitorStorage{getDatabaseSizeFunc: func() (int64, error) { return 300, nil }}
+		withQuota := *cfg
+		withQuota.MaxDatabaseBytes = 1000
+		monitor := NewDiskMonitor(storage, &MockDiskUsageReader{used: 50, total: 200}, nil, &withQuota)
+		now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		monitor.now = func() time.Time { return now }
+
+		require.NoError(t, monitor.Check(ctx))
+		assert.Equal(t, domain.DiskUsage{
+			CheckedAt:       now,
+			MediaUsedBytes:  50,
+			MediaTotalBytes: 200,
+			MediaPercent:    25,
+			DatabaseBytes:   300,
+			DatabasePercent: 30,
+		}, monitor.Usage())
+		assert.False(t, monitor.UploadsFrozen())
+	})
+
+	t.Run("database usage without a quota", func(t *testing.T) {
+		storage := &MockDiskMonitorStorage{getDatabaseSizeFunc: func() (int64, error) { return 1 << 40, nil }}
+		monitor := NewDiskMonitor(storage, &MockDiskUsageReader{used: 1, total: 100}, nil, cfg)
+
+		require.NoError(t, monitor.Check(ctx))
+		assert.Zero(t, monitor.Usage().DatabasePercent)
+		assert.False(t, monitor.UploadsFrozen())
+	})
+
+	t.Run("alerts once per threshold crossing", func(t *testing.T) {
+		media := &MockDiskUsageReader{used: 85, total: 100}
+		alerts := &MockAlertSender{}
+		monitor := NewDiskMonitor(&MockDiskMonitorStorage{}, media, alerts, cfg)
+
+		require.NoError(t, monitor.Check(ctx))
+		require.NoError(t, monitor.Check(ctx))
+		require.Len(t, alerts.alerts, 1)
+		assert.Contains(t, alerts.alerts[0], "85.0%")
+
+		media.used = 50
+		require.NoError(t, monitor.Check(ctx))
+		media.used = 81
+		require.NoError(t, monitor.Check(ctx))
+		assert.Len(t, alerts.alerts, 2, "alert is re-armed after usage dropped")
+	})
+
+	t.Run("freezes and resumes uploads", func(t *testing.T) {
+		media := &MockDiskUsageReader{used: 95, total: 100}
+		alerts := &MockAlertSender{}
+		monitor := NewDiskMonitor(&MockDiskMonitorStorage{}, media, alerts, cfg)
+
+		require.NoError(t, monitor.Check(ctx))
+		assert.True(t, monitor.UploadsFrozen())
+		require.Len(t, alerts.alerts, 1)
+		assert.Contains(t, alerts.alerts[0], "Uploads are frozen")
+
+		media.used = 70
+		require.NoError(t, monitor.Check(ctx))
+		assert.False(t, monitor.UploadsFrozen())
+	})
+
+	t.Run("database quota freezes uploads", func(t *testing.T) {
+		storage := &MockDiskMonitorStorage{getDatabaseSizeFunc: func() (int64, error) { return 950, nil }}
+		withQuota := *cfg
+		withQuota.MaxDatabaseBytes = 1000
+		monitor := NewDiskMonitor(storage, &MockDiskUsageReader{used: 1, total: 100}, nil, &withQuota)
+
+		require.NoError(t, monitor.Check(ctx))
+		assert.True(t, monitor.UploadsFrozen())
+	})
+
+	t.Run("measurement error keeps the last usage", func(t *testing.T) {
+		media := &MockDiskUsageReader{used: 95, total: 100}
+		monitor := NewDiskMonitor(&MockDiskMonitorStorage{}, media, nil, cfg)
+		require.NoError(t, monitor.Check(ctx))
+
+		media.err = errors.New("statfs failed")
+		require.Error(t, monitor.Check(ctx))
+		assert.True(t, monitor.UploadsFrozen())
+	})
+}
+
+func TestUploadFreeze(t *testing.T) {
+	cfg := &config.DiskMonitorConfig{AlertPercent: 80, FreezePercent: 90}
+	monitor := NewDiskMonitor(&MockDiskMonitorStorage{}, &MockDiskUsageReader{used: 99, total: 100}, nil, cfg)
+	require.NoError(t, monitor.Check(context.Background()))
+	files := []*domain.PendingFile{{FileCommonMetadata: domain.FileCommonMetadata{MimeType: "image/png"}}}
+
+	t.Run("refuses messages with files", func(t *testing.T) {
+		message := NewUploadFreeze(&MockMessageService{
+			createFunc: func(domain.MessageCreationData) (domain.MsgId, error) {
+				t.Fatal("message with files created during a freeze")
+				return 0, nil
+			},
+		}, monitor)
+
+		_, err := message.Create(context.Background(), domain.MessageCreationData{PendingFiles: files})
+		requireStatus(t, err, http.StatusInsufficientStorage)
+	})
+
+	t.Run("passes text messages through", func(t *testing.T) {
+		message := NewUploadFreeze(&MockMessageService{}, monitor)
+
+		_, err := message.Create(context.Background(), domain.MessageCreationData{Text: "text"})
+		require.NoError(t, err)
+	})
+
+	t.Run("refuses uploads ahead of posts", func(t *testing.T) {
+		message := NewUploadFreeze(&MockMessageService{
+			uploadFunc: func(domain.BoardShortName, domain.User, *domain.PendingFile) (domain.PendingUpload, error) {
+				t.Fatal("file uploaded during a freeze")
+				return domain.PendingUpload{}, nil
+			},
+		}, monitor)
+
+		_, err := message.Upload(context.Background(), "b", domain.User{Id: 1}, files[0])
+		requireStatus(t, err, http.StatusInsufficientStorage)
+	})
+
+	t.Run("lets messages claim earlier uploads", func(t *testing.T) {
+		message := NewUploadFreeze(&MockMessageService{}, monitor)
+
+		_, err := message.Create(context.Background(), domain.MessageCreationData{UploadTokens: []domain.UploadToken{"token"}})
+		require.NoError(t, err)
+	})
+
+	t.Run("refuses threads before creating them", func(t *testing.T) {
+		storage := &MockThreadStorage{
+			createThreadFunc: func(domain.ThreadCreationData, *int) (domain.ThreadId, time.Time, error) {
+				t.Fatal("thread created during a freeze")
+				return 0, time.Time{}, nil
+			},
+		}
+		thread := NewThreadUploadFreeze(NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{}), monitor)
+
+		_, err := thread.Create(context.Background(), domain.ThreadCreationData{
+			Title:     "title",
+			OpMessage: domain.MessageCreationData{PendingFiles: files},
+		})
+		requireStatus(t, err, http.StatusInsufficientStorage)
+	})
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/itchan-dev/itchan/shared/crypto"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// EmailKeyRotationStorage defines storage interface for re-encrypting the
+// stored emails
+type EmailKeyRotationStorage interface {
+	GetUserEmails(ctx context.Context, afterId domain.UserId, limit int) ([]domain.User, error)
+	ReplaceEmailEncrypted(ctx context.Context, userId domain.UserId, oldCiphertext, newCiphertext []byte) (bool, error)
+}
+
+// EmailRotator re-encrypts emails from the previous keys of the key ring to
+// the current one
+type EmailRotator interface {
+	Rotate(ciphertext []byte) ([]byte, error)
+	DecryptCurrent(ciphertext []byte) (string, error)
+	Hash(email string) []byte
+}
+
+// EmailKeyRotation moves the stored emails to a new encryption key. The API
+// keeps decrypting with the previous keys meanwhile, so it runs online.
+type EmailKeyRotation struct {
+	storage EmailKeyRotationStorage
+	crypto  EmailRotator
+}
+
+func NewEmailKeyRotation(storage EmailKeyRotationStorage, crypto EmailRotator) *EmailKeyRotation {
+	return &EmailKeyRotation{storage: storage, crypto: crypto}
+}
+
+// Run re-encrypts the emails of the users after afterId, batchSize users at a
+// time, calling checkpoint with the last user of every finished batch so an
+// interrupted run can resume there. Every new ciphertext is decrypted with the
+// current key alone and checked against the stored email hash before it
+// replaces the old one. Emails already under the current key are skipped, so
+// running again is harmless.
+func (r *EmailKeyRotation) Run(ctx context.Context, afterId domain.UserId, batchSize int, checkpoint func(lastId domain.UserId) error) (domain.EmailKeyRotationReport, error) {
+	report := domain.EmailKeyRotationReport{LastId: afterId}
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		users, err := r.storage.GetUserEmails(ctx, report.LastId, batchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(users) == 0 {
+			return report, nil
+		}
+
+		for _, u := range users {
+			if err := r.rotate(ctx, u, &report); err != nil {
+				return report, err
+			}
+		}
+
+		report.LastId = users[len(users)-1].Id
+		if checkpoint != nil {
+			if err := checkpoint(report.LastId); err != nil {
+				return report, err
+			}
+		}
+		logger.Log.Info("email key rotation batch done", "last_id", report.LastId, "rotated", report.Rotated, "current", report.Current)
+	}
+}
+
+func (r *EmailKeyRotation) rotate(ctx context.Context, u domain.User, report *domain.EmailKeyRotationReport) error {
+	rotated, err := r.crypto.Rotate(u.EmailEncrypted)
+	if err != nil {
+		if stderrors.Is(err, crypto.ErrUnknownKey) {
+			logger.Log.Warn("email not readable with any key of the key ring", "user_id", u.Id)
+			report.Unreadable = append(report.Unreadable, u.Id)
+			return nil
+		}
+		return fmt.Errorf("failed to re-encrypt email of user %d: %w", u.Id, err)
+	}
+	if rotated == nil {
+		report.Current++
+		return nil
+	}
+
+	email, err := r.crypto.DecryptCurrent(rotated)
+	if err != nil || !bytes.Equal(r.crypto.Hash(email), u.EmailHash) {
+		logger.Log.Error("re-encrypted email failed verification", "user_id", u.Id, "error", err)
+		report.Failed = append(report.Failed, u.Id)
+		return nil
+	}
+
+	replaced, err := r.storage.ReplaceEmailEncrypted(ctx, u.Id, u.EmailEncrypted, rotated)
+	if err != nil {
+		return err
+	}
+	if !replaced {
+		logger.Log.Warn("encrypted email changed during rotation", "user_id", u.Id)
+		report.Failed = append(report.Failed, u.Id)
+		return nil
+	}
+	report.Rotated++
+	return nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/crypto"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockEmailKeyRotationStorage struct {
+	users   []domain.User
+	changed map[domain.UserId]bool // Rows changed behind the rotation's back
+}
+
+func (m *MockEmailKeyRotationStorage) GetUserEmails(ctx context.Context, afterId domain.UserId, limit int) ([]domain.User, error) {
+	batch := []domain.User{}
+	for _, u := range m.users {
+		if u.Id > afterId && len(batch) < limit {
+			batch = append(batch, u)
+		}
+	}
+	return batch, nil
+}
+
+func (m *MockEmailKeyRotationStorage) ReplaceEmailEncrypted(ctx context.Context, userId domain.UserId, oldCiphertext, newCiphertext []byte) (bool, error) {
+	for i, u := range m.users {
+		if u.Id == userId && !m.changed[userId] && bytes.Equal(u.EmailEncrypted, oldCiphertext) {
+			m.users[i].EmailEncrypted = newCiphertext
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func testEncryptionKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+// --- Tests ---
+
+func TestEmailKeyRotation(t *testing.T) {
+	oldCrypto, err := crypto.NewEmailCrypto(testEncryptionKey(1), "")
+	require.NoError(t, err)
+	ring, err := crypto.NewEmailCrypto(testEncryptionKey(2), "", testEncryptionKey(1))
+	require.NoError(t, err)
+
+	user := func(id domain.UserId, email string, c *crypto.EmailCrypto) domain.User {
+		encrypted, err := c.Encrypt(email)
+		require.NoError(t, err)
+		return domain.User{Id: id, EmailEncrypted: encrypted, EmailHash: c.Hash(email)}
+	}
+
+	t.Run("rotates old emails and skips current ones", func(t *testing.T) {
+		storage := &MockEmailKeyRotationStorage{users: []domain.User{
+			user(1, "a@example.com", oldCrypto),
+			user(2, "b@example.com", ring),
+			user(3, "c@example.com", oldCrypto),
+			{Id: 4, EmailEncrypted: []byte("seed@seed.invalid"), EmailHash: []byte("x")},
+		}}
+		var checkpoints []domain.UserId
+
+		report, err := NewEmailKeyRotation(storage, ring).Run(context.Background(), 0, 2, func(lastId domain.UserId) error {
+			checkpoints = append(checkpoints, lastId)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Rotated)
+		assert.Equal(t, 1, report.Current)
+		assert.Equal(t, []domain.UserId{4}, report.Unreadable)
+		assert.Empty(t, report.Failed)
+		assert.Equal(t, domain.UserId(4), report.LastId)
+		assert.Equal(t, []domain.UserId{2, 4}, checkpoints)
+
+		for i, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			got, err := ring.DecryptCurrent(storage.users[i].EmailEncrypted)
+			require.NoError(t, err)
+			assert.Equal(t, email, got)
+		}
+	})
+
+	t.Run("resumes after the checkpoint", func(t *testing.T) {
+		storage := &MockEmailKeyRotationStorage{users: []domain.User{
+			user(1, "a@example.com", oldCrypto),
+			user(2, "b@example.com", oldCrypto),
+		}}
+
+		report, err := NewEmailKeyRotation(storage, ring).Run(context.Background(), 1, 10, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Rotated)
+		_, err = ring.DecryptCurrent(storage.users[0].EmailEncrypted)
+		assert.Error(t, err, "users up to the checkpoint are left alone")
+	})
+
+	t.Run("hash mismatch and concurrent changes fail", func(t *testing.T) {
+		mismatched := user(1, "a@example.com", oldCrypto)
+		mismatched.EmailHash = oldCrypto.Hash("other@example.com")
+		storage := &MockEmailKeyRotationStorage{
+			users:   []domain.User{mismatched, user(2, "b@example.com", oldCrypto)},
+			changed: map[domain.UserId]bool{2: true},
+		}
+
+		report, err := NewEmailKeyRotation(storage, ring).Run(context.Background(), 0, 10, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.Rotated)
+		assert.Equal(t, []domain.UserId{1, 2}, report.Failed)
+	})
+
+	t.Run("checkpoint error stops the run", func(t *testing.T) {
+		storage := &MockEmailKeyRotationStorage{users: []domain.User{user(1, "a@example.com", oldCrypto)}}
+		checkpointErr := errors.New("disk full")
+
+		_, err := NewEmailKeyRotation(storage, ring).Run(context.Background(), 0, 10, func(domain.UserId) error {
+			return checkpointErr
+		})
+
+		assert.ErrorIs(t, err, checkpointErr)
+	})
+}
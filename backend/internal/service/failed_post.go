@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// Longer error messages are cut, the start says what went wrong.
+const maxFailedPostErrorLength = 1000
+
+// FailedPostService keeps snapshots of refused and failed post creation
+// requests, when failed_post_capture is enabled, for admins diagnosing posts
+// their authors say were lost.
+type FailedPostService interface {
+	// Enabled reports whether failed posts are captured.
+	Enabled() bool
+	Capture(ctx context.Context, post domain.FailedPost) error
+	List(ctx context.Context, page int) ([]domain.FailedPost, error)
+}
+
+// FailedPostStorage defines storage interface for captured failed posts
+type FailedPostStorage interface {
+	SaveFailedPost(ctx context.Context, post domain.FailedPost) (domain.FailedPostId, error)
+	GetFailedPosts(ctx context.Context, limit, offset int) ([]domain.FailedPost, error)
+}
+
+type FailedPost struct {
+	storage FailedPostStorage
+	cfg     *config.Public
+	now     func() time.Time
+}
+
+func NewFailedPost(storage FailedPostStorage, cfg *config.Public) *FailedPost {
+	return &FailedPost{
+		storage: storage,
+		cfg:     cfg,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *FailedPost) Enabled() bool {
+	return s.cfg.FailedPostCapture.Enabled
+}
+
+func (s *FailedPost) Capture(ctx context.Context, post domain.FailedPost) error {
+	if !s.Enabled() {
+		return nil
+	}
+	if len(post.Error) > maxFailedPostErrorLength {
+		post.Error = strings.ToValidUTF8(post.Error[:maxFailedPostErrorLength], "")
+	}
+	post.CreatedAt = s.now()
+	_, err := s.storage.SaveFailedPost(ctx, post)
+	return err
+}
+
+func (s *FailedPost) List(ctx context.Context, page int) ([]domain.FailedPost, error) {
+	page = max(1, page)
+	limit := s.cfg.FailedPostsPageLimit
+	offset := (page - 1) * limit
+	return s.storage.GetFailedPosts(ctx, limit, offset)
+}
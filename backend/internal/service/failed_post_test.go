@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockFailedPostStorage struct {
+	SaveFailedPostFunc func(post domain.FailedPost) (domain.FailedPostId, error)
+	GetFailedPostsFunc func(limit, offset int) ([]domain.FailedPost, error)
+}
+
+func (m *MockFailedPostStorage) SaveFailedPost(ctx context.Context, post domain.FailedPost) (domain.FailedPostId, error) {
+	if m.SaveFailedPostFunc != nil {
+		return m.SaveFailedPostFunc(post)
+	}
+	return 1, nil
+}
+
+func (m *MockFailedPostStorage) GetFailedPosts(ctx context.Context, limit, offset int) ([]domain.FailedPost, error) {
+	if m.GetFailedPostsFunc != nil {
+		return m.GetFailedPostsFunc(limit, offset)
+	}
+	return nil, nil
+}
+
+// --- Tests ---
+
+func TestFailedPostCapture(t *testing.T) {
+	now := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	enabled := &config.Public{FailedPostCapture: config.FailedPostCaptureConfig{Enabled: true}}
+
+	t.Run("stores the capture with its time", func(t *testing.T) {
+		var saved domain.FailedPost
+		storage := &MockFailedPostStorage{
+			SaveFailedPostFunc: func(post domain.FailedPost) (domain.FailedPostId, error) {
+				saved = post
+				return 1, nil
+			},
+		}
+		s := NewFailedPost(storage, enabled)
+		s.now = func() time.Time { return now }
+
+		err := s.Capture(context.Background(), domain.FailedPost{UserId: 7, Board: "b", StatusCode: 400, Error: "Message text is too long"})
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.UserId(7), saved.UserId)
+		assert.Equal(t, "Message text is too long", saved.Error)
+		assert.Equal(t, now, saved.CreatedAt)
+	})
+
+	t.Run("cuts long errors on a character boundary", func(t *testing.T) {
+		var saved domain.FailedPost
+		storage := &MockFailedPostStorage{
+			SaveFailedPostFunc: func(post domain.FailedPost) (domain.FailedPostId, error) {
+				saved = post
+				return 1, nil
+			},
+		}
+		s := NewFailedPost(storage, enabled)
+
+		err := s.Capture(context.Background(), domain.FailedPost{Error: "x" + strings.Repeat("я", maxFailedPostErrorLength)})
+
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(saved.Error), maxFailedPostErrorLength)
+		assert.True(t, utf8.ValidString(saved.Error))
+	})
+
+	t.Run("stores nothing when disabled", func(t *testing.T) {
+		storage := &MockFailedPostStorage{
+			SaveFailedPostFunc: func(post domain.FailedPost) (domain.FailedPostId, error) {
+				t.Fatal("saved while disabled")
+				return 0, nil
+			},
+		}
+		s := NewFailedPost(storage, &config.Public{})
+
+		assert.False(t, s.Enabled())
+		require.NoError(t, s.Capture(context.Background(), domain.FailedPost{}))
+	})
+}
+
+func TestFailedPostList(t *testing.T) {
+	storage := &MockFailedPostStorage{
+		GetFailedPostsFunc: func(limit, offset int) ([]domain.FailedPost, error) {
+			assert.Equal(t, 20, limit)
+			assert.Equal(t, 20, offset)
+			return []domain.FailedPost{{Id: 3}}, nil
+		},
+	}
+	s := NewFailedPost(storage, &config.Public{FailedPostsPageLimit: 20})
+
+	posts, err := s.List(context.Background(), 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.FailedPost{{Id: 3}}, posts)
+}
@@ -14,6 +14,7 @@ type MediaGarbageCollector struct {
 	storage          GCStorage
 	mediaStorage     GCMediaStorage
 	safetyThreshold  time.Duration
+	uploadTTL        time.Duration
 	lastCleanupStats CleanupStats
 }
 
@@ -24,6 +25,7 @@ type CleanupStats struct {
 	OrphanedFiles      int
 	FilesDeleted       int
 	FileRecordsDeleted int // Number of orphaned file records deleted from DB
+	UploadsExpired     int // Number of unclaimed pending uploads dropped
 	BytesReclaimed     int64
 	DurationMs         int64
 	Errors             []string
@@ -31,8 +33,9 @@ type CleanupStats struct {
 
 // GCStorage defines the database operations needed for garbage collection.
 type GCStorage interface {
-	GetAllFilePaths() ([]string, error)
-	DeleteOrphanedFileRecords() (int64, error)
+	GetAllFilePaths(ctx context.Context) ([]string, error)
+	DeleteOrphanedFileRecords(ctx context.Context) (int64, error)
+	DeleteExpiredUploads(ctx context.Context, before time.Time) (int64, error)
 }
 
 // GCMediaStorage defines the filesystem operations needed for garbage collection.
@@ -45,15 +48,18 @@ type GCMediaStorage interface {
 // NewMediaGarbageCollector creates a new garbage collector instance.
 // safetyThreshold is the minimum age a file must have before being deleted.
 // This prevents deletion of files that were just uploaded but not yet committed to DB.
+// uploadTTL is how long files uploaded ahead of a post wait to be claimed.
 func NewMediaGarbageCollector(
 	storage GCStorage,
 	mediaStorage GCMediaStorage,
 	safetyThreshold time.Duration,
+	uploadTTL time.Duration,
 ) *MediaGarbageCollector {
 	return &MediaGarbageCollector{
 		storage:         storage,
 		mediaStorage:    mediaStorage,
 		safetyThreshold: safetyThreshold,
+		uploadTTL:       uploadTTL,
 	}
 }
 
@@ -71,7 +77,7 @@ func (gc *MediaGarbageCollector) StartBackgroundCleanup(ctx context.Context, int
 		for {
 			select {
 			case <-ticker.C:
-				if err := gc.RunCleanup(); err != nil {
+				if err := gc.RunCleanup(ctx); err != nil {
 					logger.Log.Error("media gc cleanup failed",
 						"component", "media_gc",
 						"error", err)
@@ -83,6 +89,7 @@ func (gc *MediaGarbageCollector) StartBackgroundCleanup(ctx context.Context, int
 						"orphans", stats.OrphanedFiles,
 						"deleted", stats.FilesDeleted,
 						"db_records_deleted", stats.FileRecordsDeleted,
+						"uploads_expired", stats.UploadsExpired,
 						"bytes_reclaimed", stats.BytesReclaimed,
 						"duration_ms", stats.DurationMs,
 						"errors", len(stats.Errors))
@@ -98,17 +105,29 @@ func (gc *MediaGarbageCollector) StartBackgroundCleanup(ctx context.Context, int
 
 // RunCleanup executes a single garbage collection cycle.
 // It can be called manually for testing or maintenance.
-func (gc *MediaGarbageCollector) RunCleanup() error {
+func (gc *MediaGarbageCollector) RunCleanup(ctx context.Context) error {
 	startTime := time.Now()
 	stats := CleanupStats{
 		RunAt:  startTime,
 		Errors: []string{},
 	}
 
+	// Unclaimed uploads go first so their file records are orphaned below
+	expired, err := gc.storage.DeleteExpiredUploads(ctx, startTime.Add(-gc.uploadTTL))
+	if err != nil {
+		logger.Log.Error("failed to delete expired uploads",
+			"component", "media_gc",
+			"error", err)
+		stats.Errors = append(stats.Errors, "failed to delete expired uploads: "+err.Error())
+	} else {
+		stats.UploadsExpired = int(expired)
+		cleanupRowsDeleted.WithLabelValues("pending_uploads").Add(float64(expired))
+	}
+
 	// Step 0: Delete orphaned file records from database (BEFORE disk cleanup)
 	// This is critical - deleting DB records creates new orphaned files on disk
 	// which will be cleaned up in the next steps
-	count, err := gc.storage.DeleteOrphanedFileRecords()
+	count, err := gc.storage.DeleteOrphanedFileRecords(ctx)
 	if err != nil {
 		logger.Log.Error("failed to delete orphaned file records",
 			"component", "media_gc",
@@ -124,7 +143,7 @@ func (gc *MediaGarbageCollector) RunCleanup() error {
 	}
 
 	// Step 1: Get all file paths from the database
-	dbPaths, err := gc.storage.GetAllFilePaths()
+	dbPaths, err := gc.storage.GetAllFilePaths(ctx)
 	if err != nil {
 		return err
 	}
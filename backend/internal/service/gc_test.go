@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
@@ -16,11 +17,12 @@ type MockGCStorage struct {
 	mu                            sync.Mutex
 	getAllFilePathsFunc           func() ([]string, error)
 	deleteOrphanedFileRecordsFunc func() (int64, error)
+	deleteExpiredUploadsFunc      func(before time.Time) (int64, error)
 	getAllFilePathsCalls          int
 	deleteOrphanedRecordsCalls    int
 }
 
-func (m *MockGCStorage) GetAllFilePaths() ([]string, error) {
+func (m *MockGCStorage) GetAllFilePaths(ctx context.Context) ([]string, error) {
 	m.mu.Lock()
 	m.getAllFilePathsCalls++
 	m.mu.Unlock()
@@ -31,7 +33,7 @@ func (m *MockGCStorage) GetAllFilePaths() ([]string, error) {
 	return []string{}, nil
 }
 
-func (m *MockGCStorage) DeleteOrphanedFileRecords() (int64, error) {
+func (m *MockGCStorage) DeleteOrphanedFileRecords(ctx context.Context) (int64, error) {
 	m.mu.Lock()
 	m.deleteOrphanedRecordsCalls++
 	m.mu.Unlock()
@@ -42,6 +44,13 @@ func (m *MockGCStorage) DeleteOrphanedFileRecords() (int64, error) {
 	return 0, nil
 }
 
+func (m *MockGCStorage) DeleteExpiredUploads(ctx context.Context, before time.Time) (int64, error) {
+	if m.deleteExpiredUploadsFunc != nil {
+		return m.deleteExpiredUploadsFunc(before)
+	}
+	return 0, nil
+}
+
 type MockGCMediaStorage struct {
 	mu                  sync.Mutex
 	walkFilesFunc       func() ([]string, error)
@@ -130,10 +139,10 @@ func TestMediaGarbageCollectorCleanup(t *testing.T) {
 			return time.Now().Add(-10 * time.Minute), nil
 		}
 
-		gc := NewMediaGarbageCollector(storage, mediaStorage, safetyThreshold)
+		gc := NewMediaGarbageCollector(storage, mediaStorage, safetyThreshold, time.Hour)
 
 		// Run cleanup
-		err := gc.RunCleanup()
+		err := gc.RunCleanup(context.Background())
 		require.NoError(t, err)
 
 		// Verify DB orphaned records were deleted first
@@ -159,6 +168,23 @@ func TestMediaGarbageCollectorCleanup(t *testing.T) {
 		mediaStorage.mu.Unlock()
 	})
 
+	t.Run("drops expired uploads before orphaned file records", func(t *testing.T) {
+		storage := &MockGCStorage{}
+		var cutoff time.Time
+		storage.deleteExpiredUploadsFunc = func(before time.Time) (int64, error) {
+			assert.Zero(t, storage.deleteOrphanedRecordsCalls, "Uploads must expire before orphaned records are collected")
+			cutoff = before
+			return 3, nil
+		}
+
+		gc := NewMediaGarbageCollector(storage, &MockGCMediaStorage{}, 5*time.Minute, time.Hour)
+		require.NoError(t, gc.RunCleanup(context.Background()))
+
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), cutoff, time.Minute)
+		assert.Equal(t, 3, gc.GetLastCleanupStats().UploadsExpired)
+		assert.Equal(t, 1, storage.deleteOrphanedRecordsCalls)
+	})
+
 	t.Run("respects safety threshold and skips young files", func(t *testing.T) {
 		storage := &MockGCStorage{}
 		mediaStorage := &MockGCMediaStorage{}
@@ -190,10 +216,10 @@ func TestMediaGarbageCollectorCleanup(t *testing.T) {
 			return time.Now().Add(-1 * time.Minute), nil // Too young
 		}
 
-		gc := NewMediaGarbageCollector(storage, mediaStorage, safetyThreshold)
+		gc := NewMediaGarbageCollector(storage, mediaStorage, safetyThreshold, time.Hour)
 
 		// Run cleanup
-		err := gc.RunCleanup()
+		err := gc.RunCleanup(context.Background())
 		require.NoError(t, err)
 
 		// Verify stats
@@ -245,10 +271,10 @@ func TestMediaGarbageCollectorCleanup(t *testing.T) {
 			return nil
 		}
 
-		gc := NewMediaGarbageCollector(storage, mediaStorage, safetyThreshold)
+		gc := NewMediaGarbageCollector(storage, mediaStorage, safetyThreshold, time.Hour)
 
 		// Run cleanup (should not fail despite errors)
-		err := gc.RunCleanup()
+		err := gc.RunCleanup(context.Background())
 		require.NoError(t, err)
 
 		// Verify stats track errors
@@ -286,10 +312,10 @@ func TestMediaGarbageCollectorCleanup(t *testing.T) {
 			return []string{}, nil
 		}
 
-		gc := NewMediaGarbageCollector(storage, mediaStorage, safetyThreshold)
+		gc := NewMediaGarbageCollector(storage, mediaStorage, safetyThreshold, time.Hour)
 
 		// Run cleanup
-		err := gc.RunCleanup()
+		err := gc.RunCleanup(context.Background())
 		require.NoError(t, err)
 
 		// Verify stats
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"unicode"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// A key whose request neither finished nor failed within the upload request
+// timeout plus this margin is considered abandoned (e.g. the server
+// restarted) and can be reserved again. Posts run under the upload timeout,
+// so a request still transcoding its attachments keeps its key.
+const idempotencyStaleMargin = 5 * time.Minute
+
+// IdempotencyService backs the Idempotency-Key header of post creation, so a
+// client retrying after a lost response does not post twice.
+type IdempotencyService interface {
+	// Begin reserves the user's key for a request to path. If an earlier
+	// request with the key succeeded, it returns that request's key with the
+	// response to replay instead.
+	Begin(ctx context.Context, userId domain.UserId, key, path string) (*domain.IdempotencyKey, error)
+	// Finish stores the response of the request that reserved the key.
+	Finish(ctx context.Context, userId domain.UserId, key string, statusCode int, response []byte) error
+	// Abort frees the key of a failed request, so a retry runs again.
+	Abort(ctx context.Context, userId domain.UserId, key string) error
+}
+
+// IdempotencyStorage defines storage interface for idempotency keys
+type IdempotencyStorage interface {
+	ReserveIdempotencyKey(ctx context.Context, key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, userId domain.UserId, key string, statusCode int, response []byte) error
+	DeleteIdempotencyKey(ctx context.Context, userId domain.UserId, key string) error
+}
+
+type Idempotency struct {
+	storage IdempotencyStorage
+	cfg     *config.Public
+	now     func() time.Time
+}
+
+func NewIdempotency(storage IdempotencyStorage, cfg *config.Public) *Idempotency {
+	return &Idempotency{
+		storage: storage,
+		cfg:     cfg,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *Idempotency) Begin(ctx context.Context, userId domain.UserId, key, path string) (*domain.IdempotencyKey, error) {
+	if err := validateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	existing, reserved, err := s.storage.ReserveIdempotencyKey(ctx, domain.IdempotencyKey{
+		UserId:      userId,
+		Key:         key,
+		RequestPath: path,
+		CreatedAt:   now,
+	}, now.Add(-s.cfg.IdempotencyKeyTTL), now.Add(-s.staleAfter()))
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	if existing.RequestPath != path {
+		return nil, &errors.ErrorWithStatusCode{Message: "Idempotency-Key was already used for another request", StatusCode: http.StatusUnprocessableEntity}
+	}
+	if existing.StatusCode == 0 {
+		return nil, &errors.ErrorWithStatusCode{Message: "A request with this Idempotency-Key is still in progress", StatusCode: http.StatusConflict}
+	}
+	return &existing, nil
+}
+
+func (s *Idempotency) Finish(ctx context.Context, userId domain.UserId, key string, statusCode int, response []byte) error {
+	return s.storage.CompleteIdempotencyKey(ctx, userId, key, statusCode, response)
+}
+
+func (s *Idempotency) Abort(ctx context.Context, userId domain.UserId, key string) error {
+	return s.storage.DeleteIdempotencyKey(ctx, userId, key)
+}
+
+// staleAfter is how long a key stays reserved by a request in progress.
+func (s *Idempotency) staleAfter() time.Duration {
+	return s.cfg.UploadRequestTimeout + idempotencyStaleMargin
+}
+
+func validateIdempotencyKey(key string) error {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return &errors.ErrorWithStatusCode{Message: "Idempotency-Key must be 1 to 255 characters long", StatusCode: http.StatusBadRequest}
+	}
+	for _, r := range key {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return &errors.ErrorWithStatusCode{Message: "Idempotency-Key must be printable ASCII", StatusCode: http.StatusBadRequest}
+		}
+	}
+	return nil
+}
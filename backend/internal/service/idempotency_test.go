@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mock for IdempotencyStorage ---
+
+type MockIdempotencyStorage struct {
+	ReserveIdempotencyKeyFunc  func(key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error)
+	CompleteIdempotencyKeyFunc func(userId domain.UserId, key string, statusCode int, response []byte) error
+	DeleteIdempotencyKeyFunc   func(userId domain.UserId, key string) error
+}
+
+func (m *MockIdempotencyStorage) ReserveIdempotencyKey(ctx context.Context, key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error) {
+	if m.ReserveIdempotencyKeyFunc != nil {
+		return m.ReserveIdempotencyKeyFunc(key, expiredBefore, staleBefore)
+	}
+	return domain.IdempotencyKey{}, true, nil
+}
+
+func (m *MockIdempotencyStorage) CompleteIdempotencyKey(ctx context.Context, userId domain.UserId, key string, statusCode int, response []byte) error {
+	if m.CompleteIdempotencyKeyFunc != nil {
+		return m.CompleteIdempotencyKeyFunc(userId, key, statusCode, response)
+	}
+	return nil
+}
+
+func (m *MockIdempotencyStorage) DeleteIdempotencyKey(ctx context.Context, userId domain.UserId, key string) error {
+	if m.DeleteIdempotencyKeyFunc != nil {
+		return m.DeleteIdempotencyKeyFunc(userId, key)
+	}
+	return nil
+}
+
+// --- Tests ---
+
+func TestIdempotencyBegin(t *testing.T) {
+	now := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	cfg := &config.Public{IdempotencyKeyTTL: 24 * time.Hour, UploadRequestTimeout: 10 * time.Minute}
+	newService := func(storage *MockIdempotencyStorage) *Idempotency {
+		s := NewIdempotency(storage, cfg)
+		s.now = func() time.Time { return now }
+		return s
+	}
+	statusOf := func(t *testing.T, err error) int {
+		t.Helper()
+		var e *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &e))
+		return e.StatusCode
+	}
+
+	t.Run("reserves a new key", func(t *testing.T) {
+		s := newService(&MockIdempotencyStorage{
+			ReserveIdempotencyKeyFunc: func(key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error) {
+				assert.Equal(t, domain.UserId(7), key.UserId)
+				assert.Equal(t, "retry-1", key.Key)
+				assert.Equal(t, "/v1/b/1", key.RequestPath)
+				assert.Equal(t, now, key.CreatedAt)
+				assert.Equal(t, now.Add(-24*time.Hour), expiredBefore)
+				assert.Equal(t, now.Add(-15*time.Minute), staleBefore, "upload timeout plus margin")
+				return domain.IdempotencyKey{}, true, nil
+			},
+		})
+
+		stored, err := s.Begin(context.Background(), 7, "retry-1", "/v1/b/1")
+
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+	})
+
+	t.Run("returns the stored response", func(t *testing.T) {
+		s := newService(&MockIdempotencyStorage{
+			ReserveIdempotencyKeyFunc: func(key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error) {
+				return domain.IdempotencyKey{RequestPath: "/v1/b/1", StatusCode: http.StatusCreated, Response: []byte(`{"id": 4}`)}, false, nil
+			},
+		})
+
+		stored, err := s.Begin(context.Background(), 7, "retry-1", "/v1/b/1")
+
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, http.StatusCreated, stored.StatusCode)
+		assert.Equal(t, `{"id": 4}`, string(stored.Response))
+	})
+
+	t.Run("request still in progress", func(t *testing.T) {
+		s := newService(&MockIdempotencyStorage{
+			ReserveIdempotencyKeyFunc: func(key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error) {
+				return domain.IdempotencyKey{RequestPath: "/v1/b/1"}, false, nil
+			},
+		})
+
+		_, err := s.Begin(context.Background(), 7, "retry-1", "/v1/b/1")
+
+		assert.Equal(t, http.StatusConflict, statusOf(t, err))
+	})
+
+	t.Run("slow upload keeps its key past five minutes", func(t *testing.T) {
+		// The first request is still transcoding a video after 7 minutes,
+		// within the 10 minute upload timeout
+		inProgress := domain.IdempotencyKey{RequestPath: "/v1/b/1", CreatedAt: now.Add(-7 * time.Minute)}
+		s := newService(&MockIdempotencyStorage{
+			ReserveIdempotencyKeyFunc: func(key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error) {
+				if inProgress.CreatedAt.Before(staleBefore) {
+					return domain.IdempotencyKey{}, true, nil
+				}
+				return inProgress, false, nil
+			},
+		})
+
+		_, err := s.Begin(context.Background(), 7, "retry-1", "/v1/b/1")
+
+		assert.Equal(t, http.StatusConflict, statusOf(t, err), "the retry must not post a second time")
+	})
+
+	t.Run("key used for another request", func(t *testing.T) {
+		s := newService(&MockIdempotencyStorage{
+			ReserveIdempotencyKeyFunc: func(key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error) {
+				return domain.IdempotencyKey{RequestPath: "/v1/b", StatusCode: http.StatusCreated}, false, nil
+			},
+		})
+
+		_, err := s.Begin(context.Background(), 7, "retry-1", "/v1/b/1")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, statusOf(t, err))
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		s := newService(&MockIdempotencyStorage{
+			ReserveIdempotencyKeyFunc: func(key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error) {
+				t.Fatal("invalid keys must not be stored")
+				return domain.IdempotencyKey{}, false, nil
+			},
+		})
+
+		for _, key := range []string{strings.Repeat("k", maxIdempotencyKeyLength+1), "line\nbreak", "ключ"} {
+			_, err := s.Begin(context.Background(), 7, key, "/v1/b/1")
+			assert.Equal(t, http.StatusBadRequest, statusOf(t, err), key)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+	sharedutils "github.com/itchan-dev/itchan/shared/utils"
+)
+
+const revokeTokenLength = 32
+
+// deviceFingerprint identifies the browser and network of a login. Only the
+// network prefix of the IP is used (/24 for IPv4, /48 for IPv6), so a new
+// address from the same provider pool doesn't count as a new device.
+func deviceFingerprint(device domain.Device) string {
+	network := device.IP
+	if addr, err := netip.ParseAddr(device.IP); err == nil {
+		bits := 48
+		if addr.Unmap().Is4() {
+			addr, bits = addr.Unmap(), 24
+		}
+		if prefix, err := addr.Prefix(bits); err == nil {
+			network = prefix.String()
+		}
+	}
+	return sharedutils.HashSHA256(device.UserAgent + "\n" + network)
+}
+
+// checkLoginDevice records the device of a login and emails the user when it
+// is new. The first device of an account is recorded silently. Failures are
+// only logged: they must not block the login.
+func (a *Auth) checkLoginDevice(ctx context.Context, user domain.User, email domain.Email, device domain.Device) {
+	if !a.cfg.NewDeviceLoginEmails {
+		return
+	}
+
+	revokeToken := sharedutils.GenerateRandomString(revokeTokenLength, "abcdefghijklmnopqrstuvwxyz0123456789")
+	isNew, err := a.storage.SaveLoginDevice(ctx, domain.LoginDevice{
+		UserId:          user.Id,
+		Fingerprint:     deviceFingerprint(device),
+		RevokeTokenHash: sharedutils.HashSHA256(revokeToken),
+	})
+	if err != nil {
+		logger.Log.Error("failed to save login device", "user_id", user.Id, "error", err)
+		return
+	}
+	if !isNew {
+		return
+	}
+	devices, err := a.storage.CountLoginDevices(ctx, user.Id)
+	if err != nil {
+		logger.Log.Error("failed to count login devices", "user_id", user.Id, "error", err)
+		return
+	}
+	if devices <= 1 {
+		return
+	}
+
+	if err := a.email.Send(strings.ToLower(email), "Вход с нового устройства (Itchan)", a.newDeviceEmailBody(device, revokeToken)); err != nil {
+		logger.Log.Error("failed to send new device email", "user_id", user.Id, "error", err)
+		return
+	}
+	logger.Log.Info("login from new device", "user_id", user.Id)
+}
+
+func (a *Auth) newDeviceEmailBody(device domain.Device, revokeToken string) string {
+	userAgent, ip := device.UserAgent, device.IP
+	if userAgent == "" {
+		userAgent = "неизвестен"
+	}
+	if ip == "" {
+		ip = "неизвестен"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `Здравствуйте.
+
+В ваш аккаунт Itchan выполнен вход с нового устройства.
+
+Браузер: %s
+IP-адрес: %s
+
+Если это были вы, ничего делать не нужно.
+`, userAgent, ip)
+	if site := strings.TrimRight(a.cfg.SiteURL, "/"); site != "" {
+		fmt.Fprintf(&b, `
+Если это были не вы, завершите все сеансы по ссылке и смените пароль:
+%s/sessions/revoke?token=%s
+`, site, revokeToken)
+	} else {
+		b.WriteString("\nЕсли это были не вы, смените пароль на странице регистрации.\n")
+	}
+	b.WriteString(`
+---
+Это автоматическое уведомление, пожалуйста, не отвечайте на него.`)
+	return b.String()
+}
+
+// RevokeSessions signs the user out everywhere on behalf of the "this wasn't
+// me" link of a new device email. Access tokens are turned away as soon as
+// the blacklist caches update, refresh tokens at once.
+func (a *Auth) RevokeSessions(ctx context.Context, token string) error {
+	if token == "" {
+		return &errors.ErrorWithStatusCode{Message: "Token is required", StatusCode: http.StatusBadRequest}
+	}
+	userId, err := a.storage.RevokeSessionsByDeviceToken(ctx, sharedutils.HashSHA256(token))
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Info("sessions revoked from new device email", "user_id", userId)
+	if err := a.blacklistCache.Update(); err != nil {
+		logger.Log.Warn("sessions revoked but cache update failed", "user_id", userId, "error", err)
+	}
+	return nil
+}
+
+// checkSessionNotRevoked turns away refresh tokens issued before the user's
+// sessions were revoked.
+func (a *Auth) checkSessionNotRevoked(ctx context.Context, userId domain.UserId, issuedAt time.Time) error {
+	revokedAt, err := a.storage.SessionsRevokedAt(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if !revokedAt.IsZero() && !issuedAt.After(revokedAt.Truncate(time.Second)) {
+		return &errors.ErrorWithStatusCode{Message: "Session was revoked, please sign in again", StatusCode: http.StatusUnauthorized}
+	}
+	return nil
+}
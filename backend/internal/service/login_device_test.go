@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/blacklist"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	sharedutils "github.com/itchan-dev/itchan/shared/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestDeviceFingerprint(t *testing.T) {
+	firefox := "Mozilla/5.0 Firefox/130.0"
+
+	assert.Equal(t,
+		deviceFingerprint(domain.Device{UserAgent: firefox, IP: "203.0.113.7"}),
+		deviceFingerprint(domain.Device{UserAgent: firefox, IP: "203.0.113.200"}),
+		"same IPv4 /24")
+	assert.Equal(t,
+		deviceFingerprint(domain.Device{UserAgent: firefox, IP: "2001:db8:1:2::1"}),
+		deviceFingerprint(domain.Device{UserAgent: firefox, IP: "2001:db8:1:ffff::9"}),
+		"same IPv6 /48")
+	assert.NotEqual(t,
+		deviceFingerprint(domain.Device{UserAgent: firefox, IP: "203.0.113.7"}),
+		deviceFingerprint(domain.Device{UserAgent: firefox, IP: "198.51.100.7"}),
+		"other network")
+	assert.NotEqual(t,
+		deviceFingerprint(domain.Device{UserAgent: firefox, IP: "203.0.113.7"}),
+		deviceFingerprint(domain.Device{UserAgent: "Mozilla/5.0 Chrome/128.0", IP: "203.0.113.7"}),
+		"other browser")
+}
+
+func TestLoginNewDevice(t *testing.T) {
+	passHash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	device := &domain.Device{UserAgent: "Mozilla/5.0 Firefox/130.0", IP: "203.0.113.7"}
+	creds := domain.Credentials{Email: "Test@Example.com", Password: "password", Device: device}
+
+	setup := func(enabled bool, isNew bool, devices int) (*Auth, *MockAuthStorage, *[]string) {
+		var bodies []string
+		storage := &MockAuthStorage{
+			UserFunc: func(emailHash []byte) (domain.User, error) {
+				return domain.User{Id: 3, PassHash: domain.Password(passHash)}, nil
+			},
+			SaveLoginDeviceFunc: func(d domain.LoginDevice) (bool, error) {
+				assert.Equal(t, domain.UserId(3), d.UserId)
+				assert.Equal(t, deviceFingerprint(*device), d.Fingerprint)
+				assert.Len(t, d.RevokeTokenHash, 64)
+				return isNew, nil
+			},
+			CountLoginDevicesFunc: func(userId domain.UserId) (int, error) { return devices, nil },
+		}
+		email := &MockEmail{SendFunc: func(recipient, subject, body string) error {
+			assert.Equal(t, "test@example.com", recipient)
+			bodies = append(bodies, body)
+			return nil
+		}}
+		cfg := &config.Public{SiteURL: "https://itchan.example/", NewDeviceLoginEmails: enabled}
+		return NewAuth(storage, email, &MockJwt{}, cfg, nil, &MockEmailCrypto{}, &MockCredentialsValidator{}, sharedutils.AllowedSources{}), storage, &bodies
+	}
+
+	t.Run("new device is emailed with a revoke link", func(t *testing.T) {
+		s, _, bodies := setup(true, true, 2)
+
+		_, err := s.Login(context.Background(), creds)
+
+		require.NoError(t, err)
+		require.Len(t, *bodies, 1)
+		body := (*bodies)[0]
+		assert.Contains(t, body, device.UserAgent)
+		assert.Contains(t, body, device.IP)
+		assert.Contains(t, body, "https://itchan.example/sessions/revoke?token=")
+	})
+
+	t.Run("first device and known devices are not emailed", func(t *testing.T) {
+		for _, tc := range []struct {
+			isNew   bool
+			devices int
+		}{{true, 1}, {false, 2}} {
+			s, _, bodies := setup(true, tc.isNew, tc.devices)
+
+			_, err := s.Login(context.Background(), creds)
+
+			require.NoError(t, err)
+			assert.Empty(t, *bodies)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s, storage, bodies := setup(false, true, 2)
+		storage.SaveLoginDeviceFunc = func(d domain.LoginDevice) (bool, error) {
+			t.Fatal("devices must not be recorded when disabled")
+			return false, nil
+		}
+
+		_, err := s.Login(context.Background(), creds)
+
+		require.NoError(t, err)
+		assert.Empty(t, *bodies)
+	})
+
+	t.Run("storage failure does not block the login", func(t *testing.T) {
+		s, storage, bodies := setup(true, true, 2)
+		storage.SaveLoginDeviceFunc = func(d domain.LoginDevice) (bool, error) {
+			return false, errors.New("db down")
+		}
+
+		token, err := s.Login(context.Background(), creds)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, token)
+		assert.Empty(t, *bodies)
+	})
+}
+
+func TestRevokeSessions(t *testing.T) {
+	cacheUpdates := 0
+	cache := blacklist.NewCache(&MockBlacklistCacheStorage{
+		GetRecentSessionRevocationsFunc: func(since time.Time) (map[domain.UserId]time.Time, error) {
+			cacheUpdates++
+			return map[domain.UserId]time.Time{3: time.Now()}, nil
+		},
+	}, time.Hour)
+
+	var gotHash string
+	storage := &MockAuthStorage{
+		RevokeSessionsByDeviceTokenFunc: func(tokenHash string) (domain.UserId, error) {
+			gotHash = tokenHash
+			if tokenHash != sharedutils.HashSHA256("valid") {
+				return 0, &internal_errors.ErrorWithStatusCode{Message: "Link is invalid or was already used", StatusCode: http.StatusNotFound}
+			}
+			return 3, nil
+		},
+	}
+	s := NewAuth(storage, &MockEmail{}, &MockJwt{}, &config.Public{}, cache, &MockEmailCrypto{}, &MockCredentialsValidator{}, sharedutils.AllowedSources{})
+
+	t.Run("revokes and updates the cache", func(t *testing.T) {
+		require.NoError(t, s.RevokeSessions(context.Background(), "valid"))
+
+		assert.Equal(t, sharedutils.HashSHA256("valid"), gotHash, "only the token hash is stored")
+		assert.Equal(t, 1, cacheUpdates)
+		assert.True(t, cache.SessionRevoked(3, time.Now().Add(-time.Minute)))
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		err := s.RevokeSessions(context.Background(), "unknown")
+
+		var e *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, http.StatusNotFound, e.StatusCode)
+	})
+
+	t.Run("empty token", func(t *testing.T) {
+		err := s.RevokeSessions(context.Background(), "")
+
+		var e *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, http.StatusBadRequest, e.StatusCode)
+	})
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// defaultMaintenanceMessage is shown when the admin gave no message.
+const defaultMaintenanceMessage = "The site is read-only for scheduled maintenance. Reading still works, posting is paused."
+
+// MaintenanceService schedules read-only maintenance windows. The window is
+// cached in memory, so writes can be refused while the database is down.
+type MaintenanceService interface {
+	// Current returns the window that is active or still to come, nil if none.
+	Current() *domain.MaintenanceWindow
+	Schedule(ctx context.Context, window domain.MaintenanceWindow) (domain.MaintenanceWindow, error)
+	Cancel(ctx context.Context) error
+}
+
+// MaintenanceStorage defines storage interface for the maintenance window
+type MaintenanceStorage interface {
+	GetMaintenanceWindow(ctx context.Context) (domain.MaintenanceWindow, error)
+	SetMaintenanceWindow(ctx context.Context, window domain.MaintenanceWindow) error
+	DeleteMaintenanceWindow(ctx context.Context) error
+}
+
+type Maintenance struct {
+	storage MaintenanceStorage
+	now     func() time.Time
+
+	mu     sync.RWMutex
+	window *domain.MaintenanceWindow
+}
+
+func NewMaintenance(storage MaintenanceStorage) *Maintenance {
+	return &Maintenance{
+		storage: storage,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// StartBackgroundRefresh loads the window right away and then every interval
+// until ctx is cancelled, so windows set through another backend instance
+// are picked up. A failed load keeps the last known window.
+func (s *Maintenance) StartBackgroundRefresh(ctx context.Context, interval time.Duration) {
+	logger.Log.Info("started maintenance window refresh",
+		"component", "maintenance",
+		"interval", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Refresh(ctx); err != nil {
+				logger.Log.Error("maintenance window refresh failed", "component", "maintenance", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				logger.Log.Info("stopping maintenance window refresh", "component", "maintenance")
+				return
+			}
+		}
+	}()
+}
+
+// Refresh reloads the window from storage.
+func (s *Maintenance) Refresh(ctx context.Context) error {
+	window, err := s.storage.GetMaintenanceWindow(ctx)
+	switch {
+	case errors.IsNotFound(err):
+		s.set(nil)
+	case err != nil:
+		return err
+	default:
+		s.set(&window)
+	}
+	return nil
+}
+
+func (s *Maintenance) Current() *domain.MaintenanceWindow {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.window == nil || s.window.EndedAt(s.now()) {
+		return nil
+	}
+	window := *s.window
+	return &window
+}
+
+// Schedule replaces the maintenance window. A zero StartsAt starts it now.
+func (s *Maintenance) Schedule(ctx context.Context, window domain.MaintenanceWindow) (domain.MaintenanceWindow, error) {
+	now := s.now()
+	if window.StartsAt.IsZero() || window.StartsAt.Before(now) {
+		window.StartsAt = now
+	}
+	if window.EndsAt != nil && !window.EndsAt.After(window.StartsAt) {
+		return domain.MaintenanceWindow{}, &errors.ErrorWithStatusCode{Message: "Maintenance must end after it starts and in the future", StatusCode: http.StatusBadRequest}
+	}
+	if window.Message == "" {
+		window.Message = defaultMaintenanceMessage
+	}
+	window.CreatedAt = now
+
+	if err := s.storage.SetMaintenanceWindow(ctx, window); err != nil {
+		return domain.MaintenanceWindow{}, err
+	}
+	s.set(&window)
+	logger.Log.Info("maintenance scheduled", "component", "maintenance",
+		"starts_at", window.StartsAt, "ends_at", window.EndsAt, "created_by", window.CreatedBy)
+	return window, nil
+}
+
+// Cancel ends the maintenance window early or drops a scheduled one.
+func (s *Maintenance) Cancel(ctx context.Context) error {
+	if err := s.storage.DeleteMaintenanceWindow(ctx); err != nil {
+		return err
+	}
+	s.set(nil)
+	logger.Log.Info("maintenance cancelled", "component", "maintenance")
+	return nil
+}
+
+func (s *Maintenance) set(window *domain.MaintenanceWindow) {
+	s.mu.Lock()
+	s.window = window
+	s.mu.Unlock()
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mock for MaintenanceStorage ---
+
+type MockMaintenanceStorage struct {
+	GetMaintenanceWindowFunc    func() (domain.MaintenanceWindow, error)
+	SetMaintenanceWindowFunc    func(window domain.MaintenanceWindow) error
+	DeleteMaintenanceWindowFunc func() error
+}
+
+func (m *MockMaintenanceStorage) GetMaintenanceWindow(ctx context.Context) (domain.MaintenanceWindow, error) {
+	if m.GetMaintenanceWindowFunc != nil {
+		return m.GetMaintenanceWindowFunc()
+	}
+	return domain.MaintenanceWindow{}, &internal_errors.ErrorWithStatusCode{Message: "No maintenance is scheduled", StatusCode: http.StatusNotFound}
+}
+
+func (m *MockMaintenanceStorage) SetMaintenanceWindow(ctx context.Context, window domain.MaintenanceWindow) error {
+	if m.SetMaintenanceWindowFunc != nil {
+		return m.SetMaintenanceWindowFunc(window)
+	}
+	return nil
+}
+
+func (m *MockMaintenanceStorage) DeleteMaintenanceWindow(ctx context.Context) error {
+	if m.DeleteMaintenanceWindowFunc != nil {
+		return m.DeleteMaintenanceWindowFunc()
+	}
+	return nil
+}
+
+// --- Tests ---
+
+func TestMaintenanceSchedule(t *testing.T) {
+	now := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	newService := func(storage *MockMaintenanceStorage) *Maintenance {
+		s := NewMaintenance(storage)
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("starts now with the default message", func(t *testing.T) {
+		var saved domain.MaintenanceWindow
+		s := newService(&MockMaintenanceStorage{
+			SetMaintenanceWindowFunc: func(window domain.MaintenanceWindow) error {
+				saved = window
+				return nil
+			},
+		})
+
+		window, err := s.Schedule(context.Background(), domain.MaintenanceWindow{})
+		require.NoError(t, err)
+		assert.Equal(t, now, window.StartsAt)
+		assert.Equal(t, defaultMaintenanceMessage, window.Message)
+		assert.Equal(t, window, saved)
+
+		current := s.Current()
+		require.NotNil(t, current)
+		assert.True(t, current.ActiveAt(now))
+	})
+
+	t.Run("scheduled window is upcoming until it starts", func(t *testing.T) {
+		startsAt := now.Add(time.Hour)
+		endsAt := now.Add(2 * time.Hour)
+		s := newService(&MockMaintenanceStorage{})
+
+		_, err := s.Schedule(context.Background(), domain.MaintenanceWindow{Message: "db upgrade", StartsAt: startsAt, EndsAt: &endsAt})
+		require.NoError(t, err)
+
+		current := s.Current()
+		require.NotNil(t, current)
+		assert.Equal(t, "db upgrade", current.Message)
+		assert.False(t, current.ActiveAt(now))
+		assert.True(t, current.ActiveAt(startsAt))
+
+		s.now = func() time.Time { return endsAt }
+		assert.Nil(t, s.Current(), "ended windows are dropped")
+	})
+
+	t.Run("end must be after the start", func(t *testing.T) {
+		endsAt := now.Add(-time.Minute)
+		s := newService(&MockMaintenanceStorage{
+			SetMaintenanceWindowFunc: func(window domain.MaintenanceWindow) error {
+				t.Fatal("invalid window must not be saved")
+				return nil
+			},
+		})
+
+		_, err := s.Schedule(context.Background(), domain.MaintenanceWindow{EndsAt: &endsAt})
+		var e *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, http.StatusBadRequest, e.StatusCode)
+		assert.Nil(t, s.Current())
+	})
+
+	t.Run("storage error keeps the previous window", func(t *testing.T) {
+		s := newService(&MockMaintenanceStorage{})
+		_, err := s.Schedule(context.Background(), domain.MaintenanceWindow{Message: "first"})
+		require.NoError(t, err)
+
+		s.storage = &MockMaintenanceStorage{
+			SetMaintenanceWindowFunc: func(window domain.MaintenanceWindow) error { return errors.New("db down") },
+		}
+		_, err = s.Schedule(context.Background(), domain.MaintenanceWindow{Message: "second"})
+		require.Error(t, err)
+		assert.Equal(t, "first", s.Current().Message)
+	})
+}
+
+func TestMaintenanceRefresh(t *testing.T) {
+	now := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	stored := domain.MaintenanceWindow{Message: "set elsewhere", StartsAt: now.Add(-time.Minute)}
+	storage := &MockMaintenanceStorage{
+		GetMaintenanceWindowFunc: func() (domain.MaintenanceWindow, error) { return stored, nil },
+	}
+	s := NewMaintenance(storage)
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Refresh(context.Background()))
+	require.NotNil(t, s.Current())
+	assert.Equal(t, "set elsewhere", s.Current().Message)
+
+	t.Run("failed refresh keeps the window", func(t *testing.T) {
+		storage.GetMaintenanceWindowFunc = func() (domain.MaintenanceWindow, error) {
+			return domain.MaintenanceWindow{}, errors.New("connection refused")
+		}
+		require.Error(t, s.Refresh(context.Background()))
+		assert.NotNil(t, s.Current())
+	})
+
+	t.Run("cleared window", func(t *testing.T) {
+		storage.GetMaintenanceWindowFunc = nil
+		require.NoError(t, s.Refresh(context.Background()))
+		assert.Nil(t, s.Current())
+	})
+}
+
+func TestMaintenanceCancel(t *testing.T) {
+	s := NewMaintenance(&MockMaintenanceStorage{})
+	_, err := s.Schedule(context.Background(), domain.MaintenanceWindow{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Cancel(context.Background()))
+	assert.Nil(t, s.Current())
+
+	s.storage = &MockMaintenanceStorage{
+		DeleteMaintenanceWindowFunc: func() error {
+			return &internal_errors.ErrorWithStatusCode{Message: "No maintenance is scheduled", StatusCode: http.StatusNotFound}
+		},
+	}
+	assert.True(t, internal_errors.IsNotFound(s.Cancel(context.Background())))
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+)
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// MediaLookupService resolves content-addressed media URLs to stored files.
+type MediaLookupService interface {
+	Locate(ctx context.Context, sha256 string, viewer *domain.User) ([]domain.MediaLocation, error)
+}
+
+// MediaLookupStorage defines storage interface for finding files by content hash
+type MediaLookupStorage interface {
+	GetMediaLocations(ctx context.Context, sha256 string) ([]domain.MediaLocation, error)
+	GetBoards(ctx context.Context) ([]domain.BoardMetadata, error)
+}
+
+type MediaLookup struct {
+	storage MediaLookupStorage
+}
+
+func NewMediaLookup(storage MediaLookupStorage) *MediaLookup {
+	return &MediaLookup{storage: storage}
+}
+
+// Locate returns the stored copies of the file with the given hash on boards
+// viewer can read. Copies the viewer cannot see are reported as not found,
+// so hashes do not reveal what was posted on restricted boards.
+func (m *MediaLookup) Locate(ctx context.Context, sha256 string, viewer *domain.User) ([]domain.MediaLocation, error) {
+	if !sha256Pattern.MatchString(sha256) {
+		return nil, &errors.ErrorWithStatusCode{Message: "Invalid file hash", StatusCode: http.StatusBadRequest}
+	}
+	locations, err := m.storage.GetMediaLocations(ctx, sha256)
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) == 0 {
+		return nil, &errors.ErrorWithStatusCode{Message: "File not found", StatusCode: http.StatusNotFound}
+	}
+
+	boards, err := m.storage.GetBoards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	readable := make(map[domain.BoardShortName]bool, len(boards))
+	for _, b := range boards {
+		readable[b.ShortName] = canView(viewer, b)
+	}
+
+	visible := locations[:0]
+	for _, loc := range locations {
+		if readable[loc.Board] {
+			visible = append(visible, loc)
+		}
+	}
+	if len(visible) == 0 {
+		return nil, &errors.ErrorWithStatusCode{Message: "File not found", StatusCode: http.StatusNotFound}
+	}
+	return visible, nil
+}
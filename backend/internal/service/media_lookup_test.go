@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockMediaLookupStorage struct {
+	getMediaLocationsFunc func(sha256 string) ([]domain.MediaLocation, error)
+	getBoardsFunc         func() ([]domain.BoardMetadata, error)
+}
+
+func (m *MockMediaLookupStorage) GetMediaLocations(ctx context.Context, sha256 string) ([]domain.MediaLocation, error) {
+	if m.getMediaLocationsFunc != nil {
+		return m.getMediaLocationsFunc(sha256)
+	}
+	return nil, nil
+}
+
+func (m *MockMediaLookupStorage) GetBoards(ctx context.Context) ([]domain.BoardMetadata, error) {
+	if m.getBoardsFunc != nil {
+		return m.getBoardsFunc()
+	}
+	return nil, nil
+}
+
+func TestMediaLookupLocate(t *testing.T) {
+	ctx := context.Background()
+	hash := strings.Repeat("ab", 32)
+	storage := &MockMediaLookupStorage{
+		getMediaLocationsFunc: func(sha256 string) ([]domain.MediaLocation, error) {
+			assert.Equal(t, hash, sha256)
+			return []domain.MediaLocation{
+				{Board: "corp", FilePath: "corp/1/a.jpg"},
+				{Board: "b", FilePath: "b/2/a.jpg"},
+			}, nil
+		},
+		getBoardsFunc: func() ([]domain.BoardMetadata, error) {
+			return []domain.BoardMetadata{
+				{ShortName: "b"},
+				{ShortName: "corp", AllowedEmailDomains: []string{"corp.com"}},
+			}, nil
+		},
+	}
+	lookup := NewMediaLookup(storage)
+
+	t.Run("copies on readable boards", func(t *testing.T) {
+		locations, err := lookup.Locate(ctx, hash, &domain.User{Id: 1, EmailDomain: "corp.com"})
+		require.NoError(t, err)
+		assert.Len(t, locations, 2)
+
+		locations, err = lookup.Locate(ctx, hash, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.MediaLocation{{Board: "b", FilePath: "b/2/a.jpg"}}, locations)
+	})
+
+	t.Run("only on restricted boards", func(t *testing.T) {
+		restricted := &MockMediaLookupStorage{
+			getMediaLocationsFunc: func(string) ([]domain.MediaLocation, error) {
+				return []domain.MediaLocation{{Board: "corp", FilePath: "corp/1/a.jpg"}}, nil
+			},
+			getBoardsFunc: storage.getBoardsFunc,
+		}
+		_, err := NewMediaLookup(restricted).Locate(ctx, hash, &domain.User{Id: 1, EmailDomain: "other.com"})
+		requireStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("unknown hash", func(t *testing.T) {
+		_, err := NewMediaLookup(&MockMediaLookupStorage{}).Locate(ctx, hash, nil)
+		requireStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("malformed hash", func(t *testing.T) {
+		_, err := lookup.Locate(ctx, "../../etc/passwd", nil)
+		requireStatus(t, err, http.StatusBadRequest)
+		_, err = lookup.Locate(ctx, strings.ToUpper(hash), nil)
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+}
@@ -38,4 +38,8 @@ type MediaStorage interface {
 
 	// DeleteBoard removes all media for an entire board.
 	DeleteBoard(boardID string) error
+
+	// RenameBoard moves all media of a board to the directory of its new
+	// short name. A board without media is not an error.
+	RenameBoard(oldBoardID, newBoardID string) error
 }
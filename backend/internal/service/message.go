@@ -2,27 +2,58 @@ package service
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"image"
 	_ "image/gif"
 	"image/jpeg"
 	_ "image/png"
+	"io"
+	"net/http"
 	"os"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	svcutils "github.com/itchan-dev/itchan/backend/internal/service/utils"
 	"github.com/itchan-dev/itchan/backend/internal/utils"
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+	sharedutils "github.com/itchan-dev/itchan/shared/utils"
 )
 
 type MessageService interface {
-	Create(creationData domain.MessageCreationData) (msgId domain.MsgId, err error)
-	Get(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error)
-	Delete(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error
+	// Create returns ErrPostQueued when the message was held for pre-moderation
+	Create(ctx context.Context, creationData domain.MessageCreationData) (msgId domain.MsgId, err error)
+	// QueueThread validates the OP of a new thread like Create and holds it
+	// with the thread for pre-moderation; it returns ErrPostQueued on success
+	QueueThread(ctx context.Context, creationData domain.MessageCreationData, thread domain.QueuedThread) error
+	// Upload stores a file ahead of the post; the post claims it with the returned token
+	Upload(ctx context.Context, board domain.BoardShortName, author domain.User, file *domain.PendingFile) (domain.PendingUpload, error)
+	Get(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error)
+	// Resolve returns the message with the given board-local post number
+	Resolve(ctx context.Context, board domain.BoardShortName, postNumber domain.PostNumber) (domain.Message, error)
+	Delete(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error
+	// DeleteOwn lets the author delete their reply within the self-delete window
+	DeleteOwn(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, author domain.UserId) error
+	// GetFileMetadata returns what sanitization stripped from the message's attachments (admin only)
+	GetFileMetadata(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error)
 }
 
+// uploadsDir is the directory under the board's media folder that holds files
+// uploaded ahead of their post.
+const uploadsDir = "uploads"
+
+const uploadTokenLength = 32
+
+// bumpLimitNotice is posted by the system account on boards with
+// BoardSettings.BumpLimitNotice once a thread stops bumping.
+const bumpLimitNotice = "Bump limit reached \u2014 thread will no longer bump"
+
 type Message struct {
 	storage      MessageStorage
 	validator    MessageValidator
@@ -31,13 +62,30 @@ type Message struct {
 }
 
 type MessageStorage interface {
-	CreateMessage(creationData domain.MessageCreationData, attachments domain.Attachments) (msgId domain.MsgId, err error)
-	GetMessage(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error)
-	DeleteMessage(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error
+	CreateMessage(ctx context.Context, creationData domain.MessageCreationData, attachments domain.Attachments) (msgId domain.MsgId, err error)
+	GetMessage(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error)
+	ResolvePostNumber(ctx context.Context, board domain.BoardShortName, postNumber domain.PostNumber) (domain.ThreadId, domain.MsgId, error)
+	DeleteMessage(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error
+	// GetLastMessageTime returns when the user last posted, or nil if they never did
+	GetLastMessageTime(ctx context.Context, userId domain.UserId) (*time.Time, error)
+	GetBoardSettings(ctx context.Context, board domain.BoardShortName) (domain.BoardSettings, error)
+	// GetThreadOpOnly reports whether only the OP may reply, and the OP's author (nil before the OP is written)
+	GetThreadOpOnly(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (opOnly bool, opAuthor *domain.UserId, err error)
+	// ClaimBumpLimitNotice reports whether the thread is past the bump limit
+	// without a notice yet; true is returned only once per thread
+	ClaimBumpLimitNotice(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (bool, error)
+	GetMessageFileMetadata(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error)
+	SavePendingUpload(ctx context.Context, upload domain.PendingUpload) error
+	GetPremodPoster(ctx context.Context, board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error)
+	QueuePost(ctx context.Context, creationData domain.MessageCreationData, thread *domain.QueuedThread, attachments domain.Attachments) (domain.QueuedPostId, error)
 }
 
 type MessageValidator interface {
-	Text(text domain.MsgText) error
+	// Text returns the text normalized for storage, or why it is rejected,
+	// counting its length for the board's language
+	Text(text domain.MsgText, language domain.BoardLanguage) (domain.MsgText, error)
+	// FilterText applies the board's handling of invisible and stacked characters
+	FilterText(text domain.MsgText, filter domain.TextFilter) (domain.MsgText, error)
 	PendingFiles(files []*domain.PendingFile) error
 }
 
@@ -50,9 +98,21 @@ func NewMessage(storage MessageStorage, validator MessageValidator, mediaStorage
 	}
 }
 
-func (b *Message) Create(creationData domain.MessageCreationData) (domain.MsgId, error) {
+func (b *Message) Create(ctx context.Context, creationData domain.MessageCreationData) (domain.MsgId, error) {
+	return b.create(ctx, creationData, nil)
+}
+
+func (b *Message) QueueThread(ctx context.Context, creationData domain.MessageCreationData, thread domain.QueuedThread) error {
+	_, err := b.create(ctx, creationData, &thread)
+	return err
+}
+
+// create writes the message, or queues it for pre-moderation when the board
+// holds the author's posts. A non-nil thread marks the OP of a thread that
+// does not exist yet, which is always queued.
+func (b *Message) create(ctx context.Context, creationData domain.MessageCreationData, thread *domain.QueuedThread) (domain.MsgId, error) {
 	// Determine what content we have
-	hasFiles := len(creationData.PendingFiles) > 0
+	hasFiles := len(creationData.PendingFiles) > 0 || len(creationData.UploadTokens) > 0
 	hasText := len(strings.TrimSpace(string(creationData.Text))) > 0
 
 	// Business rule: must have EITHER text OR files
@@ -63,15 +123,73 @@ func (b *Message) Create(creationData domain.MessageCreationData) (domain.MsgId,
 		}
 	}
 
+	// Threads reserved for their OP take replies from the OP and moderators only
+	if thread == nil && !creationData.Author.Admin {
+		opOnly, opAuthor, err := b.storage.GetThreadOpOnly(ctx, creationData.Board, creationData.ThreadId)
+		if err != nil {
+			return 0, err
+		}
+		if opOnly && opAuthor != nil && *opAuthor != creationData.Author.Id {
+			return 0, &errors.ErrorWithStatusCode{
+				Message:    "Only the thread's author can post in this thread",
+				StatusCode: http.StatusForbidden,
+			}
+		}
+	}
+
+	settings, err := b.storage.GetBoardSettings(ctx, creationData.Board)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkPostingRules(settings, creationData.Author); err != nil {
+		return 0, err
+	}
+
+	// Clients other than the frontend parser may send any reply list
+	creationData.ReplyTo = capReplies(creationData.ReplyTo, b.cfg.MaxRepliesPerMessage)
+
+	held := thread != nil
+	if !held {
+		if held, err = heldForApproval(ctx, b.storage, creationData.Board, settings, creationData.Author); err != nil {
+			return 0, err
+		}
+	}
+
+	// Accounts on probation post slower and without attachments
+	if onProbation(b.cfg, creationData.Author) {
+		if hasFiles {
+			return 0, probationForbidden(b.cfg, creationData.Author, "upload attachments")
+		}
+		lastPost, err := b.storage.GetLastMessageTime(ctx, creationData.Author.Id)
+		if err != nil {
+			return 0, err
+		}
+		if err := probationCooldown(b.cfg, lastPost); err != nil {
+			return 0, err
+		}
+	}
+
 	// Validate text only if text is provided
 	if hasText {
-		if err := b.validator.Text(creationData.Text); err != nil {
+		text, err := b.validator.Text(creationData.Text, settings.Language)
+		if err != nil {
 			return 0, err
 		}
+		if text, err = b.validator.FilterText(text, settings.TextFilter); err != nil {
+			return 0, err
+		}
+		// Nothing may be left once invisible characters are stripped
+		if strings.TrimSpace(text) == "" && !hasFiles {
+			return 0, &errors.ErrorWithStatusCode{
+				Message:    "message must contain either text or attachments",
+				StatusCode: 400,
+			}
+		}
+		creationData.Text = text
 	}
 
 	// Validate files only if files are provided
-	if hasFiles {
+	if len(creationData.PendingFiles) > 0 {
 		if err := b.validator.PendingFiles(creationData.PendingFiles); err != nil {
 			return 0, err
 		}
@@ -80,19 +198,41 @@ func (b *Message) Create(creationData domain.MessageCreationData) (domain.MsgId,
 	var attachments domain.Attachments
 	var savedFiles []string
 
-	if len(creationData.PendingFiles) > 0 {
-		var err error
+	if hasFiles {
+		settings, err := b.uploadSettings(ctx, creationData.Board, creationData.PendingFiles, len(creationData.UploadTokens))
+		if err != nil {
+			return 0, err
+		}
+		// Files of a queued post wait with the uploads until it is approved
+		dir := fmt.Sprintf("%d", creationData.ThreadId)
+		if held {
+			dir = uploadsDir
+		}
 		attachments, savedFiles, err = b.processAndSaveFiles(
 			creationData.Board,
-			creationData.ThreadId,
+			dir,
 			creationData.PendingFiles,
+			settings,
 		)
 		if err != nil {
 			return 0, err // No DB pollution if file processing fails
 		}
 	}
 
-	msgID, err := b.storage.CreateMessage(creationData, attachments)
+	if held {
+		queueId, err := b.storage.QueuePost(ctx, creationData, thread, attachments)
+		if err != nil {
+			for _, path := range savedFiles {
+				b.mediaStorage.DeleteFile(path)
+			}
+			return 0, err
+		}
+		logger.Log.Info("post queued for approval", "board", creationData.Board, "queue_id", queueId, "user_id", creationData.Author.Id)
+		return 0, ErrPostQueued
+	}
+
+	// Uploaded files stay pending if this fails, so the post can be retried
+	msgID, err := b.storage.CreateMessage(ctx, creationData, attachments)
 	if err != nil {
 		for _, path := range savedFiles {
 			b.mediaStorage.DeleteFile(path)
@@ -100,25 +240,168 @@ func (b *Message) Create(creationData domain.MessageCreationData) (domain.MsgId,
 		return 0, err
 	}
 
+	if settings.BumpLimitNotice {
+		b.postBumpLimitNotice(ctx, creationData.Board, creationData.ThreadId)
+	}
+
 	return msgID, nil
 }
 
+// capReplies drops repeated reply targets and keeps the first limit replies,
+// the same ones the markdown parser links as replies. A limit of zero keeps
+// every distinct target.
+func capReplies(replies *domain.Replies, limit int) *domain.Replies {
+	if replies == nil {
+		return nil
+	}
+	type target struct {
+		thread domain.ThreadId
+		msg    domain.MsgId
+	}
+	seen := make(map[target]struct{}, len(*replies))
+	capped := make(domain.Replies, 0, len(*replies))
+	for _, reply := range *replies {
+		if limit > 0 && len(capped) >= limit {
+			break
+		}
+		if reply == nil {
+			continue
+		}
+		key := target{reply.ToThreadId, reply.To}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		capped = append(capped, reply)
+	}
+	return &capped
+}
+
+// postBumpLimitNotice posts the bump limit notice as the system account if
+// the thread just went past the limit. The reply that got it there is already
+// stored, so failures are only logged.
+func (b *Message) postBumpLimitNotice(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) {
+	due, err := b.storage.ClaimBumpLimitNotice(ctx, board, threadId)
+	if err != nil {
+		logger.Log.Error("checking bump limit notice", "board", board, "thread_id", threadId, "error", err)
+		return
+	}
+	if !due {
+		return
+	}
+
+	_, err = b.storage.CreateMessage(ctx, domain.MessageCreationData{
+		Board:    board,
+		ThreadId: threadId,
+		Author:   domain.User{Id: domain.SystemUserId},
+		Text:     bumpLimitNotice,
+	}, nil)
+	if err != nil {
+		logger.Log.Error("posting bump limit notice", "board", board, "thread_id", threadId, "error", err)
+	}
+}
+
+// Upload sanitizes and stores a file before the post carrying it is written,
+// under the same rules as attachments sent with the post. The file stays
+// pending until a post on the board by the same author claims its token, or
+// until the media GC drops it after UploadTTL.
+func (b *Message) Upload(ctx context.Context, board domain.BoardShortName, author domain.User, file *domain.PendingFile) (domain.PendingUpload, error) {
+	if onProbation(b.cfg, author) {
+		return domain.PendingUpload{}, probationForbidden(b.cfg, author, "upload attachments")
+	}
+
+	files := []*domain.PendingFile{file}
+	if err := b.validator.PendingFiles(files); err != nil {
+		return domain.PendingUpload{}, err
+	}
+	settings, err := b.uploadSettings(ctx, board, files, 0)
+	if err != nil {
+		return domain.PendingUpload{}, err
+	}
+	attachments, savedFiles, err := b.processAndSaveFiles(board, uploadsDir, files, settings)
+	if err != nil {
+		return domain.PendingUpload{}, err
+	}
+
+	upload := domain.PendingUpload{
+		Token:     sharedutils.GenerateRandomString(uploadTokenLength, "abcdefghijklmnopqrstuvwxyz0123456789"),
+		Board:     board,
+		UserId:    author.Id,
+		File:      attachments[0].File,
+		CreatedAt: time.Now(),
+	}
+	if err := b.storage.SavePendingUpload(ctx, upload); err != nil {
+		for _, path := range savedFiles {
+			b.mediaStorage.DeleteFile(path)
+		}
+		return domain.PendingUpload{}, err
+	}
+	return upload, nil
+}
+
+// uploadSettings returns the board settings that decide how files are
+// processed, after checking the files against the board's upload rules.
+// Uploads claimed by token were checked when uploaded and only count toward
+// the attachment limit.
+func (b *Message) uploadSettings(ctx context.Context, board domain.BoardShortName, pendingFiles []*domain.PendingFile, claimed int) (domain.BoardSettings, error) {
+	settings, err := b.storage.GetBoardSettings(ctx, board)
+	if err != nil {
+		return domain.BoardSettings{}, err
+	}
+
+	maxAttachments := settings.MaxAttachmentsOr(b.cfg.MaxAttachmentsPerMessage)
+	if maxAttachments == 0 {
+		return domain.BoardSettings{}, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("/%s/ does not accept attachments", board),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	if len(pendingFiles)+claimed > maxAttachments {
+		return domain.BoardSettings{}, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("too many attachments: max %d allowed", maxAttachments),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	for _, f := range pendingFiles {
+		if f.IsDocument() && !settings.AllowDocuments {
+			return domain.BoardSettings{}, &errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("/%s/ does not accept PDF or text attachments", board),
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+		if !settings.AcceptsMimeType(f.MimeType) {
+			return domain.BoardSettings{}, &errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("/%s/ does not accept %s files", board, f.MimeType),
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+	}
+	return settings, nil
+}
+
+// processAndSaveFiles sanitizes the files and saves them under the board's
+// dir, a thread ID or uploadsDir.
 func (b *Message) processAndSaveFiles(
 	board domain.BoardShortName,
-	threadID domain.ThreadId,
+	dir string,
 	pendingFiles []*domain.PendingFile,
+	settings domain.BoardSettings,
 ) (domain.Attachments, []string, error) {
 	var attachments domain.Attachments
 	savedFiles := make([]string, 0) // Track for cleanup on error
+	videoProfile := b.cfg.Media.VideoProfileFor(settings.VideoProfile)
 
 	for _, pendingFile := range pendingFiles {
 		var filePath string
 		var sanitizedMetadata domain.FileCommonMetadata
 		var thumbnailPath *string
+		var textPreview string
+		var strippedMetadata *domain.StrippedMetadata
 
 		if pendingFile.IsVideo() {
 			// Video: Sanitize + extract thumbnail in one ffmpeg pass, then move
-			sanitizedVideo, err := svcutils.SanitizeVideo(pendingFile, b.cfg.Media.ThumbnailMaxSize)
+			sanitizedVideo, err := svcutils.SanitizeVideo(pendingFile, b.cfg.Media.ThumbnailMaxSize, videoProfile)
 			if err != nil {
 				// Cleanup saved files
 				for _, p := range savedFiles {
@@ -131,7 +414,7 @@ func (b *Message) processAndSaveFiles(
 			filePath, err = b.mediaStorage.MoveFile(
 				sanitizedVideo.TempFilePath,
 				string(board),
-				fmt.Sprintf("%d", threadID),
+				dir,
 				sanitizedVideo.Filename,
 			)
 			if err != nil {
@@ -172,7 +455,7 @@ func (b *Message) processAndSaveFiles(
 				sanitizedImage.Image.(image.Image),
 				sanitizedImage.Format,
 				string(board),
-				fmt.Sprintf("%d", threadID),
+				dir,
 				sanitizedImage.Filename,
 			)
 			if err != nil {
@@ -184,20 +467,51 @@ func (b *Message) processAndSaveFiles(
 			// Track saved file immediately after saving
 			savedFiles = append(savedFiles, filePath)
 			sanitizedMetadata = sanitizedImage.FileCommonMetadata
+			strippedMetadata = sanitizedImage.Metadata
 			// Update size with actual encoded size
 			sanitizedMetadata.SizeBytes = imageSize
 
 			// Generate thumbnail from the SAME decoded image (no re-decode!)
-			thumbnail := utils.GenerateThumbnail(sanitizedImage.Image.(image.Image), b.cfg.Media.ThumbnailMaxSize)
-			var thumbBuf bytes.Buffer
-			if err := jpeg.Encode(&thumbBuf, thumbnail, &jpeg.Options{Quality: b.cfg.Media.JpegQualityThumbnail}); err == nil {
-				thumbPath, err := b.mediaStorage.SaveThumbnail(&thumbBuf, filePath)
-				if err == nil {
+			if thumbPath, err := b.saveThumbnail(sanitizedImage.Image.(image.Image), filePath); err == nil {
+				savedFiles = append(savedFiles, thumbPath)
+				thumbnailPath = &thumbPath
+			}
+			// Note: We don't fail the upload if thumbnail generation fails
+
+		} else if pendingFile.IsDocument() {
+			// PDF or text: sanitize to a temp file, then move
+			sanitizedDoc, err := svcutils.SanitizeDocument(pendingFile, b.cfg.Media.TextPreviewChars)
+			if err != nil {
+				for _, p := range savedFiles {
+					b.mediaStorage.DeleteFile(p)
+				}
+				return nil, nil, err
+			}
+
+			filePath, err = b.mediaStorage.MoveFile(
+				sanitizedDoc.TempFilePath,
+				string(board),
+				dir,
+				sanitizedDoc.Filename,
+			)
+			if err != nil {
+				os.Remove(sanitizedDoc.TempFilePath)
+				for _, p := range savedFiles {
+					b.mediaStorage.DeleteFile(p)
+				}
+				return nil, nil, fmt.Errorf("failed to move document file: %w", err)
+			}
+			savedFiles = append(savedFiles, filePath)
+			sanitizedMetadata = sanitizedDoc.FileCommonMetadata
+			textPreview = sanitizedDoc.TextPreview
+
+			// PDFs get their first page as thumbnail
+			if page, ok := sanitizedDoc.FirstPage.(image.Image); ok {
+				if thumbPath, err := b.saveThumbnail(page, filePath); err == nil {
 					savedFiles = append(savedFiles, thumbPath)
 					thumbnailPath = &thumbPath
 				}
 			}
-			// Note: We don't fail the upload if thumbnail generation fails
 
 		} else {
 			// Unsupported file type (should not happen if validation is correct)
@@ -211,6 +525,12 @@ func (b *Message) processAndSaveFiles(
 			OriginalFilename:   pendingFile.Filename,
 			OriginalMimeType:   pendingFile.MimeType,
 			ThumbnailPath:      thumbnailPath,
+			Sha256:             b.hashStoredFile(filePath),
+			TextPreview:        textPreview,
+			StrippedMetadata:   strippedMetadata,
+		}
+		if thumbnailPath != nil {
+			fileData.ThumbnailSha256 = b.hashStoredFile(*thumbnailPath)
 		}
 
 		// Create attachment (MessageId will be set by storage layer)
@@ -225,28 +545,132 @@ func (b *Message) processAndSaveFiles(
 	return attachments, savedFiles, nil
 }
 
-func (b *Message) Get(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
-	message, err := b.storage.GetMessage(board, threadId, id)
+// saveThumbnail scales img down to the thumbnail size and saves it as JPEG
+// next to filePath.
+func (b *Message) saveThumbnail(img image.Image, filePath string) (string, error) {
+	thumbnail := utils.GenerateThumbnail(img, b.cfg.Media.ThumbnailMaxSize)
+	var thumbBuf bytes.Buffer
+	if err := jpeg.Encode(&thumbBuf, thumbnail, &jpeg.Options{Quality: b.cfg.Media.JpegQualityThumbnail}); err != nil {
+		return "", err
+	}
+	return b.mediaStorage.SaveThumbnail(&thumbBuf, filePath)
+}
+
+// hashStoredFile returns the hex SHA-256 of a saved file for its
+// content-addressed URL. On failure the file keeps its path-based URL.
+func (b *Message) hashStoredFile(filePath string) string {
+	f, err := b.mediaStorage.Read(filePath)
+	if err != nil {
+		logger.Log.Warn("failed to open file for hashing", "path", filePath, "error", err)
+		return ""
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		logger.Log.Warn("failed to hash file", "path", filePath, "error", err)
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (b *Message) Get(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+	message, err := b.storage.GetMessage(ctx, board, threadId, id)
 	if err != nil {
 		return domain.Message{}, err
 	}
 	return message, nil
 }
 
-func (b *Message) Delete(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error {
+// Resolve looks up a message by its board-local post number. Post numbers
+// start at 1, so anything lower is rejected before reaching storage.
+func (b *Message) Resolve(ctx context.Context, board domain.BoardShortName, postNumber domain.PostNumber) (domain.Message, error) {
+	if postNumber < 1 {
+		return domain.Message{}, &errors.ErrorWithStatusCode{Message: "Invalid post number", StatusCode: http.StatusBadRequest}
+	}
+	threadId, id, err := b.storage.ResolvePostNumber(ctx, board, postNumber)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	return b.storage.GetMessage(ctx, board, threadId, id)
+}
+
+// GetFileMetadata fails with 404 for unknown messages, and returns an empty
+// list when none of the attachments carried metadata.
+func (b *Message) GetFileMetadata(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error) {
+	if _, err := b.storage.GetMessage(ctx, board, threadId, id); err != nil {
+		return nil, err
+	}
+	return b.storage.GetMessageFileMetadata(ctx, board, threadId, id)
+}
+
+func (b *Message) Delete(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
+	deletion.Reason = strings.TrimSpace(deletion.Reason)
+	if utf8.RuneCountInString(deletion.Reason) > b.cfg.DeletionReasonMaxLen {
+		return &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Deletion reason must be at most %d characters", b.cfg.DeletionReasonMaxLen),
+			StatusCode: 400,
+		}
+	}
+
 	// First, get the message to find its attachments
-	msg, err := b.storage.GetMessage(board, threadId, id)
+	msg, err := b.storage.GetMessage(ctx, board, threadId, id)
 	if err != nil {
 		return err
 	}
+	return b.deleteMessage(ctx, board, threadId, id, msg.Attachments, deletion)
+}
+
+// DeleteOwn deletes a reply on its author's request. OP messages are excluded
+// (they carry the thread), and replied-to posts only go on boards that allow it.
+func (b *Message) DeleteOwn(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, author domain.UserId) error {
+	window := b.cfg.SelfDeleteWindow
+	if window <= 0 {
+		return &errors.ErrorWithStatusCode{Message: "Deleting your own posts is disabled", StatusCode: http.StatusForbidden}
+	}
+
+	msg, err := b.storage.GetMessage(ctx, board, threadId, id)
+	if err != nil {
+		return err
+	}
+	if msg.Author.Id != author {
+		return &errors.ErrorWithStatusCode{Message: "You can only delete your own posts", StatusCode: http.StatusForbidden}
+	}
+	if msg.IsOp() {
+		return &errors.ErrorWithStatusCode{Message: "The opening post cannot be deleted", StatusCode: http.StatusForbidden}
+	}
+	if time.Since(msg.CreatedAt) > window {
+		return &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Posts can only be deleted within %s of posting", window),
+			StatusCode: http.StatusForbidden,
+		}
+	}
+	if len(msg.Replies) > 0 {
+		settings, err := b.storage.GetBoardSettings(ctx, board)
+		if err != nil {
+			return err
+		}
+		if !settings.SelfDeleteReplied {
+			return &errors.ErrorWithStatusCode{Message: "Posts that already have replies cannot be deleted", StatusCode: http.StatusConflict}
+		}
+	}
+
+	if err := b.deleteMessage(ctx, board, threadId, id, msg.Attachments, domain.MessageDeletionData{DeletedBy: &author, ByAuthor: true}); err != nil {
+		return err
+	}
+	logger.Log.Info("message deleted by its author", "board", board, "thread_id", threadId, "message_id", id, "user_id", author)
+	return nil
+}
 
+// deleteMessage removes the message from storage and then its files from the media storage.
+func (b *Message) deleteMessage(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, attachments domain.Attachments, deletion domain.MessageDeletionData) error {
 	// Delete the message from storage (DB will cascade delete attachments records)
-	err = b.storage.DeleteMessage(board, threadId, id)
+	err := b.storage.DeleteMessage(ctx, board, threadId, id, deletion)
 	if err != nil {
 		return err
 	}
 
-	for _, attachment := range msg.Attachments {
+	for _, attachment := range attachments {
 		if attachment.File != nil {
 			if err := b.mediaStorage.DeleteFile(attachment.File.FilePath); err != nil {
 				// Best effort: log errors but don't fail the operation
@@ -2,12 +2,15 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"image"
 	"io"
+	"net/http"
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
@@ -48,6 +51,7 @@ type SharedMockMediaStorage struct {
 	deleteFileFunc   func(filePath string) error
 	deleteThreadFunc func(boardID, threadID string) error
 	deleteBoardFunc  func(boardID string) error
+	renameBoardFunc  func(oldBoardID, newBoardID string) error
 
 	mu                sync.Mutex
 	saveFileCalls     []SaveFileCall
@@ -183,6 +187,13 @@ func (m *SharedMockMediaStorage) DeleteBoard(boardID string) error {
 	return nil
 }
 
+func (m *SharedMockMediaStorage) RenameBoard(oldBoardID, newBoardID string) error {
+	if m.renameBoardFunc != nil {
+		return m.renameBoardFunc(oldBoardID, newBoardID)
+	}
+	return nil
+}
+
 // --- Helper Functions ---
 
 func createTestConfig() *config.Public {
@@ -229,7 +240,7 @@ func TestValidatePendingFiles(t *testing.T) {
 			},
 		}
 
-		_, err := service.Create(creationData)
+		_, err := service.Create(context.Background(), creationData)
 		assert.NoError(t, err)
 	})
 
@@ -261,7 +272,7 @@ func TestValidatePendingFiles(t *testing.T) {
 			PendingFiles: files,
 		}
 
-		_, err := service.Create(creationData)
+		_, err := service.Create(context.Background(), creationData)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "too many attachments")
 	})
@@ -291,7 +302,7 @@ func TestValidatePendingFiles(t *testing.T) {
 			},
 		}
 
-		_, err := service.Create(creationData)
+		_, err := service.Create(context.Background(), creationData)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "unsupported file type")
 	})
@@ -321,7 +332,7 @@ func TestValidatePendingFiles(t *testing.T) {
 			},
 		}
 
-		_, err := service.Create(creationData)
+		_, err := service.Create(context.Background(), creationData)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "file too large")
 	})
@@ -371,7 +382,7 @@ func TestValidatePendingFiles(t *testing.T) {
 			},
 		}
 
-		_, err := service.Create(creationData)
+		_, err := service.Create(context.Background(), creationData)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "total attachments size too large")
 	})
@@ -401,7 +412,7 @@ func TestValidatePendingFiles(t *testing.T) {
 				},
 			}
 
-			_, err := service.Create(creationData)
+			_, err := service.Create(context.Background(), creationData)
 			assert.NoError(t, err, "Should accept "+mimeType)
 		}
 	})
@@ -432,7 +443,7 @@ func TestValidatePendingFiles(t *testing.T) {
 			},
 		}
 
-		_, err := service.Create(creationData)
+		_, err := service.Create(context.Background(), creationData)
 		assert.NoError(t, err, "Should accept "+mimeType)
 	})
 }
@@ -483,7 +494,7 @@ func TestCreateMessageWithFiles(t *testing.T) {
 			},
 		}
 
-		msgID, err := service.Create(creationData)
+		msgID, err := service.Create(context.Background(), creationData)
 
 		require.NoError(t, err)
 		assert.Equal(t, createdMessageID, msgID)
@@ -534,7 +545,7 @@ func TestCreateMessageWithFiles(t *testing.T) {
 			},
 		}
 
-		_, err := service.Create(creationData)
+		_, err := service.Create(context.Background(), creationData)
 
 		require.Error(t, err)
 		assert.True(t, errors.Is(err, createMessageError), "Should return the CreateMessage error")
@@ -601,7 +612,7 @@ func TestCreateMessageWithFiles(t *testing.T) {
 			},
 		}
 
-		_, err := service.Create(creationData)
+		_, err := service.Create(context.Background(), creationData)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to save image file")
@@ -649,7 +660,7 @@ func TestCreateMessageWithFiles(t *testing.T) {
 			},
 		}
 
-		_, err := service.Create(creationData)
+		_, err := service.Create(context.Background(), creationData)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "file too large")
@@ -666,6 +677,152 @@ func TestCreateMessageWithFiles(t *testing.T) {
 	})
 }
 
+func TestMessageUpload(t *testing.T) {
+	image := func(t *testing.T) *domain.PendingFile {
+		data := loadTestImage(t)
+		return &domain.PendingFile{
+			FileCommonMetadata: domain.FileCommonMetadata{Filename: "image.jpg", SizeBytes: int64(len(data)), MimeType: "image/jpeg"},
+			Data:               bytes.NewReader(data),
+		}
+	}
+
+	t.Run("saves the sanitized file under a new token", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		mediaStorage := &SharedMockMediaStorage{}
+		var saved domain.PendingUpload
+		storage.savePendingUploadFunc = func(upload domain.PendingUpload) error {
+			saved = upload
+			return nil
+		}
+		service := NewMessage(storage, &MockMessageValidator{}, mediaStorage, createTestConfig())
+
+		upload, err := service.Upload(context.Background(), "tech", domain.User{Id: 7}, image(t))
+		require.NoError(t, err)
+
+		assert.Len(t, upload.Token, uploadTokenLength)
+		assert.Equal(t, saved, upload)
+		assert.Equal(t, domain.BoardShortName("tech"), upload.Board)
+		assert.Equal(t, domain.UserId(7), upload.UserId)
+		require.NotNil(t, upload.File)
+		assert.Equal(t, "tech/uploads/image.jpg", upload.File.FilePath)
+		assert.Equal(t, "image.jpg", upload.File.OriginalFilename)
+
+		mediaStorage.mu.Lock()
+		require.Len(t, mediaStorage.saveImageCalls, 1)
+		assert.Equal(t, uploadsDir, mediaStorage.saveImageCalls[0].ThreadID)
+		mediaStorage.mu.Unlock()
+	})
+
+	t.Run("tokens are unique", func(t *testing.T) {
+		service := NewMessage(&MockMessageStorage{}, &MockMessageValidator{}, &SharedMockMediaStorage{}, createTestConfig())
+
+		first, err := service.Upload(context.Background(), "tech", domain.User{Id: 7}, image(t))
+		require.NoError(t, err)
+		second, err := service.Upload(context.Background(), "tech", domain.User{Id: 7}, image(t))
+		require.NoError(t, err)
+		assert.NotEqual(t, first.Token, second.Token)
+	})
+
+	t.Run("accounts on probation can't upload", func(t *testing.T) {
+		cfg := createTestConfig()
+		cfg.NewAccountAge = 24 * time.Hour
+		mediaStorage := &SharedMockMediaStorage{}
+		service := NewMessage(&MockMessageStorage{}, &MockMessageValidator{}, mediaStorage, cfg)
+
+		_, err := service.Upload(context.Background(), "tech", domain.User{Id: 7, CreatedAt: time.Now()}, image(t))
+		requireStatus(t, err, http.StatusForbidden)
+		assert.Empty(t, mediaStorage.saveImageCalls)
+	})
+
+	t.Run("board upload rules apply", func(t *testing.T) {
+		limit := 0
+		storage := &MockMessageStorage{
+			getBoardSettingsFunc: func(board domain.BoardShortName) (domain.BoardSettings, error) {
+				return domain.BoardSettings{UploadRules: domain.UploadRules{MaxAttachments: &limit}}, nil
+			},
+		}
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createTestConfig())
+
+		_, err := service.Upload(context.Background(), "tech", domain.User{Id: 7}, image(t))
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("removes the file when it can't be recorded", func(t *testing.T) {
+		storage := &MockMessageStorage{
+			savePendingUploadFunc: func(upload domain.PendingUpload) error {
+				return errors.New("database error")
+			},
+		}
+		mediaStorage := &SharedMockMediaStorage{}
+		service := NewMessage(storage, &MockMessageValidator{}, mediaStorage, createTestConfig())
+
+		_, err := service.Upload(context.Background(), "tech", domain.User{Id: 7}, image(t))
+		require.Error(t, err)
+
+		mediaStorage.mu.Lock()
+		assert.Len(t, mediaStorage.deleteFileCalls, 2, "Image and thumbnail should have been deleted")
+		mediaStorage.mu.Unlock()
+	})
+}
+
+func TestCreateMessageWithUploadTokens(t *testing.T) {
+	t.Run("tokens alone make a message", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		mediaStorage := &SharedMockMediaStorage{}
+		service := NewMessage(storage, &MockMessageValidator{}, mediaStorage, createTestConfig())
+
+		_, err := service.Create(context.Background(), domain.MessageCreationData{
+			Board:        "tech",
+			ThreadId:     1,
+			Author:       domain.User{Id: 1},
+			UploadTokens: []domain.UploadToken{"a", "b"},
+		})
+		require.NoError(t, err)
+
+		storage.mu.Lock()
+		assert.Equal(t, []domain.UploadToken{"a", "b"}, storage.createMessageArg.UploadTokens)
+		assert.Empty(t, storage.createMessageAttachments, "Uploads are linked by storage, not saved again")
+		storage.mu.Unlock()
+		assert.Empty(t, mediaStorage.saveImageCalls)
+	})
+
+	t.Run("tokens count toward the attachment limit", func(t *testing.T) {
+		data := loadTestImage(t)
+		service := NewMessage(&MockMessageStorage{}, &MockMessageValidator{}, &SharedMockMediaStorage{}, createTestConfig())
+
+		_, err := service.Create(context.Background(), domain.MessageCreationData{
+			Board:        "tech",
+			ThreadId:     1,
+			Author:       domain.User{Id: 1},
+			UploadTokens: []domain.UploadToken{"a", "b", "c", "d"},
+			PendingFiles: []*domain.PendingFile{{
+				FileCommonMetadata: domain.FileCommonMetadata{Filename: "image.jpg", SizeBytes: int64(len(data)), MimeType: "image/jpeg"},
+				Data:               bytes.NewReader(data),
+			}},
+		})
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("uploads are kept when the message fails", func(t *testing.T) {
+		storage := &MockMessageStorage{
+			createMessageFunc: func(creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
+				return 0, errors.New("database error")
+			},
+		}
+		mediaStorage := &SharedMockMediaStorage{}
+		service := NewMessage(storage, &MockMessageValidator{}, mediaStorage, createTestConfig())
+
+		_, err := service.Create(context.Background(), domain.MessageCreationData{
+			Board:        "tech",
+			ThreadId:     1,
+			Author:       domain.User{Id: 1},
+			UploadTokens: []domain.UploadToken{"a"},
+		})
+		require.Error(t, err)
+		assert.Empty(t, mediaStorage.deleteFileCalls, "Uploaded files stay for a retry")
+	})
+}
+
 func TestMessageDeleteWithAttachments(t *testing.T) {
 	t.Run("deletes message and all attachment files", func(t *testing.T) {
 		cfg := createTestConfig()
@@ -698,7 +855,7 @@ func TestMessageDeleteWithAttachments(t *testing.T) {
 
 		service := NewMessage(storage, validator, mediaStorage, cfg)
 
-		err := service.Delete("tech", 1, 1)
+		err := service.Delete(context.Background(), "tech", 1, 1, domain.MessageDeletionData{})
 		require.NoError(t, err)
 
 		// Verify message was deleted
@@ -746,7 +903,7 @@ func TestMessageDeleteWithAttachments(t *testing.T) {
 		service := NewMessage(storage, validator, mediaStorage, cfg)
 
 		// Should not error despite file deletion failure
-		err := service.Delete("tech", 1, 1)
+		err := service.Delete(context.Background(), "tech", 1, 1, domain.MessageDeletionData{})
 		assert.NoError(t, err)
 
 		// Message should still be deleted
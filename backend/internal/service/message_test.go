@@ -2,9 +2,12 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"net/http"
 	"sync" // Used for tracking calls in mocks safely in parallel tests
 	"testing"
+	"time"
 
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
@@ -23,6 +26,7 @@ func createDefaultTestConfig() *config.Public {
 		MaxDecodedImageSize:      20 * 1024 * 1024,
 		AllowedImageMimeTypes:    []string{"image/jpeg", "image/png", "image/gif"},
 		AllowedVideoMimeTypes:    []string{"video/mp4", "video/webm"},
+		DeletionReasonMaxLen:     200,
 	}
 }
 
@@ -30,9 +34,18 @@ func createDefaultTestConfig() *config.Public {
 
 // MockMessageStorage mocks the MessageStorage interface.
 type MockMessageStorage struct {
-	createMessageFunc func(creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error)
-	getMessageFunc    func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error)
-	deleteMessageFunc func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error
+	createMessageFunc      func(creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error)
+	getMessageFunc         func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error)
+	resolvePostNumberFunc  func(board domain.BoardShortName, postNumber domain.PostNumber) (domain.ThreadId, domain.MsgId, error)
+	deleteMessageFunc      func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error
+	getLastMessageTimeFunc func(userId domain.UserId) (*time.Time, error)
+	getBoardSettingsFunc   func(board domain.BoardShortName) (domain.BoardSettings, error)
+	getThreadOpOnlyFunc    func(board domain.BoardShortName, threadId domain.ThreadId) (bool, *domain.UserId, error)
+	claimBumpLimitFunc     func(board domain.BoardShortName, threadId domain.ThreadId) (bool, error)
+	getFileMetadataFunc    func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error)
+	savePendingUploadFunc  func(upload domain.PendingUpload) error
+	getPremodPosterFunc    func(board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error)
+	queuePostFunc          func(creationData domain.MessageCreationData, thread *domain.QueuedThread, attachments domain.Attachments) (domain.QueuedPostId, error)
 
 	mu                       sync.Mutex
 	createMessageCalled      bool
@@ -45,6 +58,7 @@ type MockMessageStorage struct {
 	deleteMessageArgBoard    domain.BoardShortName
 	deleteMessageArgThreadId domain.ThreadId
 	deleteMessageArgId       domain.MsgId
+	deleteMessageArgDeletion domain.MessageDeletionData
 }
 
 func (m *MockMessageStorage) ResetCallTracking() {
@@ -60,9 +74,10 @@ func (m *MockMessageStorage) ResetCallTracking() {
 	m.deleteMessageArgBoard = ""
 	m.deleteMessageArgThreadId = 0
 	m.deleteMessageArgId = 0
+	m.deleteMessageArgDeletion = domain.MessageDeletionData{}
 }
 
-func (m *MockMessageStorage) CreateMessage(creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
+func (m *MockMessageStorage) CreateMessage(ctx context.Context, creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
 	m.mu.Lock()
 	m.createMessageCalled = true
 	m.createMessageArg = creationData
@@ -76,7 +91,7 @@ func (m *MockMessageStorage) CreateMessage(creationData domain.MessageCreationDa
 	return 1, nil
 }
 
-func (m *MockMessageStorage) GetMessage(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+func (m *MockMessageStorage) GetMessage(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
 	m.mu.Lock()
 	m.getMessageCalled = true
 	m.getMessageArgThreadId = threadId
@@ -90,12 +105,13 @@ func (m *MockMessageStorage) GetMessage(board domain.BoardShortName, threadId do
 	return domain.Message{MessageMetadata: domain.MessageMetadata{Id: id, ThreadId: threadId}}, nil
 }
 
-func (m *MockMessageStorage) DeleteMessage(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error {
+func (m *MockMessageStorage) DeleteMessage(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
 	m.mu.Lock()
 	m.deleteMessageCalled = true
 	m.deleteMessageArgBoard = board
 	m.deleteMessageArgThreadId = threadId
 	m.deleteMessageArgId = id
+	m.deleteMessageArgDeletion = deletion
 	m.mu.Unlock()
 
 	if m.deleteMessageFunc != nil {
@@ -104,17 +120,88 @@ func (m *MockMessageStorage) DeleteMessage(board domain.BoardShortName, threadId
 	return nil // Default success
 }
 
+func (m *MockMessageStorage) GetLastMessageTime(ctx context.Context, userId domain.UserId) (*time.Time, error) {
+	if m.getLastMessageTimeFunc != nil {
+		return m.getLastMessageTimeFunc(userId)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStorage) GetBoardSettings(ctx context.Context, board domain.BoardShortName) (domain.BoardSettings, error) {
+	if m.getBoardSettingsFunc != nil {
+		return m.getBoardSettingsFunc(board)
+	}
+	return domain.BoardSettings{}, nil
+}
+
+func (m *MockMessageStorage) GetThreadOpOnly(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (bool, *domain.UserId, error) {
+	if m.getThreadOpOnlyFunc != nil {
+		return m.getThreadOpOnlyFunc(board, threadId)
+	}
+	return false, nil, nil
+}
+
+func (m *MockMessageStorage) ClaimBumpLimitNotice(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+	if m.claimBumpLimitFunc != nil {
+		return m.claimBumpLimitFunc(board, threadId)
+	}
+	return false, nil
+}
+
+func (m *MockMessageStorage) GetMessageFileMetadata(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error) {
+	if m.getFileMetadataFunc != nil {
+		return m.getFileMetadataFunc(board, threadId, id)
+	}
+	return []domain.FileMetadataReport{}, nil
+}
+
+func (m *MockMessageStorage) ResolvePostNumber(ctx context.Context, board domain.BoardShortName, postNumber domain.PostNumber) (domain.ThreadId, domain.MsgId, error) {
+	if m.resolvePostNumberFunc != nil {
+		return m.resolvePostNumberFunc(board, postNumber)
+	}
+	return 0, 0, nil
+}
+
+func (m *MockMessageStorage) SavePendingUpload(ctx context.Context, upload domain.PendingUpload) error {
+	if m.savePendingUploadFunc != nil {
+		return m.savePendingUploadFunc(upload)
+	}
+	return nil
+}
+
+func (m *MockMessageStorage) GetPremodPoster(ctx context.Context, board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error) {
+	if m.getPremodPosterFunc != nil {
+		return m.getPremodPosterFunc(board, userId)
+	}
+	return domain.PremodPoster{}, nil
+}
+
+func (m *MockMessageStorage) QueuePost(ctx context.Context, creationData domain.MessageCreationData, thread *domain.QueuedThread, attachments domain.Attachments) (domain.QueuedPostId, error) {
+	if m.queuePostFunc != nil {
+		return m.queuePostFunc(creationData, thread, attachments)
+	}
+	return 1, nil
+}
+
 // MockMessageValidator mocks the MessageValidator interface.
 type MockMessageValidator struct {
 	textFunc         func(text domain.MsgText) error
 	pendingFilesFunc func(files []*domain.PendingFile) error
+	filterTextFunc   func(text domain.MsgText, filter domain.TextFilter) (domain.MsgText, error)
 }
 
-func (m *MockMessageValidator) Text(text domain.MsgText) error {
+func (m *MockMessageValidator) Text(text domain.MsgText, language domain.BoardLanguage) (domain.MsgText, error) {
 	if m.textFunc != nil {
-		return m.textFunc(text)
+		return text, m.textFunc(text)
 	}
-	return nil // Default valid
+	return text, nil // Default valid
+}
+
+func (m *MockMessageValidator) FilterText(text domain.MsgText, filter domain.TextFilter) (domain.MsgText, error) {
+	if m.filterTextFunc != nil {
+		return m.filterTextFunc(text, filter)
+	}
+	return text, nil // Default leaves the text as is
 }
 
 func (m *MockMessageValidator) PendingFiles(files []*domain.PendingFile) error {
@@ -156,7 +243,7 @@ func TestMessageCreate(t *testing.T) {
 		}
 
 		// Act
-		createdId, err := service.Create(testCreationData)
+		createdId, err := service.Create(context.Background(), testCreationData)
 
 		// Assert
 		require.NoError(t, err)
@@ -192,7 +279,7 @@ func TestMessageCreate(t *testing.T) {
 		}
 
 		// Act
-		createdId, err := service.Create(creationDataWithDomain)
+		createdId, err := service.Create(context.Background(), creationDataWithDomain)
 
 		// Assert
 		require.NoError(t, err)
@@ -222,7 +309,7 @@ func TestMessageCreate(t *testing.T) {
 		}
 
 		// Act
-		_, err := service.Create(testCreationData)
+		_, err := service.Create(context.Background(), testCreationData)
 
 		// Assert
 		require.Error(t, err)
@@ -251,7 +338,7 @@ func TestMessageCreate(t *testing.T) {
 		// storage.createMessageFunc is not set, so CreateMessage should not be called
 
 		// Act
-		_, err := service.Create(testCreationData)
+		_, err := service.Create(context.Background(), testCreationData)
 
 		// Assert
 		require.Error(t, err)
@@ -263,6 +350,203 @@ func TestMessageCreate(t *testing.T) {
 		assert.False(t, storage.deleteMessageCalled, "DeleteMessage should not be called")
 		storage.mu.Unlock()
 	})
+
+	t.Run("Board text filter", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		validator := &MockMessageValidator{}
+		service := NewMessage(storage, validator, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+		storage.getBoardSettingsFunc = func(board domain.BoardShortName) (domain.BoardSettings, error) {
+			return domain.BoardSettings{TextFilter: domain.TextFilterStrip}, nil
+		}
+		validator.filterTextFunc = func(text domain.MsgText, filter domain.TextFilter) (domain.MsgText, error) {
+			assert.Equal(t, domain.TextFilterStrip, filter)
+			return "filtered", nil
+		}
+		storage.createMessageFunc = func(creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
+			assert.Equal(t, domain.MsgText("filtered"), creationData.Text)
+			return expectedCreatedId, nil
+		}
+
+		_, err := service.Create(context.Background(), testCreationData)
+		require.NoError(t, err)
+
+		// Text made only of invisible characters leaves nothing to post
+		validator.filterTextFunc = func(text domain.MsgText, filter domain.TextFilter) (domain.MsgText, error) {
+			return "", nil
+		}
+		storage.ResetCallTracking()
+		_, err = service.Create(context.Background(), testCreationData)
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusBadRequest, e.StatusCode)
+		assert.False(t, storage.createMessageCalled)
+	})
+
+	t.Run("OP-only thread", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+		opId := testAuthor.Id + 1
+		storage.getThreadOpOnlyFunc = func(board domain.BoardShortName, threadId domain.ThreadId) (bool, *domain.UserId, error) {
+			return true, &opId, nil
+		}
+
+		_, err := service.Create(context.Background(), testCreationData)
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusForbidden, e.StatusCode)
+		assert.False(t, storage.createMessageCalled)
+
+		// The OP and moderators can still post
+		op := testCreationData
+		op.Author.Id = opId
+		_, err = service.Create(context.Background(), op)
+		require.NoError(t, err)
+
+		moderator := testCreationData
+		moderator.Author.Admin = true
+		storage.getThreadOpOnlyFunc = func(board domain.BoardShortName, threadId domain.ThreadId) (bool, *domain.UserId, error) {
+			t.Fatal("moderators should not need the thread lookup")
+			return false, nil, nil
+		}
+		_, err = service.Create(context.Background(), moderator)
+		require.NoError(t, err)
+	})
+
+	t.Run("Bump limit notice", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+		notice := true
+		storage.getBoardSettingsFunc = func(board domain.BoardShortName) (domain.BoardSettings, error) {
+			return domain.BoardSettings{BumpLimitNotice: notice}, nil
+		}
+		due := false
+		claims := 0
+		storage.claimBumpLimitFunc = func(board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+			assert.Equal(t, testCreationData.Board, board)
+			assert.Equal(t, testCreationData.ThreadId, threadId)
+			claims++
+			return due, nil
+		}
+		var created []domain.MessageCreationData
+		storage.createMessageFunc = func(creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
+			created = append(created, creationData)
+			return domain.MsgId(len(created)), nil
+		}
+
+		// Below the limit only the reply is posted
+		_, err := service.Create(context.Background(), testCreationData)
+		require.NoError(t, err)
+		assert.Len(t, created, 1)
+
+		// The reply that reaches the limit is followed by the notice
+		due = true
+		createdId, err := service.Create(context.Background(), testCreationData)
+		require.NoError(t, err)
+		assert.Equal(t, domain.MsgId(2), createdId, "the reply's id is returned, not the notice's")
+		require.Len(t, created, 3)
+		assert.Equal(t, domain.MessageCreationData{
+			Board:    testCreationData.Board,
+			ThreadId: testCreationData.ThreadId,
+			Author:   domain.User{Id: domain.SystemUserId},
+			Text:     bumpLimitNotice,
+		}, created[2])
+
+		// A failed notice does not fail the reply
+		storage.createMessageFunc = func(creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
+			if creationData.Author.IsSystem() {
+				return 0, errors.New("db write failed")
+			}
+			return 4, nil
+		}
+		_, err = service.Create(context.Background(), testCreationData)
+		require.NoError(t, err)
+
+		// Boards without the setting are never checked
+		notice = false
+		claims = 0
+		_, err = service.Create(context.Background(), testCreationData)
+		require.NoError(t, err)
+		assert.Zero(t, claims)
+	})
+}
+
+func TestMessageCreateCapsReplies(t *testing.T) {
+	cfg := createDefaultTestConfig()
+	cfg.MaxRepliesPerMessage = 3
+	replies := func() *domain.Replies {
+		return &domain.Replies{
+			{ToThreadId: 1, To: 1},
+			{ToThreadId: 1, To: 2},
+			{ToThreadId: 1, To: 1}, // repeated target
+			{ToThreadId: 2, To: 1},
+			{ToThreadId: 2, To: 2},
+			{ToThreadId: 2, To: 3},
+		}
+	}
+	want := domain.Replies{{ToThreadId: 1, To: 1}, {ToThreadId: 1, To: 2}, {ToThreadId: 2, To: 1}}
+
+	t.Run("reply in a thread", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		var stored *domain.Replies
+		storage.createMessageFunc = func(creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
+			stored = creationData.ReplyTo
+			return 2, nil
+		}
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg)
+
+		_, err := service.Create(context.Background(), domain.MessageCreationData{Board: "tst", ThreadId: 1, Author: domain.User{Id: 1}, Text: "text", ReplyTo: replies()})
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, want, *stored)
+	})
+
+	t.Run("new thread from the board page", func(t *testing.T) {
+		messageStorage := &MockMessageStorage{}
+		var stored *domain.Replies
+		messageStorage.createMessageFunc = func(creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
+			stored = creationData.ReplyTo
+			return 1, nil
+		}
+		threadStorage := &MockThreadStorage{
+			createThreadFunc: func(creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error) {
+				return 3, time.Now().UTC(), nil
+			},
+		}
+		messages := NewMessage(messageStorage, &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg)
+		service := NewThread(threadStorage, &MockThreadValidator{}, messages, &SharedMockMediaStorage{}, cfg)
+
+		_, err := service.Create(context.Background(), domain.ThreadCreationData{
+			Title: "Thread", Board: "tst",
+			OpMessage: domain.MessageCreationData{Board: "tst", Author: domain.User{Id: 1}, Text: "OP", ReplyTo: replies()},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, want, *stored)
+	})
+
+	t.Run("post held for approval", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		var queued *domain.Replies
+		storage.queuePostFunc = func(creationData domain.MessageCreationData, thread *domain.QueuedThread, attachments domain.Attachments) (domain.QueuedPostId, error) {
+			queued = creationData.ReplyTo
+			return 1, nil
+		}
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg)
+
+		err := service.QueueThread(context.Background(), domain.MessageCreationData{Board: "tst", Author: domain.User{Id: 1}, Text: "OP", ReplyTo: replies()}, domain.QueuedThread{Title: "Thread"})
+		require.ErrorIs(t, err, ErrPostQueued)
+		require.NotNil(t, queued)
+		assert.Equal(t, want, *queued)
+	})
+
+	t.Run("no replies stay nil", func(t *testing.T) {
+		assert.Nil(t, capReplies(nil, 3))
+		all := capReplies(replies(), 0)
+		assert.Len(t, *all, 5, "a zero limit only drops repeated targets")
+	})
 }
 
 func TestMessageGet(t *testing.T) {
@@ -289,7 +573,7 @@ func TestMessageGet(t *testing.T) {
 		}
 
 		// Act
-		message, err := service.Get("test", testThreadId, testId)
+		message, err := service.Get(context.Background(), "test", testThreadId, testId)
 
 		// Assert
 		require.NoError(t, err)
@@ -320,7 +604,7 @@ func TestMessageGet(t *testing.T) {
 		}
 
 		// Act
-		_, err := service.Get("test", testThreadId, testId)
+		_, err := service.Get(context.Background(), "test", testThreadId, testId)
 
 		// Assert
 		require.Error(t, err)
@@ -336,6 +620,55 @@ func TestMessageGet(t *testing.T) {
 	})
 }
 
+func TestMessageResolve(t *testing.T) {
+	t.Run("resolves to the stored message", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		storage.ResetCallTracking()
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+		storage.resolvePostNumberFunc = func(board domain.BoardShortName, postNumber domain.PostNumber) (domain.ThreadId, domain.MsgId, error) {
+			assert.Equal(t, domain.BoardShortName("b"), board)
+			assert.Equal(t, domain.PostNumber(42), postNumber)
+			return 7, 3, nil
+		}
+		storage.getMessageFunc = func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+			return domain.Message{MessageMetadata: domain.MessageMetadata{Board: board, ThreadId: threadId, Id: id, PostNumber: 42}}, nil
+		}
+
+		msg, err := service.Resolve(context.Background(), "b", 42)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ThreadId(7), msg.ThreadId)
+		assert.Equal(t, domain.MsgId(3), msg.Id)
+	})
+
+	t.Run("rejects numbers below one", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		storage.ResetCallTracking()
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+		storage.resolvePostNumberFunc = func(board domain.BoardShortName, postNumber domain.PostNumber) (domain.ThreadId, domain.MsgId, error) {
+			t.Fatal("storage should not be called")
+			return 0, 0, nil
+		}
+
+		_, err := service.Resolve(context.Background(), "b", 0)
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("unknown number", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		storage.ResetCallTracking()
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+		storage.resolvePostNumberFunc = func(board domain.BoardShortName, postNumber domain.PostNumber) (domain.ThreadId, domain.MsgId, error) {
+			return 0, 0, &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
+		}
+
+		_, err := service.Resolve(context.Background(), "b", 5)
+		requireStatus(t, err, http.StatusNotFound)
+		storage.mu.Lock()
+		assert.False(t, storage.getMessageCalled)
+		storage.mu.Unlock()
+	})
+}
+
 func TestMessageDelete(t *testing.T) {
 	// Common test data
 	testBoard := domain.BoardShortName("tst")
@@ -369,7 +702,7 @@ func TestMessageDelete(t *testing.T) {
 		}
 
 		// Act
-		err := service.Delete(testBoard, testThreadId, testId)
+		err := service.Delete(context.Background(), testBoard, testThreadId, testId, domain.MessageDeletionData{Reason: "  rule 3  "})
 
 		// Assert
 		require.NoError(t, err)
@@ -379,11 +712,30 @@ func TestMessageDelete(t *testing.T) {
 		assert.Equal(t, testBoard, storage.deleteMessageArgBoard)
 		assert.Equal(t, testThreadId, storage.deleteMessageArgThreadId)
 		assert.Equal(t, testId, storage.deleteMessageArgId)
+		assert.Equal(t, "rule 3", storage.deleteMessageArgDeletion.Reason, "reason should be trimmed")
 		assert.False(t, storage.createMessageCalled, "CreateMessage should not be called")
 		assert.True(t, storage.getMessageCalled, "GetMessage should have been called")
 		storage.mu.Unlock()
 	})
 
+	t.Run("Reason too long", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		storage.ResetCallTracking()
+		cfg := createDefaultTestConfig()
+		cfg.DeletionReasonMaxLen = 5
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg)
+
+		err := service.Delete(context.Background(), testBoard, testThreadId, testId, domain.MessageDeletionData{Reason: "too long reason"})
+
+		require.Error(t, err)
+		var errWithStatus *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &errWithStatus))
+		assert.Equal(t, 400, errWithStatus.StatusCode)
+		storage.mu.Lock()
+		assert.False(t, storage.deleteMessageCalled, "DeleteMessage should not be called")
+		storage.mu.Unlock()
+	})
+
 	t.Run("Storage error during DeleteMessage", func(t *testing.T) {
 		// Arrange
 		storage := &MockMessageStorage{}
@@ -412,7 +764,7 @@ func TestMessageDelete(t *testing.T) {
 		}
 
 		// Act
-		err := service.Delete(testBoard, testThreadId, testId)
+		err := service.Delete(context.Background(), testBoard, testThreadId, testId, domain.MessageDeletionData{})
 
 		// Assert
 		require.Error(t, err)
@@ -429,6 +781,85 @@ func TestMessageDelete(t *testing.T) {
 	})
 }
 
+func TestMessageDeleteOwn(t *testing.T) {
+	const author = domain.UserId(7)
+	cfg := createDefaultTestConfig()
+	cfg.SelfDeleteWindow = 10 * time.Minute
+	ctx := context.Background()
+
+	newStorage := func(msg domain.Message) *MockMessageStorage {
+		return &MockMessageStorage{
+			getMessageFunc: func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+				return msg, nil
+			},
+		}
+	}
+	reply := func(age time.Duration, replies domain.Replies) domain.Message {
+		return domain.Message{MessageMetadata: domain.MessageMetadata{
+			Board: "b", ThreadId: 1, Id: 2,
+			Author:    domain.User{Id: author},
+			CreatedAt: time.Now().UTC().Add(-age),
+			Replies:   replies,
+		}}
+	}
+
+	t.Run("author deletes a fresh reply", func(t *testing.T) {
+		storage := newStorage(reply(time.Minute, nil))
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg)
+
+		require.NoError(t, service.DeleteOwn(ctx, "b", 1, 2, author))
+		require.True(t, storage.deleteMessageCalled)
+		assert.True(t, storage.deleteMessageArgDeletion.ByAuthor)
+		assert.Equal(t, author, *storage.deleteMessageArgDeletion.DeletedBy)
+	})
+
+	t.Run("refusals", func(t *testing.T) {
+		op := reply(time.Minute, nil)
+		op.Id = 1
+		replied := domain.Replies{{Board: "b", FromThreadId: 1, ToThreadId: 1, From: 3, To: 2}}
+
+		for name, tc := range map[string]struct {
+			msg    domain.Message
+			user   domain.UserId
+			cfg    func(*config.Public)
+			status int
+		}{
+			"someone else's post": {msg: reply(time.Minute, nil), user: author + 1, status: http.StatusForbidden},
+			"opening post":        {msg: op, status: http.StatusForbidden},
+			"window passed":       {msg: reply(time.Hour, nil), status: http.StatusForbidden},
+			"replied post":        {msg: reply(time.Minute, replied), status: http.StatusConflict},
+			"disabled":            {msg: reply(time.Minute, nil), cfg: func(c *config.Public) { c.SelfDeleteWindow = 0 }, status: http.StatusForbidden},
+		} {
+			t.Run(name, func(t *testing.T) {
+				testCfg := *cfg
+				if tc.cfg != nil {
+					tc.cfg(&testCfg)
+				}
+				storage := newStorage(tc.msg)
+				service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, &testCfg)
+
+				user := author
+				if tc.user != 0 {
+					user = tc.user
+				}
+				requireStatus(t, service.DeleteOwn(ctx, "b", 1, tc.msg.Id, user), tc.status)
+				assert.False(t, storage.deleteMessageCalled)
+			})
+		}
+	})
+
+	t.Run("board allows deleting replied posts", func(t *testing.T) {
+		storage := newStorage(reply(time.Minute, domain.Replies{{Board: "b", FromThreadId: 1, ToThreadId: 1, From: 3, To: 2}}))
+		storage.getBoardSettingsFunc = func(board domain.BoardShortName) (domain.BoardSettings, error) {
+			return domain.BoardSettings{SelfDeleteReplied: true}, nil
+		}
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg)
+
+		require.NoError(t, service.DeleteOwn(ctx, "b", 1, 2, author))
+		assert.True(t, storage.deleteMessageCalled)
+	})
+}
+
 func TestMessageCreate_TextOrAttachmentsRequired(t *testing.T) {
 	t.Run("empty text and no files - should fail", func(t *testing.T) {
 		// Arrange
@@ -446,7 +877,7 @@ func TestMessageCreate_TextOrAttachmentsRequired(t *testing.T) {
 		}
 
 		// Act
-		_, err := service.Create(testCreationData)
+		_, err := service.Create(context.Background(), testCreationData)
 
 		// Assert
 		require.Error(t, err)
@@ -469,7 +900,7 @@ func TestMessageCreate_TextOrAttachmentsRequired(t *testing.T) {
 		}
 
 		// Act
-		_, err := service.Create(testCreationData)
+		_, err := service.Create(context.Background(), testCreationData)
 
 		// Assert
 		require.Error(t, err)
@@ -492,7 +923,7 @@ func TestMessageCreate_TextOrAttachmentsRequired(t *testing.T) {
 		}
 
 		// Act
-		msgId, err := service.Create(testCreationData)
+		msgId, err := service.Create(context.Background(), testCreationData)
 
 		// Assert
 		require.NoError(t, err)
@@ -524,7 +955,7 @@ func TestMessageCreate_TextOrAttachmentsRequired(t *testing.T) {
 		}
 
 		// Act
-		msgId, err := service.Create(testCreationData)
+		msgId, err := service.Create(context.Background(), testCreationData)
 
 		// Assert
 		require.NoError(t, err)
@@ -556,7 +987,7 @@ func TestMessageCreate_TextOrAttachmentsRequired(t *testing.T) {
 		}
 
 		// Act
-		msgId, err := service.Create(testCreationData)
+		msgId, err := service.Create(context.Background(), testCreationData)
 
 		// Assert
 		require.NoError(t, err)
@@ -587,7 +1018,7 @@ func TestMessageCreate_TextOrAttachmentsRequired(t *testing.T) {
 		}
 
 		// Act
-		_, err := service.Create(testCreationData)
+		_, err := service.Create(context.Background(), testCreationData)
 
 		// Assert
 		require.Error(t, err)
@@ -627,10 +1058,125 @@ func TestMessageCreate_TextOrAttachmentsRequired(t *testing.T) {
 		}
 
 		// Act
-		_, err := service.Create(testCreationData)
+		_, err := service.Create(context.Background(), testCreationData)
 
 		// Assert
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "unsupported file type")
 	})
 }
+
+func TestMessageUploadSettings(t *testing.T) {
+	cfg := &config.Public{MaxAttachmentsPerMessage: 4}
+	video := &domain.PendingFile{FileCommonMetadata: domain.FileCommonMetadata{MimeType: "video/webm"}}
+	image := &domain.PendingFile{FileCommonMetadata: domain.FileCommonMetadata{MimeType: "image/png"}}
+	pdf := &domain.PendingFile{FileCommonMetadata: domain.FileCommonMetadata{MimeType: "application/pdf"}}
+	boardSettings := func(settings domain.BoardSettings) *MockMessageStorage {
+		return &MockMessageStorage{
+			getBoardSettingsFunc: func(board domain.BoardShortName) (domain.BoardSettings, error) {
+				assert.Equal(t, domain.BoardShortName("v"), board)
+				return settings, nil
+			},
+		}
+	}
+
+	t.Run("board settings for videos", func(t *testing.T) {
+		storage := boardSettings(domain.BoardSettings{VideoProfile: "small"})
+		svc := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg).(*Message)
+
+		settings, err := svc.uploadSettings(context.Background(), "v", []*domain.PendingFile{image, video}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "small", settings.VideoProfile)
+	})
+
+	t.Run("site-wide attachment limit by default", func(t *testing.T) {
+		svc := NewMessage(boardSettings(domain.BoardSettings{}), &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg).(*Message)
+
+		_, err := svc.uploadSettings(context.Background(), "v", []*domain.PendingFile{image, image, image, image}, 0)
+		require.NoError(t, err)
+		_, err = svc.uploadSettings(context.Background(), "v", []*domain.PendingFile{image, image, image, image, image}, 0)
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("board attachment limit", func(t *testing.T) {
+		limit := 1
+		svc := NewMessage(boardSettings(domain.BoardSettings{UploadRules: domain.UploadRules{MaxAttachments: &limit}}), &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg).(*Message)
+
+		_, err := svc.uploadSettings(context.Background(), "v", []*domain.PendingFile{image}, 0)
+		require.NoError(t, err)
+		_, err = svc.uploadSettings(context.Background(), "v", []*domain.PendingFile{image, image}, 0)
+		requireStatus(t, err, http.StatusBadRequest)
+		assert.Contains(t, err.Error(), "max 1 allowed")
+	})
+
+	t.Run("board without attachments", func(t *testing.T) {
+		limit := 0
+		svc := NewMessage(boardSettings(domain.BoardSettings{UploadRules: domain.UploadRules{MaxAttachments: &limit}}), &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg).(*Message)
+
+		_, err := svc.uploadSettings(context.Background(), "v", []*domain.PendingFile{image}, 0)
+		requireStatus(t, err, http.StatusBadRequest)
+		assert.Contains(t, err.Error(), "does not accept attachments")
+	})
+
+	t.Run("board mime type allowlist", func(t *testing.T) {
+		svc := NewMessage(boardSettings(domain.BoardSettings{UploadRules: domain.UploadRules{AllowedMimeTypes: []string{"image/png"}}}), &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg).(*Message)
+
+		_, err := svc.uploadSettings(context.Background(), "v", []*domain.PendingFile{image}, 0)
+		require.NoError(t, err)
+		_, err = svc.uploadSettings(context.Background(), "v", []*domain.PendingFile{image, video}, 0)
+		requireStatus(t, err, http.StatusBadRequest)
+		assert.Contains(t, err.Error(), "video/webm")
+	})
+
+	t.Run("documents on a board that allows them", func(t *testing.T) {
+		svc := NewMessage(boardSettings(domain.BoardSettings{UploadRules: domain.UploadRules{AllowDocuments: true}}), &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg).(*Message)
+
+		_, err := svc.uploadSettings(context.Background(), "v", []*domain.PendingFile{pdf}, 0)
+		require.NoError(t, err)
+	})
+
+	t.Run("documents on a board that doesn't allow them", func(t *testing.T) {
+		svc := NewMessage(boardSettings(domain.BoardSettings{}), &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg).(*Message)
+
+		_, err := svc.uploadSettings(context.Background(), "v", []*domain.PendingFile{image, pdf}, 0)
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+}
+
+func TestMessageGetFileMetadata(t *testing.T) {
+	ctx := context.Background()
+	board := domain.BoardShortName("b")
+
+	t.Run("returns the stored metadata", func(t *testing.T) {
+		want := []domain.FileMetadataReport{{FileId: 7, OriginalFilename: "photo.jpg", StrippedMetadata: domain.StrippedMetadata{CameraModel: "Pixel 8", HasGPS: true}}}
+		storage := &MockMessageStorage{
+			getFileMetadataFunc: func(b domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error) {
+				assert.Equal(t, board, b)
+				assert.Equal(t, domain.ThreadId(1), threadId)
+				assert.Equal(t, domain.MsgId(2), id)
+				return want, nil
+			},
+		}
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+		got, err := service.GetFileMetadata(ctx, board, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("unknown message", func(t *testing.T) {
+		storage := &MockMessageStorage{
+			getMessageFunc: func(b domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+				return domain.Message{}, &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
+			},
+			getFileMetadataFunc: func(b domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error) {
+				t.Fatal("metadata must not be read for unknown messages")
+				return nil, nil
+			},
+		}
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+		_, err := service.GetFileMetadata(ctx, board, 1, 2)
+		requireStatus(t, err, http.StatusNotFound)
+	})
+}
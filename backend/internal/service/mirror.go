@@ -0,0 +1,492 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchan-dev/itchan/backend/internal/utils/federation"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/validation"
+)
+
+// maxMirrorListingPages stops the thread listing of a source board that never
+// returns an empty page.
+const maxMirrorListingPages = 100
+
+// MirrorService keeps local boards as read-only copies of boards of other
+// instances.
+type MirrorService interface {
+	// Create turns an empty local board into a mirror of a board of another instance
+	Create(ctx context.Context, mirror domain.BoardMirror) error
+	// Delete detaches the mirror; copied threads stay and the board accepts posts again
+	Delete(ctx context.Context, board domain.BoardShortName) error
+	List(ctx context.Context) ([]domain.BoardMirror, error)
+	// Sync copies what changed on the source right away instead of waiting for the background sync
+	Sync(ctx context.Context, board domain.BoardShortName) error
+}
+
+// MirrorStorage defines storage interface for board mirrors and their sync state
+type MirrorStorage interface {
+	CreateBoardMirror(ctx context.Context, mirror domain.BoardMirror) error
+	DeleteBoardMirror(ctx context.Context, board domain.BoardShortName) error
+	GetBoardMirror(ctx context.Context, board domain.BoardShortName) (domain.BoardMirror, error)
+	GetBoardMirrors(ctx context.Context) ([]domain.BoardMirror, error)
+	RecordBoardMirrorSync(ctx context.Context, board domain.BoardShortName, syncErr string) error
+	GetMirroredThreads(ctx context.Context, board domain.BoardShortName) ([]domain.MirroredThread, error)
+	ApplyMirrorThreadUpdate(ctx context.Context, update domain.MirrorThreadUpdate) error
+	DeleteMirroredThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) error
+}
+
+// MirrorSource reads a board of another instance. A missing board or thread
+// is reported as federation.ErrNotFound.
+type MirrorSource interface {
+	GetBoard(ctx context.Context, board domain.BoardShortName, page int) (domain.Board, error)
+	GetThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error)
+	GetMedia(ctx context.Context, path string) (io.ReadCloser, error)
+}
+
+type Mirror struct {
+	storage      MirrorStorage
+	mediaStorage MediaStorage
+	newSource    func(sourceURL string) MirrorSource
+	cfg          *config.Public
+	mu           sync.Mutex // Held by a running sync, so the background and a manual one never overlap
+}
+
+// NewMirror returns the mirror service. newSource opens the instance at a
+// mirror's SourceURL, usually federation.New.
+func NewMirror(storage MirrorStorage, mediaStorage MediaStorage, newSource func(sourceURL string) MirrorSource, cfg *config.Public) *Mirror {
+	return &Mirror{
+		storage:      storage,
+		mediaStorage: mediaStorage,
+		newSource:    newSource,
+		cfg:          cfg,
+	}
+}
+
+// Create checks that the source board answers before attaching the mirror,
+// so a typo is reported right away rather than as a failed sync later.
+func (s *Mirror) Create(ctx context.Context, mirror domain.BoardMirror) error {
+	sourceURL, err := normalizeSourceURL(mirror.SourceURL)
+	if err != nil {
+		return err
+	}
+	mirror.SourceURL = sourceURL
+	if mirror.SourceBoard == "" {
+		return &errors.ErrorWithStatusCode{Message: "Source board is required", StatusCode: http.StatusBadRequest}
+	}
+
+	if _, err := s.newSource(mirror.SourceURL).GetBoard(ctx, mirror.SourceBoard, 1); err != nil {
+		return &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Source board /%s/ is not available: %v", mirror.SourceBoard, err),
+			StatusCode: http.StatusBadGateway,
+		}
+	}
+
+	if err := s.storage.CreateBoardMirror(ctx, mirror); err != nil {
+		return err
+	}
+	logger.Log.Info("board mirror attached", "board", mirror.Board, "source_url", mirror.SourceURL, "source_board", mirror.SourceBoard, "admin_id", mirror.CreatedBy)
+	return nil
+}
+
+func (s *Mirror) Delete(ctx context.Context, board domain.BoardShortName) error {
+	if err := s.storage.DeleteBoardMirror(ctx, board); err != nil {
+		return err
+	}
+	logger.Log.Info("board mirror detached", "board", board)
+	return nil
+}
+
+func (s *Mirror) List(ctx context.Context) ([]domain.BoardMirror, error) {
+	return s.storage.GetBoardMirrors(ctx)
+}
+
+func (s *Mirror) Sync(ctx context.Context, board domain.BoardShortName) error {
+	mirror, err := s.storage.GetBoardMirror(ctx, board)
+	if err != nil {
+		return err
+	}
+	if !s.mu.TryLock() {
+		return &errors.ErrorWithStatusCode{Message: "A mirror sync is already running", StatusCode: http.StatusConflict}
+	}
+	defer s.mu.Unlock()
+
+	if err := s.syncAndRecord(ctx, mirror); err != nil {
+		var statusErr *errors.ErrorWithStatusCode
+		if stderrors.As(err, &statusErr) {
+			return err
+		}
+		// Mostly the source instance failing, which is not our internal error
+		return &errors.ErrorWithStatusCode{Message: fmt.Sprintf("Mirror sync failed: %v", err), StatusCode: http.StatusBadGateway}
+	}
+	return nil
+}
+
+// StartBackgroundSync syncs every mirror right away and then every interval
+// until ctx is cancelled.
+func (s *Mirror) StartBackgroundSync(ctx context.Context, interval time.Duration) {
+	logger.Log.Info("started board mirror sync",
+		"component", "mirror",
+		"interval", interval,
+		"threads_per_sync", s.cfg.Federation.ThreadsPerSync)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.SyncAll(ctx); err != nil {
+				logger.Log.Error("board mirror sync failed", "component", "mirror", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				logger.Log.Info("board mirror sync shutting down gracefully", "component", "mirror")
+				return
+			}
+		}
+	}()
+}
+
+// SyncAll syncs every mirror in turn. A failing source only fails its own
+// mirror; the error is recorded on it and the others still sync.
+func (s *Mirror) SyncAll(ctx context.Context) error {
+	mirrors, err := s.storage.GetBoardMirrors(ctx)
+	if err != nil {
+		return err
+	}
+	if len(mirrors) == 0 {
+		return nil
+	}
+	if !s.mu.TryLock() {
+		return nil // A manual sync is running, the next tick catches up
+	}
+	defer s.mu.Unlock()
+
+	for _, mirror := range mirrors {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.syncAndRecord(ctx, mirror)
+	}
+	return nil
+}
+
+// syncAndRecord syncs one mirror and stores the outcome for the admin page.
+func (s *Mirror) syncAndRecord(ctx context.Context, mirror domain.BoardMirror) error {
+	syncErr := s.syncBoard(ctx, mirror)
+	message := ""
+	if syncErr != nil {
+		message = syncErr.Error()
+		logger.Log.Warn("board mirror sync failed", "component", "mirror", "board", mirror.Board, "source_url", mirror.SourceURL, "error", syncErr)
+	}
+	if err := s.storage.RecordBoardMirrorSync(ctx, mirror.Board, message); err != nil {
+		return err
+	}
+	return syncErr
+}
+
+// syncBoard copies the threads that changed on the source since the last
+// sync, oldest bump last like the listing, at most ThreadsPerSync of them.
+// Threads gone from the source are deleted here; threads a moderator deleted
+// here are left alone.
+func (s *Mirror) syncBoard(ctx context.Context, mirror domain.BoardMirror) error {
+	source := s.newSource(mirror.SourceURL)
+
+	listed, err := listSourceThreads(ctx, source, mirror.SourceBoard)
+	if err != nil {
+		return err
+	}
+	tracked, err := s.storage.GetMirroredThreads(ctx, mirror.Board)
+	if err != nil {
+		return err
+	}
+	state := make(map[domain.ThreadId]domain.MirroredThread, len(tracked))
+	for _, t := range tracked {
+		state[t.ThreadId] = t
+	}
+
+	onSource := make(map[domain.ThreadId]bool, len(listed))
+	for _, t := range listed {
+		onSource[t.Id] = true
+	}
+	for _, t := range tracked {
+		if t.Deleted || onSource[t.ThreadId] {
+			continue
+		}
+		// The listing only shows live threads; make sure this one is really gone
+		_, err := source.GetThread(ctx, mirror.SourceBoard, t.ThreadId, 1)
+		if !stderrors.Is(err, federation.ErrNotFound) {
+			continue
+		}
+		if err := s.storage.DeleteMirroredThread(ctx, mirror.Board, t.ThreadId); err != nil {
+			return err
+		}
+		if err := s.mediaStorage.DeleteThread(string(mirror.Board), fmt.Sprintf("%d", t.ThreadId)); err != nil {
+			logger.Log.Warn("failed to delete media of mirrored thread", "component", "mirror", "board", mirror.Board, "thread_id", t.ThreadId, "error", err)
+		}
+	}
+
+	copied := 0
+	for _, t := range listed {
+		if copied >= s.cfg.Federation.ThreadsPerSync {
+			break
+		}
+		prev, ok := state[t.Id]
+		if ok && (prev.Deleted || !t.LastModifiedAt.Truncate(time.Millisecond).After(prev.SourceModifiedAt.Truncate(time.Millisecond))) {
+			continue
+		}
+		if err := s.syncThread(ctx, source, mirror, t, prev.LastMessageId); err != nil {
+			return fmt.Errorf("thread %d: %w", t.Id, err)
+		}
+		copied++
+	}
+	return nil
+}
+
+// listSourceThreads reads every page of the source board's listing.
+func listSourceThreads(ctx context.Context, source MirrorSource, board domain.BoardShortName) ([]domain.ThreadMetadata, error) {
+	var threads []domain.ThreadMetadata
+	seen := make(map[domain.ThreadId]bool)
+	for page := 1; page <= maxMirrorListingPages; page++ {
+		result, err := source.GetBoard(ctx, board, page)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Threads) == 0 {
+			break
+		}
+		for _, t := range result.Threads {
+			if !seen[t.Id] {
+				seen[t.Id] = true
+				threads = append(threads, t.ThreadMetadata)
+			}
+		}
+	}
+	return threads, nil
+}
+
+// syncThread reads the whole source thread and applies it in one update.
+// Messages above lastMessageId are copied with their attachments, the
+// earlier ones only get their current text.
+func (s *Mirror) syncThread(ctx context.Context, source MirrorSource, mirror domain.BoardMirror, meta domain.ThreadMetadata, lastMessageId domain.MsgId) error {
+	var messages []*domain.Message
+	for page, totalPages := 1, 1; page <= totalPages; page++ {
+		thread, err := source.GetThread(ctx, mirror.SourceBoard, meta.Id, page)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, thread.Messages...)
+		if thread.Pagination != nil {
+			totalPages = thread.Pagination.TotalPages
+		}
+		meta = thread.ThreadMetadata
+	}
+
+	links := strings.NewReplacer(
+		fmt.Sprintf(`href="/%s/`, mirror.SourceBoard), fmt.Sprintf(`href="/%s/`, mirror.Board),
+		fmt.Sprintf(`data-board="%s"`, mirror.SourceBoard), fmt.Sprintf(`data-board="%s"`, mirror.Board),
+	)
+
+	meta.Board = mirror.Board
+	update := domain.MirrorThreadUpdate{Board: mirror.Board, Thread: meta}
+	var savedFiles []string
+	for _, m := range messages {
+		copied := domain.MirroredMessage{
+			Id:         m.Id,
+			PostNumber: m.PostNumber,
+			Text:       links.Replace(m.Text),
+			CreatedAt:  m.CreatedAt,
+		}
+		update.SourceIds = append(update.SourceIds, m.Id)
+		update.Replies = append(update.Replies, m.Replies...)
+		if m.Id <= lastMessageId {
+			update.Edited = append(update.Edited, copied)
+			continue
+		}
+
+		attachments, saved, err := s.copyAttachments(ctx, source, mirror.Board, meta.Id, m.Attachments)
+		savedFiles = append(savedFiles, saved...)
+		if err != nil {
+			s.deleteFiles(savedFiles)
+			return err
+		}
+		copied.Attachments = attachments
+		update.New = append(update.New, copied)
+	}
+
+	if err := s.storage.ApplyMirrorThreadUpdate(ctx, update); err != nil {
+		s.deleteFiles(savedFiles)
+		return err
+	}
+	return nil
+}
+
+// copyAttachments downloads the files of a source message into local media.
+// Files over the local size limit or of a type not allowed here are left out,
+// as are files whose content doesn't match their type, which the source can't
+// be trusted on. Anything else that fails fails the thread, which is then
+// retried on the next sync.
+func (s *Mirror) copyAttachments(ctx context.Context, source MirrorSource, board domain.BoardShortName, threadId domain.ThreadId, attachments domain.Attachments) (domain.Attachments, []string, error) {
+	var copied domain.Attachments
+	var savedFiles []string
+	dir := fmt.Sprintf("%d", threadId)
+	allowed := validation.BuildAllowedMimeMap(s.cfg.AllowedImageMimeTypes, s.cfg.AllowedVideoMimeTypes, s.cfg.AllowedDocumentMimeTypes)
+	denylist := validation.Denylist{Extensions: s.cfg.BlockedFileExtensions, MimeTypes: s.cfg.BlockedMimeTypes}
+
+	for _, a := range attachments {
+		f := a.File
+		if f == nil || f.FilePath == "" {
+			continue
+		}
+		if f.SizeBytes > s.cfg.MaxAttachmentSizeBytes {
+			logger.Log.Warn("skipped mirrored attachment over the size limit", "component", "mirror", "board", board, "thread_id", threadId, "size", f.SizeBytes)
+			continue
+		}
+		ext := mirrorFileExt(f.MimeType)
+		if !allowed[f.MimeType] || ext == "" {
+			logger.Log.Warn("skipped mirrored attachment of a type not allowed here", "component", "mirror", "board", board, "thread_id", threadId, "mime_type", f.MimeType)
+			continue
+		}
+
+		// Named after the checked type, not the source's extension
+		filename := strings.TrimSuffix(f.Filename, filepath.Ext(f.Filename)) + ext
+		filePath, sum, err := s.download(ctx, source, f.MediaURL(), func(body io.Reader) (string, error) {
+			data, err := checkMirroredFile(body, f.Filename, f.MimeType, denylist)
+			if err != nil {
+				return "", err
+			}
+			return s.mediaStorage.SaveFile(data, string(board), dir, filename)
+		})
+		if isRejectedContent(err) {
+			logger.Log.Warn("skipped mirrored attachment with unexpected content", "component", "mirror", "board", board, "thread_id", threadId, "error", err)
+			continue
+		}
+		if err != nil {
+			return nil, savedFiles, err
+		}
+		savedFiles = append(savedFiles, filePath)
+
+		file := &domain.File{
+			FileCommonMetadata: f.FileCommonMetadata,
+			FilePath:           filePath,
+			OriginalFilename:   f.OriginalFilename,
+			OriginalMimeType:   f.OriginalMimeType,
+			Sha256:             sum,
+			TextPreview:        f.TextPreview,
+		}
+		file.Filename = filename
+		if thumbURL := f.ThumbnailURL(); thumbURL != "" {
+			// Thumbnails are always JPEG, see SaveThumbnail
+			thumbPath, thumbSum, err := s.download(ctx, source, thumbURL, func(body io.Reader) (string, error) {
+				data, err := checkMirroredFile(body, "thumb.jpg", "image/jpeg", denylist)
+				if err != nil {
+					return "", err
+				}
+				return s.mediaStorage.SaveThumbnail(data, filePath)
+			})
+			switch {
+			case isRejectedContent(err):
+				logger.Log.Warn("skipped mirrored thumbnail with unexpected content", "component", "mirror", "board", board, "thread_id", threadId, "error", err)
+			case err != nil:
+				return nil, savedFiles, err
+			default:
+				savedFiles = append(savedFiles, thumbPath)
+				file.ThumbnailPath = &thumbPath
+				file.ThumbnailSha256 = thumbSum
+			}
+		}
+
+		copied = append(copied, &domain.Attachment{Board: board, File: file})
+	}
+	return copied, savedFiles, nil
+}
+
+// mirrorFileExts are the extensions mirrored files of the types produced by
+// the sanitizers are stored with. Other allowed types use the system table.
+var mirrorFileExts = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+	"video/ogg":       ".ogv",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+}
+
+func mirrorFileExt(mimeType string) string {
+	if ext, ok := mirrorFileExts[mimeType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// checkMirroredFile runs the denylist and content checks of local uploads on
+// a downloaded file and returns the data to save.
+func checkMirroredFile(body io.Reader, filename, mimeType string, denylist validation.Denylist) (io.Reader, error) {
+	file := &domain.PendingFile{
+		FileCommonMetadata: domain.FileCommonMetadata{Filename: filename, MimeType: mimeType},
+		Data:               body,
+	}
+	if err := validation.CheckFileContents([]*domain.PendingFile{file}, denylist); err != nil {
+		return nil, err
+	}
+	return file.Data, nil
+}
+
+func isRejectedContent(err error) bool {
+	return stderrors.Is(err, validation.ErrBlockedFile) || stderrors.Is(err, validation.ErrContentMismatch)
+}
+
+// download streams a source media file into save and hashes it on the way.
+// The read is capped at the attachment size limit in case the source's size
+// was wrong.
+func (s *Mirror) download(ctx context.Context, source MirrorSource, path string, save func(io.Reader) (string, error)) (string, string, error) {
+	body, err := source.GetMedia(ctx, path)
+	if err != nil {
+		return "", "", err
+	}
+	defer body.Close()
+
+	h := sha256.New()
+	saved, err := save(io.TeeReader(io.LimitReader(body, s.cfg.MaxAttachmentSizeBytes), h))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return saved, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (s *Mirror) deleteFiles(paths []string) {
+	for _, p := range paths {
+		s.mediaStorage.DeleteFile(p)
+	}
+}
+
+// normalizeSourceURL accepts the base URL of an instance with or without a
+// trailing slash.
+func normalizeSourceURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", &errors.ErrorWithStatusCode{Message: "Source URL must be an http or https URL", StatusCode: http.StatusBadRequest}
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/backend/internal/utils/federation"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockMirrorStorage struct {
+	createBoardMirrorFunc     func(mirror domain.BoardMirror) error
+	getBoardMirrorFunc        func(board domain.BoardShortName) (domain.BoardMirror, error)
+	getBoardMirrorsFunc       func() ([]domain.BoardMirror, error)
+	getMirroredThreadsFunc    func(board domain.BoardShortName) ([]domain.MirroredThread, error)
+	applyMirrorThreadUpdateFn func(update domain.MirrorThreadUpdate) error
+	deleteMirroredThreadFunc  func(board domain.BoardShortName, id domain.ThreadId) error
+
+	recordedSyncs []string
+}
+
+func (m *MockMirrorStorage) CreateBoardMirror(ctx context.Context, mirror domain.BoardMirror) error {
+	if m.createBoardMirrorFunc != nil {
+		return m.createBoardMirrorFunc(mirror)
+	}
+	return nil
+}
+
+func (m *MockMirrorStorage) DeleteBoardMirror(ctx context.Context, board domain.BoardShortName) error {
+	return nil
+}
+
+func (m *MockMirrorStorage) GetBoardMirror(ctx context.Context, board domain.BoardShortName) (domain.BoardMirror, error) {
+	if m.getBoardMirrorFunc != nil {
+		return m.getBoardMirrorFunc(board)
+	}
+	return domain.BoardMirror{Board: board, SourceURL: "https://source.example", SourceBoard: "src"}, nil
+}
+
+func (m *MockMirrorStorage) GetBoardMirrors(ctx context.Context) ([]domain.BoardMirror, error) {
+	if m.getBoardMirrorsFunc != nil {
+		return m.getBoardMirrorsFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockMirrorStorage) RecordBoardMirrorSync(ctx context.Context, board domain.BoardShortName, syncErr string) error {
+	m.recordedSyncs = append(m.recordedSyncs, syncErr)
+	return nil
+}
+
+func (m *MockMirrorStorage) GetMirroredThreads(ctx context.Context, board domain.BoardShortName) ([]domain.MirroredThread, error) {
+	if m.getMirroredThreadsFunc != nil {
+		return m.getMirroredThreadsFunc(board)
+	}
+	return nil, nil
+}
+
+func (m *MockMirrorStorage) ApplyMirrorThreadUpdate(ctx context.Context, update domain.MirrorThreadUpdate) error {
+	if m.applyMirrorThreadUpdateFn != nil {
+		return m.applyMirrorThreadUpdateFn(update)
+	}
+	return nil
+}
+
+func (m *MockMirrorStorage) DeleteMirroredThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) error {
+	if m.deleteMirroredThreadFunc != nil {
+		return m.deleteMirroredThreadFunc(board, id)
+	}
+	return nil
+}
+
+// MockMirrorSource serves a fixed board: threads in listing order, each with
+// a single page of messages.
+type MockMirrorSource struct {
+	threads  []domain.Thread
+	media    map[string]string
+	boardErr error
+}
+
+func (m *MockMirrorSource) GetBoard(ctx context.Context, board domain.BoardShortName, page int) (domain.Board, error) {
+	if m.boardErr != nil {
+		return domain.Board{}, m.boardErr
+	}
+	result := domain.Board{Page: page}
+	if page == 1 {
+		for i := range m.threads {
+			result.Threads = append(result.Threads, &domain.Thread{ThreadMetadata: m.threads[i].ThreadMetadata})
+		}
+	}
+	return result, nil
+}
+
+func (m *MockMirrorSource) GetThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error) {
+	for _, t := range m.threads {
+		if t.Id == id {
+			t.Pagination = &domain.ThreadPagination{CurrentPage: 1, TotalPages: 1}
+			return t, nil
+		}
+	}
+	return domain.Thread{}, fmt.Errorf("/v1/%s/%d: %w", board, id, federation.ErrNotFound)
+}
+
+func (m *MockMirrorSource) GetMedia(ctx context.Context, path string) (io.ReadCloser, error) {
+	data, ok := m.media[path]
+	if !ok {
+		return nil, errors.New("connection reset")
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+func newTestMirror(storage *MockMirrorStorage, source *MockMirrorSource, media *SharedMockMediaStorage) *Mirror {
+	cfg := &config.Public{
+		MaxAttachmentSizeBytes:   1024,
+		AllowedImageMimeTypes:    []string{"image/png"},
+		AllowedDocumentMimeTypes: []string{"text/plain"},
+		BlockedFileExtensions:    []string{".html"},
+		BlockedMimeTypes:         []string{"text/html"},
+	}
+	cfg.Federation.ThreadsPerSync = 10
+	return NewMirror(storage, media, func(string) MirrorSource { return source }, cfg)
+}
+
+// --- Tests ---
+
+func TestMirrorCreate(t *testing.T) {
+	t.Run("normalizes the source URL", func(t *testing.T) {
+		var created domain.BoardMirror
+		storage := &MockMirrorStorage{
+			createBoardMirrorFunc: func(mirror domain.BoardMirror) error {
+				created = mirror
+				return nil
+			},
+		}
+		svc := newTestMirror(storage, &MockMirrorSource{}, &SharedMockMediaStorage{})
+
+		err := svc.Create(context.Background(), domain.BoardMirror{Board: "b", SourceURL: " https://source.example/ ", SourceBoard: "src"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://source.example", created.SourceURL)
+	})
+
+	t.Run("rejects a non-http URL", func(t *testing.T) {
+		svc := newTestMirror(&MockMirrorStorage{}, &MockMirrorSource{}, &SharedMockMediaStorage{})
+
+		err := svc.Create(context.Background(), domain.BoardMirror{Board: "b", SourceURL: "file:///etc", SourceBoard: "src"})
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusBadRequest, e.StatusCode)
+	})
+
+	t.Run("unreachable source board", func(t *testing.T) {
+		storage := &MockMirrorStorage{
+			createBoardMirrorFunc: func(mirror domain.BoardMirror) error {
+				t.Fatal("mirror must not be created")
+				return nil
+			},
+		}
+		svc := newTestMirror(storage, &MockMirrorSource{boardErr: federation.ErrNotFound}, &SharedMockMediaStorage{})
+
+		err := svc.Create(context.Background(), domain.BoardMirror{Board: "b", SourceURL: "https://source.example", SourceBoard: "src"})
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusBadGateway, e.StatusCode)
+	})
+}
+
+func TestMirrorSync(t *testing.T) {
+	modified := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	sourceThread := func(id domain.ThreadId, messages ...*domain.Message) domain.Thread {
+		return domain.Thread{
+			ThreadMetadata: domain.ThreadMetadata{Id: id, Board: "src", Title: "title", LastModifiedAt: modified},
+			Messages:       messages,
+		}
+	}
+	message := func(id domain.MsgId, text string) *domain.Message {
+		return &domain.Message{MessageMetadata: domain.MessageMetadata{Id: id, PostNumber: domain.PostNumber(id + 100)}, Text: text}
+	}
+
+	t.Run("copies new threads and rewrites links to the local board", func(t *testing.T) {
+		var updates []domain.MirrorThreadUpdate
+		storage := &MockMirrorStorage{
+			applyMirrorThreadUpdateFn: func(update domain.MirrorThreadUpdate) error {
+				updates = append(updates, update)
+				return nil
+			},
+		}
+		link := `<a href="/src/1#p1" class="message-link" data-board="src" data-message-id="1" data-thread-id="1">&gt;&gt;1</a>`
+		source := &MockMirrorSource{threads: []domain.Thread{sourceThread(1, message(1, "op"), message(2, link))}}
+		svc := newTestMirror(storage, source, &SharedMockMediaStorage{})
+
+		require.NoError(t, svc.Sync(context.Background(), "b"))
+
+		require.Len(t, updates, 1)
+		u := updates[0]
+		assert.Equal(t, domain.BoardShortName("b"), u.Board)
+		assert.Equal(t, domain.BoardShortName("b"), u.Thread.Board)
+		require.Len(t, u.New, 2)
+		assert.Empty(t, u.Edited)
+		assert.Equal(t, domain.PostNumber(102), u.New[1].PostNumber)
+		assert.Contains(t, u.New[1].Text, `href="/b/1#p1"`)
+		assert.Contains(t, u.New[1].Text, `data-board="b"`)
+		assert.Equal(t, []domain.MsgId{1, 2}, u.SourceIds)
+		assert.Equal(t, []string{""}, storage.recordedSyncs)
+	})
+
+	t.Run("skips unchanged and locally deleted threads", func(t *testing.T) {
+		storage := &MockMirrorStorage{
+			getMirroredThreadsFunc: func(board domain.BoardShortName) ([]domain.MirroredThread, error) {
+				return []domain.MirroredThread{
+					{ThreadId: 1, SourceModifiedAt: modified, LastMessageId: 1},
+					{ThreadId: 2, SourceModifiedAt: modified.Add(-time.Hour), LastMessageId: 1, Deleted: true},
+				}, nil
+			},
+			applyMirrorThreadUpdateFn: func(update domain.MirrorThreadUpdate) error {
+				t.Fatalf("thread %d must not be synced", update.Thread.Id)
+				return nil
+			},
+		}
+		source := &MockMirrorSource{threads: []domain.Thread{sourceThread(1, message(1, "op")), sourceThread(2, message(1, "op"))}}
+		svc := newTestMirror(storage, source, &SharedMockMediaStorage{})
+
+		require.NoError(t, svc.Sync(context.Background(), "b"))
+	})
+
+	t.Run("splits copied and new messages of a changed thread", func(t *testing.T) {
+		var update domain.MirrorThreadUpdate
+		storage := &MockMirrorStorage{
+			getMirroredThreadsFunc: func(board domain.BoardShortName) ([]domain.MirroredThread, error) {
+				return []domain.MirroredThread{{ThreadId: 1, SourceModifiedAt: modified.Add(-time.Minute), LastMessageId: 2}}, nil
+			},
+			applyMirrorThreadUpdateFn: func(u domain.MirrorThreadUpdate) error {
+				update = u
+				return nil
+			},
+		}
+		source := &MockMirrorSource{threads: []domain.Thread{sourceThread(1, message(1, "op edited"), message(3, "new"))}}
+		svc := newTestMirror(storage, source, &SharedMockMediaStorage{})
+
+		require.NoError(t, svc.Sync(context.Background(), "b"))
+
+		require.Len(t, update.Edited, 1)
+		assert.Equal(t, "op edited", update.Edited[0].Text)
+		require.Len(t, update.New, 1)
+		assert.Equal(t, domain.MsgId(3), update.New[0].Id)
+		assert.Equal(t, []domain.MsgId{1, 3}, update.SourceIds)
+	})
+
+	t.Run("deletes threads gone from the source", func(t *testing.T) {
+		var deleted []domain.ThreadId
+		storage := &MockMirrorStorage{
+			getMirroredThreadsFunc: func(board domain.BoardShortName) ([]domain.MirroredThread, error) {
+				return []domain.MirroredThread{{ThreadId: 5, SourceModifiedAt: modified, LastMessageId: 3}}, nil
+			},
+			deleteMirroredThreadFunc: func(board domain.BoardShortName, id domain.ThreadId) error {
+				deleted = append(deleted, id)
+				return nil
+			},
+		}
+		media := &SharedMockMediaStorage{}
+		svc := newTestMirror(storage, &MockMirrorSource{}, media)
+
+		require.NoError(t, svc.Sync(context.Background(), "b"))
+		assert.Equal(t, []domain.ThreadId{5}, deleted)
+		assert.Equal(t, []DeleteThreadCall{{"b", "5"}}, media.deleteThreadCalls)
+	})
+
+	t.Run("copies attachments and skips oversized ones", func(t *testing.T) {
+		var update domain.MirrorThreadUpdate
+		storage := &MockMirrorStorage{
+			applyMirrorThreadUpdateFn: func(u domain.MirrorThreadUpdate) error {
+				update = u
+				return nil
+			},
+		}
+		op := message(1, "op")
+		op.Attachments = domain.Attachments{
+			{File: &domain.File{FilePath: "src/1/a.txt", FileCommonMetadata: domain.FileCommonMetadata{Filename: "a.txt", SizeBytes: 5, MimeType: "text/plain"}}},
+			{File: &domain.File{FilePath: "src/1/big.png", FileCommonMetadata: domain.FileCommonMetadata{Filename: "big.png", SizeBytes: 4096}}},
+		}
+		source := &MockMirrorSource{
+			threads: []domain.Thread{sourceThread(1, op)},
+			media:   map[string]string{"/media/src/1/a.txt": "hello"},
+		}
+		media := &SharedMockMediaStorage{}
+		svc := newTestMirror(storage, source, media)
+
+		require.NoError(t, svc.Sync(context.Background(), "b"))
+
+		require.Len(t, update.New, 1)
+		require.Len(t, update.New[0].Attachments, 1)
+		file := update.New[0].Attachments[0].File
+		assert.Equal(t, "b/1/a.txt", file.FilePath)
+		assert.Equal(t, "text/plain", file.MimeType)
+		assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", file.Sha256)
+		require.Len(t, media.saveFileCalls, 1)
+		assert.Equal(t, "hello", string(media.saveFileCalls[0].Data))
+	})
+
+	t.Run("skips attachments of other types or with unexpected content", func(t *testing.T) {
+		var update domain.MirrorThreadUpdate
+		storage := &MockMirrorStorage{
+			applyMirrorThreadUpdateFn: func(u domain.MirrorThreadUpdate) error {
+				update = u
+				return nil
+			},
+		}
+		thumb := "src/1/thumb_c.png"
+		op := message(1, "op")
+		op.Attachments = domain.Attachments{
+			{File: &domain.File{FilePath: "src/1/a.svg", FileCommonMetadata: domain.FileCommonMetadata{Filename: "a.svg", SizeBytes: 5, MimeType: "image/svg+xml"}}},
+			{File: &domain.File{FilePath: "src/1/b.png", FileCommonMetadata: domain.FileCommonMetadata{Filename: "b.png", SizeBytes: 20, MimeType: "image/png"}}},
+			{File: &domain.File{FilePath: "src/1/c.html", FileCommonMetadata: domain.FileCommonMetadata{Filename: "c.html", SizeBytes: 5, MimeType: "text/plain"}}},
+			{File: &domain.File{FilePath: "src/1/d.exe", ThumbnailPath: &thumb, FileCommonMetadata: domain.FileCommonMetadata{Filename: "d.exe", SizeBytes: 5, MimeType: "text/plain"}}},
+		}
+		source := &MockMirrorSource{
+			threads: []domain.Thread{sourceThread(1, op)},
+			media: map[string]string{
+				"/media/src/1/b.png":       "<html><script>alert(1)</script></html>",
+				"/media/src/1/c.html":      "hello",
+				"/media/src/1/d.exe":       "hello",
+				"/media/src/1/thumb_c.png": "<html></html>",
+			},
+		}
+		media := &SharedMockMediaStorage{}
+		svc := newTestMirror(storage, source, media)
+
+		require.NoError(t, svc.Sync(context.Background(), "b"))
+
+		require.Len(t, update.New, 1)
+		require.Len(t, update.New[0].Attachments, 1)
+		file := update.New[0].Attachments[0].File
+		assert.Equal(t, "b/1/d.txt", file.FilePath, "stored under the extension of the checked type")
+		assert.Nil(t, file.ThumbnailPath)
+		require.Len(t, media.saveFileCalls, 1)
+	})
+
+	t.Run("failed download leaves the thread for the next sync", func(t *testing.T) {
+		storage := &MockMirrorStorage{
+			applyMirrorThreadUpdateFn: func(u domain.MirrorThreadUpdate) error {
+				t.Fatal("thread must not be applied")
+				return nil
+			},
+		}
+		op := message(1, "op")
+		op.Attachments = domain.Attachments{
+			{File: &domain.File{FilePath: "src/1/a.txt", FileCommonMetadata: domain.FileCommonMetadata{Filename: "a.txt", SizeBytes: 5, MimeType: "text/plain"}}},
+		}
+		svc := newTestMirror(storage, &MockMirrorSource{threads: []domain.Thread{sourceThread(1, op)}}, &SharedMockMediaStorage{})
+
+		err := svc.Sync(context.Background(), "b")
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusBadGateway, e.StatusCode)
+		require.Len(t, storage.recordedSyncs, 1)
+		assert.Contains(t, storage.recordedSyncs[0], "connection reset")
+	})
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// ModerationStorage provides the signals the escalation policy counts.
+type ModerationStorage interface {
+	CountDeletedMessagesByAuthor(ctx context.Context, userId domain.UserId, since time.Time) (int, error)
+}
+
+// Moderation wraps MessageService so that every moderator deletion is fed to
+// the auto-ban escalation policy. Everything else is passed through unchanged.
+type Moderation struct {
+	MessageService
+	storage ModerationStorage
+	auth    AuthService
+	policy  EscalationPolicy
+	now     func() time.Time
+}
+
+// NewModeration creates a MessageService that applies the configured
+// auto-ban rules after moderator deletions.
+func NewModeration(message MessageService, storage ModerationStorage, auth AuthService, cfg *config.Public) *Moderation {
+	return &Moderation{
+		MessageService: message,
+		storage:        storage,
+		auth:           auth,
+		policy:         EscalationPolicy{Rules: cfg.AutoBanRules},
+		now:            func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Delete removes the message and, when a moderator deleted it, re-evaluates
+// the author against the escalation rules. Escalation failures are logged but
+// never fail the deletion itself.
+func (m *Moderation) Delete(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
+	msg, err := m.MessageService.Get(ctx, board, threadId, id)
+	if err != nil {
+		return err
+	}
+	if err := m.MessageService.Delete(ctx, board, threadId, id, deletion); err != nil {
+		return err
+	}
+
+	if deletion.DeletedBy == nil || msg.Author.Admin || msg.Author.Id == 0 || msg.Author.IsSystem() {
+		return nil
+	}
+	if err := m.escalate(ctx, msg.Author.Id); err != nil {
+		logger.Log.Error("auto-ban escalation failed", "user_id", msg.Author.Id, "error", err)
+	}
+	return nil
+}
+
+func (m *Moderation) escalate(ctx context.Context, userId domain.UserId) error {
+	now := m.now()
+	decision, err := m.policy.Evaluate(func(signal string, window time.Duration) (int, error) {
+		switch signal {
+		case config.AutoBanSignalDeletedPosts:
+			return m.storage.CountDeletedMessagesByAuthor(ctx, userId, now.Add(-window))
+		default:
+			return 0, fmt.Errorf("unknown auto-ban signal %q", signal)
+		}
+	})
+	if err != nil || decision == nil {
+		return err
+	}
+
+	reason := decision.Rationale()
+	if err := m.auth.BlacklistUserUntil(ctx, userId, reason, now.Add(decision.Rule.BanDuration)); err != nil {
+		return err
+	}
+	logger.Log.Warn("auto-ban applied",
+		"user_id", userId,
+		"signal", decision.Rule.Signal,
+		"count", decision.Count,
+		"window", decision.Rule.Window,
+		"ban_duration", decision.Rule.BanDuration)
+	return nil
+}
+
+// EscalationPolicy turns moderation signals into automatic temporary bans.
+type EscalationPolicy struct {
+	Rules []config.AutoBanRule
+}
+
+// EscalationDecision is the rule that triggered a ban and the count that tripped it.
+type EscalationDecision struct {
+	Rule  config.AutoBanRule
+	Count int
+}
+
+// Rationale is the human-readable ban reason stored with the blacklist entry.
+func (d EscalationDecision) Rationale() string {
+	return fmt.Sprintf("Automatic ban: %d %s within %s (threshold %d)",
+		d.Count, signalDescription(d.Rule.Signal), d.Rule.Window, d.Rule.Threshold)
+}
+
+// Evaluate checks every rule using count to look up how many signals occurred
+// within the rule's window. When several rules match, the one with the longest
+// ban wins. It returns nil when no rule matches.
+func (p EscalationPolicy) Evaluate(count func(signal string, window time.Duration) (int, error)) (*EscalationDecision, error) {
+	var decision *EscalationDecision
+	for _, rule := range p.Rules {
+		n, err := count(rule.Signal, rule.Window)
+		if err != nil {
+			return nil, err
+		}
+		if n < rule.Threshold {
+			continue
+		}
+		if decision == nil || rule.BanDuration > decision.Rule.BanDuration {
+			decision = &EscalationDecision{Rule: rule, Count: n}
+		}
+	}
+	return decision, nil
+}
+
+func signalDescription(signal string) string {
+	switch signal {
+	case config.AutoBanSignalDeletedPosts:
+		return "posts deleted by moderators"
+	default:
+		return signal
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockModerationStorage struct {
+	CountDeletedMessagesByAuthorFunc func(userId domain.UserId, since time.Time) (int, error)
+}
+
+func (m *MockModerationStorage) CountDeletedMessagesByAuthor(ctx context.Context, userId domain.UserId, since time.Time) (int, error) {
+	if m.CountDeletedMessagesByAuthorFunc != nil {
+		return m.CountDeletedMessagesByAuthorFunc(userId, since)
+	}
+	return 0, nil
+}
+
+// mockModerationAuth implements the parts of AuthService the moderation service uses.
+type mockModerationAuth struct {
+	AuthService
+	blacklistUserUntilFunc func(userId domain.UserId, reason string, expiresAt time.Time) error
+}
+
+func (m *mockModerationAuth) BlacklistUserUntil(ctx context.Context, userId domain.UserId, reason string, expiresAt time.Time) error {
+	if m.blacklistUserUntilFunc != nil {
+		return m.blacklistUserUntilFunc(userId, reason, expiresAt)
+	}
+	return nil
+}
+
+// --- Tests ---
+
+func TestEscalationPolicyEvaluate(t *testing.T) {
+	daily := config.AutoBanRule{Signal: config.AutoBanSignalDeletedPosts, Threshold: 3, Window: 24 * time.Hour, BanDuration: 24 * time.Hour}
+	weekly := config.AutoBanRule{Signal: config.AutoBanSignalDeletedPosts, Threshold: 10, Window: 7 * 24 * time.Hour, BanDuration: 7 * 24 * time.Hour}
+	policy := EscalationPolicy{Rules: []config.AutoBanRule{daily, weekly}}
+
+	counts := func(perWindow map[time.Duration]int) func(string, time.Duration) (int, error) {
+		return func(signal string, window time.Duration) (int, error) {
+			assert.Equal(t, config.AutoBanSignalDeletedPosts, signal)
+			return perWindow[window], nil
+		}
+	}
+
+	t.Run("below threshold", func(t *testing.T) {
+		decision, err := policy.Evaluate(counts(map[time.Duration]int{daily.Window: 2, weekly.Window: 9}))
+		require.NoError(t, err)
+		assert.Nil(t, decision)
+	})
+
+	t.Run("threshold is inclusive", func(t *testing.T) {
+		decision, err := policy.Evaluate(counts(map[time.Duration]int{daily.Window: 3, weekly.Window: 3}))
+		require.NoError(t, err)
+		require.NotNil(t, decision)
+		assert.Equal(t, daily, decision.Rule)
+		assert.Equal(t, 3, decision.Count)
+	})
+
+	t.Run("longest ban wins", func(t *testing.T) {
+		decision, err := policy.Evaluate(counts(map[time.Duration]int{daily.Window: 4, weekly.Window: 12}))
+		require.NoError(t, err)
+		require.NotNil(t, decision)
+		assert.Equal(t, weekly, decision.Rule)
+		assert.Equal(t, 12, decision.Count)
+	})
+
+	t.Run("no rules", func(t *testing.T) {
+		decision, err := EscalationPolicy{}.Evaluate(func(string, time.Duration) (int, error) {
+			t.Fatal("no signals should be counted without rules")
+			return 0, nil
+		})
+		require.NoError(t, err)
+		assert.Nil(t, decision)
+	})
+
+	t.Run("count error", func(t *testing.T) {
+		mockErr := errors.New("db down")
+		_, err := policy.Evaluate(func(string, time.Duration) (int, error) { return 0, mockErr })
+		assert.ErrorIs(t, err, mockErr)
+	})
+
+	t.Run("rationale", func(t *testing.T) {
+		d := EscalationDecision{Rule: daily, Count: 3}
+		assert.Equal(t, "Automatic ban: 3 posts deleted by moderators within 24h0m0s (threshold 3)", d.Rationale())
+	})
+}
+
+func TestModerationDelete(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	rule := config.AutoBanRule{Signal: config.AutoBanSignalDeletedPosts, Threshold: 2, Window: time.Hour, BanDuration: 24 * time.Hour}
+	cfg := &config.Public{AutoBanRules: []config.AutoBanRule{rule}}
+	moderatorId := domain.UserId(1)
+	author := domain.User{Id: 42}
+
+	newService := func(message MessageService, storage ModerationStorage, auth AuthService) *Moderation {
+		s := NewModeration(message, storage, auth, cfg)
+		s.now = func() time.Time { return now }
+		return s
+	}
+	messageBy := func(user domain.User) *MockMessageService {
+		return &MockMessageService{
+			getFunc: func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+				return domain.Message{MessageMetadata: domain.MessageMetadata{Author: user}}, nil
+			},
+		}
+	}
+
+	t.Run("threshold reached bans author", func(t *testing.T) {
+		var bannedUser domain.UserId
+		var bannedUntil time.Time
+		var bannedReason string
+		storage := &MockModerationStorage{
+			CountDeletedMessagesByAuthorFunc: func(userId domain.UserId, since time.Time) (int, error) {
+				assert.Equal(t, author.Id, userId)
+				assert.Equal(t, now.Add(-time.Hour), since)
+				return 2, nil
+			},
+		}
+		auth := &mockModerationAuth{blacklistUserUntilFunc: func(userId domain.UserId, reason string, expiresAt time.Time) error {
+			bannedUser, bannedReason, bannedUntil = userId, reason, expiresAt
+			return nil
+		}}
+
+		err := newService(messageBy(author), storage, auth).Delete(context.Background(), "b", 1, 2, domain.MessageDeletionData{DeletedBy: &moderatorId})
+		require.NoError(t, err)
+		assert.Equal(t, author.Id, bannedUser)
+		assert.Equal(t, now.Add(24*time.Hour), bannedUntil)
+		assert.Contains(t, bannedReason, "Automatic ban: 2 posts deleted")
+	})
+
+	t.Run("below threshold keeps author", func(t *testing.T) {
+		storage := &MockModerationStorage{
+			CountDeletedMessagesByAuthorFunc: func(domain.UserId, time.Time) (int, error) { return 1, nil },
+		}
+		auth := &mockModerationAuth{blacklistUserUntilFunc: func(domain.UserId, string, time.Time) error {
+			t.Fatal("author must not be banned below the threshold")
+			return nil
+		}}
+
+		require.NoError(t, newService(messageBy(author), storage, auth).Delete(context.Background(), "b", 1, 2, domain.MessageDeletionData{DeletedBy: &moderatorId}))
+	})
+
+	t.Run("admins and unattributed deletions are not escalated", func(t *testing.T) {
+		storage := &MockModerationStorage{
+			CountDeletedMessagesByAuthorFunc: func(domain.UserId, time.Time) (int, error) {
+				t.Fatal("escalation must not be evaluated")
+				return 0, nil
+			},
+		}
+
+		admin := domain.User{Id: 7, Admin: true}
+		require.NoError(t, newService(messageBy(admin), storage, &mockModerationAuth{}).Delete(context.Background(), "b", 1, 2, domain.MessageDeletionData{DeletedBy: &moderatorId}))
+		require.NoError(t, newService(messageBy(author), storage, &mockModerationAuth{}).Delete(context.Background(), "b", 1, 2, domain.MessageDeletionData{}))
+	})
+
+	t.Run("escalation failure does not fail deletion", func(t *testing.T) {
+		storage := &MockModerationStorage{
+			CountDeletedMessagesByAuthorFunc: func(domain.UserId, time.Time) (int, error) { return 0, errors.New("db down") },
+		}
+
+		require.NoError(t, newService(messageBy(author), storage, &mockModerationAuth{}).Delete(context.Background(), "b", 1, 2, domain.MessageDeletionData{DeletedBy: &moderatorId}))
+	})
+
+	t.Run("delete error is returned before escalation", func(t *testing.T) {
+		mockErr := errors.New("not found")
+		message := messageBy(author)
+		message.deleteFunc = func(domain.BoardShortName, domain.ThreadId, domain.MsgId) error { return mockErr }
+		storage := &MockModerationStorage{
+			CountDeletedMessagesByAuthorFunc: func(domain.UserId, time.Time) (int, error) {
+				t.Fatal("escalation must not run when deletion fails")
+				return 0, nil
+			},
+		}
+
+		err := newService(message, storage, &mockModerationAuth{}).Delete(context.Background(), "b", 1, 2, domain.MessageDeletionData{DeletedBy: &moderatorId})
+		assert.ErrorIs(t, err, mockErr)
+	})
+}
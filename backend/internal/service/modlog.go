@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+)
+
+// ModLogService serves the public moderation log of boards that publish it.
+type ModLogService interface {
+	List(ctx context.Context, board domain.BoardShortName, page int) ([]domain.ModLogEntry, int, error)
+}
+
+// ModLogStorage defines storage interface for the moderation log
+type ModLogStorage interface {
+	GetBoardSettings(ctx context.Context, board domain.BoardShortName) (domain.BoardSettings, error)
+	GetModLog(ctx context.Context, board domain.BoardShortName, limit, offset int) ([]domain.ModLogEntry, int, error)
+}
+
+type ModLog struct {
+	storage ModLogStorage
+	cfg     *config.Public
+}
+
+func NewModLog(storage ModLogStorage, cfg *config.Public) ModLogService {
+	return &ModLog{
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+// List returns a page of the board's log, newest first, and the total number
+// of entries. Boards that keep their log private answer as if it did not exist.
+func (m *ModLog) List(ctx context.Context, board domain.BoardShortName, page int) ([]domain.ModLogEntry, int, error) {
+	settings, err := m.storage.GetBoardSettings(ctx, board)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !settings.PublicModLog {
+		return nil, 0, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Board '%s' does not publish its moderation log", board),
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	page = max(1, page)
+	limit := m.cfg.ModLogPageLimit
+	offset := (page - 1) * limit
+	return m.storage.GetModLog(ctx, board, limit, offset)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockModLogStorage struct {
+	getBoardSettingsFunc func(board domain.BoardShortName) (domain.BoardSettings, error)
+	getModLogFunc        func(board domain.BoardShortName, limit, offset int) ([]domain.ModLogEntry, int, error)
+}
+
+func (m *MockModLogStorage) GetBoardSettings(ctx context.Context, board domain.BoardShortName) (domain.BoardSettings, error) {
+	if m.getBoardSettingsFunc != nil {
+		return m.getBoardSettingsFunc(board)
+	}
+	return domain.BoardSettings{}, nil
+}
+
+func (m *MockModLogStorage) GetModLog(ctx context.Context, board domain.BoardShortName, limit, offset int) ([]domain.ModLogEntry, int, error) {
+	if m.getModLogFunc != nil {
+		return m.getModLogFunc(board, limit, offset)
+	}
+	return nil, 0, nil
+}
+
+func TestModLogList(t *testing.T) {
+	cfg := &config.Public{ModLogPageLimit: 10}
+
+	t.Run("public log is paginated", func(t *testing.T) {
+		entries := []domain.ModLogEntry{{Board: "b", Action: domain.ModLogDeleteMessage, Reason: "spam"}}
+		storage := &MockModLogStorage{
+			getBoardSettingsFunc: func(board domain.BoardShortName) (domain.BoardSettings, error) {
+				return domain.BoardSettings{PublicModLog: true}, nil
+			},
+			getModLogFunc: func(board domain.BoardShortName, limit, offset int) ([]domain.ModLogEntry, int, error) {
+				assert.Equal(t, domain.BoardShortName("b"), board)
+				assert.Equal(t, 10, limit)
+				assert.Equal(t, 10, offset)
+				return entries, 11, nil
+			},
+		}
+
+		got, total, err := NewModLog(storage, cfg).List(context.Background(), "b", 2)
+		require.NoError(t, err)
+		assert.Equal(t, entries, got)
+		assert.Equal(t, 11, total)
+	})
+
+	t.Run("private log is not found", func(t *testing.T) {
+		storage := &MockModLogStorage{
+			getModLogFunc: func(board domain.BoardShortName, limit, offset int) ([]domain.ModLogEntry, int, error) {
+				t.Fatal("private log was read")
+				return nil, 0, nil
+			},
+		}
+
+		_, _, err := NewModLog(storage, cfg).List(context.Background(), "b", 1)
+		requireStatus(t, err, http.StatusNotFound)
+	})
+}
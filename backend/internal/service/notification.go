@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// NotificationService reads and acknowledges a user's notifications. The
+// notifications themselves are created by the storage together with the
+// replies they are about.
+type NotificationService interface {
+	List(ctx context.Context, userId domain.UserId, page int) ([]domain.Notification, int, error)
+	UnreadCount(ctx context.Context, userId domain.UserId) (int, error)
+	MarkRead(ctx context.Context, userId domain.UserId, id domain.NotificationId) error
+	MarkAllRead(ctx context.Context, userId domain.UserId) error
+}
+
+// NotificationStorage defines storage interface for notifications
+type NotificationStorage interface {
+	GetNotifications(ctx context.Context, userId domain.UserId, limit, offset int) ([]domain.Notification, int, error)
+	CountUnreadNotifications(ctx context.Context, userId domain.UserId) (int, error)
+	MarkNotificationRead(ctx context.Context, userId domain.UserId, id domain.NotificationId) error
+	MarkAllNotificationsRead(ctx context.Context, userId domain.UserId) error
+}
+
+type Notification struct {
+	storage NotificationStorage
+	cfg     *config.Public
+}
+
+func NewNotification(storage NotificationStorage, cfg *config.Public) NotificationService {
+	return &Notification{
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+// List returns one page of the user's notifications, newest first, and the
+// total number of notifications.
+func (n *Notification) List(ctx context.Context, userId domain.UserId, page int) ([]domain.Notification, int, error) {
+	page = max(1, page)
+	limit := n.cfg.NotificationsPageLimit
+	offset := (page - 1) * limit
+	return n.storage.GetNotifications(ctx, userId, limit, offset)
+}
+
+func (n *Notification) UnreadCount(ctx context.Context, userId domain.UserId) (int, error) {
+	return n.storage.CountUnreadNotifications(ctx, userId)
+}
+
+func (n *Notification) MarkRead(ctx context.Context, userId domain.UserId, id domain.NotificationId) error {
+	return n.storage.MarkNotificationRead(ctx, userId, id)
+}
+
+func (n *Notification) MarkAllRead(ctx context.Context, userId domain.UserId) error {
+	return n.storage.MarkAllNotificationsRead(ctx, userId)
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockNotificationStorage struct {
+	getNotificationsFunc         func(userId domain.UserId, limit, offset int) ([]domain.Notification, int, error)
+	countUnreadNotificationsFunc func(userId domain.UserId) (int, error)
+	markNotificationReadFunc     func(userId domain.UserId, id domain.NotificationId) error
+	markAllNotificationsReadFunc func(userId domain.UserId) error
+}
+
+func (m *MockNotificationStorage) GetNotifications(ctx context.Context, userId domain.UserId, limit, offset int) ([]domain.Notification, int, error) {
+	if m.getNotificationsFunc != nil {
+		return m.getNotificationsFunc(userId, limit, offset)
+	}
+	return nil, 0, nil
+}
+
+func (m *MockNotificationStorage) CountUnreadNotifications(ctx context.Context, userId domain.UserId) (int, error) {
+	if m.countUnreadNotificationsFunc != nil {
+		return m.countUnreadNotificationsFunc(userId)
+	}
+	return 0, nil
+}
+
+func (m *MockNotificationStorage) MarkNotificationRead(ctx context.Context, userId domain.UserId, id domain.NotificationId) error {
+	if m.markNotificationReadFunc != nil {
+		return m.markNotificationReadFunc(userId, id)
+	}
+	return nil
+}
+
+func (m *MockNotificationStorage) MarkAllNotificationsRead(ctx context.Context, userId domain.UserId) error {
+	if m.markAllNotificationsReadFunc != nil {
+		return m.markAllNotificationsReadFunc(userId)
+	}
+	return nil
+}
+
+// --- Tests ---
+
+func TestNotificationList(t *testing.T) {
+	storage := &MockNotificationStorage{
+		getNotificationsFunc: func(userId domain.UserId, limit, offset int) ([]domain.Notification, int, error) {
+			assert.Equal(t, domain.UserId(7), userId)
+			assert.Equal(t, 20, limit)
+			assert.Equal(t, 20, offset)
+			return []domain.Notification{{Id: 1}}, 21, nil
+		},
+	}
+	svc := NewNotification(storage, &config.Public{NotificationsPageLimit: 20})
+
+	notifications, total, err := svc.List(context.Background(), 7, 2)
+	require.NoError(t, err)
+	assert.Len(t, notifications, 1)
+	assert.Equal(t, 21, total)
+
+	t.Run("page below one is the first page", func(t *testing.T) {
+		storage.getNotificationsFunc = func(_ domain.UserId, _, offset int) ([]domain.Notification, int, error) {
+			assert.Equal(t, 0, offset)
+			return nil, 0, nil
+		}
+		_, _, err := svc.List(context.Background(), 7, -1)
+		require.NoError(t, err)
+	})
+}
+
+func TestNotificationMarkRead(t *testing.T) {
+	called := false
+	storage := &MockNotificationStorage{
+		markNotificationReadFunc: func(userId domain.UserId, id domain.NotificationId) error {
+			called = true
+			assert.Equal(t, domain.UserId(7), userId)
+			assert.Equal(t, domain.NotificationId(3), id)
+			return nil
+		},
+	}
+	svc := NewNotification(storage, &config.Public{})
+
+	require.NoError(t, svc.MarkRead(context.Background(), 7, 3))
+	assert.True(t, called)
+}
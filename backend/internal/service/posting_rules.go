@@ -0,0 +1,40 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+)
+
+// checkPostingRules enforces who may post on a board, which can be narrower
+// than who may read it. Admins are exempt, except on mirrored boards, which
+// only the mirror sync writes to.
+func checkPostingRules(settings domain.BoardSettings, author domain.User) error {
+	if settings.Mirror {
+		return &errors.ErrorWithStatusCode{
+			Message:    "This board is a read-only mirror of a board on another instance",
+			StatusCode: http.StatusForbidden,
+		}
+	}
+	if author.Admin {
+		return nil
+	}
+	if len(settings.PostingEmailDomains) > 0 && !slices.Contains(settings.PostingEmailDomains, author.EmailDomain) {
+		return &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Only accounts with an email at %s can post on this board", strings.Join(settings.PostingEmailDomains, ", ")),
+			StatusCode: http.StatusForbidden,
+		}
+	}
+	if days := settings.PostingMinAccountAgeDays; days > 0 && time.Since(author.CreatedAt) < time.Duration(days)*24*time.Hour {
+		return &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Accounts must be at least %d days old to post on this board", days),
+			StatusCode: http.StatusForbidden,
+		}
+	}
+	return nil
+}
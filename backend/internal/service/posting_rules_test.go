@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPostingRules(t *testing.T) {
+	settings := domain.BoardSettings{PostingEmailDomains: []string{"corp.com"}, PostingMinAccountAgeDays: 7}
+	old := time.Now().Add(-30 * 24 * time.Hour)
+
+	assert.NoError(t, checkPostingRules(domain.BoardSettings{}, domain.User{CreatedAt: time.Now()}), "no rules")
+	assert.NoError(t, checkPostingRules(settings, domain.User{EmailDomain: "corp.com", CreatedAt: old}))
+	assert.NoError(t, checkPostingRules(settings, domain.User{Admin: true, EmailDomain: "other.com", CreatedAt: time.Now()}), "admins are exempt")
+
+	err := checkPostingRules(settings, domain.User{EmailDomain: "other.com", CreatedAt: old})
+	requireStatus(t, err, http.StatusForbidden)
+	assert.Contains(t, err.Error(), "corp.com")
+
+	err = checkPostingRules(settings, domain.User{EmailDomain: "corp.com", CreatedAt: time.Now().Add(-24 * time.Hour)})
+	requireStatus(t, err, http.StatusForbidden)
+	assert.Contains(t, err.Error(), "7 days old")
+
+	err = checkPostingRules(domain.BoardSettings{Mirror: true}, domain.User{Admin: true, CreatedAt: old})
+	requireStatus(t, err, http.StatusForbidden)
+	assert.Contains(t, err.Error(), "read-only mirror", "mirrors refuse admins too")
+}
+
+func TestCreate_PostingRules(t *testing.T) {
+	settings := domain.BoardSettings{PostingEmailDomains: []string{"corp.com"}}
+	outsider := domain.User{Id: 1, EmailDomain: "other.com", CreatedAt: time.Now()}
+
+	t.Run("reply", func(t *testing.T) {
+		storage := &MockMessageStorage{
+			getBoardSettingsFunc: func(board domain.BoardShortName) (domain.BoardSettings, error) { return settings, nil },
+		}
+		service := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+		_, err := service.Create(context.Background(), domain.MessageCreationData{Board: "tst", ThreadId: 1, Author: outsider, Text: "hello"})
+
+		requireStatus(t, err, http.StatusForbidden)
+		assert.False(t, storage.createMessageCalled)
+	})
+
+	t.Run("thread", func(t *testing.T) {
+		createCalled := false
+		storage := &MockThreadStorage{
+			getBoardSettingsFunc: func(board domain.BoardShortName) (domain.BoardSettings, error) { return settings, nil },
+			createThreadFunc: func(creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error) {
+				createCalled = true
+				return 1, time.Now(), nil
+			},
+		}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+		_, err := service.Create(context.Background(), domain.ThreadCreationData{
+			Title: "Title", Board: "tst",
+			OpMessage: domain.MessageCreationData{Author: outsider, Text: "OP"},
+		})
+
+		requireStatus(t, err, http.StatusForbidden)
+		require.False(t, createCalled)
+	})
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"strconv"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// ErrPostQueued is returned by message and thread creation instead of an id
+// when the post was held for approval on a board with pre-moderation.
+var ErrPostQueued = stderrors.New("post is awaiting moderator approval")
+
+// premodQueueLimit caps the queued posts shown to moderators at once; the
+// oldest come first, so approving or rejecting them brings up the rest.
+const premodQueueLimit = 100
+
+// PremodService lets moderators review the posts held back on boards with
+// pre-moderation and override which users' posts skip it.
+type PremodService interface {
+	Queue(ctx context.Context, board domain.BoardShortName) (domain.PremodQueue, error)
+	// Approve publishes a queued post; trust also lets its author skip pre-moderation from now on
+	Approve(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId, moderator domain.UserId, trust bool) (domain.ApprovedPost, error)
+	Reject(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId, moderator domain.UserId) error
+	// SetTrust overrides the board's trust rules for a user; nil goes back to the rules
+	SetTrust(ctx context.Context, board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error
+}
+
+// PremodStorage defines storage interface for the pre-moderation queue
+type PremodStorage interface {
+	GetQueuedPosts(ctx context.Context, board domain.BoardShortName, limit int) ([]domain.QueuedPost, error)
+	ApproveQueuedPost(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId, maxThreadCount *int) (domain.ApprovedPost, error)
+	RejectQueuedPost(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId) error
+	SetPosterTrust(ctx context.Context, board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error
+	GetPremodPosters(ctx context.Context, board domain.BoardShortName) ([]domain.PremodPoster, error)
+}
+
+type Premod struct {
+	storage PremodStorage
+	cfg     *config.Public
+}
+
+func NewPremod(storage PremodStorage, cfg *config.Public) PremodService {
+	return &Premod{
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (s *Premod) Queue(ctx context.Context, board domain.BoardShortName) (domain.PremodQueue, error) {
+	posts, err := s.storage.GetQueuedPosts(ctx, board, premodQueueLimit)
+	if err != nil {
+		return domain.PremodQueue{}, err
+	}
+	posters, err := s.storage.GetPremodPosters(ctx, board)
+	if err != nil {
+		return domain.PremodQueue{}, err
+	}
+	return domain.PremodQueue{Board: board, Posts: posts, Posters: posters}, nil
+}
+
+// Approve publishes the post as it was submitted. The checks of the board's
+// posting rules already ran when it was queued, so they are not repeated.
+func (s *Premod) Approve(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId, moderator domain.UserId, trust bool) (domain.ApprovedPost, error) {
+	approved, err := s.storage.ApproveQueuedPost(ctx, board, id, s.cfg.MaxThreadCount)
+	if err != nil {
+		return domain.ApprovedPost{}, err
+	}
+	logger.Log.Info("queued post approved", "board", board, "queue_id", id, "thread_id", approved.ThreadId, "message_id", approved.Id, "moderator_id", moderator)
+
+	if trust {
+		if err := s.SetTrust(ctx, board, approved.AuthorId, &trust, moderator); err != nil {
+			return domain.ApprovedPost{}, err
+		}
+	}
+	return approved, nil
+}
+
+func (s *Premod) Reject(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId, moderator domain.UserId) error {
+	if err := s.storage.RejectQueuedPost(ctx, board, id); err != nil {
+		return err
+	}
+	logger.Log.Info("queued post rejected", "board", board, "queue_id", id, "moderator_id", moderator)
+	return nil
+}
+
+func (s *Premod) SetTrust(ctx context.Context, board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error {
+	if err := s.storage.SetPosterTrust(ctx, board, userId, trusted, moderator); err != nil {
+		return err
+	}
+	override := "default"
+	if trusted != nil {
+		override = strconv.FormatBool(*trusted)
+	}
+	logger.Log.Info("poster trust override set", "board", board, "user_id", userId, "trusted", override, "moderator_id", moderator)
+	return nil
+}
+
+// heldForApproval reports whether a post by author on board goes to the
+// pre-moderation queue: on boards with pre-moderation, unless the author is
+// a moderator or trusted on the board.
+func heldForApproval(ctx context.Context, storage interface {
+	GetPremodPoster(ctx context.Context, board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error)
+}, board domain.BoardShortName, settings domain.BoardSettings, author domain.User) (bool, error) {
+	if !settings.PreModeration || author.Admin || author.IsSystem() {
+		return false, nil
+	}
+	poster, err := storage.GetPremodPoster(ctx, board, author.Id)
+	if err != nil {
+		return false, err
+	}
+	return !posterTrusted(settings, poster, author), nil
+}
+
+// posterTrusted applies the board's automatic trust rules, a moderator's
+// override taking precedence: users are trusted once enough of their posts
+// were approved or their account is old enough.
+func posterTrusted(settings domain.BoardSettings, poster domain.PremodPoster, author domain.User) bool {
+	if poster.Trusted != nil {
+		return *poster.Trusted
+	}
+	if n := settings.PremodTrustApprovals; n > 0 && poster.Approvals >= n {
+		return true
+	}
+	if days := settings.PremodTrustAccountAgeDays; days > 0 && time.Since(author.CreatedAt) >= time.Duration(days)*24*time.Hour {
+		return true
+	}
+	return false
+}
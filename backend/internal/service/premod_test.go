@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockPremodStorage struct {
+	getQueuedPostsFunc    func(board domain.BoardShortName, limit int) ([]domain.QueuedPost, error)
+	approveQueuedPostFunc func(board domain.BoardShortName, id domain.QueuedPostId, maxThreadCount *int) (domain.ApprovedPost, error)
+	rejectQueuedPostFunc  func(board domain.BoardShortName, id domain.QueuedPostId) error
+	setPosterTrustFunc    func(board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error
+	getPremodPostersFunc  func(board domain.BoardShortName) ([]domain.PremodPoster, error)
+}
+
+func (m *MockPremodStorage) GetQueuedPosts(ctx context.Context, board domain.BoardShortName, limit int) ([]domain.QueuedPost, error) {
+	if m.getQueuedPostsFunc != nil {
+		return m.getQueuedPostsFunc(board, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockPremodStorage) ApproveQueuedPost(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId, maxThreadCount *int) (domain.ApprovedPost, error) {
+	if m.approveQueuedPostFunc != nil {
+		return m.approveQueuedPostFunc(board, id, maxThreadCount)
+	}
+	return domain.ApprovedPost{}, nil
+}
+
+func (m *MockPremodStorage) RejectQueuedPost(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId) error {
+	if m.rejectQueuedPostFunc != nil {
+		return m.rejectQueuedPostFunc(board, id)
+	}
+	return nil
+}
+
+func (m *MockPremodStorage) SetPosterTrust(ctx context.Context, board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error {
+	if m.setPosterTrustFunc != nil {
+		return m.setPosterTrustFunc(board, userId, trusted, moderator)
+	}
+	return nil
+}
+
+func (m *MockPremodStorage) GetPremodPosters(ctx context.Context, board domain.BoardShortName) ([]domain.PremodPoster, error) {
+	if m.getPremodPostersFunc != nil {
+		return m.getPremodPostersFunc(board)
+	}
+	return nil, nil
+}
+
+// --- Tests ---
+
+func TestPremodQueue(t *testing.T) {
+	storage := &MockPremodStorage{
+		getQueuedPostsFunc: func(board domain.BoardShortName, limit int) ([]domain.QueuedPost, error) {
+			assert.Equal(t, domain.BoardShortName("b"), board)
+			assert.Equal(t, premodQueueLimit, limit)
+			return []domain.QueuedPost{{Id: 1}}, nil
+		},
+		getPremodPostersFunc: func(board domain.BoardShortName) ([]domain.PremodPoster, error) {
+			return []domain.PremodPoster{{UserId: 5}}, nil
+		},
+	}
+	svc := NewPremod(storage, &config.Public{})
+
+	queue, err := svc.Queue(context.Background(), "b")
+	require.NoError(t, err)
+	assert.Equal(t, domain.BoardShortName("b"), queue.Board)
+	assert.Len(t, queue.Posts, 1)
+	assert.Len(t, queue.Posters, 1)
+}
+
+func TestPremodApprove(t *testing.T) {
+	maxThreads := 50
+	approved := domain.ApprovedPost{ThreadId: 3, Id: 4, AuthorId: 5}
+
+	t.Run("without trust", func(t *testing.T) {
+		storage := &MockPremodStorage{
+			approveQueuedPostFunc: func(board domain.BoardShortName, id domain.QueuedPostId, maxThreadCount *int) (domain.ApprovedPost, error) {
+				assert.Equal(t, domain.QueuedPostId(7), id)
+				assert.Equal(t, &maxThreads, maxThreadCount)
+				return approved, nil
+			},
+			setPosterTrustFunc: func(board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error {
+				t.Fatal("SetPosterTrust should not be called")
+				return nil
+			},
+		}
+		svc := NewPremod(storage, &config.Public{MaxThreadCount: &maxThreads})
+
+		got, err := svc.Approve(context.Background(), "b", 7, 1, false)
+		require.NoError(t, err)
+		assert.Equal(t, approved, got)
+	})
+
+	t.Run("with trust", func(t *testing.T) {
+		trusted := false
+		storage := &MockPremodStorage{
+			approveQueuedPostFunc: func(board domain.BoardShortName, id domain.QueuedPostId, maxThreadCount *int) (domain.ApprovedPost, error) {
+				return approved, nil
+			},
+			setPosterTrustFunc: func(board domain.BoardShortName, userId domain.UserId, override *bool, moderator domain.UserId) error {
+				assert.Equal(t, approved.AuthorId, userId)
+				assert.Equal(t, domain.UserId(1), moderator)
+				require.NotNil(t, override)
+				trusted = *override
+				return nil
+			},
+		}
+		svc := NewPremod(storage, &config.Public{})
+
+		_, err := svc.Approve(context.Background(), "b", 7, 1, true)
+		require.NoError(t, err)
+		assert.True(t, trusted)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		storage := &MockPremodStorage{
+			approveQueuedPostFunc: func(board domain.BoardShortName, id domain.QueuedPostId, maxThreadCount *int) (domain.ApprovedPost, error) {
+				return domain.ApprovedPost{}, &internal_errors.ErrorWithStatusCode{Message: "Queued post not found", StatusCode: http.StatusNotFound}
+			},
+		}
+		svc := NewPremod(storage, &config.Public{})
+
+		_, err := svc.Approve(context.Background(), "b", 7, 1, true)
+		requireStatus(t, err, http.StatusNotFound)
+	})
+}
+
+func TestMessageCreate_PreModeration(t *testing.T) {
+	author := domain.User{Id: 1, CreatedAt: time.Now().Add(-48 * time.Hour)}
+	message := func(author domain.User) domain.MessageCreationData {
+		return domain.MessageCreationData{Board: "tst", ThreadId: 1, Author: author, Text: "hello"}
+	}
+	newStorage := func(preModeration, trusted bool) *MockMessageStorage {
+		return &MockMessageStorage{
+			getBoardSettingsFunc: func(board domain.BoardShortName) (domain.BoardSettings, error) {
+				return domain.BoardSettings{PreModeration: preModeration}, nil
+			},
+			getPremodPosterFunc: func(board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error) {
+				assert.Equal(t, author.Id, userId)
+				return domain.PremodPoster{Trusted: &trusted}, nil
+			},
+		}
+	}
+
+	t.Run("untrusted poster is queued", func(t *testing.T) {
+		storage := newStorage(true, false)
+		var queued domain.MessageCreationData
+		storage.queuePostFunc = func(creationData domain.MessageCreationData, thread *domain.QueuedThread, attachments domain.Attachments) (domain.QueuedPostId, error) {
+			assert.Nil(t, thread)
+			queued = creationData
+			return 1, nil
+		}
+		svc := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+		_, err := svc.Create(context.Background(), message(author))
+
+		assert.True(t, errors.Is(err, ErrPostQueued))
+		assert.Equal(t, domain.MsgText("hello"), queued.Text)
+		assert.False(t, storage.createMessageCalled)
+	})
+
+	for _, tt := range []struct {
+		name          string
+		preModeration bool
+		trusted       bool
+		author        domain.User
+	}{
+		{"board without pre-moderation", false, false, author},
+		{"trusted poster", true, true, author},
+		{"moderator", true, false, domain.User{Id: 2, Admin: true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newStorage(tt.preModeration, tt.trusted)
+			storage.queuePostFunc = func(creationData domain.MessageCreationData, thread *domain.QueuedThread, attachments domain.Attachments) (domain.QueuedPostId, error) {
+				t.Fatal("QueuePost should not be called")
+				return 0, nil
+			}
+			svc := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+			_, err := svc.Create(context.Background(), message(tt.author))
+
+			require.NoError(t, err)
+			assert.True(t, storage.createMessageCalled)
+		})
+	}
+
+	t.Run("queued thread skips the thread checks", func(t *testing.T) {
+		storage := newStorage(true, false)
+		storage.getThreadOpOnlyFunc = func(board domain.BoardShortName, threadId domain.ThreadId) (bool, *domain.UserId, error) {
+			t.Fatal("GetThreadOpOnly should not be called for a thread that does not exist yet")
+			return false, nil, nil
+		}
+		storage.queuePostFunc = func(creationData domain.MessageCreationData, thread *domain.QueuedThread, attachments domain.Attachments) (domain.QueuedPostId, error) {
+			require.NotNil(t, thread)
+			assert.Equal(t, domain.ThreadTitle("Title"), thread.Title)
+			return 1, nil
+		}
+		svc := NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+		err := svc.QueueThread(context.Background(), domain.MessageCreationData{Board: "tst", Author: author, Text: "OP"}, domain.QueuedThread{Title: "Title"})
+
+		assert.True(t, errors.Is(err, ErrPostQueued))
+	})
+}
+
+func TestThreadCreate_PreModeration(t *testing.T) {
+	author := domain.User{Id: 7}
+	data := domain.ThreadCreationData{
+		Title: "Title", Board: "tst", OpOnly: true,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "OP"},
+	}
+	newStorage := func(trusted bool, created *bool) *MockThreadStorage {
+		return &MockThreadStorage{
+			getBoardSettingsFunc: func(board domain.BoardShortName) (domain.BoardSettings, error) {
+				return domain.BoardSettings{PreModeration: true}, nil
+			},
+			getPremodPosterFunc: func(board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error) {
+				return domain.PremodPoster{Trusted: &trusted}, nil
+			},
+			createThreadFunc: func(creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error) {
+				*created = true
+				return 1, time.Now(), nil
+			},
+		}
+	}
+
+	t.Run("untrusted poster is queued", func(t *testing.T) {
+		created := false
+		messageService := &MockMessageService{
+			queueThreadFunc: func(creationData domain.MessageCreationData, thread domain.QueuedThread) error {
+				assert.Equal(t, domain.BoardShortName("tst"), creationData.Board)
+				assert.Equal(t, domain.QueuedThread{Title: "Title", OpOnly: true}, thread)
+				return ErrPostQueued
+			},
+		}
+		svc := NewThread(newStorage(false, &created), &MockThreadValidator{}, messageService, &SharedMockMediaStorage{}, &config.Public{})
+
+		_, err := svc.Create(context.Background(), data)
+
+		assert.True(t, errors.Is(err, ErrPostQueued))
+		assert.False(t, created, "CreateThread should not be called for a queued thread")
+	})
+
+	t.Run("trusted poster", func(t *testing.T) {
+		created := false
+		svc := NewThread(newStorage(true, &created), &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+		_, err := svc.Create(context.Background(), data)
+
+		require.NoError(t, err)
+		assert.True(t, created)
+	})
+}
+
+func TestPosterTrusted(t *testing.T) {
+	yes, no := true, false
+	veteran := domain.User{Id: 1, CreatedAt: time.Now().Add(-40 * 24 * time.Hour)}
+	newcomer := domain.User{Id: 2, CreatedAt: time.Now().Add(-time.Hour)}
+	rules := domain.BoardSettings{PreModeration: true, PremodTrustApprovals: 3, PremodTrustAccountAgeDays: 30}
+
+	tests := []struct {
+		name     string
+		settings domain.BoardSettings
+		poster   domain.PremodPoster
+		author   domain.User
+		want     bool
+	}{
+		{"no rules", domain.BoardSettings{PreModeration: true}, domain.PremodPoster{Approvals: 10}, veteran, false},
+		{"too few approvals", rules, domain.PremodPoster{Approvals: 2}, newcomer, false},
+		{"enough approvals", rules, domain.PremodPoster{Approvals: 3}, newcomer, true},
+		{"old account", rules, domain.PremodPoster{}, veteran, true},
+		{"trusted by moderator", rules, domain.PremodPoster{Trusted: &yes}, newcomer, true},
+		{"held by moderator", rules, domain.PremodPoster{Approvals: 5, Trusted: &no}, veteran, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, posterTrusted(tt.settings, tt.poster, tt.author))
+		})
+	}
+}
+
+func TestPremodSetTrust(t *testing.T) {
+	called := false
+	storage := &MockPremodStorage{
+		setPosterTrustFunc: func(board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error {
+			called = true
+			assert.Equal(t, domain.UserId(5), userId)
+			assert.Nil(t, trusted)
+			assert.Equal(t, domain.UserId(1), moderator)
+			return nil
+		},
+	}
+	svc := NewPremod(storage, &config.Public{})
+
+	require.NoError(t, svc.SetTrust(context.Background(), "b", 5, nil, 1))
+	assert.True(t, called)
+}
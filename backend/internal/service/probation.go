@@ -0,0 +1,44 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+)
+
+// onProbation reports whether the account is younger than NewAccountAge.
+// Admins are never on probation.
+func onProbation(cfg *config.Public, user domain.User) bool {
+	return cfg.NewAccountAge > 0 && !user.Admin && time.Since(user.CreatedAt) < cfg.NewAccountAge
+}
+
+// probationForbidden is returned when an account on probation attempts
+// something only established accounts may do.
+func probationForbidden(cfg *config.Public, user domain.User, action string) error {
+	remaining := time.Until(user.CreatedAt.Add(cfg.NewAccountAge)).Round(time.Minute)
+	return &errors.ErrorWithStatusCode{
+		Message:    fmt.Sprintf("Accounts younger than %s cannot %s. Try again in %s", cfg.NewAccountAge, action, remaining),
+		StatusCode: http.StatusForbidden,
+	}
+}
+
+// probationCooldown enforces NewAccountPostCooldown given the time of the
+// user's previous post (nil if they have not posted yet).
+func probationCooldown(cfg *config.Public, lastPost *time.Time) error {
+	if cfg.NewAccountPostCooldown <= 0 || lastPost == nil {
+		return nil
+	}
+	wait := time.Until(lastPost.Add(cfg.NewAccountPostCooldown))
+	if wait <= 0 {
+		return nil
+	}
+	return &errors.ErrorWithStatusCode{
+		Message: fmt.Sprintf("New accounts can post once every %s. Try again in %s",
+			cfg.NewAccountPostCooldown, wait.Round(time.Second)),
+		StatusCode: http.StatusTooManyRequests,
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageCreate_Probation(t *testing.T) {
+	newcomer := domain.User{Id: 1, CreatedAt: time.Now().Add(-time.Hour)}
+	veteran := domain.User{Id: 2, CreatedAt: time.Now().Add(-48 * time.Hour)}
+	admin := domain.User{Id: 3, Admin: true, CreatedAt: time.Now()}
+
+	newService := func(storage *MockMessageStorage) MessageService {
+		cfg := createDefaultTestConfig()
+		cfg.NewAccountAge = 24 * time.Hour
+		cfg.NewAccountPostCooldown = time.Minute
+		return NewMessage(storage, &MockMessageValidator{}, &SharedMockMediaStorage{}, cfg)
+	}
+	textMessage := func(author domain.User) domain.MessageCreationData {
+		return domain.MessageCreationData{Board: "tst", ThreadId: 1, Author: author, Text: "hello"}
+	}
+
+	t.Run("attachments are rejected", func(t *testing.T) {
+		storage := &MockMessageStorage{}
+		data := textMessage(newcomer)
+		data.PendingFiles = []*domain.PendingFile{{
+			FileCommonMetadata: domain.FileCommonMetadata{Filename: "test.jpg", SizeBytes: 1, MimeType: "image/jpeg"},
+			Data:               bytes.NewReader([]byte{0}),
+		}}
+
+		_, err := newService(storage).Create(context.Background(), data)
+
+		requireStatus(t, err, http.StatusForbidden)
+		assert.Contains(t, err.Error(), "cannot upload attachments")
+		assert.False(t, storage.createMessageCalled)
+	})
+
+	t.Run("cooldown is enforced", func(t *testing.T) {
+		storage := &MockMessageStorage{
+			getLastMessageTimeFunc: func(userId domain.UserId) (*time.Time, error) {
+				assert.Equal(t, newcomer.Id, userId)
+				last := time.Now().Add(-10 * time.Second)
+				return &last, nil
+			},
+		}
+
+		_, err := newService(storage).Create(context.Background(), textMessage(newcomer))
+
+		requireStatus(t, err, http.StatusTooManyRequests)
+		assert.False(t, storage.createMessageCalled)
+	})
+
+	t.Run("cooldown elapsed", func(t *testing.T) {
+		storage := &MockMessageStorage{
+			getLastMessageTimeFunc: func(userId domain.UserId) (*time.Time, error) {
+				last := time.Now().Add(-2 * time.Minute)
+				return &last, nil
+			},
+		}
+
+		_, err := newService(storage).Create(context.Background(), textMessage(newcomer))
+
+		require.NoError(t, err)
+		assert.True(t, storage.createMessageCalled)
+	})
+
+	t.Run("established accounts and admins are exempt", func(t *testing.T) {
+		for _, author := range []domain.User{veteran, admin} {
+			storage := &MockMessageStorage{
+				getLastMessageTimeFunc: func(userId domain.UserId) (*time.Time, error) {
+					t.Fatal("GetLastMessageTime should not be called")
+					return nil, nil
+				},
+			}
+
+			_, err := newService(storage).Create(context.Background(), textMessage(author))
+
+			require.NoError(t, err)
+		}
+	})
+}
+
+func TestThreadCreate_Probation(t *testing.T) {
+	cfg := createDefaultTestConfig()
+	cfg.NewAccountAge = 24 * time.Hour
+
+	t.Run("new accounts cannot create threads", func(t *testing.T) {
+		createCalled := false
+		storage := &MockThreadStorage{
+			createThreadFunc: func(creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error) {
+				createCalled = true
+				return 1, time.Now(), nil
+			},
+		}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, cfg)
+
+		_, err := service.Create(context.Background(), domain.ThreadCreationData{
+			Title: "Title", Board: "tst",
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: 1, CreatedAt: time.Now()}, Text: "OP"},
+		})
+
+		requireStatus(t, err, http.StatusForbidden)
+		assert.Contains(t, err.Error(), "cannot create threads")
+		assert.False(t, createCalled)
+	})
+
+	t.Run("disabled when age is zero", func(t *testing.T) {
+		storage := &MockThreadStorage{}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, createDefaultTestConfig())
+
+		_, err := service.Create(context.Background(), domain.ThreadCreationData{
+			Title: "Title", Board: "tst",
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: 1, CreatedAt: time.Now()}, Text: "OP"},
+		})
+
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+	sharedutils "github.com/itchan-dev/itchan/shared/utils"
+)
+
+const (
+	pushSnippetLen = 120              // Runes of the reply shown in the notification
+	pushTimeout    = 30 * time.Second // Limit for delivering one reply to all recipients
+)
+
+// PushService manages the browser push subscriptions of a user.
+type PushService interface {
+	Subscribe(ctx context.Context, sub domain.PushSubscription) error
+	Unsubscribe(ctx context.Context, userId domain.UserId, endpoint string) error
+}
+
+// PushStorage defines storage interface for browser push subscriptions
+type PushStorage interface {
+	SavePushSubscription(ctx context.Context, sub domain.PushSubscription) error
+	DeletePushSubscription(ctx context.Context, userId domain.UserId, endpoint string) error
+	// GetReplyPushSubscriptions returns the subscriptions of the users notified
+	// about the message, unless its author is shadowbanned on the board.
+	GetReplyPushSubscriptions(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) ([]domain.PushSubscription, error)
+}
+
+// PushSender delivers an encrypted push message to a browser. An expired
+// subscription is reported as an error with status 410 Gone.
+type PushSender interface {
+	Send(ctx context.Context, sub domain.PushSubscription, payload []byte) error
+}
+
+// pushPayload is what the service worker (static/js/push-sw.js) receives
+type pushPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+}
+
+// Push stores subscriptions and sends reply notifications to them. A nil
+// sender means push notifications are not configured.
+type Push struct {
+	storage PushStorage
+	sender  PushSender
+	cfg     *config.Public
+}
+
+func NewPush(storage PushStorage, sender PushSender, cfg *config.Public) *Push {
+	return &Push{
+		storage: storage,
+		sender:  sender,
+		cfg:     cfg,
+	}
+}
+
+// Subscribe saves a browser subscription for the user, replacing any earlier
+// subscription of the same browser.
+func (p *Push) Subscribe(ctx context.Context, sub domain.PushSubscription) error {
+	if p.sender == nil {
+		return &internal_errors.ErrorWithStatusCode{Message: "Push notifications are disabled", StatusCode: http.StatusNotFound}
+	}
+	if u, err := url.Parse(sub.Endpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+		return &internal_errors.ErrorWithStatusCode{Message: "Push endpoint must be an https URL", StatusCode: http.StatusBadRequest}
+	}
+	if !validKey(sub.P256dh, 65) || !validKey(sub.Auth, 16) {
+		return &internal_errors.ErrorWithStatusCode{Message: "Invalid push subscription keys", StatusCode: http.StatusBadRequest}
+	}
+	return p.storage.SavePushSubscription(ctx, sub)
+}
+
+// Unsubscribe removes one of the user's subscriptions. Removing an unknown
+// subscription is a no-op.
+func (p *Push) Unsubscribe(ctx context.Context, userId domain.UserId, endpoint string) error {
+	return p.storage.DeletePushSubscription(ctx, userId, endpoint)
+}
+
+// NotifyReply pushes a new message to the browsers of the users it replies
+// to. Delivery failures are logged; subscriptions the push service no longer
+// knows are removed.
+func (p *Push) NotifyReply(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId, text domain.MsgText) {
+	subs, err := p.storage.GetReplyPushSubscriptions(ctx, board, threadId, msgId)
+	if err != nil {
+		logger.Log.Error("failed to get push subscriptions", "board", board, "thread_id", threadId, "msg_id", msgId, "error", err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(pushPayload{
+		Title: fmt.Sprintf("New reply on /%s/", board),
+		Body:  pushSnippet(text),
+		URL:   fmt.Sprintf("/%s/%d?page=%d#p%d", board, threadId, sharedutils.CalculatePage(int(msgId), p.cfg.MessagesPerThreadPage), msgId),
+	})
+	if err != nil {
+		logger.Log.Error("failed to encode push payload", "error", err)
+		return
+	}
+
+	for _, sub := range subs {
+		err := p.sender.Send(ctx, sub, payload)
+		if err == nil {
+			continue
+		}
+		var e *internal_errors.ErrorWithStatusCode
+		if errors.As(err, &e) && e.StatusCode == http.StatusGone {
+			if err := p.storage.DeletePushSubscription(ctx, sub.UserId, sub.Endpoint); err != nil {
+				logger.Log.Error("failed to delete expired push subscription", "user_id", sub.UserId, "error", err)
+			}
+			continue
+		}
+		logger.Log.Warn("failed to send push notification", "user_id", sub.UserId, "error", err)
+	}
+}
+
+// ReplyPush wraps MessageService so that the authors of the messages a new
+// message replies to get a browser push notification. Everything else is
+// passed through unchanged.
+type ReplyPush struct {
+	MessageService
+	push *Push
+}
+
+func NewReplyPush(message MessageService, push *Push) *ReplyPush {
+	return &ReplyPush{
+		MessageService: message,
+		push:           push,
+	}
+}
+
+// Create creates the message and, if it replies to anyone, sends the push
+// notifications in the background so posting never waits for push services.
+func (r *ReplyPush) Create(ctx context.Context, creationData domain.MessageCreationData) (domain.MsgId, error) {
+	msgId, err := r.MessageService.Create(ctx, creationData)
+	if err != nil || creationData.ReplyTo == nil || len(*creationData.ReplyTo) == 0 {
+		return msgId, err
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+		defer cancel()
+		r.push.NotifyReply(ctx, creationData.Board, creationData.ThreadId, msgId, creationData.Text)
+	}()
+	return msgId, nil
+}
+
+// validKey reports whether s is a base64url key of the given length
+func validKey(s string, length int) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	return err == nil && len(raw) == length
+}
+
+func pushSnippet(text domain.MsgText) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= pushSnippetLen {
+		return text
+	}
+	return string([]rune(text)[:pushSnippetLen]) + "…"
+}
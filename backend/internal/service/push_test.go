@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockPushStorage struct {
+	savePushSubscriptionFunc      func(sub domain.PushSubscription) error
+	deletePushSubscriptionFunc    func(userId domain.UserId, endpoint string) error
+	getReplyPushSubscriptionsFunc func(board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) ([]domain.PushSubscription, error)
+}
+
+func (m *MockPushStorage) SavePushSubscription(ctx context.Context, sub domain.PushSubscription) error {
+	if m.savePushSubscriptionFunc != nil {
+		return m.savePushSubscriptionFunc(sub)
+	}
+	return nil
+}
+
+func (m *MockPushStorage) DeletePushSubscription(ctx context.Context, userId domain.UserId, endpoint string) error {
+	if m.deletePushSubscriptionFunc != nil {
+		return m.deletePushSubscriptionFunc(userId, endpoint)
+	}
+	return nil
+}
+
+func (m *MockPushStorage) GetReplyPushSubscriptions(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) ([]domain.PushSubscription, error) {
+	if m.getReplyPushSubscriptionsFunc != nil {
+		return m.getReplyPushSubscriptionsFunc(board, threadId, msgId)
+	}
+	return nil, nil
+}
+
+type MockPushSender struct {
+	sendFunc func(sub domain.PushSubscription, payload []byte) error
+}
+
+func (m *MockPushSender) Send(ctx context.Context, sub domain.PushSubscription, payload []byte) error {
+	if m.sendFunc != nil {
+		return m.sendFunc(sub, payload)
+	}
+	return nil
+}
+
+type MockCreateMessageService struct {
+	MessageService
+	createFunc func(creationData domain.MessageCreationData) (domain.MsgId, error)
+}
+
+func (m *MockCreateMessageService) Create(ctx context.Context, creationData domain.MessageCreationData) (domain.MsgId, error) {
+	return m.createFunc(creationData)
+}
+
+// --- Tests ---
+
+func TestPushSubscribe(t *testing.T) {
+	ctx := context.Background()
+	valid := domain.PushSubscription{
+		UserId:   7,
+		Endpoint: "https://push.example.com/abc",
+		P256dh:   base64.RawURLEncoding.EncodeToString(make([]byte, 65)),
+		Auth:     base64.URLEncoding.EncodeToString(make([]byte, 16)),
+	}
+
+	t.Run("saves a valid subscription", func(t *testing.T) {
+		var saved domain.PushSubscription
+		storage := &MockPushStorage{
+			savePushSubscriptionFunc: func(sub domain.PushSubscription) error {
+				saved = sub
+				return nil
+			},
+		}
+		p := NewPush(storage, &MockPushSender{}, &config.Public{})
+
+		require.NoError(t, p.Subscribe(ctx, valid))
+		assert.Equal(t, valid, saved)
+	})
+
+	t.Run("invalid subscriptions", func(t *testing.T) {
+		p := NewPush(&MockPushStorage{}, &MockPushSender{}, &config.Public{})
+
+		plain := valid
+		plain.Endpoint = "http://push.example.com/abc"
+		requireStatus(t, p.Subscribe(ctx, plain), http.StatusBadRequest)
+
+		shortKey := valid
+		shortKey.P256dh = base64.RawURLEncoding.EncodeToString(make([]byte, 32))
+		requireStatus(t, p.Subscribe(ctx, shortKey), http.StatusBadRequest)
+
+		badAuth := valid
+		badAuth.Auth = "not base64!"
+		requireStatus(t, p.Subscribe(ctx, badAuth), http.StatusBadRequest)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		p := NewPush(&MockPushStorage{}, nil, &config.Public{})
+		requireStatus(t, p.Subscribe(ctx, valid), http.StatusNotFound)
+	})
+}
+
+func TestPushNotifyReply(t *testing.T) {
+	subs := []domain.PushSubscription{
+		{UserId: 1, Endpoint: "https://push.example.com/one"},
+		{UserId: 2, Endpoint: "https://push.example.com/gone"},
+		{UserId: 3, Endpoint: "https://push.example.com/down"},
+	}
+	storage := &MockPushStorage{
+		getReplyPushSubscriptionsFunc: func(board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) ([]domain.PushSubscription, error) {
+			assert.Equal(t, "b", board)
+			assert.Equal(t, domain.ThreadId(5), threadId)
+			assert.Equal(t, domain.MsgId(12), msgId)
+			return subs, nil
+		},
+	}
+	var deleted []string
+	storage.deletePushSubscriptionFunc = func(userId domain.UserId, endpoint string) error {
+		deleted = append(deleted, endpoint)
+		return nil
+	}
+	var payloads []pushPayload
+	sender := &MockPushSender{
+		sendFunc: func(sub domain.PushSubscription, payload []byte) error {
+			switch sub.UserId {
+			case 2:
+				return &internal_errors.ErrorWithStatusCode{Message: "gone", StatusCode: http.StatusGone}
+			case 3:
+				return errors.New("push service unavailable")
+			}
+			var p pushPayload
+			require.NoError(t, json.Unmarshal(payload, &p))
+			payloads = append(payloads, p)
+			return nil
+		},
+	}
+	p := NewPush(storage, sender, &config.Public{MessagesPerThreadPage: 10})
+
+	p.NotifyReply(context.Background(), "b", 5, 12, "  a\nlong "+strings.Repeat("x", 200))
+
+	require.Len(t, payloads, 1)
+	assert.Equal(t, "New reply on /b/", payloads[0].Title)
+	assert.Equal(t, "/b/5?page=2#p12", payloads[0].URL)
+	assert.True(t, strings.HasPrefix(payloads[0].Body, "a long xxx"))
+	assert.Equal(t, pushSnippetLen+1, len([]rune(payloads[0].Body)), "snippet plus ellipsis")
+	assert.Equal(t, []string{"https://push.example.com/gone"}, deleted, "only expired subscriptions are removed")
+}
+
+func TestReplyPushCreate(t *testing.T) {
+	ctx := context.Background()
+	pushed := make(chan domain.MsgId, 1)
+	storage := &MockPushStorage{
+		getReplyPushSubscriptionsFunc: func(board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) ([]domain.PushSubscription, error) {
+			pushed <- msgId
+			return nil, nil
+		},
+	}
+	message := &MockCreateMessageService{
+		createFunc: func(creationData domain.MessageCreationData) (domain.MsgId, error) { return 9, nil },
+	}
+	r := NewReplyPush(message, NewPush(storage, &MockPushSender{}, &config.Public{}))
+
+	t.Run("reply is pushed", func(t *testing.T) {
+		msgId, err := r.Create(ctx, domain.MessageCreationData{Board: "b", ThreadId: 5, ReplyTo: &domain.Replies{{To: 1, ToThreadId: 5}}})
+		require.NoError(t, err)
+		assert.Equal(t, domain.MsgId(9), msgId)
+		select {
+		case id := <-pushed:
+			assert.Equal(t, domain.MsgId(9), id)
+		case <-time.After(time.Second):
+			t.Fatal("reply was not pushed")
+		}
+	})
+
+	t.Run("messages without replies are not", func(t *testing.T) {
+		_, err := r.Create(ctx, domain.MessageCreationData{Board: "b", ThreadId: 5})
+		require.NoError(t, err)
+		select {
+		case <-pushed:
+			t.Fatal("unexpected push")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
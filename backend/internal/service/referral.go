@@ -1,19 +1,20 @@
 package service
 
 import (
+	"context"
 	"strings"
 
 	"github.com/itchan-dev/itchan/shared/domain"
 )
 
 type ReferralService interface {
-	RecordAction(source, action, ip string) error
-	GetStats() ([]domain.ReferralActionStats, error)
+	RecordAction(ctx context.Context, source, action, ip string) error
+	GetStats(ctx context.Context) ([]domain.ReferralActionStats, error)
 }
 
 type ReferralStorage interface {
-	SaveReferralAction(source, action, ip string) error
-	GetReferralActionStats() ([]domain.ReferralActionStats, error)
+	SaveReferralAction(ctx context.Context, source, action, ip string) error
+	GetReferralActionStats(ctx context.Context) ([]domain.ReferralActionStats, error)
 }
 
 type Referral struct {
@@ -24,13 +25,13 @@ func NewReferral(storage ReferralStorage) *Referral {
 	return &Referral{storage: storage}
 }
 
-func (s *Referral) RecordAction(source, action, ip string) error {
+func (s *Referral) RecordAction(ctx context.Context, source, action, ip string) error {
 	source = sanitizeSource(source)
-	return s.storage.SaveReferralAction(source, action, ip)
+	return s.storage.SaveReferralAction(ctx, source, action, ip)
 }
 
-func (s *Referral) GetStats() ([]domain.ReferralActionStats, error) {
-	return s.storage.GetReferralActionStats()
+func (s *Referral) GetStats(ctx context.Context) ([]domain.ReferralActionStats, error) {
+	return s.storage.GetReferralActionStats(ctx)
 }
 
 func sanitizeSource(source string) string {
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// ShadowbanService manages per-board shadowbans. A shadowbanned user keeps
+// posting as usual, but only they and admins can see those messages.
+type ShadowbanService interface {
+	Shadowban(ctx context.Context, board domain.BoardShortName, userId, moderator domain.UserId) error
+	Unshadowban(ctx context.Context, board domain.BoardShortName, userId domain.UserId) error
+	List(ctx context.Context, page int) ([]domain.Shadowban, error)
+	// Hides reports whether a message by author on board must be hidden from viewer.
+	Hides(ctx context.Context, board domain.BoardShortName, author domain.UserId, viewer *domain.User) (bool, error)
+	// FilterMessage prepares a single message for viewer like a thread page
+	// does: it drops hidden reply links and reports false when the message
+	// itself is hidden.
+	FilterMessage(ctx context.Context, board domain.BoardShortName, msg *domain.Message, viewer *domain.User) (bool, error)
+}
+
+// ShadowbanStorage defines storage interface for shadowbans
+type ShadowbanStorage interface {
+	ShadowbanUser(ctx context.Context, board domain.BoardShortName, userId, createdBy domain.UserId) error
+	UnshadowbanUser(ctx context.Context, board domain.BoardShortName, userId domain.UserId) error
+	GetShadowbans(ctx context.Context, limit, offset int) ([]domain.Shadowban, error)
+	GetShadowbannedUsers(ctx context.Context, board domain.BoardShortName) ([]domain.UserId, error)
+}
+
+type Shadowban struct {
+	storage ShadowbanStorage
+	cfg     *config.Public
+}
+
+func NewShadowban(storage ShadowbanStorage, cfg *config.Public) ShadowbanService {
+	return &Shadowban{
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (s *Shadowban) Shadowban(ctx context.Context, board domain.BoardShortName, userId, moderator domain.UserId) error {
+	if err := s.storage.ShadowbanUser(ctx, board, userId, moderator); err != nil {
+		return err
+	}
+	logger.Log.Info("user shadowbanned", "board", board, "user_id", userId, "moderator_id", moderator)
+	return nil
+}
+
+func (s *Shadowban) Unshadowban(ctx context.Context, board domain.BoardShortName, userId domain.UserId) error {
+	if err := s.storage.UnshadowbanUser(ctx, board, userId); err != nil {
+		return err
+	}
+	logger.Log.Info("shadowban lifted", "board", board, "user_id", userId)
+	return nil
+}
+
+func (s *Shadowban) List(ctx context.Context, page int) ([]domain.Shadowban, error) {
+	page = max(1, page)
+	limit := s.cfg.ShadowbansPageLimit
+	offset := (page - 1) * limit
+	return s.storage.GetShadowbans(ctx, limit, offset)
+}
+
+func (s *Shadowban) Hides(ctx context.Context, board domain.BoardShortName, author domain.UserId, viewer *domain.User) (bool, error) {
+	hidden, err := loadShadowbanned(ctx, s.storage, board)
+	if err != nil {
+		return false, err
+	}
+	return hidden.hides(author, viewer), nil
+}
+
+func (s *Shadowban) FilterMessage(ctx context.Context, board domain.BoardShortName, msg *domain.Message, viewer *domain.User) (bool, error) {
+	hidden, err := loadShadowbanned(ctx, s.storage, board)
+	if err != nil {
+		return false, err
+	}
+	return len(hidden.filterMessages([]*domain.Message{msg}, viewer)) == 1, nil
+}
+
+// shadowbanned is the set of users shadowbanned on one board.
+type shadowbanned map[domain.UserId]struct{}
+
+func loadShadowbanned(ctx context.Context, storage interface {
+	GetShadowbannedUsers(ctx context.Context, board domain.BoardShortName) ([]domain.UserId, error)
+}, board domain.BoardShortName) (shadowbanned, error) {
+	userIds, err := storage.GetShadowbannedUsers(ctx, board)
+	if err != nil {
+		return nil, err
+	}
+	set := make(shadowbanned, len(userIds))
+	for _, id := range userIds {
+		set[id] = struct{}{}
+	}
+	return set, nil
+}
+
+func (s shadowbanned) contains(userId domain.UserId) bool {
+	_, ok := s[userId]
+	return ok
+}
+
+// hides reports whether the author's messages are hidden from viewer.
+// Admins and the author themselves always see them.
+func (s shadowbanned) hides(author domain.UserId, viewer *domain.User) bool {
+	if viewer != nil && (viewer.Admin || viewer.Id == author) {
+		return false
+	}
+	return s.contains(author)
+}
+
+// filterThread removes messages and reply links hidden from viewer. Messages
+// that stay visible only because viewer is an admin are marked Shadowbanned.
+// A thread started by a hidden user is reported as not found.
+func (s shadowbanned) filterThread(thread *domain.Thread, viewer *domain.User) error {
+	if len(s) == 0 {
+		return nil
+	}
+	if len(thread.Messages) > 0 && thread.Messages[0].IsOp() && s.hides(thread.Messages[0].Author.Id, viewer) {
+		return &errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+	}
+
+	thread.Messages = s.filterMessages(thread.Messages, viewer)
+	return nil
+}
+
+// filterMessages drops the messages and reply links hidden from viewer, and
+// marks messages admins see only because they are admins. The slice is
+// filtered in place.
+func (s shadowbanned) filterMessages(messages []*domain.Message, viewer *domain.User) []*domain.Message {
+	visible := messages[:0]
+	for _, msg := range messages {
+		if s.hides(msg.Author.Id, viewer) {
+			continue
+		}
+		msg.Shadowbanned = viewer != nil && viewer.Admin && s.contains(msg.Author.Id)
+		msg.Replies = s.filterReplies(msg.Replies, viewer)
+		visible = append(visible, msg)
+	}
+	return visible
+}
+
+// filterReplies drops the reply links sent by users hidden from viewer
+func (s shadowbanned) filterReplies(replies domain.Replies, viewer *domain.User) domain.Replies {
+	visible := replies[:0]
+	for _, reply := range replies {
+		if !s.hides(reply.FromAuthor, viewer) {
+			visible = append(visible, reply)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockShadowbanStorage struct {
+	shadowbanUserFunc        func(board domain.BoardShortName, userId, createdBy domain.UserId) error
+	unshadowbanUserFunc      func(board domain.BoardShortName, userId domain.UserId) error
+	getShadowbansFunc        func(limit, offset int) ([]domain.Shadowban, error)
+	getShadowbannedUsersFunc func(board domain.BoardShortName) ([]domain.UserId, error)
+}
+
+func (m *MockShadowbanStorage) ShadowbanUser(ctx context.Context, board domain.BoardShortName, userId, createdBy domain.UserId) error {
+	if m.shadowbanUserFunc != nil {
+		return m.shadowbanUserFunc(board, userId, createdBy)
+	}
+	return nil
+}
+
+func (m *MockShadowbanStorage) UnshadowbanUser(ctx context.Context, board domain.BoardShortName, userId domain.UserId) error {
+	if m.unshadowbanUserFunc != nil {
+		return m.unshadowbanUserFunc(board, userId)
+	}
+	return nil
+}
+
+func (m *MockShadowbanStorage) GetShadowbans(ctx context.Context, limit, offset int) ([]domain.Shadowban, error) {
+	if m.getShadowbansFunc != nil {
+		return m.getShadowbansFunc(limit, offset)
+	}
+	return nil, nil
+}
+
+func (m *MockShadowbanStorage) GetShadowbannedUsers(ctx context.Context, board domain.BoardShortName) ([]domain.UserId, error) {
+	if m.getShadowbannedUsersFunc != nil {
+		return m.getShadowbannedUsersFunc(board)
+	}
+	return nil, nil
+}
+
+// --- Tests ---
+
+func TestShadowbanList(t *testing.T) {
+	storage := &MockShadowbanStorage{
+		getShadowbansFunc: func(limit, offset int) ([]domain.Shadowban, error) {
+			assert.Equal(t, 10, limit)
+			assert.Equal(t, 10, offset)
+			return []domain.Shadowban{{Board: "b", UserId: 1}}, nil
+		},
+	}
+	svc := NewShadowban(storage, &config.Public{ShadowbansPageLimit: 10})
+
+	shadowbans, err := svc.List(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Len(t, shadowbans, 1)
+}
+
+func TestShadowbanHides(t *testing.T) {
+	storage := &MockShadowbanStorage{
+		getShadowbannedUsersFunc: func(board domain.BoardShortName) ([]domain.UserId, error) {
+			assert.Equal(t, domain.BoardShortName("b"), board)
+			return []domain.UserId{5}, nil
+		},
+	}
+	svc := NewShadowban(storage, &config.Public{})
+
+	tests := []struct {
+		name   string
+		author domain.UserId
+		viewer *domain.User
+		want   bool
+	}{
+		{"anonymous viewer", 5, nil, true},
+		{"other user", 5, &domain.User{Id: 6}, true},
+		{"author", 5, &domain.User{Id: 5}, false},
+		{"admin", 5, &domain.User{Id: 7, Admin: true}, false},
+		{"not shadowbanned", 6, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hidden, err := svc.Hides(context.Background(), "b", tt.author, tt.viewer)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, hidden)
+		})
+	}
+}
+
+func TestShadowbanFilterMessage(t *testing.T) {
+	storage := &MockShadowbanStorage{
+		getShadowbannedUsersFunc: func(board domain.BoardShortName) ([]domain.UserId, error) {
+			return []domain.UserId{5}, nil
+		},
+	}
+	svc := NewShadowban(storage, &config.Public{})
+	newMessage := func(author domain.UserId) *domain.Message {
+		return &domain.Message{MessageMetadata: domain.MessageMetadata{
+			Author:  domain.User{Id: author},
+			Replies: domain.Replies{{From: 2, FromAuthor: 5}, {From: 3, FromAuthor: 6}},
+		}}
+	}
+
+	t.Run("drops replies of shadowbanned users", func(t *testing.T) {
+		msg := newMessage(6)
+		visible, err := svc.FilterMessage(context.Background(), "b", msg, nil)
+		require.NoError(t, err)
+		assert.True(t, visible)
+		require.Len(t, msg.Replies, 1)
+		assert.Equal(t, domain.MsgId(3), msg.Replies[0].From)
+	})
+
+	t.Run("shadowbanned user sees their own replies", func(t *testing.T) {
+		msg := newMessage(6)
+		visible, err := svc.FilterMessage(context.Background(), "b", msg, &domain.User{Id: 5})
+		require.NoError(t, err)
+		assert.True(t, visible)
+		assert.Len(t, msg.Replies, 2)
+	})
+
+	t.Run("hidden message", func(t *testing.T) {
+		visible, err := svc.FilterMessage(context.Background(), "b", newMessage(5), nil)
+		require.NoError(t, err)
+		assert.False(t, visible)
+	})
+
+	t.Run("admin sees it marked", func(t *testing.T) {
+		msg := newMessage(5)
+		visible, err := svc.FilterMessage(context.Background(), "b", msg, &domain.User{Id: 7, Admin: true})
+		require.NoError(t, err)
+		assert.True(t, visible)
+		assert.True(t, msg.Shadowbanned)
+	})
+}
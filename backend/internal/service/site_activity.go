@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// SiteActivityService provides the data for the index page activity widgets.
+type SiteActivityService interface {
+	Get(ctx context.Context, viewer *domain.User) (domain.SiteActivity, error)
+}
+
+// SiteActivityStorage defines storage interface for site activity queries
+type SiteActivityStorage interface {
+	GetBoards(ctx context.Context) ([]domain.BoardMetadata, error)
+	GetSiteActivity(ctx context.Context, boards []domain.BoardShortName, since time.Time, postsLimit, threadsLimit int) (domain.SiteActivity, error)
+}
+
+// SiteActivity implements SiteActivityService.
+// Results are cached per set of visible boards, so viewers sharing an email
+// domain (or anonymous viewers) share one cache entry.
+type SiteActivity struct {
+	storage SiteActivityStorage
+	cfg     *config.Public
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]domain.SiteActivity
+}
+
+// NewSiteActivity creates a new SiteActivity service
+func NewSiteActivity(storage SiteActivityStorage, cfg *config.Public) SiteActivityService {
+	return &SiteActivity{
+		storage: storage,
+		cfg:     cfg,
+		now:     func() time.Time { return time.Now().UTC() },
+		cache:   make(map[string]domain.SiteActivity),
+	}
+}
+
+// Get returns activity for the boards the viewer may read. A nil viewer is anonymous.
+func (s *SiteActivity) Get(ctx context.Context, viewer *domain.User) (domain.SiteActivity, error) {
+	boards, err := s.storage.GetBoards(ctx)
+	if err != nil {
+		return domain.SiteActivity{}, err
+	}
+
+	var visible []domain.BoardShortName
+	for _, b := range boards {
+		if canView(viewer, b) {
+			visible = append(visible, b.ShortName)
+		}
+	}
+	slices.Sort(visible)
+	key := strings.Join(visible, ",")
+
+	now := s.now()
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.GeneratedAt) < s.cfg.ActivityCacheTTL {
+		return cached, nil
+	}
+
+	dayStart := now.Truncate(24 * time.Hour)
+	activity, err := s.storage.GetSiteActivity(ctx, visible, dayStart, s.cfg.ActivityLatestPostsLimit, s.cfg.ActivityThreadsLimit)
+	if err != nil {
+		return domain.SiteActivity{}, err
+	}
+	activity.GeneratedAt = now
+
+	s.mu.Lock()
+	s.cache[key] = activity
+	s.mu.Unlock()
+
+	return activity, nil
+}
+
+// canView mirrors the board access middleware: public boards are open to everyone,
+// corporate boards only to admins and users from an allowed email domain.
+func canView(viewer *domain.User, board domain.BoardMetadata) bool {
+	if len(board.AllowedEmailDomains) == 0 {
+		return true
+	}
+	if viewer == nil {
+		return false
+	}
+	return viewer.Admin || slices.Contains(board.AllowedEmailDomains, viewer.EmailDomain)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mock for SiteActivityStorage ---
+
+type MockSiteActivityStorage struct {
+	GetBoardsFunc       func() ([]domain.BoardMetadata, error)
+	GetSiteActivityFunc func(boards []domain.BoardShortName, since time.Time, postsLimit, threadsLimit int) (domain.SiteActivity, error)
+}
+
+func (m *MockSiteActivityStorage) GetBoards(ctx context.Context) ([]domain.BoardMetadata, error) {
+	if m.GetBoardsFunc != nil {
+		return m.GetBoardsFunc()
+	}
+	return []domain.BoardMetadata{}, nil
+}
+
+func (m *MockSiteActivityStorage) GetSiteActivity(ctx context.Context, boards []domain.BoardShortName, since time.Time, postsLimit, threadsLimit int) (domain.SiteActivity, error) {
+	if m.GetSiteActivityFunc != nil {
+		return m.GetSiteActivityFunc(boards, since, postsLimit, threadsLimit)
+	}
+	return domain.SiteActivity{}, nil
+}
+
+// --- Tests ---
+
+func TestSiteActivityGet(t *testing.T) {
+	cfg := &config.Public{ActivityLatestPostsLimit: 10, ActivityThreadsLimit: 5, ActivityCacheTTL: time.Minute}
+	boards := []domain.BoardMetadata{
+		{ShortName: "b"},
+		{ShortName: "corp", AllowedEmailDomains: []string{"corp.com"}},
+		{ShortName: "a"},
+	}
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	newService := func(storage SiteActivityStorage) *SiteActivity {
+		s := NewSiteActivity(storage, cfg).(*SiteActivity)
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("anonymous viewer only sees public boards", func(t *testing.T) {
+		// Arrange
+		var gotBoards []domain.BoardShortName
+		var gotSince time.Time
+		storage := &MockSiteActivityStorage{
+			GetBoardsFunc: func() ([]domain.BoardMetadata, error) { return boards, nil },
+			GetSiteActivityFunc: func(b []domain.BoardShortName, since time.Time, postsLimit, threadsLimit int) (domain.SiteActivity, error) {
+				gotBoards, gotSince = b, since
+				assert.Equal(t, 10, postsLimit)
+				assert.Equal(t, 5, threadsLimit)
+				return domain.SiteActivity{PostsToday: 7}, nil
+			},
+		}
+
+		// Act
+		activity, err := newService(storage).Get(context.Background(), nil)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []domain.BoardShortName{"a", "b"}, gotBoards)
+		assert.Equal(t, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), gotSince)
+		assert.Equal(t, 7, activity.PostsToday)
+		assert.Equal(t, now, activity.GeneratedAt)
+	})
+
+	t.Run("corporate and admin viewers see restricted boards", func(t *testing.T) {
+		for _, viewer := range []*domain.User{
+			{Id: 1, EmailDomain: "corp.com"},
+			{Id: 2, EmailDomain: "other.com", Admin: true},
+		} {
+			var gotBoards []domain.BoardShortName
+			storage := &MockSiteActivityStorage{
+				GetBoardsFunc: func() ([]domain.BoardMetadata, error) { return boards, nil },
+				GetSiteActivityFunc: func(b []domain.BoardShortName, since time.Time, postsLimit, threadsLimit int) (domain.SiteActivity, error) {
+					gotBoards = b
+					return domain.SiteActivity{}, nil
+				},
+			}
+
+			_, err := newService(storage).Get(context.Background(), viewer)
+
+			require.NoError(t, err)
+			assert.Equal(t, []domain.BoardShortName{"a", "b", "corp"}, gotBoards)
+		}
+	})
+
+	t.Run("results are cached per visible board set until TTL", func(t *testing.T) {
+		// Arrange
+		calls := 0
+		storage := &MockSiteActivityStorage{
+			GetBoardsFunc: func() ([]domain.BoardMetadata, error) { return boards, nil },
+			GetSiteActivityFunc: func(b []domain.BoardShortName, since time.Time, postsLimit, threadsLimit int) (domain.SiteActivity, error) {
+				calls++
+				return domain.SiteActivity{PostsToday: calls}, nil
+			},
+		}
+		service := newService(storage)
+
+		// Act & Assert
+		first, err := service.Get(context.Background(), nil)
+		require.NoError(t, err)
+		second, err := service.Get(context.Background(), &domain.User{EmailDomain: "gmail.com"}) // same visible boards
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, first, second)
+
+		_, err = service.Get(context.Background(), &domain.User{EmailDomain: "corp.com"}) // different board set
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+
+		now = now.Add(2 * time.Minute)
+		refreshed, err := service.Get(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 3, refreshed.PostsToday)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		storageErr := errors.New("db down")
+		storage := &MockSiteActivityStorage{
+			GetBoardsFunc: func() ([]domain.BoardMetadata, error) { return nil, storageErr },
+		}
+
+		_, err := newService(storage).Get(context.Background(), nil)
+
+		assert.ErrorIs(t, err, storageErr)
+	})
+}
@@ -1,19 +1,64 @@
 package service
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/itchan-dev/itchan/backend/internal/utils"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/crypto"
 	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
 )
 
+// threadUpdatesLimit caps the messages returned by one GetUpdates call
+const threadUpdatesLimit = 100
+
+// messageLinkTarget matches the target of a >>thread#msg link in message HTML
+var messageLinkTarget = regexp.MustCompile(`data-message-id="(\d+)" data-thread-id="(\d+)"`)
+
+// threadRangeLimit caps the messages returned by one GetRange call, besides the OP
+const threadRangeLimit = 200
+
 type ThreadService interface {
-	// Create returns only ThreadId - OP message always has Id=1
-	Create(creationData domain.ThreadCreationData) (domain.ThreadId, error)
-	Get(board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error)
-	GetLastModified(board domain.BoardShortName, id domain.ThreadId) (time.Time, error)
-	Delete(board domain.BoardShortName, id domain.ThreadId) error
-	TogglePinned(board domain.BoardShortName, id domain.ThreadId) (bool, error)
+	// Create returns only ThreadId - OP message always has Id=1. It returns
+	// ErrPostQueued when the thread was held for pre-moderation
+	Create(ctx context.Context, creationData domain.ThreadCreationData) (domain.ThreadId, error)
+	// Get returns a page of the thread as seen by viewer (nil for anonymous readers)
+	Get(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error)
+	// GetRange returns the OP and the messages with ids from..to (to = 0 means as many as allowed)
+	GetRange(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId, viewer *domain.User) (domain.Thread, error)
+	// GetUpdates returns the messages posted after since, for polling clients
+	GetUpdates(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, viewer *domain.User) (domain.ThreadUpdates, error)
+	// GetGraph returns the reply graph of the thread, for thread maps
+	GetGraph(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadGraph, error)
+	// GetAttachments returns every attachment of the thread, for the gallery view
+	GetAttachments(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadAttachments, error)
+	// Export returns the whole thread as an archive that can be checked for tampering
+	Export(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadArchive, error)
+	GetLastModified(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (time.Time, error)
+	// Delete removes the thread on a moderator's request, reason goes to the moderation log
+	Delete(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, reason string) error
+	// DeletionReport counts what Delete would remove, without changing anything
+	DeletionReport(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.DeletionReport, error)
+	TogglePinned(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (bool, error)
+	// ToggleOpOnly switches whether only the OP and moderators may reply
+	ToggleOpOnly(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (bool, error)
+	// EditTitle retitles the thread: moderators anytime, the OP within the configured window
+	EditTitle(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editor domain.User) error
+	GetTitleHistory(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) ([]domain.ThreadTitleEdit, error)
+	// SetSuccessor marks successor as the continuation of the thread:
+	// moderators for any thread, the OP once the thread is past the bump limit
+	SetSuccessor(ctx context.Context, board domain.BoardShortName, id, successor domain.ThreadId, editor domain.User) error
 }
 
 type Thread struct {
@@ -21,39 +66,76 @@ type Thread struct {
 	validator      ThreadValidator
 	messageService MessageService
 	mediaStorage   MediaStorage
-	maxThreadCount *int
+	cfg            *config.Public
 }
 
 type ThreadStorage interface {
-	CreateThread(creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error)
-	GetThread(board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error)
-	GetThreadLastModified(board domain.BoardShortName, id domain.ThreadId) (time.Time, error)
-	DeleteThread(board domain.BoardShortName, id domain.ThreadId) error
-	TogglePinnedStatus(board domain.BoardShortName, threadId domain.ThreadId) (bool, error)
+	CreateThread(ctx context.Context, creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error)
+	GetThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error)
+	GetThreadRange(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error)
+	GetThreadUpdates(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, limit int) (domain.ThreadUpdates, error)
+	GetThreadGraph(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadGraph, error)
+	GetThreadAttachments(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadAttachments, error)
+	GetThreadArchive(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadArchive, error)
+	GetThreadLastModified(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (time.Time, error)
+	DeleteThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) error
+	GetDeletionReport(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId) (domain.DeletionReport, error)
+	TogglePinnedStatus(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (bool, error)
+	ToggleOpOnlyStatus(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (bool, error)
+	UpdateThreadTitle(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editedBy domain.UserId, byModerator bool) error
+	GetThreadTitleEdits(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) ([]domain.ThreadTitleEdit, error)
+	SetThreadSuccessor(ctx context.Context, board domain.BoardShortName, id, successor domain.ThreadId, setBy domain.UserId) error
+	GetShadowbannedUsers(ctx context.Context, board domain.BoardShortName) ([]domain.UserId, error)
+	GetBoardSettings(ctx context.Context, board domain.BoardShortName) (domain.BoardSettings, error)
+	CountUserThreadsSince(ctx context.Context, board domain.BoardShortName, userId domain.UserId, since time.Time) (int, error)
+	GetPremodPoster(ctx context.Context, board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error)
+	IsWatchingThread(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) (bool, error)
+	GetExistingMessages(ctx context.Context, board domain.BoardShortName, links []domain.MessageLink) ([]domain.MessageLink, error)
+	GetReadPosition(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) (domain.MsgId, error)
+	SetReadPosition(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	RecordModeration(ctx context.Context, entry domain.ModLogEntry) error
 }
 
 type ThreadValidator interface {
-	Title(title domain.ThreadTitle) error
+	// Title checks the title, counting its length for the board's language
+	Title(title domain.ThreadTitle, language domain.BoardLanguage) error
 }
 
-func NewThread(storage ThreadStorage, validator ThreadValidator, messageService MessageService, mediaStorage MediaStorage, maxThreadCount *int) ThreadService {
+func NewThread(storage ThreadStorage, validator ThreadValidator, messageService MessageService, mediaStorage MediaStorage, cfg *config.Public) ThreadService {
 	return &Thread{
 		storage:        storage,
 		validator:      validator,
 		messageService: messageService,
 		mediaStorage:   mediaStorage,
-		maxThreadCount: maxThreadCount,
+		cfg:            cfg,
 	}
 }
 
-func (b *Thread) Create(creationData domain.ThreadCreationData) (domain.ThreadId, error) {
-	// Validate title
-	err := b.validator.Title(creationData.Title)
+func (b *Thread) Create(ctx context.Context, creationData domain.ThreadCreationData) (domain.ThreadId, error) {
+	if author := creationData.OpMessage.Author; onProbation(b.cfg, author) {
+		return -1, probationForbidden(b.cfg, author, "create threads")
+	}
+
+	settings, err := b.checkRequirements(ctx, creationData)
 	if err != nil {
 		return -1, err
 	}
+	if err := b.validator.Title(creationData.Title, settings.Language); err != nil {
+		return -1, err
+	}
 
-	threadID, createdAt, err := b.storage.CreateThread(creationData, b.maxThreadCount)
+	// A held thread is only created once a moderator approves its OP
+	held, err := heldForApproval(ctx, b.storage, creationData.Board, settings, creationData.OpMessage.Author)
+	if err != nil {
+		return -1, err
+	}
+	if held {
+		opMessageData := creationData.OpMessage
+		opMessageData.Board = creationData.Board
+		return -1, b.messageService.QueueThread(ctx, opMessageData, domain.QueuedThread{Title: creationData.Title, OpOnly: creationData.OpOnly})
+	}
+
+	threadID, createdAt, err := b.storage.CreateThread(ctx, creationData, b.cfg.MaxThreadCount)
 	if err != nil {
 		return -1, err
 	}
@@ -63,29 +145,328 @@ func (b *Thread) Create(creationData domain.ThreadCreationData) (domain.ThreadId
 	opMessageData.ThreadId = threadID
 	opMessageData.CreatedAt = &createdAt
 
-	_, err = b.messageService.Create(opMessageData)
+	_, err = b.messageService.Create(ctx, opMessageData)
 	if err != nil {
-		b.storage.DeleteThread(creationData.Board, threadID)
+		b.storage.DeleteThread(ctx, creationData.Board, threadID)
 		return -1, fmt.Errorf("failed to create OP message: %w", err)
 	}
 
 	return threadID, nil
 }
 
-func (b *Thread) Get(board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error) {
-	thread, err := b.storage.GetThread(board, id, page)
+func (b *Thread) Get(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error) {
+	thread, err := b.storage.GetThread(ctx, board, id, page)
 	if err != nil {
 		return domain.Thread{}, err
 	}
+	return b.prepareForViewer(ctx, board, id, thread, viewer)
+}
+
+// GetRange serves links into the middle of long threads: the OP and up to
+// threadRangeLimit messages starting at from, prepared for viewer like a page.
+func (b *Thread) GetRange(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId, viewer *domain.User) (domain.Thread, error) {
+	if from < 1 {
+		return domain.Thread{}, &errors.ErrorWithStatusCode{Message: "from must be a positive message id", StatusCode: http.StatusBadRequest}
+	}
+	if to == 0 {
+		to = from + threadRangeLimit - 1
+	}
+	if to < from {
+		return domain.Thread{}, &errors.ErrorWithStatusCode{Message: "to must not be less than from", StatusCode: http.StatusBadRequest}
+	}
+	if to-from+1 > threadRangeLimit {
+		return domain.Thread{}, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("At most %d messages can be fetched at once", threadRangeLimit),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	thread, err := b.storage.GetThreadRange(ctx, board, id, from, to)
+	if err != nil {
+		return domain.Thread{}, err
+	}
+	return b.prepareForViewer(ctx, board, id, thread, viewer)
+}
+
+// prepareForViewer hides shadowbanned content and marks the viewer's own
+// messages, watch state and read position on a fetched thread. The position
+// returned is the one from before this view, which then moves it forward to
+// the last message shown, unless an admin is viewing as the user: view-as is
+// read-only and must not mark the user's posts as read.
+func (b *Thread) prepareForViewer(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, thread domain.Thread, viewer *domain.User) (domain.Thread, error) {
+	hidden, err := loadShadowbanned(ctx, b.storage, board)
+	if err != nil {
+		return domain.Thread{}, err
+	}
+	if err := hidden.filterThread(&thread, viewer); err != nil {
+		return domain.Thread{}, err
+	}
+	markOwn(thread.Messages, viewer)
+	b.markDeadLinks(ctx, board, thread.Messages)
+
+	if viewer != nil {
+		thread.Watched, err = b.storage.IsWatchingThread(ctx, viewer.Id, board, id)
+		if err != nil {
+			return domain.Thread{}, err
+		}
+		thread.LastReadId, err = b.storage.GetReadPosition(ctx, viewer.Id, board, id)
+		if err != nil {
+			return domain.Thread{}, err
+		}
+		if n := len(thread.Messages); n > 0 && thread.Messages[n-1].Id > thread.LastReadId && viewer.ImpersonatedBy == nil {
+			// Failing to remember the position should not fail the page
+			if err := b.storage.SetReadPosition(ctx, viewer.Id, board, id, thread.Messages[n-1].Id); err != nil {
+				logger.Log.Error("failed to update read position", "board", board, "thread_id", id, "user_id", viewer.Id, "error", err)
+			}
+		}
+	}
 	return thread, nil
 }
 
-func (b *Thread) Delete(board domain.BoardShortName, id domain.ThreadId) error {
-	err := b.storage.DeleteThread(board, id)
+// GetUpdates returns up to threadUpdatesLimit messages after since, filtered
+// and marked for viewer like a thread page. Clients keep polling with the last
+// id they got until no messages are returned.
+func (b *Thread) GetUpdates(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, viewer *domain.User) (domain.ThreadUpdates, error) {
+	if since < 0 {
+		return domain.ThreadUpdates{}, &errors.ErrorWithStatusCode{Message: "since must not be negative", StatusCode: http.StatusBadRequest}
+	}
+	updates, err := b.storage.GetThreadUpdates(ctx, board, id, since, threadUpdatesLimit)
+	if err != nil {
+		return domain.ThreadUpdates{}, err
+	}
+
+	hidden, err := loadShadowbanned(ctx, b.storage, board)
+	if err != nil {
+		return domain.ThreadUpdates{}, err
+	}
+	if len(hidden) > 0 {
+		// The OP is usually not among the updates, so look it up to hide
+		// threads of shadowbanned users as GetThread does
+		op, err := b.messageService.Get(ctx, board, id, 1)
+		if err != nil {
+			return domain.ThreadUpdates{}, err
+		}
+		if hidden.hides(op.Author.Id, viewer) {
+			return domain.ThreadUpdates{}, &errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+		}
+		updates.Messages = hidden.filterMessages(updates.Messages, viewer)
+		updates.Backlinks = hidden.filterReplies(updates.Backlinks, viewer)
+	}
+
+	markOwn(updates.Messages, viewer)
+	b.markDeadLinks(ctx, board, updates.Messages)
+	if viewer != nil {
+		for _, reply := range updates.Backlinks {
+			reply.FromOwn = reply.FromAuthor == viewer.Id
+		}
+	}
+	return updates, nil
+}
+
+// GetGraph returns every message of the thread and the reply links between
+// them. Messages hidden from viewer are dropped along with their links, so the
+// graph gives away no more than the thread pages do.
+func (b *Thread) GetGraph(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadGraph, error) {
+	graph, err := b.storage.GetThreadGraph(ctx, board, id)
+	if err != nil {
+		return domain.ThreadGraph{}, err
+	}
+
+	hidden, err := loadShadowbanned(ctx, b.storage, board)
+	if err != nil {
+		return domain.ThreadGraph{}, err
+	}
+	if len(hidden) == 0 {
+		return graph, nil
+	}
+	if len(graph.Nodes) > 0 && graph.Nodes[0].Id == 1 && hidden.hides(graph.Nodes[0].Author, viewer) {
+		return domain.ThreadGraph{}, &errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+	}
+
+	visible := make(map[domain.MsgId]bool, len(graph.Nodes))
+	nodes := graph.Nodes[:0]
+	for _, node := range graph.Nodes {
+		if !hidden.hides(node.Author, viewer) {
+			visible[node.Id] = true
+			nodes = append(nodes, node)
+		}
+	}
+	edges := graph.Edges[:0]
+	for _, edge := range graph.Edges {
+		if visible[edge.From] && visible[edge.To] {
+			edges = append(edges, edge)
+		}
+	}
+	graph.Nodes, graph.Edges = nodes, edges
+	return graph, nil
+}
+
+// GetAttachments returns the attachments of the thread in posting order,
+// leaving out those of messages hidden from viewer.
+func (b *Thread) GetAttachments(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadAttachments, error) {
+	attachments, err := b.storage.GetThreadAttachments(ctx, board, id)
+	if err != nil {
+		return domain.ThreadAttachments{}, err
+	}
+
+	hidden, err := loadShadowbanned(ctx, b.storage, board)
+	if err != nil {
+		return domain.ThreadAttachments{}, err
+	}
+	if len(hidden) == 0 {
+		return attachments, nil
+	}
+	if hidden.hides(attachments.OpAuthor, viewer) {
+		return domain.ThreadAttachments{}, &errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+	}
+
+	visible := attachments.Attachments[:0]
+	for _, item := range attachments.Attachments {
+		if !hidden.hides(item.Author, viewer) {
+			visible = append(visible, item)
+		}
+	}
+	attachments.Attachments = visible
+	return attachments, nil
+}
+
+// Export returns every message of the thread visible to viewer with its
+// content hash, and the Merkle root over those hashes in message order.
+// Messages stored without a hash are exported but left out of the root.
+func (b *Thread) Export(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadArchive, error) {
+	archive, err := b.storage.GetThreadArchive(ctx, board, id)
+	if err != nil {
+		return domain.ThreadArchive{}, err
+	}
+
+	hidden, err := loadShadowbanned(ctx, b.storage, board)
+	if err != nil {
+		return domain.ThreadArchive{}, err
+	}
+	if len(archive.Messages) > 0 && archive.Messages[0].Id == 1 && hidden.hides(archive.Messages[0].Author, viewer) {
+		return domain.ThreadArchive{}, &errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+	}
+
+	visible := archive.Messages[:0]
+	var leaves [][]byte
+	for _, m := range archive.Messages {
+		if hidden.hides(m.Author, viewer) {
+			continue
+		}
+		visible = append(visible, m)
+		if m.ContentHash != "" {
+			leaf, err := hex.DecodeString(m.ContentHash)
+			if err != nil {
+				return domain.ThreadArchive{}, fmt.Errorf("invalid content hash of message %d: %w", m.Id, err)
+			}
+			leaves = append(leaves, leaf)
+		}
+	}
+	archive.Messages = visible
+	archive.ExportedAt = time.Now().UTC()
+	archive.HashVersion = crypto.ContentHashVersion
+	archive.MerkleRoot = hex.EncodeToString(crypto.MerkleRoot(leaves))
+	return archive, nil
+}
+
+// markDeadLinks sets DeadLinks on messages quoting messages that no longer
+// exist, checking the targets of all messages in one query. The links are a
+// cosmetic hint, so a failed check is logged and leaves them unmarked.
+func (b *Thread) markDeadLinks(ctx context.Context, board domain.BoardShortName, messages []*domain.Message) {
+	seen := make(map[domain.MessageLink]struct{})
+	var links []domain.MessageLink
+	for _, msg := range messages {
+		for _, link := range messageLinks(msg.Text) {
+			if _, ok := seen[link]; !ok {
+				seen[link] = struct{}{}
+				links = append(links, link)
+			}
+		}
+	}
+	if len(links) == 0 {
+		return
+	}
+
+	existing, err := b.storage.GetExistingMessages(ctx, board, links)
+	if err != nil {
+		logger.Log.Error("failed to check linked messages", "board", board, "error", err)
+		return
+	}
+	for _, link := range existing {
+		delete(seen, link)
+	}
+	if len(seen) == 0 {
+		return
+	}
+	for _, msg := range messages {
+		for _, link := range messageLinks(msg.Text) {
+			if _, dead := seen[link]; dead && !slices.Contains(msg.DeadLinks, link) {
+				msg.DeadLinks = append(msg.DeadLinks, link)
+			}
+		}
+	}
+}
+
+// messageLinks returns the targets of the message links in parsed message text.
+func messageLinks(text string) []domain.MessageLink {
+	var links []domain.MessageLink
+	for _, m := range messageLinkTarget.FindAllStringSubmatch(text, -1) {
+		msgId, err1 := strconv.ParseInt(m[1], 10, 64)
+		threadId, err2 := strconv.ParseInt(m[2], 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		links = append(links, domain.MessageLink{ThreadId: threadId, Id: msgId})
+	}
+	return links
+}
+
+// markOwn flags the viewer's messages, the reply links they sent, and the
+// messages replying to them, so clients can render "(You)" markers. Only
+// replies whose sender is among messages is known, so a reply on another page
+// of the thread is not flagged.
+func markOwn(messages []*domain.Message, viewer *domain.User) {
+	if viewer == nil {
+		return
+	}
+	type msgRef struct {
+		thread domain.ThreadId
+		id     domain.MsgId
+	}
+	byRef := make(map[msgRef]*domain.Message, len(messages))
+	for _, msg := range messages {
+		byRef[msgRef{msg.ThreadId, msg.Id}] = msg
+	}
+	for _, msg := range messages {
+		msg.IsOwn = msg.Author.Id == viewer.Id
+		for _, reply := range msg.Replies {
+			reply.FromOwn = reply.FromAuthor == viewer.Id
+			if sender, ok := byRef[msgRef{reply.FromThreadId, reply.From}]; ok && msg.IsOwn && !reply.FromOwn {
+				sender.RepliesToOwn = true
+			}
+		}
+	}
+}
+
+func (b *Thread) Delete(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > b.cfg.DeletionReasonMaxLen {
+		return &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Deletion reason must be at most %d characters", b.cfg.DeletionReasonMaxLen),
+			StatusCode: 400,
+		}
+	}
+
+	err := b.storage.DeleteThread(ctx, board, id)
 	if err != nil {
 		return err
 	}
 
+	// The thread is already gone, a missing log entry must not turn it into an error
+	if err := b.storage.RecordModeration(ctx, domain.ModLogEntry{Board: board, Action: domain.ModLogDeleteThread, Reason: reason}); err != nil {
+		logger.Log.Error("failed to record thread deletion in the moderation log", "board", board, "thread_id", id, "error", err)
+	}
+
 	// Best effort: log errors but don't fail the operation
 	if err := b.mediaStorage.DeleteThread(string(board), fmt.Sprintf("%d", id)); err != nil {
 	}
@@ -93,10 +474,115 @@ func (b *Thread) Delete(board domain.BoardShortName, id domain.ThreadId) error {
 	return nil
 }
 
-func (b *Thread) GetLastModified(board domain.BoardShortName, id domain.ThreadId) (time.Time, error) {
-	return b.storage.GetThreadLastModified(board, id)
+func (b *Thread) DeletionReport(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.DeletionReport, error) {
+	return b.storage.GetDeletionReport(ctx, board, &id)
+}
+
+func (b *Thread) GetLastModified(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (time.Time, error) {
+	return b.storage.GetThreadLastModified(ctx, board, id)
+}
+
+func (b *Thread) TogglePinned(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (bool, error) {
+	return b.storage.TogglePinnedStatus(ctx, board, id)
+}
+
+func (b *Thread) ToggleOpOnly(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (bool, error) {
+	return b.storage.ToggleOpOnlyStatus(ctx, board, id)
+}
+
+// EditTitle changes the thread title. The OP may do so within
+// ThreadTitleEditWindow of creating the thread, moderators at any time. Every
+// change is kept in the thread's title history.
+func (b *Thread) EditTitle(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editor domain.User) error {
+	settings, err := b.storage.GetBoardSettings(ctx, board)
+	if err != nil {
+		return err
+	}
+	if err := b.validator.Title(title, settings.Language); err != nil {
+		return err
+	}
+
+	if !editor.Admin {
+		window := b.cfg.ThreadTitleEditWindow
+		if window <= 0 {
+			return &errors.ErrorWithStatusCode{Message: "Only moderators can change thread titles", StatusCode: http.StatusForbidden}
+		}
+		op, err := b.messageService.Get(ctx, board, id, 1)
+		if err != nil {
+			return err
+		}
+		if op.Author.Id != editor.Id {
+			return &errors.ErrorWithStatusCode{Message: "Only the thread's author can change its title", StatusCode: http.StatusForbidden}
+		}
+		if time.Since(op.CreatedAt) > window {
+			return &errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("Thread titles can only be changed within %s of posting", window),
+				StatusCode: http.StatusForbidden,
+			}
+		}
+	}
+
+	return b.storage.UpdateThreadTitle(ctx, board, id, title, editor.Id, editor.Admin)
+}
+
+func (b *Thread) GetTitleHistory(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) ([]domain.ThreadTitleEdit, error) {
+	return b.storage.GetThreadTitleEdits(ctx, board, id)
+}
+
+func (b *Thread) SetSuccessor(ctx context.Context, board domain.BoardShortName, id, successor domain.ThreadId, editor domain.User) error {
+	if successor <= id {
+		return &errors.ErrorWithStatusCode{Message: "A thread can only be continued in a newer thread", StatusCode: http.StatusBadRequest}
+	}
+
+	if !editor.Admin {
+		thread, err := b.storage.GetThreadRange(ctx, board, id, 1, 1)
+		if err != nil {
+			return err
+		}
+		if len(thread.Messages) == 0 || !thread.Messages[0].IsOp() || thread.Messages[0].Author.Id != editor.Id {
+			return &errors.ErrorWithStatusCode{Message: "Only the thread's author can link its continuation", StatusCode: http.StatusForbidden}
+		}
+		if thread.MessageCount <= b.cfg.BumpLimit {
+			return &errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("Only threads past the bump limit of %d posts can be continued", b.cfg.BumpLimit),
+				StatusCode: http.StatusForbidden,
+			}
+		}
+	}
+
+	return b.storage.SetThreadSuccessor(ctx, board, id, successor, editor.Id)
+}
+
+// checkRequirements enforces the board's thread creation settings on the OP
+// and returns the settings.
+func (b *Thread) checkRequirements(ctx context.Context, creationData domain.ThreadCreationData) (domain.BoardSettings, error) {
+	settings, err := b.storage.GetBoardSettings(ctx, creationData.Board)
+	if err != nil {
+		return domain.BoardSettings{}, err
+	}
+	if err := checkPostingRules(settings, creationData.OpMessage.Author); err != nil {
+		return domain.BoardSettings{}, err
+	}
+
+	op := creationData.OpMessage
+	if settings.MinOpTextLength > 0 && utils.TextLength(strings.TrimSpace(string(op.Text)), settings.Language) < settings.MinOpTextLength {
+		return domain.BoardSettings{}, requirementError("Threads on /%s/ need an opening post of at least %d characters", creationData.Board, settings.MinOpTextLength)
+	}
+	if settings.RequireOpAttachment && len(op.PendingFiles)+len(op.UploadTokens) == 0 {
+		return domain.BoardSettings{}, requirementError("Threads on /%s/ need at least one attachment in the opening post", creationData.Board)
+	}
+	if settings.MaxThreadsPerUserPerDay > 0 {
+		count, err := b.storage.CountUserThreadsSince(ctx, creationData.Board, op.Author.Id, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			return domain.BoardSettings{}, err
+		}
+		if count >= settings.MaxThreadsPerUserPerDay {
+			return domain.BoardSettings{}, requirementError("You can start at most %d threads per day on /%s/", settings.MaxThreadsPerUserPerDay, creationData.Board)
+		}
+	}
+	return settings, nil
 }
 
-func (b *Thread) TogglePinned(board domain.BoardShortName, id domain.ThreadId) (bool, error) {
-	return b.storage.TogglePinnedStatus(board, id)
+func requirementError(format string, args ...interface{}) error {
+	return &errors.ErrorWithStatusCode{Message: fmt.Sprintf(format, args...), StatusCode: http.StatusBadRequest}
 }
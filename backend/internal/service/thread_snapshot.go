@@ -0,0 +1,257 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/markup"
+)
+
+// snapshotsDir is the directory under the board's media folder that holds
+// its thread snapshots. Deleting a thread only removes the thread's own
+// directory, so snapshots outlive it.
+const snapshotsDir = "snapshots"
+
+//go:embed templates/thread_snapshot.html
+var threadSnapshotTemplateText string
+
+var threadSnapshotTemplate = template.Must(template.New("thread_snapshot").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04:05 UTC") },
+	"formatSize": formatSnapshotSize,
+}).Parse(threadSnapshotTemplateText))
+
+// snapshotMessageHref matches the href of a message link, capturing the
+// board, thread and message of its target.
+var snapshotMessageHref = regexp.MustCompile(`href="/([^/"]+)/(\d+)#p(\d+)"`)
+
+// ThreadSnapshotService saves threads as self-contained HTML pages.
+type ThreadSnapshotService interface {
+	// Create saves a snapshot of the thread as anonymous readers see it, or
+	// returns the latest one when the thread has not changed since
+	Create(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, author domain.UserId) (domain.ThreadSnapshot, error)
+	List(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId, page int) ([]domain.ThreadSnapshot, error)
+	Get(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) (domain.ThreadSnapshot, error)
+	Delete(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) error
+}
+
+// ThreadSnapshotStorage defines storage interface for thread snapshots
+type ThreadSnapshotStorage interface {
+	AddThreadSnapshot(ctx context.Context, snapshot domain.ThreadSnapshot) (domain.ThreadSnapshotId, error)
+	GetThreadSnapshot(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) (domain.ThreadSnapshot, error)
+	GetThreadSnapshots(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId, limit, offset int) ([]domain.ThreadSnapshot, error)
+	DeleteThreadSnapshot(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) (string, error)
+}
+
+type ThreadSnapshot struct {
+	storage      ThreadSnapshotStorage
+	threads      ThreadService
+	mediaStorage MediaStorage
+	cfg          *config.Public
+}
+
+func NewThreadSnapshot(storage ThreadSnapshotStorage, threads ThreadService, mediaStorage MediaStorage, cfg *config.Public) ThreadSnapshotService {
+	return &ThreadSnapshot{
+		storage:      storage,
+		threads:      threads,
+		mediaStorage: mediaStorage,
+		cfg:          cfg,
+	}
+}
+
+// threadSnapshotPage is the data of the snapshot template.
+type threadSnapshotPage struct {
+	Board      domain.BoardShortName
+	ThreadId   domain.ThreadId
+	Title      string
+	Source     string
+	SavedAt    time.Time
+	MerkleRoot string
+	Messages   []threadSnapshotMessage
+}
+
+type threadSnapshotMessage struct {
+	Id        domain.MsgId
+	CreatedAt time.Time
+	Text      template.HTML
+	Files     []threadSnapshotFile
+}
+
+type threadSnapshotFile struct {
+	Name      string
+	SizeBytes int64
+	Image     template.URL // Data URI of the thumbnail; empty once the image budget is spent
+}
+
+func (s *ThreadSnapshot) Create(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, author domain.UserId) (domain.ThreadSnapshot, error) {
+	lastModified, err := s.threads.GetLastModified(ctx, board, threadId)
+	if err != nil {
+		return domain.ThreadSnapshot{}, err
+	}
+	latest, err := s.storage.GetThreadSnapshots(ctx, board, &threadId, 1, 0)
+	if err != nil {
+		return domain.ThreadSnapshot{}, err
+	}
+	if len(latest) > 0 && !latest[0].CreatedAt.Before(lastModified) {
+		return latest[0], nil
+	}
+
+	// Snapshots are public, so they show what a reader without an account sees
+	archive, err := s.threads.Export(ctx, board, threadId, nil)
+	if err != nil {
+		return domain.ThreadSnapshot{}, err
+	}
+	attachments, err := s.threads.GetAttachments(ctx, board, threadId, nil)
+	if err != nil {
+		return domain.ThreadSnapshot{}, err
+	}
+	page, err := s.render(archive, attachments)
+	if err != nil {
+		return domain.ThreadSnapshot{}, fmt.Errorf("failed to render snapshot of thread %d: %w", threadId, err)
+	}
+
+	filePath, err := s.mediaStorage.SaveFile(bytes.NewReader(page), string(board), snapshotsDir, "snapshot.html")
+	if err != nil {
+		return domain.ThreadSnapshot{}, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	id, err := s.storage.AddThreadSnapshot(ctx, domain.ThreadSnapshot{
+		Board:        board,
+		ThreadId:     threadId,
+		Title:        archive.Title,
+		FilePath:     filePath,
+		SizeBytes:    int64(len(page)),
+		MessageCount: len(archive.Messages),
+		CreatedBy:    &author,
+	})
+	if err != nil {
+		if delErr := s.mediaStorage.DeleteFile(filePath); delErr != nil {
+			logger.Log.Error("failed to delete snapshot file", "path", filePath, "error", delErr)
+		}
+		return domain.ThreadSnapshot{}, err
+	}
+	return s.storage.GetThreadSnapshot(ctx, board, id)
+}
+
+func (s *ThreadSnapshot) List(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId, page int) ([]domain.ThreadSnapshot, error) {
+	if page < 1 {
+		page = 1
+	}
+	limit := s.cfg.ThreadSnapshotsPageLimit
+	return s.storage.GetThreadSnapshots(ctx, board, threadId, limit, (page-1)*limit)
+}
+
+func (s *ThreadSnapshot) Get(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) (domain.ThreadSnapshot, error) {
+	return s.storage.GetThreadSnapshot(ctx, board, id)
+}
+
+func (s *ThreadSnapshot) Delete(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) error {
+	filePath, err := s.storage.DeleteThreadSnapshot(ctx, board, id)
+	if err != nil {
+		return err
+	}
+	// Best effort: the media garbage collector removes the file otherwise
+	if err := s.mediaStorage.DeleteFile(filePath); err != nil {
+		logger.Log.Error("failed to delete snapshot file", "path", filePath, "error", err)
+	}
+	return nil
+}
+
+// render builds the snapshot page. Message text is sanitized again since the
+// page is opened outside the site, links within the thread become anchors,
+// and thumbnails are embedded until ThreadSnapshotMaxImageBytes is spent.
+func (s *ThreadSnapshot) render(archive domain.ThreadArchive, attachments domain.ThreadAttachments) ([]byte, error) {
+	files := make(map[domain.MsgId][]threadSnapshotFile)
+	budget := s.cfg.ThreadSnapshotMaxImageBytes
+	for _, item := range attachments.Attachments {
+		if item.Attachment == nil || item.Attachment.File == nil {
+			continue
+		}
+		file := item.Attachment.File
+		entry := threadSnapshotFile{Name: file.OriginalFilename, SizeBytes: file.SizeBytes}
+		if file.ThumbnailPath != nil && budget > 0 {
+			if data, ok := s.readThumbnail(*file.ThumbnailPath, budget); ok {
+				budget -= int64(len(data))
+				entry.Image = template.URL("data:" + http.DetectContentType(data) + ";base64," + base64.StdEncoding.EncodeToString(data))
+			}
+		}
+		files[item.Attachment.MessageId] = append(files[item.Attachment.MessageId], entry)
+	}
+
+	source := fmt.Sprintf("/%s/%d", archive.Board, archive.ThreadId)
+	siteURL := strings.TrimSuffix(s.cfg.SiteURL, "/")
+	page := threadSnapshotPage{
+		Board:      archive.Board,
+		ThreadId:   archive.ThreadId,
+		Title:      archive.Title,
+		Source:     siteURL + source,
+		SavedAt:    archive.ExportedAt,
+		MerkleRoot: archive.MerkleRoot,
+		Messages:   make([]threadSnapshotMessage, 0, len(archive.Messages)),
+	}
+	for _, m := range archive.Messages {
+		text, _ := markup.Sanitize(m.Text)
+		text = snapshotMessageHref.ReplaceAllStringFunc(text, func(href string) string {
+			target := snapshotMessageHref.FindStringSubmatch(href)
+			if target[1] == archive.Board && target[2] == fmt.Sprint(archive.ThreadId) {
+				return `href="#p` + target[3] + `"`
+			}
+			return `href="` + siteURL + href[len(`href="`):]
+		})
+		page.Messages = append(page.Messages, threadSnapshotMessage{
+			Id:        m.Id,
+			CreatedAt: m.CreatedAt,
+			Text:      template.HTML(text),
+			Files:     files[m.Id],
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := threadSnapshotTemplate.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readThumbnail reads a thumbnail of at most limit bytes. A thumbnail missing
+// from the media storage leaves the file without a preview rather than
+// failing the snapshot.
+func (s *ThreadSnapshot) readThumbnail(path string, limit int64) ([]byte, bool) {
+	r, err := s.mediaStorage.Read(path)
+	if err != nil {
+		logger.Log.Warn("failed to open thumbnail for snapshot", "path", path, "error", err)
+		return nil, false
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		logger.Log.Warn("failed to read thumbnail for snapshot", "path", path, "error", err)
+		return nil, false
+	}
+	if int64(len(data)) > limit || !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return nil, false
+	}
+	return data, true
+}
+
+// formatSnapshotSize formats a file size for the snapshot page.
+func formatSnapshotSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockThreadSnapshotStorage struct {
+	addThreadSnapshotFunc    func(snapshot domain.ThreadSnapshot) (domain.ThreadSnapshotId, error)
+	getThreadSnapshotFunc    func(board domain.BoardShortName, id domain.ThreadSnapshotId) (domain.ThreadSnapshot, error)
+	getThreadSnapshotsFunc   func(board domain.BoardShortName, threadId *domain.ThreadId, limit, offset int) ([]domain.ThreadSnapshot, error)
+	deleteThreadSnapshotFunc func(board domain.BoardShortName, id domain.ThreadSnapshotId) (string, error)
+}
+
+func (m *MockThreadSnapshotStorage) AddThreadSnapshot(ctx context.Context, snapshot domain.ThreadSnapshot) (domain.ThreadSnapshotId, error) {
+	if m.addThreadSnapshotFunc != nil {
+		return m.addThreadSnapshotFunc(snapshot)
+	}
+	return 1, nil
+}
+
+func (m *MockThreadSnapshotStorage) GetThreadSnapshot(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) (domain.ThreadSnapshot, error) {
+	if m.getThreadSnapshotFunc != nil {
+		return m.getThreadSnapshotFunc(board, id)
+	}
+	return domain.ThreadSnapshot{Id: id, Board: board}, nil
+}
+
+func (m *MockThreadSnapshotStorage) GetThreadSnapshots(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId, limit, offset int) ([]domain.ThreadSnapshot, error) {
+	if m.getThreadSnapshotsFunc != nil {
+		return m.getThreadSnapshotsFunc(board, threadId, limit, offset)
+	}
+	return []domain.ThreadSnapshot{}, nil
+}
+
+func (m *MockThreadSnapshotStorage) DeleteThreadSnapshot(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) (string, error) {
+	if m.deleteThreadSnapshotFunc != nil {
+		return m.deleteThreadSnapshotFunc(board, id)
+	}
+	return "", nil
+}
+
+// stubSnapshotThreads answers the ThreadService calls a snapshot makes.
+type stubSnapshotThreads struct {
+	ThreadService
+	lastModified time.Time
+	archive      domain.ThreadArchive
+	attachments  domain.ThreadAttachments
+	viewers      []*domain.User
+}
+
+func (s *stubSnapshotThreads) GetLastModified(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (time.Time, error) {
+	return s.lastModified, nil
+}
+
+func (s *stubSnapshotThreads) Export(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadArchive, error) {
+	s.viewers = append(s.viewers, viewer)
+	return s.archive, nil
+}
+
+func (s *stubSnapshotThreads) GetAttachments(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, viewer *domain.User) (domain.ThreadAttachments, error) {
+	s.viewers = append(s.viewers, viewer)
+	return s.attachments, nil
+}
+
+func snapshotThumbnail(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestThreadSnapshotCreate(t *testing.T) {
+	modified := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	thumbPath := "b/7/thumb_a.png"
+	otherThumbPath := "b/7/thumb_b.png"
+	newThreads := func() *stubSnapshotThreads {
+		return &stubSnapshotThreads{
+			lastModified: modified,
+			archive: domain.ThreadArchive{
+				Board: "b", ThreadId: 7, Title: "Cats & dogs", ExportedAt: modified, MerkleRoot: "abcd",
+				Messages: []domain.ArchivedMessage{
+					{Id: 1, CreatedAt: modified, Text: "hello<script>alert(1)</script>"},
+					{Id: 2, CreatedAt: modified, Text: `<a href="/b/7#p1" class="message-link message-link-preview" data-board="b" data-message-id="1" data-thread-id="7">&gt;&gt;1</a> and <a href="/b/9#p3" class="message-link message-link-preview" data-board="b" data-message-id="3" data-thread-id="9">&gt;&gt;3</a>`},
+				},
+			},
+			attachments: domain.ThreadAttachments{Attachments: []domain.ThreadAttachment{
+				{Attachment: &domain.Attachment{MessageId: 1, File: &domain.File{
+					FileCommonMetadata: domain.FileCommonMetadata{SizeBytes: 2048}, OriginalFilename: "cat.png", ThumbnailPath: &thumbPath,
+				}}},
+				{Attachment: &domain.Attachment{MessageId: 2, File: &domain.File{
+					FileCommonMetadata: domain.FileCommonMetadata{SizeBytes: 4096}, OriginalFilename: "dog.png", ThumbnailPath: &otherThumbPath,
+				}}},
+			}},
+		}
+	}
+	thumbnail := snapshotThumbnail(t)
+	newMedia := func() *SharedMockMediaStorage {
+		return &SharedMockMediaStorage{readFunc: func(filePath string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(thumbnail)), nil
+		}}
+	}
+
+	t.Run("renders a self-contained page", func(t *testing.T) {
+		threads := newThreads()
+		media := newMedia()
+		var added domain.ThreadSnapshot
+		snapshots := NewThreadSnapshot(&MockThreadSnapshotStorage{
+			addThreadSnapshotFunc: func(snapshot domain.ThreadSnapshot) (domain.ThreadSnapshotId, error) {
+				added = snapshot
+				return 5, nil
+			},
+		}, threads, media, &config.Public{SiteURL: "https://example.org/", ThreadSnapshotMaxImageBytes: int64(len(thumbnail))})
+
+		snapshot, err := snapshots.Create(context.Background(), "b", 7, 42)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ThreadSnapshotId(5), snapshot.Id)
+		assert.Equal(t, []*domain.User{nil, nil}, threads.viewers, "snapshots show the thread as anonymous readers see it")
+
+		require.Len(t, media.saveFileCalls, 1)
+		call := media.saveFileCalls[0]
+		assert.Equal(t, "b", call.BoardID)
+		assert.Equal(t, snapshotsDir, call.ThreadID)
+		assert.Equal(t, "b/snapshots/snapshot.html", added.FilePath)
+		assert.Equal(t, int64(len(call.Data)), added.SizeBytes)
+		assert.Equal(t, 2, added.MessageCount)
+		assert.Equal(t, "Cats & dogs", added.Title)
+		require.NotNil(t, added.CreatedBy)
+		assert.Equal(t, domain.UserId(42), *added.CreatedBy)
+
+		page := string(call.Data)
+		assert.Contains(t, page, "Cats &amp; dogs")
+		assert.NotContains(t, page, "<script>")
+		assert.Contains(t, page, `href="#p1"`, "links within the thread become anchors")
+		assert.Contains(t, page, `href="https://example.org/b/9#p3"`, "links to other threads point at the site")
+		assert.Contains(t, page, `<img src="data:image/png;base64,`)
+		assert.Equal(t, 1, bytes.Count(call.Data, []byte("<img")), "thumbnails beyond the budget are not embedded")
+		assert.Contains(t, page, "dog.png (4.0 KB)")
+		assert.Contains(t, page, "https://example.org/b/7")
+		assert.Contains(t, page, "abcd")
+	})
+
+	t.Run("returns the latest snapshot when the thread is unchanged", func(t *testing.T) {
+		media := newMedia()
+		latest := domain.ThreadSnapshot{Id: 3, Board: "b", ThreadId: 7, CreatedAt: modified.Add(time.Minute)}
+		snapshots := NewThreadSnapshot(&MockThreadSnapshotStorage{
+			getThreadSnapshotsFunc: func(board domain.BoardShortName, threadId *domain.ThreadId, limit, offset int) ([]domain.ThreadSnapshot, error) {
+				require.NotNil(t, threadId)
+				assert.Equal(t, domain.ThreadId(7), *threadId)
+				return []domain.ThreadSnapshot{latest}, nil
+			},
+		}, newThreads(), media, &config.Public{ThreadSnapshotMaxImageBytes: 1 << 20})
+
+		snapshot, err := snapshots.Create(context.Background(), "b", 7, 42)
+		require.NoError(t, err)
+		assert.Equal(t, latest, snapshot)
+		assert.Empty(t, media.saveFileCalls)
+	})
+
+	t.Run("missing thumbnails do not fail the snapshot", func(t *testing.T) {
+		media := &SharedMockMediaStorage{readFunc: func(filePath string) (io.ReadCloser, error) {
+			return nil, errors.New("no such file")
+		}}
+		snapshots := NewThreadSnapshot(&MockThreadSnapshotStorage{}, newThreads(), media, &config.Public{ThreadSnapshotMaxImageBytes: 1 << 20})
+
+		_, err := snapshots.Create(context.Background(), "b", 7, 42)
+		require.NoError(t, err)
+		require.Len(t, media.saveFileCalls, 1)
+		assert.NotContains(t, string(media.saveFileCalls[0].Data), "<img")
+		assert.Contains(t, string(media.saveFileCalls[0].Data), "cat.png (2.0 KB)")
+	})
+
+	t.Run("file is removed when the record cannot be added", func(t *testing.T) {
+		media := newMedia()
+		snapshots := NewThreadSnapshot(&MockThreadSnapshotStorage{
+			addThreadSnapshotFunc: func(snapshot domain.ThreadSnapshot) (domain.ThreadSnapshotId, error) {
+				return 0, errors.New("db down")
+			},
+		}, newThreads(), media, &config.Public{ThreadSnapshotMaxImageBytes: 1 << 20})
+
+		_, err := snapshots.Create(context.Background(), "b", 7, 42)
+		require.Error(t, err)
+		assert.Equal(t, []string{"b/snapshots/snapshot.html"}, media.deleteFileCalls)
+	})
+}
+
+func TestThreadSnapshotListAndDelete(t *testing.T) {
+	t.Run("list pages by the configured limit", func(t *testing.T) {
+		var gotLimit, gotOffset int
+		snapshots := NewThreadSnapshot(&MockThreadSnapshotStorage{
+			getThreadSnapshotsFunc: func(board domain.BoardShortName, threadId *domain.ThreadId, limit, offset int) ([]domain.ThreadSnapshot, error) {
+				gotLimit, gotOffset = limit, offset
+				return []domain.ThreadSnapshot{}, nil
+			},
+		}, &stubSnapshotThreads{}, &SharedMockMediaStorage{}, &config.Public{ThreadSnapshotsPageLimit: 10})
+
+		_, err := snapshots.List(context.Background(), "b", nil, 3)
+		require.NoError(t, err)
+		assert.Equal(t, 10, gotLimit)
+		assert.Equal(t, 20, gotOffset)
+	})
+
+	t.Run("delete removes the file", func(t *testing.T) {
+		media := &SharedMockMediaStorage{}
+		snapshots := NewThreadSnapshot(&MockThreadSnapshotStorage{
+			deleteThreadSnapshotFunc: func(board domain.BoardShortName, id domain.ThreadSnapshotId) (string, error) {
+				return "b/snapshots/x.html", nil
+			},
+		}, &stubSnapshotThreads{}, media, &config.Public{})
+
+		require.NoError(t, snapshots.Delete(context.Background(), "b", 1))
+		assert.Equal(t, []string{"b/snapshots/x.html"}, media.deleteFileCalls)
+	})
+
+	t.Run("delete of a missing snapshot", func(t *testing.T) {
+		media := &SharedMockMediaStorage{}
+		snapshots := NewThreadSnapshot(&MockThreadSnapshotStorage{
+			deleteThreadSnapshotFunc: func(board domain.BoardShortName, id domain.ThreadSnapshotId) (string, error) {
+				return "", &internal_errors.ErrorWithStatusCode{Message: "Snapshot not found", StatusCode: http.StatusNotFound}
+			},
+		}, &stubSnapshotThreads{}, media, &config.Public{})
+
+		requireStatus(t, snapshots.Delete(context.Background(), "b", 1), http.StatusNotFound)
+		assert.Empty(t, media.deleteFileCalls)
+	})
+}
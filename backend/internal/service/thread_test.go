@@ -1,11 +1,17 @@
 package service
 
 import (
+	"context"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"net/http"
 	"sync" // Used for tracking calls in mocks safely in parallel tests
 	"testing"
 	"time"
 
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/crypto"
 	"github.com/itchan-dev/itchan/shared/domain"
 	internal_errors "github.com/itchan-dev/itchan/shared/errors"
 	"github.com/stretchr/testify/assert"
@@ -16,38 +22,83 @@ import (
 
 // MockMessageService mocks the MessageService interface.
 type MockMessageService struct {
-	createFunc func(creationData domain.MessageCreationData) (domain.MsgId, error)
-	getFunc    func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error)
-	deleteFunc func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error
+	createFunc      func(creationData domain.MessageCreationData) (domain.MsgId, error)
+	queueThreadFunc func(creationData domain.MessageCreationData, thread domain.QueuedThread) error
+	uploadFunc      func(board domain.BoardShortName, author domain.User, file *domain.PendingFile) (domain.PendingUpload, error)
+	getFunc         func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error)
+	deleteFunc      func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error
 }
 
-func (m *MockMessageService) Create(creationData domain.MessageCreationData) (domain.MsgId, error) {
+func (m *MockMessageService) Create(ctx context.Context, creationData domain.MessageCreationData) (domain.MsgId, error) {
 	if m.createFunc != nil {
 		return m.createFunc(creationData)
 	}
 	return 1, nil // Default: return arbitrary ID (always 1 for OP)
 }
 
-func (m *MockMessageService) Get(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+func (m *MockMessageService) QueueThread(ctx context.Context, creationData domain.MessageCreationData, thread domain.QueuedThread) error {
+	if m.queueThreadFunc != nil {
+		return m.queueThreadFunc(creationData, thread)
+	}
+	return ErrPostQueued
+}
+
+func (m *MockMessageService) Upload(ctx context.Context, board domain.BoardShortName, author domain.User, file *domain.PendingFile) (domain.PendingUpload, error) {
+	if m.uploadFunc != nil {
+		return m.uploadFunc(board, author, file)
+	}
+	return domain.PendingUpload{Token: "token", Board: board, UserId: author.Id}, nil
+}
+
+func (m *MockMessageService) Get(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
 	if m.getFunc != nil {
 		return m.getFunc(board, threadId, id)
 	}
 	return domain.Message{}, nil
 }
 
-func (m *MockMessageService) Delete(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error {
+func (m *MockMessageService) Resolve(ctx context.Context, board domain.BoardShortName, postNumber domain.PostNumber) (domain.Message, error) {
+	return domain.Message{}, nil
+}
+
+func (m *MockMessageService) Delete(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
 	if m.deleteFunc != nil {
 		return m.deleteFunc(board, threadId, id)
 	}
 	return nil
 }
 
+func (m *MockMessageService) DeleteOwn(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, author domain.UserId) error {
+	return nil
+}
+
+func (m *MockMessageService) GetFileMetadata(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error) {
+	return nil, nil
+}
+
 // MockThreadStorage mocks the ThreadStorage interface.
 type MockThreadStorage struct {
 	createThreadFunc func(creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error)
 	getThreadFunc               func(board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error)
+	getThreadRangeFunc          func(board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error)
 	deleteThreadFunc            func(board domain.BoardShortName, id domain.ThreadId) error
 	togglePinnedStatusFunc      func(board domain.BoardShortName, threadId domain.ThreadId) (bool, error)
+	toggleOpOnlyStatusFunc      func(board domain.BoardShortName, threadId domain.ThreadId) (bool, error)
+	getShadowbannedUsersFunc    func(board domain.BoardShortName) ([]domain.UserId, error)
+	getBoardSettingsFunc        func(board domain.BoardShortName) (domain.BoardSettings, error)
+	countUserThreadsSinceFunc   func(board domain.BoardShortName, userId domain.UserId, since time.Time) (int, error)
+	getPremodPosterFunc         func(board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error)
+	isWatchingThreadFunc        func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) (bool, error)
+	getExistingMessagesFunc     func(board domain.BoardShortName, links []domain.MessageLink) ([]domain.MessageLink, error)
+	getReadPositionFunc         func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) (domain.MsgId, error)
+	setReadPositionFunc         func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error
+	getThreadUpdatesFunc        func(board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, limit int) (domain.ThreadUpdates, error)
+	getThreadGraphFunc          func(board domain.BoardShortName, id domain.ThreadId) (domain.ThreadGraph, error)
+	getThreadAttachmentsFunc    func(board domain.BoardShortName, id domain.ThreadId) (domain.ThreadAttachments, error)
+	getThreadArchiveFunc        func(board domain.BoardShortName, id domain.ThreadId) (domain.ThreadArchive, error)
+	recordModerationFunc        func(entry domain.ModLogEntry) error
+	updateThreadTitleFunc       func(board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editedBy domain.UserId, byModerator bool) error
+	setThreadSuccessorFunc      func(board domain.BoardShortName, id, successor domain.ThreadId, setBy domain.UserId) error
 
 	mu                 sync.Mutex
 	deleteThreadCalled bool
@@ -63,21 +114,32 @@ func (m *MockThreadStorage) ResetCallTracking() {
 	m.deleteIdArg = 0
 }
 
-func (m *MockThreadStorage) CreateThread(creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error) {
+func (m *MockThreadStorage) CreateThread(ctx context.Context, creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error) {
 	if m.createThreadFunc != nil {
 		return m.createThreadFunc(creationData, maxThreadCount)
 	}
 	return 1, time.Now().UTC(), nil
 }
 
-func (m *MockThreadStorage) GetThread(board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error) {
+func (m *MockThreadStorage) GetThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error) {
 	if m.getThreadFunc != nil {
 		return m.getThreadFunc(board, id, page)
 	}
 	return domain.Thread{Messages: []*domain.Message{{MessageMetadata: domain.MessageMetadata{Id: domain.MsgId(id)}}}}, nil
 }
 
-func (m *MockThreadStorage) DeleteThread(board domain.BoardShortName, id domain.ThreadId) error {
+func (m *MockThreadStorage) GetThreadRange(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error) {
+	if m.getThreadRangeFunc != nil {
+		return m.getThreadRangeFunc(board, id, from, to)
+	}
+	return domain.Thread{}, nil
+}
+
+func (m *MockThreadStorage) GetDeletionReport(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId) (domain.DeletionReport, error) {
+	return domain.DeletionReport{}, nil
+}
+
+func (m *MockThreadStorage) DeleteThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) error {
 	m.mu.Lock()
 	m.deleteThreadCalled = true
 	m.deleteBoardArg = board
@@ -90,25 +152,141 @@ func (m *MockThreadStorage) DeleteThread(board domain.BoardShortName, id domain.
 	return nil
 }
 
-func (m *MockThreadStorage) GetThreadLastModified(board domain.BoardShortName, id domain.ThreadId) (time.Time, error) {
+func (m *MockThreadStorage) GetThreadLastModified(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (time.Time, error) {
 	return time.Now().UTC(), nil
 }
 
-func (m *MockThreadStorage) TogglePinnedStatus(board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+func (m *MockThreadStorage) TogglePinnedStatus(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
 	if m.togglePinnedStatusFunc != nil {
 		return m.togglePinnedStatusFunc(board, threadId)
 	}
 	return true, nil
 }
 
+func (m *MockThreadStorage) ToggleOpOnlyStatus(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+	if m.toggleOpOnlyStatusFunc != nil {
+		return m.toggleOpOnlyStatusFunc(board, threadId)
+	}
+	return true, nil
+}
+
+func (m *MockThreadStorage) UpdateThreadTitle(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editedBy domain.UserId, byModerator bool) error {
+	if m.updateThreadTitleFunc != nil {
+		return m.updateThreadTitleFunc(board, id, title, editedBy, byModerator)
+	}
+	return nil
+}
+
+func (m *MockThreadStorage) GetThreadTitleEdits(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) ([]domain.ThreadTitleEdit, error) {
+	return nil, nil
+}
+
+func (m *MockThreadStorage) SetThreadSuccessor(ctx context.Context, board domain.BoardShortName, id, successor domain.ThreadId, setBy domain.UserId) error {
+	if m.setThreadSuccessorFunc != nil {
+		return m.setThreadSuccessorFunc(board, id, successor, setBy)
+	}
+	return nil
+}
+
+func (m *MockThreadStorage) GetShadowbannedUsers(ctx context.Context, board domain.BoardShortName) ([]domain.UserId, error) {
+	if m.getShadowbannedUsersFunc != nil {
+		return m.getShadowbannedUsersFunc(board)
+	}
+	return nil, nil
+}
+
+func (m *MockThreadStorage) GetBoardSettings(ctx context.Context, board domain.BoardShortName) (domain.BoardSettings, error) {
+	if m.getBoardSettingsFunc != nil {
+		return m.getBoardSettingsFunc(board)
+	}
+	return domain.BoardSettings{}, nil
+}
+
+func (m *MockThreadStorage) CountUserThreadsSince(ctx context.Context, board domain.BoardShortName, userId domain.UserId, since time.Time) (int, error) {
+	if m.countUserThreadsSinceFunc != nil {
+		return m.countUserThreadsSinceFunc(board, userId, since)
+	}
+	return 0, nil
+}
+
+func (m *MockThreadStorage) GetPremodPoster(ctx context.Context, board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error) {
+	if m.getPremodPosterFunc != nil {
+		return m.getPremodPosterFunc(board, userId)
+	}
+	return domain.PremodPoster{}, nil
+}
+
+func (m *MockThreadStorage) IsWatchingThread(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+	if m.isWatchingThreadFunc != nil {
+		return m.isWatchingThreadFunc(userId, board, threadId)
+	}
+	return false, nil
+}
+
+func (m *MockThreadStorage) GetExistingMessages(ctx context.Context, board domain.BoardShortName, links []domain.MessageLink) ([]domain.MessageLink, error) {
+	if m.getExistingMessagesFunc != nil {
+		return m.getExistingMessagesFunc(board, links)
+	}
+	return links, nil
+}
+
+func (m *MockThreadStorage) GetReadPosition(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) (domain.MsgId, error) {
+	if m.getReadPositionFunc != nil {
+		return m.getReadPositionFunc(userId, board, threadId)
+	}
+	return 0, nil
+}
+
+func (m *MockThreadStorage) SetReadPosition(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	if m.setReadPositionFunc != nil {
+		return m.setReadPositionFunc(userId, board, threadId, msgId)
+	}
+	return nil
+}
+
+func (m *MockThreadStorage) GetThreadUpdates(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, limit int) (domain.ThreadUpdates, error) {
+	if m.getThreadUpdatesFunc != nil {
+		return m.getThreadUpdatesFunc(board, id, since, limit)
+	}
+	return domain.ThreadUpdates{}, nil
+}
+
+func (m *MockThreadStorage) GetThreadGraph(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadGraph, error) {
+	if m.getThreadGraphFunc != nil {
+		return m.getThreadGraphFunc(board, id)
+	}
+	return domain.ThreadGraph{}, nil
+}
+
+func (m *MockThreadStorage) GetThreadAttachments(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadAttachments, error) {
+	if m.getThreadAttachmentsFunc != nil {
+		return m.getThreadAttachmentsFunc(board, id)
+	}
+	return domain.ThreadAttachments{}, nil
+}
+
+func (m *MockThreadStorage) GetThreadArchive(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadArchive, error) {
+	if m.getThreadArchiveFunc != nil {
+		return m.getThreadArchiveFunc(board, id)
+	}
+	return domain.ThreadArchive{}, nil
+}
+
+func (m *MockThreadStorage) RecordModeration(ctx context.Context, entry domain.ModLogEntry) error {
+	if m.recordModerationFunc != nil {
+		return m.recordModerationFunc(entry)
+	}
+	return nil
+}
+
 // MockThreadValidator mocks the ThreadValidator interface.
 type MockThreadValidator struct {
-	titleFunc func(title domain.ThreadTitle) error
+	titleFunc func(title domain.ThreadTitle, language domain.BoardLanguage) error
 }
 
-func (m *MockThreadValidator) Title(title domain.ThreadTitle) error {
+func (m *MockThreadValidator) Title(title domain.ThreadTitle, language domain.BoardLanguage) error {
 	if m.titleFunc != nil {
-		return m.titleFunc(title)
+		return m.titleFunc(title, language)
 	}
 	return nil // Default valid
 }
@@ -134,10 +312,10 @@ func TestThreadCreate(t *testing.T) {
 		validator := &MockThreadValidator{}
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
-		service := NewThread(storage, validator, messageService, mediaStorage, nil)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{})
 		createCalled := false
 
-		validator.titleFunc = func(title domain.ThreadTitle) error {
+		validator.titleFunc = func(title domain.ThreadTitle, language domain.BoardLanguage) error {
 			assert.Equal(t, validTitle, title)
 			return nil
 		}
@@ -152,7 +330,7 @@ func TestThreadCreate(t *testing.T) {
 		}
 
 		// Act
-		threadId, err := service.Create(validCreationData)
+		threadId, err := service.Create(context.Background(), validCreationData)
 
 		// Assert
 		require.NoError(t, err)
@@ -168,7 +346,7 @@ func TestThreadCreate(t *testing.T) {
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
 		maxCount := 100
-		service := NewThread(storage, validator, messageService, mediaStorage, &maxCount)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{MaxThreadCount: &maxCount})
 		createCalled := false
 
 		storage.createThreadFunc = func(creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error) {
@@ -183,7 +361,7 @@ func TestThreadCreate(t *testing.T) {
 		}
 
 		// Act
-		threadId, err := service.Create(validCreationData)
+		threadId, err := service.Create(context.Background(), validCreationData)
 
 		// Assert
 		require.NoError(t, err)
@@ -198,11 +376,11 @@ func TestThreadCreate(t *testing.T) {
 		validator := &MockThreadValidator{}
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
-		service := NewThread(storage, validator, messageService, mediaStorage, nil)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{})
 		validationError := &internal_errors.ErrorWithStatusCode{Message: "Invalid title", StatusCode: 400}
 		createCalled := false
 
-		validator.titleFunc = func(title domain.ThreadTitle) error {
+		validator.titleFunc = func(title domain.ThreadTitle, language domain.BoardLanguage) error {
 			assert.Equal(t, validTitle, title)
 			return validationError
 		}
@@ -212,7 +390,7 @@ func TestThreadCreate(t *testing.T) {
 		}
 
 		// Act
-		_, err := service.Create(validCreationData)
+		_, err := service.Create(context.Background(), validCreationData)
 
 		// Assert
 		require.Error(t, err)
@@ -220,6 +398,27 @@ func TestThreadCreate(t *testing.T) {
 		assert.False(t, createCalled, "CreateThread should not be called on validation error")
 	})
 
+	t.Run("Title checked for the board's language", func(t *testing.T) {
+		storage := &MockThreadStorage{}
+		storage.ResetCallTracking()
+		validator := &MockThreadValidator{}
+		service := NewThread(storage, validator, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+		storage.getBoardSettingsFunc = func(board domain.BoardShortName) (domain.BoardSettings, error) {
+			return domain.BoardSettings{Language: domain.LanguageJapanese}, nil
+		}
+		var checkedFor domain.BoardLanguage
+		validator.titleFunc = func(title domain.ThreadTitle, language domain.BoardLanguage) error {
+			checkedFor = language
+			return nil
+		}
+
+		_, err := service.Create(context.Background(), validCreationData)
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.LanguageJapanese, checkedFor)
+	})
+
 	t.Run("Storage error during CreateThread", func(t *testing.T) {
 		// Arrange
 		storage := &MockThreadStorage{}
@@ -227,7 +426,7 @@ func TestThreadCreate(t *testing.T) {
 		validator := &MockThreadValidator{}
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
-		service := NewThread(storage, validator, messageService, mediaStorage, nil)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{})
 		storageError := errors.New("db connection lost")
 		createCalled := false
 
@@ -237,7 +436,7 @@ func TestThreadCreate(t *testing.T) {
 		}
 
 		// Act
-		_, err := service.Create(validCreationData)
+		_, err := service.Create(context.Background(), validCreationData)
 
 		// Assert
 		require.Error(t, err)
@@ -256,7 +455,7 @@ func TestThreadGet(t *testing.T) {
 		validator := &MockThreadValidator{} // Not used in Get
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
-		service := NewThread(storage, validator, messageService, mediaStorage, nil)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{})
 		expectedThread := domain.Thread{
 			ThreadMetadata: domain.ThreadMetadata{Title: "test title"},
 			Messages:       []*domain.Message{{MessageMetadata: domain.MessageMetadata{Id: domain.MsgId(testId)}}},
@@ -271,7 +470,7 @@ func TestThreadGet(t *testing.T) {
 		}
 
 		// Act
-		thread, err := service.Get("test", testId, 1)
+		thread, err := service.Get(context.Background(), "test", testId, 1, nil)
 
 		// Assert
 		require.NoError(t, err)
@@ -285,7 +484,7 @@ func TestThreadGet(t *testing.T) {
 		validator := &MockThreadValidator{}
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
-		service := NewThread(storage, validator, messageService, mediaStorage, nil)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{})
 		storageError := errors.New("mock GetThread error")
 		getCalled := false
 
@@ -296,13 +495,419 @@ func TestThreadGet(t *testing.T) {
 		}
 
 		// Act
-		_, err := service.Get("test", testId, 1)
+		_, err := service.Get(context.Background(), "test", testId, 1, nil)
 
 		// Assert
 		require.Error(t, err)
 		assert.True(t, errors.Is(err, storageError))
 		assert.True(t, getCalled, "Storage GetThread should be called")
 	})
+
+	shadowbanThread := func() domain.Thread {
+		return domain.Thread{Messages: []*domain.Message{
+			{MessageMetadata: domain.MessageMetadata{Id: 1, Author: domain.User{Id: 1}, Replies: domain.Replies{
+				{From: 2, FromAuthor: 2},
+				{From: 3, FromAuthor: 3},
+			}}},
+			{MessageMetadata: domain.MessageMetadata{Id: 2, Author: domain.User{Id: 2}}},
+			{MessageMetadata: domain.MessageMetadata{Id: 3, Author: domain.User{Id: 3}}},
+		}}
+	}
+	newShadowbanService := func(banned ...domain.UserId) ThreadService {
+		storage := &MockThreadStorage{
+			getThreadFunc: func(domain.BoardShortName, domain.ThreadId, int) (domain.Thread, error) {
+				return shadowbanThread(), nil
+			},
+			getShadowbannedUsersFunc: func(board domain.BoardShortName) ([]domain.UserId, error) {
+				assert.Equal(t, domain.BoardShortName("test"), board)
+				return banned, nil
+			},
+		}
+		return NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+	}
+	messageIds := func(thread domain.Thread) []domain.MsgId {
+		var ids []domain.MsgId
+		for _, msg := range thread.Messages {
+			ids = append(ids, msg.Id)
+		}
+		return ids
+	}
+
+	t.Run("Shadowbanned messages hidden from others", func(t *testing.T) {
+		thread, err := newShadowbanService(3).Get(context.Background(), "test", testId, 1, &domain.User{Id: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.MsgId{1, 2}, messageIds(thread))
+		require.Len(t, thread.Messages[0].Replies, 1)
+		assert.Equal(t, domain.MsgId(2), thread.Messages[0].Replies[0].From)
+		assert.False(t, thread.Messages[1].Shadowbanned)
+	})
+
+	t.Run("Shadowbanned messages visible to their author", func(t *testing.T) {
+		thread, err := newShadowbanService(3).Get(context.Background(), "test", testId, 1, &domain.User{Id: 3})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.MsgId{1, 2, 3}, messageIds(thread))
+		assert.Len(t, thread.Messages[0].Replies, 2)
+		assert.False(t, thread.Messages[2].Shadowbanned, "authors must not learn about the shadowban")
+	})
+
+	t.Run("Shadowbanned messages marked for admins", func(t *testing.T) {
+		thread, err := newShadowbanService(3).Get(context.Background(), "test", testId, 1, &domain.User{Id: 9, Admin: true})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.MsgId{1, 2, 3}, messageIds(thread))
+		assert.True(t, thread.Messages[2].Shadowbanned)
+		assert.False(t, thread.Messages[1].Shadowbanned)
+	})
+
+	t.Run("Thread by shadowbanned user not found for anonymous viewer", func(t *testing.T) {
+		_, err := newShadowbanService(1).Get(context.Background(), "test", testId, 1, nil)
+		requireStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("Watched flag for logged-in viewer", func(t *testing.T) {
+		storage := &MockThreadStorage{
+			getThreadFunc: func(domain.BoardShortName, domain.ThreadId, int) (domain.Thread, error) {
+				return domain.Thread{}, nil
+			},
+			isWatchingThreadFunc: func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+				assert.Equal(t, domain.UserId(5), userId)
+				assert.Equal(t, testId, threadId)
+				return true, nil
+			},
+		}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+		thread, err := service.Get(context.Background(), "test", testId, 1, &domain.User{Id: 5})
+		require.NoError(t, err)
+		assert.True(t, thread.Watched)
+
+		thread, err = service.Get(context.Background(), "test", testId, 1, nil)
+		require.NoError(t, err)
+		assert.False(t, thread.Watched, "anonymous viewers watch nothing")
+	})
+
+	t.Run("Read position for logged-in viewer", func(t *testing.T) {
+		var moved []domain.MsgId
+		storage := &MockThreadStorage{
+			getThreadFunc: func(domain.BoardShortName, domain.ThreadId, int) (domain.Thread, error) {
+				return domain.Thread{Messages: []*domain.Message{
+					{MessageMetadata: domain.MessageMetadata{Id: 1}},
+					{MessageMetadata: domain.MessageMetadata{Id: 4}},
+				}}, nil
+			},
+			getReadPositionFunc: func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) (domain.MsgId, error) {
+				assert.Equal(t, domain.UserId(5), userId)
+				return 2, nil
+			},
+			setReadPositionFunc: func(userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+				moved = append(moved, msgId)
+				return errors.New("db down")
+			},
+		}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+		thread, err := service.Get(context.Background(), "test", testId, 1, &domain.User{Id: 5})
+		require.NoError(t, err, "a failed position update does not fail the page")
+		assert.Equal(t, domain.MsgId(2), thread.LastReadId, "the position from before the view is returned")
+		assert.Equal(t, []domain.MsgId{4}, moved)
+
+		_, err = service.Get(context.Background(), "test", testId, 1, nil)
+		require.NoError(t, err)
+		assert.Len(t, moved, 1, "anonymous views are not tracked")
+	})
+
+	t.Run("Read position is left alone when viewing as the user", func(t *testing.T) {
+		admin := domain.UserId(1)
+		storage := &MockThreadStorage{
+			getThreadFunc: func(domain.BoardShortName, domain.ThreadId, int) (domain.Thread, error) {
+				return domain.Thread{Messages: []*domain.Message{{MessageMetadata: domain.MessageMetadata{Id: 4}}}}, nil
+			},
+			getReadPositionFunc: func(domain.UserId, domain.BoardShortName, domain.ThreadId) (domain.MsgId, error) {
+				return 2, nil
+			},
+			setReadPositionFunc: func(domain.UserId, domain.BoardShortName, domain.ThreadId, domain.MsgId) error {
+				t.Fatal("view-as must not move the user's read position")
+				return nil
+			},
+		}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+		thread, err := service.Get(context.Background(), "test", testId, 1, &domain.User{Id: 5, ImpersonatedBy: &admin})
+		require.NoError(t, err)
+		assert.Equal(t, domain.MsgId(2), thread.LastReadId, "the user's unread posts are still shown")
+	})
+}
+
+func TestThreadGetRange(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("passes the range and prepares for the viewer", func(t *testing.T) {
+		viewer := &domain.User{Id: 5}
+		storage := &MockThreadStorage{
+			getThreadRangeFunc: func(board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error) {
+				assert.Equal(t, domain.MsgId(100), from)
+				assert.Equal(t, domain.MsgId(150), to)
+				return domain.Thread{Messages: []*domain.Message{
+					{MessageMetadata: domain.MessageMetadata{Id: 1, Author: domain.User{Id: 1}}},
+					{MessageMetadata: domain.MessageMetadata{Id: 100, Author: domain.User{Id: 5}}},
+					{MessageMetadata: domain.MessageMetadata{Id: 101, Author: domain.User{Id: 6}}},
+				}}, nil
+			},
+			getShadowbannedUsersFunc: func(board domain.BoardShortName) ([]domain.UserId, error) {
+				return []domain.UserId{6}, nil
+			},
+		}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+		thread, err := service.GetRange(ctx, "b", 1, 100, 150, viewer)
+		require.NoError(t, err)
+		require.Len(t, thread.Messages, 2, "shadowbanned message is hidden")
+		assert.True(t, thread.Messages[1].IsOwn)
+	})
+
+	t.Run("open-ended range", func(t *testing.T) {
+		storage := &MockThreadStorage{
+			getThreadRangeFunc: func(board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error) {
+				assert.Equal(t, domain.MsgId(10+threadRangeLimit-1), to)
+				return domain.Thread{}, nil
+			},
+		}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+		_, err := service.GetRange(ctx, "b", 1, 10, 0, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		storage := &MockThreadStorage{
+			getThreadRangeFunc: func(domain.BoardShortName, domain.ThreadId, domain.MsgId, domain.MsgId) (domain.Thread, error) {
+				t.Fatal("storage called for an invalid range")
+				return domain.Thread{}, nil
+			},
+		}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+		for _, r := range [][2]domain.MsgId{{0, 10}, {20, 10}, {1, threadRangeLimit + 1}} {
+			_, err := service.GetRange(ctx, "b", 1, r[0], r[1], nil)
+			requireStatus(t, err, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestThreadGetUpdates(t *testing.T) {
+	testId := domain.ThreadId(1)
+	updates := func() domain.ThreadUpdates {
+		return domain.ThreadUpdates{
+			Messages: []*domain.Message{
+				{MessageMetadata: domain.MessageMetadata{Id: 4, Author: domain.User{Id: 2}}},
+				{MessageMetadata: domain.MessageMetadata{Id: 5, Author: domain.User{Id: 3}}},
+			},
+			Backlinks: domain.Replies{
+				{From: 4, To: 1, FromAuthor: 2},
+				{From: 5, To: 2, FromAuthor: 3},
+			},
+		}
+	}
+	newService := func(opAuthor domain.UserId, banned ...domain.UserId) ThreadService {
+		storage := &MockThreadStorage{
+			getThreadUpdatesFunc: func(board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, limit int) (domain.ThreadUpdates, error) {
+				assert.Equal(t, domain.MsgId(3), since)
+				assert.Equal(t, threadUpdatesLimit, limit)
+				return updates(), nil
+			},
+			getShadowbannedUsersFunc: func(board domain.BoardShortName) ([]domain.UserId, error) {
+				return banned, nil
+			},
+		}
+		messages := &MockMessageService{
+			getFunc: func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+				assert.Equal(t, domain.MsgId(1), id)
+				return domain.Message{MessageMetadata: domain.MessageMetadata{Id: 1, Author: domain.User{Id: opAuthor}}}, nil
+			},
+		}
+		return NewThread(storage, &MockThreadValidator{}, messages, &SharedMockMediaStorage{}, &config.Public{})
+	}
+
+	t.Run("Own messages and backlinks marked", func(t *testing.T) {
+		got, err := newService(1).GetUpdates(context.Background(), "test", testId, 3, &domain.User{Id: 2})
+		require.NoError(t, err)
+		require.Len(t, got.Messages, 2)
+		assert.True(t, got.Messages[0].IsOwn)
+		assert.False(t, got.Messages[1].IsOwn)
+		assert.True(t, got.Backlinks[0].FromOwn)
+		assert.False(t, got.Backlinks[1].FromOwn)
+	})
+
+	t.Run("Shadowbanned messages and backlinks hidden", func(t *testing.T) {
+		got, err := newService(1, 3).GetUpdates(context.Background(), "test", testId, 3, nil)
+		require.NoError(t, err)
+		require.Len(t, got.Messages, 1)
+		assert.Equal(t, domain.MsgId(4), got.Messages[0].Id)
+		require.Len(t, got.Backlinks, 1)
+		assert.Equal(t, domain.MsgId(4), got.Backlinks[0].From)
+	})
+
+	t.Run("Thread by shadowbanned user not found", func(t *testing.T) {
+		_, err := newService(3, 3).GetUpdates(context.Background(), "test", testId, 3, &domain.User{Id: 2})
+		requireStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("Negative since", func(t *testing.T) {
+		_, err := newService(1).GetUpdates(context.Background(), "test", testId, -1, nil)
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+}
+
+func TestThreadGetGraph(t *testing.T) {
+	testId := domain.ThreadId(1)
+	newService := func(banned ...domain.UserId) ThreadService {
+		storage := &MockThreadStorage{
+			getThreadGraphFunc: func(board domain.BoardShortName, id domain.ThreadId) (domain.ThreadGraph, error) {
+				return domain.ThreadGraph{
+					Nodes: []domain.GraphNode{{Id: 1, Author: 1}, {Id: 2, Author: 2}, {Id: 3, Author: 3}},
+					Edges: []domain.GraphEdge{{From: 2, To: 1}, {From: 3, To: 1}, {From: 3, To: 2}, {From: 2, To: 3}},
+				}, nil
+			},
+			getShadowbannedUsersFunc: func(board domain.BoardShortName) ([]domain.UserId, error) {
+				return banned, nil
+			},
+		}
+		return NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+	}
+
+	t.Run("Full graph", func(t *testing.T) {
+		graph, err := newService().GetGraph(context.Background(), "test", testId, nil)
+		require.NoError(t, err)
+		assert.Len(t, graph.Nodes, 3)
+		assert.Len(t, graph.Edges, 4)
+	})
+
+	t.Run("Shadowbanned messages and their links hidden", func(t *testing.T) {
+		graph, err := newService(3).GetGraph(context.Background(), "test", testId, &domain.User{Id: 2})
+		require.NoError(t, err)
+		require.Len(t, graph.Nodes, 2)
+		assert.Equal(t, domain.MsgId(2), graph.Nodes[1].Id)
+		assert.Equal(t, []domain.GraphEdge{{From: 2, To: 1}}, graph.Edges)
+	})
+
+	t.Run("Shadowbanned author and admins see everything", func(t *testing.T) {
+		for _, viewer := range []*domain.User{{Id: 3}, {Id: 9, Admin: true}} {
+			graph, err := newService(3).GetGraph(context.Background(), "test", testId, viewer)
+			require.NoError(t, err)
+			assert.Len(t, graph.Edges, 4)
+		}
+	})
+
+	t.Run("Thread by shadowbanned user not found", func(t *testing.T) {
+		_, err := newService(1).GetGraph(context.Background(), "test", testId, nil)
+		requireStatus(t, err, http.StatusNotFound)
+	})
+}
+
+func TestThreadGetAttachments(t *testing.T) {
+	testId := domain.ThreadId(1)
+	newService := func(banned ...domain.UserId) ThreadService {
+		storage := &MockThreadStorage{
+			getThreadAttachmentsFunc: func(board domain.BoardShortName, id domain.ThreadId) (domain.ThreadAttachments, error) {
+				return domain.ThreadAttachments{
+					Attachments: []domain.ThreadAttachment{
+						{Attachment: &domain.Attachment{Id: 1, MessageId: 2}, Author: 2},
+						{Attachment: &domain.Attachment{Id: 2, MessageId: 3}, Author: 3},
+						{Attachment: &domain.Attachment{Id: 3, MessageId: 4}, Author: 2},
+					},
+					OpAuthor: 1,
+				}, nil
+			},
+			getShadowbannedUsersFunc: func(board domain.BoardShortName) ([]domain.UserId, error) {
+				return banned, nil
+			},
+		}
+		return NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+	}
+
+	t.Run("All attachments", func(t *testing.T) {
+		result, err := newService().GetAttachments(context.Background(), "test", testId, nil)
+		require.NoError(t, err)
+		assert.Len(t, result.Attachments, 3)
+	})
+
+	t.Run("Attachments of shadowbanned messages hidden", func(t *testing.T) {
+		result, err := newService(3).GetAttachments(context.Background(), "test", testId, &domain.User{Id: 2})
+		require.NoError(t, err)
+		require.Len(t, result.Attachments, 2)
+		assert.Equal(t, domain.AttachmentId(1), result.Attachments[0].Attachment.Id)
+		assert.Equal(t, domain.AttachmentId(3), result.Attachments[1].Attachment.Id)
+	})
+
+	t.Run("Shadowbanned author sees own attachments", func(t *testing.T) {
+		result, err := newService(3).GetAttachments(context.Background(), "test", testId, &domain.User{Id: 3})
+		require.NoError(t, err)
+		assert.Len(t, result.Attachments, 3)
+	})
+
+	t.Run("Thread by shadowbanned user not found", func(t *testing.T) {
+		_, err := newService(1).GetAttachments(context.Background(), "test", testId, nil)
+		requireStatus(t, err, http.StatusNotFound)
+	})
+}
+
+func TestThreadExport(t *testing.T) {
+	testId := domain.ThreadId(1)
+	hashes := map[domain.MsgId][]byte{1: {0x01}, 2: {0x02}, 4: {0x04}}
+	newService := func(banned ...domain.UserId) ThreadService {
+		storage := &MockThreadStorage{
+			getThreadArchiveFunc: func(board domain.BoardShortName, id domain.ThreadId) (domain.ThreadArchive, error) {
+				archive := domain.ThreadArchive{Board: board, ThreadId: id, Title: "title"}
+				for _, m := range []struct {
+					id     domain.MsgId
+					author domain.UserId
+				}{{1, 1}, {2, 2}, {3, 2}, {4, 3}} {
+					archive.Messages = append(archive.Messages, domain.ArchivedMessage{
+						Id: m.id, Author: m.author, ContentHash: hex.EncodeToString(hashes[m.id]),
+					})
+				}
+				return archive, nil
+			},
+			getShadowbannedUsersFunc: func(board domain.BoardShortName) ([]domain.UserId, error) {
+				return banned, nil
+			},
+		}
+		return NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+	}
+	root := func(ids ...domain.MsgId) string {
+		var leaves [][]byte
+		for _, id := range ids {
+			leaves = append(leaves, hashes[id])
+		}
+		return hex.EncodeToString(crypto.MerkleRoot(leaves))
+	}
+
+	t.Run("Root covers the messages with a hash", func(t *testing.T) {
+		archive, err := newService().Export(context.Background(), "test", testId, nil)
+		require.NoError(t, err)
+		assert.Len(t, archive.Messages, 4)
+		assert.Equal(t, crypto.ContentHashVersion, archive.HashVersion)
+		assert.False(t, archive.ExportedAt.IsZero())
+		assert.Equal(t, root(1, 2, 4), archive.MerkleRoot)
+	})
+
+	t.Run("Shadowbanned messages left out of the export and the root", func(t *testing.T) {
+		archive, err := newService(3).Export(context.Background(), "test", testId, &domain.User{Id: 2})
+		require.NoError(t, err)
+		require.Len(t, archive.Messages, 3)
+		assert.Equal(t, domain.MsgId(3), archive.Messages[2].Id)
+		assert.Equal(t, root(1, 2), archive.MerkleRoot)
+	})
+
+	t.Run("Shadowbanned author exports own messages", func(t *testing.T) {
+		archive, err := newService(3).Export(context.Background(), "test", testId, &domain.User{Id: 3})
+		require.NoError(t, err)
+		assert.Equal(t, root(1, 2, 4), archive.MerkleRoot)
+	})
+
+	t.Run("Thread by shadowbanned user not found", func(t *testing.T) {
+		_, err := newService(1).Export(context.Background(), "test", testId, nil)
+		requireStatus(t, err, http.StatusNotFound)
+	})
 }
 
 func TestThreadDelete(t *testing.T) {
@@ -317,19 +922,25 @@ func TestThreadDelete(t *testing.T) {
 		validator := &MockThreadValidator{} // Not used in Delete
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
-		service := NewThread(storage, validator, messageService, mediaStorage, nil)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{DeletionReasonMaxLen: 200})
 
 		storage.deleteThreadFunc = func(board domain.BoardShortName, id domain.ThreadId) error {
 			assert.Equal(t, testBoard, board)
 			assert.Equal(t, testId, id)
 			return nil
 		}
+		var logged []domain.ModLogEntry
+		storage.recordModerationFunc = func(entry domain.ModLogEntry) error {
+			logged = append(logged, entry)
+			return nil
+		}
 
 		// Act
-		err := service.Delete(testBoard, testId)
+		err := service.Delete(context.Background(), testBoard, testId, "  spam ")
 
 		// Assert
 		require.NoError(t, err)
+		assert.Equal(t, []domain.ModLogEntry{{Board: testBoard, Action: domain.ModLogDeleteThread, Reason: "spam"}}, logged)
 		storage.mu.Lock()
 		assert.True(t, storage.deleteThreadCalled, "DeleteThread should have been called")
 		assert.Equal(t, testBoard, storage.deleteBoardArg)
@@ -337,6 +948,19 @@ func TestThreadDelete(t *testing.T) {
 		storage.mu.Unlock()
 	})
 
+	t.Run("Reason too long", func(t *testing.T) {
+		storage := &MockThreadStorage{}
+		storage.ResetCallTracking()
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{DeletionReasonMaxLen: 5})
+
+		err := service.Delete(context.Background(), testBoard, testId, "too long reason")
+
+		requireStatus(t, err, http.StatusBadRequest)
+		storage.mu.Lock()
+		assert.False(t, storage.deleteThreadCalled, "DeleteThread should not be called")
+		storage.mu.Unlock()
+	})
+
 	t.Run("Storage error", func(t *testing.T) {
 		// Arrange
 		storage := &MockThreadStorage{}
@@ -344,7 +968,7 @@ func TestThreadDelete(t *testing.T) {
 		validator := &MockThreadValidator{}
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
-		service := NewThread(storage, validator, messageService, mediaStorage, nil)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{})
 		storageError := errors.New("mock DeleteThread error")
 
 		storage.deleteThreadFunc = func(board domain.BoardShortName, id domain.ThreadId) error {
@@ -353,8 +977,13 @@ func TestThreadDelete(t *testing.T) {
 			return storageError
 		}
 
+		storage.recordModerationFunc = func(entry domain.ModLogEntry) error {
+			t.Fatal("failed deletion recorded in the moderation log")
+			return nil
+		}
+
 		// Act
-		err := service.Delete(testBoard, testId)
+		err := service.Delete(context.Background(), testBoard, testId, "")
 
 		// Assert
 		require.Error(t, err)
@@ -365,6 +994,17 @@ func TestThreadDelete(t *testing.T) {
 		assert.Equal(t, testId, storage.deleteIdArg)
 		storage.mu.Unlock()
 	})
+
+	t.Run("Moderation log error does not fail the deletion", func(t *testing.T) {
+		storage := &MockThreadStorage{
+			recordModerationFunc: func(entry domain.ModLogEntry) error {
+				return errors.New("mock RecordModeration error")
+			},
+		}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{DeletionReasonMaxLen: 200})
+
+		require.NoError(t, service.Delete(context.Background(), testBoard, testId, "spam"))
+	})
 }
 
 func TestThreadTogglePinned(t *testing.T) {
@@ -378,7 +1018,7 @@ func TestThreadTogglePinned(t *testing.T) {
 		validator := &MockThreadValidator{}
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
-		service := NewThread(storage, validator, messageService, mediaStorage, nil)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{})
 
 		toggleCalled := false
 		storage.togglePinnedStatusFunc = func(board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
@@ -389,7 +1029,7 @@ func TestThreadTogglePinned(t *testing.T) {
 		}
 
 		// Act
-		newStatus, err := service.TogglePinned(testBoard, testId)
+		newStatus, err := service.TogglePinned(context.Background(), testBoard, testId)
 
 		// Assert
 		require.NoError(t, err)
@@ -403,7 +1043,7 @@ func TestThreadTogglePinned(t *testing.T) {
 		validator := &MockThreadValidator{}
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
-		service := NewThread(storage, validator, messageService, mediaStorage, nil)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{})
 
 		toggleCalled := false
 		storage.togglePinnedStatusFunc = func(board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
@@ -414,7 +1054,7 @@ func TestThreadTogglePinned(t *testing.T) {
 		}
 
 		// Act
-		newStatus, err := service.TogglePinned(testBoard, testId)
+		newStatus, err := service.TogglePinned(context.Background(), testBoard, testId)
 
 		// Assert
 		require.NoError(t, err)
@@ -428,7 +1068,7 @@ func TestThreadTogglePinned(t *testing.T) {
 		validator := &MockThreadValidator{}
 		mediaStorage := &SharedMockMediaStorage{}
 		messageService := &MockMessageService{}
-		service := NewThread(storage, validator, messageService, mediaStorage, nil)
+		service := NewThread(storage, validator, messageService, mediaStorage, &config.Public{})
 
 		storageError := errors.New("database connection error")
 		toggleCalled := false
@@ -440,7 +1080,7 @@ func TestThreadTogglePinned(t *testing.T) {
 		}
 
 		// Act
-		_, err := service.TogglePinned(testBoard, testId)
+		_, err := service.TogglePinned(context.Background(), testBoard, testId)
 
 		// Assert
 		require.Error(t, err)
@@ -448,3 +1088,340 @@ func TestThreadTogglePinned(t *testing.T) {
 		assert.True(t, toggleCalled, "Storage TogglePinnedStatus should have been called")
 	})
 }
+
+func TestThreadToggleOpOnly(t *testing.T) {
+	testBoard := domain.BoardShortName("tst")
+	testId := domain.ThreadId(42)
+
+	storage := &MockThreadStorage{}
+	service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+	toggleCalled := false
+	storage.toggleOpOnlyStatusFunc = func(board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+		toggleCalled = true
+		assert.Equal(t, testBoard, board)
+		assert.Equal(t, testId, threadId)
+		return true, nil
+	}
+
+	newStatus, err := service.ToggleOpOnly(context.Background(), testBoard, testId)
+	require.NoError(t, err)
+	assert.True(t, newStatus)
+	assert.True(t, toggleCalled, "Storage ToggleOpOnlyStatus should be called")
+}
+
+func TestThreadEditTitle(t *testing.T) {
+	const opAuthor = domain.UserId(5)
+	ctx := context.Background()
+	newTitle := domain.ThreadTitle("Better title")
+
+	// setup returns a service whose thread was started by opAuthor opAge ago,
+	// and the recorded editor (nil until the storage is updated)
+	setup := func(window, opAge time.Duration) (ThreadService, **domain.User) {
+		edited := new(*domain.User)
+		storage := &MockThreadStorage{
+			updateThreadTitleFunc: func(board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editedBy domain.UserId, byModerator bool) error {
+				assert.Equal(t, newTitle, title)
+				*edited = &domain.User{Id: editedBy, Admin: byModerator}
+				return nil
+			},
+		}
+		messages := &MockMessageService{
+			getFunc: func(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+				require.Equal(t, domain.MsgId(1), id, "ownership is checked against the OP")
+				return domain.Message{MessageMetadata: domain.MessageMetadata{
+					Author:    domain.User{Id: opAuthor},
+					CreatedAt: time.Now().UTC().Add(-opAge),
+				}}, nil
+			},
+		}
+		cfg := &config.Public{ThreadTitleEditWindow: window}
+		return NewThread(storage, &MockThreadValidator{}, messages, &SharedMockMediaStorage{}, cfg), edited
+	}
+
+	t.Run("OP within the window", func(t *testing.T) {
+		service, edited := setup(15*time.Minute, time.Minute)
+
+		require.NoError(t, service.EditTitle(ctx, "b", 1, newTitle, domain.User{Id: opAuthor}))
+		require.NotNil(t, *edited)
+		assert.Equal(t, domain.User{Id: opAuthor}, **edited)
+	})
+
+	t.Run("moderator anytime", func(t *testing.T) {
+		service, edited := setup(0, 24*time.Hour)
+
+		require.NoError(t, service.EditTitle(ctx, "b", 1, newTitle, domain.User{Id: 99, Admin: true}))
+		require.NotNil(t, *edited)
+		assert.Equal(t, domain.User{Id: 99, Admin: true}, **edited)
+	})
+
+	t.Run("refusals", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			window, opAge time.Duration
+			editor        domain.UserId
+		}{
+			"someone else":     {window: 15 * time.Minute, opAge: time.Minute, editor: opAuthor + 1},
+			"window passed":    {window: 15 * time.Minute, opAge: time.Hour, editor: opAuthor},
+			"editing disabled": {window: 0, opAge: time.Minute, editor: opAuthor},
+		} {
+			t.Run(name, func(t *testing.T) {
+				service, edited := setup(tc.window, tc.opAge)
+
+				requireStatus(t, service.EditTitle(ctx, "b", 1, newTitle, domain.User{Id: tc.editor}), http.StatusForbidden)
+				assert.Nil(t, *edited)
+			})
+		}
+	})
+
+	t.Run("invalid title", func(t *testing.T) {
+		validationErr := &internal_errors.ErrorWithStatusCode{Message: "too long", StatusCode: http.StatusBadRequest}
+		service := NewThread(&MockThreadStorage{}, &MockThreadValidator{
+			titleFunc: func(domain.ThreadTitle, domain.BoardLanguage) error { return validationErr },
+		}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+		assert.ErrorIs(t, service.EditTitle(ctx, "b", 1, newTitle, domain.User{Id: 1, Admin: true}), validationErr)
+	})
+}
+
+func TestThreadSetSuccessor(t *testing.T) {
+	const opAuthor = domain.UserId(5)
+	ctx := context.Background()
+
+	// setup returns a service whose thread 1 was started by opAuthor and has
+	// posts messages, and the recorded link (nil until the storage is updated)
+	setup := func(posts int) (ThreadService, *[]domain.ThreadId) {
+		var linked []domain.ThreadId
+		storage := &MockThreadStorage{
+			getThreadRangeFunc: func(board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error) {
+				return domain.Thread{
+					ThreadMetadata: domain.ThreadMetadata{Id: id, MessageCount: posts},
+					Messages:       []*domain.Message{{MessageMetadata: domain.MessageMetadata{Id: 1, Author: domain.User{Id: opAuthor}}}},
+				}, nil
+			},
+			setThreadSuccessorFunc: func(board domain.BoardShortName, id, successor domain.ThreadId, setBy domain.UserId) error {
+				linked = []domain.ThreadId{id, successor, setBy}
+				return nil
+			},
+		}
+		cfg := &config.Public{BumpLimit: 10}
+		return NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, cfg), &linked
+	}
+
+	t.Run("OP of a thread past the bump limit", func(t *testing.T) {
+		service, linked := setup(11)
+
+		require.NoError(t, service.SetSuccessor(ctx, "b", 1, 7, domain.User{Id: opAuthor}))
+		assert.Equal(t, []domain.ThreadId{1, 7, opAuthor}, *linked)
+	})
+
+	t.Run("moderator anytime", func(t *testing.T) {
+		service, linked := setup(2)
+
+		require.NoError(t, service.SetSuccessor(ctx, "b", 1, 7, domain.User{Id: 99, Admin: true}))
+		assert.Equal(t, []domain.ThreadId{1, 7, 99}, *linked)
+	})
+
+	t.Run("refusals", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			posts     int
+			editor    domain.UserId
+			successor domain.ThreadId
+			status    int
+		}{
+			"someone else":         {posts: 11, editor: opAuthor + 1, successor: 7, status: http.StatusForbidden},
+			"still bumping":        {posts: 10, editor: opAuthor, successor: 7, status: http.StatusForbidden},
+			"same thread":          {posts: 11, editor: opAuthor, successor: 1, status: http.StatusBadRequest},
+			"moderator, older one": {posts: 11, editor: 0, successor: 0, status: http.StatusBadRequest},
+		} {
+			t.Run(name, func(t *testing.T) {
+				service, linked := setup(tc.posts)
+
+				requireStatus(t, service.SetSuccessor(ctx, "b", 1, tc.successor, domain.User{Id: tc.editor, Admin: tc.editor == 0}), tc.status)
+				assert.Nil(t, *linked)
+			})
+		}
+	})
+}
+
+func TestThreadCreate_BoardRequirements(t *testing.T) {
+	author := domain.User{Id: 7}
+	creationData := func(text domain.MsgText, files int) domain.ThreadCreationData {
+		data := domain.ThreadCreationData{
+			Title: "Title", Board: "tst",
+			OpMessage: domain.MessageCreationData{Author: author, Text: text},
+		}
+		for i := 0; i < files; i++ {
+			data.OpMessage.PendingFiles = append(data.OpMessage.PendingFiles, &domain.PendingFile{})
+		}
+		return data
+	}
+	uploaded := creationData("text", 0)
+	uploaded.OpMessage.UploadTokens = []domain.UploadToken{"token"}
+
+	tests := []struct {
+		name       string
+		settings   domain.BoardSettings
+		threads    int
+		data       domain.ThreadCreationData
+		wantErrMsg string
+	}{
+		{"no requirements", domain.BoardSettings{}, 100, creationData("", 0), ""},
+		{"OP text too short", domain.BoardSettings{MinOpTextLength: 10}, 0, creationData("  short  ", 0), "at least 10 characters"},
+		{"OP text counted in characters", domain.BoardSettings{MinOpTextLength: 5}, 0, creationData("привет", 0), ""},
+		{"attachment missing", domain.BoardSettings{RequireOpAttachment: true}, 0, creationData("text", 0), "at least one attachment"},
+		{"attachment present", domain.BoardSettings{RequireOpAttachment: true}, 0, creationData("text", 1), ""},
+		{"attachment uploaded beforehand", domain.BoardSettings{RequireOpAttachment: true}, 0, uploaded, ""},
+		{"daily limit reached", domain.BoardSettings{MaxThreadsPerUserPerDay: 2}, 2, creationData("text", 0), "at most 2 threads per day"},
+		{"daily limit not reached", domain.BoardSettings{MaxThreadsPerUserPerDay: 2}, 1, creationData("text", 0), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createCalled := false
+			storage := &MockThreadStorage{
+				getBoardSettingsFunc: func(board domain.BoardShortName) (domain.BoardSettings, error) {
+					assert.Equal(t, domain.BoardShortName("tst"), board)
+					return tt.settings, nil
+				},
+				countUserThreadsSinceFunc: func(board domain.BoardShortName, userId domain.UserId, since time.Time) (int, error) {
+					assert.Equal(t, author.Id, userId)
+					assert.WithinDuration(t, time.Now().Add(-24*time.Hour), since, time.Minute)
+					return tt.threads, nil
+				},
+				createThreadFunc: func(creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error) {
+					createCalled = true
+					return 1, time.Now(), nil
+				},
+			}
+			service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+			_, err := service.Create(context.Background(), tt.data)
+
+			if tt.wantErrMsg == "" {
+				require.NoError(t, err)
+				assert.True(t, createCalled)
+				return
+			}
+			requireStatus(t, err, http.StatusBadRequest)
+			assert.Contains(t, err.Error(), tt.wantErrMsg)
+			assert.False(t, createCalled, "CreateThread should not be called when requirements are not met")
+		})
+	}
+
+	t.Run("board not found", func(t *testing.T) {
+		storage := &MockThreadStorage{
+			getBoardSettingsFunc: func(board domain.BoardShortName) (domain.BoardSettings, error) {
+				return domain.BoardSettings{}, &internal_errors.ErrorWithStatusCode{Message: "Board 'tst' not found", StatusCode: http.StatusNotFound}
+			},
+		}
+		service := NewThread(storage, &MockThreadValidator{}, &MockMessageService{}, &SharedMockMediaStorage{}, &config.Public{})
+
+		_, err := service.Create(context.Background(), creationData("text", 0))
+
+		requireStatus(t, err, http.StatusNotFound)
+	})
+}
+
+func TestMarkOwn(t *testing.T) {
+	viewer := &domain.User{Id: 1}
+	newMessages := func() []*domain.Message {
+		return []*domain.Message{
+			{MessageMetadata: domain.MessageMetadata{ThreadId: 1, Id: 1, Author: domain.User{Id: 1}, Replies: domain.Replies{
+				{FromThreadId: 1, From: 2, FromAuthor: 2},
+				{FromThreadId: 1, From: 3, FromAuthor: 1},
+				{FromThreadId: 1, From: 9, FromAuthor: 3}, // Sender on another page
+			}}},
+			{MessageMetadata: domain.MessageMetadata{ThreadId: 1, Id: 2, Author: domain.User{Id: 2}, Replies: domain.Replies{
+				{FromThreadId: 1, From: 3, FromAuthor: 1},
+			}}},
+			{MessageMetadata: domain.MessageMetadata{ThreadId: 1, Id: 3, Author: domain.User{Id: 1}}},
+		}
+	}
+
+	t.Run("flags own messages, own replies and replies to own messages", func(t *testing.T) {
+		messages := newMessages()
+
+		markOwn(messages, viewer)
+
+		assert.True(t, messages[0].IsOwn)
+		assert.False(t, messages[1].IsOwn)
+		assert.True(t, messages[2].IsOwn)
+
+		assert.False(t, messages[0].RepliesToOwn)
+		assert.True(t, messages[1].RepliesToOwn)
+		assert.False(t, messages[2].RepliesToOwn, "Replying to yourself is not a reply to you")
+
+		assert.False(t, messages[0].Replies[0].FromOwn)
+		assert.True(t, messages[0].Replies[1].FromOwn)
+		assert.False(t, messages[0].Replies[2].FromOwn)
+		assert.True(t, messages[1].Replies[0].FromOwn)
+	})
+
+	t.Run("anonymous viewer", func(t *testing.T) {
+		messages := newMessages()
+
+		markOwn(messages, nil)
+
+		for _, msg := range messages {
+			assert.False(t, msg.IsOwn)
+			assert.False(t, msg.RepliesToOwn)
+		}
+	})
+}
+
+func TestMarkDeadLinks(t *testing.T) {
+	link := func(threadId domain.ThreadId, msgId domain.MsgId) string {
+		return fmt.Sprintf(`<a href="/test/%d#p%d" class="message-link message-link-preview" data-board="test" data-message-id="%d" data-thread-id="%d">&gt;&gt;%d#%d</a>`,
+			threadId, msgId, msgId, threadId, threadId, msgId)
+	}
+	newMessages := func() []*domain.Message {
+		return []*domain.Message{
+			{MessageMetadata: domain.MessageMetadata{ThreadId: 1, Id: 1}, Text: "no links"},
+			{MessageMetadata: domain.MessageMetadata{ThreadId: 1, Id: 2}, Text: link(1, 1) + "<br>" + link(7, 3) + link(7, 3)},
+			{MessageMetadata: domain.MessageMetadata{ThreadId: 1, Id: 3}, Text: link(7, 3) + link(1, 2)},
+		}
+	}
+
+	t.Run("marks links to missing messages", func(t *testing.T) {
+		var checked []domain.MessageLink
+		service := &Thread{storage: &MockThreadStorage{
+			getExistingMessagesFunc: func(board domain.BoardShortName, links []domain.MessageLink) ([]domain.MessageLink, error) {
+				assert.Equal(t, domain.BoardShortName("test"), board)
+				checked = links
+				return []domain.MessageLink{{ThreadId: 1, Id: 1}, {ThreadId: 1, Id: 2}}, nil
+			},
+		}}
+		messages := newMessages()
+
+		service.markDeadLinks(context.Background(), "test", messages)
+
+		assert.Equal(t, []domain.MessageLink{{ThreadId: 1, Id: 1}, {ThreadId: 7, Id: 3}, {ThreadId: 1, Id: 2}}, checked, "each target is checked once")
+		assert.Empty(t, messages[0].DeadLinks)
+		assert.Equal(t, []domain.MessageLink{{ThreadId: 7, Id: 3}}, messages[1].DeadLinks)
+		assert.Equal(t, []domain.MessageLink{{ThreadId: 7, Id: 3}}, messages[2].DeadLinks)
+	})
+
+	t.Run("failed check leaves links unmarked", func(t *testing.T) {
+		service := &Thread{storage: &MockThreadStorage{
+			getExistingMessagesFunc: func(board domain.BoardShortName, links []domain.MessageLink) ([]domain.MessageLink, error) {
+				return nil, errors.New("db down")
+			},
+		}}
+		messages := newMessages()
+
+		service.markDeadLinks(context.Background(), "test", messages)
+
+		for _, msg := range messages {
+			assert.Empty(t, msg.DeadLinks)
+		}
+	})
+
+	t.Run("no links, no query", func(t *testing.T) {
+		service := &Thread{storage: &MockThreadStorage{
+			getExistingMessagesFunc: func(board domain.BoardShortName, links []domain.MessageLink) ([]domain.MessageLink, error) {
+				t.Fatal("unexpected query")
+				return nil, nil
+			},
+		}}
+		service.markDeadLinks(context.Background(), "test", newMessages()[:1])
+	})
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedBoard wraps BoardService to record spans for board page loads, between
+// the request span and the spans of the queries they run. Other methods pass
+// through untraced.
+type TracedBoard struct {
+	BoardService
+}
+
+func NewTracedBoard(board BoardService) *TracedBoard {
+	return &TracedBoard{BoardService: board}
+}
+
+func (t *TracedBoard) Get(ctx context.Context, shortName domain.BoardShortName, page int, viewer *domain.User) (board domain.Board, err error) {
+	ctx, span := tracing.Start(ctx, "BoardService.Get", attribute.String("board", shortName), attribute.Int("page", page))
+	defer func() { tracing.End(span, err) }()
+	return t.BoardService.Get(ctx, shortName, page, viewer)
+}
+
+func (t *TracedBoard) List(ctx context.Context, sort domain.BoardSort, stats domain.BoardStatsMode, page int, viewer *domain.User) (boards []domain.BoardMetadata, total int, err error) {
+	ctx, span := tracing.Start(ctx, "BoardService.List", attribute.String("include_stats", stats), attribute.Int("page", page))
+	defer func() { tracing.End(span, err) }()
+	return t.BoardService.List(ctx, sort, stats, page, viewer)
+}
+
+// TracedThread does the same for thread reads and thread creation.
+type TracedThread struct {
+	ThreadService
+}
+
+func NewTracedThread(thread ThreadService) *TracedThread {
+	return &TracedThread{ThreadService: thread}
+}
+
+func (t *TracedThread) Create(ctx context.Context, creationData domain.ThreadCreationData) (id domain.ThreadId, err error) {
+	ctx, span := tracing.Start(ctx, "ThreadService.Create", attribute.String("board", creationData.Board))
+	defer func() { tracing.End(span, err) }()
+	return t.ThreadService.Create(ctx, creationData)
+}
+
+func (t *TracedThread) Get(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (thread domain.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "ThreadService.Get", threadAttributes(board, id, attribute.Int("page", page))...)
+	defer func() { tracing.End(span, err) }()
+	return t.ThreadService.Get(ctx, board, id, page, viewer)
+}
+
+func (t *TracedThread) GetRange(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId, viewer *domain.User) (thread domain.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "ThreadService.GetRange", threadAttributes(board, id, attribute.Int64("from", from), attribute.Int64("to", to))...)
+	defer func() { tracing.End(span, err) }()
+	return t.ThreadService.GetRange(ctx, board, id, from, to, viewer)
+}
+
+func (t *TracedThread) GetUpdates(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, viewer *domain.User) (updates domain.ThreadUpdates, err error) {
+	ctx, span := tracing.Start(ctx, "ThreadService.GetUpdates", threadAttributes(board, id, attribute.Int64("since", since))...)
+	defer func() { tracing.End(span, err) }()
+	return t.ThreadService.GetUpdates(ctx, board, id, since, viewer)
+}
+
+// TracedMessage records spans for posting and single message reads.
+type TracedMessage struct {
+	MessageService
+}
+
+func NewTracedMessage(message MessageService) *TracedMessage {
+	return &TracedMessage{MessageService: message}
+}
+
+func (t *TracedMessage) Create(ctx context.Context, creationData domain.MessageCreationData) (id domain.MsgId, err error) {
+	ctx, span := tracing.Start(ctx, "MessageService.Create", threadAttributes(creationData.Board, creationData.ThreadId)...)
+	defer func() { tracing.End(span, err) }()
+	return t.MessageService.Create(ctx, creationData)
+}
+
+func (t *TracedMessage) Get(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (msg domain.Message, err error) {
+	ctx, span := tracing.Start(ctx, "MessageService.Get", threadAttributes(board, threadId, attribute.Int64("message", id))...)
+	defer func() { tracing.End(span, err) }()
+	return t.MessageService.Get(ctx, board, threadId, id)
+}
+
+func threadAttributes(board domain.BoardShortName, id domain.ThreadId, extra ...attribute.KeyValue) []attribute.KeyValue {
+	return append([]attribute.KeyValue{attribute.String("board", board), attribute.Int64("thread", id)}, extra...)
+}
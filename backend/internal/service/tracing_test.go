@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// stubThreadService answers Get and leaves the other methods unimplemented.
+type stubThreadService struct {
+	ThreadService
+	get func(ctx context.Context) (domain.Thread, error)
+}
+
+func (s *stubThreadService) Get(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int, viewer *domain.User) (domain.Thread, error) {
+	return s.get(ctx)
+}
+
+func TestTracedThread(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	t.Run("storage calls run inside the service span", func(t *testing.T) {
+		var inner trace.SpanContext
+		traced := NewTracedThread(&stubThreadService{get: func(ctx context.Context) (domain.Thread, error) {
+			inner = trace.SpanContextFromContext(ctx)
+			return domain.Thread{}, nil
+		}})
+
+		_, err := traced.Get(context.Background(), "b", 7, 2, nil)
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.NotEmpty(t, spans)
+		span := spans[len(spans)-1]
+		assert.Equal(t, "ThreadService.Get", span.Name())
+		assert.Equal(t, span.SpanContext().SpanID(), inner.SpanID())
+		assert.Contains(t, span.Attributes(), attribute.String("board", "b"))
+		assert.Contains(t, span.Attributes(), attribute.Int64("thread", 7))
+		assert.Contains(t, span.Attributes(), attribute.Int("page", 2))
+	})
+
+	t.Run("errors are recorded and returned unchanged", func(t *testing.T) {
+		notFound := &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+		traced := NewTracedThread(&stubThreadService{get: func(ctx context.Context) (domain.Thread, error) {
+			return domain.Thread{}, notFound
+		}})
+
+		_, err := traced.Get(context.Background(), "b", 7, 1, nil)
+		requireStatus(t, err, http.StatusNotFound)
+
+		spans := recorder.Ended()
+		span := spans[len(spans)-1]
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Equal(t, "Thread not found", span.Status().Description)
+	})
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/itchan-dev/itchan/shared/config"
@@ -9,7 +10,9 @@ import (
 
 // UserActivityService provides methods for fetching user activity data
 type UserActivityService interface {
-	GetUserActivity(userId domain.UserId) ([]domain.Message, error)
+	GetUserActivity(ctx context.Context, userId domain.UserId) ([]domain.Message, error)
+	GetUserPosts(ctx context.Context, user *domain.User, page int) ([]domain.LatestPost, int, error)
+	GetUserStats(ctx context.Context, userId domain.UserId) (domain.UserStats, error)
 }
 
 // UserActivity implements UserActivityService
@@ -20,7 +23,10 @@ type UserActivity struct {
 
 // UserActivityStorage defines storage interface for user activity operations
 type UserActivityStorage interface {
-	GetUserMessages(userId domain.UserId, limit int) ([]domain.Message, error)
+	GetUserMessages(ctx context.Context, userId domain.UserId, limit int) ([]domain.Message, error)
+	GetBoards(ctx context.Context) ([]domain.BoardMetadata, error)
+	GetUserPosts(ctx context.Context, userId domain.UserId, boards []domain.BoardShortName, limit, offset int) ([]domain.LatestPost, int, error)
+	GetUserStats(ctx context.Context, userId domain.UserId) (domain.UserStats, error)
 }
 
 // NewUserActivity creates a new UserActivity service
@@ -32,13 +38,47 @@ func NewUserActivity(storage UserActivityStorage, cfg *config.Public) UserActivi
 }
 
 // GetUserActivity fetches user's recent messages
-func (s *UserActivity) GetUserActivity(userId domain.UserId) ([]domain.Message, error) {
+func (s *UserActivity) GetUserActivity(ctx context.Context, userId domain.UserId) ([]domain.Message, error) {
 	limit := s.cfg.UserMessagesPageLimit
 
-	messages, err := s.storage.GetUserMessages(userId, limit)
+	messages, err := s.storage.GetUserMessages(ctx, userId, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user messages: %w", err)
 	}
+	for i := range messages {
+		messages[i].IsOwn = true
+	}
 
 	return messages, nil
 }
+
+// GetUserPosts returns one page of the user's post history and the total number
+// of posts. Posts on boards the user can no longer read (e.g. after an email
+// domain change) are left out.
+func (s *UserActivity) GetUserPosts(ctx context.Context, user *domain.User, page int) ([]domain.LatestPost, int, error) {
+	boards, err := s.storage.GetBoards(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var visible []domain.BoardShortName
+	for _, b := range boards {
+		if canView(user, b) {
+			visible = append(visible, b.ShortName)
+		}
+	}
+
+	page = max(1, page)
+	limit := s.cfg.UserPostsPageLimit
+	offset := (page - 1) * limit
+	return s.storage.GetUserPosts(ctx, user.Id, visible, limit, offset)
+}
+
+// GetUserStats returns the user's posting statistics. Unlike GetUserPosts it
+// counts posts on every board, so moderators see the whole account.
+func (s *UserActivity) GetUserStats(ctx context.Context, userId domain.UserId) (domain.UserStats, error) {
+	stats, err := s.storage.GetUserStats(ctx, userId)
+	if err != nil {
+		return domain.UserStats{}, fmt.Errorf("failed to get user stats: %w", err)
+	}
+	return stats, nil
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -15,15 +16,39 @@ import (
 
 type MockUserActivityStorage struct {
 	GetUserMessagesFunc func(userId domain.UserId, limit int) ([]domain.Message, error)
+	GetBoardsFunc       func() ([]domain.BoardMetadata, error)
+	GetUserPostsFunc    func(userId domain.UserId, boards []domain.BoardShortName, limit, offset int) ([]domain.LatestPost, int, error)
+	GetUserStatsFunc    func(userId domain.UserId) (domain.UserStats, error)
 }
 
-func (m *MockUserActivityStorage) GetUserMessages(userId domain.UserId, limit int) ([]domain.Message, error) {
+func (m *MockUserActivityStorage) GetUserMessages(ctx context.Context, userId domain.UserId, limit int) ([]domain.Message, error) {
 	if m.GetUserMessagesFunc != nil {
 		return m.GetUserMessagesFunc(userId, limit)
 	}
 	return []domain.Message{}, nil
 }
 
+func (m *MockUserActivityStorage) GetBoards(ctx context.Context) ([]domain.BoardMetadata, error) {
+	if m.GetBoardsFunc != nil {
+		return m.GetBoardsFunc()
+	}
+	return []domain.BoardMetadata{}, nil
+}
+
+func (m *MockUserActivityStorage) GetUserPosts(ctx context.Context, userId domain.UserId, boards []domain.BoardShortName, limit, offset int) ([]domain.LatestPost, int, error) {
+	if m.GetUserPostsFunc != nil {
+		return m.GetUserPostsFunc(userId, boards, limit, offset)
+	}
+	return []domain.LatestPost{}, 0, nil
+}
+
+func (m *MockUserActivityStorage) GetUserStats(ctx context.Context, userId domain.UserId) (domain.UserStats, error) {
+	if m.GetUserStatsFunc != nil {
+		return m.GetUserStatsFunc(userId)
+	}
+	return domain.UserStats{}, nil
+}
+
 // --- Tests ---
 
 func TestGetUserActivity(t *testing.T) {
@@ -71,7 +96,7 @@ func TestGetUserActivity(t *testing.T) {
 		service := NewUserActivity(storage, cfg)
 
 		// Act
-		response, err := service.GetUserActivity(userId)
+		response, err := service.GetUserActivity(context.Background(), userId)
 
 		// Assert
 		require.NoError(t, err)
@@ -94,7 +119,7 @@ func TestGetUserActivity(t *testing.T) {
 		service := NewUserActivity(storage, cfg)
 
 		// Act
-		response, err := service.GetUserActivity(userId)
+		response, err := service.GetUserActivity(context.Background(), userId)
 
 		// Assert
 		require.NoError(t, err)
@@ -117,7 +142,7 @@ func TestGetUserActivity(t *testing.T) {
 		service := NewUserActivity(storage, cfg)
 
 		// Act
-		response, err := service.GetUserActivity(userId)
+		response, err := service.GetUserActivity(context.Background(), userId)
 
 		// Assert
 		require.Error(t, err)
@@ -145,7 +170,7 @@ func TestGetUserActivity(t *testing.T) {
 		service := NewUserActivity(storage, cfg)
 
 		// Act
-		_, err := service.GetUserActivity(userId)
+		_, err := service.GetUserActivity(context.Background(), userId)
 
 		// Assert
 		require.NoError(t, err)
@@ -153,6 +178,99 @@ func TestGetUserActivity(t *testing.T) {
 	})
 }
 
+func TestGetUserPosts(t *testing.T) {
+	user := &domain.User{Id: 42, EmailDomain: "corp.com"}
+	boards := []domain.BoardMetadata{
+		{ShortName: "b"},
+		{ShortName: "corp", AllowedEmailDomains: []string{"corp.com"}},
+		{ShortName: "other", AllowedEmailDomains: []string{"other.com"}},
+	}
+
+	t.Run("pages over visible boards", func(t *testing.T) {
+		expected := []domain.LatestPost{{Board: "corp", ThreadId: 1, Id: 3}}
+		storage := &MockUserActivityStorage{
+			GetBoardsFunc: func() ([]domain.BoardMetadata, error) { return boards, nil },
+			GetUserPostsFunc: func(id domain.UserId, visible []domain.BoardShortName, limit, offset int) ([]domain.LatestPost, int, error) {
+				assert.Equal(t, user.Id, id)
+				assert.Equal(t, []domain.BoardShortName{"b", "corp"}, visible)
+				assert.Equal(t, 10, limit)
+				assert.Equal(t, 20, offset)
+				return expected, 21, nil
+			},
+		}
+		service := NewUserActivity(storage, &config.Public{UserPostsPageLimit: 10})
+
+		posts, total, err := service.GetUserPosts(context.Background(), user, 3)
+
+		require.NoError(t, err)
+		assert.Equal(t, expected, posts)
+		assert.Equal(t, 21, total)
+	})
+
+	t.Run("page below one is the first page", func(t *testing.T) {
+		var capturedOffset int
+		storage := &MockUserActivityStorage{
+			GetUserPostsFunc: func(_ domain.UserId, _ []domain.BoardShortName, _, offset int) ([]domain.LatestPost, int, error) {
+				capturedOffset = offset
+				return nil, 0, nil
+			},
+		}
+		service := NewUserActivity(storage, &config.Public{UserPostsPageLimit: 10})
+
+		_, _, err := service.GetUserPosts(context.Background(), user, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, capturedOffset)
+	})
+
+	t.Run("boards error", func(t *testing.T) {
+		storageErr := errors.New("db down")
+		storage := &MockUserActivityStorage{
+			GetBoardsFunc: func() ([]domain.BoardMetadata, error) { return nil, storageErr },
+			GetUserPostsFunc: func(domain.UserId, []domain.BoardShortName, int, int) ([]domain.LatestPost, int, error) {
+				t.Fatal("GetUserPosts should not be called")
+				return nil, 0, nil
+			},
+		}
+		service := NewUserActivity(storage, &config.Public{UserPostsPageLimit: 10})
+
+		_, _, err := service.GetUserPosts(context.Background(), user, 1)
+
+		require.ErrorIs(t, err, storageErr)
+	})
+}
+
+func TestGetUserStats(t *testing.T) {
+	t.Run("returns storage stats", func(t *testing.T) {
+		first := time.Now().UTC()
+		expected := domain.UserStats{Posts: 7, ThreadsStarted: 2, Attachments: 3, FirstPostAt: &first}
+		storage := &MockUserActivityStorage{
+			GetUserStatsFunc: func(id domain.UserId) (domain.UserStats, error) {
+				assert.Equal(t, domain.UserId(42), id)
+				return expected, nil
+			},
+		}
+		service := NewUserActivity(storage, &config.Public{})
+
+		stats, err := service.GetUserStats(context.Background(), 42)
+
+		require.NoError(t, err)
+		assert.Equal(t, expected, stats)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		storageErr := errors.New("db down")
+		storage := &MockUserActivityStorage{
+			GetUserStatsFunc: func(domain.UserId) (domain.UserStats, error) { return domain.UserStats{}, storageErr },
+		}
+		service := NewUserActivity(storage, &config.Public{})
+
+		_, err := service.GetUserStats(context.Background(), 42)
+
+		require.ErrorIs(t, err, storageErr)
+	})
+}
+
 func TestNewUserActivity(t *testing.T) {
 	storage := &MockUserActivityStorage{}
 	cfg := &config.Public{
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// provisionedPassHash is stored for imported accounts. It is not a bcrypt
+// hash, so no password matches until the user sets one through registration.
+const provisionedPassHash = "-"
+
+// UserImportService creates accounts in bulk, e.g. to bring a whole company
+// onto its domain-restricted boards at once.
+type UserImportService interface {
+	// Import creates accounts for the emails that have none. With invite, the
+	// new users get an invitation email; the emails go out in the background.
+	Import(ctx context.Context, rows []domain.UserImportRow, invite bool) (domain.UserImportReport, error)
+}
+
+// UserImportStorage defines storage interface for bulk account imports
+type UserImportStorage interface {
+	User(ctx context.Context, emailHash []byte) (domain.User, error)
+	SaveUser(ctx context.Context, user domain.User) (domain.UserId, error)
+}
+
+type UserImport struct {
+	storage     UserImportStorage
+	email       Email
+	emailCrypto EmailCrypto
+	cfg         *config.Public
+	sendMu      sync.Mutex // Serializes invitation runs, so overlapping imports keep to the batch pace
+	sleep       func(ctx context.Context, d time.Duration)
+}
+
+func NewUserImport(storage UserImportStorage, email Email, emailCrypto EmailCrypto, cfg *config.Public) *UserImport {
+	return &UserImport{
+		storage:     storage,
+		email:       email,
+		emailCrypto: emailCrypto,
+		cfg:         cfg,
+		sleep:       sleepContext,
+	}
+}
+
+// ParseUserImportCSV reads the emails of an import. Emails are taken from the
+// "email" column when the first row names one, otherwise from the first
+// column of every row. Blank rows are skipped.
+func ParseUserImportCSV(r io.Reader) ([]domain.UserImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []domain.UserImportRow
+	column := 0
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if stderrors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, &errors.ErrorWithStatusCode{Message: fmt.Sprintf("Invalid CSV: %v", err), StatusCode: http.StatusBadRequest}
+		}
+		if first {
+			if i := headerColumn(record, "email"); i >= 0 {
+				column = i
+				continue
+			}
+		}
+		line, _ := reader.FieldPos(0)
+		if column >= len(record) || strings.TrimSpace(record[column]) == "" {
+			continue
+		}
+		rows = append(rows, domain.UserImportRow{Line: line, Email: strings.TrimSpace(record[column])})
+	}
+	return rows, nil
+}
+
+func headerColumn(record []string, name string) int {
+	for i, cell := range record {
+		if strings.EqualFold(strings.TrimSpace(cell), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *UserImport) Import(ctx context.Context, rows []domain.UserImportRow, invite bool) (domain.UserImportReport, error) {
+	report, created, err := s.CreateAccounts(ctx, rows)
+	if err != nil {
+		return report, err
+	}
+	if invite && len(created) > 0 {
+		report.Invited = len(created)
+		go s.SendInvitations(context.WithoutCancel(ctx), created)
+	}
+	return report, nil
+}
+
+// CreateAccounts creates an account for each valid email without one and
+// returns the emails of the new accounts. Imported accounts skip the
+// registration domain restrictions: the admin vouches for them. They have no
+// password until their owner sets one.
+func (s *UserImport) CreateAccounts(ctx context.Context, rows []domain.UserImportRow) (domain.UserImportReport, []domain.Email, error) {
+	report := domain.UserImportReport{Rejected: []domain.UserImportProblem{}}
+	if len(rows) > s.cfg.UserImport.MaxRows {
+		return report, nil, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Too many emails (%d), at most %d are imported at once", len(rows), s.cfg.UserImport.MaxRows),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	var created []domain.Email
+	seen := make(map[domain.Email]bool, len(rows))
+	for _, row := range rows {
+		email := strings.ToLower(row.Email)
+		reject := func(reason string) {
+			report.Rejected = append(report.Rejected, domain.UserImportProblem{Line: row.Line, Email: row.Email, Reason: reason})
+		}
+		if err := s.email.IsCorrect(email); err != nil {
+			reject("invalid email")
+			continue
+		}
+		if seen[email] {
+			reject("duplicate")
+			continue
+		}
+		seen[email] = true
+
+		emailHash := s.emailCrypto.Hash(email)
+		_, err := s.storage.User(ctx, emailHash)
+		if err == nil {
+			report.Existing++
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return report, created, err
+		}
+
+		emailEncrypted, err := s.emailCrypto.Encrypt(email)
+		if err != nil {
+			return report, created, fmt.Errorf("failed to encrypt email: %w", err)
+		}
+		emailDomain, err := s.emailCrypto.ExtractDomain(email)
+		if err != nil {
+			reject("invalid email")
+			continue
+		}
+		if _, err := s.storage.SaveUser(ctx, domain.User{
+			EmailEncrypted: emailEncrypted,
+			EmailDomain:    emailDomain,
+			EmailHash:      emailHash,
+			EmailIndex:     s.emailCrypto.BlindIndex(email),
+			PassHash:       provisionedPassHash,
+		}); err != nil {
+			return report, created, err
+		}
+		report.Created++
+		created = append(created, email)
+	}
+
+	logger.Log.Info("users imported", "created", report.Created, "existing", report.Existing, "rejected", len(report.Rejected))
+	return report, created, nil
+}
+
+// SendInvitations emails the users of an import how to set their password,
+// BatchSize emails at a time with BatchInterval between batches. Failed
+// emails are logged and skipped; it returns how many were sent.
+func (s *UserImport) SendInvitations(ctx context.Context, emails []domain.Email) int {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	sent := 0
+	for i, email := range emails {
+		if i > 0 && i%s.cfg.UserImport.BatchSize == 0 {
+			s.sleep(ctx, s.cfg.UserImport.BatchInterval)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.email.Send(email, "Приглашение в Itchan", s.formatInvitation(email)); err != nil {
+			emailHash := s.emailCrypto.Hash(email)
+			logger.Log.Error("failed to send import invitation", "email_hash_prefix", fmt.Sprintf("%x", emailHash[:8]), "error", err)
+			continue
+		}
+		sent++
+	}
+
+	logger.Log.Info("import invitations sent", "sent", sent, "failed", len(emails)-sent)
+	return sent
+}
+
+func (s *UserImport) formatInvitation(email domain.Email) string {
+	registerPage := "странице регистрации"
+	if site := strings.TrimRight(s.cfg.SiteURL, "/"); site != "" {
+		registerPage = fmt.Sprintf("странице регистрации: %s/register", site)
+	}
+	return fmt.Sprintf(`Здравствуйте.
+
+Для вас создана учётная запись в Itchan с адресом %s.
+
+Чтобы войти, задайте пароль на %s
+Укажите этот адрес, придумайте пароль и введите код подтверждения, который придёт следующим письмом.
+
+---
+Это автоматическое уведомление, пожалуйста, не отвечайте на него.`, email, registerPage)
+}
+
+// sleepContext waits for d or until ctx is cancelled.
+func sleepContext(ctx context.Context, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockUserImportStorage struct {
+	users map[string]domain.User
+	saved []domain.User
+}
+
+func (m *MockUserImportStorage) User(ctx context.Context, emailHash []byte) (domain.User, error) {
+	if user, ok := m.users[string(emailHash)]; ok {
+		return user, nil
+	}
+	return domain.User{}, &internal_errors.ErrorWithStatusCode{Message: "User not found", StatusCode: http.StatusNotFound}
+}
+
+func (m *MockUserImportStorage) SaveUser(ctx context.Context, user domain.User) (domain.UserId, error) {
+	m.saved = append(m.saved, user)
+	return domain.UserId(len(m.saved)), nil
+}
+
+func setupUserImportService(storage *MockUserImportStorage, email *MockEmail) *UserImport {
+	cfg := &config.Public{
+		SiteURL: "https://itchan.example",
+		UserImport: config.UserImportConfig{
+			MaxRows:       5,
+			BatchSize:     2,
+			BatchInterval: time.Minute,
+		},
+	}
+	s := NewUserImport(storage, email, &MockEmailCrypto{}, cfg)
+	s.sleep = func(ctx context.Context, d time.Duration) {}
+	return s
+}
+
+// --- Tests ---
+
+func TestParseUserImportCSV(t *testing.T) {
+	t.Run("without header takes first column", func(t *testing.T) {
+		rows, err := ParseUserImportCSV(strings.NewReader("a@example.com\n\nb@example.com,Bob\n"))
+
+		require.NoError(t, err)
+		assert.Equal(t, []domain.UserImportRow{
+			{Line: 1, Email: "a@example.com"},
+			{Line: 3, Email: "b@example.com"},
+		}, rows)
+	})
+
+	t.Run("with header takes email column", func(t *testing.T) {
+		rows, err := ParseUserImportCSV(strings.NewReader("name,Email\nAlice, a@example.com\nBob,\n"))
+
+		require.NoError(t, err)
+		assert.Equal(t, []domain.UserImportRow{{Line: 2, Email: "a@example.com"}}, rows)
+	})
+
+	t.Run("malformed csv", func(t *testing.T) {
+		_, err := ParseUserImportCSV(strings.NewReader("\"a@example.com\n"))
+
+		var e *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, http.StatusBadRequest, e.StatusCode)
+	})
+}
+
+func TestUserImportCreateAccounts(t *testing.T) {
+	t.Run("creates missing accounts and reports the rest", func(t *testing.T) {
+		storage := &MockUserImportStorage{users: map[string]domain.User{
+			"hash_old@example.com": {Id: 7},
+		}}
+		s := setupUserImportService(storage, &MockEmail{})
+
+		report, created, err := s.CreateAccounts(context.Background(), []domain.UserImportRow{
+			{Line: 1, Email: "New@Example.com"},
+			{Line: 2, Email: "not-an-email"},
+			{Line: 3, Email: "new@example.com"},
+			{Line: 4, Email: "old@example.com"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Existing)
+		assert.Equal(t, []domain.UserImportProblem{
+			{Line: 2, Email: "not-an-email", Reason: "invalid email"},
+			{Line: 3, Email: "new@example.com", Reason: "duplicate"},
+		}, report.Rejected)
+		assert.Equal(t, []domain.Email{"new@example.com"}, created)
+
+		require.Len(t, storage.saved, 1)
+		assert.Equal(t, provisionedPassHash, storage.saved[0].PassHash)
+		assert.Equal(t, []byte("hash_new@example.com"), storage.saved[0].EmailHash)
+		assert.Equal(t, "example.com", storage.saved[0].EmailDomain)
+	})
+
+	t.Run("rejects imports over the row limit", func(t *testing.T) {
+		storage := &MockUserImportStorage{}
+		s := setupUserImportService(storage, &MockEmail{})
+		rows := make([]domain.UserImportRow, 6)
+		for i := range rows {
+			rows[i] = domain.UserImportRow{Line: i + 1, Email: "user@example.com"}
+		}
+
+		_, _, err := s.CreateAccounts(context.Background(), rows)
+
+		var e *internal_errors.ErrorWithStatusCode
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, http.StatusBadRequest, e.StatusCode)
+		assert.Empty(t, storage.saved)
+	})
+}
+
+func TestUserImportSendInvitations(t *testing.T) {
+	t.Run("sends in batches and skips failures", func(t *testing.T) {
+		var sentTo []string
+		email := &MockEmail{SendFunc: func(recipient, subject, body string) error {
+			if recipient == "c@example.com" {
+				return errors.New("smtp down")
+			}
+			sentTo = append(sentTo, recipient)
+			assert.Contains(t, body, "https://itchan.example/register")
+			return nil
+		}}
+		s := setupUserImportService(&MockUserImportStorage{}, email)
+		pauses := 0
+		s.sleep = func(ctx context.Context, d time.Duration) {
+			assert.Equal(t, time.Minute, d)
+			pauses++
+		}
+
+		sent := s.SendInvitations(context.Background(), []domain.Email{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"})
+
+		assert.Equal(t, 4, sent)
+		assert.Equal(t, []string{"a@example.com", "b@example.com", "d@example.com", "e@example.com"}, sentTo)
+		assert.Equal(t, 2, pauses)
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		calls := 0
+		email := &MockEmail{SendFunc: func(recipient, subject, body string) error {
+			calls++
+			return nil
+		}}
+		s := setupUserImportService(&MockUserImportStorage{}, email)
+		ctx, cancel := context.WithCancel(context.Background())
+		s.sleep = func(ctx context.Context, d time.Duration) { cancel() }
+
+		sent := s.SendInvitations(ctx, []domain.Email{"a@example.com", "b@example.com", "c@example.com"})
+
+		assert.Equal(t, 2, sent)
+		assert.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// maxMetadataScan is how much of an image upload is buffered to look for EXIF.
+// A JPEG APP1 segment is at most 64KB and comes before the image data.
+const maxMetadataScan = 128 << 10
+
+// TIFF tags of the EXIF IFD0 kept for moderators
+const (
+	exifTagMake     = 0x010F
+	exifTagModel    = 0x0110
+	exifTagSoftware = 0x0131
+	exifTagGPSIFD   = 0x8825
+
+	tiffTypeASCII     = 2
+	maxExifStringSize = 256
+)
+
+// extractStrippedMetadata reads camera details from the EXIF block of a JPEG
+// or PNG header. GPS coordinates are not read, only whether a GPS IFD exists.
+// Returns nil when there is no EXIF or nothing of interest in it.
+func extractStrippedMetadata(header []byte) *domain.StrippedMetadata {
+	tiff := findExifTIFF(header)
+	if tiff == nil {
+		return nil
+	}
+	meta := parseTIFFMetadata(tiff)
+	if meta == nil || *meta == (domain.StrippedMetadata{}) {
+		return nil
+	}
+	return meta
+}
+
+// findExifTIFF returns the TIFF structure of the EXIF block, or nil.
+func findExifTIFF(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return findJPEGExif(data[2:])
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return findPNGExif(data[8:])
+	}
+	return nil
+}
+
+func findJPEGExif(data []byte) []byte {
+	for len(data) >= 4 && data[0] == 0xFF {
+		marker := data[1]
+		if marker == 0xDA || marker == 0xD9 { // Start of scan or end of image: no EXIF
+			return nil
+		}
+		size := int(binary.BigEndian.Uint16(data[2:4]))
+		if size < 2 || 2+size > len(data) {
+			return nil
+		}
+		segment := data[4 : 2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		data = data[2+size:]
+	}
+	return nil
+}
+
+func findPNGExif(data []byte) []byte {
+	for len(data) >= 12 {
+		size := int(binary.BigEndian.Uint32(data[:4]))
+		if size > len(data)-12 {
+			return nil
+		}
+		switch string(data[4:8]) {
+		case "eXIf":
+			return data[8 : 8+size]
+		case "IDAT": // EXIF must come before the image data
+			return nil
+		}
+		data = data[12+size:]
+	}
+	return nil
+}
+
+// parseTIFFMetadata reads the interesting tags of IFD0. Returns nil if the
+// TIFF header is malformed.
+func parseTIFFMetadata(tiff []byte) *domain.StrippedMetadata {
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd > len(tiff)-2 {
+		return nil
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+
+	meta := &domain.StrippedMetadata{}
+	for i := range count {
+		entry := ifd + 2 + i*12
+		if entry > len(tiff)-12 {
+			break
+		}
+		switch order.Uint16(tiff[entry:]) {
+		case exifTagMake:
+			meta.CameraMake = tiffString(tiff, order, entry)
+		case exifTagModel:
+			meta.CameraModel = tiffString(tiff, order, entry)
+		case exifTagSoftware:
+			meta.Software = tiffString(tiff, order, entry)
+		case exifTagGPSIFD:
+			gpsIFD := int(order.Uint32(tiff[entry+8:]))
+			meta.HasGPS = gpsIFD >= 8 && gpsIFD <= len(tiff)-2 && order.Uint16(tiff[gpsIFD:]) > 0
+		}
+	}
+	return meta
+}
+
+// tiffString reads an ASCII entry; values of up to 4 bytes are stored inline.
+func tiffString(tiff []byte, order binary.ByteOrder, entry int) string {
+	if order.Uint16(tiff[entry+2:]) != tiffTypeASCII {
+		return ""
+	}
+	size := int(order.Uint32(tiff[entry+4:]))
+	start := entry + 8
+	if size > 4 {
+		start = int(order.Uint32(tiff[entry+8:]))
+	}
+	if size <= 0 || start > len(tiff)-size {
+		return ""
+	}
+	value := tiff[start : start+min(size, maxExifStringSize)]
+	if i := bytes.IndexByte(value, 0); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(strings.ToValidUTF8(string(value), ""))
+}
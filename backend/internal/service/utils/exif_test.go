@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildExifTIFF builds a little-endian TIFF block with Make, Model and,
+// optionally, a GPS IFD holding only a version entry.
+func buildExifTIFF(cameraMake, cameraModel string, withGPS bool) []byte {
+	le := binary.LittleEndian
+	makeValue := append([]byte(cameraMake), 0)
+	modelValue := append([]byte(cameraModel), 0)
+
+	entries := 2
+	if withGPS {
+		entries++
+	}
+	dataStart := 8 + 2 + entries*12 + 4
+
+	buf := []byte("II")
+	buf = le.AppendUint16(buf, 42)
+	buf = le.AppendUint32(buf, 8)
+	buf = le.AppendUint16(buf, uint16(entries))
+
+	var data []byte
+	for _, e := range []struct {
+		tag   uint16
+		value []byte
+	}{{exifTagMake, makeValue}, {exifTagModel, modelValue}} {
+		buf = le.AppendUint16(buf, e.tag)
+		buf = le.AppendUint16(buf, tiffTypeASCII)
+		buf = le.AppendUint32(buf, uint32(len(e.value)))
+		if len(e.value) <= 4 { // Stored inline
+			buf = append(buf, append(e.value, make([]byte, 4-len(e.value))...)...)
+			continue
+		}
+		buf = le.AppendUint32(buf, uint32(dataStart+len(data)))
+		data = append(data, e.value...)
+	}
+
+	if withGPS {
+		buf = le.AppendUint16(buf, exifTagGPSIFD)
+		buf = le.AppendUint16(buf, 4) // LONG
+		buf = le.AppendUint32(buf, 1)
+		buf = le.AppendUint32(buf, uint32(dataStart+len(data)))
+	}
+	buf = le.AppendUint32(buf, 0) // No next IFD
+	buf = append(buf, data...)
+
+	if withGPS {
+		buf = le.AppendUint16(buf, 1)
+		buf = le.AppendUint16(buf, 0) // GPSVersionID
+		buf = le.AppendUint16(buf, 1) // BYTE
+		buf = le.AppendUint32(buf, 4)
+		buf = append(buf, 2, 2, 0, 0)
+		buf = le.AppendUint32(buf, 0)
+	}
+	return buf
+}
+
+// jpegWithExif encodes a small JPEG and inserts an APP1 EXIF segment after SOI.
+func jpegWithExif(t *testing.T, tiff []byte) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil))
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := encoded.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func TestExtractStrippedMetadata(t *testing.T) {
+	t.Run("JPEG with camera and GPS", func(t *testing.T) {
+		data := jpegWithExif(t, buildExifTIFF("Canon", "EOS R5", true))
+
+		meta := extractStrippedMetadata(data)
+		require.NotNil(t, meta)
+		assert.Equal(t, domain.StrippedMetadata{CameraMake: "Canon", CameraModel: "EOS R5", HasGPS: true}, *meta)
+	})
+
+	t.Run("short values are stored inline", func(t *testing.T) {
+		data := jpegWithExif(t, buildExifTIFF("LG", "G7", false))
+
+		meta := extractStrippedMetadata(data)
+		require.NotNil(t, meta)
+		assert.Equal(t, "LG", meta.CameraMake)
+		assert.Equal(t, "G7", meta.CameraModel)
+		assert.False(t, meta.HasGPS)
+	})
+
+	t.Run("PNG eXIf chunk", func(t *testing.T) {
+		tiff := buildExifTIFF("Apple", "iPhone 15", true)
+		data := []byte("\x89PNG\r\n\x1a\n")
+		data = binary.BigEndian.AppendUint32(data, uint32(len(tiff)))
+		data = append(data, "eXIf"...)
+		data = append(data, tiff...)
+		data = append(data, 0, 0, 0, 0) // CRC is not checked
+
+		meta := extractStrippedMetadata(data)
+		require.NotNil(t, meta)
+		assert.Equal(t, "iPhone 15", meta.CameraModel)
+		assert.True(t, meta.HasGPS)
+	})
+
+	t.Run("no EXIF", func(t *testing.T) {
+		var encoded bytes.Buffer
+		require.NoError(t, jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil))
+		assert.Nil(t, extractStrippedMetadata(encoded.Bytes()))
+	})
+
+	t.Run("truncated or garbage input", func(t *testing.T) {
+		data := jpegWithExif(t, buildExifTIFF("Canon", "EOS R5", true))
+		for i := range len(data) {
+			assert.NotPanics(t, func() { extractStrippedMetadata(data[:i]) })
+		}
+		assert.Nil(t, extractStrippedMetadata([]byte("GIF89a")))
+		assert.Nil(t, parseTIFFMetadata([]byte("XX\x2a\x00\x08\x00\x00\x00")))
+	})
+}
+
+func TestSanitizeImage_KeepsStrippedMetadata(t *testing.T) {
+	data := jpegWithExif(t, buildExifTIFF("Canon", "EOS R5", true))
+	pendingFile := &domain.PendingFile{
+		FileCommonMetadata: domain.FileCommonMetadata{
+			Filename:  "photo.jpg",
+			MimeType:  "image/jpeg",
+			SizeBytes: int64(len(data)),
+		},
+		Data: bytes.NewReader(data),
+	}
+
+	result, err := SanitizeImage(pendingFile, 100*1024*1024)
+	require.NoError(t, err)
+	require.NotNil(t, result.Metadata)
+	assert.Equal(t, "EOS R5", result.Metadata.CameraModel)
+	assert.True(t, result.Metadata.HasGPS)
+	assert.Equal(t, 8, *result.ImageWidth, "image still decodes after the header scan")
+}
@@ -1,29 +1,124 @@
 package utils
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"image"
 	_ "image/gif"
 	_ "image/jpeg"
-	_ "image/png"
+	"image/png"
 	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
 )
 
-// sanitizeVideoWithThumbnail strips metadata from a video and extracts a scaled
+// videoOutput is the container and encoder settings of a transcoding codec.
+type videoOutput struct {
+	ext      string
+	mimeType string
+	args     []string
+}
+
+var videoOutputs = map[string]videoOutput{
+	config.VideoCodecH264: {
+		ext:      ".mp4",
+		mimeType: "video/mp4",
+		args: []string{
+			"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-pix_fmt", "yuv420p",
+			"-c:a", "aac", "-b:a", "128k",
+			"-movflags", "+faststart",
+		},
+	},
+	config.VideoCodecVP9: {
+		ext:      ".webm",
+		mimeType: "video/webm",
+		args: []string{
+			"-c:v", "libvpx-vp9", "-crf", "32", "-row-mt", "1",
+			"-c:a", "libopus", "-b:a", "96k",
+		},
+	},
+}
+
+// streamCopyArgs keep every stream of the upload as is.
+var streamCopyArgs = []string{"-map", "0", "-c", "copy"}
+
+// transcodeArgs returns the ffmpeg output options that encode the first video
+// and audio stream with the given codec, scaled to width x height.
+func transcodeArgs(out videoOutput, codec string, maxBitrateKbps, width, height int) []string {
+	args := []string{"-map", "0:v:0", "-map", "0:a:0?"}
+	args = append(args, out.args...)
+	args = append(args, "-vf", fmt.Sprintf("scale=%d:%d", width, height))
+
+	switch {
+	case codec == config.VideoCodecH264 && maxBitrateKbps > 0:
+		args = append(args, "-maxrate", fmt.Sprintf("%dk", maxBitrateKbps), "-bufsize", fmt.Sprintf("%dk", 2*maxBitrateKbps))
+	case codec == config.VideoCodecVP9:
+		// Constrained quality with a cap, constant quality without one
+		args = append(args, "-b:v", fmt.Sprintf("%dk", maxBitrateKbps))
+	}
+	return args
+}
+
+// fitVideoSize scales width x height down to fit maxWidth x maxHeight (0 means
+// no limit), keeping the aspect ratio. Sizes are rounded down to even numbers,
+// which yuv420p encoders require. oversized reports whether scaling was needed.
+func fitVideoSize(width, height, maxWidth, maxHeight int) (w, h int, oversized bool) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = min(scale, float64(maxWidth)/float64(width))
+	}
+	if maxHeight > 0 && height > maxHeight {
+		scale = min(scale, float64(maxHeight)/float64(height))
+	}
+	w = max(int(float64(width)*scale)&^1, 2)
+	h = max(int(float64(height)*scale)&^1, 2)
+	return w, h, scale < 1
+}
+
+// probeVideoSize returns the dimensions of the first video stream.
+func probeVideoSize(inputPath string) (int, int, error) {
+	cmd := exec.Command("ffprobe",
+		"-v", "error",
+		"-protocol_whitelist", "file,pipe",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height",
+		"-of", "csv=p=0:s=x",
+		inputPath,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("ffprobe failed: %w (stderr: %s)", err, stderr.String())
+	}
+
+	var width, height int
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(out)), "%dx%d", &width, &height); err != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("no video stream found")
+	}
+	return width, height, nil
+}
+
+// sanitizeVideoWithThumbnail strips metadata from a video, writing it with
+// outputArgs to a temp file with extension outputExt, and extracts a scaled
 // first-frame thumbnail in a single ffmpeg invocation.
 // Returns (sanitizedVideoPath, thumbnailJPEGBytes, error).
 // Thumbnail bytes may be nil if extraction failed (non-fatal).
-func sanitizeVideoWithThumbnail(inputPath string, thumbMaxSize int) (string, []byte, error) {
-	// Extract extension from input file so ffmpeg can determine output format
-	ext := filepath.Ext(inputPath)
-	tmpFile, err := os.CreateTemp("", "sanitized_video_*"+ext)
+func sanitizeVideoWithThumbnail(inputPath, outputExt string, outputArgs []string, thumbMaxSize int) (string, []byte, error) {
+	// The extension lets ffmpeg determine the output format
+	tmpFile, err := os.CreateTemp("", "sanitized_video_*"+outputExt)
 	if err != nil {
 		return "", nil, err
 	}
@@ -36,14 +131,15 @@ func sanitizeVideoWithThumbnail(inputPath string, thumbMaxSize int) (string, []b
 	)
 
 	// Single ffmpeg command with two outputs:
-	//   1. Sanitized video file (copy streams, strip metadata)
+	//   1. Sanitized video file (strip metadata, streams copied or transcoded)
 	//   2. Scaled first-frame JPEG thumbnail (to stdout pipe)
-	cmd := exec.Command("ffmpeg",
+	args := []string{
 		"-protocol_whitelist", "file,pipe", // Prevent SSRF: only allow local file access
 		"-i", inputPath,
-		// Output 1: sanitized video
-		"-map", "0",
-		"-c", "copy",
+	}
+	// Output 1: sanitized video
+	args = append(args, outputArgs...)
+	args = append(args,
 		"-map_metadata", "-1",
 		"-map_metadata:s:v", "-1",
 		"-map_metadata:s:a", "-1",
@@ -58,6 +154,7 @@ func sanitizeVideoWithThumbnail(inputPath string, thumbMaxSize int) (string, []b
 		"-vcodec", "mjpeg",
 		"pipe:1",
 	)
+	cmd := exec.Command("ffmpeg", args...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -108,8 +205,13 @@ func SanitizeImage(pendingFile *domain.PendingFile, maxDecodedSize int64) (*doma
 		reader = bytes.NewReader(data)
 	}
 
+	// Keep what EXIF said about the camera for moderators before decoding drops it
+	buffered := bufio.NewReaderSize(reader, maxMetadataScan)
+	header, _ := buffered.Peek(maxMetadataScan)
+	metadata := extractStrippedMetadata(header)
+
 	// Decode image — this strips EXIF metadata automatically
-	img, format, err := image.Decode(reader)
+	img, format, err := image.Decode(buffered)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode image: %w", err)
 	}
@@ -142,16 +244,20 @@ func SanitizeImage(pendingFile *domain.PendingFile, maxDecodedSize int64) (*doma
 			ImageWidth:  &width,
 			ImageHeight: &height,
 		},
-		Image:  img,
-		Format: format,
+		Image:    img,
+		Format:   format,
+		Metadata: metadata,
 	}, nil
 }
 
 // SanitizeVideo sanitizes a video file by stripping metadata and extracting a
-// scaled thumbnail, both in a single ffmpeg invocation.
+// scaled thumbnail, both in a single ffmpeg invocation. The profile decides
+// whether the video is transcoded and how large it may be: videos over its
+// size limits are downscaled, or rejected when the profile says so or keeps
+// the original streams.
 // thumbMaxSize is the maximum dimension (px) for the thumbnail.
 // Returns a SanitizedVideo with path to temp file on disk (caller must move/delete it).
-func SanitizeVideo(pendingFile *domain.PendingFile, thumbMaxSize int) (*domain.SanitizedVideo, error) {
+func SanitizeVideo(pendingFile *domain.PendingFile, thumbMaxSize int, profile config.VideoProfile) (*domain.SanitizedVideo, error) {
 	origExt := filepath.Ext(pendingFile.Filename)
 
 	// Create temp input file for ffmpeg
@@ -160,18 +266,52 @@ func SanitizeVideo(pendingFile *domain.PendingFile, thumbMaxSize int) (*domain.S
 		return nil, fmt.Errorf("failed to create temp input file: %w", err)
 	}
 	tmpInputPath := tmpInput.Name()
+	defer os.Remove(tmpInputPath)
 
 	// Write uploaded data to temp file
 	_, copyErr := io.Copy(tmpInput, pendingFile.Data)
 	tmpInput.Close()
 	if copyErr != nil {
-		os.Remove(tmpInputPath)
 		return nil, fmt.Errorf("failed to write temp video: %w", copyErr)
 	}
 
+	metadata := domain.FileCommonMetadata{
+		Filename:    pendingFile.Filename,
+		MimeType:    pendingFile.MimeType,
+		ImageWidth:  pendingFile.ImageWidth,
+		ImageHeight: pendingFile.ImageHeight,
+	}
+	outputExt := origExt
+	outputArgs := streamCopyArgs
+
+	limited := profile.MaxWidth > 0 || profile.MaxHeight > 0
+	if profile.Codec != "" || limited {
+		width, height, err := probeVideoSize(tmpInputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read video dimensions: %w", err)
+		}
+		outWidth, outHeight, oversized := fitVideoSize(width, height, profile.MaxWidth, profile.MaxHeight)
+		if oversized && (profile.RejectOversized || profile.Codec == "") {
+			return nil, &errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("Video %s is %dx%d, larger than the allowed %s", pendingFile.Filename, width, height, sizeLimit(profile)),
+				StatusCode: http.StatusRequestEntityTooLarge,
+			}
+		}
+
+		if profile.Codec != "" {
+			out := videoOutputs[profile.Codec]
+			outputExt = out.ext
+			outputArgs = transcodeArgs(out, profile.Codec, profile.MaxBitrateKbps, outWidth, outHeight)
+			metadata.Filename = strings.TrimSuffix(pendingFile.Filename, origExt) + out.ext
+			metadata.MimeType = out.mimeType
+			width, height = outWidth, outHeight
+		}
+		metadata.ImageWidth = &width
+		metadata.ImageHeight = &height
+	}
+
 	// Sanitize + extract thumbnail in one ffmpeg pass
-	tmpOutputPath, thumbnail, err := sanitizeVideoWithThumbnail(tmpInputPath, thumbMaxSize)
-	os.Remove(tmpInputPath) // Clean up input immediately
+	tmpOutputPath, thumbnail, err := sanitizeVideoWithThumbnail(tmpInputPath, outputExt, outputArgs, thumbMaxSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sanitize video: %w", err)
 	}
@@ -182,16 +322,185 @@ func SanitizeVideo(pendingFile *domain.PendingFile, thumbMaxSize int) (*domain.S
 		os.Remove(tmpOutputPath)
 		return nil, fmt.Errorf("failed to stat sanitized video: %w", err)
 	}
+	metadata.SizeBytes = fileInfo.Size() // Updated size after sanitization
 
 	return &domain.SanitizedVideo{
-		FileCommonMetadata: domain.FileCommonMetadata{
-			Filename:    pendingFile.Filename,
-			SizeBytes:   fileInfo.Size(), // Updated size after sanitization
-			MimeType:    pendingFile.MimeType,
-			ImageWidth:  pendingFile.ImageWidth,
-			ImageHeight: pendingFile.ImageHeight,
-		},
-		TempFilePath: tmpOutputPath,
-		Thumbnail:    thumbnail,
+		FileCommonMetadata: metadata,
+		TempFilePath:       tmpOutputPath,
+		Thumbnail:          thumbnail,
 	}, nil
 }
+
+// sizeLimit describes the size limits of a profile, e.g. "1280x720".
+func sizeLimit(profile config.VideoProfile) string {
+	switch {
+	case profile.MaxWidth > 0 && profile.MaxHeight > 0:
+		return fmt.Sprintf("%dx%d", profile.MaxWidth, profile.MaxHeight)
+	case profile.MaxWidth > 0:
+		return fmt.Sprintf("width of %dpx", profile.MaxWidth)
+	default:
+		return fmt.Sprintf("height of %dpx", profile.MaxHeight)
+	}
+}
+
+// CheckGhostscriptAvailable reports whether PDFs can be sanitized.
+func CheckGhostscriptAvailable() error {
+	cmd := exec.Command("gs", "--version")
+	return cmd.Run()
+}
+
+// Ghostscript limits. A crafted PDF can declare a huge page or loop forever,
+// so both runs get a deadline and the preview is rendered onto a fixed
+// US Letter page (612x792 points, 612x792 px at 72 DPI) it is scaled to fit.
+const (
+	pdfSanitizeTimeout = time.Minute
+	pdfPreviewTimeout  = 20 * time.Second
+	pdfPreviewWidthPt  = 612
+	pdfPreviewHeightPt = 792
+)
+
+// sanitizePDF re-renders a PDF with Ghostscript's pdfwrite device, which drops
+// JavaScript, embedded files and other active content, and renders the first
+// page of the result as a PNG at 72 DPI.
+// Returns (sanitizedPDFPath, firstPagePNGBytes, error).
+// First page bytes may be nil if rendering failed (non-fatal).
+func sanitizePDF(inputPath string) (string, []byte, error) {
+	tmpFile, err := os.CreateTemp("", "sanitized_pdf_*.pdf")
+	if err != nil {
+		return "", nil, err
+	}
+	outputPath := tmpFile.Name()
+	tmpFile.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), pdfSanitizeTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gs",
+		"-q", "-dSAFER", "-dBATCH", "-dNOPAUSE",
+		"-sDEVICE=pdfwrite",
+		"-o", outputPath,
+		inputPath,
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(outputPath)
+		if ctx.Err() != nil {
+			return "", nil, fmt.Errorf("ghostscript timed out after %s", pdfSanitizeTimeout)
+		}
+		return "", nil, fmt.Errorf("ghostscript failed: %w (stderr: %s)", err, stderr.String())
+	}
+
+	// Render from the sanitized copy so the preview matches what is served
+	previewCtx, cancelPreview := context.WithTimeout(context.Background(), pdfPreviewTimeout)
+	defer cancelPreview()
+	var stdout bytes.Buffer
+	cmd = exec.CommandContext(previewCtx, "gs",
+		"-q", "-dSAFER", "-dBATCH", "-dNOPAUSE",
+		"-sDEVICE=png16m",
+		"-dFirstPage=1", "-dLastPage=1",
+		"-r72",
+		"-dFIXEDMEDIA", "-dPDFFitPage",
+		fmt.Sprintf("-dDEVICEWIDTHPOINTS=%d", pdfPreviewWidthPt),
+		fmt.Sprintf("-dDEVICEHEIGHTPOINTS=%d", pdfPreviewHeightPt),
+		"-o", "-",
+		outputPath,
+	)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil || stdout.Len() == 0 {
+		return outputPath, nil, nil
+	}
+	return outputPath, stdout.Bytes(), nil
+}
+
+// sanitizeText makes a text file valid UTF-8 without NUL bytes and with Unix
+// line endings.
+func sanitizeText(data []byte) []byte {
+	text := strings.ToValidUTF8(string(data), "\uFFFD")
+	text = strings.ReplaceAll(text, "\x00", "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return []byte(text)
+}
+
+// textPreview returns the first maxChars characters of text, cut at the last
+// line break when there is one.
+func textPreview(text string, maxChars int) string {
+	if utf8.RuneCountInString(text) <= maxChars {
+		return strings.TrimSpace(text)
+	}
+	runes := []rune(text)[:maxChars]
+	preview := string(runes)
+	if i := strings.LastIndexByte(preview, '\n'); i > 0 {
+		preview = preview[:i]
+	}
+	return strings.TrimSpace(preview) + "\n…"
+}
+
+// SanitizeDocument sanitizes a PDF or plain text attachment. PDFs are re-rendered
+// without active content and get their first page rendered for a thumbnail;
+// text is normalized to UTF-8 and gets a preview of up to previewChars characters.
+// Returns a SanitizedDocument with path to temp file on disk (caller must move/delete it).
+func SanitizeDocument(pendingFile *domain.PendingFile, previewChars int) (*domain.SanitizedDocument, error) {
+	data, err := io.ReadAll(pendingFile.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+
+	baseName := strings.TrimSuffix(pendingFile.Filename, filepath.Ext(pendingFile.Filename))
+	doc := &domain.SanitizedDocument{
+		FileCommonMetadata: domain.FileCommonMetadata{MimeType: pendingFile.MimeType},
+	}
+
+	var ext string
+	switch {
+	case pendingFile.IsPDF():
+		ext = ".pdf"
+	case pendingFile.IsText():
+		ext = ".txt"
+		data = sanitizeText(data)
+		doc.TextPreview = textPreview(string(data), previewChars)
+	default:
+		return nil, fmt.Errorf("unsupported document type: %s", pendingFile.MimeType)
+	}
+	doc.Filename = baseName + ext
+
+	tmpFile, err := os.CreateTemp("", "upload_document_*"+ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp document file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	_, writeErr := tmpFile.Write(data)
+	tmpFile.Close()
+	if writeErr != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to write temp document: %w", writeErr)
+	}
+
+	if pendingFile.IsPDF() {
+		sanitizedPath, firstPage, err := sanitizePDF(tmpPath)
+		os.Remove(tmpPath)
+		if err != nil {
+			logger.Log.Warn("failed to sanitize PDF", "filename", pendingFile.Filename, "error", err)
+			return nil, &errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("Could not process PDF %s", pendingFile.Filename),
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+		tmpPath = sanitizedPath
+
+		if img, err := png.Decode(bytes.NewReader(firstPage)); err == nil {
+			width, height := img.Bounds().Dx(), img.Bounds().Dy()
+			doc.FirstPage = img
+			doc.ImageWidth = &width
+			doc.ImageHeight = &height
+		}
+	}
+
+	fileInfo, err := os.Stat(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to stat sanitized document: %w", err)
+	}
+	doc.SizeBytes = fileInfo.Size()
+	doc.TempFilePath = tmpPath
+	return doc, nil
+}
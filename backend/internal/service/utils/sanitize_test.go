@@ -2,14 +2,18 @@ package utils
 
 import (
 	"bytes"
+	stderrors "errors"
 	"image"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
+	"net/http"
 	"os"
 	"testing"
 
+	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -425,7 +429,7 @@ func TestSanitizeVideo_ReturnsTempPath(t *testing.T) {
 		Data: bytes.NewReader(videoData),
 	}
 
-	result, err := SanitizeVideo(pendingFile, 225)
+	result, err := SanitizeVideo(pendingFile, 225, config.VideoProfile{})
 
 	// Clean up temp file if sanitization created one
 	if result != nil {
@@ -481,7 +485,7 @@ func TestSanitizeVideo_TempFileExists(t *testing.T) {
 		Data: bytes.NewReader(videoData),
 	}
 
-	result, err := SanitizeVideo(pendingFile, 225)
+	result, err := SanitizeVideo(pendingFile, 225, config.VideoProfile{})
 
 	// Clean up temp file
 	if result != nil {
@@ -541,7 +545,7 @@ func TestSanitizeVideo_FileSizeMatches(t *testing.T) {
 		Data: bytes.NewReader(videoData),
 	}
 
-	result, err := SanitizeVideo(pendingFile, 225)
+	result, err := SanitizeVideo(pendingFile, 225, config.VideoProfile{})
 
 	// Clean up temp file
 	if result != nil {
@@ -586,7 +590,7 @@ func TestSanitizeVideo_ThumbnailExtracted(t *testing.T) {
 		Data: bytes.NewReader(videoData),
 	}
 
-	result, err := SanitizeVideo(pendingFile, 225)
+	result, err := SanitizeVideo(pendingFile, 225, config.VideoProfile{})
 	if result != nil {
 		defer os.Remove(result.TempFilePath)
 	}
@@ -615,3 +619,173 @@ func TestSanitizeVideo_ThumbnailExtracted(t *testing.T) {
 	assert.LessOrEqual(t, bounds.Dx(), 225, "Thumbnail width should be <= maxSize")
 	assert.LessOrEqual(t, bounds.Dy(), 225, "Thumbnail height should be <= maxSize")
 }
+
+func TestFitVideoSize(t *testing.T) {
+	tests := []struct {
+		name                  string
+		width, height         int
+		maxWidth, maxHeight   int
+		wantWidth, wantHeight int
+		wantOversized         bool
+	}{
+		{"no limits", 1920, 1080, 0, 0, 1920, 1080, false},
+		{"within limits", 1280, 720, 1920, 1080, 1280, 720, false},
+		{"width limited", 3840, 2160, 1280, 0, 1280, 720, true},
+		{"height limited", 1080, 1920, 0, 720, 404, 720, true},
+		{"both limited, keeps aspect ratio", 4000, 1000, 1920, 1080, 1920, 480, true},
+		{"odd sizes rounded to even", 641, 481, 0, 0, 640, 480, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h, oversized := fitVideoSize(tt.width, tt.height, tt.maxWidth, tt.maxHeight)
+			assert.Equal(t, tt.wantWidth, w)
+			assert.Equal(t, tt.wantHeight, h)
+			assert.Equal(t, tt.wantOversized, oversized)
+		})
+	}
+}
+
+func TestTranscodeArgs(t *testing.T) {
+	h264 := transcodeArgs(videoOutputs[config.VideoCodecH264], config.VideoCodecH264, 2000, 1280, 720)
+	assert.Contains(t, h264, "libx264")
+	assert.Subset(t, h264, []string{"-vf", "scale=1280:720", "-maxrate", "2000k", "-bufsize", "4000k"})
+
+	assert.NotContains(t, transcodeArgs(videoOutputs[config.VideoCodecH264], config.VideoCodecH264, 0, 1280, 720), "-maxrate")
+
+	vp9 := transcodeArgs(videoOutputs[config.VideoCodecVP9], config.VideoCodecVP9, 0, 640, 360)
+	assert.Contains(t, vp9, "libvpx-vp9")
+	assert.Subset(t, vp9, []string{"-b:v", "0k"}, "VP9 without a cap uses constant quality")
+}
+
+// TestSanitizeVideo_Profiles tests transcoding, downscaling and rejection of oversized videos
+func TestSanitizeVideo_Profiles(t *testing.T) {
+	if err := CheckFFmpegAvailable(); err != nil {
+		t.Skip("ffmpeg not available, skipping video test")
+	}
+	videoData, err := os.ReadFile("../../../test_data/test_video.webm")
+	if err != nil {
+		t.Skip("test_video.webm not found, skipping profile test")
+	}
+	pendingFile := func() *domain.PendingFile {
+		return &domain.PendingFile{
+			FileCommonMetadata: domain.FileCommonMetadata{
+				Filename:  "clip.webm",
+				MimeType:  "video/webm",
+				SizeBytes: int64(len(videoData)),
+			},
+			Data: bytes.NewReader(videoData),
+		}
+	}
+
+	t.Run("transcodes and downscales", func(t *testing.T) {
+		result, err := SanitizeVideo(pendingFile(), 225, config.VideoProfile{Codec: config.VideoCodecH264, MaxWidth: 64, MaxHeight: 64})
+		if err != nil {
+			t.Skipf("ffmpeg couldn't transcode test video, skipping: %v", err)
+		}
+		defer os.Remove(result.TempFilePath)
+
+		assert.Equal(t, "clip.mp4", result.Filename)
+		assert.Equal(t, "video/mp4", result.MimeType)
+		require.NotNil(t, result.ImageWidth)
+		require.NotNil(t, result.ImageHeight)
+		assert.LessOrEqual(t, *result.ImageWidth, 64)
+		assert.LessOrEqual(t, *result.ImageHeight, 64)
+	})
+
+	t.Run("rejects oversized", func(t *testing.T) {
+		result, err := SanitizeVideo(pendingFile(), 225, config.VideoProfile{MaxWidth: 2, MaxHeight: 2})
+		if result != nil {
+			os.Remove(result.TempFilePath)
+		}
+		var statusErr *errors.ErrorWithStatusCode
+		if !stderrors.As(err, &statusErr) {
+			t.Skipf("ffprobe couldn't read test video, skipping: %v", err)
+		}
+		assert.Equal(t, http.StatusRequestEntityTooLarge, statusErr.StatusCode)
+	})
+}
+
+func TestSanitizeText(t *testing.T) {
+	got := sanitizeText([]byte("line one\r\nline\x00 two\xff\r\n"))
+	assert.Equal(t, "line one\nline two�\n", string(got))
+}
+
+func TestTextPreview(t *testing.T) {
+	assert.Equal(t, "short text", textPreview("short text\n", 100))
+	assert.Equal(t, "first line\n…", textPreview("first line\nsecond line", 15))
+	assert.Equal(t, "no line bre\n…", textPreview("no line breaks here", 11))
+	assert.Equal(t, "привет\n…", textPreview("привет мир", 6), "counts characters, not bytes")
+}
+
+func TestSanitizeDocument_Text(t *testing.T) {
+	data := []byte("hello\r\nworld\r\n")
+	pendingFile := &domain.PendingFile{
+		FileCommonMetadata: domain.FileCommonMetadata{
+			Filename:  "notes.log",
+			MimeType:  "text/plain",
+			SizeBytes: int64(len(data)),
+		},
+		Data: bytes.NewReader(data),
+	}
+
+	result, err := SanitizeDocument(pendingFile, 500)
+	require.NoError(t, err)
+	defer os.Remove(result.TempFilePath)
+
+	assert.Equal(t, "notes.txt", result.Filename)
+	assert.Equal(t, "text/plain", result.MimeType)
+	assert.Equal(t, "hello\nworld", result.TextPreview)
+	assert.Nil(t, result.FirstPage)
+
+	stored, err := os.ReadFile(result.TempFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\nworld\n", string(stored))
+	assert.Equal(t, int64(len(stored)), result.SizeBytes)
+}
+
+func TestSanitizeDocument_InvalidPDF(t *testing.T) {
+	data := []byte("not a pdf at all")
+	pendingFile := &domain.PendingFile{
+		FileCommonMetadata: domain.FileCommonMetadata{
+			Filename:  "broken.pdf",
+			MimeType:  "application/pdf",
+			SizeBytes: int64(len(data)),
+		},
+		Data: bytes.NewReader(data),
+	}
+
+	result, err := SanitizeDocument(pendingFile, 500)
+	assert.Nil(t, result)
+	var statusErr *errors.ErrorWithStatusCode
+	require.True(t, stderrors.As(err, &statusErr))
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+}
+
+func TestSanitizeDocument_PDF(t *testing.T) {
+	if err := CheckGhostscriptAvailable(); err != nil {
+		t.Skip("ghostscript not available, skipping PDF test")
+	}
+	data := []byte("%PDF-1.4\n" +
+		"1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+		"2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n" +
+		"3 0 obj << /Type /Page /Parent 2 0 R /MediaBox [0 0 200 100] >> endobj\n" +
+		"trailer << /Root 1 0 R >>\n%%EOF\n")
+	pendingFile := &domain.PendingFile{
+		FileCommonMetadata: domain.FileCommonMetadata{
+			Filename:  "paper.pdf",
+			MimeType:  "application/pdf",
+			SizeBytes: int64(len(data)),
+		},
+		Data: bytes.NewReader(data),
+	}
+
+	result, err := SanitizeDocument(pendingFile, 500)
+	require.NoError(t, err)
+	defer os.Remove(result.TempFilePath)
+
+	assert.Equal(t, "paper.pdf", result.Filename)
+	require.NotNil(t, result.FirstPage)
+	require.NotNil(t, result.ImageWidth)
+	assert.Equal(t, 200, *result.ImageWidth)
+	assert.Equal(t, 100, *result.ImageHeight)
+}
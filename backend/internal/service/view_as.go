@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+const viewAsReasonMaxLen = 200
+
+// ViewAsService lets admins see the site exactly as another user does, to
+// debug board permissions and hidden content. Every session is recorded.
+type ViewAsService interface {
+	// Start issues a read-only token authenticating as userId on behalf of admin.
+	Start(ctx context.Context, admin, userId domain.UserId, reason string) (token string, expiresAt time.Time, err error)
+	List(ctx context.Context, page int) ([]domain.ViewAsSession, error)
+}
+
+// ViewAsStorage defines storage interface for view-as sessions
+type ViewAsStorage interface {
+	GetUserById(ctx context.Context, id domain.UserId) (domain.User, error)
+	SaveViewAsSession(ctx context.Context, session domain.ViewAsSession) (domain.ViewAsSessionId, error)
+	GetViewAsSessions(ctx context.Context, limit, offset int) ([]domain.ViewAsSession, error)
+}
+
+// ViewAsTokenIssuer signs view-as tokens; it is satisfied by jwt.JwtService.
+type ViewAsTokenIssuer interface {
+	NewViewAsToken(user domain.User, admin domain.UserId, ttl time.Duration) (string, error)
+}
+
+type ViewAs struct {
+	storage ViewAsStorage
+	jwt     ViewAsTokenIssuer
+	cfg     *config.Public
+}
+
+func NewViewAs(storage ViewAsStorage, jwt ViewAsTokenIssuer, cfg *config.Public) ViewAsService {
+	return &ViewAs{
+		storage: storage,
+		jwt:     jwt,
+		cfg:     cfg,
+	}
+}
+
+func (s *ViewAs) Start(ctx context.Context, admin, userId domain.UserId, reason string) (string, time.Time, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", time.Time{}, &errors.ErrorWithStatusCode{Message: "A reason is required", StatusCode: http.StatusBadRequest}
+	}
+	if utf8.RuneCountInString(reason) > viewAsReasonMaxLen {
+		return "", time.Time{}, &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Reason must be at most %d characters", viewAsReasonMaxLen),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	if userId == admin {
+		return "", time.Time{}, &errors.ErrorWithStatusCode{Message: "Cannot view the site as yourself", StatusCode: http.StatusBadRequest}
+	}
+
+	user, err := s.storage.GetUserById(ctx, userId)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	ttl := s.cfg.ViewAsTTL
+	token, err := s.jwt.NewViewAsToken(user, admin, ttl)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().UTC().Add(ttl)
+
+	// The session is recorded before the token is handed out, so no view-as
+	// access goes unaudited
+	id, err := s.storage.SaveViewAsSession(ctx, domain.ViewAsSession{
+		AdminId:   admin,
+		UserId:    userId,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	logger.Log.Info("view-as started", "session_id", id, "admin_id", admin, "user_id", userId, "expires_at", expiresAt, "reason", reason)
+	return token, expiresAt, nil
+}
+
+func (s *ViewAs) List(ctx context.Context, page int) ([]domain.ViewAsSession, error) {
+	page = max(1, page)
+	limit := s.cfg.ViewAsPageLimit
+	offset := (page - 1) * limit
+	return s.storage.GetViewAsSessions(ctx, limit, offset)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockViewAsStorage struct {
+	getUserByIdFunc       func(id domain.UserId) (domain.User, error)
+	saveViewAsSessionFunc func(session domain.ViewAsSession) (domain.ViewAsSessionId, error)
+	getViewAsSessionsFunc func(limit, offset int) ([]domain.ViewAsSession, error)
+}
+
+func (m *MockViewAsStorage) GetUserById(ctx context.Context, id domain.UserId) (domain.User, error) {
+	if m.getUserByIdFunc != nil {
+		return m.getUserByIdFunc(id)
+	}
+	return domain.User{Id: id}, nil
+}
+
+func (m *MockViewAsStorage) SaveViewAsSession(ctx context.Context, session domain.ViewAsSession) (domain.ViewAsSessionId, error) {
+	if m.saveViewAsSessionFunc != nil {
+		return m.saveViewAsSessionFunc(session)
+	}
+	return 1, nil
+}
+
+func (m *MockViewAsStorage) GetViewAsSessions(ctx context.Context, limit, offset int) ([]domain.ViewAsSession, error) {
+	if m.getViewAsSessionsFunc != nil {
+		return m.getViewAsSessionsFunc(limit, offset)
+	}
+	return nil, nil
+}
+
+type MockViewAsTokenIssuer struct {
+	user  domain.User
+	admin domain.UserId
+	ttl   time.Duration
+}
+
+func (m *MockViewAsTokenIssuer) NewViewAsToken(user domain.User, admin domain.UserId, ttl time.Duration) (string, error) {
+	m.user, m.admin, m.ttl = user, admin, ttl
+	return "view-as-token", nil
+}
+
+// --- Tests ---
+
+func TestViewAsStart(t *testing.T) {
+	cfg := &config.Public{ViewAsTTL: 15 * time.Minute}
+
+	t.Run("issues a token and records the session", func(t *testing.T) {
+		var saved domain.ViewAsSession
+		issuer := &MockViewAsTokenIssuer{}
+		viewAs := NewViewAs(&MockViewAsStorage{
+			getUserByIdFunc: func(id domain.UserId) (domain.User, error) {
+				return domain.User{Id: id, EmailDomain: "uni.edu"}, nil
+			},
+			saveViewAsSessionFunc: func(session domain.ViewAsSession) (domain.ViewAsSessionId, error) {
+				saved = session
+				return 1, nil
+			},
+		}, issuer, cfg)
+
+		token, expiresAt, err := viewAs.Start(context.Background(), 1, 2, "  board access report  ")
+		require.NoError(t, err)
+		assert.Equal(t, "view-as-token", token)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), expiresAt, time.Minute)
+
+		assert.Equal(t, domain.User{Id: 2, EmailDomain: "uni.edu"}, issuer.user)
+		assert.Equal(t, domain.UserId(1), issuer.admin)
+		assert.Equal(t, 15*time.Minute, issuer.ttl)
+
+		assert.Equal(t, domain.UserId(1), saved.AdminId)
+		assert.Equal(t, domain.UserId(2), saved.UserId)
+		assert.Equal(t, "board access report", saved.Reason)
+		assert.Equal(t, expiresAt, saved.ExpiresAt)
+	})
+
+	t.Run("reason is required", func(t *testing.T) {
+		viewAs := NewViewAs(&MockViewAsStorage{}, &MockViewAsTokenIssuer{}, cfg)
+		_, _, err := viewAs.Start(context.Background(), 1, 2, "   ")
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("reason too long", func(t *testing.T) {
+		viewAs := NewViewAs(&MockViewAsStorage{}, &MockViewAsTokenIssuer{}, cfg)
+		_, _, err := viewAs.Start(context.Background(), 1, 2, strings.Repeat("a", viewAsReasonMaxLen+1))
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("cannot view as yourself", func(t *testing.T) {
+		viewAs := NewViewAs(&MockViewAsStorage{}, &MockViewAsTokenIssuer{}, cfg)
+		_, _, err := viewAs.Start(context.Background(), 1, 1, "test")
+		requireStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		viewAs := NewViewAs(&MockViewAsStorage{
+			getUserByIdFunc: func(id domain.UserId) (domain.User, error) {
+				return domain.User{}, &internal_errors.ErrorWithStatusCode{Message: "User not found", StatusCode: http.StatusNotFound}
+			},
+		}, &MockViewAsTokenIssuer{}, cfg)
+		_, _, err := viewAs.Start(context.Background(), 1, 2, "test")
+		requireStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("no token without an audit record", func(t *testing.T) {
+		viewAs := NewViewAs(&MockViewAsStorage{
+			saveViewAsSessionFunc: func(session domain.ViewAsSession) (domain.ViewAsSessionId, error) {
+				return 0, errors.New("db down")
+			},
+		}, &MockViewAsTokenIssuer{}, cfg)
+		token, _, err := viewAs.Start(context.Background(), 1, 2, "test")
+		require.Error(t, err)
+		assert.Empty(t, token)
+	})
+}
+
+func TestViewAsList(t *testing.T) {
+	var gotLimit, gotOffset int
+	viewAs := NewViewAs(&MockViewAsStorage{
+		getViewAsSessionsFunc: func(limit, offset int) ([]domain.ViewAsSession, error) {
+			gotLimit, gotOffset = limit, offset
+			return nil, nil
+		},
+	}, &MockViewAsTokenIssuer{}, &config.Public{ViewAsPageLimit: 10})
+
+	_, err := viewAs.List(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, 10, gotLimit)
+	assert.Equal(t, 20, gotOffset)
+}
@@ -6,10 +6,14 @@ import (
 
 	"github.com/itchan-dev/itchan/backend/internal/handler"
 	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/backend/internal/storage"
 	"github.com/itchan-dev/itchan/backend/internal/storage/fs"
-	"github.com/itchan-dev/itchan/backend/internal/storage/pg"
 	"github.com/itchan-dev/itchan/backend/internal/utils"
+	"github.com/itchan-dev/itchan/backend/internal/utils/directory"
 	"github.com/itchan-dev/itchan/backend/internal/utils/email"
+	"github.com/itchan-dev/itchan/backend/internal/utils/federation"
+	"github.com/itchan-dev/itchan/backend/internal/utils/webhook"
+	"github.com/itchan-dev/itchan/backend/internal/utils/webpush"
 	"github.com/itchan-dev/itchan/shared/blacklist"
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/crypto"
@@ -22,7 +26,7 @@ import (
 
 // Dependencies struct to hold all initialized dependencies.
 type Dependencies struct {
-	Storage        *pg.Storage
+	Storage        storage.Storage
 	MediaStorage   *fs.Storage
 	Handler        *handler.Handler
 	AccessData     *board_access.BoardAccess
@@ -36,7 +40,7 @@ type Dependencies struct {
 // SetupDependencies initializes all dependencies required for the application.
 func SetupDependencies(cfg *config.Config) (*Dependencies, error) {
 	ctx, cancel := context.WithCancel(context.Background())
-	storage, err := pg.New(ctx, cfg)
+	storage, err := storage.New(ctx, cfg)
 	if err != nil {
 		cancel()
 		return nil, err
@@ -49,14 +53,22 @@ func SetupDependencies(cfg *config.Config) (*Dependencies, error) {
 		return nil, err
 	}
 
-	accessData := board_access.New()
-	accessData.StartBackgroundUpdate(ctx, 1*time.Minute, storage)
+	accessData := board_access.NewCache(storage, cfg.Public.BoardAccessCacheTTL)
 
 	// Initialize garbage collector for orphaned media files
 	// Safety threshold: 24 hours - files must be at least 24h old before deletion
 	// Cleanup interval: runs daily at roughly the same time
-	mediaGC := service.NewMediaGarbageCollector(storage, mediaStorage, 24*time.Hour)
+	mediaGC := service.NewMediaGarbageCollector(storage, mediaStorage, 24*time.Hour, cfg.Public.UploadTTL)
 	mediaGC.StartBackgroundCleanup(ctx, 24*time.Hour)
+	service.NewCleanup(storage, &cfg.Public).StartBackgroundCleanup(ctx, time.Hour)
+
+	// Uploads are frozen while the media disk or the database is nearly full
+	var alerts service.AlertSender
+	if cfg.Private.AlertWebhookURL != "" {
+		alerts = webhook.New(cfg.Private.AlertWebhookURL)
+	}
+	diskMonitor := service.NewDiskMonitor(storage, mediaStorage, alerts, &cfg.Public.DiskMonitor)
+	diskMonitor.StartBackgroundCheck(ctx)
 
 	email := email.New(&cfg.Private.Email)
 	jwtService := jwt.New(cfg.JwtKey(), cfg.JwtTTL())
@@ -78,7 +90,7 @@ func SetupDependencies(cfg *config.Config) (*Dependencies, error) {
 	// Create auth middleware
 	secureCookies := cfg.Public.SecureCookies
 	authMiddleware := middleware.NewAuth(jwtService, blacklistCache, secureCookies)
-	emailCrypto, err := crypto.NewEmailCrypto(cfg.Private.EncryptionKey)
+	emailCrypto, err := crypto.NewEmailCrypto(cfg.Private.EncryptionKey, cfg.Private.EmailIndexKey, cfg.Private.PreviousEncryptionKeys...)
 	if err != nil {
 		cancel()
 		return nil, err
@@ -87,12 +99,71 @@ func SetupDependencies(cfg *config.Config) (*Dependencies, error) {
 	referral := service.NewReferral(storage)
 	allowedRefs := sharedutils.NewAllowedSources(cfg.Private.AllowedRefs)
 	auth := service.NewAuth(storage, email, jwtService, &cfg.Public, blacklistCache, emailCrypto, &utils.PasswordValidator{Сfg: &cfg.Public}, allowedRefs)
-	board := service.NewBoard(storage, utils.New(&cfg.Public), mediaStorage)
-	message := service.NewMessage(storage, &utils.MessageValidator{Сfg: &cfg.Public}, mediaStorage, &cfg.Public)
-	thread := service.NewThread(storage, &utils.ThreadTitleValidator{Сfg: &cfg.Public}, message, mediaStorage, cfg.Public.MaxThreadCount)
+	board := service.NewBoard(storage, utils.New(&cfg.Public), mediaStorage, accessData, &cfg.Public)
+	service.StartBoardPurge(ctx, board, 10*time.Minute)
+	boardAppearance := service.NewBoardAppearance(storage, mediaStorage, &cfg.Public)
+	// Moderator deletions go through the auto-ban escalation policy
+	var message service.MessageService = service.NewModeration(
+		service.NewMessage(storage, &utils.MessageValidator{Сfg: &cfg.Public}, mediaStorage, &cfg.Public),
+		storage, auth, &cfg.Public,
+	)
+	message = service.NewUploadFreeze(message, diskMonitor)
+	// Replies are pushed to subscribed browsers when a VAPID key is configured
+	var pushSender service.PushSender
+	if cfg.Public.VAPIDPublicKey != "" {
+		sender, err := webpush.New(&cfg.Private.WebPush, cfg.Public.VAPIDPublicKey)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		pushSender = sender
+	}
+	push := service.NewPush(storage, pushSender, &cfg.Public)
+	if pushSender != nil {
+		message = service.NewReplyPush(message, push)
+	}
+	message = service.NewTracedMessage(message)
+	var thread service.ThreadService = service.NewTracedThread(service.NewThreadUploadFreeze(
+		service.NewThread(storage, &utils.ThreadTitleValidator{Сfg: &cfg.Public}, message, mediaStorage, &cfg.Public),
+		diskMonitor,
+	))
 	userActivity := service.NewUserActivity(storage, &cfg.Public)
+	siteActivity := service.NewSiteActivity(storage, &cfg.Public)
+	appeal := service.NewAppeal(storage, auth, &cfg.Public)
+	shadowban := service.NewShadowban(storage, &cfg.Public)
+	viewAs := service.NewViewAs(storage, jwtService, &cfg.Public)
+	modLog := service.NewModLog(storage, &cfg.Public)
+	notification := service.NewNotification(storage, &cfg.Public)
+	digest := service.NewDigest(storage, email, emailCrypto, &cfg.Public)
+	if cfg.Public.SiteURL != "" {
+		digest.StartBackgroundSending(ctx, cfg.Public.EmailDigestInterval)
+	}
+
+	mediaLookup := service.NewMediaLookup(storage)
+	boardStats := service.NewBoardStats(storage, &cfg.Public)
+	boardStats.StartBackgroundRollup(ctx, 10*time.Minute)
+	premod := service.NewPremod(storage, &cfg.Public)
+	bookmark := service.NewBookmark(storage, &cfg.Public)
+	mirror := service.NewMirror(storage, mediaStorage, func(sourceURL string) service.MirrorSource {
+		return federation.New(sourceURL, cfg.Public.Federation.Timeout)
+	}, &cfg.Public)
+	mirror.StartBackgroundSync(ctx, cfg.Public.Federation.Interval)
+	userImport := service.NewUserImport(storage, email, emailCrypto, &cfg.Public)
+	var directorySource service.DirectorySource
+	if dirCfg := cfg.Private.DirectorySync; dirCfg.URL != "" {
+		directorySource = directory.New(dirCfg.URL, dirCfg.Token, dirCfg.Format, dirCfg.Timeout)
+	}
+	directorySync := service.NewDirectorySync(storage, directorySource, emailCrypto, blacklistCache, cfg)
+	directorySync.StartBackgroundSync(ctx, cfg.Private.DirectorySync.Interval)
+
+	// Read-only maintenance windows, kept in memory so they hold while the database is down
+	maintenance := service.NewMaintenance(storage)
+	maintenance.StartBackgroundRefresh(ctx, 30*time.Second)
+
+	backup := service.NewBackup(storage, alerts, &cfg.Private.Backup)
+	backup.StartBackgroundBackups(ctx)
 
-	h := handler.New(auth, board, thread, message, userActivity, referral, mediaStorage, cfg, storage)
+	h := handler.New(auth, service.NewTracedBoard(board), boardAppearance, thread, message, userActivity, referral, siteActivity, appeal, shadowban, viewAs, modLog, notification, digest, push, mediaLookup, boardStats, premod, bookmark, mirror, userImport, directorySync, service.NewIdempotency(storage, &cfg.Public), maintenance, service.NewFailedPost(storage, &cfg.Public), service.NewThreadSnapshot(storage, thread, mediaStorage, &cfg.Public), backup, mediaStorage, cfg, storage, diskMonitor)
 
 	return &Dependencies{
 		Storage:        storage,
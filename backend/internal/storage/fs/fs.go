@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/itchan-dev/itchan/backend/internal/service"
@@ -26,6 +27,7 @@ type Storage struct {
 // Ensure Storage struct implements the interfaces at compile time.
 var _ service.MediaStorage = (*Storage)(nil)
 var _ service.GCMediaStorage = (*Storage)(nil)
+var _ service.DiskUsageReader = (*Storage)(nil)
 
 func New(rootPath string, jpegQualityMain int) (*Storage, error) {
 	// Use filepath.Clean to prevent path traversal issues like "media/../"
@@ -290,6 +292,24 @@ func (s *Storage) DeleteBoard(boardID string) error {
 	return nil
 }
 
+// RenameBoard moves a board's directory to its new short name. It refuses
+// to merge into an existing directory.
+func (s *Storage) RenameBoard(oldBoardID, newBoardID string) error {
+	oldPath := filepath.Join(s.rootPath, oldBoardID)
+	newPath := filepath.Join(s.rootPath, newBoardID)
+
+	if _, err := os.Stat(oldPath); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("board directory %s already exists", newBoardID)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to move board directory: %w", err)
+	}
+	return nil
+}
+
 // WalkFiles walks the entire storage directory and returns all file paths
 // relative to the root. This is used by the garbage collector.
 func (s *Storage) WalkFiles() ([]string, error) {
@@ -334,3 +354,15 @@ func (s *Storage) GetFileModTime(filePath string) (time.Time, error) {
 	}
 	return info.ModTime(), nil
 }
+
+// DiskUsage returns the used and total bytes of the filesystem holding the
+// root. Like df, space reserved for root is not counted in the total.
+func (s *Storage) DiskUsage() (used, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(s.rootPath, &st); err != nil {
+		return 0, 0, fmt.Errorf("failed to stat filesystem: %w", err)
+	}
+	blockSize := uint64(st.Bsize)
+	used = (st.Blocks - st.Bfree) * blockSize
+	return used, used + st.Bavail*blockSize, nil
+}
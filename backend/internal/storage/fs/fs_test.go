@@ -552,6 +552,50 @@ func TestDeleteBoard(t *testing.T) {
 	})
 }
 
+func TestRenameBoard(t *testing.T) {
+	t.Run("moves board directory to the new name", func(t *testing.T) {
+		storage, err := New(t.TempDir(), 85)
+		require.NoError(t, err)
+
+		path, err := storage.SaveFile(bytes.NewReader([]byte("1")), "board1", "thread1", "file.txt")
+		require.NoError(t, err)
+
+		err = storage.RenameBoard("board1", "renamed")
+		require.NoError(t, err)
+
+		reader, err := storage.Read(filepath.Join("renamed", "thread1", filepath.Base(path)))
+		require.NoError(t, err)
+		reader.Close()
+
+		_, err = os.Stat(filepath.Join(storage.rootPath, "board1"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("succeeds when board has no media", func(t *testing.T) {
+		storage, err := New(t.TempDir(), 85)
+		require.NoError(t, err)
+
+		err = storage.RenameBoard("nonexistent", "renamed")
+		assert.NoError(t, err)
+	})
+
+	t.Run("refuses to overwrite an existing directory", func(t *testing.T) {
+		storage, err := New(t.TempDir(), 85)
+		require.NoError(t, err)
+
+		path, err := storage.SaveFile(bytes.NewReader([]byte("1")), "board1", "thread1", "file.txt")
+		require.NoError(t, err)
+		_, err = storage.SaveFile(bytes.NewReader([]byte("2")), "board2", "thread1", "file.txt")
+		require.NoError(t, err)
+
+		err = storage.RenameBoard("board1", "board2")
+		assert.Error(t, err)
+
+		_, err = os.Stat(filepath.Join(storage.rootPath, path))
+		assert.NoError(t, err)
+	})
+}
+
 // TestFullWorkflow tests a complete workflow
 func TestFullWorkflow(t *testing.T) {
 	storage, err := New(t.TempDir(), 85)
@@ -660,3 +704,13 @@ func TestSaveThumbnail(t *testing.T) {
 		assert.NotEqual(t, path1, path2)
 	})
 }
+
+func TestDiskUsage(t *testing.T) {
+	storage, err := New(t.TempDir(), 85)
+	require.NoError(t, err)
+
+	used, total, err := storage.DiskUsage()
+	require.NoError(t, err)
+	assert.Positive(t, total)
+	assert.LessOrEqual(t, used, total)
+}
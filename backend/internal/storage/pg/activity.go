@@ -0,0 +1,111 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/utils"
+	"github.com/lib/pq"
+)
+
+// GetSiteActivity collects the index page widgets data for the given boards:
+// the latest posts, the number of posts created since `since` and the threads
+// with the most messages since `since`. Messages of shadowbanned users are
+// left out, since the result is shared by all viewers.
+func (s *Storage) GetSiteActivity(ctx context.Context, boards []domain.BoardShortName, since time.Time, postsLimit, threadsLimit int) (domain.SiteActivity, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	activity := domain.SiteActivity{
+		LatestPosts:   []domain.LatestPost{},
+		ActiveThreads: []domain.ActiveThread{},
+	}
+	if len(boards) == 0 {
+		return activity, nil
+	}
+
+	latest, err := s.getLatestPosts(ctx, boards, postsLimit)
+	if err != nil {
+		return domain.SiteActivity{}, err
+	}
+	activity.LatestPosts = latest
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT count(*) FROM messages m
+		WHERE m.board = ANY($1) AND m.created_at >= $2 AND `+notShadowbannedCondition,
+		pq.Array(boards), since,
+	).Scan(&activity.PostsToday)
+	if err != nil {
+		return domain.SiteActivity{}, fmt.Errorf("failed to count posts since %s: %w", since, err)
+	}
+
+	active, err := s.getActiveThreads(ctx, boards, since, threadsLimit)
+	if err != nil {
+		return domain.SiteActivity{}, err
+	}
+	activity.ActiveThreads = active
+
+	return activity, nil
+}
+
+func (s *Storage) getLatestPosts(ctx context.Context, boards []domain.BoardShortName, limit int) ([]domain.LatestPost, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.board, m.thread_id, t.title, m.id, m.text, m.created_at
+		FROM messages m
+		JOIN threads t ON t.board = m.board AND t.id = m.thread_id
+		WHERE m.board = ANY($1) AND `+notShadowbannedCondition+`
+		ORDER BY m.created_at DESC
+		LIMIT $2`,
+		pq.Array(boards), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []domain.LatestPost{}
+	for rows.Next() {
+		var p domain.LatestPost
+		if err := rows.Scan(&p.Board, &p.ThreadId, &p.ThreadTitle, &p.Id, &p.Text, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan latest post: %w", err)
+		}
+		p.Page = utils.CalculatePage(int(p.Id), s.cfg.Public.MessagesPerThreadPage)
+		posts = append(posts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating latest posts: %w", err)
+	}
+	return posts, nil
+}
+
+func (s *Storage) getActiveThreads(ctx context.Context, boards []domain.BoardShortName, since time.Time, limit int) ([]domain.ActiveThread, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.board, t.id, t.title, t.message_count, t.last_bumped_at, t.is_pinned, count(*) AS recent
+		FROM messages m
+		JOIN threads t ON t.board = m.board AND t.id = m.thread_id
+		WHERE m.board = ANY($1) AND m.created_at >= $2 AND `+notShadowbannedCondition+`
+		GROUP BY t.board, t.id
+		ORDER BY recent DESC, t.last_bumped_at DESC
+		LIMIT $3`,
+		pq.Array(boards), since, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch active threads: %w", err)
+	}
+	defer rows.Close()
+
+	threads := []domain.ActiveThread{}
+	for rows.Next() {
+		var t domain.ActiveThread
+		if err := rows.Scan(&t.Board, &t.Id, &t.Title, &t.MessageCount, &t.LastBumped, &t.IsPinned, &t.MessagesToday); err != nil {
+			return nil, fmt.Errorf("failed to scan active thread: %w", err)
+		}
+		threads = append(threads, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating active threads: %w", err)
+	}
+	return threads, nil
+}
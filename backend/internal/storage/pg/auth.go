@@ -19,12 +19,12 @@ import (
 
 // SaveUser is the public entry point for creating a new user. It wraps the
 // core logic in a transaction to ensure the operation is atomic.
-func (s *Storage) SaveUser(user domain.User) (domain.UserId, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) SaveUser(ctx context.Context, user domain.User) (domain.UserId, error) {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
 	var id domain.UserId
-	err := s.withTx(ctx, func(tx *sql.Tx) error {
+	err := s.withTx(ctx, func(tx Querier) error {
 		var err error
 		id, err = s.saveUser(tx, user)
 		return err
@@ -34,17 +34,35 @@ func (s *Storage) SaveUser(user domain.User) (domain.UserId, error) {
 
 // User is a public, read-only method to fetch a user by their email hash. It uses
 // the main database connection pool for efficiency.
-func (s *Storage) User(emailHash []byte) (domain.User, error) {
-	return s.user(s.db, emailHash)
+func (s *Storage) User(ctx context.Context, emailHash []byte) (domain.User, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.user(q, emailHash)
+}
+
+// UserByEmailIndex fetches a user by the blind index of their email, for
+// admin lookups.
+func (s *Storage) UserByEmailIndex(ctx context.Context, emailIndex []byte) (domain.User, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.userByEmailIndex(q, emailIndex)
+}
+
+// SetEmailIndex stores the blind index of a user's email, backfilling users
+// registered before the index key was configured.
+func (s *Storage) SetEmailIndex(ctx context.Context, userId domain.UserId, emailIndex []byte) error {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.setEmailIndex(q, userId, emailIndex)
 }
 
 // UpdatePassword is the public entry point for changing a user's password.
 // It manages the transaction for this security-sensitive operation.
-func (s *Storage) UpdatePassword(emailHash []byte, newPasswordHash domain.Password) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) UpdatePassword(ctx context.Context, emailHash []byte, newPasswordHash domain.Password) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.updatePassword(tx, emailHash, newPasswordHash)
 	})
 }
@@ -52,38 +70,40 @@ func (s *Storage) UpdatePassword(emailHash []byte, newPasswordHash domain.Passwo
 // DeleteUser is the public entry point for deleting a user account.
 // It wraps the deletion in a transaction. The database schema's ON DELETE
 // CASCADE constraints will handle cleaning up related data (e.g., confirmation data).
-func (s *Storage) DeleteUser(emailHash []byte) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) DeleteUser(ctx context.Context, emailHash []byte) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.deleteUser(tx, emailHash)
 	})
 }
 
 // SaveConfirmationData is the public entry point for storing password reset
 // or account confirmation tokens.
-func (s *Storage) SaveConfirmationData(data domain.ConfirmationData) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) SaveConfirmationData(ctx context.Context, data domain.ConfirmationData) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.saveConfirmationData(tx, data)
 	})
 }
 
 // ConfirmationData is a public, read-only method to retrieve confirmation data.
-func (s *Storage) ConfirmationData(emailHash []byte) (domain.ConfirmationData, error) {
-	return s.confirmationData(s.db, emailHash)
+func (s *Storage) ConfirmationData(ctx context.Context, emailHash []byte) (domain.ConfirmationData, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.confirmationData(q, emailHash)
 }
 
 // DeleteConfirmationData is the public entry point for removing used or expired
 // confirmation data.
-func (s *Storage) DeleteConfirmationData(emailHash []byte) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) DeleteConfirmationData(ctx context.Context, emailHash []byte) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.deleteConfirmationData(tx, emailHash)
 	})
 }
@@ -98,8 +118,8 @@ func (s *Storage) DeleteConfirmationData(emailHash []byte) error {
 func (s *Storage) saveUser(q Querier, user domain.User) (domain.UserId, error) {
 	var id int64
 	err := q.QueryRow(
-		"INSERT INTO users(email_encrypted, email_domain, email_hash, password_hash, is_admin, referral_source) VALUES($1, $2, $3, $4, $5, NULLIF($6, '')) RETURNING id",
-		user.EmailEncrypted, user.EmailDomain, user.EmailHash, user.PassHash, user.Admin, user.ReferralSource,
+		"INSERT INTO users(email_encrypted, email_domain, email_hash, password_hash, is_admin, referral_source, email_index) VALUES($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''::bytea)) RETURNING id",
+		user.EmailEncrypted, user.EmailDomain, user.EmailHash, user.PassHash, user.Admin, user.ReferralSource, user.EmailIndex,
 	).Scan(&id)
 	if err != nil {
 		return -1, fmt.Errorf("failed to insert user: %w", err)
@@ -111,9 +131,9 @@ func (s *Storage) saveUser(q Querier, user domain.User) (domain.UserId, error) {
 func (s *Storage) user(q Querier, emailHash []byte) (domain.User, error) {
 	var user domain.User
 	err := q.QueryRow(
-		"SELECT id, email_encrypted, email_domain, email_hash, password_hash, is_admin, created_at FROM users WHERE email_hash = $1",
+		"SELECT id, email_encrypted, email_domain, email_hash, email_index, password_hash, is_admin, created_at FROM users WHERE email_hash = $1",
 		emailHash,
-	).Scan(&user.Id, &user.EmailEncrypted, &user.EmailDomain, &user.EmailHash, &user.PassHash, &user.Admin, &user.CreatedAt)
+	).Scan(&user.Id, &user.EmailEncrypted, &user.EmailDomain, &user.EmailHash, &user.EmailIndex, &user.PassHash, &user.Admin, &user.CreatedAt)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -125,6 +145,30 @@ func (s *Storage) user(q Querier, emailHash []byte) (domain.User, error) {
 	return user, nil
 }
 
+// userByEmailIndex fetches a single user record by email blind index.
+func (s *Storage) userByEmailIndex(q Querier, emailIndex []byte) (domain.User, error) {
+	var user domain.User
+	err := q.QueryRow(
+		"SELECT id, email_domain, email_index, is_admin, created_at FROM users WHERE email_index = $1",
+		emailIndex,
+	).Scan(&user.Id, &user.EmailDomain, &user.EmailIndex, &user.Admin, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, &internal_errors.ErrorWithStatusCode{Message: "User not found", StatusCode: http.StatusNotFound}
+		}
+		return domain.User{}, fmt.Errorf("failed to query user by email index: %w", err)
+	}
+	return user, nil
+}
+
+// setEmailIndex fills in the blind index of a user that has none yet.
+func (s *Storage) setEmailIndex(q Querier, userId domain.UserId, emailIndex []byte) error {
+	if _, err := q.Exec("UPDATE users SET email_index = $1 WHERE id = $2 AND email_index IS NULL", emailIndex, userId); err != nil {
+		return fmt.Errorf("failed to set email index for user %d: %w", userId, err)
+	}
+	return nil
+}
+
 // updatePassword contains the core logic for updating a user's password hash.
 func (s *Storage) updatePassword(q Querier, emailHash []byte, newPasswordHash domain.Password) error {
 	result, err := q.Exec("UPDATE users SET password_hash = $1 WHERE email_hash = $2", newPasswordHash, emailHash)
@@ -210,56 +254,62 @@ func (s *Storage) deleteConfirmationData(q Querier, emailHash []byte) error {
 // =========================================================================
 
 // SaveInviteCode saves a new invite code to the database
-func (s *Storage) SaveInviteCode(invite domain.InviteCode) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) SaveInviteCode(ctx context.Context, invite domain.InviteCode) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.saveInviteCode(tx, invite)
 	})
 }
 
 // InviteCodeByHash fetches an invite code by its hash
-func (s *Storage) InviteCodeByHash(codeHash string) (domain.InviteCode, error) {
-	return s.inviteCodeByHash(s.db, codeHash)
+func (s *Storage) InviteCodeByHash(ctx context.Context, codeHash string) (domain.InviteCode, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.inviteCodeByHash(q, codeHash)
 }
 
 // GetInvitesByUser returns invite codes created by a user, with pagination.
-func (s *Storage) GetInvitesByUser(userId domain.UserId, limit, offset int) ([]domain.InviteCode, error) {
-	return s.getInvitesByUser(s.db, userId, limit, offset)
+func (s *Storage) GetInvitesByUser(ctx context.Context, userId domain.UserId, limit, offset int) ([]domain.InviteCode, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getInvitesByUser(q, userId, limit, offset)
 }
 
 // CountActiveInvites returns the number of active (unused, unexpired) invites for a user
-func (s *Storage) CountActiveInvites(userId domain.UserId) (int, error) {
-	return s.countActiveInvites(s.db, userId)
+func (s *Storage) CountActiveInvites(ctx context.Context, userId domain.UserId) (int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.countActiveInvites(q, userId)
 }
 
 // MarkInviteUsed marks an invite code as used by a specific user
-func (s *Storage) MarkInviteUsed(codeHash string, usedBy domain.UserId) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) MarkInviteUsed(ctx context.Context, codeHash string, usedBy domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.markInviteUsed(tx, codeHash, usedBy)
 	})
 }
 
 // DeleteInviteCode deletes an invite code by its hash
-func (s *Storage) DeleteInviteCode(codeHash string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) DeleteInviteCode(ctx context.Context, codeHash string) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.deleteInviteCode(tx, codeHash)
 	})
 }
 
 // DeleteInvitesByUser deletes all unused invite codes created by a user
-func (s *Storage) DeleteInvitesByUser(userId domain.UserId) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) DeleteInvitesByUser(ctx context.Context, userId domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.deleteInvitesByUser(tx, userId)
 	})
 }
@@ -2,7 +2,6 @@ package pg
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"net/http"
 	"time"
@@ -23,36 +22,51 @@ func (s *Storage) GetRecentlyBlacklistedUsers(since time.Time) ([]domain.UserId,
 
 // BlacklistUser adds a user to the blacklist. This is the public entry point
 // that wraps the operation in a transaction.
-func (s *Storage) BlacklistUser(userId domain.UserId, reason string, blacklistedBy domain.UserId) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) BlacklistUser(ctx context.Context, userId domain.UserId, reason string, blacklistedBy domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.blacklistUser(tx, userId, reason, blacklistedBy)
 	})
 }
 
+// BlacklistUserUntil applies an automatic, temporary ban. It never shortens or
+// replaces a longer ban that is already in place.
+func (s *Storage) BlacklistUserUntil(ctx context.Context, userId domain.UserId, reason string, expiresAt time.Time) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.blacklistUserUntil(tx, userId, reason, expiresAt)
+	})
+}
+
 // UnblacklistUser removes a user from the blacklist. This is the public entry point
 // that wraps the operation in a transaction.
-func (s *Storage) UnblacklistUser(userId domain.UserId) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) UnblacklistUser(ctx context.Context, userId domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.unblacklistUser(tx, userId)
 	})
 }
 
 // IsUserBlacklisted checks if a specific user is currently blacklisted.
 // This is a read-only operation used for direct DB checks (e.g., at login).
-func (s *Storage) IsUserBlacklisted(userId domain.UserId) (bool, error) {
-	return s.isUserBlacklisted(s.db, userId)
+func (s *Storage) IsUserBlacklisted(ctx context.Context, userId domain.UserId) (bool, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.isUserBlacklisted(q, userId)
 }
 
 // GetBlacklistedUsersWithDetails retrieves blacklisted users with their full details
 // (reason, blacklisted_at, blacklisted_by) for admin display purposes, with pagination.
-func (s *Storage) GetBlacklistedUsersWithDetails(limit, offset int) ([]domain.BlacklistEntry, error) {
-	return s.getBlacklistedUsersWithDetails(s.db, limit, offset)
+func (s *Storage) GetBlacklistedUsersWithDetails(ctx context.Context, limit, offset int) ([]domain.BlacklistEntry, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getBlacklistedUsersWithDetails(q, limit, offset)
 }
 
 // =========================================================================
@@ -65,7 +79,7 @@ func (s *Storage) getRecentlyBlacklistedUsers(q Querier, since time.Time) ([]dom
 	rows, err := q.Query(`
 		SELECT user_id
 		FROM user_blacklist
-		WHERE blacklisted_at >= $1
+		WHERE blacklisted_at >= $1 AND `+activeBanCondition+`
 		ORDER BY blacklisted_at DESC`,
 		since,
 	)
@@ -101,7 +115,8 @@ func (s *Storage) blacklistUser(q Querier, userId domain.UserId, reason string,
 	}
 
 	// Use INSERT ... ON CONFLICT to make operation idempotent
-	// If user is already blacklisted, update the reason and timestamp
+	// If user is already blacklisted, update the reason and timestamp.
+	// Manual bans are permanent, so this also clears any automatic expiry.
 	_, err := q.Exec(`
 		INSERT INTO user_blacklist (user_id, reason, blacklisted_by, blacklisted_at)
 		VALUES ($1, $2, $3, NOW() AT TIME ZONE 'utc')
@@ -109,7 +124,8 @@ func (s *Storage) blacklistUser(q Querier, userId domain.UserId, reason string,
 		DO UPDATE SET
 			reason = EXCLUDED.reason,
 			blacklisted_by = EXCLUDED.blacklisted_by,
-			blacklisted_at = NOW() AT TIME ZONE 'utc'`,
+			blacklisted_at = NOW() AT TIME ZONE 'utc',
+			expires_at = NULL`,
 		userId, reason, blacklistedBy,
 	)
 	if err != nil {
@@ -119,6 +135,32 @@ func (s *Storage) blacklistUser(q Querier, userId domain.UserId, reason string,
 	return nil
 }
 
+// activeBanCondition filters user_blacklist down to bans that are in effect.
+// Expired temporary bans stay until replaced, lifted or removed by the cleanup job.
+const activeBanCondition = `(expires_at IS NULL OR expires_at > NOW() AT TIME ZONE 'utc')`
+
+// blacklistUserUntil contains the core logic for automatic temporary bans.
+// An existing row is only replaced if it is a temporary ban ending before expiresAt,
+// so a permanent ban or a longer temporary one stays untouched.
+func (s *Storage) blacklistUserUntil(q Querier, userId domain.UserId, reason string, expiresAt time.Time) error {
+	_, err := q.Exec(`
+		INSERT INTO user_blacklist (user_id, reason, blacklisted_by, blacklisted_at, expires_at)
+		VALUES ($1, $2, NULL, NOW() AT TIME ZONE 'utc', $3)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			reason = EXCLUDED.reason,
+			blacklisted_by = NULL,
+			blacklisted_at = EXCLUDED.blacklisted_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE user_blacklist.expires_at IS NOT NULL AND user_blacklist.expires_at < EXCLUDED.expires_at`,
+		userId, reason, expiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to apply temporary ban: %w", err)
+	}
+	return nil
+}
+
 // unblacklistUser contains the core logic for removing a blacklist entry.
 func (s *Storage) unblacklistUser(q Querier, userId domain.UserId) error {
 	result, err := q.Exec("DELETE FROM user_blacklist WHERE user_id = $1", userId)
@@ -144,7 +186,7 @@ func (s *Storage) unblacklistUser(q Querier, userId domain.UserId) error {
 // isUserBlacklisted contains the core logic for checking blacklist status.
 func (s *Storage) isUserBlacklisted(q Querier, userId domain.UserId) (bool, error) {
 	var exists bool
-	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM user_blacklist WHERE user_id = $1)", userId).Scan(&exists)
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM user_blacklist WHERE user_id = $1 AND "+activeBanCondition+")", userId).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check blacklist status: %w", err)
 	}
@@ -157,9 +199,11 @@ func (s *Storage) getBlacklistedUsersWithDetails(q Querier, limit, offset int) (
 		SELECT
 			ub.user_id,
 			ub.blacklisted_at AT TIME ZONE 'utc' as blacklisted_at,
-			ub.reason,
-			ub.blacklisted_by
+			COALESCE(ub.reason, ''),
+			COALESCE(ub.blacklisted_by, 0),
+			ub.expires_at
 		FROM user_blacklist ub
+		WHERE `+activeBanCondition+`
 		ORDER BY ub.blacklisted_at DESC
 		LIMIT $1 OFFSET $2`,
 		limit, offset,
@@ -177,6 +221,7 @@ func (s *Storage) getBlacklistedUsersWithDetails(q Querier, limit, offset int) (
 			&entry.BlacklistedAt,
 			&entry.Reason,
 			&entry.BlacklistedBy,
+			&entry.ExpiresAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan blacklist entry: %w", err)
 		}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/itchan-dev/itchan/shared/domain"
@@ -35,11 +36,11 @@ var partitionTmplSimple string
 // It wraps the entire board creation process, including metadata insertion,
 // partition creation, and view creation, within a single atomic transaction.
 // This guarantees that a board is either fully created or not created at all.
-func (s *Storage) CreateBoard(creationData domain.BoardCreationData) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *Storage) CreateBoard(ctx context.Context, creationData domain.BoardCreationData) error {
+	ctx, cancel := s.longQueryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.createBoard(tx, creationData)
 	})
 }
@@ -48,11 +49,11 @@ func (s *Storage) CreateBoard(creationData domain.BoardCreationData) error {
 // It manages the transaction for this destructive operation, ensuring that the
 // board's materialized view, all its table partitions, and its metadata
 // are removed atomically.
-func (s *Storage) DeleteBoard(shortName domain.BoardShortName) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *Storage) DeleteBoard(ctx context.Context, shortName domain.BoardShortName) error {
+	ctx, cancel := s.longQueryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.deleteBoard(tx, shortName)
 	})
 }
@@ -60,13 +61,25 @@ func (s *Storage) DeleteBoard(shortName domain.BoardShortName) error {
 // GetBoard is a public, read-only method for fetching a single page of a board's
 // content. It delegates directly to the internal method using the main
 // database connection pool.
-func (s *Storage) GetBoard(shortName domain.BoardShortName, page int) (domain.Board, error) {
-	return s.getBoard(s.db, shortName, page)
+func (s *Storage) GetBoard(ctx context.Context, shortName domain.BoardShortName, page int) (domain.Board, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getBoard(q, shortName, page)
 }
 
 // GetBoards is a public, read-only method to fetch metadata for all boards.
-func (s *Storage) GetBoards() ([]domain.BoardMetadata, error) {
-	return s.getBoards(s.db)
+func (s *Storage) GetBoards(ctx context.Context) ([]domain.BoardMetadata, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getBoards(q)
+}
+
+// ListBoards is a public, read-only method returning one page of board metadata
+// in the given order, together with the total number of boards.
+func (s *Storage) ListBoards(ctx context.Context, sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.listBoards(q, sort, stats, limit, offset)
 }
 
 // GetActiveBoards is a public, read-only method used by the view refresh
@@ -78,10 +91,19 @@ func (s *Storage) GetActiveBoards(interval time.Duration) ([]domain.Board, error
 // GetBoardLastModified returns the view_last_modified_at timestamp for a board.
 // This reflects the last_activity_at value snapshotted after each materialized view refresh,
 // ensuring the returned timestamp only covers changes the view has actually incorporated.
-func (s *Storage) GetBoardLastModified(shortName domain.BoardShortName) (time.Time, error) {
+// Incremental previews are never behind, so last_activity_at is returned as is.
+func (s *Storage) GetBoardLastModified(ctx context.Context, shortName domain.BoardShortName) (time.Time, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	column := "view_last_modified_at"
+	if s.cfg.Public.IncrementalBoardPreviews {
+		column = "last_activity_at"
+	}
+
 	var lastModified time.Time
-	err := s.db.QueryRow(
-		`SELECT view_last_modified_at FROM boards WHERE short_name = $1`, shortName,
+	err := q.QueryRow(
+		`SELECT `+column+` FROM boards WHERE short_name = $1`, shortName,
 	).Scan(&lastModified)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -94,12 +116,64 @@ func (s *Storage) GetBoardLastModified(shortName domain.BoardShortName) (time.Ti
 	return lastModified, nil
 }
 
+// UpdateBoardSettings replaces the admin-editable settings of a board.
+func (s *Storage) UpdateBoardSettings(ctx context.Context, shortName domain.BoardShortName, settings domain.BoardSettings) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.updateBoardSettings(tx, shortName, settings)
+	})
+}
+
+// GetBoardSettings returns the admin-editable settings of a board.
+func (s *Storage) GetBoardSettings(ctx context.Context, shortName domain.BoardShortName) (domain.BoardSettings, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getBoardSettings(q, shortName)
+}
+
 // GetBoardsWithPermissions returns a map of board short names to their allowed email domains.
 // Returns nil for boards without restrictions (public boards).
 func (s *Storage) GetBoardsWithPermissions() (map[string][]string, error) {
 	return getBoardsWithPermissions(s.db)
 }
 
+// GetBoardsPendingDeletion returns the short names of boards scheduled for
+// deletion. It is used by the board_access middleware to hide them.
+func (s *Storage) GetBoardsPendingDeletion() ([]string, error) {
+	return getBoardsPendingDeletion(s.db)
+}
+
+// ScheduleBoardDeletion marks a board for deletion after deleteAfter. The board
+// stays in the database until the purge job drops it.
+func (s *Storage) ScheduleBoardDeletion(ctx context.Context, shortName domain.BoardShortName, deleteAfter time.Time) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.scheduleBoardDeletion(tx, shortName, deleteAfter)
+	})
+}
+
+// CancelBoardDeletion restores a board that is scheduled for deletion.
+func (s *Storage) CancelBoardDeletion(ctx context.Context, shortName domain.BoardShortName) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.cancelBoardDeletion(tx, shortName)
+	})
+}
+
+// GetPendingBoardDeletions returns the boards scheduled for deletion, due
+// first.
+func (s *Storage) GetPendingBoardDeletions(ctx context.Context) ([]domain.PendingBoardDeletion, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getPendingBoardDeletions(q)
+}
+
 // =========================================================================
 // Internal Methods (Core Database Logic)
 // These methods accept a Querier and are transaction-agnostic.
@@ -114,8 +188,8 @@ func (s *Storage) createBoard(q Querier, creationData domain.BoardCreationData)
 
 	// Insert board metadata.
 	_, err := q.Exec(`
-        INSERT INTO boards (name, short_name) VALUES ($1, $2)`,
-		creationData.Name, creationData.ShortName,
+        INSERT INTO boards (name, short_name, description) VALUES ($1, $2, $3)`,
+		creationData.Name, creationData.ShortName, creationData.Description,
 	)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // Unique violation
@@ -125,6 +199,10 @@ func (s *Storage) createBoard(q Querier, creationData domain.BoardCreationData)
 		}
 		return fmt.Errorf("failed to insert board metadata: %w", err)
 	}
+	// A board created under a name another board was renamed from takes it over.
+	if _, err = q.Exec(`DELETE FROM board_redirects WHERE old_short_name = $1`, creationData.ShortName); err != nil {
+		return fmt.Errorf("failed to drop board redirect: %w", err)
+	}
 
 	// Insert board permissions if provided.
 	if creationData.AllowedEmails != nil {
@@ -139,39 +217,51 @@ func (s *Storage) createBoard(q Querier, creationData domain.BoardCreationData)
 		}
 	}
 
+	if err := createBoardPartitions(q, creationData.ShortName); err != nil {
+		return err
+	}
+	return s.createBoardView(q, creationData.ShortName)
+}
+
+// createBoardPartitions creates the board's partitions of the partitioned
+// tables, with the sequence that numbers its threads.
+func createBoardPartitions(q Querier, shortName domain.BoardShortName) error {
 	// Create partition for threads table (requires sequence for auto-increment ID).
-	threadsSeqName := fmt.Sprintf("threads_id_seq_%s", creationData.ShortName)
+	threadsSeqName := fmt.Sprintf("threads_id_seq_%s", shortName)
 	threadsQuery := fmt.Sprintf(partitionTmpl,
 		pq.QuoteIdentifier(threadsSeqName),
-		PartitionName(creationData.ShortName, "threads"),
+		PartitionName(shortName, "threads"),
 		pq.QuoteIdentifier("threads"),
 		pq.QuoteLiteral(threadsSeqName),
-		pq.QuoteLiteral(creationData.ShortName),
+		pq.QuoteLiteral(shortName),
 	)
-	if _, err = q.Exec(threadsQuery); err != nil {
-		return fmt.Errorf("failed to create threads partition for board '%s': %w", creationData.ShortName, err)
+	if _, err := q.Exec(threadsQuery); err != nil {
+		return fmt.Errorf("failed to create threads partition for board '%s': %w", shortName, err)
 	}
 
 	// Create partitions for tables without sequences (id is set explicitly).
 	for _, table := range []string{"messages", "attachments", "message_replies"} {
 		query := fmt.Sprintf(partitionTmplSimple,
-			PartitionName(creationData.ShortName, table),
+			PartitionName(shortName, table),
 			pq.QuoteIdentifier(table),
-			pq.QuoteLiteral(creationData.ShortName),
+			pq.QuoteLiteral(shortName),
 		)
-		if _, err = q.Exec(query); err != nil {
-			return fmt.Errorf("failed to create %s partition for board '%s': %w", table, creationData.ShortName, err)
+		if _, err := q.Exec(query); err != nil {
+			return fmt.Errorf("failed to create %s partition for board '%s': %w", table, shortName, err)
 		}
 	}
+	return nil
+}
 
-	// Create the materialized view for board content previews.
+// createBoardView creates the materialized view for board content previews.
+func (s *Storage) createBoardView(q Querier, shortName domain.BoardShortName) error {
 	viewQuery := fmt.Sprintf(viewTmpl,
-		ViewTableName(creationData.ShortName),
+		ViewTableName(shortName),
 		s.cfg.Public.NLastMsg,
-		pq.QuoteLiteral(creationData.ShortName),
+		pq.QuoteLiteral(shortName),
 	)
-	if _, err = q.Exec(viewQuery); err != nil {
-		return fmt.Errorf("failed to create materialized view for board '%s': %w", creationData.ShortName, err)
+	if _, err := q.Exec(viewQuery); err != nil {
+		return fmt.Errorf("failed to create materialized view for board '%s': %w", shortName, err)
 	}
 	return nil
 }
@@ -202,18 +292,8 @@ func (s *Storage) deleteBoard(q Querier, shortName domain.BoardShortName) error
 		return fmt.Errorf("error iterating file IDs: %w", err)
 	}
 
-	// Drop the materialized view first, as it may depend on the tables to be dropped.
-	if _, err := q.Exec(fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s", ViewTableName(shortName))); err != nil {
-		return fmt.Errorf("failed to drop view for board '%s': %w", shortName, err)
-	}
-
-	// Drop all table partitions associated with the board.
-	// The CASCADE clause handles dependent objects like sequences.
-	for _, table := range []string{"message_replies", "attachments", "messages", "threads"} {
-		partition := PartitionName(shortName, table)
-		if _, err := q.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", partition)); err != nil {
-			return fmt.Errorf("failed to drop %s partition for board '%s': %w", table, shortName, err)
-		}
+	if err := dropBoardPartitions(q, shortName); err != nil {
+		return err
 	}
 
 	// Finally, delete the board's metadata record. Foreign key constraints with
@@ -245,13 +325,183 @@ func (s *Storage) deleteBoard(q Querier, shortName domain.BoardShortName) error
 	return nil
 }
 
+// dropBoardPartitions drops the board's materialized view and partitions.
+func dropBoardPartitions(q Querier, shortName domain.BoardShortName) error {
+	// Drop the materialized view first, as it may depend on the tables to be dropped.
+	if _, err := q.Exec(fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s", ViewTableName(shortName))); err != nil {
+		return fmt.Errorf("failed to drop view for board '%s': %w", shortName, err)
+	}
+
+	// Drop all table partitions associated with the board.
+	// The CASCADE clause handles dependent objects like sequences.
+	for _, table := range []string{"message_replies", "attachments", "messages", "threads"} {
+		partition := PartitionName(shortName, table)
+		if _, err := q.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", partition)); err != nil {
+			return fmt.Errorf("failed to drop %s partition for board '%s': %w", table, shortName, err)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) scheduleBoardDeletion(q Querier, shortName domain.BoardShortName, deleteAfter time.Time) error {
+	result, err := q.Exec(
+		`UPDATE boards SET delete_after = $2 WHERE short_name = $1 AND delete_after IS NULL`,
+		shortName, deleteAfter.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to schedule deletion of board '%s': %w", shortName, err)
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM boards WHERE short_name = $1)`, shortName).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check board existence: %w", err)
+	}
+	if !exists {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+		}
+	}
+	return &internal_errors.ErrorWithStatusCode{
+		Message: fmt.Sprintf("Board '%s' is already scheduled for deletion", shortName), StatusCode: http.StatusConflict,
+	}
+}
+
+func (s *Storage) cancelBoardDeletion(q Querier, shortName domain.BoardShortName) error {
+	result, err := q.Exec(
+		`UPDATE boards SET delete_after = NULL WHERE short_name = $1 AND delete_after IS NOT NULL`,
+		shortName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cancel deletion of board '%s': %w", shortName, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Board '%s' is not scheduled for deletion", shortName), StatusCode: http.StatusNotFound,
+		}
+	}
+	return nil
+}
+
+func (s *Storage) getPendingBoardDeletions(q Querier) ([]domain.PendingBoardDeletion, error) {
+	rows, err := q.Query(`
+		SELECT short_name, name, delete_after
+		FROM boards
+		WHERE delete_after IS NOT NULL
+		ORDER BY delete_after, short_name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query boards pending deletion: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []domain.PendingBoardDeletion
+	for rows.Next() {
+		var p domain.PendingBoardDeletion
+		if err := rows.Scan(&p.Board, &p.Name, &p.DeleteAfter); err != nil {
+			return nil, fmt.Errorf("failed to scan board pending deletion: %w", err)
+		}
+		pending = append(pending, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating boards pending deletion: %w", err)
+	}
+	return pending, nil
+}
+
+func getBoardsPendingDeletion(q Querier) ([]string, error) {
+	rows, err := q.Query(`SELECT short_name FROM boards WHERE delete_after IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query boards pending deletion: %w", err)
+	}
+	defer rows.Close()
+
+	var boards []string
+	for rows.Next() {
+		var board string
+		if err := rows.Scan(&board); err != nil {
+			return nil, fmt.Errorf("failed to scan board pending deletion: %w", err)
+		}
+		boards = append(boards, board)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating boards pending deletion: %w", err)
+	}
+	return boards, nil
+}
+
+func (s *Storage) updateBoardSettings(q Querier, shortName domain.BoardShortName, settings domain.BoardSettings) error {
+	result, err := q.Exec(`
+		UPDATE boards SET
+			show_deletion_stubs = $2,
+			min_op_text_length = $3,
+			require_op_attachment = $4,
+			max_threads_per_user_per_day = $5,
+			description = $6,
+			noindex = $7,
+			video_profile = $8,
+			allow_documents = $9,
+			max_attachments = $10,
+			allowed_mime_types = $11,
+			public_modlog = $12,
+			self_delete_replied = $13,
+			text_filter = $14,
+			posting_email_domains = $15,
+			posting_min_account_age_days = $16,
+			bump_limit_notice = $17,
+			pre_moderation = $18,
+			premod_trust_approvals = $19,
+			premod_trust_account_age_days = $20,
+			language = $21
+		WHERE short_name = $1`,
+		shortName, settings.ShowDeletionStubs, settings.MinOpTextLength, settings.RequireOpAttachment, settings.MaxThreadsPerUserPerDay, settings.Description,
+		settings.Noindex, settings.VideoProfile, settings.AllowDocuments, settings.MaxAttachments, strings.Join(settings.AllowedMimeTypes, ","), settings.PublicModLog, settings.SelfDeleteReplied, settings.TextFilter,
+		strings.Join(settings.PostingEmailDomains, ","), settings.PostingMinAccountAgeDays, settings.BumpLimitNotice,
+		settings.PreModeration, settings.PremodTrustApprovals, settings.PremodTrustAccountAgeDays, settings.Language,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update settings for board '%s': %w", shortName, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+		}
+	}
+
+	// Settings change how threads render, so invalidate cached thread pages.
+	return s.touchBoardThreads(q, shortName)
+}
+
+func (s *Storage) getBoardSettings(q Querier, shortName domain.BoardShortName) (domain.BoardSettings, error) {
+	var settings domain.BoardSettings
+	err := q.QueryRow(`
+		SELECT description, show_deletion_stubs, noindex, min_op_text_length, require_op_attachment, max_threads_per_user_per_day, video_profile, allow_documents, max_attachments, allowed_mime_types, public_modlog, self_delete_replied, text_filter, posting_email_domains, posting_min_account_age_days, bump_limit_notice, pre_moderation, premod_trust_approvals, premod_trust_account_age_days, language, EXISTS (SELECT 1 FROM board_mirrors bm WHERE bm.board = boards.short_name)
+		FROM boards WHERE short_name = $1`,
+		shortName,
+	).Scan(&settings.Description, &settings.ShowDeletionStubs, &settings.Noindex, &settings.MinOpTextLength, &settings.RequireOpAttachment, &settings.MaxThreadsPerUserPerDay, &settings.VideoProfile, &settings.AllowDocuments, &settings.MaxAttachments, (*commaList)(&settings.AllowedMimeTypes), &settings.PublicModLog, &settings.SelfDeleteReplied, &settings.TextFilter, (*commaList)(&settings.PostingEmailDomains), &settings.PostingMinAccountAgeDays, &settings.BumpLimitNotice, &settings.PreModeration, &settings.PremodTrustApprovals, &settings.PremodTrustAccountAgeDays, &settings.Language, &settings.Mirror)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.BoardSettings{}, &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+			}
+		}
+		return domain.BoardSettings{}, fmt.Errorf("failed to fetch settings for board '%s': %w", shortName, err)
+	}
+	return settings, nil
+}
+
 // getBoard contains the core logic for fetching a board's content.
 func (s *Storage) getBoard(q Querier, shortName domain.BoardShortName, page int) (domain.Board, error) {
 	var metadata domain.BoardMetadata
 	err := q.QueryRow(`
-	       SELECT name, short_name, created_at, last_activity_at FROM boards WHERE short_name = $1`,
+	       SELECT name, short_name, created_at, last_activity_at, category_id, position,
+	              description, show_deletion_stubs, noindex, min_op_text_length, require_op_attachment, max_threads_per_user_per_day, video_profile, allow_documents, max_attachments, allowed_mime_types, public_modlog, self_delete_replied, text_filter, posting_email_domains, posting_min_account_age_days, bump_limit_notice, pre_moderation, premod_trust_approvals, premod_trust_account_age_days, language, EXISTS (SELECT 1 FROM board_mirrors bm WHERE bm.board = boards.short_name)
+	       FROM boards WHERE short_name = $1`,
 		shortName,
-	).Scan(&metadata.Name, &metadata.ShortName, &metadata.CreatedAt, &metadata.LastActivityAt)
+	).Scan(&metadata.Name, &metadata.ShortName, &metadata.CreatedAt, &metadata.LastActivityAt, &metadata.CategoryId, &metadata.Position,
+		&metadata.Description, &metadata.ShowDeletionStubs, &metadata.Noindex, &metadata.MinOpTextLength, &metadata.RequireOpAttachment, &metadata.MaxThreadsPerUserPerDay, &metadata.VideoProfile, &metadata.AllowDocuments, &metadata.MaxAttachments, (*commaList)(&metadata.AllowedMimeTypes), &metadata.PublicModLog, &metadata.SelfDeleteReplied, &metadata.TextFilter, (*commaList)(&metadata.PostingEmailDomains), &metadata.PostingMinAccountAgeDays, &metadata.BumpLimitNotice, &metadata.PreModeration, &metadata.PremodTrustApprovals, &metadata.PremodTrustAccountAgeDays, &metadata.Language, &metadata.Mirror)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return domain.Board{}, &internal_errors.ErrorWithStatusCode{
@@ -261,19 +511,9 @@ func (s *Storage) getBoard(q Querier, shortName domain.BoardShortName, page int)
 		return domain.Board{}, fmt.Errorf("failed to fetch board metadata for '%s': %w", shortName, err)
 	}
 
-	// Fetch threads and their messages from the materialized view
-	// ViewTableName returns an already quoted identifier
+	// Fetch threads and their messages from the materialized view or thread_previews
 	rows, err := q.Query(
-		fmt.Sprintf(`
-            SELECT thread_title, message_count, last_bumped_at, thread_id, is_pinned,
-                   msg_id, author_id, email_domain, author_is_admin, show_email_domain,
-                   text, created_at
-            FROM %s
-            WHERE thread_order BETWEEN $1 * ($2 - 1) + 1 AND $1 * $2
-            ORDER BY thread_order, msg_id
-			`,
-			ViewTableName(shortName),
-		),
+		s.boardPageQuery(shortName),
 		s.cfg.Public.ThreadsPerPage,
 		page,
 	)
@@ -295,6 +535,7 @@ func (s *Storage) getBoard(q Querier, shortName domain.BoardShortName, page int)
 		ShowEmailDomain   bool
 		Text              domain.MsgText
 		CreatedAt         time.Time
+		PostNumber        domain.PostNumber
 	}
 
 	// Map for efficient message lookup when attaching replies and attachments
@@ -310,7 +551,7 @@ func (s *Storage) getBoard(q Querier, shortName domain.BoardShortName, page int)
 		if err := rows.Scan(
 			&row.ThreadTitle, &row.NMessages, &row.LastBumpTs, &row.ThreadID, &row.IsPinned, &row.MsgID,
 			&row.AuthorID, &row.AuthorEmailDomain, &row.AuthorIsAdmin, &row.ShowEmailDomain,
-			&row.Text, &row.CreatedAt,
+			&row.Text, &row.CreatedAt, &row.PostNumber,
 		); err != nil {
 			return domain.Board{}, fmt.Errorf("failed to scan thread/message row: %w", err)
 		}
@@ -341,7 +582,8 @@ func (s *Storage) getBoard(q Querier, shortName domain.BoardShortName, page int)
 		}
 		msg := &domain.Message{
 			MessageMetadata: domain.MessageMetadata{
-				Id: row.MsgID,
+				Id:         row.MsgID,
+				PostNumber: row.PostNumber,
 				Author: domain.User{
 					Id:          row.AuthorID,
 					EmailDomain: row.AuthorEmailDomain,
@@ -371,17 +613,11 @@ func (s *Storage) getBoard(q Querier, shortName domain.BoardShortName, page int)
 		threads = append(threads, &threadCopy)
 	}
 
-	// Enrich parsed messages with replies
+	// Enrich parsed messages with replies and attachments. The view already leaves
+	// out shadowbanned users' messages; their reply links are dropped too.
 	if len(messageKeys) > 0 {
-		if err := enrichMessagesWithReplies(q, shortName, messageKeys, idToMessage, s.cfg.Public.MessagesPerThreadPage); err != nil {
-			return domain.Board{}, fmt.Errorf("failed to enrich replies for board page: %w", err)
-		}
-	}
-
-	// Enrich parsed messages with attachments
-	if len(messageKeys) > 0 {
-		if err := enrichMessagesWithAttachments(q, shortName, messageKeys, idToMessage); err != nil {
-			return domain.Board{}, fmt.Errorf("failed to enrich attachments for board page: %w", err)
+		if err := enrichMessages(q, shortName, messageKeys, idToMessage, s.cfg.Public.MessagesPerThreadPage, true); err != nil {
+			return domain.Board{}, fmt.Errorf("failed to enrich board page: %w", err)
 		}
 	}
 
@@ -391,45 +627,156 @@ func (s *Storage) getBoard(q Querier, shortName domain.BoardShortName, page int)
 	}, nil
 }
 
+// boardMetadataColumns are all fields that constitute BoardMetadata, in scanBoards order.
+const boardMetadataColumns = `
+		name, short_name, created_at, last_activity_at, category_id, position,
+		description, show_deletion_stubs, noindex, min_op_text_length, require_op_attachment, max_threads_per_user_per_day, video_profile, allow_documents, max_attachments, allowed_mime_types, public_modlog, self_delete_replied, text_filter, posting_email_domains, posting_min_account_age_days, bump_limit_notice, pre_moderation, premod_trust_approvals, premod_trust_account_age_days, language, EXISTS (SELECT 1 FROM board_mirrors bm WHERE bm.board = boards.short_name)`
+
+// boardOrder maps the listing sort options to ORDER BY clauses. Short name
+// breaks ties so pages are stable.
+var boardOrder = map[domain.BoardSort]string{
+	domain.BoardSortPosition: "position, short_name",
+	domain.BoardSortActivity: "last_activity_at DESC NULLS LAST, short_name",
+	domain.BoardSortCreated:  "created_at DESC NULLS LAST, short_name",
+	domain.BoardSortName:     "name, short_name",
+}
+
 // getBoards contains the core logic for fetching all board metadata.
 func (s *Storage) getBoards(q Querier) ([]domain.BoardMetadata, error) {
-	var boards []domain.BoardMetadata
-	rows, err := q.Query(`
-	SELECT
-		name, short_name, created_at, last_activity_at
+	rows, err := q.Query(`SELECT` + boardMetadataColumns + `
 	FROM boards
-	ORDER BY short_name
-	`) // Querying all fields that constitute BoardMetadata
+	WHERE delete_after IS NULL
+	ORDER BY position, short_name
+	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query boards: %w", err)
 	}
+	boards, err := scanBoards(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enrich boards with permissions (corporate vs public board distinction)
+	if err := enrichBoardsWithPermissions(q, boards); err != nil {
+		return nil, err
+	}
+
+	return boards, nil
+}
+
+// listBoards contains the core logic for the paginated board listing.
+func (s *Storage) listBoards(q Querier, sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error) {
+	order, ok := boardOrder[sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown board sort '%s'", sort)
+	}
+
+	var total int
+	if err := q.QueryRow(`SELECT COUNT(*) FROM boards WHERE delete_after IS NULL`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count boards: %w", err)
+	}
+
+	rows, err := q.Query(`SELECT`+boardMetadataColumns+`
+	FROM boards
+	WHERE delete_after IS NULL
+	ORDER BY `+order+`
+	LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query boards: %w", err)
+	}
+	boards, err := scanBoards(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := enrichBoardsWithPermissions(q, boards); err != nil {
+		return nil, 0, err
+	}
+	if stats != domain.BoardStatsNone {
+		if err := enrichBoardsWithStats(q, boards); err != nil {
+			return nil, 0, err
+		}
+	}
+	if stats == domain.BoardStatsLatest {
+		if err := enrichBoardsWithLatestThreads(q, boards); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return boards, total, nil
+}
+
+// scanBoards reads rows selected with boardMetadataColumns and closes them.
+func scanBoards(rows *sql.Rows) ([]domain.BoardMetadata, error) {
 	defer rows.Close()
 
+	var boards []domain.BoardMetadata
 	for rows.Next() {
 		var boardMeta domain.BoardMetadata
-		err = rows.Scan(
+		err := rows.Scan(
 			&boardMeta.Name,
 			&boardMeta.ShortName,
 			&boardMeta.CreatedAt,
 			&boardMeta.LastActivityAt,
+			&boardMeta.CategoryId,
+			&boardMeta.Position,
+			&boardMeta.Description,
+			&boardMeta.ShowDeletionStubs,
+			&boardMeta.Noindex,
+			&boardMeta.MinOpTextLength,
+			&boardMeta.RequireOpAttachment,
+			&boardMeta.MaxThreadsPerUserPerDay,
+			&boardMeta.VideoProfile,
+			&boardMeta.AllowDocuments,
+			&boardMeta.MaxAttachments,
+			(*commaList)(&boardMeta.AllowedMimeTypes),
+			&boardMeta.PublicModLog,
+			&boardMeta.SelfDeleteReplied,
+			&boardMeta.TextFilter,
+			(*commaList)(&boardMeta.PostingEmailDomains),
+			&boardMeta.PostingMinAccountAgeDays,
+			&boardMeta.BumpLimitNotice,
+			&boardMeta.PreModeration,
+			&boardMeta.PremodTrustApprovals,
+			&boardMeta.PremodTrustAccountAgeDays,
+			&boardMeta.Language,
+			&boardMeta.Mirror,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan board metadata: %w", err)
 		}
 		boards = append(boards, boardMeta)
 	}
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating board rows: %w", err)
 	}
-
-	// Enrich boards with permissions (corporate vs public board distinction)
-	if err := enrichBoardsWithPermissions(q, boards); err != nil {
-		return nil, err
-	}
-
 	return boards, nil
 }
 
+// commaList scans a comma-separated text column; an empty value is nil.
+type commaList []string
+
+func (l *commaList) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a comma-separated list", src)
+	}
+	if value == "" {
+		*l = nil
+		return nil
+	}
+	*l = strings.Split(value, ",")
+	return nil
+}
+
 // getActiveBoards contains the core logic for finding boards with recent activity.
 func (s *Storage) getActiveBoards(q Querier, interval time.Duration) ([]domain.Board, error) {
 	rows, err := q.Query(`
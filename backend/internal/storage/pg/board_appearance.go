@@ -0,0 +1,159 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.BoardAppearanceStorage interface)
+// =========================================================================
+
+// GetBoardAppearance returns the custom stylesheet and the banners of a
+// board, oldest banner first.
+func (s *Storage) GetBoardAppearance(ctx context.Context, shortName domain.BoardShortName) (domain.BoardAppearance, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var appearance domain.BoardAppearance
+	err := q.QueryRow(`SELECT custom_css FROM boards WHERE short_name = $1`, shortName).Scan(&appearance.CustomCSS)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.BoardAppearance{}, &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+			}
+		}
+		return domain.BoardAppearance{}, fmt.Errorf("failed to fetch appearance of board '%s': %w", shortName, err)
+	}
+
+	appearance.Banners, err = s.getBoardBanners(q, shortName)
+	if err != nil {
+		return domain.BoardAppearance{}, err
+	}
+	return appearance, nil
+}
+
+// SetBoardCustomCSS replaces the custom stylesheet of a board.
+func (s *Storage) SetBoardCustomCSS(ctx context.Context, shortName domain.BoardShortName, css string) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		result, err := tx.Exec(`UPDATE boards SET custom_css = $2 WHERE short_name = $1`, shortName, css)
+		if err != nil {
+			return fmt.Errorf("failed to update custom css of board '%s': %w", shortName, err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+			}
+		}
+		return s.touchBoardThreads(tx, shortName)
+	})
+}
+
+// AddBoardBanner records a banner whose file is already in the media storage.
+func (s *Storage) AddBoardBanner(ctx context.Context, shortName domain.BoardShortName, filePath string) (domain.BoardBannerId, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	var id domain.BoardBannerId
+	err := s.withTx(ctx, func(tx Querier) error {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM boards WHERE short_name = $1)`, shortName).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check board '%s': %w", shortName, err)
+		}
+		if !exists {
+			return &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+			}
+		}
+
+		err := tx.QueryRow(`
+			INSERT INTO board_banners (board, file_path, created_at)
+			VALUES ($1, $2, $3)
+			RETURNING id`,
+			shortName, filePath, time.Now().UTC(),
+		).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to add banner to board '%s': %w", shortName, err)
+		}
+		return s.touchBoardThreads(tx, shortName)
+	})
+	return id, err
+}
+
+// DeleteBoardBanner removes a banner and returns the path of its file, which
+// the caller deletes from the media storage.
+func (s *Storage) DeleteBoardBanner(ctx context.Context, shortName domain.BoardShortName, id domain.BoardBannerId) (string, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	var filePath string
+	err := s.withTx(ctx, func(tx Querier) error {
+		err := tx.QueryRow(`
+			DELETE FROM board_banners WHERE board = $1 AND id = $2
+			RETURNING file_path`,
+			shortName, id,
+		).Scan(&filePath)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return &internal_errors.ErrorWithStatusCode{Message: "Banner not found", StatusCode: http.StatusNotFound}
+			}
+			return fmt.Errorf("failed to delete banner %d of board '%s': %w", id, shortName, err)
+		}
+		return s.touchBoardThreads(tx, shortName)
+	})
+	return filePath, err
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+func (s *Storage) getBoardBanners(q Querier, shortName domain.BoardShortName) ([]domain.BoardBanner, error) {
+	rows, err := q.Query(`
+		SELECT id, board, file_path, created_at
+		FROM board_banners
+		WHERE board = $1
+		ORDER BY id`,
+		shortName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch banners of board '%s': %w", shortName, err)
+	}
+	defer rows.Close()
+
+	banners := []domain.BoardBanner{}
+	for rows.Next() {
+		var b domain.BoardBanner
+		if err := rows.Scan(&b.Id, &b.Board, &b.FilePath, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan banner: %w", err)
+		}
+		banners = append(banners, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating banners: %w", err)
+	}
+	return banners, nil
+}
+
+// touchBoardThreads invalidates cached thread pages after a change that
+// affects how every page of the board renders.
+func (s *Storage) touchBoardThreads(q Querier, shortName domain.BoardShortName) error {
+	_, err := q.Exec(`
+		UPDATE threads SET last_modified_at = $2 WHERE board = $1`,
+		shortName, time.Now().UTC().Round(time.Microsecond),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to touch threads of board '%s': %w", shortName, err)
+	}
+	return nil
+}
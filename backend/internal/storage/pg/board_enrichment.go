@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/lib/pq"
 )
 
 // enrichBoardsWithPermissions fetches and attaches allowed email domains to boards.
@@ -63,3 +64,94 @@ func getBoardsWithPermissions(q Querier) (map[string][]string, error) {
 
 	return permissions, nil
 }
+
+// enrichBoardsWithStats attaches thread and message counts to boards.
+// Boards without threads get zero counts.
+func enrichBoardsWithStats(q Querier, boards []domain.BoardMetadata) error {
+	if len(boards) == 0 {
+		return nil
+	}
+
+	shortNames := make([]string, len(boards))
+	for i, b := range boards {
+		shortNames[i] = string(b.ShortName)
+		boards[i].Stats = &domain.BoardStats{}
+	}
+
+	rows, err := q.Query(`
+		SELECT board, COUNT(*), COALESCE(SUM(message_count), 0)
+		FROM threads
+		WHERE board = ANY($1)
+		GROUP BY board`,
+		pq.Array(shortNames),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query board stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[domain.BoardShortName]domain.BoardStats, len(boards))
+	for rows.Next() {
+		var board domain.BoardShortName
+		var s domain.BoardStats
+		if err := rows.Scan(&board, &s.ThreadCount, &s.MessageCount); err != nil {
+			return fmt.Errorf("failed to scan board stats row: %w", err)
+		}
+		stats[board] = s
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating board stats rows: %w", err)
+	}
+
+	for i := range boards {
+		*boards[i].Stats = stats[boards[i].ShortName]
+	}
+	return nil
+}
+
+// enrichBoardsWithLatestThreads attaches the most recently bumped thread to
+// boards that already carry stats. Threads started by users shadowbanned on
+// the board are skipped, since the listing is shared by all viewers.
+func enrichBoardsWithLatestThreads(q Querier, boards []domain.BoardMetadata) error {
+	if len(boards) == 0 {
+		return nil
+	}
+
+	shortNames := make([]string, len(boards))
+	for i, b := range boards {
+		shortNames[i] = string(b.ShortName)
+	}
+
+	rows, err := q.Query(`
+		SELECT DISTINCT ON (t.board) t.board, t.id, t.title, t.last_bumped_at
+		FROM threads t
+		JOIN messages m ON m.board = t.board AND m.thread_id = t.id AND m.id = 1
+		WHERE t.board = ANY($1) AND `+notShadowbannedCondition+`
+		ORDER BY t.board, t.last_bumped_at DESC, t.id DESC`,
+		pq.Array(shortNames),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query latest board threads: %w", err)
+	}
+	defer rows.Close()
+
+	latest := make(map[domain.BoardShortName]*domain.BoardLatestThread, len(boards))
+	for rows.Next() {
+		var board domain.BoardShortName
+		var t domain.BoardLatestThread
+		if err := rows.Scan(&board, &t.Id, &t.Title, &t.LastBumped); err != nil {
+			return fmt.Errorf("failed to scan latest board thread row: %w", err)
+		}
+		latest[board] = &t
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating latest board thread rows: %w", err)
+	}
+
+	for i := range boards {
+		if boards[i].Stats != nil {
+			boards[i].Stats.LatestThread = latest[boards[i].ShortName]
+		}
+	}
+	return nil
+}
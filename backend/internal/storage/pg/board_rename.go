@@ -0,0 +1,222 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+
+	"github.com/lib/pq"
+)
+
+// boardColumnTables lists the tables outside the board partitions whose rows
+// belong to a board, with the column holding its short name.
+var boardColumnTables = []struct{ table, column string }{
+	{"board_permissions", "board_short_name"},
+	{"board_banners", "board"},
+	{"thread_previews", "board"},
+	{"pending_uploads", "board"},
+	{"message_deletions", "board"},
+	{"thread_title_edits", "board"},
+	{"thread_successors", "board"},
+	{"premod_queue", "board"},
+	{"premod_posters", "board"},
+	{"moderation_log", "board"},
+	{"user_shadowbans", "board"},
+	{"notifications", "board"},
+	{"message_bookmarks", "board"},
+	{"thread_watches", "board"},
+	{"thread_reads", "board"},
+	{"board_post_counts", "board"},
+	{"board_mirrors", "board"},
+	{"mirrored_threads", "board"},
+	{"thread_snapshots", "board"},
+}
+
+// =========================================================================
+// Public Methods (satisfy the service.BoardStorage interface)
+// =========================================================================
+
+// RenameBoard moves a board and everything on it to a new short name in a
+// single transaction. When redirectUntil is set, the old name redirects to
+// the new one until then.
+func (s *Storage) RenameBoard(ctx context.Context, oldName, newName domain.BoardShortName, redirectUntil *time.Time) error {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.renameBoard(tx, oldName, newName, redirectUntil)
+	})
+}
+
+// GetBoardRedirects returns the unexpired redirects from old board short
+// names to current ones. It is used by the board_access middleware.
+func (s *Storage) GetBoardRedirects() (map[string]string, error) {
+	return getBoardRedirects(s.db)
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// =========================================================================
+
+// renameBoard copies the board into partitions under the new name and drops
+// the old ones, as the partition bounds of a board cannot be changed in place.
+// Rows of the other tables are moved with an UPDATE, and stored media paths
+// are rewritten to the new board directory.
+func (s *Storage) renameBoard(q Querier, oldName, newName domain.BoardShortName, redirectUntil *time.Time) error {
+	var pendingDeletion bool
+	err := q.QueryRow(`SELECT delete_after IS NOT NULL FROM boards WHERE short_name = $1`, oldName).Scan(&pendingDeletion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Board '%s' not found", oldName), StatusCode: http.StatusNotFound,
+			}
+		}
+		return fmt.Errorf("failed to fetch board '%s': %w", oldName, err)
+	}
+	if pendingDeletion {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Board '%s' is scheduled for deletion", oldName), StatusCode: http.StatusConflict,
+		}
+	}
+
+	var taken bool
+	if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM boards WHERE short_name = $1)`, newName).Scan(&taken); err != nil {
+		return fmt.Errorf("failed to check board existence: %w", err)
+	}
+	if taken {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Board with short name '%s' already exists", newName), StatusCode: http.StatusConflict,
+		}
+	}
+
+	// The boards row is copied whole through a temporary table, so new
+	// settings columns need no change here.
+	if err := copyBoardRows(q, "boards", "boards", "short_name", oldName, newName); err != nil {
+		return err
+	}
+
+	if err := createBoardPartitions(q, newName); err != nil {
+		return err
+	}
+	_, err = q.Exec(
+		fmt.Sprintf(`SELECT setval($1::regclass, last_value, is_called) FROM %s`, pq.QuoteIdentifier(fmt.Sprintf("threads_id_seq_%s", oldName))),
+		pq.QuoteIdentifier(fmt.Sprintf("threads_id_seq_%s", newName)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to carry over thread ids of board '%s': %w", oldName, err)
+	}
+	// Parents before children, so the foreign keys hold on every insert.
+	for _, table := range []string{"threads", "messages", "attachments", "message_replies"} {
+		if err := copyBoardRows(q, PartitionName(oldName, table), pq.QuoteIdentifier(table), "board", oldName, newName); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range boardColumnTables {
+		query := fmt.Sprintf(`UPDATE %s SET %s = $2 WHERE %s = $1`, t.table, t.column, t.column)
+		if _, err := q.Exec(query, oldName, newName); err != nil {
+			return fmt.Errorf("failed to move %s of board '%s': %w", t.table, oldName, err)
+		}
+	}
+
+	if err := renameMediaPaths(q, oldName, newName); err != nil {
+		return err
+	}
+
+	if _, err := q.Exec(`UPDATE board_redirects SET new_short_name = $2 WHERE new_short_name = $1`, oldName, newName); err != nil {
+		return fmt.Errorf("failed to update board redirects: %w", err)
+	}
+	if _, err := q.Exec(`DELETE FROM board_redirects WHERE old_short_name = $1`, newName); err != nil {
+		return fmt.Errorf("failed to update board redirects: %w", err)
+	}
+	if redirectUntil != nil {
+		_, err := q.Exec(`
+			INSERT INTO board_redirects (old_short_name, new_short_name, expires_at) VALUES ($1, $2, $3)
+			ON CONFLICT (old_short_name) DO UPDATE SET new_short_name = EXCLUDED.new_short_name, expires_at = EXCLUDED.expires_at`,
+			oldName, newName, redirectUntil.UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add board redirect: %w", err)
+		}
+	}
+
+	if err := dropBoardPartitions(q, oldName); err != nil {
+		return err
+	}
+	if _, err := q.Exec(`DELETE FROM boards WHERE short_name = $1`, oldName); err != nil {
+		return fmt.Errorf("failed to delete board metadata for '%s': %w", oldName, err)
+	}
+
+	// Created last, so the view is filled with the copied threads.
+	return s.createBoardView(q, newName)
+}
+
+// copyBoardRows inserts the rows of source whose column equals oldName into
+// target, with the column set to newName. Going through a temporary table
+// keeps every other column as is without listing them.
+func copyBoardRows(q Querier, source, target, column string, oldName, newName domain.BoardShortName) error {
+	queries := []string{
+		fmt.Sprintf(`CREATE TEMP TABLE board_rename_rows AS SELECT * FROM %s WHERE %s = %s`, source, column, pq.QuoteLiteral(oldName)),
+		fmt.Sprintf(`UPDATE board_rename_rows SET %s = %s`, column, pq.QuoteLiteral(newName)),
+		fmt.Sprintf(`INSERT INTO %s SELECT * FROM board_rename_rows`, target),
+		`DROP TABLE board_rename_rows`,
+	}
+	for _, query := range queries {
+		if _, err := q.Exec(query); err != nil {
+			return fmt.Errorf("failed to copy %s of board '%s': %w", source, oldName, err)
+		}
+	}
+	return nil
+}
+
+// renameMediaPaths points stored file, thumbnail, banner and snapshot paths
+// under the old board directory to the new one.
+func renameMediaPaths(q Querier, oldName, newName domain.BoardShortName) error {
+	for _, t := range []struct{ table, column string }{
+		{"files", "file_path"},
+		{"files", "thumbnail_path"},
+		{"board_banners", "file_path"},
+		{"thread_snapshots", "file_path"},
+	} {
+		query := fmt.Sprintf(`
+			UPDATE %[1]s SET %[2]s = $2::text || substr(%[2]s, length($1::text) + 1)
+			WHERE substr(%[2]s, 1, length($1::text) + 1) = $1::text || '/'`,
+			t.table, t.column,
+		)
+		if _, err := q.Exec(query, oldName, newName); err != nil {
+			return fmt.Errorf("failed to update %s.%s of board '%s': %w", t.table, t.column, oldName, err)
+		}
+	}
+	return nil
+}
+
+func getBoardRedirects(q Querier) (map[string]string, error) {
+	rows, err := q.Query(`
+		SELECT old_short_name, new_short_name
+		FROM board_redirects
+		WHERE expires_at > NOW() AT TIME ZONE 'utc'`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query board redirects: %w", err)
+	}
+	defer rows.Close()
+
+	redirects := make(map[string]string)
+	for rows.Next() {
+		var oldName, newName string
+		if err := rows.Scan(&oldName, &newName); err != nil {
+			return nil, fmt.Errorf("failed to scan board redirect: %w", err)
+		}
+		redirects[oldName] = newName
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating board redirects: %w", err)
+	}
+	return redirects, nil
+}
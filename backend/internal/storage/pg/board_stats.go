@@ -0,0 +1,173 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.BoardStatsStorage interface)
+// =========================================================================
+
+// RollupPostCounts recounts the posts of every board in the hours within
+// [from, until), replacing what was stored for them.
+func (s *Storage) RollupPostCounts(ctx context.Context, from, until time.Time) error {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return rollupPostCounts(tx, from, until)
+	})
+}
+
+// GetLatestPostCountHour returns the latest hour with stored post counts, or
+// nil before the first rollup.
+func (s *Storage) GetLatestPostCountHour(ctx context.Context) (*time.Time, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var latest sql.NullTime
+	if err := q.QueryRow(`SELECT max(hour) FROM board_post_counts`).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to get latest post count hour: %w", err)
+	}
+	if !latest.Valid {
+		return nil, nil
+	}
+	return &latest.Time, nil
+}
+
+// DeletePostCountsBefore drops the post counts of hours before the given time.
+func (s *Storage) DeletePostCountsBefore(ctx context.Context, before time.Time) (int64, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	result, err := q.Exec(`DELETE FROM board_post_counts WHERE hour < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old post counts: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected, nil
+}
+
+// GetPostCounts returns the stored post counts from since on, ordered by
+// board and hour. An empty board returns the counts of all boards.
+func (s *Storage) GetPostCounts(ctx context.Context, board domain.BoardShortName, since time.Time) ([]domain.HourlyPostCount, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return getPostCounts(q, board, since)
+}
+
+// GetTopPosters ranks the users who posted on board from since on by post
+// count, up to limit of them.
+func (s *Storage) GetTopPosters(ctx context.Context, board domain.BoardShortName, since time.Time, limit int) ([]domain.PosterActivity, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return getTopPosters(q, board, since, limit)
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// =========================================================================
+
+func rollupPostCounts(q Querier, from, until time.Time) error {
+	if _, err := q.Exec(
+		`DELETE FROM board_post_counts WHERE hour >= $1 AND hour < $2`,
+		from.UTC(), until.UTC(),
+	); err != nil {
+		return fmt.Errorf("failed to clear post counts: %w", err)
+	}
+	_, err := q.Exec(`
+		INSERT INTO board_post_counts (board, hour, posts)
+		SELECT board, date_trunc('hour', created_at), count(*)
+		FROM messages
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY 1, 2`,
+		from.UTC(), until.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to roll up post counts: %w", err)
+	}
+	return nil
+}
+
+func getPostCounts(q Querier, board domain.BoardShortName, since time.Time) ([]domain.HourlyPostCount, error) {
+	rows, err := q.Query(`
+		SELECT board, hour, posts
+		FROM board_post_counts
+		WHERE hour >= $1 AND ($2 = '' OR board = $2)
+		ORDER BY board, hour`,
+		since.UTC(), board,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []domain.HourlyPostCount
+	for rows.Next() {
+		var c domain.HourlyPostCount
+		if err := rows.Scan(&c.Board, &c.Hour, &c.Posts); err != nil {
+			return nil, fmt.Errorf("failed to scan post count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post counts: %w", err)
+	}
+	return counts, nil
+}
+
+func getTopPosters(q Querier, board domain.BoardShortName, since time.Time, limit int) ([]domain.PosterActivity, error) {
+	rows, err := q.Query(`
+		WITH recent AS (
+			SELECT author_id, id, created_at
+			FROM messages
+			WHERE board = $1 AND created_at >= $2 AND author_id <> $4 -- not the system account
+		), bursts AS (
+			SELECT author_id, max(posts) AS burst
+			FROM (
+				SELECT author_id, count(*) AS posts
+				FROM recent
+				GROUP BY author_id, date_trunc('minute', created_at)
+			) per_minute
+			GROUP BY author_id
+		)
+		SELECT
+			r.author_id,
+			count(*),
+			count(*) FILTER (WHERE r.id = 1),
+			b.burst,
+			max(r.created_at),
+			EXISTS (SELECT 1 FROM user_shadowbans s WHERE s.board = $1 AND s.user_id = r.author_id)
+		FROM recent r
+		JOIN bursts b ON b.author_id = r.author_id
+		GROUP BY r.author_id, b.burst
+		ORDER BY count(*) DESC, b.burst DESC, r.author_id
+		LIMIT $3`,
+		board, since.UTC(), limit, domain.SystemUserId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top posters: %w", err)
+	}
+	defer rows.Close()
+
+	posters := []domain.PosterActivity{}
+	for rows.Next() {
+		var p domain.PosterActivity
+		if err := rows.Scan(&p.UserId, &p.Posts, &p.Threads, &p.Burst, &p.LastPostAt, &p.Shadowbanned); err != nil {
+			return nil, fmt.Errorf("failed to scan poster: %w", err)
+		}
+		posters = append(posters, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating posters: %w", err)
+	}
+	return posters, nil
+}
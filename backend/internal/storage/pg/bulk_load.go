@@ -0,0 +1,222 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+	sharedstorage "github.com/itchan-dev/itchan/shared/storage/pg"
+	"github.com/lib/pq"
+)
+
+// BulkLoadSpec describes the synthetic content BulkLoad writes into a board.
+type BulkLoadSpec struct {
+	Board             domain.BoardShortName
+	Threads           int
+	MessagesPerThread int             // Including the OP
+	Authors           []domain.UserId // Messages are attributed round-robin
+	Span              time.Duration   // Messages are spread over this period ending now
+	Seed              int64           // Seed for the generated texts
+}
+
+// BulkLoad fills an existing board with generated threads and messages using
+// COPY, which is orders of magnitude faster than posting one message at a time.
+// Rows are copied into the parent tables and routed to the board's partitions by
+// Postgres. Thread counters, bump times and board activity are set as if every
+// message had been posted through createMessage. It is meant for seeding
+// development databases and generating load for benchmarks, not for production data.
+// Returns the number of messages inserted.
+func (s *Storage) BulkLoad(ctx context.Context, spec BulkLoadSpec) (int, error) {
+	if spec.Threads <= 0 || spec.MessagesPerThread <= 0 {
+		return 0, fmt.Errorf("bulk load needs at least one thread and one message per thread")
+	}
+	if len(spec.Authors) == 0 {
+		return 0, fmt.Errorf("bulk load needs at least one author")
+	}
+
+	start := time.Now()
+	var inserted int
+	err := sharedstorage.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		var err error
+		inserted, err = s.bulkLoad(ctx, tx, spec)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	logger.Log.Info("bulk load finished", "board", spec.Board, "threads", spec.Threads,
+		"messages", inserted, "duration", time.Since(start))
+	return inserted, nil
+}
+
+// bulkLoad contains the core logic of BulkLoad. COPY requires a transaction,
+// so unlike other internal methods it takes *sql.Tx instead of a Querier.
+func (s *Storage) bulkLoad(ctx context.Context, tx *sql.Tx, spec BulkLoadSpec) (int, error) {
+	q := sharedstorage.WithContext(ctx, tx)
+	ids, err := s.reserveThreadIds(q, spec.Board, spec.Threads)
+	if err != nil {
+		return 0, err
+	}
+
+	// Threads start evenly over the span and their messages follow at a fixed
+	// step, so the last message of the newest thread lands right before now.
+	now := time.Now().UTC().Round(time.Microsecond)
+	origin := now.Add(-spec.Span)
+	threadStep := spec.Span / time.Duration(spec.Threads)
+	msgStep := threadStep / time.Duration(spec.MessagesPerThread)
+	msgTime := func(thread, msg int) time.Time {
+		return origin.Add(time.Duration(thread)*threadStep + time.Duration(msg)*msgStep)
+	}
+	// A message bumps while the thread has at most BumpLimit messages before it.
+	lastBump := spec.MessagesPerThread - 1
+	if lastBump > s.cfg.Public.BumpLimit {
+		lastBump = s.cfg.Public.BumpLimit
+	}
+
+	err = copyRows(ctx, tx, "threads", []string{
+		"id", "title", "board", "message_count", "next_message_id",
+		"last_bumped_at", "last_modified_at", "created_at", "is_pinned",
+	}, func(add func(...interface{}) error) error {
+		for i, id := range ids {
+			err := add(id, fmt.Sprintf("Seeded thread #%d", i+1), spec.Board,
+				spec.MessagesPerThread, spec.MessagesPerThread+1,
+				msgTime(i, lastBump), msgTime(i, spec.MessagesPerThread-1), msgTime(i, 0), false)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Messages are added in creation order, so their post numbers follow it too.
+	var firstPostNumber domain.PostNumber
+	err = q.QueryRow(`
+		UPDATE boards SET next_post_number = next_post_number + $2
+		WHERE short_name = $1
+		RETURNING next_post_number - $2`,
+		spec.Board, spec.Threads*spec.MessagesPerThread,
+	).Scan(&firstPostNumber)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve post numbers: %w", err)
+	}
+
+	rnd := rand.New(rand.NewSource(spec.Seed))
+	inserted := 0
+	err = copyRows(ctx, tx, "messages", []string{
+		"id", "board", "thread_id", "author_id", "text", "show_email_domain", "created_at", "updated_at", "post_number",
+	}, func(add func(...interface{}) error) error {
+		for i, id := range ids {
+			for m := 0; m < spec.MessagesPerThread; m++ {
+				createdAt := msgTime(i, m)
+				author := spec.Authors[inserted%len(spec.Authors)]
+				postNumber := firstPostNumber + domain.PostNumber(inserted)
+				if err := add(m+1, spec.Board, id, author, seedText(rnd), false, createdAt, createdAt, postNumber); err != nil {
+					return err
+				}
+				inserted++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = q.Exec(`
+		UPDATE boards SET last_activity_at = GREATEST(last_activity_at, $1)
+		WHERE short_name = $2`,
+		msgTime(spec.Threads-1, spec.MessagesPerThread-1), spec.Board,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update board activity: %w", err)
+	}
+
+	if s.cfg.Public.IncrementalBoardPreviews {
+		if err := s.rebuildBoardPreviews(q, spec.Board); err != nil {
+			return 0, err
+		}
+	} else if err := s.refreshMaterializedView(q, spec.Board); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+// reserveThreadIds takes n ids from the board's thread sequence, so threads
+// posted after the load continue the numbering.
+func (s *Storage) reserveThreadIds(q Querier, board domain.BoardShortName, n int) ([]domain.ThreadId, error) {
+	seqName := fmt.Sprintf("threads_id_seq_%s", board)
+	rows, err := q.Query(`SELECT nextval($1::regclass) FROM generate_series(1, $2)`, pq.QuoteIdentifier(seqName), n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve thread ids for board '%s': %w", board, err)
+	}
+	defer rows.Close()
+
+	ids := make([]domain.ThreadId, 0, n)
+	for rows.Next() {
+		var id domain.ThreadId
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan thread id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating thread ids: %w", err)
+	}
+	return ids, nil
+}
+
+// copyRows streams the rows produced by fill into table with COPY FROM STDIN.
+func copyRows(ctx context.Context, tx *sql.Tx, table string, columns []string, fill func(add func(...interface{}) error) error) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return fmt.Errorf("failed to start copy into %s: %w", table, err)
+	}
+	defer stmt.Close()
+
+	err = fill(func(values ...interface{}) error {
+		_, err := stmt.ExecContext(ctx, values...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy row into %s: %w", table, err)
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to finish copy into %s: %w", table, err)
+	}
+	return nil
+}
+
+var seedWords = strings.Fields(`lorem ipsum dolor sit amet consectetur adipiscing elit sed do
+	eiusmod tempor incididunt ut labore et dolore magna aliqua enim ad minim veniam quis nostrud
+	exercitation ullamco laboris nisi aliquip ex ea commodo consequat`)
+
+// seedText returns a message text of a few sentences of filler words.
+func seedText(rnd *rand.Rand) string {
+	var b strings.Builder
+	sentences := 1 + rnd.Intn(4)
+	for i := 0; i < sentences; i++ {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		words := 4 + rnd.Intn(12)
+		for w := 0; w < words; w++ {
+			word := seedWords[rnd.Intn(len(seedWords))]
+			if w == 0 {
+				word = strings.ToUpper(word[:1]) + word[1:]
+			} else {
+				b.WriteString(" ")
+			}
+			b.WriteString(word)
+		}
+		b.WriteString(".")
+	}
+	return b.String()
+}
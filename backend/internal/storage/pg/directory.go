@@ -0,0 +1,109 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/lib/pq"
+)
+
+// directoryDeactivationCondition matches the user_blacklist rows written by
+// directory sync: automatic bans without an expiry.
+const directoryDeactivationCondition = `blacklisted_by IS NULL AND expires_at IS NULL`
+
+// =========================================================================
+// Public Methods
+// =========================================================================
+
+// GetDirectoryAccounts lists the accounts whose email is in one of domains,
+// telling which of them directory sync has deactivated.
+func (s *Storage) GetDirectoryAccounts(ctx context.Context, domains []string) ([]domain.DirectoryAccount, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getDirectoryAccounts(q, domains)
+}
+
+// DeactivateUsers bans the users for good on behalf of directory sync. A
+// temporary ban is replaced; a ban by an admin stays as it is.
+func (s *Storage) DeactivateUsers(ctx context.Context, userIds []domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.deactivateUsers(tx, userIds)
+	})
+}
+
+// ReactivateUsers lifts the directory sync deactivations of the users. Other
+// bans are left alone.
+func (s *Storage) ReactivateUsers(ctx context.Context, userIds []domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.reactivateUsers(tx, userIds)
+	})
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+func (s *Storage) getDirectoryAccounts(q Querier, domains []string) ([]domain.DirectoryAccount, error) {
+	rows, err := q.Query(`
+		SELECT u.id, u.email_hash, COALESCE(u.is_admin, false),
+			EXISTS (SELECT 1 FROM user_blacklist ub WHERE ub.user_id = u.id AND `+directoryDeactivationCondition+`)
+		FROM users u
+		WHERE u.email_domain = ANY($1)
+		ORDER BY u.id`,
+		pq.Array(domains),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query directory accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []domain.DirectoryAccount{}
+	for rows.Next() {
+		var a domain.DirectoryAccount
+		if err := rows.Scan(&a.Id, &a.EmailHash, &a.Admin, &a.Deactivated); err != nil {
+			return nil, fmt.Errorf("failed to scan directory account: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating directory accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+func (s *Storage) deactivateUsers(q Querier, userIds []domain.UserId) error {
+	_, err := q.Exec(`
+		INSERT INTO user_blacklist (user_id, reason, blacklisted_by, blacklisted_at, expires_at)
+		SELECT id, $2, NULL, NOW() AT TIME ZONE 'utc', NULL FROM unnest($1::int[]) AS id
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			reason = EXCLUDED.reason,
+			blacklisted_at = EXCLUDED.blacklisted_at,
+			expires_at = NULL
+		WHERE user_blacklist.expires_at IS NOT NULL`,
+		pq.Array(userIds), domain.DirectoryDeactivationReason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate users: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) reactivateUsers(q Querier, userIds []domain.UserId) error {
+	_, err := q.Exec(`
+		DELETE FROM user_blacklist
+		WHERE user_id = ANY($1) AND `+directoryDeactivationCondition,
+		pq.Array(userIds),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reactivate users: %w", err)
+	}
+	return nil
+}
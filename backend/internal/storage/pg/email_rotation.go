@@ -0,0 +1,72 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// =========================================================================
+// Public Methods
+// =========================================================================
+
+// GetUserEmails returns the next batch of users after afterId by id, with
+// their encrypted email and email hash, for the encryption key rotation.
+func (s *Storage) GetUserEmails(ctx context.Context, afterId domain.UserId, limit int) ([]domain.User, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getUserEmails(q, afterId, limit)
+}
+
+// ReplaceEmailEncrypted stores a re-encrypted email. It reports false when the
+// stored ciphertext is no longer oldCiphertext, so a concurrent change is not
+// overwritten.
+func (s *Storage) ReplaceEmailEncrypted(ctx context.Context, userId domain.UserId, oldCiphertext, newCiphertext []byte) (bool, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.replaceEmailEncrypted(q, userId, oldCiphertext, newCiphertext)
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+func (s *Storage) getUserEmails(q Querier, afterId domain.UserId, limit int) ([]domain.User, error) {
+	rows, err := q.Query(
+		"SELECT id, email_encrypted, email_hash FROM users WHERE id > $1 ORDER BY id LIMIT $2",
+		afterId, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user emails: %w", err)
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	for rows.Next() {
+		var u domain.User
+		if err := rows.Scan(&u.Id, &u.EmailEncrypted, &u.EmailHash); err != nil {
+			return nil, fmt.Errorf("failed to scan user email: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user emails: %w", err)
+	}
+	return users, nil
+}
+
+func (s *Storage) replaceEmailEncrypted(q Querier, userId domain.UserId, oldCiphertext, newCiphertext []byte) (bool, error) {
+	result, err := q.Exec(
+		"UPDATE users SET email_encrypted = $1 WHERE id = $2 AND email_encrypted = $3",
+		newCiphertext, userId, oldCiphertext,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to replace encrypted email of user %d: %w", userId, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check affected rows for encrypted email: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
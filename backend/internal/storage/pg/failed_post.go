@@ -0,0 +1,107 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.FailedPostStorage interface)
+// =========================================================================
+
+// SaveFailedPost stores the snapshot of a failed post request.
+func (s *Storage) SaveFailedPost(ctx context.Context, post domain.FailedPost) (domain.FailedPostId, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.saveFailedPost(q, post)
+}
+
+// GetFailedPosts lists captured failed posts, newest first, for the admin panel.
+func (s *Storage) GetFailedPosts(ctx context.Context, limit, offset int) ([]domain.FailedPost, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getFailedPosts(q, limit, offset)
+}
+
+// DeleteExpiredFailedPosts drops captures made before the given time.
+func (s *Storage) DeleteExpiredFailedPosts(ctx context.Context, before time.Time) (int64, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.deleteExpiredFailedPosts(q, before)
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+func (s *Storage) saveFailedPost(q Querier, post domain.FailedPost) (domain.FailedPostId, error) {
+	request, err := json.Marshal(post.Request)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode failed post request: %w", err)
+	}
+	var id domain.FailedPostId
+	err = q.QueryRow(`
+		INSERT INTO failed_posts (user_id, board, thread_id, status_code, error, request, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		post.UserId, post.Board, post.ThreadId, post.StatusCode, post.Error, string(request), post.CreatedAt.UTC(),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save failed post of user %d: %w", post.UserId, err)
+	}
+	return id, nil
+}
+
+func (s *Storage) getFailedPosts(q Querier, limit, offset int) ([]domain.FailedPost, error) {
+	rows, err := q.Query(`
+		SELECT id, user_id, board, thread_id, status_code, error, request, created_at
+		FROM failed_posts
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query failed posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []domain.FailedPost{}
+	for rows.Next() {
+		var post domain.FailedPost
+		var threadId sql.NullInt64
+		var request []byte
+		if err := rows.Scan(&post.Id, &post.UserId, &post.Board, &threadId, &post.StatusCode, &post.Error, &request, &post.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan failed post: %w", err)
+		}
+		if err := json.Unmarshal(request, &post.Request); err != nil {
+			return nil, fmt.Errorf("failed to decode request of failed post %d: %w", post.Id, err)
+		}
+		if threadId.Valid {
+			id := domain.ThreadId(threadId.Int64)
+			post.ThreadId = &id
+		}
+		post.CreatedAt = post.CreatedAt.UTC()
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating failed posts: %w", err)
+	}
+	return posts, nil
+}
+
+func (s *Storage) deleteExpiredFailedPosts(q Querier, before time.Time) (int64, error) {
+	result, err := q.Exec(`DELETE FROM failed_posts WHERE created_at < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired failed posts: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected, nil
+}
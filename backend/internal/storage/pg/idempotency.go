@@ -0,0 +1,132 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.IdempotencyStorage interface)
+// =========================================================================
+
+// ReserveIdempotencyKey claims the user's key for a request. A row created
+// before expiredBefore, or one still in progress since before staleBefore (its
+// request never finished), is taken over. If the key is held, it returns
+// false with the row holding it.
+func (s *Storage) ReserveIdempotencyKey(ctx context.Context, key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	var existing domain.IdempotencyKey
+	var reserved bool
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		existing, reserved, err = s.reserveIdempotencyKey(tx, key, expiredBefore, staleBefore)
+		return err
+	})
+	return existing, reserved, err
+}
+
+// CompleteIdempotencyKey stores the response of the request holding the key.
+func (s *Storage) CompleteIdempotencyKey(ctx context.Context, userId domain.UserId, key string, statusCode int, response []byte) error {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.completeIdempotencyKey(q, userId, key, statusCode, response)
+}
+
+// DeleteIdempotencyKey frees the key, so the next request with it runs again.
+func (s *Storage) DeleteIdempotencyKey(ctx context.Context, userId domain.UserId, key string) error {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.deleteIdempotencyKey(q, userId, key)
+}
+
+// DeleteExpiredIdempotencyKeys drops keys created before the given time.
+func (s *Storage) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.deleteExpiredIdempotencyKeys(q, before)
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+func (s *Storage) reserveIdempotencyKey(q Querier, key domain.IdempotencyKey, expiredBefore, staleBefore time.Time) (domain.IdempotencyKey, bool, error) {
+	result, err := q.Exec(`
+		INSERT INTO idempotency_keys (user_id, key, request_path, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, key) DO UPDATE SET
+			request_path = EXCLUDED.request_path,
+			status_code = NULL,
+			response = NULL,
+			created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.created_at < $5
+		   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < $6)`,
+		key.UserId, key.Key, key.RequestPath, key.CreatedAt.UTC(), expiredBefore.UTC(), staleBefore.UTC(),
+	)
+	if err != nil {
+		return domain.IdempotencyKey{}, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return domain.IdempotencyKey{}, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected > 0 {
+		return domain.IdempotencyKey{}, true, nil
+	}
+
+	existing := domain.IdempotencyKey{UserId: key.UserId, Key: key.Key}
+	var statusCode sql.NullInt64
+	err = q.QueryRow(`
+		SELECT request_path, status_code, response, created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2`,
+		key.UserId, key.Key,
+	).Scan(&existing.RequestPath, &statusCode, &existing.Response, &existing.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Freed by its request between the two statements
+		return s.reserveIdempotencyKey(q, key, expiredBefore, staleBefore)
+	}
+	if err != nil {
+		return domain.IdempotencyKey{}, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	existing.StatusCode = int(statusCode.Int64)
+	return existing, false, nil
+}
+
+func (s *Storage) completeIdempotencyKey(q Querier, userId domain.UserId, key string, statusCode int, response []byte) error {
+	_, err := q.Exec(`
+		UPDATE idempotency_keys SET status_code = $3, response = $4
+		WHERE user_id = $1 AND key = $2`,
+		userId, key, statusCode, response,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) deleteIdempotencyKey(q Querier, userId domain.UserId, key string) error {
+	if _, err := q.Exec(`DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`, userId, key); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) deleteExpiredIdempotencyKeys(q Querier, before time.Time) (int64, error) {
+	result, err := q.Exec(`DELETE FROM idempotency_keys WHERE created_at < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected, nil
+}
@@ -110,6 +110,65 @@ func TestUserByEmailHash(t *testing.T) {
 	})
 }
 
+func TestUserByEmailIndex(t *testing.T) {
+	tx, rollback := beginTx(t)
+	defer rollback()
+
+	indexed := domain.User{
+		EmailEncrypted: []byte("encrypted_indexed@example.com"),
+		EmailDomain:    "example.com",
+		EmailHash:      []byte("hash_indexed@example.com"),
+		EmailIndex:     []byte("index_indexed@example.com"),
+		PassHash:       "test_pass_hash",
+	}
+	indexedId, err := storage.saveUser(tx, indexed)
+	require.NoError(t, err)
+
+	legacy := domain.User{
+		EmailEncrypted: []byte("encrypted_legacy@example.com"),
+		EmailDomain:    "example.com",
+		EmailHash:      []byte("hash_legacy@example.com"),
+		PassHash:       "test_pass_hash",
+	}
+	legacyId, err := storage.saveUser(tx, legacy)
+	require.NoError(t, err)
+
+	t.Run("find indexed user", func(t *testing.T) {
+		found, err := storage.userByEmailIndex(tx, indexed.EmailIndex)
+		require.NoError(t, err)
+		assert.Equal(t, indexedId, found.Id)
+		assert.Equal(t, indexed.EmailDomain, found.EmailDomain)
+	})
+
+	t.Run("user saved without index has none", func(t *testing.T) {
+		found, err := storage.user(tx, legacy.EmailHash)
+		require.NoError(t, err)
+		assert.Nil(t, found.EmailIndex)
+	})
+
+	t.Run("backfilled index is found", func(t *testing.T) {
+		emailIndex := []byte("index_legacy@example.com")
+		require.NoError(t, storage.setEmailIndex(tx, legacyId, emailIndex))
+
+		found, err := storage.userByEmailIndex(tx, emailIndex)
+		require.NoError(t, err)
+		assert.Equal(t, legacyId, found.Id)
+	})
+
+	t.Run("existing index is not overwritten", func(t *testing.T) {
+		require.NoError(t, storage.setEmailIndex(tx, indexedId, []byte("index_other@example.com")))
+
+		found, err := storage.user(tx, indexed.EmailHash)
+		require.NoError(t, err)
+		assert.Equal(t, indexed.EmailIndex, found.EmailIndex)
+	})
+
+	t.Run("unknown index returns 404 error", func(t *testing.T) {
+		_, err := storage.userByEmailIndex(tx, []byte("index_nonexistent@example.com"))
+		requireNotFoundError(t, err)
+	})
+}
+
 func TestUpdatePassword(t *testing.T) {
 	tx, rollback := beginTx(t)
 	defer rollback()
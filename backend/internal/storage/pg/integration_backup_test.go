@@ -0,0 +1,67 @@
+package pg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRuns(t *testing.T) {
+	tx, rollback := beginTx(t)
+	defer rollback()
+
+	_, err := storage.getLastSuccessfulBackup(tx)
+	requireNotFoundError(t, err)
+	requireNotFoundError(t, storage.finishBackupRun(tx, domain.BackupRun{Id: 999999, Status: domain.BackupFailed}))
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	t.Run("add and finish", func(t *testing.T) {
+		id, err := storage.addBackupRun(tx, now.Add(-2*time.Hour))
+		require.NoError(t, err)
+
+		runs, err := storage.getBackupRuns(tx, 10)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, domain.BackupRunning, runs[0].Status)
+		assert.Nil(t, runs[0].FinishedAt)
+
+		finishedAt := now.Add(-time.Hour)
+		require.NoError(t, storage.finishBackupRun(tx, domain.BackupRun{
+			Id: id, Status: domain.BackupSucceeded, FinishedAt: &finishedAt, PausedMs: 1500,
+		}))
+
+		got, err := storage.getLastSuccessfulBackup(tx)
+		require.NoError(t, err)
+		assert.Equal(t, id, got.Id)
+		assert.True(t, now.Add(-2*time.Hour).Equal(got.StartedAt))
+		require.NotNil(t, got.FinishedAt)
+		assert.True(t, finishedAt.Equal(*got.FinishedAt))
+		assert.Equal(t, int64(1500), got.PausedMs)
+	})
+
+	t.Run("failed runs are not successful", func(t *testing.T) {
+		id, err := storage.addBackupRun(tx, now)
+		require.NoError(t, err)
+		require.NoError(t, storage.finishBackupRun(tx, domain.BackupRun{
+			Id: id, Status: domain.BackupFailed, FinishedAt: &now, Error: "pg_dump: connection refused",
+		}))
+
+		got, err := storage.getLastSuccessfulBackup(tx)
+		require.NoError(t, err)
+		assert.NotEqual(t, id, got.Id)
+
+		runs, err := storage.getBackupRuns(tx, 10)
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		assert.Equal(t, id, runs[0].Id, "newest first")
+		assert.Equal(t, "pg_dump: connection refused", runs[0].Error)
+
+		runs, err = storage.getBackupRuns(tx, 1)
+		require.NoError(t, err)
+		assert.Len(t, runs, 1)
+	})
+}
@@ -276,3 +276,67 @@ func TestCascadeDeleteBlacklist(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 0, count)
 }
+
+func TestBlacklistUserUntil(t *testing.T) {
+	tx, rollback := beginTx(t)
+	defer rollback()
+
+	adminId := createTestUser(t, tx, "admin@test.com")
+	now := time.Now().UTC()
+
+	t.Run("temporary ban is active until it expires", func(t *testing.T) {
+		userId := createTestUser(t, tx, "temp@test.com")
+		require.NoError(t, storage.blacklistUserUntil(tx, userId, "Automatic ban", now.Add(time.Hour)))
+
+		isBlacklisted, err := storage.isUserBlacklisted(tx, userId)
+		require.NoError(t, err)
+		assert.True(t, isBlacklisted)
+
+		entries, err := storage.getBlacklistedUsersWithDetails(tx, 100, 0)
+		require.NoError(t, err)
+		var entry *domain.BlacklistEntry
+		for i := range entries {
+			if entries[i].UserId == userId {
+				entry = &entries[i]
+			}
+		}
+		require.NotNil(t, entry)
+		assert.Equal(t, domain.UserId(0), entry.BlacklistedBy, "automatic bans have no moderator")
+		require.NotNil(t, entry.ExpiresAt)
+	})
+
+	t.Run("expired ban is ignored", func(t *testing.T) {
+		userId := createTestUser(t, tx, "expired@test.com")
+		require.NoError(t, storage.blacklistUserUntil(tx, userId, "Automatic ban", now.Add(-time.Minute)))
+
+		isBlacklisted, err := storage.isUserBlacklisted(tx, userId)
+		require.NoError(t, err)
+		assert.False(t, isBlacklisted)
+
+		recent, err := storage.getRecentlyBlacklistedUsers(tx, now.Add(-time.Hour))
+		require.NoError(t, err)
+		assert.NotContains(t, recent, userId)
+	})
+
+	t.Run("does not replace a permanent ban", func(t *testing.T) {
+		userId := createTestUser(t, tx, "permanent@test.com")
+		require.NoError(t, storage.blacklistUser(tx, userId, "Manual", adminId))
+		require.NoError(t, storage.blacklistUserUntil(tx, userId, "Automatic ban", now.Add(time.Hour)))
+
+		var reason string
+		var expiresAt *time.Time
+		require.NoError(t, tx.QueryRow("SELECT reason, expires_at FROM user_blacklist WHERE user_id = $1", userId).Scan(&reason, &expiresAt))
+		assert.Equal(t, "Manual", reason)
+		assert.Nil(t, expiresAt)
+	})
+
+	t.Run("manual ban makes a temporary ban permanent", func(t *testing.T) {
+		userId := createTestUser(t, tx, "upgrade@test.com")
+		require.NoError(t, storage.blacklistUserUntil(tx, userId, "Automatic ban", now.Add(time.Hour)))
+		require.NoError(t, storage.blacklistUser(tx, userId, "Manual", adminId))
+
+		var expiresAt *time.Time
+		require.NoError(t, tx.QueryRow("SELECT expires_at FROM user_blacklist WHERE user_id = $1", userId).Scan(&expiresAt))
+		assert.Nil(t, expiresAt)
+	})
+}
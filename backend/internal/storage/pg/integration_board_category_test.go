@@ -0,0 +1,89 @@
+//go:build !polluting
+
+package pg
+
+import (
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBoardCategoryOperations verifies category CRUD and board assignment.
+// Uses transactional testing for complete isolation.
+func TestBoardCategoryOperations(t *testing.T) {
+	t.Run("create, list and reorder", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		second, err := storage.createBoardCategory(tx, domain.BoardCategoryCreationData{Name: "cat_" + generateString(t), Position: 2})
+		require.NoError(t, err)
+		first, err := storage.createBoardCategory(tx, domain.BoardCategoryCreationData{Name: "cat_" + generateString(t), Position: 1})
+		require.NoError(t, err)
+
+		categories, err := storage.getBoardCategories(tx)
+		require.NoError(t, err)
+		var ids []domain.BoardCategoryId
+		for _, c := range categories {
+			if c.Id == first || c.Id == second {
+				ids = append(ids, c.Id)
+			}
+		}
+		assert.Equal(t, []domain.BoardCategoryId{first, second}, ids)
+
+		require.NoError(t, storage.updateBoardCategory(tx, first, domain.BoardCategoryCreationData{Name: "renamed_" + generateString(t), Position: 3}))
+		categories, err = storage.getBoardCategories(tx)
+		require.NoError(t, err)
+		ids = nil
+		for _, c := range categories {
+			if c.Id == first || c.Id == second {
+				ids = append(ids, c.Id)
+			}
+		}
+		assert.Equal(t, []domain.BoardCategoryId{second, first}, ids)
+	})
+
+	t.Run("duplicate name conflicts", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		name := "dup_" + generateString(t)
+		_, err := storage.createBoardCategory(tx, domain.BoardCategoryCreationData{Name: name})
+		require.NoError(t, err)
+		_, err = storage.createBoardCategory(tx, domain.BoardCategoryCreationData{Name: name})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already exists")
+	})
+
+	t.Run("assign board and delete category", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		categoryId, err := storage.createBoardCategory(tx, domain.BoardCategoryCreationData{Name: "cat_" + generateString(t)})
+		require.NoError(t, err)
+
+		require.NoError(t, storage.setBoardCategory(tx, boardShortName, &categoryId, 5))
+		board, err := storage.getBoard(tx, boardShortName, 1)
+		require.NoError(t, err)
+		require.NotNil(t, board.CategoryId)
+		assert.Equal(t, categoryId, *board.CategoryId)
+		assert.Equal(t, 5, board.Position)
+
+		require.NoError(t, storage.deleteBoardCategory(tx, categoryId))
+		board, err = storage.getBoard(tx, boardShortName, 1)
+		require.NoError(t, err)
+		assert.Nil(t, board.CategoryId, "board should become uncategorized")
+	})
+
+	t.Run("not found errors", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		requireNotFoundError(t, storage.deleteBoardCategory(tx, -1))
+		requireNotFoundError(t, storage.updateBoardCategory(tx, -1, domain.BoardCategoryCreationData{Name: "x"}))
+		requireNotFoundError(t, storage.setBoardCategory(tx, "nonexistent", nil, 0))
+	})
+}
@@ -0,0 +1,101 @@
+package pg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollupPostCounts(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	// Taken before posting, so a post crossing into the next hour still falls within the range
+	hour := time.Now().UTC().Truncate(time.Hour)
+	from, until := hour.Add(-time.Hour), hour.Add(2*time.Hour)
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Counted", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+	})
+
+	// Recounting the same hours replaces the counts instead of adding to them
+	for range 2 {
+		require.NoError(t, rollupPostCounts(tx, from, until))
+	}
+
+	counts, err := getPostCounts(tx, boardShortName, from)
+	require.NoError(t, err)
+	require.NotEmpty(t, counts)
+	posts := 0
+	for _, c := range counts {
+		assert.Equal(t, boardShortName, c.Board)
+		assert.True(t, c.Hour.Equal(c.Hour.Truncate(time.Hour)), "hour %s is not truncated", c.Hour)
+		assert.False(t, c.Hour.Before(hour), "hour %s is before the posts", c.Hour)
+		posts += c.Posts
+	}
+	assert.Equal(t, 2, posts)
+
+	counts, err = getPostCounts(tx, boardShortName, until)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
+
+func TestGetTopPosters(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	since := time.Now().UTC().Add(-time.Minute)
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	flooder := createTestUser(t, tx, generateString(t)+"@example.com")
+	regular := createTestUser(t, tx, generateString(t)+"@example.com")
+
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Flooded", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: flooder}, Text: "OP"},
+	})
+	for range 3 {
+		createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: flooder}, Text: "spam",
+		})
+	}
+	createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: regular}, Text: "reply",
+	})
+	require.NoError(t, storage.shadowbanUser(tx, boardShortName, flooder, regular))
+
+	posters, err := getTopPosters(tx, boardShortName, since, 10)
+	require.NoError(t, err)
+	require.Len(t, posters, 2)
+
+	top := posters[0]
+	assert.Equal(t, flooder, top.UserId)
+	assert.Equal(t, 4, top.Posts)
+	assert.Equal(t, 1, top.Threads)
+	assert.GreaterOrEqual(t, top.Burst, 2, "four posts within seconds span at most two clock minutes")
+	assert.WithinDuration(t, time.Now(), top.LastPostAt, time.Minute)
+	assert.True(t, top.Shadowbanned)
+
+	assert.Equal(t, regular, posters[1].UserId)
+	assert.Equal(t, 1, posters[1].Posts)
+	assert.Equal(t, 0, posters[1].Threads)
+	assert.False(t, posters[1].Shadowbanned)
+
+	posters, err = getTopPosters(tx, boardShortName, since, 1)
+	require.NoError(t, err)
+	assert.Len(t, posters, 1)
+
+	posters, err = getTopPosters(tx, boardShortName, time.Now().UTC().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, posters)
+}
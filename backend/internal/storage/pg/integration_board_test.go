@@ -5,10 +5,12 @@ package pg
 import (
 	"fmt"
 	"math/rand"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
 	sharedstorage "github.com/itchan-dev/itchan/shared/storage/pg"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -105,6 +107,20 @@ func TestBoardOperations(t *testing.T) {
 			assert.Equal(t, boardShortName, boardMetadata.BoardMetadata.ShortName)
 		})
 
+		t.Run("retrieves description set at creation", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardShortName := domain.BoardShortName(generateString(t))
+			require.NoError(t, storage.createBoard(tx, domain.BoardCreationData{
+				Name: "Described", ShortName: boardShortName, Description: "All about tests",
+			}))
+
+			board, err := storage.getBoard(tx, boardShortName, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "All about tests", board.Description)
+		})
+
 		t.Run("fails for non-existent board", func(t *testing.T) {
 			tx, cleanup := beginTx(t)
 			defer cleanup()
@@ -249,6 +265,126 @@ func TestBoardOperations(t *testing.T) {
 		})
 	})
 
+	// =========================================================================
+	// Test: ListBoards
+	// Verifies sorting, paging and stats of the paginated board listing.
+	// =========================================================================
+	t.Run("ListBoards", func(t *testing.T) {
+		t.Run("sorts and pages", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			for _, board := range []domain.BoardCreationData{
+				{Name: "Zulu", ShortName: "a"},
+				{Name: "Alpha", ShortName: "b"},
+				{Name: "Mike", ShortName: "c"},
+			} {
+				require.NoError(t, storage.createBoard(tx, board))
+			}
+			now := time.Now().UTC()
+			for shortName, activity := range map[string]time.Time{"a": now.Add(-time.Hour), "b": now, "c": now.Add(-2 * time.Hour)} {
+				_, err := tx.Exec("UPDATE boards SET last_activity_at = $1 WHERE short_name = $2", activity, shortName)
+				require.NoError(t, err)
+			}
+
+			shortNames := func(boards []domain.BoardMetadata) []domain.BoardShortName {
+				var names []domain.BoardShortName
+				for _, b := range boards {
+					names = append(names, b.ShortName)
+				}
+				return names
+			}
+
+			boards, total, err := storage.listBoards(tx, domain.BoardSortName, domain.BoardStatsNone, 10, 0)
+			require.NoError(t, err)
+			assert.Equal(t, 3, total)
+			assert.Equal(t, []domain.BoardShortName{"b", "c", "a"}, shortNames(boards))
+
+			boards, _, err = storage.listBoards(tx, domain.BoardSortActivity, domain.BoardStatsNone, 10, 0)
+			require.NoError(t, err)
+			assert.Equal(t, []domain.BoardShortName{"b", "a", "c"}, shortNames(boards))
+
+			boards, total, err = storage.listBoards(tx, domain.BoardSortPosition, domain.BoardStatsNone, 2, 2)
+			require.NoError(t, err)
+			assert.Equal(t, 3, total, "total counts all pages")
+			assert.Equal(t, []domain.BoardShortName{"c"}, shortNames(boards))
+			assert.Nil(t, boards[0].Stats, "stats are only loaded on request")
+
+			_, _, err = storage.listBoards(tx, "popularity", domain.BoardStatsNone, 10, 0)
+			assert.Error(t, err)
+		})
+
+		t.Run("includes stats", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			busy := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, busy)
+			empty := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, empty)
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Thread", Board: busy,
+				OpMessage: domain.MessageCreationData{Board: busy, Author: domain.User{Id: userID}, Text: "OP"},
+			})
+			createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Other", Board: busy,
+				OpMessage: domain.MessageCreationData{Board: busy, Author: domain.User{Id: userID}, Text: "OP"},
+			})
+			createTestMessage(t, tx, domain.MessageCreationData{
+				Board: busy, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+			})
+
+			boards, _, err := storage.listBoards(tx, domain.BoardSortPosition, domain.BoardStatsCounts, 100, 0)
+			require.NoError(t, err)
+			stats := make(map[domain.BoardShortName]domain.BoardStats)
+			for _, b := range boards {
+				require.NotNil(t, b.Stats)
+				stats[b.ShortName] = *b.Stats
+			}
+			assert.Equal(t, domain.BoardStats{ThreadCount: 2, MessageCount: 3}, stats[busy])
+			assert.Equal(t, domain.BoardStats{}, stats[empty])
+		})
+
+		t.Run("includes the latest thread", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			busy := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, busy)
+			empty := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, empty)
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			bumped, _ := createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Bumped", Board: busy,
+				OpMessage: domain.MessageCreationData{Board: busy, Author: domain.User{Id: userID}, Text: "OP"},
+			})
+			createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Older", Board: busy,
+				OpMessage: domain.MessageCreationData{Board: busy, Author: domain.User{Id: userID}, Text: "OP"},
+			})
+			lastBumped := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+			_, err := tx.Exec(`UPDATE threads SET last_bumped_at = $1 WHERE board = $2 AND id = $3`, lastBumped, busy, bumped)
+			require.NoError(t, err)
+
+			boards, _, err := storage.listBoards(tx, domain.BoardSortPosition, domain.BoardStatsLatest, 100, 0)
+			require.NoError(t, err)
+			stats := make(map[domain.BoardShortName]domain.BoardStats)
+			for _, b := range boards {
+				require.NotNil(t, b.Stats)
+				stats[b.ShortName] = *b.Stats
+			}
+			require.NotNil(t, stats[busy].LatestThread)
+			assert.Equal(t, bumped, stats[busy].LatestThread.Id)
+			assert.Equal(t, "Bumped", stats[busy].LatestThread.Title)
+			assert.WithinDuration(t, lastBumped, stats[busy].LatestThread.LastBumped, time.Second)
+			assert.Equal(t, 2, stats[busy].ThreadCount)
+			assert.Nil(t, stats[empty].LatestThread)
+		})
+	})
+
 	// =========================================================================
 	// Test: GetActiveBoards
 	// Verifies retrieval of recently active boards based on activity timestamp.
@@ -361,6 +497,111 @@ func TestBoardOperations(t *testing.T) {
 		})
 	})
 
+	// =========================================================================
+	// Test: BoardSettings
+	// Verifies thread creation requirements are stored and per-user threads counted.
+	// =========================================================================
+	t.Run("BoardSettings", func(t *testing.T) {
+		t.Run("round trip", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardShortName := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardShortName)
+
+			settings, err := storage.getBoardSettings(tx, boardShortName)
+			require.NoError(t, err)
+			assert.Equal(t, domain.BoardSettings{}, settings, "new boards have no requirements")
+
+			want := domain.BoardSettings{Description: "Board about things", Noindex: true, PublicModLog: true, SelfDeleteReplied: true, BumpLimitNotice: true, MinOpTextLength: 20, RequireOpAttachment: true, MaxThreadsPerUserPerDay: 3, VideoProfile: "720p", TextFilter: domain.TextFilterReject, Language: domain.LanguageJapanese, PostingEmailDomains: []string{"corp.com", "corp.org"}, PostingMinAccountAgeDays: 7, PreModeration: true, PremodTrustApprovals: 5, PremodTrustAccountAgeDays: 30}
+			maxAttachments := 2
+			want.UploadRules = domain.UploadRules{AllowDocuments: true, MaxAttachments: &maxAttachments, AllowedMimeTypes: []string{"image/jpeg", "image/png"}}
+			require.NoError(t, storage.updateBoardSettings(tx, boardShortName, want))
+
+			settings, err = storage.getBoardSettings(tx, boardShortName)
+			require.NoError(t, err)
+			assert.Equal(t, want, settings)
+
+			board, err := storage.getBoard(tx, boardShortName, 1)
+			require.NoError(t, err)
+			assert.Equal(t, want, board.BoardSettings)
+		})
+
+		t.Run("fails for non-existent board", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			_, err := storage.getBoardSettings(tx, "nonexistentboard")
+			requireNotFoundError(t, err)
+		})
+
+		t.Run("counts user threads", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardShortName := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardShortName)
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+			otherID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Mine", Board: boardShortName,
+				OpMessage: domain.MessageCreationData{Board: boardShortName, Author: domain.User{Id: userID}, Text: "OP"},
+			})
+			createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Other", Board: boardShortName,
+				OpMessage: domain.MessageCreationData{Board: boardShortName, Author: domain.User{Id: otherID}, Text: "OP"},
+			})
+			createTestMessage(t, tx, domain.MessageCreationData{
+				Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+			})
+
+			count, err := storage.countUserThreadsSince(tx, boardShortName, userID, time.Now().Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 1, count, "replies and other users' threads are not counted")
+
+			count, err = storage.countUserThreadsSince(tx, boardShortName, userID, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+		})
+
+		t.Run("threads inherit noindex", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			publicBoard := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, publicBoard)
+			restrictedBoard := domain.BoardShortName(generateString(t))
+			require.NoError(t, storage.createBoard(tx, domain.BoardCreationData{
+				Name: "Restricted", ShortName: restrictedBoard, AllowedEmails: &domain.Emails{"example.com"},
+			}))
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			threadIn := func(board domain.BoardShortName) domain.ThreadId {
+				id, _ := createTestThread(t, tx, domain.ThreadCreationData{
+					Title: "Thread", Board: board,
+					OpMessage: domain.MessageCreationData{Board: board, Author: domain.User{Id: userID}, Text: "OP"},
+				})
+				return id
+			}
+			publicThread := threadIn(publicBoard)
+			restrictedThread := threadIn(restrictedBoard)
+
+			thread, err := storage.getThread(tx, publicBoard, publicThread, 1)
+			require.NoError(t, err)
+			assert.False(t, thread.Noindex)
+
+			thread, err = storage.getThread(tx, restrictedBoard, restrictedThread, 1)
+			require.NoError(t, err)
+			assert.True(t, thread.Noindex, "email restricted boards are never indexed")
+
+			require.NoError(t, storage.updateBoardSettings(tx, publicBoard, domain.BoardSettings{Noindex: true}))
+			thread, err = storage.getThread(tx, publicBoard, publicThread, 1)
+			require.NoError(t, err)
+			assert.True(t, thread.Noindex)
+		})
+	})
+
 	// =========================================================================
 	// Test: ThreadsToDelete
 	// Verifies finding the IDs of the oldest non-pinned threads to delete.
@@ -523,6 +764,154 @@ func TestBoardOperations(t *testing.T) {
 
 // TestBoardViewWorkflow verifies board view operations with materialized view refresh.
 // Uses transactional testing with non-concurrent refresh to access uncommitted data.
+func TestBoardDeletionSchedule(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	listed := func() bool {
+		boards, err := storage.getBoards(tx)
+		require.NoError(t, err)
+		for _, b := range boards {
+			if b.ShortName == boardShortName {
+				return true
+			}
+		}
+		return false
+	}
+	require.True(t, listed())
+
+	deleteAfter := time.Now().UTC().Add(time.Hour).Round(time.Microsecond)
+	require.NoError(t, storage.scheduleBoardDeletion(tx, boardShortName, deleteAfter))
+	assert.False(t, listed(), "boards pending deletion are not listed")
+
+	pending, err := storage.getPendingBoardDeletions(tx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, boardShortName, pending[0].Board)
+	assert.True(t, deleteAfter.Equal(pending[0].DeleteAfter))
+
+	names, err := getBoardsPendingDeletion(tx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{boardShortName}, names)
+
+	err = storage.scheduleBoardDeletion(tx, boardShortName, deleteAfter)
+	var e *internal_errors.ErrorWithStatusCode
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, http.StatusConflict, e.StatusCode)
+	requireNotFoundError(t, storage.scheduleBoardDeletion(tx, domain.BoardShortName(generateString(t)), deleteAfter))
+
+	require.NoError(t, storage.cancelBoardDeletion(tx, boardShortName))
+	assert.True(t, listed(), "restored boards are listed again")
+	requireNotFoundError(t, storage.cancelBoardDeletion(tx, boardShortName))
+}
+
+func TestRenameBoard(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	oldName := domain.BoardShortName(generateString(t))
+	newName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, oldName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, opID := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Renamed", Board: oldName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	msgID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: oldName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+		ReplyTo: &domain.Replies{{To: opID, ToThreadId: threadID}},
+	})
+	attachments := getRandomAttachments(t)
+	attachments[0].File.FilePath = fmt.Sprintf("%s/%d/%s", oldName, threadID, attachments[0].File.Filename)
+	require.NoError(t, storage.addAttachments(tx, oldName, threadID, msgID, attachments))
+
+	redirectUntil := time.Now().UTC().Add(time.Hour)
+	require.NoError(t, storage.renameBoard(tx, oldName, newName, &redirectUntil))
+
+	_, err := storage.getBoard(tx, oldName, 1)
+	requireNotFoundError(t, err)
+	_, err = storage.getBoard(tx, newName, 1)
+	require.NoError(t, err)
+
+	thread, err := storage.getThread(tx, newName, threadID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", thread.Title)
+	assert.Len(t, thread.Messages, 2)
+
+	msg, err := storage.getMessage(tx, newName, threadID, msgID)
+	require.NoError(t, err)
+	require.Len(t, msg.Attachments, 2)
+	var paths []string
+	for _, a := range msg.Attachments {
+		paths = append(paths, a.File.FilePath)
+	}
+	assert.Contains(t, paths, fmt.Sprintf("%s/%d/%s", newName, threadID, attachments[0].File.Filename))
+	op, err := storage.getMessage(tx, newName, threadID, opID)
+	require.NoError(t, err)
+	assert.Len(t, op.Replies, 1)
+
+	// Thread ids keep counting from where the old board stopped.
+	nextThreadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "After rename", Board: newName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	assert.Greater(t, nextThreadID, threadID)
+
+	redirects, err := getBoardRedirects(tx)
+	require.NoError(t, err)
+	assert.Equal(t, newName, redirects[oldName])
+
+	requireNotFoundError(t, storage.renameBoard(tx, oldName, generateString(t), nil))
+
+	takenName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, takenName)
+	err = storage.renameBoard(tx, newName, takenName, nil)
+	var e *internal_errors.ErrorWithStatusCode
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, http.StatusConflict, e.StatusCode)
+
+	// A new board under the old name ends the redirect.
+	createTestBoard(t, tx, oldName)
+	redirects, err = getBoardRedirects(tx)
+	require.NoError(t, err)
+	assert.NotContains(t, redirects, oldName)
+}
+
+func TestGetDeletionReport(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "With files", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	msgID := createTestMessage(t, tx, domain.MessageCreationData{Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply"})
+	require.NoError(t, storage.addAttachments(tx, boardShortName, threadID, msgID, getRandomAttachments(t)))
+	createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Text only", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+
+	report, err := storage.getDeletionReport(tx, boardShortName, nil)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeletionReport{Threads: 2, Messages: 3, Attachments: 2, AttachmentBytes: 3072}, report)
+
+	report, err = storage.getDeletionReport(tx, boardShortName, &threadID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeletionReport{Threads: 1, Messages: 2, Attachments: 2, AttachmentBytes: 3072}, report)
+
+	missing := threadID + 1000
+	_, err = storage.getDeletionReport(tx, boardShortName, &missing)
+	requireNotFoundError(t, err)
+	_, err = storage.getDeletionReport(tx, domain.BoardShortName(generateString(t)), nil)
+	requireNotFoundError(t, err)
+}
+
 func TestBoardViewWorkflow(t *testing.T) {
 	t.Run("pagination and ordering", func(t *testing.T) {
 		tx, cleanup := beginTx(t)
@@ -0,0 +1,108 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookmarks(t *testing.T) {
+	ctx := context.Background()
+
+	// The public methods read outside of a transaction, so the data is committed.
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	otherBoard := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, otherBoard)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, otherBoard)) }()
+	user := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@bookmark.com")}
+	hidden := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@bookmark.com")}
+	require.NoError(t, storage.ShadowbanUser(ctx, board, hidden.Id, user.Id))
+
+	threadId, opId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Bookmarked", Board: board,
+		OpMessage: domain.MessageCreationData{Author: user, Text: "op"},
+	})
+	replyId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: user, Text: "reference"})
+	hiddenId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: hidden, Text: "hidden"})
+	otherThreadId, otherOpId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Elsewhere", Board: otherBoard,
+		OpMessage: domain.MessageCreationData{Author: user, Text: "other op"},
+	})
+
+	require.NoError(t, storage.AddBookmark(ctx, user.Id, board, threadId, opId))
+	require.NoError(t, storage.AddBookmark(ctx, user.Id, board, threadId, replyId))
+	require.NoError(t, storage.AddBookmark(ctx, user.Id, board, threadId, replyId), "bookmarking again is a no-op")
+	require.NoError(t, storage.AddBookmark(ctx, user.Id, board, threadId, hiddenId))
+	require.NoError(t, storage.AddBookmark(ctx, user.Id, otherBoard, otherThreadId, otherOpId))
+
+	t.Run("list", func(t *testing.T) {
+		bookmarks, total, err := storage.GetBookmarks(ctx, user.Id, []domain.BoardShortName{board, otherBoard}, 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total, "shadowbanned messages are hidden")
+		require.Len(t, bookmarks, 2)
+		assert.Equal(t, otherOpId, bookmarks[0].Message.Id)
+		assert.Equal(t, otherBoard, bookmarks[0].Message.Board)
+		assert.Equal(t, replyId, bookmarks[1].Message.Id)
+		assert.Equal(t, domain.ThreadTitle("Bookmarked"), bookmarks[1].Message.ThreadTitle)
+		assert.Equal(t, domain.MsgText("reference"), bookmarks[1].Message.Text)
+		assert.Equal(t, 1, bookmarks[1].Message.Page)
+		assert.False(t, bookmarks[1].CreatedAt.IsZero())
+
+		bookmarks, total, err = storage.GetBookmarks(ctx, user.Id, []domain.BoardShortName{board}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, bookmarks, 2)
+		assert.Equal(t, opId, bookmarks[1].Message.Id)
+
+		bookmarks, total, err = storage.GetBookmarks(ctx, user.Id, nil, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.NotNil(t, bookmarks)
+		assert.Empty(t, bookmarks)
+
+		bookmarks, total, err = storage.GetBookmarks(ctx, hidden.Id, []domain.BoardShortName{board, otherBoard}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 0, total, "bookmarks are per user")
+		assert.Empty(t, bookmarks)
+	})
+
+	t.Run("own shadowbanned message", func(t *testing.T) {
+		require.NoError(t, storage.AddBookmark(ctx, hidden.Id, board, threadId, hiddenId))
+		bookmarks, total, err := storage.GetBookmarks(ctx, hidden.Id, []domain.BoardShortName{board}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, bookmarks, 1)
+		assert.Equal(t, hiddenId, bookmarks[0].Message.Id)
+	})
+
+	t.Run("missing message", func(t *testing.T) {
+		requireNotFoundError(t, storage.AddBookmark(ctx, user.Id, board, threadId, 9999))
+		requireNotFoundError(t, storage.AddBookmark(ctx, user.Id, otherBoard, threadId, replyId))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, storage.DeleteBookmark(ctx, user.Id, board, threadId, opId))
+		requireNotFoundError(t, storage.DeleteBookmark(ctx, user.Id, board, threadId, opId))
+		// Another user's bookmark
+		requireNotFoundError(t, storage.DeleteBookmark(ctx, hidden.Id, board, threadId, replyId))
+
+		_, total, err := storage.GetBookmarks(ctx, user.Id, []domain.BoardShortName{board}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+	})
+
+	t.Run("deleted message", func(t *testing.T) {
+		require.NoError(t, storage.DeleteMessage(ctx, board, threadId, replyId, domain.MessageDeletionData{}))
+
+		_, total, err := storage.GetBookmarks(ctx, user.Id, []domain.BoardShortName{board}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 0, total)
+		// The bookmark is removed with the message
+		requireNotFoundError(t, storage.DeleteBookmark(ctx, user.Id, board, threadId, replyId))
+	})
+}
@@ -0,0 +1,75 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkLoad(t *testing.T) {
+	tx, rollback := beginTx(t)
+	defer rollback()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, board)
+	authors := []domain.UserId{
+		createTestUser(t, tx, generateString(t)+"@test.com"),
+		createTestUser(t, tx, generateString(t)+"@test.com"),
+	}
+
+	spec := BulkLoadSpec{
+		Board:             board,
+		Threads:           5,
+		MessagesPerThread: storage.cfg.Public.BumpLimit + 5,
+		Authors:           authors,
+		Span:              time.Hour,
+		Seed:              1,
+	}
+	inserted, err := storage.bulkLoad(context.Background(), tx, spec)
+	require.NoError(t, err)
+	require.Equal(t, spec.Threads*spec.MessagesPerThread, inserted)
+
+	var messageCount int
+	require.NoError(t, tx.QueryRow(`SELECT count(*) FROM messages WHERE board = $1`, board).Scan(&messageCount))
+	require.Equal(t, inserted, messageCount)
+
+	t.Run("board page shows the newest threads", func(t *testing.T) {
+		b, err := storage.getBoard(tx, board, 1)
+		require.NoError(t, err)
+		require.Len(t, b.Threads, storage.cfg.Public.ThreadsPerPage)
+		require.Equal(t, domain.ThreadTitle("Seeded thread #5"), b.Threads[0].Title)
+		require.Equal(t, spec.MessagesPerThread, b.Threads[0].MessageCount)
+	})
+
+	t.Run("threads are stopped at the bump limit", func(t *testing.T) {
+		var lastBumped, bumpedBy time.Time
+		require.NoError(t, tx.QueryRow(`
+			SELECT t.last_bumped_at, m.created_at
+			FROM threads t
+			JOIN messages m ON m.board = t.board AND m.thread_id = t.id AND m.id = $2
+			WHERE t.board = $1
+			ORDER BY t.id LIMIT 1`,
+			board, storage.cfg.Public.BumpLimit+1,
+		).Scan(&lastBumped, &bumpedBy))
+		require.True(t, lastBumped.Equal(bumpedBy))
+	})
+
+	t.Run("posting continues the numbering", func(t *testing.T) {
+		var firstId, lastId domain.ThreadId
+		require.NoError(t, tx.QueryRow(`SELECT min(id), max(id) FROM threads WHERE board = $1`, board).Scan(&firstId, &lastId))
+
+		threadId, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "After load", Board: board,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: authors[0]}, Text: "op"},
+		})
+		require.Greater(t, threadId, lastId)
+
+		msgId := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: board, ThreadId: firstId, Author: domain.User{Id: authors[0]}, Text: "reply",
+		})
+		require.Equal(t, domain.MsgId(spec.MessagesPerThread+1), msgId)
+	})
+}
@@ -0,0 +1,63 @@
+package pg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanup(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("expired confirmation data", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		expired := domain.ConfirmationData{EmailHash: []byte("expired_hash"), PasswordHash: "hash", ConfirmationCodeHash: "code", Expires: now.Add(-time.Minute)}
+		pending := domain.ConfirmationData{EmailHash: []byte("pending_hash"), PasswordHash: "hash", ConfirmationCodeHash: "code", Expires: now.Add(time.Hour)}
+		require.NoError(t, storage.saveConfirmationData(tx, expired))
+		require.NoError(t, storage.saveConfirmationData(tx, pending))
+
+		deleted, err := storage.deleteExpiredConfirmationData(tx, now)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(1))
+
+		_, err = storage.confirmationData(tx, expired.EmailHash)
+		assert.Error(t, err)
+		_, err = storage.confirmationData(tx, pending.EmailHash)
+		assert.NoError(t, err)
+	})
+
+	t.Run("expired bans", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		expiredId := createTestUser(t, tx, "expired_ban@example.com")
+		activeId := createTestUser(t, tx, "active_ban@example.com")
+		permanentId := createTestUser(t, tx, "permanent_ban@example.com")
+		require.NoError(t, storage.blacklistUserUntil(tx, expiredId, "spam", now.Add(-time.Minute)))
+		require.NoError(t, storage.blacklistUserUntil(tx, activeId, "spam", now.Add(time.Hour)))
+		require.NoError(t, storage.blacklistUser(tx, permanentId, "spam", activeId))
+
+		deleted, err := storage.deleteExpiredBans(tx, now)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(1))
+
+		var remaining []domain.UserId
+		rows, err := tx.Query(`SELECT user_id FROM user_blacklist ORDER BY user_id`)
+		require.NoError(t, err)
+		defer rows.Close()
+		for rows.Next() {
+			var id domain.UserId
+			require.NoError(t, rows.Scan(&id))
+			remaining = append(remaining, id)
+		}
+		require.NoError(t, rows.Err())
+		assert.NotContains(t, remaining, expiredId)
+		assert.Contains(t, remaining, activeId)
+		assert.Contains(t, remaining, permanentId)
+	})
+}
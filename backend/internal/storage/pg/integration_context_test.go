@@ -0,0 +1,46 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCancellation(t *testing.T) {
+	t.Run("query timeout from config", func(t *testing.T) {
+		cfg := *storage.cfg
+		cfg.Public.QueryTimeout = 50 * time.Millisecond
+		s := &Storage{db: storage.db, cfg: &cfg}
+
+		q, cancel := s.conn(context.Background())
+		defer cancel()
+
+		start := time.Now()
+		_, err := q.Exec(`SELECT pg_sleep(5)`)
+		require.Error(t, err)
+		require.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("cancelled caller", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := storage.withTx(ctx, func(tx Querier) error {
+			_, err := tx.Exec(`SELECT pg_sleep(5)`)
+			return err
+		})
+		require.Error(t, err)
+		require.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("cancelled caller fails public methods", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := storage.GetBoards(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
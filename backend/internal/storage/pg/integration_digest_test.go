@@ -0,0 +1,204 @@
+package pg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThreadWatches(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	user := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@watch.com")}
+	threadId, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Watched", Board: board,
+		OpMessage: domain.MessageCreationData{Author: user, Text: "op"},
+	})
+
+	watching, err := storage.IsWatchingThread(ctx, user.Id, board, threadId)
+	require.NoError(t, err)
+	assert.False(t, watching)
+
+	require.NoError(t, storage.WatchThread(ctx, user.Id, board, threadId))
+	require.NoError(t, storage.WatchThread(ctx, user.Id, board, threadId), "watching again is a no-op")
+	watching, err = storage.IsWatchingThread(ctx, user.Id, board, threadId)
+	require.NoError(t, err)
+	assert.True(t, watching)
+
+	require.NoError(t, storage.UnwatchThread(ctx, user.Id, board, threadId))
+	watching, err = storage.IsWatchingThread(ctx, user.Id, board, threadId)
+	require.NoError(t, err)
+	assert.False(t, watching)
+
+	err = storage.WatchThread(ctx, user.Id, board, threadId+1000)
+	var e *internal_errors.ErrorWithStatusCode
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, http.StatusNotFound, e.StatusCode)
+}
+
+func TestDigestSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@digest.com")}
+	token := generateString(t)
+	subscribedAt := time.Now().Add(-2 * time.Hour)
+
+	frequency, err := storage.GetDigestFrequency(ctx, user.Id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DigestOff, frequency)
+
+	require.NoError(t, storage.SetDigestFrequency(ctx, user.Id, domain.DigestDaily, token, subscribedAt))
+	require.NoError(t, storage.SetDigestFrequency(ctx, user.Id, domain.DigestWeekly, "other-token", time.Now()), "only the frequency changes")
+	frequency, err = storage.GetDigestFrequency(ctx, user.Id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DigestWeekly, frequency)
+
+	due := func(dailyBefore, weeklyBefore time.Time) *domain.DigestSubscription {
+		subs, err := storage.GetDueDigestSubscriptions(ctx, dailyBefore, weeklyBefore)
+		require.NoError(t, err)
+		for _, sub := range subs {
+			if sub.UserId == user.Id {
+				return &sub
+			}
+		}
+		return nil
+	}
+	assert.Nil(t, due(time.Now(), time.Now().Add(-3*time.Hour)), "weekly subscription is not due yet")
+	sub := due(time.Now().Add(-time.Hour), time.Now())
+	require.NotNil(t, sub)
+	assert.Equal(t, token, sub.UnsubscribeToken)
+	assert.WithinDuration(t, subscribedAt, sub.LastSentAt, time.Millisecond)
+	assert.NotEmpty(t, sub.EmailEncrypted)
+
+	require.NoError(t, storage.MarkDigestSent(ctx, user.Id, time.Now()))
+	assert.Nil(t, due(time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+
+	t.Run("unsubscribe by token", func(t *testing.T) {
+		err := storage.DeleteDigestSubscriptionByToken(ctx, "other-token")
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusNotFound, e.StatusCode)
+
+		require.NoError(t, storage.DeleteDigestSubscriptionByToken(ctx, token))
+		frequency, err := storage.GetDigestFrequency(ctx, user.Id)
+		require.NoError(t, err)
+		assert.Equal(t, domain.DigestOff, frequency)
+		require.NoError(t, storage.DeleteDigestSubscription(ctx, user.Id), "unsubscribing twice is a no-op")
+	})
+}
+
+func TestGetDigest(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	user := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@digest.com")}
+	other := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@digest.com")}
+	hidden := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@digest.com")}
+	require.NoError(t, storage.ShadowbanUser(ctx, board, hidden.Id, user.Id))
+
+	watchedId, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Watched", Board: board,
+		OpMessage: domain.MessageCreationData{Author: other, Text: "op"},
+	})
+	ownId, ownOpId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Own", Board: board,
+		OpMessage: domain.MessageCreationData{Author: user, Text: "op"},
+	})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: watchedId, Author: other, Text: "before watching"})
+	require.NoError(t, storage.WatchThread(ctx, user.Id, board, watchedId))
+	since := time.Now()
+
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: watchedId, Author: other, Text: "new"})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: watchedId, Author: other, Text: "newer"})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: watchedId, Author: user, Text: "own"})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: watchedId, Author: hidden, Text: "hidden"})
+	replyTo := &domain.Replies{{To: ownOpId, ToThreadId: ownId}}
+	replyId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: ownId, Author: other, Text: "reply", ReplyTo: replyTo})
+
+	digest, err := storage.GetDigest(ctx, user.Id, since, 10)
+	require.NoError(t, err)
+	require.Len(t, digest.Threads, 1)
+	assert.Equal(t, domain.WatchedThreadActivity{Board: board, ThreadId: watchedId, Title: "Watched", NewReplies: 2}, digest.Threads[0])
+	require.Len(t, digest.Replies, 1)
+	assert.Equal(t, replyId, digest.Replies[0].Id)
+	assert.Equal(t, domain.ThreadTitle("Own"), digest.Replies[0].ThreadTitle)
+
+	require.NoError(t, storage.MarkAllNotificationsRead(ctx, user.Id))
+	digest, err = storage.GetDigest(ctx, user.Id, since, 10)
+	require.NoError(t, err)
+	assert.Empty(t, digest.Replies, "read replies are left out")
+
+	digest, err = storage.GetDigest(ctx, user.Id, time.Now(), 10)
+	require.NoError(t, err)
+	assert.True(t, digest.Empty())
+}
+
+func TestReadPositions(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	user := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@read.com")}
+	other := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@read.com")}
+	hidden := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@read.com")}
+	require.NoError(t, storage.ShadowbanUser(ctx, board, hidden.Id, user.Id))
+
+	threadId, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Watched", Board: board,
+		OpMessage: domain.MessageCreationData{Author: other, Text: "op"},
+	})
+	quietId, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Quiet", Board: board,
+		OpMessage: domain.MessageCreationData{Author: user, Text: "op"},
+	})
+	secondId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: other, Text: "second"})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: user, Text: "own"})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: hidden, Text: "hidden"})
+	lastId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: other, Text: "last"})
+	require.NoError(t, storage.WatchThread(ctx, user.Id, board, threadId))
+	require.NoError(t, storage.WatchThread(ctx, user.Id, board, quietId))
+
+	position, err := storage.GetReadPosition(ctx, user.Id, board, threadId)
+	require.NoError(t, err)
+	assert.Equal(t, domain.MsgId(0), position)
+
+	watched, err := storage.GetWatchedThreads(ctx, user.Id)
+	require.NoError(t, err)
+	require.Len(t, watched, 2)
+	assert.Equal(t, domain.WatchedThread{Board: board, ThreadId: threadId, Title: "Watched", Unread: 3, Page: 1}, watched[0], "own and hidden messages are not unread")
+	assert.Equal(t, quietId, watched[1].ThreadId)
+	assert.Zero(t, watched[1].Unread)
+
+	require.NoError(t, storage.SetReadPosition(ctx, user.Id, board, threadId, secondId))
+	require.NoError(t, storage.SetReadPosition(ctx, user.Id, board, threadId, 1), "an older position is ignored")
+	position, err = storage.GetReadPosition(ctx, user.Id, board, threadId)
+	require.NoError(t, err)
+	assert.Equal(t, secondId, position)
+
+	watched, err = storage.GetWatchedThreads(ctx, user.Id)
+	require.NoError(t, err)
+	require.Len(t, watched, 2)
+	assert.Equal(t, secondId, watched[0].LastReadId)
+	assert.Equal(t, 1, watched[0].Unread)
+
+	require.NoError(t, storage.SetReadPosition(ctx, user.Id, board, threadId, lastId))
+	watched, err = storage.GetWatchedThreads(ctx, user.Id)
+	require.NoError(t, err)
+	assert.Zero(t, watched[0].Unread)
+
+	err = storage.SetReadPosition(ctx, user.Id, board, threadId, lastId+1)
+	var e *internal_errors.ErrorWithStatusCode
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, http.StatusNotFound, e.StatusCode)
+}
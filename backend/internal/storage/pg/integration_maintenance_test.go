@@ -0,0 +1,54 @@
+package pg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintainBoards(t *testing.T) {
+	ctx := context.Background()
+	allOps := []MaintenanceOp{MaintenanceVacuum, MaintenanceAnalyze, MaintenanceReindex}
+
+	t.Run("runs every operation on partitions and view", func(t *testing.T) {
+		// VACUUM cannot run in a transaction, so the board is committed.
+		board := domain.BoardShortName(generateString(t))
+		require.NoError(t, storage.CreateBoard(ctx, domain.BoardCreationData{Name: "Maintenance", ShortName: board}))
+		defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+
+		results, err := storage.MaintainBoards(ctx, []domain.BoardShortName{board}, allOps, 5*time.Second)
+		require.NoError(t, err)
+		require.Len(t, results, (len(boardPartitionedTables)+1)*len(allOps))
+		for _, r := range results {
+			require.NoError(t, r.Err, "%s %s", r.Op, r.Relation)
+		}
+		require.Equal(t, PartitionName(board, "threads"), results[0].Relation)
+		require.Equal(t, ViewTableName(board), results[len(results)-1].Relation)
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		_, err := storage.MaintainBoards(ctx, nil, []MaintenanceOp{"cluster"}, time.Second)
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		require.Equal(t, http.StatusBadRequest, e.StatusCode)
+	})
+
+	t.Run("one run at a time", func(t *testing.T) {
+		conn, err := storage.db.Conn(ctx)
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, maintenanceLockKey)
+		require.NoError(t, err)
+		defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, maintenanceLockKey)
+
+		_, err = storage.MaintainBoards(ctx, nil, allOps, time.Second)
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		require.Equal(t, http.StatusConflict, e.StatusCode)
+	})
+}
@@ -0,0 +1,116 @@
+package pg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMediaLocations(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	author := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@media.com")}
+
+	threadId, opId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Media", Board: board,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+	})
+
+	fileHash := strings.Repeat("a1", 32)
+	thumbHash := strings.Repeat("b2", 32)
+	thumbPath := string(board) + "/thumb.jpg"
+	attachments := getRandomAttachments(t)
+	attachments[0].File.Sha256 = fileHash
+	attachments[0].File.ThumbnailSha256 = thumbHash
+	attachments[0].File.ThumbnailPath = &thumbPath
+	require.NoError(t, storage.addAttachments(storage.db, board, threadId, opId, attachments))
+
+	t.Run("file hash", func(t *testing.T) {
+		locations, err := storage.GetMediaLocations(ctx, fileHash)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.MediaLocation{{Board: board, FilePath: attachments[0].File.FilePath}}, locations)
+	})
+
+	t.Run("thumbnail hash", func(t *testing.T) {
+		locations, err := storage.GetMediaLocations(ctx, thumbHash)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.MediaLocation{{Board: board, FilePath: thumbPath}}, locations)
+	})
+
+	t.Run("unknown hash", func(t *testing.T) {
+		locations, err := storage.GetMediaLocations(ctx, strings.Repeat("c3", 32))
+		require.NoError(t, err)
+		assert.Empty(t, locations)
+	})
+
+	t.Run("hashes are returned with the message", func(t *testing.T) {
+		msg, err := storage.getMessage(storage.db, board, threadId, opId)
+		require.NoError(t, err)
+		require.Len(t, msg.Attachments, 2)
+		var hashed *domain.File
+		for _, a := range msg.Attachments {
+			if a.File.Sha256 != "" {
+				hashed = a.File
+			}
+		}
+		require.NotNil(t, hashed)
+		assert.Equal(t, fileHash, hashed.Sha256)
+		assert.Equal(t, thumbHash, hashed.ThumbnailSha256)
+	})
+}
+
+func TestGetMessageFileMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	author := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@media.com")}
+
+	threadId, opId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Metadata", Board: board,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+	})
+
+	attachments := getRandomAttachments(t)
+	attachments[1].File.StrippedMetadata = &domain.StrippedMetadata{CameraMake: "Canon", CameraModel: "EOS R5", HasGPS: true}
+	require.NoError(t, storage.addAttachments(storage.db, board, threadId, opId, attachments))
+	var metadataRows int
+	require.NoError(t, storage.db.QueryRow(`SELECT COUNT(*) FROM file_metadata`).Scan(&metadataRows))
+
+	t.Run("only files with metadata are reported", func(t *testing.T) {
+		reports, err := storage.GetMessageFileMetadata(ctx, board, threadId, opId)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.NotZero(t, reports[0].FileId)
+		assert.Equal(t, attachments[1].File.OriginalFilename, reports[0].OriginalFilename)
+		assert.Equal(t, *attachments[1].File.StrippedMetadata, reports[0].StrippedMetadata)
+	})
+
+	t.Run("message without attachments", func(t *testing.T) {
+		reports, err := storage.GetMessageFileMetadata(ctx, board, threadId, opId+1000)
+		require.NoError(t, err)
+		assert.Empty(t, reports)
+	})
+
+	t.Run("metadata is removed with the message", func(t *testing.T) {
+		require.NoError(t, storage.DeleteMessage(ctx, board, threadId, opId, domain.MessageDeletionData{}))
+
+		var count int
+		require.NoError(t, storage.db.QueryRow(`SELECT COUNT(*) FROM file_metadata`).Scan(&count))
+		assert.Equal(t, metadataRows-1, count)
+	})
+}
+
+func TestGetDatabaseSize(t *testing.T) {
+	size, err := storage.GetDatabaseSize(context.Background())
+	require.NoError(t, err)
+	assert.Positive(t, size)
+}
@@ -0,0 +1,84 @@
+package pg
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/require"
+)
+
+// seedEnrichmentBoard fills a board with threads whose messages each reply to
+// the one before and carry attachments, so page loads have replies and files
+// to enrich on every message.
+func seedEnrichmentBoard(b *testing.B, q Querier, board domain.BoardShortName, threads, messages int) []domain.ThreadId {
+	b.Helper()
+	userId, err := storage.saveUser(q, domain.User{
+		EmailEncrypted: []byte("encrypted_bench@example.com"),
+		EmailDomain:    "example.com",
+		EmailHash:      []byte("hash_bench@example.com"),
+		PassHash:       "test_hash",
+	})
+	require.NoError(b, err)
+	require.NoError(b, storage.createBoard(q, domain.BoardCreationData{Name: "Bench", ShortName: board}))
+
+	var ids []domain.ThreadId
+	for i := 0; i < threads; i++ {
+		threadId, createdAt, err := storage.createThread(q, domain.ThreadCreationData{Title: "bench", Board: board})
+		require.NoError(b, err)
+		ids = append(ids, threadId)
+
+		var prev domain.MsgId
+		for j := 0; j < messages; j++ {
+			data := domain.MessageCreationData{Board: board, ThreadId: threadId, Author: domain.User{Id: userId}, Text: "bench"}
+			if j == 0 {
+				data.CreatedAt = &createdAt
+			} else {
+				data.ReplyTo = &domain.Replies{{Board: board, FromThreadId: threadId, ToThreadId: threadId, To: prev}}
+			}
+			msgId, err := storage.createMessage(q, data)
+			require.NoError(b, err)
+
+			attachments := domain.Attachments{}
+			for k := 0; k < 2; k++ {
+				name := fmt.Sprintf("bench_%d_%d_%d", threadId, msgId, k)
+				attachments = append(attachments, &domain.Attachment{File: &domain.File{
+					FileCommonMetadata: domain.FileCommonMetadata{Filename: name, SizeBytes: 1024, MimeType: "image/jpeg"},
+					FilePath:           name,
+					OriginalFilename:   name,
+				}})
+			}
+			require.NoError(b, storage.addAttachments(q, board, threadId, msgId, attachments))
+			prev = msgId
+		}
+	}
+	return ids
+}
+
+func BenchmarkGetBoard(b *testing.B) {
+	tx, err := storage.db.Begin()
+	require.NoError(b, err)
+	defer tx.Rollback()
+	seedEnrichmentBoard(b, tx, "benchb", storage.cfg.Public.ThreadsPerPage, 20)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := storage.getBoard(tx, "benchb", 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetThread(b *testing.B) {
+	tx, err := storage.db.Begin()
+	require.NoError(b, err)
+	defer tx.Rollback()
+	ids := seedEnrichmentBoard(b, tx, "bencht", 1, storage.cfg.Public.MessagesPerThreadPage)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := storage.getThread(tx, "bencht", ids[0], 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
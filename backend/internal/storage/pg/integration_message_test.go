@@ -3,7 +3,9 @@
 package pg
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/stretchr/testify/assert"
@@ -265,6 +267,118 @@ func TestMessageOperations(t *testing.T) {
 		})
 	})
 
+	t.Run("DeletionStubs", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName("bstub")
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, "stubs@example.com")
+		threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Stub Test", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+		msgID := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID}, Text: "rule breaker", ThreadId: threadID,
+		})
+
+		require.NoError(t, storage.recordMessageDeletion(tx, boardShortName, threadID, msgID, domain.MessageDeletionData{Reason: "rule 3", DeletedBy: &userID}))
+		require.NoError(t, storage.deleteMessage(tx, boardShortName, threadID, msgID))
+
+		count, err := storage.countDeletedMessagesByAuthor(tx, userID, time.Now().UTC().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, count, "deletion is attributed to the message author")
+
+		thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		assert.Empty(t, thread.Deleted, "stubs are hidden until the board enables them")
+
+		require.NoError(t, storage.updateBoardSettings(tx, boardShortName, domain.BoardSettings{ShowDeletionStubs: true}))
+		thread, err = storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		require.Len(t, thread.Deleted, 1)
+		assert.Equal(t, msgID, thread.Deleted[0].Id)
+		assert.Equal(t, "rule 3", thread.Deleted[0].Reason)
+	})
+
+	t.Run("ModerationLog", func(t *testing.T) {
+		ctx := context.Background()
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, storage.db, boardShortName)
+		defer func() { require.NoError(t, storage.DeleteBoard(ctx, boardShortName)) }()
+		otherBoard := domain.BoardShortName(generateString(t))
+		createTestBoard(t, storage.db, otherBoard)
+		defer func() { require.NoError(t, storage.DeleteBoard(ctx, otherBoard)) }()
+
+		userID := createTestUser(t, storage.db, generateString(t)+"@modlog.com")
+		threadID, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+			Title: "Modlog Test", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+		msgID := createTestMessage(t, storage.db, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID}, Text: "rule breaker", ThreadId: threadID,
+		})
+
+		require.NoError(t, storage.DeleteMessage(ctx, boardShortName, threadID, msgID, domain.MessageDeletionData{Reason: "rule 3", DeletedBy: &userID}))
+		require.NoError(t, storage.RecordModeration(ctx, domain.ModLogEntry{Board: boardShortName, Action: domain.ModLogDeleteThread, Reason: "off-topic"}))
+		require.NoError(t, storage.RecordModeration(ctx, domain.ModLogEntry{Board: otherBoard, Action: domain.ModLogDeleteThread}))
+
+		entries, total, err := storage.GetModLog(ctx, boardShortName, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, domain.ModLogDeleteThread, entries[0].Action, "newest first")
+		assert.Equal(t, "off-topic", entries[0].Reason)
+		assert.Equal(t, domain.ModLogEntry{Board: boardShortName, Action: domain.ModLogDeleteMessage, Reason: "rule 3", CreatedAt: entries[1].CreatedAt}, entries[1])
+		assert.False(t, entries[1].CreatedAt.IsZero())
+
+		entries, total, err = storage.GetModLog(ctx, boardShortName, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, domain.ModLogDeleteMessage, entries[0].Action)
+
+		entries, _, err = storage.GetModLog(ctx, "nonexistent", 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("SelfDeletion", func(t *testing.T) {
+		ctx := context.Background()
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, storage.db, boardShortName)
+		defer func() { require.NoError(t, storage.DeleteBoard(ctx, boardShortName)) }()
+		require.NoError(t, storage.UpdateBoardSettings(ctx, boardShortName, domain.BoardSettings{ShowDeletionStubs: true}))
+
+		userID := createTestUser(t, storage.db, generateString(t)+"@selfdelete.com")
+		threadID, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+			Title: "Self Delete Test", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+		msgID := createTestMessage(t, storage.db, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID}, Text: "typo", ThreadId: threadID,
+		})
+
+		require.NoError(t, storage.DeleteMessage(ctx, boardShortName, threadID, msgID, domain.MessageDeletionData{DeletedBy: &userID, ByAuthor: true}))
+
+		var byAuthor bool
+		require.NoError(t, storage.db.QueryRow(`SELECT by_author FROM message_deletions WHERE board = $1 AND thread_id = $2 AND message_id = $3`,
+			boardShortName, threadID, msgID).Scan(&byAuthor))
+		assert.True(t, byAuthor, "self-deletion is kept for auditing")
+
+		count, err := storage.countDeletedMessagesByAuthor(storage.db, userID, time.Now().UTC().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, count, "self-deletions do not count towards auto-bans")
+
+		thread, err := storage.getThread(storage.db, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		assert.Empty(t, thread.Deleted, "no stub for self-deletions")
+
+		entries, _, err := storage.GetModLog(ctx, boardShortName, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "self-deletions are not moderation")
+	})
+
 	t.Run("AddAttachments", func(t *testing.T) {
 		tx, cleanup := beginTx(t)
 		defer cleanup()
@@ -352,6 +466,38 @@ func TestMessageOperations(t *testing.T) {
 			assert.Equal(t, "tech/1/image2.png", attachmentsAfter[1].File.FilePath)
 		})
 
+		t.Run("stores text previews", func(t *testing.T) {
+			msgID := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "Message with a text file",
+				ThreadId: threadID,
+			})
+
+			attachments := domain.Attachments{
+				&domain.Attachment{
+					File: &domain.File{
+						FileCommonMetadata: domain.FileCommonMetadata{
+							Filename:  "notes.txt",
+							SizeBytes: 12,
+							MimeType:  "text/plain",
+						},
+						FilePath:         "tech/1/notes.txt",
+						OriginalFilename: "notes.txt",
+						TextPreview:      "hello\nworld",
+					},
+				},
+				getRandomAttachments(t)[0],
+			}
+			require.NoError(t, storage.addAttachments(tx, boardShortName, threadID, msgID, attachments))
+
+			stored, err := storage.getMessageAttachments(tx, boardShortName, threadID, msgID)
+			require.NoError(t, err)
+			require.Len(t, stored, 2)
+			assert.Equal(t, "hello\nworld", stored[0].File.TextPreview)
+			assert.Empty(t, stored[1].File.TextPreview, "other files have no preview")
+		})
+
 		t.Run("can add more attachments to message that already has some", func(t *testing.T) {
 			// Create message with attachments
 			initialAttachments := getRandomAttachments(t)
@@ -553,8 +699,130 @@ func TestMessageOperations(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, 3, thread.MessageCount)
 	})
+
+	t.Run("GetLastMessageTime", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, "last_post@example.com")
+
+		last, err := storage.getLastMessageTime(tx, userID)
+		require.NoError(t, err)
+		assert.Nil(t, last, "user without messages has no last post")
+
+		createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Thread", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+
+		last, err = storage.getLastMessageTime(tx, userID)
+		require.NoError(t, err)
+		require.NotNil(t, last)
+		assert.WithinDuration(t, time.Now(), *last, time.Minute)
+	})
 }
 
 func intPtr(i int) *int {
 	return &i
 }
+
+// TestPostNumbers verifies that post numbers count messages across all
+// threads of a board and that replies carry the number of their sender.
+func TestPostNumbers(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	otherBoard := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, otherBoard)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+	newThread := func(board domain.BoardShortName) domain.ThreadId {
+		id, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Numbered", Board: board,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+		return id
+	}
+	first := newThread(boardShortName)
+	second := newThread(boardShortName)
+	newThread(otherBoard)
+	replyID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: first, Author: domain.User{Id: userID}, Text: "reply",
+		ReplyTo: &domain.Replies{{To: 1, ToThreadId: first}},
+	})
+
+	postNumber := func(threadID domain.ThreadId, msgID domain.MsgId) domain.PostNumber {
+		msg, err := storage.getMessage(tx, boardShortName, threadID, msgID)
+		require.NoError(t, err)
+		return msg.PostNumber
+	}
+	assert.Equal(t, domain.PostNumber(1), postNumber(first, 1))
+	assert.Equal(t, domain.PostNumber(2), postNumber(second, 1))
+	assert.Equal(t, domain.PostNumber(3), postNumber(first, replyID), "numbers are shared by all threads of the board")
+
+	op, err := storage.getMessage(tx, boardShortName, first, 1)
+	require.NoError(t, err)
+	require.Len(t, op.Replies, 1)
+	assert.Equal(t, domain.PostNumber(3), op.Replies[0].FromPostNumber)
+}
+
+func TestResolvePostNumber(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Resolved", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	replyID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+	})
+
+	gotThread, gotMsg, err := storage.resolvePostNumber(tx, boardShortName, 2)
+	require.NoError(t, err)
+	assert.Equal(t, threadID, gotThread)
+	assert.Equal(t, replyID, gotMsg)
+
+	_, _, err = storage.resolvePostNumber(tx, boardShortName, 99)
+	requireNotFoundError(t, err)
+}
+
+func TestGetExistingMessages(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Linked", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	replyID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+	})
+	deletedID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "deleted",
+	})
+	require.NoError(t, storage.deleteMessage(tx, boardShortName, threadID, deletedID))
+
+	existing, err := storage.getExistingMessages(tx, boardShortName, []domain.MessageLink{
+		{ThreadId: threadID, Id: 1},
+		{ThreadId: threadID, Id: replyID},
+		{ThreadId: threadID, Id: deletedID},
+		{ThreadId: threadID + 1000, Id: 1},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []domain.MessageLink{{ThreadId: threadID, Id: 1}, {ThreadId: threadID, Id: replyID}}, existing)
+
+	existing, err = storage.getExistingMessages(tx, boardShortName, nil)
+	require.NoError(t, err)
+	assert.Empty(t, existing)
+}
@@ -0,0 +1,176 @@
+package pg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoardMirrors(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	admin := createTestUser(t, storage.db, generateString(t)+"@mirror.com")
+
+	requireStatus := func(t *testing.T, err error, status int) {
+		t.Helper()
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, status, e.StatusCode)
+	}
+
+	t.Run("attach", func(t *testing.T) {
+		usedBoard := domain.BoardShortName(generateString(t))
+		createTestBoard(t, storage.db, usedBoard)
+		defer func() { require.NoError(t, storage.DeleteBoard(ctx, usedBoard)) }()
+		createTestThread(t, storage.db, domain.ThreadCreationData{
+			Title: "Local", Board: usedBoard,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: admin}, Text: "op"},
+		})
+
+		requireStatus(t, storage.CreateBoardMirror(ctx, domain.BoardMirror{Board: usedBoard, SourceURL: "https://source.example", SourceBoard: "src"}), http.StatusConflict)
+		requireNotFoundError(t, storage.CreateBoardMirror(ctx, domain.BoardMirror{Board: "nonexistent", SourceURL: "https://source.example", SourceBoard: "src"}))
+
+		require.NoError(t, storage.CreateBoardMirror(ctx, domain.BoardMirror{Board: board, SourceURL: "https://source.example", SourceBoard: "src", CreatedBy: admin}))
+		requireStatus(t, storage.CreateBoardMirror(ctx, domain.BoardMirror{Board: board, SourceURL: "https://other.example", SourceBoard: "src"}), http.StatusConflict)
+
+		mirror, err := storage.GetBoardMirror(ctx, board)
+		require.NoError(t, err)
+		assert.Equal(t, "https://source.example", mirror.SourceURL)
+		assert.Equal(t, domain.BoardShortName("src"), mirror.SourceBoard)
+		assert.Equal(t, admin, mirror.CreatedBy)
+		assert.Nil(t, mirror.LastSyncedAt)
+
+		settings, err := storage.GetBoardSettings(ctx, board)
+		require.NoError(t, err)
+		assert.True(t, settings.Mirror)
+	})
+
+	t.Run("sync result", func(t *testing.T) {
+		require.NoError(t, storage.RecordBoardMirrorSync(ctx, board, "source returned 500"))
+		mirror, err := storage.GetBoardMirror(ctx, board)
+		require.NoError(t, err)
+		assert.Equal(t, "source returned 500", mirror.LastError)
+		assert.Nil(t, mirror.LastSyncedAt)
+
+		require.NoError(t, storage.RecordBoardMirrorSync(ctx, board, ""))
+		mirrors, err := storage.GetBoardMirrors(ctx)
+		require.NoError(t, err)
+		var found bool
+		for _, m := range mirrors {
+			if m.Board == board {
+				found = true
+				assert.Empty(t, m.LastError)
+				assert.NotNil(t, m.LastSyncedAt)
+			}
+		}
+		assert.True(t, found)
+	})
+
+	created := time.Now().UTC().Add(-time.Hour).Round(time.Millisecond)
+	thread := domain.ThreadMetadata{Id: 40, Title: "Copied", LastBumped: created, LastModifiedAt: created}
+
+	t.Run("copy a new thread", func(t *testing.T) {
+		err := storage.ApplyMirrorThreadUpdate(ctx, domain.MirrorThreadUpdate{
+			Board:  board,
+			Thread: thread,
+			New: []domain.MirroredMessage{
+				{Id: 1, PostNumber: 100, Text: "op", CreatedAt: created},
+				{Id: 3, PostNumber: 105, Text: "reply", CreatedAt: created.Add(time.Minute)},
+			},
+			SourceIds: []domain.MsgId{1, 3},
+			Replies:   domain.Replies{{FromThreadId: 40, From: 3, ToThreadId: 40, To: 1, CreatedAt: created.Add(time.Minute)}},
+		})
+		require.NoError(t, err)
+
+		copied, err := storage.GetThread(ctx, board, 40, 1)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ThreadTitle("Copied"), copied.Title)
+		require.Len(t, copied.Messages, 2)
+		assert.Equal(t, domain.PostNumber(105), copied.Messages[1].PostNumber)
+		assert.Equal(t, domain.MirrorUserId, copied.Messages[1].Author.Id)
+		require.Len(t, copied.Messages[0].Replies, 1)
+		assert.Equal(t, domain.MsgId(3), copied.Messages[0].Replies[0].From)
+
+		tracked, err := storage.GetMirroredThreads(ctx, board)
+		require.NoError(t, err)
+		require.Len(t, tracked, 1)
+		assert.Equal(t, domain.MsgId(3), tracked[0].LastMessageId)
+		assert.False(t, tracked[0].Deleted)
+	})
+
+	t.Run("update a copied thread", func(t *testing.T) {
+		thread.Title = "Renamed"
+		thread.LastModifiedAt = created.Add(time.Hour)
+		err := storage.ApplyMirrorThreadUpdate(ctx, domain.MirrorThreadUpdate{
+			Board:     board,
+			Thread:    thread,
+			Edited:    []domain.MirroredMessage{{Id: 1, Text: "op edited"}},
+			New:       []domain.MirroredMessage{{Id: 4, PostNumber: 107, Text: "later", CreatedAt: created.Add(2 * time.Minute)}},
+			SourceIds: []domain.MsgId{1, 4},
+		})
+		require.NoError(t, err)
+
+		copied, err := storage.GetThread(ctx, board, 40, 1)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ThreadTitle("Renamed"), copied.Title)
+		require.Len(t, copied.Messages, 2, "message 3 was deleted on the source")
+		assert.Equal(t, "op edited", copied.Messages[0].Text)
+		assert.Equal(t, domain.MsgId(4), copied.Messages[1].Id)
+
+		tracked, err := storage.GetMirroredThreads(ctx, board)
+		require.NoError(t, err)
+		require.Len(t, tracked, 1)
+		assert.Equal(t, domain.MsgId(4), tracked[0].LastMessageId)
+		assert.True(t, tracked[0].SourceModifiedAt.Equal(thread.LastModifiedAt))
+	})
+
+	t.Run("local deletion sticks", func(t *testing.T) {
+		require.NoError(t, storage.DeleteThread(ctx, board, 40))
+
+		tracked, err := storage.GetMirroredThreads(ctx, board)
+		require.NoError(t, err)
+		require.Len(t, tracked, 1)
+		assert.True(t, tracked[0].Deleted)
+
+		require.NoError(t, storage.DeleteMirroredThread(ctx, board, 40))
+		tracked, err = storage.GetMirroredThreads(ctx, board)
+		require.NoError(t, err)
+		assert.Empty(t, tracked)
+	})
+
+	t.Run("detach", func(t *testing.T) {
+		require.NoError(t, storage.ApplyMirrorThreadUpdate(ctx, domain.MirrorThreadUpdate{
+			Board:     board,
+			Thread:    domain.ThreadMetadata{Id: 50, Title: "Kept", LastBumped: created, LastModifiedAt: created},
+			New:       []domain.MirroredMessage{{Id: 1, PostNumber: 200, Text: "op", CreatedAt: created}},
+			SourceIds: []domain.MsgId{1},
+		}))
+
+		require.NoError(t, storage.DeleteBoardMirror(ctx, board))
+		requireNotFoundError(t, storage.DeleteBoardMirror(ctx, board))
+
+		settings, err := storage.GetBoardSettings(ctx, board)
+		require.NoError(t, err)
+		assert.False(t, settings.Mirror)
+		_, err = storage.GetThread(ctx, board, 50, 1)
+		require.NoError(t, err, "copied threads stay")
+
+		threadId, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+			Title: "Local", Board: board,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: admin}, Text: "op"},
+		})
+		assert.Greater(t, threadId, domain.ThreadId(50), "local threads continue after the copied ids")
+		local, err := storage.GetThread(ctx, board, threadId, 1)
+		require.NoError(t, err)
+		assert.Greater(t, local.Messages[0].PostNumber, domain.PostNumber(200))
+	})
+}
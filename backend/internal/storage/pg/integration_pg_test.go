@@ -118,6 +118,8 @@ func mustSetup(ctx context.Context) (testcontainers.Container, error) {
 			BumpLimit:                   15,
 			BoardPreviewRefreshInterval: 60, // Use a longer interval for tests
 			MessagesPerThreadPage:       10,
+			QueryTimeout:                5 * time.Second,
+			LongQueryTimeout:            30 * time.Second,
 		},
 		Private: config.Private{
 			Pg: config.Pg{
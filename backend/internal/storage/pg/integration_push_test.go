@@ -0,0 +1,54 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushSubscriptions(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	author := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@push.com")}
+	other := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@push.com")}
+	replier := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@push.com")}
+	hidden := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@push.com")}
+	require.NoError(t, storage.ShadowbanUser(ctx, board, hidden.Id, author.Id))
+
+	endpoint := "https://push.example.com/" + generateString(t)
+	require.NoError(t, storage.SavePushSubscription(ctx, domain.PushSubscription{UserId: other.Id, Endpoint: endpoint, P256dh: "old", Auth: "old"}))
+	// The same browser subscribed again after logging in as the author
+	require.NoError(t, storage.SavePushSubscription(ctx, domain.PushSubscription{UserId: author.Id, Endpoint: endpoint, P256dh: "key", Auth: "secret"}))
+
+	threadId, opId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Pushed", Board: board,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+	})
+	replyTo := &domain.Replies{{To: opId, ToThreadId: threadId}}
+	replyId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: replier, Text: "reply", ReplyTo: replyTo})
+	hiddenId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: hidden, Text: "hidden", ReplyTo: replyTo})
+
+	subs, err := storage.GetReplyPushSubscriptions(ctx, board, threadId, replyId)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PushSubscription{{UserId: author.Id, Endpoint: endpoint, P256dh: "key", Auth: "secret"}}, subs)
+
+	subs, err = storage.GetReplyPushSubscriptions(ctx, board, threadId, hiddenId)
+	require.NoError(t, err)
+	assert.Empty(t, subs, "replies of shadowbanned authors are not pushed")
+
+	require.NoError(t, storage.DeletePushSubscription(ctx, other.Id, endpoint), "only the owner can delete it")
+	subs, err = storage.GetReplyPushSubscriptions(ctx, board, threadId, replyId)
+	require.NoError(t, err)
+	assert.Len(t, subs, 1)
+
+	require.NoError(t, storage.DeletePushSubscription(ctx, author.Id, endpoint))
+	subs, err = storage.GetReplyPushSubscriptions(ctx, board, threadId, replyId)
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+}
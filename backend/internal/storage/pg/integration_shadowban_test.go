@@ -0,0 +1,119 @@
+package pg
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowbans(t *testing.T) {
+	t.Run("shadowban and unshadowban", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		adminId := createTestUser(t, tx, "shadowban_admin@test.com")
+		userId := createTestUser(t, tx, "shadowban_user@test.com")
+
+		require.NoError(t, storage.shadowbanUser(tx, boardShortName, userId, adminId))
+		require.NoError(t, storage.shadowbanUser(tx, boardShortName, userId, adminId), "shadowban must be idempotent")
+
+		users, err := storage.getShadowbannedUsers(tx, boardShortName)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.UserId{userId}, users)
+
+		shadowbans, err := storage.getShadowbans(tx, 100, 0)
+		require.NoError(t, err)
+		found := false
+		for _, sb := range shadowbans {
+			if sb.Board == boardShortName && sb.UserId == userId {
+				found = true
+				assert.Equal(t, adminId, sb.CreatedBy)
+			}
+		}
+		assert.True(t, found)
+
+		require.NoError(t, storage.unshadowbanUser(tx, boardShortName, userId))
+		users, err = storage.getShadowbannedUsers(tx, boardShortName)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+
+		requireNotFoundError(t, storage.unshadowbanUser(tx, boardShortName, userId))
+	})
+
+	t.Run("cannot shadowban yourself", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		adminId := createTestUser(t, tx, "shadowban_admin@test.com")
+
+		err := storage.shadowbanUser(tx, boardShortName, adminId, adminId)
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusBadRequest, e.StatusCode)
+	})
+
+	t.Run("unknown board", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		adminId := createTestUser(t, tx, "shadowban_admin@test.com")
+		userId := createTestUser(t, tx, "shadowban_user@test.com")
+
+		err := storage.shadowbanUser(tx, domain.BoardShortName(generateString(t)), userId, adminId)
+		requireNotFoundError(t, err)
+	})
+
+	t.Run("hidden from board view and does not bump", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		adminId := createTestUser(t, tx, "shadowban_admin@test.com")
+		userId := createTestUser(t, tx, "shadowban_user@test.com")
+		otherId := createTestUser(t, tx, "shadowban_other@test.com")
+		require.NoError(t, storage.shadowbanUser(tx, boardShortName, userId, adminId))
+
+		visibleThread, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Visible", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: otherId}, Text: "Visible OP"},
+		})
+		createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Hidden", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userId}, Text: "Hidden OP"},
+		})
+
+		before, err := storage.getThread(tx, boardShortName, visibleThread, 1)
+		require.NoError(t, err)
+		createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, ThreadId: visibleThread, Author: domain.User{Id: userId}, Text: "Hidden reply",
+			ReplyTo: &domain.Replies{{To: 1, ToThreadId: visibleThread}},
+		})
+		after, err := storage.getThread(tx, boardShortName, visibleThread, 1)
+		require.NoError(t, err)
+		assert.Equal(t, before.LastBumped, after.LastBumped, "shadowbanned replies must not bump")
+		require.Len(t, after.Messages, 2, "thread storage keeps shadowbanned messages")
+		require.Len(t, after.Messages[0].Replies, 1)
+		assert.Equal(t, userId, after.Messages[0].Replies[0].FromAuthor)
+
+		op, err := storage.getMessage(tx, boardShortName, visibleThread, 1)
+		require.NoError(t, err)
+		require.Len(t, op.Replies, 1)
+		assert.Equal(t, userId, op.Replies[0].FromAuthor, "single messages carry reply authors too")
+
+		require.NoError(t, storage.refreshMaterializedView(tx, boardShortName))
+		board, err := storage.getBoard(tx, boardShortName, 1)
+		require.NoError(t, err)
+		requireThreadOrder(t, board.Threads, []string{"Visible"})
+		requireMessageOrder(t, board.Threads[0].Messages, []string{"Visible OP"})
+		assert.Empty(t, board.Threads[0].Messages[0].Replies)
+	})
+}
@@ -0,0 +1,89 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThreadPreviews(t *testing.T) {
+	cfg := *storage.cfg
+	cfg.Public.IncrementalBoardPreviews = true
+	incremental := &Storage{db: storage.db, cfg: &cfg}
+
+	// boardPages renders the board with both preview implementations as
+	// thread title -> message texts, in page order.
+	boardPages := func(t *testing.T, q Querier, board domain.BoardShortName) (fromView, fromTable [][]string) {
+		t.Helper()
+		flatten := func(b domain.Board) [][]string {
+			var out [][]string
+			for _, thread := range b.Threads {
+				texts := []string{string(thread.Title)}
+				for _, m := range thread.Messages {
+					texts = append(texts, string(m.Text))
+				}
+				out = append(out, texts)
+			}
+			return out
+		}
+
+		require.NoError(t, storage.refreshMaterializedView(q, board))
+		viewBoard, err := storage.getBoard(q, board, 1)
+		require.NoError(t, err)
+		tableBoard, err := incremental.getBoard(q, board, 1)
+		require.NoError(t, err)
+		return flatten(viewBoard), flatten(tableBoard)
+	}
+
+	t.Run("matches the materialized view", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		board := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, board)
+		adminId := createTestUser(t, tx, generateString(t)+"@test.com")
+		userId := createTestUser(t, tx, generateString(t)+"@test.com")
+		hiddenId := createTestUser(t, tx, generateString(t)+"@test.com")
+
+		post := func(threadId domain.ThreadId, author domain.UserId, text string) domain.MsgId {
+			id, err := incremental.createMessage(tx, domain.MessageCreationData{
+				Board: board, ThreadId: threadId, Author: domain.User{Id: author}, Text: domain.MsgText(text),
+			})
+			require.NoError(t, err)
+			return id
+		}
+		thread := func(title string, author domain.UserId) domain.ThreadId {
+			id, _, err := incremental.createThread(tx, domain.ThreadCreationData{Title: domain.ThreadTitle(title), Board: board})
+			require.NoError(t, err)
+			post(id, author, title+" OP")
+			return id
+		}
+
+		long := thread("Long", userId)
+		for _, text := range []string{"r1", "r2", "r3", "r4"} {
+			post(long, userId, text)
+		}
+		last := post(long, userId, "r5")
+		thread("Hidden", hiddenId)
+		mixed := thread("Mixed", userId)
+		post(mixed, hiddenId, "hidden reply")
+
+		fromView, fromTable := boardPages(t, tx, board)
+		require.Equal(t, fromView, fromTable)
+
+		require.NoError(t, incremental.shadowbanUser(tx, board, hiddenId, adminId))
+		require.NoError(t, incremental.deleteMessage(tx, board, long, last))
+		post(long, hiddenId, "reply after shadowban")
+
+		fromView, fromTable = boardPages(t, tx, board)
+		require.Equal(t, fromView, fromTable)
+		require.Equal(t, [][]string{{"Mixed", "Mixed OP"}, {"Long", "Long OP", "r4"}}, fromTable)
+
+		require.NoError(t, incremental.unshadowbanUser(tx, board, hiddenId))
+		require.NoError(t, incremental.deleteThread(tx, board, mixed))
+
+		fromView, fromTable = boardPages(t, tx, board)
+		require.Equal(t, fromView, fromTable)
+	})
+}
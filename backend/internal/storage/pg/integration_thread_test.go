@@ -5,10 +5,12 @@ package pg
 import (
 	"database/sql"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -573,6 +575,218 @@ func TestThreadOperations(t *testing.T) {
 	})
 }
 
+func TestUpdateThreadTitle(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	opID := createTestUser(t, tx, generateString(t)+"@example.com")
+	modID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Tpyo", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: opID}, Text: "OP"},
+	})
+
+	require.NoError(t, storage.updateThreadTitle(tx, boardShortName, threadID, "Typo", opID, false))
+	require.NoError(t, storage.updateThreadTitle(tx, boardShortName, threadID, "Typo", opID, false), "unchanged title is a no-op")
+	require.NoError(t, storage.updateThreadTitle(tx, boardShortName, threadID, "Fixed typo", modID, true))
+
+	thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ThreadTitle("Fixed typo"), thread.Title)
+
+	edits, err := storage.getThreadTitleEdits(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	require.Len(t, edits, 2)
+	assert.Equal(t, domain.ThreadTitle("Tpyo"), edits[0].OldTitle)
+	assert.Equal(t, domain.ThreadTitle("Typo"), edits[0].NewTitle)
+	assert.Equal(t, opID, *edits[0].EditedBy)
+	assert.False(t, edits[0].ByModerator)
+	assert.Equal(t, domain.ThreadTitle("Fixed typo"), edits[1].NewTitle)
+	assert.Equal(t, modID, *edits[1].EditedBy)
+	assert.True(t, edits[1].ByModerator)
+	assert.False(t, edits[1].EditedAt.IsZero())
+
+	err = storage.updateThreadTitle(tx, boardShortName, threadID+1000, "Missing", opID, false)
+	requireNotFoundError(t, err)
+}
+
+func TestThreadOpOnly(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	opID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Updates", Board: boardShortName, OpOnly: true,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: opID}, Text: "OP"},
+	})
+
+	opOnly, opAuthor, err := storage.getThreadOpOnly(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.True(t, opOnly)
+	require.NotNil(t, opAuthor)
+	assert.Equal(t, opID, *opAuthor)
+
+	thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+	require.NoError(t, err)
+	assert.True(t, thread.OpOnly)
+
+	newStatus, err := storage.toggleOpOnlyStatus(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.False(t, newStatus)
+	opOnly, _, err = storage.getThreadOpOnly(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.False(t, opOnly)
+
+	_, err = storage.toggleOpOnlyStatus(tx, boardShortName, threadID+1000)
+	requireNotFoundError(t, err)
+	_, _, err = storage.getThreadOpOnly(tx, boardShortName, threadID+1000)
+	requireNotFoundError(t, err)
+}
+
+func TestClaimBumpLimitNotice(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Long thread", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	reply := domain.MessageCreationData{Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply"}
+
+	// The OP and replies up to the limit still bump
+	for range storage.cfg.Public.BumpLimit - 1 {
+		createTestMessage(t, tx, reply)
+	}
+	claimed, err := storage.claimBumpLimitNotice(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.False(t, claimed, "the thread still bumps")
+
+	createTestMessage(t, tx, reply)
+	claimed, err = storage.claimBumpLimitNotice(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = storage.claimBumpLimitNotice(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.False(t, claimed, "the notice is due only once")
+
+	// The system account exists in every database
+	noticeID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: domain.SystemUserId}, Text: "Bump limit reached",
+	})
+	notice, err := storage.getMessage(tx, boardShortName, threadID, noticeID)
+	require.NoError(t, err)
+	assert.True(t, notice.Author.IsSystem())
+
+	claimed, err = storage.claimBumpLimitNotice(tx, boardShortName, threadID+1000)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
+
+func TestSetThreadSuccessor(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	newThread := func(title domain.ThreadTitle) domain.ThreadId {
+		id, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: title, Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+		return id
+	}
+	oldID := newThread("Part 1")
+	newID := newThread("Part 2")
+	otherID := newThread("Part 2, again")
+
+	require.NoError(t, storage.setThreadSuccessor(tx, boardShortName, oldID, newID, userID))
+
+	old, err := storage.getThreadMetadata(tx, boardShortName, oldID)
+	require.NoError(t, err)
+	require.NotNil(t, old.SuccessorId)
+	assert.Equal(t, newID, *old.SuccessorId)
+	assert.Nil(t, old.PredecessorId)
+
+	successor, err := storage.getThreadMetadata(tx, boardShortName, newID)
+	require.NoError(t, err)
+	require.NotNil(t, successor.PredecessorId)
+	assert.Equal(t, oldID, *successor.PredecessorId)
+	assert.Nil(t, successor.SuccessorId)
+
+	t.Run("replacing the successor", func(t *testing.T) {
+		require.NoError(t, storage.setThreadSuccessor(tx, boardShortName, oldID, otherID, userID))
+
+		old, err := storage.getThreadMetadata(tx, boardShortName, oldID)
+		require.NoError(t, err)
+		require.NotNil(t, old.SuccessorId)
+		assert.Equal(t, otherID, *old.SuccessorId)
+
+		replaced, err := storage.getThreadMetadata(tx, boardShortName, newID)
+		require.NoError(t, err)
+		assert.Nil(t, replaced.PredecessorId)
+	})
+
+	t.Run("a thread continues only one thread", func(t *testing.T) {
+		err := storage.setThreadSuccessor(tx, boardShortName, newID, otherID, userID)
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusConflict, e.StatusCode)
+	})
+
+	t.Run("unknown thread", func(t *testing.T) {
+		err := storage.setThreadSuccessor(tx, boardShortName, oldID, otherID+1000, userID)
+		requireNotFoundError(t, err)
+	})
+}
+
+func TestGetThreadRange(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	author := domain.User{Id: userID}
+	threadID, opID := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Range", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "OP"},
+	})
+	for i := 0; i < 5; i++ {
+		createTestMessage(t, tx, domain.MessageCreationData{Board: boardShortName, ThreadId: threadID, Author: author, Text: "reply"})
+	}
+
+	t.Run("includes the OP and the requested messages", func(t *testing.T) {
+		thread, err := storage.getThreadRange(tx, boardShortName, threadID, 3, 4)
+		require.NoError(t, err)
+		require.Len(t, thread.Messages, 3)
+		assert.Equal(t, opID, thread.Messages[0].Id)
+		assert.Equal(t, domain.MsgId(3), thread.Messages[1].Id)
+		assert.Equal(t, domain.MsgId(4), thread.Messages[2].Id)
+		assert.Equal(t, domain.ThreadTitle("Range"), thread.Title)
+		assert.Equal(t, 6, thread.Pagination.TotalCount)
+	})
+
+	t.Run("range past the end", func(t *testing.T) {
+		thread, err := storage.getThreadRange(tx, boardShortName, threadID, 5, 100)
+		require.NoError(t, err)
+		require.Len(t, thread.Messages, 3)
+		assert.Equal(t, domain.MsgId(6), thread.Messages[2].Id)
+	})
+
+	t.Run("missing thread", func(t *testing.T) {
+		_, err := storage.getThreadRange(tx, boardShortName, threadID+1000, 1, 10)
+		requireNotFoundError(t, err)
+	})
+}
+
 // TestCreateThreadWithCleanup verifies that createThreadWithCleanup enforces
 // the max thread count by deleting the oldest non-pinned threads.
 func TestCreateThreadWithCleanup(t *testing.T) {
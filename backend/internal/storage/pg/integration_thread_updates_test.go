@@ -0,0 +1,187 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetThreadUpdates(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	author := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@updates.com")}
+
+	threadId, opId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Live", Board: board,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+	})
+	firstId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: author, Text: "first"})
+	secondId := createTestMessage(t, storage.db, domain.MessageCreationData{
+		Board: board, ThreadId: threadId, Author: author, Text: "second",
+		ReplyTo: &domain.Replies{{To: opId, ToThreadId: threadId}, {To: firstId, ToThreadId: threadId}},
+	})
+	thirdId := createTestMessage(t, storage.db, domain.MessageCreationData{
+		Board: board, ThreadId: threadId, Author: author, Text: "third",
+		ReplyTo: &domain.Replies{{To: secondId, ToThreadId: threadId}},
+	})
+
+	t.Run("messages after since with backlinks to earlier ones", func(t *testing.T) {
+		updates, err := storage.GetThreadUpdates(ctx, board, threadId, firstId, 10)
+		require.NoError(t, err)
+
+		require.Len(t, updates.Messages, 2)
+		assert.Equal(t, secondId, updates.Messages[0].Id)
+		assert.Equal(t, thirdId, updates.Messages[1].Id)
+		require.Len(t, updates.Messages[0].Replies, 1, "replies among the new messages are on the messages")
+		assert.Equal(t, thirdId, updates.Messages[0].Replies[0].From)
+
+		require.Len(t, updates.Backlinks, 2)
+		for _, reply := range updates.Backlinks {
+			assert.Equal(t, secondId, reply.From)
+			assert.Equal(t, author.Id, reply.FromAuthor)
+		}
+		assert.ElementsMatch(t, []domain.MsgId{opId, firstId}, []domain.MsgId{updates.Backlinks[0].To, updates.Backlinks[1].To})
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		updates, err := storage.GetThreadUpdates(ctx, board, threadId, firstId, 1)
+		require.NoError(t, err)
+		require.Len(t, updates.Messages, 1)
+		assert.Equal(t, secondId, updates.Messages[0].Id)
+		assert.Len(t, updates.Backlinks, 2)
+	})
+
+	t.Run("nothing new", func(t *testing.T) {
+		updates, err := storage.GetThreadUpdates(ctx, board, threadId, thirdId, 10)
+		require.NoError(t, err)
+		assert.Empty(t, updates.Messages)
+		assert.Empty(t, updates.Backlinks)
+	})
+
+	t.Run("unknown thread", func(t *testing.T) {
+		_, err := storage.GetThreadUpdates(ctx, board, threadId+1000, 0, 10)
+		requireNotFoundError(t, err)
+	})
+}
+
+func TestGetThreadGraph(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	author := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@graph.com")}
+
+	threadId, opId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Graph", Board: board,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+	})
+	otherThreadId, otherOpId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Other", Board: board,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "other op"},
+	})
+	firstId := createTestMessage(t, storage.db, domain.MessageCreationData{
+		Board: board, ThreadId: threadId, Author: author, Text: "first",
+		ReplyTo: &domain.Replies{{To: opId, ToThreadId: threadId}, {To: otherOpId, ToThreadId: otherThreadId}},
+	})
+	secondId := createTestMessage(t, storage.db, domain.MessageCreationData{
+		Board: board, ThreadId: threadId, Author: author, Text: "second",
+		ReplyTo: &domain.Replies{{To: opId, ToThreadId: threadId}, {To: firstId, ToThreadId: threadId}},
+	})
+
+	t.Run("messages and replies within the thread", func(t *testing.T) {
+		graph, err := storage.GetThreadGraph(ctx, board, threadId)
+		require.NoError(t, err)
+
+		require.Len(t, graph.Nodes, 3)
+		for i, id := range []domain.MsgId{opId, firstId, secondId} {
+			assert.Equal(t, id, graph.Nodes[i].Id)
+			assert.Equal(t, author.Id, graph.Nodes[i].Author)
+			assert.Equal(t, 1, graph.Nodes[i].Page)
+			assert.False(t, graph.Nodes[i].CreatedAt.IsZero())
+		}
+		assert.Equal(t, []domain.GraphEdge{
+			{From: firstId, To: opId},
+			{From: secondId, To: opId},
+			{From: secondId, To: firstId},
+		}, graph.Edges, "the reply to the other thread is left out")
+	})
+
+	t.Run("thread without replies", func(t *testing.T) {
+		graph, err := storage.GetThreadGraph(ctx, board, otherThreadId)
+		require.NoError(t, err)
+		assert.Len(t, graph.Nodes, 1)
+		assert.NotNil(t, graph.Edges)
+		assert.Empty(t, graph.Edges)
+	})
+
+	t.Run("missing thread", func(t *testing.T) {
+		_, err := storage.GetThreadGraph(ctx, board, threadId+1000)
+		requireNotFoundError(t, err)
+	})
+}
+
+func TestGetThreadAttachments(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	opAuthor := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@gallery.com")}
+	replier := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@gallery.com")}
+
+	threadId, opId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Gallery", Board: board,
+		OpMessage: domain.MessageCreationData{Author: opAuthor, Text: "op"},
+	})
+	otherThreadId, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Other", Board: board,
+		OpMessage: domain.MessageCreationData{Author: opAuthor, Text: "other op"},
+	})
+	replyId := createTestMessage(t, storage.db, domain.MessageCreationData{
+		Board: board, ThreadId: threadId, Author: replier, Text: "reply",
+	})
+	createTestMessage(t, storage.db, domain.MessageCreationData{
+		Board: board, ThreadId: threadId, Author: replier, Text: "no files",
+	})
+	// Added out of posting order: the result still follows the messages
+	replyFiles := getRandomAttachments(t)
+	require.NoError(t, storage.addAttachments(storage.db, board, threadId, replyId, replyFiles))
+	opFiles := getRandomAttachments(t)[:1]
+	require.NoError(t, storage.addAttachments(storage.db, board, threadId, opId, opFiles))
+
+	t.Run("attachments in posting order", func(t *testing.T) {
+		result, err := storage.GetThreadAttachments(ctx, board, threadId)
+		require.NoError(t, err)
+
+		require.Len(t, result.Attachments, 3)
+		assert.Equal(t, opAuthor.Id, result.OpAuthor)
+		wantFiles := []string{opFiles[0].File.FilePath, replyFiles[0].File.FilePath, replyFiles[1].File.FilePath}
+		wantAuthors := []domain.UserId{opAuthor.Id, replier.Id, replier.Id}
+		wantMessages := []domain.MsgId{opId, replyId, replyId}
+		for i, item := range result.Attachments {
+			assert.Equal(t, wantFiles[i], item.Attachment.File.FilePath)
+			assert.Equal(t, wantAuthors[i], item.Author)
+			assert.Equal(t, wantMessages[i], item.Attachment.MessageId)
+			assert.Equal(t, 1, item.Page)
+		}
+	})
+
+	t.Run("thread without attachments", func(t *testing.T) {
+		result, err := storage.GetThreadAttachments(ctx, board, otherThreadId)
+		require.NoError(t, err)
+		assert.NotNil(t, result.Attachments)
+		assert.Empty(t, result.Attachments)
+	})
+
+	t.Run("missing thread", func(t *testing.T) {
+		_, err := storage.GetThreadAttachments(ctx, board, threadId+1000)
+		requireNotFoundError(t, err)
+	})
+}
@@ -0,0 +1,160 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// =========================================================================
+// Public Methods (login devices and session revocation)
+// =========================================================================
+
+// SaveLoginDevice records a login from the device. It reports whether the
+// device is new to the user; for a known device only last_seen_at moves and
+// the revoke token of its first login is kept.
+func (s *Storage) SaveLoginDevice(ctx context.Context, device domain.LoginDevice) (bool, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	var isNew bool
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		isNew, err = s.saveLoginDevice(tx, device)
+		return err
+	})
+	return isNew, err
+}
+
+// CountLoginDevices returns how many devices the user has logged in from.
+func (s *Storage) CountLoginDevices(ctx context.Context, userId domain.UserId) (int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.countLoginDevices(q, userId)
+}
+
+// RevokeSessionsByDeviceToken forgets the device the token was issued for and
+// revokes every session of its user, returning the user. The token works
+// once: unknown and used tokens are not found.
+func (s *Storage) RevokeSessionsByDeviceToken(ctx context.Context, tokenHash string) (domain.UserId, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	var userId domain.UserId
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		userId, err = s.revokeSessionsByDeviceToken(tx, tokenHash)
+		return err
+	})
+	return userId, err
+}
+
+// SessionsRevokedAt returns when the user's sessions were last revoked, or the
+// zero time if they never were.
+func (s *Storage) SessionsRevokedAt(ctx context.Context, userId domain.UserId) (time.Time, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.sessionsRevokedAt(q, userId)
+}
+
+// GetRecentSessionRevocations returns the users whose sessions were revoked
+// after since, with the time of revocation. This is used by the blacklist
+// cache.
+func (s *Storage) GetRecentSessionRevocations(since time.Time) (map[domain.UserId]time.Time, error) {
+	return s.getRecentSessionRevocations(s.db, since)
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+func (s *Storage) saveLoginDevice(q Querier, device domain.LoginDevice) (bool, error) {
+	// xmax is 0 for a freshly inserted row and set for an updated one
+	var isNew bool
+	err := q.QueryRow(`
+		INSERT INTO login_devices (user_id, fingerprint, revoke_token_hash, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, NOW() AT TIME ZONE 'utc', NOW() AT TIME ZONE 'utc')
+		ON CONFLICT (user_id, fingerprint) DO UPDATE SET last_seen_at = EXCLUDED.last_seen_at
+		RETURNING xmax = 0`,
+		device.UserId, device.Fingerprint, device.RevokeTokenHash,
+	).Scan(&isNew)
+	if err != nil {
+		return false, fmt.Errorf("failed to save login device: %w", err)
+	}
+	return isNew, nil
+}
+
+func (s *Storage) countLoginDevices(q Querier, userId domain.UserId) (int, error) {
+	var count int
+	if err := q.QueryRow(`SELECT count(*) FROM login_devices WHERE user_id = $1`, userId).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count login devices: %w", err)
+	}
+	return count, nil
+}
+
+func (s *Storage) revokeSessionsByDeviceToken(q Querier, tokenHash string) (domain.UserId, error) {
+	var userId domain.UserId
+	err := q.QueryRow(`DELETE FROM login_devices WHERE revoke_token_hash = $1 RETURNING user_id`, tokenHash).Scan(&userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, &internal_errors.ErrorWithStatusCode{Message: "Link is invalid or was already used", StatusCode: http.StatusNotFound}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete login device: %w", err)
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO session_revocations (user_id, revoked_at)
+		VALUES ($1, NOW() AT TIME ZONE 'utc')
+		ON CONFLICT (user_id) DO UPDATE SET revoked_at = EXCLUDED.revoked_at`,
+		userId,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return userId, nil
+}
+
+func (s *Storage) sessionsRevokedAt(q Querier, userId domain.UserId) (time.Time, error) {
+	var revokedAt time.Time
+	err := q.QueryRow(`SELECT revoked_at FROM session_revocations WHERE user_id = $1`, userId).Scan(&revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get session revocation: %w", err)
+	}
+	return revokedAt, nil
+}
+
+func (s *Storage) getRecentSessionRevocations(q Querier, since time.Time) (map[domain.UserId]time.Time, error) {
+	rows, err := q.Query(`
+		SELECT user_id, revoked_at
+		FROM session_revocations
+		WHERE revoked_at >= $1`,
+		since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session revocations: %w", err)
+	}
+	defer rows.Close()
+
+	revocations := make(map[domain.UserId]time.Time)
+	for rows.Next() {
+		var userId domain.UserId
+		var revokedAt time.Time
+		if err := rows.Scan(&userId, &revokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session revocation: %w", err)
+		}
+		revocations[userId] = revokedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session revocations: %w", err)
+	}
+	return revocations, nil
+}
@@ -0,0 +1,128 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/lib/pq"
+)
+
+// MaintenanceOp is a maintenance command run over the relations of a board.
+type MaintenanceOp string
+
+const (
+	MaintenanceVacuum  MaintenanceOp = "vacuum"
+	MaintenanceAnalyze MaintenanceOp = "analyze"
+	MaintenanceReindex MaintenanceOp = "reindex"
+)
+
+// maintenanceStatements maps operations to their SQL. REINDEX runs
+// concurrently, so readers and writers of the partition are not blocked. An
+// interrupted concurrent reindex leaves an invalid "_ccnew" index behind, which
+// the next reindex of the table replaces.
+var maintenanceStatements = map[MaintenanceOp]string{
+	MaintenanceVacuum:  "VACUUM %s",
+	MaintenanceAnalyze: "ANALYZE %s",
+	MaintenanceReindex: "REINDEX TABLE CONCURRENTLY %s",
+}
+
+// boardPartitionedTables are the tables with one partition per board.
+var boardPartitionedTables = []string{"threads", "messages", "attachments", "message_replies"}
+
+// maintenanceLockKey is the advisory lock held for the duration of a maintenance
+// run, so overlapping runs (e.g. a scheduled one and a manual one) never compete.
+const maintenanceLockKey int64 = 0x6974636d61696e74 // "itcmaint"
+
+// MaintenanceResult describes one operation on one relation.
+type MaintenanceResult struct {
+	Relation string
+	Op       MaintenanceOp
+	Duration time.Duration
+	Err      error // Set when the operation failed or was skipped (e.g. lock timeout)
+}
+
+// MaintainBoards runs ops, in the given order, over the partitions and the
+// preview materialized view of each board. Statements that wait longer than
+// lockTimeout for a lock are skipped rather than queueing up behind (and in
+// front of) application queries; they are reported in the results and the run
+// continues. Only one run can be active at a time: a concurrent call fails with
+// 409. The returned error is reserved for problems that stop the whole run.
+func (s *Storage) MaintainBoards(ctx context.Context, boards []domain.BoardShortName, ops []MaintenanceOp, lockTimeout time.Duration) ([]MaintenanceResult, error) {
+	for _, op := range ops {
+		if _, ok := maintenanceStatements[op]; !ok {
+			return nil, &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Unknown maintenance operation '%s'", op), StatusCode: http.StatusBadRequest,
+			}
+		}
+	}
+
+	// VACUUM and REINDEX CONCURRENTLY cannot run inside a transaction, and the
+	// advisory lock and lock_timeout are session state, so everything runs on
+	// one dedicated connection.
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance connection: %w", err)
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, maintenanceLockKey).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("failed to acquire maintenance lock: %w", err)
+	}
+	if !locked {
+		return nil, &internal_errors.ErrorWithStatusCode{
+			Message: "Another maintenance run is in progress", StatusCode: http.StatusConflict,
+		}
+	}
+	// The connection goes back to the pool, so the session state is reset even
+	// if ctx has been cancelled in the meantime.
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, maintenanceLockKey); err != nil {
+			logger.Log.Error("failed to release maintenance lock", "error", err)
+		}
+		if _, err := conn.ExecContext(context.Background(), `RESET lock_timeout`); err != nil {
+			logger.Log.Error("failed to reset lock_timeout", "error", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`SET lock_timeout = %d`, lockTimeout.Milliseconds())); err != nil {
+		return nil, fmt.Errorf("failed to set lock_timeout: %w", err)
+	}
+
+	var results []MaintenanceResult
+	for _, board := range boards {
+		relations := make([]string, 0, len(boardPartitionedTables)+1)
+		for _, table := range boardPartitionedTables {
+			relations = append(relations, PartitionName(board, table))
+		}
+		// The view exists for every board, even with incremental previews enabled.
+		relations = append(relations, ViewTableName(board))
+
+		for _, relation := range relations {
+			for _, op := range ops {
+				if err := ctx.Err(); err != nil {
+					return results, err
+				}
+				start := time.Now()
+				_, err := conn.ExecContext(ctx, fmt.Sprintf(maintenanceStatements[op], relation))
+				result := MaintenanceResult{Relation: relation, Op: op, Duration: time.Since(start)}
+				if err != nil {
+					var pqErr *pq.Error
+					if errors.As(err, &pqErr) && pqErr.Code == "55P03" { // lock_not_available
+						err = fmt.Errorf("skipped, lock not available within %s", lockTimeout)
+					}
+					result.Err = err
+					logger.Log.Warn("maintenance operation failed", "relation", relation, "op", op, "error", err)
+				}
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
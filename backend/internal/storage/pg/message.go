@@ -24,26 +24,29 @@ import (
 // atomic database transaction. This ensures that all related database operations
 // (updating board/thread metadata, inserting the message, attachments, and replies)
 // either succeed together or fail together, maintaining data integrity.
-func (s *Storage) CreateMessage(creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *Storage) CreateMessage(ctx context.Context, creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
+	ctx, cancel := s.longQueryContext(ctx)
 	defer cancel()
 
 	var msgID domain.MsgId
-	err := s.withTx(ctx, func(tx *sql.Tx) error {
+	err := s.withTx(ctx, func(tx Querier) error {
 		var err error
 		msgID, err = s.createMessage(tx, creationData)
 		if err != nil {
 			return err
 		}
 
-		// Add attachments in the same transaction
+		// Link uploads made ahead of the post and add new attachments in the same transaction
+		if err := s.claimUploads(tx, creationData, msgID); err != nil {
+			return err
+		}
 		if len(attachments) > 0 {
 			if err := s.addAttachments(tx, creationData.Board, creationData.ThreadId, msgID, attachments); err != nil {
 				return err
 			}
 		}
 
-		return nil
+		return s.storeContentHash(tx, creationData.Board, creationData.ThreadId, msgID)
 	})
 	return msgID, err
 }
@@ -52,11 +55,22 @@ func (s *Storage) CreateMessage(creationData domain.MessageCreationData, attachm
 // It manages the transaction for this operation, ensuring that the board's
 // last activity is updated and the message is deleted atomically. The cascading
 // deletion of related attachments and replies is handled by the database schema.
-func (s *Storage) DeleteMessage(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// The moderator's reason and the moderation log entry are recorded in the
+// same transaction; self-deletions by the author are kept out of the log.
+func (s *Storage) DeleteMessage(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
+		// Record first: the author is read from the row that is about to be deleted.
+		if err := s.recordMessageDeletion(tx, board, threadId, id, deletion); err != nil {
+			return err
+		}
+		if !deletion.ByAuthor {
+			if err := s.recordModeration(tx, domain.ModLogEntry{Board: board, Action: domain.ModLogDeleteMessage, Reason: deletion.Reason}); err != nil {
+				return err
+			}
+		}
 		return s.deleteMessage(tx, board, threadId, id)
 	})
 }
@@ -65,9 +79,32 @@ func (s *Storage) DeleteMessage(board domain.BoardShortName, threadId domain.Thr
 // attachments and replies. Since it doesn't modify data, it doesn't need to
 // create its own transaction. It can use the main database connection pool (s.db)
 // as the Querier, allowing for concurrent reads.
-func (s *Storage) GetMessage(board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+func (s *Storage) GetMessage(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
 	// Delegate to the internal method, passing the main DB connection pool.
-	return s.getMessage(s.db, board, threadId, id)
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getMessage(q, board, threadId, id)
+}
+
+// ResolvePostNumber returns the thread and message ids of a board-local post number.
+func (s *Storage) ResolvePostNumber(ctx context.Context, board domain.BoardShortName, postNumber domain.PostNumber) (domain.ThreadId, domain.MsgId, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.resolvePostNumber(q, board, postNumber)
+}
+
+// GetExistingMessages returns the links whose target message still exists on the board.
+func (s *Storage) GetExistingMessages(ctx context.Context, board domain.BoardShortName, links []domain.MessageLink) ([]domain.MessageLink, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getExistingMessages(q, board, links)
+}
+
+// GetLastMessageTime returns when the user last posted, or nil if they never did.
+func (s *Storage) GetLastMessageTime(ctx context.Context, userId domain.UserId) (*time.Time, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getLastMessageTime(q, userId)
 }
 
 // =========================================================================
@@ -90,37 +127,46 @@ func (s *Storage) createMessage(q Querier, creationData domain.MessageCreationDa
 		createdAt = time.Now().UTC().Round(time.Microsecond)
 	}
 
-	// Atomically update the parent board's last_activity timestamp.
-	result, err := q.Exec(`
+	// Atomically update the parent board's last_activity timestamp and take the
+	// next board-local post number. The row is locked for the rest of the
+	// transaction either way, so the counter costs no extra contention.
+	var postNumber domain.PostNumber
+	err := q.QueryRow(`
 	       	UPDATE boards SET
-				last_activity_at = GREATEST(last_activity_at, $1)
+				last_activity_at = GREATEST(last_activity_at, $1),
+				next_post_number = next_post_number + 1
 	       	WHERE short_name = $2
+			RETURNING next_post_number - 1
 			`,
 		createdAt, creationData.Board,
-	)
+	).Scan(&postNumber)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, &internal_errors.ErrorWithStatusCode{Message: "Board not found", StatusCode: http.StatusNotFound}
+		}
 		return -1, fmt.Errorf("failed to update board activity: %w", err)
 	}
-	if affected, _ := result.RowsAffected(); affected == 0 {
-		return -1, &internal_errors.ErrorWithStatusCode{Message: "Board not found", StatusCode: http.StatusNotFound}
-	}
 
 	// Update the parent thread's metadata (reply count and bump timestamp) and get the
 	// new message's ID (which equals next_message_id before increment).
 	// We use next_message_id instead of message_count to avoid PK violations when
 	// messages are deleted in the middle of a thread (creating gaps).
 	// We return (next_message_id - 1) to get the pre-increment value which is the actual message ID.
+	// Shadowbanned users never bump, otherwise the thread order would give them away.
 	var msgId int64
 	err = q.QueryRow(`
 	       UPDATE threads SET
 	           message_count = message_count + 1,
 	           next_message_id = next_message_id + 1,
-	           last_bumped_at = CASE WHEN message_count > $1 THEN last_bumped_at ELSE $2 END,
+	           last_bumped_at = CASE
+	               WHEN message_count > $1 THEN last_bumped_at
+	               WHEN EXISTS (SELECT 1 FROM user_shadowbans WHERE board = $3 AND user_id = $5) THEN last_bumped_at
+	               ELSE $2 END,
 	           last_modified_at = $2
 	       WHERE board = $3 AND id = $4
 		   RETURNING next_message_id - 1
 		   `,
-		s.cfg.Public.BumpLimit, createdAt, creationData.Board, creationData.ThreadId,
+		s.cfg.Public.BumpLimit, createdAt, creationData.Board, creationData.ThreadId, creationData.Author.Id,
 	).Scan(&msgId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -133,9 +179,9 @@ func (s *Storage) createMessage(q Querier, creationData domain.MessageCreationDa
 	// The message ID is per-thread sequential (1, 2, 3...) - id=1 is always OP.
 	partitionName := PartitionName(creationData.Board, "messages")
 	_, err = q.Exec(fmt.Sprintf(`
-	       INSERT INTO %s (id, author_id, text, created_at, thread_id, updated_at, board, show_email_domain)
-	       VALUES ($1, $2, $3, $4, $5, $4, $6, $7)`, partitionName),
-		msgId, creationData.Author.Id, creationData.Text, createdAt, creationData.ThreadId, creationData.Board, creationData.ShowEmailDomain,
+	       INSERT INTO %s (id, author_id, text, created_at, thread_id, updated_at, board, show_email_domain, post_number)
+	       VALUES ($1, $2, $3, $4, $5, $4, $6, $7, $8)`, partitionName),
+		msgId, creationData.Author.Id, creationData.Text, createdAt, creationData.ThreadId, creationData.Board, creationData.ShowEmailDomain, postNumber,
 	)
 	if err != nil {
 		return -1, fmt.Errorf("failed to insert message: %w", err)
@@ -153,9 +199,16 @@ func (s *Storage) createMessage(q Querier, creationData domain.MessageCreationDa
 			if err != nil {
 				return -1, fmt.Errorf("failed to insert message reply relationship: %w", err)
 			}
+			if err := s.notifyReply(q, creationData.Board, creationData.ThreadId, msgId, creationData.Author.Id, reply, createdAt); err != nil {
+				return -1, err
+			}
 		}
 	}
 
+	if err := s.addToThreadPreview(q, creationData.Board, creationData.ThreadId, domain.MsgId(msgId), creationData.Author.Id); err != nil {
+		return -1, err
+	}
+
 	return msgId, nil
 }
 
@@ -207,6 +260,21 @@ func (s *Storage) deleteMessage(q Querier, board domain.BoardShortName, threadId
 	if affected, _ := result.RowsAffected(); affected == 0 {
 		return &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
 	}
+	if _, err := q.Exec(`
+		DELETE FROM notifications WHERE board = $1 AND thread_id = $2 AND message_id = $3`,
+		board, threadId, id,
+	); err != nil {
+		return fmt.Errorf("failed to delete notifications of message: %w", err)
+	}
+	if _, err := q.Exec(`
+		DELETE FROM message_bookmarks WHERE board = $1 AND thread_id = $2 AND message_id = $3`,
+		board, threadId, id,
+	); err != nil {
+		return fmt.Errorf("failed to delete bookmarks of message: %w", err)
+	}
+	if err := s.removeFromThreadPreview(q, board, threadId, &id); err != nil {
+		return err
+	}
 
 	// Decrement the thread's message count and update last_modified_at to reflect the deletion
 	_, err = q.Exec(`
@@ -237,19 +305,71 @@ func (s *Storage) deleteMessage(q Querier, board domain.BoardShortName, threadId
 	return nil
 }
 
+// recordMessageDeletion stores the moderator's reason for a deleted message, or
+// marks it as deleted by its author.
+// Re-deleting the same message id (not possible in practice, ids are never reused)
+// would simply overwrite the previous record.
+func (s *Storage) recordMessageDeletion(q Querier, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
+	_, err := q.Exec(`
+		INSERT INTO message_deletions (board, thread_id, message_id, reason, deleted_by, author_id, deleted_at, by_author)
+		SELECT $1, $2, $3, $4, $5, m.author_id, $6, $7
+		FROM messages m
+		WHERE m.board = $1 AND m.thread_id = $2 AND m.id = $3
+		ON CONFLICT (board, thread_id, message_id) DO UPDATE
+		SET reason = EXCLUDED.reason, deleted_by = EXCLUDED.deleted_by,
+		    author_id = EXCLUDED.author_id, by_author = EXCLUDED.by_author, deleted_at = EXCLUDED.deleted_at`,
+		board, threadId, id, deletion.Reason, deletion.DeletedBy, time.Now().UTC().Round(time.Microsecond), deletion.ByAuthor,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record message deletion: %w", err)
+	}
+	return nil
+}
+
+// getDeletedMessages returns public deletion stubs for a thread with message ids in
+// [fromId, toId]. It returns nothing unless the board has deletion stubs enabled.
+func (s *Storage) getDeletedMessages(q Querier, board domain.BoardShortName, threadId domain.ThreadId, fromId, toId domain.MsgId) ([]domain.DeletedMessage, error) {
+	rows, err := q.Query(`
+		SELECT d.message_id, d.reason, d.deleted_at
+		FROM message_deletions d
+		JOIN boards b ON b.short_name = d.board
+		WHERE d.board = $1 AND d.thread_id = $2 AND d.message_id BETWEEN $3 AND $4
+		  AND b.show_deletion_stubs AND NOT d.by_author
+		ORDER BY d.message_id`,
+		board, threadId, fromId, toId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted messages: %w", err)
+	}
+	defer rows.Close()
+
+	var deleted []domain.DeletedMessage
+	for rows.Next() {
+		d := domain.DeletedMessage{Board: board, ThreadId: threadId}
+		if err := rows.Scan(&d.Id, &d.Reason, &d.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted message: %w", err)
+		}
+		deleted = append(deleted, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted messages: %w", err)
+	}
+	return deleted, nil
+}
+
 // getMessage contains the core logic for fetching a message and all its related data.
 // It composes several helper functions to build the complete domain.Message object.
 func (s *Storage) getMessage(q Querier, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
 	var msg domain.Message
 	err := q.QueryRow(`
-	   SELECT m.id, m.author_id, u.email_domain, u.is_admin, m.text, m.show_email_domain, m.created_at, m.thread_id, m.updated_at, m.board
+	   SELECT m.id, m.author_id, u.email_domain, u.is_admin, m.text, m.show_email_domain, m.created_at, m.thread_id, m.updated_at, m.board, m.post_number
 	   FROM messages m
 	   JOIN users u ON m.author_id = u.id
 	   WHERE m.board = $1 AND m.thread_id = $2 AND m.id = $3`,
 		board, threadId, id,
 	).Scan(
 		&msg.Id, &msg.Author.Id, &msg.Author.EmailDomain, &msg.Author.Admin, &msg.Text, &msg.ShowEmailDomain, &msg.CreatedAt, &msg.ThreadId,
-		&msg.ModifiedAt, &msg.Board,
+		&msg.ModifiedAt, &msg.Board, &msg.PostNumber,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -277,11 +397,72 @@ func (s *Storage) getMessage(q Querier, board domain.BoardShortName, threadId do
 	return msg, nil
 }
 
+func (s *Storage) resolvePostNumber(q Querier, board domain.BoardShortName, postNumber domain.PostNumber) (domain.ThreadId, domain.MsgId, error) {
+	var threadId domain.ThreadId
+	var msgId domain.MsgId
+	err := q.QueryRow(
+		`SELECT thread_id, id FROM messages WHERE board = $1 AND post_number = $2`,
+		board, postNumber,
+	).Scan(&threadId, &msgId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
+		}
+		return 0, 0, fmt.Errorf("failed to resolve post number: %w", err)
+	}
+	return threadId, msgId, nil
+}
+
+// getExistingMessages looks up all link targets in one query.
+func (s *Storage) getExistingMessages(q Querier, board domain.BoardShortName, links []domain.MessageLink) ([]domain.MessageLink, error) {
+	if len(links) == 0 {
+		return nil, nil
+	}
+	threadIds := make([]int64, len(links))
+	msgIds := make([]int64, len(links))
+	for i, link := range links {
+		threadIds[i] = int64(link.ThreadId)
+		msgIds[i] = int64(link.Id)
+	}
+
+	rows, err := q.Query(`
+		SELECT m.thread_id, m.id
+		FROM unnest($2::bigint[], $3::bigint[]) AS keys(thread_id, msg_id)
+		JOIN messages m ON m.board = $1 AND m.thread_id = keys.thread_id AND m.id = keys.msg_id`,
+		board, pq.Array(threadIds), pq.Array(msgIds),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check linked messages: %w", err)
+	}
+	defer rows.Close()
+
+	var existing []domain.MessageLink
+	for rows.Next() {
+		var link domain.MessageLink
+		if err := rows.Scan(&link.ThreadId, &link.Id); err != nil {
+			return nil, fmt.Errorf("failed to scan linked message: %w", err)
+		}
+		existing = append(existing, link)
+	}
+	return existing, rows.Err()
+}
+
+// getLastMessageTime returns the creation time of the user's most recent message.
+func (s *Storage) getLastMessageTime(q Querier, userId domain.UserId) (*time.Time, error) {
+	var last sql.NullTime
+	if err := q.QueryRow(`SELECT MAX(created_at) FROM messages WHERE author_id = $1`, userId).Scan(&last); err != nil {
+		return nil, fmt.Errorf("failed to query last message time: %w", err)
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
 // getMessageAttachments fetches all attachment records associated with a specific message.
 func (s *Storage) getMessageAttachments(q Querier, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Attachments, error) {
 	rows, err := q.Query(`
-        SELECT a.id, a.board, a.thread_id, a.message_id, a.file_id,
-               f.file_path, f.filename, f.original_filename, f.file_size_bytes, f.mime_type, f.original_mime_type, f.image_width, f.image_height, f.thumbnail_path
+        SELECT `+attachmentColumns+`
         FROM attachments a
         JOIN files f ON a.file_id = f.id
         WHERE a.board = $1 AND a.thread_id = $2 AND a.message_id = $3
@@ -295,16 +476,11 @@ func (s *Storage) getMessageAttachments(q Querier, board domain.BoardShortName,
 
 	var attachments domain.Attachments
 	for rows.Next() {
-		var attachment domain.Attachment
-		var file domain.File
-		if err := rows.Scan(
-			&attachment.Id, &attachment.Board, &attachment.ThreadId, &attachment.MessageId, &attachment.FileId,
-			&file.FilePath, &file.Filename, &file.OriginalFilename, &file.SizeBytes, &file.MimeType, &file.OriginalMimeType, &file.ImageWidth, &file.ImageHeight, &file.ThumbnailPath,
-		); err != nil {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan attachment row: %w", err)
 		}
-		attachment.File = &file
-		attachments = append(attachments, &attachment)
+		attachments = append(attachments, attachment)
 	}
 	return attachments, rows.Err()
 }
@@ -312,8 +488,10 @@ func (s *Storage) getMessageAttachments(q Querier, board domain.BoardShortName,
 // getMessageRepliesTo fetches all reply relationships where the specified message is the *receiver*.
 func (s *Storage) getMessageRepliesTo(q Querier, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Replies, error) {
 	rows, err := q.Query(`
-	       SELECT mr.board, mr.sender_message_id, mr.sender_thread_id, mr.receiver_message_id, mr.receiver_thread_id, mr.created_at
+	       SELECT mr.board, mr.sender_message_id, mr.sender_thread_id, mr.receiver_message_id, mr.receiver_thread_id, mr.created_at,
+	              sm.author_id, sm.post_number
 	       FROM message_replies mr
+	       JOIN messages sm ON sm.board = mr.board AND sm.thread_id = mr.sender_thread_id AND sm.id = mr.sender_message_id
 	       WHERE mr.board = $1 AND mr.receiver_thread_id = $2 AND mr.receiver_message_id = $3
 	       ORDER BY mr.created_at`,
 		board, threadId, id,
@@ -326,7 +504,7 @@ func (s *Storage) getMessageRepliesTo(q Querier, board domain.BoardShortName, th
 	var replies domain.Replies
 	for rows.Next() {
 		var reply domain.Reply
-		if err := rows.Scan(&reply.Board, &reply.From, &reply.FromThreadId, &reply.To, &reply.ToThreadId, &reply.CreatedAt); err != nil {
+		if err := rows.Scan(&reply.Board, &reply.From, &reply.FromThreadId, &reply.To, &reply.ToThreadId, &reply.CreatedAt, &reply.FromAuthor, &reply.FromPostNumber); err != nil {
 			return nil, fmt.Errorf("failed to scan reply row: %w", err)
 		}
 		// From (sender_message_id) is now the per-thread sequential ID, which is also the ordinal
@@ -364,28 +542,52 @@ func (s *Storage) getMessageRepliesFrom(q Querier, board domain.BoardShortName,
 // addAttachments is the internal method to add attachments within a transaction
 func (s *Storage) addAttachments(q Querier, board domain.BoardShortName, threadId domain.ThreadId, messageID domain.MsgId, attachments domain.Attachments) error {
 	for _, attachment := range attachments {
-		// Insert file record
-		var fileId int64
-		err := q.QueryRow(`
-            INSERT INTO files (file_path, filename, original_filename, file_size_bytes, mime_type, original_mime_type, image_width, image_height, thumbnail_path)
-            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
-			attachment.File.FilePath, attachment.File.Filename, attachment.File.OriginalFilename, attachment.File.SizeBytes,
-			attachment.File.MimeType, attachment.File.OriginalMimeType, attachment.File.ImageWidth, attachment.File.ImageHeight, attachment.File.ThumbnailPath,
-		).Scan(&fileId)
+		fileId, err := s.insertFile(q, attachment.File)
 		if err != nil {
-			return fmt.Errorf("failed to insert file: %w", err)
+			return err
+		}
+		if err := s.insertAttachment(q, board, threadId, messageID, fileId); err != nil {
+			return err
 		}
+	}
 
-		// Insert attachment record
-		attachPartitionName := PartitionName(board, "attachments")
-		_, err = q.Exec(fmt.Sprintf(`
-            INSERT INTO %s (board, thread_id, message_id, file_id) VALUES ($1, $2, $3, $4)`, attachPartitionName),
-			board, threadId, messageID, fileId,
+	return nil
+}
+
+// insertFile stores the file record and the metadata stripped from it.
+func (s *Storage) insertFile(q Querier, file *domain.File) (domain.FileId, error) {
+	var fileId domain.FileId
+	err := q.QueryRow(`
+            INSERT INTO files (file_path, filename, original_filename, file_size_bytes, mime_type, original_mime_type, image_width, image_height, thumbnail_path, sha256, thumbnail_sha256, text_preview)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, '')) RETURNING id`,
+		file.FilePath, file.Filename, file.OriginalFilename, file.SizeBytes,
+		file.MimeType, file.OriginalMimeType, file.ImageWidth, file.ImageHeight, file.ThumbnailPath,
+		file.Sha256, file.ThumbnailSha256, file.TextPreview,
+	).Scan(&fileId)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert file: %w", err)
+	}
+
+	if meta := file.StrippedMetadata; meta != nil {
+		_, err = q.Exec(`
+            INSERT INTO file_metadata (file_id, camera_make, camera_model, software, has_gps) VALUES ($1, $2, $3, $4, $5)`,
+			fileId, meta.CameraMake, meta.CameraModel, meta.Software, meta.HasGPS,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert attachment link: %w", err)
+			return 0, fmt.Errorf("failed to insert file metadata: %w", err)
 		}
 	}
+	return fileId, nil
+}
 
+func (s *Storage) insertAttachment(q Querier, board domain.BoardShortName, threadId domain.ThreadId, messageID domain.MsgId, fileId domain.FileId) error {
+	attachPartitionName := PartitionName(board, "attachments")
+	_, err := q.Exec(fmt.Sprintf(`
+            INSERT INTO %s (board, thread_id, message_id, file_id) VALUES ($1, $2, $3, $4)`, attachPartitionName),
+		board, threadId, messageID, fileId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert attachment link: %w", err)
+	}
 	return nil
 }
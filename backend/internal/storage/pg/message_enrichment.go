@@ -1,7 +1,10 @@
 package pg
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/itchan-dev/itchan/shared/utils"
@@ -15,87 +18,144 @@ type MsgKey struct {
 	MsgId    domain.MsgId
 }
 
-// enrichMessagesWithReplies fetches and attaches reply data to messages.
-// It queries message_replies table for the given board and message keys,
-// and populates the Replies field of each message in the idToMessage map.
-//
-// This function is board-specific due to table partitioning.
-// Call it once per board when enriching cross-board message lists.
-func enrichMessagesWithReplies(
-	q Querier,
-	board domain.BoardShortName,
-	messageKeys []MsgKey,
-	idToMessage map[MsgKey]*domain.Message,
-	messagesPerPage int,
-) error {
-	if len(messageKeys) == 0 {
-		return nil // No messages to enrich
-	}
+// attachmentColumns selects an attachment joined with its file, in the order
+// scanAttachment expects. Queries alias attachments as a and files as f.
+const attachmentColumns = `
+	a.id, a.board, a.thread_id, a.message_id, a.file_id,
+	f.file_path, f.filename, f.original_filename, f.file_size_bytes, f.mime_type, f.original_mime_type,
+	f.image_width, f.image_height, f.thumbnail_path,
+	COALESCE(f.sha256, ''), COALESCE(f.thumbnail_sha256, ''), COALESCE(f.text_preview, '')`
 
-	// Build arrays of thread_ids and msg_ids for the query
-	threadIds := make([]int64, len(messageKeys))
-	msgIds := make([]int64, len(messageKeys))
-	for i, key := range messageKeys {
-		threadIds[i] = int64(key.ThreadId)
-		msgIds[i] = int64(key.MsgId)
+// scanAttachment scans one row selected with attachmentColumns.
+func scanAttachment(scanner interface {
+	Scan(dest ...any) error
+}) (*domain.Attachment, error) {
+	var attachment domain.Attachment
+	var file domain.File
+	if err := scanner.Scan(
+		&attachment.Id, &attachment.Board, &attachment.ThreadId, &attachment.MessageId, &attachment.FileId,
+		&file.FilePath, &file.Filename, &file.OriginalFilename, &file.SizeBytes, &file.MimeType, &file.OriginalMimeType,
+		&file.ImageWidth, &file.ImageHeight, &file.ThumbnailPath,
+		&file.Sha256, &file.ThumbnailSha256, &file.TextPreview,
+	); err != nil {
+		return nil, err
 	}
+	attachment.File = &file
+	return &attachment, nil
+}
 
-	// Query replies matching our message keys using JOIN with unnest for optimal performance
-	rows, err := q.Query(`
-		SELECT
-			mr.sender_message_id,
-			mr.sender_thread_id,
-			mr.receiver_message_id,
-			mr.receiver_thread_id,
-			mr.created_at
-		FROM message_replies mr
-		JOIN unnest($2::bigint[], $3::bigint[]) AS keys(thread_id, msg_id)
-		  ON mr.receiver_thread_id = keys.thread_id
-		  AND mr.receiver_message_id = keys.msg_id
-		WHERE mr.board = $1
-		ORDER BY mr.created_at
-	`, board, pq.Array(threadIds), pq.Array(msgIds))
-	if err != nil {
-		return fmt.Errorf("failed to fetch replies for board %s: %w", board, err)
-	}
-	defer rows.Close()
+// enrichedReply and enrichedAttachment are the JSON objects enrichMessages
+// aggregates per message.
+type enrichedReply struct {
+	From           domain.MsgId      `json:"from"`
+	FromThreadId   domain.ThreadId   `json:"from_thread_id"`
+	FromAuthor     domain.UserId     `json:"from_author"`
+	FromPostNumber domain.PostNumber `json:"from_post_number"`
+	CreatedAt      dbTime            `json:"created_at"`
+}
 
-	for rows.Next() {
-		var reply domain.Reply
-		if err := rows.Scan(
-			&reply.From,
-			&reply.FromThreadId,
-			&reply.To,
-			&reply.ToThreadId,
-			&reply.CreatedAt,
-		); err != nil {
-			return fmt.Errorf("failed to scan reply row for board %s: %w", board, err)
-		}
+type enrichedAttachment struct {
+	Id               domain.AttachmentId `json:"id"`
+	FileId           domain.FileId       `json:"file_id"`
+	FilePath         string              `json:"file_path"`
+	Filename         string              `json:"filename"`
+	OriginalFilename string              `json:"original_filename"`
+	SizeBytes        int64               `json:"size_bytes"`
+	MimeType         string              `json:"mime_type"`
+	OriginalMimeType string              `json:"original_mime_type"`
+	ImageWidth       *int                `json:"image_width"`
+	ImageHeight      *int                `json:"image_height"`
+	ThumbnailPath    *string             `json:"thumbnail_path"`
+	Sha256           string              `json:"sha256"`
+	ThumbnailSha256  string              `json:"thumbnail_sha256"`
+	TextPreview      string              `json:"text_preview"`
+}
 
-		reply.Board = board
-		// From (sender_message_id) is the per-thread sequential ID, which is also the ordinal
-		reply.FromPage = utils.CalculatePage(int(reply.From), messagesPerPage)
+// dbTime reads the timestamps of JSON built by the database, which carry no
+// time zone; they are UTC like every stored time.
+type dbTime time.Time
 
-		key := MsgKey{ThreadId: reply.ToThreadId, MsgId: reply.To}
-		if msg, ok := idToMessage[key]; ok {
-			msg.Replies = append(msg.Replies, &reply)
-		}
+func (t *dbTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.Parse("2006-01-02T15:04:05.999999999", strings.TrimSuffix(s, "Z"))
+	if err != nil {
+		return err
 	}
+	*t = dbTime(parsed)
+	return nil
+}
 
-	return rows.Err()
+// enrichMessagesQuery returns, for each (thread_id, msg_id) pair of $2 and $3,
+// the replies to the message and its attachments as JSON arrays (NULL when
+// there are none), so a page is enriched in one round trip.
+func enrichMessagesQuery(hideShadowbanned bool) string {
+	replyFilter := ""
+	if hideShadowbanned {
+		replyFilter = `
+			  AND NOT EXISTS (SELECT 1 FROM user_shadowbans sb WHERE sb.board = sm.board AND sb.user_id = sm.author_id)`
+	}
+	return `
+		SELECT
+			keys.thread_id,
+			keys.msg_id,
+			(SELECT json_agg(json_build_object(
+					'from', mr.sender_message_id,
+					'from_thread_id', mr.sender_thread_id,
+					'from_author', sm.author_id,
+					'from_post_number', sm.post_number,
+					'created_at', mr.created_at
+				) ORDER BY mr.created_at)
+			FROM message_replies mr
+			JOIN messages sm
+			  ON sm.board = mr.board
+			  AND sm.thread_id = mr.sender_thread_id
+			  AND sm.id = mr.sender_message_id
+			WHERE mr.board = $1
+			  AND mr.receiver_thread_id = keys.thread_id
+			  AND mr.receiver_message_id = keys.msg_id` + replyFilter + `
+			),
+			(SELECT json_agg(json_build_object(
+					'id', a.id,
+					'file_id', a.file_id,
+					'file_path', f.file_path,
+					'filename', f.filename,
+					'original_filename', f.original_filename,
+					'size_bytes', f.file_size_bytes,
+					'mime_type', f.mime_type,
+					'original_mime_type', f.original_mime_type,
+					'image_width', f.image_width,
+					'image_height', f.image_height,
+					'thumbnail_path', f.thumbnail_path,
+					'sha256', COALESCE(f.sha256, ''),
+					'thumbnail_sha256', COALESCE(f.thumbnail_sha256, ''),
+					'text_preview', COALESCE(f.text_preview, '')
+				) ORDER BY a.id)
+			FROM attachments a
+			JOIN files f ON a.file_id = f.id
+			WHERE a.board = $1
+			  AND a.thread_id = keys.thread_id
+			  AND a.message_id = keys.msg_id
+			)
+		FROM unnest($2::bigint[], $3::bigint[]) AS keys(thread_id, msg_id)`
 }
 
-// enrichMessagesWithAttachments fetches and attaches file attachments to messages.
-// It queries attachments and files tables with JOIN for the given board and message keys,
-// and populates the Attachments field of each message in the idToMessage map.
+// enrichMessages fetches the replies and attachments of the messages in one
+// query and attaches them to the messages in idToMessage. With
+// hideShadowbanned, replies sent by users shadowbanned on the board are left
+// out.
 //
 // This function is board-specific due to table partitioning.
 // Call it once per board when enriching cross-board message lists.
-func enrichMessagesWithAttachments(
+func enrichMessages(
 	q Querier,
 	board domain.BoardShortName,
 	messageKeys []MsgKey,
 	idToMessage map[MsgKey]*domain.Message,
+	messagesPerPage int,
+	hideShadowbanned bool,
 ) error {
 	if len(messageKeys) == 0 {
 		return nil // No messages to enrich
@@ -109,52 +169,83 @@ func enrichMessagesWithAttachments(
 		msgIds[i] = int64(key.MsgId)
 	}
 
-	// Query attachments matching our message keys using JOIN with unnest for optimal performance
-	rows, err := q.Query(`
-		SELECT
-			a.id,
-			a.board,
-			a.thread_id,
-			a.message_id,
-			a.file_id,
-			f.file_path,
-			f.filename,
-			f.original_filename,
-			f.file_size_bytes,
-			f.mime_type,
-			f.original_mime_type,
-			f.image_width,
-			f.image_height,
-			f.thumbnail_path
-		FROM attachments a
-		JOIN files f ON a.file_id = f.id
-		JOIN unnest($2::bigint[], $3::bigint[]) AS keys(thread_id, msg_id)
-		  ON a.thread_id = keys.thread_id
-		  AND a.message_id = keys.msg_id
-		WHERE a.board = $1
-		ORDER BY a.id
-	`, board, pq.Array(threadIds), pq.Array(msgIds))
+	rows, err := q.Query(enrichMessagesQuery(hideShadowbanned), board, pq.Array(threadIds), pq.Array(msgIds))
 	if err != nil {
-		return fmt.Errorf("failed to fetch attachments for board %s: %w", board, err)
+		return fmt.Errorf("failed to fetch replies and attachments for board %s: %w", board, err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var attachment domain.Attachment
-		var file domain.File
-		if err := rows.Scan(
-			&attachment.Id, &attachment.Board, &attachment.ThreadId, &attachment.MessageId, &attachment.FileId,
-			&file.FilePath, &file.Filename, &file.OriginalFilename, &file.SizeBytes,
-			&file.MimeType, &file.OriginalMimeType, &file.ImageWidth, &file.ImageHeight, &file.ThumbnailPath,
-		); err != nil {
-			return fmt.Errorf("failed to scan attachment row for board %s: %w", board, err)
+		var key MsgKey
+		var repliesJSON, attachmentsJSON []byte
+		if err := rows.Scan(&key.ThreadId, &key.MsgId, &repliesJSON, &attachmentsJSON); err != nil {
+			return fmt.Errorf("failed to scan enrichment row for board %s: %w", board, err)
+		}
+		msg, ok := idToMessage[key]
+		if !ok {
+			continue
 		}
-		attachment.File = &file
-		key := MsgKey{ThreadId: attachment.ThreadId, MsgId: attachment.MessageId}
-		if msg, ok := idToMessage[key]; ok {
-			msg.Attachments = append(msg.Attachments, &attachment)
+		if err := attachEnrichment(msg, board, key, repliesJSON, attachmentsJSON, messagesPerPage); err != nil {
+			return fmt.Errorf("failed to decode enrichment for board %s: %w", board, err)
 		}
 	}
 
 	return rows.Err()
 }
+
+// attachEnrichment decodes the JSON arrays of one enrichMessages row into msg.
+func attachEnrichment(msg *domain.Message, board domain.BoardShortName, key MsgKey, repliesJSON, attachmentsJSON []byte, messagesPerPage int) error {
+	if repliesJSON != nil {
+		var replies []enrichedReply
+		if err := json.Unmarshal(repliesJSON, &replies); err != nil {
+			return err
+		}
+		for _, r := range replies {
+			msg.Replies = append(msg.Replies, &domain.Reply{
+				Board:          board,
+				From:           r.From,
+				FromThreadId:   r.FromThreadId,
+				To:             key.MsgId,
+				ToThreadId:     key.ThreadId,
+				CreatedAt:      time.Time(r.CreatedAt),
+				FromAuthor:     r.FromAuthor,
+				FromPostNumber: r.FromPostNumber,
+				// From (sender_message_id) is the per-thread sequential ID, which is also the ordinal
+				FromPage: utils.CalculatePage(int(r.From), messagesPerPage),
+			})
+		}
+	}
+
+	if attachmentsJSON != nil {
+		var attachments []enrichedAttachment
+		if err := json.Unmarshal(attachmentsJSON, &attachments); err != nil {
+			return err
+		}
+		for _, a := range attachments {
+			msg.Attachments = append(msg.Attachments, &domain.Attachment{
+				Id:        a.Id,
+				Board:     board,
+				ThreadId:  key.ThreadId,
+				MessageId: key.MsgId,
+				FileId:    a.FileId,
+				File: &domain.File{
+					FileCommonMetadata: domain.FileCommonMetadata{
+						Filename:    a.Filename,
+						SizeBytes:   a.SizeBytes,
+						MimeType:    a.MimeType,
+						ImageWidth:  a.ImageWidth,
+						ImageHeight: a.ImageHeight,
+					},
+					FilePath:         a.FilePath,
+					OriginalFilename: a.OriginalFilename,
+					OriginalMimeType: a.OriginalMimeType,
+					ThumbnailPath:    a.ThumbnailPath,
+					Sha256:           a.Sha256,
+					ThumbnailSha256:  a.ThumbnailSha256,
+					TextPreview:      a.TextPreview,
+				},
+			})
+		}
+	}
+	return nil
+}
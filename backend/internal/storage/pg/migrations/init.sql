@@ -7,19 +7,38 @@ CREATE TABLE IF NOT EXISTS users (
     password_hash          text NOT NULL,
     is_admin               boolean default false,
     created_at             timestamp default (now() at time zone 'utc'),
-    referral_source        varchar(100)
+    referral_source        varchar(100),
+    email_index            bytea  -- Keyed blind index of the email for admin lookup; NULL until indexed
 );
 
 -- Index on email_hash for fast lookups (unique constraint already creates an index)
 -- Index on email_domain for board permission queries
 CREATE INDEX IF NOT EXISTS idx_users_email_domain ON users(email_domain);
+-- Admin lookup by email
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_index ON users(email_index) WHERE email_index IS NOT NULL;
+
+-- Reserved author of system messages (domain.SystemUserId). The id is below
+-- the serial range and no password or email hashes to the empty values, so
+-- it can never log in.
+INSERT INTO users (id, email_encrypted, email_domain, email_hash, password_hash, is_admin)
+VALUES (-1, '', '', '', '', false)
+ON CONFLICT DO NOTHING;
+
+-- Reserved author of posts copied from other instances (domain.MirrorUserId),
+-- locked out the same way
+INSERT INTO users (id, email_encrypted, email_domain, email_hash, password_hash, is_admin)
+VALUES (-2, '', '', 'mirror', '', false)
+ON CONFLICT DO NOTHING;
 
 -- Stores blacklisted users
+-- An automatic permanent ban (no blacklisted_by, no expires_at) is a
+-- directory sync deactivation, lifted by the sync when the user is listed again
 CREATE TABLE IF NOT EXISTS user_blacklist (
     user_id        int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     blacklisted_at timestamp NOT NULL DEFAULT (now() at time zone 'utc'),
     reason         text,
-    blacklisted_by int REFERENCES users(id),
+    blacklisted_by int REFERENCES users(id),      -- NULL for automatic bans
+    expires_at     timestamp,                     -- NULL for permanent bans
     PRIMARY KEY (user_id)
 );
 
@@ -27,6 +46,56 @@ CREATE TABLE IF NOT EXISTS user_blacklist (
 CREATE INDEX IF NOT EXISTS idx_user_blacklist_time
     ON user_blacklist (blacklisted_at DESC);
 
+-- Ban appeals. The ban columns snapshot the user_blacklist row being appealed;
+-- (user_id, banned_at) identifies that ban, so each ban can be appealed once.
+CREATE TABLE IF NOT EXISTS ban_appeals (
+    id          bigserial PRIMARY KEY,
+    user_id     int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    banned_at   timestamp NOT NULL,
+    ban_reason  text,
+    banned_by   int REFERENCES users(id),
+    text        text NOT NULL,
+    status      varchar(10) NOT NULL default 'pending',
+    response    text NOT NULL default '',
+    decided_by  int REFERENCES users(id),
+    created_at  timestamp NOT NULL default (now() at time zone 'utc'),
+    decided_at  timestamp,
+    UNIQUE (user_id, banned_at)
+);
+CREATE INDEX IF NOT EXISTS idx_ban_appeals_status ON ban_appeals (status, created_at);
+
+-- Devices a user has logged in from, identified by a hash of the user agent
+-- and the coarse IP. A login from a device not listed here is emailed to the
+-- user with a link carrying revoke_token_hash's token.
+CREATE TABLE IF NOT EXISTS login_devices (
+    user_id           int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fingerprint       varchar(64) NOT NULL,
+    revoke_token_hash varchar(64) NOT NULL UNIQUE,
+    first_seen_at     timestamp NOT NULL DEFAULT (now() at time zone 'utc'),
+    last_seen_at      timestamp NOT NULL DEFAULT (now() at time zone 'utc'),
+    PRIMARY KEY (user_id, fingerprint)
+);
+
+-- Tokens issued to a user before revoked_at are no longer accepted
+CREATE TABLE IF NOT EXISTS session_revocations (
+    user_id    int PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    revoked_at timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_session_revocations_time ON session_revocations (revoked_at DESC);
+
+-- Idempotency-Key headers of post creation requests. status_code is NULL
+-- while the request runs; afterwards the response is replayed to retries.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id      int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key          varchar(255) NOT NULL,
+    request_path text NOT NULL,
+    status_code  int,
+    response     bytea,
+    created_at   timestamp NOT NULL DEFAULT (now() at time zone 'utc'),
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_time ON idempotency_keys (created_at);
+
 -- Used for account confirmation or password resets
 CREATE TABLE IF NOT EXISTS confirmation_data (
     email_hash             bytea PRIMARY KEY,
@@ -36,14 +105,51 @@ CREATE TABLE IF NOT EXISTS confirmation_data (
     created_at             timestamp default (now() at time zone 'utc')
 );
 
+-- Groups boards on the index page (e.g. "Technology", "Creative")
+CREATE TABLE IF NOT EXISTS board_categories (
+    id         serial PRIMARY KEY,
+    name       varchar(100) NOT NULL UNIQUE,
+    position   int NOT NULL default 0,
+    created_at timestamp NOT NULL default (now() at time zone 'utc')
+);
+
 -- Represents a message board
 CREATE TABLE IF NOT EXISTS boards (
     short_name             varchar(10) PRIMARY KEY,
     name                   varchar(254) NOT NULL,
+    description            text NOT NULL default '',
     created_at             timestamp default (now() at time zone 'utc'),
     last_activity_at       timestamp default (now() at time zone 'utc'),
-    view_last_modified_at  timestamp default (now() at time zone 'utc')
+    view_last_modified_at  timestamp default (now() at time zone 'utc'),
+    category_id            int REFERENCES board_categories(id) ON DELETE SET NULL,
+    position               int NOT NULL default 0,
+    show_deletion_stubs    boolean NOT NULL default false,
+    noindex                boolean NOT NULL default false, -- ask search engines not to index the board
+    -- Thread creation requirements (0/false = no requirement)
+    min_op_text_length           int NOT NULL default 0 CHECK (min_op_text_length >= 0),
+    require_op_attachment        boolean NOT NULL default false,
+    max_threads_per_user_per_day int NOT NULL default 0 CHECK (max_threads_per_user_per_day >= 0),
+    video_profile          text NOT NULL default '', -- media.video_profiles entry, '' = default profile
+    allow_documents        boolean NOT NULL default false, -- accept PDF and plain text attachments
+    max_attachments        integer CHECK (max_attachments >= 0), -- NULL = site-wide limit, 0 = no attachments
+    allowed_mime_types     text NOT NULL default '', -- comma-separated subset of the site types, '' = all
+    public_modlog          boolean NOT NULL default false, -- publish the redacted moderation log
+    self_delete_replied    boolean NOT NULL default false, -- authors may also delete posts that have replies
+    text_filter            text NOT NULL default '', -- zero-width, bidi and zalgo characters: strip, reject or off ('' = strip)
+    -- Posting rules on top of visibility ('' / 0 = everyone who can read may post)
+    posting_email_domains        text NOT NULL default '', -- comma-separated email domains whose accounts may post
+    posting_min_account_age_days int NOT NULL default 0 CHECK (posting_min_account_age_days >= 0),
+    bump_limit_notice      boolean NOT NULL default false, -- post a system message when a thread reaches the bump limit
+    pre_moderation         boolean NOT NULL default false, -- hold posts of untrusted users until a moderator approves them
+    -- Automatic trust under pre-moderation (0 = rule off)
+    premod_trust_approvals        int NOT NULL default 0 CHECK (premod_trust_approvals >= 0),
+    premod_trust_account_age_days int NOT NULL default 0 CHECK (premod_trust_account_age_days >= 0),
+    language               text NOT NULL default '', -- interface strings, dates and length counting: en, ru, ja, zh or ko ('' = en)
+    custom_css             text NOT NULL default '', -- admin stylesheet, sanitized by the frontend when served
+    delete_after           timestamp, -- set while the board waits out its deletion grace period, NULL = live
+    next_post_number       bigint NOT NULL default 1 -- board-local number of the next message, never reused
 );
+COMMENT ON COLUMN boards.category_id IS 'NULL means the board is listed outside of any category';
 
 CREATE TABLE IF NOT EXISTS board_permissions (
     board_short_name     varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
@@ -53,6 +159,34 @@ CREATE TABLE IF NOT EXISTS board_permissions (
 -- Index to quickly find all boards a user can access
 CREATE INDEX IF NOT EXISTS idx_board_permissions_email ON board_permissions (allowed_email_domain);
 
+-- Banner images of a board, one is picked at random for every page view.
+-- Files live under <board>/banners/ in the media storage.
+CREATE TABLE IF NOT EXISTS board_banners (
+    id          serial PRIMARY KEY,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    file_path   text NOT NULL,
+    created_at  timestamp NOT NULL default (now() at time zone 'utc')
+);
+CREATE INDEX IF NOT EXISTS idx_board_banners_board ON board_banners (board);
+
+-- Short names boards were renamed from. Links to the old name redirect to the
+-- new one until expires_at; renaming the board again follows the chain.
+CREATE TABLE IF NOT EXISTS board_redirects (
+    old_short_name  varchar(10) PRIMARY KEY,
+    new_short_name  varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    expires_at      timestamp NOT NULL
+);
+
+-- Hourly post counts per board, rolled up from messages in the background
+-- for the admin activity charts and kept for board_stats_retention.
+CREATE TABLE IF NOT EXISTS board_post_counts (
+    board  varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    hour   timestamp NOT NULL,
+    posts  int NOT NULL,
+    PRIMARY KEY (board, hour)
+);
+CREATE INDEX IF NOT EXISTS idx_board_post_counts_hour ON board_post_counts (hour);
+
 -- Represents a thread on a board
 -- metainfo in first thread message
 CREATE TABLE IF NOT EXISTS threads (
@@ -65,6 +199,8 @@ CREATE TABLE IF NOT EXISTS threads (
 	last_modified_at timestamp NOT NULL default (now() at time zone 'utc'),
     created_at       timestamp NOT NULL default (now() at time zone 'utc'),
     is_pinned        boolean NOT NULL default false,
+    op_only          boolean NOT NULL default false, -- only the OP and moderators may reply
+    bump_limit_noticed boolean NOT NULL default false, -- the bump limit notice was posted
     PRIMARY KEY (board, id)
 ) PARTITION BY LIST (board);
 COMMENT ON COLUMN threads.next_message_id IS 'Next available message ID (sequential, never decrements). Used for message ID assignment to prevent gaps from causing PK violations.';
@@ -79,14 +215,35 @@ CREATE TABLE IF NOT EXISTS messages (
     author_id   int NOT NULL REFERENCES users(id),
     text        text NOT NULL,
     show_email_domain boolean NOT NULL DEFAULT false,
+    post_number bigint NOT NULL, -- board-local sequential number across all threads
     created_at  timestamp NOT NULL default (now() at time zone 'utc'),
     updated_at  timestamp NOT NULL default (now() at time zone 'utc'),
+    content_hash bytea, -- SHA-256 of the content for archive exports; NULL for bulk-loaded messages
+    author_commitment bytea, -- HMAC of the author covered by content_hash, kept so key rotation doesn't break it
 
     PRIMARY KEY (board, thread_id, id),
     FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
 ) PARTITION BY LIST (board);
 -- Get all messages by a user (for moderation, user history, etc.)
 CREATE INDEX IF NOT EXISTS idx_messages_author ON messages (author_id);
+-- Resolve a board-local post number to its thread and message
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_post_number ON messages (board, post_number);
+-- Latest posts feed and "posts today" counter on the index page
+CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages (created_at DESC);
+
+-- Messages shown in board page previews (OP and last n_last_msg of every thread).
+-- Used instead of the per-board materialized views when incremental_board_previews
+-- is enabled; kept up to date in the same transaction as every post and deletion.
+-- Threads started by users shadowbanned on the board have no rows at all.
+-- No foreign key to the partitioned messages table, so dropping a board's
+-- partitions does not depend on it.
+CREATE TABLE IF NOT EXISTS thread_previews (
+    board      varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id  bigint NOT NULL,
+    msg_id     int NOT NULL,
+
+    PRIMARY KEY (board, thread_id, msg_id)
+);
 
 CREATE TABLE IF NOT EXISTS files (
     id                 bigserial PRIMARY KEY,
@@ -98,8 +255,10 @@ CREATE TABLE IF NOT EXISTS files (
     original_mime_type varchar(255) NOT NULL,
     image_width        int,
     image_height       int,
-    thumbnail_path     text
-    -- file_hash_sha256  bytea UNIQUE
+    thumbnail_path     text,
+    sha256             char(64),  -- Hex content hash for /media/sha256/ URLs; NULL for files uploaded before hashing
+    thumbnail_sha256   char(64),
+    text_preview       text       -- Leading excerpt of text/plain attachments, shown inline
 );
 COMMENT ON COLUMN files.filename IS 'Sanitized filename stored on disk (may differ from upload if sanitized, e.g., photo.gif -> photo.jpg)';
 COMMENT ON COLUMN files.original_filename IS 'Filename as uploaded by user (before any sanitization)';
@@ -107,6 +266,30 @@ COMMENT ON COLUMN files.original_filename IS 'Filename as uploaded by user (befo
 CREATE INDEX IF NOT EXISTS idx_files_thumbnail_path 
 ON files (thumbnail_path) 
 WHERE thumbnail_path IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_files_sha256 ON files (sha256) WHERE sha256 IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_files_thumbnail_sha256 ON files (thumbnail_sha256) WHERE thumbnail_sha256 IS NOT NULL;
+
+-- Metadata stripped from uploads at sanitize time, only exposed to admins.
+-- GPS coordinates are never stored, only whether the upload had them.
+CREATE TABLE IF NOT EXISTS file_metadata (
+    file_id      bigint PRIMARY KEY REFERENCES files(id) ON DELETE CASCADE,
+    camera_make  text NOT NULL default '',
+    camera_model text NOT NULL default '',
+    software     text NOT NULL default '',
+    has_gps      boolean NOT NULL default false
+);
+
+-- Files uploaded ahead of the post that will carry them. Creating the post
+-- turns the row into an attachment; the media GC drops unclaimed ones.
+CREATE TABLE IF NOT EXISTS pending_uploads (
+    token      varchar(64) PRIMARY KEY,
+    board      varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    user_id    int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_id    bigint NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    created_at timestamp NOT NULL DEFAULT (now() at time zone 'utc')
+);
+CREATE INDEX IF NOT EXISTS idx_pending_uploads_created ON pending_uploads (created_at);
+CREATE INDEX IF NOT EXISTS idx_pending_uploads_file ON pending_uploads (file_id);
 
 -- Sequence for attachments (global, used across all board partitions)
 CREATE SEQUENCE IF NOT EXISTS attachments_id_seq;
@@ -146,6 +329,230 @@ CREATE TABLE IF NOT EXISTS message_replies (
 CREATE INDEX IF NOT EXISTS idx_message_replies_receiver_msg
     ON message_replies (board, receiver_thread_id, receiver_message_id);
 
+-- Moderator reasons for deleted messages. Rows outlive the message itself so
+-- thread views can show a stub in its place (see boards.show_deletion_stubs).
+CREATE TABLE IF NOT EXISTS message_deletions (
+    board       varchar(10) NOT NULL,
+    thread_id   bigint NOT NULL,
+    message_id  int NOT NULL,
+    reason      text NOT NULL default '',
+    deleted_by  int REFERENCES users(id) ON DELETE SET NULL,
+    author_id   int REFERENCES users(id) ON DELETE CASCADE,
+    by_author   boolean NOT NULL default false, -- the author deleted their own post
+    deleted_at  timestamp NOT NULL default (now() at time zone 'utc'),
+
+    PRIMARY KEY (board, thread_id, message_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+
+-- Auto-ban escalation counts recent deletions per author
+CREATE INDEX IF NOT EXISTS idx_message_deletions_author
+    ON message_deletions (author_id, deleted_at DESC);
+
+-- Thread title changes by the OP or a moderator, kept for moderation review
+CREATE TABLE IF NOT EXISTS thread_title_edits (
+    id           bigserial PRIMARY KEY,
+    board        varchar(10) NOT NULL,
+    thread_id    bigint NOT NULL,
+    old_title    text NOT NULL,
+    new_title    text NOT NULL,
+    edited_by    int REFERENCES users(id) ON DELETE SET NULL,
+    by_moderator boolean NOT NULL default false,
+    edited_at    timestamp NOT NULL default (now() at time zone 'utc'),
+
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_thread_title_edits_thread
+    ON thread_title_edits (board, thread_id, edited_at);
+
+-- A thread continued in a newer thread of the same board, typically once it
+-- stopped bumping. Each thread has at most one successor and continues at
+-- most one thread.
+CREATE TABLE IF NOT EXISTS thread_successors (
+    board        varchar(10) NOT NULL,
+    thread_id    bigint NOT NULL,
+    successor_id bigint NOT NULL CHECK (successor_id > thread_id),
+    set_by       int REFERENCES users(id) ON DELETE SET NULL,
+    created_at   timestamp NOT NULL default (now() at time zone 'utc'),
+
+    PRIMARY KEY (board, thread_id),
+    UNIQUE (board, successor_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE,
+    FOREIGN KEY (board, successor_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+
+-- Posts held back on boards with pre_moderation until a moderator approves
+-- them. thread_id is NULL for a new thread, which then carries its title.
+CREATE TABLE IF NOT EXISTS premod_queue (
+    id                bigserial PRIMARY KEY,
+    board             varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id         bigint,
+    title             text NOT NULL default '',
+    op_only           boolean NOT NULL default false,
+    author_id         int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    text              text NOT NULL,
+    show_email_domain boolean NOT NULL default false,
+    created_at        timestamp NOT NULL default (now() at time zone 'utc'),
+
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_premod_queue_board ON premod_queue (board, created_at);
+
+-- Files of queued posts, kept out of the orphan cleanup until the post is
+-- approved or rejected
+CREATE TABLE IF NOT EXISTS premod_queue_files (
+    post_id  bigint NOT NULL REFERENCES premod_queue(id) ON DELETE CASCADE,
+    file_id  bigint NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    position int NOT NULL,
+
+    PRIMARY KEY (post_id, position)
+);
+CREATE INDEX IF NOT EXISTS idx_premod_queue_files_file ON premod_queue_files (file_id);
+
+-- Messages a queued post replies to
+CREATE TABLE IF NOT EXISTS premod_queue_replies (
+    post_id             bigint NOT NULL REFERENCES premod_queue(id) ON DELETE CASCADE,
+    receiver_thread_id  bigint NOT NULL,
+    receiver_message_id bigint NOT NULL,
+
+    PRIMARY KEY (post_id, receiver_thread_id, receiver_message_id)
+);
+
+-- Pre-moderation standing of a user on a board: approved posts so far and the
+-- moderator's override of the board's trust rules
+CREATE TABLE IF NOT EXISTS premod_posters (
+    board      varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    user_id    int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    approvals  int NOT NULL default 0,
+    trusted    boolean, -- true always skips the queue, false always holds, NULL = board rules
+    updated_by int REFERENCES users(id) ON DELETE SET NULL, -- moderator who last set trusted
+    updated_at timestamp NOT NULL default (now() at time zone 'utc'),
+
+    PRIMARY KEY (board, user_id)
+);
+
+-- Boards kept as read-only copies of a board of another instance. Nobody can
+-- post on them; the background sync writes what the source has.
+CREATE TABLE IF NOT EXISTS board_mirrors (
+    board          varchar(10) PRIMARY KEY REFERENCES boards(short_name) ON DELETE CASCADE,
+    source_url     text NOT NULL,
+    source_board   varchar(10) NOT NULL,
+    created_by     int REFERENCES users(id) ON DELETE SET NULL,
+    created_at     timestamp NOT NULL default (now() at time zone 'utc'),
+    last_synced_at timestamp,                  -- NULL until a sync goes through
+    last_error     text NOT NULL default ''    -- why the last sync failed
+);
+
+-- Sync state of each copied thread. Copied threads and messages keep their
+-- source ids. Rows outlive threads deleted by moderators here, so neither they
+-- nor messages up to last_message_id are copied again.
+CREATE TABLE IF NOT EXISTS mirrored_threads (
+    board              varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id          bigint NOT NULL,
+    source_modified_at timestamp NOT NULL,
+    last_message_id    int NOT NULL,
+    synced_at          timestamp NOT NULL default (now() at time zone 'utc'),
+
+    PRIMARY KEY (board, thread_id)
+);
+
+-- Redacted record of moderator actions, published per board when the board
+-- enables public_modlog. Holds no user identifiers on purpose.
+CREATE TABLE IF NOT EXISTS moderation_log (
+    id          bigserial PRIMARY KEY,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    action      varchar(20) NOT NULL,
+    reason      text NOT NULL default '',
+    created_at  timestamp NOT NULL default (now() at time zone 'utc')
+);
+CREATE INDEX IF NOT EXISTS idx_moderation_log_board
+    ON moderation_log (board, created_at DESC);
+
+-- Per-board shadowbans. Messages of a shadowbanned user are still stored, but only
+-- the user and admins can see them; they are left out of the board views entirely.
+CREATE TABLE IF NOT EXISTS user_shadowbans (
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    user_id     int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_by  int REFERENCES users(id) ON DELETE SET NULL,
+    created_at  timestamp NOT NULL default (now() at time zone 'utc'),
+
+    PRIMARY KEY (board, user_id)
+);
+
+-- Replies to a user's messages, shown in their notification center. Rows of
+-- deleted messages are removed with the message.
+CREATE TABLE IF NOT EXISTS notifications (
+    id          bigserial PRIMARY KEY,
+    user_id     int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id   bigint NOT NULL,
+    message_id  int NOT NULL,
+    created_at  timestamp NOT NULL default (now() at time zone 'utc'),
+    read_at     timestamp,  -- NULL while unread
+
+    UNIQUE (user_id, board, thread_id, message_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications (user_id) WHERE read_at IS NULL;
+
+-- Messages users saved to come back to. Like notifications, rows of deleted
+-- messages are removed with the message.
+CREATE TABLE IF NOT EXISTS message_bookmarks (
+    user_id     int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id   bigint NOT NULL,
+    message_id  int NOT NULL,
+    created_at  timestamp NOT NULL default (now() at time zone 'utc'),
+
+    PRIMARY KEY (user_id, board, thread_id, message_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_message_bookmarks_user ON message_bookmarks (user_id, created_at DESC);
+
+-- Threads a user watches. New replies in them go into the user's email digest.
+CREATE TABLE IF NOT EXISTS thread_watches (
+    user_id     int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id   bigint NOT NULL,
+    created_at  timestamp NOT NULL default (now() at time zone 'utc'),
+
+    PRIMARY KEY (user_id, board, thread_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+
+-- How far each user has read a thread: the last message id they have seen.
+-- Moves forward only; unread counts and the "unread posts below" divider
+-- compare against it.
+CREATE TABLE IF NOT EXISTS thread_reads (
+    user_id      int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    board        varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id    bigint NOT NULL,
+    last_read_id bigint NOT NULL,
+
+    PRIMARY KEY (user_id, board, thread_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+
+-- Email digest opt-ins. No row means no digest; unsubscribing deletes the row.
+CREATE TABLE IF NOT EXISTS email_digests (
+    user_id            int PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    frequency          varchar(10) NOT NULL CHECK (frequency IN ('daily', 'weekly')),
+    unsubscribe_token  varchar(64) NOT NULL UNIQUE,  -- Used by the link in every digest
+    last_sent_at       timestamp NOT NULL            -- Opt-in time until the first digest is sent
+);
+
+-- Browser Web Push subscriptions. An endpoint belongs to one browser profile,
+-- so subscribing it again (e.g. after logging in as someone else) moves it.
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    endpoint    text PRIMARY KEY,
+    user_id     int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    p256dh      varchar(128) NOT NULL,  -- Browser public key, base64url
+    auth        varchar(64) NOT NULL,   -- Browser authentication secret, base64url
+    created_at  timestamp NOT NULL default (now() at time zone 'utc')
+);
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user ON push_subscriptions (user_id);
+
 -- Stores invite codes (similar to confirmation_data)
 CREATE TABLE IF NOT EXISTS invite_codes (
     code_hash          varchar(80) PRIMARY KEY,      -- bcrypt hash of the invite code
@@ -184,3 +591,70 @@ CREATE TABLE IF NOT EXISTS referral_actions (
     UNIQUE(ip, source, action)
 );
 CREATE INDEX IF NOT EXISTS idx_referral_actions_source ON referral_actions (source);
+
+-- Audit log of admins viewing the site as another user. No foreign keys:
+-- the record must outlive the accounts involved.
+CREATE TABLE IF NOT EXISTS view_as_sessions (
+    id          bigserial PRIMARY KEY,
+    admin_id    int NOT NULL,
+    user_id     int NOT NULL,
+    reason      text NOT NULL,
+    created_at  timestamp NOT NULL DEFAULT (now() at time zone 'utc'),
+    expires_at  timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_view_as_sessions_created ON view_as_sessions (created_at DESC);
+
+-- Scheduled read-only maintenance; at most one row. Writes are refused from
+-- starts_at until ends_at, or until the row is deleted when ends_at is NULL.
+CREATE TABLE IF NOT EXISTS maintenance_window (
+    id         int PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    message    text NOT NULL,
+    starts_at  timestamp NOT NULL,
+    ends_at    timestamp,
+    created_by int,
+    created_at timestamp NOT NULL DEFAULT (now() at time zone 'utc')
+);
+
+-- Failed thread and message creation requests, captured when
+-- failed_post_capture is enabled. request is the sanitized snapshot
+-- (domain.FailedPostRequest): no file contents and no upload tokens.
+CREATE TABLE IF NOT EXISTS failed_posts (
+    id          bigserial PRIMARY KEY,
+    user_id     int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    board       text NOT NULL,
+    thread_id   bigint,
+    status_code int NOT NULL,
+    error       text NOT NULL,
+    request     jsonb NOT NULL,
+    created_at  timestamp NOT NULL DEFAULT (now() at time zone 'utc')
+);
+CREATE INDEX IF NOT EXISTS idx_failed_posts_created ON failed_posts (created_at DESC);
+
+-- Static HTML snapshots of threads. Files live under <board>/snapshots/ in
+-- the media storage. Snapshots outlive their thread, so thread_id is not a
+-- foreign key.
+CREATE TABLE IF NOT EXISTS thread_snapshots (
+    id            bigserial PRIMARY KEY,
+    board         varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id     bigint NOT NULL,
+    title         text NOT NULL,
+    file_path     text NOT NULL,
+    size_bytes    bigint NOT NULL,
+    message_count int NOT NULL,
+    created_by    int REFERENCES users(id) ON DELETE SET NULL,
+    created_at    timestamp NOT NULL DEFAULT (now() at time zone 'utc')
+);
+CREATE INDEX IF NOT EXISTS idx_thread_snapshots_board ON thread_snapshots (board, thread_id, id);
+
+-- Runs of the backup coordinator. status is running, succeeded or failed; a
+-- run cut short by a backend restart stays running. paused_ms is how long
+-- writes were refused while the database command ran.
+CREATE TABLE IF NOT EXISTS backup_runs (
+    id          bigserial PRIMARY KEY,
+    status      text NOT NULL,
+    started_at  timestamp NOT NULL,
+    finished_at timestamp,
+    paused_ms   bigint NOT NULL DEFAULT 0,
+    error       text NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_backup_runs_status ON backup_runs (status, started_at DESC);
@@ -0,0 +1,465 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/lib/pq"
+)
+
+// boardMirrorColumns are the columns scanned by scanBoardMirror.
+const boardMirrorColumns = `board, source_url, source_board, COALESCE(created_by, 0), created_at, last_synced_at, last_error`
+
+// =========================================================================
+// Public Methods (satisfy the service.MirrorStorage interface)
+// =========================================================================
+
+// CreateBoardMirror attaches a mirror to a board. Only a board without
+// threads can become a mirror, so copied ids never meet local ones.
+func (s *Storage) CreateBoardMirror(ctx context.Context, mirror domain.BoardMirror) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.createBoardMirror(tx, mirror)
+	})
+}
+
+// DeleteBoardMirror detaches the mirror. The copied threads stay and the board
+// takes posts again, which lets a mirror replace a source that went away.
+func (s *Storage) DeleteBoardMirror(ctx context.Context, board domain.BoardShortName) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.deleteBoardMirror(tx, board)
+	})
+}
+
+// GetBoardMirror returns the mirror attached to the board.
+func (s *Storage) GetBoardMirror(ctx context.Context, board domain.BoardShortName) (domain.BoardMirror, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	return s.getBoardMirror(q, board)
+}
+
+// GetBoardMirrors returns all mirrors ordered by board.
+func (s *Storage) GetBoardMirrors(ctx context.Context) ([]domain.BoardMirror, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	return s.getBoardMirrors(q)
+}
+
+// RecordBoardMirrorSync stores the outcome of a sync: an empty syncErr marks
+// it as gone through, anything else is kept as the reason it failed.
+func (s *Storage) RecordBoardMirrorSync(ctx context.Context, board domain.BoardShortName, syncErr string) error {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	return s.recordBoardMirrorSync(q, board, syncErr)
+}
+
+// GetMirroredThreads returns the sync state of every thread copied to the board.
+func (s *Storage) GetMirroredThreads(ctx context.Context, board domain.BoardShortName) ([]domain.MirroredThread, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	return s.getMirroredThreads(q, board)
+}
+
+// ApplyMirrorThreadUpdate writes one synced thread in a single transaction,
+// so a failed sync leaves the thread as it was and is retried as a whole.
+func (s *Storage) ApplyMirrorThreadUpdate(ctx context.Context, update domain.MirrorThreadUpdate) error {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.applyMirrorThreadUpdate(tx, update)
+	})
+}
+
+// DeleteMirroredThread removes a copied thread that is gone from the source,
+// with its sync state.
+func (s *Storage) DeleteMirroredThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.deleteMirroredThread(tx, board, id)
+	})
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// =========================================================================
+
+func (s *Storage) createBoardMirror(q Querier, mirror domain.BoardMirror) error {
+	var threads int
+	err := q.QueryRow(`
+		SELECT (SELECT count(*) FROM threads WHERE board = $1)
+		FROM boards WHERE short_name = $1`,
+		mirror.Board,
+	).Scan(&threads)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &internal_errors.ErrorWithStatusCode{Message: "Board not found", StatusCode: http.StatusNotFound}
+		}
+		return fmt.Errorf("failed to check board '%s': %w", mirror.Board, err)
+	}
+	if threads > 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: "Only a board without threads can become a mirror", StatusCode: http.StatusConflict,
+		}
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO board_mirrors (board, source_url, source_board, created_by)
+		VALUES ($1, $2, $3, $4)`,
+		mirror.Board, mirror.SourceURL, mirror.SourceBoard, mirror.CreatedBy,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // Unique violation
+			return &internal_errors.ErrorWithStatusCode{Message: "Board is already a mirror", StatusCode: http.StatusConflict}
+		}
+		return fmt.Errorf("failed to create mirror of board '%s': %w", mirror.Board, err)
+	}
+	return nil
+}
+
+func (s *Storage) deleteBoardMirror(q Querier, board domain.BoardShortName) error {
+	result, err := q.Exec(`DELETE FROM board_mirrors WHERE board = $1`, board)
+	if err != nil {
+		return fmt.Errorf("failed to delete mirror of board '%s': %w", board, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{Message: "Board is not a mirror", StatusCode: http.StatusNotFound}
+	}
+	if _, err := q.Exec(`DELETE FROM mirrored_threads WHERE board = $1`, board); err != nil {
+		return fmt.Errorf("failed to delete mirrored threads of board '%s': %w", board, err)
+	}
+	return nil
+}
+
+func (s *Storage) getBoardMirror(q Querier, board domain.BoardShortName) (domain.BoardMirror, error) {
+	mirror, err := scanBoardMirror(q.QueryRow(`SELECT `+boardMirrorColumns+` FROM board_mirrors WHERE board = $1`, board))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.BoardMirror{}, &internal_errors.ErrorWithStatusCode{Message: "Board is not a mirror", StatusCode: http.StatusNotFound}
+		}
+		return domain.BoardMirror{}, fmt.Errorf("failed to get mirror of board '%s': %w", board, err)
+	}
+	return mirror, nil
+}
+
+func (s *Storage) getBoardMirrors(q Querier) ([]domain.BoardMirror, error) {
+	rows, err := q.Query(`SELECT ` + boardMirrorColumns + ` FROM board_mirrors ORDER BY board`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query board mirrors: %w", err)
+	}
+	defer rows.Close()
+
+	mirrors := []domain.BoardMirror{}
+	for rows.Next() {
+		mirror, err := scanBoardMirror(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan board mirror: %w", err)
+		}
+		mirrors = append(mirrors, mirror)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating board mirrors: %w", err)
+	}
+	return mirrors, nil
+}
+
+func scanBoardMirror(scanner interface {
+	Scan(dest ...any) error
+}) (domain.BoardMirror, error) {
+	var m domain.BoardMirror
+	var lastSynced sql.NullTime
+	if err := scanner.Scan(&m.Board, &m.SourceURL, &m.SourceBoard, &m.CreatedBy, &m.CreatedAt, &lastSynced, &m.LastError); err != nil {
+		return domain.BoardMirror{}, err
+	}
+	if lastSynced.Valid {
+		m.LastSyncedAt = &lastSynced.Time
+	}
+	return m, nil
+}
+
+func (s *Storage) recordBoardMirrorSync(q Querier, board domain.BoardShortName, syncErr string) error {
+	result, err := q.Exec(`
+		UPDATE board_mirrors SET
+			last_error = $2,
+			last_synced_at = CASE WHEN $2 = '' THEN now() at time zone 'utc' ELSE last_synced_at END
+		WHERE board = $1`,
+		board, syncErr,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record sync of board '%s': %w", board, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{Message: "Board is not a mirror", StatusCode: http.StatusNotFound}
+	}
+	return nil
+}
+
+func (s *Storage) getMirroredThreads(q Querier, board domain.BoardShortName) ([]domain.MirroredThread, error) {
+	rows, err := q.Query(`
+		SELECT mt.board, mt.thread_id, mt.source_modified_at, mt.last_message_id,
+		       NOT EXISTS (SELECT 1 FROM threads t WHERE t.board = mt.board AND t.id = mt.thread_id)
+		FROM mirrored_threads mt
+		WHERE mt.board = $1
+		ORDER BY mt.thread_id`,
+		board,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mirrored threads of board '%s': %w", board, err)
+	}
+	defer rows.Close()
+
+	threads := []domain.MirroredThread{}
+	for rows.Next() {
+		var t domain.MirroredThread
+		if err := rows.Scan(&t.Board, &t.ThreadId, &t.SourceModifiedAt, &t.LastMessageId, &t.Deleted); err != nil {
+			return nil, fmt.Errorf("failed to scan mirrored thread: %w", err)
+		}
+		threads = append(threads, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating mirrored threads: %w", err)
+	}
+	return threads, nil
+}
+
+// applyMirrorThreadUpdate creates or updates the thread under its source id,
+// deletes copied messages the source no longer has, updates edited texts and
+// inserts the new messages with their source ids. The source wins for all of
+// it; messages deleted here stay deleted because their ids are at most
+// last_message_id.
+func (s *Storage) applyMirrorThreadUpdate(q Querier, u domain.MirrorThreadUpdate) error {
+	var lastMessageId domain.MsgId
+	err := q.QueryRow(`
+		SELECT last_message_id FROM mirrored_threads WHERE board = $1 AND thread_id = $2`,
+		u.Board, u.Thread.Id,
+	).Scan(&lastMessageId)
+	isNew := errors.Is(err, sql.ErrNoRows)
+	if err != nil && !isNew {
+		return fmt.Errorf("failed to get sync state of thread %d: %w", u.Thread.Id, err)
+	}
+
+	now := time.Now().UTC().Round(time.Microsecond)
+	if isNew {
+		if err := s.insertMirroredThread(q, u); err != nil {
+			return err
+		}
+	} else {
+		result, err := q.Exec(`
+			UPDATE threads SET title = $3, is_pinned = $4, op_only = $5, last_bumped_at = $6, last_modified_at = $7
+			WHERE board = $1 AND id = $2`,
+			u.Board, u.Thread.Id, u.Thread.Title, u.Thread.IsPinned, u.Thread.OpOnly, u.Thread.LastBumped, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update mirrored thread %d: %w", u.Thread.Id, err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			// Deleted here by a moderator since the sync read the state
+			return nil
+		}
+	}
+
+	// Copied messages the source deleted
+	rows, err := q.Query(`
+		SELECT id FROM messages WHERE board = $1 AND thread_id = $2 AND id <= $3`,
+		u.Board, u.Thread.Id, lastMessageId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get copied messages of thread %d: %w", u.Thread.Id, err)
+	}
+	onSource := make(map[domain.MsgId]bool, len(u.SourceIds))
+	for _, id := range u.SourceIds {
+		onSource[id] = true
+	}
+	var gone []domain.MsgId
+	for rows.Next() {
+		var id domain.MsgId
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan copied message: %w", err)
+		}
+		if !onSource[id] {
+			gone = append(gone, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating copied messages: %w", err)
+	}
+	for _, id := range gone {
+		if err := s.deleteMessage(q, u.Board, u.Thread.Id, id); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range u.Edited {
+		result, err := q.Exec(`
+			UPDATE messages SET text = $4, updated_at = $5
+			WHERE board = $1 AND thread_id = $2 AND id = $3 AND text <> $4`,
+			u.Board, u.Thread.Id, m.Id, m.Text, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update mirrored message %d: %w", m.Id, err)
+		}
+		// The hash covers the text, so an edit is hashed again
+		if edited, _ := result.RowsAffected(); edited > 0 {
+			if err := s.storeContentHash(q, u.Board, u.Thread.Id, m.Id); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, m := range u.New {
+		if err := s.insertMirroredMessage(q, u.Board, u.Thread.Id, m); err != nil {
+			return err
+		}
+		lastMessageId = max(lastMessageId, m.Id)
+	}
+
+	for _, r := range u.Replies {
+		_, err := q.Exec(`
+			INSERT INTO message_replies (board, sender_thread_id, sender_message_id, receiver_thread_id, receiver_message_id, created_at)
+			SELECT $1::varchar, $2::bigint, $3::int, $4::bigint, $5::int, $6::timestamp
+			WHERE EXISTS (SELECT 1 FROM messages WHERE board = $1 AND thread_id = $2 AND id = $3)
+			  AND EXISTS (SELECT 1 FROM messages WHERE board = $1 AND thread_id = $4 AND id = $5)
+			ON CONFLICT DO NOTHING`,
+			u.Board, r.FromThreadId, r.From, r.ToThreadId, r.To, r.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert mirrored reply: %w", err)
+		}
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO mirrored_threads (board, thread_id, source_modified_at, last_message_id, synced_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (board, thread_id) DO UPDATE SET
+			source_modified_at = EXCLUDED.source_modified_at,
+			last_message_id = EXCLUDED.last_message_id,
+			synced_at = EXCLUDED.synced_at`,
+		u.Board, u.Thread.Id, u.Thread.LastModifiedAt, lastMessageId, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save sync state of thread %d: %w", u.Thread.Id, err)
+	}
+	return nil
+}
+
+// insertMirroredThread creates a copied thread under its source id and moves
+// the board's thread sequence past it, so the board keeps working once the
+// mirror is detached.
+func (s *Storage) insertMirroredThread(q Querier, u domain.MirrorThreadUpdate) error {
+	createdAt := u.Thread.LastBumped
+	if len(u.New) > 0 {
+		createdAt = u.New[0].CreatedAt
+	}
+	_, err := q.Exec(`
+		INSERT INTO threads (id, title, board, is_pinned, op_only, created_at, last_bumped_at, last_modified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)`,
+		u.Thread.Id, u.Thread.Title, u.Board, u.Thread.IsPinned, u.Thread.OpOnly, createdAt, u.Thread.LastBumped,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // Unique violation
+			return &internal_errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("Thread %d of /%s/ exists but was not copied by the mirror", u.Thread.Id, u.Board),
+				StatusCode: http.StatusConflict,
+			}
+		}
+		return fmt.Errorf("failed to insert mirrored thread %d: %w", u.Thread.Id, err)
+	}
+
+	seqName := fmt.Sprintf("threads_id_seq_%s", u.Board)
+	_, err = q.Exec(
+		fmt.Sprintf(`SELECT setval($1::regclass, $2) FROM %s WHERE last_value < $2 OR NOT is_called`, pq.QuoteIdentifier(seqName)),
+		pq.QuoteIdentifier(seqName), u.Thread.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to advance thread ids of board '%s': %w", u.Board, err)
+	}
+	return nil
+}
+
+// insertMirroredMessage inserts a copied message under its source id and post
+// number and advances the counters createMessage takes them from.
+func (s *Storage) insertMirroredMessage(q Querier, board domain.BoardShortName, threadId domain.ThreadId, m domain.MirroredMessage) error {
+	// Without a source number ($3 = 0) the next local one is taken like in
+	// createMessage, otherwise the counter only moves past the source number.
+	postNumber := m.PostNumber
+	err := q.QueryRow(`
+		UPDATE boards SET
+			last_activity_at = GREATEST(last_activity_at, $2),
+			next_post_number = GREATEST(next_post_number + ($3 = 0)::int, $3 + 1)
+		WHERE short_name = $1
+		RETURNING next_post_number - 1`,
+		board, m.CreatedAt, postNumber,
+	).Scan(&postNumber)
+	if err != nil {
+		return fmt.Errorf("failed to take post number on board '%s': %w", board, err)
+	}
+	if m.PostNumber > 0 {
+		postNumber = m.PostNumber
+	}
+
+	_, err = q.Exec(`
+		UPDATE threads SET
+			message_count = message_count + 1,
+			next_message_id = GREATEST(next_message_id, $3 + 1)
+		WHERE board = $1 AND id = $2`,
+		board, threadId, m.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update mirrored thread %d: %w", threadId, err)
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO messages (id, author_id, text, created_at, thread_id, updated_at, board, show_email_domain, post_number)
+		VALUES ($1, $2, $3, $4, $5, $4, $6, false, $7)`,
+		m.Id, domain.MirrorUserId, m.Text, m.CreatedAt, threadId, board, postNumber,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert mirrored message %d: %w", m.Id, err)
+	}
+	if len(m.Attachments) > 0 {
+		if err := s.addAttachments(q, board, threadId, m.Id, m.Attachments); err != nil {
+			return err
+		}
+	}
+	if err := s.storeContentHash(q, board, threadId, m.Id); err != nil {
+		return err
+	}
+	return s.addToThreadPreview(q, board, threadId, m.Id, domain.MirrorUserId)
+}
+
+// deleteMirroredThread also drops the state of threads already deleted here.
+func (s *Storage) deleteMirroredThread(q Querier, board domain.BoardShortName, id domain.ThreadId) error {
+	var exists bool
+	err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM threads WHERE board = $1 AND id = $2)`, board, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check mirrored thread %d: %w", id, err)
+	}
+	if exists {
+		if err := s.deleteThread(q, board, id); err != nil {
+			return err
+		}
+	}
+	if _, err := q.Exec(`DELETE FROM mirrored_threads WHERE board = $1 AND thread_id = $2`, board, id); err != nil {
+		return fmt.Errorf("failed to delete sync state of thread %d: %w", id, err)
+	}
+	return nil
+}
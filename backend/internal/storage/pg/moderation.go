@@ -0,0 +1,156 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// =========================================================================
+// Public Methods (automatic moderation signals and the moderation log)
+// =========================================================================
+
+// CountDeletedMessagesByAuthor returns how many of the user's messages were
+// deleted by moderators since the given time. Self-deletions do not count.
+func (s *Storage) CountDeletedMessagesByAuthor(ctx context.Context, userId domain.UserId, since time.Time) (int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.countDeletedMessagesByAuthor(q, userId, since)
+}
+
+// RecordModeration appends an entry to the board's moderation log.
+func (s *Storage) RecordModeration(ctx context.Context, entry domain.ModLogEntry) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.recordModeration(tx, entry)
+	})
+}
+
+// GetModLog returns a page of the board's moderation log, newest first, and
+// the total number of entries.
+func (s *Storage) GetModLog(ctx context.Context, board domain.BoardShortName, limit, offset int) ([]domain.ModLogEntry, int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getModLog(q, board, limit, offset)
+}
+
+// GetDeletionReport counts what deleting the board, or one of its threads when
+// threadId is non-nil, would remove.
+func (s *Storage) GetDeletionReport(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId) (domain.DeletionReport, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getDeletionReport(q, board, threadId)
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+func (s *Storage) countDeletedMessagesByAuthor(q Querier, userId domain.UserId, since time.Time) (int, error) {
+	var count int
+	err := q.QueryRow(`
+		SELECT COUNT(*) FROM message_deletions
+		WHERE author_id = $1 AND deleted_at >= $2 AND NOT by_author`,
+		userId, since,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted messages for user %d: %w", userId, err)
+	}
+	return count, nil
+}
+
+func (s *Storage) recordModeration(q Querier, entry domain.ModLogEntry) error {
+	_, err := q.Exec(`
+		INSERT INTO moderation_log (board, action, reason, created_at)
+		VALUES ($1, $2, $3, $4)`,
+		entry.Board, entry.Action, entry.Reason, time.Now().UTC().Round(time.Microsecond),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record %s on board '%s': %w", entry.Action, entry.Board, err)
+	}
+	return nil
+}
+
+func (s *Storage) getModLog(q Querier, board domain.BoardShortName, limit, offset int) ([]domain.ModLogEntry, int, error) {
+	var total int
+	if err := q.QueryRow(`SELECT COUNT(*) FROM moderation_log WHERE board = $1`, board).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count moderation log of board '%s': %w", board, err)
+	}
+
+	rows, err := q.Query(`
+		SELECT board, action, reason, created_at
+		FROM moderation_log
+		WHERE board = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`,
+		board, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query moderation log of board '%s': %w", board, err)
+	}
+	defer rows.Close()
+
+	entries := []domain.ModLogEntry{}
+	for rows.Next() {
+		var entry domain.ModLogEntry
+		if err := rows.Scan(&entry.Board, &entry.Action, &entry.Reason, &entry.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan moderation log entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating moderation log: %w", err)
+	}
+	return entries, total, nil
+}
+
+func (s *Storage) getDeletionReport(q Querier, board domain.BoardShortName, threadId *domain.ThreadId) (domain.DeletionReport, error) {
+	var exists bool
+	var err error
+	if threadId == nil {
+		err = q.QueryRow(`SELECT EXISTS (SELECT 1 FROM boards WHERE short_name = $1)`, board).Scan(&exists)
+	} else {
+		err = q.QueryRow(`SELECT EXISTS (SELECT 1 FROM threads WHERE board = $1 AND id = $2)`, board, *threadId).Scan(&exists)
+	}
+	if err != nil {
+		return domain.DeletionReport{}, fmt.Errorf("failed to check deletion target: %w", err)
+	}
+	if !exists {
+		message := fmt.Sprintf("Board '%s' not found", board)
+		if threadId != nil {
+			message = "Thread not found"
+		}
+		return domain.DeletionReport{}, &internal_errors.ErrorWithStatusCode{Message: message, StatusCode: http.StatusNotFound}
+	}
+
+	threadsFilter, messagesFilter, attachmentsFilter := "", "", ""
+	args := []any{board}
+	if threadId != nil {
+		threadsFilter, messagesFilter, attachmentsFilter = " AND id = $2", " AND thread_id = $2", " AND a.thread_id = $2"
+		args = append(args, *threadId)
+	}
+
+	var report domain.DeletionReport
+	err = q.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM threads WHERE board = $1`+threadsFilter+`),
+			(SELECT COUNT(*) FROM messages WHERE board = $1`+messagesFilter+`),
+			COUNT(a.id),
+			COALESCE(SUM(f.file_size_bytes), 0)::bigint
+		FROM attachments a
+		JOIN files f ON f.id = a.file_id
+		WHERE a.board = $1`+attachmentsFilter,
+		args...,
+	).Scan(&report.Threads, &report.Messages, &report.Attachments, &report.AttachmentBytes)
+	if err != nil {
+		return domain.DeletionReport{}, fmt.Errorf("failed to count deletion target: %w", err)
+	}
+	return report, nil
+}
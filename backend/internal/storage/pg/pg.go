@@ -16,6 +16,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/itchan-dev/itchan/backend/internal/service"
@@ -42,6 +43,15 @@ var _ service.MessageStorage = (*Storage)(nil)
 var _ service.UserActivityStorage = (*Storage)(nil)
 var _ service.GCStorage = (*Storage)(nil)
 var _ service.ReferralStorage = (*Storage)(nil)
+var _ service.SiteActivityStorage = (*Storage)(nil)
+var _ service.AppealStorage = (*Storage)(nil)
+var _ service.ModerationStorage = (*Storage)(nil)
+var _ service.ShadowbanStorage = (*Storage)(nil)
+var _ service.ViewAsStorage = (*Storage)(nil)
+var _ service.NotificationStorage = (*Storage)(nil)
+var _ service.DigestStorage = (*Storage)(nil)
+var _ service.PushStorage = (*Storage)(nil)
+var _ service.MediaLookupStorage = (*Storage)(nil)
 
 // Storage is the central struct for the PostgreSQL persistence layer.
 // It holds the database connection pool and application configuration, and acts
@@ -53,24 +63,44 @@ type Storage struct {
 
 // New creates and returns a new Storage instance.
 // It establishes a connection to the database and starts any necessary
-// background processes, such as the materialized view refresher.
+// background processes, such as the materialized view refresher (or rebuilds
+// the thread previews when incremental board previews are enabled).
 // This function is the main entry point for initializing the persistence layer.
 func New(ctx context.Context, cfg *config.Config) (*Storage, error) {
+	storage, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Public.IncrementalBoardPreviews {
+		if err := storage.RebuildThreadPreviews(); err != nil {
+			storage.Cleanup()
+			return nil, err
+		}
+	} else {
+		storage.StartPeriodicViewRefresh(
+			ctx,
+			cfg.Public.BoardPreviewRefreshInterval*time.Second,
+			cfg.Public.BoardActivityWindow*time.Second,
+		)
+	}
+
+	return storage, nil
+}
+
+// Open connects to the database without starting background processes.
+// It is meant for command-line tools running next to the API, which already
+// refreshes the board previews.
+func Open(cfg *config.Config) (*Storage, error) {
 	logger.Log.Info("connecting to database")
 	db, err := sharedstorage.Connect(cfg, sharedstorage.DefaultConnectionConfig())
 	if err != nil {
 		return nil, err
 	}
 	logger.Log.Info("successfully connected to database")
+	sharedstorage.SetSlowQueryThreshold(cfg.Public.SlowQueryThreshold)
 
-	storage := &Storage{db, cfg}
-	storage.StartPeriodicViewRefresh(
-		ctx,
-		cfg.Public.BoardPreviewRefreshInterval*time.Second,
-		cfg.Public.BoardActivityWindow*time.Second,
-	)
-
-	return storage, nil
+	return &Storage{db, cfg}, nil
 }
 
 // Cleanup gracefully closes the database connection pool.
@@ -90,18 +120,67 @@ func (s *Storage) Ping(ctx context.Context) error {
 // =========================================================================
 
 // withTx is a convenience wrapper around shared storage's WithTx helper.
-// It provides a method-style API while delegating to the shared implementation.
+// The transaction is handed over as a Querier bound to ctx, so every statement
+// in it is cancelled together with ctx.
 //
 // Usage:
 //
 //	func (s *Storage) SomeOperation(ctx context.Context, data ...) error {
-//	    return s.withTx(ctx, func(tx *sql.Tx) error {
+//	    ctx, cancel := s.queryContext(ctx)
+//	    defer cancel()
+//	    return s.withTx(ctx, func(tx Querier) error {
 //	        // Your transaction logic here
 //	        return s.somePrivateMethod(tx, data)
 //	    })
 //	}
-func (s *Storage) withTx(ctx context.Context, fn func(*sql.Tx) error) error {
-	return sharedstorage.WithTx(ctx, s.db, fn)
+//
+// The transaction runs again when PostgreSQL aborts it on a serialization
+// failure or deadlock (see sharedstorage.RetryTx), so fn must not have effects
+// outside of it. It starts by setting statement_timeout, see statementTimeout.
+func (s *Storage) withTx(ctx context.Context, fn func(Querier) error) error {
+	return sharedstorage.RetryTx(ctx, s.db, s.cfg.Public.TxRetryAttempts, func(tx *sql.Tx) error {
+		q := sharedstorage.WithContext(ctx, tx)
+		if timeout := s.statementTimeout(ctx); timeout > 0 {
+			if _, err := q.Exec(`SELECT set_config('statement_timeout', $1, true)`, strconv.FormatInt(timeout.Milliseconds(), 10)); err != nil {
+				return fmt.Errorf("failed to set statement timeout: %w", err)
+			}
+		}
+		return fn(q)
+	})
+}
+
+// longQueryKey marks contexts derived by longQueryContext.
+type longQueryKey struct{}
+
+// statementTimeout returns the statement_timeout of a transaction bound to
+// ctx: the configured one, raised to long_query_timeout for heavy operations
+// so the server does not stop them before the client would. Zero leaves the
+// server's setting alone.
+func (s *Storage) statementTimeout(ctx context.Context) time.Duration {
+	timeout := s.cfg.Public.StatementTimeout
+	if timeout > 0 && ctx.Value(longQueryKey{}) != nil {
+		timeout = max(timeout, s.cfg.Public.LongQueryTimeout)
+	}
+	return timeout
+}
+
+// queryContext derives the context of a storage call from the caller's context,
+// limited by the configured query timeout.
+func (s *Storage) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.cfg.Public.QueryTimeout)
+}
+
+// longQueryContext is queryContext for heavy operations (DDL, cascading deletes, statistics).
+func (s *Storage) longQueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = context.WithValue(ctx, longQueryKey{}, true)
+	return context.WithTimeout(ctx, s.cfg.Public.LongQueryTimeout)
+}
+
+// conn returns the connection pool bound to ctx and the query timeout, for
+// read-only calls that do not need a transaction.
+func (s *Storage) conn(ctx context.Context) (Querier, context.CancelFunc) {
+	ctx, cancel := s.queryContext(ctx)
+	return sharedstorage.WithContext(ctx, s.db), cancel
 }
 
 // =========================================================================
@@ -128,12 +207,20 @@ func ViewTableName(shortName domain.BoardShortName) string {
 
 // GetAllFilePaths returns all file paths stored in the database.
 // This is used by the garbage collector to identify orphaned files.
-// Returns both original file paths and thumbnail paths.
-func (s *Storage) GetAllFilePaths() ([]string, error) {
-	rows, err := s.db.Query(`
+// Returns original file paths, thumbnail paths and board banners.
+func (s *Storage) GetAllFilePaths(ctx context.Context) ([]string, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+	q := sharedstorage.WithContext(ctx, s.db)
+
+	rows, err := q.Query(`
 		SELECT file_path FROM files WHERE file_path IS NOT NULL
 		UNION
 		SELECT thumbnail_path FROM files WHERE thumbnail_path IS NOT NULL
+		UNION
+		SELECT file_path FROM board_banners
+		UNION
+		SELECT file_path FROM thread_snapshots
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query file paths: %w", err)
@@ -156,15 +243,26 @@ func (s *Storage) GetAllFilePaths() ([]string, error) {
 	return paths, nil
 }
 
-// DeleteOrphanedFileRecords deletes file records not referenced by any attachment.
+// DeleteOrphanedFileRecords deletes file records not referenced by any attachment,
+// pending upload or queued post.
 // Returns the number of records deleted.
 // This is used by the garbage collector to clean up orphaned database records.
-func (s *Storage) DeleteOrphanedFileRecords() (int64, error) {
-	result, err := s.db.Exec(`
+func (s *Storage) DeleteOrphanedFileRecords(ctx context.Context) (int64, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+	q := sharedstorage.WithContext(ctx, s.db)
+
+	result, err := q.Exec(`
 		DELETE FROM files
 		WHERE id NOT IN (
 			SELECT DISTINCT file_id FROM attachments
 		)
+		AND id NOT IN (
+			SELECT file_id FROM pending_uploads
+		)
+		AND id NOT IN (
+			SELECT file_id FROM premod_queue_files
+		)
 	`)
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete orphaned file records: %w", err)
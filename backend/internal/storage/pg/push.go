@@ -0,0 +1,82 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.PushStorage interface)
+// =========================================================================
+
+// SavePushSubscription stores a browser subscription. A known endpoint is
+// moved to the given user with its new keys.
+func (s *Storage) SavePushSubscription(ctx context.Context, sub domain.PushSubscription) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		_, err := tx.Exec(`
+			INSERT INTO push_subscriptions (endpoint, user_id, p256dh, auth, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (endpoint) DO UPDATE
+			SET user_id = EXCLUDED.user_id, p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth, created_at = EXCLUDED.created_at`,
+			sub.Endpoint, sub.UserId, sub.P256dh, sub.Auth, time.Now().UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save push subscription: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeletePushSubscription removes one of the user's subscriptions. Removing an
+// unknown subscription is a no-op.
+func (s *Storage) DeletePushSubscription(ctx context.Context, userId domain.UserId, endpoint string) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		_, err := tx.Exec(`DELETE FROM push_subscriptions WHERE user_id = $1 AND endpoint = $2`, userId, endpoint)
+		if err != nil {
+			return fmt.Errorf("failed to delete push subscription: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetReplyPushSubscriptions returns the subscriptions of everyone notified
+// about the message. Nobody is pushed replies of shadowbanned authors.
+func (s *Storage) GetReplyPushSubscriptions(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) ([]domain.PushSubscription, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	rows, err := q.Query(`
+		SELECT p.user_id, p.endpoint, p.p256dh, p.auth
+		FROM notifications n
+		JOIN messages m ON m.board = n.board AND m.thread_id = n.thread_id AND m.id = n.message_id
+		JOIN push_subscriptions p ON p.user_id = n.user_id
+		WHERE n.board = $1 AND n.thread_id = $2 AND n.message_id = $3 AND `+notShadowbannedCondition,
+		board, threadId, msgId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch push subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []domain.PushSubscription{}
+	for rows.Next() {
+		var sub domain.PushSubscription
+		if err := rows.Scan(&sub.UserId, &sub.Endpoint, &sub.P256dh, &sub.Auth); err != nil {
+			return nil, fmt.Errorf("failed to scan push subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating push subscriptions: %w", err)
+	}
+	return subs, nil
+}
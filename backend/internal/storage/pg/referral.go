@@ -2,18 +2,16 @@ package pg
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"time"
 
 	"github.com/itchan-dev/itchan/shared/domain"
 )
 
-func (s *Storage) SaveReferralAction(source, action, ip string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) SaveReferralAction(ctx context.Context, source, action, ip string) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		_, err := tx.Exec(
 			"INSERT INTO referral_actions(source, action, ip) VALUES($1, $2, $3::inet) ON CONFLICT(ip, source, action) DO NOTHING",
 			source, action, ip,
@@ -25,8 +23,11 @@ func (s *Storage) SaveReferralAction(source, action, ip string) error {
 	})
 }
 
-func (s *Storage) GetReferralActionStats() ([]domain.ReferralActionStats, error) {
-	rows, err := s.db.Query(`
+func (s *Storage) GetReferralActionStats(ctx context.Context) ([]domain.ReferralActionStats, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	rows, err := q.Query(`
 		SELECT source, action, COUNT(*) AS count
 		FROM referral_actions
 		GROUP BY source, action
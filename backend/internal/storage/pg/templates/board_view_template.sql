@@ -1,6 +1,7 @@
 -- Create materialized view to store op message and several last replies
 -- This is neccessary for fast access to board page, otherwise it would require complex queries every GetBoard request
 -- This view stores op message (id=1) and several (value in config) last messages
+-- Messages of users shadowbanned on the board are left out, as are threads they started
 CREATE MATERIALIZED VIEW %[1]s AS
 	WITH data AS (
 		SELECT
@@ -15,7 +16,8 @@ CREATE MATERIALIZED VIEW %[1]s AS
 			u.is_admin as author_is_admin,
 			m.show_email_domain as show_email_domain,
 			m.text as text,
-			m.created_at as created_at
+			m.created_at as created_at,
+			m.post_number as post_number
 		FROM threads as t
 		JOIN messages as m
 			ON t.id = m.thread_id
@@ -24,6 +26,15 @@ CREATE MATERIALIZED VIEW %[1]s AS
 		WHERE
 		(m.id = 1 OR ((t.next_message_id - 1 - m.id) < %[2]d)) -- op msg (id=1) and last messages should be presented
 		AND t.board = %[3]s
+		AND NOT EXISTS ( -- shadowbanned author
+			SELECT 1 FROM user_shadowbans sb
+			WHERE sb.board = m.board AND sb.user_id = m.author_id
+		)
+		AND NOT EXISTS ( -- thread started by a shadowbanned user
+			SELECT 1 FROM messages op
+			JOIN user_shadowbans sb ON sb.board = op.board AND sb.user_id = op.author_id
+			WHERE op.board = t.board AND op.thread_id = t.id AND op.id = 1
+		)
 	)
 	SELECT
 		*
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
+	"math"
 	"net/http"
 	"time"
 
@@ -24,14 +25,14 @@ import (
 // using pg_advisory_xact_lock to prevent race conditions. When maxThreadCount is non-nil,
 // it acquires a board-level advisory lock, creates the thread, and deletes the oldest
 // non-pinned threads if the board exceeds the limit.
-func (s *Storage) CreateThread(creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *Storage) CreateThread(ctx context.Context, creationData domain.ThreadCreationData, maxThreadCount *int) (domain.ThreadId, time.Time, error) {
+	ctx, cancel := s.longQueryContext(ctx)
 	defer cancel()
 
 	var threadID domain.ThreadId
 	var createdAt time.Time
 
-	err := s.withTx(ctx, func(tx *sql.Tx) error {
+	err := s.withTx(ctx, func(tx Querier) error {
 		var err error
 		threadID, createdAt, err = s.createThreadWithCleanup(tx, creationData, maxThreadCount)
 		return err
@@ -43,31 +44,62 @@ func (s *Storage) CreateThread(creationData domain.ThreadCreationData, maxThread
 // messages, replies, and attachments. It decides whether to use the optimized single-page
 // fetch or the paginated fetch based on thread size.
 // The page parameter controls pagination (1-based). Page 0 or 1 returns the first page.
-func (s *Storage) GetThread(board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error) {
-	return s.getThread(s.db, board, id, page)
+func (s *Storage) GetThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getThread(q, board, id, page)
+}
+
+// GetThreadUpdates returns up to limit messages of the thread after since, in
+// id order, with the reply links they added to earlier messages of the thread.
+func (s *Storage) GetThreadUpdates(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, limit int) (domain.ThreadUpdates, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getThreadUpdates(q, board, id, since, limit)
+}
+
+// GetThreadGraph returns the messages of a thread and the reply links between them.
+func (s *Storage) GetThreadGraph(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadGraph, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getThreadGraph(q, board, id)
+}
+
+// GetThreadAttachments returns every attachment of a thread in posting order.
+func (s *Storage) GetThreadAttachments(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadAttachments, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getThreadAttachments(q, board, id)
+}
+
+// GetThreadRange returns the OP and the messages with ids from..to of a thread.
+func (s *Storage) GetThreadRange(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getThreadRange(q, board, id, from, to)
 }
 
 // DeleteThread is the public entry point for deleting a thread. It wraps the core
 // deletion logic in a transaction to ensure atomicity. The database schema's
 // foreign key constraints will cascade the delete from the thread to all of its
 // contained messages, attachments, and replies.
-func (s *Storage) DeleteThread(board domain.BoardShortName, id domain.ThreadId) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) DeleteThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) error {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
-	return s.withTx(ctx, func(tx *sql.Tx) error {
+	return s.withTx(ctx, func(tx Querier) error {
 		return s.deleteThread(tx, board, id)
 	})
 }
 
 // TogglePinnedStatus is the public entry point for toggling a thread's pinned status.
 // It wraps the update in a transaction and returns the new pinned status.
-func (s *Storage) TogglePinnedStatus(board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Storage) TogglePinnedStatus(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+	ctx, cancel := s.queryContext(ctx)
 	defer cancel()
 
 	var newStatus bool
-	err := s.withTx(ctx, func(tx *sql.Tx) error {
+	err := s.withTx(ctx, func(tx Querier) error {
 		var err error
 		newStatus, err = s.togglePinnedStatus(tx, board, threadId)
 		return err
@@ -75,10 +107,76 @@ func (s *Storage) TogglePinnedStatus(board domain.BoardShortName, threadId domai
 	return newStatus, err
 }
 
+// ToggleOpOnlyStatus flips whether only the OP and moderators may reply to the
+// thread and returns the new value.
+func (s *Storage) ToggleOpOnlyStatus(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	var newStatus bool
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		newStatus, err = s.toggleOpOnlyStatus(tx, board, threadId)
+		return err
+	})
+	return newStatus, err
+}
+
+// GetThreadOpOnly reports whether the thread is reserved for its OP, and who
+// the OP is. opAuthor is nil while the OP message is not written yet.
+func (s *Storage) GetThreadOpOnly(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (opOnly bool, opAuthor *domain.UserId, err error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	return s.getThreadOpOnly(q, board, threadId)
+}
+
+// ClaimBumpLimitNotice reports whether the thread is past the bump limit and
+// its notice is still due, marking it as posted. Only one caller ever gets true.
+func (s *Storage) ClaimBumpLimitNotice(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	return s.claimBumpLimitNotice(q, board, threadId)
+}
+
+// UpdateThreadTitle changes the title of a thread and records the change in
+// its title history, atomically.
+func (s *Storage) UpdateThreadTitle(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editedBy domain.UserId, byModerator bool) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.updateThreadTitle(tx, board, id, title, editedBy, byModerator)
+	})
+}
+
+// GetThreadTitleEdits returns the title history of a thread, oldest first.
+func (s *Storage) GetThreadTitleEdits(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) ([]domain.ThreadTitleEdit, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	return s.getThreadTitleEdits(q, board, id)
+}
+
+// SetThreadSuccessor records that the thread is continued in successor,
+// replacing the successor set before.
+func (s *Storage) SetThreadSuccessor(ctx context.Context, board domain.BoardShortName, id, successor domain.ThreadId, setBy domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.setThreadSuccessor(tx, board, id, successor, setBy)
+	})
+}
+
 // GetThreadLastModified returns only the last_modified_at timestamp for a thread.
-func (s *Storage) GetThreadLastModified(board domain.BoardShortName, id domain.ThreadId) (time.Time, error) {
+func (s *Storage) GetThreadLastModified(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (time.Time, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
 	var lastModified time.Time
-	err := s.db.QueryRow(
+	err := q.QueryRow(
 		`SELECT last_modified_at FROM threads WHERE board = $1 AND id = $2`, board, id,
 	).Scan(&lastModified)
 	if err != nil {
@@ -92,6 +190,13 @@ func (s *Storage) GetThreadLastModified(board domain.BoardShortName, id domain.T
 	return lastModified, nil
 }
 
+// CountUserThreadsSince counts threads the user started on a board since the given time.
+func (s *Storage) CountUserThreadsSince(ctx context.Context, board domain.BoardShortName, userId domain.UserId, since time.Time) (int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.countUserThreadsSince(q, board, userId, since)
+}
+
 // =========================================================================
 // Internal Methods (Core Database Logic)
 // These methods accept a Querier and are transaction-agnostic.
@@ -105,31 +210,398 @@ func (s *Storage) getThread(q Querier, board domain.BoardShortName, id domain.Th
 		page = 1
 	}
 
+	metadata, err := s.getThreadMetadata(q, board, id)
+	if err != nil {
+		return domain.Thread{}, err
+	}
+
+	messagesPerPage := s.cfg.Public.MessagesPerThreadPage
+
+	var thread domain.Thread
+	if metadata.MessageCount <= messagesPerPage {
+		thread, err = s.getThreadSinglePage(q, metadata, board, id)
+	} else {
+		thread, err = s.getThreadPaginated(q, metadata, board, id, page, messagesPerPage)
+	}
+	if err != nil {
+		return domain.Thread{}, err
+	}
+
+	fromId, toId, err := s.deletedMessagesRange(q, thread)
+	if err != nil {
+		return domain.Thread{}, err
+	}
+	thread.Deleted, err = s.getDeletedMessages(q, board, id, fromId, toId)
+	if err != nil {
+		return domain.Thread{}, err
+	}
+
+	return thread, nil
+}
+
+// getThreadMetadata fetches the thread row with the board settings shown on
+// thread pages.
+func (s *Storage) getThreadMetadata(q Querier, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadMetadata, error) {
 	var metadata domain.ThreadMetadata
 	err := q.QueryRow(`
 		SELECT
-			id, title, board, message_count, last_bumped_at, last_modified_at, is_pinned
-		FROM threads
-		WHERE board = $1 AND id = $2`,
+			t.id, t.title, t.board, t.message_count, t.last_bumped_at, t.last_modified_at, t.is_pinned, t.op_only,
+			b.noindex OR EXISTS (SELECT 1 FROM board_permissions bp WHERE bp.board_short_name = t.board),
+			b.allow_documents, b.max_attachments, b.allowed_mime_types, b.language,
+			EXISTS (SELECT 1 FROM board_mirrors bm WHERE bm.board = t.board),
+			(SELECT ts.successor_id FROM thread_successors ts WHERE ts.board = t.board AND ts.thread_id = t.id),
+			(SELECT ts.thread_id FROM thread_successors ts WHERE ts.board = t.board AND ts.successor_id = t.id)
+		FROM threads t
+		JOIN boards b ON b.short_name = t.board
+		WHERE t.board = $1 AND t.id = $2`,
 		board, id,
 	).Scan(
 		&metadata.Id, &metadata.Title, &metadata.Board,
-		&metadata.MessageCount, &metadata.LastBumped, &metadata.LastModifiedAt, &metadata.IsPinned,
+		&metadata.MessageCount, &metadata.LastBumped, &metadata.LastModifiedAt, &metadata.IsPinned, &metadata.OpOnly,
+		&metadata.Noindex, &metadata.AllowDocuments, &metadata.MaxAttachments, (*commaList)(&metadata.AllowedMimeTypes), &metadata.Language, &metadata.Mirror,
+		&metadata.SuccessorId, &metadata.PredecessorId,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return domain.Thread{}, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+			return domain.ThreadMetadata{}, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
 		}
-		return domain.Thread{}, fmt.Errorf("failed to fetch thread metadata: %w", err)
+		return domain.ThreadMetadata{}, fmt.Errorf("failed to fetch thread metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// getThreadRange fetches the OP and the messages with ids from..to for links
+// into the middle of a long thread. Message ids are per-thread ordinals, so the
+// range is a keyset scan of the messages primary key rather than an OFFSET.
+// Pagination reports the page holding from.
+func (s *Storage) getThreadRange(q Querier, board domain.BoardShortName, id domain.ThreadId, from, to domain.MsgId) (domain.Thread, error) {
+	metadata, err := s.getThreadMetadata(q, board, id)
+	if err != nil {
+		return domain.Thread{}, err
 	}
 
 	messagesPerPage := s.cfg.Public.MessagesPerThreadPage
+	messages := []*domain.Message{}
+	idToMessage := make(map[MsgKey]*domain.Message)
+	var messageKeys []MsgKey
 
-	if metadata.MessageCount <= messagesPerPage {
-		return s.getThreadSinglePage(q, metadata, board, id)
+	msgRows, err := q.Query(`
+		SELECT
+			m.id, m.author_id, u.email_domain, m.text, m.show_email_domain, m.created_at, m.thread_id,
+			m.updated_at, m.board, u.is_admin, m.post_number
+		FROM messages m
+		JOIN users u ON m.author_id = u.id
+		WHERE m.board = $1 AND m.thread_id = $2 AND (m.id = 1 OR m.id BETWEEN $3 AND $4)
+		ORDER BY m.id`,
+		board, id, from, to,
+	)
+	if err != nil {
+		return domain.Thread{}, fmt.Errorf("failed to fetch thread message range: %w", err)
+	}
+	defer msgRows.Close()
+
+	for msgRows.Next() {
+		var msg domain.Message
+		if err := msgRows.Scan(
+			&msg.Id, &msg.Author.Id, &msg.Author.EmailDomain, &msg.Text, &msg.ShowEmailDomain, &msg.CreatedAt,
+			&msg.ThreadId, &msg.ModifiedAt, &msg.Board, &msg.Author.Admin, &msg.PostNumber,
+		); err != nil {
+			return domain.Thread{}, fmt.Errorf("failed to scan message row: %w", err)
+		}
+		msg.Page = utils.CalculatePage(int(msg.Id), messagesPerPage)
+		msg.Replies = domain.Replies{}
+		msg.Attachments = domain.Attachments{}
+		messages = append(messages, &msg)
+		key := MsgKey{ThreadId: id, MsgId: msg.Id}
+		idToMessage[key] = &msg
+		messageKeys = append(messageKeys, key)
+	}
+	if err = msgRows.Err(); err != nil {
+		return domain.Thread{}, fmt.Errorf("error iterating message rows: %w", err)
+	}
+
+	if len(messageKeys) > 0 {
+		if err := enrichMessages(q, board, messageKeys, idToMessage, messagesPerPage, false); err != nil {
+			return domain.Thread{}, fmt.Errorf("failed to enrich thread range: %w", err)
+		}
+	}
+
+	deleted, err := s.getDeletedMessages(q, board, id, from, to)
+	if err != nil {
+		return domain.Thread{}, err
+	}
+
+	return domain.Thread{
+		ThreadMetadata: metadata,
+		Messages:       messages,
+		Deleted:        deleted,
+		Pagination: &domain.ThreadPagination{
+			CurrentPage: utils.CalculatePage(int(from), messagesPerPage),
+			TotalPages:  max((metadata.MessageCount+messagesPerPage-1)/messagesPerPage, 1),
+			TotalCount:  metadata.MessageCount,
+		},
+	}, nil
+}
+
+// getThreadGraph fetches every message of the thread and the reply links
+// between them. Replies to or from other threads are left out.
+func (s *Storage) getThreadGraph(q Querier, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadGraph, error) {
+	var exists bool
+	if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM threads WHERE board = $1 AND id = $2)`, board, id).Scan(&exists); err != nil {
+		return domain.ThreadGraph{}, fmt.Errorf("failed to check thread: %w", err)
+	}
+	if !exists {
+		return domain.ThreadGraph{}, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+	}
+
+	graph := domain.ThreadGraph{Nodes: []domain.GraphNode{}, Edges: []domain.GraphEdge{}}
+	nodeRows, err := q.Query(`
+		SELECT id, author_id, created_at FROM messages
+		WHERE board = $1 AND thread_id = $2
+		ORDER BY id`,
+		board, id,
+	)
+	if err != nil {
+		return domain.ThreadGraph{}, fmt.Errorf("failed to fetch graph nodes: %w", err)
+	}
+	defer nodeRows.Close()
+	for nodeRows.Next() {
+		var node domain.GraphNode
+		if err := nodeRows.Scan(&node.Id, &node.Author, &node.CreatedAt); err != nil {
+			return domain.ThreadGraph{}, fmt.Errorf("failed to scan graph node: %w", err)
+		}
+		node.Page = utils.CalculatePage(int(node.Id), s.cfg.Public.MessagesPerThreadPage)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	if err := nodeRows.Err(); err != nil {
+		return domain.ThreadGraph{}, fmt.Errorf("error iterating graph nodes: %w", err)
+	}
+
+	edgeRows, err := q.Query(`
+		SELECT sender_message_id, receiver_message_id FROM message_replies
+		WHERE board = $1 AND sender_thread_id = $2 AND receiver_thread_id = $2
+		ORDER BY sender_message_id, receiver_message_id`,
+		board, id,
+	)
+	if err != nil {
+		return domain.ThreadGraph{}, fmt.Errorf("failed to fetch graph edges: %w", err)
+	}
+	defer edgeRows.Close()
+	for edgeRows.Next() {
+		var edge domain.GraphEdge
+		if err := edgeRows.Scan(&edge.From, &edge.To); err != nil {
+			return domain.ThreadGraph{}, fmt.Errorf("failed to scan graph edge: %w", err)
+		}
+		graph.Edges = append(graph.Edges, edge)
+	}
+	if err := edgeRows.Err(); err != nil {
+		return domain.ThreadGraph{}, fmt.Errorf("error iterating graph edges: %w", err)
+	}
+	return graph, nil
+}
+
+// getThreadAttachments fetches the attachments of the thread with the author
+// and page of their messages.
+func (s *Storage) getThreadAttachments(q Querier, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadAttachments, error) {
+	var exists bool
+	if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM threads WHERE board = $1 AND id = $2)`, board, id).Scan(&exists); err != nil {
+		return domain.ThreadAttachments{}, fmt.Errorf("failed to check thread: %w", err)
+	}
+	if !exists {
+		return domain.ThreadAttachments{}, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+	}
+
+	authors := make(map[domain.MsgId]domain.UserId)
+	authorRows, err := q.Query(`
+		SELECT id, author_id FROM messages
+		WHERE board = $1 AND thread_id = $2`,
+		board, id,
+	)
+	if err != nil {
+		return domain.ThreadAttachments{}, fmt.Errorf("failed to fetch message authors: %w", err)
+	}
+	defer authorRows.Close()
+	for authorRows.Next() {
+		var msgId domain.MsgId
+		var author domain.UserId
+		if err := authorRows.Scan(&msgId, &author); err != nil {
+			return domain.ThreadAttachments{}, fmt.Errorf("failed to scan message author: %w", err)
+		}
+		authors[msgId] = author
+	}
+	if err := authorRows.Err(); err != nil {
+		return domain.ThreadAttachments{}, fmt.Errorf("error iterating message authors: %w", err)
+	}
+
+	result := domain.ThreadAttachments{Attachments: []domain.ThreadAttachment{}, OpAuthor: authors[1]}
+	rows, err := q.Query(`
+		SELECT `+attachmentColumns+`
+		FROM attachments a
+		JOIN files f ON a.file_id = f.id
+		WHERE a.board = $1 AND a.thread_id = $2
+		ORDER BY a.message_id, a.id`,
+		board, id,
+	)
+	if err != nil {
+		return domain.ThreadAttachments{}, fmt.Errorf("failed to fetch thread attachments: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return domain.ThreadAttachments{}, fmt.Errorf("failed to scan attachment row: %w", err)
+		}
+		result.Attachments = append(result.Attachments, domain.ThreadAttachment{
+			Attachment: attachment,
+			Page:       utils.CalculatePage(int(attachment.MessageId), s.cfg.Public.MessagesPerThreadPage),
+			Author:     authors[attachment.MessageId],
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return domain.ThreadAttachments{}, fmt.Errorf("error iterating thread attachments: %w", err)
+	}
+	return result, nil
+}
+
+// getThreadUpdates fetches the messages after since like a thread page does.
+// Backlinks only cover replies within the thread: a reply from another thread
+// shows up on the next page load.
+func (s *Storage) getThreadUpdates(q Querier, board domain.BoardShortName, id domain.ThreadId, since domain.MsgId, limit int) (domain.ThreadUpdates, error) {
+	var exists bool
+	if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM threads WHERE board = $1 AND id = $2)`, board, id).Scan(&exists); err != nil {
+		return domain.ThreadUpdates{}, fmt.Errorf("failed to check thread: %w", err)
+	}
+	if !exists {
+		return domain.ThreadUpdates{}, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+	}
+
+	messagesPerPage := s.cfg.Public.MessagesPerThreadPage
+	updates := domain.ThreadUpdates{Messages: []*domain.Message{}, Backlinks: domain.Replies{}}
+	idToMessage := make(map[MsgKey]*domain.Message)
+	var messageKeys []MsgKey
+
+	msgRows, err := q.Query(`
+		SELECT
+			m.id, m.author_id, u.email_domain, m.text, m.show_email_domain, m.created_at, m.thread_id,
+			m.updated_at, m.board, u.is_admin, m.post_number
+		FROM messages m
+		JOIN users u ON m.author_id = u.id
+		WHERE m.board = $1 AND m.thread_id = $2 AND m.id > $3
+		ORDER BY m.id
+		LIMIT $4`,
+		board, id, since, limit,
+	)
+	if err != nil {
+		return domain.ThreadUpdates{}, fmt.Errorf("failed to fetch new thread messages: %w", err)
+	}
+	defer msgRows.Close()
+
+	for msgRows.Next() {
+		var msg domain.Message
+		if err := msgRows.Scan(
+			&msg.Id, &msg.Author.Id, &msg.Author.EmailDomain, &msg.Text, &msg.ShowEmailDomain, &msg.CreatedAt,
+			&msg.ThreadId, &msg.ModifiedAt, &msg.Board, &msg.Author.Admin, &msg.PostNumber,
+		); err != nil {
+			return domain.ThreadUpdates{}, fmt.Errorf("failed to scan message row: %w", err)
+		}
+		msg.Page = utils.CalculatePage(int(msg.Id), messagesPerPage)
+		msg.Replies = domain.Replies{}
+		msg.Attachments = domain.Attachments{}
+		updates.Messages = append(updates.Messages, &msg)
+		key := MsgKey{ThreadId: id, MsgId: msg.Id}
+		idToMessage[key] = &msg
+		messageKeys = append(messageKeys, key)
+	}
+	if err = msgRows.Err(); err != nil {
+		return domain.ThreadUpdates{}, fmt.Errorf("error iterating message rows: %w", err)
+	}
+	if len(messageKeys) == 0 {
+		return updates, nil
+	}
+
+	if err := enrichMessages(q, board, messageKeys, idToMessage, messagesPerPage, false); err != nil {
+		return domain.ThreadUpdates{}, fmt.Errorf("failed to enrich thread updates: %w", err)
 	}
 
-	return s.getThreadPaginated(q, metadata, board, id, page, messagesPerPage)
+	lastId := updates.Messages[len(updates.Messages)-1].Id
+	replyRows, err := q.Query(`
+		SELECT
+			mr.board, mr.sender_message_id, mr.sender_thread_id, mr.receiver_message_id, mr.receiver_thread_id,
+			mr.created_at, sm.author_id, sm.post_number
+		FROM message_replies mr
+		JOIN messages sm
+		  ON sm.board = mr.board
+		  AND sm.thread_id = mr.sender_thread_id
+		  AND sm.id = mr.sender_message_id
+		WHERE mr.board = $1 AND mr.receiver_thread_id = $2 AND mr.sender_thread_id = $2
+		  AND mr.receiver_message_id <= $3 AND mr.sender_message_id > $3 AND mr.sender_message_id <= $4
+		ORDER BY mr.created_at`,
+		board, id, since, lastId,
+	)
+	if err != nil {
+		return domain.ThreadUpdates{}, fmt.Errorf("failed to fetch thread backlinks: %w", err)
+	}
+	defer replyRows.Close()
+	for replyRows.Next() {
+		var reply domain.Reply
+		if err := replyRows.Scan(&reply.Board, &reply.From, &reply.FromThreadId, &reply.To, &reply.ToThreadId, &reply.CreatedAt, &reply.FromAuthor, &reply.FromPostNumber); err != nil {
+			return domain.ThreadUpdates{}, fmt.Errorf("failed to scan reply row: %w", err)
+		}
+		reply.FromPage = utils.CalculatePage(int(reply.From), messagesPerPage)
+		updates.Backlinks = append(updates.Backlinks, &reply)
+	}
+	if err := replyRows.Err(); err != nil {
+		return domain.ThreadUpdates{}, fmt.Errorf("error iterating reply rows: %w", err)
+	}
+	return updates, nil
+}
+
+// deletedMessagesRange returns the message id range whose deletion stubs belong on
+// the fetched page: from the page's first message up to (but excluding) the first
+// message of the next page, so stubs falling between two pages are not lost.
+func (s *Storage) deletedMessagesRange(q Querier, thread domain.Thread) (domain.MsgId, domain.MsgId, error) {
+	pageMessages := thread.Messages
+	page := thread.Pagination.CurrentPage
+	if page > 1 && len(pageMessages) > 0 && pageMessages[0].IsOp() {
+		pageMessages = pageMessages[1:] // OP is prepended to every page
+	}
+
+	fromId := domain.MsgId(1)
+	if page > 1 && len(pageMessages) > 0 {
+		fromId = pageMessages[0].Id
+	}
+	if page >= thread.Pagination.TotalPages || len(pageMessages) == 0 {
+		return fromId, math.MaxInt32, nil
+	}
+
+	var nextId sql.NullInt64
+	err := q.QueryRow(`
+		SELECT MIN(id) FROM messages
+		WHERE board = $1 AND thread_id = $2 AND id > $3`,
+		thread.Board, thread.Id, pageMessages[len(pageMessages)-1].Id,
+	).Scan(&nextId)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to find next page boundary: %w", err)
+	}
+	if !nextId.Valid {
+		return fromId, math.MaxInt32, nil
+	}
+	return fromId, nextId.Int64 - 1, nil
+}
+
+// countUserThreadsSince counts OP messages (id = 1) authored by the user. Deleted
+// threads are not counted, so removing a thread frees up the user's quota.
+func (s *Storage) countUserThreadsSince(q Querier, board domain.BoardShortName, userId domain.UserId, since time.Time) (int, error) {
+	var count int
+	err := q.QueryRow(`
+		SELECT count(*) FROM messages
+		WHERE board = $1 AND id = 1 AND author_id = $2 AND created_at >= $3`,
+		board, userId, since.UTC(),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count threads of user %d on board '%s': %w", userId, board, err)
+	}
+	return count, nil
 }
 
 // createThread handles the specific database operation of inserting a new record
@@ -168,13 +640,14 @@ func (s *Storage) createThread(q Querier, creationData domain.ThreadCreationData
 	partitionName := PartitionName(creationData.Board, "threads")
 	err = q.QueryRow(
 		fmt.Sprintf(`
-	           INSERT INTO %s (title, board, is_pinned, created_at)
-	           VALUES ($1, $2, $3, $4)
+	           INSERT INTO %s (title, board, is_pinned, op_only, created_at)
+	           VALUES ($1, $2, $3, $4, $5)
 	           RETURNING id, created_at
 	       `, partitionName),
 		creationData.Title,
 		creationData.Board,
 		creationData.IsPinned,
+		creationData.OpOnly,
 		createdAt,
 	).Scan(&id, &createdTs)
 	if err != nil {
@@ -230,7 +703,7 @@ func (s *Storage) getThreadSinglePage(q Querier, metadata domain.ThreadMetadata,
 	msgRows, err := q.Query(`
 		SELECT
 			m.id, m.author_id, u.email_domain, m.text, m.show_email_domain, m.created_at, m.thread_id,
-			m.updated_at, m.board, u.is_admin
+			m.updated_at, m.board, u.is_admin, m.post_number
 		FROM messages m
 		JOIN users u ON m.author_id = u.id
 		WHERE m.board = $1 AND m.thread_id = $2
@@ -246,7 +719,7 @@ func (s *Storage) getThreadSinglePage(q Querier, metadata domain.ThreadMetadata,
 		var msg domain.Message
 		if err := msgRows.Scan(
 			&msg.Id, &msg.Author.Id, &msg.Author.EmailDomain, &msg.Text, &msg.ShowEmailDomain, &msg.CreatedAt,
-			&msg.ThreadId, &msg.ModifiedAt, &msg.Board, &msg.Author.Admin,
+			&msg.ThreadId, &msg.ModifiedAt, &msg.Board, &msg.Author.Admin, &msg.PostNumber,
 		); err != nil {
 			return domain.Thread{}, fmt.Errorf("failed to scan message row: %w", err)
 		}
@@ -262,8 +735,12 @@ func (s *Storage) getThreadSinglePage(q Querier, metadata domain.ThreadMetadata,
 	replyRows, err := q.Query(`
 		SELECT
 			mr.board, mr.sender_message_id, mr.sender_thread_id, mr.receiver_message_id, mr.receiver_thread_id,
-			mr.created_at
+			mr.created_at, sm.author_id, sm.post_number
 		FROM message_replies mr
+		JOIN messages sm
+		  ON sm.board = mr.board
+		  AND sm.thread_id = mr.sender_thread_id
+		  AND sm.id = mr.sender_message_id
 		WHERE mr.board = $1 AND mr.receiver_thread_id = $2
 		ORDER BY mr.created_at`,
 		board, id,
@@ -274,7 +751,7 @@ func (s *Storage) getThreadSinglePage(q Querier, metadata domain.ThreadMetadata,
 	defer replyRows.Close()
 	for replyRows.Next() {
 		var reply domain.Reply
-		if err := replyRows.Scan(&reply.Board, &reply.From, &reply.FromThreadId, &reply.To, &reply.ToThreadId, &reply.CreatedAt); err != nil {
+		if err := replyRows.Scan(&reply.Board, &reply.From, &reply.FromThreadId, &reply.To, &reply.ToThreadId, &reply.CreatedAt, &reply.FromAuthor, &reply.FromPostNumber); err != nil {
 			return domain.Thread{}, fmt.Errorf("failed to scan reply row: %w", err)
 		}
 		reply.FromPage = 1 // Single page thread
@@ -285,9 +762,7 @@ func (s *Storage) getThreadSinglePage(q Querier, metadata domain.ThreadMetadata,
 
 	// Fetch all attachments for the entire thread
 	attachRows, err := q.Query(`
-		SELECT
-			a.id, a.board, a.thread_id, a.message_id, a.file_id,
-			f.file_path, f.filename, f.original_filename, f.file_size_bytes, f.mime_type, f.original_mime_type, f.image_width, f.image_height, f.thumbnail_path
+		SELECT `+attachmentColumns+`
 		FROM attachments a
 		JOIN files f ON a.file_id = f.id
 		WHERE a.board = $1 AND a.thread_id = $2
@@ -299,17 +774,12 @@ func (s *Storage) getThreadSinglePage(q Querier, metadata domain.ThreadMetadata,
 	}
 	defer attachRows.Close()
 	for attachRows.Next() {
-		var attachment domain.Attachment
-		var file domain.File
-		if err := attachRows.Scan(
-			&attachment.Id, &attachment.Board, &attachment.ThreadId, &attachment.MessageId, &attachment.FileId,
-			&file.FilePath, &file.Filename, &file.OriginalFilename, &file.SizeBytes, &file.MimeType, &file.OriginalMimeType, &file.ImageWidth, &file.ImageHeight, &file.ThumbnailPath,
-		); err != nil {
+		attachment, err := scanAttachment(attachRows)
+		if err != nil {
 			return domain.Thread{}, fmt.Errorf("failed to scan attachment row: %w", err)
 		}
-		attachment.File = &file
 		if msg, ok := msgIDMap[attachment.MessageId]; ok {
-			msg.Attachments = append(msg.Attachments, &attachment)
+			msg.Attachments = append(msg.Attachments, attachment)
 		}
 	}
 
@@ -339,7 +809,7 @@ func (s *Storage) getThreadPaginated(q Querier, metadata domain.ThreadMetadata,
 		opRow := q.QueryRow(`
 			SELECT
 				m.id, m.author_id, u.email_domain, m.text, m.show_email_domain, m.created_at, m.thread_id,
-				m.updated_at, m.board, u.is_admin
+				m.updated_at, m.board, u.is_admin, m.post_number
 			FROM messages m
 			JOIN users u ON m.author_id = u.id
 			WHERE m.board = $1 AND m.thread_id = $2 AND m.id = 1`,
@@ -348,7 +818,7 @@ func (s *Storage) getThreadPaginated(q Querier, metadata domain.ThreadMetadata,
 		var opMsg domain.Message
 		if err := opRow.Scan(
 			&opMsg.Id, &opMsg.Author.Id, &opMsg.Author.EmailDomain, &opMsg.Text, &opMsg.ShowEmailDomain, &opMsg.CreatedAt,
-			&opMsg.ThreadId, &opMsg.ModifiedAt, &opMsg.Board, &opMsg.Author.Admin,
+			&opMsg.ThreadId, &opMsg.ModifiedAt, &opMsg.Board, &opMsg.Author.Admin, &opMsg.PostNumber,
 		); err != nil && !errors.Is(err, sql.ErrNoRows) {
 			return domain.Thread{}, fmt.Errorf("failed to fetch OP message: %w", err)
 		} else if err == nil {
@@ -366,7 +836,7 @@ func (s *Storage) getThreadPaginated(q Querier, metadata domain.ThreadMetadata,
 	msgRows, err := q.Query(`
 		SELECT
 			m.id, m.author_id, u.email_domain, m.text, m.show_email_domain, m.created_at, m.thread_id,
-			m.updated_at, m.board, u.is_admin
+			m.updated_at, m.board, u.is_admin, m.post_number
 		FROM messages m
 		JOIN users u ON m.author_id = u.id
 		WHERE m.board = $1 AND m.thread_id = $2
@@ -383,7 +853,7 @@ func (s *Storage) getThreadPaginated(q Querier, metadata domain.ThreadMetadata,
 		var msg domain.Message
 		if err := msgRows.Scan(
 			&msg.Id, &msg.Author.Id, &msg.Author.EmailDomain, &msg.Text, &msg.ShowEmailDomain, &msg.CreatedAt,
-			&msg.ThreadId, &msg.ModifiedAt, &msg.Board, &msg.Author.Admin,
+			&msg.ThreadId, &msg.ModifiedAt, &msg.Board, &msg.Author.Admin, &msg.PostNumber,
 		); err != nil {
 			return domain.Thread{}, fmt.Errorf("failed to scan message row: %w", err)
 		}
@@ -401,11 +871,8 @@ func (s *Storage) getThreadPaginated(q Querier, metadata domain.ThreadMetadata,
 
 	// Enrich only the messages on this page using the shared enrichment functions
 	if len(messageKeys) > 0 {
-		if err := enrichMessagesWithReplies(q, board, messageKeys, idToMessage, messagesPerPage); err != nil {
-			return domain.Thread{}, fmt.Errorf("failed to enrich replies for thread page: %w", err)
-		}
-		if err := enrichMessagesWithAttachments(q, board, messageKeys, idToMessage); err != nil {
-			return domain.Thread{}, fmt.Errorf("failed to enrich attachments for thread page: %w", err)
+		if err := enrichMessages(q, board, messageKeys, idToMessage, messagesPerPage, false); err != nil {
+			return domain.Thread{}, fmt.Errorf("failed to enrich thread page: %w", err)
 		}
 	}
 
@@ -468,6 +935,9 @@ func (s *Storage) deleteThread(q Querier, board domain.BoardShortName, id domain
 	if affected, _ := result.RowsAffected(); affected == 0 {
 		return &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
 	}
+	if err := s.removeFromThreadPreview(q, board, id, nil); err != nil {
+		return err
+	}
 
 	// STEP 4: Batch delete file records (now that attachments are cascaded away)
 	// FK constraints will prevent deletion if files are still referenced elsewhere
@@ -514,3 +984,190 @@ func (s *Storage) togglePinnedStatus(q Querier, board domain.BoardShortName, thr
 
 	return newStatus, nil
 }
+
+// toggleOpOnlyStatus flips op_only like togglePinnedStatus flips is_pinned.
+// Board previews don't show the flag, so the board's activity is left alone.
+func (s *Storage) toggleOpOnlyStatus(q Querier, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+	var newStatus bool
+	err := q.QueryRow(
+		"UPDATE threads SET op_only = NOT op_only, last_modified_at = NOW() AT TIME ZONE 'utc' WHERE board = $1 AND id = $2 RETURNING op_only",
+		board, threadId,
+	).Scan(&newStatus)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+		}
+		return false, fmt.Errorf("failed to toggle thread op-only status: %w", err)
+	}
+
+	return newStatus, nil
+}
+
+func (s *Storage) getThreadOpOnly(q Querier, board domain.BoardShortName, threadId domain.ThreadId) (bool, *domain.UserId, error) {
+	var opOnly bool
+	var opAuthor sql.NullInt64
+	err := q.QueryRow(`
+		SELECT t.op_only, m.author_id
+		FROM threads t
+		LEFT JOIN messages m ON m.board = t.board AND m.thread_id = t.id AND m.id = 1
+		WHERE t.board = $1 AND t.id = $2`,
+		board, threadId,
+	).Scan(&opOnly, &opAuthor)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+		}
+		return false, nil, fmt.Errorf("failed to fetch thread op-only status: %w", err)
+	}
+	if !opAuthor.Valid {
+		return opOnly, nil, nil
+	}
+	author := domain.UserId(opAuthor.Int64)
+	return opOnly, &author, nil
+}
+
+func (s *Storage) claimBumpLimitNotice(q Querier, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+	result, err := q.Exec(`
+		UPDATE threads SET bump_limit_noticed = true
+		WHERE board = $1 AND id = $2 AND NOT bump_limit_noticed AND message_count > $3`,
+		board, threadId, s.cfg.Public.BumpLimit,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim bump limit notice: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim bump limit notice: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// updateThreadTitle sets the new title and appends the change to
+// thread_title_edits. The board's last activity is bumped like on a pin
+// toggle, so the materialized board view picks up the new title on its next
+// refresh; incremental previews read the title from threads directly.
+func (s *Storage) updateThreadTitle(q Querier, board domain.BoardShortName, id domain.ThreadId, title domain.ThreadTitle, editedBy domain.UserId, byModerator bool) error {
+	var oldTitle domain.ThreadTitle
+	err := q.QueryRow(`SELECT title FROM threads WHERE board = $1 AND id = $2 FOR UPDATE`, board, id).Scan(&oldTitle)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+		}
+		return fmt.Errorf("failed to fetch thread title: %w", err)
+	}
+	if oldTitle == title {
+		return nil
+	}
+
+	_, err = q.Exec(`
+        UPDATE threads SET title = $3, last_modified_at = NOW() AT TIME ZONE 'utc'
+        WHERE board = $1 AND id = $2`,
+		board, id, title,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update thread title: %w", err)
+	}
+
+	_, err = q.Exec(`
+        UPDATE boards SET last_activity_at = NOW() AT TIME ZONE 'utc'
+        WHERE short_name = $1`,
+		board,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update board activity on title edit: %w", err)
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO thread_title_edits (board, thread_id, old_title, new_title, edited_by, by_moderator, edited_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		board, id, oldTitle, title, editedBy, byModerator, time.Now().UTC().Round(time.Microsecond),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record thread title edit: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) getThreadTitleEdits(q Querier, board domain.BoardShortName, id domain.ThreadId) ([]domain.ThreadTitleEdit, error) {
+	rows, err := q.Query(`
+		SELECT old_title, new_title, edited_by, by_moderator, edited_at
+		FROM thread_title_edits
+		WHERE board = $1 AND thread_id = $2
+		ORDER BY edited_at, id`,
+		board, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thread title edits: %w", err)
+	}
+	defer rows.Close()
+
+	edits := []domain.ThreadTitleEdit{}
+	for rows.Next() {
+		var edit domain.ThreadTitleEdit
+		var editedBy sql.NullInt64
+		if err := rows.Scan(&edit.OldTitle, &edit.NewTitle, &editedBy, &edit.ByModerator, &edit.EditedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan thread title edit: %w", err)
+		}
+		if editedBy.Valid {
+			userId := domain.UserId(editedBy.Int64)
+			edit.EditedBy = &userId
+		}
+		edits = append(edits, edit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating thread title edits: %w", err)
+	}
+	return edits, nil
+}
+
+// setThreadSuccessor links the two threads. Both threads, and a successor
+// replaced by this call, show the link, so their cached pages are
+// invalidated.
+func (s *Storage) setThreadSuccessor(q Querier, board domain.BoardShortName, id, successor domain.ThreadId, setBy domain.UserId) error {
+	var found int
+	err := q.QueryRow(`SELECT count(*) FROM threads WHERE board = $1 AND id IN ($2, $3)`, board, id, successor).Scan(&found)
+	if err != nil {
+		return fmt.Errorf("failed to check threads: %w", err)
+	}
+	if found < 2 {
+		return &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+	}
+
+	var continued domain.ThreadId
+	err = q.QueryRow(`
+		SELECT thread_id FROM thread_successors
+		WHERE board = $1 AND successor_id = $2 AND thread_id <> $3`,
+		board, successor, id,
+	).Scan(&continued)
+	if err == nil {
+		return &internal_errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Thread %d already continues thread %d", successor, continued),
+			StatusCode: http.StatusConflict,
+		}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check thread successors: %w", err)
+	}
+
+	createdAt := time.Now().UTC().Round(time.Microsecond)
+	_, err = q.Exec(`
+		UPDATE threads SET last_modified_at = $4
+		WHERE board = $1 AND (id IN ($2, $3) OR id = (SELECT successor_id FROM thread_successors WHERE board = $1 AND thread_id = $2))`,
+		board, id, successor, createdAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to touch linked threads: %w", err)
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO thread_successors (board, thread_id, successor_id, set_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (board, thread_id) DO UPDATE SET
+			successor_id = excluded.successor_id, set_by = excluded.set_by, created_at = excluded.created_at`,
+		board, id, successor, setBy, createdAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set thread successor: %w", err)
+	}
+	return nil
+}
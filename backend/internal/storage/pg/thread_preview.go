@@ -0,0 +1,170 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/lib/pq"
+)
+
+// Thread previews are the incremental alternative to the per-board materialized
+// views (see board_view.go), enabled with incremental_board_previews. The
+// thread_previews table holds the keys of the messages a board page shows: the
+// OP and the last NLastMsg messages of each thread, without messages of users
+// shadowbanned on the board and without threads such users started. It is
+// updated in the same transaction as every post and deletion, so board pages are
+// never stale and no refresh job is needed.
+
+// previewRowsSelect selects the preview keys with the same rules as the
+// materialized view template. $1 is NLastMsg, extra conditions are appended.
+const previewRowsSelect = `
+	SELECT m.board, m.thread_id, m.id
+	FROM threads t
+	JOIN messages m ON m.board = t.board AND m.thread_id = t.id
+	WHERE (m.id = 1 OR (t.next_message_id - 1 - m.id) < $1)
+	  AND NOT EXISTS ( -- shadowbanned author
+		SELECT 1 FROM user_shadowbans sb
+		WHERE sb.board = m.board AND sb.user_id = m.author_id
+	  )
+	  AND NOT EXISTS ( -- thread started by a shadowbanned user
+		SELECT 1 FROM messages op
+		JOIN user_shadowbans sb ON sb.board = op.board AND sb.user_id = op.author_id
+		WHERE op.board = t.board AND op.thread_id = t.id AND op.id = 1
+	  )`
+
+// RebuildThreadPreviews recreates the preview rows of all boards. It is run on
+// startup when incremental previews are enabled, so rows missed while the
+// materialized views were in use never leak into board pages.
+func (s *Storage) RebuildThreadPreviews() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	err := s.withTx(ctx, func(tx Querier) error {
+		if _, err := tx.Exec(`DELETE FROM thread_previews`); err != nil {
+			return fmt.Errorf("failed to clear thread previews: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO thread_previews (board, thread_id, msg_id)`+previewRowsSelect, s.cfg.Public.NLastMsg); err != nil {
+			return fmt.Errorf("failed to rebuild thread previews: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Log.Info("thread previews rebuilt", "duration", time.Since(start))
+	return nil
+}
+
+// addToThreadPreview records a new message in its thread's preview and drops
+// messages that fell out of the last NLastMsg. Nothing is added for authors
+// shadowbanned on the board or to threads whose OP is hidden (the OP row is missing).
+func (s *Storage) addToThreadPreview(q Querier, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId, authorId domain.UserId) error {
+	if !s.cfg.Public.IncrementalBoardPreviews {
+		return nil
+	}
+
+	_, err := q.Exec(`
+		INSERT INTO thread_previews (board, thread_id, msg_id)
+		SELECT $1::varchar, $2::bigint, $3::int
+		WHERE NOT EXISTS (SELECT 1 FROM user_shadowbans WHERE board = $1 AND user_id = $4)
+		  AND ($3::int = 1 OR EXISTS (SELECT 1 FROM thread_previews WHERE board = $1 AND thread_id = $2 AND msg_id = 1))`,
+		board, threadId, msgId, authorId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add message to thread preview: %w", err)
+	}
+
+	_, err = q.Exec(`
+		DELETE FROM thread_previews
+		WHERE board = $1 AND thread_id = $2 AND msg_id <> 1 AND msg_id <= $3::int - $4::int`,
+		board, threadId, msgId, s.cfg.Public.NLastMsg,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to trim thread preview: %w", err)
+	}
+	return nil
+}
+
+// removeFromThreadPreview drops a deleted message from its thread's preview, or
+// the whole thread when msgId is nil. Older messages do not move into the
+// preview, matching the materialized view which picks the last NLastMsg ids.
+func (s *Storage) removeFromThreadPreview(q Querier, board domain.BoardShortName, threadId domain.ThreadId, msgId *domain.MsgId) error {
+	if !s.cfg.Public.IncrementalBoardPreviews {
+		return nil
+	}
+
+	var err error
+	if msgId == nil {
+		_, err = q.Exec(`DELETE FROM thread_previews WHERE board = $1 AND thread_id = $2`, board, threadId)
+	} else {
+		_, err = q.Exec(`DELETE FROM thread_previews WHERE board = $1 AND thread_id = $2 AND msg_id = $3`, board, threadId, *msgId)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove from thread preview: %w", err)
+	}
+	return nil
+}
+
+// rebuildBoardPreviews recreates the preview rows of one board. Used when a
+// shadowban changes which messages of the board are visible.
+func (s *Storage) rebuildBoardPreviews(q Querier, board domain.BoardShortName) error {
+	if !s.cfg.Public.IncrementalBoardPreviews {
+		return nil
+	}
+
+	if _, err := q.Exec(`DELETE FROM thread_previews WHERE board = $1`, board); err != nil {
+		return fmt.Errorf("failed to clear previews of board '%s': %w", board, err)
+	}
+	_, err := q.Exec(`INSERT INTO thread_previews (board, thread_id, msg_id)`+previewRowsSelect+`
+	  AND t.board = $2`,
+		s.cfg.Public.NLastMsg, board,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild previews of board '%s': %w", board, err)
+	}
+	return nil
+}
+
+// boardPageQuery returns the query for one page of board previews. Both variants
+// take ($1 threads per page, $2 page) and select the same columns in the same
+// order: threads ordered pinned first, then by bump time, each with its preview messages.
+func (s *Storage) boardPageQuery(shortName domain.BoardShortName) string {
+	if !s.cfg.Public.IncrementalBoardPreviews {
+		// ViewTableName returns an already quoted identifier
+		return fmt.Sprintf(`
+            SELECT thread_title, message_count, last_bumped_at, thread_id, is_pinned,
+                   msg_id, author_id, email_domain, author_is_admin, show_email_domain,
+                   text, created_at, post_number
+            FROM %s
+            WHERE thread_order BETWEEN $1 * ($2 - 1) + 1 AND $1 * $2
+            ORDER BY thread_order, msg_id
+			`,
+			ViewTableName(shortName),
+		)
+	}
+
+	return fmt.Sprintf(`
+		WITH page AS (
+			SELECT t.id, t.title, t.message_count, t.last_bumped_at, t.is_pinned
+			FROM threads t
+			JOIN thread_previews op ON op.board = t.board AND op.thread_id = t.id AND op.msg_id = 1
+			WHERE t.board = %[1]s
+			ORDER BY t.is_pinned DESC, t.last_bumped_at DESC, t.id
+			LIMIT $1 OFFSET $1 * ($2 - 1)
+		)
+		SELECT page.title, page.message_count, page.last_bumped_at, page.id, page.is_pinned,
+		       m.id, m.author_id, u.email_domain, u.is_admin, m.show_email_domain,
+		       m.text, m.created_at, m.post_number
+		FROM page
+		JOIN thread_previews p ON p.board = %[1]s AND p.thread_id = page.id
+		JOIN messages m ON m.board = p.board AND m.thread_id = p.thread_id AND m.id = p.msg_id
+		JOIN users u ON u.id = m.author_id
+		ORDER BY page.is_pinned DESC, page.last_bumped_at DESC, page.id, m.id
+		`,
+		pq.QuoteLiteral(shortName),
+	)
+}
@@ -3,19 +3,22 @@ package pg
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/itchan-dev/itchan/shared/domain"
+	sharedstorage "github.com/itchan-dev/itchan/shared/storage/pg"
+	"github.com/itchan-dev/itchan/shared/utils"
+	"github.com/lib/pq"
 )
 
 // GetUserMessages fetches user's last N messages across all boards.
 // Returns FULLY enriched domain.Message objects with Author, Attachments, Replies.
-func (s *Storage) GetUserMessages(userId domain.UserId, limit int) ([]domain.Message, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *Storage) GetUserMessages(ctx context.Context, userId domain.UserId, limit int) ([]domain.Message, error) {
+	ctx, cancel := s.longQueryContext(ctx)
 	defer cancel()
+	q := sharedstorage.WithContext(ctx, s.db)
 
 	// Step 1: Fetch messages with author data
-	rows, err := s.db.QueryContext(ctx, `
+	rows, err := q.Query(`
 		SELECT
 			m.id, m.board, m.thread_id, m.text, m.created_at,
 			u.id, u.email_domain, u.is_admin
@@ -75,11 +78,8 @@ func (s *Storage) GetUserMessages(userId domain.UserId, limit int) ([]domain.Mes
 		}
 
 		// Enrich with replies and attachments for this board only
-		if err := enrichMessagesWithReplies(s.db, board, messageKeys, idToMessage, s.cfg.Public.MessagesPerThreadPage); err != nil {
-			return nil, fmt.Errorf("failed to enrich replies for board %s: %w", board, err)
-		}
-		if err := enrichMessagesWithAttachments(s.db, board, messageKeys, idToMessage); err != nil {
-			return nil, fmt.Errorf("failed to enrich attachments for board %s: %w", board, err)
+		if err := enrichMessages(q, board, messageKeys, idToMessage, s.cfg.Public.MessagesPerThreadPage, false); err != nil {
+			return nil, fmt.Errorf("failed to enrich board %s: %w", board, err)
 		}
 	}
 
@@ -90,3 +90,78 @@ func (s *Storage) GetUserMessages(userId domain.UserId, limit int) ([]domain.Mes
 
 	return messages, nil
 }
+
+// GetUserPosts returns one page of the user's posts on the given boards, newest
+// first, and the total number of such posts. Unlike the activity feed, the
+// user's own shadowbanned posts are included.
+func (s *Storage) GetUserPosts(ctx context.Context, userId domain.UserId, boards []domain.BoardShortName, limit, offset int) ([]domain.LatestPost, int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	posts := []domain.LatestPost{}
+	if len(boards) == 0 {
+		return posts, 0, nil
+	}
+
+	var total int
+	err := q.QueryRow(`
+		SELECT count(*) FROM messages
+		WHERE author_id = $1 AND board = ANY($2)`,
+		userId, pq.Array(boards),
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count user posts: %w", err)
+	}
+
+	rows, err := q.Query(`
+		SELECT m.board, m.thread_id, t.title, m.id, m.text, m.created_at
+		FROM messages m
+		JOIN threads t ON t.board = m.board AND t.id = m.thread_id
+		WHERE m.author_id = $1 AND m.board = ANY($2)
+		ORDER BY m.created_at DESC
+		LIMIT $3 OFFSET $4`,
+		userId, pq.Array(boards), limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch user posts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p domain.LatestPost
+		if err := rows.Scan(&p.Board, &p.ThreadId, &p.ThreadTitle, &p.Id, &p.Text, &p.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user post: %w", err)
+		}
+		p.Page = utils.CalculatePage(int(p.Id), s.cfg.Public.MessagesPerThreadPage)
+		posts = append(posts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating user posts: %w", err)
+	}
+	return posts, total, nil
+}
+
+// GetUserStats counts the user's posts, started threads and uploaded
+// attachments. Deleted messages are not counted.
+func (s *Storage) GetUserStats(ctx context.Context, userId domain.UserId) (domain.UserStats, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var stats domain.UserStats
+	err := q.QueryRow(`
+		SELECT
+			count(*),
+			count(*) FILTER (WHERE m.id = 1),
+			min(m.created_at),
+			(SELECT count(*) FROM attachments a
+			 JOIN messages am ON am.board = a.board AND am.thread_id = a.thread_id AND am.id = a.message_id
+			 WHERE am.author_id = $1)
+		FROM messages m
+		WHERE m.author_id = $1`,
+		userId,
+	).Scan(&stats.Posts, &stats.ThreadsStarted, &stats.FirstPostAt, &stats.Attachments)
+	if err != nil {
+		return domain.UserStats{}, fmt.Errorf("failed to get user stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,197 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// =========================================================================
+// Public Methods (ban appeals)
+// =========================================================================
+
+// CreateAppeal files an appeal against the user's current ban and returns its ID.
+func (s *Storage) CreateAppeal(ctx context.Context, userId domain.UserId, text string) (domain.AppealId, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	var id domain.AppealId
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		id, err = s.createAppeal(tx, userId, text)
+		return err
+	})
+	return id, err
+}
+
+// GetAppeal returns a single appeal by ID.
+func (s *Storage) GetAppeal(ctx context.Context, id domain.AppealId) (domain.Appeal, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getAppeal(q, id)
+}
+
+// GetLatestAppeal returns the user's most recent appeal.
+func (s *Storage) GetLatestAppeal(ctx context.Context, userId domain.UserId) (domain.Appeal, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getLatestAppeal(q, userId)
+}
+
+// GetAppeals returns appeals with the given status, oldest first, so the
+// moderation queue is worked in submission order.
+func (s *Storage) GetAppeals(ctx context.Context, status domain.AppealStatus, limit, offset int) ([]domain.Appeal, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getAppeals(q, status, limit, offset)
+}
+
+// DecideAppeal moves a pending appeal to its final status.
+func (s *Storage) DecideAppeal(ctx context.Context, id domain.AppealId, status domain.AppealStatus, response string, decidedBy domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.decideAppeal(tx, id, status, response, decidedBy)
+	})
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+const appealColumns = `
+	id, user_id, text, status, banned_at, COALESCE(ban_reason, ''), COALESCE(banned_by, 0),
+	response, decided_by, created_at, decided_at`
+
+func scanAppeal(row interface{ Scan(...any) error }) (domain.Appeal, error) {
+	var a domain.Appeal
+	var decidedBy sql.NullInt64
+	var decidedAt sql.NullTime
+	err := row.Scan(
+		&a.Id, &a.UserId, &a.Text, &a.Status, &a.Ban.BlacklistedAt, &a.Ban.Reason, &a.Ban.BlacklistedBy,
+		&a.Response, &decidedBy, &a.CreatedAt, &decidedAt,
+	)
+	if err != nil {
+		return domain.Appeal{}, err
+	}
+	a.Ban.UserId = a.UserId
+	if decidedBy.Valid {
+		a.DecidedBy = &decidedBy.Int64
+	}
+	if decidedAt.Valid {
+		a.DecidedAt = &decidedAt.Time
+	}
+	return a, nil
+}
+
+func (s *Storage) createAppeal(q Querier, userId domain.UserId, text string) (domain.AppealId, error) {
+	var id domain.AppealId
+	err := q.QueryRow(`
+		INSERT INTO ban_appeals (user_id, banned_at, ban_reason, banned_by, text)
+		SELECT user_id, blacklisted_at, reason, blacklisted_by, $2
+		FROM user_blacklist
+		WHERE user_id = $1 AND `+activeBanCondition+`
+		RETURNING id`,
+		userId, text,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, &internal_errors.ErrorWithStatusCode{
+				Message: "Account is not suspended", StatusCode: http.StatusBadRequest,
+			}
+		}
+		if isUniqueViolation(err) {
+			return 0, &internal_errors.ErrorWithStatusCode{
+				Message: "An appeal for this suspension was already submitted", StatusCode: http.StatusConflict,
+			}
+		}
+		return 0, fmt.Errorf("failed to insert appeal: %w", err)
+	}
+	return id, nil
+}
+
+func (s *Storage) getAppeal(q Querier, id domain.AppealId) (domain.Appeal, error) {
+	a, err := scanAppeal(q.QueryRow(`SELECT `+appealColumns+` FROM ban_appeals WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Appeal{}, &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Appeal %d not found", id), StatusCode: http.StatusNotFound,
+			}
+		}
+		return domain.Appeal{}, fmt.Errorf("failed to fetch appeal %d: %w", id, err)
+	}
+	return a, nil
+}
+
+func (s *Storage) getLatestAppeal(q Querier, userId domain.UserId) (domain.Appeal, error) {
+	a, err := scanAppeal(q.QueryRow(`
+		SELECT `+appealColumns+` FROM ban_appeals
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`, userId))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Appeal{}, &internal_errors.ErrorWithStatusCode{
+				Message: "Appeal not found", StatusCode: http.StatusNotFound,
+			}
+		}
+		return domain.Appeal{}, fmt.Errorf("failed to fetch latest appeal for user %d: %w", userId, err)
+	}
+	return a, nil
+}
+
+func (s *Storage) getAppeals(q Querier, status domain.AppealStatus, limit, offset int) ([]domain.Appeal, error) {
+	rows, err := q.Query(`
+		SELECT `+appealColumns+` FROM ban_appeals
+		WHERE status = $1
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3`,
+		status, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appeals: %w", err)
+	}
+	defer rows.Close()
+
+	var appeals []domain.Appeal
+	for rows.Next() {
+		a, err := scanAppeal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan appeal: %w", err)
+		}
+		appeals = append(appeals, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating appeals: %w", err)
+	}
+	return appeals, nil
+}
+
+func (s *Storage) decideAppeal(q Querier, id domain.AppealId, status domain.AppealStatus, response string, decidedBy domain.UserId) error {
+	result, err := q.Exec(`
+		UPDATE ban_appeals
+		SET status = $2, response = $3, decided_by = $4, decided_at = $5
+		WHERE id = $1 AND status = 'pending'`,
+		id, status, response, decidedBy, now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to decide appeal %d: %w", id, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// Distinguish a missing appeal from one that was already decided.
+		if _, err := s.getAppeal(q, id); err != nil {
+			return err
+		}
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Appeal %d was already decided", id), StatusCode: http.StatusConflict,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/crypto"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.ThreadStorage interface)
+// =========================================================================
+
+// GetThreadArchive returns every message of a thread in id order with what
+// its content hash covers, see package pg.
+func (s *Storage) GetThreadArchive(ctx context.Context, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadArchive, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	var archive domain.ThreadArchive
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		archive, err = s.getThreadArchive(tx, board, id)
+		return err
+	})
+	return archive, err
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// =========================================================================
+
+// archivedMessages reads the messages of a thread, or only msgId, with the
+// fields their content hash covers, the attachment hashes in attachment order.
+// The author commitment is the one stored with the hash, so the hash stays
+// valid after the encryption key is rotated; messages without one get it
+// computed with the current key.
+func (s *Storage) archivedMessages(q Querier, board domain.BoardShortName, threadId domain.ThreadId, msgId *domain.MsgId) ([]domain.ArchivedMessage, error) {
+	rows, err := q.Query(`
+		SELECT m.id, m.author_id, m.text, m.created_at, m.content_hash, m.author_commitment,
+			(
+				SELECT json_group_array(COALESCE(f.sha256, '') ORDER BY a.id)
+				FROM attachments a JOIN files f ON f.id = a.file_id
+				WHERE a.board = m.board AND a.thread_id = m.thread_id AND a.message_id = m.id
+			)
+		FROM messages m
+		WHERE m.board = $1 AND m.thread_id = $2 AND ($3 IS NULL OR m.id = $3)
+		ORDER BY m.id`,
+		board, threadId, msgId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch archived messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []domain.ArchivedMessage{}
+	for rows.Next() {
+		var m domain.ArchivedMessage
+		var contentHash, commitment []byte
+		var attachmentHashes string
+		if err := rows.Scan(&m.Id, &m.Author, &m.Text, &m.CreatedAt, &contentHash, &commitment, &attachmentHashes); err != nil {
+			return nil, fmt.Errorf("failed to scan archived message: %w", err)
+		}
+		if err := json.Unmarshal([]byte(attachmentHashes), &m.AttachmentHashes); err != nil {
+			return nil, fmt.Errorf("failed to decode attachment hashes of message %d: %w", m.Id, err)
+		}
+		m.CreatedAt = m.CreatedAt.UTC()
+		if len(commitment) == 0 {
+			commitment = s.authorCommitment(threadId, m)
+		}
+		m.AuthorCommitment = hex.EncodeToString(commitment)
+		m.ContentHash = hex.EncodeToString(contentHash)
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating archived messages: %w", err)
+	}
+	return messages, nil
+}
+
+// authorCommitment is keyed with the encryption key, see package pg.
+func (s *Storage) authorCommitment(threadId domain.ThreadId, m domain.ArchivedMessage) []byte {
+	return crypto.AuthorCommitment([]byte(s.cfg.Private.EncryptionKey), int64(threadId), int64(m.Id), m.CreatedAt, int64(m.Author))
+}
+
+// storeContentHash hashes the message as stored, so it has to run after
+// everything the hash covers is written: the text and the attachments.
+func (s *Storage) storeContentHash(q Querier, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	messages, err := s.archivedMessages(q, board, threadId, &msgId)
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return fmt.Errorf("failed to hash message %d: not found", msgId)
+	}
+	m := messages[0]
+	commitment, err := hex.DecodeString(m.AuthorCommitment)
+	if err != nil {
+		return fmt.Errorf("failed to decode author commitment of message %d: %w", msgId, err)
+	}
+	hash := crypto.MessageContentHash(crypto.MessageContent{
+		ThreadId:         int64(threadId),
+		MessageId:        int64(m.Id),
+		CreatedAt:        m.CreatedAt,
+		Text:             m.Text,
+		AttachmentHashes: m.AttachmentHashes,
+		AuthorCommitment: commitment,
+	})
+	_, err = q.Exec(`
+		UPDATE messages SET content_hash = $4, author_commitment = $5
+		WHERE board = $1 AND thread_id = $2 AND id = $3`,
+		board, threadId, msgId, hash, commitment,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store content hash of message %d: %w", msgId, err)
+	}
+	return nil
+}
+
+func (s *Storage) getThreadArchive(q Querier, board domain.BoardShortName, id domain.ThreadId) (domain.ThreadArchive, error) {
+	archive := domain.ThreadArchive{Board: board, ThreadId: id}
+	err := q.QueryRow(`SELECT title FROM threads WHERE board = $1 AND id = $2`, board, id).Scan(&archive.Title)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ThreadArchive{}, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+	}
+	if err != nil {
+		return domain.ThreadArchive{}, fmt.Errorf("failed to fetch thread: %w", err)
+	}
+
+	archive.Messages, err = s.archivedMessages(q, board, id, nil)
+	if err != nil {
+		return domain.ThreadArchive{}, err
+	}
+	return archive, nil
+}
@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// backupRunColumns selects a run in the order scanBackupRun expects.
+const backupRunColumns = `id, status, started_at, finished_at, paused_ms, error`
+
+// =========================================================================
+// Public Methods (satisfy the service.BackupStorage interface)
+// =========================================================================
+
+// AddBackupRun records a backup run that has started.
+func (s *Storage) AddBackupRun(ctx context.Context, startedAt time.Time) (domain.BackupRunId, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.addBackupRun(q, startedAt)
+}
+
+// FinishBackupRun records the outcome of a run.
+func (s *Storage) FinishBackupRun(ctx context.Context, run domain.BackupRun) error {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.finishBackupRun(q, run)
+}
+
+// GetBackupRuns returns the latest runs, newest first.
+func (s *Storage) GetBackupRuns(ctx context.Context, limit int) ([]domain.BackupRun, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getBackupRuns(q, limit)
+}
+
+// GetLastSuccessfulBackup returns the latest run that succeeded, 404 if none.
+func (s *Storage) GetLastSuccessfulBackup(ctx context.Context) (domain.BackupRun, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getLastSuccessfulBackup(q)
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+func (s *Storage) addBackupRun(q Querier, startedAt time.Time) (domain.BackupRunId, error) {
+	var id domain.BackupRunId
+	err := q.QueryRow(`
+		INSERT INTO backup_runs (status, started_at)
+		VALUES ($1, $2)
+		RETURNING id`,
+		domain.BackupRunning, startedAt.UTC(),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add backup run: %w", err)
+	}
+	return id, nil
+}
+
+func (s *Storage) finishBackupRun(q Querier, run domain.BackupRun) error {
+	var finishedAt any
+	if run.FinishedAt != nil {
+		finishedAt = run.FinishedAt.UTC()
+	}
+	result, err := q.Exec(`
+		UPDATE backup_runs
+		SET status = $2, finished_at = $3, paused_ms = $4, error = $5
+		WHERE id = $1`,
+		run.Id, run.Status, finishedAt, run.PausedMs, run.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish backup run %d: %w", run.Id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{Message: "Backup run not found", StatusCode: http.StatusNotFound}
+	}
+	return nil
+}
+
+func (s *Storage) getBackupRuns(q Querier, limit int) ([]domain.BackupRun, error) {
+	rows, err := q.Query(`
+		SELECT `+backupRunColumns+`
+		FROM backup_runs
+		ORDER BY started_at DESC, id DESC
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch backup runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []domain.BackupRun{}
+	for rows.Next() {
+		run, err := scanBackupRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan backup run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating backup runs: %w", err)
+	}
+	return runs, nil
+}
+
+func (s *Storage) getLastSuccessfulBackup(q Querier) (domain.BackupRun, error) {
+	run, err := scanBackupRun(q.QueryRow(`
+		SELECT `+backupRunColumns+`
+		FROM backup_runs
+		WHERE status = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1`,
+		domain.BackupSucceeded,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return run, &internal_errors.ErrorWithStatusCode{Message: "No backup has succeeded yet", StatusCode: http.StatusNotFound}
+	}
+	if err != nil {
+		return run, fmt.Errorf("failed to get last successful backup: %w", err)
+	}
+	return run, nil
+}
+
+// scanBackupRun scans one row selected with backupRunColumns.
+func scanBackupRun(scanner interface {
+	Scan(dest ...any) error
+}) (domain.BackupRun, error) {
+	var run domain.BackupRun
+	var finishedAt sql.NullTime
+	if err := scanner.Scan(&run.Id, &run.Status, &run.StartedAt, &finishedAt, &run.PausedMs, &run.Error); err != nil {
+		return domain.BackupRun{}, err
+	}
+	run.StartedAt = run.StartedAt.UTC()
+	if finishedAt.Valid {
+		t := finishedAt.Time.UTC()
+		run.FinishedAt = &t
+	}
+	return run, nil
+}
@@ -0,0 +1,239 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// =========================================================================
+// Public Methods (blacklist operations for admin functionality)
+// =========================================================================
+
+// GetRecentlyBlacklistedUsers fetches all user IDs that were blacklisted
+// after the specified time. This is used for cache updates with TTL-based filtering.
+func (s *Storage) GetRecentlyBlacklistedUsers(since time.Time) ([]domain.UserId, error) {
+	return s.getRecentlyBlacklistedUsers(s.db, since)
+}
+
+// BlacklistUser adds a user to the blacklist. This is the public entry point
+// that wraps the operation in a transaction.
+func (s *Storage) BlacklistUser(ctx context.Context, userId domain.UserId, reason string, blacklistedBy domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.blacklistUser(tx, userId, reason, blacklistedBy)
+	})
+}
+
+// BlacklistUserUntil applies an automatic, temporary ban. It never shortens or
+// replaces a longer ban that is already in place.
+func (s *Storage) BlacklistUserUntil(ctx context.Context, userId domain.UserId, reason string, expiresAt time.Time) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.blacklistUserUntil(tx, userId, reason, expiresAt)
+	})
+}
+
+// UnblacklistUser removes a user from the blacklist. This is the public entry point
+// that wraps the operation in a transaction.
+func (s *Storage) UnblacklistUser(ctx context.Context, userId domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.unblacklistUser(tx, userId)
+	})
+}
+
+// IsUserBlacklisted checks if a specific user is currently blacklisted.
+// This is a read-only operation used for direct DB checks (e.g., at login).
+func (s *Storage) IsUserBlacklisted(ctx context.Context, userId domain.UserId) (bool, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.isUserBlacklisted(q, userId)
+}
+
+// GetBlacklistedUsersWithDetails retrieves blacklisted users with their full details
+// (reason, blacklisted_at, blacklisted_by) for admin display purposes, with pagination.
+func (s *Storage) GetBlacklistedUsersWithDetails(ctx context.Context, limit, offset int) ([]domain.BlacklistEntry, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getBlacklistedUsersWithDetails(q, limit, offset)
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+// getRecentlyBlacklistedUsers contains the core logic for fetching recently blacklisted users.
+func (s *Storage) getRecentlyBlacklistedUsers(q Querier, since time.Time) ([]domain.UserId, error) {
+	rows, err := q.Query(`
+		SELECT user_id
+		FROM user_blacklist
+		WHERE blacklisted_at >= $1 AND `+activeBanCondition+`
+		ORDER BY blacklisted_at DESC`,
+		since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recently blacklisted users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIds []domain.UserId
+	for rows.Next() {
+		var userId domain.UserId
+		if err := rows.Scan(&userId); err != nil {
+			return nil, fmt.Errorf("failed to scan blacklisted user ID: %w", err)
+		}
+		userIds = append(userIds, userId)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blacklisted users: %w", err)
+	}
+
+	return userIds, nil
+}
+
+// blacklistUser contains the core logic for inserting a blacklist entry.
+func (s *Storage) blacklistUser(q Querier, userId domain.UserId, reason string, blacklistedBy domain.UserId) error {
+	// Check if user is trying to blacklist themselves
+	if userId == blacklistedBy {
+		return &internal_errors.ErrorWithStatusCode{
+			Message:    "Cannot blacklist yourself",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	// Use INSERT ... ON CONFLICT to make operation idempotent
+	// If user is already blacklisted, update the reason and timestamp.
+	// Manual bans are permanent, so this also clears any automatic expiry.
+	_, err := q.Exec(`
+		INSERT INTO user_blacklist (user_id, reason, blacklisted_by, blacklisted_at)
+		VALUES ($1, $2, $3, `+sqlNow+`)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			reason = EXCLUDED.reason,
+			blacklisted_by = EXCLUDED.blacklisted_by,
+			blacklisted_at = EXCLUDED.blacklisted_at,
+			expires_at = NULL`,
+		userId, reason, blacklistedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to blacklist user: %w", err)
+	}
+
+	return nil
+}
+
+// activeBanCondition filters user_blacklist down to bans that are in effect.
+// Expired temporary bans stay until replaced, lifted or removed by the cleanup job.
+const activeBanCondition = `(expires_at IS NULL OR expires_at > ` + sqlNow + `)`
+
+// blacklistUserUntil contains the core logic for automatic temporary bans.
+// An existing row is only replaced if it is a temporary ban ending before expiresAt,
+// so a permanent ban or a longer temporary one stays untouched.
+func (s *Storage) blacklistUserUntil(q Querier, userId domain.UserId, reason string, expiresAt time.Time) error {
+	_, err := q.Exec(`
+		INSERT INTO user_blacklist (user_id, reason, blacklisted_by, blacklisted_at, expires_at)
+		VALUES ($1, $2, NULL, `+sqlNow+`, $3)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			reason = EXCLUDED.reason,
+			blacklisted_by = NULL,
+			blacklisted_at = EXCLUDED.blacklisted_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE user_blacklist.expires_at IS NOT NULL AND user_blacklist.expires_at < EXCLUDED.expires_at`,
+		userId, reason, expiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to apply temporary ban: %w", err)
+	}
+	return nil
+}
+
+// unblacklistUser contains the core logic for removing a blacklist entry.
+func (s *Storage) unblacklistUser(q Querier, userId domain.UserId) error {
+	result, err := q.Exec("DELETE FROM user_blacklist WHERE user_id = $1", userId)
+	if err != nil {
+		return fmt.Errorf("failed to unblacklist user: %w", err)
+	}
+
+	rowsDeleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows for unblacklist: %w", err)
+	}
+
+	if rowsDeleted == 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message:    "User is not blacklisted",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	return nil
+}
+
+// isUserBlacklisted contains the core logic for checking blacklist status.
+func (s *Storage) isUserBlacklisted(q Querier, userId domain.UserId) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM user_blacklist WHERE user_id = $1 AND "+activeBanCondition+")", userId).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check blacklist status: %w", err)
+	}
+	return exists, nil
+}
+
+// getBlacklistedUsersWithDetails contains the core logic for fetching blacklist entries with details.
+func (s *Storage) getBlacklistedUsersWithDetails(q Querier, limit, offset int) ([]domain.BlacklistEntry, error) {
+	rows, err := q.Query(`
+		SELECT
+			ub.user_id,
+			ub.blacklisted_at,
+			COALESCE(ub.reason, ''),
+			COALESCE(ub.blacklisted_by, 0),
+			ub.expires_at
+		FROM user_blacklist ub
+		WHERE `+activeBanCondition+`
+		ORDER BY ub.blacklisted_at DESC
+		LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blacklisted users with details: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.BlacklistEntry
+	for rows.Next() {
+		var entry domain.BlacklistEntry
+		if err := rows.Scan(
+			&entry.UserId,
+			&entry.BlacklistedAt,
+			&entry.Reason,
+			&entry.BlacklistedBy,
+			&entry.ExpiresAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan blacklist entry: %w", err)
+		}
+
+		// Email field removed from BlacklistEntry - display user ID instead
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blacklist entries: %w", err)
+	}
+
+	return entries, nil
+}
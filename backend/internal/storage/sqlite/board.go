@@ -0,0 +1,725 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+var emptyAllowedEmailsError = errors.New("allowedEmails should be either nil or not empty")
+
+// =========================================================================
+// Public Methods (satisfy the service.BoardStorage interface)
+// =========================================================================
+
+// CreateBoard is the public entry point for creating a new board.
+// The board metadata and its permissions are inserted in a single transaction.
+func (s *Storage) CreateBoard(ctx context.Context, creationData domain.BoardCreationData) error {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.createBoard(tx, creationData)
+	})
+}
+
+// DeleteBoard is the public entry point for deleting a board.
+// The board row and, through cascading foreign keys, all its threads, messages
+// and attachments are removed in a single transaction.
+func (s *Storage) DeleteBoard(ctx context.Context, shortName domain.BoardShortName) error {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.deleteBoard(tx, shortName)
+	})
+}
+
+// GetBoard is a public, read-only method for fetching a single page of a board's
+// content. It delegates directly to the internal method using the main
+// database connection pool.
+func (s *Storage) GetBoard(ctx context.Context, shortName domain.BoardShortName, page int) (domain.Board, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getBoard(q, shortName, page)
+}
+
+// GetBoards is a public, read-only method to fetch metadata for all boards.
+func (s *Storage) GetBoards(ctx context.Context) ([]domain.BoardMetadata, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getBoards(q)
+}
+
+// ListBoards is a public, read-only method returning one page of board metadata
+// in the given order, together with the total number of boards.
+func (s *Storage) ListBoards(ctx context.Context, sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.listBoards(q, sort, stats, limit, offset)
+}
+
+// GetBoardLastModified returns the last_activity_at timestamp for a board.
+// Board pages are read from the tables directly, so they are never behind it.
+func (s *Storage) GetBoardLastModified(ctx context.Context, shortName domain.BoardShortName) (time.Time, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var lastModified time.Time
+	err := q.QueryRow(
+		`SELECT last_activity_at FROM boards WHERE short_name = $1`, shortName,
+	).Scan(&lastModified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+			}
+		}
+		return time.Time{}, fmt.Errorf("failed to fetch board last modified for '%s': %w", shortName, err)
+	}
+	return lastModified, nil
+}
+
+// UpdateBoardSettings replaces the admin-editable settings of a board.
+func (s *Storage) UpdateBoardSettings(ctx context.Context, shortName domain.BoardShortName, settings domain.BoardSettings) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.updateBoardSettings(tx, shortName, settings)
+	})
+}
+
+// GetBoardSettings returns the admin-editable settings of a board.
+func (s *Storage) GetBoardSettings(ctx context.Context, shortName domain.BoardShortName) (domain.BoardSettings, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getBoardSettings(q, shortName)
+}
+
+// GetBoardsWithPermissions returns a map of board short names to their allowed email domains.
+// Returns nil for boards without restrictions (public boards).
+func (s *Storage) GetBoardsWithPermissions() (map[string][]string, error) {
+	return getBoardsWithPermissions(s.db)
+}
+
+// GetBoardsPendingDeletion returns the short names of boards scheduled for
+// deletion. It is used by the board_access middleware to hide them.
+func (s *Storage) GetBoardsPendingDeletion() ([]string, error) {
+	return getBoardsPendingDeletion(s.db)
+}
+
+// ScheduleBoardDeletion marks a board for deletion after deleteAfter. The board
+// stays in the database until the purge job drops it.
+func (s *Storage) ScheduleBoardDeletion(ctx context.Context, shortName domain.BoardShortName, deleteAfter time.Time) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.scheduleBoardDeletion(tx, shortName, deleteAfter)
+	})
+}
+
+// CancelBoardDeletion restores a board that is scheduled for deletion.
+func (s *Storage) CancelBoardDeletion(ctx context.Context, shortName domain.BoardShortName) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.cancelBoardDeletion(tx, shortName)
+	})
+}
+
+// GetPendingBoardDeletions returns the boards scheduled for deletion, due
+// first.
+func (s *Storage) GetPendingBoardDeletions(ctx context.Context) ([]domain.PendingBoardDeletion, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getPendingBoardDeletions(q)
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+// createBoard inserts the board metadata and permissions. It must be executed
+// within a transaction.
+func (s *Storage) createBoard(q Querier, creationData domain.BoardCreationData) error {
+	if creationData.AllowedEmails != nil && len(*creationData.AllowedEmails) == 0 {
+		return fmt.Errorf("%w: allowed_emails cannot be empty", emptyAllowedEmailsError)
+	}
+
+	// Insert board metadata.
+	_, err := q.Exec(`
+        INSERT INTO boards (name, short_name, description) VALUES ($1, $2, $3)`,
+		creationData.Name, creationData.ShortName, creationData.Description,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Board with short name '%s' already exists", creationData.ShortName), StatusCode: http.StatusConflict,
+			}
+		}
+		return fmt.Errorf("failed to insert board metadata: %w", err)
+	}
+	// A board created under a name another board was renamed from takes it over.
+	if _, err = q.Exec(`DELETE FROM board_redirects WHERE old_short_name = $1`, creationData.ShortName); err != nil {
+		return fmt.Errorf("failed to drop board redirect: %w", err)
+	}
+
+	// Insert board permissions if provided.
+	if creationData.AllowedEmails != nil {
+		for _, email := range *creationData.AllowedEmails {
+			_, err = q.Exec(`
+				INSERT INTO board_permissions (board_short_name, allowed_email_domain) VALUES ($1, $2)`,
+				creationData.ShortName, email,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert board permission for '%s': %w", email, err)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteBoard removes the board and everything on it. It must be executed
+// within a transaction.
+func (s *Storage) deleteBoard(q Querier, shortName domain.BoardShortName) error {
+	// Collect the attached files before the attachments are cascade-deleted.
+	ids, err := fileIDs(q, `board = $1`, shortName)
+	if err != nil {
+		return fmt.Errorf("board '%s': %w", shortName, err)
+	}
+
+	// Foreign key constraints with CASCADE delete all associated threads and messages.
+	result, err := q.Exec("DELETE FROM boards WHERE short_name = $1", shortName)
+	if err != nil {
+		return fmt.Errorf("failed to delete board metadata for '%s': %w", shortName, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Board '%s' not found for deletion", shortName), StatusCode: http.StatusNotFound,
+		}
+	}
+
+	deleteFiles(q, ids, "board", shortName)
+	return nil
+}
+
+func (s *Storage) scheduleBoardDeletion(q Querier, shortName domain.BoardShortName, deleteAfter time.Time) error {
+	result, err := q.Exec(
+		`UPDATE boards SET delete_after = $2 WHERE short_name = $1 AND delete_after IS NULL`,
+		shortName, deleteAfter.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to schedule deletion of board '%s': %w", shortName, err)
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM boards WHERE short_name = $1)`, shortName).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check board existence: %w", err)
+	}
+	if !exists {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+		}
+	}
+	return &internal_errors.ErrorWithStatusCode{
+		Message: fmt.Sprintf("Board '%s' is already scheduled for deletion", shortName), StatusCode: http.StatusConflict,
+	}
+}
+
+func (s *Storage) cancelBoardDeletion(q Querier, shortName domain.BoardShortName) error {
+	result, err := q.Exec(
+		`UPDATE boards SET delete_after = NULL WHERE short_name = $1 AND delete_after IS NOT NULL`,
+		shortName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cancel deletion of board '%s': %w", shortName, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Board '%s' is not scheduled for deletion", shortName), StatusCode: http.StatusNotFound,
+		}
+	}
+	return nil
+}
+
+func (s *Storage) getPendingBoardDeletions(q Querier) ([]domain.PendingBoardDeletion, error) {
+	rows, err := q.Query(`
+		SELECT short_name, name, delete_after
+		FROM boards
+		WHERE delete_after IS NOT NULL
+		ORDER BY delete_after, short_name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query boards pending deletion: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []domain.PendingBoardDeletion
+	for rows.Next() {
+		var p domain.PendingBoardDeletion
+		if err := rows.Scan(&p.Board, &p.Name, &p.DeleteAfter); err != nil {
+			return nil, fmt.Errorf("failed to scan board pending deletion: %w", err)
+		}
+		pending = append(pending, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating boards pending deletion: %w", err)
+	}
+	return pending, nil
+}
+
+func getBoardsPendingDeletion(q Querier) ([]string, error) {
+	rows, err := q.Query(`SELECT short_name FROM boards WHERE delete_after IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query boards pending deletion: %w", err)
+	}
+	defer rows.Close()
+
+	var boards []string
+	for rows.Next() {
+		var board string
+		if err := rows.Scan(&board); err != nil {
+			return nil, fmt.Errorf("failed to scan board pending deletion: %w", err)
+		}
+		boards = append(boards, board)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating boards pending deletion: %w", err)
+	}
+	return boards, nil
+}
+
+func (s *Storage) updateBoardSettings(q Querier, shortName domain.BoardShortName, settings domain.BoardSettings) error {
+	result, err := q.Exec(`
+		UPDATE boards SET
+			show_deletion_stubs = $2,
+			min_op_text_length = $3,
+			require_op_attachment = $4,
+			max_threads_per_user_per_day = $5,
+			description = $6,
+			noindex = $7,
+			video_profile = $8,
+			allow_documents = $9,
+			max_attachments = $10,
+			allowed_mime_types = $11,
+			public_modlog = $12,
+			self_delete_replied = $13,
+			text_filter = $14,
+			posting_email_domains = $15,
+			posting_min_account_age_days = $16,
+			bump_limit_notice = $17,
+			pre_moderation = $18,
+			premod_trust_approvals = $19,
+			premod_trust_account_age_days = $20,
+			language = $21
+		WHERE short_name = $1`,
+		shortName, settings.ShowDeletionStubs, settings.MinOpTextLength, settings.RequireOpAttachment, settings.MaxThreadsPerUserPerDay, settings.Description,
+		settings.Noindex, settings.VideoProfile, settings.AllowDocuments, settings.MaxAttachments, strings.Join(settings.AllowedMimeTypes, ","), settings.PublicModLog, settings.SelfDeleteReplied, settings.TextFilter,
+		strings.Join(settings.PostingEmailDomains, ","), settings.PostingMinAccountAgeDays, settings.BumpLimitNotice,
+		settings.PreModeration, settings.PremodTrustApprovals, settings.PremodTrustAccountAgeDays, settings.Language,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update settings for board '%s': %w", shortName, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+		}
+	}
+
+	// Settings change how threads render, so invalidate cached thread pages.
+	return s.touchBoardThreads(q, shortName)
+}
+
+func (s *Storage) getBoardSettings(q Querier, shortName domain.BoardShortName) (domain.BoardSettings, error) {
+	var settings domain.BoardSettings
+	err := q.QueryRow(`
+		SELECT description, show_deletion_stubs, noindex, min_op_text_length, require_op_attachment, max_threads_per_user_per_day, video_profile, allow_documents, max_attachments, allowed_mime_types, public_modlog, self_delete_replied, text_filter, posting_email_domains, posting_min_account_age_days, bump_limit_notice, pre_moderation, premod_trust_approvals, premod_trust_account_age_days, language, EXISTS (SELECT 1 FROM board_mirrors bm WHERE bm.board = boards.short_name)
+		FROM boards WHERE short_name = $1`,
+		shortName,
+	).Scan(&settings.Description, &settings.ShowDeletionStubs, &settings.Noindex, &settings.MinOpTextLength, &settings.RequireOpAttachment, &settings.MaxThreadsPerUserPerDay, &settings.VideoProfile, &settings.AllowDocuments, &settings.MaxAttachments, (*commaList)(&settings.AllowedMimeTypes), &settings.PublicModLog, &settings.SelfDeleteReplied, &settings.TextFilter, (*commaList)(&settings.PostingEmailDomains), &settings.PostingMinAccountAgeDays, &settings.BumpLimitNotice, &settings.PreModeration, &settings.PremodTrustApprovals, &settings.PremodTrustAccountAgeDays, &settings.Language, &settings.Mirror)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.BoardSettings{}, &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+			}
+		}
+		return domain.BoardSettings{}, fmt.Errorf("failed to fetch settings for board '%s': %w", shortName, err)
+	}
+	return settings, nil
+}
+
+// getBoard contains the core logic for fetching a board's content.
+func (s *Storage) getBoard(q Querier, shortName domain.BoardShortName, page int) (domain.Board, error) {
+	var metadata domain.BoardMetadata
+	err := q.QueryRow(`
+	       SELECT name, short_name, created_at, last_activity_at, category_id, position,
+	              description, show_deletion_stubs, noindex, min_op_text_length, require_op_attachment, max_threads_per_user_per_day, video_profile, allow_documents, max_attachments, allowed_mime_types, public_modlog, self_delete_replied, text_filter, posting_email_domains, posting_min_account_age_days, bump_limit_notice, pre_moderation, premod_trust_approvals, premod_trust_account_age_days, language, EXISTS (SELECT 1 FROM board_mirrors bm WHERE bm.board = boards.short_name)
+	       FROM boards WHERE short_name = $1`,
+		shortName,
+	).Scan(&metadata.Name, &metadata.ShortName, &metadata.CreatedAt, &metadata.LastActivityAt, &metadata.CategoryId, &metadata.Position,
+		&metadata.Description, &metadata.ShowDeletionStubs, &metadata.Noindex, &metadata.MinOpTextLength, &metadata.RequireOpAttachment, &metadata.MaxThreadsPerUserPerDay, &metadata.VideoProfile, &metadata.AllowDocuments, &metadata.MaxAttachments, (*commaList)(&metadata.AllowedMimeTypes), &metadata.PublicModLog, &metadata.SelfDeleteReplied, &metadata.TextFilter, (*commaList)(&metadata.PostingEmailDomains), &metadata.PostingMinAccountAgeDays, &metadata.BumpLimitNotice, &metadata.PreModeration, &metadata.PremodTrustApprovals, &metadata.PremodTrustAccountAgeDays, &metadata.Language, &metadata.Mirror)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Board{}, &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+			}
+		}
+		return domain.Board{}, fmt.Errorf("failed to fetch board metadata for '%s': %w", shortName, err)
+	}
+
+	// Fetch the threads of the page, each with its OP and last messages
+	rows, err := q.Query(
+		boardPageQuery,
+		shortName,
+		s.cfg.Public.ThreadsPerPage,
+		page,
+		s.cfg.Public.NLastMsg,
+	)
+	if err != nil {
+		return domain.Board{}, fmt.Errorf("failed to fetch threads for board '%s': %w", shortName, err)
+	}
+	defer rows.Close()
+
+	type rowData struct {
+		ThreadTitle       domain.ThreadTitle
+		NMessages         int
+		LastBumpTs        time.Time
+		ThreadID          domain.ThreadId
+		IsPinned          bool
+		MsgID             domain.MsgId
+		AuthorID          domain.UserId
+		AuthorEmailDomain string
+		AuthorIsAdmin     bool
+		ShowEmailDomain   bool
+		Text              domain.MsgText
+		CreatedAt         time.Time
+		PostNumber        domain.PostNumber
+	}
+
+	// Map for efficient message lookup when attaching replies and attachments
+	// Key is (threadId, msgId) since msgId is per-thread
+	idToMessage := make(map[MsgKey]*domain.Message)
+	var messageKeys []MsgKey // Collect all message keys to fetch related data in bulk queries
+
+	var threads []*domain.Thread
+	var thread domain.Thread
+	var currentThread domain.ThreadId = -1
+	for rows.Next() {
+		var row rowData
+		if err := rows.Scan(
+			&row.ThreadTitle, &row.NMessages, &row.LastBumpTs, &row.ThreadID, &row.IsPinned, &row.MsgID,
+			&row.AuthorID, &row.AuthorEmailDomain, &row.AuthorIsAdmin, &row.ShowEmailDomain,
+			&row.Text, &row.CreatedAt, &row.PostNumber,
+		); err != nil {
+			return domain.Board{}, fmt.Errorf("failed to scan thread/message row: %w", err)
+		}
+
+		// rows are sorted by last_bumped_at and msg_id
+		// so, if row.ThreadID != currentThread(basically previous row/rows thread_id)
+		// that means new thread started, and we fully parsed previous thread
+		// we need to add parsed thread to threads and create new thread object
+		if currentThread != row.ThreadID {
+			// Thread is not empty (can be empty if this is first row)
+			if len(thread.Messages) > 0 {
+				// Create a copy to avoid all pointers pointing to the same variable
+				threadCopy := thread
+				threads = append(threads, &threadCopy)
+			}
+			currentThread = row.ThreadID
+			thread = domain.Thread{
+				ThreadMetadata: domain.ThreadMetadata{
+					Id:           row.ThreadID,
+					Title:        row.ThreadTitle,
+					Board:        shortName, // Board shortName from the outer scope
+					MessageCount: row.NMessages,
+					LastBumped:   row.LastBumpTs,
+					IsPinned:     row.IsPinned,
+				},
+				Messages: []*domain.Message{},
+			}
+		}
+		msg := &domain.Message{
+			MessageMetadata: domain.MessageMetadata{
+				Id:         row.MsgID,
+				PostNumber: row.PostNumber,
+				Author: domain.User{
+					Id:          row.AuthorID,
+					EmailDomain: row.AuthorEmailDomain,
+					Admin:       row.AuthorIsAdmin,
+				},
+				ShowEmailDomain: row.ShowEmailDomain,
+				CreatedAt:       row.CreatedAt,
+				ThreadId:        row.ThreadID,
+				Board:           shortName,
+				Replies:         domain.Replies{}, // Initialize empty replies slice
+			},
+			Text: row.Text,
+		}
+		thread.Messages = append(thread.Messages, msg)
+		key := MsgKey{ThreadId: row.ThreadID, MsgId: row.MsgID}
+		idToMessage[key] = msg
+		messageKeys = append(messageKeys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return domain.Board{}, fmt.Errorf("error iterating thread/message rows: %w", err)
+	}
+
+	// Add the last thread if any threads were parsed
+	if len(thread.Messages) > 0 {
+		// Create a copy to avoid all pointers pointing to the same variable
+		threadCopy := thread
+		threads = append(threads, &threadCopy)
+	}
+
+	// Enrich parsed messages with replies and attachments. The view already leaves
+	// out shadowbanned users' messages; their reply links are dropped too.
+	if len(messageKeys) > 0 {
+		if err := enrichMessages(q, shortName, messageKeys, idToMessage, s.cfg.Public.MessagesPerThreadPage, true); err != nil {
+			return domain.Board{}, fmt.Errorf("failed to enrich board page: %w", err)
+		}
+	}
+
+	return domain.Board{
+		BoardMetadata: metadata,
+		Threads:       threads,
+	}, nil
+}
+
+// boardPageQuery selects one page of board previews, taking ($1 board,
+// $2 threads per page, $3 page, $4 last messages per thread): threads ordered
+// pinned first, then by bump time, each with its OP and last messages. Like the
+// board view in package pg, it leaves out messages of users shadowbanned on
+// the board and threads they started.
+const boardPageQuery = `
+	WITH page AS (
+		SELECT t.id, t.title, t.message_count, t.last_bumped_at, t.is_pinned, t.next_message_id
+		FROM threads t
+		JOIN messages op ON op.board = t.board AND op.thread_id = t.id AND op.id = 1
+		WHERE t.board = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_shadowbans sb WHERE sb.board = op.board AND sb.user_id = op.author_id
+		  )
+		ORDER BY t.is_pinned DESC, t.last_bumped_at DESC, t.id
+		LIMIT $2 OFFSET $2 * ($3 - 1)
+	)
+	SELECT page.title, page.message_count, page.last_bumped_at, page.id, page.is_pinned,
+	       m.id, m.author_id, u.email_domain, u.is_admin, m.show_email_domain,
+	       m.text, m.created_at, m.post_number
+	FROM page
+	JOIN messages m ON m.board = $1 AND m.thread_id = page.id
+	JOIN users u ON u.id = m.author_id
+	WHERE (m.id = 1 OR page.next_message_id - 1 - m.id < $4)
+	  AND NOT EXISTS (
+		SELECT 1 FROM user_shadowbans sb WHERE sb.board = m.board AND sb.user_id = m.author_id
+	  )
+	ORDER BY page.is_pinned DESC, page.last_bumped_at DESC, page.id, m.id
+`
+
+// boardMetadataColumns are all fields that constitute BoardMetadata, in scanBoards order.
+const boardMetadataColumns = `
+		name, short_name, created_at, last_activity_at, category_id, position,
+		description, show_deletion_stubs, noindex, min_op_text_length, require_op_attachment, max_threads_per_user_per_day, video_profile, allow_documents, max_attachments, allowed_mime_types, public_modlog, self_delete_replied, text_filter, posting_email_domains, posting_min_account_age_days, bump_limit_notice, pre_moderation, premod_trust_approvals, premod_trust_account_age_days, language, EXISTS (SELECT 1 FROM board_mirrors bm WHERE bm.board = boards.short_name)`
+
+// boardOrder maps the listing sort options to ORDER BY clauses. Short name
+// breaks ties so pages are stable.
+var boardOrder = map[domain.BoardSort]string{
+	domain.BoardSortPosition: "position, short_name",
+	domain.BoardSortActivity: "last_activity_at DESC NULLS LAST, short_name",
+	domain.BoardSortCreated:  "created_at DESC NULLS LAST, short_name",
+	domain.BoardSortName:     "name, short_name",
+}
+
+// getBoards contains the core logic for fetching all board metadata.
+func (s *Storage) getBoards(q Querier) ([]domain.BoardMetadata, error) {
+	rows, err := q.Query(`SELECT` + boardMetadataColumns + `
+	FROM boards
+	WHERE delete_after IS NULL
+	ORDER BY position, short_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query boards: %w", err)
+	}
+	boards, err := scanBoards(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enrich boards with permissions (corporate vs public board distinction)
+	if err := enrichBoardsWithPermissions(q, boards); err != nil {
+		return nil, err
+	}
+
+	return boards, nil
+}
+
+// listBoards contains the core logic for the paginated board listing.
+func (s *Storage) listBoards(q Querier, sort domain.BoardSort, stats domain.BoardStatsMode, limit, offset int) ([]domain.BoardMetadata, int, error) {
+	order, ok := boardOrder[sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown board sort '%s'", sort)
+	}
+
+	var total int
+	if err := q.QueryRow(`SELECT COUNT(*) FROM boards WHERE delete_after IS NULL`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count boards: %w", err)
+	}
+
+	rows, err := q.Query(`SELECT`+boardMetadataColumns+`
+	FROM boards
+	WHERE delete_after IS NULL
+	ORDER BY `+order+`
+	LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query boards: %w", err)
+	}
+	boards, err := scanBoards(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := enrichBoardsWithPermissions(q, boards); err != nil {
+		return nil, 0, err
+	}
+	if stats != domain.BoardStatsNone {
+		if err := enrichBoardsWithStats(q, boards); err != nil {
+			return nil, 0, err
+		}
+	}
+	if stats == domain.BoardStatsLatest {
+		if err := enrichBoardsWithLatestThreads(q, boards); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return boards, total, nil
+}
+
+// scanBoards reads rows selected with boardMetadataColumns and closes them.
+func scanBoards(rows *sql.Rows) ([]domain.BoardMetadata, error) {
+	defer rows.Close()
+
+	var boards []domain.BoardMetadata
+	for rows.Next() {
+		var boardMeta domain.BoardMetadata
+		err := rows.Scan(
+			&boardMeta.Name,
+			&boardMeta.ShortName,
+			&boardMeta.CreatedAt,
+			&boardMeta.LastActivityAt,
+			&boardMeta.CategoryId,
+			&boardMeta.Position,
+			&boardMeta.Description,
+			&boardMeta.ShowDeletionStubs,
+			&boardMeta.Noindex,
+			&boardMeta.MinOpTextLength,
+			&boardMeta.RequireOpAttachment,
+			&boardMeta.MaxThreadsPerUserPerDay,
+			&boardMeta.VideoProfile,
+			&boardMeta.AllowDocuments,
+			&boardMeta.MaxAttachments,
+			(*commaList)(&boardMeta.AllowedMimeTypes),
+			&boardMeta.PublicModLog,
+			&boardMeta.SelfDeleteReplied,
+			&boardMeta.TextFilter,
+			(*commaList)(&boardMeta.PostingEmailDomains),
+			&boardMeta.PostingMinAccountAgeDays,
+			&boardMeta.BumpLimitNotice,
+			&boardMeta.PreModeration,
+			&boardMeta.PremodTrustApprovals,
+			&boardMeta.PremodTrustAccountAgeDays,
+			&boardMeta.Language,
+			&boardMeta.Mirror,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan board metadata: %w", err)
+		}
+		boards = append(boards, boardMeta)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating board rows: %w", err)
+	}
+	return boards, nil
+}
+
+// commaList scans a comma-separated text column; an empty value is nil.
+type commaList []string
+
+func (l *commaList) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a comma-separated list", src)
+	}
+	if value == "" {
+		*l = nil
+		return nil
+	}
+	*l = strings.Split(value, ",")
+	return nil
+}
+
+// threadCount contains the core logic for counting threads on a board.
+func (s *Storage) threadCount(q Querier, board domain.BoardShortName) (int, error) {
+	var count int
+	err := q.QueryRow(`SELECT count(*) as count FROM threads WHERE board = $1`, board).Scan(&count)
+	if err != nil {
+		return -1, fmt.Errorf("failed to count threads for board '%s': %w", board, err)
+	}
+	return count, nil
+}
+
+// threadsToDelete returns IDs of the oldest non-pinned threads that should be removed
+// to keep the board at or below maxCount.
+func (s *Storage) threadsToDelete(q Querier, board domain.BoardShortName, maxCount int) ([]domain.ThreadId, error) {
+	count, err := s.threadCount(q, board)
+	if err != nil {
+		return nil, err
+	}
+	limit := count - maxCount
+	if limit < 0 {
+		limit = 0
+	}
+	rows, err := q.Query(`
+		SELECT id FROM threads
+		WHERE board = $1 AND is_pinned = FALSE
+		ORDER BY last_bumped_at ASC, id
+		LIMIT $2`,
+		board, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find threads to delete for board '%s': %w", board, err)
+	}
+	defer rows.Close()
+
+	var ids []domain.ThreadId
+	for rows.Next() {
+		var id domain.ThreadId
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan thread ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// =========================================================================
+// Public Methods (board categories for the grouped index page)
+// =========================================================================
+
+// CreateBoardCategory inserts a new board category and returns its ID.
+func (s *Storage) CreateBoardCategory(ctx context.Context, data domain.BoardCategoryCreationData) (domain.BoardCategoryId, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	var id domain.BoardCategoryId
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		id, err = s.createBoardCategory(tx, data)
+		return err
+	})
+	return id, err
+}
+
+// UpdateBoardCategory renames and/or reorders an existing category.
+func (s *Storage) UpdateBoardCategory(ctx context.Context, id domain.BoardCategoryId, data domain.BoardCategoryCreationData) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.updateBoardCategory(tx, id, data)
+	})
+}
+
+// DeleteBoardCategory removes a category. Boards that belonged to it are kept
+// and become uncategorized (ON DELETE SET NULL).
+func (s *Storage) DeleteBoardCategory(ctx context.Context, id domain.BoardCategoryId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.deleteBoardCategory(tx, id)
+	})
+}
+
+// GetBoardCategories returns all categories ordered for display.
+// The Boards field of the returned categories is left empty.
+func (s *Storage) GetBoardCategories(ctx context.Context) ([]domain.BoardCategory, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getBoardCategories(q)
+}
+
+// SetBoardCategory assigns a board to a category (nil to unassign) and sets
+// its position inside that category.
+func (s *Storage) SetBoardCategory(ctx context.Context, shortName domain.BoardShortName, categoryId *domain.BoardCategoryId, position int) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.setBoardCategory(tx, shortName, categoryId, position)
+	})
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+func (s *Storage) createBoardCategory(q Querier, data domain.BoardCategoryCreationData) (domain.BoardCategoryId, error) {
+	var id domain.BoardCategoryId
+	err := q.QueryRow(`
+		INSERT INTO board_categories (name, position) VALUES ($1, $2)
+		RETURNING id`,
+		data.Name, data.Position,
+	).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Category '%s' already exists", data.Name), StatusCode: http.StatusConflict,
+			}
+		}
+		return 0, fmt.Errorf("failed to insert board category: %w", err)
+	}
+	return id, nil
+}
+
+func (s *Storage) updateBoardCategory(q Querier, id domain.BoardCategoryId, data domain.BoardCategoryCreationData) error {
+	result, err := q.Exec(`
+		UPDATE board_categories SET name = $2, position = $3 WHERE id = $1`,
+		id, data.Name, data.Position,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Category '%s' already exists", data.Name), StatusCode: http.StatusConflict,
+			}
+		}
+		return fmt.Errorf("failed to update board category %d: %w", id, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Category %d not found", id), StatusCode: http.StatusNotFound,
+		}
+	}
+	return nil
+}
+
+func (s *Storage) deleteBoardCategory(q Querier, id domain.BoardCategoryId) error {
+	result, err := q.Exec(`DELETE FROM board_categories WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete board category %d: %w", id, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Category %d not found", id), StatusCode: http.StatusNotFound,
+		}
+	}
+	return nil
+}
+
+func (s *Storage) getBoardCategories(q Querier) ([]domain.BoardCategory, error) {
+	rows, err := q.Query(`
+		SELECT id, name, position
+		FROM board_categories
+		ORDER BY position, name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query board categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []domain.BoardCategory
+	for rows.Next() {
+		var c domain.BoardCategory
+		if err := rows.Scan(&c.Id, &c.Name, &c.Position); err != nil {
+			return nil, fmt.Errorf("failed to scan board category: %w", err)
+		}
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating board categories: %w", err)
+	}
+	return categories, nil
+}
+
+func (s *Storage) setBoardCategory(q Querier, shortName domain.BoardShortName, categoryId *domain.BoardCategoryId, position int) error {
+	result, err := q.Exec(`
+		UPDATE boards SET category_id = $2, position = $3 WHERE short_name = $1`,
+		shortName, categoryId, position,
+	)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return &internal_errors.ErrorWithStatusCode{
+				Message: fmt.Sprintf("Category %d not found", *categoryId), StatusCode: http.StatusNotFound,
+			}
+		}
+		return fmt.Errorf("failed to set category for board '%s': %w", shortName, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("Board '%s' not found", shortName), StatusCode: http.StatusNotFound,
+		}
+	}
+	return nil
+}
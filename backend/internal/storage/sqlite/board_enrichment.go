@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// enrichBoardsWithPermissions fetches and attaches allowed email domains to boards.
+// It queries board_permissions table and populates the AllowedEmailDomains field
+// of each board in the provided slice.
+//
+// Boards without permissions (public boards) will have nil AllowedEmailDomains.
+// Boards with permissions (corporate boards) will have a non-empty slice.
+func enrichBoardsWithPermissions(q Querier, boards []domain.BoardMetadata) error {
+	if len(boards) == 0 {
+		return nil // No boards to enrich
+	}
+
+	// Load all board permissions in a single query
+	permissions, err := getBoardsWithPermissions(q)
+	if err != nil {
+		return fmt.Errorf("failed to load board permissions: %w", err)
+	}
+
+	// Populate AllowedEmailDomains for each board
+	for i := range boards {
+		boardKey := string(boards[i].ShortName)
+		if domains, exists := permissions[boardKey]; exists {
+			boards[i].AllowedEmailDomains = domains
+		}
+		// If not exists, AllowedEmailDomains remains nil (public board)
+	}
+
+	return nil
+}
+
+// getBoardsWithPermissions queries all board permissions and returns a map
+// of board short names to their allowed email domains.
+func getBoardsWithPermissions(q Querier) (map[string][]string, error) {
+	rows, err := q.Query(`
+		SELECT board_short_name, allowed_email_domain
+		FROM board_permissions
+		ORDER BY board_short_name, allowed_email_domain
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query board permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := make(map[string][]string)
+	for rows.Next() {
+		var boardShortName string
+		var allowedDomain string
+		if err := rows.Scan(&boardShortName, &allowedDomain); err != nil {
+			return nil, fmt.Errorf("failed to scan board permission row: %w", err)
+		}
+		permissions[boardShortName] = append(permissions[boardShortName], allowedDomain)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating board permission rows: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// enrichBoardsWithStats attaches thread and message counts to boards.
+// Boards without threads get zero counts.
+func enrichBoardsWithStats(q Querier, boards []domain.BoardMetadata) error {
+	if len(boards) == 0 {
+		return nil
+	}
+
+	shortNames := make([]any, len(boards))
+	for i, b := range boards {
+		shortNames[i] = b.ShortName
+		boards[i].Stats = &domain.BoardStats{}
+	}
+
+	rows, err := q.Query(`
+		SELECT board, COUNT(*), COALESCE(SUM(message_count), 0)
+		FROM threads
+		WHERE board IN (`+placeholders(1, len(shortNames))+`)
+		GROUP BY board`,
+		shortNames...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query board stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[domain.BoardShortName]domain.BoardStats, len(boards))
+	for rows.Next() {
+		var board domain.BoardShortName
+		var s domain.BoardStats
+		if err := rows.Scan(&board, &s.ThreadCount, &s.MessageCount); err != nil {
+			return fmt.Errorf("failed to scan board stats row: %w", err)
+		}
+		stats[board] = s
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating board stats rows: %w", err)
+	}
+
+	for i := range boards {
+		*boards[i].Stats = stats[boards[i].ShortName]
+	}
+	return nil
+}
+
+// enrichBoardsWithLatestThreads attaches the most recently bumped thread to
+// boards that already carry stats. Threads started by users shadowbanned on
+// the board are skipped, since the listing is shared by all viewers.
+func enrichBoardsWithLatestThreads(q Querier, boards []domain.BoardMetadata) error {
+	if len(boards) == 0 {
+		return nil
+	}
+
+	shortNames := make([]any, len(boards))
+	for i, b := range boards {
+		shortNames[i] = b.ShortName
+	}
+
+	rows, err := q.Query(`
+		SELECT board, id, title, last_bumped_at FROM (
+			SELECT t.board, t.id, t.title, t.last_bumped_at,
+				ROW_NUMBER() OVER (PARTITION BY t.board ORDER BY t.last_bumped_at DESC, t.id DESC) AS rn
+			FROM threads t
+			JOIN messages m ON m.board = t.board AND m.thread_id = t.id AND m.id = 1
+			WHERE t.board IN (`+placeholders(1, len(shortNames))+`) AND `+notShadowbannedCondition+`
+		)
+		WHERE rn = 1`,
+		shortNames...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query latest board threads: %w", err)
+	}
+	defer rows.Close()
+
+	latest := make(map[domain.BoardShortName]*domain.BoardLatestThread, len(boards))
+	for rows.Next() {
+		var board domain.BoardShortName
+		var t domain.BoardLatestThread
+		if err := rows.Scan(&board, &t.Id, &t.Title, &t.LastBumped); err != nil {
+			return fmt.Errorf("failed to scan latest board thread row: %w", err)
+		}
+		latest[board] = &t
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating latest board thread rows: %w", err)
+	}
+
+	for i := range boards {
+		if boards[i].Stats != nil {
+			boards[i].Stats.LatestThread = latest[boards[i].ShortName]
+		}
+	}
+	return nil
+}
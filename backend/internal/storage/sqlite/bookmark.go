@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// bookmarksFrom joins bookmarks (b) with their messages (m) and threads (t).
+// The user ($1) still sees bookmarks of their own shadowbanned messages.
+const bookmarksFrom = `
+		FROM message_bookmarks b
+		JOIN messages m ON m.board = b.board AND m.thread_id = b.thread_id AND m.id = b.message_id
+		JOIN threads t ON t.board = b.board AND t.id = b.thread_id
+		WHERE b.user_id = $1 AND (m.author_id = $1 OR ` + notShadowbannedCondition + `)`
+
+// =========================================================================
+// Public Methods (satisfy the service.BookmarkStorage interface)
+// =========================================================================
+
+// AddBookmark bookmarks a message for the user. Bookmarking it again is a no-op.
+func (s *Storage) AddBookmark(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.addBookmark(tx, userId, board, threadId, msgId)
+	})
+}
+
+// DeleteBookmark removes one of the user's bookmarks.
+func (s *Storage) DeleteBookmark(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		result, err := tx.Exec(`
+			DELETE FROM message_bookmarks
+			WHERE user_id = $1 AND board = $2 AND thread_id = $3 AND message_id = $4`,
+			userId, board, threadId, msgId,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to delete bookmark: %w", err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return &internal_errors.ErrorWithStatusCode{Message: "Bookmark not found", StatusCode: http.StatusNotFound}
+		}
+		return nil
+	})
+}
+
+// GetBookmarks returns one page of the user's bookmarks on the given boards,
+// newest first, and the total number of them, see package pg.
+func (s *Storage) GetBookmarks(ctx context.Context, userId domain.UserId, boards []domain.BoardShortName, limit, offset int) ([]domain.Bookmark, int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	bookmarks := []domain.Bookmark{}
+	if len(boards) == 0 {
+		return bookmarks, 0, nil
+	}
+
+	var total int
+	err := q.QueryRow(
+		`SELECT count(*) `+bookmarksFrom+` AND b.board IN (`+placeholders(2, len(boards))+`)`,
+		append([]any{userId}, boardArgs(boards)...)...,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count bookmarks: %w", err)
+	}
+
+	rows, err := q.Query(`
+		SELECT b.created_at, m.board, m.thread_id, t.title, m.id, m.text, m.created_at
+		`+bookmarksFrom+` AND b.board IN (`+placeholders(4, len(boards))+`)
+		ORDER BY b.created_at DESC, b.message_id DESC
+		LIMIT $2 OFFSET $3`,
+		append([]any{userId, limit, offset}, boardArgs(boards)...)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch bookmarks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b domain.Bookmark
+		p := &b.Message
+		if err := rows.Scan(&b.CreatedAt, &p.Board, &p.ThreadId, &p.ThreadTitle, &p.Id, &p.Text, &p.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan bookmark: %w", err)
+		}
+		p.Page = utils.CalculatePage(int(p.Id), s.cfg.Public.MessagesPerThreadPage)
+		bookmarks = append(bookmarks, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating bookmarks: %w", err)
+	}
+	return bookmarks, total, nil
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+func (s *Storage) addBookmark(q Querier, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	// Bookmarks reference the thread only, like notifications, so the
+	// message is checked here
+	var exists bool
+	err := q.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM messages WHERE board = $1 AND thread_id = $2 AND id = $3)`,
+		board, threadId, msgId,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check message for bookmark: %w", err)
+	}
+	if !exists {
+		return &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO message_bookmarks (user_id, board, thread_id, message_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, board, thread_id, message_id) DO NOTHING`,
+		userId, board, threadId, msgId, now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bookmark message %d in thread %d on board '%s': %w", msgId, threadId, board, err)
+	}
+	return nil
+}
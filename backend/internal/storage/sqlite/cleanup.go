@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.CleanupStorage interface)
+// =========================================================================
+
+// DeleteExpiredConfirmationData drops registration and password reset
+// requests whose confirmation code expired before the given time.
+func (s *Storage) DeleteExpiredConfirmationData(ctx context.Context, before time.Time) (int64, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.deleteExpiredConfirmationData(q, before)
+}
+
+// DeleteExpiredBans drops temporary bans that ended before the given time.
+// Permanent bans have no expiry and are never touched.
+func (s *Storage) DeleteExpiredBans(ctx context.Context, before time.Time) (int64, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.deleteExpiredBans(q, before)
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+func (s *Storage) deleteExpiredConfirmationData(q Querier, before time.Time) (int64, error) {
+	result, err := q.Exec(`DELETE FROM confirmation_data WHERE expires_at < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired confirmation data: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected, nil
+}
+
+func (s *Storage) deleteExpiredBans(q Querier, before time.Time) (int64, error) {
+	result, err := q.Exec(`DELETE FROM user_blacklist WHERE expires_at IS NOT NULL AND expires_at < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired bans: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected, nil
+}
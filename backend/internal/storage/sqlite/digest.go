@@ -0,0 +1,373 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.DigestStorage interface)
+// =========================================================================
+
+// WatchThread adds a thread to the user's watched threads. Watching a watched
+// thread again is a no-op.
+func (s *Storage) WatchThread(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		_, err := tx.Exec(`
+			INSERT INTO thread_watches (user_id, board, thread_id, created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, board, thread_id) DO NOTHING`,
+			userId, board, threadId, now(),
+		)
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+			}
+			return fmt.Errorf("failed to watch thread %d on board '%s': %w", threadId, board, err)
+		}
+		return nil
+	})
+}
+
+// UnwatchThread removes a thread from the user's watched threads. Unwatching
+// a thread that is not watched is a no-op.
+func (s *Storage) UnwatchThread(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		_, err := tx.Exec(
+			`DELETE FROM thread_watches WHERE user_id = $1 AND board = $2 AND thread_id = $3`,
+			userId, board, threadId,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to unwatch thread %d on board '%s': %w", threadId, board, err)
+		}
+		return nil
+	})
+}
+
+// IsWatchingThread reports whether the user watches the thread.
+func (s *Storage) IsWatchingThread(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) (bool, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var watching bool
+	err := q.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM thread_watches WHERE user_id = $1 AND board = $2 AND thread_id = $3)`,
+		userId, board, threadId,
+	).Scan(&watching)
+	if err != nil {
+		return false, fmt.Errorf("failed to check thread watch: %w", err)
+	}
+	return watching, nil
+}
+
+// GetReadPosition returns the last message of the thread the user has seen,
+// or 0 if they have not opened it.
+func (s *Storage) GetReadPosition(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId) (domain.MsgId, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var lastReadId domain.MsgId
+	err := q.QueryRow(
+		`SELECT last_read_id FROM thread_reads WHERE user_id = $1 AND board = $2 AND thread_id = $3`,
+		userId, board, threadId,
+	).Scan(&lastReadId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get read position: %w", err)
+	}
+	return lastReadId, nil
+}
+
+// SetReadPosition records that the user has seen the thread up to msgId. The
+// position only moves forward, so reading an older page keeps it.
+func (s *Storage) SetReadPosition(ctx context.Context, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.setReadPosition(tx, userId, board, threadId, msgId)
+	})
+}
+
+// GetWatchedThreads returns the user's watched threads with the number of
+// messages of others they have not read yet, threads with the most unread
+// first.
+func (s *Storage) GetWatchedThreads(ctx context.Context, userId domain.UserId) ([]domain.WatchedThread, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	rows, err := q.Query(`
+		SELECT w.board, w.thread_id, t.title, COALESCE(r.last_read_id, 0) AS last_read_id,
+			(SELECT count(*) FROM messages m
+			 WHERE m.board = w.board AND m.thread_id = w.thread_id
+			   AND m.id > COALESCE(r.last_read_id, 0) AND m.author_id <> $1
+			   AND `+notShadowbannedCondition+`) AS unread
+		FROM thread_watches w
+		JOIN threads t ON t.board = w.board AND t.id = w.thread_id
+		LEFT JOIN thread_reads r ON r.user_id = w.user_id AND r.board = w.board AND r.thread_id = w.thread_id
+		WHERE w.user_id = $1
+		ORDER BY unread DESC, w.created_at DESC, w.board, w.thread_id`,
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watched threads: %w", err)
+	}
+	defer rows.Close()
+
+	var threads []domain.WatchedThread
+	for rows.Next() {
+		var t domain.WatchedThread
+		if err := rows.Scan(&t.Board, &t.ThreadId, &t.Title, &t.LastReadId, &t.Unread); err != nil {
+			return nil, fmt.Errorf("failed to scan watched thread: %w", err)
+		}
+		t.Page = utils.CalculatePage(int(t.LastReadId)+1, s.cfg.Public.MessagesPerThreadPage)
+		threads = append(threads, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watched threads: %w", err)
+	}
+	return threads, nil
+}
+
+// GetDigestFrequency returns the user's digest frequency, or DigestOff if
+// they are not subscribed.
+func (s *Storage) GetDigestFrequency(ctx context.Context, userId domain.UserId) (domain.DigestFrequency, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var frequency domain.DigestFrequency
+	err := q.QueryRow(`SELECT frequency FROM email_digests WHERE user_id = $1`, userId).Scan(&frequency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.DigestOff, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get digest frequency: %w", err)
+	}
+	return frequency, nil
+}
+
+// SetDigestFrequency subscribes the user to digests starting at since, see
+// package pg.
+func (s *Storage) SetDigestFrequency(ctx context.Context, userId domain.UserId, frequency domain.DigestFrequency, unsubscribeToken string, since time.Time) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		_, err := tx.Exec(`
+			INSERT INTO email_digests (user_id, frequency, unsubscribe_token, last_sent_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id) DO UPDATE SET frequency = EXCLUDED.frequency`,
+			userId, frequency, unsubscribeToken, since.UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to set digest frequency: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteDigestSubscription unsubscribes the user. Unsubscribing without a
+// subscription is a no-op.
+func (s *Storage) DeleteDigestSubscription(ctx context.Context, userId domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		if _, err := tx.Exec(`DELETE FROM email_digests WHERE user_id = $1`, userId); err != nil {
+			return fmt.Errorf("failed to delete digest subscription: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteDigestSubscriptionByToken unsubscribes the user the token was issued
+// to. Unknown tokens, including those of past subscriptions, are not found.
+func (s *Storage) DeleteDigestSubscriptionByToken(ctx context.Context, token string) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		result, err := tx.Exec(`DELETE FROM email_digests WHERE unsubscribe_token = $1`, token)
+		if err != nil {
+			return fmt.Errorf("failed to delete digest subscription: %w", err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return &internal_errors.ErrorWithStatusCode{Message: "Subscription not found", StatusCode: http.StatusNotFound}
+		}
+		return nil
+	})
+}
+
+// GetDueDigestSubscriptions returns the subscriptions whose period has passed,
+// see package pg.
+func (s *Storage) GetDueDigestSubscriptions(ctx context.Context, dailyBefore, weeklyBefore time.Time) ([]domain.DigestSubscription, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	rows, err := q.Query(`
+		SELECT d.user_id, u.email_encrypted, d.frequency, d.unsubscribe_token, d.last_sent_at
+		FROM email_digests d
+		JOIN users u ON u.id = d.user_id
+		WHERE (d.frequency = $1 AND d.last_sent_at <= $2)
+		   OR (d.frequency = $3 AND d.last_sent_at <= $4)
+		ORDER BY d.user_id`,
+		domain.DigestDaily, dailyBefore.UTC(), domain.DigestWeekly, weeklyBefore.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due digests: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []domain.DigestSubscription
+	for rows.Next() {
+		var sub domain.DigestSubscription
+		if err := rows.Scan(&sub.UserId, &sub.EmailEncrypted, &sub.Frequency, &sub.UnsubscribeToken, &sub.LastSentAt); err != nil {
+			return nil, fmt.Errorf("failed to scan digest subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating digest subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// GetDigest collects the activity for the user's digest since the given time,
+// see package pg.
+func (s *Storage) GetDigest(ctx context.Context, userId domain.UserId, since time.Time, repliesLimit int) (domain.Digest, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	threads, err := s.getWatchedThreadActivity(q, userId, since)
+	if err != nil {
+		return domain.Digest{}, err
+	}
+	replies, err := s.getDigestReplies(q, userId, since, repliesLimit)
+	if err != nil {
+		return domain.Digest{}, err
+	}
+	return domain.Digest{Threads: threads, Replies: replies}, nil
+}
+
+// MarkDigestSent records that the user's digest was handled at sentAt, which
+// starts the next period.
+func (s *Storage) MarkDigestSent(ctx context.Context, userId domain.UserId, sentAt time.Time) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		if _, err := tx.Exec(`UPDATE email_digests SET last_sent_at = $2 WHERE user_id = $1`, userId, sentAt.UTC()); err != nil {
+			return fmt.Errorf("failed to mark digest as sent: %w", err)
+		}
+		return nil
+	})
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+// setReadPosition moves the user's read position in the thread to msgId
+// unless it is already past it. The message must exist.
+func (s *Storage) setReadPosition(q Querier, userId domain.UserId, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId) error {
+	var exists bool
+	err := q.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM messages WHERE board = $1 AND thread_id = $2 AND id = $3)`,
+		board, threadId, msgId,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check message for read position: %w", err)
+	}
+	if !exists {
+		return &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO thread_reads (user_id, board, thread_id, last_read_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, board, thread_id) DO UPDATE
+		SET last_read_id = max(thread_reads.last_read_id, EXCLUDED.last_read_id)`,
+		userId, board, threadId, msgId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set read position in thread %d on board '%s': %w", threadId, board, err)
+	}
+	return nil
+}
+
+func (s *Storage) getWatchedThreadActivity(q Querier, userId domain.UserId, since time.Time) ([]domain.WatchedThreadActivity, error) {
+	rows, err := q.Query(`
+		SELECT w.board, w.thread_id, t.title, count(*)
+		FROM thread_watches w
+		JOIN threads t ON t.board = w.board AND t.id = w.thread_id
+		JOIN messages m ON m.board = w.board AND m.thread_id = w.thread_id
+		WHERE w.user_id = $1 AND m.author_id <> $1
+		  AND m.created_at > $2 AND m.created_at > w.created_at
+		  AND `+notShadowbannedCondition+`
+		GROUP BY w.board, w.thread_id, t.title
+		ORDER BY count(*) DESC, w.board, w.thread_id`,
+		userId, since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watched thread activity: %w", err)
+	}
+	defer rows.Close()
+
+	var threads []domain.WatchedThreadActivity
+	for rows.Next() {
+		var t domain.WatchedThreadActivity
+		if err := rows.Scan(&t.Board, &t.ThreadId, &t.Title, &t.NewReplies); err != nil {
+			return nil, fmt.Errorf("failed to scan watched thread activity: %w", err)
+		}
+		threads = append(threads, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watched thread activity: %w", err)
+	}
+	return threads, nil
+}
+
+func (s *Storage) getDigestReplies(q Querier, userId domain.UserId, since time.Time, limit int) ([]domain.LatestPost, error) {
+	rows, err := q.Query(`
+		SELECT m.board, m.thread_id, t.title, m.id, m.text, m.created_at
+		`+notificationsFrom+` AND n.read_at IS NULL AND n.created_at > $2
+		ORDER BY n.created_at DESC, n.id DESC
+		LIMIT $3`,
+		userId, since.UTC(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query digest replies: %w", err)
+	}
+	defer rows.Close()
+
+	var replies []domain.LatestPost
+	for rows.Next() {
+		var p domain.LatestPost
+		if err := rows.Scan(&p.Board, &p.ThreadId, &p.ThreadTitle, &p.Id, &p.Text, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan digest reply: %w", err)
+		}
+		p.Page = utils.CalculatePage(int(p.Id), s.cfg.Public.MessagesPerThreadPage)
+		replies = append(replies, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating digest replies: %w", err)
+	}
+	return replies, nil
+}
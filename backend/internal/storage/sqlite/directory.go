@@ -0,0 +1,121 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// directoryDeactivationCondition matches the user_blacklist rows written by
+// directory sync: automatic bans without an expiry.
+const directoryDeactivationCondition = `blacklisted_by IS NULL AND expires_at IS NULL`
+
+// =========================================================================
+// Public Methods
+// =========================================================================
+
+// GetDirectoryAccounts lists the accounts whose email is in one of domains,
+// telling which of them directory sync has deactivated.
+func (s *Storage) GetDirectoryAccounts(ctx context.Context, domains []string) ([]domain.DirectoryAccount, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getDirectoryAccounts(q, domains)
+}
+
+// DeactivateUsers bans the users for good on behalf of directory sync. A
+// temporary ban is replaced; a ban by an admin stays as it is.
+func (s *Storage) DeactivateUsers(ctx context.Context, userIds []domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.deactivateUsers(tx, userIds)
+	})
+}
+
+// ReactivateUsers lifts the directory sync deactivations of the users. Other
+// bans are left alone.
+func (s *Storage) ReactivateUsers(ctx context.Context, userIds []domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.reactivateUsers(tx, userIds)
+	})
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+func (s *Storage) getDirectoryAccounts(q Querier, domains []string) ([]domain.DirectoryAccount, error) {
+	args := make([]any, len(domains))
+	for i, d := range domains {
+		args[i] = d
+	}
+	rows, err := q.Query(`
+		SELECT u.id, u.email_hash, COALESCE(u.is_admin, false),
+			EXISTS (SELECT 1 FROM user_blacklist ub WHERE ub.user_id = u.id AND `+directoryDeactivationCondition+`)
+		FROM users u
+		WHERE u.email_domain IN (`+placeholders(1, len(domains))+`)
+		ORDER BY u.id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query directory accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []domain.DirectoryAccount{}
+	for rows.Next() {
+		var a domain.DirectoryAccount
+		if err := rows.Scan(&a.Id, &a.EmailHash, &a.Admin, &a.Deactivated); err != nil {
+			return nil, fmt.Errorf("failed to scan directory account: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating directory accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+func (s *Storage) deactivateUsers(q Querier, userIds []domain.UserId) error {
+	for _, id := range userIds {
+		_, err := q.Exec(`
+			INSERT INTO user_blacklist (user_id, reason, blacklisted_by, blacklisted_at, expires_at)
+			VALUES ($1, $2, NULL, `+sqlNow+`, NULL)
+			ON CONFLICT (user_id)
+			DO UPDATE SET
+				reason = EXCLUDED.reason,
+				blacklisted_at = EXCLUDED.blacklisted_at,
+				expires_at = NULL
+			WHERE user_blacklist.expires_at IS NOT NULL`,
+			id, domain.DirectoryDeactivationReason,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to deactivate user %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) reactivateUsers(q Querier, userIds []domain.UserId) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+	args := make([]any, len(userIds))
+	for i, id := range userIds {
+		args[i] = id
+	}
+	_, err := q.Exec(`
+		DELETE FROM user_blacklist
+		WHERE user_id IN (`+placeholders(1, len(userIds))+`) AND `+directoryDeactivationCondition,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reactivate users: %w", err)
+	}
+	return nil
+}
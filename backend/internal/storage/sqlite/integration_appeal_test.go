@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBanAppeals(t *testing.T) {
+	t.Run("create snapshots ban and lists pending", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		adminId := createTestUser(t, tx, "appeal_admin@test.com")
+		userId := createTestUser(t, tx, "appeal_user@test.com")
+		require.NoError(t, storage.blacklistUser(tx, userId, "Spam", adminId))
+
+		id, err := storage.createAppeal(tx, userId, "It was not spam")
+		require.NoError(t, err)
+
+		appeal, err := storage.getAppeal(tx, id)
+		require.NoError(t, err)
+		assert.Equal(t, userId, appeal.UserId)
+		assert.Equal(t, "It was not spam", appeal.Text)
+		assert.Equal(t, domain.AppealPending, appeal.Status)
+		assert.Equal(t, "Spam", appeal.Ban.Reason)
+		assert.Equal(t, adminId, appeal.Ban.BlacklistedBy)
+		assert.Nil(t, appeal.DecidedAt)
+
+		pending, err := storage.getAppeals(tx, domain.AppealPending, 100, 0)
+		require.NoError(t, err)
+		found := false
+		for _, a := range pending {
+			found = found || a.Id == id
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("decide appeal", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		adminId := createTestUser(t, tx, "appeal_admin@test.com")
+		userId := createTestUser(t, tx, "appeal_user@test.com")
+		require.NoError(t, storage.blacklistUser(tx, userId, "Spam", adminId))
+		id, err := storage.createAppeal(tx, userId, "Please")
+		require.NoError(t, err)
+
+		require.NoError(t, storage.decideAppeal(tx, id, domain.AppealDenied, "No", adminId))
+		latest, err := storage.getLatestAppeal(tx, userId)
+		require.NoError(t, err)
+		assert.Equal(t, id, latest.Id)
+		assert.Equal(t, domain.AppealDenied, latest.Status)
+		assert.Equal(t, "No", latest.Response)
+		require.NotNil(t, latest.DecidedBy)
+		assert.Equal(t, adminId, *latest.DecidedBy)
+		assert.NotNil(t, latest.DecidedAt)
+
+		err = storage.decideAppeal(tx, id, domain.AppealAccepted, "", adminId)
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusConflict, e.StatusCode)
+
+		requireNotFoundError(t, storage.decideAppeal(tx, -1, domain.AppealAccepted, "", adminId))
+	})
+
+	t.Run("user without ban cannot appeal", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		userId := createTestUser(t, tx, "appeal_user@test.com")
+		_, err := storage.createAppeal(tx, userId, "Please")
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusBadRequest, e.StatusCode)
+	})
+
+	t.Run("one appeal per ban", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		adminId := createTestUser(t, tx, "appeal_admin@test.com")
+		userId := createTestUser(t, tx, "appeal_user@test.com")
+		require.NoError(t, storage.blacklistUser(tx, userId, "Spam", adminId))
+		_, err := storage.createAppeal(tx, userId, "Please")
+		require.NoError(t, err)
+
+		_, err = storage.createAppeal(tx, userId, "Please again")
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusConflict, e.StatusCode)
+	})
+}
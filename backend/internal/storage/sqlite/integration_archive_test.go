@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/crypto"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recomputeContentHash hashes an archived message the way a verifier does.
+func recomputeContentHash(t *testing.T, threadId domain.ThreadId, m domain.ArchivedMessage) string {
+	t.Helper()
+	commitment, err := hex.DecodeString(m.AuthorCommitment)
+	require.NoError(t, err)
+	return hex.EncodeToString(crypto.MessageContentHash(crypto.MessageContent{
+		ThreadId:         int64(threadId),
+		MessageId:        int64(m.Id),
+		CreatedAt:        m.CreatedAt,
+		Text:             m.Text,
+		AttachmentHashes: m.AttachmentHashes,
+		AuthorCommitment: commitment,
+	}))
+}
+
+func TestGetThreadArchive(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	author := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@archive.com")}
+
+	// Created without the public entry point, like bulk-loaded messages
+	threadId, opId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Archive", Board: board,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+	})
+	files := getRandomAttachments(t)
+	files[0].File.Sha256 = strings.Repeat("a1", 32)
+	files[1].File.Sha256 = strings.Repeat("b2", 32)
+	replyId, err := storage.CreateMessage(ctx, domain.MessageCreationData{
+		Board: board, ThreadId: threadId, Author: author, Text: "reply",
+	}, files)
+	require.NoError(t, err)
+
+	t.Run("stored hash matches the exported fields", func(t *testing.T) {
+		archive, err := storage.GetThreadArchive(ctx, board, threadId)
+		require.NoError(t, err)
+		assert.Equal(t, "Archive", archive.Title)
+		require.Len(t, archive.Messages, 2)
+
+		op, reply := archive.Messages[0], archive.Messages[1]
+		assert.Equal(t, opId, op.Id)
+		assert.Empty(t, op.ContentHash, "messages stored without a hash")
+		assert.Empty(t, op.AttachmentHashes)
+
+		assert.Equal(t, replyId, reply.Id)
+		assert.Equal(t, author.Id, reply.Author)
+		assert.Equal(t, []string{files[0].File.Sha256, files[1].File.Sha256}, reply.AttachmentHashes)
+		assert.Len(t, reply.ContentHash, 64)
+		assert.Equal(t, recomputeContentHash(t, threadId, reply), reply.ContentHash)
+		assert.NotEqual(t, op.AuthorCommitment, reply.AuthorCommitment, "posts of one author are not linkable")
+	})
+
+	t.Run("hash stays valid after the encryption key is rotated", func(t *testing.T) {
+		before, err := storage.GetThreadArchive(ctx, board, threadId)
+		require.NoError(t, err)
+
+		oldKey := storage.cfg.Private.EncryptionKey
+		storage.cfg.Private.EncryptionKey = "cm90YXRlZC1rZXktcm90YXRlZC1rZXktcm90YXRlZDE="
+		defer func() { storage.cfg.Private.EncryptionKey = oldKey }()
+
+		archive, err := storage.GetThreadArchive(ctx, board, threadId)
+		require.NoError(t, err)
+		reply := archive.Messages[1]
+		assert.Equal(t, before.Messages[1].AuthorCommitment, reply.AuthorCommitment)
+		assert.Equal(t, recomputeContentHash(t, threadId, reply), reply.ContentHash)
+	})
+
+	t.Run("edit behind the application's back is detected", func(t *testing.T) {
+		_, err := storage.db.Exec(`UPDATE messages SET text = 'forged' WHERE board = $1 AND thread_id = $2 AND id = $3`, board, threadId, replyId)
+		require.NoError(t, err)
+
+		archive, err := storage.GetThreadArchive(ctx, board, threadId)
+		require.NoError(t, err)
+		reply := archive.Messages[1]
+		assert.Equal(t, "forged", reply.Text)
+		assert.NotEqual(t, recomputeContentHash(t, threadId, reply), reply.ContentHash)
+	})
+
+	t.Run("missing thread", func(t *testing.T) {
+		_, err := storage.GetThreadArchive(ctx, board, threadId+1000)
+		requireNotFoundError(t, err)
+	})
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoardAppearance(t *testing.T) {
+	ctx := context.Background()
+	requireNotFound := func(t *testing.T, err error) {
+		t.Helper()
+		var statusErr *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	}
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+
+	t.Run("new board has no appearance", func(t *testing.T) {
+		appearance, err := storage.GetBoardAppearance(ctx, board)
+		require.NoError(t, err)
+		assert.Empty(t, appearance.CustomCSS)
+		assert.NotNil(t, appearance.Banners)
+		assert.Empty(t, appearance.Banners)
+	})
+
+	t.Run("custom css", func(t *testing.T) {
+		require.NoError(t, storage.SetBoardCustomCSS(ctx, board, ".post { color: red; }"))
+
+		appearance, err := storage.GetBoardAppearance(ctx, board)
+		require.NoError(t, err)
+		assert.Equal(t, ".post { color: red; }", appearance.CustomCSS)
+	})
+
+	t.Run("banners", func(t *testing.T) {
+		first, err := storage.AddBoardBanner(ctx, board, string(board)+"/banners/1.png")
+		require.NoError(t, err)
+		second, err := storage.AddBoardBanner(ctx, board, string(board)+"/banners/2.jpg")
+		require.NoError(t, err)
+
+		appearance, err := storage.GetBoardAppearance(ctx, board)
+		require.NoError(t, err)
+		require.Len(t, appearance.Banners, 2)
+		assert.Equal(t, first, appearance.Banners[0].Id)
+		assert.Equal(t, board, appearance.Banners[0].Board)
+		assert.Equal(t, string(board)+"/banners/1.png", appearance.Banners[0].FilePath)
+		assert.False(t, appearance.Banners[0].CreatedAt.IsZero())
+
+		paths, err := storage.GetAllFilePaths(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, paths, string(board)+"/banners/1.png", "banners are not orphans for the media gc")
+
+		filePath, err := storage.DeleteBoardBanner(ctx, board, second)
+		require.NoError(t, err)
+		assert.Equal(t, string(board)+"/banners/2.jpg", filePath)
+
+		appearance, err = storage.GetBoardAppearance(ctx, board)
+		require.NoError(t, err)
+		assert.Len(t, appearance.Banners, 1)
+	})
+
+	t.Run("banner of another board", func(t *testing.T) {
+		appearance, err := storage.GetBoardAppearance(ctx, board)
+		require.NoError(t, err)
+		require.NotEmpty(t, appearance.Banners)
+
+		_, err = storage.DeleteBoardBanner(ctx, "other", appearance.Banners[0].Id)
+		requireNotFound(t, err)
+	})
+
+	t.Run("unknown board", func(t *testing.T) {
+		_, err := storage.GetBoardAppearance(ctx, "nonexistent")
+		requireNotFound(t, err)
+		requireNotFound(t, storage.SetBoardCustomCSS(ctx, "nonexistent", "body {}"))
+		_, err = storage.AddBoardBanner(ctx, "nonexistent", "nonexistent/banners/1.png")
+		requireNotFound(t, err)
+	})
+}
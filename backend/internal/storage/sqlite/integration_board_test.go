@@ -0,0 +1,983 @@
+package sqlite
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBoardOperations verifies board CRUD operations and related queries.
+// Uses transactional testing for complete isolation.
+func TestBoardOperations(t *testing.T) {
+	// =========================================================================
+	// Test: CreateBoard
+	// Verifies board creation with various configurations and error handling.
+	// =========================================================================
+	t.Run("CreateBoard", func(t *testing.T) {
+		t.Run("success with allowed emails", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardName := "Test Create Board"
+			bShortName := domain.BoardShortName(generateString(t))
+			allowedEmails := &domain.Emails{"test@example.com"}
+
+			err := storage.createBoard(tx, domain.BoardCreationData{
+				Name:          boardName,
+				ShortName:     bShortName,
+				AllowedEmails: allowedEmails,
+			})
+			require.NoError(t, err)
+		})
+
+		t.Run("success without allowed emails", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardName := "Test Create Board"
+			bShortName := domain.BoardShortName(generateString(t))
+
+			err := storage.createBoard(tx, domain.BoardCreationData{
+				Name:          boardName,
+				ShortName:     bShortName,
+				AllowedEmails: nil,
+			})
+			require.NoError(t, err)
+		})
+
+		t.Run("fails on duplicate short name", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardShortName := domain.BoardShortName(generateString(t))
+			boardData := domain.BoardCreationData{
+				Name:          "Test Board",
+				ShortName:     boardShortName,
+				AllowedEmails: nil,
+			}
+
+			err := storage.createBoard(tx, boardData)
+			require.NoError(t, err)
+
+			boardData.Name = "Another Name"
+			err = storage.createBoard(tx, boardData)
+			require.Error(t, err, "Creating board with duplicate short name should fail")
+			assert.Contains(t, err.Error(), "already exists")
+		})
+
+		t.Run("fails on empty allowed emails", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			bShortName := domain.BoardShortName(generateString(t))
+			err := storage.createBoard(tx, domain.BoardCreationData{
+				Name:          "Test Board",
+				ShortName:     bShortName,
+				AllowedEmails: &domain.Emails{},
+			})
+			require.ErrorIs(t, err, emptyAllowedEmailsError)
+		})
+	})
+
+	// =========================================================================
+	// Test: GetBoard
+	// Verifies board retrieval accuracy.
+	// =========================================================================
+	t.Run("GetBoard", func(t *testing.T) {
+		t.Run("retrieves board with correct metadata", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardShortName := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardShortName)
+
+			boardMetadata, err := storage.getBoard(tx, boardShortName, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "Test Board "+string(boardShortName), boardMetadata.BoardMetadata.Name)
+			assert.Equal(t, boardShortName, boardMetadata.BoardMetadata.ShortName)
+		})
+
+		t.Run("retrieves description set at creation", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardShortName := domain.BoardShortName(generateString(t))
+			require.NoError(t, storage.createBoard(tx, domain.BoardCreationData{
+				Name: "Described", ShortName: boardShortName, Description: "All about tests",
+			}))
+
+			board, err := storage.getBoard(tx, boardShortName, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "All about tests", board.Description)
+		})
+
+		t.Run("fails for non-existent board", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			_, err := storage.getBoard(tx, "nonexistentboard", 1)
+			requireNotFoundError(t, err)
+		})
+	})
+
+	// =========================================================================
+	// Test: DeleteBoard
+	// Verifies board deletion and cascading effects.
+	// =========================================================================
+	t.Run("DeleteBoard", func(t *testing.T) {
+		t.Run("success with cascade cleanup", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardShortName := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardShortName)
+
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Test Thread",
+				Board: boardShortName,
+				OpMessage: domain.MessageCreationData{
+					Board:  boardShortName,
+					Author: domain.User{Id: userID},
+					Text:   "OP",
+				},
+			})
+
+			messageID := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "Test Message",
+				ThreadId: threadID,
+			})
+
+			// Add attachments to message
+			attachments := getRandomAttachments(t)
+			err := storage.addAttachments(tx, boardShortName, threadID, messageID, attachments)
+			require.NoError(t, err)
+
+			// Get file IDs before deletion
+			attachmentsBefore, err := storage.getMessageAttachments(tx, boardShortName, threadID, messageID)
+			require.NoError(t, err)
+			var fileIDs []int64
+			for _, att := range attachmentsBefore {
+				fileIDs = append(fileIDs, att.FileId)
+			}
+
+			err = storage.deleteBoard(tx, boardShortName)
+			require.NoError(t, err)
+
+			_, err = storage.getBoard(tx, boardShortName, 1)
+			requireNotFoundError(t, err)
+
+			_, err = storage.getThread(tx, boardShortName, threadID, 1)
+			requireNotFoundError(t, err)
+
+			_, err = storage.getMessage(tx, boardShortName, threadID, messageID)
+			requireNotFoundError(t, err)
+
+			// Verify files are deleted from files table
+			for _, fileID := range fileIDs {
+				var count int
+				err = tx.QueryRow("SELECT COUNT(*) FROM files WHERE id = $1", fileID).Scan(&count)
+				require.NoError(t, err)
+				assert.Equal(t, 0, count, "File %d should be deleted from files table", fileID)
+			}
+		})
+
+		t.Run("fails for non-existent board", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			err := storage.deleteBoard(tx, "nonexistentboard_del_test")
+			requireNotFoundError(t, err)
+		})
+	})
+
+	// =========================================================================
+	// Test: GetBoards
+	// Verifies retrieval of all board metadata.
+	// =========================================================================
+	t.Run("GetBoards", func(t *testing.T) {
+		t.Run("returns boards in creation order", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			createdBoards := []domain.BoardCreationData{
+				{Name: "Board Alpha", ShortName: "a", AllowedEmails: &domain.Emails{"one@example.com"}},
+				{Name: "Board Beta", ShortName: "b", AllowedEmails: nil},
+				{Name: "Board Gamma", ShortName: "c", AllowedEmails: &domain.Emails{"three@example.com", "another@example.com"}},
+			}
+
+			var expectedOrder []domain.BoardShortName
+			for _, board := range createdBoards {
+				err := storage.createBoard(tx, board)
+				require.NoError(t, err)
+				expectedOrder = append(expectedOrder, board.ShortName)
+			}
+
+			allBoards, err := storage.getBoards(tx)
+			require.NoError(t, err)
+			require.Len(t, allBoards, 3, "Should retrieve all 3 created boards")
+
+			for i, expectedSN := range expectedOrder {
+				assert.Equal(t, expectedSN, allBoards[i].ShortName, "Board order mismatch at index %d", i)
+			}
+		})
+	})
+
+	// =========================================================================
+	// Test: ListBoards
+	// Verifies sorting, paging and stats of the paginated board listing.
+	// =========================================================================
+	t.Run("ListBoards", func(t *testing.T) {
+		t.Run("sorts and pages", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			for _, board := range []domain.BoardCreationData{
+				{Name: "Zulu", ShortName: "a"},
+				{Name: "Alpha", ShortName: "b"},
+				{Name: "Mike", ShortName: "c"},
+			} {
+				require.NoError(t, storage.createBoard(tx, board))
+			}
+			now := time.Now().UTC()
+			for shortName, activity := range map[string]time.Time{"a": now.Add(-time.Hour), "b": now, "c": now.Add(-2 * time.Hour)} {
+				_, err := tx.Exec("UPDATE boards SET last_activity_at = $1 WHERE short_name = $2", activity, shortName)
+				require.NoError(t, err)
+			}
+
+			shortNames := func(boards []domain.BoardMetadata) []domain.BoardShortName {
+				var names []domain.BoardShortName
+				for _, b := range boards {
+					names = append(names, b.ShortName)
+				}
+				return names
+			}
+
+			boards, total, err := storage.listBoards(tx, domain.BoardSortName, domain.BoardStatsNone, 10, 0)
+			require.NoError(t, err)
+			assert.Equal(t, 3, total)
+			assert.Equal(t, []domain.BoardShortName{"b", "c", "a"}, shortNames(boards))
+
+			boards, _, err = storage.listBoards(tx, domain.BoardSortActivity, domain.BoardStatsNone, 10, 0)
+			require.NoError(t, err)
+			assert.Equal(t, []domain.BoardShortName{"b", "a", "c"}, shortNames(boards))
+
+			boards, total, err = storage.listBoards(tx, domain.BoardSortPosition, domain.BoardStatsNone, 2, 2)
+			require.NoError(t, err)
+			assert.Equal(t, 3, total, "total counts all pages")
+			assert.Equal(t, []domain.BoardShortName{"c"}, shortNames(boards))
+			assert.Nil(t, boards[0].Stats, "stats are only loaded on request")
+
+			_, _, err = storage.listBoards(tx, "popularity", domain.BoardStatsNone, 10, 0)
+			assert.Error(t, err)
+		})
+
+		t.Run("includes stats", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			busy := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, busy)
+			empty := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, empty)
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Thread", Board: busy,
+				OpMessage: domain.MessageCreationData{Board: busy, Author: domain.User{Id: userID}, Text: "OP"},
+			})
+			createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Other", Board: busy,
+				OpMessage: domain.MessageCreationData{Board: busy, Author: domain.User{Id: userID}, Text: "OP"},
+			})
+			createTestMessage(t, tx, domain.MessageCreationData{
+				Board: busy, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+			})
+
+			boards, _, err := storage.listBoards(tx, domain.BoardSortPosition, domain.BoardStatsCounts, 100, 0)
+			require.NoError(t, err)
+			stats := make(map[domain.BoardShortName]domain.BoardStats)
+			for _, b := range boards {
+				require.NotNil(t, b.Stats)
+				stats[b.ShortName] = *b.Stats
+			}
+			assert.Equal(t, domain.BoardStats{ThreadCount: 2, MessageCount: 3}, stats[busy])
+			assert.Equal(t, domain.BoardStats{}, stats[empty])
+		})
+
+		t.Run("includes the latest thread", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			busy := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, busy)
+			empty := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, empty)
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			bumped, _ := createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Bumped", Board: busy,
+				OpMessage: domain.MessageCreationData{Board: busy, Author: domain.User{Id: userID}, Text: "OP"},
+			})
+			createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Older", Board: busy,
+				OpMessage: domain.MessageCreationData{Board: busy, Author: domain.User{Id: userID}, Text: "OP"},
+			})
+			lastBumped := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+			_, err := tx.Exec(`UPDATE threads SET last_bumped_at = $1 WHERE board = $2 AND id = $3`, lastBumped, busy, bumped)
+			require.NoError(t, err)
+
+			boards, _, err := storage.listBoards(tx, domain.BoardSortPosition, domain.BoardStatsLatest, 100, 0)
+			require.NoError(t, err)
+			stats := make(map[domain.BoardShortName]domain.BoardStats)
+			for _, b := range boards {
+				require.NotNil(t, b.Stats)
+				stats[b.ShortName] = *b.Stats
+			}
+			require.NotNil(t, stats[busy].LatestThread)
+			assert.Equal(t, bumped, stats[busy].LatestThread.Id)
+			assert.Equal(t, "Bumped", stats[busy].LatestThread.Title)
+			assert.WithinDuration(t, lastBumped, stats[busy].LatestThread.LastBumped, time.Second)
+			assert.Equal(t, 2, stats[busy].ThreadCount)
+			assert.Nil(t, stats[empty].LatestThread)
+		})
+	})
+
+	// =========================================================================
+	// Test: ThreadCount
+	// Verifies counting threads on a board.
+	// =========================================================================
+	t.Run("ThreadCount", func(t *testing.T) {
+		t.Run("counts correctly", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardA := domain.BoardShortName(generateString(t))
+			boardB := domain.BoardShortName(generateString(t))
+
+			createTestBoard(t, tx, boardA)
+			createTestBoard(t, tx, boardB)
+
+			count, err := storage.threadCount(tx, boardA)
+			require.NoError(t, err)
+			assert.Equal(t, 0, count, "Empty board should have 0 threads")
+
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+			for i := 1; i <= 3; i++ {
+				createTestThread(t, tx, domain.ThreadCreationData{
+					Title: domain.ThreadTitle(fmt.Sprintf("Thread %d", i)),
+					Board: boardA,
+					OpMessage: domain.MessageCreationData{
+						Board:  boardA,
+						Author: domain.User{Id: userID},
+						Text:   domain.MsgText(fmt.Sprintf("OP %d", i)),
+					},
+				})
+			}
+
+			createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Thread B",
+				Board: boardB,
+				OpMessage: domain.MessageCreationData{
+					Board:  boardB,
+					Author: domain.User{Id: userID},
+					Text:   "OP B",
+				},
+			})
+
+			countA, err := storage.threadCount(tx, boardA)
+			require.NoError(t, err)
+			assert.Equal(t, 3, countA, "Board A should have 3 threads")
+
+			countB, err := storage.threadCount(tx, boardB)
+			require.NoError(t, err)
+			assert.Equal(t, 1, countB, "Board B should have 1 thread")
+		})
+	})
+
+	// =========================================================================
+	// Test: BoardSettings
+	// Verifies thread creation requirements are stored and per-user threads counted.
+	// =========================================================================
+	t.Run("BoardSettings", func(t *testing.T) {
+		t.Run("round trip", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardShortName := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardShortName)
+
+			settings, err := storage.getBoardSettings(tx, boardShortName)
+			require.NoError(t, err)
+			assert.Equal(t, domain.BoardSettings{}, settings, "new boards have no requirements")
+
+			want := domain.BoardSettings{Description: "Board about things", Noindex: true, PublicModLog: true, SelfDeleteReplied: true, BumpLimitNotice: true, MinOpTextLength: 20, RequireOpAttachment: true, MaxThreadsPerUserPerDay: 3, VideoProfile: "720p", TextFilter: domain.TextFilterReject, Language: domain.LanguageJapanese, PostingEmailDomains: []string{"corp.com", "corp.org"}, PostingMinAccountAgeDays: 7, PreModeration: true, PremodTrustApprovals: 5, PremodTrustAccountAgeDays: 30}
+			maxAttachments := 2
+			want.UploadRules = domain.UploadRules{AllowDocuments: true, MaxAttachments: &maxAttachments, AllowedMimeTypes: []string{"image/jpeg", "image/png"}}
+			require.NoError(t, storage.updateBoardSettings(tx, boardShortName, want))
+
+			settings, err = storage.getBoardSettings(tx, boardShortName)
+			require.NoError(t, err)
+			assert.Equal(t, want, settings)
+
+			board, err := storage.getBoard(tx, boardShortName, 1)
+			require.NoError(t, err)
+			assert.Equal(t, want, board.BoardSettings)
+		})
+
+		t.Run("fails for non-existent board", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			_, err := storage.getBoardSettings(tx, "nonexistentboard")
+			requireNotFoundError(t, err)
+		})
+
+		t.Run("counts user threads", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardShortName := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardShortName)
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+			otherID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Mine", Board: boardShortName,
+				OpMessage: domain.MessageCreationData{Board: boardShortName, Author: domain.User{Id: userID}, Text: "OP"},
+			})
+			createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Other", Board: boardShortName,
+				OpMessage: domain.MessageCreationData{Board: boardShortName, Author: domain.User{Id: otherID}, Text: "OP"},
+			})
+			createTestMessage(t, tx, domain.MessageCreationData{
+				Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+			})
+
+			count, err := storage.countUserThreadsSince(tx, boardShortName, userID, time.Now().Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 1, count, "replies and other users' threads are not counted")
+
+			count, err = storage.countUserThreadsSince(tx, boardShortName, userID, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+		})
+
+		t.Run("threads inherit noindex", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			publicBoard := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, publicBoard)
+			restrictedBoard := domain.BoardShortName(generateString(t))
+			require.NoError(t, storage.createBoard(tx, domain.BoardCreationData{
+				Name: "Restricted", ShortName: restrictedBoard, AllowedEmails: &domain.Emails{"example.com"},
+			}))
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			threadIn := func(board domain.BoardShortName) domain.ThreadId {
+				id, _ := createTestThread(t, tx, domain.ThreadCreationData{
+					Title: "Thread", Board: board,
+					OpMessage: domain.MessageCreationData{Board: board, Author: domain.User{Id: userID}, Text: "OP"},
+				})
+				return id
+			}
+			publicThread := threadIn(publicBoard)
+			restrictedThread := threadIn(restrictedBoard)
+
+			thread, err := storage.getThread(tx, publicBoard, publicThread, 1)
+			require.NoError(t, err)
+			assert.False(t, thread.Noindex)
+
+			thread, err = storage.getThread(tx, restrictedBoard, restrictedThread, 1)
+			require.NoError(t, err)
+			assert.True(t, thread.Noindex, "email restricted boards are never indexed")
+
+			require.NoError(t, storage.updateBoardSettings(tx, publicBoard, domain.BoardSettings{Noindex: true}))
+			thread, err = storage.getThread(tx, publicBoard, publicThread, 1)
+			require.NoError(t, err)
+			assert.True(t, thread.Noindex)
+		})
+	})
+
+	// =========================================================================
+	// Test: ThreadsToDelete
+	// Verifies finding the IDs of the oldest non-pinned threads to delete.
+	// =========================================================================
+	t.Run("ThreadsToDelete", func(t *testing.T) {
+		t.Run("empty board returns nothing", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardA := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardA)
+
+			ids, err := storage.threadsToDelete(tx, boardA, 10)
+			require.NoError(t, err)
+			assert.Empty(t, ids)
+		})
+
+		t.Run("under limit returns nothing", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardA := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardA)
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Single Thread",
+				Board: boardA,
+				OpMessage: domain.MessageCreationData{
+					Board:  boardA,
+					Author: domain.User{Id: userID},
+					Text:   "OP Single",
+				},
+			})
+
+			ids, err := storage.threadsToDelete(tx, boardA, 3)
+			require.NoError(t, err)
+			assert.Empty(t, ids)
+		})
+
+		t.Run("returns oldest threads over limit", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardA := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardA)
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			var threadIDs []domain.ThreadId
+			for i := 1; i <= 3; i++ {
+				tID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+					Title: domain.ThreadTitle(fmt.Sprintf("Thread %d", i)),
+					Board: boardA,
+					OpMessage: domain.MessageCreationData{
+						Board:  boardA,
+						Author: domain.User{Id: userID},
+						Text:   domain.MsgText(fmt.Sprintf("OP %d", i)),
+					},
+				})
+				threadIDs = append(threadIDs, tID)
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			ids, err := storage.threadsToDelete(tx, boardA, 1)
+			require.NoError(t, err)
+			require.Len(t, ids, 2)
+			assert.Equal(t, threadIDs[0], ids[0], "Oldest thread should be first")
+			assert.Equal(t, threadIDs[1], ids[1], "Second oldest should be second")
+		})
+
+		t.Run("ignores pinned threads", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardA := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardA)
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			createTestThread(t, tx, domain.ThreadCreationData{
+				Title:    "Pinned Thread",
+				Board:    boardA,
+				IsPinned: true,
+				OpMessage: domain.MessageCreationData{
+					Board:  boardA,
+					Author: domain.User{Id: userID},
+					Text:   "OP Pinned",
+				},
+			})
+			time.Sleep(20 * time.Millisecond)
+
+			tID2, _ := createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Normal Thread",
+				Board: boardA,
+				OpMessage: domain.MessageCreationData{
+					Board:  boardA,
+					Author: domain.User{Id: userID},
+					Text:   "OP Normal",
+				},
+			})
+
+			// 2 threads total, limit 1 — only non-pinned should be returned
+			ids, err := storage.threadsToDelete(tx, boardA, 1)
+			require.NoError(t, err)
+			require.Len(t, ids, 1)
+			assert.Equal(t, tID2, ids[0])
+
+			// Pin the normal thread too — nothing deletable
+			_, err = tx.Exec("UPDATE threads SET is_pinned = TRUE WHERE id = $1 AND board = $2", tID2, boardA)
+			require.NoError(t, err)
+
+			ids, err = storage.threadsToDelete(tx, boardA, 1)
+			require.NoError(t, err)
+			assert.Empty(t, ids)
+		})
+
+		t.Run("board isolation", func(t *testing.T) {
+			tx, cleanup := beginTx(t)
+			defer cleanup()
+
+			boardA := domain.BoardShortName(generateString(t))
+			boardB := domain.BoardShortName(generateString(t))
+			createTestBoard(t, tx, boardA)
+			createTestBoard(t, tx, boardB)
+
+			userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			tA, _ := createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Thread A",
+				Board: boardA,
+				OpMessage: domain.MessageCreationData{
+					Board:  boardA,
+					Author: domain.User{Id: userID},
+					Text:   "OP A",
+				},
+			})
+
+			createTestThread(t, tx, domain.ThreadCreationData{
+				Title: "Thread B",
+				Board: boardB,
+				OpMessage: domain.MessageCreationData{
+					Board:  boardB,
+					Author: domain.User{Id: userID},
+					Text:   "OP B",
+				},
+			})
+
+			// Board A has 1 thread, limit 0 — should return only board A's thread
+			idsA, err := storage.threadsToDelete(tx, boardA, 0)
+			require.NoError(t, err)
+			require.Len(t, idsA, 1)
+			assert.Equal(t, tA, idsA[0])
+
+			// Board B should be unaffected
+			idsB, err := storage.threadsToDelete(tx, boardB, 1)
+			require.NoError(t, err)
+			assert.Empty(t, idsB)
+		})
+	})
+}
+
+// TestBoardPageWorkflow verifies board page pagination and ordering.
+func TestBoardDeletionSchedule(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	listed := func() bool {
+		boards, err := storage.getBoards(tx)
+		require.NoError(t, err)
+		for _, b := range boards {
+			if b.ShortName == boardShortName {
+				return true
+			}
+		}
+		return false
+	}
+	require.True(t, listed())
+
+	deleteAfter := time.Now().UTC().Add(time.Hour).Round(time.Microsecond)
+	require.NoError(t, storage.scheduleBoardDeletion(tx, boardShortName, deleteAfter))
+	assert.False(t, listed(), "boards pending deletion are not listed")
+
+	pending, err := storage.getPendingBoardDeletions(tx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, boardShortName, pending[0].Board)
+	assert.True(t, deleteAfter.Equal(pending[0].DeleteAfter))
+
+	names, err := getBoardsPendingDeletion(tx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{boardShortName}, names)
+
+	err = storage.scheduleBoardDeletion(tx, boardShortName, deleteAfter)
+	var e *internal_errors.ErrorWithStatusCode
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, http.StatusConflict, e.StatusCode)
+	requireNotFoundError(t, storage.scheduleBoardDeletion(tx, domain.BoardShortName(generateString(t)), deleteAfter))
+
+	require.NoError(t, storage.cancelBoardDeletion(tx, boardShortName))
+	assert.True(t, listed(), "restored boards are listed again")
+	requireNotFoundError(t, storage.cancelBoardDeletion(tx, boardShortName))
+}
+
+func TestRenameBoard(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	oldName := domain.BoardShortName(generateString(t))
+	newName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, oldName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, opID := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Renamed", Board: oldName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	msgID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: oldName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+		ReplyTo: &domain.Replies{{To: opID, ToThreadId: threadID}},
+	})
+	attachments := getRandomAttachments(t)
+	attachments[0].File.FilePath = fmt.Sprintf("%s/%d/%s", oldName, threadID, attachments[0].File.Filename)
+	require.NoError(t, storage.addAttachments(tx, oldName, threadID, msgID, attachments))
+
+	redirectUntil := time.Now().UTC().Add(time.Hour)
+	require.NoError(t, storage.renameBoard(tx, oldName, newName, &redirectUntil))
+
+	_, err := storage.getBoard(tx, oldName, 1)
+	requireNotFoundError(t, err)
+	_, err = storage.getBoard(tx, newName, 1)
+	require.NoError(t, err)
+
+	thread, err := storage.getThread(tx, newName, threadID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", thread.Title)
+	assert.Len(t, thread.Messages, 2)
+
+	msg, err := storage.getMessage(tx, newName, threadID, msgID)
+	require.NoError(t, err)
+	require.Len(t, msg.Attachments, 2)
+	var paths []string
+	for _, a := range msg.Attachments {
+		paths = append(paths, a.File.FilePath)
+	}
+	assert.Contains(t, paths, fmt.Sprintf("%s/%d/%s", newName, threadID, attachments[0].File.Filename))
+	op, err := storage.getMessage(tx, newName, threadID, opID)
+	require.NoError(t, err)
+	assert.Len(t, op.Replies, 1)
+
+	// Thread ids keep counting from where the old board stopped.
+	nextThreadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "After rename", Board: newName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	assert.Greater(t, nextThreadID, threadID)
+
+	redirects, err := getBoardRedirects(tx)
+	require.NoError(t, err)
+	assert.Equal(t, newName, redirects[oldName])
+
+	requireNotFoundError(t, storage.renameBoard(tx, oldName, generateString(t), nil))
+
+	takenName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, takenName)
+	err = storage.renameBoard(tx, newName, takenName, nil)
+	var e *internal_errors.ErrorWithStatusCode
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, http.StatusConflict, e.StatusCode)
+
+	// A new board under the old name ends the redirect.
+	createTestBoard(t, tx, oldName)
+	redirects, err = getBoardRedirects(tx)
+	require.NoError(t, err)
+	assert.NotContains(t, redirects, oldName)
+}
+
+func TestGetDeletionReport(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "With files", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	msgID := createTestMessage(t, tx, domain.MessageCreationData{Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply"})
+	require.NoError(t, storage.addAttachments(tx, boardShortName, threadID, msgID, getRandomAttachments(t)))
+	createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Text only", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+
+	report, err := storage.getDeletionReport(tx, boardShortName, nil)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeletionReport{Threads: 2, Messages: 3, Attachments: 2, AttachmentBytes: 3072}, report)
+
+	report, err = storage.getDeletionReport(tx, boardShortName, &threadID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeletionReport{Threads: 1, Messages: 2, Attachments: 2, AttachmentBytes: 3072}, report)
+
+	missing := threadID + 1000
+	_, err = storage.getDeletionReport(tx, boardShortName, &missing)
+	requireNotFoundError(t, err)
+	_, err = storage.getDeletionReport(tx, domain.BoardShortName(generateString(t)), nil)
+	requireNotFoundError(t, err)
+}
+
+func TestBoardPageWorkflow(t *testing.T) {
+	t.Run("pagination and ordering", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		// Use explicit timestamps to ensure deterministic ordering within transaction
+		baseTime := time.Now().UTC()
+
+		// Create test threads with explicit timestamps
+		threadTitles := []string{"thread1", "thread2", "thread3", "thread4", "thread5"}
+		threadIds := make([]domain.ThreadId, len(threadTitles))
+
+		for i, title := range threadTitles {
+			threadData := domain.ThreadCreationData{
+				Title: domain.ThreadTitle(title),
+				Board: boardShortName,
+				OpMessage: domain.MessageCreationData{
+					Board:  boardShortName,
+					Author: domain.User{Id: userID},
+					Text:   domain.MsgText(fmt.Sprintf("op%d", i+1)),
+				},
+			}
+			tid, _ := createTestThread(t, tx, threadData)
+			threadIds[i] = tid
+		}
+
+		// Add messages to create different bump orders
+		messages := []struct {
+			threadIdx int
+			text      string
+			hasAttach bool
+		}{
+			{0, "msg1_t1", false},
+			{1, "msg2_t2", false},
+			{2, "msg3_t3", false},
+			{0, "msg4_t1", true}, // thread1 gets bumped again
+			{3, "msg5_t4", false},
+		}
+
+		for i, msg := range messages {
+			// Add distinct timestamps (1 second apart) to ensure proper bump ordering
+			timestamp := baseTime.Add(time.Duration(i+10) * time.Second)
+			msgData := domain.MessageCreationData{
+				Board:     boardShortName,
+				Author:    domain.User{Id: userID},
+				Text:      domain.MsgText(msg.text),
+				ThreadId:  threadIds[msg.threadIdx],
+				CreatedAt: &timestamp,
+			}
+			if msg.hasAttach {
+			}
+			createTestMessage(t, tx, msgData)
+		}
+
+		// Page 1: should show 3 threads in bump order
+		board, err := storage.getBoard(tx, boardShortName, 1)
+		require.NoError(t, err)
+		require.Len(t, board.Threads, storage.cfg.Public.ThreadsPerPage, "Page 1 should show %d threads", storage.cfg.Public.ThreadsPerPage)
+
+		expectedOrder := []string{"thread4", "thread1", "thread3"}
+		requireThreadOrder(t, board.Threads, expectedOrder)
+
+		// Verify message content and order within threads
+		requireMessageOrder(t, board.Threads[0].Messages, []string{"op4", "msg5_t4"})
+		requireMessageOrder(t, board.Threads[1].Messages, []string{"op1", "msg1_t1", "msg4_t1"})
+		requireMessageOrder(t, board.Threads[2].Messages, []string{"op3", "msg3_t3"})
+
+		// Page 2: should show remaining 2 threads
+		board, err = storage.getBoard(tx, boardShortName, 2)
+		require.NoError(t, err)
+		require.Len(t, board.Threads, 2, "Page 2 should show 2 threads")
+
+		expectedOrder = []string{"thread2", "thread5"}
+		requireThreadOrder(t, board.Threads, expectedOrder)
+		requireMessageOrder(t, board.Threads[0].Messages, []string{"op2", "msg2_t2"})
+		requireMessageOrder(t, board.Threads[1].Messages, []string{"op5"})
+	})
+
+	t.Run("structural invariants", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		// Create multiple threads
+		threadCount := storage.cfg.Public.ThreadsPerPage*2 + 1
+		createdThreadIDs := make([]domain.ThreadId, threadCount)
+
+		for i := range createdThreadIDs {
+			tid, _ := createTestThread(t, tx, domain.ThreadCreationData{
+				Title: domain.ThreadTitle(fmt.Sprintf("Stress Thread %d", i+1)),
+				Board: boardShortName,
+				OpMessage: domain.MessageCreationData{
+					Board:  boardShortName,
+					Author: domain.User{Id: userID},
+					Text:   domain.MsgText(fmt.Sprintf("OP for thread %d", i+1)),
+				},
+			})
+			createdThreadIDs[i] = tid
+		}
+
+		// Add messages to various threads
+		r := rand.New(rand.NewSource(42))
+		messageCount := threadCount * 5
+
+		for i := 0; i < messageCount; i++ {
+			targetThreadID := createdThreadIDs[r.Intn(len(createdThreadIDs))]
+			createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     domain.MsgText(fmt.Sprintf("Reply %d", i)),
+				ThreadId: targetThreadID,
+			})
+		}
+
+		// Calculate expected pages
+		pages := (threadCount + storage.cfg.Public.ThreadsPerPage - 1) / storage.cfg.Public.ThreadsPerPage
+
+		for page := 1; page <= pages; page++ {
+			board, err := storage.getBoard(tx, boardShortName, page)
+			require.NoError(t, err)
+			require.NotEmpty(t, board.Threads, "Board threads shouldn't be empty")
+
+			// Verify thread count per page
+			assert.LessOrEqual(t, len(board.Threads), storage.cfg.Public.ThreadsPerPage,
+				"Page %d thread count (%d) exceeds limit (%d)", page, len(board.Threads), storage.cfg.Public.ThreadsPerPage)
+
+			// Verify thread ordering
+			var lastBumped time.Time
+			for i, thread := range board.Threads {
+				if i > 0 {
+					assert.False(t, thread.LastBumped.After(lastBumped),
+						"Thread order incorrect at index %d on page %d", i, page)
+				}
+				lastBumped = thread.LastBumped
+
+				// Verify message count per thread
+				assert.LessOrEqual(t, len(thread.Messages), storage.cfg.Public.NLastMsg+1,
+					"Message count (%d) exceeds limit (%d) in thread on page %d",
+					len(thread.Messages), storage.cfg.Public.NLastMsg+1, page)
+
+				// Verify OP is first
+				if len(thread.Messages) > 0 {
+					assert.True(t, thread.Messages[0].IsOp(), "First message must be OP on page %d", page)
+				}
+			}
+		}
+
+		// Test empty page beyond valid range
+		board, err := storage.getBoard(tx, boardShortName, pages+1)
+		require.NoError(t, err)
+		assert.Empty(t, board.Threads, "Page beyond valid range should be empty")
+	})
+}
@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectoryDeactivation(t *testing.T) {
+	tx, rollback := beginTx(t)
+	defer rollback()
+
+	adminId := createTestUser(t, tx, "admin@other.com")
+	listed := createTestUser(t, tx, "listed@corp.com")
+	departed := createTestUser(t, tx, "departed@corp.com")
+	banned := createTestUser(t, tx, "banned@corp.com")
+	suspended := createTestUser(t, tx, "suspended@corp.com")
+	createTestUser(t, tx, "outsider@gmail.com")
+
+	require.NoError(t, storage.blacklistUser(tx, banned, "Spam", adminId))
+	require.NoError(t, storage.blacklistUserUntil(tx, suspended, "Flood", time.Now().Add(time.Hour)))
+
+	require.NoError(t, storage.deactivateUsers(tx, []domain.UserId{departed, banned, suspended}))
+
+	t.Run("lists managed accounts with their deactivation", func(t *testing.T) {
+		accounts, err := storage.getDirectoryAccounts(tx, []string{"corp.com"})
+		require.NoError(t, err)
+
+		deactivated := map[domain.UserId]bool{}
+		for _, a := range accounts {
+			deactivated[a.Id] = a.Deactivated
+		}
+		assert.Equal(t, map[domain.UserId]bool{listed: false, departed: true, banned: false, suspended: true}, deactivated)
+		assert.Equal(t, []byte("hash_listed@corp.com"), accounts[0].EmailHash)
+	})
+
+	t.Run("deactivated users are banned for good", func(t *testing.T) {
+		for _, id := range []domain.UserId{departed, suspended} {
+			isBlacklisted, err := storage.isUserBlacklisted(tx, id)
+			require.NoError(t, err)
+			assert.True(t, isBlacklisted)
+		}
+
+		entries, err := storage.getBlacklistedUsersWithDetails(tx, 10, 0)
+		require.NoError(t, err)
+		for _, e := range entries {
+			switch e.UserId {
+			case departed, suspended:
+				assert.Equal(t, domain.DirectoryDeactivationReason, e.Reason)
+				assert.Nil(t, e.ExpiresAt)
+			case banned:
+				assert.Equal(t, "Spam", e.Reason, "a ban by an admin is kept")
+			}
+		}
+	})
+
+	t.Run("reactivation lifts only deactivations", func(t *testing.T) {
+		require.NoError(t, storage.reactivateUsers(tx, []domain.UserId{departed, banned}))
+
+		isBlacklisted, err := storage.isUserBlacklisted(tx, departed)
+		require.NoError(t, err)
+		assert.False(t, isBlacklisted)
+
+		isBlacklisted, err = storage.isUserBlacklisted(tx, banned)
+		require.NoError(t, err)
+		assert.True(t, isBlacklisted)
+	})
+}
@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailRotation(t *testing.T) {
+	tx, rollback := beginTx(t)
+	defer rollback()
+
+	first := createTestUser(t, tx, "first@example.com")
+	second := createTestUser(t, tx, "second@example.com")
+
+	t.Run("batches by id after the cursor", func(t *testing.T) {
+		users, err := storage.getUserEmails(tx, first-1, 1)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, first, users[0].Id)
+
+		users, err = storage.getUserEmails(tx, first, 10)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, second, users[0].Id)
+		assert.Equal(t, []byte("hash_second@example.com"), users[0].EmailHash)
+	})
+
+	t.Run("replaces only the expected ciphertext", func(t *testing.T) {
+		users, err := storage.getUserEmails(tx, first-1, 1)
+		require.NoError(t, err)
+		old := users[0].EmailEncrypted
+
+		replaced, err := storage.replaceEmailEncrypted(tx, first, []byte("stale"), []byte("rotated"))
+		require.NoError(t, err)
+		assert.False(t, replaced)
+
+		replaced, err = storage.replaceEmailEncrypted(tx, first, old, []byte("rotated"))
+		require.NoError(t, err)
+		assert.True(t, replaced)
+
+		users, err = storage.getUserEmails(tx, first-1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("rotated"), users[0].EmailEncrypted)
+	})
+
+	t.Run("no users after the last", func(t *testing.T) {
+		users, err := storage.getUserEmails(tx, second, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.User{}, users)
+	})
+}
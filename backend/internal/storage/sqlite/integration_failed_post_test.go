@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailedPosts(t *testing.T) {
+	t.Run("save and list newest first", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		userId := createTestUser(t, tx, "failed_post_user@test.com")
+		threadId := domain.ThreadId(12)
+		now := time.Now().UTC().Truncate(time.Second)
+
+		firstId, err := storage.saveFailedPost(tx, domain.FailedPost{
+			UserId:     userId,
+			Board:      "b",
+			StatusCode: 500,
+			Error:      "Internal error",
+			Request:    domain.FailedPostRequest{Payload: json.RawMessage(`{"title":"t"}`), ContentLength: 100},
+			CreatedAt:  now.Add(-time.Minute),
+		})
+		require.NoError(t, err)
+		secondId, err := storage.saveFailedPost(tx, domain.FailedPost{
+			UserId:     userId,
+			Board:      "b",
+			ThreadId:   &threadId,
+			StatusCode: 400,
+			Error:      "Message text is too long",
+			Request: domain.FailedPostRequest{
+				Payload:      json.RawMessage(`{"text":"hello"}`),
+				Attachments:  []domain.FailedPostAttachment{{Filename: "cat.png", MimeType: "image/png", SizeBytes: 16}},
+				UploadTokens: 1,
+				UserAgent:    "test-agent",
+			},
+			CreatedAt: now,
+		})
+		require.NoError(t, err)
+
+		posts, err := storage.getFailedPosts(tx, 2, 0)
+		require.NoError(t, err)
+		require.Len(t, posts, 2)
+		assert.Equal(t, secondId, posts[0].Id)
+		assert.Equal(t, userId, posts[0].UserId)
+		require.NotNil(t, posts[0].ThreadId)
+		assert.Equal(t, threadId, *posts[0].ThreadId)
+		assert.Equal(t, 400, posts[0].StatusCode)
+		assert.Equal(t, "Message text is too long", posts[0].Error)
+		assert.JSONEq(t, `{"text":"hello"}`, string(posts[0].Request.Payload))
+		assert.Equal(t, []domain.FailedPostAttachment{{Filename: "cat.png", MimeType: "image/png", SizeBytes: 16}}, posts[0].Request.Attachments)
+		assert.Equal(t, 1, posts[0].Request.UploadTokens)
+		assert.WithinDuration(t, now, posts[0].CreatedAt, time.Second)
+		assert.Equal(t, firstId, posts[1].Id)
+		assert.Nil(t, posts[1].ThreadId)
+
+		posts, err = storage.getFailedPosts(tx, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, firstId, posts[0].Id)
+	})
+
+	t.Run("delete expired", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		userId := createTestUser(t, tx, "failed_post_user@test.com")
+		now := time.Now().UTC()
+		_, err := storage.saveFailedPost(tx, domain.FailedPost{UserId: userId, Board: "b", StatusCode: 500, CreatedAt: now.Add(-8 * 24 * time.Hour)})
+		require.NoError(t, err)
+		keptId, err := storage.saveFailedPost(tx, domain.FailedPost{UserId: userId, Board: "b", StatusCode: 500, CreatedAt: now})
+		require.NoError(t, err)
+
+		deleted, err := storage.deleteExpiredFailedPosts(tx, now.Add(-7*24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		posts, err := storage.getFailedPosts(tx, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, keptId, posts[0].Id)
+	})
+}
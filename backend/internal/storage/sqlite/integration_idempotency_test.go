@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	tx, rollback := beginTx(t)
+	defer rollback()
+
+	userId := createTestUser(t, tx, "idempotency@example.com")
+	now := time.Now().UTC()
+	key := domain.IdempotencyKey{UserId: userId, Key: "retry-1", RequestPath: "/v1/b/1", CreatedAt: now}
+	expiredBefore, staleBefore := now.Add(-24*time.Hour), now.Add(-5*time.Minute)
+
+	t.Run("reserved once, then replayed", func(t *testing.T) {
+		_, reserved, err := storage.reserveIdempotencyKey(tx, key, expiredBefore, staleBefore)
+		require.NoError(t, err)
+		assert.True(t, reserved)
+
+		existing, reserved, err := storage.reserveIdempotencyKey(tx, key, expiredBefore, staleBefore)
+		require.NoError(t, err)
+		assert.False(t, reserved)
+		assert.Equal(t, 0, existing.StatusCode, "still in progress")
+
+		require.NoError(t, storage.completeIdempotencyKey(tx, userId, key.Key, http.StatusCreated, []byte(`{"id": 4}`)))
+
+		existing, reserved, err = storage.reserveIdempotencyKey(tx, key, expiredBefore, staleBefore)
+		require.NoError(t, err)
+		assert.False(t, reserved)
+		assert.Equal(t, "/v1/b/1", existing.RequestPath)
+		assert.Equal(t, http.StatusCreated, existing.StatusCode)
+		assert.Equal(t, `{"id": 4}`, string(existing.Response))
+	})
+
+	t.Run("freed key is reserved again", func(t *testing.T) {
+		require.NoError(t, storage.deleteIdempotencyKey(tx, userId, key.Key))
+
+		_, reserved, err := storage.reserveIdempotencyKey(tx, key, expiredBefore, staleBefore)
+		require.NoError(t, err)
+		assert.True(t, reserved)
+	})
+
+	t.Run("abandoned and expired keys are taken over", func(t *testing.T) {
+		abandoned := key
+		abandoned.Key = "abandoned"
+		abandoned.CreatedAt = now.Add(-10 * time.Minute)
+		_, reserved, err := storage.reserveIdempotencyKey(tx, abandoned, expiredBefore, staleBefore)
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		retry := abandoned
+		retry.CreatedAt = now
+		_, reserved, err = storage.reserveIdempotencyKey(tx, retry, expiredBefore, staleBefore)
+		require.NoError(t, err)
+		assert.True(t, reserved, "a request that never finished no longer holds the key")
+
+		expired := key
+		expired.Key = "expired"
+		expired.CreatedAt = now.Add(-25 * time.Hour)
+		_, _, err = storage.reserveIdempotencyKey(tx, expired, expiredBefore, staleBefore)
+		require.NoError(t, err)
+		require.NoError(t, storage.completeIdempotencyKey(tx, userId, expired.Key, http.StatusCreated, []byte(`{}`)))
+
+		deleted, err := storage.deleteExpiredIdempotencyKeys(tx, expiredBefore)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(1))
+
+		expired.CreatedAt = now
+		_, reserved, err = storage.reserveIdempotencyKey(tx, expired, expiredBefore, staleBefore)
+		require.NoError(t, err)
+		assert.True(t, reserved)
+	})
+}
@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginDevices(t *testing.T) {
+	tx, rollback := beginTx(t)
+	defer rollback()
+
+	userId := createTestUser(t, tx, "devices@example.com")
+	laptop := domain.LoginDevice{UserId: userId, Fingerprint: "laptop", RevokeTokenHash: "laptop_token"}
+	phone := domain.LoginDevice{UserId: userId, Fingerprint: "phone", RevokeTokenHash: "phone_token"}
+
+	t.Run("tells new devices from known ones", func(t *testing.T) {
+		isNew, err := storage.saveLoginDevice(tx, laptop)
+		require.NoError(t, err)
+		assert.True(t, isNew)
+
+		again := laptop
+		again.RevokeTokenHash = "another_token"
+		isNew, err = storage.saveLoginDevice(tx, again)
+		require.NoError(t, err)
+		assert.False(t, isNew)
+
+		isNew, err = storage.saveLoginDevice(tx, phone)
+		require.NoError(t, err)
+		assert.True(t, isNew)
+
+		count, err := storage.countLoginDevices(tx, userId)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("revoke token works once and revokes sessions", func(t *testing.T) {
+		revokedAt, err := storage.sessionsRevokedAt(tx, userId)
+		require.NoError(t, err)
+		assert.True(t, revokedAt.IsZero())
+
+		got, err := storage.revokeSessionsByDeviceToken(tx, "phone_token")
+		require.NoError(t, err)
+		assert.Equal(t, userId, got)
+
+		revokedAt, err = storage.sessionsRevokedAt(tx, userId)
+		require.NoError(t, err)
+		assert.False(t, revokedAt.IsZero())
+
+		revocations, err := storage.getRecentSessionRevocations(tx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Contains(t, revocations, userId)
+
+		count, err := storage.countLoginDevices(tx, userId)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count, "the reported device is forgotten")
+
+		_, err = storage.revokeSessionsByDeviceToken(tx, "phone_token")
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusNotFound, e.StatusCode)
+
+		_, err = storage.revokeSessionsByDeviceToken(tx, "another_token")
+		require.ErrorAs(t, err, &e, "a known device keeps the token of its first login")
+	})
+}
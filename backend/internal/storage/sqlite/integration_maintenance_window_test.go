@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindow(t *testing.T) {
+	tx, rollback := beginTx(t)
+	defer rollback()
+
+	_, err := storage.getMaintenanceWindow(tx)
+	requireNotFoundError(t, err)
+	requireNotFoundError(t, storage.deleteMaintenanceWindow(tx))
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	adminId := createTestUser(t, tx, "maintenance@example.com")
+	endsAt := now.Add(2 * time.Hour)
+	window := domain.MaintenanceWindow{
+		Message:   "db upgrade",
+		StartsAt:  now.Add(time.Hour),
+		EndsAt:    &endsAt,
+		CreatedBy: &adminId,
+		CreatedAt: now,
+	}
+
+	t.Run("set and get", func(t *testing.T) {
+		require.NoError(t, storage.setMaintenanceWindow(tx, window))
+
+		got, err := storage.getMaintenanceWindow(tx)
+		require.NoError(t, err)
+		assert.Equal(t, "db upgrade", got.Message)
+		assert.True(t, window.StartsAt.Equal(got.StartsAt))
+		require.NotNil(t, got.EndsAt)
+		assert.True(t, endsAt.Equal(*got.EndsAt))
+		require.NotNil(t, got.CreatedBy)
+		assert.Equal(t, adminId, *got.CreatedBy)
+	})
+
+	t.Run("replaced by the next window", func(t *testing.T) {
+		require.NoError(t, storage.setMaintenanceWindow(tx, domain.MaintenanceWindow{Message: "now", StartsAt: now, CreatedAt: now}))
+
+		got, err := storage.getMaintenanceWindow(tx)
+		require.NoError(t, err)
+		assert.Equal(t, "now", got.Message)
+		assert.Nil(t, got.EndsAt)
+		assert.Nil(t, got.CreatedBy)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, storage.deleteMaintenanceWindow(tx))
+		_, err := storage.getMaintenanceWindow(tx)
+		requireNotFoundError(t, err)
+	})
+}
@@ -0,0 +1,826 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMessageOperations verifies message CRUD operations with attachments and reply cascades.
+func TestMessageOperations(t *testing.T) {
+	t.Run("CreateMessage", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName("btest")
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, "author@example.com")
+		threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Test Thread",
+			Board: boardShortName,
+			OpMessage: domain.MessageCreationData{
+				Author: domain.User{Id: userID},
+				Text:   "OP Message",
+			},
+		})
+
+		t.Run("with attachments and replies", func(t *testing.T) {
+			targetMsgID := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "Target Message",
+				ThreadId: threadID,
+			})
+
+			attachments := getRandomAttachments(t)
+			creationData := domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "A new message",
+				ThreadId: threadID,
+				ReplyTo: &domain.Replies{
+					{To: targetMsgID, ToThreadId: threadID},
+				},
+			}
+
+			msgID, err := storage.createMessage(tx, creationData)
+			require.NoError(t, err)
+			require.Greater(t, msgID, int64(0))
+
+			// Add attachments separately
+			err = storage.addAttachments(tx, boardShortName, threadID, msgID, attachments)
+			require.NoError(t, err)
+
+			createdMsg, err := storage.getMessage(tx, boardShortName, threadID, msgID)
+			require.NoError(t, err)
+			assert.Equal(t, creationData.Text, createdMsg.Text)
+			require.Len(t, createdMsg.Attachments, 2)
+			assert.Equal(t, attachments[0].File.FilePath, createdMsg.Attachments[0].File.FilePath)
+
+			replies, err := storage.getMessageRepliesFrom(tx, boardShortName, threadID, msgID)
+			require.NoError(t, err)
+			require.Len(t, replies, 1)
+			assert.Equal(t, targetMsgID, replies[0].To)
+		})
+
+		t.Run("updates thread metadata", func(t *testing.T) {
+			threadBefore, err := storage.getThread(tx, boardShortName, threadID, 1)
+			require.NoError(t, err)
+
+			createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "A new reply",
+				ThreadId: threadID,
+			})
+
+			threadAfter, err := storage.getThread(tx, boardShortName, threadID, 1)
+			require.NoError(t, err)
+			assert.Equal(t, threadBefore.MessageCount+1, threadAfter.MessageCount)
+			assert.True(t, threadAfter.LastBumped.After(threadBefore.LastBumped))
+		})
+
+		t.Run("fails on invalid board or thread", func(t *testing.T) {
+			_, err := storage.createMessage(tx, domain.MessageCreationData{
+				Board:    "nonexistent",
+				Author:   domain.User{Id: userID},
+				ThreadId: threadID,
+			})
+			require.Error(t, err)
+
+			_, err = storage.createMessage(tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				ThreadId: -999,
+			})
+			requireNotFoundError(t, err)
+		})
+
+		t.Run("stores and retrieves show_email_domain", func(t *testing.T) {
+			// Create message with ShowEmailDomain = true
+			msgWithDomain := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:           boardShortName,
+				Author:          domain.User{Id: userID},
+				Text:            "Company post",
+				ShowEmailDomain: true,
+				ThreadId:        threadID,
+			})
+
+			retrieved, err := storage.getMessage(tx, boardShortName, threadID, msgWithDomain)
+			require.NoError(t, err)
+			assert.True(t, retrieved.ShowEmailDomain, "ShowEmailDomain should be true")
+			assert.Equal(t, "example.com", retrieved.Author.EmailDomain)
+
+			// Create message with ShowEmailDomain = false (default)
+			msgWithoutDomain := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "Anonymous post",
+				ThreadId: threadID,
+			})
+
+			retrieved2, err := storage.getMessage(tx, boardShortName, threadID, msgWithoutDomain)
+			require.NoError(t, err)
+			assert.False(t, retrieved2.ShowEmailDomain, "ShowEmailDomain should default to false")
+		})
+	})
+
+	t.Run("GetMessage", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName("bget")
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, "getter@example.com")
+		threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Get Test", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+
+		targetMsgID := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Target message", ThreadId: threadID,
+		})
+
+		msgID := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Message to get", ThreadId: threadID,
+			ReplyTo: &domain.Replies{{To: targetMsgID, ToThreadId: threadID}},
+		})
+
+		// Add attachments
+		attachments := getRandomAttachments(t)
+		err := storage.addAttachments(tx, boardShortName, threadID, msgID, attachments)
+		require.NoError(t, err)
+
+		// Create a reply TO msgID
+		_ = createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Reply to msgID", ThreadId: threadID,
+			ReplyTo: &domain.Replies{{To: msgID, ToThreadId: threadID}},
+		})
+
+		msg, err := storage.getMessage(tx, boardShortName, threadID, msgID)
+		require.NoError(t, err)
+		assert.Equal(t, msgID, msg.Id)
+		assert.Equal(t, "Message to get", msg.Text)
+		require.Len(t, msg.Attachments, 2)
+		require.Len(t, msg.Replies, 1)
+		assert.Equal(t, msgID, msg.Replies[0].To)
+
+		_, err = storage.getMessage(tx, boardShortName, threadID, -999)
+		requireNotFoundError(t, err)
+	})
+
+	t.Run("DeleteMessage", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName("bdel")
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, "deleter@example.com")
+		threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Delete Test", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+
+		msgBeingRepliedTo := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "I will be replied to", ThreadId: threadID,
+		})
+
+		msgToDelete := createTestMessage(t, tx, domain.MessageCreationData{
+			Board:    boardShortName,
+			Author:   domain.User{Id: userID},
+			Text:     "I will be deleted",
+			ThreadId: threadID,
+			ReplyTo:  &domain.Replies{{To: msgBeingRepliedTo, ToThreadId: threadID}},
+		})
+
+		// Add attachments to message that will be deleted
+		attachments := getRandomAttachments(t)
+		err := storage.addAttachments(tx, boardShortName, threadID, msgToDelete, attachments)
+		require.NoError(t, err)
+
+		msgReplyingToDeleted := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "My target will be deleted", ThreadId: threadID,
+			ReplyTo: &domain.Replies{{To: msgToDelete, ToThreadId: threadID}},
+		})
+
+		_, err = storage.getMessage(tx, boardShortName, threadID, msgToDelete)
+		require.NoError(t, err)
+		attachmentsBefore, err := storage.getMessageAttachments(tx, boardShortName, threadID, msgToDelete)
+		require.NoError(t, err)
+		require.NotEmpty(t, attachmentsBefore)
+		repliesToBefore, err := storage.getMessageRepliesTo(tx, boardShortName, threadID, msgToDelete)
+		require.NoError(t, err)
+		require.NotEmpty(t, repliesToBefore)
+		repliesFromBefore, err := storage.getMessageRepliesFrom(tx, boardShortName, threadID, msgToDelete)
+		require.NoError(t, err)
+		require.NotEmpty(t, repliesFromBefore)
+
+		err = storage.deleteMessage(tx, boardShortName, threadID, msgToDelete)
+		require.NoError(t, err)
+
+		_, err = storage.getMessage(tx, boardShortName, threadID, msgToDelete)
+		requireNotFoundError(t, err)
+
+		attachmentsAfter, err := storage.getMessageAttachments(tx, boardShortName, threadID, msgToDelete)
+		require.NoError(t, err)
+		assert.Empty(t, attachmentsAfter)
+
+		// Verify files are deleted from files table
+		for _, att := range attachmentsBefore {
+			var count int
+			err = tx.QueryRow("SELECT COUNT(*) FROM files WHERE id = $1", att.FileId).Scan(&count)
+			require.NoError(t, err)
+			assert.Equal(t, 0, count, "File %d should be deleted from files table", att.FileId)
+		}
+
+		repliesToAfter, err := storage.getMessageRepliesTo(tx, boardShortName, threadID, msgToDelete)
+		require.NoError(t, err)
+		assert.Empty(t, repliesToAfter)
+
+		repliesFromAfter, err := storage.getMessageRepliesFrom(tx, boardShortName, threadID, msgToDelete)
+		require.NoError(t, err)
+		assert.Empty(t, repliesFromAfter)
+
+		_, err = storage.getMessage(tx, boardShortName, threadID, msgBeingRepliedTo)
+		require.NoError(t, err)
+
+		_, err = storage.getMessage(tx, boardShortName, threadID, msgReplyingToDeleted)
+		require.NoError(t, err)
+
+		t.Run("fails on invalid board or message", func(t *testing.T) {
+			err := storage.deleteMessage(tx, "nonexistent", threadID, msgBeingRepliedTo)
+			requireNotFoundError(t, err)
+
+			err = storage.deleteMessage(tx, boardShortName, threadID, -1)
+			requireNotFoundError(t, err)
+		})
+	})
+
+	t.Run("DeletionStubs", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName("bstub")
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, "stubs@example.com")
+		threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Stub Test", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+		msgID := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID}, Text: "rule breaker", ThreadId: threadID,
+		})
+
+		require.NoError(t, storage.recordMessageDeletion(tx, boardShortName, threadID, msgID, domain.MessageDeletionData{Reason: "rule 3", DeletedBy: &userID}))
+		require.NoError(t, storage.deleteMessage(tx, boardShortName, threadID, msgID))
+
+		count, err := storage.countDeletedMessagesByAuthor(tx, userID, time.Now().UTC().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, count, "deletion is attributed to the message author")
+
+		thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		assert.Empty(t, thread.Deleted, "stubs are hidden until the board enables them")
+
+		require.NoError(t, storage.updateBoardSettings(tx, boardShortName, domain.BoardSettings{ShowDeletionStubs: true}))
+		thread, err = storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		require.Len(t, thread.Deleted, 1)
+		assert.Equal(t, msgID, thread.Deleted[0].Id)
+		assert.Equal(t, "rule 3", thread.Deleted[0].Reason)
+	})
+
+	t.Run("ModerationLog", func(t *testing.T) {
+		ctx := context.Background()
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, storage.db, boardShortName)
+		defer func() { require.NoError(t, storage.DeleteBoard(ctx, boardShortName)) }()
+		otherBoard := domain.BoardShortName(generateString(t))
+		createTestBoard(t, storage.db, otherBoard)
+		defer func() { require.NoError(t, storage.DeleteBoard(ctx, otherBoard)) }()
+
+		userID := createTestUser(t, storage.db, generateString(t)+"@modlog.com")
+		threadID, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+			Title: "Modlog Test", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+		msgID := createTestMessage(t, storage.db, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID}, Text: "rule breaker", ThreadId: threadID,
+		})
+
+		require.NoError(t, storage.DeleteMessage(ctx, boardShortName, threadID, msgID, domain.MessageDeletionData{Reason: "rule 3", DeletedBy: &userID}))
+		require.NoError(t, storage.RecordModeration(ctx, domain.ModLogEntry{Board: boardShortName, Action: domain.ModLogDeleteThread, Reason: "off-topic"}))
+		require.NoError(t, storage.RecordModeration(ctx, domain.ModLogEntry{Board: otherBoard, Action: domain.ModLogDeleteThread}))
+
+		entries, total, err := storage.GetModLog(ctx, boardShortName, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, domain.ModLogDeleteThread, entries[0].Action, "newest first")
+		assert.Equal(t, "off-topic", entries[0].Reason)
+		assert.Equal(t, domain.ModLogEntry{Board: boardShortName, Action: domain.ModLogDeleteMessage, Reason: "rule 3", CreatedAt: entries[1].CreatedAt}, entries[1])
+		assert.False(t, entries[1].CreatedAt.IsZero())
+
+		entries, total, err = storage.GetModLog(ctx, boardShortName, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, domain.ModLogDeleteMessage, entries[0].Action)
+
+		entries, _, err = storage.GetModLog(ctx, "nonexistent", 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("SelfDeletion", func(t *testing.T) {
+		ctx := context.Background()
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, storage.db, boardShortName)
+		defer func() { require.NoError(t, storage.DeleteBoard(ctx, boardShortName)) }()
+		require.NoError(t, storage.UpdateBoardSettings(ctx, boardShortName, domain.BoardSettings{ShowDeletionStubs: true}))
+
+		userID := createTestUser(t, storage.db, generateString(t)+"@selfdelete.com")
+		threadID, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+			Title: "Self Delete Test", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+		msgID := createTestMessage(t, storage.db, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID}, Text: "typo", ThreadId: threadID,
+		})
+
+		require.NoError(t, storage.DeleteMessage(ctx, boardShortName, threadID, msgID, domain.MessageDeletionData{DeletedBy: &userID, ByAuthor: true}))
+
+		var byAuthor bool
+		require.NoError(t, storage.db.QueryRow(`SELECT by_author FROM message_deletions WHERE board = $1 AND thread_id = $2 AND message_id = $3`,
+			boardShortName, threadID, msgID).Scan(&byAuthor))
+		assert.True(t, byAuthor, "self-deletion is kept for auditing")
+
+		count, err := storage.countDeletedMessagesByAuthor(storage.db, userID, time.Now().UTC().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, count, "self-deletions do not count towards auto-bans")
+
+		thread, err := storage.getThread(storage.db, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		assert.Empty(t, thread.Deleted, "no stub for self-deletions")
+
+		entries, _, err := storage.GetModLog(ctx, boardShortName, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "self-deletions are not moderation")
+	})
+
+	t.Run("AddAttachments", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName("badd")
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, "adder@example.com")
+		threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Add Attachments Test",
+			Board: boardShortName,
+			OpMessage: domain.MessageCreationData{
+				Author: domain.User{Id: userID},
+				Text:   "OP",
+			},
+		})
+
+		t.Run("adds attachments to existing message", func(t *testing.T) {
+			// Create message without attachments
+			msgID := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "Message without attachments",
+				ThreadId: threadID,
+			})
+
+			// Verify no attachments initially
+			attachmentsBefore, err := storage.getMessageAttachments(tx, boardShortName, threadID, msgID)
+			require.NoError(t, err)
+			assert.Empty(t, attachmentsBefore)
+
+			// Add attachments
+			attachments := domain.Attachments{
+				&domain.Attachment{
+					Board:     boardShortName,
+					ThreadId:  threadID,
+					MessageId: msgID,
+					File: &domain.File{
+						FileCommonMetadata: domain.FileCommonMetadata{
+							Filename:    "image1.jpg",
+							SizeBytes:   1024,
+							MimeType:    "image/jpeg",
+							ImageWidth:  intPtr(800),
+							ImageHeight: intPtr(600),
+						},
+						FilePath:         "tech/1/image1.jpg",
+						OriginalFilename: "image1.jpg",
+					},
+				},
+				&domain.Attachment{
+					Board:     boardShortName,
+					ThreadId:  threadID,
+					MessageId: msgID,
+					File: &domain.File{
+						FileCommonMetadata: domain.FileCommonMetadata{
+							Filename:    "image2.png",
+							SizeBytes:   2048,
+							MimeType:    "image/png",
+							ImageWidth:  intPtr(1024),
+							ImageHeight: intPtr(768),
+						},
+						FilePath:         "tech/1/image2.png",
+						OriginalFilename: "image2.png",
+					},
+				},
+			}
+
+			err = storage.addAttachments(tx, boardShortName, threadID, msgID, attachments)
+			require.NoError(t, err)
+
+			// Verify attachments were added
+			attachmentsAfter, err := storage.getMessageAttachments(tx, boardShortName, threadID, msgID)
+			require.NoError(t, err)
+			assert.Len(t, attachmentsAfter, 2)
+
+			// Verify first attachment
+			assert.Equal(t, "tech/1/image1.jpg", attachmentsAfter[0].File.FilePath)
+			assert.Equal(t, "image1.jpg", attachmentsAfter[0].File.Filename)
+			assert.Equal(t, "image1.jpg", attachmentsAfter[0].File.OriginalFilename)
+			assert.Equal(t, int64(1024), attachmentsAfter[0].File.SizeBytes)
+			assert.Equal(t, "image/jpeg", attachmentsAfter[0].File.MimeType)
+			assert.NotNil(t, attachmentsAfter[0].File.ImageWidth)
+			assert.Equal(t, 800, *attachmentsAfter[0].File.ImageWidth)
+
+			// Verify second attachment
+			assert.Equal(t, "tech/1/image2.png", attachmentsAfter[1].File.FilePath)
+		})
+
+		t.Run("stores text previews", func(t *testing.T) {
+			msgID := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "Message with a text file",
+				ThreadId: threadID,
+			})
+
+			attachments := domain.Attachments{
+				&domain.Attachment{
+					File: &domain.File{
+						FileCommonMetadata: domain.FileCommonMetadata{
+							Filename:  "notes.txt",
+							SizeBytes: 12,
+							MimeType:  "text/plain",
+						},
+						FilePath:         "tech/1/notes.txt",
+						OriginalFilename: "notes.txt",
+						TextPreview:      "hello\nworld",
+					},
+				},
+				getRandomAttachments(t)[0],
+			}
+			require.NoError(t, storage.addAttachments(tx, boardShortName, threadID, msgID, attachments))
+
+			stored, err := storage.getMessageAttachments(tx, boardShortName, threadID, msgID)
+			require.NoError(t, err)
+			require.Len(t, stored, 2)
+			assert.Equal(t, "hello\nworld", stored[0].File.TextPreview)
+			assert.Empty(t, stored[1].File.TextPreview, "other files have no preview")
+		})
+
+		t.Run("can add more attachments to message that already has some", func(t *testing.T) {
+			// Create message with attachments
+			initialAttachments := getRandomAttachments(t)
+			msgID := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "Message with initial attachments",
+				ThreadId: threadID,
+			})
+
+			// Add initial attachments
+			err := storage.addAttachments(tx, boardShortName, threadID, msgID, initialAttachments)
+			require.NoError(t, err)
+			initialCount := len(initialAttachments)
+
+			// Create new attachments to add
+			newAttachments := getRandomAttachments(t)
+			err = storage.addAttachments(tx, boardShortName, threadID, msgID, newAttachments)
+			require.NoError(t, err)
+
+			// Verify total attachments
+			attachmentsAfter, err := storage.getMessageAttachments(tx, boardShortName, threadID, msgID)
+			require.NoError(t, err)
+			assert.Len(t, attachmentsAfter, initialCount+len(newAttachments))
+		})
+
+		t.Run("handles video files without dimensions", func(t *testing.T) {
+			msgID := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "Message for video",
+				ThreadId: threadID,
+			})
+
+			attachments := domain.Attachments{
+				&domain.Attachment{
+					Board:     boardShortName,
+					ThreadId:  threadID,
+					MessageId: msgID,
+					File: &domain.File{
+						FileCommonMetadata: domain.FileCommonMetadata{
+							Filename:    "video.mp4",
+							SizeBytes:   10000,
+							MimeType:    "video/mp4",
+							ImageWidth:  nil,
+							ImageHeight: nil,
+						},
+						FilePath:         "tech/1/video.mp4",
+						OriginalFilename: "video.mp4",
+					},
+				},
+			}
+
+			err := storage.addAttachments(tx, boardShortName, threadID, msgID, attachments)
+			require.NoError(t, err)
+
+			retrieved, err := storage.getMessageAttachments(tx, boardShortName, threadID, msgID)
+			require.NoError(t, err)
+			assert.Len(t, retrieved, 1)
+			assert.Nil(t, retrieved[0].File.ImageWidth)
+			assert.Nil(t, retrieved[0].File.ImageHeight)
+		})
+
+		t.Run("fails with invalid message ID", func(t *testing.T) {
+			attachments := domain.Attachments{
+				&domain.Attachment{
+					Board:     boardShortName,
+					ThreadId:  threadID,
+					MessageId: -999,
+					File: &domain.File{
+						FileCommonMetadata: domain.FileCommonMetadata{
+							Filename:  "file.jpg",
+							SizeBytes: 1000,
+							MimeType:  "image/jpeg",
+						},
+						FilePath:         "tech/1/file.jpg",
+						OriginalFilename: "file.jpg",
+					},
+				},
+			}
+
+			err := storage.addAttachments(tx, boardShortName, threadID, -999, attachments)
+			require.Error(t, err)
+		})
+	})
+
+	// Test for the bug where deleting a message in the middle of a thread
+	// causes a primary key violation when creating a new message
+	t.Run("DeleteMessageInMiddle_ThenCreateNewMessage", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName("bgap")
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, "gaptest@example.com")
+		threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Gap Delete Test",
+			Board: boardShortName,
+			OpMessage: domain.MessageCreationData{
+				Author: domain.User{Id: userID},
+				Text:   "OP",
+			},
+		})
+
+		// Create messages with IDs 2, 3, 4, 5
+		msg2 := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Message 2", ThreadId: threadID,
+		})
+		msg3 := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Message 3", ThreadId: threadID,
+		})
+		msg4 := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Message 4", ThreadId: threadID,
+		})
+		msg5 := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Message 5", ThreadId: threadID,
+		})
+
+		// Verify IDs are sequential
+		assert.Equal(t, int64(2), msg2)
+		assert.Equal(t, int64(3), msg3)
+		assert.Equal(t, int64(4), msg4)
+		assert.Equal(t, int64(5), msg5)
+
+		// Delete message 3 (creates a gap: 1, 2, 4, 5)
+		err := storage.deleteMessage(tx, boardShortName, threadID, msg3)
+		require.NoError(t, err)
+
+		// Verify message 3 is deleted
+		_, err = storage.getMessage(tx, boardShortName, threadID, msg3)
+		requireNotFoundError(t, err)
+
+		// Create a new message - this should get ID 6 (not 5, which would conflict)
+		msg6 := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Message 6 after gap", ThreadId: threadID,
+		})
+
+		// The bug would cause msg6 to be 5 (PK violation)
+		// With the fix, it should be 6
+		assert.Equal(t, int64(6), msg6, "New message should get ID 6, not reuse deleted ID 5")
+
+		// Verify we can retrieve the new message
+		retrieved, err := storage.getMessage(tx, boardShortName, threadID, msg6)
+		require.NoError(t, err)
+		assert.Equal(t, "Message 6 after gap", retrieved.Text)
+
+		// Verify message count is correct (6 total created - 1 deleted = 5)
+		thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 5, thread.MessageCount, "Thread should have 5 messages")
+	})
+
+	// Test deleting the last message (should work fine)
+	t.Run("DeleteLastMessage_ThenCreateNewMessage", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName("blast")
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, "lasttest@example.com")
+		threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Last Delete Test",
+			Board: boardShortName,
+			OpMessage: domain.MessageCreationData{
+				Author: domain.User{Id: userID},
+				Text:   "OP",
+			},
+		})
+
+		// Create messages 2, 3
+		createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Message 2", ThreadId: threadID,
+		})
+		msg3 := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Message 3", ThreadId: threadID,
+		})
+
+		// Delete last message (3)
+		err := storage.deleteMessage(tx, boardShortName, threadID, msg3)
+		require.NoError(t, err)
+
+		// Create new message - should get ID 4
+		msg4 := createTestMessage(t, tx, domain.MessageCreationData{
+			Board: boardShortName, Author: domain.User{Id: userID},
+			Text: "Message 4", ThreadId: threadID,
+		})
+
+		assert.Equal(t, int64(4), msg4, "New message should get ID 4")
+
+		// Verify thread has 3 messages (1 OP + 1 msg2 + 1 msg4)
+		thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 3, thread.MessageCount)
+	})
+
+	t.Run("GetLastMessageTime", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, "last_post@example.com")
+
+		last, err := storage.getLastMessageTime(tx, userID)
+		require.NoError(t, err)
+		assert.Nil(t, last, "user without messages has no last post")
+
+		createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Thread", Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+
+		last, err = storage.getLastMessageTime(tx, userID)
+		require.NoError(t, err)
+		require.NotNil(t, last)
+		assert.WithinDuration(t, time.Now(), *last, time.Minute)
+	})
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// TestPostNumbers verifies that post numbers count messages across all
+// threads of a board and that replies carry the number of their sender.
+func TestPostNumbers(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	otherBoard := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, otherBoard)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+	newThread := func(board domain.BoardShortName) domain.ThreadId {
+		id, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Numbered", Board: board,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+		return id
+	}
+	first := newThread(boardShortName)
+	second := newThread(boardShortName)
+	newThread(otherBoard)
+	replyID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: first, Author: domain.User{Id: userID}, Text: "reply",
+		ReplyTo: &domain.Replies{{To: 1, ToThreadId: first}},
+	})
+
+	postNumber := func(threadID domain.ThreadId, msgID domain.MsgId) domain.PostNumber {
+		msg, err := storage.getMessage(tx, boardShortName, threadID, msgID)
+		require.NoError(t, err)
+		return msg.PostNumber
+	}
+	assert.Equal(t, domain.PostNumber(1), postNumber(first, 1))
+	assert.Equal(t, domain.PostNumber(2), postNumber(second, 1))
+	assert.Equal(t, domain.PostNumber(3), postNumber(first, replyID), "numbers are shared by all threads of the board")
+
+	op, err := storage.getMessage(tx, boardShortName, first, 1)
+	require.NoError(t, err)
+	require.Len(t, op.Replies, 1)
+	assert.Equal(t, domain.PostNumber(3), op.Replies[0].FromPostNumber)
+}
+
+func TestResolvePostNumber(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Resolved", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	replyID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+	})
+
+	gotThread, gotMsg, err := storage.resolvePostNumber(tx, boardShortName, 2)
+	require.NoError(t, err)
+	assert.Equal(t, threadID, gotThread)
+	assert.Equal(t, replyID, gotMsg)
+
+	_, _, err = storage.resolvePostNumber(tx, boardShortName, 99)
+	requireNotFoundError(t, err)
+}
+
+func TestGetExistingMessages(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Linked", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	replyID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply",
+	})
+	deletedID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "deleted",
+	})
+	require.NoError(t, storage.deleteMessage(tx, boardShortName, threadID, deletedID))
+
+	existing, err := storage.getExistingMessages(tx, boardShortName, []domain.MessageLink{
+		{ThreadId: threadID, Id: 1},
+		{ThreadId: threadID, Id: replyID},
+		{ThreadId: threadID, Id: deletedID},
+		{ThreadId: threadID + 1000, Id: 1},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []domain.MessageLink{{ThreadId: threadID, Id: 1}, {ThreadId: threadID, Id: replyID}}, existing)
+
+	existing, err = storage.getExistingMessages(tx, boardShortName, nil)
+	require.NoError(t, err)
+	assert.Empty(t, existing)
+}
@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	ctx := context.Background()
+
+	// The public methods read outside of a transaction, so the data is committed.
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	author := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@notify.com")}
+	replier := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@notify.com")}
+	hidden := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@notify.com")}
+	require.NoError(t, storage.ShadowbanUser(ctx, board, hidden.Id, author.Id))
+
+	threadId, opId := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Notified", Board: board,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+	})
+	secondId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: author, Text: "second"})
+	replyTo := func(ids ...domain.MsgId) *domain.Replies {
+		replies := domain.Replies{}
+		for _, id := range ids {
+			replies = append(replies, &domain.Reply{To: id, ToThreadId: threadId})
+		}
+		return &replies
+	}
+	// Quoting two messages of the same author makes one notification
+	replyId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: replier, Text: "reply", ReplyTo: replyTo(opId, secondId)})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: author, Text: "self reply", ReplyTo: replyTo(opId)})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: hidden, Text: "hidden reply", ReplyTo: replyTo(opId)})
+	lastId := createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: replier, Text: "last reply", ReplyTo: replyTo(secondId)})
+
+	notifications, total, err := storage.GetNotifications(ctx, author.Id, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, notifications, 2)
+	assert.Equal(t, lastId, notifications[0].Message.Id)
+	assert.Equal(t, replyId, notifications[1].Message.Id)
+	assert.Equal(t, domain.ThreadTitle("Notified"), notifications[1].Message.ThreadTitle)
+	assert.Equal(t, domain.MsgText("reply"), notifications[1].Message.Text)
+	assert.False(t, notifications[0].Read)
+
+	unread, err := storage.CountUnreadNotifications(ctx, author.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, unread)
+
+	t.Run("mark one read", func(t *testing.T) {
+		err := storage.MarkNotificationRead(ctx, replier.Id, notifications[1].Id)
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e, "another user's notification")
+		assert.Equal(t, http.StatusNotFound, e.StatusCode)
+
+		require.NoError(t, storage.MarkNotificationRead(ctx, author.Id, notifications[1].Id))
+		require.NoError(t, storage.MarkNotificationRead(ctx, author.Id, notifications[1].Id), "marking again is a no-op")
+
+		unread, err := storage.CountUnreadNotifications(ctx, author.Id)
+		require.NoError(t, err)
+		assert.Equal(t, 1, unread)
+	})
+
+	t.Run("mark all read", func(t *testing.T) {
+		require.NoError(t, storage.MarkAllNotificationsRead(ctx, author.Id))
+
+		unread, err := storage.CountUnreadNotifications(ctx, author.Id)
+		require.NoError(t, err)
+		assert.Equal(t, 0, unread)
+		page, _, err := storage.GetNotifications(ctx, author.Id, 10, 0)
+		require.NoError(t, err)
+		for _, n := range page {
+			assert.True(t, n.Read)
+		}
+	})
+
+	t.Run("removed with the message", func(t *testing.T) {
+		require.NoError(t, storage.DeleteMessage(ctx, board, threadId, lastId, domain.MessageDeletionData{}))
+
+		var count int
+		require.NoError(t, storage.db.QueryRow(`SELECT count(*) FROM notifications WHERE board = $1 AND message_id = $2`, board, lastId).Scan(&count))
+		assert.Zero(t, count)
+		_, total, err := storage.GetNotifications(ctx, author.Id, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+	})
+}
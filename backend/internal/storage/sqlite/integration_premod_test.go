@@ -0,0 +1,200 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPremodQueue(t *testing.T) {
+	setup := func(t *testing.T, q Querier) (domain.BoardShortName, domain.UserId, domain.ThreadId, domain.MsgId) {
+		t.Helper()
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, q, boardShortName)
+		userId := createTestUser(t, q, "premod_user@test.com")
+		threadId, opId := createTestThread(t, q, domain.ThreadCreationData{
+			Title: "Existing Thread",
+			Board: boardShortName,
+			OpMessage: domain.MessageCreationData{
+				Author: domain.User{Id: userId},
+				Text:   "OP Message",
+			},
+		})
+		return boardShortName, userId, threadId, opId
+	}
+
+	t.Run("approve reply", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		boardShortName, userId, threadId, opId := setup(t, tx)
+		attachments := getRandomAttachments(t)
+
+		id, err := storage.queuePost(tx, domain.MessageCreationData{
+			Board:    boardShortName,
+			Author:   domain.User{Id: userId},
+			Text:     "Held reply",
+			ThreadId: threadId,
+			ReplyTo:  &domain.Replies{{To: opId, ToThreadId: threadId}},
+		}, nil, attachments)
+		require.NoError(t, err)
+
+		posts, err := storage.getQueuedPosts(tx, boardShortName, 10)
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, id, posts[0].Id)
+		assert.False(t, posts[0].IsThread())
+		assert.Equal(t, "Held reply", string(posts[0].Text))
+		assert.Equal(t, []string{attachments[0].File.OriginalFilename, attachments[1].File.OriginalFilename}, posts[0].Files)
+
+		thread, err := storage.getThread(tx, boardShortName, threadId, 1)
+		require.NoError(t, err)
+		assert.Len(t, thread.Messages, 1, "queued post must not be visible in the thread")
+
+		approved, err := storage.approveQueuedPost(tx, boardShortName, id, nil)
+		require.NoError(t, err)
+		assert.Equal(t, threadId, approved.ThreadId)
+		assert.Equal(t, userId, approved.AuthorId)
+
+		msg, err := storage.getMessage(tx, boardShortName, threadId, approved.Id)
+		require.NoError(t, err)
+		assert.Equal(t, "Held reply", string(msg.Text))
+		require.Len(t, msg.Attachments, 2)
+		assert.Equal(t, attachments[0].File.FilePath, msg.Attachments[0].File.FilePath)
+
+		replies, err := storage.getMessageRepliesFrom(tx, boardShortName, threadId, approved.Id)
+		require.NoError(t, err)
+		require.Len(t, replies, 1)
+		assert.Equal(t, opId, replies[0].To)
+
+		poster, err := storage.getPremodPoster(tx, boardShortName, userId)
+		require.NoError(t, err)
+		assert.Equal(t, 1, poster.Approvals)
+
+		posts, err = storage.getQueuedPosts(tx, boardShortName, 10)
+		require.NoError(t, err)
+		assert.Empty(t, posts)
+
+		_, err = storage.approveQueuedPost(tx, boardShortName, id, nil)
+		requireNotFoundError(t, err)
+	})
+
+	t.Run("approve thread", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		boardShortName, userId, _, _ := setup(t, tx)
+
+		id, err := storage.queuePost(tx, domain.MessageCreationData{
+			Board:  boardShortName,
+			Author: domain.User{Id: userId},
+			Text:   "Held OP",
+		}, &domain.QueuedThread{Title: "Held Thread", OpOnly: true}, nil)
+		require.NoError(t, err)
+
+		posts, err := storage.getQueuedPosts(tx, boardShortName, 10)
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.True(t, posts[0].IsThread())
+		assert.Equal(t, domain.ThreadTitle("Held Thread"), posts[0].Title)
+		assert.Empty(t, posts[0].Files)
+
+		approved, err := storage.approveQueuedPost(tx, boardShortName, id, nil)
+		require.NoError(t, err)
+
+		thread, err := storage.getThread(tx, boardShortName, approved.ThreadId, 1)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ThreadTitle("Held Thread"), thread.Title)
+		assert.True(t, thread.OpOnly)
+		require.Len(t, thread.Messages, 1)
+		assert.Equal(t, approved.Id, thread.Messages[0].Id)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		boardShortName, userId, threadId, _ := setup(t, tx)
+
+		id, err := storage.queuePost(tx, domain.MessageCreationData{
+			Board:    boardShortName,
+			Author:   domain.User{Id: userId},
+			Text:     "Spam",
+			ThreadId: threadId,
+		}, nil, getRandomAttachments(t))
+		require.NoError(t, err)
+
+		require.NoError(t, storage.deleteQueuedPost(tx, boardShortName, id))
+		posts, err := storage.getQueuedPosts(tx, boardShortName, 10)
+		require.NoError(t, err)
+		assert.Empty(t, posts)
+
+		requireNotFoundError(t, storage.deleteQueuedPost(tx, boardShortName, id))
+		_, err = storage.approveQueuedPost(tx, boardShortName, id, nil)
+		requireNotFoundError(t, err)
+	})
+
+	t.Run("queue is per board", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		boardShortName, userId, threadId, _ := setup(t, tx)
+		otherBoard := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, otherBoard)
+
+		id, err := storage.queuePost(tx, domain.MessageCreationData{
+			Board:    boardShortName,
+			Author:   domain.User{Id: userId},
+			Text:     "Held reply",
+			ThreadId: threadId,
+		}, nil, nil)
+		require.NoError(t, err)
+
+		posts, err := storage.getQueuedPosts(tx, otherBoard, 10)
+		require.NoError(t, err)
+		assert.Empty(t, posts)
+
+		_, err = storage.approveQueuedPost(tx, otherBoard, id, nil)
+		requireNotFoundError(t, err)
+	})
+
+	t.Run("poster trust", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		boardShortName, userId, _, _ := setup(t, tx)
+		adminId := createTestUser(t, tx, "premod_admin@test.com")
+		trusted, untrusted := true, false
+
+		poster, err := storage.getPremodPoster(tx, boardShortName, userId)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PremodPoster{Board: boardShortName, UserId: userId}, poster)
+
+		require.NoError(t, storage.setPosterTrust(tx, boardShortName, userId, trusted, adminId))
+		require.NoError(t, storage.countPremodApproval(tx, boardShortName, userId))
+		poster, err = storage.getPremodPoster(tx, boardShortName, userId)
+		require.NoError(t, err)
+		require.NotNil(t, poster.Trusted)
+		assert.True(t, *poster.Trusted)
+		assert.Equal(t, adminId, poster.UpdatedBy)
+		assert.Equal(t, 1, poster.Approvals, "an override keeps the approvals")
+
+		require.NoError(t, storage.setPosterTrust(tx, boardShortName, userId, untrusted, adminId))
+		posters, err := storage.getPremodPosters(tx, boardShortName)
+		require.NoError(t, err)
+		require.Len(t, posters, 1)
+		require.NotNil(t, posters[0].Trusted)
+		assert.False(t, *posters[0].Trusted)
+
+		require.NoError(t, storage.clearPosterTrust(tx, boardShortName, userId))
+		poster, err = storage.getPremodPoster(tx, boardShortName, userId)
+		require.NoError(t, err)
+		assert.Nil(t, poster.Trusted)
+		assert.Equal(t, 1, poster.Approvals)
+
+		requireNotFoundError(t, storage.clearPosterTrust(tx, boardShortName, userId))
+		requireNotFoundError(t, storage.setPosterTrust(tx, domain.BoardShortName(generateString(t)), userId, trusted, adminId))
+	})
+}
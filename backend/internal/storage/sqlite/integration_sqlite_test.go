@@ -0,0 +1,214 @@
+// Tests for the SQLite storage layer. They follow the transactional pattern of
+// the package pg integration tests: each test runs in a transaction that is
+// rolled back at the end, and helpers call the internal methods with the
+// transaction as Querier. The database is a temporary file, so no external
+// services are needed.
+package sqlite
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// storage is a global instance of our storage layer, initialized once for the suite.
+	storage *Storage
+)
+
+// TestMain creates the database in a temporary directory, runs the tests and
+// removes the directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "itchan-sqlite-test")
+	if err != nil {
+		log.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	cfg := &config.Config{
+		Public: config.Public{
+			ThreadsPerPage:        3,
+			NLastMsg:              3,
+			BumpLimit:             15,
+			MessagesPerThreadPage: 10,
+			QueryTimeout:          5 * time.Second,
+			LongQueryTimeout:      30 * time.Second,
+		},
+		Private: config.Private{
+			Storage: config.StorageSqlite,
+			Sqlite:  config.Sqlite{Path: filepath.Join(dir, "itchan.db")},
+		},
+	}
+	storage, err = New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	exitCode := m.Run()
+
+	if err := storage.Cleanup(); err != nil {
+		log.Printf("Error cleaning up storage: %v", err)
+	}
+	os.RemoveAll(dir)
+	os.Exit(exitCode)
+}
+
+// =========================================================================
+// Transactional Test Helpers
+// =========================================================================
+
+// createTestUser creates a user within the given transaction.
+// Since storage layer no longer handles crypto, we create test data directly.
+func createTestUser(t *testing.T, q Querier, email string) domain.UserId {
+	t.Helper()
+
+	// Extract domain from email for test purposes
+	emailDomain := ""
+	if parts := strings.Split(email, "@"); len(parts) == 2 {
+		emailDomain = parts[1]
+	}
+
+	// Use simple test data - storage layer just stores what it receives
+	userID, err := storage.saveUser(q, domain.User{
+		EmailEncrypted: []byte("encrypted_" + email),
+		EmailDomain:    emailDomain,
+		EmailHash:      []byte("hash_" + email),
+		PassHash:       "test_hash",
+		Admin:          false,
+	})
+	require.NoError(t, err)
+	return userID
+}
+
+// createTestBoard creates a board within the given transaction.
+func createTestBoard(t *testing.T, q Querier, shortName domain.BoardShortName) {
+	t.Helper()
+	err := storage.createBoard(q, domain.BoardCreationData{
+		Name:      "Test Board " + string(shortName),
+		ShortName: shortName,
+	})
+	require.NoError(t, err)
+}
+
+// createTestThread creates a thread and its OP message within the given transaction.
+func createTestThread(t *testing.T, q Querier, data domain.ThreadCreationData) (domain.ThreadId, domain.MsgId) {
+	t.Helper()
+	threadID, createdTs, err := storage.createThread(q, data)
+	require.NoError(t, err)
+
+	data.OpMessage.ThreadId = threadID
+	data.OpMessage.CreatedAt = &createdTs
+	data.OpMessage.Board = data.Board
+
+	opMsgID, err := storage.createMessage(q, data.OpMessage)
+	require.NoError(t, err)
+	return threadID, opMsgID
+}
+
+// createTestMessage creates a message within the given transaction.
+func createTestMessage(t *testing.T, q Querier, data domain.MessageCreationData) domain.MsgId {
+	t.Helper()
+	msgID, err := storage.createMessage(q, data)
+	require.NoError(t, err)
+	return msgID
+}
+
+// =========================================================================
+// General Test Utility Functions
+// =========================================================================
+
+// generateString creates a short, unique, alphanumeric string for test data.
+func generateString(t *testing.T) string {
+	t.Helper()
+	return strings.ReplaceAll(uuid.New().String()[:8], "-", "")
+}
+
+// requireNotFoundError asserts that an error is a not-found error with status code 404.
+func requireNotFoundError(t *testing.T, err error) {
+	t.Helper()
+	require.Error(t, err, "Expected a not-found error, but got nil")
+	var e *internal_errors.ErrorWithStatusCode
+	require.ErrorAs(t, err, &e, "Error is not of type ErrorWithStatusCode")
+	require.Equal(t, http.StatusNotFound, e.StatusCode, "Expected status code 404")
+}
+
+// getRandomAttachments generates a sample attachments slice for use in tests.
+func getRandomAttachments(t *testing.T) domain.Attachments {
+	t.Helper()
+	f1Name := generateString(t)
+	f2Name := generateString(t)
+	attachments := domain.Attachments{
+		&domain.Attachment{
+			File: &domain.File{
+				FileCommonMetadata: domain.FileCommonMetadata{
+					Filename:  f1Name,
+					SizeBytes: 1024,
+					MimeType:  "image/jpeg",
+				},
+				FilePath:         f1Name,
+				OriginalFilename: f1Name,
+			},
+		},
+		&domain.Attachment{
+			File: &domain.File{
+				FileCommonMetadata: domain.FileCommonMetadata{
+					Filename:  f2Name,
+					SizeBytes: 2048,
+					MimeType:  "image/png",
+				},
+				FilePath:         f2Name,
+				OriginalFilename: f2Name,
+			},
+		},
+	}
+	return attachments
+}
+
+// beginTx starts a new transaction and returns it, failing the test on error.
+// Also returns a cleanup function that rolls back the transaction.
+func beginTx(t *testing.T) (*sql.Tx, func()) {
+	t.Helper()
+	tx, err := storage.db.Begin()
+	require.NoError(t, err)
+	return tx, func() { tx.Rollback() }
+}
+
+// requireThreadOrder verifies that threads appear in the expected order by title.
+func requireThreadOrder(t *testing.T, threads []*domain.Thread, expectedTitles []string) {
+	t.Helper()
+	require.Len(t, threads, len(expectedTitles), "Thread count mismatch")
+	for i, expectedTitle := range expectedTitles {
+		require.Equal(t, expectedTitle, string(threads[i].Title),
+			"Thread at index %d has wrong title: expected %q, got %q",
+			i, expectedTitle, threads[i].Title)
+	}
+}
+
+// requireMessageOrder verifies that messages appear in the expected order by text.
+func requireMessageOrder(t *testing.T, messages []*domain.Message, expectedTexts []string) {
+	t.Helper()
+	require.Len(t, messages, len(expectedTexts), "Message count mismatch")
+	for i, expectedText := range expectedTexts {
+		require.Equal(t, expectedText, string(messages[i].Text),
+			"Message at index %d has wrong text: expected %q, got %q",
+			i, expectedText, messages[i].Text)
+	}
+}
+
+// TestNewReopensDatabase verifies that the schema can be applied to an
+// existing database, as happens on every restart.
+func TestNewReopensDatabase(t *testing.T) {
+	reopened, err := New(storage.cfg)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Cleanup())
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThreadSnapshots(t *testing.T) {
+	ctx := context.Background()
+	requireNotFound := func(t *testing.T, err error) {
+		t.Helper()
+		var statusErr *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	}
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	userId := createTestUser(t, storage.db, generateString(t)+"@example.com")
+
+	add := func(threadId domain.ThreadId, name string) domain.ThreadSnapshotId {
+		t.Helper()
+		id, err := storage.AddThreadSnapshot(ctx, domain.ThreadSnapshot{
+			Board: board, ThreadId: threadId, Title: "Snapshot", FilePath: string(board) + "/snapshots/" + name,
+			SizeBytes: 1234, MessageCount: 5, CreatedBy: &userId,
+		})
+		require.NoError(t, err)
+		return id
+	}
+	first := add(1, "1.html")
+	second := add(2, "2.html")
+	third := add(1, "3.html")
+
+	t.Run("get", func(t *testing.T) {
+		snapshot, err := storage.GetThreadSnapshot(ctx, board, first)
+		require.NoError(t, err)
+		assert.Equal(t, first, snapshot.Id)
+		assert.Equal(t, board, snapshot.Board)
+		assert.Equal(t, domain.ThreadId(1), snapshot.ThreadId)
+		assert.Equal(t, "Snapshot", snapshot.Title)
+		assert.Equal(t, string(board)+"/snapshots/1.html", snapshot.FilePath)
+		assert.Equal(t, int64(1234), snapshot.SizeBytes)
+		assert.Equal(t, 5, snapshot.MessageCount)
+		require.NotNil(t, snapshot.CreatedBy)
+		assert.Equal(t, userId, *snapshot.CreatedBy)
+		assert.False(t, snapshot.CreatedAt.IsZero())
+
+		_, err = storage.GetThreadSnapshot(ctx, "nonexistent", first)
+		requireNotFound(t, err)
+	})
+
+	t.Run("list newest first", func(t *testing.T) {
+		snapshots, err := storage.GetThreadSnapshots(ctx, board, nil, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 3)
+		assert.Equal(t, []domain.ThreadSnapshotId{third, second, first}, []domain.ThreadSnapshotId{snapshots[0].Id, snapshots[1].Id, snapshots[2].Id})
+
+		threadId := domain.ThreadId(1)
+		snapshots, err = storage.GetThreadSnapshots(ctx, board, &threadId, 1, 0)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		assert.Equal(t, third, snapshots[0].Id)
+
+		snapshots, err = storage.GetThreadSnapshots(ctx, board, &threadId, 10, 1)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		assert.Equal(t, first, snapshots[0].Id)
+	})
+
+	t.Run("files are not orphans for the media gc", func(t *testing.T) {
+		paths, err := storage.GetAllFilePaths(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, paths, string(board)+"/snapshots/2.html")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		filePath, err := storage.DeleteThreadSnapshot(ctx, board, second)
+		require.NoError(t, err)
+		assert.Equal(t, string(board)+"/snapshots/2.html", filePath)
+
+		_, err = storage.GetThreadSnapshot(ctx, board, second)
+		requireNotFound(t, err)
+		_, err = storage.DeleteThreadSnapshot(ctx, board, second)
+		requireNotFound(t, err)
+	})
+}
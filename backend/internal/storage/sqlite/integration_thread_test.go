@@ -0,0 +1,954 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/lib/pq"
+)
+
+// TestThreadOperations verifies thread CRUD operations and bump limit enforcement.
+func TestThreadOperations(t *testing.T) {
+	t.Run("CreateThread", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		t.Run("Success", func(t *testing.T) {
+			opMsg := domain.MessageCreationData{
+				Board:  boardShortName,
+				Author: domain.User{Id: userID},
+				Text:   "Original Post Text",
+			}
+
+			time.Sleep(50 * time.Millisecond)
+			creationTimeStart := time.Now()
+
+			threadID, createdAt, err := storage.createThread(tx, domain.ThreadCreationData{
+				Title:     "Test Thread Creation",
+				Board:     boardShortName,
+				OpMessage: opMsg,
+			})
+			require.NoError(t, err)
+			require.Greater(t, threadID, int64(0))
+
+			opMsg.ThreadId = threadID
+			opMsg.CreatedAt = &createdAt
+			opMsg.Board = boardShortName
+			_, err = storage.createMessage(tx, opMsg)
+			require.NoError(t, err)
+
+			createdThread, err := storage.getThread(tx, boardShortName, threadID, 1)
+			require.NoError(t, err)
+
+			assert.Equal(t, "Test Thread Creation", createdThread.Title)
+			assert.Equal(t, boardShortName, createdThread.Board)
+			assert.Equal(t, 1, createdThread.MessageCount)
+			require.Len(t, createdThread.Messages, 1)
+
+			op := createdThread.Messages[0]
+			assert.Equal(t, opMsg.Text, op.Text)
+			assert.Equal(t, opMsg.Author.Id, op.Author.Id)
+			assert.Empty(t, op.Attachments) // No attachments added
+			assert.Equal(t, threadID, op.ThreadId)
+
+			assert.WithinDuration(t, creationTimeStart, createdThread.LastBumped, 5*time.Second)
+			assert.Equal(t, createdAt, createdThread.LastBumped.UTC())
+		})
+
+		t.Run("BoardNotFound", func(t *testing.T) {
+			opMsg := domain.MessageCreationData{
+				Board:  "nonexistentboard",
+				Author: domain.User{Id: userID},
+				Text:   "Original Post Text",
+			}
+			_, _, err := storage.createThread(tx, domain.ThreadCreationData{
+				Title:     "Invalid Board Thread",
+				Board:     "nonexistentboard",
+				OpMessage: opMsg,
+			})
+			requireNotFoundError(t, err)
+		})
+
+		t.Run("CreatePinnedThread", func(t *testing.T) {
+			opMsg := domain.MessageCreationData{
+				Board:  boardShortName,
+				Author: domain.User{Id: userID},
+				Text:   "Pinned Post",
+			}
+			threadID, createdAt, err := storage.createThread(tx, domain.ThreadCreationData{
+				Title:     "Pinned Thread",
+				Board:     boardShortName,
+				IsPinned:  true,
+				OpMessage: opMsg,
+			})
+			require.NoError(t, err)
+
+			opMsg.ThreadId = threadID
+			opMsg.CreatedAt = &createdAt
+			opMsg.Board = boardShortName
+			_, err = storage.createMessage(tx, opMsg)
+			require.NoError(t, err)
+
+			thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+			require.NoError(t, err)
+			assert.True(t, thread.IsPinned)
+		})
+	})
+
+	t.Run("GetThread", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		t.Run("WithReplies", func(t *testing.T) {
+			opMsg := domain.MessageCreationData{
+				Board:  boardShortName,
+				Author: domain.User{Id: userID},
+				Text:   "Test OP Get",
+			}
+
+			threadID, createdAt, err := storage.createThread(tx, domain.ThreadCreationData{
+				Title:     "Test Get Thread WithReplies",
+				Board:     boardShortName,
+				OpMessage: opMsg,
+			})
+			require.NoError(t, err)
+
+			opMsg.ThreadId = threadID
+			opMsg.CreatedAt = &createdAt
+			opMsg.Board = boardShortName
+			_, err = storage.createMessage(tx, opMsg)
+			require.NoError(t, err)
+
+			user2 := createTestUser(t, tx, generateString(t)+"@example.com")
+			user3 := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			msgID1, err := storage.createMessage(tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: user2},
+				Text:     "Reply 1 Text",
+				ThreadId: threadID,
+			})
+			require.NoError(t, err)
+
+			time.Sleep(10 * time.Millisecond)
+			msgID2, err := storage.createMessage(tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: user3},
+				Text:     "Reply 2 Text",
+				ThreadId: threadID,
+			})
+			require.NoError(t, err)
+
+			thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "Test Get Thread WithReplies", thread.Title)
+			assert.Equal(t, boardShortName, thread.Board)
+			assert.Equal(t, 3, thread.MessageCount)
+			require.Len(t, thread.Messages, 3)
+
+			requireMessageOrder(t, thread.Messages, []string{"Test OP Get", "Reply 1 Text", "Reply 2 Text"})
+
+			op := thread.Messages[0]
+			assert.Equal(t, threadID, op.ThreadId)
+			assert.Empty(t, op.Attachments) // No attachments added
+
+			reply1 := thread.Messages[1]
+			assert.Equal(t, msgID1, reply1.Id)
+			require.NotNil(t, reply1.ThreadId)
+			assert.Equal(t, threadID, reply1.ThreadId)
+			assert.Empty(t, reply1.Attachments)
+			assert.Equal(t, user2, reply1.Author.Id)
+			assert.Len(t, reply1.Replies, 0)
+
+			reply2 := thread.Messages[2]
+			assert.Equal(t, msgID2, reply2.Id)
+			require.NotNil(t, reply2.ThreadId)
+			assert.Equal(t, threadID, reply2.ThreadId)
+			assert.Nil(t, reply2.Attachments)
+			assert.Equal(t, user3, reply2.Author.Id)
+			assert.Len(t, reply2.Replies, 0)
+
+			assert.Equal(t, reply2.CreatedAt, thread.LastBumped)
+		})
+
+		t.Run("RepliesToMessages", func(t *testing.T) {
+			opMsg := domain.MessageCreationData{
+				Board:  boardShortName,
+				Author: domain.User{Id: userID},
+				Text:   "OP for replies test",
+			}
+
+			threadID, createdAt, err := storage.createThread(tx, domain.ThreadCreationData{
+				Title:     "Thread With Message Replies",
+				Board:     boardShortName,
+				OpMessage: opMsg,
+			})
+			require.NoError(t, err)
+
+			opMsg.ThreadId = threadID
+			opMsg.CreatedAt = &createdAt
+			opMsg.Board = boardShortName
+			_, err = storage.createMessage(tx, opMsg)
+			require.NoError(t, err)
+
+			user2 := createTestUser(t, tx, generateString(t)+"@example.com")
+			user3 := createTestUser(t, tx, generateString(t)+"@example.com")
+
+			msgID1, err := storage.createMessage(tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: user2},
+				Text:     "First reply",
+				ThreadId: threadID,
+			})
+			require.NoError(t, err)
+
+			msgID2, err := storage.createMessage(tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: user3},
+				Text:     "Reply to first reply",
+				ThreadId: threadID,
+				ReplyTo: &domain.Replies{
+					{
+						FromThreadId: threadID,
+						To:           msgID1,
+						ToThreadId:   threadID,
+						CreatedAt:    time.Now().UTC(),
+					},
+				},
+			})
+			require.NoError(t, err)
+
+			thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+			require.NoError(t, err)
+			require.Len(t, thread.Messages, 3)
+
+			var firstReply *domain.Message
+			for i := range thread.Messages {
+				if thread.Messages[i].Id == msgID1 {
+					firstReply = thread.Messages[i]
+					break
+				}
+			}
+
+			require.NotNil(t, firstReply)
+			require.Len(t, firstReply.Replies, 1)
+			assert.Equal(t, msgID2, firstReply.Replies[0].From)
+			assert.Equal(t, msgID1, firstReply.Replies[0].To)
+		})
+
+		t.Run("OnlyOP", func(t *testing.T) {
+			opMsg := domain.MessageCreationData{
+				Board:  boardShortName,
+				Author: domain.User{Id: userID},
+				Text:   "Lonely OP",
+			}
+
+			threadID, createdAt, err := storage.createThread(tx, domain.ThreadCreationData{
+				Title:     "Only OP Thread",
+				Board:     boardShortName,
+				OpMessage: opMsg,
+			})
+			require.NoError(t, err)
+
+			opMsg.ThreadId = threadID
+			opMsg.CreatedAt = &createdAt
+			opMsg.Board = boardShortName
+			_, err = storage.createMessage(tx, opMsg)
+			require.NoError(t, err)
+
+			thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "Only OP Thread", thread.Title)
+			assert.Equal(t, boardShortName, thread.Board)
+			assert.Equal(t, 1, thread.MessageCount)
+			require.Len(t, thread.Messages, 1)
+
+			op := thread.Messages[0]
+			assert.Equal(t, "Lonely OP", op.Text)
+			assert.Equal(t, createdAt, thread.LastBumped.UTC())
+		})
+
+		t.Run("NotFound", func(t *testing.T) {
+			_, err := storage.getThread(tx, boardShortName, -999, 1)
+			requireNotFoundError(t, err)
+		})
+	})
+
+	t.Run("DeleteThread", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		t.Run("NotFoundThread", func(t *testing.T) {
+			err := storage.deleteThread(tx, boardShortName, -999)
+			requireNotFoundError(t, err)
+		})
+
+		t.Run("NotFoundBoard", func(t *testing.T) {
+			opMsg := domain.MessageCreationData{
+				Board:  boardShortName,
+				Author: domain.User{Id: userID},
+				Text:   "Temp OP",
+			}
+			threadID, createdAt, err := storage.createThread(tx, domain.ThreadCreationData{
+				Title:     "Temp Thread",
+				Board:     boardShortName,
+				OpMessage: opMsg,
+			})
+			require.NoError(t, err)
+
+			opMsg.ThreadId = threadID
+			opMsg.CreatedAt = &createdAt
+			opMsg.Board = boardShortName
+			_, err = storage.createMessage(tx, opMsg)
+			require.NoError(t, err)
+
+			err = storage.deleteThread(tx, "nonexistentboard", threadID)
+			requireNotFoundError(t, err)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			opMsg := domain.MessageCreationData{
+				Board:  boardShortName,
+				Author: domain.User{Id: userID},
+				Text:   "Test OP Delete",
+			}
+
+			threadID, createdAt, err := storage.createThread(tx, domain.ThreadCreationData{
+				Title:     "Test Delete Thread",
+				Board:     boardShortName,
+				OpMessage: opMsg,
+			})
+			require.NoError(t, err)
+
+			opMsg.ThreadId = threadID
+			opMsg.CreatedAt = &createdAt
+			opMsg.Board = boardShortName
+			opMsgID, err := storage.createMessage(tx, opMsg)
+			require.NoError(t, err)
+
+			reply1 := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "Reply 1 Delete",
+				ThreadId: threadID,
+			})
+			reply2 := createTestMessage(t, tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     "Reply 2 Delete",
+				ThreadId: threadID,
+				ReplyTo: &domain.Replies{
+					{
+						FromThreadId: threadID,
+						To:           opMsgID,
+						ToThreadId:   threadID,
+					},
+				},
+			})
+
+			// Add attachments to messages
+			attachments := getRandomAttachments(t)
+			err = storage.addAttachments(tx, boardShortName, threadID, reply1, attachments)
+			require.NoError(t, err)
+
+			// Get file IDs before deletion
+			attachmentsBefore, err := storage.getMessageAttachments(tx, boardShortName, threadID, reply1)
+			require.NoError(t, err)
+			var fileIDs []int64
+			for _, att := range attachmentsBefore {
+				fileIDs = append(fileIDs, att.FileId)
+			}
+
+			err = storage.deleteThread(tx, boardShortName, threadID)
+			require.NoError(t, err)
+
+			_, err = storage.getThread(tx, boardShortName, threadID, 1)
+			requireNotFoundError(t, err)
+
+			_, err = storage.getMessage(tx, boardShortName, threadID, opMsgID)
+			requireNotFoundError(t, err)
+			_, err = storage.getMessage(tx, boardShortName, threadID, reply1)
+			requireNotFoundError(t, err)
+			_, err = storage.getMessage(tx, boardShortName, threadID, reply2)
+			requireNotFoundError(t, err)
+
+			// Verify that cascading deletes removed related data
+			replies, err := storage.getMessageRepliesFrom(tx, boardShortName, threadID, opMsgID)
+			require.NoError(t, err)
+			assert.Empty(t, replies, "Replies should be deleted via cascade")
+
+			attachments2, err := storage.getMessageAttachments(tx, boardShortName, threadID, opMsgID)
+			require.NoError(t, err)
+			assert.Empty(t, attachments2, "Attachments should be deleted via cascade")
+
+			// Verify files are deleted from files table
+			for _, fileID := range fileIDs {
+				var count int
+				err = tx.QueryRow("SELECT COUNT(*) FROM files WHERE id = $1", fileID).Scan(&count)
+				require.NoError(t, err)
+				assert.Equal(t, 0, count, "File %d should be deleted from files table", fileID)
+			}
+		})
+	})
+
+	t.Run("LastModifiedAt", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		opMsg := domain.MessageCreationData{
+			Board:  boardShortName,
+			Author: domain.User{Id: userID},
+			Text:   "OP for last_modified test",
+		}
+
+		threadID, createdAt, err := storage.createThread(tx, domain.ThreadCreationData{
+			Title:     "LastModifiedAt Test",
+			Board:     boardShortName,
+			OpMessage: opMsg,
+		})
+		require.NoError(t, err)
+
+		opMsg.ThreadId = threadID
+		opMsg.CreatedAt = &createdAt
+		opMsg.Board = boardShortName
+		_, err = storage.createMessage(tx, opMsg)
+		require.NoError(t, err)
+
+		threadAfterCreate, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		assert.False(t, threadAfterCreate.LastModifiedAt.IsZero(), "LastModifiedAt should be set after creation")
+		assert.Equal(t, threadAfterCreate.LastBumped, threadAfterCreate.LastModifiedAt,
+			"LastModifiedAt should equal LastBumped after initial creation")
+
+		// Fill up to bump limit so LastBumped stops updating
+		bumpLimit := storage.cfg.Public.BumpLimit
+		for i := 0; i < bumpLimit; i++ {
+			time.Sleep(10 * time.Millisecond)
+			_, err := storage.createMessage(tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     fmt.Sprintf("Reply %d", i+1),
+				ThreadId: threadID,
+			})
+			require.NoError(t, err)
+		}
+
+		threadAtLimit, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		lastBumpAtLimit := threadAtLimit.LastBumped
+		lastModifiedAtLimit := threadAtLimit.LastModifiedAt
+
+		// Post another message past the bump limit
+		time.Sleep(10 * time.Millisecond)
+		msgOverLimit := createTestMessage(t, tx, domain.MessageCreationData{
+			Board:    boardShortName,
+			Author:   domain.User{Id: userID},
+			Text:     "Reply over limit",
+			ThreadId: threadID,
+		})
+
+		threadOverLimit, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+
+		// LastBumped should NOT have changed (bump limit reached)
+		assert.Equal(t, lastBumpAtLimit.UTC(), threadOverLimit.LastBumped.UTC(),
+			"LastBumped should freeze after bump limit")
+
+		// LastModifiedAt SHOULD have changed (always updates)
+		assert.True(t, threadOverLimit.LastModifiedAt.After(lastModifiedAtLimit),
+			"LastModifiedAt should still update past bump limit")
+
+		// Delete a message and verify LastModifiedAt updates
+		time.Sleep(10 * time.Millisecond)
+		lastModifiedBeforeDelete := threadOverLimit.LastModifiedAt
+
+		err = storage.deleteMessage(tx, boardShortName, threadID, msgOverLimit)
+		require.NoError(t, err)
+
+		threadAfterDelete, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+
+		assert.True(t, threadAfterDelete.LastModifiedAt.After(lastModifiedBeforeDelete),
+			"LastModifiedAt should update after message deletion")
+	})
+
+	t.Run("BumpLimit", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		boardShortName := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, boardShortName)
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		opMsg := domain.MessageCreationData{
+			Board:  boardShortName,
+			Author: domain.User{Id: userID},
+			Text:   "OP for bump test",
+		}
+
+		threadID, createdAt, err := storage.createThread(tx, domain.ThreadCreationData{
+			Title:     "Bump Limit Test",
+			Board:     boardShortName,
+			OpMessage: opMsg,
+		})
+		require.NoError(t, err)
+
+		opMsg.ThreadId = threadID
+		opMsg.CreatedAt = &createdAt
+		opMsg.Board = boardShortName
+		_, err = storage.createMessage(tx, opMsg)
+		require.NoError(t, err)
+
+		bumpLimit := storage.cfg.Public.BumpLimit
+		require.Greater(t, bumpLimit, 0)
+
+		for i := 0; i < bumpLimit-1; i++ {
+			_, err := storage.createMessage(tx, domain.MessageCreationData{
+				Board:    boardShortName,
+				Author:   domain.User{Id: userID},
+				Text:     fmt.Sprintf("Reply %d", i+1),
+				ThreadId: threadID,
+			})
+			require.NoError(t, err)
+		}
+
+		threadBefore, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		require.Equal(t, bumpLimit, threadBefore.MessageCount)
+		lastBumpBefore := threadBefore.LastBumped
+
+		msgAtLimit, err := storage.createMessage(tx, domain.MessageCreationData{
+			Board:    boardShortName,
+			Author:   domain.User{Id: userID},
+			Text:     fmt.Sprintf("Reply %d (at limit)", bumpLimit),
+			ThreadId: threadID,
+		})
+		require.NoError(t, err)
+
+		createdMsgAtLimit, err := storage.getMessage(tx, boardShortName, threadID, msgAtLimit)
+		require.NoError(t, err)
+
+		threadAtLimit, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, bumpLimit+1, threadAtLimit.MessageCount)
+		assert.Equal(t, createdMsgAtLimit.CreatedAt, threadAtLimit.LastBumped)
+		assert.True(t, threadAtLimit.LastBumped.After(lastBumpBefore))
+		lastBumpAtLimit := threadAtLimit.LastBumped
+
+		_ = createTestMessage(t, tx, domain.MessageCreationData{
+			Board:    boardShortName,
+			Author:   domain.User{Id: userID},
+			Text:     "Reply over limit",
+			ThreadId: threadID,
+		})
+
+		threadOverLimit, err := storage.getThread(tx, boardShortName, threadID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, bumpLimit+2, threadOverLimit.MessageCount)
+		assert.Equal(t, lastBumpAtLimit.UTC(), threadOverLimit.LastBumped.UTC())
+	})
+}
+
+func TestUpdateThreadTitle(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	opID := createTestUser(t, tx, generateString(t)+"@example.com")
+	modID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Tpyo", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: opID}, Text: "OP"},
+	})
+
+	require.NoError(t, storage.updateThreadTitle(tx, boardShortName, threadID, "Typo", opID, false))
+	require.NoError(t, storage.updateThreadTitle(tx, boardShortName, threadID, "Typo", opID, false), "unchanged title is a no-op")
+	require.NoError(t, storage.updateThreadTitle(tx, boardShortName, threadID, "Fixed typo", modID, true))
+
+	thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ThreadTitle("Fixed typo"), thread.Title)
+
+	edits, err := storage.getThreadTitleEdits(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	require.Len(t, edits, 2)
+	assert.Equal(t, domain.ThreadTitle("Tpyo"), edits[0].OldTitle)
+	assert.Equal(t, domain.ThreadTitle("Typo"), edits[0].NewTitle)
+	assert.Equal(t, opID, *edits[0].EditedBy)
+	assert.False(t, edits[0].ByModerator)
+	assert.Equal(t, domain.ThreadTitle("Fixed typo"), edits[1].NewTitle)
+	assert.Equal(t, modID, *edits[1].EditedBy)
+	assert.True(t, edits[1].ByModerator)
+	assert.False(t, edits[1].EditedAt.IsZero())
+
+	err = storage.updateThreadTitle(tx, boardShortName, threadID+1000, "Missing", opID, false)
+	requireNotFoundError(t, err)
+}
+
+func TestThreadOpOnly(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	opID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Updates", Board: boardShortName, OpOnly: true,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: opID}, Text: "OP"},
+	})
+
+	opOnly, opAuthor, err := storage.getThreadOpOnly(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.True(t, opOnly)
+	require.NotNil(t, opAuthor)
+	assert.Equal(t, opID, *opAuthor)
+
+	thread, err := storage.getThread(tx, boardShortName, threadID, 1)
+	require.NoError(t, err)
+	assert.True(t, thread.OpOnly)
+
+	newStatus, err := storage.toggleOpOnlyStatus(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.False(t, newStatus)
+	opOnly, _, err = storage.getThreadOpOnly(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.False(t, opOnly)
+
+	_, err = storage.toggleOpOnlyStatus(tx, boardShortName, threadID+1000)
+	requireNotFoundError(t, err)
+	_, _, err = storage.getThreadOpOnly(tx, boardShortName, threadID+1000)
+	requireNotFoundError(t, err)
+}
+
+func TestClaimBumpLimitNotice(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	threadID, _ := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Long thread", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+	})
+	reply := domain.MessageCreationData{Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: userID}, Text: "reply"}
+
+	// The OP and replies up to the limit still bump
+	for range storage.cfg.Public.BumpLimit - 1 {
+		createTestMessage(t, tx, reply)
+	}
+	claimed, err := storage.claimBumpLimitNotice(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.False(t, claimed, "the thread still bumps")
+
+	createTestMessage(t, tx, reply)
+	claimed, err = storage.claimBumpLimitNotice(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = storage.claimBumpLimitNotice(tx, boardShortName, threadID)
+	require.NoError(t, err)
+	assert.False(t, claimed, "the notice is due only once")
+
+	// The system account exists in every database
+	noticeID := createTestMessage(t, tx, domain.MessageCreationData{
+		Board: boardShortName, ThreadId: threadID, Author: domain.User{Id: domain.SystemUserId}, Text: "Bump limit reached",
+	})
+	notice, err := storage.getMessage(tx, boardShortName, threadID, noticeID)
+	require.NoError(t, err)
+	assert.True(t, notice.Author.IsSystem())
+
+	claimed, err = storage.claimBumpLimitNotice(tx, boardShortName, threadID+1000)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
+
+func TestSetThreadSuccessor(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	newThread := func(title domain.ThreadTitle) domain.ThreadId {
+		id, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: title, Board: boardShortName,
+			OpMessage: domain.MessageCreationData{Author: domain.User{Id: userID}, Text: "OP"},
+		})
+		return id
+	}
+	oldID := newThread("Part 1")
+	newID := newThread("Part 2")
+	otherID := newThread("Part 2, again")
+
+	require.NoError(t, storage.setThreadSuccessor(tx, boardShortName, oldID, newID, userID))
+
+	old, err := storage.getThreadMetadata(tx, boardShortName, oldID)
+	require.NoError(t, err)
+	require.NotNil(t, old.SuccessorId)
+	assert.Equal(t, newID, *old.SuccessorId)
+	assert.Nil(t, old.PredecessorId)
+
+	successor, err := storage.getThreadMetadata(tx, boardShortName, newID)
+	require.NoError(t, err)
+	require.NotNil(t, successor.PredecessorId)
+	assert.Equal(t, oldID, *successor.PredecessorId)
+	assert.Nil(t, successor.SuccessorId)
+
+	t.Run("replacing the successor", func(t *testing.T) {
+		require.NoError(t, storage.setThreadSuccessor(tx, boardShortName, oldID, otherID, userID))
+
+		old, err := storage.getThreadMetadata(tx, boardShortName, oldID)
+		require.NoError(t, err)
+		require.NotNil(t, old.SuccessorId)
+		assert.Equal(t, otherID, *old.SuccessorId)
+
+		replaced, err := storage.getThreadMetadata(tx, boardShortName, newID)
+		require.NoError(t, err)
+		assert.Nil(t, replaced.PredecessorId)
+	})
+
+	t.Run("a thread continues only one thread", func(t *testing.T) {
+		err := storage.setThreadSuccessor(tx, boardShortName, newID, otherID, userID)
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusConflict, e.StatusCode)
+	})
+
+	t.Run("unknown thread", func(t *testing.T) {
+		err := storage.setThreadSuccessor(tx, boardShortName, oldID, otherID+1000, userID)
+		requireNotFoundError(t, err)
+	})
+}
+
+func TestGetThreadRange(t *testing.T) {
+	tx, cleanup := beginTx(t)
+	defer cleanup()
+
+	boardShortName := domain.BoardShortName(generateString(t))
+	createTestBoard(t, tx, boardShortName)
+	userID := createTestUser(t, tx, generateString(t)+"@example.com")
+	author := domain.User{Id: userID}
+	threadID, opID := createTestThread(t, tx, domain.ThreadCreationData{
+		Title: "Range", Board: boardShortName,
+		OpMessage: domain.MessageCreationData{Author: author, Text: "OP"},
+	})
+	for i := 0; i < 5; i++ {
+		createTestMessage(t, tx, domain.MessageCreationData{Board: boardShortName, ThreadId: threadID, Author: author, Text: "reply"})
+	}
+
+	t.Run("includes the OP and the requested messages", func(t *testing.T) {
+		thread, err := storage.getThreadRange(tx, boardShortName, threadID, 3, 4)
+		require.NoError(t, err)
+		require.Len(t, thread.Messages, 3)
+		assert.Equal(t, opID, thread.Messages[0].Id)
+		assert.Equal(t, domain.MsgId(3), thread.Messages[1].Id)
+		assert.Equal(t, domain.MsgId(4), thread.Messages[2].Id)
+		assert.Equal(t, domain.ThreadTitle("Range"), thread.Title)
+		assert.Equal(t, 6, thread.Pagination.TotalCount)
+	})
+
+	t.Run("range past the end", func(t *testing.T) {
+		thread, err := storage.getThreadRange(tx, boardShortName, threadID, 5, 100)
+		require.NoError(t, err)
+		require.Len(t, thread.Messages, 3)
+		assert.Equal(t, domain.MsgId(6), thread.Messages[2].Id)
+	})
+
+	t.Run("missing thread", func(t *testing.T) {
+		_, err := storage.getThreadRange(tx, boardShortName, threadID+1000, 1, 10)
+		requireNotFoundError(t, err)
+	})
+}
+
+// TestCreateThreadWithCleanup verifies that createThreadWithCleanup enforces
+// the max thread count by deleting the oldest non-pinned threads.
+func TestCreateThreadWithCleanup(t *testing.T) {
+	// helper to create a thread with OP message via createThreadWithCleanup
+	createFullThread := func(t *testing.T, tx *sql.Tx, board domain.BoardShortName, userID domain.UserId, title string, maxCount *int, isPinned bool, createdAt *time.Time) domain.ThreadId {
+		t.Helper()
+		opMsg := domain.MessageCreationData{
+			Board:  board,
+			Author: domain.User{Id: userID},
+			Text:   "OP for " + title,
+		}
+		if createdAt != nil {
+			opMsg.CreatedAt = createdAt
+		}
+		threadID, ts, err := storage.createThreadWithCleanup(tx, domain.ThreadCreationData{
+			Title:     domain.ThreadTitle(title),
+			Board:     board,
+			IsPinned:  isPinned,
+			OpMessage: opMsg,
+		}, maxCount)
+		require.NoError(t, err)
+		opMsg.ThreadId = threadID
+		opMsg.CreatedAt = &ts
+		opMsg.Board = board
+		_, err = storage.createMessage(tx, opMsg)
+		require.NoError(t, err)
+		return threadID
+	}
+
+	t.Run("DeletesOldestWhenOverLimit", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		board := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, board)
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		maxCount := 3
+
+		// Create 3 threads (at limit)
+		t1 := time.Now().UTC().Add(-3 * time.Second).Round(time.Microsecond)
+		t2 := time.Now().UTC().Add(-2 * time.Second).Round(time.Microsecond)
+		t3 := time.Now().UTC().Add(-1 * time.Second).Round(time.Microsecond)
+
+		thread1 := createFullThread(t, tx, board, userID, "Thread 1", &maxCount, false, &t1)
+		thread2 := createFullThread(t, tx, board, userID, "Thread 2", &maxCount, false, &t2)
+		_ = createFullThread(t, tx, board, userID, "Thread 3", &maxCount, false, &t3)
+
+		count, err := storage.threadCount(tx, board)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		// Create a 4th thread — should delete thread1 (oldest bumped)
+		_ = createFullThread(t, tx, board, userID, "Thread 4", &maxCount, false, nil)
+
+		count, err = storage.threadCount(tx, board)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		_, err = storage.getThread(tx, board, thread1, 1)
+		requireNotFoundError(t, err)
+
+		// thread2 should still exist
+		_, err = storage.getThread(tx, board, thread2, 1)
+		require.NoError(t, err)
+	})
+
+	t.Run("PinnedThreadsNotDeleted", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		board := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, board)
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		maxCount := 2
+
+		// Create a pinned thread (oldest) and a regular thread
+		t1 := time.Now().UTC().Add(-2 * time.Second).Round(time.Microsecond)
+		t2 := time.Now().UTC().Add(-1 * time.Second).Round(time.Microsecond)
+
+		pinnedThread := createFullThread(t, tx, board, userID, "Pinned", &maxCount, true, &t1)
+		regularThread := createFullThread(t, tx, board, userID, "Regular", &maxCount, false, &t2)
+
+		// Create a 3rd thread — should delete regularThread, not the pinned one
+		_ = createFullThread(t, tx, board, userID, "New Thread", &maxCount, false, nil)
+
+		// Pinned thread must survive
+		_, err := storage.getThread(tx, board, pinnedThread, 1)
+		require.NoError(t, err)
+
+		// Regular thread was oldest non-pinned, should be gone
+		_, err = storage.getThread(tx, board, regularThread, 1)
+		requireNotFoundError(t, err)
+	})
+
+	t.Run("NilMaxCountSkipsCleanup", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		board := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, board)
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		// Create several threads with no limit
+		for i := 0; i < 5; i++ {
+			createFullThread(t, tx, board, userID, fmt.Sprintf("Unlimited %d", i), nil, false, nil)
+		}
+
+		count, err := storage.threadCount(tx, board)
+		require.NoError(t, err)
+		assert.Equal(t, 5, count)
+	})
+
+	t.Run("AllPinnedOverLimitStopsGracefully", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		board := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, board)
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		maxCount := 1
+
+		// Create 2 pinned threads — over limit, but nothing deletable
+		pinned1 := createFullThread(t, tx, board, userID, "Pinned 1", &maxCount, true, nil)
+		pinned2 := createFullThread(t, tx, board, userID, "Pinned 2", &maxCount, true, nil)
+
+		// Create another thread — cleanup should not fail despite being over limit
+		newThread := createFullThread(t, tx, board, userID, "New Thread", &maxCount, false, nil)
+
+		// All three threads should exist
+		_, err := storage.getThread(tx, board, pinned1, 1)
+		require.NoError(t, err)
+		_, err = storage.getThread(tx, board, pinned2, 1)
+		require.NoError(t, err)
+		_, err = storage.getThread(tx, board, newThread, 1)
+		require.NoError(t, err)
+
+		count, err := storage.threadCount(tx, board)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("DeletesMultipleWhenFarOverLimit", func(t *testing.T) {
+		tx, cleanup := beginTx(t)
+		defer cleanup()
+
+		board := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, board)
+		userID := createTestUser(t, tx, generateString(t)+"@example.com")
+
+		// Create 5 threads with no limit
+		for i := 0; i < 5; i++ {
+			ts := time.Now().UTC().Add(time.Duration(-5+i) * time.Second).Round(time.Microsecond)
+			createFullThread(t, tx, board, userID, fmt.Sprintf("Thread %d", i), nil, false, &ts)
+		}
+
+		// Now create one more with limit=2 — should delete 4 oldest, keeping 2 total
+		maxCount := 2
+		createFullThread(t, tx, board, userID, "Final Thread", &maxCount, false, nil)
+
+		count, err := storage.threadCount(tx, board)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingUploads(t *testing.T) {
+	newUpload := func(t *testing.T, board domain.BoardShortName, userId domain.UserId, createdAt time.Time) domain.PendingUpload {
+		return domain.PendingUpload{
+			Token:     generateString(t),
+			Board:     board,
+			UserId:    userId,
+			File:      getRandomAttachments(t)[0].File,
+			CreatedAt: createdAt,
+		}
+	}
+	requireConflict := func(t *testing.T, err error) {
+		t.Helper()
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusConflict, e.StatusCode)
+	}
+
+	t.Run("message claims its uploads once", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		board := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, board)
+		author := domain.User{Id: createTestUser(t, tx, generateString(t)+"@upload.com")}
+		threadId, _ := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Uploads", Board: board,
+			OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+		})
+
+		upload := newUpload(t, board, author.Id, time.Now())
+		require.NoError(t, storage.savePendingUpload(tx, upload))
+
+		reply := domain.MessageCreationData{Board: board, ThreadId: threadId, Author: author, UploadTokens: []domain.UploadToken{upload.Token}}
+		msgId := createTestMessage(t, tx, reply)
+		require.NoError(t, storage.claimUploads(tx, reply, msgId))
+
+		attachments, err := storage.getMessageAttachments(tx, board, threadId, msgId)
+		require.NoError(t, err)
+		require.Len(t, attachments, 1)
+		assert.Equal(t, upload.File.FilePath, attachments[0].File.FilePath)
+
+		again := createTestMessage(t, tx, reply)
+		requireConflict(t, storage.claimUploads(tx, reply, again))
+	})
+
+	t.Run("only the uploader can claim on the same board", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		board := domain.BoardShortName(generateString(t))
+		otherBoard := domain.BoardShortName(generateString(t))
+		createTestBoard(t, tx, board)
+		createTestBoard(t, tx, otherBoard)
+		author := domain.User{Id: createTestUser(t, tx, generateString(t)+"@upload.com")}
+		other := domain.User{Id: createTestUser(t, tx, generateString(t)+"@upload.com")}
+		threadId, opId := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Uploads", Board: board,
+			OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+		})
+		otherThreadId, otherOpId := createTestThread(t, tx, domain.ThreadCreationData{
+			Title: "Elsewhere", Board: otherBoard,
+			OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+		})
+
+		upload := newUpload(t, board, author.Id, time.Now())
+		require.NoError(t, storage.savePendingUpload(tx, upload))
+		tokens := []domain.UploadToken{upload.Token}
+
+		requireConflict(t, storage.claimUploads(tx, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: other, UploadTokens: tokens}, opId))
+		requireConflict(t, storage.claimUploads(tx, domain.MessageCreationData{Board: otherBoard, ThreadId: otherThreadId, Author: author, UploadTokens: tokens}, otherOpId))
+		require.NoError(t, storage.claimUploads(tx, domain.MessageCreationData{Board: board, ThreadId: threadId, Author: author, UploadTokens: tokens}, opId))
+	})
+
+	t.Run("failed post keeps its uploads", func(t *testing.T) {
+		ctx := context.Background()
+		board := domain.BoardShortName(generateString(t))
+		createTestBoard(t, storage.db, board)
+		defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+		author := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@upload.com")}
+		threadId, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+			Title: "Retry", Board: board,
+			OpMessage: domain.MessageCreationData{Author: author, Text: "op"},
+		})
+
+		upload := newUpload(t, board, author.Id, time.Now())
+		require.NoError(t, storage.SavePendingUpload(ctx, upload))
+
+		reply := domain.MessageCreationData{Board: board, ThreadId: threadId, Author: author, Text: "reply", UploadTokens: []domain.UploadToken{upload.Token, "unknown"}}
+		_, err := storage.CreateMessage(ctx, reply, nil)
+		requireConflict(t, err)
+
+		reply.UploadTokens = []domain.UploadToken{upload.Token}
+		msgId, err := storage.CreateMessage(ctx, reply, getRandomAttachments(t))
+		require.NoError(t, err)
+
+		msg, err := storage.GetMessage(ctx, board, threadId, msgId)
+		require.NoError(t, err)
+		assert.Len(t, msg.Attachments, 3)
+	})
+
+	t.Run("expired uploads lose their files", func(t *testing.T) {
+		ctx := context.Background()
+		board := domain.BoardShortName(generateString(t))
+		createTestBoard(t, storage.db, board)
+		defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+		userId := createTestUser(t, storage.db, generateString(t)+"@upload.com")
+
+		expired := newUpload(t, board, userId, time.Now().Add(-2*time.Hour))
+		fresh := newUpload(t, board, userId, time.Now())
+		require.NoError(t, storage.SavePendingUpload(ctx, expired))
+		require.NoError(t, storage.SavePendingUpload(ctx, fresh))
+
+		count, err := storage.DeleteExpiredUploads(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, count, int64(1))
+		_, err = storage.DeleteOrphanedFileRecords(ctx)
+		require.NoError(t, err)
+
+		paths, err := storage.GetAllFilePaths(ctx)
+		require.NoError(t, err)
+		assert.NotContains(t, paths, expired.File.FilePath)
+		assert.Contains(t, paths, fresh.File.FilePath)
+	})
+}
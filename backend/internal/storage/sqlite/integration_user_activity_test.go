@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUserPosts(t *testing.T) {
+	ctx := context.Background()
+
+	// GetUserPosts reads outside of a transaction, so the data is committed.
+	boardA := domain.BoardShortName(generateString(t))
+	boardB := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, boardA)
+	createTestBoard(t, storage.db, boardB)
+	defer func() {
+		require.NoError(t, storage.DeleteBoard(ctx, boardA))
+		require.NoError(t, storage.DeleteBoard(ctx, boardB))
+	}()
+	user := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@posts.com")}
+	other := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@posts.com")}
+
+	base := time.Now().UTC().Add(-time.Hour)
+	at := func(minutes int) *time.Time {
+		ts := base.Add(time.Duration(minutes) * time.Minute)
+		return &ts
+	}
+	threadA, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Thread A", Board: boardA,
+		OpMessage: domain.MessageCreationData{Author: user, Text: "op a", CreatedAt: at(1)},
+	})
+	threadB, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Thread B", Board: boardB,
+		OpMessage: domain.MessageCreationData{Author: other, Text: "op b", CreatedAt: at(2)},
+	})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: boardB, ThreadId: threadB, Author: user, Text: "reply b", CreatedAt: at(3)})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: boardA, ThreadId: threadA, Author: user, Text: "reply a", CreatedAt: at(4)})
+
+	t.Run("newest first with total", func(t *testing.T) {
+		posts, total, err := storage.GetUserPosts(ctx, user.Id, []domain.BoardShortName{boardA, boardB}, 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		require.Len(t, posts, 2)
+		assert.Equal(t, domain.MsgText("reply a"), posts[0].Text)
+		assert.Equal(t, domain.MsgText("reply b"), posts[1].Text)
+		assert.Equal(t, domain.ThreadTitle("Thread B"), posts[1].ThreadTitle)
+		assert.Equal(t, 1, posts[1].Page)
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		posts, total, err := storage.GetUserPosts(ctx, user.Id, []domain.BoardShortName{boardA, boardB}, 2, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		require.Len(t, posts, 1)
+		assert.Equal(t, boardA, posts[0].Board)
+		assert.Equal(t, threadA, posts[0].ThreadId)
+		assert.Equal(t, domain.MsgId(1), posts[0].Id)
+	})
+
+	t.Run("only given boards", func(t *testing.T) {
+		posts, total, err := storage.GetUserPosts(ctx, user.Id, []domain.BoardShortName{boardB}, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, posts, 1)
+		assert.Equal(t, domain.MsgText("reply b"), posts[0].Text)
+	})
+
+	t.Run("no boards", func(t *testing.T) {
+		posts, total, err := storage.GetUserPosts(ctx, user.Id, nil, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Empty(t, posts)
+		assert.NotNil(t, posts)
+	})
+}
+
+func TestGetUserStats(t *testing.T) {
+	ctx := context.Background()
+
+	board := domain.BoardShortName(generateString(t))
+	createTestBoard(t, storage.db, board)
+	defer func() { require.NoError(t, storage.DeleteBoard(ctx, board)) }()
+	user := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@stats.com")}
+	other := domain.User{Id: createTestUser(t, storage.db, generateString(t)+"@stats.com")}
+
+	t.Run("user without posts", func(t *testing.T) {
+		stats, err := storage.GetUserStats(ctx, user.Id)
+		require.NoError(t, err)
+		assert.Equal(t, domain.UserStats{}, stats)
+	})
+
+	first := time.Now().UTC().Add(-time.Hour).Round(time.Millisecond)
+	own, ownOp := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Own", Board: board,
+		OpMessage: domain.MessageCreationData{Author: user, Text: "op", CreatedAt: &first},
+	})
+	foreign, _ := createTestThread(t, storage.db, domain.ThreadCreationData{
+		Title: "Foreign", Board: board,
+		OpMessage: domain.MessageCreationData{Author: other, Text: "op"},
+	})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: own, Author: user, Text: "reply"})
+	createTestMessage(t, storage.db, domain.MessageCreationData{Board: board, ThreadId: foreign, Author: user, Text: "reply"})
+	require.NoError(t, storage.addAttachments(storage.db, board, own, ownOp, getRandomAttachments(t)))
+
+	t.Run("counts posts, threads and attachments", func(t *testing.T) {
+		stats, err := storage.GetUserStats(ctx, user.Id)
+		require.NoError(t, err)
+		assert.Equal(t, 3, stats.Posts)
+		assert.Equal(t, 1, stats.ThreadsStarted)
+		assert.Equal(t, 2, stats.Attachments)
+		require.NotNil(t, stats.FirstPostAt)
+		assert.WithinDuration(t, first, *stats.FirstPostAt, time.Millisecond)
+	})
+
+	t.Run("other users are not counted", func(t *testing.T) {
+		stats, err := storage.GetUserStats(ctx, other.Id)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.Posts)
+		assert.Equal(t, 1, stats.ThreadsStarted)
+		assert.Zero(t, stats.Attachments)
+	})
+}
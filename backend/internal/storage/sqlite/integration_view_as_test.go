@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewAsSessions(t *testing.T) {
+	t.Run("get user by id", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		userId := createTestUser(t, tx, "view_as_user@test.com")
+
+		user, err := storage.getUserById(tx, userId)
+		require.NoError(t, err)
+		assert.Equal(t, userId, user.Id)
+		assert.Equal(t, "test.com", user.EmailDomain)
+		assert.False(t, user.Admin)
+
+		_, err = storage.getUserById(tx, userId+1_000_000)
+		requireNotFoundError(t, err)
+	})
+
+	t.Run("save and list newest first", func(t *testing.T) {
+		tx, rollback := beginTx(t)
+		defer rollback()
+
+		adminId := createTestUser(t, tx, "view_as_admin@test.com")
+		userId := createTestUser(t, tx, "view_as_user@test.com")
+		expiresAt := time.Now().Add(15 * time.Minute).UTC().Truncate(time.Second)
+
+		firstId, err := storage.saveViewAsSession(tx, domain.ViewAsSession{AdminId: adminId, UserId: userId, Reason: "first", ExpiresAt: expiresAt})
+		require.NoError(t, err)
+		secondId, err := storage.saveViewAsSession(tx, domain.ViewAsSession{AdminId: adminId, UserId: userId, Reason: "second", ExpiresAt: expiresAt})
+		require.NoError(t, err)
+		assert.Greater(t, secondId, firstId)
+
+		sessions, err := storage.getViewAsSessions(tx, 2, 0)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.Equal(t, secondId, sessions[0].Id)
+		assert.Equal(t, "second", sessions[0].Reason)
+		assert.Equal(t, adminId, sessions[0].AdminId)
+		assert.Equal(t, userId, sessions[0].UserId)
+		assert.WithinDuration(t, expiresAt, sessions[0].ExpiresAt, time.Second)
+		assert.Equal(t, firstId, sessions[1].Id)
+
+		sessions, err = storage.getViewAsSessions(tx, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, firstId, sessions[0].Id)
+	})
+}
@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.MaintenanceStorage interface)
+// =========================================================================
+
+// GetMaintenanceWindow returns the scheduled maintenance window, 404 if none.
+func (s *Storage) GetMaintenanceWindow(ctx context.Context) (domain.MaintenanceWindow, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getMaintenanceWindow(q)
+}
+
+// SetMaintenanceWindow replaces the scheduled maintenance window.
+func (s *Storage) SetMaintenanceWindow(ctx context.Context, window domain.MaintenanceWindow) error {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.setMaintenanceWindow(q, window)
+}
+
+// DeleteMaintenanceWindow cancels the scheduled maintenance window, 404 if none.
+func (s *Storage) DeleteMaintenanceWindow(ctx context.Context) error {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.deleteMaintenanceWindow(q)
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+func (s *Storage) getMaintenanceWindow(q Querier) (domain.MaintenanceWindow, error) {
+	var window domain.MaintenanceWindow
+	var endsAt sql.NullTime
+	var createdBy sql.NullInt64
+	err := q.QueryRow(`
+		SELECT message, starts_at, ends_at, created_by, created_at
+		FROM maintenance_window
+		WHERE id = 1`,
+	).Scan(&window.Message, &window.StartsAt, &endsAt, &createdBy, &window.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return window, &internal_errors.ErrorWithStatusCode{Message: "No maintenance is scheduled", StatusCode: http.StatusNotFound}
+	}
+	if err != nil {
+		return window, fmt.Errorf("failed to get maintenance window: %w", err)
+	}
+	window.StartsAt = window.StartsAt.UTC()
+	window.CreatedAt = window.CreatedAt.UTC()
+	if endsAt.Valid {
+		t := endsAt.Time.UTC()
+		window.EndsAt = &t
+	}
+	if createdBy.Valid {
+		id := domain.UserId(createdBy.Int64)
+		window.CreatedBy = &id
+	}
+	return window, nil
+}
+
+func (s *Storage) setMaintenanceWindow(q Querier, window domain.MaintenanceWindow) error {
+	var endsAt any
+	if window.EndsAt != nil {
+		endsAt = window.EndsAt.UTC()
+	}
+	_, err := q.Exec(`
+		INSERT INTO maintenance_window (id, message, starts_at, ends_at, created_by, created_at)
+		VALUES (1, $1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET
+			message = EXCLUDED.message,
+			starts_at = EXCLUDED.starts_at,
+			ends_at = EXCLUDED.ends_at,
+			created_by = EXCLUDED.created_by,
+			created_at = EXCLUDED.created_at`,
+		window.Message, window.StartsAt.UTC(), endsAt, window.CreatedBy, window.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set maintenance window: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) deleteMaintenanceWindow(q Querier) error {
+	result, err := q.Exec(`DELETE FROM maintenance_window WHERE id = 1`)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{Message: "No maintenance is scheduled", StatusCode: http.StatusNotFound}
+	}
+	return nil
+}
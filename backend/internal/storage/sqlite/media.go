@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.MediaLookupStorage interface)
+// =========================================================================
+
+// GetMediaLocations returns the files and thumbnails with the given content
+// hash, see package pg.
+func (s *Storage) GetMediaLocations(ctx context.Context, sha256 string) ([]domain.MediaLocation, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	rows, err := q.Query(`
+		SELECT a.board, f.file_path
+		FROM files f
+		JOIN attachments a ON a.file_id = f.id
+		WHERE f.sha256 = $1
+		UNION
+		SELECT a.board, f.thumbnail_path
+		FROM files f
+		JOIN attachments a ON a.file_id = f.id
+		WHERE f.thumbnail_sha256 = $1`,
+		sha256,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch media locations: %w", err)
+	}
+	defer rows.Close()
+
+	locations := []domain.MediaLocation{}
+	for rows.Next() {
+		var loc domain.MediaLocation
+		if err := rows.Scan(&loc.Board, &loc.FilePath); err != nil {
+			return nil, fmt.Errorf("failed to scan media location: %w", err)
+		}
+		locations = append(locations, loc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating media locations: %w", err)
+	}
+	return locations, nil
+}
+
+// =========================================================================
+// Public Methods (satisfy the service.MessageStorage interface)
+// =========================================================================
+
+// GetMessageFileMetadata returns the stripped metadata of a message's
+// attachments, see package pg.
+func (s *Storage) GetMessageFileMetadata(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) ([]domain.FileMetadataReport, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	rows, err := q.Query(`
+		SELECT f.id, f.original_filename, m.camera_make, m.camera_model, m.software, m.has_gps
+		FROM attachments a
+		JOIN files f ON f.id = a.file_id
+		JOIN file_metadata m ON m.file_id = f.id
+		WHERE a.board = $1 AND a.thread_id = $2 AND a.message_id = $3
+		ORDER BY a.id`,
+		board, threadId, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch file metadata: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []domain.FileMetadataReport{}
+	for rows.Next() {
+		var r domain.FileMetadataReport
+		if err := rows.Scan(&r.FileId, &r.OriginalFilename, &r.CameraMake, &r.CameraModel, &r.Software, &r.HasGPS); err != nil {
+			return nil, fmt.Errorf("failed to scan file metadata: %w", err)
+		}
+		reports = append(reports, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating file metadata: %w", err)
+	}
+	return reports, nil
+}
+
+// =========================================================================
+// Public Methods (satisfy the service.DiskMonitorStorage interface)
+// =========================================================================
+
+// GetDatabaseSize returns the size of the database on disk in bytes.
+func (s *Storage) GetDatabaseSize(ctx context.Context) (int64, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var size int64
+	if err := q.QueryRow(`SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`).Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to fetch database size: %w", err)
+	}
+	return size, nil
+}
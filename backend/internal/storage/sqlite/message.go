@@ -0,0 +1,554 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.MessageStorage interface)
+// =========================================================================
+
+// CreateMessage serves as the public entry point for creating a new message.
+// It is responsible for wrapping the core message creation logic in a single,
+// atomic database transaction. This ensures that all related database operations
+// (updating board/thread metadata, inserting the message, attachments, and replies)
+// either succeed together or fail together, maintaining data integrity.
+func (s *Storage) CreateMessage(ctx context.Context, creationData domain.MessageCreationData, attachments domain.Attachments) (domain.MsgId, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	var msgID domain.MsgId
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		msgID, err = s.createMessage(tx, creationData)
+		if err != nil {
+			return err
+		}
+
+		// Link uploads made ahead of the post and add new attachments in the same transaction
+		if err := s.claimUploads(tx, creationData, msgID); err != nil {
+			return err
+		}
+		if len(attachments) > 0 {
+			if err := s.addAttachments(tx, creationData.Board, creationData.ThreadId, msgID, attachments); err != nil {
+				return err
+			}
+		}
+
+		return s.storeContentHash(tx, creationData.Board, creationData.ThreadId, msgID)
+	})
+	return msgID, err
+}
+
+// DeleteMessage is the public entry point for deleting a message.
+// It manages the transaction for this operation, ensuring that the board's
+// last activity is updated and the message is deleted atomically. The cascading
+// deletion of related attachments and replies is handled by the database schema.
+// The moderator's reason and the moderation log entry are recorded in the
+// same transaction; self-deletions by the author are kept out of the log.
+func (s *Storage) DeleteMessage(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		// Record first: the author is read from the row that is about to be deleted.
+		if err := s.recordMessageDeletion(tx, board, threadId, id, deletion); err != nil {
+			return err
+		}
+		if !deletion.ByAuthor {
+			if err := s.recordModeration(tx, domain.ModLogEntry{Board: board, Action: domain.ModLogDeleteMessage, Reason: deletion.Reason}); err != nil {
+				return err
+			}
+		}
+		return s.deleteMessage(tx, board, threadId, id)
+	})
+}
+
+// GetMessage is a read-only operation that fetches a complete message, including its
+// attachments and replies. Since it doesn't modify data, it doesn't need to
+// create its own transaction. It can use the main database connection pool (s.db)
+// as the Querier, allowing for concurrent reads.
+func (s *Storage) GetMessage(ctx context.Context, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+	// Delegate to the internal method, passing the main DB connection pool.
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getMessage(q, board, threadId, id)
+}
+
+// ResolvePostNumber returns the thread and message ids of a board-local post number.
+func (s *Storage) ResolvePostNumber(ctx context.Context, board domain.BoardShortName, postNumber domain.PostNumber) (domain.ThreadId, domain.MsgId, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.resolvePostNumber(q, board, postNumber)
+}
+
+// GetExistingMessages returns the links whose target message still exists on the board.
+func (s *Storage) GetExistingMessages(ctx context.Context, board domain.BoardShortName, links []domain.MessageLink) ([]domain.MessageLink, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getExistingMessages(q, board, links)
+}
+
+// GetLastMessageTime returns when the user last posted, or nil if they never did.
+func (s *Storage) GetLastMessageTime(ctx context.Context, userId domain.UserId) (*time.Time, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getLastMessageTime(q, userId)
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+// createMessage contains the core logic for inserting a new message and its related data.
+// It's unexported and accepts a Querier, allowing it to be run within a transaction
+// managed by a public method (like CreateMessage or CreateThread) or in a test.
+// Returns the message ID (which is per-thread sequential: 1, 2, 3...).
+func (s *Storage) createMessage(q Querier, creationData domain.MessageCreationData) (domain.MsgId, error) {
+	// Determine the creation timestamp for all related records in this operation.
+	// Use the provided timestamp if available (useful for testing or migrations).
+	createdAt := now()
+	if creationData.CreatedAt != nil {
+		createdAt = creationData.CreatedAt.UTC()
+	}
+
+	// Atomically update the parent board's last_activity timestamp and take the
+	// next board-local post number.
+	// The two-argument MAX is SQLite's GREATEST.
+	var postNumber domain.PostNumber
+	err := q.QueryRow(`
+	       	UPDATE boards SET
+				last_activity_at = MAX(last_activity_at, $1),
+				next_post_number = next_post_number + 1
+	       	WHERE short_name = $2
+			RETURNING next_post_number - 1
+			`,
+		createdAt, creationData.Board,
+	).Scan(&postNumber)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, &internal_errors.ErrorWithStatusCode{Message: "Board not found", StatusCode: http.StatusNotFound}
+		}
+		return -1, fmt.Errorf("failed to update board activity: %w", err)
+	}
+
+	// Update the parent thread's metadata (reply count and bump timestamp) and get the
+	// new message's ID (which equals next_message_id before increment).
+	// We use next_message_id instead of message_count to avoid PK violations when
+	// messages are deleted in the middle of a thread (creating gaps).
+	// We return (next_message_id - 1) to get the pre-increment value which is the actual message ID.
+	// Shadowbanned users never bump, otherwise the thread order would give them away.
+	var msgId int64
+	err = q.QueryRow(`
+	       UPDATE threads SET
+	           message_count = message_count + 1,
+	           next_message_id = next_message_id + 1,
+	           last_bumped_at = CASE
+	               WHEN message_count > $1 THEN last_bumped_at
+	               WHEN EXISTS (SELECT 1 FROM user_shadowbans WHERE board = $3 AND user_id = $5) THEN last_bumped_at
+	               ELSE $2 END,
+	           last_modified_at = $2
+	       WHERE board = $3 AND id = $4
+		   RETURNING next_message_id - 1
+		   `,
+		s.cfg.Public.BumpLimit, createdAt, creationData.Board, creationData.ThreadId, creationData.Author.Id,
+	).Scan(&msgId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, &internal_errors.ErrorWithStatusCode{Message: "Thread not found", StatusCode: http.StatusNotFound}
+		}
+		return -1, fmt.Errorf("failed to update thread: %w", err)
+	}
+
+	// Insert the message record.
+	// The message ID is per-thread sequential (1, 2, 3...) - id=1 is always OP.
+	_, err = q.Exec(`
+	       INSERT INTO messages (id, author_id, text, created_at, thread_id, updated_at, board, show_email_domain, post_number)
+	       VALUES ($1, $2, $3, $4, $5, $4, $6, $7, $8)`,
+		msgId, creationData.Author.Id, creationData.Text, createdAt, creationData.ThreadId, creationData.Board, creationData.ShowEmailDomain, postNumber,
+	)
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert message: %w", err)
+	}
+
+	// If this message is a reply to others, insert those relationships.
+	if creationData.ReplyTo != nil {
+		for _, reply := range *creationData.ReplyTo {
+			_, err := q.Exec(`
+			             INSERT INTO message_replies (board, sender_message_id, sender_thread_id, receiver_message_id, receiver_thread_id, created_at)
+			             VALUES ($1, $2, $3, $4, $5, $6)`,
+				creationData.Board, msgId, creationData.ThreadId, reply.To, reply.ToThreadId, createdAt,
+			)
+			if err != nil {
+				return -1, fmt.Errorf("failed to insert message reply relationship: %w", err)
+			}
+			if err := s.notifyReply(q, creationData.Board, creationData.ThreadId, msgId, creationData.Author.Id, reply, createdAt); err != nil {
+				return -1, err
+			}
+		}
+	}
+
+	return msgId, nil
+}
+
+// deleteMessage contains the core logic for removing a message record and updating
+// parent metadata. It is unexported and accepts a Querier.
+func (s *Storage) deleteMessage(q Querier, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) error {
+	// Collect file IDs BEFORE cascade delete (while attachments still exist)
+	ids, err := fileIDs(q, `board = $1 AND thread_id = $2 AND message_id = $3`, board, threadId, id)
+	if err != nil {
+		return err
+	}
+
+	// Update the board's last_activity timestamp to reflect the deletion.
+	deletedTs := now()
+	result, err := q.Exec(`
+	       UPDATE boards SET last_activity_at = MAX(last_activity_at, $1)
+	       WHERE short_name = $2`,
+		deletedTs, board,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update board activity on delete: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{Message: "Board not found", StatusCode: http.StatusNotFound}
+	}
+
+	// Delete the message. Foreign key constraints with ON DELETE CASCADE
+	// will handle the automatic deletion of related attachments and replies.
+	result, err = q.Exec("DELETE FROM messages WHERE board = $1 AND thread_id = $2 AND id = $3", board, threadId, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
+	}
+	if _, err := q.Exec(`
+		DELETE FROM notifications WHERE board = $1 AND thread_id = $2 AND message_id = $3`,
+		board, threadId, id,
+	); err != nil {
+		return fmt.Errorf("failed to delete notifications of message: %w", err)
+	}
+	if _, err := q.Exec(`
+		DELETE FROM message_bookmarks WHERE board = $1 AND thread_id = $2 AND message_id = $3`,
+		board, threadId, id,
+	); err != nil {
+		return fmt.Errorf("failed to delete bookmarks of message: %w", err)
+	}
+
+	// Decrement the thread's message count and update last_modified_at to reflect the deletion
+	_, err = q.Exec(`
+		UPDATE threads SET message_count = message_count - 1, last_modified_at = $3
+		WHERE board = $1 AND id = $2`,
+		board, threadId, deletedTs,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update thread message count: %w", err)
+	}
+
+	deleteFiles(q, ids, "board", board, "thread_id", threadId, "message_id", id)
+	return nil
+}
+
+// recordMessageDeletion stores the moderator's reason for a deleted message, or
+// marks it as deleted by its author.
+// Re-deleting the same message id (not possible in practice, ids are never reused)
+// would simply overwrite the previous record.
+func (s *Storage) recordMessageDeletion(q Querier, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId, deletion domain.MessageDeletionData) error {
+	_, err := q.Exec(`
+		INSERT INTO message_deletions (board, thread_id, message_id, reason, deleted_by, author_id, deleted_at, by_author)
+		SELECT $1, $2, $3, $4, $5, m.author_id, $6, $7
+		FROM messages m
+		WHERE m.board = $1 AND m.thread_id = $2 AND m.id = $3
+		  AND true -- SQLite needs a WHERE clause here to parse ON CONFLICT
+		ON CONFLICT (board, thread_id, message_id) DO UPDATE
+		SET reason = EXCLUDED.reason, deleted_by = EXCLUDED.deleted_by,
+		    author_id = EXCLUDED.author_id, by_author = EXCLUDED.by_author, deleted_at = EXCLUDED.deleted_at`,
+		board, threadId, id, deletion.Reason, deletion.DeletedBy, now(), deletion.ByAuthor,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record message deletion: %w", err)
+	}
+	return nil
+}
+
+// getDeletedMessages returns public deletion stubs for a thread with message ids in
+// [fromId, toId]. It returns nothing unless the board has deletion stubs enabled.
+func (s *Storage) getDeletedMessages(q Querier, board domain.BoardShortName, threadId domain.ThreadId, fromId, toId domain.MsgId) ([]domain.DeletedMessage, error) {
+	rows, err := q.Query(`
+		SELECT d.message_id, d.reason, d.deleted_at
+		FROM message_deletions d
+		JOIN boards b ON b.short_name = d.board
+		WHERE d.board = $1 AND d.thread_id = $2 AND d.message_id BETWEEN $3 AND $4
+		  AND b.show_deletion_stubs AND NOT d.by_author
+		ORDER BY d.message_id`,
+		board, threadId, fromId, toId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted messages: %w", err)
+	}
+	defer rows.Close()
+
+	var deleted []domain.DeletedMessage
+	for rows.Next() {
+		d := domain.DeletedMessage{Board: board, ThreadId: threadId}
+		if err := rows.Scan(&d.Id, &d.Reason, &d.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted message: %w", err)
+		}
+		deleted = append(deleted, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted messages: %w", err)
+	}
+	return deleted, nil
+}
+
+// getMessage contains the core logic for fetching a message and all its related data.
+// It composes several helper functions to build the complete domain.Message object.
+func (s *Storage) getMessage(q Querier, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Message, error) {
+	var msg domain.Message
+	err := q.QueryRow(`
+	   SELECT m.id, m.author_id, u.email_domain, u.is_admin, m.text, m.show_email_domain, m.created_at, m.thread_id, m.updated_at, m.board, m.post_number
+	   FROM messages m
+	   JOIN users u ON m.author_id = u.id
+	   WHERE m.board = $1 AND m.thread_id = $2 AND m.id = $3`,
+		board, threadId, id,
+	).Scan(
+		&msg.Id, &msg.Author.Id, &msg.Author.EmailDomain, &msg.Author.Admin, &msg.Text, &msg.ShowEmailDomain, &msg.CreatedAt, &msg.ThreadId,
+		&msg.ModifiedAt, &msg.Board, &msg.PostNumber,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Message{}, &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
+		}
+		return domain.Message{}, fmt.Errorf("failed to query message: %w", err)
+	}
+
+	// Calculate page from message ID (which is per-thread sequential)
+	msg.Page = utils.CalculatePage(int(msg.Id), s.cfg.Public.MessagesPerThreadPage)
+
+	// Fetch and attach related data using helper functions.
+	attachments, err := s.getMessageAttachments(q, board, threadId, id)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	msg.Attachments = attachments
+
+	replies, err := s.getMessageRepliesTo(q, board, threadId, id) // Replies *to* this message
+	if err != nil {
+		return domain.Message{}, err
+	}
+	msg.Replies = replies
+
+	return msg, nil
+}
+
+func (s *Storage) resolvePostNumber(q Querier, board domain.BoardShortName, postNumber domain.PostNumber) (domain.ThreadId, domain.MsgId, error) {
+	var threadId domain.ThreadId
+	var msgId domain.MsgId
+	err := q.QueryRow(
+		`SELECT thread_id, id FROM messages WHERE board = $1 AND post_number = $2`,
+		board, postNumber,
+	).Scan(&threadId, &msgId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, &internal_errors.ErrorWithStatusCode{Message: "Message not found", StatusCode: http.StatusNotFound}
+		}
+		return 0, 0, fmt.Errorf("failed to resolve post number: %w", err)
+	}
+	return threadId, msgId, nil
+}
+
+// getExistingMessages looks up all link targets in one query.
+func (s *Storage) getExistingMessages(q Querier, board domain.BoardShortName, links []domain.MessageLink) ([]domain.MessageLink, error) {
+	if len(links) == 0 {
+		return nil, nil
+	}
+	msgKeys := make([]MsgKey, len(links))
+	for i, link := range links {
+		msgKeys[i] = MsgKey{ThreadId: link.ThreadId, MsgId: link.Id}
+	}
+	keys, keyArgs := msgKeyValues(2, msgKeys)
+
+	rows, err := q.Query(`
+		WITH keys(thread_id, msg_id) AS (`+keys+`)
+		SELECT m.thread_id, m.id
+		FROM keys
+		JOIN messages m ON m.board = $1 AND m.thread_id = keys.thread_id AND m.id = keys.msg_id`,
+		append([]any{board}, keyArgs...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check linked messages: %w", err)
+	}
+	defer rows.Close()
+
+	var existing []domain.MessageLink
+	for rows.Next() {
+		var link domain.MessageLink
+		if err := rows.Scan(&link.ThreadId, &link.Id); err != nil {
+			return nil, fmt.Errorf("failed to scan linked message: %w", err)
+		}
+		existing = append(existing, link)
+	}
+	return existing, rows.Err()
+}
+
+// getLastMessageTime returns the creation time of the user's most recent message.
+func (s *Storage) getLastMessageTime(q Querier, userId domain.UserId) (*time.Time, error) {
+	// MAX(created_at) would come back as text, so the row is selected instead.
+	var last time.Time
+	err := q.QueryRow(`
+		SELECT created_at FROM messages WHERE author_id = $1
+		ORDER BY created_at DESC LIMIT 1`,
+		userId,
+	).Scan(&last)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query last message time: %w", err)
+	}
+	return &last, nil
+}
+
+// getMessageAttachments fetches all attachment records associated with a specific message.
+func (s *Storage) getMessageAttachments(q Querier, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Attachments, error) {
+	rows, err := q.Query(`
+        SELECT `+attachmentColumns+`
+        FROM attachments a
+        JOIN files f ON a.file_id = f.id
+        WHERE a.board = $1 AND a.thread_id = $2 AND a.message_id = $3
+        ORDER BY a.id`,
+		board, threadId, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments domain.Attachments
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment row: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// getMessageRepliesTo fetches all reply relationships where the specified message is the *receiver*.
+func (s *Storage) getMessageRepliesTo(q Querier, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Replies, error) {
+	rows, err := q.Query(`
+	       SELECT mr.board, mr.sender_message_id, mr.sender_thread_id, mr.receiver_message_id, mr.receiver_thread_id, mr.created_at,
+	              sm.author_id, sm.post_number
+	       FROM message_replies mr
+	       JOIN messages sm ON sm.board = mr.board AND sm.thread_id = mr.sender_thread_id AND sm.id = mr.sender_message_id
+	       WHERE mr.board = $1 AND mr.receiver_thread_id = $2 AND mr.receiver_message_id = $3
+	       ORDER BY mr.created_at`,
+		board, threadId, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message replies: %w", err)
+	}
+	defer rows.Close()
+
+	var replies domain.Replies
+	for rows.Next() {
+		var reply domain.Reply
+		if err := rows.Scan(&reply.Board, &reply.From, &reply.FromThreadId, &reply.To, &reply.ToThreadId, &reply.CreatedAt, &reply.FromAuthor, &reply.FromPostNumber); err != nil {
+			return nil, fmt.Errorf("failed to scan reply row: %w", err)
+		}
+		// From (sender_message_id) is now the per-thread sequential ID, which is also the ordinal
+		reply.FromPage = utils.CalculatePage(int(reply.From), s.cfg.Public.MessagesPerThreadPage)
+		replies = append(replies, &reply)
+	}
+	return replies, rows.Err()
+}
+
+// getMessageRepliesFrom fetches all reply relationships where the specified message is the *sender*.
+func (s *Storage) getMessageRepliesFrom(q Querier, board domain.BoardShortName, threadId domain.ThreadId, id domain.MsgId) (domain.Replies, error) {
+	rows, err := q.Query(`
+	       SELECT board, sender_message_id, sender_thread_id, receiver_message_id, receiver_thread_id, created_at
+	       FROM message_replies
+	       WHERE board = $1 AND sender_thread_id = $2 AND sender_message_id = $3
+	       ORDER BY created_at`,
+		board, threadId, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message replies from: %w", err)
+	}
+	defer rows.Close()
+
+	var replies domain.Replies
+	for rows.Next() {
+		var reply domain.Reply
+		if err := rows.Scan(&reply.Board, &reply.From, &reply.FromThreadId, &reply.To, &reply.ToThreadId, &reply.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reply row from: %w", err)
+		}
+		replies = append(replies, &reply)
+	}
+	return replies, rows.Err()
+}
+
+// addAttachments is the internal method to add attachments within a transaction
+func (s *Storage) addAttachments(q Querier, board domain.BoardShortName, threadId domain.ThreadId, messageID domain.MsgId, attachments domain.Attachments) error {
+	for _, attachment := range attachments {
+		fileId, err := s.insertFile(q, attachment.File)
+		if err != nil {
+			return err
+		}
+		if err := s.insertAttachment(q, board, threadId, messageID, fileId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertFile stores the file record and the metadata stripped from it.
+func (s *Storage) insertFile(q Querier, file *domain.File) (domain.FileId, error) {
+	var fileId domain.FileId
+	err := q.QueryRow(`
+            INSERT INTO files (file_path, filename, original_filename, file_size_bytes, mime_type, original_mime_type, image_width, image_height, thumbnail_path, sha256, thumbnail_sha256, text_preview)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, '')) RETURNING id`,
+		file.FilePath, file.Filename, file.OriginalFilename, file.SizeBytes,
+		file.MimeType, file.OriginalMimeType, file.ImageWidth, file.ImageHeight, file.ThumbnailPath,
+		file.Sha256, file.ThumbnailSha256, file.TextPreview,
+	).Scan(&fileId)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert file: %w", err)
+	}
+
+	if meta := file.StrippedMetadata; meta != nil {
+		_, err = q.Exec(`
+            INSERT INTO file_metadata (file_id, camera_make, camera_model, software, has_gps) VALUES ($1, $2, $3, $4, $5)`,
+			fileId, meta.CameraMake, meta.CameraModel, meta.Software, meta.HasGPS,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert file metadata: %w", err)
+		}
+	}
+	return fileId, nil
+}
+
+func (s *Storage) insertAttachment(q Querier, board domain.BoardShortName, threadId domain.ThreadId, messageID domain.MsgId, fileId domain.FileId) error {
+	_, err := q.Exec(`
+            INSERT INTO attachments (board, thread_id, message_id, file_id) VALUES ($1, $2, $3, $4)`,
+		board, threadId, messageID, fileId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert attachment link: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// notificationsFrom joins notifications (n) with their replies (m) and threads
+// (t), see package pg.
+const notificationsFrom = `
+		FROM notifications n
+		JOIN messages m ON m.board = n.board AND m.thread_id = n.thread_id AND m.id = n.message_id
+		JOIN threads t ON t.board = n.board AND t.id = n.thread_id
+		WHERE n.user_id = $1 AND ` + notShadowbannedCondition
+
+// =========================================================================
+// Public Methods (satisfy the service.NotificationStorage interface)
+// =========================================================================
+
+// GetNotifications returns one page of the user's notifications, newest first,
+// and the total number of notifications.
+func (s *Storage) GetNotifications(ctx context.Context, userId domain.UserId, limit, offset int) ([]domain.Notification, int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getNotifications(q, userId, limit, offset)
+}
+
+// CountUnreadNotifications returns the number of notifications the user has not read yet.
+func (s *Storage) CountUnreadNotifications(ctx context.Context, userId domain.UserId) (int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var unread int
+	if err := q.QueryRow(`SELECT count(*) `+notificationsFrom+` AND n.read_at IS NULL`, userId).Scan(&unread); err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return unread, nil
+}
+
+// MarkNotificationRead marks one of the user's notifications as read. Marking
+// a read notification again is a no-op.
+func (s *Storage) MarkNotificationRead(ctx context.Context, userId domain.UserId, id domain.NotificationId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		result, err := tx.Exec(`
+			UPDATE notifications SET read_at = COALESCE(read_at, $3)
+			WHERE id = $1 AND user_id = $2`,
+			id, userId, now(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to mark notification %d as read: %w", id, err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return &internal_errors.ErrorWithStatusCode{Message: "Notification not found", StatusCode: http.StatusNotFound}
+		}
+		return nil
+	})
+}
+
+// MarkAllNotificationsRead marks all of the user's notifications as read.
+func (s *Storage) MarkAllNotificationsRead(ctx context.Context, userId domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		_, err := tx.Exec(`
+			UPDATE notifications SET read_at = $2
+			WHERE user_id = $1 AND read_at IS NULL`,
+			userId, now(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to mark notifications as read: %w", err)
+		}
+		return nil
+	})
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+func (s *Storage) getNotifications(q Querier, userId domain.UserId, limit, offset int) ([]domain.Notification, int, error) {
+	var total int
+	if err := q.QueryRow(`SELECT count(*) `+notificationsFrom, userId).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	rows, err := q.Query(`
+		SELECT n.id, n.read_at IS NOT NULL, m.board, m.thread_id, t.title, m.id, m.text, m.created_at
+		`+notificationsFrom+`
+		ORDER BY n.created_at DESC, n.id DESC
+		LIMIT $2 OFFSET $3`,
+		userId, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []domain.Notification{}
+	for rows.Next() {
+		var n domain.Notification
+		p := &n.Message
+		if err := rows.Scan(&n.Id, &n.Read, &p.Board, &p.ThreadId, &p.ThreadTitle, &p.Id, &p.Text, &p.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		p.Page = utils.CalculatePage(int(p.Id), s.cfg.Public.MessagesPerThreadPage)
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating notifications: %w", err)
+	}
+	return notifications, total, nil
+}
+
+// notifyReply notifies the author of the message replied to, see
+// pg.Storage.notifyReply.
+func (s *Storage) notifyReply(q Querier, board domain.BoardShortName, threadId domain.ThreadId, msgId domain.MsgId, sender domain.UserId, to *domain.Reply, createdAt time.Time) error {
+	_, err := q.Exec(`
+		INSERT INTO notifications (user_id, board, thread_id, message_id, created_at)
+		SELECT author_id, $1, $2, $3, $4 FROM messages
+		WHERE board = $1 AND thread_id = $5 AND id = $6 AND author_id <> $7
+		ON CONFLICT (user_id, board, thread_id, message_id) DO NOTHING`,
+		board, threadId, msgId, createdAt, to.ToThreadId, to.To, sender,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create reply notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,455 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.PremodStorage and MessageStorage interfaces)
+// =========================================================================
+
+// QueuePost holds a post for pre-moderation instead of writing the message.
+// New attachments are recorded as files of the queued post, and uploads made
+// ahead of it are claimed the same way a message claims them. thread is set
+// for the OP of a new thread.
+func (s *Storage) QueuePost(ctx context.Context, creationData domain.MessageCreationData, thread *domain.QueuedThread, attachments domain.Attachments) (domain.QueuedPostId, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	var id domain.QueuedPostId
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		id, err = s.queuePost(tx, creationData, thread, attachments)
+		return err
+	})
+	return id, err
+}
+
+// GetQueuedPosts lists up to limit posts waiting for approval on a board, oldest first.
+func (s *Storage) GetQueuedPosts(ctx context.Context, board domain.BoardShortName, limit int) ([]domain.QueuedPost, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getQueuedPosts(q, board, limit)
+}
+
+// ApproveQueuedPost publishes a queued post: the thread for a held OP, then
+// the message with its files and reply links. The post leaves the queue in
+// the same transaction, so it is published at most once.
+func (s *Storage) ApproveQueuedPost(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId, maxThreadCount *int) (domain.ApprovedPost, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	var approved domain.ApprovedPost
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		approved, err = s.approveQueuedPost(tx, board, id, maxThreadCount)
+		return err
+	})
+	return approved, err
+}
+
+// RejectQueuedPost drops a queued post. Its files are left to the orphan cleanup.
+func (s *Storage) RejectQueuedPost(ctx context.Context, board domain.BoardShortName, id domain.QueuedPostId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.deleteQueuedPost(tx, board, id)
+	})
+}
+
+// GetPremodPoster returns the user's standing under pre-moderation on the
+// board; a user without approved posts or an override gets the zero value.
+func (s *Storage) GetPremodPoster(ctx context.Context, board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getPremodPoster(q, board, userId)
+}
+
+// SetPosterTrust overrides the board's trust rules for the user: true lets
+// their posts skip the queue, false holds them regardless of the rules, and
+// nil goes back to the rules.
+func (s *Storage) SetPosterTrust(ctx context.Context, board domain.BoardShortName, userId domain.UserId, trusted *bool, moderator domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		if trusted == nil {
+			return s.clearPosterTrust(tx, board, userId)
+		}
+		return s.setPosterTrust(tx, board, userId, *trusted, moderator)
+	})
+}
+
+// GetPremodPosters lists the users with approved posts or an override on a
+// board, most recently changed first.
+func (s *Storage) GetPremodPosters(ctx context.Context, board domain.BoardShortName) ([]domain.PremodPoster, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getPremodPosters(q, board)
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+func (s *Storage) queuePost(q Querier, creationData domain.MessageCreationData, thread *domain.QueuedThread, attachments domain.Attachments) (domain.QueuedPostId, error) {
+	var threadId *domain.ThreadId
+	var title domain.ThreadTitle
+	var opOnly bool
+	if thread != nil {
+		title, opOnly = thread.Title, thread.OpOnly
+	} else {
+		threadId = &creationData.ThreadId
+	}
+
+	var id domain.QueuedPostId
+	err := q.QueryRow(`
+		INSERT INTO premod_queue (board, thread_id, title, op_only, author_id, text, show_email_domain)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		creationData.Board, threadId, title, opOnly, creationData.Author.Id, creationData.Text, creationData.ShowEmailDomain,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue post: %w", err)
+	}
+
+	position := 0
+	addFile := func(fileId domain.FileId) error {
+		position++
+		_, err := q.Exec(
+			`INSERT INTO premod_queue_files (post_id, file_id, position) VALUES ($1, $2, $3)`,
+			id, fileId, position,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add file to queued post: %w", err)
+		}
+		return nil
+	}
+	for _, token := range creationData.UploadTokens {
+		fileId, err := s.claimUpload(q, creationData.Board, creationData.Author.Id, token)
+		if err != nil {
+			return 0, err
+		}
+		if err := addFile(fileId); err != nil {
+			return 0, err
+		}
+	}
+	for _, attachment := range attachments {
+		fileId, err := s.insertFile(q, attachment.File)
+		if err != nil {
+			return 0, err
+		}
+		if err := addFile(fileId); err != nil {
+			return 0, err
+		}
+	}
+
+	if creationData.ReplyTo != nil {
+		for _, reply := range *creationData.ReplyTo {
+			_, err := q.Exec(`
+				INSERT INTO premod_queue_replies (post_id, receiver_thread_id, receiver_message_id)
+				VALUES ($1, $2, $3)
+				ON CONFLICT DO NOTHING`,
+				id, reply.ToThreadId, reply.To,
+			)
+			if err != nil {
+				return 0, fmt.Errorf("failed to save reply of queued post: %w", err)
+			}
+		}
+	}
+	return id, nil
+}
+
+func (s *Storage) getQueuedPosts(q Querier, board domain.BoardShortName, limit int) ([]domain.QueuedPost, error) {
+	rows, err := q.Query(`
+		SELECT p.id, p.board, p.thread_id, p.title, p.op_only, p.author_id, p.text, p.show_email_domain, p.created_at,
+		       COALESCE((SELECT group_concat(f.original_filename, char(10))
+		                 FROM premod_queue_files pf JOIN files f ON f.id = pf.file_id
+		                 WHERE pf.post_id = p.id), '')
+		FROM premod_queue p
+		WHERE p.board = $1
+		ORDER BY p.created_at, p.id
+		LIMIT $2`,
+		board, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queued posts on board '%s': %w", board, err)
+	}
+	defer rows.Close()
+
+	posts := []domain.QueuedPost{}
+	for rows.Next() {
+		var post domain.QueuedPost
+		var files string
+		if err := rows.Scan(&post.Id, &post.Board, &post.ThreadId, &post.Title, &post.OpOnly, &post.AuthorId, &post.Text, &post.ShowEmailDomain, &post.CreatedAt, &files); err != nil {
+			return nil, fmt.Errorf("failed to scan queued post: %w", err)
+		}
+		if files != "" {
+			post.Files = strings.Split(files, "\n")
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating queued posts: %w", err)
+	}
+	return posts, nil
+}
+
+func (s *Storage) approveQueuedPost(q Querier, board domain.BoardShortName, id domain.QueuedPostId, maxThreadCount *int) (domain.ApprovedPost, error) {
+	// Files and replies go with the post row, so read them first
+	fileIds, err := s.queuedPostFiles(q, id)
+	if err != nil {
+		return domain.ApprovedPost{}, err
+	}
+	replies, err := s.queuedPostReplies(q, board, id)
+	if err != nil {
+		return domain.ApprovedPost{}, err
+	}
+
+	var post domain.QueuedPost
+	err = q.QueryRow(`
+		DELETE FROM premod_queue WHERE board = $1 AND id = $2
+		RETURNING thread_id, title, op_only, author_id, text, show_email_domain`,
+		board, id,
+	).Scan(&post.ThreadId, &post.Title, &post.OpOnly, &post.AuthorId, &post.Text, &post.ShowEmailDomain)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ApprovedPost{}, queuedPostNotFound()
+		}
+		return domain.ApprovedPost{}, fmt.Errorf("failed to take post %d off the queue: %w", id, err)
+	}
+
+	creationData := domain.MessageCreationData{
+		Board:           board,
+		Author:          domain.User{Id: post.AuthorId},
+		Text:            post.Text,
+		ShowEmailDomain: post.ShowEmailDomain,
+	}
+	if len(replies) > 0 {
+		creationData.ReplyTo = &replies
+	}
+
+	if post.IsThread() {
+		threadId, createdAt, err := s.createThreadWithCleanup(q, domain.ThreadCreationData{
+			Title:     post.Title,
+			Board:     board,
+			OpOnly:    post.OpOnly,
+			OpMessage: creationData,
+		}, maxThreadCount)
+		if err != nil {
+			return domain.ApprovedPost{}, err
+		}
+		creationData.ThreadId = threadId
+		creationData.CreatedAt = &createdAt
+	} else {
+		creationData.ThreadId = *post.ThreadId
+	}
+
+	msgId, err := s.createMessage(q, creationData)
+	if err != nil {
+		return domain.ApprovedPost{}, err
+	}
+	if err := s.countPremodApproval(q, board, post.AuthorId); err != nil {
+		return domain.ApprovedPost{}, err
+	}
+	for _, fileId := range fileIds {
+		if err := s.insertAttachment(q, board, creationData.ThreadId, msgId, fileId); err != nil {
+			return domain.ApprovedPost{}, err
+		}
+	}
+	if err := s.storeContentHash(q, board, creationData.ThreadId, msgId); err != nil {
+		return domain.ApprovedPost{}, err
+	}
+	return domain.ApprovedPost{ThreadId: creationData.ThreadId, Id: msgId, AuthorId: post.AuthorId}, nil
+}
+
+func (s *Storage) queuedPostFiles(q Querier, id domain.QueuedPostId) ([]domain.FileId, error) {
+	rows, err := q.Query(`SELECT file_id FROM premod_queue_files WHERE post_id = $1 ORDER BY position`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query files of queued post %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	var fileIds []domain.FileId
+	for rows.Next() {
+		var fileId domain.FileId
+		if err := rows.Scan(&fileId); err != nil {
+			return nil, fmt.Errorf("failed to scan file of queued post: %w", err)
+		}
+		fileIds = append(fileIds, fileId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating files of queued post: %w", err)
+	}
+	return fileIds, nil
+}
+
+// queuedPostReplies returns the reply links of a queued post whose receivers
+// still exist; messages deleted while the post waited are skipped.
+func (s *Storage) queuedPostReplies(q Querier, board domain.BoardShortName, id domain.QueuedPostId) (domain.Replies, error) {
+	rows, err := q.Query(`
+		SELECT r.receiver_thread_id, r.receiver_message_id
+		FROM premod_queue_replies r
+		JOIN messages m ON m.board = $1 AND m.thread_id = r.receiver_thread_id AND m.id = r.receiver_message_id
+		WHERE r.post_id = $2`,
+		board, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query replies of queued post %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	var replies domain.Replies
+	for rows.Next() {
+		reply := &domain.Reply{Board: board}
+		if err := rows.Scan(&reply.ToThreadId, &reply.To); err != nil {
+			return nil, fmt.Errorf("failed to scan reply of queued post: %w", err)
+		}
+		replies = append(replies, reply)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating replies of queued post: %w", err)
+	}
+	return replies, nil
+}
+
+func (s *Storage) deleteQueuedPost(q Querier, board domain.BoardShortName, id domain.QueuedPostId) error {
+	result, err := q.Exec(`DELETE FROM premod_queue WHERE board = $1 AND id = $2`, board, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete queued post %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return queuedPostNotFound()
+	}
+	return nil
+}
+
+func queuedPostNotFound() error {
+	return &internal_errors.ErrorWithStatusCode{Message: "Queued post not found", StatusCode: http.StatusNotFound}
+}
+
+// premodPosterColumns are the columns scanned by scanPremodPoster.
+const premodPosterColumns = `board, user_id, approvals, trusted, COALESCE(updated_by, 0), updated_at`
+
+func scanPremodPoster(scanner interface {
+	Scan(dest ...any) error
+}) (domain.PremodPoster, error) {
+	var p domain.PremodPoster
+	var trusted sql.NullBool
+	if err := scanner.Scan(&p.Board, &p.UserId, &p.Approvals, &trusted, &p.UpdatedBy, &p.UpdatedAt); err != nil {
+		return domain.PremodPoster{}, err
+	}
+	if trusted.Valid {
+		p.Trusted = &trusted.Bool
+	}
+	return p, nil
+}
+
+func (s *Storage) getPremodPoster(q Querier, board domain.BoardShortName, userId domain.UserId) (domain.PremodPoster, error) {
+	p, err := scanPremodPoster(q.QueryRow(
+		`SELECT `+premodPosterColumns+` FROM premod_posters WHERE board = $1 AND user_id = $2`,
+		board, userId,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.PremodPoster{Board: board, UserId: userId}, nil
+	}
+	if err != nil {
+		return domain.PremodPoster{}, fmt.Errorf("failed to fetch premod standing of user %d on board '%s': %w", userId, board, err)
+	}
+	return p, nil
+}
+
+// countPremodApproval records an approved post of the user on the board.
+func (s *Storage) countPremodApproval(q Querier, board domain.BoardShortName, userId domain.UserId) error {
+	_, err := q.Exec(`
+		INSERT INTO premod_posters (board, user_id, approvals)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (board, user_id) DO UPDATE SET approvals = premod_posters.approvals + 1`,
+		board, userId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to count approval of user %d on board '%s': %w", userId, board, err)
+	}
+	return nil
+}
+
+func (s *Storage) setPosterTrust(q Querier, board domain.BoardShortName, userId domain.UserId, trusted bool, moderator domain.UserId) error {
+	_, err := q.Exec(`
+		INSERT INTO premod_posters (board, user_id, trusted, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (board, user_id) DO UPDATE SET
+			trusted = excluded.trusted,
+			updated_by = excluded.updated_by,
+			updated_at = `+sqlNow+``,
+		board, userId, trusted, moderator,
+	)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return &internal_errors.ErrorWithStatusCode{
+				Message: "Board or user not found", StatusCode: http.StatusNotFound,
+			}
+		}
+		return fmt.Errorf("failed to set trust of user %d on board '%s': %w", userId, board, err)
+	}
+	return nil
+}
+
+func (s *Storage) clearPosterTrust(q Querier, board domain.BoardShortName, userId domain.UserId) error {
+	result, err := q.Exec(`
+		UPDATE premod_posters SET trusted = NULL, updated_by = NULL, updated_at = `+sqlNow+`
+		WHERE board = $1 AND user_id = $2 AND trusted IS NOT NULL`,
+		board, userId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear trust of user %d on board '%s': %w", userId, board, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{Message: "User has no trust override on this board", StatusCode: http.StatusNotFound}
+	}
+	return nil
+}
+
+func (s *Storage) getPremodPosters(q Querier, board domain.BoardShortName) ([]domain.PremodPoster, error) {
+	rows, err := q.Query(`
+		SELECT `+premodPosterColumns+`
+		FROM premod_posters
+		WHERE board = $1
+		ORDER BY updated_at DESC, user_id`,
+		board,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query premod posters on board '%s': %w", board, err)
+	}
+	defer rows.Close()
+
+	posters := []domain.PremodPoster{}
+	for rows.Next() {
+		p, err := scanPremodPoster(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan premod poster: %w", err)
+		}
+		posters = append(posters, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating premod posters: %w", err)
+	}
+	return posters, nil
+}
@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+func (s *Storage) SaveReferralAction(ctx context.Context, source, action, ip string) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		_, err := tx.Exec(
+			"INSERT INTO referral_actions(source, action, ip) VALUES($1, $2, $3) ON CONFLICT(ip, source, action) DO NOTHING",
+			source, action, ip,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert referral action: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *Storage) GetReferralActionStats(ctx context.Context) ([]domain.ReferralActionStats, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	rows, err := q.Query(`
+		SELECT source, action, COUNT(*) AS count
+		FROM referral_actions
+		GROUP BY source, action
+		ORDER BY source, action
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query referral action stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.ReferralActionStats
+	for rows.Next() {
+		var s domain.ReferralActionStats
+		if err := rows.Scan(&s.Source, &s.Action, &s.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan referral action stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,531 @@
+-- SQLite schema, applied on every start (all statements are idempotent).
+-- It mirrors pg/migrations/init.sql without the per-board partitions and the
+-- materialized views: all boards share the tables below and board pages are
+-- read from them directly.
+--
+-- Timestamps are stored as UTC text in the driver's time format and the
+-- defaults below use the same format, so they compare correctly as text.
+
+CREATE TABLE IF NOT EXISTS users (
+    id                     integer PRIMARY KEY AUTOINCREMENT,
+    email_encrypted        blob NOT NULL,
+    email_domain           varchar(254) NOT NULL,
+    email_hash             blob NOT NULL UNIQUE,
+    password_hash          text NOT NULL,
+    is_admin               boolean default false,
+    created_at             timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    referral_source        varchar(100),
+    email_index            blob
+);
+CREATE INDEX IF NOT EXISTS idx_users_email_domain ON users(email_domain);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_index ON users(email_index) WHERE email_index IS NOT NULL;
+
+-- Reserved author of system messages (domain.SystemUserId), unable to log in
+INSERT INTO users (id, email_encrypted, email_domain, email_hash, password_hash, is_admin)
+VALUES (-1, '', '', '', '', false)
+ON CONFLICT DO NOTHING;
+
+-- Reserved author of posts copied from other instances (domain.MirrorUserId)
+INSERT INTO users (id, email_encrypted, email_domain, email_hash, password_hash, is_admin)
+VALUES (-2, '', '', 'mirror', '', false)
+ON CONFLICT DO NOTHING;
+
+-- An automatic permanent ban (no blacklisted_by, no expires_at) is a
+-- directory sync deactivation, lifted by the sync when the user is listed again
+CREATE TABLE IF NOT EXISTS user_blacklist (
+    user_id        integer NOT NULL PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    blacklisted_at timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    reason         text,
+    blacklisted_by integer REFERENCES users(id),  -- NULL for automatic bans
+    expires_at     timestamp                      -- NULL for permanent bans
+);
+CREATE INDEX IF NOT EXISTS idx_user_blacklist_time ON user_blacklist (blacklisted_at DESC);
+
+CREATE TABLE IF NOT EXISTS ban_appeals (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    user_id     integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    banned_at   timestamp NOT NULL,
+    ban_reason  text,
+    banned_by   integer REFERENCES users(id),
+    text        text NOT NULL,
+    status      varchar(10) NOT NULL default 'pending',
+    response    text NOT NULL default '',
+    decided_by  integer REFERENCES users(id),
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    decided_at  timestamp,
+    UNIQUE (user_id, banned_at)
+);
+CREATE INDEX IF NOT EXISTS idx_ban_appeals_status ON ban_appeals (status, created_at);
+
+CREATE TABLE IF NOT EXISTS login_devices (
+    user_id           integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fingerprint       varchar(64) NOT NULL,
+    revoke_token_hash varchar(64) NOT NULL UNIQUE,
+    first_seen_at     timestamp NOT NULL,
+    last_seen_at      timestamp NOT NULL,
+    PRIMARY KEY (user_id, fingerprint)
+);
+
+CREATE TABLE IF NOT EXISTS session_revocations (
+    user_id    integer PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    revoked_at timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_session_revocations_time ON session_revocations (revoked_at DESC);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id      integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key          varchar(255) NOT NULL,
+    request_path text NOT NULL,
+    status_code  integer,
+    response     blob,
+    created_at   timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_time ON idempotency_keys (created_at);
+
+CREATE TABLE IF NOT EXISTS confirmation_data (
+    email_hash             blob PRIMARY KEY,
+    password_hash          varchar(80) NOT NULL,
+    confirmation_code_hash varchar(80) default '',
+    expires_at             timestamp,
+    created_at             timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS board_categories (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    name       varchar(100) NOT NULL UNIQUE,
+    position   integer NOT NULL default 0,
+    created_at timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS boards (
+    short_name             varchar(10) PRIMARY KEY,
+    name                   varchar(254) NOT NULL,
+    description            text NOT NULL default '',
+    created_at             timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    last_activity_at       timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    category_id            integer REFERENCES board_categories(id) ON DELETE SET NULL,
+    position               integer NOT NULL default 0,
+    show_deletion_stubs    boolean NOT NULL default false,
+    noindex                boolean NOT NULL default false,
+    min_op_text_length           integer NOT NULL default 0 CHECK (min_op_text_length >= 0),
+    require_op_attachment        boolean NOT NULL default false,
+    max_threads_per_user_per_day integer NOT NULL default 0 CHECK (max_threads_per_user_per_day >= 0),
+    video_profile          text NOT NULL default '',
+    allow_documents        boolean NOT NULL default false,
+    max_attachments        integer CHECK (max_attachments >= 0),
+    allowed_mime_types     text NOT NULL default '',
+    public_modlog          boolean NOT NULL default false,
+    self_delete_replied    boolean NOT NULL default false,
+    text_filter            text NOT NULL default '',
+    posting_email_domains        text NOT NULL default '',
+    posting_min_account_age_days integer NOT NULL default 0 CHECK (posting_min_account_age_days >= 0),
+    bump_limit_notice      boolean NOT NULL default false,
+    pre_moderation         boolean NOT NULL default false,
+    premod_trust_approvals        integer NOT NULL default 0 CHECK (premod_trust_approvals >= 0),
+    premod_trust_account_age_days integer NOT NULL default 0 CHECK (premod_trust_account_age_days >= 0),
+    language               text NOT NULL default '',
+    custom_css             text NOT NULL default '',
+    delete_after           timestamp,
+    -- Replaces the per-board thread id sequence: ids are never reused
+    next_thread_id         integer NOT NULL default 1,
+    next_post_number       integer NOT NULL default 1
+);
+
+CREATE TABLE IF NOT EXISTS board_permissions (
+    board_short_name     varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    allowed_email_domain varchar(254) NOT NULL,
+    PRIMARY KEY (board_short_name, allowed_email_domain)
+);
+CREATE INDEX IF NOT EXISTS idx_board_permissions_email ON board_permissions (allowed_email_domain);
+
+CREATE TABLE IF NOT EXISTS board_banners (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    file_path   text NOT NULL,
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+CREATE INDEX IF NOT EXISTS idx_board_banners_board ON board_banners (board);
+
+CREATE TABLE IF NOT EXISTS board_redirects (
+    old_short_name  varchar(10) PRIMARY KEY,
+    new_short_name  varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    expires_at      timestamp NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS board_post_counts (
+    board  varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    hour   timestamp NOT NULL,
+    posts  integer NOT NULL,
+    PRIMARY KEY (board, hour)
+);
+CREATE INDEX IF NOT EXISTS idx_board_post_counts_hour ON board_post_counts (hour);
+
+CREATE TABLE IF NOT EXISTS threads (
+    id               integer NOT NULL,
+    title            text NOT NULL,
+    board            varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    message_count    integer NOT NULL default 0,
+    next_message_id  integer NOT NULL default 1,
+    last_bumped_at   timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    last_modified_at timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    created_at       timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    is_pinned        boolean NOT NULL default false,
+    op_only          boolean NOT NULL default false, -- only the OP and moderators may reply
+    bump_limit_noticed boolean NOT NULL default false, -- the bump limit notice was posted
+    PRIMARY KEY (board, id)
+);
+CREATE INDEX IF NOT EXISTS threads_last_bumped_at_index ON threads (board, is_pinned DESC, last_bumped_at DESC);
+
+-- id is per-thread sequential (1, 2, 3...) - id=1 is always OP
+CREATE TABLE IF NOT EXISTS messages (
+    id          integer NOT NULL,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id   integer NOT NULL,
+    author_id   integer NOT NULL REFERENCES users(id),
+    text        text NOT NULL,
+    show_email_domain boolean NOT NULL DEFAULT false,
+    post_number integer NOT NULL,
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    content_hash blob,
+    author_commitment blob,
+
+    PRIMARY KEY (board, thread_id, id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_messages_author ON messages (author_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_post_number ON messages (board, post_number);
+CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS files (
+    id                 integer PRIMARY KEY AUTOINCREMENT,
+    file_path          text NOT NULL UNIQUE,
+    filename           varchar(255) NOT NULL,
+    original_filename  text NOT NULL,
+    file_size_bytes    integer NOT NULL,
+    mime_type          varchar(255) NOT NULL,
+    original_mime_type varchar(255) NOT NULL,
+    image_width        integer,
+    image_height       integer,
+    thumbnail_path     text,
+    sha256             char(64),
+    thumbnail_sha256   char(64),
+    text_preview       text
+);
+CREATE INDEX IF NOT EXISTS idx_files_thumbnail_path ON files (thumbnail_path) WHERE thumbnail_path IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_files_sha256 ON files (sha256) WHERE sha256 IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_files_thumbnail_sha256 ON files (thumbnail_sha256) WHERE thumbnail_sha256 IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS file_metadata (
+    file_id      integer PRIMARY KEY REFERENCES files(id) ON DELETE CASCADE,
+    camera_make  text NOT NULL default '',
+    camera_model text NOT NULL default '',
+    software     text NOT NULL default '',
+    has_gps      boolean NOT NULL default false
+);
+
+CREATE TABLE IF NOT EXISTS pending_uploads (
+    token      varchar(64) PRIMARY KEY,
+    board      varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    user_id    integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_id    integer NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    created_at timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+CREATE INDEX IF NOT EXISTS idx_pending_uploads_created ON pending_uploads (created_at);
+CREATE INDEX IF NOT EXISTS idx_pending_uploads_file ON pending_uploads (file_id);
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id                integer PRIMARY KEY AUTOINCREMENT,
+    board             varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id         integer NOT NULL,
+    message_id        integer NOT NULL,
+    file_id           integer NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+
+    FOREIGN KEY (board, thread_id, message_id) REFERENCES messages(board, thread_id, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS attachments_message_id_index ON attachments (board, thread_id, message_id);
+CREATE INDEX IF NOT EXISTS attachments_file_id_index ON attachments (file_id);
+
+CREATE TABLE IF NOT EXISTS message_replies (
+    board               varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    sender_thread_id    integer NOT NULL,
+    sender_message_id   integer NOT NULL,
+    receiver_thread_id  integer NOT NULL,
+    receiver_message_id integer NOT NULL,
+    created_at          timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    PRIMARY KEY (board, sender_thread_id, sender_message_id, receiver_thread_id, receiver_message_id),
+    FOREIGN KEY (board, sender_thread_id, sender_message_id)
+        REFERENCES messages(board, thread_id, id) ON DELETE CASCADE,
+    FOREIGN KEY (board, receiver_thread_id, receiver_message_id)
+        REFERENCES messages(board, thread_id, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_message_replies_receiver_msg
+    ON message_replies (board, receiver_thread_id, receiver_message_id);
+
+CREATE TABLE IF NOT EXISTS message_deletions (
+    board       varchar(10) NOT NULL,
+    thread_id   integer NOT NULL,
+    message_id  integer NOT NULL,
+    reason      text NOT NULL default '',
+    deleted_by  integer REFERENCES users(id) ON DELETE SET NULL,
+    author_id   integer REFERENCES users(id) ON DELETE CASCADE,
+    by_author   boolean NOT NULL default false,
+    deleted_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    PRIMARY KEY (board, thread_id, message_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_message_deletions_author ON message_deletions (author_id, deleted_at DESC);
+
+CREATE TABLE IF NOT EXISTS thread_title_edits (
+    id           integer PRIMARY KEY AUTOINCREMENT,
+    board        varchar(10) NOT NULL,
+    thread_id    integer NOT NULL,
+    old_title    text NOT NULL,
+    new_title    text NOT NULL,
+    edited_by    integer REFERENCES users(id) ON DELETE SET NULL,
+    by_moderator boolean NOT NULL default false,
+    edited_at    timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_thread_title_edits_thread ON thread_title_edits (board, thread_id, edited_at);
+
+CREATE TABLE IF NOT EXISTS thread_successors (
+    board        varchar(10) NOT NULL,
+    thread_id    integer NOT NULL,
+    successor_id integer NOT NULL CHECK (successor_id > thread_id),
+    set_by       integer REFERENCES users(id) ON DELETE SET NULL,
+    created_at   timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    PRIMARY KEY (board, thread_id),
+    UNIQUE (board, successor_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE,
+    FOREIGN KEY (board, successor_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS premod_queue (
+    id                integer PRIMARY KEY AUTOINCREMENT,
+    board             varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id         integer,
+    title             text NOT NULL default '',
+    op_only           boolean NOT NULL default false,
+    author_id         integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    text              text NOT NULL,
+    show_email_domain boolean NOT NULL default false,
+    created_at        timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_premod_queue_board ON premod_queue (board, created_at);
+
+CREATE TABLE IF NOT EXISTS premod_queue_files (
+    post_id  integer NOT NULL REFERENCES premod_queue(id) ON DELETE CASCADE,
+    file_id  integer NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    position integer NOT NULL,
+
+    PRIMARY KEY (post_id, position)
+);
+CREATE INDEX IF NOT EXISTS idx_premod_queue_files_file ON premod_queue_files (file_id);
+
+CREATE TABLE IF NOT EXISTS premod_queue_replies (
+    post_id             integer NOT NULL REFERENCES premod_queue(id) ON DELETE CASCADE,
+    receiver_thread_id  integer NOT NULL,
+    receiver_message_id integer NOT NULL,
+
+    PRIMARY KEY (post_id, receiver_thread_id, receiver_message_id)
+);
+
+CREATE TABLE IF NOT EXISTS premod_posters (
+    board      varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    user_id    integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    approvals  integer NOT NULL default 0,
+    trusted    boolean,
+    updated_by integer REFERENCES users(id) ON DELETE SET NULL,
+    updated_at timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    PRIMARY KEY (board, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS board_mirrors (
+    board          varchar(10) PRIMARY KEY REFERENCES boards(short_name) ON DELETE CASCADE,
+    source_url     text NOT NULL,
+    source_board   varchar(10) NOT NULL,
+    created_by     integer REFERENCES users(id) ON DELETE SET NULL,
+    created_at     timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    last_synced_at timestamp,
+    last_error     text NOT NULL default ''
+);
+
+CREATE TABLE IF NOT EXISTS mirrored_threads (
+    board              varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id          integer NOT NULL,
+    source_modified_at timestamp NOT NULL,
+    last_message_id    integer NOT NULL,
+    synced_at          timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    PRIMARY KEY (board, thread_id)
+);
+
+CREATE TABLE IF NOT EXISTS moderation_log (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    action      varchar(20) NOT NULL,
+    reason      text NOT NULL default '',
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+CREATE INDEX IF NOT EXISTS idx_moderation_log_board ON moderation_log (board, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS user_shadowbans (
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    user_id     integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_by  integer REFERENCES users(id) ON DELETE SET NULL,
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    PRIMARY KEY (board, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    user_id     integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id   integer NOT NULL,
+    message_id  integer NOT NULL,
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    read_at     timestamp,  -- NULL while unread
+
+    UNIQUE (user_id, board, thread_id, message_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications (user_id) WHERE read_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS message_bookmarks (
+    user_id     integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id   integer NOT NULL,
+    message_id  integer NOT NULL,
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    PRIMARY KEY (user_id, board, thread_id, message_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_message_bookmarks_user ON message_bookmarks (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS thread_watches (
+    user_id     integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    board       varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id   integer NOT NULL,
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    PRIMARY KEY (user_id, board, thread_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thread_reads (
+    user_id      integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    board        varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id    integer NOT NULL,
+    last_read_id integer NOT NULL,
+
+    PRIMARY KEY (user_id, board, thread_id),
+    FOREIGN KEY (board, thread_id) REFERENCES threads(board, id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS email_digests (
+    user_id            integer PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    frequency          varchar(10) NOT NULL CHECK (frequency IN ('daily', 'weekly')),
+    unsubscribe_token  varchar(64) NOT NULL UNIQUE,
+    last_sent_at       timestamp NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    endpoint    text PRIMARY KEY,
+    user_id     integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    p256dh      varchar(128) NOT NULL,
+    auth        varchar(64) NOT NULL,
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user ON push_subscriptions (user_id);
+
+CREATE TABLE IF NOT EXISTS invite_codes (
+    code_hash          varchar(80) PRIMARY KEY,
+    created_by         integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at         timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at         timestamp NOT NULL,
+    used_by            integer REFERENCES users(id) ON DELETE SET NULL,
+    used_at            timestamp,
+
+    CONSTRAINT used_consistency CHECK (
+        (used_by IS NULL AND used_at IS NULL) OR
+        (used_by IS NOT NULL AND used_at IS NOT NULL)
+    )
+);
+CREATE INDEX IF NOT EXISTS idx_invite_codes_created_by ON invite_codes (created_by, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_invite_codes_expires ON invite_codes (expires_at);
+
+CREATE TABLE IF NOT EXISTS referral_actions (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    source     varchar(100) NOT NULL,
+    action     varchar(50) NOT NULL,
+    ip         text NOT NULL,
+    created_at timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    UNIQUE(ip, source, action)
+);
+CREATE INDEX IF NOT EXISTS idx_referral_actions_source ON referral_actions (source);
+
+CREATE TABLE IF NOT EXISTS view_as_sessions (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    admin_id    integer NOT NULL,
+    user_id     integer NOT NULL,
+    reason      text NOT NULL,
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at  timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_view_as_sessions_created ON view_as_sessions (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS maintenance_window (
+    id         integer PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    message    text NOT NULL,
+    starts_at  timestamp NOT NULL,
+    ends_at    timestamp,
+    created_by integer,
+    created_at timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS failed_posts (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    user_id     integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    board       text NOT NULL,
+    thread_id   integer,
+    status_code integer NOT NULL,
+    error       text NOT NULL,
+    request     text NOT NULL,
+    created_at  timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+CREATE INDEX IF NOT EXISTS idx_failed_posts_created ON failed_posts (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS thread_snapshots (
+    id            integer PRIMARY KEY AUTOINCREMENT,
+    board         varchar(10) NOT NULL REFERENCES boards(short_name) ON DELETE CASCADE,
+    thread_id     integer NOT NULL,
+    title         text NOT NULL,
+    file_path     text NOT NULL,
+    size_bytes    integer NOT NULL,
+    message_count integer NOT NULL,
+    created_by    integer REFERENCES users(id) ON DELETE SET NULL,
+    created_at    timestamp NOT NULL default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+CREATE INDEX IF NOT EXISTS idx_thread_snapshots_board ON thread_snapshots (board, thread_id, id);
+
+CREATE TABLE IF NOT EXISTS backup_runs (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    status      text NOT NULL,
+    started_at  timestamp NOT NULL,
+    finished_at timestamp,
+    paused_ms   integer NOT NULL DEFAULT 0,
+    error       text NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_backup_runs_status ON backup_runs (status, started_at DESC);
@@ -0,0 +1,180 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// notShadowbannedCondition filters messages (aliased as m) down to those
+// whose author is not shadowbanned on the message's board.
+const notShadowbannedCondition = `NOT EXISTS (
+			SELECT 1 FROM user_shadowbans sb
+			WHERE sb.board = m.board AND sb.user_id = m.author_id
+		)`
+
+// =========================================================================
+// Public Methods (satisfy the service.ShadowbanStorage interface)
+// =========================================================================
+
+// ShadowbanUser shadowbans a user on a board. Shadowbanning an already
+// shadowbanned user is a no-op.
+func (s *Storage) ShadowbanUser(ctx context.Context, board domain.BoardShortName, userId, createdBy domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.shadowbanUser(tx, board, userId, createdBy)
+	})
+}
+
+// UnshadowbanUser lifts a user's shadowban on a board.
+func (s *Storage) UnshadowbanUser(ctx context.Context, board domain.BoardShortName, userId domain.UserId) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.unshadowbanUser(tx, board, userId)
+	})
+}
+
+// GetShadowbans lists shadowbans on all boards, newest first, for the admin panel.
+func (s *Storage) GetShadowbans(ctx context.Context, limit, offset int) ([]domain.Shadowban, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getShadowbans(q, limit, offset)
+}
+
+// GetShadowbannedUsers returns the ids of users shadowbanned on a board.
+func (s *Storage) GetShadowbannedUsers(ctx context.Context, board domain.BoardShortName) ([]domain.UserId, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getShadowbannedUsers(q, board)
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+func (s *Storage) shadowbanUser(q Querier, board domain.BoardShortName, userId, createdBy domain.UserId) error {
+	if userId == createdBy {
+		return &internal_errors.ErrorWithStatusCode{
+			Message:    "Cannot shadowban yourself",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	result, err := q.Exec(`
+		INSERT INTO user_shadowbans (board, user_id, created_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (board, user_id) DO NOTHING`,
+		board, userId, createdBy,
+	)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return &internal_errors.ErrorWithStatusCode{
+				Message: "Board or user not found", StatusCode: http.StatusNotFound,
+			}
+		}
+		return fmt.Errorf("failed to shadowban user %d on board '%s': %w", userId, board, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil
+	}
+
+	return s.touchShadowbannedContent(q, board, userId)
+}
+
+func (s *Storage) unshadowbanUser(q Querier, board domain.BoardShortName, userId domain.UserId) error {
+	result, err := q.Exec(
+		`DELETE FROM user_shadowbans WHERE board = $1 AND user_id = $2`,
+		board, userId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to unshadowban user %d on board '%s': %w", userId, board, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &internal_errors.ErrorWithStatusCode{
+			Message: "User is not shadowbanned on this board", StatusCode: http.StatusNotFound,
+		}
+	}
+
+	return s.touchShadowbannedContent(q, board, userId)
+}
+
+// touchShadowbannedContent marks the board and every thread the user posted in
+// as modified, so cached board and thread pages are not served with the user's
+// messages in their previous visibility.
+func (s *Storage) touchShadowbannedContent(q Querier, board domain.BoardShortName, userId domain.UserId) error {
+	_, err := q.Exec(`
+		UPDATE threads SET last_modified_at = `+sqlNow+`
+		WHERE board = $1 AND id IN (
+			SELECT DISTINCT thread_id FROM messages WHERE board = $1 AND author_id = $2
+		)`,
+		board, userId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to touch threads of user %d on board '%s': %w", userId, board, err)
+	}
+
+	_, err = q.Exec(
+		`UPDATE boards SET last_activity_at = `+sqlNow+` WHERE short_name = $1`,
+		board,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update board activity: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) getShadowbans(q Querier, limit, offset int) ([]domain.Shadowban, error) {
+	rows, err := q.Query(`
+		SELECT board, user_id, COALESCE(created_by, 0), created_at
+		FROM user_shadowbans
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shadowbans: %w", err)
+	}
+	defer rows.Close()
+
+	shadowbans := []domain.Shadowban{}
+	for rows.Next() {
+		var sb domain.Shadowban
+		if err := rows.Scan(&sb.Board, &sb.UserId, &sb.CreatedBy, &sb.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shadowban: %w", err)
+		}
+		shadowbans = append(shadowbans, sb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shadowbans: %w", err)
+	}
+	return shadowbans, nil
+}
+
+func (s *Storage) getShadowbannedUsers(q Querier, board domain.BoardShortName) ([]domain.UserId, error) {
+	rows, err := q.Query(`SELECT user_id FROM user_shadowbans WHERE board = $1`, board)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shadowbanned users on board '%s': %w", board, err)
+	}
+	defer rows.Close()
+
+	var userIds []domain.UserId
+	for rows.Next() {
+		var userId domain.UserId
+		if err := rows.Scan(&userId); err != nil {
+			return nil, fmt.Errorf("failed to scan shadowbanned user ID: %w", err)
+		}
+		userIds = append(userIds, userId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shadowbanned users: %w", err)
+	}
+	return userIds, nil
+}
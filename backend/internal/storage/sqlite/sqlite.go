@@ -0,0 +1,278 @@
+// Package sqlite implements the storage layer on an SQLite database, for small
+// and self-hosted deployments that do not want to operate PostgreSQL.
+// It provides the same service layer storage interfaces as package pg.
+//
+// The package follows the same "Public/Private Method" pattern as package pg:
+// public methods manage the context and the transaction, private methods take a
+// Querier and contain the database logic. The differences come from SQLite
+// itself:
+//   - All boards share one set of tables; there are no per-board partitions.
+//   - Board pages are read directly from the threads and messages tables, so
+//     there are no materialized views and no refresh job.
+//   - Writes are serialized by the database, so no advisory locks are needed.
+//   - Timestamps are stored as text and compare correctly only in UTC, so every
+//     time passed to a query is converted to UTC first.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+	sharedstorage "github.com/itchan-dev/itchan/shared/storage/pg"
+	sharedsqlite "github.com/itchan-dev/itchan/shared/storage/sqlite"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Querier is an alias to the shared Querier interface, see package pg.
+type Querier = sharedstorage.Querier
+
+// Interface satisfaction checks, see package pg.
+var _ service.AuthStorage = (*Storage)(nil)
+var _ service.BoardStorage = (*Storage)(nil)
+var _ service.ThreadStorage = (*Storage)(nil)
+var _ service.MessageStorage = (*Storage)(nil)
+var _ service.UserActivityStorage = (*Storage)(nil)
+var _ service.GCStorage = (*Storage)(nil)
+var _ service.ReferralStorage = (*Storage)(nil)
+var _ service.SiteActivityStorage = (*Storage)(nil)
+var _ service.AppealStorage = (*Storage)(nil)
+var _ service.ModerationStorage = (*Storage)(nil)
+var _ service.ShadowbanStorage = (*Storage)(nil)
+var _ service.NotificationStorage = (*Storage)(nil)
+var _ service.DigestStorage = (*Storage)(nil)
+var _ service.PushStorage = (*Storage)(nil)
+var _ service.MediaLookupStorage = (*Storage)(nil)
+
+//go:embed schema.sql
+var schema string
+
+// maxOpenConns bounds the connection pool. SQLite allows a single writer at a
+// time, so more connections would only queue up on the database lock.
+const maxOpenConns = 8
+
+// Storage is the central struct for the SQLite persistence layer.
+type Storage struct {
+	db  *sql.DB
+	cfg *config.Config
+}
+
+// New opens the SQLite database file and creates the schema if needed.
+// No background processes are started: board pages are always read from the
+// tables directly.
+func New(cfg *config.Config) (*Storage, error) {
+	logger.Log.Info("opening sqlite database", "path", cfg.Private.Sqlite.Path)
+	db, err := sharedsqlite.Connect(cfg, maxOpenConns)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	logger.Log.Info("successfully opened sqlite database")
+	// Queries go through the same Querier wrapper as with PostgreSQL
+	sharedstorage.SetSlowQueryThreshold(cfg.Public.SlowQueryThreshold)
+
+	return &Storage{db, cfg}, nil
+}
+
+// Cleanup closes the database. It should be called during application shutdown.
+func (s *Storage) Cleanup() error {
+	return s.db.Close()
+}
+
+// Ping checks if the database is reachable. Used by health check endpoints.
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// =========================================================================
+// Transaction and Context Helpers
+// =========================================================================
+
+// withTx runs fn in a transaction bound to ctx, see pg.Storage.withTx.
+// Transactions take the write lock when they begin, so concurrent writers
+// wait for each other (up to the busy timeout) instead of failing mid-way.
+func (s *Storage) withTx(ctx context.Context, fn func(Querier) error) error {
+	return sharedstorage.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		return fn(sharedstorage.WithContext(ctx, tx))
+	})
+}
+
+// queryContext derives the context of a storage call from the caller's context,
+// limited by the configured query timeout.
+func (s *Storage) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.cfg.Public.QueryTimeout)
+}
+
+// longQueryContext is queryContext for heavy operations (cascading deletes, statistics).
+func (s *Storage) longQueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.cfg.Public.LongQueryTimeout)
+}
+
+// conn returns the database bound to ctx and the query timeout, for
+// read-only calls that do not need a transaction.
+func (s *Storage) conn(ctx context.Context) (Querier, context.CancelFunc) {
+	ctx, cancel := s.queryContext(ctx)
+	return sharedstorage.WithContext(ctx, s.db), cancel
+}
+
+// sqlNow is the SQL expression for the current time, in the format the
+// driver writes times in. It is the counterpart of NOW() AT TIME ZONE 'utc'.
+const sqlNow = `strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')`
+
+// now returns the current time as stored by the package: UTC, rounded to
+// microseconds like PostgreSQL timestamps.
+func now() time.Time {
+	return time.Now().UTC().Round(time.Microsecond)
+}
+
+// =========================================================================
+// Query Helpers
+// =========================================================================
+
+// placeholders returns "$from, $from+1, ..." for n query arguments, for IN
+// lists built from slices (SQLite has no array parameters).
+func placeholders(from, n int) string {
+	ps := make([]string, n)
+	for i := range ps {
+		ps[i] = fmt.Sprintf("$%d", from+i)
+	}
+	return strings.Join(ps, ", ")
+}
+
+// boardArgs converts board names to query arguments for an IN list.
+func boardArgs(boards []domain.BoardShortName) []any {
+	args := make([]any, len(boards))
+	for i, b := range boards {
+		args[i] = b
+	}
+	return args
+}
+
+// isConstraintError reports whether err is a violation of the given kind of
+// constraint (e.g. sqlite3.SQLITE_CONSTRAINT_UNIQUE).
+func isConstraintError(err error, code int) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == code
+}
+
+// isUniqueViolation matches both unique and primary key violations, which
+// PostgreSQL reports with the same code.
+func isUniqueViolation(err error) bool {
+	return isConstraintError(err, sqlite3.SQLITE_CONSTRAINT_UNIQUE) ||
+		isConstraintError(err, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
+
+func isForeignKeyViolation(err error) bool {
+	return isConstraintError(err, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY)
+}
+
+// deleteFiles removes file records by id. Like in package pg it is best-effort:
+// leftovers are removed by the media garbage collector.
+func deleteFiles(q Querier, fileIDs []int64, logArgs ...any) {
+	if len(fileIDs) == 0 {
+		return
+	}
+	args := make([]any, len(fileIDs))
+	for i, id := range fileIDs {
+		args[i] = id
+	}
+	if _, err := q.Exec(`DELETE FROM files WHERE id IN (`+placeholders(1, len(args))+`)`, args...); err != nil {
+		logger.Log.Warn("failed to delete file records", append(logArgs, "file_count", len(fileIDs), "error", err)...)
+	}
+}
+
+// fileIDs collects the ids of the files attached to the rows selected by
+// `WHERE <condition>` on the attachments table, before they are cascade-deleted.
+func fileIDs(q Querier, condition string, args ...any) ([]int64, error) {
+	rows, err := q.Query(`SELECT DISTINCT file_id FROM attachments WHERE `+condition, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan file ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating file IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// =========================================================================
+// Media Garbage Collection Methods
+// =========================================================================
+
+// GetAllFilePaths returns all file, thumbnail and banner paths stored in the database.
+func (s *Storage) GetAllFilePaths(ctx context.Context) ([]string, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+	q := sharedstorage.WithContext(ctx, s.db)
+
+	rows, err := q.Query(`
+		SELECT file_path FROM files WHERE file_path IS NOT NULL
+		UNION
+		SELECT thumbnail_path FROM files WHERE thumbnail_path IS NOT NULL
+		UNION
+		SELECT file_path FROM board_banners
+		UNION
+		SELECT file_path FROM thread_snapshots
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query file paths: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan file path: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating file paths: %w", err)
+	}
+	return paths, nil
+}
+
+// DeleteOrphanedFileRecords deletes file records not referenced by any
+// attachment, pending upload or queued post and returns the number of records deleted.
+func (s *Storage) DeleteOrphanedFileRecords(ctx context.Context) (int64, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+	q := sharedstorage.WithContext(ctx, s.db)
+
+	result, err := q.Exec(`
+		DELETE FROM files
+		WHERE NOT EXISTS (SELECT 1 FROM attachments a WHERE a.file_id = files.id)
+		AND NOT EXISTS (SELECT 1 FROM pending_uploads u WHERE u.file_id = files.id)
+		AND NOT EXISTS (SELECT 1 FROM premod_queue_files p WHERE p.file_id = files.id)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete orphaned file records: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected, nil
+}
@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// threadSnapshotColumns selects a snapshot in the order scanThreadSnapshot expects.
+const threadSnapshotColumns = `id, board, thread_id, title, file_path, size_bytes, message_count, created_by, created_at`
+
+// =========================================================================
+// Public Methods (satisfy the service.ThreadSnapshotStorage interface)
+// =========================================================================
+
+// AddThreadSnapshot records a snapshot whose file is already in the media storage.
+func (s *Storage) AddThreadSnapshot(ctx context.Context, snapshot domain.ThreadSnapshot) (domain.ThreadSnapshotId, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var id domain.ThreadSnapshotId
+	err := q.QueryRow(`
+		INSERT INTO thread_snapshots (board, thread_id, title, file_path, size_bytes, message_count, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
+		snapshot.Board, snapshot.ThreadId, snapshot.Title, snapshot.FilePath, snapshot.SizeBytes,
+		snapshot.MessageCount, snapshot.CreatedBy, now(),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add snapshot of thread %d: %w", snapshot.ThreadId, err)
+	}
+	return id, nil
+}
+
+// GetThreadSnapshot returns a snapshot of the board.
+func (s *Storage) GetThreadSnapshot(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) (domain.ThreadSnapshot, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	snapshot, err := scanThreadSnapshot(q.QueryRow(`
+		SELECT `+threadSnapshotColumns+`
+		FROM thread_snapshots
+		WHERE board = $1 AND id = $2`,
+		board, id,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ThreadSnapshot{}, &internal_errors.ErrorWithStatusCode{Message: "Snapshot not found", StatusCode: http.StatusNotFound}
+		}
+		return domain.ThreadSnapshot{}, fmt.Errorf("failed to fetch snapshot %d: %w", id, err)
+	}
+	return snapshot, nil
+}
+
+// GetThreadSnapshots returns a page of the snapshots of the board, or of one
+// of its threads when threadId is set, newest first.
+func (s *Storage) GetThreadSnapshots(ctx context.Context, board domain.BoardShortName, threadId *domain.ThreadId, limit, offset int) ([]domain.ThreadSnapshot, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	rows, err := q.Query(`
+		SELECT `+threadSnapshotColumns+`
+		FROM thread_snapshots
+		WHERE board = $1 AND ($2 IS NULL OR thread_id = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4`,
+		board, threadId, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch snapshots of board '%s': %w", board, err)
+	}
+	defer rows.Close()
+
+	snapshots := []domain.ThreadSnapshot{}
+	for rows.Next() {
+		snapshot, err := scanThreadSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+// DeleteThreadSnapshot removes a snapshot and returns the path of its file,
+// which the caller deletes from the media storage.
+func (s *Storage) DeleteThreadSnapshot(ctx context.Context, board domain.BoardShortName, id domain.ThreadSnapshotId) (string, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var filePath string
+	err := q.QueryRow(`
+		DELETE FROM thread_snapshots WHERE board = $1 AND id = $2
+		RETURNING file_path`,
+		board, id,
+	).Scan(&filePath)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", &internal_errors.ErrorWithStatusCode{Message: "Snapshot not found", StatusCode: http.StatusNotFound}
+		}
+		return "", fmt.Errorf("failed to delete snapshot %d of board '%s': %w", id, board, err)
+	}
+	return filePath, nil
+}
+
+// =========================================================================
+// Internal Methods
+// =========================================================================
+
+// scanThreadSnapshot scans one row selected with threadSnapshotColumns.
+func scanThreadSnapshot(scanner interface {
+	Scan(dest ...any) error
+}) (domain.ThreadSnapshot, error) {
+	var snapshot domain.ThreadSnapshot
+	var createdBy sql.NullInt64
+	if err := scanner.Scan(
+		&snapshot.Id, &snapshot.Board, &snapshot.ThreadId, &snapshot.Title, &snapshot.FilePath,
+		&snapshot.SizeBytes, &snapshot.MessageCount, &createdBy, &snapshot.CreatedAt,
+	); err != nil {
+		return domain.ThreadSnapshot{}, err
+	}
+	if createdBy.Valid {
+		userId := domain.UserId(createdBy.Int64)
+		snapshot.CreatedBy = &userId
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	sharedstorage "github.com/itchan-dev/itchan/shared/storage/pg"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.MessageStorage and GCStorage interfaces)
+// =========================================================================
+
+// SavePendingUpload records a file uploaded ahead of its post under the
+// upload's token. The file row is kept out of the orphan cleanup for as long
+// as the token exists.
+func (s *Storage) SavePendingUpload(ctx context.Context, upload domain.PendingUpload) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.withTx(ctx, func(tx Querier) error {
+		return s.savePendingUpload(tx, upload)
+	})
+}
+
+// DeleteExpiredUploads forgets uploads created before the given time that no
+// post has claimed. Their files are left to the orphan cleanup.
+func (s *Storage) DeleteExpiredUploads(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+
+	q := sharedstorage.WithContext(ctx, s.db)
+
+	result, err := q.Exec(`DELETE FROM pending_uploads WHERE created_at < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired uploads: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected, nil
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+func (s *Storage) savePendingUpload(q Querier, upload domain.PendingUpload) error {
+	fileId, err := s.insertFile(q, upload.File)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(`
+		INSERT INTO pending_uploads (token, board, user_id, file_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		upload.Token, upload.Board, upload.UserId, fileId, upload.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save pending upload: %w", err)
+	}
+	return nil
+}
+
+// claimUploads attaches the uploads referenced by the message's tokens to the
+// message and removes the tokens, so each upload is posted at most once. A
+// token of another user or board counts as unknown.
+func (s *Storage) claimUploads(q Querier, creationData domain.MessageCreationData, messageID domain.MsgId) error {
+	for _, token := range creationData.UploadTokens {
+		fileId, err := s.claimUpload(q, creationData.Board, creationData.Author.Id, token)
+		if err != nil {
+			return err
+		}
+		if err := s.insertAttachment(q, creationData.Board, creationData.ThreadId, messageID, fileId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// claimUpload removes a pending upload of the user on the board and returns
+// its file.
+func (s *Storage) claimUpload(q Querier, board domain.BoardShortName, userId domain.UserId, token domain.UploadToken) (domain.FileId, error) {
+	var fileId domain.FileId
+	err := q.QueryRow(`
+		DELETE FROM pending_uploads
+		WHERE token = $1 AND board = $2 AND user_id = $3
+		RETURNING file_id`,
+		token, board, userId,
+	).Scan(&fileId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, &internal_errors.ErrorWithStatusCode{
+				Message:    "An uploaded file has expired or was already posted, please attach it again",
+				StatusCode: http.StatusConflict,
+			}
+		}
+		return 0, fmt.Errorf("failed to claim upload: %w", err)
+	}
+	return fileId, nil
+}
@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	sharedstorage "github.com/itchan-dev/itchan/shared/storage/pg"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetUserMessages fetches user's last N messages across all boards.
+// Returns FULLY enriched domain.Message objects with Author, Attachments, Replies.
+func (s *Storage) GetUserMessages(ctx context.Context, userId domain.UserId, limit int) ([]domain.Message, error) {
+	ctx, cancel := s.longQueryContext(ctx)
+	defer cancel()
+	q := sharedstorage.WithContext(ctx, s.db)
+
+	// Step 1: Fetch messages with author data
+	rows, err := q.Query(`
+		SELECT
+			m.id, m.board, m.thread_id, m.text, m.created_at,
+			u.id, u.email_domain, u.is_admin
+		FROM messages m
+		JOIN users u ON m.author_id = u.id
+		WHERE m.author_id = $1
+		ORDER BY m.created_at DESC
+		LIMIT $2
+	`, userId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user messages: %w", err)
+	}
+	defer rows.Close()
+
+	// Build data structures for enrichment
+	// First collect all messages (slice might reallocate during append)
+	var messages []domain.Message
+
+	for rows.Next() {
+		var msg domain.Message
+		var author domain.User
+		err := rows.Scan(
+			&msg.Id, &msg.Board, &msg.ThreadId, &msg.Text, &msg.CreatedAt,
+			&author.Id, &author.EmailDomain, &author.Admin,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user message: %w", err)
+		}
+		msg.Author = author
+		msg.Replies = domain.Replies{}
+		msg.Attachments = domain.Attachments{}
+
+		messages = append(messages, msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user messages: %w", err)
+	}
+
+	// Now build boardToMessages map with stable pointers (slice won't reallocate anymore)
+	boardToMessages := make(map[domain.BoardShortName][]*domain.Message)
+	for i := range messages {
+		msgPtr := &messages[i]
+		boardToMessages[msgPtr.Board] = append(boardToMessages[msgPtr.Board], msgPtr)
+	}
+
+	// Step 2: Enrich board-by-board with board-specific maps
+	for board, boardMessages := range boardToMessages {
+		// Build board-specific map using (threadId, msgId) as key
+		idToMessage := make(map[MsgKey]*domain.Message)
+		messageKeys := make([]MsgKey, 0, len(boardMessages))
+
+		for _, msg := range boardMessages {
+			key := MsgKey{ThreadId: msg.ThreadId, MsgId: msg.Id}
+			idToMessage[key] = msg
+			messageKeys = append(messageKeys, key)
+		}
+
+		// Enrich with replies and attachments for this board only
+		if err := enrichMessages(q, board, messageKeys, idToMessage, s.cfg.Public.MessagesPerThreadPage, false); err != nil {
+			return nil, fmt.Errorf("failed to enrich board %s: %w", board, err)
+		}
+	}
+
+	// Return empty slice instead of nil
+	if messages == nil {
+		messages = []domain.Message{}
+	}
+
+	return messages, nil
+}
+
+// GetUserPosts returns one page of the user's posts on the given boards, newest
+// first, and the total number of such posts, see pg.Storage.GetUserPosts.
+func (s *Storage) GetUserPosts(ctx context.Context, userId domain.UserId, boards []domain.BoardShortName, limit, offset int) ([]domain.LatestPost, int, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	posts := []domain.LatestPost{}
+	if len(boards) == 0 {
+		return posts, 0, nil
+	}
+
+	var total int
+	err := q.QueryRow(`
+		SELECT count(*) FROM messages
+		WHERE author_id = $1 AND board IN (`+placeholders(2, len(boards))+`)`,
+		append([]any{userId}, boardArgs(boards)...)...,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count user posts: %w", err)
+	}
+
+	rows, err := q.Query(`
+		SELECT m.board, m.thread_id, t.title, m.id, m.text, m.created_at
+		FROM messages m
+		JOIN threads t ON t.board = m.board AND t.id = m.thread_id
+		WHERE m.author_id = $1 AND m.board IN (`+placeholders(4, len(boards))+`)
+		ORDER BY m.created_at DESC
+		LIMIT $2 OFFSET $3`,
+		append([]any{userId, limit, offset}, boardArgs(boards)...)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch user posts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p domain.LatestPost
+		if err := rows.Scan(&p.Board, &p.ThreadId, &p.ThreadTitle, &p.Id, &p.Text, &p.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user post: %w", err)
+		}
+		p.Page = utils.CalculatePage(int(p.Id), s.cfg.Public.MessagesPerThreadPage)
+		posts = append(posts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating user posts: %w", err)
+	}
+	return posts, total, nil
+}
+
+// GetUserStats counts the user's posts, started threads and uploaded
+// attachments, see pg.Storage.GetUserStats.
+func (s *Storage) GetUserStats(ctx context.Context, userId domain.UserId) (domain.UserStats, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+
+	var stats domain.UserStats
+	err := q.QueryRow(`
+		SELECT
+			count(*),
+			coalesce(sum(m.id = 1), 0),
+			(SELECT count(*) FROM attachments a
+			 JOIN messages am ON am.board = a.board AND am.thread_id = a.thread_id AND am.id = a.message_id
+			 WHERE am.author_id = $1)
+		FROM messages m
+		WHERE m.author_id = $1`,
+		userId,
+	).Scan(&stats.Posts, &stats.ThreadsStarted, &stats.Attachments)
+	if err != nil {
+		return domain.UserStats{}, fmt.Errorf("failed to get user stats: %w", err)
+	}
+
+	if stats.FirstPostAt, err = s.getFirstMessageTime(q, userId); err != nil {
+		return domain.UserStats{}, err
+	}
+	return stats, nil
+}
+
+// getFirstMessageTime returns the creation time of the user's oldest message.
+func (s *Storage) getFirstMessageTime(q Querier, userId domain.UserId) (*time.Time, error) {
+	// Like getLastMessageTime, the row is selected to keep the timestamp type.
+	var first time.Time
+	err := q.QueryRow(`
+		SELECT created_at FROM messages WHERE author_id = $1
+		ORDER BY created_at LIMIT 1`,
+		userId,
+	).Scan(&first)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query first message time: %w", err)
+	}
+	return &first, nil
+}
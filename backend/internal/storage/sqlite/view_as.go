@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// =========================================================================
+// Public Methods (satisfy the service.ViewAsStorage interface)
+// =========================================================================
+
+// GetUserById fetches the account an admin wants to view the site as, or
+// whose session is being renewed. Only the fields that go into a token are
+// loaded.
+func (s *Storage) GetUserById(ctx context.Context, id domain.UserId) (domain.User, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getUserById(q, id)
+}
+
+// SaveViewAsSession records the start of a view-as session and returns its id.
+func (s *Storage) SaveViewAsSession(ctx context.Context, session domain.ViewAsSession) (domain.ViewAsSessionId, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	var id domain.ViewAsSessionId
+	err := s.withTx(ctx, func(tx Querier) error {
+		var err error
+		id, err = s.saveViewAsSession(tx, session)
+		return err
+	})
+	return id, err
+}
+
+// GetViewAsSessions lists view-as sessions, newest first, for the admin panel.
+func (s *Storage) GetViewAsSessions(ctx context.Context, limit, offset int) ([]domain.ViewAsSession, error) {
+	q, cancel := s.conn(ctx)
+	defer cancel()
+	return s.getViewAsSessions(q, limit, offset)
+}
+
+// =========================================================================
+// Internal Methods (Core Database Logic)
+// These methods accept a Querier and are transaction-agnostic.
+// =========================================================================
+
+func (s *Storage) getUserById(q Querier, id domain.UserId) (domain.User, error) {
+	var user domain.User
+	err := q.QueryRow(
+		"SELECT id, email_domain, is_admin, created_at FROM users WHERE id = $1",
+		id,
+	).Scan(&user.Id, &user.EmailDomain, &user.Admin, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, &internal_errors.ErrorWithStatusCode{Message: "User not found", StatusCode: http.StatusNotFound}
+		}
+		return domain.User{}, fmt.Errorf("failed to query user %d: %w", id, err)
+	}
+	return user, nil
+}
+
+func (s *Storage) saveViewAsSession(q Querier, session domain.ViewAsSession) (domain.ViewAsSessionId, error) {
+	var id domain.ViewAsSessionId
+	err := q.QueryRow(`
+		INSERT INTO view_as_sessions (admin_id, user_id, reason, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		session.AdminId, session.UserId, session.Reason, session.ExpiresAt.UTC(),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save view-as session of admin %d: %w", session.AdminId, err)
+	}
+	return id, nil
+}
+
+func (s *Storage) getViewAsSessions(q Querier, limit, offset int) ([]domain.ViewAsSession, error) {
+	rows, err := q.Query(`
+		SELECT id, admin_id, user_id, reason, created_at, expires_at
+		FROM view_as_sessions
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query view-as sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []domain.ViewAsSession{}
+	for rows.Next() {
+		var session domain.ViewAsSession
+		if err := rows.Scan(&session.Id, &session.AdminId, &session.UserId, &session.Reason, &session.CreatedAt, &session.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan view-as session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating view-as sessions: %w", err)
+	}
+	return sessions, nil
+}
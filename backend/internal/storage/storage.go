@@ -0,0 +1,67 @@
+// Package storage selects the database backend configured for the API server.
+package storage
+
+import (
+	"context"
+
+	"github.com/itchan-dev/itchan/backend/internal/service"
+	"github.com/itchan-dev/itchan/backend/internal/storage/pg"
+	"github.com/itchan-dev/itchan/backend/internal/storage/sqlite"
+	"github.com/itchan-dev/itchan/shared/blacklist"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/middleware/board_access"
+)
+
+// Storage is everything the API server needs from its database: the storage
+// interfaces of the services and the caches, plus health checks and shutdown.
+type Storage interface {
+	service.AuthStorage
+	service.BoardStorage
+	service.BoardAppearanceStorage
+	service.ThreadStorage
+	service.MessageStorage
+	service.UserActivityStorage
+	service.GCStorage
+	service.CleanupStorage
+	service.ReferralStorage
+	service.SiteActivityStorage
+	service.AppealStorage
+	service.ModerationStorage
+	service.ShadowbanStorage
+	service.ViewAsStorage
+	service.ModLogStorage
+	service.NotificationStorage
+	service.DigestStorage
+	service.PushStorage
+	service.MediaLookupStorage
+	service.DiskMonitorStorage
+	service.BoardStatsStorage
+	service.PremodStorage
+	service.BookmarkStorage
+	service.MirrorStorage
+	service.UserImportStorage
+	service.EmailKeyRotationStorage
+	service.DirectorySyncStorage
+	service.IdempotencyStorage
+	service.MaintenanceStorage
+	service.FailedPostStorage
+	service.ThreadSnapshotStorage
+	service.BackupStorage
+	board_access.Storage
+	blacklist.BlacklistCacheStorage
+
+	Ping(ctx context.Context) error
+	Cleanup() error
+}
+
+var _ Storage = (*pg.Storage)(nil)
+var _ Storage = (*sqlite.Storage)(nil)
+
+// New opens the storage selected by cfg.Private.Storage. Background processes
+// of the backend (e.g. materialized view refreshes) are bound to ctx.
+func New(ctx context.Context, cfg *config.Config) (Storage, error) {
+	if cfg.Private.Storage == config.StorageSqlite {
+		return sqlite.New(cfg)
+	}
+	return pg.New(ctx, cfg)
+}
@@ -0,0 +1,173 @@
+// Package directory reads who is still a member of the organization from an
+// external directory, either a plain list of emails or a SCIM 2.0 service.
+package directory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// FormatList is a URL answering a JSON array of emails or one email per
+	// line; blank lines and lines starting with # are skipped.
+	FormatList = "list"
+	// FormatSCIM is the base URL of a SCIM 2.0 service, read through its
+	// /Users resource.
+	FormatSCIM = "scim"
+)
+
+// scimPageSize is the count asked for in each page of SCIM users.
+const scimPageSize = 100
+
+// maxListBytes bounds a list response; a directory of a million addresses
+// fits comfortably.
+const maxListBytes = 64 << 20
+
+type Client struct {
+	url    string
+	token  string
+	format string
+	client *http.Client
+}
+
+// New returns a client for the directory at endpoint. A non-empty token is
+// sent as a bearer token.
+func New(endpoint, token, format string, timeout time.Duration) *Client {
+	return &Client{
+		url:    strings.TrimRight(endpoint, "/"),
+		token:  token,
+		format: format,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Emails returns the lowercased emails of the directory's active members. A
+// SCIM user counts with all of their emails, and their userName when it is
+// an email.
+func (c *Client) Emails(ctx context.Context) ([]string, error) {
+	if c.format == FormatSCIM {
+		return c.scimEmails(ctx)
+	}
+	return c.listEmails(ctx)
+}
+
+func (c *Client) listEmails(ctx context.Context) ([]string, error) {
+	resp, err := c.get(ctx, c.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxListBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory list: %w", err)
+	}
+
+	var emails []string
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &emails); err != nil {
+			return nil, fmt.Errorf("failed to decode directory list: %w", err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			emails = append(emails, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read directory list: %w", err)
+		}
+	}
+
+	result := make([]string, 0, len(emails))
+	for _, email := range emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email == "" || strings.HasPrefix(email, "#") {
+			continue
+		}
+		result = append(result, email)
+	}
+	return result, nil
+}
+
+type scimListResponse struct {
+	TotalResults int        `json:"totalResults"`
+	Resources    []scimUser `json:"Resources"`
+}
+
+type scimUser struct {
+	UserName string `json:"userName"`
+	Active   *bool  `json:"active"` // Users without the attribute count as active
+	Emails   []struct {
+		Value string `json:"value"`
+	} `json:"emails"`
+}
+
+func (c *Client) scimEmails(ctx context.Context) ([]string, error) {
+	var emails []string
+	for startIndex := 1; ; {
+		query := url.Values{"startIndex": {fmt.Sprint(startIndex)}, "count": {fmt.Sprint(scimPageSize)}}
+		resp, err := c.get(ctx, c.url+"/Users?"+query.Encode())
+		if err != nil {
+			return nil, err
+		}
+		var page scimListResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode SCIM users: %w", err)
+		}
+
+		for _, user := range page.Resources {
+			if user.Active != nil && !*user.Active {
+				continue
+			}
+			if strings.Contains(user.UserName, "@") {
+				emails = append(emails, strings.ToLower(strings.TrimSpace(user.UserName)))
+			}
+			for _, e := range user.Emails {
+				if e.Value != "" {
+					emails = append(emails, strings.ToLower(strings.TrimSpace(e.Value)))
+				}
+			}
+		}
+
+		startIndex += len(page.Resources)
+		if len(page.Resources) == 0 || startIndex > page.TotalResults {
+			return emails, nil
+		}
+	}
+}
+
+// get returns the response of a 2xx answer; any other status is an error and
+// its body is closed.
+func (c *Client) get(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.format == FormatSCIM {
+		req.Header.Set("Accept", "application/scim+json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch directory: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("directory returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,76 @@
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEmails(t *testing.T) {
+	t.Run("one email per line", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			fmt.Fprint(w, "# exported from HR\nAlice@Corp.com\n\n  bob@corp.com\n")
+		}))
+		defer server.Close()
+
+		emails, err := New(server.URL, "secret", FormatList, time.Second).Emails(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice@corp.com", "bob@corp.com"}, emails)
+	})
+
+	t.Run("json array", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get("Authorization"))
+			fmt.Fprint(w, ` ["alice@corp.com", "BOB@corp.com"]`)
+		}))
+		defer server.Close()
+
+		emails, err := New(server.URL, "", FormatList, time.Second).Emails(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice@corp.com", "bob@corp.com"}, emails)
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "token expired", http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		_, err := New(server.URL, "", FormatList, time.Second).Emails(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+		assert.Contains(t, err.Error(), "token expired")
+	})
+}
+
+func TestSCIMEmails(t *testing.T) {
+	users := []map[string]any{
+		{"userName": "alice@corp.com", "active": true, "emails": []map[string]any{{"value": "a.smith@corp.com"}}},
+		{"userName": "bob", "emails": []map[string]any{{"value": "Bob@corp.com"}}},
+		{"userName": "carol@corp.com", "active": false},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/scim/v2/Users", r.URL.Path)
+		start, _ := strconv.Atoi(r.URL.Query().Get("startIndex"))
+		// Serve two users per page regardless of count, as servers may cap it
+		end := min(start+1, len(users))
+		json.NewEncoder(w).Encode(map[string]any{
+			"totalResults": len(users),
+			"Resources":    users[start-1 : end],
+		})
+	}))
+	defer server.Close()
+
+	emails, err := New(server.URL+"/scim/v2/", "", FormatSCIM, time.Second).Emails(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@corp.com", "a.smith@corp.com", "bob@corp.com"}, emails)
+}
@@ -0,0 +1,104 @@
+// Package federation reads boards of other itchan instances through their
+// public API. It only reads; nothing is ever posted to the other instance.
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// ErrNotFound is returned when the other instance answers 404, so callers
+// can tell a deleted thread from an unreachable instance.
+var ErrNotFound = errors.New("not found on the source instance")
+
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// New returns a client for the instance at baseURL, which serves /v1 and
+// /media. A trailing slash is ignored.
+func New(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// GetBoard returns one page of the board's thread listing. A page past the
+// last one has no threads.
+func (c *Client) GetBoard(ctx context.Context, board domain.BoardShortName, page int) (domain.Board, error) {
+	var result domain.Board
+	path := fmt.Sprintf("/v1/%s?page=%d", url.PathEscape(board), page)
+	if err := c.getJSON(ctx, path, &result); err != nil {
+		return domain.Board{}, err
+	}
+	return result, nil
+}
+
+// GetThread returns one page of a thread.
+func (c *Client) GetThread(ctx context.Context, board domain.BoardShortName, id domain.ThreadId, page int) (domain.Thread, error) {
+	var result domain.Thread
+	path := fmt.Sprintf("/v1/%s/%d?page=%d", url.PathEscape(board), id, page)
+	if err := c.getJSON(ctx, path, &result); err != nil {
+		return domain.Thread{}, err
+	}
+	return result, nil
+}
+
+// GetMedia opens a media file by its public path (File.MediaURL). The caller
+// closes the body.
+func (c *Client) GetMedia(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// get returns the response of a 2xx answer; any other status is an error and
+// its body is closed.
+func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", path, ErrNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s returned %d: %s", path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
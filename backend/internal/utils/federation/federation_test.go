@@ -0,0 +1,92 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBoard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/b", r.URL.Path)
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		json.NewEncoder(w).Encode(domain.Board{
+			BoardMetadata: domain.BoardMetadata{ShortName: "b"},
+			Threads:       []*domain.Thread{{ThreadMetadata: domain.ThreadMetadata{Id: 7, Title: "hello"}}},
+		})
+	}))
+	defer server.Close()
+
+	board, err := New(server.URL+"/", time.Second).GetBoard(context.Background(), "b", 2)
+	require.NoError(t, err)
+	require.Len(t, board.Threads, 1)
+	assert.Equal(t, domain.ThreadId(7), board.Threads[0].Id)
+	assert.Equal(t, domain.ThreadTitle("hello"), board.Threads[0].Title)
+}
+
+func TestGetThread(t *testing.T) {
+	t.Run("decodes messages and pagination", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/b/7", r.URL.Path)
+			json.NewEncoder(w).Encode(domain.Thread{
+				ThreadMetadata: domain.ThreadMetadata{Id: 7},
+				Messages:       []*domain.Message{{MessageMetadata: domain.MessageMetadata{Id: 1, PostNumber: 12}, Text: "<p>op</p>"}},
+				Pagination:     &domain.ThreadPagination{CurrentPage: 1, TotalPages: 3},
+			})
+		}))
+		defer server.Close()
+
+		thread, err := New(server.URL, time.Second).GetThread(context.Background(), "b", 7, 1)
+		require.NoError(t, err)
+		require.Len(t, thread.Messages, 1)
+		assert.Equal(t, domain.PostNumber(12), thread.Messages[0].PostNumber)
+		assert.Equal(t, "<p>op</p>", thread.Messages[0].Text)
+		assert.Equal(t, 3, thread.Pagination.TotalPages)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Thread not found", http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := New(server.URL, time.Second).GetThread(context.Background(), "b", 7, 1)
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		_, err := New(server.URL, time.Second).GetThread(context.Background(), "b", 7, 1)
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrNotFound))
+		assert.Contains(t, err.Error(), "500")
+	})
+}
+
+func TestGetMedia(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/media/b/7/a.png", r.URL.Path)
+		w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	body, err := New(server.URL, time.Second).GetMedia(context.Background(), "/media/b/7/a.png")
+	require.NoError(t, err)
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+}
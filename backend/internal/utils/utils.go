@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image"
 	"net/http"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/itchan-dev/itchan/shared/errors"
 	"golang.org/x/image/draw"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
 )
 
 func IsLetter(s string) bool {
@@ -44,14 +47,52 @@ func (e *BoardNameValidator) ShortName(name string) error {
 	return nil
 }
 
+func (e *BoardNameValidator) CategoryName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return &errors.ErrorWithStatusCode{Message: "Category name is required", StatusCode: 400}
+	}
+	if utf8.RuneCountInString(name) > e.Сfg.BoardCategoryNameMaxLen {
+		return &errors.ErrorWithStatusCode{Message: "Category name is too long", StatusCode: 400}
+	}
+	return nil
+}
+
+func (e *BoardNameValidator) Description(description string) error {
+	if utf8.RuneCountInString(description) > e.Сfg.BoardDescriptionMaxLen {
+		return &errors.ErrorWithStatusCode{Message: "Description is too long", StatusCode: 400}
+	}
+	return nil
+}
+
 func New(cfg *config.Public) *BoardNameValidator {
 	return &BoardNameValidator{Сfg: cfg}
 }
 
+// TextLength counts text against the length limits of a board in language.
+// On CJK boards wide and fullwidth characters count twice, as each says about
+// as much as a short word does elsewhere; other boards count characters.
+func TextLength(text string, language domain.BoardLanguage) int {
+	if !domain.IsCJK(language) {
+		return utf8.RuneCountInString(text)
+	}
+	length := 0
+	for _, r := range text {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			length += 2
+		default:
+			length++
+		}
+	}
+	return length
+}
+
 type ThreadTitleValidator struct{ Сfg *config.Public }
 
-func (e *ThreadTitleValidator) Title(name string) error {
-	if utf8.RuneCountInString(name) > e.Сfg.ThreadTitleMaxLen {
+// Title checks the title against the length limit, counted for the board's
+// language.
+func (e *ThreadTitleValidator) Title(name string, language domain.BoardLanguage) error {
+	if TextLength(name, language) > e.Сfg.ThreadTitleMaxLen {
 		return &errors.ErrorWithStatusCode{Message: "Name is too long", StatusCode: 400}
 	}
 	return nil
@@ -59,32 +100,125 @@ func (e *ThreadTitleValidator) Title(name string) error {
 
 type MessageValidator struct{ Сfg *config.Public }
 
-func (e *MessageValidator) Text(text string) error {
-	runeCount := utf8.RuneCountInString(text)
+// Text normalizes the message text to NFC and checks the result against the
+// length, line and repeated character limits. The text arrives rendered, so
+// lines are counted by their <br> and, in code blocks, newline separators.
+// Length is counted for the board's language, see TextLength.
+func (e *MessageValidator) Text(text string, language domain.BoardLanguage) (string, error) {
+	text = norm.NFC.String(text)
+	length := TextLength(text, language)
 
-	if runeCount > e.Сfg.MessageTextMaxLen {
-		return &errors.ErrorWithStatusCode{Message: "Text is too long", StatusCode: 400}
+	if length > e.Сfg.MessageTextMaxLen {
+		return "", &errors.ErrorWithStatusCode{Message: "Text is too long", StatusCode: 400}
 	}
 
-	if runeCount < e.Сfg.MessageTextMinLen {
-		return &errors.ErrorWithStatusCode{Message: "Text is too short", StatusCode: 400}
+	if length < e.Сfg.MessageTextMinLen {
+		return "", &errors.ErrorWithStatusCode{Message: "Text is too short", StatusCode: 400}
 	}
 
-	return nil
+	lines := strings.Count(text, "<br>") + strings.Count(text, "\n") + 1
+	if lines > e.Сfg.MessageTextMaxLines {
+		return "", &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Text has too many lines: max %d allowed", e.Сfg.MessageTextMaxLines),
+			StatusCode: 400,
+		}
+	}
+
+	if longestRun(text) > e.Сfg.MessageTextMaxRepeat {
+		return "", &errors.ErrorWithStatusCode{
+			Message:    fmt.Sprintf("Text repeats a character too many times in a row: max %d allowed", e.Сfg.MessageTextMaxRepeat),
+			StatusCode: 400,
+		}
+	}
+
+	return text, nil
 }
 
-// PendingFiles checks if pending files meet the configured constraints
-func (e *MessageValidator) PendingFiles(files []*domain.PendingFile) error {
-	if files == nil {
-		return nil
+// FilterText applies a board's text filter. Zero-width characters, bidi
+// controls and combining marks stacked beyond MessageTextMaxMarks on one
+// character are removed, or reject the text with TextFilterReject. Zero-width
+// joiners next to a non-ASCII character are kept, since emoji sequences and
+// some scripts need them.
+func (e *MessageValidator) FilterText(text string, filter domain.TextFilter) (string, error) {
+	if filter == domain.TextFilterOff {
+		return text, nil
 	}
 
-	// Check max count
-	if len(files) > e.Сfg.MaxAttachmentsPerMessage {
-		return &errors.ErrorWithStatusCode{
-			Message:    fmt.Sprintf("too many attachments: max %d allowed", e.Сfg.MaxAttachmentsPerMessage),
-			StatusCode: 400,
+	runes := []rune(text)
+	var b strings.Builder
+	b.Grow(len(text))
+	var hidden, stacked bool
+	marks := 0
+	for i, r := range runes {
+		if unicode.In(r, unicode.Mn, unicode.Me) {
+			marks++
+			if marks > e.Сfg.MessageTextMaxMarks {
+				stacked = true
+				continue
+			}
+		} else {
+			marks = 0
+		}
+		if isHiddenRune(runes, i) {
+			hidden = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	if filter == domain.TextFilterReject {
+		if hidden {
+			return "", &errors.ErrorWithStatusCode{Message: "Text contains invisible or text direction characters", StatusCode: 400}
+		}
+		if stacked {
+			return "", &errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("Text stacks too many marks on one character: max %d allowed", e.Сfg.MessageTextMaxMarks),
+				StatusCode: 400,
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+func isHiddenRune(runes []rune, i int) bool {
+	switch r := runes[i]; {
+	case r == '\u200b', r == '\u2060', r == '\ufeff', r == '\u180e', r == '\u00ad': // zero-width space, word joiner, BOM, Mongolian vowel separator, soft hyphen
+		return true
+	case r == '\u061c', r == '\u200e', r == '\u200f', r >= '\u202a' && r <= '\u202e', r >= '\u2066' && r <= '\u2069': // bidi marks, embeddings, overrides and isolates
+		return true
+	case isJoiner(r): // zero-width non-joiner and joiner
+		joins := func(j int) bool {
+			return j >= 0 && j < len(runes) && runes[j] >= utf8.RuneSelf && !isJoiner(runes[j])
+		}
+		return !joins(i-1) && !joins(i+1)
+	}
+	return false
+}
+
+func isJoiner(r rune) bool {
+	return r == '\u200c' || r == '\u200d'
+}
+
+// longestRun returns the length of the longest run of one repeated rune.
+func longestRun(s string) int {
+	longest, run := 0, 0
+	var prev rune = -1
+	for _, r := range s {
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
 		}
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+// PendingFiles checks if pending files meet the configured constraints. The
+// attachment count is a board setting and is checked by the message service.
+func (e *MessageValidator) PendingFiles(files []*domain.PendingFile) error {
+	if files == nil {
+		return nil
 	}
 
 	var totalSize int64
@@ -95,6 +229,12 @@ func (e *MessageValidator) PendingFiles(files []*domain.PendingFile) error {
 		if err := e.validateFileMeta(file.MimeType, file.SizeBytes, allowedMimeTypes); err != nil {
 			return err
 		}
+		if file.IsDocument() && file.SizeBytes > e.Сfg.MaxDocumentSizeBytes {
+			return &errors.ErrorWithStatusCode{
+				Message:    fmt.Sprintf("document too large: max %d bytes allowed", e.Сfg.MaxDocumentSizeBytes),
+				StatusCode: 400,
+			}
+		}
 
 		totalSize += file.SizeBytes
 	}
@@ -120,6 +260,9 @@ func (e *MessageValidator) buildAllowedMimeTypes() map[string]bool {
 	for _, mimeType := range e.Сfg.AllowedVideoMimeTypes {
 		allowedMimeTypes[mimeType] = true
 	}
+	for _, mimeType := range e.Сfg.AllowedDocumentMimeTypes {
+		allowedMimeTypes[mimeType] = true
+	}
 
 	return allowedMimeTypes
 }
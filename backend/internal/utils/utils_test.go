@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageValidatorText(t *testing.T) {
+	v := &MessageValidator{Сfg: &config.Public{
+		MessageTextMaxLen:    50,
+		MessageTextMinLen:    1,
+		MessageTextMaxLines:  3,
+		MessageTextMaxRepeat: 5,
+	}}
+
+	t.Run("normalizes to NFC", func(t *testing.T) {
+		text, err := v.Text("cafe\u0301", "")
+		require.NoError(t, err)
+		assert.Equal(t, "caf\u00e9", text)
+	})
+
+	t.Run("length is counted after normalization", func(t *testing.T) {
+		_, err := v.Text(strings.Repeat("e\u0301x", 17), "") // 51 runes, 34 once composed
+		assert.NoError(t, err)
+		_, err = v.Text(strings.Repeat("ab", 26), "")
+		assert.EqualError(t, err, "Text is too long")
+	})
+
+	t.Run("line limit", func(t *testing.T) {
+		_, err := v.Text("one<br>two<br>three", "")
+		assert.NoError(t, err)
+		_, err = v.Text("one<br><pre><code>two\nthree</code></pre><br>four", "")
+		assert.ErrorContains(t, err, "too many lines")
+	})
+
+	t.Run("repeated characters", func(t *testing.T) {
+		_, err := v.Text("aaaaab", "")
+		assert.NoError(t, err)
+		_, err = v.Text("ab"+strings.Repeat("\u00e9", 6), "")
+		assert.ErrorContains(t, err, "repeats a character")
+	})
+
+	t.Run("CJK boards count wide characters twice", func(t *testing.T) {
+		text := strings.Repeat("日本", 13) // 26 characters
+		_, err := v.Text(text, domain.LanguageEnglish)
+		assert.NoError(t, err)
+		_, err = v.Text(text, domain.LanguageJapanese)
+		assert.EqualError(t, err, "Text is too long")
+		_, err = v.Text(strings.Repeat("ab", 25), domain.LanguageChinese)
+		assert.NoError(t, err, "narrow characters count once")
+	})
+}
+
+func TestTextLength(t *testing.T) {
+	assert.Equal(t, 5, TextLength("日本語です", domain.LanguageRussian))
+	assert.Equal(t, 10, TextLength("日本語です", domain.LanguageJapanese))
+	assert.Equal(t, 7, TextLength("한국 ok", domain.LanguageKorean))
+	assert.Equal(t, 7, TextLength("ｈｉ ﾃｽ", domain.LanguageChinese), "fullwidth letters count twice, halfwidth katakana once")
+}
+
+func TestMessageValidatorFilterText(t *testing.T) {
+	v := &MessageValidator{Сfg: &config.Public{MessageTextMaxMarks: 2}}
+	zalgo := "he\u0301\u0302\u0303\u0304llo"
+	hidden := "b\u200bad\u202eword\u200d\u200dx"
+
+	t.Run("strip", func(t *testing.T) {
+		text, err := v.FilterText(zalgo, domain.TextFilterStrip)
+		require.NoError(t, err)
+		assert.Equal(t, "he\u0301\u0302llo", text)
+
+		text, err = v.FilterText(hidden, domain.TextFilterStrip)
+		require.NoError(t, err)
+		assert.Equal(t, "badwordx", text)
+
+		text, err = v.FilterText("", domain.TextFilterStrip)
+		require.NoError(t, err)
+		assert.Empty(t, text)
+	})
+
+	t.Run("keeps joiners of emoji and scripts", func(t *testing.T) {
+		family := "\U0001F468\u200d\U0001F469\u200d\U0001F467"
+		persian := "می\u200cخواهم"
+		for _, text := range []string{family, persian} {
+			got, err := v.FilterText(text, domain.TextFilterReject)
+			require.NoError(t, err)
+			assert.Equal(t, text, got)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		_, err := v.FilterText(hidden, domain.TextFilterReject)
+		assert.ErrorContains(t, err, "invisible")
+		_, err = v.FilterText(zalgo, domain.TextFilterReject)
+		assert.ErrorContains(t, err, "too many marks")
+	})
+
+	t.Run("off", func(t *testing.T) {
+		text, err := v.FilterText(zalgo+hidden, domain.TextFilterOff)
+		require.NoError(t, err)
+		assert.Equal(t, zalgo+hidden, text)
+	})
+}
@@ -0,0 +1,53 @@
+// Package webhook posts operational alerts to a chat or incident webhook.
+// The body is {"text": "..."}, which Slack, Mattermost and most relays accept.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type Sender struct {
+	url    string
+	client *http.Client
+}
+
+func New(url string) *Sender {
+	return &Sender{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SendAlert posts text to the webhook. Any non-2xx response is an error.
+func (s *Sender) SendAlert(ctx context.Context, text string) error {
+	body, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{text})
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("alert webhook returned %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendAlert(t *testing.T) {
+	t.Run("posts the text as JSON", func(t *testing.T) {
+		var got struct{ Text string }
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		}))
+		defer server.Close()
+
+		require.NoError(t, New(server.URL).SendAlert(context.Background(), "disk is 90% full"))
+		assert.Equal(t, "disk is 90% full", got.Text)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "no such hook", http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		err := New(server.URL).SendAlert(context.Background(), "test")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+}
@@ -0,0 +1,211 @@
+// Package webpush sends Web Push messages: the payload is encrypted for the
+// browser (RFC 8291, aes128gcm) and the request is signed with the server's
+// VAPID key (RFC 8292).
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/errors"
+)
+
+const (
+	recordSize = 4096           // Only one record is ever sent
+	messageTTL = 24 * time.Hour // How long push services keep undelivered messages
+	vapidTTL   = 12 * time.Hour // Lifetime of the signed VAPID token
+)
+
+type Sender struct {
+	key       *ecdsa.PrivateKey
+	publicKey string // Base64url uncompressed public key, sent with every request
+	subject   string
+	client    *http.Client
+}
+
+// New creates a Sender from the private key in cfg. publicKey is the key
+// browsers subscribed with and must belong to it.
+func New(cfg *config.WebPush, publicKey string) (*Sender, error) {
+	raw, err := decodeKey(cfg.VAPIDPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	pub := ecdhKey.PublicKey().Bytes()
+	if base64.RawURLEncoding.EncodeToString(pub) != strings.TrimRight(publicKey, "=") {
+		return nil, fmt.Errorf("vapid_public_key does not match the VAPID private key")
+	}
+	if cfg.Subject == "" {
+		return nil, fmt.Errorf("web push subject is required")
+	}
+
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+	return &Sender{
+		key:       key,
+		publicKey: base64.RawURLEncoding.EncodeToString(pub),
+		subject:   cfg.Subject,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Send delivers payload to the subscription. When the push service reports
+// the subscription as expired or unknown, the error has status 410 Gone and
+// the subscription should be dropped.
+func (s *Sender) Send(ctx context.Context, sub domain.PushSubscription, payload []byte) error {
+	body, err := encrypt(sub, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := s.vapid(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create push request: %w", err)
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", fmt.Sprint(int(messageTTL.Seconds())))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return &errors.ErrorWithStatusCode{Message: "Push subscription expired", StatusCode: http.StatusGone}
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push service returned %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
+
+// vapid returns the Authorization header value for a request to endpoint
+func (s *Sender) vapid(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid push endpoint: %w", err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(vapidTTL).Unix(),
+		"sub": s.subject,
+	})
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, s.publicKey), nil
+}
+
+// encrypt encrypts payload for the browser as a single aes128gcm record
+// (RFC 8291 section 3.4).
+func encrypt(sub domain.PushSubscription, payload []byte) ([]byte, error) {
+	uaPublicRaw, err := decodeKey(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription key: %w", err)
+	}
+	authSecret, err := decodeKey(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription secret: %w", err)
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	keyInfo := "WebPush: info\x00" + string(uaPublicRaw) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload)+1+gcm.Overhead() > recordSize {
+		return nil, fmt.Errorf("push payload too large: %d bytes", len(payload))
+	}
+	// 0x02 marks the last (and only) record, with no padding
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// decodeKey decodes base64url keys with or without padding, as browsers and
+// key generators differ.
+func decodeKey(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,147 @@
+package webpush
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSender(t *testing.T) *Sender {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	s, err := New(&config.WebPush{
+		VAPIDPrivateKey: base64.RawURLEncoding.EncodeToString(key.Bytes()),
+		Subject:         "mailto:admin@example.com",
+	}, base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()))
+	require.NoError(t, err)
+	return s
+}
+
+// decrypt reverses encrypt the way a browser does
+func decrypt(t *testing.T, uaPrivate *ecdh.PrivateKey, authSecret, body []byte) []byte {
+	t.Helper()
+	require.Greater(t, len(body), 21)
+	salt := body[:16]
+	assert.Equal(t, uint32(recordSize), binary.BigEndian.Uint32(body[16:20]))
+	idLen := int(body[20])
+	asPublicRaw := body[21 : 21+idLen]
+	ciphertext := body[21+idLen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicRaw)
+	require.NoError(t, err)
+	sharedSecret, err := uaPrivate.ECDH(asPublic)
+	require.NoError(t, err)
+	keyInfo := "WebPush: info\x00" + string(uaPrivate.PublicKey().Bytes()) + string(asPublicRaw)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, keyInfo, 32)
+	require.NoError(t, err)
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	require.NoError(t, err)
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(cek)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x02), plaintext[len(plaintext)-1], "last record delimiter")
+	return plaintext[:len(plaintext)-1]
+}
+
+func TestNew(t *testing.T) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	other, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	cfg := &config.WebPush{
+		VAPIDPrivateKey: base64.RawURLEncoding.EncodeToString(key.Bytes()),
+		Subject:         "mailto:admin@example.com",
+	}
+
+	_, err = New(cfg, base64.URLEncoding.EncodeToString(key.PublicKey().Bytes()))
+	assert.NoError(t, err, "padded public key")
+	_, err = New(cfg, base64.RawURLEncoding.EncodeToString(other.PublicKey().Bytes()))
+	assert.Error(t, err, "mismatched public key")
+	_, err = New(&config.WebPush{VAPIDPrivateKey: "not a key", Subject: cfg.Subject}, "")
+	assert.Error(t, err)
+}
+
+func TestSend(t *testing.T) {
+	uaPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	authSecret := make([]byte, 16)
+	_, err = rand.Read(authSecret)
+	require.NoError(t, err)
+	sender := newTestSender(t)
+
+	var got []byte
+	var authorization string
+	status := http.StatusCreated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "aes128gcm", r.Header.Get("Content-Encoding"))
+		assert.NotEmpty(t, r.Header.Get("TTL"))
+		authorization = r.Header.Get("Authorization")
+		got, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sub := domain.PushSubscription{
+		Endpoint: server.URL + "/push/abc",
+		P256dh:   base64.RawURLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(authSecret),
+	}
+
+	t.Run("payload and VAPID header", func(t *testing.T) {
+		require.NoError(t, sender.Send(context.Background(), sub, []byte(`{"title":"hi"}`)))
+		assert.Equal(t, `{"title":"hi"}`, string(decrypt(t, uaPrivate, authSecret, got)))
+
+		require.True(t, strings.HasPrefix(authorization, "vapid t="))
+		parts := strings.SplitN(strings.TrimPrefix(authorization, "vapid t="), ", k=", 2)
+		require.Len(t, parts, 2)
+		assert.Equal(t, sender.publicKey, parts[1])
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(parts[0], claims, func(*jwt.Token) (any, error) { return &sender.key.PublicKey, nil },
+			jwt.WithValidMethods([]string{"ES256"}))
+		require.NoError(t, err)
+		assert.Equal(t, server.URL, claims["aud"])
+		assert.Equal(t, "mailto:admin@example.com", claims["sub"])
+	})
+
+	t.Run("expired subscription", func(t *testing.T) {
+		status = http.StatusGone
+		err := sender.Send(context.Background(), sub, []byte("{}"))
+		var e *internal_errors.ErrorWithStatusCode
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusGone, e.StatusCode)
+	})
+
+	t.Run("push service error", func(t *testing.T) {
+		status = http.StatusTooManyRequests
+		err := sender.Send(context.Background(), sub, []byte("{}"))
+		require.Error(t, err)
+		var e *internal_errors.ErrorWithStatusCode
+		assert.False(t, errors.As(err, &e))
+	})
+}
@@ -8,11 +8,13 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // embed zone database for user timezone preferences
 
 	"github.com/itchan-dev/itchan/frontend/internal/router"
 	"github.com/itchan-dev/itchan/frontend/internal/setup"
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/tracing"
 )
 
 const (
@@ -30,7 +32,16 @@ func main() {
 
 	// Initialize logger with config settings
 	useJSON := cfg.Public.LogFormat == "json"
-	logger.Initialize(cfg.Public.LogLevel, useJSON)
+	logger.Initialize(cfg.Public.LogLevel, useJSON, logger.Sampling{
+		Initial:    cfg.Public.LogSampleInitial,
+		Thereafter: cfg.Public.LogSampleThereafter,
+	})
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Private.Tracing, "itchan-frontend")
+	if err != nil {
+		logger.Log.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
 
 	deps, err := setup.SetupDependencies(cfg)
 	if err != nil {
@@ -66,6 +77,11 @@ func main() {
 	} else {
 		logger.Log.Info("frontend server gracefully stopped")
 	}
+
+	// Send the spans of the last requests before exiting
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Log.Error("tracing shutdown error", "error", err)
+	}
 }
 
 func configureServer(handler http.Handler) *http.Server {
@@ -1,11 +1,20 @@
 package apiclient
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/itchan-dev/itchan/shared/api"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/tracing"
 )
 
 // APIClient struct handles all communication with the backend API.
@@ -18,7 +27,7 @@ type APIClient struct {
 func New(baseURL string) *APIClient {
 	return &APIClient{
 		BaseURL:    baseURL,
-		HttpClient: &http.Client{},
+		HttpClient: &http.Client{Transport: tracing.Transport(http.DefaultTransport)},
 	}
 }
 
@@ -28,7 +37,7 @@ func New(baseURL string) *APIClient {
 func (c *APIClient) do(r *http.Request, method, path string, body io.Reader) (*http.Response, error) {
 	token := getToken(r)
 	ip := getIP(r)
-	req, err := http.NewRequest(method, c.BaseURL+path, body)
+	req, err := newRequest(r, method, c.BaseURL+path, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create API request: %w", err)
 	}
@@ -37,9 +46,22 @@ func (c *APIClient) do(r *http.Request, method, path string, body io.Reader) (*h
 	if token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
+	// The backend prefers a valid view-as token and falls back to the bearer
+	// token once it expires, the same way the frontend's own middleware does
+	if viewAs, err := r.Cookie(middleware.ViewAsCookieName); err == nil {
+		req.AddCookie(&http.Cookie{Name: viewAs.Name, Value: viewAs.Value})
+	}
 	if ip != "" {
 		req.Header.Set("X-Real-IP", ip)
 	}
+	// Logins from a browser the user hasn't used before are emailed to them
+	if ua := r.UserAgent(); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	// The backend logs the request under the frontend's ID
+	if id := chi_middleware.GetReqID(r.Context()); id != "" {
+		req.Header.Set(chi_middleware.RequestIDHeader, id)
+	}
 
 	resp, err := c.HttpClient.Do(req)
 	if err != nil {
@@ -48,6 +70,28 @@ func (c *APIClient) do(r *http.Request, method, path string, body io.Reader) (*h
 	return resp, nil
 }
 
+// newRequest creates a backend request on behalf of the browser request r.
+// It carries r's trace, so backend spans join the frontend's, but not its
+// cancellation, as some calls (referral tracking) outlive r.
+func newRequest(r *http.Request, method, url string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(context.WithoutCancel(r.Context()), method, url, body)
+}
+
+// responseError turns a failed backend response into an error carrying its
+// status code, so pages can explain it. The message is the response text, or
+// the error field of a JSON api.ErrorResponse.
+func responseError(resp *http.Response) error {
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	message := strings.TrimSpace(string(bodyBytes))
+
+	var structured api.ErrorResponse
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") &&
+		json.Unmarshal(bodyBytes, &structured) == nil && structured.Error != "" {
+		message = structured.Error
+	}
+	return &internal_errors.ErrorWithStatusCode{Message: message, StatusCode: resp.StatusCode}
+}
+
 // getToken extracts the JWT token from the incoming browser request cookie.
 func getToken(r *http.Request) string {
 	if c, err := r.Cookie("access_token"); err == nil {
@@ -68,3 +112,14 @@ func withPage(path string, page int) string {
 	}
 	return path + "?" + url.Values{"page": {strconv.Itoa(page)}}.Encode()
 }
+
+// withLite asks the backend to trim attachment files for low-bandwidth pages
+func withLite(path string, lite bool) string {
+	if !lite {
+		return path
+	}
+	if strings.Contains(path, "?") {
+		return path + "&lite=true"
+	}
+	return path + "?lite=true"
+}
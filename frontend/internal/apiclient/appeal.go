@@ -0,0 +1,87 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// SubmitAppeal files a ban appeal on behalf of a suspended user.
+func (c *APIClient) SubmitAppeal(r *http.Request, email, password, text string) error {
+	jsonBody, err := json.Marshal(api.SubmitAppealRequest{Email: email, Password: password, Text: text})
+	if err != nil {
+		return fmt.Errorf("failed to marshal appeal data: %w", err)
+	}
+
+	resp, err := c.do(r, "POST", "/v1/auth/appeal", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to submit appeal: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+// GetAppeals returns ban appeals with the given status (pending when empty).
+func (c *APIClient) GetAppeals(r *http.Request, status domain.AppealStatus, page int) (api.AppealsResponse, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	if page > 1 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	path := "/v1/admin/appeals"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	resp, err := c.do(r, "GET", path, nil)
+	if err != nil {
+		return api.AppealsResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return api.AppealsResponse{}, fmt.Errorf("failed to get appeals: %s", string(bodyBytes))
+	}
+
+	var result api.AppealsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return api.AppealsResponse{}, fmt.Errorf("failed to decode appeals response: %w", err)
+	}
+	return result, nil
+}
+
+// DecideAppeal accepts or denies an appeal; decision is "accept" or "deny".
+func (c *APIClient) DecideAppeal(r *http.Request, appealID, decision, response string) error {
+	jsonBody, err := json.Marshal(api.DecideAppealRequest{Response: response})
+	if err != nil {
+		return fmt.Errorf("failed to marshal appeal decision: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/admin/appeals/%s/%s", appealID, decision)
+	resp, err := c.do(r, "POST", path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to %s appeal: %s", decision, string(bodyBytes))
+	}
+	return nil
+}
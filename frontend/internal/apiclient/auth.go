@@ -42,9 +42,9 @@ func (c *APIClient) ConfirmEmail(r *http.Request, email, code, refSource string)
 }
 
 // Login sends login credentials. It returns the raw response so the handler
-// can parse the access token from the JSON body.
-func (c *APIClient) Login(r *http.Request, email, password string) (*http.Response, error) {
-	jsonBody, err := json.Marshal(api.LoginRequest{Email: email, Password: password})
+// can parse the tokens from the JSON body.
+func (c *APIClient) Login(r *http.Request, email, password string, rememberMe bool) (*http.Response, error) {
+	jsonBody, err := json.Marshal(api.LoginRequest{Email: email, Password: password, RememberMe: rememberMe})
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal login data: %w", err)
 	}
@@ -52,6 +52,74 @@ func (c *APIClient) Login(r *http.Request, email, password string) (*http.Respon
 	return c.do(r, "POST", "/v1/auth/login", bytes.NewBuffer(jsonBody))
 }
 
+// RefreshSession trades the refresh token of a remembered login for a new
+// access token and refresh token.
+func (c *APIClient) RefreshSession(r *http.Request, refreshToken string) (string, string, error) {
+	jsonBody, err := json.Marshal(api.RefreshRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal refresh data: %w", err)
+	}
+
+	resp, err := c.do(r, "POST", "/v1/auth/refresh", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", responseError(resp)
+	}
+	var response api.LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", "", fmt.Errorf("cannot decode refresh response: %w", err)
+	}
+	if response.AccessToken == "" || response.RefreshToken == "" {
+		return "", "", fmt.Errorf("refresh response is missing a token")
+	}
+	return response.AccessToken, response.RefreshToken, nil
+}
+
+// RevokeSessions signs out everywhere the user a new device email with the
+// token was sent to. No login is needed.
+func (c *APIClient) RevokeSessions(r *http.Request, token string) error {
+	jsonBody, err := json.Marshal(api.RevokeSessionsRequest{Token: token})
+	if err != nil {
+		return fmt.Errorf("failed to marshal revoke request: %w", err)
+	}
+
+	resp, err := c.do(r, "POST", "/v1/auth/revoke_sessions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// RenewSession reissues the access token r is authenticated with.
+func (c *APIClient) RenewSession(r *http.Request) (string, error) {
+	resp, err := c.do(r, "POST", "/v1/auth/renew", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+	var response api.LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("cannot decode renew response: %w", err)
+	}
+	if response.AccessToken == "" {
+		return "", fmt.Errorf("renew response is missing the access token")
+	}
+	return response.AccessToken, nil
+}
+
 // RegisterWithInvite sends an invite code registration request to the backend.
 // It returns the generated random email address on success.
 func (c *APIClient) RegisterWithInvite(r *http.Request, inviteCode, password, refSource string) (string, error) {
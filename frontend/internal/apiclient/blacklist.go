@@ -74,3 +74,29 @@ func (c *APIClient) BlacklistUser(r *http.Request, userID string, reason string)
 
 	return nil
 }
+
+// LookupUser finds the account registered with an exact email
+func (c *APIClient) LookupUser(r *http.Request, email string) (api.UserLookupResponse, error) {
+	jsonBody, err := json.Marshal(api.UserLookupRequest{Email: email})
+	if err != nil {
+		return api.UserLookupResponse{}, fmt.Errorf("failed to marshal user lookup request: %w", err)
+	}
+
+	resp, err := c.do(r, "POST", "/v1/admin/users/lookup", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return api.UserLookupResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return api.UserLookupResponse{}, fmt.Errorf("failed to look up user: %s", string(bodyBytes))
+	}
+
+	var result api.UserLookupResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return api.UserLookupResponse{}, fmt.Errorf("failed to decode user lookup response: %w", err)
+	}
+
+	return result, nil
+}
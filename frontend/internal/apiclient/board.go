@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/itchan-dev/itchan/shared/api"
@@ -14,33 +18,65 @@ import (
 	"github.com/itchan-dev/itchan/shared/utils"
 )
 
-func (c *APIClient) GetBoards(r *http.Request) ([]domain.Board, error) {
-	resp, err := c.do(r, "GET", "/v1/boards", nil)
+// GetBoards returns one page of boards in the given order (empty means position).
+// stats selects the statistics included with each board.
+func (c *APIClient) GetBoards(r *http.Request, sort domain.BoardSort, stats domain.BoardStatsMode, page int) (api.BoardsResponse, error) {
+	query := url.Values{}
+	if sort != "" {
+		query.Set("sort", sort)
+	}
+	if stats != domain.BoardStatsNone {
+		query.Set("include_stats", stats)
+	}
+	if page > 1 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	path := "/v1/boards"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	resp, err := c.do(r, "GET", path, nil)
 	if err != nil {
-		return nil, err
+		return api.BoardsResponse{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("backend returned status %d", resp.StatusCode)
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return api.BoardsResponse{}, &internal_errors.ErrorWithStatusCode{Message: string(bodyBytes), StatusCode: resp.StatusCode}
 	}
 
-	var boardMetadata []domain.BoardMetadata
-	if err := utils.Decode(resp.Body, &boardMetadata); err != nil {
-		return nil, fmt.Errorf("cannot decode boards response: %w", err)
+	var result api.BoardsResponse
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return api.BoardsResponse{}, fmt.Errorf("cannot decode boards response: %w", err)
+	}
+	return result, nil
+}
+
+// GetGroupedBoards returns board categories in display order, each with its boards.
+// Uncategorized boards come last in a group with zero Id.
+func (c *APIClient) GetGroupedBoards(r *http.Request) ([]domain.BoardCategory, error) {
+	resp, err := c.do(r, "GET", "/v1/boards/grouped", nil)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	boards := make([]domain.Board, len(boardMetadata))
-	for i, bm := range boardMetadata {
-		boards[i] = domain.Board{BoardMetadata: bm}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("backend returned status %d", resp.StatusCode)
 	}
 
-	return boards, nil
+	var categories []domain.BoardCategory
+	if err := utils.Decode(resp.Body, &categories); err != nil {
+		return nil, fmt.Errorf("cannot decode grouped boards response: %w", err)
+	}
+	return categories, nil
 }
 
-func (c *APIClient) GetBoard(r *http.Request, shortName string, page int) (domain.Board, error) {
+func (c *APIClient) GetBoard(r *http.Request, shortName string, page int, lite bool) (domain.Board, error) {
 	var board domain.Board
-	path := withPage(fmt.Sprintf("/v1/%s", shortName), page)
+	path := withLite(withPage(fmt.Sprintf("/v1/%s", shortName), page), lite)
 
 	resp, err := c.do(r, "GET", path, nil)
 	if err != nil {
@@ -54,7 +90,7 @@ func (c *APIClient) GetBoard(r *http.Request, shortName string, page int) (domai
 		}
 	}
 	if resp.StatusCode != http.StatusOK {
-		return board, fmt.Errorf("backend returned status %d", resp.StatusCode)
+		return board, responseError(resp)
 	}
 	if err := utils.Decode(resp.Body, &board); err != nil {
 		return board, fmt.Errorf("cannot decode board response: %w", err)
@@ -62,6 +98,31 @@ func (c *APIClient) GetBoard(r *http.Request, shortName string, page int) (domai
 	return board, nil
 }
 
+// GetModLog fetches a page of the board's public moderation log
+func (c *APIClient) GetModLog(r *http.Request, shortName string, page int) (api.ModLogResponse, error) {
+	var result api.ModLogResponse
+	path := withPage(fmt.Sprintf("/v1/%s/modlog", shortName), page)
+
+	resp, err := c.do(r, "GET", path, nil)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return result, &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("/%s/ has no public moderation log", shortName), StatusCode: http.StatusNotFound,
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return result, responseError(resp)
+	}
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return result, fmt.Errorf("cannot decode moderation log response: %w", err)
+	}
+	return result, nil
+}
+
 func (c *APIClient) GetBoardLastModified(r *http.Request, shortName string) (time.Time, error) {
 	path := fmt.Sprintf("/v1/%s/last_modified", shortName)
 	resp, err := c.do(r, "GET", path, nil)
@@ -71,7 +132,7 @@ func (c *APIClient) GetBoardLastModified(r *http.Request, shortName string) (tim
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return time.Time{}, fmt.Errorf("backend returned status %d", resp.StatusCode)
+		return time.Time{}, responseError(resp)
 	}
 
 	var result api.LastModifiedResponse
@@ -100,9 +161,299 @@ func (c *APIClient) CreateBoard(r *http.Request, data api.CreateBoardRequest) er
 	return nil
 }
 
-func (c *APIClient) DeleteBoard(r *http.Request, shortName string) error {
+// DeleteBoard deletes a board, or schedules its deletion when the backend has
+// a grace period. The returned time is nil when the board is already gone.
+func (c *APIClient) DeleteBoard(r *http.Request, shortName string) (*time.Time, error) {
 	path := fmt.Sprintf("/v1/admin/%s", shortName)
 	resp, err := c.do(r, "DELETE", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to delete board: %s", string(bodyBytes))
+	}
+
+	var result api.DeleteBoardResponse
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("cannot decode board deletion response: %w", err)
+	}
+	return result.DeleteAfter, nil
+}
+
+func (c *APIClient) RestoreBoard(r *http.Request, shortName string) error {
+	path := fmt.Sprintf("/v1/admin/%s/restore", shortName)
+	resp, err := c.do(r, "POST", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to restore board: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+func (c *APIClient) RenameBoard(r *http.Request, shortName, newShortName string) error {
+	jsonBody, err := json.Marshal(api.RenameBoardRequest{ShortName: newShortName})
+	if err != nil {
+		return fmt.Errorf("failed to marshal board rename: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/admin/%s/rename", shortName)
+	resp, err := c.do(r, "POST", path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to rename board: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+func (c *APIClient) GetPendingBoardDeletions(r *http.Request) ([]domain.PendingBoardDeletion, error) {
+	resp, err := c.do(r, "GET", "/v1/admin/boards/pending_deletion", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get boards pending deletion: %s", string(bodyBytes))
+	}
+
+	var result api.PendingBoardDeletionsResponse
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("cannot decode boards pending deletion: %w", err)
+	}
+	return result.Boards, nil
+}
+
+func (c *APIClient) CreateBoardCategory(r *http.Request, data api.BoardCategoryRequest) error {
+	jsonBody, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal category data: %w", err)
+	}
+
+	resp, err := c.do(r, "POST", "/v1/admin/categories", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to create category: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+func (c *APIClient) UpdateBoardCategory(r *http.Request, categoryID string, data api.BoardCategoryRequest) error {
+	jsonBody, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal category data: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/admin/categories/%s", categoryID)
+	resp, err := c.do(r, "PUT", path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update category: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+func (c *APIClient) DeleteBoardCategory(r *http.Request, categoryID string) error {
+	path := fmt.Sprintf("/v1/admin/categories/%s", categoryID)
+	resp, err := c.do(r, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete category: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+func (c *APIClient) SetBoardCategory(r *http.Request, shortName string, data api.SetBoardCategoryRequest) error {
+	jsonBody, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal board category data: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/admin/%s/category", shortName)
+	resp, err := c.do(r, "PUT", path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to set board category: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+func (c *APIClient) UpdateBoardSettings(r *http.Request, shortName string, data api.UpdateBoardSettingsRequest) error {
+	jsonBody, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal board settings: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/admin/%s/settings", shortName)
+	resp, err := c.do(r, "PUT", path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update board settings: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+// GetBoardAppearance fetches the board's banners and its custom CSS as stored,
+// before sanitization.
+func (c *APIClient) GetBoardAppearance(r *http.Request, shortName string) (domain.BoardAppearance, error) {
+	var appearance domain.BoardAppearance
+	path := fmt.Sprintf("/v1/%s/appearance", shortName)
+
+	resp, err := c.do(r, "GET", path, nil)
+	if err != nil {
+		return appearance, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return appearance, &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("board /%s not found", shortName), StatusCode: http.StatusNotFound,
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return appearance, responseError(resp)
+	}
+	if err := utils.Decode(resp.Body, &appearance); err != nil {
+		return appearance, fmt.Errorf("cannot decode board appearance response: %w", err)
+	}
+	return appearance, nil
+}
+
+// GetTopPosters fetches the users who posted most on the board within window,
+// a duration such as "6h". An empty window leaves it to the backend default.
+func (c *APIClient) GetTopPosters(r *http.Request, shortName, window string) (domain.BoardPosters, error) {
+	var posters domain.BoardPosters
+	path := fmt.Sprintf("/v1/admin/%s/posters", shortName)
+	if window != "" {
+		path += "?window=" + url.QueryEscape(window)
+	}
+
+	resp, err := c.do(r, "GET", path, nil)
+	if err != nil {
+		return posters, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return posters, responseError(resp)
+	}
+	if err := utils.Decode(resp.Body, &posters); err != nil {
+		return posters, fmt.Errorf("cannot decode top posters response: %w", err)
+	}
+	return posters, nil
+}
+
+func (c *APIClient) SetBoardCustomCSS(r *http.Request, shortName, css string) error {
+	jsonBody, err := json.Marshal(api.SetBoardCustomCSSRequest{CSS: css})
+	if err != nil {
+		return fmt.Errorf("failed to marshal custom css: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/admin/%s/custom_css", shortName)
+	resp, err := c.do(r, "PUT", path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to set custom css: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+// UploadBoardBanner forwards an uploaded banner image to the backend as-is.
+func (c *APIClient) UploadBoardBanner(r *http.Request, shortName string, fileHeader *multipart.FileHeader) error {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open banner: %w", err)
+	}
+	defer file.Close()
+
+	// Banners are small, so the body is built in memory
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition",
+		fmt.Sprintf(`form-data; name="banner"; filename="%s"`, escapeQuotes(fileHeader.Filename)))
+	if contentType := fileHeader.Header.Get("Content-Type"); contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		return fmt.Errorf("failed to create banner part: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("failed to copy banner: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to finish banner upload: %w", err)
+	}
+
+	req, err := newRequest(r, "POST", c.BaseURL+fmt.Sprintf("/v1/admin/%s/banners", shortName), body)
+	if err != nil {
+		return fmt.Errorf("failed to create API request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if token := getToken(r); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("backend unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to upload banner: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+func (c *APIClient) DeleteBoardBanner(r *http.Request, shortName, bannerID string) error {
+	path := fmt.Sprintf("/v1/admin/%s/banners/%s", shortName, bannerID)
+	resp, err := c.do(r, "DELETE", path, nil)
 	if err != nil {
 		return err
 	}
@@ -110,7 +461,7 @@ func (c *APIClient) DeleteBoard(r *http.Request, shortName string) error {
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete board: %s", string(bodyBytes))
+		return fmt.Errorf("failed to delete banner: %s", string(bodyBytes))
 	}
 	return nil
 }
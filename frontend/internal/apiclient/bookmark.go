@@ -0,0 +1,63 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// AddBookmark bookmarks a message for the authenticated user
+func (c *APIClient) AddBookmark(r *http.Request, shortName string, threadId domain.ThreadId, msgId domain.MsgId) error {
+	jsonBody, err := json.Marshal(api.AddBookmarkRequest{Board: shortName, ThreadId: threadId, MessageId: msgId})
+	if err != nil {
+		return fmt.Errorf("failed to marshal bookmark: %w", err)
+	}
+
+	resp, err := c.do(r, "POST", "/v1/me/bookmarks", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// DeleteBookmark removes one of the authenticated user's bookmarks
+func (c *APIClient) DeleteBookmark(r *http.Request, shortName, threadId, msgId string) error {
+	resp, err := c.do(r, "DELETE", fmt.Sprintf("/v1/me/bookmarks/%s/%s/%s", shortName, threadId, msgId), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// GetBookmarks fetches one page of the authenticated user's bookmarks
+func (c *APIClient) GetBookmarks(r *http.Request, page int) (api.BookmarksResponse, error) {
+	var result api.BookmarksResponse
+	resp, err := c.do(r, "GET", withPage("/v1/me/bookmarks", page), nil)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return result, responseError(resp)
+	}
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return result, fmt.Errorf("cannot decode bookmarks response: %w", err)
+	}
+	return result, nil
+}
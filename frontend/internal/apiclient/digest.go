@@ -0,0 +1,132 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// SetThreadWatched watches or unwatches a thread for the email digest
+func (c *APIClient) SetThreadWatched(r *http.Request, shortName, threadId string, watched bool) error {
+	method := "PUT"
+	if !watched {
+		method = "DELETE"
+	}
+	resp, err := c.do(r, method, fmt.Sprintf("/v1/%s/%s/watch", shortName, threadId), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update thread watch: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+// MarkThreadRead moves the user's read position in a thread forward to lastReadId
+func (c *APIClient) MarkThreadRead(r *http.Request, shortName, threadId string, lastReadId domain.MsgId) error {
+	jsonBody, err := json.Marshal(api.MarkThreadReadRequest{LastReadId: lastReadId})
+	if err != nil {
+		return fmt.Errorf("failed to marshal read position: %w", err)
+	}
+
+	resp, err := c.do(r, "PUT", fmt.Sprintf("/v1/%s/%s/read", shortName, threadId), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// GetWatchedThreads fetches the authenticated user's watched threads with their unread counts
+func (c *APIClient) GetWatchedThreads(r *http.Request) ([]domain.WatchedThread, error) {
+	resp, err := c.do(r, "GET", "/v1/me/watched", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get watched threads: %s", string(bodyBytes))
+	}
+
+	var result api.WatchedThreadsResponse
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse watched threads: %w", err)
+	}
+	return result.Threads, nil
+}
+
+// GetDigestFrequency fetches the authenticated user's email digest frequency
+func (c *APIClient) GetDigestFrequency(r *http.Request) (domain.DigestFrequency, error) {
+	resp, err := c.do(r, "GET", "/v1/me/digest", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to get digest settings: %s", string(bodyBytes))
+	}
+
+	var result api.DigestResponse
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse digest settings: %w", err)
+	}
+	return result.Frequency, nil
+}
+
+// SetDigestFrequency changes the email digest frequency; DigestOff unsubscribes
+func (c *APIClient) SetDigestFrequency(r *http.Request, frequency domain.DigestFrequency) error {
+	jsonBody, err := json.Marshal(api.SetDigestRequest{Frequency: frequency})
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest settings: %w", err)
+	}
+
+	resp, err := c.do(r, "PUT", "/v1/me/digest", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update digest settings: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+// UnsubscribeDigest cancels the digest subscription identified by the token
+// from an unsubscribe link. No login is needed.
+func (c *APIClient) UnsubscribeDigest(r *http.Request, token string) error {
+	jsonBody, err := json.Marshal(api.UnsubscribeDigestRequest{Token: token})
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsubscribe request: %w", err)
+	}
+
+	resp, err := c.do(r, "POST", "/v1/digest/unsubscribe", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to unsubscribe: %s", string(bodyBytes))
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+)
+
+// GetFailedPosts returns the captured failed posts for the given page
+func (c *APIClient) GetFailedPosts(r *http.Request, page int) (api.FailedPostsResponse, error) {
+	path := withPage("/v1/admin/failed_posts", page)
+	resp, err := c.do(r, "GET", path, nil)
+	if err != nil {
+		return api.FailedPostsResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return api.FailedPostsResponse{}, fmt.Errorf("failed to get failed posts: %s", string(bodyBytes))
+	}
+
+	var result api.FailedPostsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return api.FailedPostsResponse{}, fmt.Errorf("failed to decode failed posts response: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,31 @@
+package apiclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetPostLimits fetches how long the authenticated user has to wait before
+// creating another thread or message
+func (c *APIClient) GetPostLimits(r *http.Request) (api.PostLimitsResponse, error) {
+	var result api.PostLimitsResponse
+	resp, err := c.do(r, "GET", "/v1/me/limits", nil)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return result, fmt.Errorf("failed to get post limits: %s", string(bodyBytes))
+	}
+
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return result, fmt.Errorf("failed to parse post limits: %w", err)
+	}
+	return result, nil
+}
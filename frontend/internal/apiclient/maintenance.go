@@ -0,0 +1,68 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetMaintenance fetches the active or upcoming maintenance window; nil if
+// none is scheduled
+func (c *APIClient) GetMaintenance(r *http.Request) (*api.MaintenanceResponse, error) {
+	resp, err := c.do(r, "GET", "/v1/maintenance", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var result api.MaintenanceResponse
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("cannot decode maintenance response: %w", err)
+	}
+	return &result, nil
+}
+
+// ScheduleMaintenance puts the instance into read-only mode for the window,
+// replacing any scheduled one
+func (c *APIClient) ScheduleMaintenance(r *http.Request, req api.ScheduleMaintenanceRequest) error {
+	jsonBody, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal maintenance request: %w", err)
+	}
+
+	resp, err := c.do(r, "PUT", "/v1/admin/maintenance", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// CancelMaintenance ends the maintenance window or drops a scheduled one
+func (c *APIClient) CancelMaintenance(r *http.Request) error {
+	resp, err := c.do(r, "DELETE", "/v1/admin/maintenance", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
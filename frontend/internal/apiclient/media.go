@@ -0,0 +1,29 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetMediaLocations resolves a content hash to the stored files the user may read
+func (c *APIClient) GetMediaLocations(r *http.Request, sha256 string) ([]domain.MediaLocation, error) {
+	var locations []domain.MediaLocation
+	resp, err := c.do(r, "GET", "/v1/media/sha256/"+url.PathEscape(sha256), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	if err := utils.Decode(resp.Body, &locations); err != nil {
+		return nil, fmt.Errorf("cannot decode media locations: %w", err)
+	}
+	return locations, nil
+}
@@ -1,11 +1,15 @@
 package apiclient
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 
+	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
 )
 
@@ -18,6 +22,11 @@ func (c *APIClient) GetMessage(r *http.Request, board, threadID, messageID strin
 	return resp, nil
 }
 
+// ResolvePostNumber returns the backend's JSON locating a board-local post number.
+func (c *APIClient) ResolvePostNumber(r *http.Request, board, postNumber string) (*http.Response, error) {
+	return c.do(r, "GET", fmt.Sprintf("/v1/%s/resolve/%s", board, postNumber), nil)
+}
+
 func (c *APIClient) GetMessageParsed(r *http.Request, board, threadID, messageID string) (*domain.Message, error) {
 	resp, err := c.GetMessage(r, board, threadID, messageID)
 	if err != nil {
@@ -38,9 +47,14 @@ func (c *APIClient) GetMessageParsed(r *http.Request, board, threadID, messageID
 	return &message, nil
 }
 
-func (c *APIClient) DeleteMessage(r *http.Request, shortName, threadID, messageID string) error {
+func (c *APIClient) DeleteMessage(r *http.Request, shortName, threadID, messageID, reason string) error {
+	jsonBody, err := json.Marshal(api.DeleteContentRequest{Reason: reason})
+	if err != nil {
+		return fmt.Errorf("failed to marshal delete request: %w", err)
+	}
+
 	path := fmt.Sprintf("/v1/admin/%s/%s/%s", shortName, threadID, messageID)
-	resp, err := c.do(r, "DELETE", path, nil)
+	resp, err := c.do(r, "DELETE", path, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return err
 	}
@@ -52,3 +66,67 @@ func (c *APIClient) DeleteMessage(r *http.Request, shortName, threadID, messageI
 	}
 	return nil
 }
+
+// DeleteOwnMessage deletes a reply on behalf of its author.
+func (c *APIClient) DeleteOwnMessage(r *http.Request, shortName, threadID, messageID string) error {
+	path := fmt.Sprintf("/v1/%s/%s/%s", shortName, threadID, messageID)
+	resp, err := c.do(r, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// UploadFile streams a single file to the backend's upload endpoint and
+// returns the response for the caller to relay.
+func (c *APIClient) UploadFile(r *http.Request, shortName string, fileHeader *multipart.FileHeader) (*http.Response, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+	go func() {
+		defer file.Close()
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition",
+			fmt.Sprintf(`form-data; name="file"; filename="%s"`, escapeQuotes(fileHeader.Filename)))
+		if contentType := fileHeader.Header.Get("Content-Type"); contentType != "" {
+			h.Set("Content-Type", contentType)
+		}
+		part, err := writer.CreatePart(h)
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+
+	req, err := newRequest(r, "POST", c.BaseURL+fmt.Sprintf("/v1/%s/uploads", shortName), pipeReader)
+	if err != nil {
+		pipeReader.Close()
+		return nil, fmt.Errorf("failed to create API request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if token := getToken(r); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if ip := getIP(r); ip != "" {
+		req.Header.Set("X-Real-IP", ip)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("backend unavailable: %w", err)
+	}
+	return resp, nil
+}
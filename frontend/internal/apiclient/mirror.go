@@ -0,0 +1,80 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetBoardMirrors fetches the boards that copy a board of another instance
+func (c *APIClient) GetBoardMirrors(r *http.Request) ([]domain.BoardMirror, error) {
+	resp, err := c.do(r, "GET", "/v1/admin/mirrors", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	var mirrors api.BoardMirrorsResponse
+	if err := utils.Decode(resp.Body, &mirrors); err != nil {
+		return nil, fmt.Errorf("cannot decode board mirrors response: %w", err)
+	}
+	return mirrors.Mirrors, nil
+}
+
+// CreateBoardMirror makes an empty board a read-only copy of sourceBoard at sourceURL
+func (c *APIClient) CreateBoardMirror(r *http.Request, shortName, sourceURL, sourceBoard string) error {
+	jsonBody, err := json.Marshal(api.CreateBoardMirrorRequest{SourceURL: sourceURL, SourceBoard: sourceBoard})
+	if err != nil {
+		return fmt.Errorf("failed to marshal board mirror: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/admin/%s/mirror", shortName)
+	resp, err := c.do(r, "PUT", path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// DeleteBoardMirror detaches the mirror, keeping the copied threads
+func (c *APIClient) DeleteBoardMirror(r *http.Request, shortName string) error {
+	path := fmt.Sprintf("/v1/admin/%s/mirror", shortName)
+	resp, err := c.do(r, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// SyncBoardMirror copies what changed on the source board right away
+func (c *APIClient) SyncBoardMirror(r *http.Request, shortName string) error {
+	path := fmt.Sprintf("/v1/admin/%s/mirror/sync", shortName)
+	resp, err := c.do(r, "POST", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
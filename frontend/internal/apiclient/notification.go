@@ -0,0 +1,81 @@
+package apiclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetNotifications fetches one page of the authenticated user's notifications
+func (c *APIClient) GetNotifications(r *http.Request, page int) (api.NotificationsResponse, error) {
+	resp, err := c.do(r, "GET", withPage("/v1/me/notifications", page), nil)
+	if err != nil {
+		return api.NotificationsResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return api.NotificationsResponse{}, fmt.Errorf("failed to get notifications: %s", string(bodyBytes))
+	}
+
+	var result api.NotificationsResponse
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return api.NotificationsResponse{}, fmt.Errorf("failed to parse notifications: %w", err)
+	}
+	return result, nil
+}
+
+// GetUnreadNotifications fetches the number of unread notifications for the header
+func (c *APIClient) GetUnreadNotifications(r *http.Request) (int, error) {
+	resp, err := c.do(r, "GET", "/v1/me/notifications/unread", nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("failed to get unread notifications: %s", string(bodyBytes))
+	}
+
+	var result api.UnreadNotificationsResponse
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse unread notifications: %w", err)
+	}
+	return result.Unread, nil
+}
+
+// MarkNotificationRead marks one notification as read
+func (c *APIClient) MarkNotificationRead(r *http.Request, id domain.NotificationId) error {
+	resp, err := c.do(r, "POST", fmt.Sprintf("/v1/me/notifications/%d/read", id), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to mark notification as read: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead marks all notifications as read
+func (c *APIClient) MarkAllNotificationsRead(r *http.Request) error {
+	resp, err := c.do(r, "POST", "/v1/me/notifications/read", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to mark notifications as read: %s", string(bodyBytes))
+	}
+	return nil
+}
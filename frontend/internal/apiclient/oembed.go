@@ -0,0 +1,31 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// GetThreadOEmbed fetches the oEmbed description of a thread
+func (c *APIClient) GetThreadOEmbed(r *http.Request, shortName, threadID string) (api.OEmbedResponse, error) {
+	var result api.OEmbedResponse
+	resp, err := c.do(r, "GET", fmt.Sprintf("/v1/%s/%s/oembed", shortName, threadID), nil)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return result, &internal_errors.ErrorWithStatusCode{
+			Message: fmt.Sprintf("thread /%s/%s not found or access denied", shortName, threadID), StatusCode: resp.StatusCode,
+		}
+	}
+
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return result, fmt.Errorf("cannot decode oembed response: %w", err)
+	}
+	return result, nil
+}
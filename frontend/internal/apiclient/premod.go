@@ -0,0 +1,101 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// ErrPostQueued is returned by CreateThread and CreateReply when the backend
+// accepted the post but holds it until a moderator approves it.
+var ErrPostQueued = errors.New("your post is awaiting moderator approval")
+
+// GetPremodQueue fetches the posts held back on the board and its known posters
+func (c *APIClient) GetPremodQueue(r *http.Request, shortName string) (api.PremodQueueResponse, error) {
+	var queue api.PremodQueueResponse
+	path := fmt.Sprintf("/v1/admin/%s/premod", shortName)
+	resp, err := c.do(r, "GET", path, nil)
+	if err != nil {
+		return queue, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return queue, responseError(resp)
+	}
+	if err := utils.Decode(resp.Body, &queue); err != nil {
+		return queue, fmt.Errorf("cannot decode premod queue response: %w", err)
+	}
+	return queue, nil
+}
+
+// ApproveQueuedPost publishes a queued post and returns where it ended up.
+// With trust, its author skips pre-moderation on the board from now on.
+func (c *APIClient) ApproveQueuedPost(r *http.Request, shortName, postID string, trust bool) (api.ApproveQueuedPostResponse, error) {
+	var approved api.ApproveQueuedPostResponse
+	jsonBody, err := json.Marshal(api.ApproveQueuedPostRequest{Trust: trust})
+	if err != nil {
+		return approved, fmt.Errorf("failed to marshal approve request: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/admin/%s/premod/%s/approve", shortName, postID)
+	resp, err := c.do(r, "POST", path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return approved, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return approved, responseError(resp)
+	}
+	if err := utils.Decode(resp.Body, &approved); err != nil {
+		return approved, fmt.Errorf("cannot decode approve response: %w", err)
+	}
+	return approved, nil
+}
+
+// RejectQueuedPost drops a queued post without publishing it
+func (c *APIClient) RejectQueuedPost(r *http.Request, shortName, postID string) error {
+	path := fmt.Sprintf("/v1/admin/%s/premod/%s/reject", shortName, postID)
+	resp, err := c.do(r, "POST", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// SetPosterTrust overrides the board's trust rules for the user: true lets
+// their posts skip the queue, false holds them, nil goes back to the rules.
+func (c *APIClient) SetPosterTrust(r *http.Request, shortName, userID string, trusted *bool) error {
+	path := fmt.Sprintf("/v1/admin/%s/premod/posters/%s/trust", shortName, userID)
+	method, body := "DELETE", io.Reader(nil)
+	if trusted != nil {
+		jsonBody, err := json.Marshal(api.SetPosterTrustRequest{Trusted: trusted})
+		if err != nil {
+			return fmt.Errorf("failed to marshal trust request: %w", err)
+		}
+		method, body = "PUT", bytes.NewBuffer(jsonBody)
+	}
+
+	resp, err := c.do(r, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+)
+
+// SubscribePush registers the browser's push subscription for the authenticated user
+func (c *APIClient) SubscribePush(r *http.Request, body api.PushSubscriptionRequest) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal push subscription: %w", err)
+	}
+
+	resp, err := c.do(r, "POST", "/v1/me/push_subscriptions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to subscribe to push notifications: %s", string(bodyBytes))
+	}
+	return nil
+}
+
+// UnsubscribePush removes the browser's push subscription
+func (c *APIClient) UnsubscribePush(r *http.Request, endpoint string) error {
+	jsonBody, err := json.Marshal(api.DeletePushSubscriptionRequest{Endpoint: endpoint})
+	if err != nil {
+		return fmt.Errorf("failed to marshal push subscription: %w", err)
+	}
+
+	resp, err := c.do(r, "DELETE", "/v1/me/push_subscriptions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to unsubscribe from push notifications: %s", string(bodyBytes))
+	}
+	return nil
+}
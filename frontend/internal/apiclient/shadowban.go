@@ -0,0 +1,58 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+)
+
+// GetShadowbans returns shadowbans on all boards for the given page
+func (c *APIClient) GetShadowbans(r *http.Request, page int) (api.ShadowbansResponse, error) {
+	path := withPage("/v1/admin/shadowbans", page)
+	resp, err := c.do(r, "GET", path, nil)
+	if err != nil {
+		return api.ShadowbansResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return api.ShadowbansResponse{}, fmt.Errorf("failed to get shadowbans: %s", string(bodyBytes))
+	}
+
+	var result api.ShadowbansResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return api.ShadowbansResponse{}, fmt.Errorf("failed to decode shadowbans response: %w", err)
+	}
+
+	return result, nil
+}
+
+// ShadowbanUser shadowbans a user on a board
+func (c *APIClient) ShadowbanUser(r *http.Request, board, userID string) error {
+	return c.setShadowban(r, "POST", board, userID)
+}
+
+// UnshadowbanUser lifts a user's shadowban on a board
+func (c *APIClient) UnshadowbanUser(r *http.Request, board, userID string) error {
+	return c.setShadowban(r, "DELETE", board, userID)
+}
+
+func (c *APIClient) setShadowban(r *http.Request, method, board, userID string) error {
+	path := fmt.Sprintf("/v1/admin/%s/users/%s/shadowban", board, userID)
+	resp, err := c.do(r, method, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update shadowban: %s", string(bodyBytes))
+	}
+
+	return nil
+}
@@ -1,19 +1,20 @@
 package apiclient
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
-	internal_errors "github.com/itchan-dev/itchan/shared/errors"
 	"github.com/itchan-dev/itchan/shared/utils"
 )
 
@@ -23,9 +24,9 @@ func escapeQuotes(s string) string {
 	return quoteEscaper.Replace(s)
 }
 
-func (c *APIClient) GetThread(r *http.Request, shortName, threadID string, page int) (domain.Thread, error) {
+func (c *APIClient) GetThread(r *http.Request, shortName, threadID string, page int, lite bool) (domain.Thread, error) {
 	var thread domain.Thread
-	path := withPage(fmt.Sprintf("/v1/%s/%s", shortName, threadID), page)
+	path := withLite(withPage(fmt.Sprintf("/v1/%s/%s", shortName, threadID), page), lite)
 	resp, err := c.do(r, "GET", path, nil)
 	if err != nil {
 		return thread, err
@@ -33,9 +34,7 @@ func (c *APIClient) GetThread(r *http.Request, shortName, threadID string, page
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return thread, &internal_errors.ErrorWithStatusCode{
-			Message: fmt.Sprintf("thread /%s/%s not found or access denied", shortName, threadID), StatusCode: resp.StatusCode,
-		}
+		return thread, responseError(resp)
 	}
 
 	if err := utils.Decode(resp.Body, &thread); err != nil {
@@ -44,6 +43,78 @@ func (c *APIClient) GetThread(r *http.Request, shortName, threadID string, page
 	return thread, nil
 }
 
+// GetThreadRange fetches the OP and the messages from..to of the thread, to=0
+// lets the backend pick the upper bound
+func (c *APIClient) GetThreadRange(r *http.Request, shortName, threadID string, from, to int, lite bool) (domain.Thread, error) {
+	var thread domain.Thread
+	query := url.Values{"from": {strconv.Itoa(from)}}
+	if to > 0 {
+		query.Set("to", strconv.Itoa(to))
+	}
+	if lite {
+		query.Set("lite", "true")
+	}
+	resp, err := c.do(r, "GET", fmt.Sprintf("/v1/%s/%s?%s", shortName, threadID, query.Encode()), nil)
+	if err != nil {
+		return thread, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return thread, responseError(resp)
+	}
+
+	if err := utils.Decode(resp.Body, &thread); err != nil {
+		return thread, fmt.Errorf("cannot decode thread response: %w", err)
+	}
+	return thread, nil
+}
+
+// GetThreadGraph fetches the reply graph of the thread; the caller passes the
+// JSON through and closes the body
+func (c *APIClient) GetThreadGraph(r *http.Request, shortName, threadID string) (*http.Response, error) {
+	return c.do(r, "GET", fmt.Sprintf("/v1/%s/%s/graph", shortName, threadID), nil)
+}
+
+// GetThreadAttachments fetches every attachment of the thread for the gallery
+func (c *APIClient) GetThreadAttachments(r *http.Request, shortName, threadID string) (domain.ThreadAttachments, error) {
+	var attachments domain.ThreadAttachments
+	resp, err := c.do(r, "GET", fmt.Sprintf("/v1/%s/%s/attachments", shortName, threadID), nil)
+	if err != nil {
+		return attachments, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return attachments, responseError(resp)
+	}
+
+	if err := utils.Decode(resp.Body, &attachments); err != nil {
+		return attachments, fmt.Errorf("cannot decode thread attachments response: %w", err)
+	}
+	return attachments, nil
+}
+
+// GetThreadUpdates fetches the messages of the thread posted after since
+func (c *APIClient) GetThreadUpdates(r *http.Request, shortName, threadID, since string) (domain.ThreadUpdates, error) {
+	var updates domain.ThreadUpdates
+	path := fmt.Sprintf("/v1/%s/%s/messages?since=%s", shortName, threadID, url.QueryEscape(since))
+	resp, err := c.do(r, "GET", path, nil)
+	if err != nil {
+		return updates, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return updates, responseError(resp)
+	}
+
+	if err := utils.Decode(resp.Body, &updates); err != nil {
+		return updates, fmt.Errorf("cannot decode thread updates response: %w", err)
+	}
+	return updates, nil
+}
+
 func (c *APIClient) GetThreadLastModified(r *http.Request, shortName, threadID string) (time.Time, error) {
 	path := fmt.Sprintf("/v1/%s/%s/last_modified", shortName, threadID)
 	resp, err := c.do(r, "GET", path, nil)
@@ -53,7 +124,7 @@ func (c *APIClient) GetThreadLastModified(r *http.Request, shortName, threadID s
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return time.Time{}, fmt.Errorf("backend returned status %d", resp.StatusCode)
+		return time.Time{}, responseError(resp)
 	}
 
 	var result api.LastModifiedResponse
@@ -64,7 +135,7 @@ func (c *APIClient) GetThreadLastModified(r *http.Request, shortName, threadID s
 }
 
 // postMultipartRequest sends a multipart/form-data POST request with JSON payload and optional file attachments
-func (c *APIClient) postMultipartRequest(path string, data any, multipartForm *multipart.Form, token string) ([]byte, int, error) {
+func (c *APIClient) postMultipartRequest(r *http.Request, path string, data any, multipartForm *multipart.Form) ([]byte, int, error) {
 	// Create multipart writer
 	pipeReader, pipeWriter := io.Pipe()
 	writer := multipart.NewWriter(pipeWriter)
@@ -127,13 +198,13 @@ func (c *APIClient) postMultipartRequest(path string, data any, multipartForm *m
 	}()
 
 	// Create request
-	req, err := http.NewRequest("POST", c.BaseURL+path, pipeReader)
+	req, err := newRequest(r, "POST", c.BaseURL+path, pipeReader)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to create API request: %w", err)
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	if token != "" {
+	if token := getToken(r); token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
@@ -149,10 +220,13 @@ func (c *APIClient) postMultipartRequest(path string, data any, multipartForm *m
 
 func (c *APIClient) CreateThread(r *http.Request, shortName string, data api.CreateThreadRequest, multipartForm *multipart.Form) (string, error) {
 	path := fmt.Sprintf("/v1/%s", shortName)
-	bodyBytes, statusCode, err := c.postMultipartRequest(path, data, multipartForm, getToken(r))
+	bodyBytes, statusCode, err := c.postMultipartRequest(r, path, data, multipartForm)
 	if err != nil {
 		return "", err
 	}
+	if statusCode == http.StatusAccepted {
+		return "", ErrPostQueued
+	}
 	if statusCode != http.StatusCreated {
 		return "", fmt.Errorf("failed to create thread: %s", string(bodyBytes))
 	}
@@ -165,10 +239,13 @@ func (c *APIClient) CreateThread(r *http.Request, shortName string, data api.Cre
 
 func (c *APIClient) CreateReply(r *http.Request, shortName, threadID string, data api.CreateMessageRequest, multipartForm *multipart.Form) (int, error) {
 	path := fmt.Sprintf("/v1/%s/%s", shortName, threadID)
-	bodyBytes, statusCode, err := c.postMultipartRequest(path, data, multipartForm, getToken(r))
+	bodyBytes, statusCode, err := c.postMultipartRequest(r, path, data, multipartForm)
 	if err != nil {
 		return 0, err
 	}
+	if statusCode == http.StatusAccepted {
+		return 0, ErrPostQueued
+	}
 	if statusCode != http.StatusCreated {
 		return 0, fmt.Errorf("failed to create reply: %s", string(bodyBytes))
 	}
@@ -181,9 +258,14 @@ func (c *APIClient) CreateReply(r *http.Request, shortName, threadID string, dat
 	return response.Page, nil
 }
 
-func (c *APIClient) DeleteThread(r *http.Request, shortName, threadID string) error {
+func (c *APIClient) DeleteThread(r *http.Request, shortName, threadID, reason string) error {
+	jsonBody, err := json.Marshal(api.DeleteContentRequest{Reason: reason})
+	if err != nil {
+		return fmt.Errorf("failed to marshal delete request: %w", err)
+	}
+
 	path := fmt.Sprintf("/v1/admin/%s/%s", shortName, threadID)
-	resp, err := c.do(r, "DELETE", path, nil)
+	resp, err := c.do(r, "DELETE", path, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return err
 	}
@@ -215,3 +297,43 @@ func (c *APIClient) TogglePinnedThread(r *http.Request, shortName, threadID stri
 	}
 	return result.IsPinned, nil
 }
+
+func (c *APIClient) ToggleOpOnlyThread(r *http.Request, shortName, threadID string) (bool, error) {
+	path := fmt.Sprintf("/v1/admin/%s/%s/op_only", shortName, threadID)
+	resp, err := c.do(r, "POST", path, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("failed to toggle op-only: %s", string(bodyBytes))
+	}
+
+	var result api.ToggleOpOnlyThreadResponse
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return false, fmt.Errorf("failed to decode op-only response: %w", err)
+	}
+	return result.OpOnly, nil
+}
+
+// SetThreadSuccessor marks successorId as the thread that continues threadID
+func (c *APIClient) SetThreadSuccessor(r *http.Request, shortName, threadID string, successorId int64) error {
+	jsonBody, err := json.Marshal(api.SetThreadSuccessorRequest{SuccessorId: successorId})
+	if err != nil {
+		return fmt.Errorf("failed to marshal successor request: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/%s/%s/successor", shortName, threadID)
+	resp, err := c.do(r, "PUT", path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
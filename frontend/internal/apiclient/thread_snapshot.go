@@ -0,0 +1,79 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// CreateThreadSnapshot saves a static HTML page of the thread, or returns the
+// latest one when the thread has not changed since
+func (c *APIClient) CreateThreadSnapshot(r *http.Request, shortName, threadId string) (domain.ThreadSnapshot, error) {
+	var snapshot domain.ThreadSnapshot
+	resp, err := c.do(r, "POST", fmt.Sprintf("/v1/%s/%s/snapshots", shortName, threadId), nil)
+	if err != nil {
+		return snapshot, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return snapshot, responseError(resp)
+	}
+	if err := utils.Decode(resp.Body, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("cannot decode snapshot response: %w", err)
+	}
+	return snapshot, nil
+}
+
+// GetThreadSnapshots fetches a page of the board's thread snapshots
+func (c *APIClient) GetThreadSnapshots(r *http.Request, shortName string, page int) (api.ThreadSnapshotsResponse, error) {
+	var result api.ThreadSnapshotsResponse
+	resp, err := c.do(r, "GET", withPage(fmt.Sprintf("/v1/%s/snapshots", shortName), page), nil)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return result, responseError(resp)
+	}
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return result, fmt.Errorf("cannot decode snapshots response: %w", err)
+	}
+	return result, nil
+}
+
+// GetThreadSnapshot fetches a snapshot's metadata, including the path of its
+// page in the media storage
+func (c *APIClient) GetThreadSnapshot(r *http.Request, shortName, snapshotId string) (domain.ThreadSnapshot, error) {
+	var snapshot domain.ThreadSnapshot
+	resp, err := c.do(r, "GET", fmt.Sprintf("/v1/%s/snapshots/%s", shortName, snapshotId), nil)
+	if err != nil {
+		return snapshot, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return snapshot, responseError(resp)
+	}
+	if err := utils.Decode(resp.Body, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("cannot decode snapshot response: %w", err)
+	}
+	return snapshot, nil
+}
+
+func (c *APIClient) DeleteThreadSnapshot(r *http.Request, shortName, snapshotId string) error {
+	resp, err := c.do(r, "DELETE", fmt.Sprintf("/v1/admin/%s/snapshots/%s", shortName, snapshotId), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
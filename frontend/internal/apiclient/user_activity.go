@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/itchan-dev/itchan/shared/utils"
 )
@@ -33,3 +34,63 @@ func (c *APIClient) GetUserActivity(r *http.Request) ([]domain.Message, error) {
 
 	return messages, nil
 }
+
+// GetUserStats fetches the authenticated user's posting statistics
+func (c *APIClient) GetUserStats(r *http.Request) (domain.UserStats, error) {
+	resp, err := c.do(r, "GET", "/v1/me/stats", nil)
+	if err != nil {
+		return domain.UserStats{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return domain.UserStats{}, fmt.Errorf("failed to get user stats: %s", string(bodyBytes))
+	}
+
+	var stats domain.UserStats
+	if err := utils.Decode(resp.Body, &stats); err != nil {
+		return domain.UserStats{}, fmt.Errorf("failed to parse user stats: %w", err)
+	}
+	return stats, nil
+}
+
+// GetUserPosts fetches one page of the authenticated user's post history
+func (c *APIClient) GetUserPosts(r *http.Request, page int) (api.UserPostsResponse, error) {
+	resp, err := c.do(r, "GET", withPage("/v1/me/posts", page), nil)
+	if err != nil {
+		return api.UserPostsResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return api.UserPostsResponse{}, fmt.Errorf("failed to get user posts: %s", string(bodyBytes))
+	}
+
+	var result api.UserPostsResponse
+	if err := utils.Decode(resp.Body, &result); err != nil {
+		return api.UserPostsResponse{}, fmt.Errorf("failed to parse user posts: %w", err)
+	}
+	return result, nil
+}
+
+// GetSiteActivity fetches the index page widgets data (latest posts, posts today, active threads)
+func (c *APIClient) GetSiteActivity(r *http.Request) (domain.SiteActivity, error) {
+	resp, err := c.do(r, "GET", "/v1/activity", nil)
+	if err != nil {
+		return domain.SiteActivity{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return domain.SiteActivity{}, fmt.Errorf("failed to get site activity: %s", string(bodyBytes))
+	}
+
+	var activity domain.SiteActivity
+	if err := utils.Decode(resp.Body, &activity); err != nil {
+		return domain.SiteActivity{}, fmt.Errorf("failed to parse site activity: %w", err)
+	}
+	return activity, nil
+}
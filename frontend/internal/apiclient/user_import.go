@@ -0,0 +1,29 @@
+package apiclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// ImportUsers forwards a CSV of emails to the backend, which creates the
+// missing accounts and, with invite, emails their owners.
+func (c *APIClient) ImportUsers(r *http.Request, csv io.Reader, invite bool) (domain.UserImportReport, error) {
+	resp, err := c.do(r, "POST", fmt.Sprintf("/v1/admin/users/import?invite=%t", invite), csv)
+	if err != nil {
+		return domain.UserImportReport{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return domain.UserImportReport{}, responseError(resp)
+	}
+	var report domain.UserImportReport
+	if err := utils.Decode(resp.Body, &report); err != nil {
+		return domain.UserImportReport{}, fmt.Errorf("cannot decode user import response: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,60 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/api"
+)
+
+// StartViewAs asks for a read-only token to view the site as a user
+func (c *APIClient) StartViewAs(r *http.Request, userID, reason string) (api.ViewAsResponse, error) {
+	jsonBody, err := json.Marshal(api.ViewAsRequest{Reason: reason})
+	if err != nil {
+		return api.ViewAsResponse{}, fmt.Errorf("failed to marshal view-as request: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/admin/users/%s/view_as", userID)
+	resp, err := c.do(r, "POST", path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return api.ViewAsResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return api.ViewAsResponse{}, fmt.Errorf("failed to start view-as: %s", string(bodyBytes))
+	}
+
+	var result api.ViewAsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return api.ViewAsResponse{}, fmt.Errorf("failed to decode view-as response: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetViewAsSessions returns the view-as audit log for the given page
+func (c *APIClient) GetViewAsSessions(r *http.Request, page int) (api.ViewAsSessionsResponse, error) {
+	path := withPage("/v1/admin/view_as", page)
+	resp, err := c.do(r, "GET", path, nil)
+	if err != nil {
+		return api.ViewAsSessionsResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return api.ViewAsSessionsResponse{}, fmt.Errorf("failed to get view-as sessions: %s", string(bodyBytes))
+	}
+
+	var result api.ViewAsSessionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return api.ViewAsSessionsResponse{}, fmt.Errorf("failed to decode view-as sessions response: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,108 @@
+// Package assets serves the frontend's static files under content-hashed
+// names, so they can be cached forever and still change on every deploy.
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hashLen is the number of hex digits of the content hash put in file names.
+const hashLen = 12
+
+// immutableCacheControl is sent for hashed names: their content never changes.
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// Assets is the manifest of a static file tree: every file's hashed name,
+// computed from its content when the tree is loaded.
+type Assets struct {
+	fsys   fs.FS
+	maxAge time.Duration // Cache lifetime for files requested by their plain name
+
+	mu       sync.RWMutex
+	hashed   map[string]string // "css/style.css" -> "css/style.0123456789ab.css"
+	original map[string]string // the reverse
+}
+
+// New hashes every file in fsys. maxAge is how long files requested by
+// their plain name (service workers, favicon) may be cached.
+func New(fsys fs.FS, maxAge time.Duration) (*Assets, error) {
+	a := &Assets{fsys: fsys, maxAge: maxAge}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Reload rehashes the tree, for development setups that serve it from disk.
+func (a *Assets) Reload() error {
+	hashed := make(map[string]string)
+	original := make(map[string]string)
+	err := fs.WalkDir(a.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(a.fsys, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		ext := path.Ext(name)
+		h := strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:])[:hashLen] + ext
+		hashed[name] = h
+		original[h] = name
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("hashing static files: %w", err)
+	}
+
+	a.mu.Lock()
+	a.hashed, a.original = hashed, original
+	a.mu.Unlock()
+	return nil
+}
+
+// Path returns the URL of the named file under /static/ with its content
+// hash in the name. An unknown name is an error, so a template that links a
+// missing file fails to render.
+func (a *Assets) Path(name string) (string, error) {
+	a.mu.RLock()
+	h, ok := a.hashed[name]
+	a.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown static file %q", name)
+	}
+	return "/static/" + h, nil
+}
+
+// Handler serves the tree by hashed and plain names, with the leading slash
+// of the request path dropped. Other paths go to fallback.
+func (a *Assets) Handler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+
+		a.mu.RLock()
+		orig, isHashed := a.original[name]
+		_, isPlain := a.hashed[name]
+		a.mu.RUnlock()
+
+		switch {
+		case isHashed:
+			w.Header().Set("Cache-Control", immutableCacheControl)
+			http.ServeFileFS(w, r, a.fsys, orig)
+		case isPlain:
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(a.maxAge.Seconds())))
+			http.ServeFileFS(w, r, a.fsys, name)
+		default:
+			fallback.ServeHTTP(w, r)
+		}
+	})
+}
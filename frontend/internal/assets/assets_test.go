@@ -0,0 +1,93 @@
+package assets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAssets(t *testing.T, fsys fstest.MapFS) *Assets {
+	t.Helper()
+	a, err := New(fsys, time.Hour)
+	require.NoError(t, err)
+	return a
+}
+
+func TestPath(t *testing.T) {
+	a := newTestAssets(t, fstest.MapFS{
+		"css/style.css": {Data: []byte("body{}")},
+		"js/main.js":    {Data: []byte("main()")},
+	})
+
+	css, err := a.Path("css/style.css")
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^/static/css/style\.[0-9a-f]{12}\.css$`), css)
+
+	js, err := a.Path("js/main.js")
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^/static/js/main\.[0-9a-f]{12}\.js$`), js)
+
+	_, err = a.Path("css/missing.css")
+	assert.Error(t, err)
+}
+
+func TestReloadChangesHash(t *testing.T) {
+	fsys := fstest.MapFS{"css/style.css": {Data: []byte("body{}")}}
+	a := newTestAssets(t, fsys)
+	before, err := a.Path("css/style.css")
+	require.NoError(t, err)
+
+	fsys["css/style.css"] = &fstest.MapFile{Data: []byte("body{color:red}")}
+	require.NoError(t, a.Reload())
+
+	after, err := a.Path("css/style.css")
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+}
+
+func TestHandler(t *testing.T) {
+	a := newTestAssets(t, fstest.MapFS{"css/style.css": {Data: []byte("body{}")}})
+	handler := http.StripPrefix("/static", a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+	hashed, err := a.Path("css/style.css")
+	require.NoError(t, err)
+
+	t.Run("hashed name is immutable", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, hashed, nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "body{}", rr.Body.String())
+		assert.Equal(t, immutableCacheControl, rr.Header().Get("Cache-Control"))
+		assert.Contains(t, rr.Header().Get("Content-Type"), "text/css")
+	})
+
+	t.Run("plain name uses max age", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/static/css/style.css", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "public, max-age=3600", rr.Header().Get("Cache-Control"))
+	})
+
+	t.Run("outdated hash falls through", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/static/css/style.000000000000.css", nil))
+
+		assert.Equal(t, http.StatusTeapot, rr.Code)
+	})
+
+	t.Run("directory falls through", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/static/css", nil))
+
+		assert.Equal(t, http.StatusTeapot, rr.Code)
+	})
+}
@@ -0,0 +1,149 @@
+// Package boardcss sanitizes the custom stylesheets admins give their boards.
+package boardcss
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	commentRe  = regexp.MustCompile(`(?s)/\*.*?(\*/|$)`)
+	selectorRe = regexp.MustCompile(`^[\w\s.#:>+~*,\[\]="'^$|()-]+$`)
+	propertyRe = regexp.MustCompile(`^(--)?[a-z][a-z-]*$`)
+	valueRe    = regexp.MustCompile(`^[\w\s#%.,()'"+\-/!*]+$`)
+	functionRe = regexp.MustCompile(`([\w-]*)\(`)
+)
+
+// allowedProperties are the properties a board stylesheet may set, besides
+// custom properties (--name). Layout and generated content are left out so a
+// board can restyle its pages but not hide or fake parts of them.
+var allowedProperties = map[string]bool{
+	"color": true, "opacity": true, "cursor": true,
+	"background": true, "background-color": true, "background-image": true,
+	"background-position": true, "background-repeat": true, "background-size": true,
+	"font": true, "font-family": true, "font-size": true, "font-style": true,
+	"font-weight": true, "font-variant": true,
+	"letter-spacing": true, "line-height": true, "word-spacing": true,
+	"text-align": true, "text-decoration": true, "text-shadow": true, "text-transform": true,
+	"border": true, "border-color": true, "border-style": true, "border-width": true,
+	"border-top": true, "border-right": true, "border-bottom": true, "border-left": true,
+	"border-radius": true, "box-shadow": true,
+	"outline": true, "outline-color": true, "outline-style": true, "outline-width": true,
+	"margin": true, "margin-top": true, "margin-right": true, "margin-bottom": true, "margin-left": true,
+	"padding": true, "padding-top": true, "padding-right": true, "padding-bottom": true, "padding-left": true,
+	"width": true, "min-width": true, "max-width": true,
+	"height": true, "min-height": true, "max-height": true,
+}
+
+// allowedFunctions can't fetch anything: url(), image-set() and the like are
+// how a stylesheet loads resources or tracks readers.
+var allowedFunctions = []string{
+	"", // plain parentheses, e.g. inside calc()
+	"rgb", "rgba", "hsl", "hsla", "var", "calc", "min", "max", "clamp",
+	"linear-gradient", "radial-gradient", "repeating-linear-gradient", "repeating-radial-gradient",
+}
+
+// Sanitize keeps the style rules of css that use allowed properties and
+// values and serializes them again, one declaration per line. At-rules
+// (@import, @font-face, @media...), comments, nested blocks and anything
+// that does not parse are dropped rather than repaired. The result is safe
+// to serve as text/css from the site's origin.
+func Sanitize(css string) string {
+	// Escapes could spell out anything the checks below look for
+	css = strings.NewReplacer(`\`, "", "<", "").Replace(css)
+	css = commentRe.ReplaceAllString(css, "")
+
+	var b strings.Builder
+	for len(css) > 0 {
+		css = strings.TrimLeft(css, " \t\r\n;")
+		if css == "" {
+			break
+		}
+
+		open := strings.IndexByte(css, '{')
+		if css[0] == '@' {
+			// Statement at-rules end at ';', block ones with their block
+			if semi := strings.IndexByte(css, ';'); semi >= 0 && (open < 0 || semi < open) {
+				css = css[semi+1:]
+				continue
+			}
+		}
+		if open < 0 {
+			break
+		}
+		end := blockEnd(css, open)
+		if end < 0 {
+			break
+		}
+		prelude, body := strings.TrimSpace(css[:open]), css[open+1:end]
+		css = css[end+1:]
+
+		if strings.HasPrefix(prelude, "@") || strings.ContainsAny(body, "{}") || !selectorRe.MatchString(prelude) {
+			continue
+		}
+		writeRule(&b, prelude, body)
+	}
+	return b.String()
+}
+
+// blockEnd returns the index of the '}' closing the block opened at open, or
+// -1 when the block is never closed.
+func blockEnd(css string, open int) int {
+	depth := 0
+	for i := open; i < len(css); i++ {
+		switch css[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func writeRule(b *strings.Builder, selector, body string) {
+	var declarations []string
+	for _, declaration := range strings.Split(body, ";") {
+		property, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.Join(strings.Fields(value), " ")
+		if allowedDeclaration(property, value) {
+			declarations = append(declarations, "  "+property+": "+value+";\n")
+		}
+	}
+	if len(declarations) == 0 {
+		return
+	}
+
+	b.WriteString(strings.Join(strings.Fields(selector), " ") + " {\n")
+	for _, d := range declarations {
+		b.WriteString(d)
+	}
+	b.WriteString("}\n")
+}
+
+func allowedDeclaration(property, value string) bool {
+	if !propertyRe.MatchString(property) || !valueRe.MatchString(value) {
+		return false
+	}
+	// A string cut by the ';' split would run into the next declaration
+	if strings.Count(value, `"`)%2 != 0 || strings.Count(value, "'")%2 != 0 {
+		return false
+	}
+	if !strings.HasPrefix(property, "--") && !allowedProperties[property] {
+		return false
+	}
+	for _, m := range functionRe.FindAllStringSubmatch(value, -1) {
+		if !slices.Contains(allowedFunctions, strings.ToLower(m[1])) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package boardcss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		css  string
+		want string
+	}{
+		{
+			name: "keeps allowed rules",
+			css:  ".post{color:#c00;background:linear-gradient(#fff, rgba(0,0,0,.5))}",
+			want: ".post {\n  color: #c00;\n  background: linear-gradient(#fff, rgba(0,0,0,.5));\n}\n",
+		},
+		{
+			name: "normalizes whitespace and property case",
+			css:  "\n  .board-header   h1 ,a:hover {\n\tFONT-SIZE :  2em ;; }",
+			want: ".board-header h1 ,a:hover {\n  font-size: 2em;\n}\n",
+		},
+		{
+			name: "custom properties",
+			css:  ":root{--accent: #09f} a{color: var(--accent) !important}",
+			want: ":root {\n  --accent: #09f;\n}\na {\n  color: var(--accent) !important;\n}\n",
+		},
+		{
+			name: "drops disallowed properties",
+			css:  ".post{position:fixed;display:none;color:red;content:'fake'}",
+			want: ".post {\n  color: red;\n}\n",
+		},
+		{
+			name: "drops url and other fetching functions",
+			css:  "body{background:url(https://evil.example/t.gif);background-image:image-set('x.png' 1x);color:red}",
+			want: "body {\n  color: red;\n}\n",
+		},
+		{
+			name: "escapes can't hide url",
+			css:  `body{background:u\72l(//evil.example)}`,
+			want: "",
+		},
+		{
+			name: "uppercase function names",
+			css:  "body{background:URL(//evil.example)}",
+			want: "",
+		},
+		{
+			name: "drops at-rules",
+			css:  "@import url(//evil.example/x.css);@import 'x.css';@media (max-width:1px){body{color:red}}@font-face{font-family:x}a{color:blue}",
+			want: "a {\n  color: blue;\n}\n",
+		},
+		{
+			name: "drops comments",
+			css:  "a{/* } body { */color:blue}/* unterminated",
+			want: "a {\n  color: blue;\n}\n",
+		},
+		{
+			name: "drops nested blocks",
+			css:  "a{color:red; b{color:blue}} p{color:green}",
+			want: "p {\n  color: green;\n}\n",
+		},
+		{
+			name: "closing style tag",
+			css:  "a{color:red}</style><script>alert(1)</script>",
+			want: "a {\n  color: red;\n}\n",
+		},
+		{
+			name: "unterminated block is dropped",
+			css:  "a{color:red} p{color:blue",
+			want: "a {\n  color: red;\n}\n",
+		},
+		{
+			name: "unbalanced quotes",
+			css:  `a{font-family:"Comic Sans;color:red}`,
+			want: "a {\n  color: red;\n}\n",
+		},
+		{
+			name: "rule without declarations",
+			css:  "a{}b{color:red}",
+			want: "b {\n  color: red;\n}\n",
+		},
+		{
+			name: "invalid selector",
+			css:  "a;b&c{color:red} p{color:blue}",
+			want: "p {\n  color: blue;\n}\n",
+		},
+		{
+			name: "empty",
+			css:  "",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Sanitize(tt.css))
+		})
+	}
+}
+
+func TestSanitizeIsIdempotent(t *testing.T) {
+	css := ":root{--a:#fff} .post , .op-post{border:1px solid var(--a);margin:calc(1em + (2px * 3))}"
+	once := Sanitize(css)
+	assert.NotEmpty(t, once)
+	assert.Equal(t, once, Sanitize(once))
+}
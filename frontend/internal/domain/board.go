@@ -6,5 +6,19 @@ import (
 
 type Board struct {
 	domain.Board
-	Threads []*Thread
+	Threads    []*Thread
+	Appearance BoardAppearance
+}
+
+// PageLanguage is the language the board page is shown in.
+func (b Board) PageLanguage() domain.BoardLanguage {
+	return b.Language
+}
+
+// BoardAppearance is what a board page needs to show the board's identity:
+// the banner picked for this render and, when the board has custom CSS,
+// a version of the sanitized stylesheet for cache busting.
+type BoardAppearance struct {
+	Banner     *domain.BoardBanner
+	CSSVersion string
 }
@@ -1,6 +1,12 @@
 package frontend_domain
 
-import "github.com/itchan-dev/itchan/shared/domain"
+import (
+	"html/template"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/domain"
+)
 
 // CommonTemplateData holds fields that are common to all page templates.
 // Available in templates as .Common via the TemplateData wrapper.
@@ -9,10 +15,23 @@ type CommonTemplateData struct {
 	Success          string
 	User             *domain.User
 	Validation       ValidationData
-	CSRFToken        string // CSRF token for form submissions
-	EmailPlaceholder string // Pre-filled email for auth forms (from cookie, not URL)
-	DisableMedia     bool   // Hide media (images/videos) and show text placeholders
-	StaticVersion    string // Cache-buster for static assets; changes on every server restart/redeploy
+	CSRFToken        string         // CSRF token for form submissions
+	EmailPlaceholder string         // Pre-filled email for auth forms (from cookie, not URL)
+	DisableMedia     bool           // Hide media (images/videos) and show text placeholders
+	InfiniteScroll   bool           // Board pages append the next page near the bottom
+	LowBandwidth     bool           // Attachments render as placeholders that load media on click
+	Location         *time.Location // Timezone used to display timestamps
+	TimeZone         string         // Name of Location (e.g. "Europe/Moscow")
+	TimeZoneExplicit bool           // True if the user picked the timezone, false if it comes from the browser
+	Language         string         // Language of the board the page belongs to, empty for English
+
+	UnreadNotifications int // Shown in the header; only loaded for full pages
+
+	Maintenance *api.MaintenanceResponse // Active or upcoming read-only maintenance, shown as a banner
+
+	LoginURL string // Login page link that comes back to the current page
+
+	Footer template.HTML // Instance footer from the config, e.g. a content license
 }
 
 // ValidationData holds all validation constants needed by templates.
@@ -21,16 +40,21 @@ type ValidationData struct {
 	// Auth-related validation
 	PasswordMinLen      int
 	ConfirmationCodeLen int
+	AppealTextMaxLen    int
 
 	// Board-related validation
-	BoardNameMaxLen      int
-	BoardShortNameMaxLen int
+	BoardNameMaxLen         int
+	BoardShortNameMaxLen    int
+	BoardCategoryNameMaxLen int
+	BoardDescriptionMaxLen  int
 
 	// Thread-related validation
 	ThreadTitleMaxLen int
 
 	// Message-related validation
 	MessageTextMaxLen int
+	SelfDeleteWindow  time.Duration // Zero disables deleting own posts
+	BoardPostNumbers  bool          // Show board-local post numbers instead of thread#msg
 
 	// Attachment-related validation
 	MaxAttachmentsPerMessage int
@@ -38,6 +62,8 @@ type ValidationData struct {
 	MaxAttachmentSizeBytes   int64
 	AllowedImageMimeTypes    []string
 	AllowedVideoMimeTypes    []string
+	AllowedDocumentMimeTypes []string // Only accepted on boards with AllowDocuments
+	MaxDocumentSizeBytes     int64
 
 	// User activity page settings
 	UserMessagesPageLimit int
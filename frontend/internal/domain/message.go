@@ -2,21 +2,31 @@ package frontend_domain
 
 import (
 	"html/template"
+	"time"
 
 	"github.com/itchan-dev/itchan/shared/domain"
 )
 
 // RenderContext contains presentation-specific fields for rendering messages.
 type RenderContext struct {
-	ExtraClasses string // CSS classes: "op-post", "reply-post", "message-preview"
-	Subject      string // Subject line (thread title for OP messages)
-	IsPinned     bool   // Whether the parent thread is pinned (only relevant for OP messages)
+	ExtraClasses string          // CSS classes: "op-post", "reply-post", "message-preview"
+	Subject      string          // Subject line (thread title for OP messages)
+	IsPinned     bool            // Whether the parent thread is pinned (only relevant for OP messages)
+	OpOnly       bool            // Whether only the OP may reply to the parent thread (only relevant for OP messages)
+	Continues    domain.ThreadId // Older thread the parent thread continues, 0 if none (only relevant for OP messages)
 }
 
 // Message wraps domain.Message with frontend-specific fields.
 // Text is overwritten for HTML safety. Replies and Page come from embedded struct.
 type Message struct {
 	domain.Message
-	Text    template.HTML
-	Context RenderContext
+	Text     template.HTML
+	Context  RenderContext
+	Deletion *domain.DeletedMessage // Non-nil for a stub shown in place of a moderated message
+}
+
+// CanSelfDelete reports whether the author may still delete this reply
+// themselves. Reply and board policy checks are left to the backend.
+func (m Message) CanSelfDelete(window time.Duration) bool {
+	return m.IsOwn && !m.IsOp() && window > 0 && time.Since(m.CreatedAt) < window
 }
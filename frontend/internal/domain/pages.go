@@ -1,15 +1,167 @@
 package frontend_domain
 
-import "github.com/itchan-dev/itchan/shared/domain"
+import (
+	"html/template"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
 
 type BoardWithAccess struct {
 	domain.Board
 	Accessible bool
 }
 
+// BoardCategoryGroup is a named category with its boards in display order.
+type BoardCategoryGroup struct {
+	Name   string
+	Boards []BoardWithAccess
+}
+
+// LatestPost is a feed entry with its text cut down to a plain snippet.
+type LatestPost struct {
+	domain.LatestPost
+	Snippet string
+}
+
+// AccountPageData is the account page: recent posts and posting statistics.
+type AccountPageData struct {
+	Activity []*Message
+	Stats    *domain.UserStats // Nil when the stats could not be loaded
+}
+
+// UserPostsPageData is one page of the user's post history.
+type UserPostsPageData struct {
+	Posts   []LatestPost
+	Page    int
+	Total   int
+	HasNext bool
+}
+
+// Bookmark is a bookmarked message with its text cut down to a plain snippet.
+type Bookmark struct {
+	LatestPost
+	BookmarkedAt time.Time
+}
+
+// BookmarksPageData is one page of the messages the user bookmarked.
+type BookmarksPageData struct {
+	Bookmarks []Bookmark
+	Page      int
+	Total     int
+	HasNext   bool
+}
+
+// ModLogPageData is one page of a board's public moderation log.
+type ModLogPageData struct {
+	Board   domain.BoardShortName
+	Entries []domain.ModLogEntry
+	Page    int
+	HasNext bool
+}
+
+// ThreadSnapshotsPageData is one page of a board's thread snapshots.
+type ThreadSnapshotsPageData struct {
+	Board     domain.BoardShortName
+	Snapshots []domain.ThreadSnapshot
+	Page      int
+	HasNext   bool
+}
+
+// BoardAppearancePageData is the admin page for a board's banners and custom CSS.
+type BoardAppearancePageData struct {
+	Board              domain.BoardShortName
+	Appearance         domain.BoardAppearance
+	CustomCSSMaxLen    int
+	MaxBannerSizeBytes int64
+	MaxBanners         int
+	BannerMimeTypes    []string
+}
+
+// BoardPostersPageData is the admin page ranking a board's most active posters.
+type BoardPostersPageData struct {
+	Posters domain.BoardPosters
+	Window  string   // As requested, empty for the default
+	Windows []string // Offered in the window selector
+}
+
+// PremodQueuePageData is the admin page of a board's pre-moderation queue.
+type PremodQueuePageData struct {
+	Board   domain.BoardShortName
+	Posts   []QueuedPost
+	Posters []domain.PremodPoster
+}
+
+// QueuedPost is a held post with its text rendered for review.
+type QueuedPost struct {
+	domain.QueuedPost
+	Text template.HTML
+}
+
+// GalleryPageData is the gallery of a thread: all its attachments in one grid.
+type GalleryPageData struct {
+	Board       domain.BoardShortName
+	ThreadId    domain.ThreadId
+	Attachments []domain.ThreadAttachment
+	Appearance  BoardAppearance
+}
+
+// Notification is a notification center entry with the reply cut down to a snippet.
+type Notification struct {
+	domain.Notification
+	Snippet string
+}
+
+// NotificationsPageData is one page of the notification center, with the
+// email digest settings.
+type NotificationsPageData struct {
+	Notifications   []Notification
+	Page            int
+	Unread          int
+	HasNext         bool
+	Watched         []domain.WatchedThread // Watched threads, those with the most unread posts first
+	DigestEnabled   bool                   // Digests are configured on this site
+	DigestFrequency domain.DigestFrequency
+	PushPublicKey   string // VAPID key browsers subscribe with; empty when push is off
+}
+
+// LoginPageData carries where to go after logging in.
+type LoginPageData struct {
+	Next string // Path on this site, empty for the board list
+}
+
+// SessionsRevokePageData carries the token of the "this wasn't me" link in
+// new device emails.
+type SessionsRevokePageData struct {
+	Token   string // Empty when the page is opened without one
+	Revoked bool   // The sessions have just been signed out
+}
+
+// ErrorPageData explains why a page could not be shown.
+type ErrorPageData struct {
+	StatusCode int
+	Title      string
+	Message    string
+	SignIn     bool   // Offer the login and registration pages
+	RequestID  string // Shown on server errors, so a report can be matched to the logs
+	BackURL    string
+	BackLabel  string
+}
+
+// ActivityWidgets holds the index page activity blocks; nil hides them.
+type ActivityWidgets struct {
+	LatestPosts   []LatestPost
+	PostsToday    int
+	ActiveThreads []domain.ActiveThread
+}
+
+// IndexPageData lists categorized boards first; boards without a category
+// fall back to the public/corporate split.
 type IndexPageData struct {
+	Categories      []BoardCategoryGroup
 	PublicBoards    []domain.Board
 	CorporateBoards []BoardWithAccess
+	Activity        *ActivityWidgets
 }
 
 type BlacklistedUsers struct {
@@ -61,7 +213,39 @@ func PivotRefStats(stats []domain.ReferralActionStats) *RefStatsPivot {
 	return &RefStatsPivot{Actions: actions, Rows: rows}
 }
 
+// BoardPlacement is a board row in the admin category editor.
+type BoardPlacement struct {
+	ShortName  domain.BoardShortName
+	Name       domain.BoardName
+	CategoryId domain.BoardCategoryId // 0 means uncategorized
+	Position   int
+	Settings   domain.BoardSettings
+	Stats      *domain.BoardStats
+}
+
+// BoardListPage is the position of the admin board table in the paginated listing.
+type BoardListPage struct {
+	Sort    domain.BoardSort
+	Page    int
+	HasNext bool
+}
+
 type AdminPageData struct {
 	Blacklisted BlacklistedUsers
+	Appeals     []domain.Appeal        // Pending ban appeals, oldest first
+	Shadowbans  []domain.Shadowban     // Newest first, first page only
+	ViewAs      []domain.ViewAsSession // Newest first, first page only
+	FailedPosts []domain.FailedPost    // Newest first, first page only
+	CaptureOn   bool                   // Whether failed posts are being captured
 	RefStats    *RefStatsPivot
+	Categories  []domain.BoardCategory
+	Boards      []BoardPlacement
+	BoardList   BoardListPage
+
+	PendingDeletions []domain.PendingBoardDeletion // Boards in their deletion grace period, due first
+	Mirrors          []domain.BoardMirror          // Boards copied from other instances
+
+	VideoProfiles       []string // Names of the configured video profiles, sorted
+	DefaultVideoProfile string
+	UploadMimeTypes     []string // Image and video types a board can restrict uploads to
 }
@@ -1,7 +1,15 @@
 package frontend_domain
 
+import "github.com/itchan-dev/itchan/shared/domain"
+
 // PostData is the typed data for the "post" template partial.
 type PostData struct {
 	Message *Message
 	Common  *CommonTemplateData
 }
+
+// ReplyLinkData is the typed data for the "reply-link" template partial.
+type ReplyLinkData struct {
+	Reply  *domain.Reply
+	Common *CommonTemplateData
+}
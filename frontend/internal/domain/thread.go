@@ -8,4 +8,71 @@ type Thread struct {
 	domain.Thread
 	Messages       []*Message
 	OmittedReplies int
+	FirstUnreadId  domain.MsgId // Where the "unread posts below" divider goes, 0 for none
+	Preview        *LinkPreview
+	Appearance     BoardAppearance
+}
+
+// PageLanguage is the language the thread page is shown in, the one of its board.
+func (t Thread) PageLanguage() domain.BoardLanguage {
+	return t.Language
+}
+
+// AcceptsRepliesFrom reports whether the reply form should be offered to user.
+// Threads reserved for their OP take replies from the OP and moderators only,
+// mirrored threads from nobody; the backend enforces the same rules.
+func (t Thread) AcceptsRepliesFrom(user *domain.User) bool {
+	if t.Mirror {
+		return false
+	}
+	if !t.OpOnly || user.Admin {
+		return true
+	}
+	for _, msg := range t.Messages {
+		if msg.Deletion == nil && msg.IsOp() {
+			return msg.IsOwn
+		}
+	}
+	return false
+}
+
+// CanLinkSuccessor reports whether the form linking the thread's continuation
+// should be offered to user: moderators and the OP of a thread that is not
+// linked yet. The backend also requires the thread to be past the bump limit.
+func (t Thread) CanLinkSuccessor(user *domain.User) bool {
+	if user == nil || t.SuccessorId != nil {
+		return false
+	}
+	if user.Admin {
+		return true
+	}
+	for _, msg := range t.Messages {
+		if msg.Deletion == nil && msg.IsOp() {
+			return msg.IsOwn
+		}
+	}
+	return false
+}
+
+// LinkPreview holds the meta/OpenGraph tags that make shared thread links unfurl.
+// All URLs are absolute.
+type LinkPreview struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+	OEmbedURL   string // oEmbed discovery endpoint for this thread
+}
+
+// ThreadUpdates is the JSON answer to thread pages polling for new posts.
+type ThreadUpdates struct {
+	Posts     string     `json:"posts"`     // Rendered new posts, in id order
+	Backlinks []Backlink `json:"backlinks"` // Reply links to add to posts already on the page
+}
+
+// Backlink is a rendered reply link from post From for the header of post To.
+type Backlink struct {
+	From domain.MsgId `json:"from"`
+	To   domain.MsgId `json:"to"`
+	HTML string       `json:"html"`
 }
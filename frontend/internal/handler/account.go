@@ -7,6 +7,7 @@ import (
 	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/itchan-dev/itchan/shared/logger"
 	mw "github.com/itchan-dev/itchan/shared/middleware"
+	"github.com/itchan-dev/itchan/shared/utils"
 )
 
 // AccountGetHandler displays the user's account page with activity
@@ -25,10 +26,43 @@ func (h *Handler) AccountGetHandler(w http.ResponseWriter, r *http.Request) {
 		activity = []domain.Message{}
 	}
 
-	activityMessages := make([]*frontend_domain.Message, len(activity))
+	data := frontend_domain.AccountPageData{Activity: make([]*frontend_domain.Message, len(activity))}
 	for i, msg := range activity {
-		activityMessages[i] = renderMessage(msg)
+		data.Activity[i] = renderMessage(msg)
 	}
 
-	h.renderTemplateWithError(w, r, "account.html", activityMessages, errMsg)
+	if stats, err := h.APIClient.GetUserStats(r); err != nil {
+		logger.Log.Error("failed to get user stats from API", "error", err)
+	} else {
+		data.Stats = &stats
+	}
+
+	h.renderTemplateWithError(w, r, "account.html", data, errMsg)
+}
+
+// UserPostsGetHandler displays the user's post history across the boards they can read
+func (h *Handler) UserPostsGetHandler(w http.ResponseWriter, r *http.Request) {
+	page := utils.GetPage(r)
+
+	result, err := h.APIClient.GetUserPosts(r, page)
+	var errMsg string
+	if err != nil {
+		logger.Log.Error("failed to get user posts from API", "error", err)
+		errMsg = "Failed to load posts"
+		result.Page = page
+	}
+
+	data := frontend_domain.UserPostsPageData{
+		Page:    result.Page,
+		Total:   result.Total,
+		HasNext: result.Page*h.Public.UserPostsPageLimit < result.Total,
+	}
+	for _, p := range result.Posts {
+		data.Posts = append(data.Posts, frontend_domain.LatestPost{
+			LatestPost: p,
+			Snippet:    snippet(plainText(p.Text), activitySnippetLen),
+		})
+	}
+
+	h.renderTemplateWithError(w, r, "user_posts.html", data, errMsg)
 }
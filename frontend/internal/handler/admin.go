@@ -2,16 +2,24 @@ package handler
 
 import (
 	"fmt"
+	"html/template"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/go-chi/chi/v5"
 	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/markup"
 	"github.com/itchan-dev/itchan/shared/utils"
+	"github.com/itchan-dev/itchan/shared/validation"
 )
 
-// AdminGetHandler displays the admin panel with blacklisted users and referral stats.
+// AdminGetHandler displays the admin panel with blacklisted and shadowbanned users, pending ban appeals, the view-as log, captured failed posts, referral stats, boards pending deletion and board mirrors.
 func (h *Handler) AdminGetHandler(w http.ResponseWriter, r *http.Request) {
 	page := utils.GetPage(r)
 
@@ -23,19 +31,221 @@ func (h *Handler) AdminGetHandler(w http.ResponseWriter, r *http.Request) {
 		blacklist = api.BlacklistResponse{Users: []domain.BlacklistEntry{}, Page: page}
 	}
 
+	appeals, err := h.APIClient.GetAppeals(r, domain.AppealPending, 1)
+	if err != nil {
+		logger.Log.Error("failed to get ban appeals from API", "error", err)
+	}
+
+	shadowbans, err := h.APIClient.GetShadowbans(r, 1)
+	if err != nil {
+		logger.Log.Error("failed to get shadowbans from API", "error", err)
+	}
+
+	viewAs, err := h.APIClient.GetViewAsSessions(r, 1)
+	if err != nil {
+		logger.Log.Error("failed to get view-as sessions from API", "error", err)
+	}
+
+	failedPosts, err := h.APIClient.GetFailedPosts(r, 1)
+	if err != nil {
+		logger.Log.Error("failed to get failed posts from API", "error", err)
+	}
+
 	stats, err := h.APIClient.GetReferralStats(r)
 	if err != nil {
 		logger.Log.Error("failed to get referral stats from API", "error", err)
 	}
 
+	groups, err := h.APIClient.GetGroupedBoards(r)
+	if err != nil {
+		logger.Log.Error("failed to get board categories from API", "error", err)
+	}
+
+	pendingDeletions, err := h.APIClient.GetPendingBoardDeletions(r)
+	if err != nil {
+		logger.Log.Error("failed to get boards pending deletion from API", "error", err)
+	}
+
+	mirrors, err := h.APIClient.GetBoardMirrors(r)
+	if err != nil {
+		logger.Log.Error("failed to get board mirrors from API", "error", err)
+	}
+
+	boardList := frontend_domain.BoardListPage{Sort: r.URL.Query().Get("boards_sort"), Page: 1}
+	if p, err := strconv.Atoi(r.URL.Query().Get("boards_page")); err == nil && p > 1 {
+		boardList.Page = p
+	}
+	boards, err := h.APIClient.GetBoards(r, boardList.Sort, domain.BoardStatsCounts, boardList.Page)
+	if err != nil {
+		logger.Log.Error("failed to get boards from API", "error", err)
+	}
+	boardList.HasNext = boardList.Page*h.Public.BoardsPageLimit < boards.Total
+
 	data := frontend_domain.AdminPageData{
 		Blacklisted: frontend_domain.BlacklistedUsers{Users: blacklist.Users, Page: blacklist.Page},
+		Appeals:     appeals.Appeals,
+		Shadowbans:  shadowbans.Shadowbans,
+		ViewAs:      viewAs.Sessions,
+		FailedPosts: failedPosts.Posts,
+		CaptureOn:   failedPosts.Enabled,
 		RefStats:    frontend_domain.PivotRefStats(stats),
+		BoardList:   boardList,
+
+		PendingDeletions: pendingDeletions,
+		Mirrors:          mirrors,
+
+		VideoProfiles:       slices.Sorted(maps.Keys(h.Public.Media.VideoProfiles)),
+		DefaultVideoProfile: h.Public.Media.DefaultVideoProfile,
+		UploadMimeTypes:     slices.Concat(h.Public.AllowedImageMimeTypes, h.Public.AllowedVideoMimeTypes),
+	}
+	for _, g := range groups {
+		if g.Id != 0 {
+			data.Categories = append(data.Categories, g)
+		}
+	}
+	for _, b := range boards.Boards {
+		placement := frontend_domain.BoardPlacement{
+			ShortName: b.ShortName, Name: b.Name, Position: b.Position,
+			Settings: b.BoardSettings, Stats: b.Stats,
+		}
+		if b.CategoryId != nil {
+			placement.CategoryId = *b.CategoryId
+		}
+		data.Boards = append(data.Boards, placement)
 	}
 
 	h.renderTemplateWithError(w, r, "admin.html", data, errMsg)
 }
 
+// RestoreBoardHandler cancels the scheduled deletion of a board.
+func (h *Handler) RestoreBoardHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+
+	if err := h.APIClient.RestoreBoard(r, shortName); err != nil {
+		logger.Log.Error("restoring board via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, fmt.Sprintf("Board /%s/ restored", shortName))
+}
+
+// RenameBoardHandler moves a board to the short name given in the form.
+func (h *Handler) RenameBoardHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+
+	if err := r.ParseForm(); err != nil {
+		logger.Log.Error("parsing form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+	newShortName := strings.TrimSpace(r.FormValue("short_name"))
+
+	if err := h.APIClient.RenameBoard(r, shortName, newShortName); err != nil {
+		logger.Log.Error("renaming board via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, fmt.Sprintf("Board /%s/ renamed to /%s/", shortName, newShortName))
+}
+
+// CreateBoardMirrorHandler makes the board in the form a mirror of a board of
+// another instance.
+func (h *Handler) CreateBoardMirrorHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		logger.Log.Error("parsing form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+	shortName := strings.TrimSpace(r.FormValue("board"))
+	sourceURL := strings.TrimSpace(r.FormValue("source_url"))
+	sourceBoard := strings.TrimSpace(r.FormValue("source_board"))
+
+	if err := h.APIClient.CreateBoardMirror(r, shortName, sourceURL, sourceBoard); err != nil {
+		logger.Log.Error("creating board mirror via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, fmt.Sprintf("Board /%s/ now mirrors /%s/ of %s; the first sync runs within the sync interval", shortName, sourceBoard, sourceURL))
+}
+
+// DeleteBoardMirrorHandler detaches a mirror. The copied threads stay.
+func (h *Handler) DeleteBoardMirrorHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+
+	if err := h.APIClient.DeleteBoardMirror(r, shortName); err != nil {
+		logger.Log.Error("deleting board mirror via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, fmt.Sprintf("Board /%s/ is no longer a mirror and accepts posts", shortName))
+}
+
+// SyncBoardMirrorHandler syncs a mirror without waiting for the background sync.
+func (h *Handler) SyncBoardMirrorHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+
+	if err := h.APIClient.SyncBoardMirror(r, shortName); err != nil {
+		logger.Log.Error("syncing board mirror via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, fmt.Sprintf("Board /%s/ synced", shortName))
+}
+
+// maxUserImportSize matches the CSV limit of the backend.
+const maxUserImportSize = 2 << 20
+
+// ImportUsersHandler forwards an uploaded CSV of emails to the backend and
+// flashes what the import did, listing the first rejected rows.
+func (h *Handler) ImportUsersHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(maxUserImportSize); err != nil {
+		logger.Log.Error("parsing multipart form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+	files := r.MultipartForm.File["csv"]
+	if len(files) != 1 {
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, "Choose one CSV file to import.")
+		return
+	}
+	if files[0].Size > maxUserImportSize {
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, fmt.Sprintf("CSV file is too large (max %.0f MB).", validation.FormatSizeMB(maxUserImportSize)))
+		return
+	}
+	file, err := files[0].Open()
+	if err != nil {
+		logger.Log.Error("opening uploaded csv", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, "Failed to read the CSV file.")
+		return
+	}
+	defer file.Close()
+
+	report, err := h.APIClient.ImportUsers(r, file, r.FormValue("invite") == "true")
+	if err != nil {
+		logger.Log.Error("importing users via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	msg := fmt.Sprintf("Created %d accounts, %d already registered, %d rejected", report.Created, report.Existing, len(report.Rejected))
+	if report.Invited > 0 {
+		msg += fmt.Sprintf("; %d invitations are being sent", report.Invited)
+	}
+	for i, p := range report.Rejected {
+		if i == 5 {
+			msg += fmt.Sprintf("; and %d more", len(report.Rejected)-i)
+			break
+		}
+		msg += fmt.Sprintf("; line %d %s: %s", p.Line, p.Email, p.Reason)
+	}
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, msg)
+}
+
 // BlacklistUserHandler handles blacklist requests from the UI
 func (h *Handler) BlacklistUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse form to get userId and reason
@@ -93,3 +303,425 @@ func (h *Handler) UnblacklistUserHandler(w http.ResponseWriter, r *http.Request)
 
 	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, "User removed from blacklist")
 }
+
+// LookupUserHandler finds the user registered with an email and reports their ID
+func (h *Handler) LookupUserHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		logger.Log.Error("parsing form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	email := r.FormValue("email")
+	if email == "" {
+		http.Error(w, "Missing email", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.APIClient.LookupUser(r, email)
+	if err != nil {
+		logger.Log.Error("looking up user via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, fmt.Sprintf("The email belongs to user %d (@%s, registered %s)", user.UserId, user.EmailDomain, user.CreatedAt.Format("2006-01-02")))
+}
+
+// ShadowbanUserHandler shadowbans the author of a post on its board
+func (h *Handler) ShadowbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	h.setShadowban(w, r, h.APIClient.ShadowbanUser, "User shadowbanned on this board")
+}
+
+// UnshadowbanUserHandler lifts a shadowban from the admin panel or a post
+func (h *Handler) UnshadowbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	h.setShadowban(w, r, h.APIClient.UnshadowbanUser, "Shadowban lifted")
+}
+
+func (h *Handler) setShadowban(w http.ResponseWriter, r *http.Request, update func(*http.Request, string, string) error, successMsg string) {
+	if err := r.ParseForm(); err != nil {
+		logger.Log.Error("parsing form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	board := r.FormValue("board")
+	userID := r.FormValue("userId")
+	if board == "" || userID == "" {
+		http.Error(w, "Missing board or userId", http.StatusBadRequest)
+		return
+	}
+
+	// Use HTTP Referer header for redirect, fallback to admin panel
+	targetURL := r.Header.Get("Referer")
+	if targetURL == "" {
+		targetURL = "/admin"
+	}
+
+	if err := update(r, board, userID); err != nil {
+		logger.Log.Error("updating shadowban via API", "error", err)
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, targetURL, flashCookieSuccess, successMsg)
+}
+
+// CreateBoardCategoryHandler creates a new board category from the admin panel
+func (h *Handler) CreateBoardCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.parseBoardCategoryForm(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.APIClient.CreateBoardCategory(r, req); err != nil {
+		logger.Log.Error("creating board category via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, "Category created")
+}
+
+// UpdateBoardCategoryHandler renames or reorders a board category
+func (h *Handler) UpdateBoardCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryId")
+	req, ok := h.parseBoardCategoryForm(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.APIClient.UpdateBoardCategory(r, categoryID, req); err != nil {
+		logger.Log.Error("updating board category via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, "Category updated")
+}
+
+// DeleteBoardCategoryHandler deletes a board category; its boards become uncategorized
+func (h *Handler) DeleteBoardCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryId")
+
+	if err := h.APIClient.DeleteBoardCategory(r, categoryID); err != nil {
+		logger.Log.Error("deleting board category via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, "Category deleted")
+}
+
+// SetBoardCategoryHandler moves a board into a category (or out of any) and sets its position
+func (h *Handler) SetBoardCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		logger.Log.Error("parsing form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	shortName := r.FormValue("board")
+	if shortName == "" {
+		http.Error(w, "Missing board", http.StatusBadRequest)
+		return
+	}
+
+	var req api.SetBoardCategoryRequest
+	if categoryStr := r.FormValue("categoryId"); categoryStr != "" {
+		categoryID, err := strconv.ParseInt(categoryStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid categoryId", http.StatusBadRequest)
+			return
+		}
+		req.CategoryId = &categoryID
+	}
+	if positionStr := r.FormValue("position"); positionStr != "" {
+		position, err := strconv.Atoi(positionStr)
+		if err != nil {
+			http.Error(w, "Invalid position", http.StatusBadRequest)
+			return
+		}
+		req.Position = position
+	}
+
+	if err := h.APIClient.SetBoardCategory(r, shortName, req); err != nil {
+		logger.Log.Error("setting board category via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, "Board placement updated")
+}
+
+// parseBoardCategoryForm reads the name/position fields shared by the category forms.
+// On failure it writes the error response and returns false.
+func (h *Handler) parseBoardCategoryForm(w http.ResponseWriter, r *http.Request) (api.BoardCategoryRequest, bool) {
+	if err := r.ParseForm(); err != nil {
+		logger.Log.Error("parsing form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return api.BoardCategoryRequest{}, false
+	}
+
+	req := api.BoardCategoryRequest{Name: r.FormValue("name")}
+	if positionStr := r.FormValue("position"); positionStr != "" {
+		position, err := strconv.Atoi(positionStr)
+		if err != nil {
+			http.Error(w, "Invalid position", http.StatusBadRequest)
+			return api.BoardCategoryRequest{}, false
+		}
+		req.Position = position
+	}
+	return req, true
+}
+
+// UpdateBoardSettingsHandler saves the per-board settings edited on the admin panel
+func (h *Handler) UpdateBoardSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		logger.Log.Error("parsing form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	shortName := r.FormValue("board")
+	if shortName == "" {
+		http.Error(w, "Missing board", http.StatusBadRequest)
+		return
+	}
+
+	req := api.UpdateBoardSettingsRequest{
+		Description:         strings.TrimSpace(r.FormValue("description")),
+		ShowDeletionStubs:   r.FormValue("show_deletion_stubs") == "on",
+		Noindex:             r.FormValue("noindex") == "on",
+		PublicModLog:        r.FormValue("public_modlog") == "on",
+		SelfDeleteReplied:   r.FormValue("self_delete_replied") == "on",
+		BumpLimitNotice:     r.FormValue("bump_limit_notice") == "on",
+		PreModeration:       r.FormValue("pre_moderation") == "on",
+		RequireOpAttachment: r.FormValue("require_op_attachment") == "on",
+		VideoProfile:        r.FormValue("video_profile"),
+		TextFilter:          r.FormValue("text_filter"),
+		Language:            r.FormValue("language"),
+		AllowDocuments:      r.FormValue("allow_documents") == "on",
+		PostingEmailDomains: splitAndTrim(r.FormValue("posting_email_domains")),
+	}
+	if v := r.FormValue("max_attachments"); v != "" { // Empty uses the site-wide limit
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid max_attachments", http.StatusBadRequest)
+			return
+		}
+		req.MaxAttachments = &n
+	}
+	// Every type checked is the same as no restriction, and keeps types
+	// enabled later on the site accepted
+	if mimeTypes := r.Form["allowed_mime_types"]; len(mimeTypes) < len(h.Public.AllowedImageMimeTypes)+len(h.Public.AllowedVideoMimeTypes) {
+		req.AllowedMimeTypes = mimeTypes
+	}
+	for field, dst := range map[string]*int{
+		"min_op_text_length":            &req.MinOpTextLength,
+		"max_threads_per_user_per_day":  &req.MaxThreadsPerUserPerDay,
+		"posting_min_account_age_days":  &req.PostingMinAccountAgeDays,
+		"premod_trust_approvals":        &req.PremodTrustApprovals,
+		"premod_trust_account_age_days": &req.PremodTrustAccountAgeDays,
+	} {
+		if v := r.FormValue(field); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, "Invalid "+field, http.StatusBadRequest)
+				return
+			}
+			*dst = n
+		}
+	}
+	if err := h.APIClient.UpdateBoardSettings(r, shortName, req); err != nil {
+		logger.Log.Error("updating board settings via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, "Board settings updated")
+}
+
+// AdminBoardAppearanceHandler shows the board's banners and custom CSS for editing
+func (h *Handler) AdminBoardAppearanceHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+
+	appearance, err := h.APIClient.GetBoardAppearance(r, shortName)
+	if err != nil {
+		h.RenderError(w, r, err)
+		return
+	}
+
+	h.renderTemplate(w, r, "board_appearance.html", frontend_domain.BoardAppearancePageData{
+		Board:              shortName,
+		Appearance:         appearance,
+		CustomCSSMaxLen:    h.Public.BoardCustomCSSMaxLen,
+		MaxBannerSizeBytes: h.Public.MaxBannerSizeBytes,
+		MaxBanners:         h.Public.MaxBannersPerBoard,
+		BannerMimeTypes:    h.Public.AllowedImageMimeTypes,
+	})
+}
+
+// postersWindows are the windows offered on the top posters page. The first
+// one matches the backend default.
+var postersWindows = []string{"1h", "15m", "6h", "24h", "168h"}
+
+// AdminBoardPostersHandler shows who posted most on the board recently, to
+// spot flooding accounts
+func (h *Handler) AdminBoardPostersHandler(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	posters, err := h.APIClient.GetTopPosters(r, chi.URLParam(r, "board"), window)
+	if err != nil {
+		h.RenderError(w, r, err)
+		return
+	}
+
+	h.renderTemplate(w, r, "board_posters.html", frontend_domain.BoardPostersPageData{
+		Posters: posters,
+		Window:  window,
+		Windows: postersWindows,
+	})
+}
+
+// AdminPremodQueueHandler shows the posts held back on a board with
+// pre-moderation, oldest first, and the users whose posts skip it
+func (h *Handler) AdminPremodQueueHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	queue, err := h.APIClient.GetPremodQueue(r, shortName)
+	if err != nil {
+		h.RenderError(w, r, err)
+		return
+	}
+
+	posts := make([]frontend_domain.QueuedPost, 0, len(queue.Posts))
+	for _, p := range queue.Posts {
+		text, _ := markup.Sanitize(p.Text)
+		posts = append(posts, frontend_domain.QueuedPost{QueuedPost: p, Text: template.HTML(text)})
+	}
+
+	h.renderTemplate(w, r, "premod_queue.html", frontend_domain.PremodQueuePageData{
+		Board:   shortName,
+		Posts:   posts,
+		Posters: queue.Posters,
+	})
+}
+
+func (h *Handler) PremodApproveHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	targetURL := "/admin/boards/" + shortName + "/premod"
+
+	approved, err := h.APIClient.ApproveQueuedPost(r, shortName, chi.URLParam(r, "postId"), r.FormValue("trust") == "on")
+	if err != nil {
+		logger.Log.Error("approving queued post via API", "error", err)
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, targetURL, flashCookieSuccess, fmt.Sprintf("Post published in thread %d", approved.ThreadId))
+}
+
+func (h *Handler) PremodRejectHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	targetURL := "/admin/boards/" + shortName + "/premod"
+
+	if err := h.APIClient.RejectQueuedPost(r, shortName, chi.URLParam(r, "postId")); err != nil {
+		logger.Log.Error("rejecting queued post via API", "error", err)
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, targetURL, flashCookieSuccess, "Post rejected")
+}
+
+func (h *Handler) PremodTrustHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	targetURL := "/admin/boards/" + shortName + "/premod"
+
+	userID := strings.TrimSpace(r.FormValue("userId"))
+	if userID == "" {
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, "User ID is required")
+		return
+	}
+
+	// "auto" drops the override so the board's trust rules decide again
+	var trusted *bool
+	switch value := r.FormValue("trust"); value {
+	case "trusted", "held":
+		override := value == "trusted"
+		trusted = &override
+	case "auto":
+	default:
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, "Invalid trust value")
+		return
+	}
+
+	if err := h.APIClient.SetPosterTrust(r, shortName, userID, trusted); err != nil {
+		logger.Log.Error("setting poster trust via API", "error", err)
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, targetURL, flashCookieSuccess, "Trust of user "+userID+" updated")
+}
+
+func (h *Handler) SetBoardCustomCSSHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	targetURL := "/admin/boards/" + shortName + "/appearance"
+
+	if err := r.ParseForm(); err != nil {
+		logger.Log.Error("parsing form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	// Browsers submit textarea line breaks as CRLF
+	css := strings.ReplaceAll(r.FormValue("css"), "\r\n", "\n")
+	if err := h.APIClient.SetBoardCustomCSS(r, shortName, css); err != nil {
+		logger.Log.Error("setting board custom css via API", "error", err)
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, targetURL, flashCookieSuccess, "Custom CSS saved")
+}
+
+func (h *Handler) UploadBoardBannerHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	targetURL := "/admin/boards/" + shortName + "/appearance"
+
+	if err := r.ParseMultipartForm(h.Public.MaxBannerSizeBytes); err != nil {
+		logger.Log.Error("parsing multipart form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+	files := r.MultipartForm.File["banner"]
+	if len(files) != 1 {
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, "Choose one image to upload.")
+		return
+	}
+	if files[0].Size > h.Public.MaxBannerSizeBytes {
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, fmt.Sprintf("Banner is too large (max %.0f MB).", validation.FormatSizeMB(h.Public.MaxBannerSizeBytes)))
+		return
+	}
+
+	if err := h.APIClient.UploadBoardBanner(r, shortName, files[0]); err != nil {
+		logger.Log.Error("uploading board banner via API", "error", err)
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, targetURL, flashCookieSuccess, "Banner uploaded")
+}
+
+func (h *Handler) DeleteBoardBannerHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	targetURL := "/admin/boards/" + shortName + "/appearance"
+
+	if err := h.APIClient.DeleteBoardBanner(r, shortName, chi.URLParam(r, "bannerId")); err != nil {
+		logger.Log.Error("deleting board banner via API", "error", err)
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, targetURL, flashCookieSuccess, "Banner deleted")
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// AppealGetHandler shows the ban appeal form. Suspended users cannot log in,
+// so the form asks for credentials alongside the appeal text.
+func (h *Handler) AppealGetHandler(w http.ResponseWriter, r *http.Request) {
+	h.renderTemplate(w, r, "appeal.html", nil)
+}
+
+func (h *Handler) AppealPostHandler(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+
+	err := h.APIClient.SubmitAppeal(r, email, r.FormValue("password"), r.FormValue("text"))
+	if err != nil {
+		logger.Log.Error("submitting appeal via API", "error", err)
+		h.setFlash(w, emailPrefillCookie, email)
+		h.redirectWithFlash(w, r, "/appeal", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/appeal", flashCookieSuccess, "Appeal submitted. A moderator will review it; submit the form again later to see the outcome.")
+}
+
+// AcceptAppealHandler accepts a ban appeal from the admin panel, lifting the ban
+func (h *Handler) AcceptAppealHandler(w http.ResponseWriter, r *http.Request) {
+	h.decideAppeal(w, r, "accept", "Appeal accepted, ban lifted")
+}
+
+// DenyAppealHandler denies a ban appeal from the admin panel
+func (h *Handler) DenyAppealHandler(w http.ResponseWriter, r *http.Request) {
+	h.decideAppeal(w, r, "deny", "Appeal denied")
+}
+
+func (h *Handler) decideAppeal(w http.ResponseWriter, r *http.Request, decision, successMsg string) {
+	if err := r.ParseForm(); err != nil {
+		logger.Log.Error("parsing form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	appealID := chi.URLParam(r, "appealId")
+	if err := h.APIClient.DecideAppeal(r, appealID, decision, r.FormValue("response")); err != nil {
+		logger.Log.Error("deciding appeal via API", "error", err, "decision", decision)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, successMsg)
+}
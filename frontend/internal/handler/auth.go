@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 
+	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
+	frontend_mw "github.com/itchan-dev/itchan/frontend/internal/middleware"
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/logger"
-	"github.com/itchan-dev/itchan/shared/middleware"
 )
 
 func (h *Handler) RegisterGetHandler(w http.ResponseWriter, r *http.Request) {
@@ -73,20 +76,26 @@ func (h *Handler) ConfirmEmailPostHandler(w http.ResponseWriter, r *http.Request
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
 
+// LoginGetHandler shows the login form. ?next= is the page to return to
+// after logging in.
 func (h *Handler) LoginGetHandler(w http.ResponseWriter, r *http.Request) {
-	h.renderTemplate(w, r, "login.html", nil)
+	next := safeNext(r.URL.Query().Get("next"))
+	h.renderTemplate(w, r, "login.html", frontend_domain.LoginPageData{Next: next})
 }
 
 func (h *Handler) LoginPostHandler(w http.ResponseWriter, r *http.Request) {
 	email := r.FormValue("email")
 	password := r.FormValue("password")
+	rememberMe := r.FormValue("remember_me") != ""
+	next := safeNext(r.FormValue("next"))
+	loginPage := withNext("/login", next)
 
-	resp, err := h.APIClient.Login(r, email, password)
+	resp, err := h.APIClient.Login(r, email, password, rememberMe)
 	if err != nil {
 		logger.Log.Error("during login API call", "error", err)
 		h.setFlash(w, flashCookieError, "Internal error: backend unavailable.")
 		h.setFlash(w, emailPrefillCookie, email)
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		http.Redirect(w, r, loginPage, http.StatusSeeOther)
 		return
 	}
 	defer resp.Body.Close()
@@ -95,7 +104,7 @@ func (h *Handler) LoginPostHandler(w http.ResponseWriter, r *http.Request) {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		h.setFlash(w, flashCookieError, string(bodyBytes))
 		h.setFlash(w, emailPrefillCookie, email)
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		http.Redirect(w, r, loginPage, http.StatusSeeOther)
 		return
 	}
 
@@ -103,37 +112,52 @@ func (h *Handler) LoginPostHandler(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewDecoder(resp.Body).Decode(&loginResp); err != nil || loginResp.AccessToken == "" {
 		logger.Log.Error("parsing login response", "error", err)
 		h.setFlash(w, flashCookieError, "Internal error: invalid login response.")
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		http.Redirect(w, r, loginPage, http.StatusSeeOther)
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Path:     "/",
-		Name:     middleware.CookieName,
-		Value:    loginResp.AccessToken,
-		MaxAge:   int(h.Public.JwtTTL.Seconds()),
-		HttpOnly: true,
-		Secure:   h.Public.SecureCookies,
-		SameSite: http.SameSiteLaxMode,
-	})
+	h.SessionCookies().Set(w, loginResp.AccessToken, loginResp.RefreshToken)
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	http.Redirect(w, r, nextOrIndex(next), http.StatusSeeOther)
 }
 
 func (h *Handler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Path:     "/",
-		Name:     middleware.CookieName,
-		Value:    "",
-		MaxAge:   -1, // Expire immediately
-		HttpOnly: true,
-		Secure:   h.Public.SecureCookies,
-		SameSite: http.SameSiteLaxMode,
-	})
+	h.SessionCookies().Clear(w)
+	h.setViewAsCookie(w, "", -1)
 
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
 
+// SessionsRevokeGetHandler shows the "this wasn't me" link of new device
+// emails. Revoking takes a click on the page, so link scanners of mail
+// services don't sign the user out.
+func (h *Handler) SessionsRevokeGetHandler(w http.ResponseWriter, r *http.Request) {
+	h.renderTemplate(w, r, "sessions_revoke.html", frontend_domain.SessionsRevokePageData{Token: r.URL.Query().Get("token")})
+}
+
+// SessionsRevokePostHandler signs the user out everywhere, this browser
+// included.
+func (h *Handler) SessionsRevokePostHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	if err := h.APIClient.RevokeSessions(r, token); err != nil {
+		logger.Log.Warn("revoking sessions via API", "error", err)
+		h.renderTemplateWithError(w, r, "sessions_revoke.html", frontend_domain.SessionsRevokePageData{}, "This link is invalid or was already used.")
+		return
+	}
+
+	h.SessionCookies().Clear(w)
+	h.renderTemplate(w, r, "sessions_revoke.html", frontend_domain.SessionsRevokePageData{Revoked: true})
+}
+
+// SessionCookies writes login cookies with the configured token lifetimes.
+func (h *Handler) SessionCookies() frontend_mw.SessionCookies {
+	return frontend_mw.SessionCookies{
+		AccessTTL:  h.Public.JwtTTL,
+		RefreshTTL: h.Public.RememberMeTTL,
+		Secure:     h.Public.SecureCookies,
+	}
+}
+
 func (h *Handler) RegisterInviteGetHandler(w http.ResponseWriter, r *http.Request) {
 	h.renderTemplate(w, r, "register_invite.html", nil)
 }
@@ -159,3 +183,55 @@ func (h *Handler) RegisterInvitePostHandler(w http.ResponseWriter, r *http.Reque
 	h.setFlash(w, emailPrefillCookie, email)
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
+
+// authPages are the pages of the login flow. They are never a target to
+// return to after logging in, and pass on the target they were given.
+var authPages = map[string]bool{
+	"/login":                   true,
+	"/logout":                  true,
+	"/register":                true,
+	"/register_invite":         true,
+	"/check_confirmation_code": true,
+	"/appeal":                  true,
+}
+
+// safeNext returns next if it is a page on this site to return to after
+// logging in, and "" otherwise, so login links cannot send users elsewhere.
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return ""
+	}
+	u, err := url.Parse(next)
+	if err != nil || u.Scheme != "" || u.Host != "" || authPages[u.Path] {
+		return ""
+	}
+	return next
+}
+
+func nextOrIndex(next string) string {
+	if next == "" {
+		return "/"
+	}
+	return next
+}
+
+// withNext adds the page to return to after logging in to an auth page path.
+func withNext(path, next string) string {
+	if next == "" {
+		return path
+	}
+	return path + "?" + url.Values{"next": {next}}.Encode()
+}
+
+// loginURL links the login page so that it returns to the page being shown.
+// The auth pages pass on the target they were given instead.
+func loginURL(r *http.Request) string {
+	if authPages[r.URL.Path] || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return withNext("/login", safeNext(r.URL.Query().Get("next")))
+	}
+	current := *r.URL
+	query := current.Query()
+	query.Del("fragment")
+	current.RawQuery = query.Encode()
+	return withNext("/login", safeNext(current.RequestURI()))
+}
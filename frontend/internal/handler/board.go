@@ -1,11 +1,21 @@
 package handler
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/frontend/internal/apiclient"
+	"github.com/itchan-dev/itchan/frontend/internal/boardcss"
+	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
 	"github.com/itchan-dev/itchan/shared/logger"
 	"github.com/itchan-dev/itchan/shared/utils"
 )
@@ -15,9 +25,16 @@ func (h *Handler) BoardGetHandler(w http.ResponseWriter, r *http.Request) {
 
 	page := utils.GetPage(r)
 
+	// ?fragment=threads returns only the thread previews and pagination
+	fragment := r.URL.Query().Get("fragment")
+	if fragment != "" && fragment != "threads" {
+		h.RenderError(w, r, &internal_errors.ErrorWithStatusCode{Message: "Unknown fragment", StatusCode: http.StatusBadRequest})
+		return
+	}
+
 	lastModified, err := h.APIClient.GetBoardLastModified(r, shortName)
 	if err != nil {
-		utils.WriteErrorAndStatusCode(w, err)
+		h.RenderError(w, r, err)
 		return
 	}
 
@@ -25,13 +42,75 @@ func (h *Handler) BoardGetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	board, err := h.APIClient.GetBoard(r, shortName, page)
+	board, err := h.APIClient.GetBoard(r, shortName, page, lowBandwidth(r))
+	if err != nil {
+		h.RenderError(w, r, err)
+		return
+	}
+
+	if fragment == "threads" {
+		h.renderFragment(w, r, "board.html", "board-threads", renderBoard(board))
+		return
+	}
+	rendered := renderBoard(board)
+	rendered.Appearance = h.boardAppearance(r, shortName)
+	h.renderTemplate(w, r, "board.html", rendered)
+}
+
+// BoardCustomCSSHandler serves the board's custom stylesheet after
+// sanitization. Pages link it with a version parameter, so it can be cached.
+func (h *Handler) BoardCustomCSSHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+
+	appearance, err := h.APIClient.GetBoardAppearance(r, shortName)
 	if err != nil {
 		utils.WriteErrorAndStatusCode(w, err)
 		return
 	}
 
-	h.renderTemplate(w, r, "board.html", renderBoard(board))
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	io.WriteString(w, boardcss.Sanitize(appearance.CustomCSS))
+}
+
+// boardAppearance picks a random banner for the board and versions its
+// stylesheet. Pages still render without them if the backend call fails.
+func (h *Handler) boardAppearance(r *http.Request, shortName string) frontend_domain.BoardAppearance {
+	var result frontend_domain.BoardAppearance
+	appearance, err := h.APIClient.GetBoardAppearance(r, shortName)
+	if err != nil {
+		logger.Log.Error("fetching board appearance", "board", shortName, "error", err)
+		return result
+	}
+
+	if len(appearance.Banners) > 0 {
+		result.Banner = &appearance.Banners[rand.IntN(len(appearance.Banners))]
+	}
+	if css := boardcss.Sanitize(appearance.CustomCSS); css != "" {
+		sum := sha256.Sum256([]byte(css))
+		result.CSSVersion = hex.EncodeToString(sum[:])[:12]
+	}
+	return result
+}
+
+// ModLogGetHandler displays the board's public moderation log, newest first
+func (h *Handler) ModLogGetHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	page := utils.GetPage(r)
+
+	result, err := h.APIClient.GetModLog(r, shortName, page)
+	if err != nil {
+		h.RenderError(w, r, err)
+		return
+	}
+
+	h.renderTemplate(w, r, "modlog.html", frontend_domain.ModLogPageData{
+		Board:   shortName,
+		Entries: result.Entries,
+		Page:    result.Page,
+		HasNext: result.Page*h.Public.ModLogPageLimit < result.Total,
+	})
 }
 
 func (h *Handler) BoardPostHandler(w http.ResponseWriter, r *http.Request) {
@@ -44,7 +123,7 @@ func (h *Handler) BoardPostHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	text := r.FormValue("text")
-	processedText, domainReplies, hasPayload, err := h.processMessageText(text, domain.MessageMetadata{Board: shortName})
+	processedText, domainReplies, hasPayload, err := h.processMessageText(r, text, domain.MessageMetadata{Board: shortName})
 	if err != nil {
 		logger.Log.Error("processing message text", "error", err)
 		h.redirectWithFlash(w, r, errorTargetURL, flashCookieError, err.Error())
@@ -52,22 +131,29 @@ func (h *Handler) BoardPostHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if message has either text OR attachments (align with backend validation)
-	hasAttachments := r.MultipartForm != nil && r.MultipartForm.File != nil && len(r.MultipartForm.File["attachments"]) > 0
+	tokens := uploadTokens(r)
+	hasAttachments := len(tokens) > 0 || r.MultipartForm != nil && r.MultipartForm.File != nil && len(r.MultipartForm.File["attachments"]) > 0
 	if !hasPayload && !hasAttachments {
 		h.redirectWithFlash(w, r, errorTargetURL, flashCookieError, "Message must contain either text or attachments.")
 		return
 	}
 
 	backendData := api.CreateThreadRequest{
-		Title: r.FormValue("title"),
+		Title:  r.FormValue("title"),
+		OpOnly: r.FormValue("op_only") == "on",
 		OpMessage: api.CreateMessageRequest{
 			Text:            processedText,
 			ShowEmailDomain: r.FormValue("show_company") == "on",
 			ReplyTo:         domainReplies,
+			UploadTokens:    tokens,
 		},
 	}
 
 	newThreadID, err := h.APIClient.CreateThread(r, shortName, backendData, r.MultipartForm)
+	if errors.Is(err, apiclient.ErrPostQueued) {
+		h.redirectWithFlash(w, r, "/"+shortName, flashCookieSuccess, "Your thread is awaiting moderator approval.")
+		return
+	}
 	if err != nil {
 		logger.Log.Error("creating thread via API", "error", err)
 		h.redirectWithFlash(w, r, errorTargetURL, flashCookieError, err.Error())
@@ -82,12 +168,18 @@ func (h *Handler) BoardDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	shortName := chi.URLParam(r, "board")
 	targetURL := "/" // Redirect to index page
 
-	err := h.APIClient.DeleteBoard(r, shortName)
+	deleteAfter, err := h.APIClient.DeleteBoard(r, shortName)
 	if err != nil {
 		logger.Log.Error("deleting board via API", "error", err)
 		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
 		return
 	}
+	if deleteAfter != nil {
+		h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, fmt.Sprintf(
+			"Board /%s/ is hidden and will be deleted at %s UTC. It can be restored until then.",
+			shortName, deleteAfter.UTC().Format("2006-01-02 15:04")))
+		return
+	}
 
 	http.Redirect(w, r, targetURL, http.StatusSeeOther)
 }
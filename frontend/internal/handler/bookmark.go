@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// BookmarksGetHandler displays the messages the user bookmarked, newest first
+func (h *Handler) BookmarksGetHandler(w http.ResponseWriter, r *http.Request) {
+	page := utils.GetPage(r)
+
+	result, err := h.APIClient.GetBookmarks(r, page)
+	var errMsg string
+	if err != nil {
+		logger.Log.Error("failed to get bookmarks from API", "error", err)
+		errMsg = "Failed to load bookmarks"
+		result.Page = page
+	}
+
+	data := frontend_domain.BookmarksPageData{
+		Page:    result.Page,
+		Total:   result.Total,
+		HasNext: result.Page*h.Public.UserPostsPageLimit < result.Total,
+	}
+	for _, b := range result.Bookmarks {
+		data.Bookmarks = append(data.Bookmarks, frontend_domain.Bookmark{
+			LatestPost: frontend_domain.LatestPost{
+				LatestPost: b.Message,
+				Snippet:    snippet(plainText(b.Message.Text), activitySnippetLen),
+			},
+			BookmarkedAt: b.CreatedAt,
+		})
+	}
+
+	h.renderTemplateWithError(w, r, "bookmarks.html", data, errMsg)
+}
+
+// BookmarkPostHandler bookmarks a message and goes back to the page the
+// button was on, which may be a board page or the thread.
+func (h *Handler) BookmarkPostHandler(w http.ResponseWriter, r *http.Request) {
+	boardShortName := chi.URLParam(r, "board")
+	target := r.Referer()
+	if target == "" {
+		target = fmt.Sprintf("/%s/%s", boardShortName, chi.URLParam(r, "thread"))
+	}
+
+	threadId, err := strconv.ParseInt(chi.URLParam(r, "thread"), 10, 64)
+	if err != nil {
+		h.redirectWithFlash(w, r, target, flashCookieError, "Invalid thread ID")
+		return
+	}
+	msgId, err := strconv.ParseInt(chi.URLParam(r, "message"), 10, 64)
+	if err != nil {
+		h.redirectWithFlash(w, r, target, flashCookieError, "Invalid message ID")
+		return
+	}
+
+	if err := h.APIClient.AddBookmark(r, boardShortName, domain.ThreadId(threadId), domain.MsgId(msgId)); err != nil {
+		logger.Log.Error("bookmarking message via API", "error", err)
+		h.redirectWithFlash(w, r, target, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, target, flashCookieSuccess, "Post bookmarked. Find it under Account > Bookmarks.")
+}
+
+// BookmarkDeleteHandler removes a bookmark from the bookmarks page
+func (h *Handler) BookmarkDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	target := "/account/bookmarks"
+	if page, err := strconv.Atoi(r.FormValue("page")); err == nil && page > 1 {
+		target = fmt.Sprintf("/account/bookmarks?page=%d", page)
+	}
+
+	err := h.APIClient.DeleteBookmark(r, chi.URLParam(r, "board"), chi.URLParam(r, "thread"), chi.URLParam(r, "message"))
+	if err != nil {
+		logger.Log.Error("deleting bookmark via API", "error", err)
+		h.redirectWithFlash(w, r, target, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, target, flashCookieSuccess, "Bookmark removed")
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// ThreadWatchPostHandler watches or unwatches a thread for the email digest.
+// The flash message also changes the cookies, so the thread page is not
+// answered from the browser cache with the previous button.
+func (h *Handler) ThreadWatchPostHandler(w http.ResponseWriter, r *http.Request) {
+	boardShortName := chi.URLParam(r, "board")
+	threadId := chi.URLParam(r, "thread")
+	target := fmt.Sprintf("/%s/%s", boardShortName, threadId)
+
+	watched := r.FormValue("watch") == "1"
+	if err := h.APIClient.SetThreadWatched(r, boardShortName, threadId, watched); err != nil {
+		logger.Log.Error("updating thread watch via API", "error", err)
+		h.redirectWithFlash(w, r, target, flashCookieError, "Failed to update thread watch")
+		return
+	}
+
+	msg := "You will no longer get this thread in your email digest"
+	if watched {
+		msg = "New replies in this thread will be in your email digest"
+	}
+	h.redirectWithFlash(w, r, target, flashCookieSuccess, msg)
+}
+
+// ThreadReadProxyHandler moves the read position over posts the thread
+// auto-refresh appended, which the backend never saw a page view for.
+func (h *Handler) ThreadReadProxyHandler(w http.ResponseWriter, r *http.Request) {
+	lastReadId, err := strconv.ParseInt(r.FormValue("last_read_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.APIClient.MarkThreadRead(r, chi.URLParam(r, "board"), chi.URLParam(r, "thread"), domain.MsgId(lastReadId))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DigestPostHandler saves the email digest frequency chosen on the notifications page
+func (h *Handler) DigestPostHandler(w http.ResponseWriter, r *http.Request) {
+	target := notificationsTarget(r)
+	frequency := domain.DigestFrequency(r.FormValue("frequency"))
+	if err := h.APIClient.SetDigestFrequency(r, frequency); err != nil {
+		logger.Log.Error("updating digest settings via API", "error", err)
+		h.redirectWithFlash(w, r, target, flashCookieError, "Failed to update email digest settings")
+		return
+	}
+	h.redirectWithFlash(w, r, target, flashCookieSuccess, "Email digest settings saved")
+}
+
+// DigestUnsubscribeGetHandler handles the unsubscribe link of digest emails.
+// A single click unsubscribes, without logging in.
+func (h *Handler) DigestUnsubscribeGetHandler(w http.ResponseWriter, r *http.Request) {
+	var errMsg string
+	if err := h.APIClient.UnsubscribeDigest(r, r.URL.Query().Get("token")); err != nil {
+		logger.Log.Warn("unsubscribing from digest via API", "error", err)
+		errMsg = "This unsubscribe link is invalid or was already used."
+	}
+	h.renderTemplateWithError(w, r, "digest_unsubscribe.html", errMsg == "", errMsg)
+}
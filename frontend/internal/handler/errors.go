@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// RenderError shows err as an error page, answered with the status err
+// carries (500 for errors without one). Requests not asking for HTML, such as
+// fragment and API proxy fetches, get the plain text error instead.
+func (h *Handler) RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	var withStatus *internal_errors.ErrorWithStatusCode
+	if errors.As(err, &withStatus) {
+		status = withStatus.StatusCode
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	page := errorPage(r, status, err)
+	if status >= http.StatusInternalServerError {
+		logger.Log.Error("serving error page",
+			"status", status,
+			"path", r.URL.Path,
+			"request_id", page.RequestID,
+			"error", err)
+	}
+
+	// Error pages must not be revalidated into a 304 once the content exists
+	w.Header().Del("Last-Modified")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	h.renderPage(w, r, "error.html", page, "", status)
+}
+
+// NotFoundHandler answers requests no route matched.
+func (h *Handler) NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	h.RenderError(w, r, &internal_errors.ErrorWithStatusCode{StatusCode: http.StatusNotFound})
+}
+
+// errorPage words the error for the page it happened on. Backend messages are
+// only shown for client errors without a dedicated explanation; server errors
+// show the request ID instead, which the backend logs under the same ID.
+func errorPage(r *http.Request, status int, err error) frontend_domain.ErrorPageData {
+	board := chi.URLParam(r, "board")
+	thread := chi.URLParam(r, "thread")
+	var pattern string
+	if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil {
+		pattern = routeCtx.RoutePattern()
+	}
+
+	page := frontend_domain.ErrorPageData{
+		StatusCode: status,
+		Title:      http.StatusText(status),
+		Message:    err.Error(),
+		BackURL:    "/",
+		BackLabel:  "Back to the board list",
+	}
+	if page.Title == "" {
+		page.Title = "Error"
+	}
+
+	switch {
+	case status == http.StatusNotFound && thread != "" && strings.HasPrefix(pattern, "/{board}/{thread}"):
+		page.Title = "Thread not found"
+		page.Message = fmt.Sprintf("Thread /%s/%s does not exist or has been deleted.", board, thread)
+		page.BackURL = "/" + board
+		page.BackLabel = fmt.Sprintf("Back to /%s/", board)
+	case status == http.StatusNotFound && pattern == "/{board}":
+		page.Title = "Board not found"
+		page.Message = fmt.Sprintf("There is no board /%s/.", board)
+	case status == http.StatusNotFound:
+		page.Title = "Page not found"
+		if page.Message == "" {
+			page.Message = "The page you are looking for does not exist."
+		}
+	case status == http.StatusUnauthorized:
+		page.Title = "Sign in required"
+		page.Message = "You need to be logged in to see this page."
+		if board != "" {
+			page.Message = fmt.Sprintf("/%s/ is only open to registered users.", board)
+		}
+		page.SignIn = true
+	case status == http.StatusForbidden:
+		page.Title = "Access restricted"
+		page.Message = "You are not allowed to see this page."
+		if board != "" {
+			page.Message = fmt.Sprintf("/%s/ is only open to users whose email address belongs to one of the organisations it was created for. "+
+				"If you are a member, log in with your organisation's address.", board)
+		}
+	case status >= http.StatusInternalServerError:
+		page.Title = "Something went wrong"
+		page.Message = "The page could not be loaded because of an error on our side. Please try again later; " +
+			"if it keeps happening, include the request ID below when reporting it."
+		page.RequestID = middleware.GetReqID(r.Context())
+	}
+	if page.Message == "" {
+		page.Message = page.Title
+	}
+
+	return page
+}
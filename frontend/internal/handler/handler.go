@@ -2,7 +2,6 @@ package handler
 
 import (
 	"html/template"
-	"net/http"
 	"sync"
 
 	"github.com/itchan-dev/itchan/frontend/internal/apiclient"
@@ -17,6 +16,10 @@ type Handler struct {
 	TextProcessor *markdown.TextProcessor
 	APIClient     *apiclient.APIClient
 	MediaPath     string // Exposed for router to create file server
+
+	footer template.HTML // Instance footer from the config, rendered once
+
+	maintenance maintenanceCache // Maintenance window shown in the page banner
 }
 
 func New(templates map[string]*template.Template, publicCfg config.Public, textProcessor *markdown.TextProcessor, apiClient *apiclient.APIClient, mediaPath string) *Handler {
@@ -26,6 +29,7 @@ func New(templates map[string]*template.Template, publicCfg config.Public, textP
 		TextProcessor: textProcessor,
 		APIClient:     apiClient,
 		MediaPath:     mediaPath,
+		footer:        textProcessor.RenderFooter(publicCfg.Instance.Footer),
 	}
 }
 
@@ -43,7 +47,3 @@ func (h *Handler) getTemplate(name string) (*template.Template, bool) {
 	h.mu.RUnlock()
 	return tmpl, ok
 }
-
-func FaviconHandler(w http.ResponseWriter, r *http.Request) {
-	http.ServeFile(w, r, "static/favicon.ico")
-}
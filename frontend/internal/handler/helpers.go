@@ -4,10 +4,12 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
 	frontend_mw "github.com/itchan-dev/itchan/frontend/internal/middleware"
+	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
 	mw "github.com/itchan-dev/itchan/shared/middleware"
 	"github.com/itchan-dev/itchan/shared/validation"
@@ -118,11 +120,25 @@ func splitAndTrim(s string) []string {
 
 // processMessageText processes user-submitted text and builds reply references.
 // Returns the processed text, domain replies, and whether the message has valid payload.
-func (h *Handler) processMessageText(text string, msgMetadata domain.MessageMetadata) (processedText string, replies *domain.Replies, hasPayload bool, err error) {
-	processedText, replyTo, hasPayload, err := h.TextProcessor.ProcessMessage(domain.Message{
+// With board post numbering, the numbers shown in links are fetched from the backend.
+func (h *Handler) processMessageText(r *http.Request, text string, msgMetadata domain.MessageMetadata) (processedText string, replies *domain.Replies, hasPayload bool, err error) {
+	msg := domain.Message{
 		Text:            text,
 		MessageMetadata: msgMetadata,
-	})
+	}
+
+	var replyTo domain.Replies
+	if h.Public.PostNumbering == config.PostNumberingBoard {
+		processedText, replyTo, hasPayload, err = h.TextProcessor.ProcessMessageWithPostNumbers(msg, func(threadId domain.ThreadId, msgId domain.MsgId) (domain.PostNumber, bool) {
+			target, err := h.APIClient.GetMessageParsed(r, msgMetadata.Board, strconv.FormatInt(threadId, 10), strconv.FormatInt(msgId, 10))
+			if err != nil || target.PostNumber == 0 {
+				return 0, false
+			}
+			return target.PostNumber, true
+		})
+	} else {
+		processedText, replyTo, hasPayload, err = h.TextProcessor.ProcessMessage(msg)
+	}
 
 	return processedText, &replyTo, hasPayload, err
 }
@@ -143,20 +159,36 @@ func (h *Handler) parseAndValidateMultipartForm(w http.ResponseWriter, r *http.R
 	return true
 }
 
+// uploadTokens returns the tokens of files the post form uploaded ahead of
+// submitting, in the order they were attached.
+func uploadTokens(r *http.Request) []domain.UploadToken {
+	if r.MultipartForm == nil {
+		return nil
+	}
+	return r.MultipartForm.Value["upload_token"]
+}
+
 // NewValidationData creates a ValidationData struct populated from the public config.
 func (h *Handler) newValidationData() frontend_domain.ValidationData {
 	return frontend_domain.ValidationData{
 		PasswordMinLen:             h.Public.PasswordMinLen,
 		ConfirmationCodeLen:        h.Public.ConfirmationCodeLen,
+		AppealTextMaxLen:           h.Public.AppealTextMaxLen,
 		BoardNameMaxLen:            h.Public.BoardNameMaxLen,
 		BoardShortNameMaxLen:       h.Public.BoardShortNameMaxLen,
+		BoardCategoryNameMaxLen:    h.Public.BoardCategoryNameMaxLen,
+		BoardDescriptionMaxLen:     h.Public.BoardDescriptionMaxLen,
 		ThreadTitleMaxLen:          h.Public.ThreadTitleMaxLen,
 		MessageTextMaxLen:          h.Public.MessageTextMaxLen,
+		SelfDeleteWindow:           h.Public.SelfDeleteWindow,
+		BoardPostNumbers:           h.Public.PostNumbering == config.PostNumberingBoard,
 		MaxAttachmentsPerMessage:   h.Public.MaxAttachmentsPerMessage,
 		MaxTotalAttachmentSize:     h.Public.MaxTotalAttachmentSize,
 		MaxAttachmentSizeBytes:     h.Public.MaxAttachmentSizeBytes,
 		AllowedImageMimeTypes:      h.Public.AllowedImageMimeTypes,
 		AllowedVideoMimeTypes:      h.Public.AllowedVideoMimeTypes,
+		AllowedDocumentMimeTypes:   h.Public.AllowedDocumentMimeTypes,
+		MaxDocumentSizeBytes:       h.Public.MaxDocumentSizeBytes,
 		UserMessagesPageLimit:      h.Public.UserMessagesPageLimit,
 		AllowedRegistrationDomains: h.Public.AllowedRegistrationDomains,
 		ThumbnailDisplayOp:         h.Public.Media.ThumbnailDisplayOp,
@@ -171,6 +203,7 @@ func (h *Handler) initCommonTemplateData(w http.ResponseWriter, r *http.Request)
 		User:       mw.GetUserFromContext(r),
 		Validation: h.newValidationData(),
 		CSRFToken:  frontend_mw.GetCSRFTokenFromContext(r),
+		Footer:     h.footer,
 	}
 	// Automatically populate flash messages (and delete them)
 	common.Error, common.Success = h.getFlashes(w, r)
@@ -180,6 +213,12 @@ func (h *Handler) initCommonTemplateData(w http.ResponseWriter, r *http.Request)
 	if c, err := r.Cookie("disable_media"); err == nil && c.Value == "1" {
 		common.DisableMedia = true
 	}
-	common.StaticVersion = fmt.Sprintf("%d", serverStart.Unix())
+	if c, err := r.Cookie(infiniteScrollCookie); err == nil && c.Value == "1" {
+		common.InfiniteScroll = true
+	}
+	common.LowBandwidth = lowBandwidth(r)
+	common.Maintenance = h.maintenanceWindow(r)
+	common.Location, common.TimeZone, common.TimeZoneExplicit = resolveTimezone(r)
+	common.LoginURL = loginURL(r)
 	return common
 }
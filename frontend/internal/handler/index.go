@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strings"
 
 	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
 	"github.com/itchan-dev/itchan/shared/api"
@@ -20,27 +21,107 @@ func domainAllowed(emailDomain string, allowedDomains []string) bool {
 }
 
 func (h *Handler) IndexGetHandler(w http.ResponseWriter, r *http.Request) {
-	boards, err := h.APIClient.GetBoards(r)
+	categories, err := h.APIClient.GetGroupedBoards(r)
 	var errMsg string
 	if err != nil {
 		errMsg = err.Error()
 	}
 
+	// Hover cards show the latest thread of each board; the index renders without them on error
+	stats := h.indexBoardStats(r)
+	for i := range categories {
+		for j := range categories[i].Boards {
+			categories[i].Boards[j].Stats = stats[categories[i].Boards[j].ShortName]
+		}
+	}
+
 	user := mw.GetUserFromContext(r)
+	withAccess := func(b domain.BoardMetadata) frontend_domain.BoardWithAccess {
+		accessible := len(b.AllowedEmailDomains) == 0 ||
+			(user != nil && (user.Admin || domainAllowed(user.EmailDomain, b.AllowedEmailDomains)))
+		return frontend_domain.BoardWithAccess{Board: domain.Board{BoardMetadata: b}, Accessible: accessible}
+	}
 
 	var pageData frontend_domain.IndexPageData
-	for _, b := range boards {
-		if len(b.AllowedEmailDomains) > 0 {
-			accessible := user != nil && (user.Admin || domainAllowed(user.EmailDomain, b.AllowedEmailDomains))
-			pageData.CorporateBoards = append(pageData.CorporateBoards, frontend_domain.BoardWithAccess{Board: b, Accessible: accessible})
-		} else {
-			pageData.PublicBoards = append(pageData.PublicBoards, b)
+	for _, c := range categories {
+		if c.Id != 0 {
+			group := frontend_domain.BoardCategoryGroup{Name: c.Name}
+			for _, b := range c.Boards {
+				group.Boards = append(group.Boards, withAccess(b))
+			}
+			if len(group.Boards) > 0 {
+				pageData.Categories = append(pageData.Categories, group)
+			}
+			continue
+		}
+		for _, b := range c.Boards {
+			if len(b.AllowedEmailDomains) > 0 {
+				pageData.CorporateBoards = append(pageData.CorporateBoards, withAccess(b))
+			} else {
+				pageData.PublicBoards = append(pageData.PublicBoards, domain.Board{BoardMetadata: b})
+			}
 		}
 	}
 
+	if activity, err := h.APIClient.GetSiteActivity(r); err != nil {
+		logger.Log.Error("failed to get site activity from API", "error", err)
+	} else {
+		pageData.Activity = buildActivityWidgets(activity)
+	}
+
 	h.renderTemplateWithError(w, r, "index.html", pageData, errMsg)
 }
 
+// indexStatsMaxPages bounds the board listing pages fetched for the index
+// hover cards.
+const indexStatsMaxPages = 10
+
+// indexBoardStats loads counts and the latest thread of every board for the
+// index hover cards, keyed by short name.
+func (h *Handler) indexBoardStats(r *http.Request) map[domain.BoardShortName]*domain.BoardStats {
+	stats := make(map[domain.BoardShortName]*domain.BoardStats)
+	for page := 1; page <= indexStatsMaxPages; page++ {
+		resp, err := h.APIClient.GetBoards(r, domain.BoardSortPosition, domain.BoardStatsLatest, page)
+		if err != nil {
+			logger.Log.Error("failed to get board stats from API", "error", err)
+			break
+		}
+		for _, b := range resp.Boards {
+			stats[b.ShortName] = b.Stats
+		}
+		if len(resp.Boards) == 0 || len(stats) >= resp.Total {
+			break
+		}
+	}
+	return stats
+}
+
+// activitySnippetLen is the max number of runes of a post shown in the latest posts widget.
+const activitySnippetLen = 120
+
+func buildActivityWidgets(activity domain.SiteActivity) *frontend_domain.ActivityWidgets {
+	widgets := &frontend_domain.ActivityWidgets{
+		PostsToday:    activity.PostsToday,
+		ActiveThreads: activity.ActiveThreads,
+	}
+	for _, p := range activity.LatestPosts {
+		widgets.LatestPosts = append(widgets.LatestPosts, frontend_domain.LatestPost{
+			LatestPost: p,
+			Snippet:    snippet(plainText(p.Text), activitySnippetLen),
+		})
+	}
+	return widgets
+}
+
+// snippet collapses whitespace and cuts text to at most n runes, adding an ellipsis when cut.
+func snippet(text string, n int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
+
 func (h *Handler) IndexPostHandler(w http.ResponseWriter, r *http.Request) {
 	targetURL := "/"
 
@@ -54,8 +135,9 @@ func (h *Handler) IndexPostHandler(w http.ResponseWriter, r *http.Request) {
 	allowedEmailsStr := r.FormValue("allowedEmails")
 
 	backendData := api.CreateBoardRequest{
-		Name:      name,
-		ShortName: shortName,
+		Name:        name,
+		ShortName:   shortName,
+		Description: strings.TrimSpace(r.FormValue("description")),
 	}
 
 	if allowedEmailsStr != "" {
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// PostLimitsHandler proxies the user's posting cooldowns for the countdown on
+// post forms.
+func (h *Handler) PostLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	limits, err := h.APIClient.GetPostLimits(r)
+	if err != nil {
+		logger.Log.Error("fetching post limits from API", "error", err)
+		http.Error(w, "Internal error: backend unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(limits); err != nil {
+		logger.Log.Error("encoding post limits", "error", err)
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// maintenanceCacheTTL is how long the maintenance banner is shown from memory
+// before the backend is asked again.
+const maintenanceCacheTTL = 30 * time.Second
+
+// maintenanceCache holds the last maintenance window fetched from the backend.
+type maintenanceCache struct {
+	mu        sync.Mutex
+	window    *api.MaintenanceResponse
+	fetchedAt time.Time
+}
+
+// maintenanceWindow returns the active or upcoming maintenance window for the
+// page banner, nil if none. One request at a time refreshes the cache while
+// the others use the last known window, which a failed fetch also keeps.
+func (h *Handler) maintenanceWindow(r *http.Request) *api.MaintenanceResponse {
+	c := &h.maintenance
+	now := time.Now()
+
+	c.mu.Lock()
+	stale := now.Sub(c.fetchedAt) >= maintenanceCacheTTL
+	if stale {
+		c.fetchedAt = now
+	}
+	c.mu.Unlock()
+
+	if stale {
+		window, err := h.APIClient.GetMaintenance(r)
+		if err != nil {
+			logger.Log.Error("fetching maintenance window", "error", err)
+		} else {
+			c.mu.Lock()
+			c.window = window
+			c.mu.Unlock()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.window == nil || (c.window.EndsAt != nil && !now.Before(*c.window.EndsAt)) {
+		return nil
+	}
+	window := *c.window
+	window.Active = !now.Before(window.StartsAt)
+	return &window
+}
+
+// forgetMaintenanceWindow makes the next page fetch the window again, so an
+// admin sees their change right away.
+func (h *Handler) forgetMaintenanceWindow() {
+	h.maintenance.mu.Lock()
+	h.maintenance.fetchedAt = time.Time{}
+	h.maintenance.mu.Unlock()
+}
+
+// ScheduleMaintenanceHandler puts the instance into read-only mode. Start and
+// end come from datetime-local inputs in the admin's timezone; an empty start
+// means now, an empty end lasts until the window is cancelled.
+func (h *Handler) ScheduleMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	loc, _, _ := resolveTimezone(r)
+	req := api.ScheduleMaintenanceRequest{Message: strings.TrimSpace(r.FormValue("message"))}
+	for _, field := range []struct {
+		name string
+		dest **time.Time
+	}{{"starts_at", &req.StartsAt}, {"ends_at", &req.EndsAt}} {
+		value := r.FormValue(field.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.ParseInLocation("2006-01-02T15:04", value, loc)
+		if err != nil {
+			h.redirectWithFlash(w, r, "/admin", flashCookieError, "Invalid date: "+value)
+			return
+		}
+		*field.dest = &t
+	}
+
+	if err := h.APIClient.ScheduleMaintenance(r, req); err != nil {
+		logger.Log.Error("scheduling maintenance via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+	h.forgetMaintenanceWindow()
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, "Maintenance scheduled")
+}
+
+// CancelMaintenanceHandler ends the maintenance window early or drops a
+// scheduled one.
+func (h *Handler) CancelMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	if err := h.APIClient.CancelMaintenance(r); err != nil {
+		logger.Log.Error("cancelling maintenance via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+	h.forgetMaintenanceWindow()
+
+	h.redirectWithFlash(w, r, "/admin", flashCookieSuccess, "Maintenance cancelled, the site is writable again")
+}
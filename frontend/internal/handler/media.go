@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// immutableMediaCacheControl lets browsers keep content-addressed files for a
+// year without revalidating. "private" keeps restricted boards out of shared caches.
+const immutableMediaCacheControl = "private, max-age=31536000, immutable"
+
+// HashedMediaHandler serves /media/sha256/{hash}.{ext}. The backend maps the
+// hash to a stored file on a board the viewer can read.
+func (h *Handler) HashedMediaHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "file")
+	ext := path.Ext(name)
+	hash := strings.TrimSuffix(name, ext)
+
+	locations, err := h.APIClient.GetMediaLocations(r, hash)
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	root := http.Dir(h.MediaPath)
+	for _, loc := range locations {
+		if path.Ext(loc.FilePath) != ext {
+			continue
+		}
+		f, err := root.Open(loc.FilePath)
+		if err != nil {
+			logger.Log.Warn("hashed media file missing on disk", "path", loc.FilePath, "error", err)
+			continue
+		}
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			f.Close()
+			continue
+		}
+		defer f.Close()
+
+		w.Header().Set("Cache-Control", immutableMediaCacheControl)
+		http.ServeContent(w, r, name, info.ModTime(), f)
+		return
+	}
+	http.NotFound(w, r)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,6 +10,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
 	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/utils"
+	"github.com/itchan-dev/itchan/shared/validation"
 )
 
 func (h *Handler) MessageDeleteHandler(w http.ResponseWriter, r *http.Request) {
@@ -17,7 +20,7 @@ func (h *Handler) MessageDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	messageId := chi.URLParam(r, "message")
 	targetURL := fmt.Sprintf("/%s/%s", boardShortName, threadId)
 
-	err := h.APIClient.DeleteMessage(r, boardShortName, threadId, messageId)
+	err := h.APIClient.DeleteMessage(r, boardShortName, threadId, messageId, r.FormValue("reason"))
 	if err != nil {
 		logger.Log.Error("deleting message via API", "error", err)
 		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
@@ -27,6 +30,23 @@ func (h *Handler) MessageDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, targetURL, http.StatusSeeOther)
 }
 
+// OwnMessageDeleteHandler lets an author delete their own reply while the
+// backend still allows it.
+func (h *Handler) OwnMessageDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	boardShortName := chi.URLParam(r, "board")
+	threadId := chi.URLParam(r, "thread")
+	messageId := chi.URLParam(r, "message")
+	targetURL := fmt.Sprintf("/%s/%s", boardShortName, threadId)
+
+	if err := h.APIClient.DeleteOwnMessage(r, boardShortName, threadId, messageId); err != nil {
+		logger.Log.Error("deleting own message via API", "error", err)
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, targetURL, flashCookieSuccess, "Your post was deleted.")
+}
+
 // MessagePreviewHandler proxies message API requests for JavaScript previews.
 func (h *Handler) MessagePreviewHandler(w http.ResponseWriter, r *http.Request) {
 	board := chi.URLParam(r, "board")
@@ -48,6 +68,71 @@ func (h *Handler) MessagePreviewHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// PostResolveHandler proxies the lookup of a board-local post number, which
+// previews use to find the thread and message behind a >>N quote.
+func (h *Handler) PostResolveHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.APIClient.ResolvePostNumber(r, chi.URLParam(r, "board"), chi.URLParam(r, "postNumber"))
+	if err != nil {
+		http.Error(w, "Internal error: backend unavailable", http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		logger.Log.Error("copying response body for post resolve", "error", err)
+	}
+}
+
+// ThreadGraphHandler proxies the reply graph JSON for the thread map.
+func (h *Handler) ThreadGraphHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.APIClient.GetThreadGraph(r, chi.URLParam(r, "board"), chi.URLParam(r, "thread"))
+	if err != nil {
+		http.Error(w, "Internal error: backend unavailable", http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		logger.Log.Error("copying response body for thread graph", "error", err)
+	}
+}
+
+// UploadProxyHandler relays a file the post form uploads ahead of submitting
+// and returns the backend's JSON with the upload token.
+func (h *Handler) UploadProxyHandler(w http.ResponseWriter, r *http.Request) {
+	maxRequestSize := validation.CalculateMaxRequestSize(h.Public.MaxTotalAttachmentSize, 1<<20)
+	if err := validation.ValidateAndParseMultipart(r, w, maxRequestSize); err != nil {
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	files := r.MultipartForm.File["file"]
+	if len(files) != 1 {
+		http.Error(w, "Exactly one file is required", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.APIClient.UploadFile(r, chi.URLParam(r, "board"), files[0])
+	if err != nil {
+		logger.Log.Error("uploading file via API", "error", err)
+		http.Error(w, "Internal error: backend unavailable", http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		logger.Log.Error("copying response body for upload", "error", err)
+	}
+}
+
 // MessagePreviewHTMLHandler returns rendered HTML for message previews.
 func (h *Handler) MessagePreviewHTMLHandler(w http.ResponseWriter, r *http.Request) {
 	board := chi.URLParam(r, "board")
@@ -89,3 +174,50 @@ func (h *Handler) MessagePreviewHTMLHandler(w http.ResponseWriter, r *http.Reque
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	_, _ = buf.WriteTo(w)
 }
+
+// ThreadUpdatesHandler returns the posts added to a thread after the "since"
+// message, rendered for thread pages that poll for new posts.
+func (h *Handler) ThreadUpdatesHandler(w http.ResponseWriter, r *http.Request) {
+	board := chi.URLParam(r, "board")
+	threadId := chi.URLParam(r, "thread")
+
+	updates, err := h.APIClient.GetThreadUpdates(r, board, threadId, r.URL.Query().Get("since"))
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+
+	tmpl, ok := h.getTemplate("partials")
+	if !ok {
+		logger.Log.Error("partials template not found in templates map")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	common := h.initCommonTemplateData(w, r)
+	posts := new(bytes.Buffer)
+	for _, msg := range updates.Messages {
+		if err := tmpl.ExecuteTemplate(posts, "post", frontend_domain.PostData{Message: renderMessage(*msg), Common: &common}); err != nil {
+			logger.Log.Error("rendering post template", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	resp := frontend_domain.ThreadUpdates{Posts: posts.String(), Backlinks: make([]frontend_domain.Backlink, 0, len(updates.Backlinks))}
+	for _, reply := range updates.Backlinks {
+		link := new(bytes.Buffer)
+		if err := tmpl.ExecuteTemplate(link, "reply-link", frontend_domain.ReplyLinkData{Reply: reply, Common: &common}); err != nil {
+			logger.Log.Error("rendering reply-link template", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		resp.Backlinks = append(resp.Backlinks, frontend_domain.Backlink{From: reply.From, To: reply.To, HTML: link.String()})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Log.Error("encoding thread updates", "error", err)
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// NotificationsGetHandler displays the notification center: replies to the user's messages, newest first
+func (h *Handler) NotificationsGetHandler(w http.ResponseWriter, r *http.Request) {
+	page := utils.GetPage(r)
+
+	result, err := h.APIClient.GetNotifications(r, page)
+	var errMsg string
+	if err != nil {
+		logger.Log.Error("failed to get notifications from API", "error", err)
+		errMsg = "Failed to load notifications"
+		result.Page = page
+	}
+
+	data := frontend_domain.NotificationsPageData{
+		Page:          result.Page,
+		Unread:        result.Unread,
+		HasNext:       result.Page*h.Public.NotificationsPageLimit < result.Total,
+		DigestEnabled: h.Public.SiteURL != "",
+		PushPublicKey: h.Public.VAPIDPublicKey,
+	}
+	data.Watched, err = h.APIClient.GetWatchedThreads(r)
+	if err != nil {
+		logger.Log.Error("failed to get watched threads from API", "error", err)
+		if errMsg == "" {
+			errMsg = "Failed to load watched threads"
+		}
+	}
+	if data.DigestEnabled {
+		data.DigestFrequency, err = h.APIClient.GetDigestFrequency(r)
+		if err != nil {
+			logger.Log.Error("failed to get digest settings from API", "error", err)
+			if errMsg == "" {
+				errMsg = "Failed to load email digest settings"
+			}
+		}
+	}
+	for _, n := range result.Notifications {
+		data.Notifications = append(data.Notifications, frontend_domain.Notification{
+			Notification: n,
+			Snippet:      snippet(plainText(n.Message.Text), activitySnippetLen),
+		})
+	}
+
+	h.renderTemplateWithError(w, r, "notifications.html", data, errMsg)
+}
+
+// NotificationReadPostHandler marks one notification as read and returns to the list
+func (h *Handler) NotificationReadPostHandler(w http.ResponseWriter, r *http.Request) {
+	target := notificationsTarget(r)
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.APIClient.MarkNotificationRead(r, id); err != nil {
+		logger.Log.Error("marking notification as read via API", "error", err)
+		h.redirectWithFlash(w, r, target, flashCookieError, "Failed to mark notification as read")
+		return
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// NotificationsReadAllPostHandler marks all notifications as read
+func (h *Handler) NotificationsReadAllPostHandler(w http.ResponseWriter, r *http.Request) {
+	target := notificationsTarget(r)
+	if err := h.APIClient.MarkAllNotificationsRead(r); err != nil {
+		logger.Log.Error("marking notifications as read via API", "error", err)
+		h.redirectWithFlash(w, r, target, flashCookieError, "Failed to mark notifications as read")
+		return
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// notificationsTarget returns the notifications page the form was posted from.
+func notificationsTarget(r *http.Request) string {
+	if page, err := strconv.Atoi(r.FormValue("page")); err == nil && page > 1 {
+		return fmt.Sprintf("/notifications?page=%d", page)
+	}
+	return "/notifications"
+}
+
+// PushPostHandler saves or removes the browser push subscription that the
+// script on the notifications page put into the form.
+func (h *Handler) PushPostHandler(w http.ResponseWriter, r *http.Request) {
+	target := notificationsTarget(r)
+	endpoint := r.FormValue("endpoint")
+
+	if r.FormValue("subscribe") != "1" {
+		if err := h.APIClient.UnsubscribePush(r, endpoint); err != nil {
+			logger.Log.Error("removing push subscription via API", "error", err)
+			h.redirectWithFlash(w, r, target, flashCookieError, "Failed to turn off browser notifications")
+			return
+		}
+		h.redirectWithFlash(w, r, target, flashCookieSuccess, "Browser notifications turned off")
+		return
+	}
+
+	var body api.PushSubscriptionRequest
+	body.Endpoint = endpoint
+	body.Keys.P256dh = r.FormValue("p256dh")
+	body.Keys.Auth = r.FormValue("auth")
+	if err := h.APIClient.SubscribePush(r, body); err != nil {
+		logger.Log.Error("saving push subscription via API", "error", err)
+		h.redirectWithFlash(w, r, target, flashCookieError, "Failed to turn on browser notifications")
+		return
+	}
+	h.redirectWithFlash(w, r, target, flashCookieSuccess, "Browser notifications turned on")
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// previewSnippetLen is the max number of runes of the OP shown in link previews.
+const previewSnippetLen = 200
+
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// OEmbedHandler implements the oEmbed provider endpoint (GET /oembed?url=...) for thread links.
+// Only JSON is supported; thread pages advertise it through a discovery <link>.
+func (h *Handler) OEmbedHandler(w http.ResponseWriter, r *http.Request) {
+	if format := r.URL.Query().Get("format"); format != "" && format != "json" {
+		http.Error(w, "Only json format is supported", http.StatusNotImplemented)
+		return
+	}
+
+	target, err := url.Parse(r.URL.Query().Get("url"))
+	if err != nil || target.Host != r.Host {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	parts := strings.Split(strings.Trim(target.Path, "/"), "/")
+	if len(parts) != 2 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if _, err := strconv.Atoi(parts[1]); err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	resp, err := h.APIClient.GetThreadOEmbed(r, parts[0], parts[1])
+	if err != nil {
+		utils.WriteErrorAndStatusCode(w, err)
+		return
+	}
+	if resp.ThumbnailURL != "" {
+		resp.ThumbnailURL = siteOrigin(r) + resp.ThumbnailURL
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Log.Error("encoding oembed response", "error", err)
+	}
+}
+
+// threadPreview builds the link preview of a thread from its OP.
+func threadPreview(r *http.Request, thread domain.Thread) *frontend_domain.LinkPreview {
+	origin := siteOrigin(r)
+	threadURL := fmt.Sprintf("%s/%s/%d", origin, thread.Board, thread.Id)
+	preview := &frontend_domain.LinkPreview{
+		URL:       threadURL,
+		Title:     fmt.Sprintf("/%s/ - %s", thread.Board, thread.Title),
+		OEmbedURL: origin + "/oembed?format=json&url=" + url.QueryEscape(threadURL),
+	}
+	if thread.Title == "" {
+		preview.Title = fmt.Sprintf("/%s/ - Thread No.%d", thread.Board, thread.Id)
+	}
+
+	description := fmt.Sprintf("%d posts", thread.MessageCount)
+	if len(thread.Messages) > 0 {
+		op := thread.Messages[0]
+		if text := snippet(plainText(op.Text), previewSnippetLen); text != "" {
+			description += " · " + text
+		}
+		for _, a := range op.Attachments {
+			if a.File != nil && a.File.ThumbnailURL() != "" {
+				preview.ImageURL = origin + a.File.ThumbnailURL()
+				break
+			}
+		}
+	}
+	preview.Description = description
+	return preview
+}
+
+// plainText strips the markup produced by the text processor.
+func plainText(s string) string {
+	return html.UnescapeString(htmlTagRe.ReplaceAllString(s, " "))
+}
+
+// siteOrigin returns the scheme and host the request was made to,
+// honouring the X-Forwarded-Proto header set by the reverse proxy.
+func siteOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
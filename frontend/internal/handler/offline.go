@@ -0,0 +1,10 @@
+package handler
+
+import "net/http"
+
+// OfflineGetHandler renders the page the service worker caches at install and
+// shows for navigations while the network is down. It is fetched without the
+// auth middleware, so the cached copy carries no user data.
+func (h *Handler) OfflineGetHandler(w http.ResponseWriter, r *http.Request) {
+	h.renderTemplate(w, r, "offline.html", nil)
+}
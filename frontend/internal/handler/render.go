@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/itchan-dev/itchan/shared/logger"
 
 	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
+	"github.com/itchan-dev/itchan/frontend/internal/markdown"
 	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/markup"
 )
 
 // serverStart is used as a cache-buster: any server restart (i.e. deployment) advances
@@ -52,6 +55,11 @@ func (h *Handler) renderTemplate(w http.ResponseWriter, r *http.Request, name st
 }
 
 func (h *Handler) renderTemplateWithError(w http.ResponseWriter, r *http.Request, name string, data any, errMsg string) {
+	h.renderPage(w, r, name, data, errMsg, http.StatusOK)
+}
+
+// renderPage renders a full page with the layout and answers with status.
+func (h *Handler) renderPage(w http.ResponseWriter, r *http.Request, name string, data any, errMsg string, status int) {
 	tmpl, ok := h.getTemplate(name)
 	if !ok {
 		http.Error(w, fmt.Sprintf("Template %s not found", name), http.StatusInternalServerError)
@@ -62,26 +70,103 @@ func (h *Handler) renderTemplateWithError(w http.ResponseWriter, r *http.Request
 	if errMsg != "" {
 		common.Error = errMsg
 	}
+	if rules, ok := data.(uploadRules); ok {
+		applyUploadRules(&common.Validation, rules)
+	}
+	if page, ok := data.(boardPage); ok {
+		common.Language = page.PageLanguage()
+	}
+	if common.User != nil {
+		unread, err := h.APIClient.GetUnreadNotifications(r)
+		if err != nil {
+			logger.Log.Warn("failed to get unread notifications from API", "error", err)
+		}
+		common.UnreadNotifications = unread
+	}
+
+	executeTemplate(w, tmpl, name, TemplateData{Data: data, Common: common}, status)
+}
+
+// renderFragment renders a single block of a page template, e.g. the messages
+// of a thread, for client-side navigation and refresh. Fragments skip the
+// layout, so the notification count the header shows is not fetched.
+func (h *Handler) renderFragment(w http.ResponseWriter, r *http.Request, name, block string, data any) {
+	tmpl, ok := h.getTemplate(name)
+	if ok {
+		tmpl = tmpl.Lookup(block)
+	}
+	if tmpl == nil {
+		http.Error(w, fmt.Sprintf("Template %s not found", block), http.StatusInternalServerError)
+		return
+	}
 
-	wrapped := TemplateData{
-		Data:   data,
-		Common: common,
+	common := h.initCommonTemplateData(w, r)
+	if rules, ok := data.(uploadRules); ok {
+		applyUploadRules(&common.Validation, rules)
 	}
+	if page, ok := data.(boardPage); ok {
+		common.Language = page.PageLanguage()
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	executeTemplate(w, tmpl, name+"#"+block, TemplateData{Data: data, Common: common}, http.StatusOK)
+}
 
+// executeTemplate buffers the output so a failing template doesn't leave a
+// half-written page behind its error. The status is only sent once the page
+// rendered.
+func executeTemplate(w http.ResponseWriter, tmpl *template.Template, name string, data TemplateData, status int) {
 	buf := new(bytes.Buffer)
-	if err := tmpl.Execute(buf, wrapped); err != nil {
+	if err := tmpl.Execute(buf, data); err != nil {
 		logger.Log.Error("error executing template", "template", name, "error", err)
 		http.Error(w, "Internal Server Error rendering template", http.StatusInternalServerError)
 		return
 	}
 
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+	}
 	_, _ = buf.WriteTo(w)
 }
 
+// uploadRules is implemented by board and thread page data, whose post forms
+// follow the board's attachment rules rather than the site-wide ones.
+type uploadRules interface {
+	MaxAttachmentsOr(def int) int
+	AcceptsMimeType(mimeType string) bool
+}
+
+// boardPage is implemented by board and thread page data, whose interface
+// strings and dates follow the board's language.
+type boardPage interface {
+	PageLanguage() domain.BoardLanguage
+}
+
+// applyUploadRules narrows the attachment limits shown to the user.
+func applyUploadRules(v *frontend_domain.ValidationData, rules uploadRules) {
+	rejected := func(mimeType string) bool { return !rules.AcceptsMimeType(mimeType) }
+	v.MaxAttachmentsPerMessage = rules.MaxAttachmentsOr(v.MaxAttachmentsPerMessage)
+	v.AllowedImageMimeTypes = slices.DeleteFunc(slices.Clone(v.AllowedImageMimeTypes), rejected)
+	v.AllowedVideoMimeTypes = slices.DeleteFunc(slices.Clone(v.AllowedVideoMimeTypes), rejected)
+	v.AllowedDocumentMimeTypes = slices.DeleteFunc(slices.Clone(v.AllowedDocumentMimeTypes), rejected)
+}
+
+// quoteFoldMinLinks is how many message links a post has to open with before
+// they are folded behind a "replying to N posts" toggle.
+const quoteFoldMinLinks = 5
+
 // renderMessage transforms a domain.Message into a frontend-specific view model.
 func renderMessage(message domain.Message) *frontend_domain.Message {
 	renderedMessage := frontend_domain.Message{Message: message}
-	renderedMessage.Text = template.HTML(message.Text)
+	// Stored text is parser output; the sanitizer guards against parser bugs
+	text, changed := markup.Sanitize(message.Text)
+	if changed {
+		logger.Log.Warn("sanitizer changed message html",
+			"board", message.Board, "thread_id", message.ThreadId, "message_id", message.Id)
+	}
+	// Dead links lose their href, so they are marked after FoldQuotes has counted them
+	text = markdown.MarkDeadLinks(markdown.FoldQuotes(text, quoteFoldMinLinks), message.DeadLinks)
+	renderedMessage.Text = template.HTML(text)
 
 	if renderedMessage.IsOp() {
 		renderedMessage.Context.ExtraClasses = "op-post"
@@ -100,16 +185,55 @@ func renderThread(thread domain.Thread) *frontend_domain.Thread {
 	}
 	for i, msg := range thread.Messages {
 		renderedThread.Messages[i] = renderMessage(*msg)
+		// The viewer's own posts are never unread, as in the watched list
+		if renderedThread.FirstUnreadId == 0 && thread.LastReadId > 0 && msg.Id > thread.LastReadId && !msg.IsOwn {
+			renderedThread.FirstUnreadId = msg.Id
+		}
 
 		// Enrich OP messages (id=1) with thread-specific context
 		if msg.IsOp() {
 			renderedThread.Messages[i].Context.Subject = thread.Title
 			renderedThread.Messages[i].Context.IsPinned = thread.IsPinned
+			renderedThread.Messages[i].Context.OpOnly = thread.OpOnly
+			if thread.PredecessorId != nil {
+				renderedThread.Messages[i].Context.Continues = *thread.PredecessorId
+			}
 		}
 	}
+	if len(thread.Deleted) > 0 {
+		renderedThread.Messages = mergeDeletionStubs(renderedThread.Messages, thread.Deleted)
+	}
 	return &renderedThread
 }
 
+// mergeDeletionStubs inserts "post deleted" stubs into messages, keeping id order.
+// Both slices are expected to be sorted by message id.
+func mergeDeletionStubs(messages []*frontend_domain.Message, deleted []domain.DeletedMessage) []*frontend_domain.Message {
+	merged := make([]*frontend_domain.Message, 0, len(messages)+len(deleted))
+	i := 0
+	for _, msg := range messages {
+		for i < len(deleted) && deleted[i].Id < msg.Id {
+			merged = append(merged, renderDeletionStub(deleted[i]))
+			i++
+		}
+		merged = append(merged, msg)
+	}
+	for ; i < len(deleted); i++ {
+		merged = append(merged, renderDeletionStub(deleted[i]))
+	}
+	return merged
+}
+
+func renderDeletionStub(d domain.DeletedMessage) *frontend_domain.Message {
+	stub := frontend_domain.Message{Deletion: &d}
+	stub.Board = d.Board
+	stub.ThreadId = d.ThreadId
+	stub.Id = d.Id
+	stub.CreatedAt = d.DeletedAt
+	stub.Context.ExtraClasses = "reply-post deleted-post"
+	return &stub
+}
+
 func renderBoard(board domain.Board) *frontend_domain.Board {
 	renderedBoard := frontend_domain.Board{Board: board, Threads: make([]*frontend_domain.Thread, len(board.Threads))}
 	for i, thread := range board.Threads {
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/sitemap"
+)
+
+// SitemapHandler serves the periodically regenerated sitemap of public boards.
+func SitemapHandler(cache *sitemap.Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		if err := cache.Write(w, siteOrigin(r)); err != nil {
+			logger.Log.Error("writing sitemap", "error", err)
+		}
+	}
+}
+
+// RobotsHandler serves robots.txt, keeping crawlers out of boards that are
+// restricted or marked noindex.
+func RobotsHandler(cache *sitemap.Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		if err := cache.WriteRobots(w, siteOrigin(r)); err != nil {
+			logger.Log.Error("writing robots.txt", "error", err)
+		}
+	}
+}
@@ -2,8 +2,37 @@ package handler
 
 import (
 	"net/http"
+	"time"
 )
 
+// Timezone preference cookies. timezoneCookie holds the zone chosen explicitly on
+// the account page; browserTimezoneCookie is kept in sync by main.js and is used
+// as a fallback when no explicit choice was made.
+const (
+	timezoneCookie        = "timezone"
+	browserTimezoneCookie = "browser_tz"
+)
+
+// infiniteScrollCookie turns on loading the next board page while scrolling
+const infiniteScrollCookie = "infinite_scroll"
+
+// lowBandwidthCookie turns on the low-bandwidth mode: board and thread pages
+// fetch trimmed attachments and show placeholders that load media on click
+const lowBandwidthCookie = "low_bandwidth"
+
+// lowBandwidth reports whether the page is rendered in low-bandwidth mode.
+// ?lowbw=1 or ?lowbw=0 overrides the saved preference for a single page.
+func lowBandwidth(r *http.Request) bool {
+	switch r.URL.Query().Get("lowbw") {
+	case "1":
+		return true
+	case "0":
+		return false
+	}
+	c, err := r.Cookie(lowBandwidthCookie)
+	return err == nil && c.Value == "1"
+}
+
 func (h *Handler) ToggleDisableMedia(w http.ResponseWriter, r *http.Request) {
 	value := "1"
 	maxAge := 365 * 24 * 60 * 60 // 1 year
@@ -28,3 +57,116 @@ func (h *Handler) ToggleDisableMedia(w http.ResponseWriter, r *http.Request) {
 	}
 	http.Redirect(w, r, target, http.StatusSeeOther)
 }
+
+// SetTimezone stores the user's timezone preference. An empty value reverts to the
+// timezone reported by the browser.
+func (h *Handler) SetTimezone(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	target := r.Referer()
+	if target == "" {
+		target = "/account"
+	}
+
+	name := r.FormValue("timezone")
+	maxAge := 365 * 24 * 60 * 60 // 1 year
+	if name == "" {
+		maxAge = -1
+	} else if _, err := time.LoadLocation(name); err != nil {
+		h.redirectWithFlash(w, r, target, flashCookieError, "Unknown timezone: "+name)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     timezoneCookie,
+		Value:    name,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   h.Public.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	h.redirectWithFlash(w, r, target, flashCookieSuccess, "Timezone updated")
+}
+
+// SetInfiniteScroll stores whether board pages load the next page as the user
+// nears the bottom instead of only showing numbered pagination.
+func (h *Handler) SetInfiniteScroll(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	target := r.Referer()
+	if target == "" {
+		target = "/account"
+	}
+
+	value := "1"
+	maxAge := 365 * 24 * 60 * 60 // 1 year
+	if r.FormValue("infinite_scroll") == "" {
+		value = ""
+		maxAge = -1
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     infiniteScrollCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   h.Public.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	h.redirectWithFlash(w, r, target, flashCookieSuccess, "Board scrolling updated")
+}
+
+// SetLowBandwidth stores whether board and thread pages use the low-bandwidth mode.
+func (h *Handler) SetLowBandwidth(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	target := r.Referer()
+	if target == "" {
+		target = "/account"
+	}
+
+	value := "1"
+	maxAge := 365 * 24 * 60 * 60 // 1 year
+	if r.FormValue("low_bandwidth") == "" {
+		value = ""
+		maxAge = -1
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     lowBandwidthCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   h.Public.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	h.redirectWithFlash(w, r, target, flashCookieSuccess, "Low-bandwidth mode updated")
+}
+
+// resolveTimezone picks the display timezone: explicit preference, then the browser's
+// zone, then UTC. Unknown zone names are ignored.
+func resolveTimezone(r *http.Request) (loc *time.Location, name string, explicit bool) {
+	if c, err := r.Cookie(timezoneCookie); err == nil && c.Value != "" {
+		if loc, err := time.LoadLocation(c.Value); err == nil {
+			return loc, c.Value, true
+		}
+	}
+	if c, err := r.Cookie(browserTimezoneCookie); err == nil && c.Value != "" {
+		if loc, err := time.LoadLocation(c.Value); err == nil {
+			return loc, c.Value, false
+		}
+	}
+	return time.UTC, "UTC", false
+}
@@ -1,13 +1,18 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/frontend/internal/apiclient"
+	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
 	"github.com/itchan-dev/itchan/shared/api"
 	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
 	"github.com/itchan-dev/itchan/shared/logger"
 	"github.com/itchan-dev/itchan/shared/utils"
 )
@@ -18,9 +23,16 @@ func (h *Handler) ThreadGetHandler(w http.ResponseWriter, r *http.Request) {
 
 	page := utils.GetPage(r)
 
+	// ?fragment=messages returns only the pagination and posts for soft navigation
+	fragment := r.URL.Query().Get("fragment")
+	if fragment != "" && fragment != "messages" {
+		h.RenderError(w, r, &internal_errors.ErrorWithStatusCode{Message: "Unknown fragment", StatusCode: http.StatusBadRequest})
+		return
+	}
+
 	lastModified, err := h.APIClient.GetThreadLastModified(r, shortName, threadId)
 	if err != nil {
-		utils.WriteErrorAndStatusCode(w, err)
+		h.RenderError(w, r, err)
 		return
 	}
 
@@ -28,13 +40,57 @@ func (h *Handler) ThreadGetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	thread, err := h.APIClient.GetThread(r, shortName, threadId, page)
+	var thread domain.Thread
+	if fromParam := r.URL.Query().Get("from"); fromParam != "" {
+		// Deep permalinks load the messages around the target instead of its page
+		from, err := strconv.Atoi(fromParam)
+		if err != nil || from < 1 {
+			h.RenderError(w, r, &internal_errors.ErrorWithStatusCode{Message: "Invalid message range", StatusCode: http.StatusBadRequest})
+			return
+		}
+		to, _ := strconv.Atoi(r.URL.Query().Get("to"))
+		thread, err = h.APIClient.GetThreadRange(r, shortName, threadId, from, to, lowBandwidth(r))
+	} else {
+		thread, err = h.APIClient.GetThread(r, shortName, threadId, page, lowBandwidth(r))
+	}
+	if err != nil {
+		h.RenderError(w, r, err)
+		return
+	}
+
+	rendered := renderThread(thread)
+	if fragment == "messages" {
+		h.renderFragment(w, r, "thread.html", "thread-messages", rendered)
+		return
+	}
+	rendered.Preview = threadPreview(r, thread)
+	rendered.Appearance = h.boardAppearance(r, shortName)
+	h.renderTemplate(w, r, "thread.html", rendered)
+}
+
+// ThreadGalleryGetHandler shows all attachments of a thread as a grid; the
+// lightbox in main.js steps through them.
+func (h *Handler) ThreadGalleryGetHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	threadIdStr := chi.URLParam(r, "thread")
+	threadId, err := strconv.Atoi(threadIdStr)
+	if err != nil {
+		h.RenderError(w, r, &internal_errors.ErrorWithStatusCode{Message: "Invalid thread ID", StatusCode: http.StatusBadRequest})
+		return
+	}
+
+	result, err := h.APIClient.GetThreadAttachments(r, shortName, threadIdStr)
 	if err != nil {
-		utils.WriteErrorAndStatusCode(w, err)
+		h.RenderError(w, r, err)
 		return
 	}
 
-	h.renderTemplate(w, r, "thread.html", renderThread(thread))
+	h.renderTemplate(w, r, "gallery.html", frontend_domain.GalleryPageData{
+		Board:       shortName,
+		ThreadId:    domain.ThreadId(threadId),
+		Attachments: result.Attachments,
+		Appearance:  h.boardAppearance(r, shortName),
+	})
 }
 
 func (h *Handler) ThreadPostHandler(w http.ResponseWriter, r *http.Request) {
@@ -55,7 +111,7 @@ func (h *Handler) ThreadPostHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	text := r.FormValue("text")
-	processedText, domainReplies, hasPayload, err := h.processMessageText(text, domain.MessageMetadata{
+	processedText, domainReplies, hasPayload, err := h.processMessageText(r, text, domain.MessageMetadata{
 		Board:    shortName,
 		ThreadId: domain.ThreadId(threadId),
 	})
@@ -66,7 +122,8 @@ func (h *Handler) ThreadPostHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if message has either text OR attachments (align with backend validation)
-	hasAttachments := r.MultipartForm != nil && r.MultipartForm.File != nil && len(r.MultipartForm.File["attachments"]) > 0
+	tokens := uploadTokens(r)
+	hasAttachments := len(tokens) > 0 || r.MultipartForm != nil && r.MultipartForm.File != nil && len(r.MultipartForm.File["attachments"]) > 0
 	if !hasPayload && !hasAttachments {
 		h.redirectWithFlash(w, r, errorTargetURL, flashCookieError, "Message must contain either text or attachments.")
 		return
@@ -76,9 +133,14 @@ func (h *Handler) ThreadPostHandler(w http.ResponseWriter, r *http.Request) {
 		Text:            processedText,
 		ShowEmailDomain: r.FormValue("show_company") == "on",
 		ReplyTo:         domainReplies,
+		UploadTokens:    tokens,
 	}
 
 	page, err := h.APIClient.CreateReply(r, shortName, threadIdStr, backendData, r.MultipartForm)
+	if errors.Is(err, apiclient.ErrPostQueued) {
+		h.redirectWithFlash(w, r, fmt.Sprintf("/%s/%s#bottom", shortName, threadIdStr), flashCookieSuccess, "Your reply is awaiting moderator approval.")
+		return
+	}
 	if err != nil {
 		logger.Log.Error("posting reply via API", "error", err)
 		h.redirectWithFlash(w, r, errorTargetURL, flashCookieError, err.Error())
@@ -94,7 +156,7 @@ func (h *Handler) ThreadDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	threadId := chi.URLParam(r, "thread")
 	targetURL := "/" + boardShortName // Redirect to board page
 
-	err := h.APIClient.DeleteThread(r, boardShortName, threadId)
+	err := h.APIClient.DeleteThread(r, boardShortName, threadId, r.FormValue("reason"))
 	if err != nil {
 		logger.Log.Error("deleting thread via API", "error", err)
 		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
@@ -122,3 +184,43 @@ func (h *Handler) ThreadTogglePinnedHandler(w http.ResponseWriter, r *http.Reque
 
 	http.Redirect(w, r, referer, http.StatusSeeOther)
 }
+
+func (h *Handler) ThreadToggleOpOnlyHandler(w http.ResponseWriter, r *http.Request) {
+	boardShortName := chi.URLParam(r, "board")
+	threadId := chi.URLParam(r, "thread")
+
+	referer := r.Header.Get("Referer")
+	if referer == "" {
+		referer = fmt.Sprintf("/%s/%s", boardShortName, threadId)
+	}
+
+	if _, err := h.APIClient.ToggleOpOnlyThread(r, boardShortName, threadId); err != nil {
+		logger.Log.Error("toggling op-only via API", "error", err)
+		h.redirectWithFlash(w, r, referer, flashCookieError, err.Error())
+		return
+	}
+
+	http.Redirect(w, r, referer, http.StatusSeeOther)
+}
+
+// ThreadSuccessorPostHandler links the thread to the one it is continued in.
+// The successor may be typed as a plain number or as a >>123 reference.
+func (h *Handler) ThreadSuccessorPostHandler(w http.ResponseWriter, r *http.Request) {
+	boardShortName := chi.URLParam(r, "board")
+	threadId := chi.URLParam(r, "thread")
+	target := fmt.Sprintf("/%s/%s", boardShortName, threadId)
+
+	successorId, err := strconv.ParseInt(strings.TrimLeft(strings.TrimSpace(r.FormValue("successor")), ">"), 10, 64)
+	if err != nil || successorId <= 0 {
+		h.redirectWithFlash(w, r, target, flashCookieError, "Enter the number of the thread this one continues in")
+		return
+	}
+
+	if err := h.APIClient.SetThreadSuccessor(r, boardShortName, threadId, successorId); err != nil {
+		logger.Log.Error("linking successor thread via API", "error", err)
+		h.redirectWithFlash(w, r, target, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, target, flashCookieSuccess, fmt.Sprintf("Thread is now continued in >>%d", successorId))
+}
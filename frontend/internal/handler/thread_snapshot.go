@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/utils"
+)
+
+// ThreadSnapshotPostHandler saves a snapshot of the thread and sends the user
+// to it.
+func (h *Handler) ThreadSnapshotPostHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	threadId := chi.URLParam(r, "thread")
+
+	snapshot, err := h.APIClient.CreateThreadSnapshot(r, shortName, threadId)
+	if err != nil {
+		logger.Log.Error("creating thread snapshot via API", "error", err)
+		h.redirectWithFlash(w, r, fmt.Sprintf("/%s/%s", shortName, threadId), flashCookieError, "Failed to save a snapshot of the thread")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/%s/snapshots/%d", shortName, snapshot.Id), http.StatusSeeOther)
+}
+
+func (h *Handler) ThreadSnapshotsGetHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	page := utils.GetPage(r)
+
+	result, err := h.APIClient.GetThreadSnapshots(r, shortName, page)
+	if err != nil {
+		h.RenderError(w, r, err)
+		return
+	}
+
+	h.renderTemplate(w, r, "snapshots.html", frontend_domain.ThreadSnapshotsPageData{
+		Board:     shortName,
+		Snapshots: result.Snapshots,
+		Page:      result.Page,
+		HasNext:   len(result.Snapshots) == h.Public.ThreadSnapshotsPageLimit,
+	})
+}
+
+// snapshotCSP lets a snapshot page use its inline styles and embedded
+// thumbnails and nothing else. The sandbox keeps it off the site's origin.
+const snapshotCSP = "default-src 'none'; style-src 'unsafe-inline'; img-src data:; sandbox"
+
+// ThreadSnapshotGetHandler serves the page of a snapshot from the media
+// storage, as a download with ?download=1.
+func (h *Handler) ThreadSnapshotGetHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+
+	snapshot, err := h.APIClient.GetThreadSnapshot(r, shortName, chi.URLParam(r, "snapshot"))
+	if err != nil {
+		h.RenderError(w, r, err)
+		return
+	}
+
+	f, err := http.Dir(h.MediaPath).Open(snapshot.FilePath)
+	if err != nil {
+		logger.Log.Warn("snapshot file missing on disk", "path", snapshot.FilePath, "error", err)
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	name := fmt.Sprintf("%s-%d-snapshot-%d.html", snapshot.Board, snapshot.ThreadId, snapshot.Id)
+	disposition := "inline"
+	if r.URL.Query().Get("download") == "1" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`%s; filename="%s"`, disposition, name))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", snapshotCSP)
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+func (h *Handler) ThreadSnapshotDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	shortName := chi.URLParam(r, "board")
+	targetURL := "/" + shortName + "/snapshots"
+
+	if err := h.APIClient.DeleteThreadSnapshot(r, shortName, chi.URLParam(r, "snapshot")); err != nil {
+		logger.Log.Error("deleting thread snapshot via API", "error", err)
+		h.redirectWithFlash(w, r, targetURL, flashCookieError, err.Error())
+		return
+	}
+
+	h.redirectWithFlash(w, r, targetURL, flashCookieSuccess, "Snapshot deleted")
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/middleware"
+)
+
+// StartViewAsHandler switches the admin to a read-only view of the site as
+// another user, for as long as the backend-issued token lasts.
+func (h *Handler) StartViewAsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		logger.Log.Error("parsing form", "error", err)
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.FormValue("userId")
+	if userID == "" {
+		http.Error(w, "Missing userId", http.StatusBadRequest)
+		return
+	}
+
+	viewAs, err := h.APIClient.StartViewAs(r, userID, r.FormValue("reason"))
+	if err != nil {
+		logger.Log.Error("starting view-as via API", "error", err)
+		h.redirectWithFlash(w, r, "/admin", flashCookieError, err.Error())
+		return
+	}
+
+	h.setViewAsCookie(w, viewAs.Token, int(time.Until(viewAs.ExpiresAt).Seconds()))
+	h.redirectWithFlash(w, r, "/", flashCookieSuccess, fmt.Sprintf("Viewing the site as user %s, read-only", userID))
+}
+
+// StopViewAsHandler drops the view-as token, returning the admin to their own session
+func (h *Handler) StopViewAsHandler(w http.ResponseWriter, r *http.Request) {
+	h.setViewAsCookie(w, "", -1)
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+func (h *Handler) setViewAsCookie(w http.ResponseWriter, token string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Path:     "/",
+		Name:     middleware.ViewAsCookieName,
+		Value:    token,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.Public.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
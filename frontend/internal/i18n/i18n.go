@@ -0,0 +1,154 @@
+// Package i18n translates the interface strings of board and thread pages
+// into the language set for the board.
+package i18n
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// translations maps a language to the translations of the English strings
+// used in templates. Strings missing here are shown in English.
+var translations = map[domain.BoardLanguage]map[string]string{
+	domain.LanguageRussian: {
+		"Subject:":                            "Тема:",
+		"Comment:":                            "Комментарий:",
+		"File:":                               "Файл:",
+		"Post":                                "Отправить",
+		"Reply":                               "Ответить",
+		"New thread":                          "Новый тред",
+		"Show my company":                     "Показать мою компанию",
+		"Only I can reply":                    "Отвечать могу только я",
+		"Moderation log":                      "Журнал модерации",
+		"Return":                              "Назад",
+		"Top":                                 "Вверх",
+		"Bottom":                              "Вниз",
+		"Gallery":                             "Галерея",
+		"Save snapshot":                       "Сохранить копию",
+		"Thread snapshots":                    "Сохранённые треды",
+		"Watch":                               "Следить",
+		"Unwatch":                             "Не следить",
+		"Thread map":                          "Карта треда",
+		"unread posts below":                  "ниже непрочитанные посты",
+		"%d post omitted.":                    "Пропущено постов: %d.",
+		"%d posts omitted.":                   "Пропущено постов: %d.",
+		"Only the thread's author can reply.": "Отвечать может только автор треда.",
+		"No threads on this board yet. Why not create one?":                "На этой доске пока нет тредов. Создайте первый!",
+		"This board is a read-only mirror of a board on another instance.": "Эта доска — зеркало доски с другого сервера, писать в неё нельзя.",
+	},
+	domain.LanguageJapanese: {
+		"Subject:":                            "題名:",
+		"Comment:":                            "本文:",
+		"File:":                               "ファイル:",
+		"Post":                                "投稿",
+		"Reply":                               "返信",
+		"New thread":                          "スレ立て",
+		"Show my company":                     "会社名を表示",
+		"Only I can reply":                    "自分だけが返信可能",
+		"Moderation log":                      "モデレーションログ",
+		"Return":                              "戻る",
+		"Top":                                 "上へ",
+		"Bottom":                              "下へ",
+		"Gallery":                             "ギャラリー",
+		"Save snapshot":                       "スナップショットを保存",
+		"Thread snapshots":                    "スレッドのスナップショット",
+		"Watch":                               "ウォッチ",
+		"Unwatch":                             "ウォッチ解除",
+		"Thread map":                          "スレッドマップ",
+		"unread posts below":                  "ここから未読",
+		"%d post omitted.":                    "%d件の投稿を省略。",
+		"%d posts omitted.":                   "%d件の投稿を省略。",
+		"Only the thread's author can reply.": "スレ主だけが返信できます。",
+		"No threads on this board yet. Why not create one?":                "この板にはまだスレッドがありません。最初のスレを立ててみませんか？",
+		"This board is a read-only mirror of a board on another instance.": "この板は他のインスタンスの板の読み取り専用ミラーです。",
+	},
+	domain.LanguageChinese: {
+		"Subject:":                            "标题:",
+		"Comment:":                            "内容:",
+		"File:":                               "文件:",
+		"Post":                                "发表",
+		"Reply":                               "回复",
+		"New thread":                          "发新帖",
+		"Show my company":                     "显示我的公司",
+		"Only I can reply":                    "仅我可以回复",
+		"Moderation log":                      "管理日志",
+		"Return":                              "返回",
+		"Top":                                 "顶部",
+		"Bottom":                              "底部",
+		"Gallery":                             "图库",
+		"Save snapshot":                       "保存快照",
+		"Thread snapshots":                    "帖子快照",
+		"Watch":                               "关注",
+		"Unwatch":                             "取消关注",
+		"Thread map":                          "帖子地图",
+		"unread posts below":                  "以下为未读回复",
+		"%d post omitted.":                    "省略了 %d 条回复。",
+		"%d posts omitted.":                   "省略了 %d 条回复。",
+		"Only the thread's author can reply.": "只有楼主可以回复。",
+		"No threads on this board yet. Why not create one?":                "本版还没有帖子，来发第一帖吧？",
+		"This board is a read-only mirror of a board on another instance.": "本版是其他站点版块的只读镜像。",
+	},
+	domain.LanguageKorean: {
+		"Subject:":                            "제목:",
+		"Comment:":                            "내용:",
+		"File:":                               "파일:",
+		"Post":                                "등록",
+		"Reply":                               "답글",
+		"New thread":                          "새 스레드",
+		"Show my company":                     "내 회사 표시",
+		"Only I can reply":                    "나만 답글 가능",
+		"Moderation log":                      "관리 기록",
+		"Return":                              "돌아가기",
+		"Top":                                 "위로",
+		"Bottom":                              "아래로",
+		"Gallery":                             "갤러리",
+		"Save snapshot":                       "스냅샷 저장",
+		"Thread snapshots":                    "스레드 스냅샷",
+		"Watch":                               "구독",
+		"Unwatch":                             "구독 해제",
+		"Thread map":                          "스레드 지도",
+		"unread posts below":                  "여기부터 읽지 않은 글",
+		"%d post omitted.":                    "%d개의 글이 생략되었습니다.",
+		"%d posts omitted.":                   "%d개의 글이 생략되었습니다.",
+		"Only the thread's author can reply.": "스레드 작성자만 답글을 달 수 있습니다.",
+		"No threads on this board yet. Why not create one?":                "이 게시판에는 아직 스레드가 없습니다. 첫 스레드를 만들어 보세요!",
+		"This board is a read-only mirror of a board on another instance.": "이 게시판은 다른 인스턴스 게시판의 읽기 전용 미러입니다.",
+	},
+}
+
+// dateLayouts are the exact timestamp formats of each language. Languages
+// without one use the English layout.
+var dateLayouts = map[domain.BoardLanguage]string{
+	domain.LanguageEnglish:  "Mon, 02 Jan 2006 15:04:05 MST",
+	domain.LanguageRussian:  "02.01.2006 15:04:05 MST",
+	domain.LanguageJapanese: "2006年01月02日 15:04:05 MST",
+	domain.LanguageChinese:  "2006年01月02日 15:04:05 MST",
+	domain.LanguageKorean:   "2006년 01월 02일 15:04:05 MST",
+}
+
+// T returns the translation of the English string s into language, or s
+// itself when there is none. With args, the result is used as a fmt format.
+func T(language domain.BoardLanguage, s string, args ...any) string {
+	if translated, ok := translations[language][s]; ok {
+		s = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(s, args...)
+	}
+	return s
+}
+
+// FormatTime renders t in loc as an exact timestamp in the date format of
+// language.
+func FormatTime(t time.Time, loc *time.Location, language domain.BoardLanguage) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	layout, ok := dateLayouts[language]
+	if !ok {
+		layout = dateLayouts[domain.LanguageEnglish]
+	}
+	return t.In(loc).Format(layout)
+}
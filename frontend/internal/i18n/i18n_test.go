@@ -0,0 +1,39 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "Комментарий:", T(domain.LanguageRussian, "Comment:"))
+	assert.Equal(t, "Comment:", T(domain.LanguageEnglish, "Comment:"))
+	assert.Equal(t, "Comment:", T("", "Comment:"), "empty language is English")
+	assert.Equal(t, "Log in", T(domain.LanguageJapanese, "Log in"), "missing strings stay English")
+	assert.Equal(t, "3 posts omitted.", T("", "%d posts omitted.", 3))
+	assert.Equal(t, "3件の投稿を省略。", T(domain.LanguageJapanese, "%d posts omitted.", 3))
+}
+
+func TestTranslationsComplete(t *testing.T) {
+	keys := translations[domain.LanguageRussian]
+	for language, strs := range translations {
+		assert.Len(t, strs, len(keys), language)
+		for key := range keys {
+			assert.Contains(t, strs, key, language)
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+	msk := time.FixedZone("MSK", 3*60*60)
+
+	assert.Equal(t, "Tue, 05 Mar 2024 14:07:09 UTC", FormatTime(ts, nil, ""))
+	assert.Equal(t, "05.03.2024 17:07:09 MSK", FormatTime(ts, msk, domain.LanguageRussian))
+	assert.Equal(t, "2024年03月05日 14:07:09 UTC", FormatTime(ts, time.UTC, domain.LanguageJapanese))
+	assert.Equal(t, "2024년 03월 05일 14:07:09 UTC", FormatTime(ts, time.UTC, domain.LanguageKorean))
+	assert.Equal(t, "Tue, 05 Mar 2024 14:07:09 UTC", FormatTime(ts, time.UTC, "xx"), "unknown language uses English")
+}
@@ -0,0 +1,33 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// linkOpenTag matches the opening tag of a message link made by formatMessageLink
+var linkOpenTag = regexp.MustCompile(`<a href="[^"]*" class="message-link message-link-preview"( data-board="[^"]*" data-message-id="(\d+)" data-thread-id="(\d+)")>`)
+
+// MarkDeadLinks turns the message links to dead targets into unclickable
+// "message-link-dead" links without previews, so quotes of deleted messages
+// don't lead to a 404. html must be rendered message text.
+func MarkDeadLinks(html string, dead []domain.MessageLink) string {
+	if len(dead) == 0 {
+		return html
+	}
+	isDead := make(map[domain.MessageLink]bool, len(dead))
+	for _, link := range dead {
+		isDead[link] = true
+	}
+	return linkOpenTag.ReplaceAllStringFunc(html, func(tag string) string {
+		m := linkOpenTag.FindStringSubmatch(tag)
+		msgId, err1 := strconv.ParseInt(m[2], 10, 64)
+		threadId, err2 := strconv.ParseInt(m[3], 10, 64)
+		if err1 != nil || err2 != nil || !isDead[domain.MessageLink{ThreadId: threadId, Id: msgId}] {
+			return tag
+		}
+		return `<a class="message-link message-link-dead"` + m[1] + `>`
+	})
+}
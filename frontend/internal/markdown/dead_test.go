@@ -0,0 +1,21 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkDeadLinks(t *testing.T) {
+	live := `<a href="/b/1#p2" class="message-link message-link-preview" data-board="b" data-message-id="2" data-thread-id="1">&gt;&gt;1#2</a>`
+	dead := `<a href="/b/7#p3" class="message-link message-link-preview" data-board="b" data-message-id="3" data-thread-id="7">&gt;&gt;7#3</a>`
+	text := live + "<br>" + dead + " and " + dead
+
+	got := MarkDeadLinks(text, []domain.MessageLink{{ThreadId: 7, Id: 3}})
+
+	marked := `<a class="message-link message-link-dead" data-board="b" data-message-id="3" data-thread-id="7">&gt;&gt;7#3</a>`
+	assert.Equal(t, live+"<br>"+marked+" and "+marked, got)
+	assert.Equal(t, text, MarkDeadLinks(text, nil))
+	assert.Equal(t, text, MarkDeadLinks(text, []domain.MessageLink{{ThreadId: 3, Id: 7}}), "thread and message ids are not swapped")
+}
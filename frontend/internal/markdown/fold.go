@@ -0,0 +1,36 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// quoteLine matches a line of rendered text holding nothing but message links
+	quoteLine   = regexp.MustCompile(`^(\s*<a href="[^"]*" class="message-link[^"]*"[^>]*>[^<]*</a>)+\s*$`)
+	messageLink = regexp.MustCompile(`<a href="[^"]*" class="message-link`)
+)
+
+// FoldQuotes collapses the message links a post opens with into a
+// "replying to N posts" toggle when there are at least minLinks of them, so
+// replies to many posts don't push their text below the fold. html must be
+// rendered message text; text without such a leading block is returned as is.
+func FoldQuotes(html string, minLinks int) string {
+	lines := strings.Split(html, "<br>")
+	n, links := 0, 0
+	for n < len(lines) && quoteLine.MatchString(lines[n]) {
+		links += len(messageLink.FindAllStringIndex(lines[n], -1))
+		n++
+	}
+	if minLinks <= 0 || links < minLinks {
+		return html
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<details class="quote-fold"><summary>replying to %d posts<span class="quote-fold-expand"> — expand</span></summary>`, links)
+	b.WriteString(strings.Join(lines[:n], "<br>"))
+	b.WriteString("</details>")
+	b.WriteString(strings.Join(lines[n:], "<br>"))
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFoldQuotes(t *testing.T) {
+	tp := New(&config.Public{MaxRepliesPerMessage: 50})
+	render := func(text string) string {
+		html, _, _, err := tp.ProcessMessage(domain.Message{
+			MessageMetadata: domain.MessageMetadata{Board: "b", ThreadId: 1, Id: 9},
+			Text:            text,
+		})
+		require.NoError(t, err)
+		return html
+	}
+
+	t.Run("folds a leading block of links", func(t *testing.T) {
+		html := render(">>1#1 >>1#2\n>>1#3\n>>2#4\nthanks all")
+		folded := FoldQuotes(html, 4)
+
+		assert.True(t, strings.HasPrefix(folded, `<details class="quote-fold"><summary>replying to 4 posts`))
+		assert.Equal(t, 1, strings.Count(folded, "</details>"))
+		assert.True(t, strings.HasSuffix(folded, "</details>thanks all"))
+		assert.Equal(t, 4, strings.Count(folded, `class="message-link`), "every link is kept")
+	})
+
+	t.Run("post of links only", func(t *testing.T) {
+		folded := FoldQuotes(render(">>1#1\n>>1#2"), 2)
+		assert.True(t, strings.HasSuffix(folded, "</details>"))
+	})
+
+	t.Run("below the threshold", func(t *testing.T) {
+		html := render(">>1#1 >>1#2\n>>1#3\nthanks")
+		assert.Equal(t, html, FoldQuotes(html, 4))
+	})
+
+	t.Run("links inside text are not counted", func(t *testing.T) {
+		html := render("see >>1#1 >>1#2 >>1#3 >>1#4 and >>1#5")
+		assert.Equal(t, html, FoldQuotes(html, 2))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		html := render(">>1#1\n>>1#2")
+		assert.Equal(t, html, FoldQuotes(html, 0))
+	})
+}
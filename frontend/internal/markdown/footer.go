@@ -0,0 +1,36 @@
+package markdown
+
+import (
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// footerLinkRegex matches [label](url) links to web pages or paths on the site.
+var footerLinkRegex = regexp.MustCompile(`\[([^\[\]]+)\]\((https?://[^\s()]+|/[^\s()]*)\)`)
+
+// RenderFooter converts the instance footer from the config to HTML. Besides
+// the inline formatting of posts it supports [label](url) links, which posts
+// do not have; every line of the text is a line of the footer.
+func (p *TextProcessor) RenderFooter(text string) template.HTML {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		var b strings.Builder
+		pos := 0
+		for _, m := range footerLinkRegex.FindAllStringSubmatchIndex(line, -1) {
+			b.WriteString(p.parseInline(line[pos:m[0]]))
+			b.WriteString(`<a href="` + escapeHTML(line[m[4]:m[5]]) + `" rel="noopener noreferrer">`)
+			b.WriteString(p.parseInline(line[m[2]:m[3]]))
+			b.WriteString("</a>")
+			pos = m[1]
+		}
+		b.WriteString(p.parseInline(line[pos:]))
+		lines[i] = b.String()
+	}
+	return template.HTML(strings.Join(lines, "<br>"))
+}
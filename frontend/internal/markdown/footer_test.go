@@ -0,0 +1,41 @@
+package markdown
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+)
+
+func TestRenderFooter(t *testing.T) {
+	tp := New(&config.Public{MaxRepliesPerMessage: 10})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected template.HTML
+	}{
+		{name: "empty", input: "  \n", expected: ""},
+		{name: "plain text is escaped", input: "<b>&</b>", expected: "&lt;b&gt;&amp;&lt;/b&gt;"},
+		{
+			name:     "link",
+			input:    "Content is [CC BY-SA 4.0](https://creativecommons.org/licenses/by-sa/4.0/).",
+			expected: `Content is <a href="https://creativecommons.org/licenses/by-sa/4.0/" rel="noopener noreferrer">CC BY-SA 4.0</a>.`,
+		},
+		{
+			name:     "site path and formatting",
+			input:    "**Rules:** [read *them*](/terms)",
+			expected: `<strong>Rules:</strong> <a href="/terms" rel="noopener noreferrer">read <em>them</em></a>`,
+		},
+		{name: "other schemes stay text", input: "[x](javascript:alert(1))", expected: "[x](javascript:alert(1))"},
+		{name: "quotes in urls are escaped", input: `[x](https://a.b/"onmouseover=)`, expected: `<a href="https://a.b/&quot;onmouseover=" rel="noopener noreferrer">x</a>`},
+		{name: "lines", input: "one\ntwo", expected: "one<br>two"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tp.RenderFooter(tt.input); got != tt.expected {
+				t.Errorf("RenderFooter(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/markup"
+)
+
+// FuzzProcessMessage checks that the parser never panics, only emits the markup
+// the sanitizer allows (so no '<' from the input survives unescaped) and keeps
+// reply links within the limit. The seeds run with go test; fuzz with
+//
+//	go test -fuzz=FuzzProcessMessage ./frontend/internal/markdown
+func FuzzProcessMessage(f *testing.F) {
+	seeds := []string{
+		"hello world",
+		"**bold** *italic* ***both*** ~~del~~ ||spoiler|| `code`",
+		"****", "******", "**a*b**c*", "*``*", "||**||**", // adjacent and interleaved markers
+		"```\ncode <script>\n```", "```unclosed\n<b>",
+		">greentext\n>>>not a link\n>>1#2",
+		">>1#2 >>1#2 >>3#4 >>99999999999999999999#1",
+		"<script>alert(1)</script>", `"><img src=x onerror=alert(1)>`, "&lt;b&gt; &amp;",
+		"line\r\nline\r\n\r\n\r\n\r\nline",
+		"\xff\xfe invalid utf8 \x00",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	const maxReplies = 3
+	tp := New(&config.Public{MaxRepliesPerMessage: maxReplies})
+
+	f.Fuzz(func(t *testing.T, text string) {
+		result, replies, _, err := tp.ProcessMessage(domain.Message{
+			MessageMetadata: domain.MessageMetadata{Board: "b", ThreadId: 1, Id: 2},
+			Text:            text,
+		})
+		if err != nil {
+			t.Fatalf("ProcessMessage returned error: %v", err)
+		}
+
+		if sanitized, changed := markup.Sanitize(result); changed {
+			t.Fatalf("output contains markup outside the allowlist:\n%q\n\nSanitized:\n%q", result, sanitized)
+		}
+
+		if len(replies) > maxReplies {
+			t.Fatalf("got %d replies, limit is %d", len(replies), maxReplies)
+		}
+		seen := make(map[string]bool)
+		for _, r := range replies {
+			key := fmt.Sprintf("%d#%d", r.ToThreadId, r.To)
+			if seen[key] {
+				t.Fatalf("duplicate reply %s", key)
+			}
+			seen[key] = true
+			if r.From != 2 || r.FromThreadId != 1 || r.Board != "b" {
+				t.Fatalf("reply %+v does not come from the processed message", r)
+			}
+			if !strings.Contains(result, fmt.Sprintf(`data-message-id="%d" data-thread-id="%d"`, r.To, r.ToThreadId)) {
+				t.Fatalf("reply %s has no link in the output", key)
+			}
+		}
+	})
+}
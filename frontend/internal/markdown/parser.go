@@ -61,8 +61,14 @@ type TextProcessor struct {
 	replyCount  int
 	seenReplies map[string]struct{}
 	hasPayload  bool
+	postNumber  PostNumberLookup
+	postNumbers map[string]domain.PostNumber // Lookup results, by link key
 }
 
+// PostNumberLookup returns the board-local post number of a message on the
+// board of the message being processed, or false if it is unknown.
+type PostNumberLookup func(threadId domain.ThreadId, msgId domain.MsgId) (domain.PostNumber, bool)
+
 // New creates a new TextProcessor
 func New(cfg *config.Public) *TextProcessor {
 	p := &TextProcessor{
@@ -155,11 +161,21 @@ func (p *TextProcessor) greentextRule() BlockRule {
 
 // ProcessMessage converts raw text to safe HTML
 func (p *TextProcessor) ProcessMessage(msg domain.Message) (string, domain.Replies, bool, error) {
+	return p.ProcessMessageWithPostNumbers(msg, nil)
+}
+
+// ProcessMessageWithPostNumbers works like ProcessMessage, but links read
+// >>N with the target's board-local post number from lookup. Links are still
+// written as >>thread#msg; targets beyond the reply limit and unknown targets
+// keep that form.
+func (p *TextProcessor) ProcessMessageWithPostNumbers(msg domain.Message, lookup PostNumberLookup) (string, domain.Replies, bool, error) {
 	p.currentMsg = &msg
 	p.replies = nil
 	p.replyCount = 0
 	p.seenReplies = make(map[string]struct{})
 	p.hasPayload = false
+	p.postNumber = lookup
+	p.postNumbers = make(map[string]domain.PostNumber)
 
 	text := strings.TrimSpace(msg.Text)
 	if text == "" {
@@ -380,15 +396,39 @@ func (p *TextProcessor) replaceLinks(text string) string {
 			}
 		}
 
-		return p.formatMessageLink(domain.ThreadId(threadId), domain.MsgId(msgId))
+		text := fmt.Sprintf("&gt;&gt;%d#%d", threadId, msgId)
+		if n, ok := p.lookupPostNumber(key, domain.ThreadId(threadId), domain.MsgId(msgId)); ok {
+			text = fmt.Sprintf("&gt;&gt;%d", n)
+		}
+		return p.formatMessageLink(domain.ThreadId(threadId), domain.MsgId(msgId), text)
 	})
 }
 
+// lookupPostNumber returns the post number of a link target. Only targets
+// counted as replies are looked up, which bounds the lookups per message.
+func (p *TextProcessor) lookupPostNumber(key string, threadId domain.ThreadId, msgId domain.MsgId) (domain.PostNumber, bool) {
+	if p.postNumber == nil {
+		return 0, false
+	}
+	if _, counted := p.seenReplies[key]; !counted {
+		return 0, false
+	}
+	if n, ok := p.postNumbers[key]; ok {
+		return n, n > 0
+	}
+	n, ok := p.postNumber(threadId, msgId)
+	if !ok {
+		n = 0
+	}
+	p.postNumbers[key] = n
+	return n, ok
+}
+
 // formatMessageLink generates HTML for a message link
-func (p *TextProcessor) formatMessageLink(threadId domain.ThreadId, msgId domain.MsgId) string {
+func (p *TextProcessor) formatMessageLink(threadId domain.ThreadId, msgId domain.MsgId, text string) string {
 	board := escapeHTML(string(p.currentMsg.Board))
-	return fmt.Sprintf(`<a href="/%s/%d#p%d" class="message-link message-link-preview" data-board="%s" data-message-id="%d" data-thread-id="%d">&gt;&gt;%d#%d</a>`,
-		p.currentMsg.Board, threadId, msgId, board, msgId, threadId, threadId, msgId)
+	return fmt.Sprintf(`<a href="/%s/%d#p%d" class="message-link message-link-preview" data-board="%s" data-message-id="%d" data-thread-id="%d">%s</a>`,
+		p.currentMsg.Board, threadId, msgId, board, msgId, threadId, text)
 }
 
 // escapeChar escapes a single character for HTML output
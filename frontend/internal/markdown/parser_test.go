@@ -6,6 +6,7 @@ import (
 
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/markup"
 )
 
 func TestRender(t *testing.T) {
@@ -595,6 +596,10 @@ func TestRender(t *testing.T) {
 			if hasPayload != tt.hasPayload {
 				t.Errorf("hasPayload = %v, want %v", hasPayload, tt.hasPayload)
 			}
+
+			if sanitized, changed := markup.Sanitize(result); changed {
+				t.Errorf("Sanitize changed parser output:\n%q\n\nGot:\n%q", result, sanitized)
+			}
 		})
 	}
 }
@@ -686,3 +691,49 @@ func TestReplyLimit(t *testing.T) {
 		t.Errorf("Expected 2 replies (limited), got %d", len(replies))
 	}
 }
+
+func TestPostNumberLinks(t *testing.T) {
+	cfg := &config.Public{
+		MaxRepliesPerMessage: 2,
+	}
+	tp := New(cfg)
+
+	msg := domain.Message{
+		MessageMetadata: domain.MessageMetadata{
+			Board:    "test",
+			ThreadId: 1,
+			Id:       1,
+		},
+		Text: ">>5#2 >>5#2 >>7#1 >>9#9",
+	}
+
+	lookups := 0
+	html, replies, _, err := tp.ProcessMessageWithPostNumbers(msg, func(threadId domain.ThreadId, msgId domain.MsgId) (domain.PostNumber, bool) {
+		lookups++
+		if threadId == 5 && msgId == 2 {
+			return 42, true
+		}
+		return 0, false
+	})
+	if err != nil {
+		t.Fatalf("ProcessMessageWithPostNumbers returned error: %v", err)
+	}
+
+	// Repeated links are looked up once, links past the reply limit not at all
+	if lookups != 2 {
+		t.Errorf("Expected 2 lookups, got %d", lookups)
+	}
+	if len(replies) != 2 {
+		t.Errorf("Expected 2 replies, got %d", len(replies))
+	}
+	want := `<a href="/test/5#p2" class="message-link message-link-preview" data-board="test" data-message-id="2" data-thread-id="5">&gt;&gt;42</a>`
+	if !strings.Contains(html, want) {
+		t.Errorf("Expected link with post number %q in %q", want, html)
+	}
+	if !strings.Contains(html, ">&gt;&gt;7#1</a>") || !strings.Contains(html, ">&gt;&gt;9#9</a>") {
+		t.Errorf("Expected unknown targets to keep thread#msg in %q", html)
+	}
+	if _, changed := markup.Sanitize(html); changed {
+		t.Errorf("Sanitize changed parser output %q", html)
+	}
+}
@@ -0,0 +1,34 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/markup"
+)
+
+func TestSanitizeKeepsParserOutput(t *testing.T) {
+	tp := New(&config.Public{MaxRepliesPerMessage: 10})
+
+	// Browsers submit \r\n line endings, the parser keeps the \r
+	inputs := []string{
+		"line one\r\nline two",
+		"```\r\ncode <b>\r\n```\r\nafter",
+		">quote\r\n>>1#2 link\r\n||spoiler|| & \"quotes\" 'single'",
+		"**bold *and* ~~del~~** `a<b`",
+		"broken \xff utf8",
+	}
+	for _, input := range inputs {
+		result, _, _, err := tp.ProcessMessage(domain.Message{
+			MessageMetadata: domain.MessageMetadata{Board: "b", ThreadId: 1, Id: 3},
+			Text:            input,
+		})
+		if err != nil {
+			t.Fatalf("ProcessMessage returned error: %v", err)
+		}
+		if sanitized, changed := markup.Sanitize(result); changed {
+			t.Errorf("Sanitize changed parser output:\n%q\n\nGot:\n%q", result, sanitized)
+		}
+	}
+}
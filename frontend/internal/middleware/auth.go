@@ -3,6 +3,7 @@ package middleware
 import (
 	"encoding/base64"
 	"net/http"
+	"net/url"
 
 	mw "github.com/itchan-dev/itchan/shared/middleware"
 )
@@ -55,13 +56,13 @@ func (w *authRedirectWriter) WriteHeader(statusCode int) {
 
 	if statusCode == http.StatusUnauthorized {
 		w.redirected = true
-		redirectToLogin(w.ResponseWriter, w.request, w.secureCookies, "Please log in to continue")
+		redirectToLogin(w.ResponseWriter, w.request, w.secureCookies, "Please log in to continue", true)
 		return
 	}
 
 	if statusCode == http.StatusForbidden {
 		w.redirected = true
-		redirectToLogin(w.ResponseWriter, w.request, w.secureCookies, "Access denied")
+		redirectToLogin(w.ResponseWriter, w.request, w.secureCookies, "Access denied", false)
 		return
 	}
 
@@ -75,7 +76,10 @@ func (w *authRedirectWriter) Write(data []byte) (int, error) {
 	return w.ResponseWriter.Write(data)
 }
 
-func redirectToLogin(w http.ResponseWriter, r *http.Request, secureCookies bool, errorMsg string) {
+// redirectToLogin sends the user to the login page with errorMsg. With
+// comeBack the login returns to the requested page; a user who was denied
+// access would only be denied again.
+func redirectToLogin(w http.ResponseWriter, r *http.Request, secureCookies bool, errorMsg string, comeBack bool) {
 	// Set flash error cookie (base64 encoded for safe storage of special characters)
 	encodedMessage := base64.StdEncoding.EncodeToString([]byte(errorMsg))
 	cookie := &http.Cookie{
@@ -89,7 +93,12 @@ func redirectToLogin(w http.ResponseWriter, r *http.Request, secureCookies bool,
 	}
 	http.SetCookie(w, cookie)
 
-	http.Redirect(w, r, "/login", http.StatusSeeOther)
+	// Pages come back after the login; forms can't be resubmitted that way
+	target := "/login"
+	if comeBack && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		target += "?" + url.Values{"next": {r.URL.RequestURI()}}.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
 }
 
 // wrapWithRedirect wraps any middleware to intercept auth errors
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectToLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		comeBack bool
+		want     string
+	}{
+		{"page comes back after login", http.MethodGet, true, "/login?next=%2Fb%2F12%3Fpage%3D2"},
+		{"form is not resubmitted", http.MethodPost, true, "/login"},
+		{"denied page is not retried", http.MethodGet, false, "/login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			redirectToLogin(w, httptest.NewRequest(tt.method, "/b/12?page=2", nil), false, "Please log in to continue", tt.comeBack)
+
+			if w.Code != http.StatusSeeOther {
+				t.Errorf("expected status %d, got %d", http.StatusSeeOther, w.Code)
+			}
+			if got := w.Header().Get("Location"); got != tt.want {
+				t.Errorf("expected redirect to %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
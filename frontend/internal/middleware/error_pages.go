@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+// ErrorPages wraps a shared middleware that answers with plain http.Error
+// responses, such as board access checks, so the errors it writes itself are
+// handed to render instead. Responses of the handlers behind it pass through
+// untouched.
+func ErrorPages(render func(http.ResponseWriter, *http.Request, error), inner func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			captured := &errorCaptureWriter{ResponseWriter: w}
+			passed := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				captured.passed = true
+				next.ServeHTTP(w, r)
+			})
+			inner(passed).ServeHTTP(captured, r)
+
+			if captured.status != 0 {
+				render(w, r, &internal_errors.ErrorWithStatusCode{
+					Message:    strings.TrimSpace(captured.body.String()),
+					StatusCode: captured.status,
+				})
+			}
+		})
+	}
+}
+
+// errorCaptureWriter holds back an error response of the wrapped middleware
+// until it returns. Anything else goes straight to the client.
+type errorCaptureWriter struct {
+	http.ResponseWriter
+	passed bool // The request reached the next handler
+	status int  // Error status held back, 0 if none
+	body   bytes.Buffer
+}
+
+func (w *errorCaptureWriter) WriteHeader(statusCode int) {
+	if !w.passed && statusCode >= http.StatusBadRequest {
+		w.status = statusCode
+		return
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *errorCaptureWriter) Write(data []byte) (int, error) {
+	if w.status != 0 {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
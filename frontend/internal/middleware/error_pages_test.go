@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+)
+
+func TestErrorPages(t *testing.T) {
+	// deny refuses requests without ?ok, like the board access check
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Has("ok") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, "Access restricted", http.StatusForbidden)
+		})
+	}
+
+	var rendered error
+	render := func(w http.ResponseWriter, r *http.Request, err error) {
+		rendered = err
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<h1>Access restricted</h1>"))
+	}
+	handler := ErrorPages(render, deny)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Thread not found", http.StatusNotFound)
+	}))
+
+	t.Run("renders errors of the wrapped middleware", func(t *testing.T) {
+		rendered = nil
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/b", nil))
+
+		var withStatus *internal_errors.ErrorWithStatusCode
+		if !errors.As(rendered, &withStatus) {
+			t.Fatalf("expected a status error to be rendered, got %v", rendered)
+		}
+		if withStatus.StatusCode != http.StatusForbidden || withStatus.Message != "Access restricted" {
+			t.Errorf("unexpected rendered error: %d %q", withStatus.StatusCode, withStatus.Message)
+		}
+		if w.Code != http.StatusForbidden || w.Body.String() != "<h1>Access restricted</h1>" {
+			t.Errorf("expected only the rendered page, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("leaves responses of the next handler alone", func(t *testing.T) {
+		rendered = nil
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/b?ok", nil))
+
+		if rendered != nil {
+			t.Errorf("expected nothing rendered, got %v", rendered)
+		}
+		if w.Code != http.StatusNotFound || w.Body.String() != "Thread not found\n" {
+			t.Errorf("expected the handler's response, got %d %q", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/jwt"
+	"github.com/itchan-dev/itchan/shared/logger"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+)
+
+// RefreshCookieName holds the refresh token of a "remember me" login
+const RefreshCookieName = "refresh_token"
+
+// SessionCookies writes the cookies of a login session.
+type SessionCookies struct {
+	AccessTTL  time.Duration // Lifetime of access tokens (jwt_ttl)
+	RefreshTTL time.Duration // Lifetime of refresh tokens (remember_me_ttl)
+	Secure     bool
+}
+
+// Set stores the tokens of a login. A remembered login, one with a refresh
+// token, survives browser restarts; otherwise the access token is a session
+// cookie.
+func (c SessionCookies) Set(w http.ResponseWriter, accessToken, refreshToken string) {
+	c.setAccess(w, accessToken, refreshToken != "")
+	if refreshToken != "" {
+		c.set(w, RefreshCookieName, refreshToken, int(c.RefreshTTL.Seconds()))
+	}
+}
+
+// Clear removes both cookies, logging the user out.
+func (c SessionCookies) Clear(w http.ResponseWriter) {
+	c.set(w, mw.CookieName, "", -1)
+	c.set(w, RefreshCookieName, "", -1)
+}
+
+func (c SessionCookies) setAccess(w http.ResponseWriter, accessToken string, persistent bool) {
+	maxAge := 0
+	if persistent {
+		maxAge = int(c.AccessTTL.Seconds())
+	}
+	c.set(w, mw.CookieName, accessToken, maxAge)
+}
+
+func (c SessionCookies) set(w http.ResponseWriter, name, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Path:     "/",
+		Name:     name,
+		Value:    value,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// SessionConfig holds configuration for the session renewal middleware.
+type SessionConfig struct {
+	Cookies    SessionCookies
+	RenewAfter time.Duration // Access tokens older than this are reissued
+	Jwt        jwt.JwtService
+	Renew      func(r *http.Request) (string, error)
+	Refresh    func(r *http.Request, refreshToken string) (accessToken, newRefreshToken string, err error)
+}
+
+// SessionRenewal keeps active users logged in. An access token older than
+// RenewAfter is reissued, so the session expires jwt_ttl after the last visit
+// rather than after the login. When the access token is gone or expired, a
+// refresh token left by a "remember me" login gets a new one. Either way the
+// rest of the request already sees the new token.
+func SessionRenewal(cfg SessionConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			refresh, _ := r.Cookie(RefreshCookieName)
+			remembered := refresh != nil && refresh.Value != ""
+
+			if access, err := r.Cookie(mw.CookieName); err == nil && access.Value != "" {
+				if token, err := cfg.Jwt.DecodeToken(access.Value); err == nil {
+					if issuedAt, _ := token.Claims.GetIssuedAt(); issuedAt != nil && time.Since(issuedAt.Time) > cfg.RenewAfter && !viewingAs(r) {
+						if renewed, err := cfg.Renew(r); err == nil {
+							cfg.Cookies.setAccess(w, renewed, remembered)
+							r = withAccessToken(r, renewed)
+						} else {
+							logger.Log.Debug("session renewal failed", "error", err)
+						}
+					}
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			if remembered {
+				accessToken, refreshToken, err := cfg.Refresh(r, refresh.Value)
+				switch {
+				case err == nil:
+					cfg.Cookies.Set(w, accessToken, refreshToken)
+					r = withAccessToken(r, accessToken)
+				case rejected(err):
+					// Expired, or the user was deleted or suspended
+					cfg.Cookies.set(w, RefreshCookieName, "", -1)
+				default:
+					logger.Log.Warn("session refresh failed", "error", err)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// viewingAs reports whether an admin is viewing the site as another user.
+// The admin's own token is left alone until the view-as session ends.
+func viewingAs(r *http.Request) bool {
+	c, err := r.Cookie(mw.ViewAsCookieName)
+	return err == nil && c.Value != ""
+}
+
+// rejected reports whether the backend turned a refresh token down, as
+// opposed to failing to answer.
+func rejected(err error) bool {
+	var withStatus *internal_errors.ErrorWithStatusCode
+	return errors.As(err, &withStatus) && withStatus.StatusCode < http.StatusInternalServerError
+}
+
+// withAccessToken replaces the access token cookie of r, for the middleware
+// and handlers after SessionRenewal and the backend calls they make.
+func withAccessToken(r *http.Request, token string) *http.Request {
+	cookies := r.Cookies()
+	r = r.Clone(r.Context())
+	r.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != mw.CookieName {
+			r.AddCookie(c)
+		}
+	}
+	r.AddCookie(&http.Cookie{Name: mw.CookieName, Value: token})
+	return r
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	internal_errors "github.com/itchan-dev/itchan/shared/errors"
+	"github.com/itchan-dev/itchan/shared/jwt"
+	mw "github.com/itchan-dev/itchan/shared/middleware"
+)
+
+func TestSessionRenewal(t *testing.T) {
+	jwtService := jwt.New("test_secret", time.Hour)
+	user := domain.User{Id: 1, EmailDomain: "example.com", CreatedAt: time.Now()}
+	accessToken, _ := jwtService.NewToken(user)
+	expiredToken, _ := jwt.New("test_secret", -time.Hour).NewToken(user)
+
+	tests := []struct {
+		name       string
+		cookies    []*http.Cookie
+		renewAfter time.Duration
+		refreshErr error
+		wantToken  string            // Access token the handler sees
+		wantSet    map[string]string // Cookies set on the response
+		wantMaxAge map[string]int    // MaxAge of the set cookies
+	}{
+		{
+			name:       "fresh token is kept",
+			cookies:    []*http.Cookie{{Name: mw.CookieName, Value: accessToken}},
+			renewAfter: time.Hour,
+			wantToken:  accessToken,
+		},
+		{
+			name:       "old token is renewed as a session cookie",
+			cookies:    []*http.Cookie{{Name: mw.CookieName, Value: accessToken}},
+			renewAfter: -time.Second,
+			wantToken:  "renewed",
+			wantSet:    map[string]string{mw.CookieName: "renewed"},
+			wantMaxAge: map[string]int{mw.CookieName: 0},
+		},
+		{
+			name:       "remembered token stays persistent",
+			cookies:    []*http.Cookie{{Name: mw.CookieName, Value: accessToken}, {Name: RefreshCookieName, Value: "refresh"}},
+			renewAfter: -time.Second,
+			wantToken:  "renewed",
+			wantSet:    map[string]string{mw.CookieName: "renewed"},
+			wantMaxAge: map[string]int{mw.CookieName: 3600},
+		},
+		{
+			name:       "view-as session is not renewed",
+			cookies:    []*http.Cookie{{Name: mw.CookieName, Value: accessToken}, {Name: mw.ViewAsCookieName, Value: "view-as"}},
+			renewAfter: -time.Second,
+			wantToken:  accessToken,
+		},
+		{
+			name:       "expired token is refreshed",
+			cookies:    []*http.Cookie{{Name: mw.CookieName, Value: expiredToken}, {Name: RefreshCookieName, Value: "refresh"}},
+			renewAfter: time.Hour,
+			wantToken:  "refreshed",
+			wantSet:    map[string]string{mw.CookieName: "refreshed", RefreshCookieName: "rotated"},
+			wantMaxAge: map[string]int{mw.CookieName: 3600, RefreshCookieName: 86400},
+		},
+		{
+			name:       "rejected refresh token is cleared",
+			cookies:    []*http.Cookie{{Name: RefreshCookieName, Value: "refresh"}},
+			renewAfter: time.Hour,
+			refreshErr: &internal_errors.ErrorWithStatusCode{Message: "Invalid refresh token", StatusCode: http.StatusUnauthorized},
+			wantSet:    map[string]string{RefreshCookieName: ""},
+			wantMaxAge: map[string]int{RefreshCookieName: -1},
+		},
+		{
+			name:       "refresh token is kept while the backend is down",
+			cookies:    []*http.Cookie{{Name: RefreshCookieName, Value: "refresh"}},
+			renewAfter: time.Hour,
+			refreshErr: errors.New("backend unavailable"),
+		},
+		{
+			name:       "no session",
+			renewAfter: time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := SessionConfig{
+				Cookies:    SessionCookies{AccessTTL: time.Hour, RefreshTTL: 24 * time.Hour},
+				RenewAfter: tt.renewAfter,
+				Jwt:        jwtService,
+				Renew:      func(r *http.Request) (string, error) { return "renewed", nil },
+				Refresh: func(r *http.Request, refreshToken string) (string, string, error) {
+					if tt.refreshErr != nil {
+						return "", "", tt.refreshErr
+					}
+					return "refreshed", "rotated", nil
+				},
+			}
+			var gotToken string
+			handler := SessionRenewal(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if c, err := r.Cookie(mw.CookieName); err == nil {
+					gotToken = c.Value
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/b", nil)
+			for _, c := range tt.cookies {
+				req.AddCookie(c)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if gotToken != tt.wantToken {
+				t.Errorf("handler saw access token %q, want %q", gotToken, tt.wantToken)
+			}
+			set := w.Result().Cookies()
+			if len(set) != len(tt.wantSet) {
+				t.Fatalf("expected %d cookies to be set, got %v", len(tt.wantSet), set)
+			}
+			for _, c := range set {
+				if want, ok := tt.wantSet[c.Name]; !ok || c.Value != want {
+					t.Errorf("cookie %s = %q, want %q", c.Name, c.Value, want)
+				}
+				if c.MaxAge != tt.wantMaxAge[c.Name] {
+					t.Errorf("cookie %s MaxAge = %d, want %d", c.Name, c.MaxAge, tt.wantMaxAge[c.Name])
+				}
+			}
+		})
+	}
+}
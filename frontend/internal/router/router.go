@@ -14,6 +14,7 @@ import (
 	"github.com/itchan-dev/itchan/frontend/internal/setup"
 	mw "github.com/itchan-dev/itchan/shared/middleware"
 	rl "github.com/itchan-dev/itchan/shared/middleware/ratelimiter"
+	"github.com/itchan-dev/itchan/shared/tracing"
 	sharedutils "github.com/itchan-dev/itchan/shared/utils"
 )
 
@@ -22,15 +23,33 @@ func SetupRouter(deps *setup.Dependencies) *chi.Mux {
 
 	r.Use(middleware.StripSlashes)
 
+	// Tag every request with an ID, shown on error pages and passed on to the backend
+	r.Use(middleware.RequestID)
+
+	// Request spans; calls to the backend continue them (no-op unless tracing is configured)
+	r.Use(tracing.Middleware)
+
 	r.Use(middleware.Compress(5))
 
-	frontendCSP := "default-src 'self'; " +
-		"style-src 'self' 'unsafe-inline'; " +
-		"img-src 'self' data: blob:; " +
-		"frame-ancestors 'none'; " +
-		"base-uri 'self'; " +
-		"form-action 'self'"
-	r.Use(mw.SecurityHeadersWithCSP(deps.Public.SecureCookies, frontendCSP))
+	// Pages only load the site's own scripts. Message markup contains no
+	// styles or external resources; inline styles are left to the templates.
+	pageHeaders := mw.SecurityHeadersConfig{
+		HTTPS: deps.Public.SecureCookies,
+		CSP: "default-src 'self'; " +
+			"script-src 'self'; " +
+			"style-src 'self' 'unsafe-inline'; " +
+			"img-src 'self' data: blob:; " +
+			"object-src 'none'; " +
+			"base-uri 'self'; " +
+			"form-action 'self'",
+		FrameAncestors: deps.Public.FrameAncestors,
+		ReferrerPolicy: deps.Public.ReferrerPolicy,
+	}
+	r.Use(mw.SecurityHeaders(pageHeaders))
+
+	// Uploads opened directly must not run anything
+	mediaHeaders := pageHeaders
+	mediaHeaders.CSP = "default-src 'none'; img-src 'self'; media-src 'self'"
 
 	if deps.Public.CSRFEnabled {
 		r.Use(frontend_mw.GenerateCSRFToken(frontend_mw.CSRFConfig{
@@ -50,6 +69,15 @@ func SetupRouter(deps *setup.Dependencies) *chi.Mux {
 	// Referral tracking middleware: captures ?ref= param into cookie on first visit
 	r.Use(frontend_mw.ReferralTracking(referralCfg))
 
+	// Renews the access token of active users and restores remembered logins
+	r.Use(frontend_mw.SessionRenewal(frontend_mw.SessionConfig{
+		Cookies:    deps.Handler.SessionCookies(),
+		RenewAfter: deps.Public.JwtRenewAfter,
+		Jwt:        deps.Jwt,
+		Renew:      deps.Handler.APIClient.RenewSession,
+		Refresh:    deps.Handler.APIClient.RefreshSession,
+	}))
+
 	// Health check endpoint (no auth required)
 	// Support both GET and HEAD for health checks (wget --spider uses HEAD)
 	healthHandler := func(w http.ResponseWriter, r *http.Request) {
@@ -60,15 +88,25 @@ func SetupRouter(deps *setup.Dependencies) *chi.Mux {
 	r.Head("/health", healthHandler)
 
 	// Public routes (GET endpoints - no rate limiting needed)
-	r.Get("/favicon.ico", handler.FaviconHandler)
-	r.With(frontend_mw.TrackReferralAction("get_login", referralCfg)).Get("/login", deps.Handler.LoginGetHandler)
-	r.With(frontend_mw.TrackReferralAction("get_register", referralCfg)).Get("/register", deps.Handler.RegisterGetHandler)
-	r.With(frontend_mw.TrackReferralAction("get_register_invite", referralCfg)).Get("/register_invite", deps.Handler.RegisterInviteGetHandler)
-	r.With(frontend_mw.TrackReferralAction("get_check_confirmation_code", referralCfg)).Get("/check_confirmation_code", deps.Handler.ConfirmEmailGetHandler)
+	r.Handle("/favicon.ico", deps.Assets.Handler(http.NotFoundHandler()))
+	r.Handle("/sw.js", deps.Assets.Handler(http.NotFoundHandler())) // at the root so its scope is the whole site
+	r.Get("/offline", deps.Handler.OfflineGetHandler)
+	r.Get("/sitemap.xml", handler.SitemapHandler(deps.Sitemap))
+	r.Get("/robots.txt", handler.RobotsHandler(deps.Sitemap))
 
 	// Create frontend auth middleware wrapper (needed for optional auth routes below)
 	authMw := frontend_mw.NewAuth(deps.AuthMiddleware, deps.Public.SecureCookies)
 
+	// Auth pages (optional auth, so the header shows who is logged in)
+	r.Group(func(authPages chi.Router) {
+		authPages.Use(authMw.OptionalAuth())
+		authPages.With(frontend_mw.TrackReferralAction("get_login", referralCfg)).Get("/login", deps.Handler.LoginGetHandler)
+		authPages.With(frontend_mw.TrackReferralAction("get_register", referralCfg)).Get("/register", deps.Handler.RegisterGetHandler)
+		authPages.With(frontend_mw.TrackReferralAction("get_register_invite", referralCfg)).Get("/register_invite", deps.Handler.RegisterInviteGetHandler)
+		authPages.With(frontend_mw.TrackReferralAction("get_check_confirmation_code", referralCfg)).Get("/check_confirmation_code", deps.Handler.ConfirmEmailGetHandler)
+		authPages.Get("/appeal", deps.Handler.AppealGetHandler)
+	})
+
 	// Public routes with optional auth (shows user info if logged in)
 	r.Group(func(optionalAuthRouter chi.Router) {
 		optionalAuthRouter.Use(authMw.OptionalAuth())
@@ -78,24 +116,46 @@ func SetupRouter(deps *setup.Dependencies) *chi.Mux {
 		optionalAuthRouter.Get("/terms", deps.Handler.TermsGetHandler)
 		optionalAuthRouter.Get("/privacy", deps.Handler.PrivacyGetHandler)
 		optionalAuthRouter.Get("/contacts", deps.Handler.ContactsGetHandler)
+		optionalAuthRouter.Get("/digest/unsubscribe", deps.Handler.DigestUnsubscribeGetHandler) // Link in digest emails
+		optionalAuthRouter.Get("/sessions/revoke", deps.Handler.SessionsRevokeGetHandler)       // Link in new device emails
 	})
 
 	// Public board reading routes (optional auth, board access restricted to public boards for anon users)
 	r.Group(func(publicBoard chi.Router) {
 		publicBoard.Use(authMw.OptionalAuth())
-		publicBoard.Use(mw.RestrictBoardAccess(deps.AccessData))
+		publicBoard.Use(mw.RedirectRenamedBoards(deps.AccessData))
+		publicBoard.Use(frontend_mw.ErrorPages(deps.Handler.RenderError, mw.RestrictBoardAccess(deps.AccessData, deps.Public.HideRestrictedBoards)))
 		publicBoard.Use(mw.RateLimit(rl.Rps10(), mw.GetIP))
 
-		mediaPath := deps.Handler.MediaPath
-		publicBoard.Handle("/media/{board}/*", http.StripPrefix("/media/", noDirectoryListing(http.FileServer(http.Dir(mediaPath)))))
+		publicBoard.Group(func(media chi.Router) {
+			media.Use(mw.SecurityHeaders(mediaHeaders))
+			mediaPath := deps.Handler.MediaPath
+			media.Get("/media/sha256/{file}", deps.Handler.HashedMediaHandler)
+			media.Handle("/media/{board}/*", http.StripPrefix("/media/", noDirectoryListing(http.FileServer(http.Dir(mediaPath)))))
+		})
 
 		publicBoard.Get("/", deps.Handler.IndexGetHandler)
 		publicBoard.Get("/{board}", deps.Handler.BoardGetHandler)
+		publicBoard.Get("/{board}/modlog", deps.Handler.ModLogGetHandler)
+		publicBoard.Get("/{board}/snapshots", deps.Handler.ThreadSnapshotsGetHandler)
+		publicBoard.Get("/{board}/snapshots/{snapshot}", deps.Handler.ThreadSnapshotGetHandler)
+		publicBoard.Get("/{board}/custom.css", deps.Handler.BoardCustomCSSHandler)
 		publicBoard.With(frontend_mw.TrackReferralAction("get_thread", referralCfg)).Get("/{board}/{thread}", deps.Handler.ThreadGetHandler)
+		publicBoard.Get("/{board}/{thread}/gallery", deps.Handler.ThreadGalleryGetHandler)
+
+		// oEmbed provider for thread link previews
+		publicBoard.Get("/oembed", deps.Handler.OEmbedHandler)
 
 		// API proxy for message preview (JSON and HTML)
 		publicBoard.Get("/api-proxy/v1/{board}/{thread}/{message}", deps.Handler.MessagePreviewHandler)
 		publicBoard.Get("/api-proxy/v1/{board}/{thread}/{message}/html", deps.Handler.MessagePreviewHTMLHandler)
+		publicBoard.Get("/api-proxy/v1/{board}/resolve/{postNumber}", deps.Handler.PostResolveHandler)
+
+		// New posts for thread auto-refresh
+		publicBoard.Get("/api-proxy/v1/{board}/{thread}/updates", deps.Handler.ThreadUpdatesHandler)
+
+		// Reply graph for the thread map
+		publicBoard.Get("/api-proxy/v1/{board}/{thread}/graph", deps.Handler.ThreadGraphHandler)
 	})
 
 	// Flash redirect handler for rate-limited POST routes
@@ -110,10 +170,13 @@ func SetupRouter(deps *setup.Dependencies) *chi.Mux {
 		publicPosts.Group(func(publicPostsEmail chi.Router) {
 			publicPostsEmail.Use(mw.RateLimitWithHandler(rl.New(5.0/60.0, 5, 1*time.Hour), mw.GetEmailFromForm, onRateLimitExceeded)) // 5 attempts per minute by email
 			publicPostsEmail.Post("/login", deps.Handler.LoginPostHandler)
+			publicPostsEmail.Post("/appeal", deps.Handler.AppealPostHandler)
 			publicPostsEmail.Post("/register", deps.Handler.RegisterPostHandler)
 			publicPostsEmail.With(frontend_mw.TrackReferralAction("registration", referralCfg)).Post("/check_confirmation_code", deps.Handler.ConfirmEmailPostHandler)
 		})
 
+		publicPosts.Post("/sessions/revoke", deps.Handler.SessionsRevokePostHandler)
+
 		// Using invite_code field
 		publicPosts.Group(func(publicPostsInvite chi.Router) {
 			publicPostsInvite.Use(mw.RateLimitWithHandler(rl.New(5.0/60.0, 5, 1*time.Hour), mw.GetFieldFromForm("invite_code"), onRateLimitExceeded)) // 5 attempts per minute by each invite code
@@ -121,8 +184,19 @@ func SetupRouter(deps *setup.Dependencies) *chi.Mux {
 		})
 	})
 
-	fileServer := http.FileServer(http.Dir("static"))
-	r.Handle("/static/*", http.StripPrefix("/static/", cacheStaticFiles(fileServer, deps.Public.StaticCacheMaxAge)))
+	// Files an operator drops into the static directory (e.g. invites.txt)
+	// are served from disk alongside the compiled-in ones
+	diskFiles := cacheStaticFiles(noDirectoryListing(http.FileServer(http.Dir("static"))), deps.Public.StaticCacheMaxAge)
+	r.Handle("/static/*", http.StripPrefix("/static", deps.Assets.Handler(diskFiles)))
+
+	// Leaving view-as mode has to work while the view-as token makes every
+	// authenticated request read-only, so it sits outside the auth groups
+	r.Group(func(viewAsRouter chi.Router) {
+		if deps.Public.CSRFEnabled {
+			viewAsRouter.Use(frontend_mw.ValidateCSRFToken())
+		}
+		viewAsRouter.Post("/view_as/stop", deps.Handler.StopViewAsHandler)
+	})
 
 	// Admin-only routes (register before generic path patterns to avoid conflicts)
 	r.Group(func(adminRouter chi.Router) {
@@ -134,17 +208,48 @@ func SetupRouter(deps *setup.Dependencies) *chi.Mux {
 
 		adminRouter.Get("/admin", deps.Handler.AdminGetHandler)
 		adminRouter.Post("/admin/unblacklist", deps.Handler.UnblacklistUserHandler)
+		adminRouter.Post("/admin/users/lookup", deps.Handler.LookupUserHandler)
 		adminRouter.Post("/blacklist/user", deps.Handler.BlacklistUserHandler)
+		adminRouter.Post("/admin/shadowban", deps.Handler.ShadowbanUserHandler)
+		adminRouter.Post("/admin/unshadowban", deps.Handler.UnshadowbanUserHandler)
+		adminRouter.Post("/admin/view_as", deps.Handler.StartViewAsHandler)
+		adminRouter.Post("/admin/appeals/{appealId}/accept", deps.Handler.AcceptAppealHandler)
+		adminRouter.Post("/admin/appeals/{appealId}/deny", deps.Handler.DenyAppealHandler)
+		adminRouter.Post("/admin/categories", deps.Handler.CreateBoardCategoryHandler)
+		adminRouter.Post("/admin/categories/{categoryId}/update", deps.Handler.UpdateBoardCategoryHandler)
+		adminRouter.Post("/admin/categories/{categoryId}/delete", deps.Handler.DeleteBoardCategoryHandler)
+		adminRouter.Post("/admin/board-category", deps.Handler.SetBoardCategoryHandler)
+		adminRouter.Post("/admin/board-settings", deps.Handler.UpdateBoardSettingsHandler)
+		adminRouter.Get("/admin/boards/{board}/appearance", deps.Handler.AdminBoardAppearanceHandler)
+		adminRouter.Get("/admin/boards/{board}/posters", deps.Handler.AdminBoardPostersHandler)
+		adminRouter.Get("/admin/boards/{board}/premod", deps.Handler.AdminPremodQueueHandler)
+		adminRouter.Post("/admin/boards/{board}/premod/{postId}/approve", deps.Handler.PremodApproveHandler)
+		adminRouter.Post("/admin/boards/{board}/premod/{postId}/reject", deps.Handler.PremodRejectHandler)
+		adminRouter.Post("/admin/boards/{board}/premod/trust", deps.Handler.PremodTrustHandler)
+		adminRouter.Post("/admin/boards/{board}/custom-css", deps.Handler.SetBoardCustomCSSHandler)
+		adminRouter.Post("/admin/boards/{board}/banners", deps.Handler.UploadBoardBannerHandler)
+		adminRouter.Post("/admin/boards/{board}/banners/{bannerId}/delete", deps.Handler.DeleteBoardBannerHandler)
+		adminRouter.Post("/admin/boards/{board}/restore", deps.Handler.RestoreBoardHandler)
+		adminRouter.Post("/admin/boards/{board}/rename", deps.Handler.RenameBoardHandler)
+		adminRouter.Post("/admin/mirrors", deps.Handler.CreateBoardMirrorHandler)
+		adminRouter.Post("/admin/boards/{board}/mirror/delete", deps.Handler.DeleteBoardMirrorHandler)
+		adminRouter.Post("/admin/boards/{board}/mirror/sync", deps.Handler.SyncBoardMirrorHandler)
+		adminRouter.Post("/admin/users/import", deps.Handler.ImportUsersHandler)
+		adminRouter.Post("/admin/maintenance", deps.Handler.ScheduleMaintenanceHandler)
+		adminRouter.Post("/admin/maintenance/cancel", deps.Handler.CancelMaintenanceHandler)
 		adminRouter.Post("/{board}/delete", deps.Handler.BoardDeleteHandler)
+		adminRouter.Post("/{board}/snapshots/{snapshot}/delete", deps.Handler.ThreadSnapshotDeleteHandler)
 		adminRouter.Post("/{board}/{thread}/delete", deps.Handler.ThreadDeleteHandler)
 		adminRouter.Post("/{board}/{thread}/pin", deps.Handler.ThreadTogglePinnedHandler)
+		adminRouter.Post("/{board}/{thread}/op-only", deps.Handler.ThreadToggleOpOnlyHandler)
 		adminRouter.Post("/{board}/{thread}/{message}/delete", deps.Handler.MessageDeleteHandler)
 	})
 
 	// Authenticated routes (write operations and user-specific pages)
 	r.Group(func(authRouter chi.Router) {
 		authRouter.Use(authMw.NeedAuth())
-		authRouter.Use(mw.RestrictBoardAccess(deps.AccessData)) // Enforce board access restrictions
+		authRouter.Use(mw.RedirectRenamedBoards(deps.AccessData))
+		authRouter.Use(mw.RestrictBoardAccess(deps.AccessData, deps.Public.HideRestrictedBoards)) // Enforce board access restrictions
 		authRouter.Use(mw.RateLimit(rl.Rps100(), mw.GetUserIDFromContext))
 
 		if deps.Public.CSRFEnabled {
@@ -152,6 +257,9 @@ func SetupRouter(deps *setup.Dependencies) *chi.Mux {
 		}
 
 		authRouter.Get("/settings/disable-media", deps.Handler.ToggleDisableMedia)
+		authRouter.Post("/settings/timezone", deps.Handler.SetTimezone)
+		authRouter.Post("/settings/infinite-scroll", deps.Handler.SetInfiniteScroll)
+		authRouter.Post("/settings/low-bandwidth", deps.Handler.SetLowBandwidth)
 
 		authRouter.Post("/", deps.Handler.IndexPostHandler)
 		authRouter.HandleFunc("/logout", deps.Handler.LogoutHandler)
@@ -164,12 +272,38 @@ func SetupRouter(deps *setup.Dependencies) *chi.Mux {
 
 		// Account page
 		authRouter.Get("/account", deps.Handler.AccountGetHandler)
+		authRouter.Get("/account/posts", deps.Handler.UserPostsGetHandler)
+		authRouter.Get("/account/bookmarks", deps.Handler.BookmarksGetHandler)
+		authRouter.Post("/account/bookmarks/{board}/{thread}/{message}/delete", deps.Handler.BookmarkDeleteHandler)
+
+		// Notification center (registered before /{board} like the invite routes)
+		authRouter.Get("/notifications", deps.Handler.NotificationsGetHandler)
+		authRouter.Post("/notifications/read", deps.Handler.NotificationsReadAllPostHandler)
+		authRouter.Post("/notifications/{id}/read", deps.Handler.NotificationReadPostHandler)
+		authRouter.Post("/notifications/digest", deps.Handler.DigestPostHandler)
+		authRouter.Post("/notifications/push", deps.Handler.PushPostHandler)
+
+		// Posting cooldowns for the countdown on post forms
+		authRouter.Get("/api-proxy/v1/me/limits", deps.Handler.PostLimitsHandler)
+
+		// Files the post forms upload while the user is still typing
+		authRouter.Post("/api-proxy/v1/{board}/uploads", deps.Handler.UploadProxyHandler)
+
+		// Read position over posts the thread auto-refresh appended
+		authRouter.Post("/api-proxy/v1/{board}/{thread}/read", deps.Handler.ThreadReadProxyHandler)
 
 		// Board write routes
 		authRouter.With(mw.RateLimitWithHandler(rl.OncePerMinute(), mw.GetUserIDFromContext, onRateLimitExceeded)).Post("/{board}", deps.Handler.BoardPostHandler)
 		authRouter.With(mw.RateLimitWithHandler(rl.OncePerSecond(), mw.GetUserIDFromContext, onRateLimitExceeded)).Post("/{board}/{thread}", deps.Handler.ThreadPostHandler)
+		authRouter.Post("/{board}/{thread}/watch", deps.Handler.ThreadWatchPostHandler)
+		authRouter.With(mw.RateLimitWithHandler(rl.OncePerMinute(), mw.GetUserIDFromContext, onRateLimitExceeded)).Post("/{board}/{thread}/snapshot", deps.Handler.ThreadSnapshotPostHandler)
+		authRouter.Post("/{board}/{thread}/successor", deps.Handler.ThreadSuccessorPostHandler)
+		authRouter.Post("/{board}/{thread}/{message}/delete-own", deps.Handler.OwnMessageDeleteHandler)
+		authRouter.Post("/{board}/{thread}/{message}/bookmark", deps.Handler.BookmarkPostHandler)
 	})
 
+	r.NotFound(deps.Handler.NotFoundHandler)
+
 	return r
 }
 
@@ -4,15 +4,20 @@ import (
 	"context"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/itchan-dev/itchan/frontend"
 	"github.com/itchan-dev/itchan/frontend/internal/apiclient"
+	"github.com/itchan-dev/itchan/frontend/internal/assets"
 	frontend_domain "github.com/itchan-dev/itchan/frontend/internal/domain"
 	"github.com/itchan-dev/itchan/frontend/internal/handler"
+	"github.com/itchan-dev/itchan/frontend/internal/i18n"
 	"github.com/itchan-dev/itchan/frontend/internal/markdown"
 	"github.com/itchan-dev/itchan/shared/blacklist"
 	"github.com/itchan-dev/itchan/shared/config"
@@ -20,12 +25,14 @@ import (
 	"github.com/itchan-dev/itchan/shared/logger"
 	"github.com/itchan-dev/itchan/shared/middleware"
 	"github.com/itchan-dev/itchan/shared/middleware/board_access"
+	"github.com/itchan-dev/itchan/shared/sitemap"
 	"github.com/itchan-dev/itchan/shared/storage"
 )
 
 const (
 	baseTemplate           = "base.html"
 	tmplPath               = "./templates"
+	staticPath             = "./static"
 	templateReloadInterval = 5 * time.Second
 	apiBaseURL             = "http://api:8080"
 )
@@ -38,7 +45,9 @@ type Dependencies struct {
 	Storage        *storage.Storage
 	AccessData     *board_access.BoardAccess
 	BlacklistCache *blacklist.Cache
+	Sitemap        *sitemap.Cache
 	AuthMiddleware *middleware.Auth
+	Assets         *assets.Assets
 	CancelFunc     context.CancelFunc
 }
 
@@ -53,12 +62,18 @@ func SetupDependencies(cfg *config.Config) (*Dependencies, error) {
 		return nil, fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	// Initialize board access data with background updates
-	accessData := board_access.New()
-	accessData.StartBackgroundUpdate(ctx, 1*time.Minute, store)
+	// Board access rules, reloaded from the database once they are older than the TTL
+	accessData := board_access.NewCache(store, cfg.Public.BoardAccessCacheTTL)
+
+	static, err := loadAssets(cfg.Public.StaticCacheMaxAge)
+	if err != nil {
+		cancel()
+		store.Cleanup()
+		return nil, err
+	}
 
 	// Load templates and other dependencies
-	templates := mustLoadTemplates(tmplPath)
+	templates := mustLoadTemplates(tmplPath, static)
 	textProcessor := markdown.New(&cfg.Public)
 	apiClient := apiclient.New(apiBaseURL)
 
@@ -69,7 +84,7 @@ func SetupDependencies(cfg *config.Config) (*Dependencies, error) {
 	}
 
 	h := handler.New(templates, cfg.Public, textProcessor, apiClient, mediaPath)
-	startTemplateReloader(ctx, h, tmplPath)
+	startTemplateReloader(ctx, h, tmplPath, static)
 
 	jwtService := jwt.New(cfg.JwtKey(), cfg.JwtTTL())
 
@@ -88,6 +103,14 @@ func SetupDependencies(cfg *config.Config) (*Dependencies, error) {
 	interval := time.Duration(cfg.Public.BlacklistCacheInterval) * time.Second
 	blacklistCache.StartBackgroundUpdate(ctx, interval)
 
+	// Sitemap is regenerated in the background; a failed first build only
+	// leaves it empty until the next run.
+	sitemapCache := sitemap.NewCache(store)
+	if err := sitemapCache.Update(); err != nil {
+		logger.Log.Error("failed to build initial sitemap", "error", err)
+	}
+	sitemapCache.StartBackgroundUpdate(ctx, cfg.Public.SitemapRefreshInterval)
+
 	// Create auth middleware
 	secureCookies := cfg.Public.SecureCookies
 	authMiddleware := middleware.NewAuth(jwtService, blacklistCache, secureCookies)
@@ -100,7 +123,9 @@ func SetupDependencies(cfg *config.Config) (*Dependencies, error) {
 		Storage:        store,
 		AccessData:     accessData,
 		BlacklistCache: blacklistCache,
+		Sitemap:        sitemapCache,
 		AuthMiddleware: authMiddleware,
+		Assets:         static,
 		CancelFunc:     cancel,
 	}, nil
 }
@@ -112,10 +137,20 @@ func bytesToMB(bytes int64) int64 {
 	return bytes / (1024 * 1024)
 }
 
+// formatSize renders a size in bytes as KB or MB, rounded down.
+func formatSize(bytes int64) string {
+	if bytes >= 1024*1024 {
+		return fmt.Sprintf("%d MB", bytes/(1024*1024))
+	}
+	return fmt.Sprintf("%d KB", bytes/1024)
+}
+
 func mimeTypeExtensions(mimeTypes []string) string {
 	var exts []string
 	for _, mime := range mimeTypes {
-		if parts := strings.SplitN(mime, "/", 2); len(parts) == 2 {
+		if mime == "text/plain" {
+			exts = append(exts, "txt")
+		} else if parts := strings.SplitN(mime, "/", 2); len(parts) == 2 {
 			exts = append(exts, parts[1])
 		}
 	}
@@ -153,8 +188,43 @@ func thumbDims(imgWidth, imgHeight *int, maxSize int) map[string]int {
 	return map[string]int{"W": w * maxSize / h, "H": maxSize}
 }
 
-func formatAcceptMimeTypes(images, videos []string) string {
-	return strings.Join(append(images, videos...), ",")
+func formatAcceptMimeTypes(mimeLists ...[]string) string {
+	return strings.Join(slices.Concat(mimeLists...), ",")
+}
+
+// formatTime renders t in loc as an exact, human-readable timestamp.
+func formatTime(t time.Time, loc *time.Location) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format("Mon, 02 Jan 2006 15:04:05 MST")
+}
+
+// relativeTime renders how long ago t was ("4 minutes ago"). Timestamps older than
+// a month fall back to a date in loc. main.js uses the same wording to keep the
+// rendered values fresh without a reload.
+func relativeTime(t time.Time, loc *time.Location) string {
+	d := time.Since(t)
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format("02 Jan 2006")
 }
 
 var functionMap template.FuncMap = template.FuncMap{
@@ -165,13 +235,38 @@ var functionMap template.FuncMap = template.FuncMap{
 		return &frontend_domain.PostData{Message: msg, Common: &common}
 	},
 	"bytesToMB":             bytesToMB,
+	"formatSize":            formatSize,
 	"mimeTypeExtensions":    mimeTypeExtensions,
 	"formatAcceptMimeTypes": formatAcceptMimeTypes,
 	"thumbDims":             thumbDims,
 	"join":                  strings.Join,
+	"formatTime":            formatTime,
+	"relativeTime":          relativeTime,
+	"t":                     i18n.T,
+	"formatBoardTime":       i18n.FormatTime,
 }
 
-func mustLoadTemplates(tmplPath string) map[string]*template.Template {
+// loadAssets hashes the static files compiled into the binary, or the ones
+// on disk in development so edits show up without a rebuild.
+func loadAssets(maxAge time.Duration) (*assets.Assets, error) {
+	var fsys fs.FS = os.DirFS(staticPath)
+	if os.Getenv("ENV") != "development" {
+		sub, err := fs.Sub(frontend.Static, "static")
+		if err != nil {
+			return nil, fmt.Errorf("failed to open embedded static files: %w", err)
+		}
+		fsys = sub
+	}
+	static, err := assets.New(fsys, maxAge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load static files: %w", err)
+	}
+	return static, nil
+}
+
+func mustLoadTemplates(tmplPath string, static *assets.Assets) map[string]*template.Template {
+	assetFuncs := template.FuncMap{"asset": static.Path}
+
 	templates := make(map[string]*template.Template)
 	files, err := os.ReadDir(tmplPath)
 	if err != nil {
@@ -182,6 +277,7 @@ func mustLoadTemplates(tmplPath string) map[string]*template.Template {
 	templates["partials"] = template.Must(
 		template.New("partials.html").
 			Funcs(functionMap).
+			Funcs(assetFuncs).
 			ParseFiles(
 				path.Join(tmplPath, "partials.html"),
 			),
@@ -192,6 +288,7 @@ func mustLoadTemplates(tmplPath string) map[string]*template.Template {
 			templates[f.Name()] = template.Must(
 				template.New(baseTemplate).
 					Funcs(functionMap).
+					Funcs(assetFuncs).
 					ParseFiles(
 						path.Join(tmplPath, baseTemplate),
 						path.Join(tmplPath, f.Name()),
@@ -204,7 +301,7 @@ func mustLoadTemplates(tmplPath string) map[string]*template.Template {
 	return templates
 }
 
-func startTemplateReloader(ctx context.Context, h *handler.Handler, tmplPath string) {
+func startTemplateReloader(ctx context.Context, h *handler.Handler, tmplPath string, static *assets.Assets) {
 	if os.Getenv("ENV") == "development" {
 		ticker := time.NewTicker(templateReloadInterval)
 		go func() {
@@ -212,7 +309,10 @@ func startTemplateReloader(ctx context.Context, h *handler.Handler, tmplPath str
 			for {
 				select {
 				case <-ticker.C:
-					h.UpdateTemplates(mustLoadTemplates(tmplPath))
+					if err := static.Reload(); err != nil {
+						logger.Log.Error("failed to reload static files", "error", err)
+					}
+					h.UpdateTemplates(mustLoadTemplates(tmplPath, static))
 				case <-ctx.Done():
 					return
 				}
@@ -0,0 +1,9 @@
+// Package frontend holds the files compiled into the frontend binary.
+package frontend
+
+import "embed"
+
+// Static is the static directory as of the build; internal/assets serves it.
+//
+//go:embed static/css static/js static/icons static/favicon.ico static/manifest.json static/sw.js
+var Static embed.FS
@@ -29,6 +29,8 @@ class MessagePreview {
         this.previewHistory = [];
         this.navigationTimeout = 700;
         this.rootKey = null;
+        // Touch screens have no hover: previews open on the first tap instead
+        this.touchOnly = window.matchMedia('(hover: none)');
         this.init();
     }
 
@@ -38,6 +40,8 @@ class MessagePreview {
 
     setupEventListeners() {
         document.addEventListener('mouseover', (e) => {
+            // Taps emulate mouseover/mouseout, which would race the tap handling below
+            if (this.touchOnly.matches) return;
             if (e.target.classList.contains('message-link-preview')) {
                 this.handleLinkMouseOver(e.target);
             } else if (e.target.closest('.message-preview')) {
@@ -48,12 +52,21 @@ class MessagePreview {
         });
 
         document.addEventListener('mouseout', (e) => {
+            if (this.touchOnly.matches) return;
             if (e.target.classList.contains('message-link-preview') || e.target.closest('.message-preview') || e.target.closest('.popup-reply-container')) {
                 this.handleChainMouseOut();
             }
         });
 
         document.addEventListener('click', (e) => {
+            // On touch screens the first tap on a link opens its preview, a second one follows it
+            const link = e.target.closest('.message-link-preview');
+            if (link && this.touchOnly.matches && !this.previewIndexes.has(this.getKey(link))) {
+                e.preventDefault();
+                this.pruneAndCreatePreview(link);
+                return;
+            }
+
             let preview = e.target.closest('.message-preview');
             if (preview) {
                 this.hideAllSuccessors(preview.dataset.key);
@@ -354,6 +367,18 @@ function setupPopupReplySystem() {
     const formEl = popup.querySelector('form');
     const textareaEl = popup.querySelector('textarea');
 
+    // The popup partial is shared by every page; take the board-specific
+    // upload rules (e.g. PDF/text files) from the page's own file input
+    const pageFileInput = document.querySelector('#attachments-reply-bottom, #attachments');
+    const popupFileInput = popup.querySelector('input[type="file"]');
+    if (pageFileInput && popupFileInput) {
+        popupFileInput.accept = pageFileInput.accept;
+        const maxDocumentSize = pageFileInput.getAttribute(UploadPreviewManager.ATTR_MAX_DOCUMENT_SIZE);
+        if (maxDocumentSize) {
+            popupFileInput.setAttribute(UploadPreviewManager.ATTR_MAX_DOCUMENT_SIZE, maxDocumentSize);
+        }
+    }
+
     // Main click listener using event delegation
     document.body.addEventListener('click', (e) => {
         const replyLink = e.target.closest('.post-reply-popup-link');
@@ -433,6 +458,7 @@ class UploadPreviewManager {
     static ATTR_MAX_FILES = 'data-max-files';
     static ATTR_MAX_TOTAL_SIZE = 'data-max-total-size';
     static ATTR_MAX_FILE_SIZE = 'data-max-file-size';
+    static ATTR_MAX_DOCUMENT_SIZE = 'data-max-document-size';
     static ERROR_DISPLAY_DURATION = 5000; // ms
 
     constructor() {
@@ -532,6 +558,7 @@ class UploadPreviewManager {
         const maxFiles = parseInt(input.getAttribute(UploadPreviewManager.ATTR_MAX_FILES)) || 0;
         const maxTotalSize = parseInt(input.getAttribute(UploadPreviewManager.ATTR_MAX_TOTAL_SIZE)) || 0;
         const maxFileSize = parseInt(input.getAttribute(UploadPreviewManager.ATTR_MAX_FILE_SIZE)) || 0;
+        const maxDocumentSize = parseInt(input.getAttribute(UploadPreviewManager.ATTR_MAX_DOCUMENT_SIZE)) || 0;
 
         // Validate max files limit
         if (maxFiles > 0 && files.length > maxFiles) {
@@ -544,21 +571,27 @@ class UploadPreviewManager {
         }
 
         // Validate file sizes in a single pass (more efficient)
-        if (maxFileSize > 0 || maxTotalSize > 0) {
+        if (maxFileSize > 0 || maxTotalSize > 0 || maxDocumentSize > 0) {
             let totalSize = 0;
             const oversizedFiles = [];
+            const oversizedDocuments = [];
 
             for (const file of files) {
                 totalSize += file.size;
                 if (maxFileSize > 0 && file.size > maxFileSize) {
                     oversizedFiles.push(file);
+                } else if (maxDocumentSize > 0 && this.isDocument(file) && file.size > maxDocumentSize) {
+                    oversizedDocuments.push(file);
                 }
             }
 
-            // Check individual file size limit
-            if (oversizedFiles.length > 0) {
-                const maxSizeMB = (maxFileSize / (1024 * 1024)).toFixed(1);
-                const fileList = oversizedFiles.map(f => `"${f.name}" (${this.formatFileSize(f.size)})`).join(', ');
+            // Check individual file size limits
+            if (oversizedFiles.length > 0 || oversizedDocuments.length > 0) {
+                const [limit, oversized] = oversizedFiles.length > 0
+                    ? [maxFileSize, oversizedFiles]
+                    : [maxDocumentSize, oversizedDocuments];
+                const maxSizeMB = (limit / (1024 * 1024)).toFixed(1);
+                const fileList = oversized.map(f => `"${f.name}" (${this.formatFileSize(f.size)})`).join(', ');
                 this.showValidationError(
                     previewContainer,
                     `The following file(s) exceed the ${maxSizeMB} MB limit: ${fileList}`
@@ -650,6 +683,11 @@ class UploadPreviewManager {
             videoIcon.className = 'file-preview-icon';
             videoIcon.textContent = '🎬';
             fileItem.appendChild(videoIcon);
+        } else if (this.isDocument(file)) {
+            const documentIcon = document.createElement('span');
+            documentIcon.className = 'file-preview-icon';
+            documentIcon.textContent = '📄';
+            fileItem.appendChild(documentIcon);
         }
 
         const fileName = document.createElement('span');
@@ -677,6 +715,10 @@ class UploadPreviewManager {
         return fileItem;
     }
 
+    isDocument(file) {
+        return file.type === 'application/pdf' || file.type === 'text/plain';
+    }
+
     removeFile(input, previewContainer, fileId) {
         const fileMap = this.fileMaps.get(input);
         if (!fileMap || !fileMap.has(fileId)) return;
@@ -769,7 +811,697 @@ class UploadPreviewManager {
     }
 }
 
-// Handle reply hash for both page load and hash changes
+// Report the browser timezone to the server so timestamps render in local time.
+// An explicit choice on the account page (timezone cookie) takes precedence server-side.
+function syncBrowserTimezone() {
+    let tz;
+    try {
+        tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    } catch (e) {
+        return;
+    }
+    if (!tz) return;
+
+    const current = document.cookie.split('; ').find(c => c.startsWith('browser_tz='));
+    if (current && decodeURIComponent(current.split('=')[1]) === tz) return;
+    document.cookie = `browser_tz=${encodeURIComponent(tz)}; path=/; max-age=31536000; samesite=lax`;
+}
+
+// Mirrors relativeTime in frontend/internal/setup/setup.go. Returns null for
+// timestamps older than a month, which keep their server-rendered date.
+function formatRelativeTime(date) {
+    const seconds = Math.floor((Date.now() - date.getTime()) / 1000);
+    const plural = (n, unit) => n === 1 ? `1 ${unit} ago` : `${n} ${unit}s ago`;
+    if (seconds < 60) return 'just now';
+    if (seconds < 3600) return plural(Math.floor(seconds / 60), 'minute');
+    if (seconds < 86400) return plural(Math.floor(seconds / 3600), 'hour');
+    if (seconds < 30 * 86400) return plural(Math.floor(seconds / 86400), 'day');
+    return null;
+}
+
+function refreshRelativeTimes(root = document) {
+    root.querySelectorAll('time.js-relative-time[datetime]').forEach(el => {
+        const date = new Date(el.getAttribute('datetime'));
+        if (isNaN(date)) return;
+        const text = formatRelativeTime(date);
+        if (text !== null && el.textContent !== text) {
+            el.textContent = text;
+        }
+    });
+}
+
+// Offer IANA zone names as suggestions for the timezone input on the account page.
+function populateTimezoneList() {
+    const list = document.getElementById('timezone-list');
+    if (!list || typeof Intl.supportedValuesOf !== 'function') return;
+    Intl.supportedValuesOf('timeZone').forEach(tz => {
+        const option = document.createElement('option');
+        option.value = tz;
+        list.appendChild(option);
+    });
+}
+
+// Browser push notifications on the notifications page. The subscription is
+// created by the browser and then submitted through the regular form, so the
+// server sees it with the usual CSRF token and answers with a flash message.
+function urlBase64ToUint8Array(base64) {
+    const padded = (base64 + '='.repeat((4 - base64.length % 4) % 4)).replace(/-/g, '+').replace(/_/g, '/');
+    return Uint8Array.from(atob(padded), c => c.charCodeAt(0));
+}
+
+async function setupPushSettings() {
+    const settings = document.getElementById('push-settings');
+    if (!settings || !('serviceWorker' in navigator) || !('PushManager' in window)) return;
+
+    const form = document.getElementById('push-form');
+    const button = document.getElementById('push-toggle');
+    const status = document.getElementById('push-status');
+    let registration;
+    try {
+        registration = await navigator.serviceWorker.register('/static/js/push-sw.js');
+    } catch (e) {
+        console.error('Service worker registration failed:', e);
+        return;
+    }
+    let subscription = await registration.pushManager.getSubscription();
+    if (subscription) {
+        button.textContent = 'Turn off';
+        status.textContent = 'Notifications about replies to your posts are on in this browser.';
+    }
+    settings.hidden = false;
+
+    button.addEventListener('click', async () => {
+        button.disabled = true;
+        try {
+            if (subscription) {
+                form.elements.endpoint.value = subscription.endpoint;
+                form.elements.subscribe.value = '0';
+                await subscription.unsubscribe();
+            } else {
+                if (await Notification.requestPermission() !== 'granted') {
+                    status.textContent = 'Notifications are blocked for this site in your browser settings.';
+                    button.disabled = false;
+                    return;
+                }
+                subscription = await registration.pushManager.subscribe({
+                    userVisibleOnly: true,
+                    applicationServerKey: urlBase64ToUint8Array(settings.dataset.vapidKey),
+                });
+                const json = subscription.toJSON();
+                form.elements.endpoint.value = json.endpoint;
+                form.elements.p256dh.value = json.keys.p256dh;
+                form.elements.auth.value = json.keys.auth;
+                form.elements.subscribe.value = '1';
+            }
+            form.submit();
+        } catch (e) {
+            console.error('Push subscription failed:', e);
+            status.textContent = 'Could not change notifications in this browser.';
+            button.disabled = false;
+        }
+    });
+}
+
+// The floating button on phones jumps to the page's post form; start typing right away
+function setupFloatingPostButton() {
+    const button = document.querySelector('.floating-post-button');
+    if (!button) return;
+    button.addEventListener('click', () => {
+        const textarea = document.querySelector(button.getAttribute('href') + ' textarea');
+        if (textarea) textarea.focus();
+    });
+}
+
+// Offline page and media cache for the installed app, see /sw.js
+function setupServiceWorker() {
+    if (!('serviceWorker' in navigator)) return;
+    navigator.serviceWorker.register('/sw.js').catch((e) => {
+        console.error('Service worker registration failed:', e);
+    });
+}
+
+// Thread auto-refresh: the last page of a thread polls for new posts. The
+// delay doubles while nothing new arrives and resets when something does;
+// polling pauses while the tab is hidden. Soft navigation swaps the posts
+// container, so it is looked up again on every poll.
+function setupThreadAutoRefresh() {
+    if (!document.querySelector('.thread-messages')) return;
+    const findContainer = () => document.querySelector('.posts-container[data-updates-url]');
+
+    const minDelay = 10 * 1000;
+    const maxDelay = 5 * 60 * 1000;
+    let delay = minDelay;
+    let timer = null;
+
+    const lastId = (container) => {
+        const posts = container.querySelectorAll(':scope > .post[data-message-id]');
+        return posts.length ? posts[posts.length - 1].dataset.messageId : '0';
+    };
+
+    const schedule = () => {
+        clearTimeout(timer);
+        timer = document.hidden || !findContainer() ? null : setTimeout(poll, delay);
+    };
+
+    const apply = (container, updates) => {
+        const fragment = document.createElement('template');
+        fragment.innerHTML = updates.posts;
+        const added = [];
+        fragment.content.querySelectorAll(':scope > .post').forEach(post => {
+            if (!document.getElementById(post.id)) {
+                container.appendChild(post);
+                added.push(post);
+            }
+        });
+        updates.backlinks.forEach(link => {
+            const target = document.getElementById(`p${link.to}`);
+            const header = target && target.querySelector('.post-header');
+            if (!header) return;
+            header.insertAdjacentHTML('beforeend', link.html);
+            // Replies to the viewer's older posts are only known here
+            const sender = document.getElementById(`p${link.from}`);
+            if (sender && target.classList.contains('my-message') && !sender.classList.contains('my-message')) {
+                sender.classList.add('reply-to-me');
+            }
+        });
+        added.forEach(post => refreshRelativeTimes(post));
+        return added.length > 0;
+    };
+
+    // Appended posts are seen without a page view, so the read position is
+    // moved over them here. Only logged-in users have the watch form.
+    const markRead = (container) => {
+        const form = document.querySelector('.watch-form');
+        if (!form) return;
+        const body = new FormData();
+        body.append('last_read_id', lastId(container));
+        const csrf = form.querySelector('input[name="csrf_token"]');
+        if (csrf) body.append('csrf_token', csrf.value);
+        fetch(container.dataset.updatesUrl.replace(/\/updates$/, '/read'), { method: 'POST', body, credentials: 'same-origin' })
+            .catch(e => console.error('Marking thread read failed:', e));
+    };
+
+    async function poll() {
+        const container = findContainer();
+        if (!container) return;
+        let gotNew = false;
+        try {
+            const url = `${container.dataset.updatesUrl}?since=${encodeURIComponent(lastId(container))}`;
+            const response = await fetch(url, { credentials: 'same-origin' });
+            if (response.ok) {
+                gotNew = apply(container, await response.json());
+            }
+        } catch (e) {
+            console.error('Thread refresh failed:', e);
+        }
+        if (gotNew) markRead(container);
+        delay = gotNew ? minDelay : Math.min(delay * 2, maxDelay);
+        schedule();
+    }
+
+    document.addEventListener('visibilitychange', () => {
+        if (document.hidden) {
+            clearTimeout(timer);
+            timer = null;
+        } else {
+            delay = minDelay;
+            poll();
+        }
+    });
+    document.addEventListener('itchan:fragment-loaded', () => {
+        delay = minDelay;
+        schedule();
+    });
+    schedule();
+}
+
+// Soft navigation: pagination links and the page form of a thread or board
+// fetch the page's HTML fragment and swap it in instead of reloading the
+// whole page. Back and forward load fragments the same way; any failure
+// falls back to a normal page load.
+function setupSoftNavigation() {
+    const selector = '.thread-messages, .board-threads';
+    const region = document.querySelector(selector);
+    if (!region) return;
+    const fragment = region.classList.contains('thread-messages') ? 'messages' : 'threads';
+    let current = window.location.pathname + window.location.search;
+
+    async function load(url, push) {
+        const target = new URL(url, window.location.href);
+        const fetchUrl = new URL(target);
+        fetchUrl.searchParams.set('fragment', fragment);
+        fetchUrl.hash = '';
+        let next;
+        try {
+            const response = await fetch(fetchUrl, { credentials: 'same-origin' });
+            if (!response.ok) throw new Error(`HTTP ${response.status}`);
+            const template = document.createElement('template');
+            template.innerHTML = await response.text();
+            next = template.content.querySelector(selector);
+            if (!next) throw new Error('fragment not found in response');
+        } catch (e) {
+            console.error('Soft navigation failed:', e);
+            if (push) window.location.assign(target);
+            else window.location.reload();
+            return;
+        }
+
+        document.querySelector(selector).replaceWith(next);
+        current = target.pathname + target.search;
+        if (push) history.pushState(null, '', target);
+        refreshRelativeTimes(next);
+        next.scrollIntoView();
+        document.dispatchEvent(new CustomEvent('itchan:fragment-loaded'));
+    }
+
+    document.addEventListener('click', (e) => {
+        const link = e.target.closest('.pagination a[href]');
+        if (!link || !link.closest(selector)) return;
+        if (e.button !== 0 || e.ctrlKey || e.metaKey || e.shiftKey || e.altKey) return;
+        e.preventDefault();
+        load(link.href, true);
+    });
+
+    document.addEventListener('submit', (e) => {
+        const form = e.target.closest('.page-form');
+        if (!form || !form.closest(selector)) return;
+        e.preventDefault();
+        const url = new URL(window.location.href);
+        url.search = new URLSearchParams(new FormData(form)).toString();
+        url.hash = '';
+        load(url, true);
+    });
+
+    window.addEventListener('popstate', () => {
+        // Hash-only history entries (post anchors) keep the same page
+        if (window.location.pathname + window.location.search === current) return;
+        load(window.location.href, false);
+    });
+}
+
+// Infinite scroll (account setting): once the pagination of a board page is
+// within a screen of the viewport, the next page's threads are fetched and
+// appended, and the pagination moves on to that page. Without
+// IntersectionObserver, after the last page or when a fetch fails, the
+// numbered pagination is left as it is.
+function setupInfiniteScroll() {
+    if (!('IntersectionObserver' in window)) return;
+    let observer = null;
+    let loading = false;
+
+    function observe() {
+        if (observer) observer.disconnect();
+        const region = document.querySelector('.board-threads[data-infinite-scroll]');
+        const pagination = region && region.querySelector(':scope > .pagination');
+        if (!pagination) return;
+        observer = new IntersectionObserver((entries) => {
+            if (entries.some(entry => entry.isIntersecting)) loadNext(region);
+        }, { rootMargin: '0px 0px 100% 0px' });
+        observer.observe(pagination);
+    }
+
+    async function loadNext(region) {
+        if (loading) return;
+        loading = true;
+        const nextPage = Number(region.dataset.page) + 1;
+        const fetchUrl = new URL(window.location.href);
+        fetchUrl.searchParams.set('page', nextPage);
+        fetchUrl.searchParams.set('fragment', 'threads');
+        fetchUrl.hash = '';
+        try {
+            const response = await fetch(fetchUrl, { credentials: 'same-origin' });
+            if (!response.ok) throw new Error(`HTTP ${response.status}`);
+            const template = document.createElement('template');
+            template.innerHTML = await response.text();
+            const next = template.content.querySelector('.board-threads');
+            if (!next) throw new Error('fragment not found in response');
+
+            const threads = next.querySelectorAll('.threads-container > .thread-preview');
+            if (threads.length === 0) {
+                observer.disconnect(); // Past the last page
+                return;
+            }
+            const container = region.querySelector('.threads-container');
+            threads.forEach(thread => {
+                const separator = document.createElement('hr');
+                separator.className = 'thread-separator';
+                container.append(separator, thread);
+                refreshRelativeTimes(thread);
+            });
+            region.querySelector(':scope > .pagination').replaceWith(next.querySelector(':scope > .pagination'));
+            region.dataset.page = nextPage;
+        } catch (e) {
+            console.error('Infinite scroll failed:', e);
+            observer.disconnect();
+            return;
+        } finally {
+            loading = false;
+        }
+        // Observe the new pagination; it may already be in range
+        observe();
+    }
+
+    // Soft navigation swaps the whole region, pagination included
+    document.addEventListener('itchan:fragment-loaded', observe);
+    observe();
+}
+
+// Low-bandwidth mode (account setting or ?lowbw=1): attachment thumbnails are
+// rendered inside <template> elements, so the browser fetches nothing until the
+// placeholder button next to one is clicked. Listening on the document covers
+// posts added by auto-refresh, soft navigation and infinite scroll.
+function setupDeferredMedia() {
+    document.addEventListener('click', (e) => {
+        const button = e.target.closest('.load-media');
+        if (!button) return;
+        const template = button.previousElementSibling;
+        if (!template || !template.matches('template.deferred-media')) return;
+        button.replaceWith(template.content.cloneNode(true));
+        template.remove();
+    });
+}
+
+// Thread map: a force-directed drawing of the reply graph, fetched the first
+// time the map is opened. Bigger dots got more replies, dots on the current
+// page are highlighted, and clicking a dot opens that post.
+function setupThreadMap() {
+    const details = document.querySelector('.thread-map[data-graph-url]');
+    if (!details) return;
+    const canvas = details.querySelector('.thread-map-canvas');
+    const status = details.querySelector('.thread-map-status');
+    let layout = null;
+
+    details.addEventListener('toggle', async () => {
+        if (!details.open || layout) return;
+        try {
+            const response = await fetch(details.dataset.graphUrl, { credentials: 'same-origin' });
+            if (!response.ok) throw new Error(`HTTP ${response.status}`);
+            const graph = await response.json();
+            layout = layoutThreadGraph(graph, canvas.width, canvas.height);
+            drawThreadGraph(canvas, layout);
+            status.textContent = `${graph.nodes.length} posts, ${layout.edges.length} replies. Click a post to open it.`;
+        } catch (e) {
+            console.error('Thread map failed:', e);
+            status.textContent = 'Could not load the thread map.';
+        }
+    });
+
+    const nodeAt = (event) => {
+        if (!layout) return null;
+        const rect = canvas.getBoundingClientRect();
+        const x = (event.clientX - rect.left) * canvas.width / rect.width;
+        const y = (event.clientY - rect.top) * canvas.height / rect.height;
+        return layout.nodes.find(node => Math.hypot(node.x - x, node.y - y) <= node.r + 2) || null;
+    };
+
+    canvas.addEventListener('mousemove', (event) => {
+        const node = nodeAt(event);
+        canvas.style.cursor = node ? 'pointer' : '';
+        canvas.title = node ? `>>${node.id} (${node.replies} replies)` : '';
+    });
+
+    canvas.addEventListener('click', (event) => {
+        const node = nodeAt(event);
+        if (!node) return;
+        if (document.getElementById(`p${node.id}`)) {
+            location.hash = `p${node.id}`;
+            return;
+        }
+        const page = node.page > 1 ? `?page=${node.page}` : '';
+        location.href = `${details.dataset.threadUrl}${page}#p${node.id}`;
+    });
+}
+
+// layoutThreadGraph places the posts with a Fruchterman-Reingold simulation:
+// every pair repels, reply links attract. Repulsion is O(n²) per step, so big
+// threads get fewer steps.
+function layoutThreadGraph(graph, width, height) {
+    const byId = new Map();
+    const nodes = graph.nodes.map((n, i) => {
+        // Start on a spiral so no two nodes coincide
+        const angle = i * 2.4;
+        const radius = Math.sqrt(i + 1) * 8;
+        const node = { id: n.id, page: n.page, replies: 0, x: width / 2 + radius * Math.cos(angle), y: height / 2 + radius * Math.sin(angle) };
+        byId.set(n.id, node);
+        return node;
+    });
+    const edges = graph.edges.map(e => [byId.get(e.from), byId.get(e.to)]).filter(([from, to]) => from && to);
+    edges.forEach(([, to]) => to.replies++);
+    nodes.forEach(node => { node.r = Math.min(3 + 2 * Math.sqrt(node.replies), 14); });
+
+    const n = nodes.length;
+    const k = Math.sqrt(width * height / Math.max(n, 1)); // ideal edge length
+    const steps = Math.max(20, Math.min(300, Math.floor(3e7 / Math.max(n * n, 1))));
+    for (let step = 0; step < steps; step++) {
+        nodes.forEach(node => { node.dx = 0; node.dy = 0; });
+        for (let i = 0; i < n; i++) {
+            for (let j = i + 1; j < n; j++) {
+                const a = nodes[i], b = nodes[j];
+                const dx = a.x - b.x, dy = a.y - b.y;
+                const f = k * k / (dx * dx + dy * dy || 0.01);
+                a.dx += dx * f; a.dy += dy * f;
+                b.dx -= dx * f; b.dy -= dy * f;
+            }
+        }
+        edges.forEach(([a, b]) => {
+            const dx = a.x - b.x, dy = a.y - b.y;
+            const f = Math.hypot(dx, dy) / k;
+            a.dx -= dx * f; a.dy -= dy * f;
+            b.dx += dx * f; b.dy += dy * f;
+        });
+        // Moves shrink each step so the layout settles
+        const maxMove = (width / 10) * (1 - step / steps);
+        nodes.forEach(node => {
+            const d = Math.hypot(node.dx, node.dy) || 1;
+            const move = Math.min(d, maxMove);
+            node.x = Math.min(width - node.r, Math.max(node.r, node.x + node.dx / d * move));
+            node.y = Math.min(height - node.r, Math.max(node.r, node.y + node.dy / d * move));
+        });
+    }
+    return { nodes, edges };
+}
+
+function drawThreadGraph(canvas, layout) {
+    const style = getComputedStyle(document.documentElement);
+    const color = (name) => style.getPropertyValue(name).trim();
+    const ctx = canvas.getContext('2d');
+    ctx.clearRect(0, 0, canvas.width, canvas.height);
+
+    ctx.strokeStyle = color('--border');
+    ctx.lineWidth = 1;
+    ctx.beginPath();
+    layout.edges.forEach(([from, to]) => {
+        ctx.moveTo(from.x, from.y);
+        ctx.lineTo(to.x, to.y);
+    });
+    ctx.stroke();
+
+    layout.nodes.forEach(node => {
+        if (node.id === 1) {
+            ctx.fillStyle = color('--green');
+        } else if (document.getElementById(`p${node.id}`)) {
+            ctx.fillStyle = color('--subject');
+        } else {
+            ctx.fillStyle = color('--text-dark');
+        }
+        ctx.beginPath();
+        ctx.arc(node.x, node.y, node.r, 0, 2 * Math.PI);
+        ctx.fill();
+    });
+}
+
+// Post cooldowns: forms marked with data-post-limit keep their submit button
+// disabled, with a countdown, until the server's rate limit allows posting.
+async function setupPostCooldowns() {
+    const forms = document.querySelectorAll('form[data-post-limit]');
+    if (!forms.length) return;
+
+    let limits;
+    try {
+        const response = await fetch('/api-proxy/v1/me/limits', { credentials: 'same-origin' });
+        if (!response.ok) return;
+        limits = await response.json();
+    } catch (e) {
+        return;
+    }
+
+    forms.forEach(form => {
+        const seconds = form.dataset.postLimit === 'thread' ? limits.thread_cooldown_seconds : limits.message_cooldown_seconds;
+        const button = form.querySelector('button[type="submit"]');
+        if (!button || !(seconds > 0)) return;
+
+        const label = button.textContent;
+        const until = Date.now() + seconds * 1000;
+        const tick = () => {
+            const left = Math.ceil((until - Date.now()) / 1000);
+            if (left <= 0) {
+                button.disabled = false;
+                button.textContent = label;
+                return;
+            }
+            button.disabled = true;
+            button.textContent = `${label} (${left}s)`;
+            setTimeout(tick, 1000);
+        };
+        tick();
+    });
+}
+
+// Early uploads: files picked in a post form are sent to the upload endpoint
+// right away, and on submit the form carries their tokens instead of the
+// files. If an upload fails the form is posted with the files as before.
+function setupEarlyUploads() {
+    const uploads = new WeakMap(); // File -> { board, token promise }
+
+    const formBoard = form => new URL(form.action, location.href).pathname.split('/')[1];
+
+    const upload = (form, file) => {
+        const board = formBoard(form);
+        const cached = uploads.get(file);
+        if (cached && cached.board === board) return cached.token;
+
+        const body = new FormData();
+        body.append('file', file);
+        const csrf = form.querySelector('input[name="csrf_token"]');
+        if (csrf) body.append('csrf_token', csrf.value);
+
+        const token = fetch(`/api-proxy/v1/${board}/uploads`, { method: 'POST', body, credentials: 'same-origin' })
+            .then(response => response.ok ? response.json() : Promise.reject(new Error(`HTTP ${response.status}`)))
+            .then(data => data.token);
+        token.catch(() => uploads.delete(file));
+        uploads.set(file, { board, token });
+        return token;
+    };
+
+    document.addEventListener('change', (e) => {
+        const input = e.target;
+        const form = input.closest && input.closest('form[data-post-limit]');
+        if (!form || !input.matches('input[type="file"][name="attachments"]')) return;
+        Array.from(input.files).forEach(file => upload(form, file).catch(() => {}));
+    });
+
+    document.addEventListener('submit', async (e) => {
+        const form = e.target;
+        if (e.defaultPrevented || !form.matches('form[data-post-limit]')) return;
+        const input = form.querySelector('input[type="file"][name="attachments"]');
+        if (!input || !input.files.length) return;
+
+        e.preventDefault();
+        const button = form.querySelector('button[type="submit"]');
+        if (button) button.disabled = true;
+
+        form.querySelectorAll('input[name="upload_token"]').forEach(el => el.remove());
+        try {
+            const tokens = await Promise.all(Array.from(input.files).map(file => upload(form, file)));
+            tokens.forEach(token => {
+                const hidden = document.createElement('input');
+                hidden.type = 'hidden';
+                hidden.name = 'upload_token';
+                hidden.value = token;
+                form.appendChild(hidden);
+            });
+            input.disabled = true; // disabled inputs are left out of the submission
+        } catch (err) {
+            // Let the backend see the files themselves and report what's wrong
+        }
+        form.submit();
+    });
+}
+
+// Gallery lightbox: opens the gallery's files one at a time over the page.
+// Arrow keys and the side buttons step through them, Escape or a click on the
+// backdrop closes it. Without JS the grid links open the files directly.
+function setupGallery() {
+    const gallery = document.querySelector('.gallery[data-lightbox]');
+    if (!gallery) return;
+    const links = Array.from(gallery.querySelectorAll('.gallery-open'));
+    if (links.length === 0) return;
+
+    const box = document.createElement('div');
+    box.className = 'lightbox';
+    box.hidden = true;
+    box.innerHTML = `
+        <div class="lightbox-media"></div>
+        <button type="button" class="lightbox-prev" title="Previous (←)">&lsaquo;</button>
+        <button type="button" class="lightbox-next" title="Next (→)">&rsaquo;</button>
+        <div class="lightbox-bar">
+            <span class="lightbox-counter"></span>
+            <span class="lightbox-name"></span>
+            <a class="lightbox-download">[download]</a>
+            <a class="lightbox-post">[post]</a>
+            <button type="button" class="link-button lightbox-close" title="Close (Esc)">[close]</button>
+        </div>`;
+    document.body.appendChild(box);
+    const media = box.querySelector('.lightbox-media');
+    let current = -1;
+
+    const show = (index) => {
+        current = (index + links.length) % links.length;
+        const link = links[current];
+        const mime = link.dataset.mime || '';
+        media.replaceChildren();
+        let element;
+        if (mime.startsWith('image/')) {
+            element = document.createElement('img');
+            element.alt = link.dataset.filename;
+        } else if (mime.startsWith('video/')) {
+            element = document.createElement('video');
+            element.controls = true;
+            element.autoplay = true;
+        } else {
+            // Media responses can't be framed, documents open in a new tab
+            element = document.createElement('a');
+            element.className = 'lightbox-file';
+            element.target = '_blank';
+            element.textContent = link.dataset.filename;
+        }
+        if (element.tagName === 'A') element.href = link.href;
+        else element.src = link.href;
+        media.appendChild(element);
+
+        const item = link.closest('.gallery-item');
+        box.querySelector('.lightbox-counter').textContent = `${current + 1} / ${links.length}`;
+        box.querySelector('.lightbox-name').textContent = link.dataset.filename;
+        const download = box.querySelector('.lightbox-download');
+        download.href = link.href;
+        download.download = link.dataset.filename;
+        box.querySelector('.lightbox-post').href = item.querySelector('.message-link').href;
+    };
+
+    const close = () => {
+        box.hidden = true;
+        media.replaceChildren(); // stops a playing video
+        document.body.classList.remove('lightbox-open');
+        current = -1;
+    };
+
+    links.forEach((link, index) => {
+        link.addEventListener('click', (e) => {
+            if (e.ctrlKey || e.metaKey || e.shiftKey || e.button !== 0) return;
+            e.preventDefault();
+            show(index);
+            box.hidden = false;
+            document.body.classList.add('lightbox-open');
+        });
+    });
+
+    box.querySelector('.lightbox-prev').addEventListener('click', () => show(current - 1));
+    box.querySelector('.lightbox-next').addEventListener('click', () => show(current + 1));
+    box.querySelector('.lightbox-close').addEventListener('click', close);
+    box.addEventListener('click', (e) => {
+        if (e.target === box || e.target === media) close();
+    });
+
+    document.addEventListener('keydown', (e) => {
+        if (box.hidden) return;
+        if (e.key === 'Escape') close();
+        else if (e.key === 'ArrowLeft') show(current - 1);
+        else if (e.key === 'ArrowRight') show(current + 1);
+        else return;
+        e.preventDefault();
+    });
+}
+
 function handleReplyHash() {
     const hash = window.location.hash;
     const replyMatch = hash.match(/^#reply-(\d+)$/);
@@ -813,6 +1545,23 @@ document.addEventListener('DOMContentLoaded', () => {
     // Setup form confirmation handlers
     setupFormHandlers();
 
+    // Timezone and relative timestamps
+    syncBrowserTimezone();
+    populateTimezoneList();
+    setupPushSettings();
+    setupServiceWorker();
+    setupFloatingPostButton();
+    setupThreadAutoRefresh();
+    setupSoftNavigation();
+    setupInfiniteScroll();
+    setupDeferredMedia();
+    setupThreadMap();
+    setupGallery();
+    setupPostCooldowns();
+    setupEarlyUploads();
+    refreshRelativeTimes();
+    setInterval(refreshRelativeTimes, 60 * 1000);
+
     // Add form validation for message posting (text OR attachments required)
     document.addEventListener('submit', (e) => {
         const form = e.target;
@@ -845,6 +1594,20 @@ function setupFormHandlers() {
             }
         }
 
+        // Handle moderator delete forms: the prompt doubles as confirmation
+        if (form.classList.contains('js-reason-form')) {
+            const reason = prompt(form.dataset.promptMessage || 'Reason (optional):');
+            if (reason === null) {
+                e.preventDefault();
+                return false;
+            }
+
+            const reasonInput = form.querySelector('input[name="reason"]');
+            if (reasonInput) {
+                reasonInput.value = reason;
+            }
+        }
+
         // Handle blacklist forms with prompt
         if (form.classList.contains('blacklist-form')) {
             const reason = prompt("Enter reason for blacklist (optional):");
@@ -0,0 +1,21 @@
+// Service worker for browser push notifications. The payload is built by
+// Push.NotifyReply in backend/internal/service/push.go.
+self.addEventListener('push', (event) => {
+    let data = {};
+    try {
+        data = event.data ? event.data.json() : {};
+    } catch (e) {
+        // Show a generic notification for unreadable payloads
+    }
+    const url = data.url || '/notifications';
+    event.waitUntil(self.registration.showNotification(data.title || 'New reply', {
+        body: data.body || '',
+        tag: url,
+        data: { url },
+    }));
+});
+
+self.addEventListener('notificationclick', (event) => {
+    event.notification.close();
+    event.waitUntil(clients.openWindow(event.notification.data.url));
+});
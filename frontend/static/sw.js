@@ -0,0 +1,84 @@
+// Service worker for the installable app. It is served at /sw.js so that
+// its scope covers the whole site. Push notifications have their own worker,
+// see js/push-sw.js.
+//
+// - Navigations go to the network; when it is unreachable the cached offline
+//   page is shown instead. Pages themselves are never cached, they change on
+//   every post and carry per-user data.
+// - Hashed static files (/static/name.<hash>.ext) never change and are served
+//   from the cache first.
+// - Images under /media/ (thumbnails in practice, full-size files are opened
+//   as documents) are served from the cache first, keeping the newest few
+//   hundred so threads read earlier still show their pictures offline.
+const VERSION = 'v1';
+const SHELL_CACHE = 'shell-' + VERSION;
+const STATIC_CACHE = 'static-' + VERSION;
+const MEDIA_CACHE = 'media-' + VERSION;
+const OFFLINE_URL = '/offline';
+const MAX_STATIC_ENTRIES = 50;
+const MAX_MEDIA_ENTRIES = 300;
+
+const hashedStatic = /^\/static\/.+\.[0-9a-f]{12}\.[a-z0-9]+$/;
+
+self.addEventListener('install', (event) => {
+    event.waitUntil((async () => {
+        const cache = await caches.open(SHELL_CACHE);
+        const response = await fetch(OFFLINE_URL, { cache: 'no-store' });
+        if (!response.ok) throw new Error('offline page returned ' + response.status);
+        // The offline page links the stylesheet and icons by hashed name;
+        // cache them with it so it renders without the network
+        const html = await response.clone().text();
+        const assets = [...html.matchAll(/(?:href|src)="(\/static\/[^"]+)"/g)].map(m => m[1]);
+        await cache.put(OFFLINE_URL, response);
+        await cache.addAll(assets);
+        await self.skipWaiting();
+    })());
+});
+
+self.addEventListener('activate', (event) => {
+    const current = [SHELL_CACHE, STATIC_CACHE, MEDIA_CACHE];
+    event.waitUntil((async () => {
+        for (const name of await caches.keys()) {
+            if (!current.includes(name)) await caches.delete(name);
+        }
+        await self.clients.claim();
+    })());
+});
+
+self.addEventListener('fetch', (event) => {
+    const request = event.request;
+    if (request.method !== 'GET') return;
+    const url = new URL(request.url);
+    if (url.origin !== self.location.origin) return;
+
+    if (request.mode === 'navigate') {
+        event.respondWith(fetch(request).catch(async () => {
+            return (await caches.match(OFFLINE_URL)) || Response.error();
+        }));
+    } else if (hashedStatic.test(url.pathname)) {
+        event.respondWith(cacheFirst(request, STATIC_CACHE, MAX_STATIC_ENTRIES));
+    } else if (url.pathname.startsWith('/media/') && request.destination === 'image') {
+        event.respondWith(cacheFirst(request, MEDIA_CACHE, MAX_MEDIA_ENTRIES));
+    }
+});
+
+async function cacheFirst(request, cacheName, maxEntries) {
+    const cache = await caches.open(cacheName);
+    const cached = await cache.match(request);
+    if (cached) return cached;
+
+    const response = await fetch(request);
+    if (response.ok) {
+        await cache.put(request, response.clone());
+        trimCache(cache, maxEntries);
+    }
+    return response;
+}
+
+// trimCache drops the oldest entries; keys() lists them in insertion order.
+async function trimCache(cache, maxEntries) {
+    const keys = await cache.keys();
+    for (const key of keys.slice(0, Math.max(0, keys.length - maxEntries))) {
+        await cache.delete(key);
+    }
+}
@@ -0,0 +1,27 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Request DTOs
+
+// SubmitAppealRequest carries credentials because suspended users cannot log in.
+type SubmitAppealRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+	Text     string `json:"text" validate:"required"`
+}
+
+type DecideAppealRequest struct {
+	Response string `json:"response"`
+}
+
+// Response DTOs
+
+type SubmitAppealResponse struct {
+	ID int64 `json:"id"`
+}
+
+type AppealsResponse struct {
+	Appeals []domain.Appeal `json:"appeals"`
+	Page    int             `json:"page"`
+}
@@ -20,15 +20,28 @@ type CheckConfirmationCodeRequest struct {
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required"`
+	RememberMe bool   `json:"remember_me"` // Also issue a refresh token
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// RevokeSessionsRequest carries the token of a new device email's "this
+// wasn't me" link
+type RevokeSessionsRequest struct {
+	Token string `json:"token" validate:"required"`
 }
 
 // Response DTOs
 
+// LoginResponse also answers session refresh and renewal.
 type LoginResponse struct {
-	Message     string `json:"message"`
-	AccessToken string `json:"access_token,omitempty"` // Token for non-cookie clients (mobile, API clients)
+	Message      string `json:"message"`
+	AccessToken  string `json:"access_token,omitempty"`  // Token for non-cookie clients (mobile, API clients)
+	RefreshToken string `json:"refresh_token,omitempty"` // Only with remember_me; exchanged at /v1/auth/refresh
 }
 
 type RegisterWithInviteResponse struct {
@@ -0,0 +1,11 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Response DTOs
+
+// BackupsResponse is the backup state with the latest runs, newest first.
+type BackupsResponse struct {
+	State domain.BackupState `json:"state"`
+	Runs  []domain.BackupRun `json:"runs"`
+}
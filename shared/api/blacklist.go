@@ -1,6 +1,10 @@
 package api
 
-import "github.com/itchan-dev/itchan/shared/domain"
+import (
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
 
 // Request DTOs
 
@@ -8,9 +12,21 @@ type BlacklistUserRequest struct {
 	Reason string `json:"reason"`
 }
 
+// UserLookupRequest is sent in the body so the email stays out of access logs
+type UserLookupRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
 // Response DTOs
 
 type BlacklistResponse struct {
 	Users []domain.BlacklistEntry `json:"users"`
 	Page  int                     `json:"page"`
 }
+
+type UserLookupResponse struct {
+	UserId      domain.UserId `json:"user_id"`
+	EmailDomain string        `json:"email_domain"`
+	Admin       bool          `json:"is_admin"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"time"
+
 	"github.com/itchan-dev/itchan/shared/domain"
 )
 
@@ -10,4 +12,76 @@ type CreateBoardRequest struct {
 	Name          string         `json:"name" validate:"required"`
 	ShortName     string         `json:"short_name" validate:"required"`
 	AllowedEmails *domain.Emails `json:"allowed_emails,omitempty"`
+	Description   string         `json:"description,omitempty"`
+}
+
+type BoardCategoryRequest struct {
+	Name     string `json:"name" validate:"required"`
+	Position int    `json:"position"`
+}
+
+type SetBoardCategoryRequest struct {
+	CategoryId *int64 `json:"category_id"` // null removes the board from its category
+	Position   int    `json:"position"`
+}
+
+type UpdateBoardSettingsRequest struct {
+	Description             string   `json:"description"`
+	ShowDeletionStubs       bool     `json:"show_deletion_stubs"`
+	Noindex                 bool     `json:"noindex"`
+	PublicModLog            bool     `json:"public_modlog"`
+	SelfDeleteReplied       bool     `json:"self_delete_replied"`
+	BumpLimitNotice         bool     `json:"bump_limit_notice"`
+	PreModeration           bool     `json:"pre_moderation"`
+	MinOpTextLength         int      `json:"min_op_text_length" validate:"gte=0"`
+	RequireOpAttachment     bool     `json:"require_op_attachment"`
+	MaxThreadsPerUserPerDay int      `json:"max_threads_per_user_per_day" validate:"gte=0"`
+	VideoProfile            string   `json:"video_profile"`                                           // empty selects the default profile
+	TextFilter              string   `json:"text_filter" validate:"omitempty,oneof=strip reject off"` // empty strips
+	Language                string   `json:"language" validate:"omitempty,oneof=en ru ja zh ko"`      // empty is English
+	AllowDocuments          bool     `json:"allow_documents"`
+	MaxAttachments          *int     `json:"max_attachments" validate:"omitnil,gte=0"` // null uses the global limit, 0 disables uploads
+	AllowedMimeTypes        []string `json:"allowed_mime_types"`                       // image and video types, empty accepts every configured one
+
+	// Posting rules, empty or zero lets everyone who can read the board post
+	PostingEmailDomains      []string `json:"posting_email_domains"`
+	PostingMinAccountAgeDays int      `json:"posting_min_account_age_days" validate:"gte=0"`
+
+	// Automatic trust under pre-moderation, zero turns a rule off
+	PremodTrustApprovals      int `json:"premod_trust_approvals" validate:"gte=0"`
+	PremodTrustAccountAgeDays int `json:"premod_trust_account_age_days" validate:"gte=0"`
+}
+
+type SetBoardCustomCSSRequest struct {
+	CSS string `json:"css"` // empty removes the stylesheet
+}
+
+// Response DTOs
+
+type CreateBoardBannerResponse struct {
+	ID int64 `json:"id"`
+}
+
+type CreateBoardCategoryResponse struct {
+	ID int64 `json:"id"`
+}
+
+// DeleteBoardResponse tells when a board scheduled for deletion will be
+// dropped. DeleteAfter is omitted when the board was deleted at once.
+type DeleteBoardResponse struct {
+	DeleteAfter *time.Time `json:"delete_after,omitempty"`
+}
+
+type RenameBoardRequest struct {
+	ShortName domain.BoardShortName `json:"short_name" validate:"required"`
+}
+
+type PendingBoardDeletionsResponse struct {
+	Boards []domain.PendingBoardDeletion `json:"boards"`
+}
+
+type BoardsResponse struct {
+	Boards []domain.BoardMetadata `json:"boards"`
+	Page   int                    `json:"page"`
+	Total  int                    `json:"total"` // Number of boards across all pages
 }
@@ -0,0 +1,19 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Request DTOs
+
+type AddBookmarkRequest struct {
+	Board     domain.BoardShortName `json:"board" validate:"required"`
+	ThreadId  domain.ThreadId       `json:"thread_id" validate:"required,gte=1"`
+	MessageId domain.MsgId          `json:"message_id" validate:"required,gte=1"`
+}
+
+// Response DTOs
+
+type BookmarksResponse struct {
+	Bookmarks []domain.Bookmark `json:"bookmarks"`
+	Page      int               `json:"page"`
+	Total     int               `json:"total"` // Number of bookmarks across all pages
+}
@@ -0,0 +1,27 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Request DTOs
+
+type SetDigestRequest struct {
+	Frequency domain.DigestFrequency `json:"frequency" validate:"omitempty,oneof=daily weekly"` // Empty unsubscribes
+}
+
+type UnsubscribeDigestRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type MarkThreadReadRequest struct {
+	LastReadId domain.MsgId `json:"last_read_id" validate:"required,gte=1"`
+}
+
+// Response DTOs
+
+type DigestResponse struct {
+	Frequency domain.DigestFrequency `json:"frequency"` // Empty when not subscribed
+}
+
+type WatchedThreadsResponse struct {
+	Threads []domain.WatchedThread `json:"threads"`
+}
@@ -0,0 +1,10 @@
+package api
+
+// Response DTOs
+
+// ErrorResponse is returned for failures that have no handler-specific
+// message, such as a recovered panic.
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+}
@@ -0,0 +1,11 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Response DTOs
+
+type FailedPostsResponse struct {
+	Posts   []domain.FailedPost `json:"posts"`
+	Page    int                 `json:"page"`
+	Enabled bool                `json:"enabled"` // Whether failed posts are being captured now
+}
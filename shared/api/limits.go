@@ -0,0 +1,10 @@
+package api
+
+// Response DTOs
+
+// PostLimitsResponse tells how long the user has to wait before posting again.
+// Zero means posting is allowed now.
+type PostLimitsResponse struct {
+	ThreadCooldownSeconds  int `json:"thread_cooldown_seconds"`
+	MessageCooldownSeconds int `json:"message_cooldown_seconds"`
+}
@@ -0,0 +1,24 @@
+package api
+
+import "time"
+
+// Request DTOs
+
+// ScheduleMaintenanceRequest puts the instance into read-only mode. A missing
+// starts_at starts it now, a missing ends_at lasts until it is cancelled.
+type ScheduleMaintenanceRequest struct {
+	Message  string     `json:"message" validate:"max=1000"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// Response DTOs
+
+// MaintenanceResponse is the active or upcoming maintenance window. The admin
+// who scheduled it is left out, as the endpoint is public.
+type MaintenanceResponse struct {
+	Message  string     `json:"message"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	Active   bool       `json:"active"`
+}
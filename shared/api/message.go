@@ -7,16 +7,38 @@ import (
 // Request DTOs
 
 type CreateMessageRequest struct {
-	Text            string              `json:"text,omitempty"`
-	ShowEmailDomain bool                `json:"show_email_domain,omitempty"`
-	Attachments     *domain.Attachments `json:"attachments,omitempty"`
-	ReplyTo         *domain.Replies     `json:"reply_to,omitempty"`
+	Text            string               `json:"text,omitempty"`
+	ShowEmailDomain bool                 `json:"show_email_domain,omitempty"`
+	Attachments     *domain.Attachments  `json:"attachments,omitempty"`
+	ReplyTo         *domain.Replies      `json:"reply_to,omitempty"`
+	UploadTokens    []domain.UploadToken `json:"upload_tokens,omitempty"` // Files uploaded beforehand with POST /v1/:board/uploads
+}
+
+// DeleteContentRequest is the optional body of moderator DELETE requests for
+// messages and threads.
+type DeleteContentRequest struct {
+	Reason string `json:"reason,omitempty"`
 }
 
 // Response DTOs
 
+// UploadFileResponse returns the token that attaches an uploaded file to a
+// post, with the file as stored after sanitization
+type UploadFileResponse struct {
+	Token domain.UploadToken `json:"token"`
+	File  *domain.File       `json:"file"`
+}
+
 // CreateMessageResponse returns the ID of the created message and its page
 type CreateMessageResponse struct {
 	Id   int64 `json:"id"`
 	Page int   `json:"page"` // Page number where the message appears
 }
+
+// ResolvePostResponse locates the message with a board-local post number
+type ResolvePostResponse struct {
+	Board     domain.BoardShortName `json:"board"`
+	ThreadId  domain.ThreadId       `json:"thread_id"`
+	MessageId domain.MsgId          `json:"message_id"`
+	Page      int                   `json:"page"` // Thread page where the message appears
+}
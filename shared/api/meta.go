@@ -0,0 +1,13 @@
+package api
+
+// Response DTOs
+
+// MetaResponse describes the instance, e.g. for directories listing several
+// of them. Footer is markdown as configured; the frontend renders it.
+type MetaResponse struct {
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	RulesURL     string `json:"rules_url,omitempty"`
+	AdminContact string `json:"admin_contact,omitempty"`
+	Footer       string `json:"footer,omitempty"`
+}
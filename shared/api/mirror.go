@@ -0,0 +1,16 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Request DTOs
+
+type CreateBoardMirrorRequest struct {
+	SourceURL   string `json:"source_url" validate:"required"`   // Base URL of the other instance, e.g. https://itchan.example
+	SourceBoard string `json:"source_board" validate:"required"` // Short name of the board there
+}
+
+// Response DTOs
+
+type BoardMirrorsResponse struct {
+	Mirrors []domain.BoardMirror `json:"mirrors"`
+}
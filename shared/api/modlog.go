@@ -0,0 +1,11 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Response DTOs
+
+type ModLogResponse struct {
+	Entries []domain.ModLogEntry `json:"entries"`
+	Page    int                  `json:"page"`
+	Total   int                  `json:"total"` // Number of entries across all pages
+}
@@ -0,0 +1,16 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Response DTOs
+
+type NotificationsResponse struct {
+	Notifications []domain.Notification `json:"notifications"`
+	Page          int                   `json:"page"`
+	Total         int                   `json:"total"`  // Number of notifications across all pages
+	Unread        int                   `json:"unread"` // Number of unread notifications across all pages
+}
+
+type UnreadNotificationsResponse struct {
+	Unread int `json:"unread"`
+}
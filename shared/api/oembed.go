@@ -0,0 +1,16 @@
+package api
+
+// Response DTOs
+
+// OEmbedResponse is an oEmbed 1.0 "link" response describing a thread.
+// ThumbnailURL is relative to the site root; the frontend makes it absolute.
+type OEmbedResponse struct {
+	Version         string `json:"version"`
+	Type            string `json:"type"`
+	Title           string `json:"title"`
+	ProviderName    string `json:"provider_name"`
+	CacheAge        int    `json:"cache_age,omitempty"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty"`
+}
@@ -0,0 +1,31 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Request DTOs
+
+type ApproveQueuedPostRequest struct {
+	Trust bool `json:"trust"` // Also let the author skip pre-moderation on the board
+}
+
+type SetPosterTrustRequest struct {
+	Trusted *bool `json:"trusted" validate:"required"` // false holds the user's posts whatever the board's trust rules
+}
+
+// Response DTOs
+
+// PostQueuedResponse answers a post held for pre-moderation (202 Accepted)
+type PostQueuedResponse struct {
+	Pending bool   `json:"pending"`
+	Message string `json:"message"`
+}
+
+type PremodQueueResponse struct {
+	Posts   []domain.QueuedPost   `json:"posts"`
+	Posters []domain.PremodPoster `json:"posters"`
+}
+
+type ApproveQueuedPostResponse struct {
+	ThreadId int64 `json:"thread_id"`
+	Id       int64 `json:"id"`
+}
@@ -0,0 +1,16 @@
+package api
+
+// Request DTOs
+
+// PushSubscriptionRequest has the shape of PushSubscription.toJSON() in the browser
+type PushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint" validate:"required,url"`
+	Keys     struct {
+		P256dh string `json:"p256dh" validate:"required"`
+		Auth   string `json:"auth" validate:"required"`
+	} `json:"keys"`
+}
+
+type DeletePushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint" validate:"required"`
+}
@@ -0,0 +1,10 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Response DTOs
+
+type ShadowbansResponse struct {
+	Shadowbans []domain.Shadowban `json:"shadowbans"`
+	Page       int                `json:"page"`
+}
@@ -1,15 +1,28 @@
 package api
 
-import "time"
+import (
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
 
 // Request DTOs
 
 type CreateThreadRequest struct {
 	Title     string               `json:"title" validate:"required"`
 	IsPinned  bool                 `json:"is_pinned,omitempty"`
+	OpOnly    bool                 `json:"op_only,omitempty"`
 	OpMessage CreateMessageRequest `json:"op_message"`
 }
 
+type EditThreadTitleRequest struct {
+	Title string `json:"title" validate:"required"`
+}
+
+type SetThreadSuccessorRequest struct {
+	SuccessorId int64 `json:"successor_id" validate:"required,gt=0"` // Newer thread of the same board
+}
+
 // Response DTOs
 
 type CreateThreadResponse struct {
@@ -20,6 +33,14 @@ type TogglePinnedThreadResponse struct {
 	IsPinned bool `json:"is_pinned"`
 }
 
+type ToggleOpOnlyThreadResponse struct {
+	OpOnly bool `json:"op_only"`
+}
+
 type LastModifiedResponse struct {
 	LastModifiedAt time.Time `json:"last_modified_at"`
 }
+
+type ThreadTitleHistoryResponse struct {
+	Edits []domain.ThreadTitleEdit `json:"edits"` // Oldest first
+}
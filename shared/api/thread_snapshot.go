@@ -0,0 +1,10 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Response DTOs
+
+type ThreadSnapshotsResponse struct {
+	Snapshots []domain.ThreadSnapshot `json:"snapshots"`
+	Page      int                     `json:"page"`
+}
@@ -0,0 +1,11 @@
+package api
+
+import "github.com/itchan-dev/itchan/shared/domain"
+
+// Response DTOs
+
+type UserPostsResponse struct {
+	Posts []domain.LatestPost `json:"posts"`
+	Page  int                 `json:"page"`
+	Total int                 `json:"total"` // Number of posts across all pages
+}
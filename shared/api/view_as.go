@@ -0,0 +1,25 @@
+package api
+
+import (
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// Request DTOs
+
+type ViewAsRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// Response DTOs
+
+type ViewAsResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ViewAsSessionsResponse struct {
+	Sessions []domain.ViewAsSession `json:"sessions"`
+	Page     int                    `json:"page"`
+}
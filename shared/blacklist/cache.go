@@ -14,11 +14,13 @@ import (
 // Admin operations (blacklist/unblacklist) belong in backend-specific storage.
 type BlacklistCacheStorage interface {
 	GetRecentlyBlacklistedUsers(since time.Time) ([]domain.UserId, error)
+	GetRecentSessionRevocations(since time.Time) (map[domain.UserId]time.Time, error)
 }
 
 type Cache struct {
 	storage        BlacklistCacheStorage
 	cache          map[domain.UserId]bool
+	revocations    map[domain.UserId]time.Time
 	mu             sync.RWMutex
 	jwtTTL         time.Duration
 	lastUpdateTime time.Time
@@ -26,14 +28,17 @@ type Cache struct {
 
 func NewCache(storage BlacklistCacheStorage, jwtTTL time.Duration) *Cache {
 	return &Cache{
-		storage: storage,
-		cache:   make(map[domain.UserId]bool),
-		jwtTTL:  jwtTTL,
+		storage:     storage,
+		cache:       make(map[domain.UserId]bool),
+		revocations: make(map[domain.UserId]time.Time),
+		jwtTTL:      jwtTTL,
 	}
 }
 
-// Update fetches recently blacklisted users from the database and updates the cache.
-// It queries for users blacklisted within (JWT TTL + 10% buffer) to handle clock skew.
+// Update fetches recently blacklisted users and session revocations from the
+// database and updates the cache.
+// It queries for entries made within (JWT TTL + 10% buffer) to handle clock skew;
+// older ones can't affect an access token that is still valid.
 func (bc *Cache) Update() error {
 	// Calculate cutoff time with 10% buffer
 	bufferMultiplier := 1.1
@@ -44,6 +49,10 @@ func (bc *Cache) Update() error {
 	if err != nil {
 		return err
 	}
+	revocations, err := bc.storage.GetRecentSessionRevocations(since)
+	if err != nil {
+		return err
+	}
 
 	// Build new cache map
 	newCache := make(map[domain.UserId]bool, len(userIds))
@@ -54,12 +63,14 @@ func (bc *Cache) Update() error {
 	// Atomically replace the cache
 	bc.mu.Lock()
 	bc.cache = newCache
+	bc.revocations = revocations
 	bc.lastUpdateTime = time.Now()
 	bc.mu.Unlock()
 
 	logger.Log.Info("blacklist cache updated",
 		"component", "blacklist_cache",
 		"entries", len(newCache),
+		"revocations", len(revocations),
 		"since", since.Format(time.RFC3339))
 	return nil
 }
@@ -70,6 +81,16 @@ func (bc *Cache) IsBlacklisted(userId domain.UserId) bool {
 	return bc.cache[userId]
 }
 
+// SessionRevoked reports whether a token issued to userId at issuedAt was
+// revoked. Token times have whole seconds, so a token from the second of the
+// revocation counts as revoked.
+func (bc *Cache) SessionRevoked(userId domain.UserId, issuedAt time.Time) bool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	revokedAt, ok := bc.revocations[userId]
+	return ok && !issuedAt.After(revokedAt.Truncate(time.Second))
+}
+
 // StartBackgroundUpdate starts a background goroutine that periodically refreshes
 // the blacklist cache. It follows the same pattern as MediaGarbageCollector.
 func (bc *Cache) StartBackgroundUpdate(ctx context.Context, interval time.Duration) {
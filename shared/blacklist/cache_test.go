@@ -13,6 +13,7 @@ import (
 // Mock storage for testing - implements minimal BlacklistCacheStorage interface
 type mockBlacklistStorage struct {
 	blacklistedUsers []domain.UserId
+	revocations      map[domain.UserId]time.Time
 	err              error
 }
 
@@ -23,6 +24,13 @@ func (m *mockBlacklistStorage) GetRecentlyBlacklistedUsers(since time.Time) ([]d
 	return m.blacklistedUsers, nil
 }
 
+func (m *mockBlacklistStorage) GetRecentSessionRevocations(since time.Time) (map[domain.UserId]time.Time, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.revocations, nil
+}
+
 func TestNewCache(t *testing.T) {
 	storage := &mockBlacklistStorage{}
 	cache := NewCache(storage, time.Hour)
@@ -111,6 +119,20 @@ func TestCache_IsBlacklisted(t *testing.T) {
 	}
 }
 
+func TestCache_SessionRevoked(t *testing.T) {
+	revokedAt := time.Date(2026, 3, 1, 12, 0, 0, 500_000_000, time.UTC)
+	storage := &mockBlacklistStorage{
+		revocations: map[domain.UserId]time.Time{1: revokedAt},
+	}
+	cache := NewCache(storage, time.Hour)
+	require.NoError(t, cache.Update())
+
+	assert.True(t, cache.SessionRevoked(1, revokedAt.Add(-time.Minute)), "token issued before the revocation")
+	assert.True(t, cache.SessionRevoked(1, revokedAt.Truncate(time.Second)), "token issued in the second of the revocation")
+	assert.False(t, cache.SessionRevoked(1, revokedAt.Add(time.Second)), "token issued after the revocation")
+	assert.False(t, cache.SessionRevoked(2, revokedAt.Add(-time.Minute)), "user without revocation")
+}
+
 func TestCache_ConcurrentAccess(t *testing.T) {
 	storage := &mockBlacklistStorage{
 		blacklistedUsers: []domain.UserId{1, 2, 3},
@@ -17,6 +17,9 @@ type Config struct {
 type Public struct {
 	JwtTTL                      time.Duration `yaml:"jwt_ttl" validate:"required"`
 	ConfirmationCodeTTL         time.Duration `yaml:"confirmation_code_ttl"` // Time until confirmation code expires
+	ViewAsTTL                   time.Duration `yaml:"view_as_ttl"`           // Lifetime of the read-only token an admin gets to view the site as another user
+	RememberMeTTL               time.Duration `yaml:"remember_me_ttl"`       // Lifetime of the refresh token issued when logging in with "remember me" (default: 30 days)
+	JwtRenewAfter               time.Duration `yaml:"jwt_renew_after"`       // Access tokens older than this are reissued on the next request (default: half of jwt_ttl)
 	ThreadsPerPage              int           `yaml:"threads_per_page" validate:"required"`
 	MessagesPerThreadPage       int           `yaml:"messages_per_thread_page"` // number of messages per thread page (0 = all)
 	MaxThreadCount              *int          `yaml:"max_thread_count"`
@@ -24,24 +27,63 @@ type Public struct {
 	BumpLimit                   int           `yaml:"bump_limit" validate:"required"` // if thread have more messages it will not get "bumped"
 	BoardPreviewRefreshInterval time.Duration `yaml:"board_preview_refresh_internval" validate:"required"`
 	BoardActivityWindow         time.Duration `yaml:"board_activity_window"`                        // How far back to check for board activity (should be > refresh interval)
+	IncrementalBoardPreviews    bool          `yaml:"incremental_board_previews"`                   // Serve board pages from the thread_previews table instead of refreshed materialized views
 	BlacklistCacheInterval      int           `yaml:"blacklist_cache_interval" validate:"required"` // Interval in seconds to refresh blacklist cache
+	SitemapRefreshInterval      time.Duration `yaml:"sitemap_refresh_interval"`                     // How often the frontend regenerates sitemap.xml
+	BoardAccessCacheTTL         time.Duration `yaml:"board_access_cache_ttl"`                       // How long board permissions, pending deletions and redirects are cached (default: 1m)
+
+	// Database query timeouts, applied on top of the request context
+	QueryTimeout       time.Duration `yaml:"query_timeout"`        // Limit for ordinary reads and writes (default: 5s)
+	LongQueryTimeout   time.Duration `yaml:"long_query_timeout"`   // Limit for board creation/deletion, thread deletion and statistics (default: 30s)
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"` // Queries taking longer are logged as warnings (disabled when 0)
+	StatementTimeout   time.Duration `yaml:"statement_timeout"`    // PostgreSQL statement_timeout set in every transaction, at least long_query_timeout for heavy operations (disabled when 0)
+	TxRetryAttempts    int           `yaml:"tx_retry_attempts"`    // Runs of a PostgreSQL transaction failing on a serialization failure or deadlock (default: 3)
+
+	// Backend request timeouts, set per route group; a request past its
+	// timeout is cancelled and answered with 504
+	ReadRequestTimeout   time.Duration `yaml:"read_request_timeout"`   // Limit for public reads (default: 10s)
+	WriteRequestTimeout  time.Duration `yaml:"write_request_timeout"`  // Limit for auth, user and admin requests (default: 1m)
+	UploadRequestTimeout time.Duration `yaml:"upload_request_timeout"` // Limit for requests carrying files: posting and banner uploads (default: 10m)
 
 	// Security settings
 	SecureCookies bool `yaml:"secure_cookies"` // Enable Secure flag on cookies (requires HTTPS)
 	CSRFEnabled   bool `yaml:"csrf_enabled"`   // Enable CSRF protection (default: true)
 
+	// Answer users who may not see a restricted board with 404, as if it did
+	// not exist, rather than 401/403 (boards, threads, messages and media),
+	// and leave them out of the board listings
+	HideRestrictedBoards bool `yaml:"hide_restricted_boards"`
+
+	// Sites allowed to show frontend pages in a frame, as CSP sources such as
+	// 'self' or https://intranet.example.com (default: none)
+	FrameAncestors []string `yaml:"frame_ancestors" validate:"dive,required,excludesall=;0x2C"`
+	ReferrerPolicy string   `yaml:"referrer_policy" validate:"omitempty,oneof=no-referrer no-referrer-when-downgrade origin origin-when-cross-origin same-origin strict-origin strict-origin-when-cross-origin"` // Default: strict-origin-when-cross-origin
+
 	// Logging settings
 	LogLevel  string `yaml:"log_level"`  // Log level: debug, info, warn, error (default: info)
 	LogFormat string `yaml:"log_format"` // Log format: text or json (default: text)
 
+	// Debug and info records with the same message are written at most
+	// log_sample_initial times a second, then one in log_sample_thereafter;
+	// warnings and errors are always written (disabled when initial is 0)
+	LogSampleInitial    int `yaml:"log_sample_initial" validate:"gte=0"`
+	LogSampleThereafter int `yaml:"log_sample_thereafter" validate:"gte=0"`
+
 	// Validation constants (optional; sensible defaults are used when zero)
-	BoardNameMaxLen      int `yaml:"board_name_max_len"`
-	BoardShortNameMaxLen int `yaml:"board_short_name_max_len"`
-	ThreadTitleMaxLen    int `yaml:"thread_title_max_len"`
-	MessageTextMaxLen    int `yaml:"message_text_max_len"`
-	MessageTextMinLen    int `yaml:"message_text_min_len"`
-	ConfirmationCodeLen  int `yaml:"confirmation_code_len"`
-	PasswordMinLen       int `yaml:"password_min_len"`
+	BoardNameMaxLen         int `yaml:"board_name_max_len"`
+	BoardShortNameMaxLen    int `yaml:"board_short_name_max_len"`
+	BoardCategoryNameMaxLen int `yaml:"board_category_name_max_len"`
+	BoardDescriptionMaxLen  int `yaml:"board_description_max_len"`
+	ThreadTitleMaxLen       int `yaml:"thread_title_max_len"`
+	MessageTextMaxLen       int `yaml:"message_text_max_len"`
+	MessageTextMinLen       int `yaml:"message_text_min_len"`
+	MessageTextMaxLines     int `yaml:"message_text_max_lines"`  // Max lines of a message, counted in the rendered text
+	MessageTextMaxRepeat    int `yaml:"message_text_max_repeat"` // Max run of one repeated character
+	MessageTextMaxMarks     int `yaml:"message_text_max_marks"`  // Max combining marks on one character, see the boards' text_filter
+	ConfirmationCodeLen     int `yaml:"confirmation_code_len"`
+	PasswordMinLen          int `yaml:"password_min_len"`
+	DeletionReasonMaxLen    int `yaml:"deletion_reason_max_len"` // Max length of a moderator's reason for deleting a message
+	AppealTextMaxLen        int `yaml:"appeal_text_max_len"`     // Max length of a banned user's appeal text
 
 	// Attachment validation constants (optional; sensible defaults are used when zero)
 	MaxAttachmentsPerMessage int      `yaml:"max_attachments_per_message"`
@@ -50,6 +92,26 @@ type Public struct {
 	MaxDecodedImageSize      int64    `yaml:"max_decoded_image_size"` // Max decoded pixel buffer size in bytes (prevents image bomb OOM)
 	AllowedImageMimeTypes    []string `yaml:"allowed_image_mime_types"`
 	AllowedVideoMimeTypes    []string `yaml:"allowed_video_mime_types"`
+	AllowedDocumentMimeTypes []string `yaml:"allowed_document_mime_types" validate:"dive,oneof=application/pdf text/plain"` // Only on boards with allow_documents
+	MaxDocumentSizeBytes     int64    `yaml:"max_document_size_bytes"`                                                      // Per-file limit for PDFs and text files
+
+	// Files uploaded ahead of a post (POST /v1/{board}/uploads) are dropped unless a post claims them within this time
+	UploadTTL time.Duration `yaml:"upload_ttl"`
+
+	// Responses to post creation requests with an Idempotency-Key header are replayed to retries with the same key for this long
+	IdempotencyKeyTTL time.Duration `yaml:"idempotency_key_ttl"`
+
+	// Uploads refused by file extension or by declared or sniffed MIME type, even if allowed above (defaults when empty)
+	BlockedFileExtensions []string `yaml:"blocked_file_extensions"`
+	BlockedMimeTypes      []string `yaml:"blocked_mime_types"`
+
+	// Board banners and custom stylesheets (optional; sensible defaults are used when zero)
+	BoardCustomCSSMaxLen int   `yaml:"board_custom_css_max_len"` // Max length of a board's custom CSS in characters
+	MaxBannerSizeBytes   int64 `yaml:"max_banner_size_bytes"`    // Per-file limit for banner images
+	MaxBannersPerBoard   int   `yaml:"max_banners_per_board"`
+
+	// Static HTML snapshots of threads, kept after the thread is pruned
+	ThreadSnapshotMaxImageBytes int64 `yaml:"thread_snapshot_max_image_bytes"` // Total size of thumbnails embedded in one snapshot; later files are listed by name
 
 	// Invite system configuration
 	InviteEnabled           bool          `yaml:"invite_enabled"`
@@ -58,18 +120,61 @@ type Public struct {
 	MaxInvitesPerUser       int           `yaml:"max_invites_per_user"`
 	MinAccountAgeForInvites time.Duration `yaml:"min_account_age_for_invites"`
 
+	// Email users when they log in from a device (browser and network) they
+	// have not logged in from before
+	NewDeviceLoginEmails bool `yaml:"new_device_login_emails"`
+
+	// New account probation (disabled when NewAccountAge is zero)
+	NewAccountAge          time.Duration `yaml:"new_account_age"`           // Younger accounts cannot create threads or upload attachments
+	NewAccountPostCooldown time.Duration `yaml:"new_account_post_cooldown"` // Minimum time between posts of an account on probation
+
+	SelfDeleteWindow      time.Duration `yaml:"self_delete_window"`       // How long authors may delete their own replies (0 = never)
+	ThreadTitleEditWindow time.Duration `yaml:"thread_title_edit_window"` // How long the OP may retitle their thread (0 = moderators only)
+
+	BoardDeletionGracePeriod  time.Duration `yaml:"board_deletion_grace_period"`  // Deleted boards are hidden this long before they are dropped (0 = at once)
+	BoardRenameRedirectPeriod time.Duration `yaml:"board_rename_redirect_period"` // Old links of a renamed board redirect this long (0 = no redirect)
+
 	// User activity page settings
 	UserMessagesPageLimit int `yaml:"user_messages_page_limit"` // Number of messages/replies shown on account page
+	UserPostsPageLimit    int `yaml:"user_posts_page_limit"`    // Number of posts per page in the user's post history
+
+	// Index page activity widgets
+	ActivityLatestPostsLimit int           `yaml:"activity_latest_posts_limit"` // Number of latest posts shown on the index page
+	ActivityThreadsLimit     int           `yaml:"activity_threads_limit"`      // Number of most active threads shown on the index page
+	ActivityCacheTTL         time.Duration `yaml:"activity_cache_ttl"`          // How long computed activity is reused before querying again
+
+	// Admin statistics
+	BoardStatsRetention time.Duration `yaml:"board_stats_retention"` // How long hourly post counts per board are kept
 
 	// Pagination limits
-	BlacklistPageLimit int `yaml:"blacklist_page_limit"` // Number of blacklisted users per page on admin panel
-	InvitesPageLimit   int `yaml:"invites_page_limit"`   // Number of invite codes per page on invites page
+	BlacklistPageLimit       int `yaml:"blacklist_page_limit"`        // Number of blacklisted users per page on admin panel
+	InvitesPageLimit         int `yaml:"invites_page_limit"`          // Number of invite codes per page on invites page
+	AppealsPageLimit         int `yaml:"appeals_page_limit"`          // Number of ban appeals per page in the moderation queue
+	ShadowbansPageLimit      int `yaml:"shadowbans_page_limit"`       // Number of shadowbans per page on admin panel
+	ViewAsPageLimit          int `yaml:"view_as_page_limit"`          // Number of view-as sessions per page in the admin audit log
+	BoardsPageLimit          int `yaml:"boards_page_limit"`           // Number of boards per page in the paginated board listing
+	NotificationsPageLimit   int `yaml:"notifications_page_limit"`    // Number of notifications per page in the notification center
+	ModLogPageLimit          int `yaml:"modlog_page_limit"`           // Number of entries per page of a board's public moderation log
+	FailedPostsPageLimit     int `yaml:"failed_posts_page_limit"`     // Number of captured failed posts per page on the admin page
+	ThreadSnapshotsPageLimit int `yaml:"thread_snapshots_page_limit"` // Number of thread snapshots per page of a board's snapshot list
+
+	// Email digests of watched threads and replies to own posts (disabled when SiteURL is empty)
+	SiteURL             string        `yaml:"site_url"`              // Public origin used for links in emails, e.g. https://itchan.ru
+	EmailDigestInterval time.Duration `yaml:"email_digest_interval"` // How often due digests are looked for and sent
+
+	// Instance description for the site footer and GET /v1/meta, which
+	// directories listing several instances read
+	Instance InstanceConfig `yaml:"instance"`
+
+	// Browser push notifications for replies (disabled when VAPIDPublicKey is empty)
+	VAPIDPublicKey string `yaml:"vapid_public_key"` // Base64url P-256 public key; the private half is in private.yaml
 
 	// Message processing settings
-	MaxRepliesPerMessage int `yaml:"max_replies_per_message"` // Maximum number of >>thread#msg reply links per message
+	MaxRepliesPerMessage int    `yaml:"max_replies_per_message"`                      // Maximum number of >>thread#msg reply links per message
+	PostNumbering        string `yaml:"post_numbering" validate:"oneof=thread board"` // Numbers shown for posts and links: thread (thread#msg) or board (board-local post numbers); default: thread
 
 	// Static file caching
-	StaticCacheMaxAge time.Duration `yaml:"static_cache_max_age"` // Cache duration for static files (CSS, JS, images)
+	StaticCacheMaxAge time.Duration `yaml:"static_cache_max_age"` // Cache duration for static files requested without their content hash
 	MediaCacheMaxAge  time.Duration `yaml:"media_cache_max_age"`  // Cache duration for user-uploaded media files
 
 	// Registration restrictions
@@ -77,6 +182,76 @@ type Public struct {
 
 	// Media processing settings
 	Media MediaConfig `yaml:"media"`
+
+	// Automatic temporary bans (empty = disabled)
+	AutoBanRules []AutoBanRule `yaml:"auto_ban_rules" validate:"dive"`
+
+	// Disk usage monitoring of the media root and the database
+	DiskMonitor DiskMonitorConfig `yaml:"disk_monitor"`
+
+	// Read-only mirrors of boards of other instances (experimental)
+	Federation FederationConfig `yaml:"federation"`
+
+	// Accounts created in bulk by admins from a list of emails
+	UserImport UserImportConfig `yaml:"user_import"`
+
+	// Snapshots of failed post requests for diagnosing lost posts (off by default)
+	FailedPostCapture FailedPostCaptureConfig `yaml:"failed_post_capture"`
+}
+
+// InstanceConfig describes the instance to its users and to other sites.
+type InstanceConfig struct {
+	Name         string `yaml:"name"`                               // Display name (default: Itchan)
+	Description  string `yaml:"description"`                        // A sentence or two about the instance
+	RulesURL     string `yaml:"rules_url" validate:"omitempty,url"` // Page with the instance rules
+	AdminContact string `yaml:"admin_contact"`                      // Email address or URL of the administration
+	Footer       string `yaml:"footer"`                             // Markdown shown at the bottom of every page, e.g. a content license
+}
+
+// DiskMonitorConfig sets when the disk usage monitor alerts and stops uploads.
+// Media usage is of the filesystem holding the media root, database usage is
+// of MaxDatabaseBytes.
+type DiskMonitorConfig struct {
+	Interval         time.Duration `yaml:"interval"`                                // How often usage is measured (default: 1m)
+	AlertPercent     float64       `yaml:"alert_percent" validate:"gte=0,lte=100"`  // Usage that sends an alert to alert_webhook_url (default: 85)
+	FreezePercent    float64       `yaml:"freeze_percent" validate:"gte=0,lte=100"` // Usage at which new uploads are refused (default: 95)
+	MaxDatabaseBytes int64         `yaml:"max_database_bytes" validate:"gte=0"`     // Database size quota, 0 = database size is not checked
+}
+
+// FederationConfig sets how often mirrored boards are copied from their
+// source instances.
+type FederationConfig struct {
+	Interval       time.Duration `yaml:"interval"`                          // Time between syncs of all mirrored boards (default: 15m)
+	ThreadsPerSync int           `yaml:"threads_per_sync" validate:"gte=0"` // Changed threads copied per board and sync, the rest wait for the next one (default: 20)
+	Timeout        time.Duration `yaml:"timeout"`                           // Timeout of each request to a source instance (default: 30s)
+}
+
+// UserImportConfig limits bulk account imports and paces their invitation
+// emails, so a company-wide import does not trip the SMTP server's limits.
+type UserImportConfig struct {
+	MaxRows       int           `yaml:"max_rows" validate:"gte=0"`   // Emails accepted per import (default: 10000)
+	BatchSize     int           `yaml:"batch_size" validate:"gte=0"` // Invitation emails sent back to back (default: 50)
+	BatchInterval time.Duration `yaml:"batch_interval"`              // Pause between batches of invitations (default: 1m)
+}
+
+// FailedPostCaptureConfig turns on saving refused and failed thread and
+// message creation requests for admins to look at.
+type FailedPostCaptureConfig struct {
+	Enabled   bool          `yaml:"enabled"`   // Capture failed posts (default: false)
+	Retention time.Duration `yaml:"retention"` // How long captures are kept (default: 168h)
+}
+
+// Auto-ban signals
+const (
+	AutoBanSignalDeletedPosts = "deleted_posts" // Posts removed by moderators
+)
+
+// AutoBanRule bans a user for BanDuration once Threshold signals occur within Window.
+type AutoBanRule struct {
+	Signal      string        `yaml:"signal" validate:"oneof=deleted_posts"`
+	Threshold   int           `yaml:"threshold" validate:"min=1"`
+	Window      time.Duration `yaml:"window" validate:"gt=0"`
+	BanDuration time.Duration `yaml:"ban_duration" validate:"gt=0"`
 }
 
 type MediaConfig struct {
@@ -85,6 +260,35 @@ type MediaConfig struct {
 	ThumbnailDisplayReply int `yaml:"thumbnail_display_reply"` // Display size (px) for reply post thumbnails (frontend)
 	JpegQualityMain       int `yaml:"jpeg_quality_main"`       // JPEG quality for main images (0-100)
 	JpegQualityThumbnail  int `yaml:"jpeg_quality_thumbnail"`  // JPEG quality for thumbnails (0-100)
+	TextPreviewChars      int `yaml:"text_preview_chars"`      // Characters of a text attachment shown inline in the thread
+
+	VideoProfiles       map[string]VideoProfile `yaml:"video_profiles" validate:"dive"` // Named video output profiles, selectable per board
+	DefaultVideoProfile string                  `yaml:"default_video_profile"`          // Profile for boards that don't select one
+}
+
+// Video codecs a VideoProfile can transcode to
+const (
+	VideoCodecH264 = "h264" // H.264 + AAC in MP4
+	VideoCodecVP9  = "vp9"  // VP9 + Opus in WebM
+)
+
+// VideoProfile describes how uploaded videos are processed. Without a codec the
+// original streams are kept, so oversized videos can only be rejected.
+type VideoProfile struct {
+	Codec           string `yaml:"codec" validate:"omitempty,oneof=h264 vp9"`
+	MaxWidth        int    `yaml:"max_width" validate:"gte=0"`        // 0 = unlimited
+	MaxHeight       int    `yaml:"max_height" validate:"gte=0"`       // 0 = unlimited
+	MaxBitrateKbps  int    `yaml:"max_bitrate_kbps" validate:"gte=0"` // Video bitrate cap when transcoding, 0 = encoder default
+	RejectOversized bool   `yaml:"reject_oversized"`                  // Reject videos over MaxWidth/MaxHeight instead of downscaling
+}
+
+// VideoProfileFor returns the named profile, or the default one for an empty
+// or unknown name.
+func (m MediaConfig) VideoProfileFor(name string) VideoProfile {
+	if profile, ok := m.VideoProfiles[name]; ok && name != "" {
+		return profile
+	}
+	return m.VideoProfiles[m.DefaultVideoProfile]
 }
 
 type Pg struct {
@@ -95,6 +299,23 @@ type Pg struct {
 	Dbname   string `yaml:"dbname" validate:"required"`
 }
 
+// Sqlite configures the SQLite database used when storage is "sqlite".
+type Sqlite struct {
+	Path string `yaml:"path" validate:"required"` // Database file, created on first start
+}
+
+// Post numbering modes selectable with the public post_numbering setting.
+const (
+	PostNumberingThread = "thread"
+	PostNumberingBoard  = "board"
+)
+
+// Storage backends selectable with the private storage setting.
+const (
+	StoragePostgres = "postgres"
+	StorageSqlite   = "sqlite"
+)
+
 type Email struct {
 	SMTPServer string `yaml:"smtp_server" validate:"required"`
 	SMTPPort   int    `yaml:"smtp_port" validate:"required"`
@@ -104,12 +325,63 @@ type Email struct {
 	Timeout    int    `yaml:"timeout"`
 }
 
+// WebPush holds the secrets for sending browser push notifications.
+type WebPush struct {
+	VAPIDPrivateKey string `yaml:"vapid_private_key"` // Base64url P-256 private key matching the public vapid_public_key
+	Subject         string `yaml:"subject"`           // Contact given to push services, a mailto: or https: URL
+}
+
+// Tracing configures the export of OpenTelemetry traces (disabled without an endpoint).
+type Tracing struct {
+	Endpoint    string  `yaml:"endpoint"`                            // host:port of an OTLP/HTTP collector, e.g. localhost:4318
+	Insecure    bool    `yaml:"insecure"`                            // Plain HTTP instead of HTTPS to the collector
+	SampleRatio float64 `yaml:"sample_ratio" validate:"gte=0,lte=1"` // Share of new traces recorded (default 1)
+}
+
+// DirectorySync deactivates the accounts of people who left the organization,
+// as told by an external directory (disabled without a url).
+type DirectorySync struct {
+	URL              string        `yaml:"url" validate:"omitempty,url"`                // List endpoint, or SCIM base URL
+	Format           string        `yaml:"format" validate:"omitempty,oneof=list scim"` // list (default) or scim
+	Token            string        `yaml:"token"`                                       // Bearer token for the directory; empty = none
+	Domains          []string      `yaml:"domains"`                                     // Domains whose accounts the directory covers; empty = allowed_registration_domains
+	Interval         time.Duration `yaml:"interval"`                                    // Time between syncs (default: 1h)
+	Timeout          time.Duration `yaml:"timeout"`                                     // Timeout of the directory requests (default: 30s)
+	MaxDeactivations int           `yaml:"max_deactivations" validate:"gte=0"`          // A sync deactivating more accounts is refused unless forced (default: 50)
+}
+
+// Backup runs scheduled database and media backups through external commands
+// (disabled when both commands are empty). In the argv of either command
+// {time} is replaced with the start time, e.g. 20240131T020000Z.
+type Backup struct {
+	DatabaseCommand []string      `yaml:"database_command"` // Dumps the database, e.g. pg_dump or a hook script
+	MediaCommand    []string      `yaml:"media_command"`    // Copies the media root, e.g. rsync or aws s3 sync; skipped when the dump failed
+	Interval        time.Duration `yaml:"interval"`         // Time between backups (default: 24h)
+	Timeout         time.Duration `yaml:"timeout"`          // Limit of each command (default: 1h)
+	PauseWrites     time.Duration `yaml:"pause_writes"`     // Writes are refused while the database command runs, for at most this long; 0 = no pause
+	DrainTimeout    time.Duration `yaml:"drain_timeout"`    // Wait for writes in progress before the database command starts (default: 10s)
+}
+
 type Private struct {
-	Pg            Pg       `yaml:"pg"`
+	Storage       string   `yaml:"storage" validate:"oneof=postgres sqlite"` // Database backend (default: postgres)
+	Pg            Pg       `yaml:"pg" validate:"-"`                          // Required when storage is postgres
+	Sqlite        Sqlite   `yaml:"sqlite" validate:"-"`                      // Required when storage is sqlite
 	Email         Email    `yaml:"email"`
+	WebPush       WebPush  `yaml:"web_push"` // Required when vapid_public_key is set
 	JwtKey        string   `yaml:"jwt_key" validate:"required"`
 	EncryptionKey string   `yaml:"encryption_key" validate:"required"`
-	AllowedRefs   []string `yaml:"allowed_refs"` // Allowlist of ref= param values to track; empty = allow all
+	EmailIndexKey string   `yaml:"email_index_key"` // Keys the blind index for admin email lookup; empty = lookup disabled
+	AllowedRefs   []string `yaml:"allowed_refs"`    // Allowlist of ref= param values to track; empty = allow all
+
+	// Former encryption keys, still decrypting emails until
+	// cmd/rotate-encryption-key has moved them to encryption_key
+	PreviousEncryptionKeys []string `yaml:"previous_encryption_keys"`
+
+	AlertWebhookURL string `yaml:"alert_webhook_url"` // Receives operational alerts as JSON; empty = alerts are only logged
+
+	Tracing       Tracing       `yaml:"tracing"`
+	DirectorySync DirectorySync `yaml:"directory_sync"`
+	Backup        Backup        `yaml:"backup"`
 }
 
 // implementing logic.Config interface
@@ -148,14 +420,52 @@ func MustLoad(configFolder string) *Config {
 
 	// Apply default values for validation constants if not set
 	applyValidationDefaults(&public)
+	if private.Storage == "" {
+		private.Storage = StoragePostgres
+	}
+	if private.Tracing.SampleRatio == 0 {
+		private.Tracing.SampleRatio = 1
+	}
+	if private.DirectorySync.Format == "" {
+		private.DirectorySync.Format = "list"
+	}
+	if private.DirectorySync.Interval == 0 {
+		private.DirectorySync.Interval = time.Hour
+	}
+	if private.DirectorySync.Timeout == 0 {
+		private.DirectorySync.Timeout = 30 * time.Second
+	}
+	if private.DirectorySync.MaxDeactivations == 0 {
+		private.DirectorySync.MaxDeactivations = 50
+	}
+	if private.Backup.Interval == 0 {
+		private.Backup.Interval = 24 * time.Hour
+	}
+	if private.Backup.Timeout == 0 {
+		private.Backup.Timeout = time.Hour
+	}
+	if private.Backup.DrainTimeout == 0 {
+		private.Backup.DrainTimeout = 10 * time.Second
+	}
 
 	validate := validator.New(validator.WithRequiredStructEnabled())
 	if err := validate.Struct(public); err != nil {
 		panic("public config validation failed: " + err.Error())
 	}
+	if _, ok := public.Media.VideoProfiles[public.Media.DefaultVideoProfile]; !ok {
+		panic("public config validation failed: unknown default_video_profile " + public.Media.DefaultVideoProfile)
+	}
 	if err := validate.Struct(private); err != nil {
 		panic("private config validation failed: " + err.Error())
 	}
+	// Only the settings of the selected database backend are required
+	storageSettings := any(private.Pg)
+	if private.Storage == StorageSqlite {
+		storageSettings = private.Sqlite
+	}
+	if err := validate.Struct(storageSettings); err != nil {
+		panic("private config validation failed: " + err.Error())
+	}
 
 	return &Config{public, private}
 }
@@ -169,6 +479,12 @@ func applyValidationDefaults(public *Public) {
 	if public.LogFormat == "" {
 		public.LogFormat = "text"
 	}
+	if public.PostNumbering == "" {
+		public.PostNumbering = PostNumberingThread
+	}
+	if public.Instance.Name == "" {
+		public.Instance.Name = "Itchan"
+	}
 
 	if public.BoardNameMaxLen == 0 {
 		public.BoardNameMaxLen = 10
@@ -176,6 +492,30 @@ func applyValidationDefaults(public *Public) {
 	if public.BoardShortNameMaxLen == 0 {
 		public.BoardShortNameMaxLen = 3
 	}
+	if public.BoardCategoryNameMaxLen == 0 {
+		public.BoardCategoryNameMaxLen = 30
+	}
+	if public.BoardDescriptionMaxLen == 0 {
+		public.BoardDescriptionMaxLen = 300
+	}
+	if public.BoardCustomCSSMaxLen == 0 {
+		public.BoardCustomCSSMaxLen = 10000
+	}
+	if public.MaxBannerSizeBytes == 0 {
+		public.MaxBannerSizeBytes = 1 << 20
+	}
+	if public.MaxBannersPerBoard == 0 {
+		public.MaxBannersPerBoard = 10
+	}
+	if public.ThreadSnapshotMaxImageBytes == 0 {
+		public.ThreadSnapshotMaxImageBytes = 10 << 20
+	}
+	if public.DeletionReasonMaxLen == 0 {
+		public.DeletionReasonMaxLen = 200
+	}
+	if public.AppealTextMaxLen == 0 {
+		public.AppealTextMaxLen = 2000
+	}
 	if public.ThreadTitleMaxLen == 0 {
 		public.ThreadTitleMaxLen = 50
 	}
@@ -185,6 +525,15 @@ func applyValidationDefaults(public *Public) {
 	if public.MessageTextMinLen == 0 {
 		public.MessageTextMinLen = 1
 	}
+	if public.MessageTextMaxLines == 0 {
+		public.MessageTextMaxLines = 200
+	}
+	if public.MessageTextMaxRepeat == 0 {
+		public.MessageTextMaxRepeat = 100
+	}
+	if public.MessageTextMaxMarks == 0 {
+		public.MessageTextMaxMarks = 4
+	}
 	if public.ConfirmationCodeLen == 0 {
 		public.ConfirmationCodeLen = 6
 	}
@@ -194,6 +543,15 @@ func applyValidationDefaults(public *Public) {
 	if public.ConfirmationCodeTTL == 0 {
 		public.ConfirmationCodeTTL = 10 * time.Minute
 	}
+	if public.ViewAsTTL == 0 {
+		public.ViewAsTTL = 15 * time.Minute
+	}
+	if public.RememberMeTTL == 0 {
+		public.RememberMeTTL = 30 * 24 * time.Hour
+	}
+	if public.JwtRenewAfter == 0 {
+		public.JwtRenewAfter = public.JwtTTL / 2
+	}
 
 	// Attachment defaults
 	if public.MaxAttachmentsPerMessage == 0 {
@@ -205,6 +563,29 @@ func applyValidationDefaults(public *Public) {
 	if public.MaxTotalAttachmentSize == 0 {
 		public.MaxTotalAttachmentSize = 20 * 1024 * 1024 // 20MB total
 	}
+	if public.UploadTTL == 0 {
+		public.UploadTTL = time.Hour
+	}
+	if public.IdempotencyKeyTTL == 0 {
+		public.IdempotencyKeyTTL = 24 * time.Hour
+	}
+	if len(public.BlockedFileExtensions) == 0 {
+		public.BlockedFileExtensions = []string{
+			".svg", ".svgz", ".html", ".htm", ".xhtml", ".xml", ".js", ".mjs",
+			".exe", ".dll", ".bat", ".cmd", ".com", ".scr", ".msi", ".ps1", ".sh", ".jar", ".apk",
+		}
+	}
+	if len(public.BlockedMimeTypes) == 0 {
+		public.BlockedMimeTypes = []string{
+			"image/svg+xml",
+			"text/html",
+			"application/xhtml+xml",
+			"text/xml",
+			"application/xml",
+			"application/javascript",
+			"text/javascript",
+		}
+	}
 	if public.MaxDecodedImageSize == 0 {
 		public.MaxDecodedImageSize = 20 * 1024 * 1024 // 20MB decoded pixel buffer (prevents image bomb OOM)
 	}
@@ -222,6 +603,15 @@ func applyValidationDefaults(public *Public) {
 			"video/ogg",
 		}
 	}
+	if len(public.AllowedDocumentMimeTypes) == 0 {
+		public.AllowedDocumentMimeTypes = []string{
+			"application/pdf",
+			"text/plain",
+		}
+	}
+	if public.MaxDocumentSizeBytes == 0 {
+		public.MaxDocumentSizeBytes = 2 * 1024 * 1024 // 2MB per document
+	}
 
 	// Invite system defaults
 	if public.InviteEnabled {
@@ -244,6 +634,20 @@ func applyValidationDefaults(public *Public) {
 		public.UserMessagesPageLimit = 50
 	}
 
+	// Activity widget defaults
+	if public.ActivityLatestPostsLimit == 0 {
+		public.ActivityLatestPostsLimit = 10
+	}
+	if public.ActivityThreadsLimit == 0 {
+		public.ActivityThreadsLimit = 5
+	}
+	if public.ActivityCacheTTL == 0 {
+		public.ActivityCacheTTL = 30 * time.Second
+	}
+	if public.BoardStatsRetention == 0 {
+		public.BoardStatsRetention = 720 * time.Hour
+	}
+
 	// Pagination defaults
 	if public.BlacklistPageLimit == 0 {
 		public.BlacklistPageLimit = 20
@@ -251,6 +655,85 @@ func applyValidationDefaults(public *Public) {
 	if public.InvitesPageLimit == 0 {
 		public.InvitesPageLimit = 20
 	}
+	if public.AppealsPageLimit == 0 {
+		public.AppealsPageLimit = 20
+	}
+	if public.ShadowbansPageLimit == 0 {
+		public.ShadowbansPageLimit = 20
+	}
+	if public.ViewAsPageLimit == 0 {
+		public.ViewAsPageLimit = 20
+	}
+	if public.FailedPostsPageLimit == 0 {
+		public.FailedPostsPageLimit = 20
+	}
+	if public.ThreadSnapshotsPageLimit == 0 {
+		public.ThreadSnapshotsPageLimit = 20
+	}
+	if public.BoardsPageLimit == 0 {
+		public.BoardsPageLimit = 50
+	}
+	if public.NotificationsPageLimit == 0 {
+		public.NotificationsPageLimit = 20
+	}
+	if public.UserPostsPageLimit == 0 {
+		public.UserPostsPageLimit = 50
+	}
+	if public.ModLogPageLimit == 0 {
+		public.ModLogPageLimit = 50
+	}
+
+	// Query timeout defaults
+	if public.QueryTimeout == 0 {
+		public.QueryTimeout = 5 * time.Second
+	}
+	if public.LongQueryTimeout == 0 {
+		public.LongQueryTimeout = 30 * time.Second
+	}
+	if public.TxRetryAttempts == 0 {
+		public.TxRetryAttempts = 3
+	}
+	if public.ReadRequestTimeout == 0 {
+		public.ReadRequestTimeout = 10 * time.Second
+	}
+	if public.WriteRequestTimeout == 0 {
+		public.WriteRequestTimeout = time.Minute
+	}
+	if public.UploadRequestTimeout == 0 {
+		public.UploadRequestTimeout = 10 * time.Minute
+	}
+
+	// Disk monitor defaults
+	if public.DiskMonitor.Interval == 0 {
+		public.DiskMonitor.Interval = time.Minute
+	}
+	if public.DiskMonitor.AlertPercent == 0 {
+		public.DiskMonitor.AlertPercent = 85
+	}
+	if public.DiskMonitor.FreezePercent == 0 {
+		public.DiskMonitor.FreezePercent = 95
+	}
+	if public.Federation.Interval == 0 {
+		public.Federation.Interval = 15 * time.Minute
+	}
+	if public.Federation.ThreadsPerSync == 0 {
+		public.Federation.ThreadsPerSync = 20
+	}
+	if public.Federation.Timeout == 0 {
+		public.Federation.Timeout = 30 * time.Second
+	}
+	if public.UserImport.MaxRows == 0 {
+		public.UserImport.MaxRows = 10000
+	}
+	if public.UserImport.BatchSize == 0 {
+		public.UserImport.BatchSize = 50
+	}
+	if public.UserImport.BatchInterval == 0 {
+		public.UserImport.BatchInterval = time.Minute
+	}
+	if public.FailedPostCapture.Retention == 0 {
+		public.FailedPostCapture.Retention = 7 * 24 * time.Hour
+	}
 
 	// Thread pagination defaults
 	if public.MessagesPerThreadPage == 0 {
@@ -262,11 +745,22 @@ func applyValidationDefaults(public *Public) {
 		public.BoardActivityWindow = public.BoardPreviewRefreshInterval * 6
 	}
 
+	if public.SitemapRefreshInterval == 0 {
+		public.SitemapRefreshInterval = time.Hour
+	}
+	if public.BoardAccessCacheTTL == 0 {
+		public.BoardAccessCacheTTL = time.Minute
+	}
+
 	// CSRF protection default (enabled by default for security)
 	if !public.CSRFEnabled {
 		public.CSRFEnabled = true
 	}
 
+	if public.EmailDigestInterval == 0 {
+		public.EmailDigestInterval = time.Hour
+	}
+
 	// Message processing defaults
 	if public.MaxRepliesPerMessage == 0 {
 		public.MaxRepliesPerMessage = 50
@@ -303,4 +797,13 @@ func applyValidationDefaults(public *Public) {
 	if public.Media.JpegQualityThumbnail == 0 {
 		public.Media.JpegQualityThumbnail = 75
 	}
+	if public.Media.TextPreviewChars == 0 {
+		public.Media.TextPreviewChars = 500
+	}
+	if len(public.Media.VideoProfiles) == 0 {
+		public.Media.VideoProfiles = map[string]VideoProfile{"original": {}} // Strip metadata, keep streams as uploaded
+	}
+	if public.Media.DefaultVideoProfile == "" {
+		public.Media.DefaultVideoProfile = "original"
+	}
 }
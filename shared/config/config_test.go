@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/go-playground/validator/v10"
 )
 
 func TestMustLoad_RequiredFields(t *testing.T) {
@@ -26,3 +28,38 @@ func TestMustLoad_RequiredFields(t *testing.T) {
 
 	_ = MustLoad(dir)
 }
+
+func TestVideoProfiles(t *testing.T) {
+	t.Run("defaults keep the uploaded streams", func(t *testing.T) {
+		var public Public
+		applyValidationDefaults(&public)
+		if got := public.Media.VideoProfileFor(""); got != (VideoProfile{}) {
+			t.Fatalf("expected stream copy profile, got %+v", got)
+		}
+	})
+
+	t.Run("unknown names fall back to the default", func(t *testing.T) {
+		media := MediaConfig{
+			VideoProfiles:       map[string]VideoProfile{"web": {Codec: VideoCodecVP9, MaxWidth: 640}, "hd": {Codec: VideoCodecH264}},
+			DefaultVideoProfile: "web",
+		}
+		if got := media.VideoProfileFor("hd"); got.Codec != VideoCodecH264 {
+			t.Fatalf("expected hd profile, got %+v", got)
+		}
+		if got := media.VideoProfileFor("missing"); got.Codec != VideoCodecVP9 || got.MaxWidth != 640 {
+			t.Fatalf("expected default profile, got %+v", got)
+		}
+	})
+
+	t.Run("codec validation", func(t *testing.T) {
+		validate := validator.New(validator.WithRequiredStructEnabled())
+		valid := MediaConfig{VideoProfiles: map[string]VideoProfile{"a": {}, "b": {Codec: VideoCodecH264}, "c": {Codec: VideoCodecVP9}}}
+		if err := validate.Struct(valid); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		invalid := MediaConfig{VideoProfiles: map[string]VideoProfile{"x": {Codec: "av1"}}}
+		if err := validate.Struct(invalid); err == nil {
+			t.Fatal("expected an error for an unknown codec")
+		}
+	})
+}
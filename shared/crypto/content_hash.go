@@ -0,0 +1,91 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ContentHashVersion is the layout of MessageContentHash, stated in exports
+// so verifiers know how to recompute the hashes.
+const ContentHashVersion = 1
+
+const (
+	contentHashTag      = "itchan-message-v1"
+	authorCommitmentTag = "itchan-author-v1"
+)
+
+// MessageContent is what a message content hash covers.
+// The board is left out: renaming a board keeps its messages as they are.
+type MessageContent struct {
+	ThreadId         int64
+	MessageId        int64
+	CreatedAt        time.Time
+	Text             string
+	AttachmentHashes []string // Hex SHA-256 of each attached file, in attachment order
+	AuthorCommitment []byte
+}
+
+// AuthorCommitment binds a message to its author without revealing them:
+// an HMAC-SHA256 under key of the author, the message ids and its time. It
+// differs for every message, so archives do not link posts by one author.
+func AuthorCommitment(key []byte, threadId, messageId int64, createdAt time.Time, authorId int64) []byte {
+	mac := hmac.New(sha256.New, key)
+	writeFields(mac, authorCommitmentTag,
+		strconv.FormatInt(threadId, 10), strconv.FormatInt(messageId, 10),
+		formatTime(createdAt), strconv.FormatInt(authorId, 10))
+	return mac.Sum(nil)
+}
+
+// MessageContentHash returns the SHA-256 of the content of a message. Every
+// field is prefixed with its length so no two messages encode the same, and
+// CreatedAt is taken in UTC as RFC 3339 with nanoseconds.
+func MessageContentHash(c MessageContent) []byte {
+	h := sha256.New()
+	writeFields(h, contentHashTag,
+		strconv.FormatInt(c.ThreadId, 10), strconv.FormatInt(c.MessageId, 10),
+		formatTime(c.CreatedAt), c.Text, string(c.AuthorCommitment),
+		strconv.Itoa(len(c.AttachmentHashes)))
+	writeFields(h, c.AttachmentHashes...)
+	return h.Sum(nil)
+}
+
+// MerkleRoot returns the root of the Merkle tree over leaves as in RFC 6962:
+// leaves are hashed with a 0x00 prefix and inner nodes with 0x01, and a tree
+// of n leaves splits at the largest power of two below n. The root of no
+// leaves is the SHA-256 of the empty string.
+func MerkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	}
+	if len(leaves) == 1 {
+		sum := sha256.Sum256(append([]byte{0x00}, leaves[0]...))
+		return sum[:]
+	}
+	split := 1
+	for split*2 < len(leaves) {
+		split *= 2
+	}
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(MerkleRoot(leaves[:split]))
+	h.Write(MerkleRoot(leaves[split:]))
+	return h.Sum(nil)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func writeFields(w io.Writer, fields ...string) {
+	var length [8]byte
+	for _, field := range fields {
+		binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+		w.Write(length[:])
+		w.Write([]byte(field))
+	}
+}
@@ -0,0 +1,84 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageContentHash(t *testing.T) {
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+	base := MessageContent{
+		ThreadId:         3,
+		MessageId:        7,
+		CreatedAt:        createdAt,
+		Text:             "hello",
+		AttachmentHashes: []string{"aa", "bb"},
+		AuthorCommitment: AuthorCommitment([]byte("key"), 3, 7, createdAt, 42),
+	}
+	hash := MessageContentHash(base)
+	assert.Len(t, hash, sha256.Size)
+
+	t.Run("does not depend on the time zone", func(t *testing.T) {
+		c := base
+		c.CreatedAt = base.CreatedAt.In(time.FixedZone("UTC+3", 3*3600))
+		assert.Equal(t, hash, MessageContentHash(c))
+	})
+
+	changes := map[string]func(c *MessageContent){
+		"thread":                 func(c *MessageContent) { c.ThreadId = 4 },
+		"message":                func(c *MessageContent) { c.MessageId = 8 },
+		"time":                   func(c *MessageContent) { c.CreatedAt = c.CreatedAt.Add(time.Microsecond) },
+		"text":                   func(c *MessageContent) { c.Text = "hello!" },
+		"attachment order":       func(c *MessageContent) { c.AttachmentHashes = []string{"bb", "aa"} },
+		"attachment removed":     func(c *MessageContent) { c.AttachmentHashes = []string{"aa"} },
+		"attachment moved":       func(c *MessageContent) { c.AttachmentHashes = []string{"aab", "b"} },
+		"text into attachments":  func(c *MessageContent) { c.Text = ""; c.AttachmentHashes = []string{"hello", "aa", "bb"} },
+		"author":                 func(c *MessageContent) { c.AuthorCommitment = AuthorCommitment([]byte("key"), 3, 7, createdAt, 43) },
+		"author commitment key":  func(c *MessageContent) { c.AuthorCommitment = AuthorCommitment([]byte("other"), 3, 7, createdAt, 42) },
+		"author commitment gone": func(c *MessageContent) { c.AuthorCommitment = nil },
+	}
+	for name, change := range changes {
+		t.Run("changes with "+name, func(t *testing.T) {
+			c := base
+			c.AttachmentHashes = append([]string(nil), base.AttachmentHashes...)
+			change(&c)
+			assert.NotEqual(t, hash, MessageContentHash(c))
+		})
+	}
+}
+
+func TestAuthorCommitment(t *testing.T) {
+	key := []byte("key")
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	commitment := AuthorCommitment(key, 3, 7, createdAt, 42)
+	assert.Equal(t, commitment, AuthorCommitment(key, 3, 7, createdAt.Local(), 42))
+	assert.NotEqual(t, commitment, AuthorCommitment(key, 3, 8, createdAt, 42), "posts of one author are not linkable")
+	assert.NotEqual(t, commitment, AuthorCommitment(key, 3, 7, createdAt.Add(time.Second), 42),
+		"same ids on another board are not linkable")
+	assert.NotEqual(t, commitment, AuthorCommitment(key, 37, 4, createdAt, 2), "fields are delimited")
+}
+
+func TestMerkleRoot(t *testing.T) {
+	sum := func(parts ...[]byte) []byte {
+		h := sha256.New()
+		for _, p := range parts {
+			h.Write(p)
+		}
+		return h.Sum(nil)
+	}
+	leaf := func(b []byte) []byte { return sum([]byte{0x00}, b) }
+	node := func(l, r []byte) []byte { return sum([]byte{0x01}, l, r) }
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+
+	empty, _ := hex.DecodeString("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+	assert.Equal(t, empty, MerkleRoot(nil))
+	assert.Equal(t, leaf(a), MerkleRoot([][]byte{a}))
+	assert.Equal(t, node(leaf(a), leaf(b)), MerkleRoot([][]byte{a, b}))
+	assert.Equal(t, node(node(leaf(a), leaf(b)), leaf(c)), MerkleRoot([][]byte{a, b, c}))
+	assert.NotEqual(t, MerkleRoot([][]byte{a, b, c}), MerkleRoot([][]byte{b, a, c}), "order matters")
+	assert.NotEqual(t, MerkleRoot([][]byte{a, b}), MerkleRoot([][]byte{a, b, b}), "no duplicated last leaf")
+}
@@ -1,8 +1,10 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -14,18 +16,53 @@ import (
 
 var (
 	ErrInvalidKey        = errors.New("encryption key must be 32 bytes for AES-256")
+	ErrInvalidIndexKey   = errors.New("email index key must be at least 32 bytes and differ from the encryption key")
 	ErrInvalidCiphertext = errors.New("ciphertext is too short or corrupted")
+	ErrUnknownKey        = errors.New("ciphertext was not encrypted with a key of the key ring")
 	ErrInvalidEmail      = errors.New("invalid email format")
 )
 
 // EmailCrypto handles encryption and decryption of email addresses
 type EmailCrypto struct {
-	key []byte
+	key          []byte
+	previousKeys [][]byte // Still decrypt while stored emails are rotated to key
+	indexKey     []byte   // Empty when no blind index is kept
 }
 
-// NewEmailCrypto creates a new EmailCrypto instance with the provided key
-// The key should be 32 bytes for AES-256
-func NewEmailCrypto(keyBase64 string) (*EmailCrypto, error) {
+// NewEmailCrypto creates a new EmailCrypto instance with the provided keys
+// The key should be 32 bytes for AES-256; the optional index key keys BlindIndex.
+// Previous keys are only used to decrypt emails not yet rotated to key.
+func NewEmailCrypto(keyBase64, indexKeyBase64 string, previousKeysBase64 ...string) (*EmailCrypto, error) {
+	key, err := decodeKey(keyBase64)
+	if err != nil {
+		return nil, err
+	}
+
+	previousKeys := make([][]byte, 0, len(previousKeysBase64))
+	for _, previousBase64 := range previousKeysBase64 {
+		previous, err := decodeKey(previousBase64)
+		if err != nil {
+			return nil, fmt.Errorf("previous key: %w", err)
+		}
+		previousKeys = append(previousKeys, previous)
+	}
+
+	var indexKey []byte
+	if indexKeyBase64 != "" {
+		indexKey, err = base64.StdEncoding.DecodeString(indexKeyBase64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode email index key: %w", err)
+		}
+		// A key shared with the encryption would tie the index to the ciphertext's secret
+		if len(indexKey) < 32 || bytes.Equal(indexKey, key) {
+			return nil, ErrInvalidIndexKey
+		}
+	}
+
+	return &EmailCrypto{key: key, previousKeys: previousKeys, indexKey: indexKey}, nil
+}
+
+func decodeKey(keyBase64 string) ([]byte, error) {
 	key, err := base64.StdEncoding.DecodeString(keyBase64)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode encryption key: %w", err)
@@ -34,8 +71,7 @@ func NewEmailCrypto(keyBase64 string) (*EmailCrypto, error) {
 	if len(key) != 32 {
 		return nil, ErrInvalidKey
 	}
-
-	return &EmailCrypto{key: key}, nil
+	return key, nil
 }
 
 // Encrypt encrypts an email address using AES-256-GCM
@@ -70,13 +106,47 @@ func (e *EmailCrypto) Encrypt(email string) ([]byte, error) {
 	return ciphertext, nil
 }
 
-// Decrypt decrypts an encrypted email address
+// Decrypt decrypts an encrypted email address, trying the previous keys when
+// the current one does not open it
 func (e *EmailCrypto) Decrypt(ciphertext []byte) (string, error) {
+	email, err := open(e.key, ciphertext)
+	if err == nil || len(e.previousKeys) == 0 {
+		return email, err
+	}
+	for _, key := range e.previousKeys {
+		if email, previousErr := open(key, ciphertext); previousErr == nil {
+			return email, nil
+		}
+	}
+	return "", err
+}
+
+// Rotate re-encrypts an email stored under a previous key with the current
+// key. It returns nil when the ciphertext already uses the current key and
+// ErrUnknownKey when no key of the ring opens it.
+func (e *EmailCrypto) Rotate(ciphertext []byte) ([]byte, error) {
+	if _, err := open(e.key, ciphertext); err == nil {
+		return nil, nil
+	}
+	for _, key := range e.previousKeys {
+		if email, err := open(key, ciphertext); err == nil {
+			return e.Encrypt(email)
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// DecryptCurrent decrypts with the current key only, to verify a rotation
+func (e *EmailCrypto) DecryptCurrent(ciphertext []byte) (string, error) {
+	return open(e.key, ciphertext)
+}
+
+func open(key, ciphertext []byte) (string, error) {
 	if len(ciphertext) == 0 {
 		return "", ErrInvalidCiphertext
 	}
 
-	block, err := aes.NewCipher(e.key)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -112,6 +182,20 @@ func (e *EmailCrypto) Hash(email string) []byte {
 	return hash[:]
 }
 
+// BlindIndex returns the HMAC-SHA256 of the normalized email under the index
+// key, for exact-match lookups by admins. Unlike Hash it cannot be checked
+// against guessed emails without the key. Returns nil when no index key is set.
+func (e *EmailCrypto) BlindIndex(email string) []byte {
+	if len(e.indexKey) == 0 {
+		return nil
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	mac := hmac.New(sha256.New, e.indexKey)
+	mac.Write([]byte(email))
+	return mac.Sum(nil)
+}
+
 // ExtractDomain extracts the domain portion from an email address
 // Returns empty string if email is invalid
 func (e *EmailCrypto) ExtractDomain(email string) (string, error) {
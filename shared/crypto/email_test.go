@@ -0,0 +1,96 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlindIndex(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	indexKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+
+	t.Run("normalized and keyed", func(t *testing.T) {
+		c, err := NewEmailCrypto(key, indexKey)
+		require.NoError(t, err)
+
+		index := c.BlindIndex("user@example.com")
+		assert.Len(t, index, 32)
+		assert.Equal(t, index, c.BlindIndex("  User@Example.COM "))
+		assert.NotEqual(t, index, c.BlindIndex("other@example.com"))
+		// Unlike Hash it depends on the key
+		assert.NotEqual(t, c.Hash("user@example.com"), index)
+
+		other, err := NewEmailCrypto(key, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, 32)))
+		require.NoError(t, err)
+		assert.NotEqual(t, index, other.BlindIndex("user@example.com"))
+	})
+
+	t.Run("disabled without index key", func(t *testing.T) {
+		c, err := NewEmailCrypto(key, "")
+		require.NoError(t, err)
+		assert.Nil(t, c.BlindIndex("user@example.com"))
+	})
+
+	t.Run("invalid index keys", func(t *testing.T) {
+		_, err := NewEmailCrypto(key, key)
+		assert.ErrorIs(t, err, ErrInvalidIndexKey)
+
+		_, err = NewEmailCrypto(key, base64.StdEncoding.EncodeToString([]byte("short")))
+		assert.ErrorIs(t, err, ErrInvalidIndexKey)
+
+		_, err = NewEmailCrypto(key, "not base64!")
+		assert.Error(t, err)
+	})
+}
+
+func TestKeyRing(t *testing.T) {
+	oldKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	newKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+
+	old, err := NewEmailCrypto(oldKey, "")
+	require.NoError(t, err)
+	ring, err := NewEmailCrypto(newKey, "", oldKey)
+	require.NoError(t, err)
+
+	oldCiphertext, err := old.Encrypt("user@example.com")
+	require.NoError(t, err)
+
+	t.Run("decrypts with previous keys", func(t *testing.T) {
+		email, err := ring.Decrypt(oldCiphertext)
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", email)
+
+		_, err = ring.DecryptCurrent(oldCiphertext)
+		assert.Error(t, err)
+	})
+
+	t.Run("rotates to the current key", func(t *testing.T) {
+		rotated, err := ring.Rotate(oldCiphertext)
+		require.NoError(t, err)
+
+		email, err := ring.DecryptCurrent(rotated)
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", email)
+
+		again, err := ring.Rotate(rotated)
+		require.NoError(t, err)
+		assert.Nil(t, again, "already under the current key")
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := ring.Rotate([]byte("not encrypted with any key here"))
+		assert.ErrorIs(t, err, ErrUnknownKey)
+
+		_, err = ring.Decrypt([]byte("not encrypted with any key here"))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid previous key", func(t *testing.T) {
+		_, err := NewEmailCrypto(newKey, "", "short")
+		assert.Error(t, err)
+	})
+}
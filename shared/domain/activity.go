@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// LatestPost is a lightweight message reference shown in the index page feed
+// and in the user's post history.
+type LatestPost struct {
+	Board       BoardShortName
+	ThreadId    ThreadId
+	ThreadTitle ThreadTitle
+	Id          MsgId
+	Page        int // Thread page the message is on
+	Text        MsgText
+	CreatedAt   time.Time
+}
+
+// ActiveThread is a thread ranked by the number of messages posted today.
+type ActiveThread struct {
+	ThreadMetadata
+	MessagesToday int
+}
+
+// SiteActivity summarizes recent activity across the boards visible to a viewer.
+type SiteActivity struct {
+	LatestPosts   []LatestPost
+	PostsToday    int
+	ActiveThreads []ActiveThread
+	GeneratedAt   time.Time
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+type AppealStatus = string
+
+const (
+	AppealPending  AppealStatus = "pending"
+	AppealAccepted AppealStatus = "accepted"
+	AppealDenied   AppealStatus = "denied"
+)
+
+// Appeal is a banned user's request to lift their ban.
+// Ban is a snapshot of the blacklist entry at the time the appeal was submitted,
+// so moderators keep the original context even after the ban is lifted.
+type Appeal struct {
+	Id        AppealId
+	UserId    UserId
+	Text      string
+	Status    AppealStatus
+	Ban       BlacklistEntry
+	Response  string  // Moderator's message to the user, set when the appeal is decided
+	DecidedBy *UserId // nil while pending
+	CreatedAt time.Time
+	DecidedAt *time.Time
+}
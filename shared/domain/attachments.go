@@ -1,8 +1,11 @@
 package domain
 
 import (
+	"encoding/json"
 	"io"
+	"path"
 	"strings"
+	"time"
 )
 
 // FileCommonMetadata contains common file metadata fields shared between
@@ -25,18 +28,45 @@ func (fcm *FileCommonMetadata) IsVideo() bool {
 	return strings.HasPrefix(fcm.MimeType, "video/")
 }
 
+// IsPDF returns true if the file is a PDF document
+func (fcm *FileCommonMetadata) IsPDF() bool {
+	return fcm.MimeType == "application/pdf"
+}
+
+// IsText returns true if the file is a plain text document
+func (fcm *FileCommonMetadata) IsText() bool {
+	return fcm.MimeType == "text/plain"
+}
+
+// IsDocument returns true for the attachment types boards accept only with
+// AllowDocuments
+func (fcm *FileCommonMetadata) IsDocument() bool {
+	return fcm.IsPDF() || fcm.IsText()
+}
+
 // PendingFile represents a file upload being processed (moved from domain/message.go)
 type PendingFile struct {
 	FileCommonMetadata
 	Data io.Reader `json:"-"`
 }
 
+// PendingUpload is a sanitized file stored ahead of the post that will
+// carry it. The post references it by Token and it expires if never claimed.
+type PendingUpload struct {
+	Token     UploadToken    `json:"token"`
+	Board     BoardShortName `json:"board"`
+	UserId    UserId         `json:"-"`
+	File      *File          `json:"file"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
 // SanitizedImage represents a sanitized image file ready to be saved.
 // Images are decoded in-memory (metadata stripped) and ready for encoding/thumbnailing.
 type SanitizedImage struct {
 	FileCommonMetadata
-	Image  any    // Decoded image.Image (any to avoid image import here)
-	Format string // Image format from image.Decode ("png", "jpeg", "gif")
+	Image    any               // Decoded image.Image (any to avoid image import here)
+	Format   string            // Image format from image.Decode ("png", "jpeg", "gif")
+	Metadata *StrippedMetadata // EXIF details removed by decoding (nil if there were none)
 }
 
 // SanitizedVideo represents a sanitized video file ready to be moved.
@@ -47,6 +77,14 @@ type SanitizedVideo struct {
 	Thumbnail    []byte // Scaled first-frame JPEG (nil if extraction failed)
 }
 
+// SanitizedDocument represents a sanitized PDF or text file ready to be moved.
+type SanitizedDocument struct {
+	FileCommonMetadata
+	TempFilePath string // Path to sanitized document on disk (always present)
+	FirstPage    any    // Rendered first page of a PDF as image.Image (nil for text or if rendering failed)
+	TextPreview  string // Beginning of a text file (empty for PDFs)
+}
+
 // File represents a file stored in the system
 type File struct {
 	FileCommonMetadata         // Sanitized file metadata
@@ -55,11 +93,17 @@ type File struct {
 	OriginalFilename   string  `json:"original_filename,omitempty"`  // User's uploaded filename (before sanitization)
 	OriginalMimeType   string  `json:"original_mime_type,omitempty"` // MIME type before sanitization (always present)
 	ThumbnailPath      *string `json:"thumbnail_path,omitempty"`     // Path to generated thumbnail (images only)
+	Sha256             string  `json:"sha256,omitempty"`             // Hex SHA-256 of the stored file; empty for files saved before hashing
+	ThumbnailSha256    string  `json:"thumbnail_sha256,omitempty"`   // Hex SHA-256 of the thumbnail
+	TextPreview        string  `json:"text_preview,omitempty"`       // Beginning of a text file, shown inline
+
+	StrippedMetadata *StrippedMetadata `json:"-"` // Only set on upload, stored for moderators
 }
 
-// MediaURL returns the public URL for serving this file.
+// MediaURL returns the public URL for serving this file. Hashed files get a
+// content-addressed URL that can be cached forever.
 func (f *File) MediaURL() string {
-	return "/media/" + f.FilePath
+	return mediaURL(f.FilePath, f.Sha256)
 }
 
 // ThumbnailURL returns the public URL for the thumbnail, or empty string if none.
@@ -67,7 +111,56 @@ func (f *File) ThumbnailURL() string {
 	if f.ThumbnailPath == nil {
 		return ""
 	}
-	return "/media/" + *f.ThumbnailPath
+	return mediaURL(*f.ThumbnailPath, f.ThumbnailSha256)
+}
+
+// Lite returns a copy of the file with only what a client needs to show its
+// thumbnail and link the original. Low-bandwidth responses use it to leave out
+// text previews and upload details.
+func (f *File) Lite() *File {
+	return &File{
+		FileCommonMetadata: FileCommonMetadata{
+			SizeBytes:   f.SizeBytes,
+			MimeType:    f.MimeType,
+			ImageWidth:  f.ImageWidth,
+			ImageHeight: f.ImageHeight,
+		},
+		Id:               f.Id,
+		FilePath:         f.FilePath,
+		OriginalFilename: f.OriginalFilename,
+		ThumbnailPath:    f.ThumbnailPath,
+		Sha256:           f.Sha256,
+		ThumbnailSha256:  f.ThumbnailSha256,
+	}
+}
+
+func mediaURL(filePath, sha256 string) string {
+	if sha256 == "" {
+		return "/media/" + filePath
+	}
+	return "/media/sha256/" + sha256 + path.Ext(filePath)
+}
+
+// StrippedMetadata is what sanitization removed from an upload, kept for
+// moderators only. GPS coordinates are never stored, just whether they were there.
+type StrippedMetadata struct {
+	CameraMake  string `json:"camera_make,omitempty"`
+	CameraModel string `json:"camera_model,omitempty"`
+	Software    string `json:"software,omitempty"`
+	HasGPS      bool   `json:"has_gps"`
+}
+
+// FileMetadataReport is the stripped metadata of one attachment of a message
+type FileMetadataReport struct {
+	FileId           FileId `json:"file_id"`
+	OriginalFilename string `json:"original_filename"`
+	StrippedMetadata
+}
+
+// MediaLocation is where a file with a given content hash is stored
+type MediaLocation struct {
+	Board    BoardShortName `json:"board"`
+	FilePath string         `json:"file_path"` // Relative to the media root
 }
 
 // Attachment represents an attachment linking a message to a file
@@ -80,5 +173,35 @@ type Attachment struct {
 	File      *File          `json:"file,omitempty"` // Optional: populated when fetching with file details
 }
 
+// attachmentFields breaks the MarshalJSON recursion of Attachment
+type attachmentFields Attachment
+
+// MarshalJSON adds the file's display fields next to the attachment ids, so API
+// clients get ready-to-use URLs without resolving the nested file themselves.
+// The nested file is kept for clients that already read it.
+func (a Attachment) MarshalJSON() ([]byte, error) {
+	view := struct {
+		attachmentFields
+		URL              string `json:"url,omitempty"`
+		ThumbURL         string `json:"thumb_url,omitempty"`
+		Mime             string `json:"mime,omitempty"`
+		Width            *int   `json:"width,omitempty"`
+		Height           *int   `json:"height,omitempty"`
+		Size             int64  `json:"size,omitempty"`
+		OriginalFilename string `json:"original_filename,omitempty"`
+	}{attachmentFields: attachmentFields(a)}
+
+	if f := a.File; f != nil {
+		view.URL = f.MediaURL()
+		view.ThumbURL = f.ThumbnailURL()
+		view.Mime = f.MimeType
+		view.Width = f.ImageWidth
+		view.Height = f.ImageHeight
+		view.Size = f.SizeBytes
+		view.OriginalFilename = f.OriginalFilename
+	}
+	return json.Marshal(view)
+}
+
 // Attachments is a slice of attachments
 type Attachments = []*Attachment
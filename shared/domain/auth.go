@@ -5,6 +5,25 @@ import "time"
 type Credentials struct {
 	Email    Email
 	Password Password
+
+	// Device is where a login comes from; logins from a device not seen
+	// before are emailed to the user. Nil skips the check.
+	Device *Device
+}
+
+// Device describes the client of a login request
+type Device struct {
+	UserAgent string
+	IP        string
+}
+
+// LoginDevice is a device a user has logged in from
+type LoginDevice struct {
+	UserId          UserId
+	Fingerprint     string // Hash of the user agent and the coarse IP
+	RevokeTokenHash string // Hash of the token in the new device email
+	FirstSeenAt     time.Time
+	LastSeenAt      time.Time
 }
 
 type User struct {
@@ -13,10 +32,24 @@ type User struct {
 	EmailEncrypted []byte
 	EmailDomain    string
 	EmailHash      []byte
+	EmailIndex     []byte // Keyed blind index for admin lookup; nil when not configured
 	PassHash       Password
 	Admin          bool
 	CreatedAt      time.Time
 	ReferralSource string
+
+	// ImpersonatedBy is the admin viewing the site as this user; only set on
+	// requests authenticated with a view-as token
+	ImpersonatedBy *UserId
+}
+
+// SystemUserId is the reserved account system messages, such as the bump
+// limit notice, are posted as. It exists in every database and cannot log in.
+const SystemUserId UserId = -1
+
+// IsSystem reports whether u is the system account rather than a person.
+func (u User) IsSystem() bool {
+	return u.Id == SystemUserId
 }
 
 // SaveUserData contains the data needed to create a new user
@@ -37,7 +70,8 @@ type BlacklistEntry struct {
 	UserId        UserId
 	BlacklistedAt time.Time
 	Reason        string
-	BlacklistedBy UserId
+	BlacklistedBy UserId     // 0 for automatic bans
+	ExpiresAt     *time.Time // nil for permanent bans
 }
 
 // InviteCode represents an invite code in the system
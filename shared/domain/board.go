@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"slices"
 	"time"
 )
 
@@ -9,6 +10,7 @@ type BoardCreationData struct {
 	Name          BoardName      `json:"name" validate:"required"`
 	ShortName     BoardShortName `json:"short_name" validate:"required"`
 	AllowedEmails *Emails        `json:"allowed_emails,omitempty"`
+	Description   string         `json:"description,omitempty"`
 }
 
 type BoardMetadata struct {
@@ -16,7 +18,131 @@ type BoardMetadata struct {
 	ShortName           BoardShortName
 	CreatedAt           time.Time
 	LastActivityAt      time.Time
-	AllowedEmailDomains []string // nil means public board, non-empty means corporate board
+	AllowedEmailDomains []string         // nil means public board, non-empty means corporate board
+	CategoryId          *BoardCategoryId // nil means the board is not assigned to any category
+	Position            int              // Order of the board inside its category (ascending)
+	Stats               *BoardStats      // nil unless requested from the paginated listing
+	BoardSettings
+}
+
+// BoardStats holds thread and message counts of a board.
+type BoardStats struct {
+	ThreadCount  int
+	MessageCount int
+	LatestThread *BoardLatestThread // Only with BoardStatsLatest; nil when the board has no threads or the viewer can't read it
+}
+
+// BoardLatestThread is the most recently bumped thread of a board, shown in
+// the board's hover card on the index.
+type BoardLatestThread struct {
+	Id         ThreadId
+	Title      string
+	LastBumped time.Time
+}
+
+// BoardStatsMode selects the statistics included in the paginated board listing.
+type BoardStatsMode = string
+
+const (
+	BoardStatsNone   BoardStatsMode = ""       // Metadata only
+	BoardStatsCounts BoardStatsMode = "counts" // Thread and message counts
+	BoardStatsLatest BoardStatsMode = "latest" // Counts and the latest thread
+)
+
+// BoardSort is the order of the paginated board listing.
+type BoardSort = string
+
+const (
+	BoardSortPosition BoardSort = "position" // Index order: position, then short name
+	BoardSortActivity BoardSort = "activity" // Most recently active first
+	BoardSortCreated  BoardSort = "created"  // Newest first
+	BoardSortName     BoardSort = "name"     // Alphabetical by name
+)
+
+// TextFilter is what a board does with zero-width characters, bidi controls
+// and stacked combining marks ("zalgo") in message text.
+type TextFilter = string
+
+const (
+	TextFilterStrip  TextFilter = "strip"  // Remove them and post the rest (the default)
+	TextFilterReject TextFilter = "reject" // Refuse the post
+	TextFilterOff    TextFilter = "off"    // Post the text as written
+)
+
+// BoardLanguage is the language a board is written in. It picks the
+// interface strings and date format of the board's pages and how text length
+// is counted against the limits.
+type BoardLanguage = string
+
+const (
+	LanguageEnglish  BoardLanguage = "en" // The default
+	LanguageRussian  BoardLanguage = "ru"
+	LanguageJapanese BoardLanguage = "ja"
+	LanguageChinese  BoardLanguage = "zh"
+	LanguageKorean   BoardLanguage = "ko"
+)
+
+// IsCJK reports whether language is written with CJK characters, which count
+// twice toward text length limits.
+func IsCJK(language BoardLanguage) bool {
+	return language == LanguageJapanese || language == LanguageChinese || language == LanguageKorean
+}
+
+// BoardSettings holds per-board options that admins can change after creation.
+type BoardSettings struct {
+	Description       string // Shown on the index, in the board header and in link previews
+	ShowDeletionStubs bool   // Show "Post deleted: <reason>" in place of messages removed by moderators
+	Noindex           bool   // Ask search engines not to index the board (robots.txt, meta robots, sitemap)
+	PublicModLog      bool   // Publish the redacted moderation log of the board
+	SelfDeleteReplied bool   // Authors may also delete their posts after someone replied to them
+	BumpLimitNotice   bool   // Post a system message when a thread reaches the bump limit
+	PreModeration     bool   // Hold posts of untrusted users until a moderator approves them
+
+	// Thread creation requirements, zero values mean no requirement
+	MinOpTextLength         int  // Minimum length of the OP text in characters
+	RequireOpAttachment     bool // OP must have at least one attachment
+	MaxThreadsPerUserPerDay int  // Threads a user may start on the board within 24 hours
+
+	VideoProfile string        // Name of the media.video_profiles entry for uploaded videos, empty means the default
+	TextFilter   TextFilter    // Handling of invisible and stacked characters in posts, empty means TextFilterStrip
+	Language     BoardLanguage // Language of the board, empty means LanguageEnglish
+
+	// Posting rules, separate from who may read the board; zero values let every reader post
+	PostingEmailDomains      []string // Email domains of the accounts that may post, empty allows all
+	PostingMinAccountAgeDays int      // Days an account must exist before it may post
+
+	// Automatic trust under pre-moderation, zero values turn a rule off
+	PremodTrustApprovals      int // Approved posts after which a user's posts skip the queue
+	PremodTrustAccountAgeDays int // Account age in days after which a user's posts skip the queue
+
+	Mirror bool // The board is a read-only copy of another instance's board; set by attaching a BoardMirror, not by the settings
+	UploadRules
+}
+
+// UploadRules narrow the global attachment limits for one board. Threads carry
+// them too, so reply forms can follow the board's rules.
+type UploadRules struct {
+	AllowDocuments   bool     // Accept PDF and plain text attachments
+	MaxAttachments   *int     // Attachments per post; nil uses max_attachments_per_message, 0 disables uploads
+	AllowedMimeTypes []string // Image and video MIME types accepted on the board, empty accepts all configured ones
+}
+
+// MaxAttachmentsOr returns the board's attachment limit, or def when the board
+// uses the global one.
+func (u UploadRules) MaxAttachmentsOr(def int) int {
+	if u.MaxAttachments == nil {
+		return def
+	}
+	return *u.MaxAttachments
+}
+
+// AcceptsMimeType reports whether the board takes files of mimeType. Documents
+// only depend on AllowDocuments. Global limits are checked separately.
+func (u UploadRules) AcceptsMimeType(mimeType string) bool {
+	if meta := (FileCommonMetadata{MimeType: mimeType}); meta.IsDocument() {
+		return u.AllowDocuments
+	}
+	return len(u.AllowedMimeTypes) == 0 || slices.Contains(u.AllowedMimeTypes, mimeType)
 }
 
 type Board struct {
@@ -24,3 +150,25 @@ type Board struct {
 	Threads []*Thread
 	Page    int `json:"page,omitempty"`
 }
+
+// PendingBoardDeletion is a board waiting out its deletion grace period. The
+// board is hidden and read-only until DeleteAfter, when it is dropped.
+type PendingBoardDeletion struct {
+	Board       BoardShortName `json:"board"`
+	Name        BoardName      `json:"name"`
+	DeleteAfter time.Time      `json:"delete_after"`
+}
+
+type BoardCategoryCreationData struct {
+	Name     BoardCategoryName
+	Position int
+}
+
+// BoardCategory groups boards on the index page.
+// Boards is only populated by the grouped board listing.
+type BoardCategory struct {
+	Id       BoardCategoryId
+	Name     BoardCategoryName
+	Position int
+	Boards   []BoardMetadata
+}
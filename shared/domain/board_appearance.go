@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// BoardBanner is an image shown at the top of board and thread pages.
+type BoardBanner struct {
+	Id        BoardBannerId
+	Board     BoardShortName
+	FilePath  string // Relative to the media root, like attachment paths
+	CreatedAt time.Time
+}
+
+// URL returns the public URL of the banner image.
+func (b *BoardBanner) URL() string {
+	return mediaURL(b.FilePath, "")
+}
+
+// BoardAppearance is the visual identity admins give a board: banners rotated
+// at random on every page view and a custom stylesheet. CustomCSS is stored
+// as entered; the frontend sanitizes it before serving.
+type BoardAppearance struct {
+	CustomCSS string
+	Banners   []BoardBanner
+}
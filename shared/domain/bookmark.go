@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// Bookmark is a message a user saved to come back to. Unlike a thread watch
+// it points at a single post and puts nothing in the email digest.
+type Bookmark struct {
+	Message   LatestPost // The bookmarked message, with the thread page it is on
+	CreatedAt time.Time  // When the message was bookmarked
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+// DigestFrequency is how often a user receives the email digest.
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "" // No digest (not subscribed)
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+// Period returns the time between two digests, or zero for DigestOff and
+// unknown values.
+func (f DigestFrequency) Period() time.Duration {
+	switch f {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// DigestSubscription is a user's opt-in to email digests.
+type DigestSubscription struct {
+	UserId           UserId
+	EmailEncrypted   []byte
+	Frequency        DigestFrequency
+	UnsubscribeToken string
+	LastSentAt       time.Time // Activity after this time goes into the next digest
+}
+
+// WatchedThreadActivity counts the new replies in one of the user's watched threads.
+type WatchedThreadActivity struct {
+	Board      BoardShortName
+	ThreadId   ThreadId
+	Title      ThreadTitle
+	NewReplies int
+}
+
+// WatchedThread is one of the user's watched threads with their read position in it.
+type WatchedThread struct {
+	Board      BoardShortName
+	ThreadId   ThreadId
+	Title      ThreadTitle
+	LastReadId MsgId // Last message the user has seen, 0 if they never opened the thread
+	Unread     int   // Messages of others after LastReadId
+	Page       int   // Thread page of the first unread message
+}
+
+// Digest is the activity collected for one email digest.
+type Digest struct {
+	Threads []WatchedThreadActivity
+	Replies []LatestPost // Unread replies to the user's messages, newest first
+}
+
+// Empty reports whether there is nothing to send.
+func (d Digest) Empty() bool {
+	return len(d.Threads) == 0 && len(d.Replies) == 0
+}
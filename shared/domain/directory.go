@@ -0,0 +1,24 @@
+package domain
+
+// DirectoryDeactivationReason is the ban reason of accounts deactivated by
+// directory sync. Such bans are automatic and permanent: they have neither a
+// banning admin nor an expiry, which is how the sync recognizes its own.
+const DirectoryDeactivationReason = "Account deactivated: no longer in the organization directory"
+
+// DirectoryAccount is an account in one of the domains managed by directory sync.
+type DirectoryAccount struct {
+	Id          UserId
+	EmailHash   []byte
+	Admin       bool
+	Deactivated bool // Banned by directory sync
+}
+
+// DirectorySyncReport tells what a directory sync did, or would do in a dry run.
+type DirectorySyncReport struct {
+	DryRun        bool     `json:"dry_run"`
+	Listed        int      `json:"listed"`         // Active emails in the directory
+	Managed       int      `json:"managed"`        // Accounts in the managed domains
+	Deactivated   []UserId `json:"deactivated"`    // Accounts missing from the directory, now banned
+	Reactivated   []UserId `json:"reactivated"`    // Accounts back in the directory, their deactivation lifted
+	SkippedAdmins []UserId `json:"skipped_admins"` // Admins missing from the directory; admins are never deactivated
+}
@@ -0,0 +1,11 @@
+package domain
+
+// EmailKeyRotationReport tells what a run of the email encryption key
+// rotation did.
+type EmailKeyRotationReport struct {
+	Rotated    int      // Emails re-encrypted with the current key
+	Current    int      // Emails already under the current key
+	Unreadable []UserId // Emails no key of the key ring decrypts, left as they were
+	Failed     []UserId // Re-encrypted emails that did not verify or changed meanwhile
+	LastId     UserId   // Last user looked at; a later run resumes after it
+}
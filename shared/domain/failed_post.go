@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FailedPost is a post creation request that was refused or failed, kept for
+// admins to diagnose reports of lost posts. It holds what the poster sent
+// minus the file contents and upload tokens.
+type FailedPost struct {
+	Id         FailedPostId      `json:"id"`
+	UserId     UserId            `json:"user_id"`
+	Board      BoardShortName    `json:"board"`
+	ThreadId   *ThreadId         `json:"thread_id,omitempty"` // Nil for a new thread
+	StatusCode int               `json:"status_code"`
+	Error      string            `json:"error"`
+	Request    FailedPostRequest `json:"request"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// FailedPostRequest is the sanitized snapshot of a failed post request.
+type FailedPostRequest struct {
+	Payload       json.RawMessage        `json:"payload,omitempty"` // The "json" form field; absent when the form could not be read
+	Attachments   []FailedPostAttachment `json:"attachments,omitempty"`
+	UploadTokens  int                    `json:"upload_tokens,omitempty"` // Number of tokens sent, the tokens themselves are dropped
+	ContentLength int64                  `json:"content_length"`
+	UserAgent     string                 `json:"user_agent,omitempty"`
+}
+
+// FailedPostAttachment describes a file of a failed post without its contents.
+type FailedPostAttachment struct {
+	Filename  string `json:"filename"`
+	MimeType  string `json:"mime_type"`
+	SizeBytes int64  `json:"size_bytes"`
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// IdempotencyKey is the Idempotency-Key header of a post creation request.
+// Retries with the same key get the stored response instead of a second post.
+type IdempotencyKey struct {
+	UserId      UserId
+	Key         string
+	RequestPath string // The key only replays requests to the same path
+	StatusCode  int    // 0 while the first request is still running
+	Response    []byte // Body of the response to replay
+	CreatedAt   time.Time
+}
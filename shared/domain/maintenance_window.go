@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// MaintenanceWindow is a period in which the instance is read-only: writes are
+// refused while reads continue. A nil EndsAt lasts until it is cancelled.
+type MaintenanceWindow struct {
+	Message   string     `json:"message"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	CreatedBy *UserId    `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ActiveAt reports whether the instance is read-only at t.
+func (m *MaintenanceWindow) ActiveAt(t time.Time) bool {
+	return !t.Before(m.StartsAt) && !m.EndedAt(t)
+}
+
+// EndedAt reports whether the window is over at t.
+func (m *MaintenanceWindow) EndedAt(t time.Time) bool {
+	return m.EndsAt != nil && !t.Before(*m.EndsAt)
+}
@@ -13,19 +13,24 @@ type MessageCreationData struct {
 	ShowEmailDomain bool
 	CreatedAt       *time.Time
 	PendingFiles    []*PendingFile // Files to be saved after message creation
+	UploadTokens    []UploadToken  // Files uploaded beforehand, linked to the message
 	ReplyTo         *Replies
 }
 
 type MessageMetadata struct {
 	Board           BoardShortName
 	ThreadId        ThreadId
-	Id              MsgId // Per-thread sequential (1, 2, 3...) - id=1 is OP
+	Id              MsgId      // Per-thread sequential (1, 2, 3...) - id=1 is OP
+	PostNumber      PostNumber // Board-local sequential (1, 2, 3...) across all threads of the board
 	Author          User
 	ShowEmailDomain bool
 	Page            int // Page number where this message appears (calculated from Id)
 	Replies         Replies
 	CreatedAt       time.Time
 	ModifiedAt      time.Time
+	Shadowbanned    bool // Author is shadowbanned on this board; only set for admin viewers
+	IsOwn           bool // Written by the viewer; only set for logged-in viewers
+	RepliesToOwn    bool // Replies to a message of the viewer shown on the same page
 }
 
 // IsOp returns true if this message is the opening post (first message in thread)
@@ -37,14 +42,41 @@ type Message struct {
 	MessageMetadata
 	Text        string
 	Attachments Attachments
+	DeadLinks   []MessageLink // Quoted messages that no longer exist; only set on thread pages
+}
+
+// MessageLink is the target of a >>thread#msg quote within the same board.
+type MessageLink struct {
+	ThreadId ThreadId
+	Id       MsgId
+}
+
+// MessageDeletionData is the moderation context of a message deletion.
+type MessageDeletionData struct {
+	Reason    string
+	DeletedBy *UserId // nil if the moderator is unknown
+	ByAuthor  bool    // The author deleted their own post; not a moderator action
+}
+
+// DeletedMessage is the public record left behind when a moderator deletes a message.
+// It is only exposed on boards with BoardSettings.ShowDeletionStubs enabled.
+type DeletedMessage struct {
+	Board     BoardShortName
+	ThreadId  ThreadId
+	Id        MsgId
+	Reason    string
+	DeletedAt time.Time
 }
 
 type Reply struct {
-	Board        BoardShortName
-	FromThreadId ThreadId
-	ToThreadId   ThreadId
-	From         MsgId // Per-thread sequential ID (also serves as ordinal)
-	To           MsgId
-	FromPage     int // Page where the sender message is located (calculated from From)
-	CreatedAt    time.Time
+	Board          BoardShortName
+	FromThreadId   ThreadId
+	ToThreadId     ThreadId
+	From           MsgId      // Per-thread sequential ID (also serves as ordinal)
+	FromPostNumber PostNumber // Board-local number of the sender; zero where it is not loaded
+	To             MsgId
+	FromPage       int // Page where the sender message is located (calculated from From)
+	CreatedAt      time.Time
+	FromAuthor     UserId `json:"-"` // Used to hide replies from shadowbanned users
+	FromOwn        bool   // Sender message was written by the viewer
 }
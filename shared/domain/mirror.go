@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+// MirrorUserId is the reserved account posts copied from other instances are
+// stored under, so they show as anonymous. Like SystemUserId it cannot log in.
+const MirrorUserId UserId = -2
+
+// BoardMirror makes a local board a read-only copy of a board of another
+// itchan instance, refreshed by the background sync.
+type BoardMirror struct {
+	Board        BoardShortName // Local board holding the copy
+	SourceURL    string         // Base URL of the source instance, serving /v1 and /media
+	SourceBoard  BoardShortName // Board on the source instance
+	CreatedBy    UserId         // Admin who attached the mirror, 0 if the account no longer exists
+	CreatedAt    time.Time
+	LastSyncedAt *time.Time // Last sync that went through, nil before the first one
+	LastError    string     // Why the last sync failed, empty if it went through
+}
+
+// MirroredThread is the sync state of one copied thread.
+type MirroredThread struct {
+	Board            BoardShortName
+	ThreadId         ThreadId  // Same id as on the source
+	SourceModifiedAt time.Time // LastModifiedAt of the source thread when it was last copied
+	LastMessageId    MsgId     // Highest source message copied; lower ids are never copied again
+	Deleted          bool      // A moderator deleted the copy here, so the thread is no longer synced
+}
+
+// MirroredMessage is a source message to copy, with its attachments already
+// saved to local media.
+type MirroredMessage struct {
+	Id          MsgId      // Same id as on the source
+	PostNumber  PostNumber // Same number as on the source, zero to take the next local one
+	Text        string     // Stored HTML, links already pointing at the local board
+	CreatedAt   time.Time
+	Attachments Attachments
+}
+
+// MirrorThreadUpdate is one sync of a thread: the thread as the source has it
+// now, the messages to copy and what became of the ones copied before.
+type MirrorThreadUpdate struct {
+	Board     BoardShortName
+	Thread    ThreadMetadata    // Source title, flags, bump and modification times
+	New       []MirroredMessage // Messages above the last copied one, ascending
+	Edited    []MirroredMessage // Copied messages still on the source, with their current text
+	SourceIds []MsgId           // Every message id of the source thread; copied messages missing here are deleted
+	Replies   Replies           // Replies to the thread's messages, kept once both ends are copied
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// ModLogAction is the kind of moderator action recorded in the moderation log.
+type ModLogAction = string
+
+const (
+	ModLogDeleteMessage ModLogAction = "delete_message"
+	ModLogDeleteThread  ModLogAction = "delete_thread"
+)
+
+// ModLogEntry is one moderator action in the moderation log. It never
+// identifies the moderator, the author or the deleted content, so boards with
+// BoardSettings.PublicModLog can publish it as is.
+type ModLogEntry struct {
+	Board     BoardShortName
+	Action    ModLogAction
+	Reason    string
+	CreatedAt time.Time
+}
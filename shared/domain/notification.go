@@ -0,0 +1,9 @@
+package domain
+
+// Notification tells a user that someone replied to one of their messages.
+// A reply quoting several of the user's messages makes a single notification.
+type Notification struct {
+	Id      NotificationId
+	Message LatestPost // The reply
+	Read    bool
+}
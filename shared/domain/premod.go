@@ -0,0 +1,66 @@
+package domain
+
+import "time"
+
+// QueuedPost is a post held back on a board with pre-moderation until a
+// moderator approves it. Nobody but moderators can see it in the meantime.
+type QueuedPost struct {
+	Id              QueuedPostId
+	Board           BoardShortName
+	ThreadId        *ThreadId   // nil for a new thread
+	Title           ThreadTitle // New threads only
+	OpOnly          bool        // New threads only
+	AuthorId        UserId
+	Text            MsgText
+	ShowEmailDomain bool
+	Files           []string // Original names of the attached files
+	CreatedAt       time.Time
+}
+
+// IsThread reports whether approving the post starts a new thread.
+func (p QueuedPost) IsThread() bool {
+	return p.ThreadId == nil
+}
+
+// QueuedThread carries what a held OP needs to become a thread on approval.
+type QueuedThread struct {
+	Title  ThreadTitle
+	OpOnly bool
+}
+
+// PremodPoster is a user's standing under pre-moderation on a board.
+type PremodPoster struct {
+	Board     BoardShortName
+	UserId    UserId
+	Approvals int    // Queued posts of the user approved on the board
+	Trusted   *bool  // Moderator override of the board's trust rules, nil when not set
+	UpdatedBy UserId // Moderator who set the override, 0 if none or the account no longer exists
+	UpdatedAt time.Time
+}
+
+// Override names the moderator override: "trusted", "held", or "auto" when the
+// board's rules decide.
+func (p PremodPoster) Override() string {
+	switch {
+	case p.Trusted == nil:
+		return "auto"
+	case *p.Trusted:
+		return "trusted"
+	default:
+		return "held"
+	}
+}
+
+// ApprovedPost locates the message a queued post became.
+type ApprovedPost struct {
+	ThreadId ThreadId
+	Id       MsgId
+	AuthorId UserId
+}
+
+// PremodQueue is what moderators review on a board with pre-moderation.
+type PremodQueue struct {
+	Board   BoardShortName
+	Posts   []QueuedPost   // Oldest first
+	Posters []PremodPoster // Users with approved posts or an override, most recently changed first
+}
@@ -0,0 +1,10 @@
+package domain
+
+// PushSubscription is a browser's Web Push subscription, as returned by
+// PushManager.subscribe. The keys are base64url encoded.
+type PushSubscription struct {
+	UserId   UserId
+	Endpoint string
+	P256dh   string // The browser's P-256 public key
+	Auth     string // The browser's authentication secret
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// Shadowban hides a user's messages on one board from everyone except the
+// user themselves and admins. The messages are still stored and accepted.
+type Shadowban struct {
+	Board     BoardShortName
+	UserId    UserId
+	CreatedBy UserId // 0 if the moderator account no longer exists
+	CreatedAt time.Time
+}
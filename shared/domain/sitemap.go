@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// SitemapEntry is a publicly indexable page: a board index when ThreadId is 0,
+// otherwise a thread.
+type SitemapEntry struct {
+	Board        BoardShortName
+	ThreadId     ThreadId
+	LastModified time.Time
+}
@@ -0,0 +1,110 @@
+package domain
+
+import "time"
+
+// AdminStats is the operational state shown to admins.
+type AdminStats struct {
+	Disk   DiskUsage   `json:"disk"`
+	Backup BackupState `json:"backup"`
+}
+
+// BackupRunStatus is the outcome of a backup run.
+type BackupRunStatus string
+
+const (
+	BackupRunning   BackupRunStatus = "running"
+	BackupSucceeded BackupRunStatus = "succeeded"
+	BackupFailed    BackupRunStatus = "failed"
+)
+
+// BackupRun is one run of the database and media backup commands.
+type BackupRun struct {
+	Id         BackupRunId     `json:"id"`
+	Status     BackupRunStatus `json:"status"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"` // Nil while running
+	PausedMs   int64           `json:"paused_ms"`             // How long writes were refused
+	Error      string          `json:"error,omitempty"`
+}
+
+// BackupState is how recent the backups are, for admins and the readiness probe.
+type BackupState struct {
+	Enabled               bool       `json:"enabled"` // Backup commands are configured on this instance
+	Running               bool       `json:"running"` // A run of this instance is in progress
+	LastSuccessAt         *time.Time `json:"last_success_at,omitempty"`
+	LastSuccessAgeSeconds *int64     `json:"last_success_age_seconds"` // Null when no backup succeeded yet
+}
+
+// DiskUsage is the latest measurement of the disk usage monitor.
+type DiskUsage struct {
+	CheckedAt       time.Time `json:"checked_at"` // Zero until the first measurement
+	MediaUsedBytes  uint64    `json:"media_used_bytes"`
+	MediaTotalBytes uint64    `json:"media_total_bytes"` // Size of the filesystem holding the media root
+	MediaPercent    float64   `json:"media_percent"`
+	DatabaseBytes   int64     `json:"database_bytes"`
+	DatabasePercent float64   `json:"database_percent"` // Of the configured quota, 0 without one
+	UploadsFrozen   bool      `json:"uploads_frozen"`
+}
+
+// Percent returns the higher of the media and database usage.
+func (u DiskUsage) Percent() float64 {
+	return max(u.MediaPercent, u.DatabasePercent)
+}
+
+// UserStats summarizes a user's posting history across all boards.
+type UserStats struct {
+	Posts          int        `json:"posts"`
+	ThreadsStarted int        `json:"threads_started"`
+	Attachments    int        `json:"attachments"`
+	FirstPostAt    *time.Time `json:"first_post_at,omitempty"` // Nil until the first post
+}
+
+// DeletionReport is what deleting a board or thread would remove, returned
+// by the delete endpoints in dry-run mode.
+type DeletionReport struct {
+	Threads         int   `json:"threads"`
+	Messages        int   `json:"messages"`
+	Attachments     int   `json:"attachments"`
+	AttachmentBytes int64 `json:"attachment_bytes"` // Stored size, thumbnails not included
+}
+
+// HourlyPostCount is the number of posts made on a board in the hour
+// starting at Hour (UTC).
+type HourlyPostCount struct {
+	Board BoardShortName `json:"board"`
+	Hour  time.Time      `json:"hour"`
+	Posts int            `json:"posts"`
+}
+
+// PostsPerHour is the posting activity of boards as hourly series that share
+// their time axis, ready to be drawn as charts.
+type PostsPerHour struct {
+	From   time.Time         `json:"from"` // Start of the first hour
+	Hours  int               `json:"hours"`
+	Boards []BoardPostSeries `json:"boards"`
+}
+
+// BoardPostSeries holds one post count per hour from PostsPerHour.From,
+// oldest first, with zeros for hours without posts.
+type BoardPostSeries struct {
+	Board BoardShortName `json:"board"`
+	Posts []int          `json:"posts"`
+}
+
+// BoardPosters ranks the users who posted most on a board within a recent
+// window, so moderators can spot flooding accounts before reports arrive.
+type BoardPosters struct {
+	Board   BoardShortName   `json:"board"`
+	Since   time.Time        `json:"since"`
+	Posters []PosterActivity `json:"posters"` // Most posts first
+}
+
+// PosterActivity is what one user posted on a board since BoardPosters.Since.
+type PosterActivity struct {
+	UserId       UserId    `json:"user_id"`
+	Posts        int       `json:"posts"`
+	Threads      int       `json:"threads"` // Threads started
+	Burst        int       `json:"burst"`   // Most posts within one clock minute
+	LastPostAt   time.Time `json:"last_post_at"`
+	Shadowbanned bool      `json:"shadowbanned"` // On this board
+}
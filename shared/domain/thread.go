@@ -9,6 +9,7 @@ type ThreadCreationData struct {
 	Title     ThreadTitle
 	Board     BoardShortName
 	IsPinned  bool
+	OpOnly    bool // Only the OP and moderators may reply
 	OpMessage MessageCreationData
 }
 
@@ -20,6 +21,15 @@ type ThreadMetadata struct {
 	LastBumped     time.Time
 	LastModifiedAt time.Time
 	IsPinned       bool
+	OpOnly         bool          // Only the OP and moderators may reply
+	Noindex        bool          // Board is hidden from search engines (noindex setting or email restriction)
+	Watched        bool          // The viewer watches the thread for the email digest
+	LastReadId     MsgId         // Last message the viewer had seen before this view, 0 if none; only set on thread pages
+	SuccessorId    *ThreadId     // Newer thread this one is continued in; only set on thread pages
+	PredecessorId  *ThreadId     // Older thread this one continues; only set on thread pages
+	Mirror         bool          // The board is a read-only mirror of another instance's board; only set on thread pages
+	Language       BoardLanguage // Language of the board; only set on thread pages
+	UploadRules                  // Attachment rules of the board, for the reply form
 }
 
 type ThreadPagination struct {
@@ -31,5 +41,96 @@ type ThreadPagination struct {
 type Thread struct {
 	ThreadMetadata
 	Messages   []*Message        `json:"messages"`
+	Deleted    []DeletedMessage  `json:"deleted,omitempty"` // Public stubs for moderated messages on this page
 	Pagination *ThreadPagination `json:"pagination,omitempty"`
 }
+
+// ThreadTitleEdit is one change of a thread title, kept for moderation review.
+type ThreadTitleEdit struct {
+	OldTitle    ThreadTitle `json:"old_title"`
+	NewTitle    ThreadTitle `json:"new_title"`
+	EditedBy    *UserId     `json:"edited_by"` // Nil once the account is deleted
+	ByModerator bool        `json:"by_moderator"`
+	EditedAt    time.Time   `json:"edited_at"`
+}
+
+// ThreadUpdates are the messages posted to a thread after a known message,
+// for clients that poll an open thread.
+type ThreadUpdates struct {
+	Messages  []*Message `json:"messages"`
+	Backlinks Replies    `json:"backlinks"` // Replies from Messages to the earlier messages of the thread
+}
+
+// ThreadGraph is the reply graph of a thread for force-directed rendering:
+// its messages and the reply links between them.
+type ThreadGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a message of a ThreadGraph.
+type GraphNode struct {
+	Id        MsgId     `json:"id"`
+	Page      int       `json:"page"`
+	CreatedAt time.Time `json:"created_at"`
+	Author    UserId    `json:"-"` // For shadowban filtering only, posters stay anonymous
+}
+
+// GraphEdge is a reply link from one message of a ThreadGraph to another.
+type GraphEdge struct {
+	From MsgId `json:"from"`
+	To   MsgId `json:"to"`
+}
+
+// ThreadAttachments lists every attachment of a thread in posting order, for
+// the gallery view.
+type ThreadAttachments struct {
+	Attachments []ThreadAttachment `json:"attachments"`
+	OpAuthor    UserId             `json:"-"` // For shadowban filtering only
+}
+
+// ThreadArchive is an export of a thread that can be checked for tampering:
+// every message carries its content hash and MerkleRoot commits to them all.
+type ThreadArchive struct {
+	Board       BoardShortName    `json:"board"`
+	ThreadId    ThreadId          `json:"thread_id"`
+	Title       string            `json:"title"`
+	ExportedAt  time.Time         `json:"exported_at"`
+	HashVersion int               `json:"hash_version"`
+	MerkleRoot  string            `json:"merkle_root"` // Hex, over the content hashes of Messages that have one
+	Messages    []ArchivedMessage `json:"messages"`
+}
+
+// ArchivedMessage is a message of a ThreadArchive with the fields its content
+// hash covers.
+type ArchivedMessage struct {
+	Id               MsgId     `json:"id"`
+	CreatedAt        time.Time `json:"created_at"`
+	Text             string    `json:"text"`
+	AttachmentHashes []string  `json:"attachment_sha256"`
+	AuthorCommitment string    `json:"author_commitment"` // Hex, stands in for the author
+	ContentHash      string    `json:"content_hash"`      // Hex; empty for messages stored without one
+	Author           UserId    `json:"-"`                 // For shadowban filtering only, posters stay anonymous
+}
+
+// ThreadSnapshot is a static HTML page of a thread kept in the media storage.
+// It outlives the thread, so pruned threads stay readable.
+type ThreadSnapshot struct {
+	Id           ThreadSnapshotId `json:"id"`
+	Board        BoardShortName   `json:"board"`
+	ThreadId     ThreadId         `json:"thread_id"`
+	Title        ThreadTitle      `json:"title"`
+	FilePath     string           `json:"file_path"`
+	SizeBytes    int64            `json:"size_bytes"`
+	MessageCount int              `json:"message_count"`
+	CreatedBy    *UserId          `json:"-"` // Nil once the account is deleted
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+// ThreadAttachment is an attachment of ThreadAttachments with the thread page
+// its message is on.
+type ThreadAttachment struct {
+	Attachment *Attachment `json:"attachment"`
+	Page       int         `json:"page"`
+	Author     UserId      `json:"-"` // For shadowban filtering only, posters stay anonymous
+}
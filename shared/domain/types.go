@@ -11,12 +11,31 @@ type (
 	BoardName      = string
 	BoardShortName = string
 
-	ThreadTitle = string
-	ThreadId    = int64
+	BoardCategoryId   = int64
+	BoardCategoryName = string
+	BoardBannerId     = int64
+
+	ThreadTitle      = string
+	ThreadId         = int64
+	ThreadSnapshotId = int64
 
 	MsgText      = string
 	MsgId        = int64
+	PostNumber   = int64
 	Replies      = []*Reply
 	FileId       = int64
 	AttachmentId = int64
+	UploadToken  = string
+
+	AppealId = int64
+
+	NotificationId = int64
+
+	ViewAsSessionId = int64
+
+	QueuedPostId = int64
+
+	FailedPostId = int64
+
+	BackupRunId = int64
 )
@@ -0,0 +1,23 @@
+package domain
+
+// UserImportRow is one email of a bulk import, with the line it came from
+// for error reports.
+type UserImportRow struct {
+	Line  int
+	Email Email
+}
+
+// UserImportReport tells the admin what a bulk import did.
+type UserImportReport struct {
+	Created  int                 `json:"created"`  // Accounts created
+	Existing int                 `json:"existing"` // Emails that already had an account, left as they were
+	Invited  int                 `json:"invited"`  // Invitation emails queued for the created accounts
+	Rejected []UserImportProblem `json:"rejected"` // Rows that were skipped
+}
+
+// UserImportProblem is a skipped row of a bulk import.
+type UserImportProblem struct {
+	Line   int    `json:"line"`
+	Email  string `json:"email"`
+	Reason string `json:"reason"`
+}
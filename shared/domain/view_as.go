@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// ViewAsSession records an admin starting to view the site as another user.
+// Sessions are kept after either account is deleted, as an audit trail.
+type ViewAsSession struct {
+	Id        ViewAsSessionId
+	AdminId   UserId
+	UserId    UserId
+	Reason    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
@@ -14,9 +14,19 @@ import (
 
 type JwtService interface {
 	NewToken(user domain.User) (string, error)
+	// NewViewAsToken issues a token acting as user for admin, valid for ttl
+	NewViewAsToken(user domain.User, admin domain.UserId, ttl time.Duration) (string, error)
 	DecodeToken(jwtStr string) (*jwt.Token, error)
+	// NewRefreshToken issues a "remember me" token for userId, valid for ttl.
+	// It only identifies the user and can't be used as an access token.
+	NewRefreshToken(userId domain.UserId, ttl time.Duration) (string, error)
+	// DecodeRefreshToken returns the user and the issue time of a refresh token
+	DecodeRefreshToken(jwtStr string) (domain.UserId, time.Time, error)
 }
 
+// refreshTokenType marks refresh tokens in the "typ" claim
+const refreshTokenType = "refresh"
+
 type Jwt struct {
 	secretKey string
 	ttl       time.Duration
@@ -27,6 +37,18 @@ func New(secretKey string, ttl time.Duration) JwtService {
 }
 
 func (j *Jwt) NewToken(user domain.User) (string, error) {
+	return j.sign(userClaims(user, j.ttl))
+}
+
+// NewViewAsToken signs the claims of user with the admin that requested them.
+// The auth middleware only lets such tokens read.
+func (j *Jwt) NewViewAsToken(user domain.User, admin domain.UserId, ttl time.Duration) (string, error) {
+	claims := userClaims(user, ttl)
+	claims["impersonated_by"] = admin
+	return j.sign(claims)
+}
+
+func userClaims(user domain.User, ttl time.Duration) jwt.MapClaims {
 	claims := jwt.MapClaims{}
 	claims["uid"] = user.Id
 	// Email removed for privacy - no longer stored in JWT
@@ -34,8 +56,38 @@ func (j *Jwt) NewToken(user domain.User) (string, error) {
 	claims["email_domain"] = user.EmailDomain
 	claims["admin"] = user.Admin
 	claims["created_at"] = user.CreatedAt.Unix()
-	claims["exp"] = time.Now().Add(j.ttl).Unix()
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(ttl).Unix()
+	return claims
+}
+
+func (j *Jwt) NewRefreshToken(userId domain.UserId, ttl time.Duration) (string, error) {
+	return j.sign(jwt.MapClaims{
+		"uid": userId,
+		"typ": refreshTokenType,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(ttl).Unix(),
+	})
+}
+
+// DecodeRefreshToken returns the user a valid refresh token was issued to and
+// when it was issued.
+func (j *Jwt) DecodeRefreshToken(jwtStr string) (domain.UserId, time.Time, error) {
+	token, err := j.parse(jwtStr)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+	uid, ok := claims["uid"].(float64)
+	iat, iatOk := claims["iat"].(float64)
+	if !ok || !iatOk || claims["typ"] != refreshTokenType {
+		return 0, time.Time{}, &internal_errors.ErrorWithStatusCode{Message: "Invalid refresh token", StatusCode: http.StatusUnauthorized}
+	}
+	return domain.UserId(uid), time.Unix(int64(iat), 0), nil
+}
 
+func (j *Jwt) sign(claims jwt.MapClaims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(j.secretKey))
 	if err != nil {
@@ -46,7 +98,19 @@ func (j *Jwt) NewToken(user domain.User) (string, error) {
 	return tokenString, nil
 }
 
+// DecodeToken parses an access token. Refresh tokens are rejected.
 func (j *Jwt) DecodeToken(jwtStr string) (*jwt.Token, error) {
+	token, err := j.parse(jwtStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["typ"] == refreshTokenType {
+		return nil, &internal_errors.ErrorWithStatusCode{Message: "Invalid access token", StatusCode: http.StatusUnauthorized}
+	}
+	return token, nil
+}
+
+func (j *Jwt) parse(jwtStr string) (*jwt.Token, error) {
 	token, err := jwt.Parse(jwtStr, func(token *jwt.Token) (interface{}, error) {
 		// Verify signing algorithm
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -113,3 +113,62 @@ func TestDecodeToken(t *testing.T) {
 	}
 
 }
+
+func TestNewViewAsToken(t *testing.T) {
+	j := New("test_secret", time.Hour)
+	user := domain.User{Id: 2, EmailDomain: "example.com", CreatedAt: time.Now().UTC()}
+
+	tokenString, err := j.NewViewAsToken(user, 1, time.Minute)
+	if err != nil {
+		t.Fatalf("NewViewAsToken() error = %v", err)
+	}
+
+	token, err := j.DecodeToken(tokenString)
+	if err != nil {
+		t.Fatalf("DecodeToken() error = %v", err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["uid"] != float64(2) || claims["impersonated_by"] != float64(1) {
+		t.Errorf("unexpected claims %v", claims)
+	}
+	exp, _ := claims.GetExpirationTime()
+	if exp == nil || time.Until(exp.Time) > time.Minute {
+		t.Errorf("view-as token should expire within its own ttl, got %v", exp)
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	j := New("test_secret", time.Hour)
+
+	tokenString, err := j.NewRefreshToken(3, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewRefreshToken() error = %v", err)
+	}
+
+	uid, issuedAt, err := j.DecodeRefreshToken(tokenString)
+	if err != nil {
+		t.Fatalf("DecodeRefreshToken() error = %v", err)
+	}
+	if uid != 3 {
+		t.Errorf("DecodeRefreshToken() = %d, want 3", uid)
+	}
+	if time.Since(issuedAt) > time.Minute {
+		t.Errorf("DecodeRefreshToken() issued at %v, want now", issuedAt)
+	}
+
+	// A refresh token is not an access token and vice versa
+	if _, err := j.DecodeToken(tokenString); err == nil {
+		t.Error("DecodeToken() accepted a refresh token")
+	}
+	accessToken, _ := j.NewToken(domain.User{Id: 3, EmailDomain: "example.com", CreatedAt: time.Now()})
+	if _, _, err := j.DecodeRefreshToken(accessToken); err == nil {
+		t.Error("DecodeRefreshToken() accepted an access token")
+	}
+
+	expired, _ := j.NewRefreshToken(3, -time.Minute)
+	_, _, err = j.DecodeRefreshToken(expired)
+	e, ok := err.(*internal_errors.ErrorWithStatusCode)
+	if !ok || e.StatusCode != http.StatusUnauthorized {
+		t.Errorf("DecodeRefreshToken() expected error with status code %d for expired token, but got %v", http.StatusUnauthorized, err)
+	}
+}
@@ -11,11 +11,12 @@ var Log *slog.Logger
 func init() {
 	// Auto-initialize with safe defaults for tests and development
 	// Production code can override by calling Initialize() explicitly
-	Initialize("info", false)
+	Initialize("info", false, Sampling{})
 }
 
-// Initialize sets up the global logger with the specified level and format
-func Initialize(level string, useJSON bool) {
+// Initialize sets up the global logger with the specified level and format.
+// High-volume debug and info messages are thinned out according to sampling.
+func Initialize(level string, useJSON bool, sampling Sampling) {
 	var handler slog.Handler
 
 	// Parse log level
@@ -33,7 +34,7 @@ func Initialize(level string, useJSON bool) {
 		handler = slog.NewTextHandler(os.Stdout, opts)
 	}
 
-	Log = slog.New(handler)
+	Log = slog.New(newSamplingHandler(handler, sampling))
 	slog.SetDefault(Log) // Make it the default for entire program
 }
 
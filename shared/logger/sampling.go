@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Sampling limits how many debug and info records with the same message are
+// written per second. Warnings and errors are never sampled.
+type Sampling struct {
+	Initial    int // Records of one message written each second before sampling starts (0 disables sampling)
+	Thereafter int // Past Initial, only every Thereafter-th record is written (0 drops them all)
+}
+
+// sampler counts records per message in the current one-second window. It is
+// shared by all handlers derived from the same root, so WithAttrs loggers
+// count towards the same limit.
+type sampler struct {
+	Sampling
+	now func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+// keep reports whether the record with this message is to be written.
+func (s *sampler) keep(msg string) bool {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.windowStart) >= time.Second {
+		s.windowStart = now
+		s.counts = make(map[string]int)
+	}
+	s.counts[msg]++
+	n := s.counts[msg]
+	if n <= s.Initial {
+		return true
+	}
+	return s.Thereafter > 0 && (n-s.Initial)%s.Thereafter == 0
+}
+
+type samplingHandler struct {
+	slog.Handler
+	sampler *sampler
+}
+
+func newSamplingHandler(h slog.Handler, sampling Sampling) slog.Handler {
+	if sampling.Initial <= 0 {
+		return h
+	}
+	return &samplingHandler{Handler: h, sampler: &sampler{Sampling: sampling, now: time.Now}}
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && !h.sampler.keep(r.Message) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler}
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogger(sampling Sampling, now *time.Time) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	h := newSamplingHandler(slog.NewTextHandler(&buf, nil), sampling)
+	if sh, ok := h.(*samplingHandler); ok {
+		sh.sampler.now = func() time.Time { return *now }
+	}
+	return slog.New(h), &buf
+}
+
+func TestSampling(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("writes the first records then one in thereafter", func(t *testing.T) {
+		log, buf := newTestLogger(Sampling{Initial: 2, Thereafter: 3}, &now)
+		for range 8 {
+			log.Info("request served")
+		}
+		// 1, 2, then 5 and 8
+		assert.Equal(t, 4, strings.Count(buf.String(), "request served"))
+	})
+
+	t.Run("counts each message separately", func(t *testing.T) {
+		log, buf := newTestLogger(Sampling{Initial: 1}, &now)
+		log.Info("a")
+		log.Info("a")
+		log.Info("b")
+		assert.Equal(t, 1, strings.Count(buf.String(), "msg=a"))
+		assert.Equal(t, 1, strings.Count(buf.String(), "msg=b"))
+	})
+
+	t.Run("starts over every second", func(t *testing.T) {
+		log, buf := newTestLogger(Sampling{Initial: 1}, &now)
+		log.Info("tick")
+		log.Info("tick")
+		now = now.Add(time.Second)
+		log.Info("tick")
+		assert.Equal(t, 2, strings.Count(buf.String(), "tick"))
+	})
+
+	t.Run("never drops warnings and errors", func(t *testing.T) {
+		log, buf := newTestLogger(Sampling{Initial: 1}, &now)
+		for range 3 {
+			log.Warn("disk almost full")
+			log.Error("query failed")
+		}
+		assert.Equal(t, 3, strings.Count(buf.String(), "disk almost full"))
+		assert.Equal(t, 3, strings.Count(buf.String(), "query failed"))
+	})
+
+	t.Run("derived loggers share the limit", func(t *testing.T) {
+		log, buf := newTestLogger(Sampling{Initial: 1}, &now)
+		log.With("component", "gc").Info("sweep")
+		log.WithGroup("job").Info("sweep")
+		assert.Equal(t, 1, strings.Count(buf.String(), "sweep"))
+	})
+
+	t.Run("disabled without initial", func(t *testing.T) {
+		log, buf := newTestLogger(Sampling{}, &now)
+		for range 5 {
+			log.Info("every time")
+		}
+		assert.Equal(t, 5, strings.Count(buf.String(), "every time"))
+	})
+}
@@ -0,0 +1,114 @@
+// Package markup checks the message HTML made by the frontend parser before
+// it is shown.
+package markup
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// messageLinkHref matches the href the parser's formatMessageLink emits
+	messageLinkHref = regexp.MustCompile(`^/\p{L}+/\d+#p\d+$`)
+	boardName       = regexp.MustCompile(`^\p{L}+$`)
+	number          = regexp.MustCompile(`^\d+$`)
+)
+
+// attrCheck validates the value of an allowed attribute
+type attrCheck func(value string) bool
+
+func oneOf(values ...string) attrCheck {
+	return func(value string) bool { return slices.Contains(values, value) }
+}
+
+// allowedTags lists the elements the parser emits and the attributes each of
+// them may carry. Keep it in sync with the block and inline rules of
+// frontend/internal/markdown.
+var allowedTags = map[string]map[string]attrCheck{
+	"br":     nil,
+	"strong": nil,
+	"em":     nil,
+	"del":    nil,
+	"code":   nil,
+	"pre":    nil,
+	"span":   {"class": oneOf("spoiler", "greentext")},
+	"a": {
+		"href":            messageLinkHref.MatchString,
+		"class":           oneOf("message-link message-link-preview"),
+		"data-board":      boardName.MatchString,
+		"data-message-id": number.MatchString,
+		"data-thread-id":  number.MatchString,
+	},
+}
+
+// Sanitize runs message HTML through an allowlist of the markup produced by
+// the parser's ProcessMessage, as a safety net against parser bugs turning into XSS.
+// Unknown elements lose their tags but keep their text, unknown or invalid
+// attributes are removed, stray end tags are dropped and unclosed elements
+// are closed. Parser output passes through byte for byte, so changed reports
+// that the input was not produced by the current parser.
+func Sanitize(s string) (sanitized string, changed bool) {
+	var b strings.Builder
+	b.Grow(len(s))
+	var open []string
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			// io.EOF: the tokenizer reads from a string and can't fail otherwise
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			sanitized = b.String()
+			return sanitized, sanitized != s
+
+		case html.TextToken:
+			// Raw instead of Text: Text also normalizes \r\n, which the parser keeps
+			b.WriteString(escapeHTML(html.UnescapeString(string(z.Raw()))))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			attrs, ok := allowedTags[tok.Data]
+			if !ok {
+				continue
+			}
+			b.WriteString("<" + tok.Data)
+			for _, attr := range tok.Attr {
+				if check, ok := attrs[attr.Key]; ok && attr.Namespace == "" && check(attr.Val) {
+					b.WriteString(" " + attr.Key + `="` + escapeHTML(attr.Val) + `"`)
+				}
+			}
+			b.WriteString(">")
+			if tok.Data != "br" {
+				open = append(open, tok.Data)
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			// Close the innermost matching element and everything opened inside it
+			i := len(open) - 1
+			for i >= 0 && open[i] != string(name) {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+			for j := len(open) - 1; j >= i; j-- {
+				b.WriteString("</" + open[j] + ">")
+			}
+			open = open[:i]
+		}
+		// Comments and doctypes are dropped
+	}
+}
+
+// htmlEscaper escapes the characters the parser escapes, the same way
+var htmlEscaper = strings.NewReplacer("<", "&lt;", ">", "&gt;", "&", "&amp;", `"`, "&quot;")
+
+func escapeHTML(s string) string {
+	return htmlEscaper.Replace(s)
+}
@@ -0,0 +1,76 @@
+package markup
+
+import (
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "script element becomes inert text",
+			input:    `hi<script>alert("x")</script>`,
+			expected: `hialert(&quot;x&quot;)`,
+		},
+		{
+			name:     "unknown element keeps its text",
+			input:    `<img src=x onerror=alert(1)><b>bold</b>`,
+			expected: `bold`,
+		},
+		{
+			name:     "event handler attribute",
+			input:    `<span class="spoiler" onmouseover="alert(1)">x</span>`,
+			expected: `<span class="spoiler">x</span>`,
+		},
+		{
+			name:     "unknown class",
+			input:    `<span class="greentext evil">x</span>`,
+			expected: `<span>x</span>`,
+		},
+		{
+			name:     "javascript link",
+			input:    `<a href="javascript:alert(1)" class="message-link message-link-preview">&gt;&gt;1#2</a>`,
+			expected: `<a class="message-link message-link-preview">&gt;&gt;1#2</a>`,
+		},
+		{
+			name:     "protocol-relative link",
+			input:    `<a href="//evil.com/1#p2">x</a>`,
+			expected: `<a>x</a>`,
+		},
+		{
+			name:     "unclosed element",
+			input:    `<strong><em>x`,
+			expected: `<strong><em>x</em></strong>`,
+		},
+		{
+			name:     "stray and misnested end tags",
+			input:    `</span><strong><em>x</strong>y</em>`,
+			expected: `<strong><em>x</em></strong>y`,
+		},
+		{
+			name:     "comment",
+			input:    `a<!-- <script> -->b`,
+			expected: `ab`,
+		},
+		{
+			name:     "bare angle bracket is escaped",
+			input:    `a < b`,
+			expected: `a &lt; b`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, changed := Sanitize(tt.input)
+			if result != tt.expected {
+				t.Errorf("Input:\n%q\n\nExpected:\n%q\n\nGot:\n%q", tt.input, tt.expected, result)
+			}
+			if !changed {
+				t.Errorf("changed = false for modified input %q", tt.input)
+			}
+		})
+	}
+}
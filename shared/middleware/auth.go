@@ -16,6 +16,7 @@ import (
 // BlacklistCache interface defines methods needed by auth middleware
 type BlacklistCache interface {
 	IsBlacklisted(userId domain.UserId) bool
+	SessionRevoked(userId domain.UserId, issuedAt time.Time) bool
 }
 
 type key int
@@ -23,6 +24,9 @@ type key int
 const (
 	UserClaimsKey key    = 0
 	CookieName    string = "access_token"
+	// ViewAsCookieName holds an admin's view-as token. While the token is
+	// valid it is used instead of the admin's own access token.
+	ViewAsCookieName string = "view_as_token"
 )
 
 // Auth holds dependencies for authentication middleware
@@ -54,6 +58,10 @@ func (a *Auth) OptionalAuth() func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, _ := a.extractUser(r)
 			if user != nil {
+				if !viewAsAllowed(user, r) {
+					http.Error(w, "View-as sessions are read-only", http.StatusForbidden)
+					return
+				}
 				ctx := context.WithValue(r.Context(), UserClaimsKey, user)
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
@@ -64,6 +72,13 @@ func (a *Auth) OptionalAuth() func(http.Handler) http.Handler {
 }
 
 func (a *Auth) extractUser(r *http.Request) (*domain.User, error) {
+	// An expired or otherwise invalid view-as token falls back to the admin's own session
+	if viewAs, err := r.Cookie(ViewAsCookieName); err == nil {
+		if user, err := a.userFromToken(viewAs.Value); err == nil && user.ImpersonatedBy != nil {
+			return user, nil
+		}
+	}
+
 	// Try to get token from cookie first (for browser clients)
 	var tokenString string
 	accessCookie, err := r.Cookie(CookieName)
@@ -77,7 +92,10 @@ func (a *Auth) extractUser(r *http.Request) (*domain.User, error) {
 	if tokenString == "" {
 		return nil, errNoToken
 	}
+	return a.userFromToken(tokenString)
+}
 
+func (a *Auth) userFromToken(tokenString string) (*domain.User, error) {
 	token, err := a.jwtService.DecodeToken(tokenString)
 	if err != nil {
 		return nil, err
@@ -114,10 +132,29 @@ func (a *Auth) extractUser(r *http.Request) (*domain.User, error) {
 		Admin:       isAdmin,
 		CreatedAt:   time.Unix(int64(createdAtFloat), 0),
 	}
+	if adminFloat, ok := claims["impersonated_by"].(float64); ok {
+		admin := domain.UserId(adminFloat)
+		user.ImpersonatedBy = &admin
+	}
 
 	if a.blacklistCache != nil && a.blacklistCache.IsBlacklisted(user.Id) {
 		return nil, errBlacklisted
 	}
+	// A view-as token dies with its admin's access
+	if user.ImpersonatedBy != nil && a.blacklistCache != nil && a.blacklistCache.IsBlacklisted(*user.ImpersonatedBy) {
+		return nil, errBlacklisted
+	}
+	// Sessions revoked from a new device email; a view-as token belongs to
+	// the admin's session
+	if iatFloat, ok := claims["iat"].(float64); ok && a.blacklistCache != nil {
+		owner := user.Id
+		if user.ImpersonatedBy != nil {
+			owner = *user.ImpersonatedBy
+		}
+		if a.blacklistCache.SessionRevoked(owner, time.Unix(int64(iatFloat), 0)) {
+			return nil, errRevoked
+		}
+	}
 
 	return user, nil
 }
@@ -127,6 +164,7 @@ var (
 	errNoToken       = errorString("no token")
 	errInvalidClaims = errorString("invalid claims")
 	errBlacklisted   = errorString("blacklisted")
+	errRevoked       = errorString("session revoked")
 )
 
 type errorString string
@@ -144,17 +182,11 @@ func (a *Auth) auth(adminOnly bool) func(http.Handler) http.Handler {
 					http.Error(w, "Please sign-in", http.StatusUnauthorized)
 				case errBlacklisted:
 					// Clear JWT cookie to force re-login
-					cookie := &http.Cookie{
-						Path:     "/",
-						Name:     CookieName,
-						Value:    "",
-						MaxAge:   -1,
-						HttpOnly: true,
-						Secure:   a.secureCookies,
-						SameSite: http.SameSiteLaxMode,
-					}
-					http.SetCookie(w, cookie)
+					a.clearCookie(w)
 					http.Error(w, "Account suspended", http.StatusForbidden)
+				case errRevoked:
+					a.clearCookie(w)
+					http.Error(w, "Session was revoked, please sign in again", http.StatusUnauthorized)
 				case errInvalidClaims:
 					logger.Log.Error("invalid jwt claims")
 					http.Error(w, "Invalid token", http.StatusUnauthorized)
@@ -165,6 +197,11 @@ func (a *Auth) auth(adminOnly bool) func(http.Handler) http.Handler {
 				return
 			}
 
+			if !viewAsAllowed(user, r) {
+				http.Error(w, "View-as sessions are read-only", http.StatusForbidden)
+				return
+			}
+
 			if adminOnly && !user.Admin {
 				http.Error(w, "Access denied. Only for admin", http.StatusForbidden)
 				return
@@ -176,6 +213,29 @@ func (a *Auth) auth(adminOnly bool) func(http.Handler) http.Handler {
 	}
 }
 
+// clearCookie removes the access token cookie
+func (a *Auth) clearCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Path:     "/",
+		Name:     CookieName,
+		Value:    "",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   a.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// viewAsAllowed reports whether the request may proceed as user. Requests made
+// with a view-as token are logged for the audit trail and may only read.
+func viewAsAllowed(user *domain.User, r *http.Request) bool {
+	if user.ImpersonatedBy == nil {
+		return true
+	}
+	logger.Log.Info("view-as request", "admin_id", *user.ImpersonatedBy, "user_id", user.Id, "method", r.Method, "path", r.URL.Path)
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
 func GetUserFromContext(r *http.Request) *domain.User {
 	user, ok := r.Context().Value(UserClaimsKey).(*domain.User)
 	if !ok {
@@ -120,6 +120,7 @@ func TestGetUserFromContext(t *testing.T) {
 // Mock blacklist cache for testing
 type mockBlacklistCache struct {
 	blacklistedUsers map[domain.UserId]bool
+	revokedBefore    map[domain.UserId]time.Time
 }
 
 func (m *mockBlacklistCache) IsBlacklisted(userId domain.UserId) bool {
@@ -129,6 +130,14 @@ func (m *mockBlacklistCache) IsBlacklisted(userId domain.UserId) bool {
 	return m.blacklistedUsers[userId]
 }
 
+func (m *mockBlacklistCache) SessionRevoked(userId domain.UserId, issuedAt time.Time) bool {
+	if m == nil {
+		return false
+	}
+	revokedAt, ok := m.revokedBefore[userId]
+	return ok && issuedAt.Before(revokedAt)
+}
+
 func TestAuthWithBearerToken(t *testing.T) {
 	jwtService := jwt_internal.New("test_secret", time.Hour)
 	admin := &domain.User{Id: 1, EmailDomain: "example.com", Admin: true}
@@ -324,3 +333,93 @@ func TestAuthWithBlacklist(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthViewAs(t *testing.T) {
+	jwtService := jwt_internal.New("test_secret", time.Hour)
+	admin := domain.User{Id: 1, EmailDomain: "admin.com", Admin: true}
+	target := domain.User{Id: 2, EmailDomain: "target.com"}
+	adminToken, _ := jwtService.NewToken(admin)
+	viewAsToken, _ := jwtService.NewViewAsToken(target, admin.Id, time.Minute)
+	expiredViewAsToken, _ := jwtService.NewViewAsToken(target, admin.Id, -time.Minute)
+	plainTargetToken, _ := jwtService.NewToken(target)
+
+	serve := func(middleware func(http.Handler) http.Handler, method string, cookies ...*http.Cookie) (*httptest.ResponseRecorder, *domain.User) {
+		req := httptest.NewRequest(method, "http://example.com", nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rr := httptest.NewRecorder()
+		var seen *domain.User
+		middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = GetUserFromContext(r)
+			w.WriteHeader(http.StatusOK)
+		})).ServeHTTP(rr, req)
+		return rr, seen
+	}
+	authMw := NewAuth(jwtService, nil, false)
+	adminCookie := &http.Cookie{Name: CookieName, Value: adminToken}
+
+	t.Run("view-as token takes precedence and reads as the user", func(t *testing.T) {
+		rr, user := serve(authMw.NeedAuth(), http.MethodGet, adminCookie, &http.Cookie{Name: ViewAsCookieName, Value: viewAsToken})
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NotNil(t, user)
+		assert.Equal(t, target.Id, user.Id)
+		assert.Equal(t, "target.com", user.EmailDomain)
+		assert.False(t, user.Admin)
+		require.NotNil(t, user.ImpersonatedBy)
+		assert.Equal(t, admin.Id, *user.ImpersonatedBy)
+	})
+
+	t.Run("writes are refused", func(t *testing.T) {
+		for _, middleware := range []func(http.Handler) http.Handler{authMw.NeedAuth(), authMw.OptionalAuth()} {
+			rr, _ := serve(middleware, http.MethodPost, adminCookie, &http.Cookie{Name: ViewAsCookieName, Value: viewAsToken})
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		}
+	})
+
+	t.Run("expired view-as token falls back to the admin", func(t *testing.T) {
+		rr, user := serve(authMw.AdminOnly(), http.MethodPost, adminCookie, &http.Cookie{Name: ViewAsCookieName, Value: expiredViewAsToken})
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, admin.Id, user.Id)
+		assert.Nil(t, user.ImpersonatedBy)
+	})
+
+	t.Run("ordinary token in the view-as cookie is ignored", func(t *testing.T) {
+		_, user := serve(authMw.OptionalAuth(), http.MethodGet, &http.Cookie{Name: ViewAsCookieName, Value: plainTargetToken})
+		assert.Nil(t, user)
+	})
+
+	t.Run("blacklisted admin loses view-as access", func(t *testing.T) {
+		blacklisted := NewAuth(jwtService, &mockBlacklistCache{blacklistedUsers: map[domain.UserId]bool{admin.Id: true}}, false)
+		_, user := serve(blacklisted.OptionalAuth(), http.MethodGet, &http.Cookie{Name: ViewAsCookieName, Value: viewAsToken})
+		assert.Nil(t, user)
+	})
+}
+
+func TestAuthWithRevokedSession(t *testing.T) {
+	jwtService := jwt_internal.New("test_secret", time.Hour)
+	token, _ := jwtService.NewToken(domain.User{Id: 1, EmailDomain: "example.com"})
+	cache := &mockBlacklistCache{revokedBefore: map[domain.UserId]time.Time{1: time.Now().Add(time.Minute)}}
+	authMw := NewAuth(jwtService, cache, false)
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: token})
+	rr := httptest.NewRecorder()
+	authMw.NeedAuth()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called for a revoked session")
+	})).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, CookieName, cookies[0].Name)
+	assert.Equal(t, -1, cookies[0].MaxAge)
+
+	// Tokens issued after the revocation are accepted
+	cache.revokedBefore[1] = time.Now().Add(-time.Minute)
+	rr = httptest.NewRecorder()
+	authMw.NeedAuth()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
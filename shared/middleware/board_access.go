@@ -8,14 +8,33 @@ import (
 	"github.com/itchan-dev/itchan/shared/logger"
 )
 
-type BoardAccess interface {
+// PermissionChecker tells who may see a board: the email domains it is
+// restricted to (nil for public boards) and whether it is scheduled for
+// deletion.
+type PermissionChecker interface {
 	AllowedDomains(board string) []string
+	PendingDeletion(board string) bool
 }
 
 // RestrictBoardAccess assumes:
 // 1. Email validation/confirmation is done in prior middleware.
 // 2. User added to request context in prior middleware
-func RestrictBoardAccess(access BoardAccess) func(http.Handler) http.Handler {
+//
+// Boards scheduled for deletion are hidden from everyone but admins, who
+// may only read them until the deletion is cancelled.
+//
+// With hideRestricted, users who may not see a restricted board get the same
+// 404 as for a board that does not exist, instead of a 401 or 403 that
+// confirms it does.
+func RestrictBoardAccess(access PermissionChecker, hideRestricted bool) func(http.Handler) http.Handler {
+	deny := func(w http.ResponseWriter) {
+		if hideRestricted {
+			http.Error(w, "Board not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Access restricted", http.StatusForbidden)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
@@ -29,9 +48,23 @@ func RestrictBoardAccess(access BoardAccess) func(http.Handler) http.Handler {
 			allowedDomains := access.AllowedDomains(board)
 
 			user := GetUserFromContext(r)
+			if access.PendingDeletion(board) {
+				if user == nil || !user.Admin {
+					http.Error(w, "Board not found", http.StatusNotFound)
+					return
+				}
+				if r.Method != http.MethodGet && r.Method != http.MethodHead {
+					http.Error(w, "Board is scheduled for deletion", http.StatusConflict)
+					return
+				}
+			}
 			if user == nil {
 				// Unauthenticated: only allow public boards (no domain restrictions)
 				if allowedDomains != nil {
+					if hideRestricted {
+						http.Error(w, "Board not found", http.StatusNotFound)
+						return
+					}
 					http.Error(w, "Please sign-in", http.StatusUnauthorized)
 					return
 				}
@@ -51,7 +84,7 @@ func RestrictBoardAccess(access BoardAccess) func(http.Handler) http.Handler {
 			// Fail-safe email format check
 			emailDomain, err := user.GetEmailDomain()
 			if err != nil {
-				http.Error(w, "Access restricted", http.StatusForbidden)
+				deny(w)
 				return
 			}
 
@@ -66,7 +99,7 @@ func RestrictBoardAccess(access BoardAccess) func(http.Handler) http.Handler {
 				"user_id", user.Id,
 				"board", board,
 				"domain", emailDomain)
-			http.Error(w, "Access restricted", http.StatusForbidden)
+			deny(w)
 		})
 	}
 }
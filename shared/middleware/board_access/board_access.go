@@ -6,28 +6,130 @@ import (
 	"time"
 
 	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// cacheLookups counts lookups of a read-through BoardAccess by whether the
+// cached rules were fresh (hit) or had to be reloaded (miss).
+var cacheLookups = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "board_access_cache_lookups_total",
+		Help: "Total number of board access rule lookups, by cache result",
+	},
+	[]string{"result"},
 )
 
 type Storage interface {
 	GetBoardsWithPermissions() (map[string][]string, error)
+	GetBoardsPendingDeletion() ([]string, error)
+	GetBoardRedirects() (map[string]string, error)
 }
 
 type BoardAccess struct {
-	data map[string][]string
-	mu   sync.RWMutex
+	data      map[string][]string
+	pending   map[string]bool   // boards scheduled for deletion
+	redirects map[string]string // old short name -> current one
+	mu        sync.RWMutex
+
+	// Set by NewCache: lookups reload the rules from storage once they are
+	// older than ttl or were invalidated.
+	storage    Storage
+	ttl        time.Duration
+	loadedAt   time.Time // zero until the first load and after Invalidate
+	generation int       // bumped by Invalidate, so a load that raced with it is not trusted
+	loadMu     sync.Mutex
+	now        func() time.Time
 }
 
 func New() *BoardAccess {
 	return &BoardAccess{
-		data: make(map[string][]string),
+		data:      make(map[string][]string),
+		pending:   make(map[string]bool),
+		redirects: make(map[string]string),
+		now:       time.Now,
+	}
+}
+
+// NewCache returns a read-through BoardAccess: the rules are loaded from s on
+// the first lookup and again on the first lookup after ttl has passed or
+// Invalidate was called. Processes that change the rules invalidate it right
+// away; others see the change within ttl.
+func NewCache(s Storage, ttl time.Duration) *BoardAccess {
+	b := New()
+	b.storage = s
+	b.ttl = ttl
+	return b
+}
+
+// Invalidate makes the next lookup reload the rules. Call it after changing
+// board permissions, scheduling or cancelling a deletion, or renaming a board.
+func (b *BoardAccess) Invalidate() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.loadedAt = time.Time{}
+	b.generation++
+}
+
+// stale reports whether the rules need reloading. b.mu must be held.
+func (b *BoardAccess) stale() bool {
+	return b.loadedAt.IsZero() || b.now().Sub(b.loadedAt) >= b.ttl
+}
+
+// refresh reloads the rules of a read-through BoardAccess when they are stale.
+// Concurrent lookups wait for a single reload. When it fails, the previous
+// rules stay in use and the next lookup tries again.
+func (b *BoardAccess) refresh() {
+	if b.storage == nil {
+		return
+	}
+	b.mu.RLock()
+	stale := b.stale()
+	b.mu.RUnlock()
+	if !stale {
+		cacheLookups.WithLabelValues("hit").Inc()
+		return
+	}
+
+	b.loadMu.Lock()
+	defer b.loadMu.Unlock()
+	b.mu.RLock()
+	stale = b.stale()
+	b.mu.RUnlock()
+	if !stale {
+		cacheLookups.WithLabelValues("hit").Inc() // reloaded while waiting
+		return
+	}
+
+	cacheLookups.WithLabelValues("miss").Inc()
+	if err := b.Update(b.storage); err != nil {
+		logger.Log.Error("failed to load board access rules",
+			"component", "board_access",
+			"error", err)
 	}
 }
 
 func (b *BoardAccess) Update(s Storage) error {
+	b.mu.RLock()
+	generation := b.generation
+	b.mu.RUnlock()
+
 	permissions, err := s.GetBoardsWithPermissions()
 	if err != nil {
 		return err
 	}
+	pendingBoards, err := s.GetBoardsPendingDeletion()
+	if err != nil {
+		return err
+	}
+	pending := make(map[string]bool, len(pendingBoards))
+	for _, board := range pendingBoards {
+		pending[board] = true
+	}
+	redirects, err := s.GetBoardRedirects()
+	if err != nil {
+		return err
+	}
 
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -35,16 +137,39 @@ func (b *BoardAccess) Update(s Storage) error {
 	// Replace the entire map to avoid stale entries
 	// Boards without entries in the map are public (no restrictions)
 	b.data = permissions
+	b.pending = pending
+	b.redirects = redirects
+	if b.generation == generation {
+		b.loadedAt = b.now()
+	}
 
 	return nil
 }
 
 func (b *BoardAccess) AllowedDomains(board string) []string {
+	b.refresh()
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	return b.data[board]
 }
 
+// PendingDeletion reports whether the board is scheduled for deletion.
+func (b *BoardAccess) PendingDeletion(board string) bool {
+	b.refresh()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.pending[board]
+}
+
+// RenamedTo returns the current short name of a board renamed from board.
+func (b *BoardAccess) RenamedTo(board string) (string, bool) {
+	b.refresh()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	newName, ok := b.redirects[board]
+	return newName, ok
+}
+
 func (b *BoardAccess) StartBackgroundUpdate(ctx context.Context, interval time.Duration, s Storage) {
 	ticker := time.NewTicker(interval)
 	logger.Log.Info("started board access background update",
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,13 +15,29 @@ import (
 type mockStorage struct {
 	mu          sync.RWMutex
 	permissions map[string][]string
+	pending     []string
+	redirects   map[string]string
 	err         error
+	loads       int
 }
 
 func (m *mockStorage) GetBoardsWithPermissions() (map[string][]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loads++
+	return m.permissions, m.err
+}
+
+func (m *mockStorage) GetBoardsPendingDeletion() ([]string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.permissions, m.err
+	return m.pending, m.err
+}
+
+func (m *mockStorage) GetBoardRedirects() (map[string]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.redirects, m.err
 }
 
 func (m *mockStorage) setPermissions(permissions map[string][]string) {
@@ -29,6 +46,18 @@ func (m *mockStorage) setPermissions(permissions map[string][]string) {
 	m.permissions = permissions
 }
 
+func (m *mockStorage) setErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+func (m *mockStorage) loadCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.loads
+}
+
 func TestNew(t *testing.T) {
 	ba := New()
 	assert.NotNil(t, ba.data, "Data map should be initialized")
@@ -81,6 +110,34 @@ func TestUpdate(t *testing.T) {
 	})
 }
 
+func TestPendingDeletion(t *testing.T) {
+	ms := &mockStorage{pending: []string{"old"}}
+	ba := New()
+	require.NoError(t, ba.Update(ms))
+
+	assert.True(t, ba.PendingDeletion("old"))
+	assert.False(t, ba.PendingDeletion("live"))
+
+	ms.mu.Lock()
+	ms.pending = nil
+	ms.mu.Unlock()
+	require.NoError(t, ba.Update(ms))
+
+	assert.False(t, ba.PendingDeletion("old"), "cancelled deletions are dropped on refresh")
+}
+
+func TestRenamedTo(t *testing.T) {
+	ba := New()
+	require.NoError(t, ba.Update(&mockStorage{redirects: map[string]string{"old": "new"}}))
+
+	newName, ok := ba.RenamedTo("old")
+	assert.True(t, ok)
+	assert.Equal(t, "new", newName)
+
+	_, ok = ba.RenamedTo("new")
+	assert.False(t, ok)
+}
+
 func TestAllowedDomains(t *testing.T) {
 	ba := New()
 	ba.data["existing"] = []string{"example.com"}
@@ -196,3 +253,72 @@ func TestBackgroundUpdateCancellation(t *testing.T) {
 	time.Sleep(interval * 3)
 	assert.Equal(t, []string{"initial.com"}, ba.AllowedDomains("test"))
 }
+
+func TestCache(t *testing.T) {
+	now := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	newCache := func(ms *mockStorage) *BoardAccess {
+		ba := NewCache(ms, time.Minute)
+		ba.now = func() time.Time { return now }
+		return ba
+	}
+
+	t.Run("loads on the first lookup and serves hits within the TTL", func(t *testing.T) {
+		ms := &mockStorage{permissions: map[string][]string{"test": {"example.com"}}}
+		ba := newCache(ms)
+		hits := testutil.ToFloat64(cacheLookups.WithLabelValues("hit"))
+		misses := testutil.ToFloat64(cacheLookups.WithLabelValues("miss"))
+
+		assert.Equal(t, []string{"example.com"}, ba.AllowedDomains("test"))
+		assert.False(t, ba.PendingDeletion("test"))
+		assert.Nil(t, ba.AllowedDomains("public"))
+
+		assert.Equal(t, 1, ms.loadCount())
+		assert.Equal(t, misses+1, testutil.ToFloat64(cacheLookups.WithLabelValues("miss")))
+		assert.Equal(t, hits+2, testutil.ToFloat64(cacheLookups.WithLabelValues("hit")))
+	})
+
+	t.Run("reloads once the TTL has passed", func(t *testing.T) {
+		ms := &mockStorage{permissions: map[string][]string{"test": {"old.com"}}}
+		ba := newCache(ms)
+		require.Equal(t, []string{"old.com"}, ba.AllowedDomains("test"))
+
+		ms.setPermissions(map[string][]string{"test": {"new.com"}})
+		ba.now = func() time.Time { return now.Add(59 * time.Second) }
+		assert.Equal(t, []string{"old.com"}, ba.AllowedDomains("test"))
+
+		ba.now = func() time.Time { return now.Add(time.Minute) }
+		assert.Equal(t, []string{"new.com"}, ba.AllowedDomains("test"))
+		assert.Equal(t, 2, ms.loadCount())
+	})
+
+	t.Run("invalidation reloads on the next lookup", func(t *testing.T) {
+		ms := &mockStorage{}
+		ba := newCache(ms)
+		require.False(t, ba.PendingDeletion("old"))
+
+		ms.mu.Lock()
+		ms.pending = []string{"old"}
+		ms.mu.Unlock()
+		ba.Invalidate()
+
+		assert.True(t, ba.PendingDeletion("old"))
+		assert.Equal(t, 2, ms.loadCount())
+	})
+
+	t.Run("keeps the previous rules when a reload fails", func(t *testing.T) {
+		ms := &mockStorage{permissions: map[string][]string{"test": {"example.com"}}}
+		ba := newCache(ms)
+		require.Equal(t, []string{"example.com"}, ba.AllowedDomains("test"))
+
+		ms.setErr(errors.New("db down"))
+		ba.Invalidate()
+		assert.Equal(t, []string{"example.com"}, ba.AllowedDomains("test"))
+		assert.Equal(t, []string{"example.com"}, ba.AllowedDomains("test"))
+		assert.Equal(t, 3, ms.loadCount(), "every lookup retries until a load succeeds")
+
+		ms.setErr(nil)
+		ba.AllowedDomains("test")
+		ba.AllowedDomains("test")
+		assert.Equal(t, 4, ms.loadCount())
+	})
+}
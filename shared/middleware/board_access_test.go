@@ -13,12 +13,17 @@ import (
 
 type mockBoardAccess struct {
 	allowedDomains map[string][]string
+	pending        map[string]bool
 }
 
 func (m *mockBoardAccess) AllowedDomains(board string) []string {
 	return m.allowedDomains[board]
 }
 
+func (m *mockBoardAccess) PendingDeletion(board string) bool {
+	return m.pending[board]
+}
+
 // withChiURLParams adds chi URL parameters to a request for testing
 func withChiURLParams(r *http.Request, params map[string]string) *http.Request {
 	chiCtx := chi.NewRouteContext()
@@ -161,6 +166,42 @@ func TestRestrictBoardAccess(t *testing.T) {
 			expectedStatus: http.StatusForbidden,
 			nextCalled:     false,
 		},
+		{
+			name: "board pending deletion hidden from users",
+			setupRequest: func() *http.Request {
+				req := httptest.NewRequest("GET", "/board/old", nil)
+				req = withChiURLParams(req, map[string]string{"board": "old"})
+				ctx := context.WithValue(req.Context(), UserClaimsKey, &domain.User{Id: 1, EmailDomain: "example.com"})
+				return req.WithContext(ctx)
+			},
+			boardAccess:    &mockBoardAccess{pending: map[string]bool{"old": true}},
+			expectedStatus: http.StatusNotFound,
+			nextCalled:     false,
+		},
+		{
+			name: "board pending deletion readable by admins",
+			setupRequest: func() *http.Request {
+				req := httptest.NewRequest("GET", "/board/old", nil)
+				req = withChiURLParams(req, map[string]string{"board": "old"})
+				ctx := context.WithValue(req.Context(), UserClaimsKey, &domain.User{Id: 1, Admin: true})
+				return req.WithContext(ctx)
+			},
+			boardAccess:    &mockBoardAccess{pending: map[string]bool{"old": true}},
+			expectedStatus: http.StatusOK,
+			nextCalled:     true,
+		},
+		{
+			name: "board pending deletion read-only for admins",
+			setupRequest: func() *http.Request {
+				req := httptest.NewRequest("POST", "/board/old", nil)
+				req = withChiURLParams(req, map[string]string{"board": "old"})
+				ctx := context.WithValue(req.Context(), UserClaimsKey, &domain.User{Id: 1, Admin: true})
+				return req.WithContext(ctx)
+			},
+			boardAccess:    &mockBoardAccess{pending: map[string]bool{"old": true}},
+			expectedStatus: http.StatusConflict,
+			nextCalled:     false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -171,7 +212,7 @@ func TestRestrictBoardAccess(t *testing.T) {
 				w.WriteHeader(http.StatusOK)
 			})
 
-			handler := RestrictBoardAccess(tt.boardAccess)(next)
+			handler := RestrictBoardAccess(tt.boardAccess, false)(next)
 
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, tt.setupRequest())
@@ -181,3 +222,42 @@ func TestRestrictBoardAccess(t *testing.T) {
 		})
 	}
 }
+
+func TestRestrictBoardAccessHidingRestrictedBoards(t *testing.T) {
+	access := &mockBoardAccess{allowedDomains: map[string][]string{"restricted": {"example.com"}}}
+	request := func(user *domain.User) *http.Request {
+		req := httptest.NewRequest("GET", "/board/restricted", nil)
+		req = withChiURLParams(req, map[string]string{"board": "restricted"})
+		if user != nil {
+			req = req.WithContext(context.WithValue(req.Context(), UserClaimsKey, user))
+		}
+		return req
+	}
+
+	tests := []struct {
+		name           string
+		user           *domain.User
+		expectedStatus int
+	}{
+		{name: "unauthenticated user", user: nil, expectedStatus: http.StatusNotFound},
+		{name: "other domain", user: &domain.User{Id: 1, EmailDomain: "other.com"}, expectedStatus: http.StatusNotFound},
+		{name: "allowed domain", user: &domain.User{Id: 1, EmailDomain: "example.com"}, expectedStatus: http.StatusOK},
+		{name: "admin", user: &domain.User{Id: 1, EmailDomain: "other.com", Admin: true}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			rr := httptest.NewRecorder()
+			RestrictBoardAccess(access, true)(next).ServeHTTP(rr, request(tt.user))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusNotFound {
+				assert.Equal(t, "Board not found\n", rr.Body.String(), "same answer as for a board that does not exist")
+			}
+		})
+	}
+}
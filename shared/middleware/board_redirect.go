@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type BoardRedirects interface {
+	RenamedTo(board string) (string, bool)
+}
+
+// RedirectRenamedBoards sends reads of a renamed board to the same path under
+// its new short name. The redirect is temporary (302): once it expires, the
+// old name may be given to another board.
+func RedirectRenamedBoards(redirects BoardRedirects) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			board := chi.URLParam(r, "board")
+			if board == "" || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			newName, ok := redirects.RenamedTo(board)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			segments := strings.Split(r.URL.Path, "/")
+			for i, segment := range segments {
+				if segment == board {
+					segments[i] = newName
+					break
+				}
+			}
+			target := *r.URL
+			target.Path = strings.Join(segments, "/")
+			target.RawPath = ""
+			http.Redirect(w, r, target.RequestURI(), http.StatusFound)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBoardRedirects map[string]string
+
+func (m mockBoardRedirects) RenamedTo(board string) (string, bool) {
+	newName, ok := m[board]
+	return newName, ok
+}
+
+func TestRedirectRenamedBoards(t *testing.T) {
+	redirects := mockBoardRedirects{"old": "new"}
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		board          string
+		expectedStatus int
+		location       string
+	}{
+		{"board page", http.MethodGet, "/old", "old", http.StatusFound, "/new"},
+		{"thread with query", http.MethodGet, "/old/12?page=2", "old", http.StatusFound, "/new/12?page=2"},
+		{"api path", http.MethodGet, "/v1/old/12", "old", http.StatusFound, "/v1/new/12"},
+		{"media path", http.MethodGet, "/media/old/12/a.jpg", "old", http.StatusFound, "/media/new/12/a.jpg"},
+		{"writes are not redirected", http.MethodPost, "/old/12", "old", http.StatusOK, ""},
+		{"board without redirect", http.MethodGet, "/new", "new", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			req := withChiURLParams(httptest.NewRequest(tt.method, tt.path, nil), map[string]string{"board": tt.board})
+			rr := httptest.NewRecorder()
+
+			RedirectRenamedBoards(redirects)(next).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.location, rr.Header().Get("Location"))
+		})
+	}
+}
@@ -118,12 +118,37 @@ func (rl *RateLimiter) Allow() bool {
 	return false
 }
 
+// wait returns how long until Allow would succeed, without taking a token
+func (rl *RateLimiter) wait(now time.Time) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	tokens := min(rl.tokens+now.Sub(rl.lastRefill).Seconds()*rl.rate, rl.capacity)
+	if tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - tokens) / rl.rate * float64(time.Second))
+}
+
 // Allow checks if a request should be allowed for a given user
 func (url *UserRateLimiter) Allow(userID string) bool {
 	limiter := url.getLimiter(userID)
 	return limiter.Allow()
 }
 
+// Wait returns how long the user has to wait before their next request is
+// allowed. It neither takes a token nor starts tracking unknown users.
+func (url *UserRateLimiter) Wait(userID string) time.Duration {
+	url.mu.RLock()
+	limiter, exists := url.limiters[userID]
+	url.mu.RUnlock()
+
+	if !exists {
+		return 0
+	}
+	return limiter.wait(time.Now())
+}
+
 // Stop cleans up all timers
 func (url *UserRateLimiter) Stop() {
 	url.mu.Lock()
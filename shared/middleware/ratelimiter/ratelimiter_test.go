@@ -154,6 +154,25 @@ func TestUserRateLimiter_Allow(t *testing.T) {
 	})
 }
 
+func TestUserRateLimiter_Wait(t *testing.T) {
+	url := New(1.0/60.0, 1, time.Minute) // once per minute
+	defer url.Stop()
+
+	assert.Zero(t, url.Wait("user1"), "unknown users can post right away")
+	url.mu.RLock()
+	assert.Empty(t, url.limiters, "Wait must not start tracking users")
+	url.mu.RUnlock()
+
+	require.True(t, url.Allow("user1"))
+	wait := url.Wait("user1")
+	assert.InDelta(t, time.Minute.Seconds(), wait.Seconds(), 1)
+	assert.InDelta(t, wait.Seconds(), url.Wait("user1").Seconds(), 1, "Wait must not take tokens")
+
+	url.limiters["user1"].lastRefill = time.Now().Add(-time.Minute)
+	assert.Zero(t, url.Wait("user1"))
+	assert.True(t, url.Allow("user1"))
+}
+
 func TestUserRateLimiter_cleanup(t *testing.T) {
 	t.Run("removes limiter after expiration time", func(t *testing.T) {
 		url := New(1, 10, 1*time.Millisecond) // Short expiration time
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/itchan-dev/itchan/shared/api"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// Recover turns a panic in a handler into a 500 JSON error instead of a
+// dropped connection. The panic is logged with its stack and the request ID
+// (set by chi's middleware.RequestID), which is also returned to the client
+// so a report can be matched to the log entry.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Deliberate aborts keep their meaning for net/http
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			requestID := middleware.GetReqID(r.Context())
+			logger.Log.Error("panic serving request",
+				"panic", rec,
+				"request_id", requestID,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"stack", string(debug.Stack()),
+			)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(api.ErrorResponse{Error: "Internal server error", RequestID: requestID})
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -2,18 +2,50 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 )
 
-// SecurityHeadersWithCSP adds security headers with custom Content-Security-Policy
-// isHTTPS: if true, adds Strict-Transport-Security header
-// csp: Content-Security-Policy value (if empty, no CSP header is set)
-func SecurityHeadersWithCSP(isHTTPS bool, csp string) func(http.Handler) http.Handler {
+// SecurityHeadersConfig configures SecurityHeaders.
+type SecurityHeadersConfig struct {
+	HTTPS bool   // Adds Strict-Transport-Security
+	CSP   string // Content-Security-Policy without frame-ancestors; empty = no CSP header
+	// FrameAncestors are the CSP sources allowed to show responses in a frame,
+	// e.g. "'self'" or "https://intranet.example.com"; empty = none
+	FrameAncestors []string
+	ReferrerPolicy string // Default: strict-origin-when-cross-origin
+}
+
+// SecurityHeaders adds security headers to every response. Setting them again
+// on a route, e.g. with a stricter CSP for user uploads, replaces them.
+func SecurityHeaders(cfg SecurityHeadersConfig) func(http.Handler) http.Handler {
+	frameAncestors := "'none'"
+	if len(cfg.FrameAncestors) > 0 {
+		frameAncestors = strings.Join(cfg.FrameAncestors, " ")
+	}
+	csp := cfg.CSP
+	if csp != "" {
+		csp = strings.TrimSuffix(strings.TrimSpace(csp), ";") + "; frame-ancestors " + frameAncestors
+	}
+	referrerPolicy := cfg.ReferrerPolicy
+	if referrerPolicy == "" {
+		referrerPolicy = "strict-origin-when-cross-origin"
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			headers := w.Header()
 
-			// Clickjacking protection
-			headers.Set("X-Frame-Options", "DENY")
+			// Clickjacking protection for browsers without CSP frame-ancestors.
+			// X-Frame-Options can't list origins, so it is left out when
+			// other sites may embed the responses.
+			switch frameAncestors {
+			case "'none'":
+				headers.Set("X-Frame-Options", "DENY")
+			case "'self'":
+				headers.Set("X-Frame-Options", "SAMEORIGIN")
+			default:
+				headers.Del("X-Frame-Options")
+			}
 
 			// Prevent MIME type sniffing
 			headers.Set("X-Content-Type-Options", "nosniff")
@@ -22,18 +54,17 @@ func SecurityHeadersWithCSP(isHTTPS bool, csp string) func(http.Handler) http.Ha
 			headers.Set("X-XSS-Protection", "1; mode=block")
 
 			// Referrer policy for privacy
-			headers.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			headers.Set("Referrer-Policy", referrerPolicy)
 
 			// Disable unnecessary browser features
 			headers.Set("Permissions-Policy", "camera=(), microphone=(), geolocation=(), payment=()")
 
-			// Add CSP if provided
 			if csp != "" {
 				headers.Set("Content-Security-Policy", csp)
 			}
 
 			// HSTS - only when using HTTPS
-			if isHTTPS {
+			if cfg.HTTPS {
 				headers.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
 			}
 
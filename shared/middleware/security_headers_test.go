@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	serve := func(handler http.Handler) http.Header {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rr.Header()
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	t.Run("defaults deny framing", func(t *testing.T) {
+		headers := serve(SecurityHeaders(SecurityHeadersConfig{CSP: "default-src 'self';"})(ok))
+
+		assert.Equal(t, "default-src 'self'; frame-ancestors 'none'", headers.Get("Content-Security-Policy"))
+		assert.Equal(t, "DENY", headers.Get("X-Frame-Options"))
+		assert.Equal(t, "nosniff", headers.Get("X-Content-Type-Options"))
+		assert.Equal(t, "strict-origin-when-cross-origin", headers.Get("Referrer-Policy"))
+		assert.Empty(t, headers.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("embedding sites", func(t *testing.T) {
+		headers := serve(SecurityHeaders(SecurityHeadersConfig{
+			HTTPS:          true,
+			CSP:            "default-src 'self'",
+			FrameAncestors: []string{"'self'", "https://intranet.example.com"},
+			ReferrerPolicy: "same-origin",
+		})(ok))
+
+		assert.Equal(t, "default-src 'self'; frame-ancestors 'self' https://intranet.example.com", headers.Get("Content-Security-Policy"))
+		assert.Empty(t, headers.Get("X-Frame-Options"))
+		assert.Equal(t, "same-origin", headers.Get("Referrer-Policy"))
+		assert.NotEmpty(t, headers.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("same origin only", func(t *testing.T) {
+		headers := serve(SecurityHeaders(SecurityHeadersConfig{FrameAncestors: []string{"'self'"}})(ok))
+
+		assert.Equal(t, "SAMEORIGIN", headers.Get("X-Frame-Options"))
+		assert.Empty(t, headers.Get("Content-Security-Policy"))
+	})
+
+	t.Run("route policy replaces the site policy", func(t *testing.T) {
+		site := SecurityHeaders(SecurityHeadersConfig{CSP: "default-src 'self'"})
+		media := SecurityHeaders(SecurityHeadersConfig{CSP: "default-src 'none'"})
+		headers := serve(site(media(ok)))
+
+		assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", headers.Get("Content-Security-Policy"))
+	})
+}
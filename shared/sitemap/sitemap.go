@@ -0,0 +1,135 @@
+package sitemap
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+)
+
+// MaxEntries is the number of URLs a single sitemap file may contain.
+const MaxEntries = 50000
+
+// Storage defines the read-only queries needed to build the sitemap and robots.txt.
+type Storage interface {
+	// GetSitemapEntries returns pages of indexable boards, most recently modified first.
+	GetSitemapEntries(limit int) ([]domain.SitemapEntry, error)
+	// GetUnindexedBoards returns boards crawlers must stay out of: those with
+	// the noindex setting and those restricted to email domains.
+	GetUnindexedBoards() ([]domain.BoardShortName, error)
+}
+
+// Cache holds the latest sitemap entries and unindexed boards. They are
+// regenerated periodically so serving sitemap.xml and robots.txt never hits
+// the database.
+type Cache struct {
+	storage   Storage
+	entries   []domain.SitemapEntry
+	unindexed []domain.BoardShortName
+	mu        sync.RWMutex
+}
+
+func NewCache(storage Storage) *Cache {
+	return &Cache{storage: storage}
+}
+
+// Update reloads the entries from the database.
+func (c *Cache) Update() error {
+	entries, err := c.storage.GetSitemapEntries(MaxEntries)
+	if err != nil {
+		return err
+	}
+	unindexed, err := c.storage.GetUnindexedBoards()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.entries = entries
+	c.unindexed = unindexed
+	c.mu.Unlock()
+
+	logger.Log.Info("sitemap regenerated",
+		"component", "sitemap",
+		"entries", len(entries),
+		"unindexed_boards", len(unindexed))
+	return nil
+}
+
+type urlSet struct {
+	XMLName xml.Name   `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+type urlEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// Write renders the sitemap with absolute URLs under origin (e.g. "https://example.com").
+func (c *Cache) Write(w io.Writer, origin string) error {
+	c.mu.RLock()
+	set := urlSet{URLs: make([]urlEntry, 0, len(c.entries))}
+	for _, e := range c.entries {
+		loc := fmt.Sprintf("%s/%s", origin, e.Board)
+		if e.ThreadId != 0 {
+			loc = fmt.Sprintf("%s/%d", loc, e.ThreadId)
+		}
+		set.URLs = append(set.URLs, urlEntry{Loc: loc, LastMod: e.LastModified.UTC().Format(time.RFC3339)})
+	}
+	c.mu.RUnlock()
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(set)
+}
+
+// WriteRobots renders robots.txt: unindexed boards are disallowed (both the
+// board index and everything under it) and crawlers are pointed at the sitemap.
+func (c *Cache) WriteRobots(w io.Writer, origin string) error {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	c.mu.RLock()
+	for _, board := range c.unindexed {
+		fmt.Fprintf(&b, "Disallow: /%s$\n", board)
+		fmt.Fprintf(&b, "Disallow: /%s/\n", board)
+	}
+	c.mu.RUnlock()
+	fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", origin)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// StartBackgroundUpdate regenerates the sitemap every interval until ctx is cancelled.
+func (c *Cache) StartBackgroundUpdate(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	logger.Log.Info("started sitemap background updates",
+		"component", "sitemap",
+		"interval", interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.Update(); err != nil {
+					logger.Log.Error("sitemap update failed",
+						"component", "sitemap",
+						"error", err)
+				}
+			case <-ctx.Done():
+				logger.Log.Info("sitemap shutting down gracefully",
+					"component", "sitemap")
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,116 @@
+package sitemap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSitemapStorage struct {
+	entries   []domain.SitemapEntry
+	unindexed []domain.BoardShortName
+	err       error
+	calls     atomic.Int32
+	limit     int
+}
+
+func (m *mockSitemapStorage) GetSitemapEntries(limit int) ([]domain.SitemapEntry, error) {
+	m.calls.Add(1)
+	m.limit = limit
+	return m.entries, m.err
+}
+
+func (m *mockSitemapStorage) GetUnindexedBoards() ([]domain.BoardShortName, error) {
+	return m.unindexed, m.err
+}
+
+func TestCache_Update(t *testing.T) {
+	t.Run("successful update", func(t *testing.T) {
+		storage := &mockSitemapStorage{entries: []domain.SitemapEntry{{Board: "b"}}}
+		cache := NewCache(storage)
+
+		require.NoError(t, cache.Update())
+		assert.Equal(t, MaxEntries, storage.limit)
+		assert.Len(t, cache.entries, 1)
+	})
+
+	t.Run("error keeps previous entries", func(t *testing.T) {
+		storage := &mockSitemapStorage{entries: []domain.SitemapEntry{{Board: "b"}}}
+		cache := NewCache(storage)
+		require.NoError(t, cache.Update())
+
+		storage.err = assert.AnError
+		assert.Error(t, cache.Update())
+		assert.Len(t, cache.entries, 1)
+	})
+}
+
+func TestCache_Write(t *testing.T) {
+	bumped := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	storage := &mockSitemapStorage{entries: []domain.SitemapEntry{
+		{Board: "b", LastModified: bumped},
+		{Board: "b", ThreadId: 42, LastModified: bumped},
+	}}
+	cache := NewCache(storage)
+	require.NoError(t, cache.Update())
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.Write(&buf, "https://example.com"))
+
+	assert.Contains(t, buf.String(), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	var set urlSet
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &set))
+	require.Len(t, set.URLs, 2)
+	assert.Equal(t, "https://example.com/b", set.URLs[0].Loc)
+	assert.Equal(t, "https://example.com/b/42", set.URLs[1].Loc)
+	assert.Equal(t, "2024-05-01T12:30:00Z", set.URLs[1].LastMod)
+}
+
+func TestCache_Write_Empty(t *testing.T) {
+	cache := NewCache(&mockSitemapStorage{})
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.Write(&buf, "http://localhost"))
+
+	var set urlSet
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &set))
+	assert.Empty(t, set.URLs)
+}
+
+func TestCache_WriteRobots(t *testing.T) {
+	cache := NewCache(&mockSitemapStorage{unindexed: []domain.BoardShortName{"corp", "hidden"}})
+	require.NoError(t, cache.Update())
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.WriteRobots(&buf, "https://example.com"))
+
+	assert.Equal(t, "User-agent: *\n"+
+		"Disallow: /corp$\n"+
+		"Disallow: /corp/\n"+
+		"Disallow: /hidden$\n"+
+		"Disallow: /hidden/\n"+
+		"\n"+
+		"Sitemap: https://example.com/sitemap.xml\n", buf.String())
+}
+
+func TestCache_BackgroundUpdate(t *testing.T) {
+	storage := &mockSitemapStorage{}
+	cache := NewCache(storage)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cache.StartBackgroundUpdate(ctx, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return storage.calls.Load() >= 2 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	time.Sleep(30 * time.Millisecond)
+	calls := storage.calls.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, calls, storage.calls.Load(), "no updates after cancellation")
+}
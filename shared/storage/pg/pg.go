@@ -7,7 +7,10 @@
 //
 // Core Components:
 //   - Querier: Interface for transaction-agnostic database operations
+//   - WithContext: Binds a Querier to a context for cancellation and timeouts
+//   - SetSlowQueryThreshold: Logging of queries slower than a threshold
 //   - WithTx: Helper for managing database transactions
+//   - RetryTx: WithTx retried on serialization failures and deadlocks
 //   - Connect: Configurable database connection establishment
 //   - SQL Identifier Utilities: Safe partition and view name generation
 package pg
@@ -15,13 +18,25 @@ package pg
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/tracing"
 	"github.com/lib/pq"
 	_ "github.com/lib/pq" // Registers the PostgreSQL driver
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // =========================================================================
@@ -37,10 +52,140 @@ import (
 //   - Testable: Can be mocked for unit tests
 //   - Composable: Works in both transactional and non-transactional contexts
 //   - Reusable: Same logic can be used across different services
+//
+// The Ctx variants honor cancellation of the given context. Code that only has
+// the plain methods at hand can still be cancelled by running it on a Querier
+// returned by WithContext.
 type Querier interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	QueryRow(query string, args ...interface{}) *sql.Row
+
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// WithContext returns a Querier whose plain methods run with ctx, so a query
+// is cancelled when ctx is cancelled or its deadline passes. Each of them is
+// recorded as a span of the trace in ctx and logged when slower than the
+// threshold set with SetSlowQueryThreshold. The Ctx variants keep using the
+// context they are given.
+//
+// Usage:
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+//	defer cancel()
+//	board, err := getBoard(pg.WithContext(ctx, db), shortName)
+func WithContext(ctx context.Context, q Querier) Querier {
+	return ctxQuerier{ctx: ctx, Querier: q}
+}
+
+type ctxQuerier struct {
+	ctx context.Context
+	Querier
+}
+
+func (q ctxQuerier) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(q.ctx, query)
+	start := time.Now()
+	result, err := q.Querier.ExecContext(ctx, query, args...)
+	endQuery(ctx, span, start, query, err)
+	return result, err
+}
+
+// Query only accounts for the time until the first rows arrive, not for
+// reading them.
+func (q ctxQuerier) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(q.ctx, query)
+	start := time.Now()
+	rows, err := q.Querier.QueryContext(ctx, query, args...)
+	endQuery(ctx, span, start, query, err)
+	return rows, err
+}
+
+func (q ctxQuerier) QueryRow(query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(q.ctx, query)
+	start := time.Now()
+	row := q.Querier.QueryRowContext(ctx, query, args...)
+	// sql.ErrNoRows only surfaces on Scan, so a missing row is not an error here
+	endQuery(ctx, span, start, query, row.Err())
+	return row
+}
+
+// startQuerySpan names the span after the statement's first keyword (SELECT,
+// UPDATE...). The statement is recorded without its arguments.
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	statement := strings.TrimSpace(query)
+	verb := statement
+	if i := strings.IndexFunc(statement, unicode.IsSpace); i >= 0 {
+		verb = statement[:i]
+	}
+	return tracing.Start(ctx, "db "+strings.ToUpper(verb), attribute.String("db.statement", statement))
+}
+
+// endQuery ends the query's span and logs the query if it was slow.
+func endQuery(ctx context.Context, span trace.Span, start time.Time, query string, err error) {
+	tracing.End(span, err)
+
+	threshold := time.Duration(slowQueryThreshold.Load())
+	if threshold <= 0 {
+		return
+	}
+	if elapsed := time.Since(start); elapsed > threshold {
+		logger.Log.Warn("slow query",
+			"component", "db",
+			"query", queryName(),
+			"board", requestBoard(ctx),
+			"duration", elapsed,
+			"statement", compactStatement(query))
+	}
+}
+
+// slowQueryThreshold holds the time.Duration past which queries are logged;
+// zero disables the log.
+var slowQueryThreshold atomic.Int64
+
+// SetSlowQueryThreshold makes queries run through WithContext Queriers be
+// logged as warnings when they take longer than threshold. Zero turns the
+// log off.
+func SetSlowQueryThreshold(threshold time.Duration) {
+	slowQueryThreshold.Store(int64(threshold))
+}
+
+// queryName names a query after the storage function that ran it, the first
+// caller outside this package and database/sql, e.g. "pg.(*Storage).getThread".
+func queryName() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		fn := frame.Function
+		if !strings.HasPrefix(fn, thisPackage+".") && !strings.HasPrefix(fn, "database/sql.") {
+			return fn[strings.LastIndex(fn, "/")+1:]
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+var thisPackage = reflect.TypeOf(ctxQuerier{}).PkgPath()
+
+// requestBoard returns the board of the request the query runs for, taken
+// from its {board} route parameter, or "" outside of board routes and for
+// background jobs.
+func requestBoard(ctx context.Context) string {
+	if routeCtx := chi.RouteContext(ctx); routeCtx != nil {
+		return routeCtx.URLParam("board")
+	}
+	return ""
+}
+
+// compactStatement collapses the statement's whitespace so it fits on one log
+// line.
+func compactStatement(query string) string {
+	return strings.Join(strings.Fields(query), " ")
 }
 
 // =========================================================================
@@ -165,6 +310,46 @@ func WithTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
 	return nil
 }
 
+// retryBaseDelay is the longest pause before the second attempt of RetryTx;
+// it doubles with every further attempt.
+const retryBaseDelay = 20 * time.Millisecond
+
+// IsTransientTxError reports whether err is a serialization failure or a
+// deadlock, which PostgreSQL resolves by aborting one of the transactions
+// involved. Running the aborted transaction again usually succeeds.
+func IsTransientTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01" // serialization_failure, deadlock_detected
+}
+
+// RetryTx is WithTx that runs the transaction again, up to attempts times in
+// total, while it fails with IsTransientTxError. Attempts are separated by a
+// random delay below a doubling bound, so transactions that collided do not
+// collide again. fn must have no effects outside the transaction, since it
+// may run more than once. Cancelling ctx stops the retries.
+func RetryTx(ctx context.Context, db *sql.DB, attempts int, fn func(*sql.Tx) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = WithTx(ctx, db, fn)
+		if err == nil || attempt >= attempts || !IsTransientTxError(err) {
+			return err
+		}
+		delay := time.Duration(rand.Int63n(int64(retryBaseDelay << (attempt - 1))))
+		logger.Log.Warn("retrying transaction",
+			"attempt", attempt+1,
+			"delay", delay,
+			"error", err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
 // =========================================================================
 // SQL Identifier Utilities
 // =========================================================================
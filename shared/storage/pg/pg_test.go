@@ -0,0 +1,143 @@
+package pg_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/logger"
+	"github.com/itchan-dev/itchan/shared/storage/pg"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// captureLog redirects the global logger to a buffer for the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	prev := logger.Log
+	logger.Log = slog.New(slog.NewTextHandler(&buf, nil))
+	t.Cleanup(func() { logger.Log = prev })
+	return &buf
+}
+
+func countThreads(q pg.Querier) (int, error) {
+	var n int
+	err := q.QueryRow(`SELECT count(*)
+		FROM threads`).Scan(&n)
+	return n, err
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE threads (id INTEGER)")
+	require.NoError(t, err)
+	t.Cleanup(func() { pg.SetSlowQueryThreshold(0) })
+
+	t.Run("logs queries over the threshold", func(t *testing.T) {
+		buf := captureLog(t)
+		pg.SetSlowQueryThreshold(1) // every query takes longer than a nanosecond
+
+		// Run the query as a handler of a board route would
+		r := chi.NewRouter()
+		r.Get("/{board}", func(w http.ResponseWriter, r *http.Request) {
+			_, err := countThreads(pg.WithContext(r.Context(), db))
+			require.NoError(t, err)
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/b", nil))
+
+		out := buf.String()
+		assert.Contains(t, out, `msg="slow query"`)
+		assert.Contains(t, out, "query=pg_test.countThreads")
+		assert.Contains(t, out, "board=b")
+		assert.Contains(t, out, `statement="SELECT count(*) FROM threads"`)
+	})
+
+	t.Run("quiet below the threshold", func(t *testing.T) {
+		buf := captureLog(t)
+		pg.SetSlowQueryThreshold(time.Hour)
+
+		_, err := countThreads(pg.WithContext(context.Background(), db))
+		require.NoError(t, err)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		buf := captureLog(t)
+		pg.SetSlowQueryThreshold(0)
+
+		_, err := countThreads(pg.WithContext(context.Background(), db))
+		require.NoError(t, err)
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestRetryTx(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	captureLog(t)
+	serializationFailure := &pq.Error{Code: "40001"}
+
+	t.Run("retries serialization failures and deadlocks", func(t *testing.T) {
+		calls := 0
+		err := pg.RetryTx(context.Background(), db, 3, func(tx *sql.Tx) error {
+			calls++
+			switch calls {
+			case 1:
+				return fmt.Errorf("failed to update thread: %w", serializationFailure)
+			case 2:
+				return &pq.Error{Code: "40P01"}
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		calls := 0
+		err := pg.RetryTx(context.Background(), db, 3, func(tx *sql.Tx) error {
+			calls++
+			return serializationFailure
+		})
+
+		assert.ErrorIs(t, err, serializationFailure)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("other errors are returned at once", func(t *testing.T) {
+		calls := 0
+		err := pg.RetryTx(context.Background(), db, 3, func(tx *sql.Tx) error {
+			calls++
+			return &pq.Error{Code: "23505"} // unique_violation
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := pg.RetryTx(ctx, db, 3, func(tx *sql.Tx) error {
+			calls++
+			cancel()
+			return serializationFailure
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
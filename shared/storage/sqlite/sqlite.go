@@ -0,0 +1,49 @@
+// Package sqlite provides SQLite database primitives for storage layers.
+//
+// It is the SQLite counterpart of shared/storage/pg for small, self-hosted
+// deployments: the backend and the frontend open the same database file, and
+// everything else (Querier, WithContext, WithTx) is shared with the PostgreSQL
+// primitives, since they only rely on database/sql.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	"github.com/itchan-dev/itchan/shared/config"
+	_ "modernc.org/sqlite" // Registers the SQLite driver
+)
+
+// Connect opens the SQLite database configured in cfg and verifies the
+// connection.
+//
+// Every connection is opened with:
+//   - foreign keys enforced, which SQLite does not do by default
+//   - WAL journaling, so readers are not blocked by the writer
+//   - a busy timeout, so concurrent writers (e.g. the backend and a command-line
+//     tool) wait for each other instead of failing
+//   - immediate transactions, so a transaction takes the write lock when it
+//     begins and never fails upgrading a read lock halfway through
+//   - times written in a fixed format, which sorts and compares correctly as
+//     text as long as all times are in UTC
+func Connect(cfg *config.Config, maxOpenConns int) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+cfg.Private.Sqlite.Path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(maxOpenConns)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
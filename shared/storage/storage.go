@@ -9,7 +9,9 @@ import (
 	"github.com/itchan-dev/itchan/shared/config"
 	"github.com/itchan-dev/itchan/shared/domain"
 	"github.com/itchan-dev/itchan/shared/middleware/board_access"
+	"github.com/itchan-dev/itchan/shared/sitemap"
 	"github.com/itchan-dev/itchan/shared/storage/pg"
+	"github.com/itchan-dev/itchan/shared/storage/sqlite"
 	_ "github.com/lib/pq" // PostgreSQL driver
 )
 
@@ -24,10 +26,20 @@ type Storage struct {
 // Interface satisfaction checks - compile-time verification
 var _ blacklist.BlacklistCacheStorage = (*Storage)(nil)
 var _ board_access.Storage = (*Storage)(nil)
+var _ sitemap.Storage = (*Storage)(nil)
 
 // New creates a new storage instance with database connection.
 // Uses lightweight connection pool settings suitable for frontend/worker services.
+// The queries below are written to run on both PostgreSQL and SQLite.
 func New(cfg *config.Config) (*Storage, error) {
+	if cfg.Private.Storage == config.StorageSqlite {
+		db, err := sqlite.Connect(cfg, pg.LightweightConnectionConfig().MaxOpenConns)
+		if err != nil {
+			return nil, err
+		}
+		return &Storage{db: db}, nil
+	}
+
 	db, err := pg.Connect(cfg, pg.LightweightConnectionConfig())
 	if err != nil {
 		return nil, err
@@ -65,15 +77,68 @@ func (s *Storage) GetBoardsWithPermissions() (map[string][]string, error) {
 	return permissions, nil
 }
 
+// GetBoardsPendingDeletion returns the short names of boards scheduled for
+// deletion, which the board_access middleware hides from non-admins.
+func (s *Storage) GetBoardsPendingDeletion() ([]string, error) {
+	rows, err := s.db.Query(`SELECT short_name FROM boards WHERE delete_after IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query boards pending deletion: %w", err)
+	}
+	defer rows.Close()
+
+	var boards []string
+	for rows.Next() {
+		var board string
+		if err := rows.Scan(&board); err != nil {
+			return nil, fmt.Errorf("failed to scan board pending deletion: %w", err)
+		}
+		boards = append(boards, board)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return boards, nil
+}
+
+// GetBoardRedirects returns the unexpired redirects from the short names of
+// renamed boards to their current ones.
+func (s *Storage) GetBoardRedirects() (map[string]string, error) {
+	rows, err := s.db.Query(`
+		SELECT old_short_name, new_short_name
+		FROM board_redirects
+		WHERE expires_at > $1`,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query board redirects: %w", err)
+	}
+	defer rows.Close()
+
+	redirects := make(map[string]string)
+	for rows.Next() {
+		var oldName, newName string
+		if err := rows.Scan(&oldName, &newName); err != nil {
+			return nil, fmt.Errorf("failed to scan board redirect: %w", err)
+		}
+		redirects[oldName] = newName
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return redirects, nil
+}
+
 // GetRecentlyBlacklistedUsers fetches all user IDs that were blacklisted
 // after the specified time. This is used by the blacklist cache.
 func (s *Storage) GetRecentlyBlacklistedUsers(since time.Time) ([]domain.UserId, error) {
 	rows, err := s.db.Query(`
 		SELECT user_id
 		FROM user_blacklist
-		WHERE blacklisted_at >= $1
+		WHERE blacklisted_at >= $1 AND (expires_at IS NULL OR expires_at > $2)
 		ORDER BY blacklisted_at DESC`,
-		since,
+		since.UTC(), time.Now().UTC(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query recently blacklisted users: %w", err)
@@ -96,6 +161,142 @@ func (s *Storage) GetRecentlyBlacklistedUsers(since time.Time) ([]domain.UserId,
 	return userIds, nil
 }
 
+// GetRecentSessionRevocations returns the users whose sessions were revoked
+// after the specified time, with the time of revocation. This is used by the
+// blacklist cache.
+func (s *Storage) GetRecentSessionRevocations(since time.Time) (map[domain.UserId]time.Time, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, revoked_at
+		FROM session_revocations
+		WHERE revoked_at >= $1`,
+		since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session revocations: %w", err)
+	}
+	defer rows.Close()
+
+	revocations := make(map[domain.UserId]time.Time)
+	for rows.Next() {
+		var userId domain.UserId
+		var revokedAt time.Time
+		if err := rows.Scan(&userId, &revokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session revocation: %w", err)
+		}
+		revocations[userId] = revokedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session revocations: %w", err)
+	}
+
+	return revocations, nil
+}
+
+// GetSitemapEntries returns the index pages and threads of indexable boards
+// (public and without the noindex setting), most recently bumped first.
+// Threads whose OP is shadowbanned are left out, as they are on the board page.
+func (s *Storage) GetSitemapEntries(limit int) ([]domain.SitemapEntry, error) {
+	// Board index pages come first, then threads. The two lists are read
+	// separately and the fallback times of boards are resolved here, because
+	// SQLite only reads plain timestamp columns back as times.
+	rows, err := s.db.Query(`
+		SELECT b.short_name, b.last_activity_at, b.created_at
+		FROM boards b
+		WHERE NOT b.noindex AND b.delete_after IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM board_permissions bp WHERE bp.board_short_name = b.short_name)
+		ORDER BY COALESCE(b.last_activity_at, b.created_at) DESC
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sitemap boards: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.SitemapEntry
+	for rows.Next() {
+		var e domain.SitemapEntry
+		var lastActivity, created sql.NullTime
+		if err := rows.Scan(&e.Board, &lastActivity, &created); err != nil {
+			return nil, fmt.Errorf("failed to scan sitemap board: %w", err)
+		}
+		switch {
+		case lastActivity.Valid:
+			e.LastModified = lastActivity.Time
+		case created.Valid:
+			e.LastModified = created.Time
+		default:
+			e.LastModified = time.Now().UTC()
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sitemap boards: %w", err)
+	}
+	if len(entries) >= limit {
+		return entries, nil
+	}
+
+	rows, err = s.db.Query(`
+		SELECT t.board, t.id, t.last_bumped_at
+		FROM threads t
+		JOIN boards b ON b.short_name = t.board
+		JOIN messages m ON m.board = t.board AND m.thread_id = t.id AND m.id = 1
+		WHERE NOT b.noindex AND b.delete_after IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM board_permissions bp WHERE bp.board_short_name = t.board)
+		  AND NOT EXISTS (SELECT 1 FROM user_shadowbans sb WHERE sb.board = m.board AND sb.user_id = m.author_id)
+		ORDER BY t.last_bumped_at DESC
+		LIMIT $1`,
+		limit-len(entries),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sitemap threads: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e domain.SitemapEntry
+		if err := rows.Scan(&e.Board, &e.ThreadId, &e.LastModified); err != nil {
+			return nil, fmt.Errorf("failed to scan sitemap entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sitemap entries: %w", err)
+	}
+	return entries, nil
+}
+
+// GetUnindexedBoards returns boards that search engines must not crawl: boards
+// with the noindex setting and boards restricted to email domains.
+func (s *Storage) GetUnindexedBoards() ([]domain.BoardShortName, error) {
+	rows, err := s.db.Query(`
+		SELECT b.short_name
+		FROM boards b
+		WHERE b.noindex
+		   OR EXISTS (SELECT 1 FROM board_permissions bp WHERE bp.board_short_name = b.short_name)
+		ORDER BY b.short_name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unindexed boards: %w", err)
+	}
+	defer rows.Close()
+
+	var boards []domain.BoardShortName
+	for rows.Next() {
+		var board domain.BoardShortName
+		if err := rows.Scan(&board); err != nil {
+			return nil, fmt.Errorf("failed to scan unindexed board: %w", err)
+		}
+		boards = append(boards, board)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating unindexed boards: %w", err)
+	}
+	return boards, nil
+}
+
 // Cleanup closes the database connection pool.
 func (s *Storage) Cleanup() {
 	if s.db != nil {
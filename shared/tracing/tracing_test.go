@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/itchan-dev/itchan/shared/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record installs a provider keeping spans in memory for the test.
+func record(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.Tracing{}, "test")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("names the span after the route", func(t *testing.T) {
+		recorder := record(t)
+		r := chi.NewRouter()
+		r.Use(Middleware)
+		r.Get("/{board}/{thread}", func(w http.ResponseWriter, r *http.Request) {})
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/b/12", nil))
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "GET /{board}/{thread}", spans[0].Name())
+		assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	})
+
+	t.Run("continues the caller's trace", func(t *testing.T) {
+		recorder := record(t)
+		r := chi.NewRouter()
+		r.Use(Middleware)
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", spans[0].SpanContext().TraceID().String())
+		assert.Equal(t, "b7ad6b7169203331", spans[0].Parent().SpanID().String())
+	})
+}
+
+func TestTransport(t *testing.T) {
+	recorder := record(t)
+	var traceparent string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer backend.Close()
+
+	ctx, span := Start(context.Background(), "frontend request")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL, nil)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: Transport(http.DefaultTransport)}).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	span.End()
+
+	require.NotEmpty(t, traceparent)
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+	assert.Len(t, recorder.Ended(), 2, "the client span and its parent")
+}
+
+func TestEnd(t *testing.T) {
+	recorder := record(t)
+
+	_, span := Start(context.Background(), "failing")
+	End(span, errors.New("boom"))
+	_, span = Start(context.Background(), "fine")
+	End(span, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "boom", spans[0].Status().Description)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
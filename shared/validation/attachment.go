@@ -14,13 +14,13 @@ import (
 	"github.com/itchan-dev/itchan/shared/domain"
 )
 
-func ValidateAttachments(fileHeaders []*multipart.FileHeader, allowedImageMimes, allowedVideoMimes []string) ([]*domain.PendingFile, error) {
+func ValidateAttachments(fileHeaders []*multipart.FileHeader, allowedMimeLists ...[]string) ([]*domain.PendingFile, error) {
 	if len(fileHeaders) == 0 {
 		return nil, nil
 	}
 
 	// Build allowed MIME types map
-	allowedMimes := BuildAllowedMimeMap(allowedImageMimes, allowedVideoMimes)
+	allowedMimes := BuildAllowedMimeMap(allowedMimeLists...)
 
 	var pendingFiles []*domain.PendingFile
 
@@ -62,13 +62,12 @@ func ValidateAttachments(fileHeaders []*multipart.FileHeader, allowedImageMimes,
 	return pendingFiles, nil
 }
 
-func BuildAllowedMimeMap(imageMimes, videoMimes []string) map[string]bool {
+func BuildAllowedMimeMap(mimeLists ...[]string) map[string]bool {
 	allowedMimes := make(map[string]bool)
-	for _, m := range imageMimes {
-		allowedMimes[m] = true
-	}
-	for _, m := range videoMimes {
-		allowedMimes[m] = true
+	for _, list := range mimeLists {
+		for _, m := range list {
+			allowedMimes[m] = true
+		}
 	}
 	return allowedMimes
 }
@@ -89,6 +88,11 @@ func DetectMimeType(fileHeader *multipart.FileHeader) (string, error) {
 		return "", fmt.Errorf("could not detect MIME type for file: %s", fileHeader.Filename)
 	}
 
+	// Drop parameters such as "; charset=utf-8" sent with text files
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = mediaType
+	}
+
 	return mimeType, nil
 }
 
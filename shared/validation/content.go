@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/itchan-dev/itchan/shared/domain"
+)
+
+// Denylist names file extensions and MIME types that are refused even when
+// the allowed lists would accept them, e.g. SVG images that can carry scripts.
+type Denylist struct {
+	Extensions []string // With the leading dot, compared case-insensitively
+	MimeTypes  []string
+}
+
+func (d Denylist) blocksExtension(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext != "" && slices.ContainsFunc(d.Extensions, func(e string) bool { return strings.EqualFold(e, ext) })
+}
+
+func (d Denylist) blocksMimeType(mimeType string) bool {
+	return slices.Contains(d.MimeTypes, mimeType)
+}
+
+// sniffedAs maps claimed types to the name http.DetectContentType reports for
+// them when it differs.
+var sniffedAs = map[string]string{
+	"video/ogg": "application/ogg",
+	"audio/ogg": "application/ogg",
+}
+
+// alwaysSniffed are types http.DetectContentType recognizes in every valid
+// file. Content it can't identify is not one of them.
+var alwaysSniffed = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"image/bmp":       true,
+	"video/webm":      true,
+	"video/ogg":       true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// CheckFileContents refuses files whose name or type is on the denylist and
+// files whose leading bytes don't match the MIME type they were uploaded as.
+// It runs before any decoding, so a renamed HTML page never reaches the
+// sanitizers. The files' data is left positioned at the start.
+func CheckFileContents(files []*domain.PendingFile, denylist Denylist) error {
+	for _, f := range files {
+		if denylist.blocksExtension(f.Filename) || denylist.blocksMimeType(f.MimeType) {
+			return fmt.Errorf("%w: %s (file: %s)", ErrBlockedFile, f.MimeType, f.Filename)
+		}
+
+		head := make([]byte, 512)
+		n, err := io.ReadFull(f.Data, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return fmt.Errorf("failed to read uploaded file: %w", err)
+		}
+		head = head[:n]
+		if seeker, ok := f.Data.(io.Seeker); ok {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("failed to rewind uploaded file: %w", err)
+			}
+		} else {
+			f.Data = io.MultiReader(bytes.NewReader(head), f.Data)
+		}
+
+		sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+		if denylist.blocksMimeType(sniffed) {
+			return fmt.Errorf("%w: %s (file: %s)", ErrBlockedFile, sniffed, f.Filename)
+		}
+		if !contentMatches(f.MimeType, sniffed) {
+			return fmt.Errorf("%w: uploaded as %s, looks like %s (file: %s)", ErrContentMismatch, f.MimeType, sniffed, f.Filename)
+		}
+	}
+	return nil
+}
+
+func contentMatches(claimed, sniffed string) bool {
+	expected := claimed
+	if name, ok := sniffedAs[claimed]; ok {
+		expected = name
+	}
+	if sniffed == expected {
+		return true
+	}
+	// Formats the sniffer doesn't know (QuickTime, most MP4 brands, AVIF...)
+	// are checked by the decoders later
+	return sniffed == "application/octet-stream" && !alwaysSniffed[claimed]
+}
@@ -10,3 +10,9 @@ var ErrInvalidMimeType = errors.New("invalid MIME type")
 
 // ErrTooManyAttachments is returned when too many files are uploaded
 var ErrTooManyAttachments = errors.New("too many attachments")
+
+// ErrBlockedFile is returned when an uploaded file's extension or type is on the denylist
+var ErrBlockedFile = errors.New("blocked file type")
+
+// ErrContentMismatch is returned when an uploaded file's content doesn't match its declared type
+var ErrContentMismatch = errors.New("file content does not match its type")
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+)
+
+func main() {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		log.Fatalf("Failed to generate VAPID key pair: %v", err)
+	}
+
+	fmt.Println("=================================================")
+	fmt.Println("  Web Push VAPID Key Pair (P-256)")
+	fmt.Println("=================================================")
+	fmt.Println()
+	fmt.Println("Add the public key to config/public.yaml:")
+	fmt.Printf("vapid_public_key: \"%s\"\n", base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()))
+	fmt.Println()
+	fmt.Println("Add the private key to your .env:")
+	fmt.Printf("VAPID_PRIVATE_KEY=\"%s\"\n", base64.RawURLEncoding.EncodeToString(key.Bytes()))
+	fmt.Println()
+	fmt.Println("IMPORTANT:")
+	fmt.Println("- Keep the private key secret!")
+	fmt.Println("- Changing the keys invalidates all existing browser subscriptions.")
+	fmt.Println("=================================================")
+}